@@ -7,91 +7,438 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"flag"
+	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/accounts"
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
-	_ "github.com/StreetsDigital/thenexusengine/pbs/internal/adapters/appnexus"
-	_ "github.com/StreetsDigital/thenexusengine/pbs/internal/adapters/demo"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters/debugbidder"
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters/ortb"
-	_ "github.com/StreetsDigital/thenexusengine/pbs/internal/adapters/pubmatic"
-	_ "github.com/StreetsDigital/thenexusengine/pbs/internal/adapters/rubicon"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/analytics"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/analytics/filelog"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/analytics/httpbatch"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/analytics/kafka"
 	pbsconfig "github.com/StreetsDigital/thenexusengine/pbs/internal/config"
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/endpoints"
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/exchange"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/flags"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/invalidation"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/lifecycle"
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/metrics"
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/middleware"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/modules"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/probe"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/router"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/storedrequests"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/usersync"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/warmup"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/cache"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/currency"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/geoip"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/idr"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/jsoncodec"
 	"github.com/StreetsDigital/thenexusengine/pbs/pkg/logger"
 	"github.com/StreetsDigital/thenexusengine/pbs/pkg/redis"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/signing"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/tracing"
 )
 
 func main() {
-	// Parse flags with environment variable fallbacks
-	port := flag.String("port", getEnvOrDefault("PBS_PORT", "8000"), "Server port")
-	idrURL := flag.String("idr-url", getEnvOrDefault("IDR_URL", "http://localhost:5050"), "IDR service URL")
-	idrEnabled := flag.Bool("idr-enabled", getEnvBoolOrDefault("IDR_ENABLED", true), "Enable IDR integration")
-	timeout := flag.Duration("timeout", 1000*time.Millisecond, "Default auction timeout")
+	// Flags override env vars, which override the config file
+	configPath := flag.String("config", os.Getenv("PBS_CONFIG"), "YAML or JSON config file")
+	port := flag.String("port", "8000", "Server port (env PBS_PORT)")
+	idrURL := flag.String("idr-url", "http://localhost:5050", "IDR service URL (env IDR_URL)")
+	idrEnabled := flag.Bool("idr-enabled", true, "Enable IDR integration (env IDR_ENABLED)")
+	timeout := flag.Duration("timeout", pbsconfig.DefaultAuctionTimeout, "Default auction timeout (env PBS_AUCTION_TIMEOUT)")
 	flag.Parse()
 
+	// The config file is exported as env vars, so it must load before anything reads them
+	var configSource *pbsconfig.Source
+	var configFile *pbsconfig.File
+	var configOverridden []string
+	if *configPath != "" {
+		configSource = pbsconfig.NewSource(*configPath)
+		file, overridden, err := configSource.Load()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid config file %s:\n%v\n", *configPath, err)
+			os.Exit(1)
+		}
+		configFile, configOverridden = file, overridden
+	}
+
 	// Initialize structured logger
 	logger.Init(logger.DefaultConfig())
 	log := logger.Log
 
+	if err := pbsconfig.ValidateEnv(); err != nil {
+		log.Fatal().Err(err).Msg("Invalid configuration")
+	}
+	if configFile != nil {
+		log.Info().
+			Str("path", configFile.Path).
+			Int("settings", configFile.Len()).
+			Strs("env_overrides", configOverridden).
+			Msg("Config file loaded")
+	}
+
+	// Flags not given on the command line fall back to env vars and the file
+	flagSet := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { flagSet[f.Name] = true })
+	if !flagSet["port"] {
+		*port = getEnvOrDefault("PBS_PORT", *port)
+	}
+	if !flagSet["idr-url"] {
+		*idrURL = getEnvOrDefault("IDR_URL", *idrURL)
+	}
+	if !flagSet["idr-enabled"] {
+		*idrEnabled = getEnvBoolOrDefault("IDR_ENABLED", *idrEnabled)
+	}
+	if !flagSet["timeout"] {
+		*timeout = getEnvDurationOrDefault("PBS_AUCTION_TIMEOUT", *timeout)
+	}
+
 	log.Info().
 		Str("port", *port).
 		Str("idr_url", *idrURL).
 		Bool("idr_enabled", *idrEnabled).
 		Dur("timeout", *timeout).
 		Msg("Starting The Nexus Engine PBS Server")
+	log.Info().Strs("modules", modules.Names()).Str("json_codec", jsoncodec.Name()).Msg("Compiled-in modules")
 
 	// Initialize Prometheus metrics
 	m := metrics.NewMetrics("pbs")
 	log.Info().Msg("Prometheus metrics enabled")
 
+	// Spans are exported when an OTLP endpoint is configured (OTEL_EXPORTER_OTLP_*)
+	var traceExporter *tracing.OTLPExporter
+	if tracingConfig := tracing.DefaultConfig(); tracingConfig.Endpoint != "" {
+		exporter, err := tracing.NewOTLPExporter(tracingConfig)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to start trace exporter")
+		}
+		traceExporter = exporter
+		tracing.SetTracer(tracing.NewTracer(exporter, tracingConfig.SampleRatio))
+		log.Info().
+			Str("endpoint", tracingConfig.Endpoint).
+			Float64("sample_ratio", tracingConfig.SampleRatio).
+			Msg("Tracing enabled")
+	}
+
 	// Initialize middleware
 	cors := middleware.NewCORS(middleware.DefaultCORSConfig())
 	security := middleware.NewSecurity(nil) // Uses DefaultSecurityConfig()
+	routeHeaders, err := middleware.ParseHeaderPolicies(os.Getenv("SECURITY_ROUTE_HEADERS"))
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid SECURITY_ROUTE_HEADERS")
+	}
+	security.AddRoutePolicies(routeHeaders...)
 	auth := middleware.NewAuth(middleware.DefaultAuthConfig())
-	publisherAuth := middleware.NewPublisherAuth(middleware.DefaultPublisherAuthConfig())
-	rateLimiter := middleware.NewRateLimiter(middleware.DefaultRateLimitConfig())
+	publisherAuthConfig := middleware.DefaultPublisherAuthConfig()
+	switch publisherAuthConfig.MismatchAction {
+	case "", middleware.MismatchActionReject, middleware.MismatchActionFlag:
+	default:
+		log.Fatal().Str("action", publisherAuthConfig.MismatchAction).Msg("Invalid PUBLISHER_DOMAIN_MISMATCH_ACTION (use reject or flag)")
+	}
+	publisherAuth := middleware.NewPublisherAuth(publisherAuthConfig)
+	syncRateLimitConfig := middleware.DefaultSyncRateLimitConfig()
+	syncRateLimiter := middleware.NewSyncRateLimiter(syncRateLimitConfig)
+	rateLimitConfig := middleware.DefaultRateLimitConfig()
+	if syncRateLimitConfig.Enabled {
+		// Sync endpoints have their own per-IP and per-publisher budget
+		rateLimitConfig.SkipPaths = syncRateLimitConfig.Paths
+	}
+	rateLimiter := middleware.NewRateLimiter(rateLimitConfig)
 	sizeLimiter := middleware.NewSizeLimiter(middleware.DefaultSizeLimitConfig())
 	gzipMiddleware := middleware.NewGzip(middleware.DefaultGzipConfig())
 
+	// Native TLS for server-to-server publishers that can't sit behind a terminating proxy
+	tlsConfig := middleware.DefaultTLSConfig()
+	serverTLS, err := tlsConfig.ServerTLS()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid TLS configuration")
+	}
+	clientCertAuth := middleware.NewClientCertAuth(tlsConfig.CNAccounts)
+
 	// Wire up metrics to middleware for observability
 	auth.SetMetrics(m)
+	publisherAuth.SetMetrics(m)
 	rateLimiter.SetMetrics(m)
+	syncRateLimiter.SetMetrics(m)
 
 	log.Info().
 		Bool("cors_enabled", true).
 		Bool("security_headers_enabled", security.GetConfig().Enabled).
+		Int("route_header_policies", len(security.GetConfig().RoutePolicies)).
 		Bool("auth_enabled", auth.IsEnabled()).
 		Bool("rate_limiting_enabled", rateLimiter != nil).
+		Bool("tls_enabled", serverTLS != nil).
+		Str("tls_client_auth", tlsConfig.ClientAuth).
+		Int("tls_client_accounts", len(tlsConfig.CNAccounts)).
 		Msg("Middleware initialized")
 
 	// Configure exchange
 	// P0: Currency conversion ENABLED by default for proper multi-currency support
 	currencyConvEnabled := os.Getenv("CURRENCY_CONVERSION_ENABLED") != "false"
+	// Static rates as units per USD, e.g. {"EUR":0.92,"GBP":0.79}; with a rate
+	// source they cover startup and stale periods
+	var currencyRates currency.Converter
+	if raw := os.Getenv("CURRENCY_RATES"); raw != "" {
+		var perUSD map[string]float64
+		if err := json.Unmarshal([]byte(raw), &perUSD); err != nil {
+			log.Fatal().Err(err).Msg("Invalid CURRENCY_RATES")
+		}
+		rates, err := currency.NewRates("USD", perUSD)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid CURRENCY_RATES")
+		}
+		currencyRates = rates
+	}
+	var rateFetcher *currency.Fetcher
+	if url := os.Getenv("CURRENCY_RATES_URL"); url != "" {
+		rateFetcher = currency.NewFetcher(currency.FetcherConfig{
+			URL:             url,
+			Base:            "USD",
+			RefreshInterval: getEnvDurationOrDefault("CURRENCY_RATES_REFRESH", currency.DefaultRefreshInterval),
+			StaleAfter:      getEnvDurationOrDefault("CURRENCY_RATES_STALE_AFTER", currency.DefaultStaleAfter),
+			Fallback:        currencyRates,
+			OnRefresh: func(err error) {
+				if err != nil {
+					log.Warn().Err(err).Str("url", url).Msg("Failed to refresh currency rates")
+				}
+			},
+		}, nil)
+		currencyRates = rateFetcher
+	}
 	idrAPIKey := os.Getenv("IDR_API_KEY")
+	// Accounts may opt out of event recording or be sampled to control volume
+	recordingPolicy, err := idr.NewRecordingPolicy(
+		getEnvFloatOrDefault("EVENT_SAMPLE_RATE", 1.0),
+		os.Getenv("EVENT_RECORDING_ACCOUNTS"),
+	)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid event recording configuration")
+	}
+	// Optional HMAC signing of IDR calls and event batches, "id:secret,..." with
+	// the first key signing; list the old key second while receivers rotate
+	outboundSigner, err := signing.FromEnv(os.Getenv("REQUEST_SIGNING_KEYS"))
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid REQUEST_SIGNING_KEYS")
+	}
+	if outboundSigner != nil {
+		log.Info().Str("key_id", outboundSigner.PrimaryKeyID()).Msg("Outbound request signing enabled")
+	}
 	config := &exchange.Config{
-		DefaultTimeout:     *timeout,
-		MaxBidders:         50,
-		IDREnabled:         *idrEnabled,
-		IDRServiceURL:      *idrURL,
-		IDRAPIKey:          idrAPIKey,
-		EventRecordEnabled: true,
-		EventBufferSize:    100,
-		CurrencyConv:       currencyConvEnabled,
-		DefaultCurrency:    "USD",
+		DefaultTimeout:       *timeout,
+		MaxBidders:           50,
+		IDREnabled:           *idrEnabled,
+		IDRServiceURL:        *idrURL,
+		IDRAPIKey:            idrAPIKey,
+		EventRecordEnabled:   true,
+		EventBufferSize:      100,
+		EventRecordingPolicy: recordingPolicy,
+		IDRSigner:            outboundSigner,
+		CurrencyConv:         currencyConvEnabled,
+		CurrencyRates:        currencyRates,
+		DefaultCurrency:      "USD",
+		// Dynamic bidders are only auctioned when this is set; the registry
+		// itself is attached below once Redis is reachable
+		DynamicBiddersEnabled: getEnvBoolOrDefault("DYNAMIC_BIDDERS_ENABLED", false),
+		DynamicRefreshPeriod:  pbsconfig.DynamicRefreshPeriod,
+		// Several SSPs throttle unidentified S2S callers
+		Identification: &adapters.Identification{
+			Name:      getEnvOrDefault("EXCHANGE_NAME", pbsconfig.EngineName),
+			Version:   pbsconfig.Version,
+			Contact:   os.Getenv("EXCHANGE_CONTACT"),
+			UserAgent: os.Getenv("OUTBOUND_USER_AGENT"),
+		},
+		// Absorbs IDR load during traffic spikes; set to 0 to disable
+		IDRSelectionCacheTTL: getEnvDurationOrDefault("IDR_SELECTION_CACHE_TTL", pbsconfig.IDRSelectionCacheTTL),
+		TMaxNetworkBuffer:    getEnvDurationOrDefault("TMAX_NETWORK_BUFFER", exchange.DefaultTMaxNetworkBuffer),
+		// Keeps bid events through IDR outages and restarts; unset drops them
+		EventSpillDir:      os.Getenv("EVENT_SPILL_DIR"),
+		EventSpillMaxBytes: int64(getEnvIntOrDefault("EVENT_SPILL_MAX_MB", 100)) << 20,
+	}
+
+	// Dynamic bidders with "sandbox": true always run sandboxed; this applies it to all of them
+	config.Sandbox = exchange.DefaultSandboxConfig()
+	config.Sandbox.Enabled = getEnvBoolOrDefault("DYNAMIC_BIDDER_SANDBOX", false)
+	config.Sandbox.TimeBudget = getEnvDurationOrDefault("DYNAMIC_BIDDER_SANDBOX_BUDGET", config.Sandbox.TimeBudget)
+
+	// Prebid Cache for VAST and bid caching, used by requests that set ext.prebid.cache
+	if url := os.Getenv("PREBID_CACHE_URL"); url != "" {
+		bidCache, err := cache.NewClient(cache.Config{
+			Endpoint:  url,
+			PublicURL: os.Getenv("PREBID_CACHE_PUBLIC_URL"),
+			Timeout:   getEnvDurationOrDefault("PREBID_CACHE_TIMEOUT", cache.DefaultTimeout),
+			TTL:       getEnvDurationOrDefault("PREBID_CACHE_TTL", cache.DefaultTTL),
+		}, nil)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid PREBID_CACHE_URL")
+		}
+		config.BidCache = bidCache
+		log.Info().Str("url", url).Msg("Prebid Cache enabled")
+	}
+
+	// Public URL of this server, for cookie sync callbacks and bid event URLs
+	hostURL := getEnvOrDefault("PBS_HOST_URL", "https://nexus-pbs.fly.dev")
+
+	// Win/imp event URLs on returned bids, pointing at /event
+	if getEnvBoolOrDefault("EVENTS_ENABLED", false) {
+		config.Events = &exchange.EventsConfig{
+			ExternalURL: hostURL,
+			TTL:         getEnvDurationOrDefault("EVENTS_BID_TTL", exchange.DefaultBidNoticeTTL),
+		}
+	}
+
+	// Server-side firing of bidders' notice URLs: winners' nurls as the auction
+	// closes, and nurl/burl on /event notifications
+	var notifier *exchange.Notifier
+	fireWinNotices := getEnvBoolOrDefault("WIN_NOTICES_ENABLED", false)
+	fireEventPixels := getEnvBoolOrDefault("EVENTS_FIRE_PIXELS", false)
+	if fireWinNotices || fireEventPixels {
+		notifierConfig := exchange.DefaultNotifierConfig()
+		notifierConfig.Timeout = getEnvDurationOrDefault("NOTICE_URL_TIMEOUT", notifierConfig.Timeout)
+		notifierConfig.Retries = getEnvIntOrDefault("NOTICE_URL_RETRIES", notifierConfig.Retries)
+		notifier = exchange.NewNotifier(notifierConfig)
+		notifier.SetMetrics(m)
+	}
+	if fireWinNotices {
+		config.WinNotifier = notifier
+	}
+
+	// Per-bidder timeouts from recent latency; tracked (see /admin/bidder-timeouts) even when off
+	config.AdaptiveTimeouts = exchange.DefaultAdaptiveTimeoutConfig()
+	config.AdaptiveTimeouts.Enabled = getEnvBoolOrDefault("ADAPTIVE_TIMEOUTS", false)
+	config.AdaptiveTimeouts.Percentile = getEnvFloatOrDefault("ADAPTIVE_TIMEOUT_PERCENTILE", config.AdaptiveTimeouts.Percentile)
+	config.AdaptiveTimeouts.Buffer = getEnvDurationOrDefault("ADAPTIVE_TIMEOUT_BUFFER", config.AdaptiveTimeouts.Buffer)
+	config.AdaptiveTimeouts.MinTimeout = getEnvDurationOrDefault("ADAPTIVE_TIMEOUT_MIN", config.AdaptiveTimeouts.MinTimeout)
+	config.AdaptiveTimeouts.MaxTimeout = getEnvDurationOrDefault("ADAPTIVE_TIMEOUT_MAX", config.AdaptiveTimeouts.MaxTimeout)
+	config.AdaptiveTimeouts.Window = getEnvIntOrDefault("ADAPTIVE_TIMEOUT_WINDOW", config.AdaptiveTimeouts.Window)
+	config.AdaptiveTimeouts.MinSamples = getEnvIntOrDefault("ADAPTIVE_TIMEOUT_MIN_SAMPLES", config.AdaptiveTimeouts.MinSamples)
+
+	// Workers bidder calls run on across all auctions, and calls that can wait for one
+	config.BidderPool = exchange.DefaultBidderPoolConfig()
+	config.BidderPool.Workers = getEnvIntOrDefault("BIDDER_POOL_WORKERS", config.BidderPool.Workers)
+	config.BidderPool.QueueSize = getEnvIntOrDefault("BIDDER_POOL_QUEUE_SIZE", config.BidderPool.QueueSize)
+	config.MaxParallelBidderRequests = getEnvIntOrDefault("BIDDER_MAX_PARALLEL_REQUESTS", config.MaxParallelBidderRequests)
+
+	// Bidder connection pool; watch bidder_connections_total for reuse rates
+	config.Transport = adapters.DefaultTransportConfig()
+	config.Transport.MaxIdleConns = getEnvIntOrDefault("BIDDER_MAX_IDLE_CONNS", config.Transport.MaxIdleConns)
+	config.Transport.MaxIdleConnsPerHost = getEnvIntOrDefault("BIDDER_MAX_IDLE_CONNS_PER_HOST", config.Transport.MaxIdleConnsPerHost)
+	config.Transport.MaxConnsPerHost = getEnvIntOrDefault("BIDDER_MAX_CONNS_PER_HOST", config.Transport.MaxConnsPerHost)
+	config.Transport.IdleConnTimeout = getEnvDurationOrDefault("BIDDER_IDLE_CONN_TIMEOUT", config.Transport.IdleConnTimeout)
+	config.Transport.TLSHandshakeTimeout = getEnvDurationOrDefault("BIDDER_TLS_HANDSHAKE_TIMEOUT", config.Transport.TLSHandshakeTimeout)
+	config.Transport.HTTP2 = getEnvBoolOrDefault("BIDDER_HTTP2", false)
+	config.Transport.DNSCacheTTL = getEnvDurationOrDefault("BIDDER_DNS_CACHE_TTL", 0)
+
+	// Skip bidders whose endpoint keeps failing; see /admin/bidder-circuits
+	config.BidderCircuitBreaker = exchange.DefaultBidderCircuitBreakerConfig()
+	config.BidderCircuitBreaker.Enabled = getEnvBoolOrDefault("BIDDER_CIRCUIT_BREAKER", true)
+	config.BidderCircuitBreaker.FailureThreshold = getEnvIntOrDefault("BIDDER_CIRCUIT_FAILURES", config.BidderCircuitBreaker.FailureThreshold)
+	config.BidderCircuitBreaker.OpenDuration = getEnvDurationOrDefault("BIDDER_CIRCUIT_OPEN_DURATION", config.BidderCircuitBreaker.OpenDuration)
+	config.BidderCircuitBreaker.HalfOpenProbes = getEnvIntOrDefault("BIDDER_CIRCUIT_PROBES", config.BidderCircuitBreaker.HalfOpenProbes)
+
+	// Close auctions early once every imp has enough bids, cancelling slower bidders
+	config.EarlyExit = exchange.DefaultEarlyExitConfig()
+	config.EarlyExit.Enabled = getEnvBoolOrDefault("AUCTION_EARLY_EXIT", false)
+	config.EarlyExit.MinBidsPerImp = getEnvIntOrDefault("AUCTION_EARLY_EXIT_MIN_BIDS", config.EarlyExit.MinBidsPerImp)
+	config.EarlyExit.MinElapsed = getEnvFloatOrDefault("AUCTION_EARLY_EXIT_MIN_ELAPSED", config.EarlyExit.MinElapsed)
+
+	// Close auctions with the bids so far once most bidders have responded, or at a soft deadline
+	config.PartialResponse = exchange.DefaultPartialResponseConfig()
+	config.PartialResponse.Enabled = getEnvBoolOrDefault("AUCTION_PARTIAL_RESPONSE", false)
+	config.PartialResponse.MinResponded = getEnvFloatOrDefault("AUCTION_PARTIAL_MIN_RESPONDED", config.PartialResponse.MinResponded)
+	config.PartialResponse.SoftDeadline = getEnvFloatOrDefault("AUCTION_PARTIAL_SOFT_DEADLINE", config.PartialResponse.SoftDeadline)
+
+	// Strip imp formats and fields each bidder's capabilities say it doesn't use
+	config.RequestShaping = getEnvBoolOrDefault("REQUEST_SHAPING", false)
+
+	// Let bids for the imp's PMP deals win over open-auction bids
+	config.PreferDeals = getEnvBoolOrDefault("PREFER_DEALS", false)
+
+	// Reject creatives that break battr, don't fit the imp, or use unsafe markup (badv/bcat are always enforced)
+	config.CreativeValidation = &exchange.CreativeValidationConfig{
+		Enabled:            getEnvBoolOrDefault("CREATIVE_VALIDATION", false),
+		BlockDocumentWrite: getEnvBoolOrDefault("CREATIVE_BLOCK_DOCUMENT_WRITE", false),
+		RequireHTTPS:       getEnvBoolOrDefault("CREATIVE_REQUIRE_HTTPS", false),
+	}
+
+	// Under GDPR, skip ("skip") or strip personal data for ("strip") bidders
+	// whose GVL vendor ID lacks consent in the TCF string
+	switch mode := os.Getenv("GDPR_VENDOR_CONSENT"); mode {
+	case exchange.VendorConsentSkip, exchange.VendorConsentStrip:
+		config.GDPRVendorConsent = mode
+	case "", "off":
+	default:
+		log.Warn().Str("mode", mode).Msg("Unknown GDPR_VENDOR_CONSENT mode, vendor consent not enforced")
+	}
+
+	// Report ("flag") or reject ("reject") native bids that don't fulfil the imp's native request
+	switch mode := getEnvOrDefault("NATIVE_VALIDATION", exchange.NativeValidationFlag); mode {
+	case exchange.NativeValidationFlag, exchange.NativeValidationReject:
+		config.NativeValidation = mode
+	case "off":
+	default:
+		log.Warn().Str("mode", mode).Msg("Unknown NATIVE_VALIDATION mode, native bids not validated")
+	}
+
+	// Built-in debug bidder; only bids on test=1 or allow-listed accounts
+	if getEnvBoolOrDefault("DEBUG_BIDDER_ENABLED", false) {
+		registerDebugBidder()
 	}
 
 	// Create exchange with default registry
 	ex := exchange.New(adapters.DefaultRegistry, config)
+	ex.SetIDRCacheMetrics(m)
+	if rec := ex.GetEventRecorder(); rec != nil {
+		rec.SetMetrics(m)
+	}
+	ex.SetAuctionMetrics(m)
+	ex.SetRolloutMetrics(m)
+	ex.SetThrottleMetrics(m)
+	ex.SetCircuitBreakerMetrics(m)
+	ex.SetConnectionMetrics(m)
+	ex.SetSandboxMetrics(m)
+	ex.SetBidderPoolMetrics(m)
+	ex.SetBidderErrorMetrics(m)
+	ex.SetCreativeMetrics(m)
+	ex.SetRequestSizeMetrics(m)
+	ex.SetMiddlewareOverheadMetrics(m)
+	ex.SetPrivacyMetrics(m)
 
-	// Initialize dynamic registry if Redis is available
+	// Runtime auction toggles, flipped via /admin/flags during incidents
+	flagRegistry := flags.NewRegistry()
+	ex.SetFlags(flagRegistry)
+
+	// On-demand cache invalidation, broadcast to other instances when Redis is available
+	cacheInvalidation := invalidation.NewManager()
+
+	// Per-publisher account configuration from ACCOUNTS_DIR, else from Redis below
+	var accountStore *accounts.Store
+	accountsDir := os.Getenv("ACCOUNTS_DIR")
+	if accountsDir != "" {
+		accountFiles, err := accounts.LoadDir(accountsDir)
+		if err != nil {
+			log.Fatal().Err(err).Str("dir", accountsDir).Msg("Invalid ACCOUNTS_DIR")
+		}
+		accountStore = accounts.New(accountFiles, 0)
+		log.Info().Int("count", accountFiles.Len()).Str("dir", accountsDir).Msg("Accounts loaded")
+	}
+
+	// Initialize dynamic registry and stored requests if Redis is available
 	var dynamicRegistry *ortb.DynamicRegistry
+	var storedRequests *storedrequests.Resolver
+	var uidStore *usersync.UIDStore
+	var syncerSource usersync.SyncerSource
 	redisURL := os.Getenv("REDIS_URL")
 	if redisURL != "" {
 		redisClient, err := redis.New(redisURL)
@@ -103,20 +450,61 @@ func main() {
 			publisherAuth.SetRedisClient(redisClient)
 			log.Info().Msg("Redis client set for auth middlewares")
 
-			dynamicRegistry = ortb.NewDynamicRegistry(redisClient, 30*time.Second)
-			if err := dynamicRegistry.Start(context.Background()); err != nil {
-				log.Warn().Err(err).Msg("Failed to start dynamic registry")
-			} else {
-				ex.SetDynamicRegistry(dynamicRegistry)
-				log.Info().
-					Int("dynamic_bidders", dynamicRegistry.Count()).
-					Msg("Dynamic bidder registry initialized")
+			// Share sync rate limit counters across instances
+			syncRateLimiter.SetRedisClient(redisClient)
+
+			// Share flag overrides across instances
+			flagRegistry.SetRedisClient(redisClient)
+
+			// Propagate cache invalidations across instances
+			cacheInvalidation.SetPubSub(redisClient)
+
+			// Expand ext.prebid.storedrequest references on auction requests
+			storedRequests = storedrequests.New(storedrequests.NewRedisFetcher(redisClient), storedrequests.Config{
+				CacheSize: getEnvIntOrDefault("STORED_REQUESTS_CACHE_SIZE", storedrequests.DefaultCacheSize),
+				CacheTTL:  getEnvDurationOrDefault("STORED_REQUESTS_CACHE_TTL", storedrequests.DefaultCacheTTL),
+			})
+
+			// Accounts are read per publisher and cached
+			if accountStore == nil {
+				accountStore = accounts.New(accounts.NewRedisFetcher(redisClient),
+					getEnvDurationOrDefault("ACCOUNTS_CACHE_TTL", accounts.DefaultCacheTTL))
 			}
+
+			// Keep UIDs server-side, with the uids cookie only carrying their ID
+			if os.Getenv("UIDS_STORE") == "redis" {
+				uidStore = usersync.NewUIDStore(redisClient, getEnvDurationOrDefault("UIDS_STORE_TTL", usersync.DefaultTTL))
+			}
+
+			// Syncer definitions from Redis, unless SYNCERS_FILE is set
+			if getEnvBoolOrDefault("SYNCERS_FROM_REDIS", false) {
+				syncerSource = usersync.NewRedisSyncerSource(redisClient)
+			}
+
+			dynamicRegistry = ortb.NewDynamicRegistry(redisClient, pbsconfig.DynamicRefreshPeriod)
+			dynamicRegistry.SetMetrics(m)
+			dynamicRegistry.SetStaleAlert(
+				getEnvIntOrDefault("DYNAMIC_REGISTRY_STALE_PERIODS", pbsconfig.DynamicStalePeriods),
+				func(age time.Duration) {
+					// Stale configs mean new or changed demand is silently missing
+					log.Warn().
+						Dur("since_last_success", age).
+						Str("alert", "dynamic_registry_stale").
+						Msg("Dynamic registry stale alert")
+				},
+			)
 		}
 	} else {
-		log.Info().Msg("REDIS_URL not set, dynamic bidders disabled")
+		log.Info().Msg("REDIS_URL not set, dynamic bidders and stored requests disabled")
 	}
 
+	// Note: Only set when present to avoid typed-nil interface issues
+	if accountStore != nil {
+		ex.SetAccounts(accountStore)
+	}
+
+	registerCaches(cacheInvalidation, ex, flagRegistry, dynamicRegistry, storedRequests, accountStore)
+
 	// List registered bidders
 	bidders := adapters.DefaultRegistry.ListBidders()
 	log.Info().
@@ -124,8 +512,73 @@ func main() {
 		Strs("bidders", bidders).
 		Msg("Static bidders registered")
 
+	// Per-account request defaults let thin integrations (pixels, SDK lite) omit fields
+	accountDefaults, err := endpoints.ParseAccountDefaults(os.Getenv("ACCOUNT_REQUEST_DEFAULTS"))
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid account request defaults")
+	}
+
+	// Analytics modules receive auction, user sync and event outcomes
+	analyticsRunner := analytics.NewRunner()
+	if path := os.Getenv("ANALYTICS_FILE_PATH"); path != "" {
+		fileModule, err := filelog.New(path)
+		if err != nil {
+			log.Fatal().Err(err).Str("path", path).Msg("Failed to open analytics file")
+		}
+		analyticsRunner.Register("file", fileModule)
+	}
+	if url := os.Getenv("ANALYTICS_HTTP_URL"); url != "" {
+		httpConfig := httpbatch.DefaultConfig(url)
+		httpConfig.BatchSize = getEnvIntOrDefault("ANALYTICS_HTTP_BATCH_SIZE", httpConfig.BatchSize)
+		httpConfig.FlushInterval = getEnvDurationOrDefault("ANALYTICS_HTTP_FLUSH_INTERVAL", httpConfig.FlushInterval)
+		httpConfig.BufferSize = getEnvIntOrDefault("ANALYTICS_HTTP_BUFFER_SIZE", httpConfig.BufferSize)
+		httpModule, err := httpbatch.New(httpConfig)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to start analytics collector module")
+		}
+		analyticsRunner.Register("http", httpModule)
+	}
+	if brokers := os.Getenv("ANALYTICS_KAFKA_BROKERS"); brokers != "" {
+		kafkaConfig := kafka.DefaultConfig(strings.Split(strings.ReplaceAll(brokers, " ", ""), ","), getEnvOrDefault("ANALYTICS_KAFKA_TOPIC", "pbs-events"))
+		kafkaConfig.BatchSize = getEnvIntOrDefault("ANALYTICS_KAFKA_BATCH_SIZE", kafkaConfig.BatchSize)
+		kafkaConfig.FlushInterval = getEnvDurationOrDefault("ANALYTICS_KAFKA_FLUSH_INTERVAL", kafkaConfig.FlushInterval)
+		kafkaConfig.BufferSize = getEnvIntOrDefault("ANALYTICS_KAFKA_BUFFER_SIZE", kafkaConfig.BufferSize)
+		kafkaConfig.Compression = getEnvOrDefault("ANALYTICS_KAFKA_COMPRESSION", kafkaConfig.Compression)
+		kafkaConfig.Acks = getEnvOrDefault("ANALYTICS_KAFKA_ACKS", kafkaConfig.Acks)
+		kafkaModule, err := kafka.New(kafkaConfig)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to start Kafka analytics module")
+		}
+		analyticsRunner.Register("kafka", kafkaModule)
+	}
+	// Note: Pass nil explicitly without modules to avoid typed-nil interface issues
+	var analyticsModule analytics.Module
+	if names := analyticsRunner.Names(); len(names) > 0 {
+		analyticsModule = analyticsRunner
+		log.Info().Strs("modules", names).Msg("Analytics modules enabled")
+	}
+
 	// Create handlers
+	// Draining takes the server out of service ahead of a deploy: health checks
+	// fail and new auctions are refused until the window ends and it stops
+	drainer := lifecycle.NewDrainer(getEnvDurationOrDefault("DRAIN_WINDOW", pbsconfig.DrainWindow))
+
 	auctionHandler := endpoints.NewAuctionHandler(ex)
+	auctionHandler.SetMetrics(m)
+	auctionHandler.SetAccountDefaults(accountDefaults)
+	auctionHandler.SetAnalytics(analyticsModule)
+	auctionHandler.SetDrain(drainer)
+	auctionV2Handler := endpoints.NewVersionedAuctionHandler(ex, endpoints.AuctionV2)
+	auctionV2Handler.SetMetrics(m)
+	auctionV2Handler.SetAccountDefaults(accountDefaults)
+	auctionV2Handler.SetAnalytics(analyticsModule)
+	auctionV2Handler.SetDrain(drainer)
+	if deprecation := auctionV1Deprecation(); deprecation != nil {
+		auctionHandler.SetDeprecation(deprecation)
+		log.Info().
+			Time("sunset", deprecation.Sunset).
+			Msg("Legacy auction endpoint marked deprecated")
+	}
 	statusHandler := endpoints.NewStatusHandler()
 	// Use dynamic handler that queries registries at request time
 	// Note: Pass nil explicitly if dynamicRegistry is nil to avoid typed-nil interface issues
@@ -135,16 +588,74 @@ func main() {
 	}
 	biddersHandler := endpoints.NewDynamicInfoBiddersHandler(adapters.DefaultRegistry, dynamicBidderLister)
 
+	// Background availability probing for the public bidder status page
+	probeConfig := probe.DefaultConfig()
+	probeConfig.Enabled = getEnvBoolOrDefault("BIDDER_PROBE_ENABLED", false)
+	probeConfig.Interval = getEnvDurationOrDefault("BIDDER_PROBE_INTERVAL", probeConfig.Interval)
+	probeConfig.Timeout = getEnvDurationOrDefault("BIDDER_PROBE_TIMEOUT", probeConfig.Timeout)
+	bidderProber := probe.New(probeConfig, probe.RegistryTargets(adapters.DefaultRegistry, dynamicRegistry))
+
 	// Cookie sync handlers
-	hostURL := os.Getenv("PBS_HOST_URL")
-	if hostURL == "" {
-		hostURL = "https://nexus-pbs.fly.dev"
-	}
 	cookieSyncConfig := endpoints.DefaultCookieSyncConfig(hostURL)
+	cookieSyncConfig.MaxSyncs = getEnvIntOrDefault("COOKIE_SYNC_MAX_SYNCS", cookieSyncConfig.MaxSyncs)
 	cookieSyncHandler := endpoints.NewCookieSyncHandler(cookieSyncConfig)
+	cookieSyncHandler.SetAnalytics(analyticsModule)
+	if accountStore != nil {
+		cookieSyncHandler.SetAccounts(accountStore)
+	}
 	setuidHandler := endpoints.NewSetUIDHandler(cookieSyncHandler.ListBidders())
+	setuidHandler.SetAnalytics(analyticsModule)
 	optoutHandler := endpoints.NewOptOutHandler()
 
+	// Syncer definitions from SYNCERS_FILE or Redis replace the built-in ones
+	// per bidder, and are reloaded so sync URLs can change without a rebuild
+	syncersFile := os.Getenv("SYNCERS_FILE")
+	if syncersFile != "" {
+		syncerSource = usersync.NewFileSyncerSource(syncersFile)
+	}
+	var syncerReloader *usersync.SyncerReloader
+	if syncerSource != nil {
+		syncerReloader = usersync.NewSyncerReloader(syncerSource,
+			getEnvDurationOrDefault("SYNCERS_REFRESH_PERIOD", usersync.DefaultSyncerRefreshPeriod),
+			func(configs map[string]usersync.SyncerConfig) {
+				cookieSyncHandler.SetSyncers(configs)
+				setuidHandler.SetBidders(cookieSyncHandler.ListBidders())
+			})
+		if err := syncerReloader.Reload(context.Background()); err != nil {
+			if syncersFile != "" {
+				log.Fatal().Err(err).Str("file", syncersFile).Msg("Invalid SYNCERS_FILE")
+			}
+			log.Warn().Err(err).Msg("Failed to load syncers from Redis, using the built-in ones")
+		}
+	}
+
+	// Signed (optionally encrypted) uids cookies, "id:secret,..." with the first
+	// key encoding; list the old key second while rotating
+	cookieKeys, err := signing.ParseKeys(os.Getenv("UIDS_COOKIE_KEYS"))
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid UIDS_COOKIE_KEYS")
+	}
+	if len(cookieKeys) > 0 {
+		cookieCodec, err := usersync.NewCookieCodec(cookieKeys,
+			getEnvBoolOrDefault("UIDS_COOKIE_ENCRYPT", false),
+			getEnvBoolOrDefault("UIDS_COOKIE_ACCEPT_PLAIN", true))
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid UIDS_COOKIE_KEYS")
+		}
+		cookieSyncHandler.SetCookieCodec(cookieCodec)
+		setuidHandler.SetCookieCodec(cookieCodec)
+		optoutHandler.SetCookieCodec(cookieCodec)
+		log.Info().Str("key_id", cookieKeys[0].ID).Msg("uids cookie signing enabled")
+	}
+	if uidStore != nil {
+		cookieSyncHandler.SetUIDStore(uidStore)
+		setuidHandler.SetUIDStore(uidStore)
+		optoutHandler.SetUIDStore(uidStore)
+		log.Info().Msg("UIDs stored in Redis")
+	} else if os.Getenv("UIDS_STORE") == "redis" {
+		log.Warn().Msg("UIDS_STORE=redis needs Redis, keeping UIDs in the uids cookie")
+	}
+
 	log.Info().
 		Str("host_url", hostURL).
 		Int("syncers", len(cookieSyncHandler.ListBidders())).
@@ -157,34 +668,148 @@ func main() {
 		privacyConfig.EnforceGDPR = false
 		log.Warn().Msg("GDPR enforcement disabled via PBS_DISABLE_GDPR_ENFORCEMENT")
 	}
-	privacyMiddleware := middleware.NewPrivacyMiddleware(privacyConfig)
-
-	// Wrap auction handler with privacy middleware
-	privacyProtectedAuction := privacyMiddleware(auctionHandler)
+	privacyConfig.Metrics = m
+	// Optional IP geo resolution for requests without device.geo.country: a
+	// MaxMind DB file, or an HTTP geo service
+	if path := os.Getenv("GEOIP_DB_PATH"); path != "" {
+		reader, err := geoip.Open(path)
+		if err != nil {
+			log.Fatal().Err(err).Str("path", path).Msg("Failed to open GeoIP database")
+		}
+		privacyConfig.GeoResolver = reader
+		log.Info().Str("path", path).Str("type", reader.DatabaseType).Msg("GeoIP database loaded")
+	} else if url := os.Getenv("GEO_SERVICE_URL"); url != "" {
+		privacyConfig.GeoResolver = geoip.NewService(geoip.ServiceConfig{
+			URL:      url,
+			Field:    os.Getenv("GEO_SERVICE_FIELD"),
+			Timeout:  getEnvDurationOrDefault("GEO_SERVICE_TIMEOUT", geoip.DefaultServiceTimeout),
+			CacheTTL: getEnvDurationOrDefault("GEO_SERVICE_CACHE_TTL", geoip.DefaultServiceCacheTTL),
+		}, nil)
+	}
+	// Applied to the auction routes only, innermost in their chain; a config
+	// reload replaces the live config
+	livePrivacy := middleware.NewLivePrivacyConfig(privacyConfig)
+	privacyMiddleware := livePrivacy.Middleware
 
 	log.Info().
 		Bool("gdpr_enforcement", privacyConfig.EnforceGDPR).
 		Bool("coppa_enforcement", privacyConfig.EnforceCOPPA).
 		Bool("strict_mode", privacyConfig.StrictMode).
+		Str("violation_mode", privacyConfig.ViolationMode).
+		Bool("geo_resolution", privacyConfig.GeoResolver != nil).
 		Msg("Privacy middleware initialized")
 
-	// Setup routes
-	mux := http.NewServeMux()
-	mux.Handle("/openrtb2/auction", privacyProtectedAuction)
-	mux.Handle("/status", statusHandler)
-	mux.Handle("/health", healthHandler())
-	mux.Handle("/info/bidders", biddersHandler)
+	// Under GDPR, cookie syncs need purpose 1 consent and the bidder's GVL vendor consent
+	if privacyConfig.EnforceGDPR {
+		cookieSyncHandler.SetTCFEnforcement(func(bidder string) int {
+			awi, _ := adapters.DefaultRegistry.Get(bidder)
+			return awi.Info.GVLVendorID
+		})
+	}
+
+	// Warmup runs after the listener is up so /health answers immediately while
+	// /ready stays 503 until caches and code paths are primed
+	warmupRunner := warmup.NewRunner(getEnvDurationOrDefault("WARMUP_TIMEOUT", pbsconfig.WarmupTimeout))
+	warmupRunner.Add("syncers", func(ctx context.Context) error {
+		return cookieSyncHandler.Warmup()
+	})
+	warmupRunner.Add("encoders", func(ctx context.Context) error {
+		gzipMiddleware.Prime(pbsconfig.WarmupGzipWriters)
+		return warmup.PrimeJSON(
+			&openrtb.BidRequest{}, &openrtb.BidResponse{}, &openrtb.BidResponseExt{},
+			&endpoints.CookieSyncRequest{}, &endpoints.CookieSyncResponse{},
+		)
+	})
+	warmupRunner.Add("auction", func(ctx context.Context) error {
+		_, err := ex.Warmup(ctx)
+		return err
+	})
+
+	// Setup routes. Every request passes through the router-wide chain:
+	// Request Start -> CORS -> Security -> Logging -> Client Cert Auth -> Metrics
+	// Note: Request Start stamps arrival time so auctions deduct middleware time from tmax
+	// Note: CORS must wrap every middleware that can reject a request, to handle preflight OPTIONS requests
+	// Note: Security headers applied early to ensure all responses have them
+	// Each group then adds only the middleware its routes need, with Gzip
+	// innermost so responses are compressed before being sent
+	rt := router.New()
+	rt.SetMetrics(m)
+	rt.Use(requestStartMiddleware, cors.Middleware, security.Middleware, loggingMiddleware, clientCertAuth.Middleware, m.Middleware)
+
+	// Health, readiness and Prometheus scrapes: never limited or compressed.
+	// Health checks fail while draining so load balancers route elsewhere.
+	ops := rt.Group()
+	ops.Handle("/status", endpoints.UnavailableWhileDraining(drainer, statusHandler))
+	ops.Handle("/health", endpoints.UnavailableWhileDraining(drainer, healthHandler()))
+	ops.Handle("/ready", endpoints.UnavailableWhileDraining(drainer, warmupRunner))
+	ops.Handle("/metrics", metrics.Handler())
+
+	// Auction: Size Limit -> Stored Requests -> PublisherAuth -> Rate Limit -> Gzip -> Privacy
+	// Note: Stored requests expand first so auth and privacy see the full request
+	auction := rt.Group(sizeLimiter.Middleware, storedRequests.Middleware, publisherAuth.Middleware, rateLimiter.Middleware, gzipMiddleware.Middleware, privacyMiddleware)
+	auction.Handle(endpoints.AuctionV1Path, auctionHandler)
+	auction.Handle(endpoints.AuctionV2Path, auctionV2Handler)
+
+	// AMP and video build an OpenRTB request and forward it through publisher
+	// auth and privacy like any other auction
+	// Note: Pass nil explicitly when stored requests are off to avoid typed-nil interface issues
+	var forwardStored endpoints.StoredRequestResolver
+	if storedRequests != nil {
+		forwardStored = storedRequests
+	}
+	forwardAuctionHandler := endpoints.NewAuctionHandler(ex)
+	forwardAuctionHandler.SetMetrics(m)
+	forwardAuctionHandler.SetAccountDefaults(accountDefaults)
+	forwardAuctionHandler.SetAnalytics(analyticsModule)
+	forwardAuctionHandler.SetDrain(drainer)
+	forwardAuction := publisherAuth.Middleware(privacyMiddleware(forwardAuctionHandler))
+	auction.Handle("GET "+endpoints.AMPPath, endpoints.NewAMPHandler(forwardStored, forwardAuction))
 
-	// Cookie sync endpoints
-	mux.Handle("/cookie_sync", cookieSyncHandler)
-	mux.Handle("/setuid", setuidHandler)
-	mux.Handle("/optout", optoutHandler)
+	// Video: Size Limit -> Rate Limit -> Gzip
+	// Note: The body is not OpenRTB, so auth and privacy run on the forwarded request instead
+	video := rt.Group(sizeLimiter.Middleware, rateLimiter.Middleware, gzipMiddleware.Middleware)
+	video.Handle("POST "+endpoints.VideoPath, endpoints.NewVideoHandler(forwardStored, forwardAuction))
 
-	// Prometheus metrics endpoint
-	mux.Handle("/metrics", metrics.Handler())
+	// Public bidder info
+	findBidder := endpoints.RegistryBidders(adapters.DefaultRegistry, dynamicRegistry)
+	info := rt.Group(rateLimiter.Middleware, gzipMiddleware.Middleware)
+	info.Handle("/info/bidders", biddersHandler)
+	info.Handle("GET /info/bidders/{name}", endpoints.NewInfoBidderHandler(findBidder))
+	info.Handle("GET /info/bidders/{name}/params", endpoints.NewInfoBidderParamsHandler(findBidder))
+	info.Handle("/info/status/bidders", bidderProber)
 
-	// Admin endpoints for runtime configuration
-	mux.HandleFunc("/admin/circuit-breaker", func(w http.ResponseWriter, r *http.Request) {
+	// Cookie sync endpoints: per-IP/per-publisher limits for /cookie_sync and /setuid
+	userSync := rt.Group(sizeLimiter.Middleware, rateLimiter.Middleware, syncRateLimiter.Middleware, gzipMiddleware.Middleware)
+	userSync.Handle("/cookie_sync", cookieSyncHandler)
+	userSync.Handle("/setuid", setuidHandler)
+	userSync.Handle("/optout", optoutHandler)
+
+	// Client render/win feedback, recorded for IDR training
+	// Note: Pass nil explicitly when event recording is off to avoid typed-nil interface issues
+	var feedbackRecorder endpoints.FeedbackRecorder
+	if rec := ex.GetEventRecorder(); rec != nil {
+		feedbackRecorder = rec
+	}
+	feedbackHandler := endpoints.NewFeedbackHandler(feedbackRecorder)
+	feedbackHandler.SetMetrics(m)
+	rt.Handle("/feedback", feedbackHandler, rateLimiter.Middleware)
+
+	// Win/imp notifications from the event URLs on returned bids, recorded for IDR
+	var eventRecorder endpoints.EventRecorder
+	if rec := ex.GetEventRecorder(); rec != nil {
+		eventRecorder = rec
+	}
+	eventHandler := endpoints.NewEventHandler(ex.BidNotices(), eventRecorder)
+	eventHandler.SetMetrics(m)
+	eventHandler.SetAnalytics(analyticsModule)
+	if fireEventPixels {
+		eventHandler.SetNotifier(notifier)
+	}
+	rt.Handle(endpoints.EventPath, eventHandler, rateLimiter.Middleware)
+
+	// Admin endpoints for runtime configuration: API key auth
+	admin := rt.Group(auth.Middleware, rateLimiter.Middleware, gzipMiddleware.Middleware)
+	admin.HandleFunc("/admin/circuit-breaker", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		if ex.GetIDRClient() != nil {
 			stats := ex.GetIDRClient().CircuitBreakerStats()
@@ -198,67 +823,250 @@ func main() {
 		}
 	})
 
-	// Build middleware chain: CORS -> Security -> Logging -> Size Limit -> Auth -> PublisherAuth -> Rate Limit -> Metrics -> Gzip -> Handler
-	// Note: CORS must be outermost to handle preflight OPTIONS requests
-	// Note: Security headers applied early to ensure all responses have them
-	// Note: Auth handles API key auth for admin endpoints
-	// Note: PublisherAuth handles publisher validation for auction endpoints
-	// Note: Gzip is innermost so responses are compressed before being sent
-	handler := http.Handler(mux)
-	handler = gzipMiddleware.Middleware(handler) // Compress responses
-	handler = m.Middleware(handler)
-	handler = rateLimiter.Middleware(handler)
-	handler = publisherAuth.Middleware(handler) // Publisher auth for auction endpoints
-	handler = auth.Middleware(handler)
-	handler = sizeLimiter.Middleware(handler)
-	handler = loggingMiddleware(handler)
-	handler = security.Middleware(handler)
-	handler = cors.Middleware(handler)
+	admin.HandleFunc("/admin/dynamic-registry", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if dynamicRegistry != nil {
+			if err := json.NewEncoder(w).Encode(dynamicRegistry.Stats()); err != nil {
+				log.Error().Err(err).Msg("failed to encode dynamic registry stats")
+			}
+		} else {
+			if err := json.NewEncoder(w).Encode(map[string]string{"status": "dynamic registry disabled"}); err != nil {
+				log.Error().Err(err).Msg("failed to encode dynamic registry disabled status")
+			}
+		}
+	})
+
+	admin.HandleFunc("/admin/idr-cache", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodDelete:
+			ex.FlushIDRCache()
+			log.Info().Msg("IDR selection cache flushed")
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(ex.IDRCacheStats()); err != nil {
+			log.Error().Err(err).Msg("failed to encode IDR cache stats")
+		}
+	})
+
+	admin.HandleFunc("/admin/bidder-timeouts", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(ex.AdaptiveTimeoutStats()); err != nil {
+			log.Error().Err(err).Msg("failed to encode bidder timeout stats")
+		}
+	})
+
+	admin.HandleFunc("/admin/bidder-circuits", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(ex.BidderCircuitStats()); err != nil {
+			log.Error().Err(err).Msg("failed to encode bidder circuit stats")
+		}
+	})
+
+	admin.Handle("/admin/flags", endpoints.NewFlagsHandler(flagRegistry))
+	admin.Handle("/admin/cache/invalidate", endpoints.NewCacheInvalidationHandler(cacheInvalidation))
+
+	// Live settings from the config file, also reloaded on SIGHUP
+	configReload := &configReloader{
+		source:          configSource,
+		timeoutFlag:     flagSet["timeout"],
+		ex:              ex,
+		privacy:         livePrivacy,
+		rateLimiter:     rateLimiter,
+		syncRateLimiter: syncRateLimiter,
+		accountStore:    accountStore,
+		accountsDir:     accountsDir,
+	}
+	admin.Handle("POST /admin/config/reload", endpoints.NewConfigReloadHandler(configReload))
+	admin.Handle("/admin/drain", endpoints.NewDrainHandler(drainer))
+	adminBidders := endpoints.NewAdminBiddersHandler(adapters.DefaultRegistry)
+	admin.Handle("GET /admin/bidders", adminBidders)
+	admin.Handle("PUT /admin/bidders/{code}", adminBidders)
+	admin.Handle("GET /admin/bidders/{code}", endpoints.NewAdminBidderHandler(findBidder))
+	if dynamicRegistry != nil {
+		// Writes reload the registry here and, via cache invalidation, on every other instance
+		dynamicBidders := endpoints.NewDynamicBiddersHandler(dynamicRegistry, func(ctx context.Context) error {
+			_, err := cacheInvalidation.Invalidate(ctx, "dynamic_registry", nil)
+			return err
+		})
+		admin.Handle("GET /admin/dynamic-bidders", dynamicBidders)
+		admin.Handle("/admin/dynamic-bidders/{code}", dynamicBidders)
+	}
 
 	// Create server (P2-6: use named constants for timeouts)
 	server := &http.Server{
 		Addr:         ":" + *port,
-		Handler:      handler,
+		Handler:      rt,
 		ReadTimeout:  pbsconfig.ServerReadTimeout,
 		WriteTimeout: pbsconfig.ServerWriteTimeout,
 		IdleTimeout:  pbsconfig.ServerIdleTimeout,
+		TLSConfig:    serverTLS,
 	}
 
-	// Start server in goroutine
-	go func() {
-		log.Info().Str("addr", ":"+*port).Msg("Server listening")
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatal().Err(err).Msg("Server error")
+	// Components stop in reverse dependency order: the HTTP server drains in-flight
+	// requests first, then the limiters and registries they use, then the event recorder
+	lc := lifecycle.NewManager(lifecycle.DefaultStopTimeout)
+	registerComponent := func(c lifecycle.Component) {
+		if err := lc.Register(c); err != nil {
+			log.Fatal().Err(err).Msg("Failed to register component")
 		}
-	}()
-
-	// Wait for shutdown signal
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	sig := <-quit
-
-	log.Info().Str("signal", sig.String()).Msg("Shutdown signal received")
-
-	// Stop rate limiter cleanup goroutine
-	rateLimiter.Stop()
-
-	// Stop dynamic registry refresh goroutine
+	}
+	registerComponent(lifecycle.Component{
+		Name: "event_recorder",
+		Stop: func(context.Context) error { return ex.Close() },
+	})
+	registerComponent(lifecycle.Component{
+		Name: "analytics",
+		Stop: func(context.Context) error { return analyticsRunner.Close() },
+	})
+	registerComponent(lifecycle.Component{Name: "rate_limiter", Stop: lifecycle.Wrap(rateLimiter.Stop)})
+	registerComponent(lifecycle.Component{Name: "sync_rate_limiter", Stop: lifecycle.Wrap(syncRateLimiter.Stop)})
+	registerComponent(lifecycle.Component{
+		Name: "flag_registry",
+		Start: func(ctx context.Context) error {
+			flagRegistry.Start(ctx, pbsconfig.FlagSyncPeriod) // No-op without Redis
+			return nil
+		},
+		Stop: lifecycle.Wrap(flagRegistry.Stop),
+	})
+	registerComponent(lifecycle.Component{
+		Name: "cache_invalidation",
+		Start: func(ctx context.Context) error {
+			// Without the subscription, invalidations still apply to this instance
+			if err := cacheInvalidation.Start(ctx); err != nil {
+				log.Warn().Err(err).Msg("Cache invalidations will not propagate from other instances")
+			}
+			return nil
+		},
+		Stop: cacheInvalidation.Stop,
+	})
+	serverDeps := []string{"event_recorder", "analytics", "rate_limiter", "sync_rate_limiter", "flag_registry", "cache_invalidation"}
+	if traceExporter != nil {
+		registerComponent(lifecycle.Component{
+			Name: "tracing",
+			Stop: func(context.Context) error { return traceExporter.Close() },
+		})
+		serverDeps = append(serverDeps, "tracing")
+	}
+	if rateFetcher != nil {
+		registerComponent(lifecycle.Component{
+			Name: "currency_rates",
+			Start: func(ctx context.Context) error {
+				// Auctions fall back to CURRENCY_RATES (or reject other currencies) until a fetch succeeds
+				rateFetcher.Start(ctx)
+				return nil
+			},
+			Stop: lifecycle.Wrap(rateFetcher.Stop),
+		})
+		serverDeps = append(serverDeps, "currency_rates")
+	}
+	if syncerReloader != nil {
+		registerComponent(lifecycle.Component{
+			Name: "syncer_reloader",
+			Start: func(ctx context.Context) error {
+				syncerReloader.Start(ctx)
+				return nil
+			},
+			Stop: lifecycle.Wrap(syncerReloader.Stop),
+		})
+	}
+	if dynamicRegistry != nil {
+		registerComponent(lifecycle.Component{
+			Name: "dynamic_registry",
+			Start: func(ctx context.Context) error {
+				// Static bidders still serve if Redis configs can't be loaded
+				if err := dynamicRegistry.Start(ctx); err != nil {
+					log.Warn().Err(err).Msg("Failed to start dynamic registry")
+					return nil
+				}
+				ex.SetDynamicRegistry(dynamicRegistry)
+				log.Info().
+					Int("dynamic_bidders", dynamicRegistry.Count()).
+					Msg("Dynamic bidder registry initialized")
+				return nil
+			},
+			Stop: lifecycle.Wrap(dynamicRegistry.Stop),
+		})
+		serverDeps = append(serverDeps, "dynamic_registry")
+	}
+	var proberDeps []string
 	if dynamicRegistry != nil {
-		dynamicRegistry.Stop()
-		log.Info().Msg("Dynamic registry stopped")
+		proberDeps = []string{"dynamic_registry"} // Probe dynamic bidders once loaded
 	}
+	registerComponent(lifecycle.Component{
+		Name:      "bidder_prober",
+		DependsOn: proberDeps,
+		Start: func(ctx context.Context) error {
+			bidderProber.Start(ctx) // No-op unless BIDDER_PROBE_ENABLED
+			return nil
+		},
+		Stop: lifecycle.Wrap(bidderProber.Stop),
+	})
+	serverDeps = append(serverDeps, "bidder_prober")
+	registerComponent(lifecycle.Component{
+		Name:      "http_server",
+		DependsOn: serverDeps,
+		Start: func(context.Context) error {
+			// Bind synchronously so a port conflict fails startup and rolls back
+			ln, err := net.Listen("tcp", server.Addr)
+			if err != nil {
+				return err
+			}
+			go func() {
+				log.Info().Str("addr", server.Addr).Bool("tls", serverTLS != nil).Msg("Server listening")
+				serve := server.Serve
+				if serverTLS != nil {
+					serve = func(ln net.Listener) error { return server.ServeTLS(ln, "", "") }
+				}
+				if err := serve(ln); err != nil && err != http.ErrServerClosed {
+					log.Fatal().Err(err).Msg("Server error")
+				}
+			}()
+			return nil
+		},
+		Stop:        server.Shutdown,
+		StopTimeout: pbsconfig.ShutdownTimeout,
+	})
 
-	// Flush pending events from exchange
-	if err := ex.Close(); err != nil {
-		log.Warn().Err(err).Msg("Error flushing event recorder")
-	} else {
-		log.Info().Msg("Event recorder flushed")
+	if err := lc.Start(context.Background()); err != nil {
+		log.Fatal().Err(err).Msg("Startup failed")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), pbsconfig.ShutdownTimeout)
-	defer cancel()
+	go warmupRunner.Run(context.Background())
 
-	if err := server.Shutdown(ctx); err != nil {
+	// Wait for a shutdown signal or the end of a drain. With DRAIN_ON_SIGNAL a
+	// signal drains first, and a second one stops without waiting.
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	reloads := make(chan os.Signal, 1)
+	signal.Notify(reloads, syscall.SIGHUP)
+	drainOnSignal := getEnvBoolOrDefault("DRAIN_ON_SIGNAL", false)
+	drainStarted := drainer.Started()
+wait:
+	for {
+		select {
+		case <-reloads:
+			configReload.Reload() // Logs the outcome
+		case <-drainStarted:
+			drainStarted = nil
+			server.SetKeepAlivesEnabled(false) // Move clients off idle connections too
+			log.Info().Dur("window", drainer.Window()).Msg("Draining")
+		case <-drainer.Done():
+			log.Info().Msg("Drain window ended")
+			break wait
+		case sig := <-quit:
+			log.Info().Str("signal", sig.String()).Msg("Shutdown signal received")
+			if !drainOnSignal || drainer.Draining() {
+				break wait
+			}
+			drainer.Drain()
+		}
+	}
+
+	if err := lc.Stop(context.Background()); err != nil {
 		log.Fatal().Err(err).Msg("Server forced to shutdown")
 	}
 
@@ -276,6 +1084,14 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// requestStartMiddleware records when a request arrived, before any other
+// middleware runs, so the auction timeout is measured from arrival
+func requestStartMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(exchange.WithRequestStart(r.Context(), time.Now())))
+	})
+}
+
 // loggingMiddleware logs HTTP requests with structured logging
 func loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -325,7 +1141,7 @@ func healthHandler() http.Handler {
 		health := map[string]interface{}{
 			"status":    "healthy",
 			"timestamp": time.Now().UTC().Format(time.RFC3339),
-			"version":   "1.0.0",
+			"version":   pbsconfig.Version,
 		}
 
 		w.Header().Set("Content-Type", "application/json")
@@ -346,6 +1162,98 @@ func generateRequestID() string {
 	return hex.EncodeToString(b)
 }
 
+// auctionV1Deprecation builds the legacy auction deprecation notice from
+// AUCTION_V1_DEPRECATED and AUCTION_V1_SUNSET (YYYY-MM-DD or RFC3339); nil when not deprecated
+func auctionV1Deprecation() *endpoints.Deprecation {
+	sunsetValue := os.Getenv("AUCTION_V1_SUNSET")
+	if !getEnvBoolOrDefault("AUCTION_V1_DEPRECATED", false) && sunsetValue == "" {
+		return nil
+	}
+
+	deprecation := &endpoints.Deprecation{Successor: endpoints.AuctionV2Path}
+	if sunsetValue != "" {
+		sunset, err := time.Parse("2006-01-02", sunsetValue)
+		if err != nil {
+			sunset, err = time.Parse(time.RFC3339, sunsetValue)
+		}
+		if err != nil {
+			logger.Log.Warn().Str("value", sunsetValue).Msg("Invalid AUCTION_V1_SUNSET, omitting Sunset header")
+		} else {
+			deprecation.Sunset = sunset
+		}
+	}
+	return deprecation
+}
+
+// registerCaches exposes caches that can serve stale data to /admin/cache/invalidate.
+// Publisher (account) registrations are read from Redis per request and need no invalidation.
+func registerCaches(m *invalidation.Manager, ex *exchange.Exchange, flagRegistry *flags.Registry, dynamicRegistry *ortb.DynamicRegistry, storedRequests *storedrequests.Resolver, accountStore *accounts.Store) {
+	// Patterns match publisher IDs
+	m.Register("idr_selection", func(_ context.Context, patterns []string) (int, error) {
+		return ex.InvalidateIDRCache(func(publisherID string) bool {
+			return invalidation.Matches(patterns, publisherID)
+		}), nil
+	})
+
+	// Overrides are re-read from Redis; patterns are ignored
+	m.Register("feature_flags", func(ctx context.Context, _ []string) (int, error) {
+		return 0, flagRegistry.Sync(ctx)
+	})
+
+	// All bidder configs are reloaded from Redis; patterns are ignored
+	if dynamicRegistry != nil {
+		m.Register("dynamic_registry", func(ctx context.Context, _ []string) (int, error) {
+			if err := dynamicRegistry.Refresh(ctx); err != nil {
+				return 0, err
+			}
+			return dynamicRegistry.Count(), nil
+		})
+	}
+
+	// Patterns match stored request and stored imp IDs
+	if storedRequests != nil {
+		m.Register("stored_requests", func(_ context.Context, patterns []string) (int, error) {
+			return storedRequests.Invalidate(func(id string) bool {
+				return invalidation.Matches(patterns, id)
+			}), nil
+		})
+	}
+
+	// Patterns match account (publisher) IDs
+	if accountStore != nil {
+		m.Register("accounts", func(_ context.Context, patterns []string) (int, error) {
+			return accountStore.Invalidate(func(id string) bool {
+				return invalidation.Matches(patterns, id)
+			}), nil
+		})
+	}
+}
+
+// registerDebugBidder adds the debug bidder to the default registry, configured from DEBUG_BIDDER_* env vars
+func registerDebugBidder() {
+	cfg := debugbidder.DefaultConfig()
+	cfg.CPM = getEnvFloatOrDefault("DEBUG_BIDDER_CPM", cfg.CPM)
+	if size := os.Getenv("DEBUG_BIDDER_SIZE"); size != "" {
+		if _, err := fmt.Sscanf(size, "%dx%d", &cfg.W, &cfg.H); err != nil {
+			logger.Log.Warn().Str("value", size).Msg("Invalid DEBUG_BIDDER_SIZE, expected WxH")
+		}
+	}
+	cfg.AdmTemplate = getEnvOrDefault("DEBUG_BIDDER_ADM_TEMPLATE", cfg.AdmTemplate)
+	cfg.Accounts = debugbidder.ParseAccounts(os.Getenv("DEBUG_BIDDER_ACCOUNTS"))
+
+	adapter, err := debugbidder.New(cfg)
+	if err != nil {
+		logger.Log.Fatal().Err(err).Msg("Failed to create debug bidder")
+	}
+	if err := adapters.RegisterAdapter(debugbidder.BidderCode, adapter, debugbidder.Info()); err != nil {
+		logger.Log.Fatal().Err(err).Msg("Failed to register debug bidder")
+	}
+	logger.Log.Info().
+		Float64("cpm", cfg.CPM).
+		Int("accounts", len(cfg.Accounts)).
+		Msg("Debug bidder enabled")
+}
+
 // getEnvOrDefault returns the environment variable value or a default
 func getEnvOrDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -362,3 +1270,30 @@ func getEnvBoolOrDefault(key string, defaultValue bool) bool {
 	}
 	return value == "true" || value == "1" || value == "yes"
 }
+
+// getEnvDurationOrDefault returns the environment variable as a duration (e.g. "5s") or a default
+func getEnvDurationOrDefault(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvFloatOrDefault returns the environment variable as float64 or a default
+func getEnvFloatOrDefault(key string, defaultValue float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvIntOrDefault returns the environment variable as int or a default
+func getEnvIntOrDefault(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
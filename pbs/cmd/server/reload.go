@@ -0,0 +1,85 @@
+package main
+
+import (
+	"os"
+	"sync"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/accounts"
+	pbsconfig "github.com/StreetsDigital/thenexusengine/pbs/internal/config"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/exchange"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/middleware"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/logger"
+)
+
+// configReloader applies the settings that are safe to change while serving
+// (auction timeout, privacy, rate limits and ACCOUNTS_DIR floors) on SIGHUP
+// or POST /admin/config/reload. Everything else needs a restart.
+type configReloader struct {
+	mu sync.Mutex // Serializes reloads
+
+	source          *pbsconfig.Source // nil without a config file
+	timeoutFlag     bool              // -timeout given, which a reload must not override
+	ex              *exchange.Exchange
+	privacy         *middleware.LivePrivacyConfig
+	rateLimiter     *middleware.RateLimiter
+	syncRateLimiter *middleware.SyncRateLimiter
+	accountStore    *accounts.Store
+	accountsDir     string // Set when accounts were loaded from ACCOUNTS_DIR
+}
+
+// Reload re-reads the config file and the accounts directory, then hands
+// their live settings to the running components. Nothing changes if either
+// is invalid.
+func (c *configReloader) Reload() (*pbsconfig.ReloadResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Read first so a bad file aborts the reload before anything is applied
+	var accountFiles *accounts.DirFetcher
+	if c.accountsDir != "" {
+		var err error
+		if accountFiles, err = accounts.LoadDir(c.accountsDir); err != nil {
+			logger.Log.Error().Err(err).Str("dir", c.accountsDir).Msg("Config reload failed")
+			return nil, err
+		}
+	}
+
+	result := &pbsconfig.ReloadResult{Applied: []string{}}
+	if c.source != nil {
+		var err error
+		if result, err = c.source.Reload(); err != nil {
+			logger.Log.Error().Err(err).Str("path", c.source.Path()).Msg("Config reload failed")
+			return nil, err
+		}
+	}
+
+	if !c.timeoutFlag {
+		c.ex.SetDefaultTimeout(getEnvDurationOrDefault("PBS_AUCTION_TIMEOUT", pbsconfig.DefaultAuctionTimeout))
+	}
+
+	// Metrics and geo resolution are wired at startup, not read from the environment
+	current := c.privacy.Load()
+	privacyConfig := middleware.DefaultPrivacyConfig()
+	if os.Getenv("PBS_DISABLE_GDPR_ENFORCEMENT") == "true" {
+		privacyConfig.EnforceGDPR = false
+	}
+	privacyConfig.Metrics = current.Metrics
+	privacyConfig.GeoResolver = current.GeoResolver
+	c.privacy.Store(privacyConfig)
+
+	rateLimitConfig := middleware.DefaultRateLimitConfig()
+	c.rateLimiter.SetLimits(rateLimitConfig.Enabled, rateLimitConfig.RequestsPerSecond, rateLimitConfig.BurstSize)
+	syncRateLimitConfig := middleware.DefaultSyncRateLimitConfig()
+	c.syncRateLimiter.SetLimits(syncRateLimitConfig.Enabled, syncRateLimitConfig.PerIPLimit, syncRateLimitConfig.PerPublisherLimit)
+
+	event := logger.Log.Info().
+		Strs("applied", result.Applied).
+		Strs("restart_required", result.RestartRequired).
+		Strs("env_overrides", result.Overridden)
+	if accountFiles != nil {
+		c.accountStore.SetFetcher(accountFiles)
+		event = event.Int("accounts", accountFiles.Len())
+	}
+	event.Msg("Config reloaded")
+	return result, nil
+}
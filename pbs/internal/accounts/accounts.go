@@ -0,0 +1,273 @@
+// Package accounts loads per-publisher account configuration: the bidders a
+// publisher may use, its default auction timeout, price floors, whether it
+// may debug auctions and how its auctions are recorded. Accounts are keyed
+// by publisher ID and read from a Redis hash or a directory of JSON files.
+// The exchange applies an account over its global configuration; publishers
+// without one get the global configuration.
+package accounts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/idr"
+)
+
+// RedisAccountsHash is the Redis hash of account ID -> account JSON
+const RedisAccountsHash = "nexus:accounts"
+
+// DefaultCacheTTL is how long a looked-up account is used before re-reading it
+const DefaultCacheTTL = time.Minute
+
+// maxTimeoutMS matches the exchange's tmax upper bound
+const maxTimeoutMS = 10000
+
+// maxCachedAccounts bounds the cache, which also remembers unknown IDs
+const maxCachedAccounts = 10000
+
+// Account is one publisher's configuration. Unset fields fall back to the
+// exchange's global configuration.
+type Account struct {
+	ID             string                `json:"id"`
+	AllowedBidders []string              `json:"allowed_bidders,omitempty"` // Empty allows every bidder
+	TimeoutMS      int                   `json:"timeout_ms,omitempty"`      // Auction timeout for requests without tmax
+	Floors         *Floors               `json:"floors,omitempty"`
+	DebugAllowed   *bool                 `json:"debug_allowed,omitempty"` // false ignores debug requests for the account
+	Events         *idr.AccountRecording `json:"events,omitempty"`        // Event recording opt-out and sampling
+}
+
+// Floors are account price floors in the exchange's default currency
+type Floors struct {
+	Default float64 `json:"default,omitempty"` // Floor for imps that set none
+	Min     float64 `json:"min,omitempty"`     // Imp floors below this are raised to it
+}
+
+// AllowsBidder reports whether the account may use bidderCode
+func (a *Account) AllowsBidder(bidderCode string) bool {
+	if len(a.AllowedBidders) == 0 {
+		return true
+	}
+	for _, code := range a.AllowedBidders {
+		if strings.EqualFold(code, bidderCode) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsDebug reports whether the account may run debug auctions
+func (a *Account) AllowsDebug() bool {
+	return a.DebugAllowed == nil || *a.DebugAllowed
+}
+
+// Validate checks the account's values are in range
+func (a *Account) Validate() error {
+	if a.ID == "" {
+		return fmt.Errorf("account id is required")
+	}
+	if a.TimeoutMS < 0 || a.TimeoutMS > maxTimeoutMS {
+		return fmt.Errorf("account %s: timeout_ms must be between 0 and %d, got %d", a.ID, maxTimeoutMS, a.TimeoutMS)
+	}
+	if f := a.Floors; f != nil {
+		if f.Default < 0 || f.Min < 0 || math.IsNaN(f.Default) || math.IsNaN(f.Min) {
+			return fmt.Errorf("account %s: floors must not be negative", a.ID)
+		}
+	}
+	if e := a.Events; e != nil && e.SampleRate != nil {
+		if rate := *e.SampleRate; math.IsNaN(rate) || rate < 0 || rate > 1 {
+			return fmt.Errorf("account %s: events sample_rate must be between 0 and 1, got %v", a.ID, rate)
+		}
+	}
+	return nil
+}
+
+// parseAccount decodes and validates an account; id is used when the JSON names none
+func parseAccount(id string, data []byte) (*Account, error) {
+	var account Account
+	if err := json.Unmarshal(data, &account); err != nil {
+		return nil, fmt.Errorf("account %s: %w", id, err)
+	}
+	if account.ID == "" {
+		account.ID = id
+	}
+	if err := account.Validate(); err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// NotFoundError is returned for publishers without an account
+type NotFoundError struct {
+	ID string
+}
+
+func (e *NotFoundError) Error() string {
+	return "account not found: " + e.ID
+}
+
+// Fetcher loads an account by ID, returning a *NotFoundError for unknown IDs
+type Fetcher interface {
+	Fetch(ctx context.Context, id string) (*Account, error)
+}
+
+// RedisClient is the subset of the Redis client the fetcher uses
+type RedisClient interface {
+	HGet(ctx context.Context, key, field string) (string, error)
+}
+
+// RedisFetcher reads accounts from RedisAccountsHash
+type RedisFetcher struct {
+	client RedisClient
+}
+
+// NewRedisFetcher creates a fetcher reading RedisAccountsHash
+func NewRedisFetcher(client RedisClient) *RedisFetcher {
+	return &RedisFetcher{client: client}
+}
+
+// Fetch implements Fetcher
+func (f *RedisFetcher) Fetch(ctx context.Context, id string) (*Account, error) {
+	value, err := f.client.HGet(ctx, RedisAccountsHash, id)
+	if err != nil {
+		return nil, fmt.Errorf("fetch account %s: %w", id, err)
+	}
+	if value == "" {
+		return nil, &NotFoundError{ID: id}
+	}
+	return parseAccount(id, []byte(value))
+}
+
+// DirFetcher serves accounts loaded from a directory of JSON files
+type DirFetcher struct {
+	accounts map[string]*Account
+}
+
+// LoadDir loads every *.json file in dir as an account. An account without
+// an "id" takes its file name, so pub-1.json configures publisher pub-1.
+func LoadDir(dir string) (*DirFetcher, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	f := &DirFetcher{accounts: make(map[string]*Account, len(paths))}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		account, err := parseAccount(strings.TrimSuffix(filepath.Base(path), ".json"), data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if _, dup := f.accounts[account.ID]; dup {
+			return nil, fmt.Errorf("%s: duplicate account %s", path, account.ID)
+		}
+		f.accounts[account.ID] = account
+	}
+	return f, nil
+}
+
+// Len returns the number of loaded accounts
+func (f *DirFetcher) Len() int {
+	return len(f.accounts)
+}
+
+// Fetch implements Fetcher
+func (f *DirFetcher) Fetch(ctx context.Context, id string) (*Account, error) {
+	account, ok := f.accounts[id]
+	if !ok {
+		return nil, &NotFoundError{ID: id}
+	}
+	return account, nil
+}
+
+// cacheEntry is a looked-up account, nil for an unknown ID
+type cacheEntry struct {
+	account *Account
+	expires time.Time
+}
+
+// Store looks up accounts through a Fetcher, caching results (including
+// unknown IDs, so publishers without an account don't hit Redis per auction)
+type Store struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu         sync.Mutex
+	fetcher    Fetcher
+	generation int // Bumped by SetFetcher
+	entries    map[string]cacheEntry
+}
+
+// New creates a store caching what fetcher loads for ttl (DefaultCacheTTL when 0)
+func New(fetcher Fetcher, ttl time.Duration) *Store {
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	return &Store{fetcher: fetcher, ttl: ttl, now: time.Now, entries: make(map[string]cacheEntry)}
+}
+
+// Account returns the account for a publisher ID, or a *NotFoundError
+func (s *Store) Account(ctx context.Context, id string) (*Account, error) {
+	s.mu.Lock()
+	entry, ok := s.entries[id]
+	s.mu.Unlock()
+	if ok && s.now().Before(entry.expires) {
+		if entry.account == nil {
+			return nil, &NotFoundError{ID: id}
+		}
+		return entry.account, nil
+	}
+
+	s.mu.Lock()
+	fetcher, generation := s.fetcher, s.generation
+	s.mu.Unlock()
+	account, err := fetcher.Fetch(ctx, id)
+	var notFound *NotFoundError
+	if err != nil && !errors.As(err, &notFound) {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if len(s.entries) >= maxCachedAccounts {
+		s.entries = make(map[string]cacheEntry)
+	}
+	if s.generation == generation { // Fetcher not replaced mid-fetch
+		s.entries[id] = cacheEntry{account: account, expires: s.now().Add(s.ttl)}
+	}
+	s.mu.Unlock()
+	return account, err
+}
+
+// SetFetcher replaces where accounts are loaded from, e.g. with a re-read
+// accounts directory, and drops every cached account
+func (s *Store) SetFetcher(fetcher Fetcher) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fetcher = fetcher
+	s.generation++
+	s.entries = make(map[string]cacheEntry)
+}
+
+// Invalidate drops cached accounts whose ID matches, so the next lookup
+// re-reads them. Returns the number dropped.
+func (s *Store) Invalidate(match func(id string) bool) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	dropped := 0
+	for id := range s.entries {
+		if match(id) {
+			delete(s.entries, id)
+			dropped++
+		}
+	}
+	return dropped
+}
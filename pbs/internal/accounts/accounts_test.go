@@ -0,0 +1,177 @@
+package accounts
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/idr"
+)
+
+type mockRedis struct {
+	hashes map[string]map[string]string
+	err    error
+	gets   int
+}
+
+func (m *mockRedis) HGet(ctx context.Context, key, field string) (string, error) {
+	m.gets++
+	if m.err != nil {
+		return "", m.err
+	}
+	return m.hashes[key][field], nil
+}
+
+func TestAccount_Rules(t *testing.T) {
+	denied := false
+	a := &Account{ID: "pub1", AllowedBidders: []string{"appnexus", "Rubicon"}, DebugAllowed: &denied}
+	if !a.AllowsBidder("rubicon") || a.AllowsBidder("pubmatic") {
+		t.Error("expected only the allow-listed bidders, case-insensitively")
+	}
+	if a.AllowsDebug() {
+		t.Error("expected debug denied")
+	}
+
+	open := &Account{ID: "pub2"}
+	if !open.AllowsBidder("pubmatic") || !open.AllowsDebug() {
+		t.Error("expected an account without settings to allow everything")
+	}
+}
+
+func TestAccount_Validate(t *testing.T) {
+	rate := 1.5
+	tests := []struct {
+		name    string
+		account Account
+		want    string
+	}{
+		{name: "no id", account: Account{}, want: "id is required"},
+		{name: "timeout", account: Account{ID: "a", TimeoutMS: 20000}, want: "timeout_ms"},
+		{name: "negative floor", account: Account{ID: "a", Floors: &Floors{Min: -1}}, want: "floors"},
+		{name: "sample rate", account: Account{ID: "a", Events: &idr.AccountRecording{SampleRate: &rate}}, want: "sample_rate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.account.Validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestRedisFetcher(t *testing.T) {
+	f := NewRedisFetcher(&mockRedis{hashes: map[string]map[string]string{
+		RedisAccountsHash: {
+			"pub1":   `{"allowed_bidders":["appnexus"],"timeout_ms":800}`,
+			"broken": `{"timeout_ms":-1}`,
+		},
+	}})
+	ctx := context.Background()
+
+	a, err := f.Fetch(ctx, "pub1")
+	if err != nil || a.ID != "pub1" || a.TimeoutMS != 800 || len(a.AllowedBidders) != 1 {
+		t.Errorf("unexpected account %+v (%v)", a, err)
+	}
+	var notFound *NotFoundError
+	if _, err := f.Fetch(ctx, "nope"); !errors.As(err, &notFound) {
+		t.Errorf("expected not found, got %v", err)
+	}
+	if _, err := f.Fetch(ctx, "broken"); err == nil || errors.As(err, &notFound) {
+		t.Errorf("expected a validation error, got %v", err)
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("pub-1.json", `{"floors":{"default":0.5}}`)
+	write("other.json", `{"id":"pub-2","debug_allowed":false}`)
+	write("notes.txt", `not an account`)
+
+	f, err := LoadDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if f.Len() != 2 {
+		t.Errorf("expected 2 accounts, got %d", f.Len())
+	}
+	if a, err := f.Fetch(context.Background(), "pub-1"); err != nil || a.Floors.Default != 0.5 {
+		t.Errorf("expected pub-1 named after its file, got %+v (%v)", a, err)
+	}
+	if a, err := f.Fetch(context.Background(), "pub-2"); err != nil || a.AllowsDebug() {
+		t.Errorf("expected pub-2 from its id field, got %+v (%v)", a, err)
+	}
+
+	write("dup.json", `{"id":"pub-1"}`)
+	if _, err := LoadDir(dir); err == nil || !strings.Contains(err.Error(), "duplicate account pub-1") {
+		t.Errorf("expected a duplicate account error, got %v", err)
+	}
+}
+
+func TestStore_Caches(t *testing.T) {
+	redis := &mockRedis{hashes: map[string]map[string]string{RedisAccountsHash: {"pub1": `{"timeout_ms":800}`}}}
+	s := New(NewRedisFetcher(redis), time.Minute)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if a, err := s.Account(ctx, "pub1"); err != nil || a.TimeoutMS != 800 {
+			t.Fatalf("unexpected account %+v (%v)", a, err)
+		}
+		var notFound *NotFoundError
+		if _, err := s.Account(ctx, "unknown"); !errors.As(err, &notFound) {
+			t.Fatalf("expected not found, got %v", err)
+		}
+	}
+	if redis.gets != 2 {
+		t.Errorf("expected one read each for the known and unknown account, got %d", redis.gets)
+	}
+
+	if dropped := s.Invalidate(func(id string) bool { return id == "pub1" }); dropped != 1 {
+		t.Errorf("expected pub1 dropped, got %d", dropped)
+	}
+	s.Account(ctx, "pub1")
+	now = now.Add(2 * time.Minute)
+	s.Account(ctx, "unknown")
+	if redis.gets != 4 {
+		t.Errorf("expected re-reads after invalidation and expiry, got %d reads", redis.gets)
+	}
+}
+
+func TestStore_SetFetcher(t *testing.T) {
+	before := &mockRedis{hashes: map[string]map[string]string{RedisAccountsHash: {"pub1": `{"timeout_ms":800}`}}}
+	after := &mockRedis{hashes: map[string]map[string]string{RedisAccountsHash: {"pub1": `{"timeout_ms":300}`}}}
+	s := New(NewRedisFetcher(before), time.Minute)
+	ctx := context.Background()
+
+	s.Account(ctx, "pub1")
+	s.SetFetcher(NewRedisFetcher(after))
+	if a, err := s.Account(ctx, "pub1"); err != nil || a.TimeoutMS != 300 {
+		t.Errorf("expected the cached account dropped and re-read, got %+v (%v)", a, err)
+	}
+	if before.gets != 1 || after.gets != 1 {
+		t.Errorf("unexpected reads: %d before, %d after", before.gets, after.gets)
+	}
+}
+
+func TestStore_FetchError(t *testing.T) {
+	redis := &mockRedis{err: errors.New("redis down")}
+	s := New(NewRedisFetcher(redis), time.Minute)
+	for i := 0; i < 2; i++ {
+		if _, err := s.Account(context.Background(), "pub1"); err == nil || !strings.Contains(err.Error(), "redis down") {
+			t.Errorf("expected the fetch error, got %v", err)
+		}
+	}
+	if redis.gets != 2 {
+		t.Errorf("expected failures not cached, got %d reads", redis.gets)
+	}
+}
@@ -3,15 +3,19 @@ package adapters
 
 import (
 	"context"
-	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"io"
-	"net"
 	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/jsoncodec"
 	"github.com/StreetsDigital/thenexusengine/pbs/pkg/logger"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/tracing"
 )
 
 // maxResponseSize limits bidder response size to prevent OOM attacks
@@ -19,7 +23,8 @@ const maxResponseSize = 1024 * 1024 // 1MB
 
 // Adapter defines the interface for bidder adapters
 type Adapter interface {
-	// MakeRequests builds HTTP requests for the bidder
+	// MakeRequests builds HTTP requests for the bidder. The request shares
+	// memory with other bidders' requests and must not be modified.
 	MakeRequests(request *openrtb.BidRequest, extraInfo *ExtraRequestInfo) ([]*RequestData, []error)
 
 	// MakeBids parses bidder responses into bids
@@ -71,6 +76,10 @@ type TypedBid struct {
 	BidVideo     *BidVideo
 	BidMeta      *openrtb.ExtBidPrebidMeta
 	DealPriority int
+
+	// Set when the exchange converted the bid from another currency
+	OriginalCPM      float64
+	OriginalCurrency string
 }
 
 // BidType represents the type of bid
@@ -113,7 +122,86 @@ type BidderInfo struct {
 	Syncer                  *SyncerInfo
 	Endpoint                string
 	ExtraInfo               string
-	DemandType              DemandType // platform (obfuscated) or publisher (transparent)
+	DemandType              DemandType      // platform (obfuscated) or publisher (transparent)
+	MaxImpsPerRequest       int             // Split requests with more imps into batches (0 = unlimited)
+	IgnoredFields           []string        // Request fields the bidder doesn't read (see IgnorableFields)
+	SupportsCOPPA           bool            // Handles child-directed (regs.coppa=1) traffic
+	UnsupportedInventory    []string        // Inventory the bidder doesn't buy (see InventoryKinds)
+	EIDSources              []string        // user.eids sources the bidder may receive; empty allows every source
+	ParamsSchema            json.RawMessage // JSON schema for the bidder's imp params; nil when it takes none
+}
+
+// Request fields a bidder can list in BidderInfo.IgnoredFields. With request
+// shaping on, the exchange leaves them out of that bidder's requests.
+const (
+	IgnoredSiteContent     = "site.content"
+	IgnoredAppContent      = "app.content"
+	IgnoredDeviceExt       = "device.ext"
+	IgnoredDeviceLegacyIDs = "device.legacy_ids" // didsha1, didmd5, dpidsha1, dpidmd5, macsha1, macmd5
+	IgnoredUserData        = "user.data"
+)
+
+// IgnorableFields lists every value BidderInfo.IgnoredFields accepts
+var IgnorableFields = []string{
+	IgnoredSiteContent,
+	IgnoredAppContent,
+	IgnoredDeviceExt,
+	IgnoredDeviceLegacyIDs,
+	IgnoredUserData,
+}
+
+// Inventory a bidder can list in BidderInfo.UnsupportedInventory. The exchange
+// leaves imps of those kinds out of that bidder's requests.
+const (
+	InventoryInterstitial = "interstitial" // imp.instl=1
+	InventoryRewarded     = "rewarded"     // imp.rwdd=1
+)
+
+// InventoryKinds lists every value BidderInfo.UnsupportedInventory accepts
+var InventoryKinds = []string{
+	InventoryInterstitial,
+	InventoryRewarded,
+}
+
+// SupportsInventory reports whether the bidder buys an inventory kind
+func (i BidderInfo) SupportsInventory(kind string) bool {
+	return !slices.Contains(i.UnsupportedInventory, kind)
+}
+
+// AllowsEIDSource reports whether the bidder may receive EIDs from a source
+func (i BidderInfo) AllowsEIDSource(source string) bool {
+	if len(i.EIDSources) == 0 {
+		return true
+	}
+	source = strings.TrimSpace(source)
+	for _, allowed := range i.EIDSources {
+		if strings.EqualFold(strings.TrimSpace(allowed), source) {
+			return true
+		}
+	}
+	return false
+}
+
+// SupportsMediaType reports whether the bidder accepts a media type on site or app
+// traffic. Bidders that declare no media types for the platform are assumed to
+// accept everything, so only an explicit list can exclude a type.
+func (i BidderInfo) SupportsMediaType(isApp bool, mediaType BidType) bool {
+	if i.Capabilities == nil {
+		return true
+	}
+	platform := i.Capabilities.Site
+	if isApp {
+		platform = i.Capabilities.App
+	}
+	if platform == nil || len(platform.MediaTypes) == 0 {
+		return true
+	}
+	for _, mt := range platform.MediaTypes {
+		if mt == mediaType {
+			return true
+		}
+	}
+	return false
 }
 
 // MaintainerInfo contains maintainer info
@@ -170,48 +258,49 @@ type HTTPClient interface {
 
 // DefaultHTTPClient implements HTTPClient
 type DefaultHTTPClient struct {
-	client *http.Client
+	client         *http.Client
+	identification *Identification
+	metrics        atomic.Pointer[connectionMetrics] // nil until SetConnectionMetrics
 }
 
-// NewHTTPClient creates a new HTTP client with connection pooling
-// P1-14: Configure transport for high-performance connection reuse
-// Connection pooling reduces latency by reusing TCP connections and TLS sessions
-// for repeated requests to the same bidder endpoints.
-func NewHTTPClient(timeout time.Duration) *DefaultHTTPClient {
-	transport := &http.Transport{
-		// Connection pooling settings
-		MaxIdleConns:        100,              // Total idle connections across all hosts
-		MaxIdleConnsPerHost: 10,               // Idle connections per bidder endpoint
-		MaxConnsPerHost:     50,               // Max concurrent connections per host
-		IdleConnTimeout:     90 * time.Second, // Keep idle connections for 90s
-
-		// TLS session caching reduces handshake overhead for repeated connections
-		TLSClientConfig: &tls.Config{
-			ClientSessionCache: tls.NewLRUClientSessionCache(100),
-			MinVersion:         tls.VersionTLS12, // Require TLS 1.2+
-		},
+// connectionMetrics boxes a ConnectionMetrics for atomic swapping
+type connectionMetrics struct {
+	ConnectionMetrics
+}
 
-		// Timeouts for connection establishment
-		DialContext: (&net.Dialer{
-			Timeout:   5 * time.Second,  // Connection timeout
-			KeepAlive: 30 * time.Second, // TCP keepalive interval
-		}).DialContext,
-		TLSHandshakeTimeout:   5 * time.Second,
-		ExpectContinueTimeout: 1 * time.Second,
-		ResponseHeaderTimeout: 10 * time.Second,
-
-		// Disable compression to reduce latency (bidder responses are usually small)
-		DisableCompression: true,
-	}
+// NewHTTPClient creates a new HTTP client with the default connection pool
+func NewHTTPClient(timeout time.Duration) *DefaultHTTPClient {
+	return NewHTTPClientWithTransport(timeout, nil)
+}
 
+// NewHTTPClientWithTransport creates a new HTTP client whose connection pool
+// is tuned by transport (nil uses DefaultTransportConfig)
+// P1-14: Connection pooling reduces latency by reusing TCP connections and TLS
+// sessions for repeated requests to the same bidder endpoints.
+func NewHTTPClientWithTransport(timeout time.Duration, transport *TransportConfig) *DefaultHTTPClient {
 	return &DefaultHTTPClient{
 		client: &http.Client{
 			Timeout:   timeout,
-			Transport: transport,
+			Transport: newTransport(transport),
 		},
 	}
 }
 
+// SetIdentification sets the headers used to identify PBS on every bidder call
+// Must be called before the client is shared across goroutines
+func (c *DefaultHTTPClient) SetIdentification(id *Identification) {
+	c.identification = id
+}
+
+// SetConnectionMetrics reports connection reuse and TLS handshakes of later calls
+func (c *DefaultHTTPClient) SetConnectionMetrics(m ConnectionMetrics) {
+	if m == nil {
+		c.metrics.Store(nil)
+		return
+	}
+	c.metrics.Store(&connectionMetrics{m})
+}
+
 // Do executes an HTTP request with proper timeout handling
 func (c *DefaultHTTPClient) Do(ctx context.Context, req *RequestData, timeout time.Duration) (*ResponseData, error) {
 	// P1-3: Respect parent context deadline - use shorter of parent deadline or specified timeout
@@ -234,6 +323,9 @@ func (c *DefaultHTTPClient) Do(ctx context.Context, req *RequestData, timeout ti
 	if err != nil {
 		return nil, err
 	}
+	if m := c.metrics.Load(); m != nil {
+		httpReq = httpReq.WithContext(withConnectionTrace(ctx, httpReq.URL.Hostname(), m))
+	}
 
 	if len(req.Body) > 0 {
 		httpReq.Body = &bodyReader{data: req.Body}
@@ -243,6 +335,12 @@ func (c *DefaultHTTPClient) Do(ctx context.Context, req *RequestData, timeout ti
 	for k, v := range req.Headers {
 		httpReq.Header[k] = v
 	}
+	c.identification.Apply(httpReq.Header)
+	tracing.Inject(ctx, httpReq.Header)
+	// Bidders may compress responses; Do decompresses them within maxResponseSize
+	if httpReq.Header.Get("Accept-Encoding") == "" {
+		httpReq.Header.Set("Accept-Encoding", EncodingGzip)
+	}
 
 	resp, err := c.client.Do(httpReq)
 	if err != nil {
@@ -260,9 +358,15 @@ func (c *DefaultHTTPClient) Do(ctx context.Context, req *RequestData, timeout ti
 	// Single goroutine for the entire read operation
 	go func() {
 		defer resp.Body.Close()
-		// Read with size limit to prevent OOM from malicious bidders
-		limitedReader := io.LimitReader(resp.Body, maxResponseSize+1) // +1 to detect overflow
-		data, err := io.ReadAll(limitedReader)
+		body, err := decodedBody(resp)
+		if err != nil {
+			readCh <- readResult{err: fmt.Errorf("invalid gzip response: %w", err)}
+			return
+		}
+		// Read with size limit to prevent OOM from malicious bidders; for
+		// compressed responses the limit applies to the decompressed size
+		limitedReader := io.LimitReader(body, maxResponseSize+1) // +1 to detect overflow
+		data, err := jsoncodec.ReadAll(limitedReader)
 		readCh <- readResult{data: data, err: err}
 	}()
 
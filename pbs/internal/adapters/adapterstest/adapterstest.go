@@ -0,0 +1,364 @@
+// Package adapterstest runs bidder adapters against JSON sample files, so an
+// adapter can be validated from example traffic instead of hand-written tests.
+//
+// Each sample file holds a mock bid request, the HTTP calls the adapter is
+// expected to make (with a mock response for each), and the bids and errors
+// expected back:
+//
+//	{
+//	  "mockBidRequest": { ...OpenRTB request... },
+//	  "httpCalls": [{
+//	    "expectedRequest": {"method": "POST", "uri": "https://...", "body": {...}, "headers": {"Content-Type": ["application/json"]}},
+//	    "mockResponse": {"status": 200, "body": {...}}
+//	  }],
+//	  "expectedBidResponses": [{"currency": "USD", "bids": [{"bid": {...}, "type": "banner"}]}],
+//	  "expectedMakeRequestsErrors": [{"value": "...", "comparison": "literal"}],
+//	  "expectedMakeBidsErrors": [{"value": "unexpected status: \\d+", "comparison": "regex"}]
+//	}
+//
+// Samples live in the exemplary and supplemental subdirectories of an
+// adapter's test directory, e.g. appnexus/appnexustest. Exemplary samples
+// show typical traffic and must not produce errors; supplemental samples
+// cover edge cases and may.
+package adapterstest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/jsonschema"
+)
+
+// Sample directories under an adapter's test directory
+const (
+	ExemplaryDir    = "exemplary"
+	SupplementalDir = "supplemental"
+)
+
+// Error comparisons for expected errors
+const (
+	CompareLiteral = "literal" // The error message equals the value (the default)
+	CompareRegex   = "regex"   // The error message matches the value as a regular expression
+)
+
+// concurrentRuns is how many times each sample is replayed in parallel to
+// expose data races (under go test -race) and shared-state bugs
+const concurrentRuns = 4
+
+// Sample is one JSON sample file
+type Sample struct {
+	BidRequest         json.RawMessage       `json:"mockBidRequest"`
+	HTTPCalls          []HTTPCall            `json:"httpCalls"`
+	BidResponses       []ExpectedBidResponse `json:"expectedBidResponses"`
+	MakeRequestsErrors []ExpectedError       `json:"expectedMakeRequestsErrors"`
+	MakeBidsErrors     []ExpectedError       `json:"expectedMakeBidsErrors"`
+}
+
+// HTTPCall is a request the adapter should make and the response it gets
+type HTTPCall struct {
+	Request  ExpectedRequest `json:"expectedRequest"`
+	Response MockResponse    `json:"mockResponse"`
+}
+
+// ExpectedRequest is compared with the adapter's RequestData. The body is
+// compared as JSON; an empty method or body isn't checked. Only the listed
+// headers are checked, so adapters may send others.
+type ExpectedRequest struct {
+	Method  string          `json:"method"`
+	URI     string          `json:"uri"`
+	Body    json.RawMessage `json:"body"`
+	Headers http.Header     `json:"headers"`
+}
+
+// MockResponse is handed to MakeBids. The body is passed through as-is, so a
+// JSON string body can stand in for a malformed response.
+type MockResponse struct {
+	Status  int             `json:"status"`
+	Body    json.RawMessage `json:"body"`
+	Headers http.Header     `json:"headers"`
+}
+
+// ExpectedBidResponse is one non-nil BidderResponse from MakeBids
+type ExpectedBidResponse struct {
+	Currency string        `json:"currency"`
+	Bids     []ExpectedBid `json:"bids"`
+}
+
+// ExpectedBid is one TypedBid, with the bid compared as JSON
+type ExpectedBid struct {
+	Bid  json.RawMessage  `json:"bid"`
+	Type adapters.BidType `json:"type"`
+}
+
+// ExpectedError is an error message to match
+type ExpectedError struct {
+	Value      string `json:"value"`
+	Comparison string `json:"comparison"`
+}
+
+// RunJSONBidderTest runs every sample in dir's exemplary and supplemental
+// subdirectories against bidder, as a subtest per file. Each sample is also
+// replayed concurrently on a shared request, and fails if the adapter
+// modifies the request it was given.
+func RunJSONBidderTest(t *testing.T, dir string, bidder adapters.Adapter) {
+	t.Helper()
+	found := false
+	for _, sub := range []string{ExemplaryDir, SupplementalDir} {
+		files, err := filepath.Glob(filepath.Join(dir, sub, "*.json"))
+		if err != nil {
+			t.Fatalf("list samples: %v", err)
+		}
+		for _, file := range files {
+			found = true
+			exemplary := sub == ExemplaryDir
+			t.Run(sub+"/"+strings.TrimSuffix(filepath.Base(file), ".json"), func(t *testing.T) {
+				sample, err := LoadSample(file)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if exemplary && (len(sample.MakeRequestsErrors) > 0 || len(sample.MakeBidsErrors) > 0) {
+					t.Fatal("exemplary samples must not expect errors; move the file to " + SupplementalDir)
+				}
+				RunSample(t, sample, bidder)
+			})
+		}
+	}
+	if !found {
+		t.Fatalf("no samples in %s/%s or %s/%s", dir, ExemplaryDir, dir, SupplementalDir)
+	}
+}
+
+// RunParamsSchemaTest checks that a bidder's params schema compiles, accepts
+// every valid params document and rejects every invalid one
+func RunParamsSchemaTest(t *testing.T, info adapters.BidderInfo, valid, invalid []string) {
+	t.Helper()
+	schema, err := jsonschema.Compile(info.ParamsSchema)
+	if err != nil {
+		t.Fatalf("params schema: %v", err)
+	}
+	for _, params := range valid {
+		if err := schema.Validate([]byte(params)); err != nil {
+			t.Errorf("expected %s valid, got %v", params, err)
+		}
+	}
+	for _, params := range invalid {
+		if err := schema.Validate([]byte(params)); err == nil {
+			t.Errorf("expected %s rejected", params)
+		}
+	}
+}
+
+// LoadSample reads a sample file
+func LoadSample(path string) (*Sample, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var sample Sample
+	if err := json.Unmarshal(data, &sample); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return &sample, nil
+}
+
+// RunSample checks bidder against one sample
+func RunSample(t *testing.T, sample *Sample, bidder adapters.Adapter) {
+	t.Helper()
+	for _, failure := range runSample(sample, bidder) {
+		t.Error(failure)
+	}
+}
+
+// runSample runs the sample once, then replays it concurrently against one
+// shared request, as the exchange hands the same request to every bidder,
+// and returns the failures
+func runSample(sample *Sample, bidder adapters.Adapter) []string {
+	var request openrtb.BidRequest
+	if err := json.Unmarshal(sample.BidRequest, &request); err != nil {
+		return []string{fmt.Sprintf("invalid mockBidRequest: %v", err)}
+	}
+	before, _ := json.Marshal(&request)
+
+	failures := checkSample(sample, &request, bidder)
+	if after, _ := json.Marshal(&request); !bytes.Equal(before, after) {
+		// Replaying would only race on the writes
+		return append(failures, fmt.Sprintf("adapter modified the bid request:\nbefore %s\nafter  %s", before, after))
+	}
+
+	var shared openrtb.BidRequest
+	json.Unmarshal(sample.BidRequest, &shared)
+	runs := make([][]string, concurrentRuns)
+	var wg sync.WaitGroup
+	for i := range runs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			runs[i] = checkSample(sample, &shared, bidder)
+		}(i)
+	}
+	wg.Wait()
+	first := failures
+	for i, run := range runs {
+		if !reflect.DeepEqual(run, first) {
+			failures = append(failures, fmt.Sprintf("concurrent run %d disagrees with the first run: %v", i, run))
+		}
+	}
+	return failures
+}
+
+// checkSample runs the adapter once and returns what didn't match the sample
+func checkSample(sample *Sample, request *openrtb.BidRequest, bidder adapters.Adapter) []string {
+	var failures []string
+	fail := func(format string, args ...any) {
+		failures = append(failures, fmt.Sprintf(format, args...))
+	}
+
+	requests, errs := bidder.MakeRequests(request, &adapters.ExtraRequestInfo{})
+	if diff := compareErrors(sample.MakeRequestsErrors, errs); diff != "" {
+		fail("MakeRequests errors: %s", diff)
+	}
+	if len(requests) != len(sample.HTTPCalls) {
+		fail("expected %d requests, got %d", len(sample.HTTPCalls), len(requests))
+		return failures
+	}
+
+	var responses []*adapters.BidderResponse
+	errs = nil
+	for i, call := range sample.HTTPCalls {
+		if diff := compareRequest(call.Request, requests[i]); diff != "" {
+			fail("request %d: %s", i, diff)
+		}
+		resp, bidErrs := bidder.MakeBids(request, &adapters.ResponseData{
+			StatusCode: call.Response.Status,
+			Body:       call.Response.Body,
+			Headers:    call.Response.Headers,
+		})
+		if resp != nil {
+			responses = append(responses, resp)
+		}
+		errs = append(errs, bidErrs...)
+	}
+	if diff := compareErrors(sample.MakeBidsErrors, errs); diff != "" {
+		fail("MakeBids errors: %s", diff)
+	}
+
+	if len(responses) != len(sample.BidResponses) {
+		fail("expected %d bid responses, got %d", len(sample.BidResponses), len(responses))
+		return failures
+	}
+	for i, expected := range sample.BidResponses {
+		if diff := compareBidResponse(expected, responses[i]); diff != "" {
+			fail("bid response %d: %s", i, diff)
+		}
+	}
+	return failures
+}
+
+func compareRequest(expected ExpectedRequest, actual *adapters.RequestData) string {
+	if actual == nil {
+		return "nil request"
+	}
+	if expected.Method != "" && expected.Method != actual.Method {
+		return fmt.Sprintf("expected method %s, got %s", expected.Method, actual.Method)
+	}
+	if expected.URI != actual.URI {
+		return fmt.Sprintf("expected uri %s, got %s", expected.URI, actual.URI)
+	}
+	for name, values := range expected.Headers {
+		if got := actual.Headers.Values(name); !reflect.DeepEqual(values, got) {
+			return fmt.Sprintf("expected header %s %v, got %v", name, values, got)
+		}
+	}
+	if len(expected.Body) > 0 {
+		if diff := compareJSON(expected.Body, actual.Body); diff != "" {
+			return "body " + diff
+		}
+	}
+	return ""
+}
+
+func compareBidResponse(expected ExpectedBidResponse, actual *adapters.BidderResponse) string {
+	if expected.Currency != actual.Currency {
+		return fmt.Sprintf("expected currency %q, got %q", expected.Currency, actual.Currency)
+	}
+	if len(expected.Bids) != len(actual.Bids) {
+		return fmt.Sprintf("expected %d bids, got %d", len(expected.Bids), len(actual.Bids))
+	}
+	for i, bid := range expected.Bids {
+		got := actual.Bids[i]
+		if got == nil || got.Bid == nil {
+			return fmt.Sprintf("bid %d is nil", i)
+		}
+		if bid.Type != got.BidType {
+			return fmt.Sprintf("bid %d: expected type %s, got %s", i, bid.Type, got.BidType)
+		}
+		gotJSON, err := json.Marshal(got.Bid)
+		if err != nil {
+			return fmt.Sprintf("bid %d: %v", i, err)
+		}
+		if diff := compareJSON(bid.Bid, gotJSON); diff != "" {
+			return fmt.Sprintf("bid %d %s", i, diff)
+		}
+	}
+	return ""
+}
+
+// compareJSON compares two JSON documents regardless of formatting and key order
+func compareJSON(expected, actual []byte) string {
+	var want, got any
+	if err := json.Unmarshal(expected, &want); err != nil {
+		return fmt.Sprintf("expected value is not JSON: %v", err)
+	}
+	if err := json.Unmarshal(actual, &got); err != nil {
+		return fmt.Sprintf("is not JSON: %v", err)
+	}
+	if !reflect.DeepEqual(want, got) {
+		return fmt.Sprintf("differs:\nexpected %s\ngot      %s", compact(expected), compact(actual))
+	}
+	return ""
+}
+
+func compact(data []byte) string {
+	var buf bytes.Buffer
+	if json.Compact(&buf, data) != nil {
+		return string(data)
+	}
+	return buf.String()
+}
+
+func compareErrors(expected []ExpectedError, actual []error) string {
+	if len(expected) != len(actual) {
+		return fmt.Sprintf("expected %d errors, got %d: %v", len(expected), len(actual), actual)
+	}
+	for i, want := range expected {
+		got := actual[i].Error()
+		switch want.Comparison {
+		case "", CompareLiteral:
+			if got != want.Value {
+				return fmt.Sprintf("error %d: expected %q, got %q", i, want.Value, got)
+			}
+		case CompareRegex:
+			matched, err := regexp.MatchString(want.Value, got)
+			if err != nil {
+				return fmt.Sprintf("error %d: invalid pattern %q: %v", i, want.Value, err)
+			}
+			if !matched {
+				return fmt.Sprintf("error %d: %q doesn't match %q", i, got, want.Value)
+			}
+		default:
+			return fmt.Sprintf("error %d: unknown comparison %q", i, want.Comparison)
+		}
+	}
+	return ""
+}
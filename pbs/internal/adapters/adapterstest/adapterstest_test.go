@@ -0,0 +1,138 @@
+package adapterstest
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+func fakeAdapter() adapters.Adapter {
+	return adapters.NewSimpleAdapter("fake", "https://fake.example/bid", "")
+}
+
+// mutatingAdapter writes to the shared request, as an adapter must not
+type mutatingAdapter struct{ adapters.Adapter }
+
+func (a mutatingAdapter) MakeRequests(request *openrtb.BidRequest, extra *adapters.ExtraRequestInfo) ([]*adapters.RequestData, []error) {
+	request.Imp[0].TagID = "changed"
+	return a.Adapter.MakeRequests(request, extra)
+}
+
+// statefulAdapter fails every call after the first
+type statefulAdapter struct {
+	adapters.Adapter
+	calls atomic.Int32
+}
+
+func (a *statefulAdapter) MakeRequests(request *openrtb.BidRequest, extra *adapters.ExtraRequestInfo) ([]*adapters.RequestData, []error) {
+	if a.calls.Add(1) > 1 {
+		return nil, []error{errors.New("already used")}
+	}
+	return a.Adapter.MakeRequests(request, extra)
+}
+
+func TestRunJSONBidderTest(t *testing.T) {
+	RunJSONBidderTest(t, "testdata", fakeAdapter())
+}
+
+func TestRunSample_Failures(t *testing.T) {
+	load := func(t *testing.T) *Sample {
+		sample, err := LoadSample("testdata/exemplary/simple.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sample
+	}
+	tests := []struct {
+		name    string
+		edit    func(*Sample)
+		bidder  adapters.Adapter
+		wantErr string
+	}{
+		{
+			name:    "uri",
+			edit:    func(s *Sample) { s.HTTPCalls[0].Request.URI = "https://other.example" },
+			wantErr: "request 0: expected uri https://other.example",
+		},
+		{
+			name:    "header",
+			edit:    func(s *Sample) { s.HTTPCalls[0].Request.Headers.Set("Accept", "text/html") },
+			wantErr: "expected header Accept [text/html]",
+		},
+		{
+			name:    "body",
+			edit:    func(s *Sample) { s.HTTPCalls[0].Request.Body = []byte(`{"id":"req-2"}`) },
+			wantErr: "request 0: body differs",
+		},
+		{
+			name:    "bid type",
+			edit:    func(s *Sample) { s.BidResponses[0].Bids[0].Type = adapters.BidTypeBanner },
+			wantErr: "bid 0: expected type banner, got native",
+		},
+		{
+			name:    "bid",
+			edit:    func(s *Sample) { s.BidResponses[0].Bids[0].Bid = []byte(`{"id":"b1","impid":"imp-1","price":3}`) },
+			wantErr: "bid response 0: bid 0 differs",
+		},
+		{
+			name:    "unexpected error",
+			edit:    func(s *Sample) { s.HTTPCalls[0].Response.Status = 500 },
+			wantErr: "MakeBids errors: expected 0 errors, got 1",
+		},
+		{
+			name:    "request count",
+			edit:    func(s *Sample) { s.HTTPCalls = append(s.HTTPCalls, s.HTTPCalls[0]) },
+			wantErr: "expected 2 requests, got 1",
+		},
+		{
+			name:    "modified request",
+			bidder:  mutatingAdapter{fakeAdapter()},
+			wantErr: "adapter modified the bid request",
+		},
+		{
+			name:    "shared state",
+			bidder:  &statefulAdapter{Adapter: fakeAdapter()},
+			wantErr: "concurrent run 0 disagrees with the first run",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sample := load(t)
+			if tt.edit != nil {
+				tt.edit(sample)
+			}
+			bidder := tt.bidder
+			if bidder == nil {
+				bidder = fakeAdapter()
+			}
+			failures := strings.Join(runSample(sample, bidder), "\n")
+			if !strings.Contains(failures, tt.wantErr) {
+				t.Errorf("expected a failure containing %q, got %q", tt.wantErr, failures)
+			}
+		})
+	}
+}
+
+func TestCompareErrors(t *testing.T) {
+	errs := []error{errors.New("unexpected status: 503")}
+	tests := []struct {
+		expected ExpectedError
+		match    bool
+	}{
+		{ExpectedError{Value: "unexpected status: 503"}, true},
+		{ExpectedError{Value: "unexpected status: 5\\d\\d", Comparison: CompareRegex}, true},
+		{ExpectedError{Value: "unexpected status: 5\\d\\d"}, false},
+		{ExpectedError{Value: "^status", Comparison: CompareRegex}, false},
+		{ExpectedError{Value: "(", Comparison: CompareRegex}, false},
+		{ExpectedError{Value: "unexpected status: 503", Comparison: "prefix"}, false},
+	}
+	for _, tt := range tests {
+		if diff := compareErrors([]ExpectedError{tt.expected}, errs); (diff == "") != tt.match {
+			t.Errorf("%+v: expected match %v, got %q", tt.expected, tt.match, diff)
+		}
+	}
+}
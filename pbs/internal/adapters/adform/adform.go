@@ -2,11 +2,11 @@
 package adform
 
 import (
-	"encoding/json"
 	"net/http"
 
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/jsoncodec"
 )
 
 const defaultEndpoint = "https://adx.adform.net/adx/openrtb"
@@ -21,7 +21,7 @@ func New(endpoint string) *Adapter {
 }
 
 func (a *Adapter) MakeRequests(request *openrtb.BidRequest, extraInfo *adapters.ExtraRequestInfo) ([]*adapters.RequestData, []error) {
-	body, err := json.Marshal(request)
+	body, err := jsoncodec.Marshal(request)
 	if err != nil {
 		return nil, []error{err}
 	}
@@ -35,7 +35,7 @@ func (a *Adapter) MakeBids(request *openrtb.BidRequest, responseData *adapters.R
 		return nil, nil
 	}
 	var bidResp openrtb.BidResponse
-	if err := json.Unmarshal(responseData.Body, &bidResp); err != nil {
+	if err := jsoncodec.Unmarshal(responseData.Body, &bidResp); err != nil {
 		return nil, []error{err}
 	}
 	response := &adapters.BidderResponse{Currency: bidResp.Cur, ResponseID: bidResp.ID, Bids: make([]*adapters.TypedBid, 0)}
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters/adapterstest"
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
 )
 
@@ -227,3 +228,7 @@ func TestInfo(t *testing.T) {
 	}
 }
 
+
+func TestJSONSamples(t *testing.T) {
+	adapterstest.RunJSONBidderTest(t, "appnexustest", New(""))
+}
@@ -0,0 +1,43 @@
+package adapters
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// EncodingGzip is the Content-Encoding of gzip-compressed bodies
+const EncodingGzip = "gzip"
+
+// GzipBody compresses a request body for bidders that accept gzip; the caller
+// sets Content-Encoding: gzip on the request
+func GzipBody(body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(body); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodedBody returns a reader of resp's body with any gzip content encoding
+// removed. Headers describing the encoded body are dropped from resp so the
+// adapter sees the response as if it had been sent uncompressed.
+func decodedBody(resp *http.Response) (io.Reader, error) {
+	if !strings.EqualFold(resp.Header.Get("Content-Encoding"), EncodingGzip) {
+		return resp.Body, nil
+	}
+	zr, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	resp.Header = resp.Header.Clone()
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	return zr, nil
+}
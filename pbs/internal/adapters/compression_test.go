@@ -0,0 +1,87 @@
+package adapters
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGzipBody(t *testing.T) {
+	body := []byte(`{"id":"req-1","imp":[{"id":"1"}]}`)
+	compressed, err := GzipBody(body)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatalf("expected gzip output: %v", err)
+	}
+	if got, _ := io.ReadAll(zr); !bytes.Equal(got, body) {
+		t.Errorf("expected the body back, got %s", got)
+	}
+}
+
+func gzipServer(t *testing.T, body []byte) *httptest.Server {
+	t.Helper()
+	compressed, err := GzipBody(body)
+	if err != nil {
+		t.Fatalf("compress failed: %v", err)
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept-Encoding") != "gzip" {
+			w.WriteHeader(http.StatusNotAcceptable)
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(compressed)
+	}))
+}
+
+func TestHTTPClientDo_GzipResponse(t *testing.T) {
+	body := []byte(`{"id":"req-1","seatbid":[]}`)
+	server := gzipServer(t, body)
+	defer server.Close()
+
+	resp, err := NewHTTPClient(5*time.Second).Do(context.Background(), &RequestData{Method: "POST", URI: server.URL}, time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || !bytes.Equal(resp.Body, body) {
+		t.Fatalf("expected the decompressed body, got %d %s", resp.StatusCode, resp.Body)
+	}
+	if resp.Headers.Get("Content-Encoding") != "" || resp.Headers.Get("Content-Type") != "application/json" {
+		t.Errorf("expected only the encoding headers dropped, got %v", resp.Headers)
+	}
+}
+
+func TestHTTPClientDo_GzipResponseTooLarge(t *testing.T) {
+	// Compresses to a few KB but expands past maxResponseSize
+	server := gzipServer(t, []byte(strings.Repeat(" ", maxResponseSize+1)))
+	defer server.Close()
+
+	_, err := NewHTTPClient(5*time.Second).Do(context.Background(), &RequestData{Method: "POST", URI: server.URL}, time.Second)
+	if err == nil || !strings.Contains(err.Error(), "response too large") {
+		t.Errorf("expected the decompressed size limited, got %v", err)
+	}
+}
+
+func TestHTTPClientDo_InvalidGzip(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write([]byte("not gzip"))
+	}))
+	defer server.Close()
+
+	_, err := NewHTTPClient(5*time.Second).Do(context.Background(), &RequestData{Method: "POST", URI: server.URL}, time.Second)
+	if err == nil || !strings.Contains(err.Error(), "invalid gzip response") {
+		t.Errorf("expected an invalid gzip error, got %v", err)
+	}
+}
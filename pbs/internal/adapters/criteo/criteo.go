@@ -3,14 +3,51 @@ package criteo
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/jsoncodec"
 )
 
-const defaultEndpoint = "https://bidder.criteo.com/cdb"
+const (
+	bidderCode      = "criteo"
+	defaultEndpoint = "https://bidder.criteo.com/cdb"
+)
+
+// ImpParams are the Criteo params in imp.ext.prebid.bidder.criteo. One of
+// zoneId or networkId is required.
+type ImpParams struct {
+	ZoneID    int    `json:"zoneId,omitempty"`
+	NetworkID int    `json:"networkId,omitempty"`
+	PubID     string `json:"pubid,omitempty"`
+}
+
+// paramsSchema is the JSON schema for ImpParams
+const paramsSchema = `{
+	"title": "Criteo Adapter Params",
+	"type": "object",
+	"properties": {
+		"zoneId": {"type": "integer", "minimum": 1, "description": "Zone ID"},
+		"networkId": {"type": "integer", "minimum": 1, "description": "Network ID, instead of zoneId"},
+		"pubid": {"type": "string", "description": "Publisher ID"}
+	},
+	"anyOf": [{"required": ["zoneId"]}, {"required": ["networkId"]}]
+}`
+
+// impExt is the imp.ext Criteo expects, holding only its own params
+type impExt struct {
+	Bidder ImpParams `json:"bidder"`
+}
+
+// bidExt is where Criteo reports each bid's media type
+type bidExt struct {
+	Prebid struct {
+		Type adapters.BidType `json:"type"`
+	} `json:"prebid"`
+}
 
 // Adapter implements the Criteo bidder
 type Adapter struct {
@@ -27,9 +64,37 @@ func New(endpoint string) *Adapter {
 
 // MakeRequests builds HTTP requests for Criteo
 func (a *Adapter) MakeRequests(request *openrtb.BidRequest, extraInfo *adapters.ExtraRequestInfo) ([]*adapters.RequestData, []error) {
-	requestBody, err := json.Marshal(request)
+	var errs []error
+	imps := make([]openrtb.Imp, 0, len(request.Imp))
+	for _, imp := range request.Imp {
+		var params ImpParams
+		if err := adapters.ImpParams(&imp, bidderCode, &params); err != nil {
+			if !errors.Is(err, adapters.ErrNoParams) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if params.ZoneID <= 0 && params.NetworkID <= 0 {
+			errs = append(errs, adapters.NewBadInputError(bidderCode, fmt.Sprintf("imp %s: zoneId or networkId is required", imp.ID)))
+			continue
+		}
+		ext, err := json.Marshal(impExt{Bidder: params})
+		if err != nil {
+			errs = append(errs, adapters.NewMarshalError(bidderCode, err))
+			continue
+		}
+		imp.Ext = ext
+		imps = append(imps, imp)
+	}
+	if len(imps) == 0 {
+		return nil, errs
+	}
+
+	reqCopy := *request
+	reqCopy.Imp = imps
+	requestBody, err := jsoncodec.Marshal(reqCopy)
 	if err != nil {
-		return nil, []error{fmt.Errorf("failed to marshal request: %v", err)}
+		return nil, append(errs, adapters.NewMarshalError(bidderCode, err))
 	}
 
 	headers := http.Header{}
@@ -38,7 +103,7 @@ func (a *Adapter) MakeRequests(request *openrtb.BidRequest, extraInfo *adapters.
 
 	return []*adapters.RequestData{
 		{Method: "POST", URI: a.endpoint, Body: requestBody, Headers: headers},
-	}, nil
+	}, errs
 }
 
 // MakeBids parses Criteo responses into bids
@@ -47,33 +112,48 @@ func (a *Adapter) MakeBids(request *openrtb.BidRequest, responseData *adapters.R
 		return nil, nil
 	}
 	if responseData.StatusCode != http.StatusOK {
-		return nil, []error{fmt.Errorf("unexpected status: %d", responseData.StatusCode)}
+		return nil, []error{adapters.NewBadStatusError(bidderCode, responseData.StatusCode)}
 	}
 
 	var bidResp openrtb.BidResponse
-	if err := json.Unmarshal(responseData.Body, &bidResp); err != nil {
-		return nil, []error{fmt.Errorf("failed to parse response: %v", err)}
+	if err := jsoncodec.Unmarshal(responseData.Body, &bidResp); err != nil {
+		return nil, []error{adapters.NewParseError(bidderCode, err)}
 	}
 
 	response := &adapters.BidderResponse{Currency: bidResp.Cur, ResponseID: bidResp.ID, Bids: make([]*adapters.TypedBid, 0)}
+	impMap := adapters.BuildImpMap(request.Imp)
 	for _, seatBid := range bidResp.SeatBid {
 		for i := range seatBid.Bid {
+			bid := &seatBid.Bid[i]
 			response.Bids = append(response.Bids, &adapters.TypedBid{
-				Bid:     &seatBid.Bid[i],
-				BidType: adapters.BidTypeBanner,
+				Bid:     bid,
+				BidType: bidType(bid, impMap),
 			})
 		}
 	}
 	return response, nil
 }
 
+// bidType prefers the media type Criteo reports in bid.ext.prebid.type
+func bidType(bid *openrtb.Bid, impMap map[string]*openrtb.Imp) adapters.BidType {
+	var ext bidExt
+	if len(bid.Ext) > 0 && json.Unmarshal(bid.Ext, &ext) == nil {
+		switch ext.Prebid.Type {
+		case adapters.BidTypeBanner, adapters.BidTypeVideo, adapters.BidTypeNative:
+			return ext.Prebid.Type
+		}
+	}
+	return adapters.GetBidTypeFromMap(bid, impMap)
+}
+
 // Info returns bidder information
 func Info() adapters.BidderInfo {
 	return adapters.BidderInfo{
-		Enabled:     true,
-		GVLVendorID: 91,
-		Endpoint:    defaultEndpoint,
-		Maintainer:  &adapters.MaintainerInfo{Email: "prebid@criteo.com"},
+		Enabled:      true,
+		GVLVendorID:  91,
+		Endpoint:     defaultEndpoint,
+		ParamsSchema: json.RawMessage(paramsSchema),
+		Maintainer:   &adapters.MaintainerInfo{Email: "prebid@criteo.com"},
 		Capabilities: &adapters.CapabilitiesInfo{
 			Site: &adapters.PlatformInfo{MediaTypes: []adapters.BidType{adapters.BidTypeBanner, adapters.BidTypeVideo, adapters.BidTypeNative}},
 			App:  &adapters.PlatformInfo{MediaTypes: []adapters.BidType{adapters.BidTypeBanner, adapters.BidTypeVideo, adapters.BidTypeNative}},
@@ -82,5 +162,5 @@ func Info() adapters.BidderInfo {
 }
 
 func init() {
-	adapters.RegisterAdapter("criteo", New(""), Info())
+	adapters.RegisterAdapter(bidderCode, New(""), Info())
 }
@@ -0,0 +1,23 @@
+package criteo
+
+import (
+	"testing"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters/adapterstest"
+)
+
+func TestJSONSamples(t *testing.T) {
+	adapterstest.RunJSONBidderTest(t, "criteotest", New(""))
+}
+
+func TestParamsSchema(t *testing.T) {
+	adapterstest.RunParamsSchemaTest(t, Info(), []string{
+		`{"zoneId":123}`,
+		`{"networkId":456,"pubid":"p"}`,
+	}, []string{
+		`{}`,
+		`{"zoneId":"123"}`,
+		`{"zoneId":1.5}`,
+		`{"networkId":0}`,
+	})
+}
@@ -0,0 +1,229 @@
+// Package debugbidder implements a built-in bidder that returns deterministic test bids.
+// It only bids on test requests (test=1) or for allow-listed accounts, so publishers can
+// verify rendering and line-item setup end-to-end without real demand.
+package debugbidder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+// BidderCode is the code the debug bidder is registered and reported under
+const BidderCode = "debugbidder"
+
+// DefaultAdmTemplate renders a labelled placeholder creative
+const DefaultAdmTemplate = `<div style="width:{{.W}}px;height:{{.H}}px;background:#f0f0f0;border:1px dashed #999;display:flex;align-items:center;justify-content:center;font-family:monospace;color:#333;">debugbidder {{.W}}x{{.H}} ${{printf "%.2f" .CPM}} imp={{.ImpID}}</div>`
+
+// defaultVAST is returned for video imps; bidders are expected to return VAST in adm
+const defaultVAST = `<VAST version="3.0"><Ad id="debugbidder-%s"><InLine><AdSystem>debugbidder</AdSystem><AdTitle>debugbidder test ad</AdTitle><Creatives></Creatives></InLine></Ad></VAST>`
+
+// Config controls the bids returned by the debug bidder
+type Config struct {
+	CPM         float64         // Price of every bid
+	W           int             // Fallback width when the imp has no banner size
+	H           int             // Fallback height when the imp has no banner size
+	AdmTemplate string          // text/template for banner adm; fields: RequestID, ImpID, CPM, W, H
+	Accounts    map[string]bool // Publisher IDs that get debug bids without test=1
+}
+
+// DefaultConfig returns a $1.00 CPM, 300x250 configuration that only bids on test=1 requests
+func DefaultConfig() *Config {
+	return &Config{
+		CPM:         1.00,
+		W:           300,
+		H:           250,
+		AdmTemplate: DefaultAdmTemplate,
+		Accounts:    map[string]bool{},
+	}
+}
+
+// admData is passed to the adm template
+type admData struct {
+	RequestID string
+	ImpID     string
+	CPM       float64
+	W         int
+	H         int
+}
+
+// Adapter implements the debug bidder
+type Adapter struct {
+	config *Config
+	adm    *template.Template
+}
+
+// New creates a debug bidder; an invalid adm template is an error so misconfiguration fails at startup
+func New(config *Config) (*Adapter, error) {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	if config.AdmTemplate == "" {
+		config.AdmTemplate = DefaultAdmTemplate
+	}
+	adm, err := template.New(BidderCode).Parse(config.AdmTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid debug bidder adm template: %w", err)
+	}
+	return &Adapter{config: config, adm: adm}, nil
+}
+
+// enabledFor reports whether the request may receive debug bids
+func (a *Adapter) enabledFor(request *openrtb.BidRequest) bool {
+	if request.Test == 1 {
+		return true
+	}
+	publisherID := ""
+	if request.Site != nil && request.Site.Publisher != nil {
+		publisherID = request.Site.Publisher.ID
+	} else if request.App != nil && request.App.Publisher != nil {
+		publisherID = request.App.Publisher.ID
+	}
+	return publisherID != "" && a.config.Accounts[publisherID]
+}
+
+// MakeRequests builds the bid response locally and hands it to the exchange as a mock request
+func (a *Adapter) MakeRequests(request *openrtb.BidRequest, _ *adapters.ExtraRequestInfo) ([]*adapters.RequestData, []error) {
+	if !a.enabledFor(request) {
+		return nil, nil
+	}
+
+	response, err := a.buildResponse(request)
+	if err != nil {
+		return nil, []error{err}
+	}
+	if response == nil {
+		return nil, nil
+	}
+
+	body, err := json.Marshal(response)
+	if err != nil {
+		return nil, []error{adapters.NewMarshalError(BidderCode, err)}
+	}
+
+	return []*adapters.RequestData{
+		{
+			Method: "MOCK", // Exchange uses the body as the response without an HTTP call
+			URI:    "debugbidder://response",
+			Body:   body,
+			Headers: http.Header{
+				"Content-Type": []string{"application/json"},
+			},
+		},
+	}, nil
+}
+
+// MakeBids parses the locally built response into bids
+func (a *Adapter) MakeBids(request *openrtb.BidRequest, responseData *adapters.ResponseData) (*adapters.BidderResponse, []error) {
+	var bidResp openrtb.BidResponse
+	if err := json.Unmarshal(responseData.Body, &bidResp); err != nil {
+		return nil, []error{adapters.NewParseError(BidderCode, err)}
+	}
+
+	response := &adapters.BidderResponse{
+		Currency:   bidResp.Cur,
+		ResponseID: bidResp.ID,
+		Bids:       make([]*adapters.TypedBid, 0),
+	}
+
+	impMap := adapters.BuildImpMap(request.Imp)
+	for _, seatBid := range bidResp.SeatBid {
+		for i := range seatBid.Bid {
+			bid := &seatBid.Bid[i]
+			response.Bids = append(response.Bids, &adapters.TypedBid{
+				Bid:     bid,
+				BidType: adapters.GetBidTypeFromMap(bid, impMap),
+			})
+		}
+	}
+
+	return response, nil
+}
+
+// buildResponse returns one bid per banner or video imp; nil when no imp is biddable
+func (a *Adapter) buildResponse(request *openrtb.BidRequest) (*openrtb.BidResponse, error) {
+	bids := make([]openrtb.Bid, 0, len(request.Imp))
+	for _, imp := range request.Imp {
+		if imp.Banner == nil && imp.Video == nil {
+			continue
+		}
+
+		w, h := a.config.W, a.config.H
+		var adm string
+		if imp.Banner != nil {
+			if imp.Banner.W > 0 && imp.Banner.H > 0 {
+				w, h = imp.Banner.W, imp.Banner.H
+			} else if len(imp.Banner.Format) > 0 {
+				w, h = imp.Banner.Format[0].W, imp.Banner.Format[0].H
+			}
+
+			var buf bytes.Buffer
+			if err := a.adm.Execute(&buf, admData{
+				RequestID: request.ID,
+				ImpID:     imp.ID,
+				CPM:       a.config.CPM,
+				W:         w,
+				H:         h,
+			}); err != nil {
+				return nil, fmt.Errorf("debug bidder adm template failed for imp %s: %w", imp.ID, err)
+			}
+			adm = buf.String()
+		} else {
+			if imp.Video.W > 0 && imp.Video.H > 0 {
+				w, h = imp.Video.W, imp.Video.H
+			}
+			adm = fmt.Sprintf(defaultVAST, imp.ID)
+		}
+
+		bids = append(bids, openrtb.Bid{
+			ID:      "debugbidder-" + imp.ID,
+			ImpID:   imp.ID,
+			Price:   a.config.CPM,
+			W:       w,
+			H:       h,
+			AdM:     adm,
+			CRID:    "debugbidder-creative",
+			ADomain: []string{"debugbidder.example.com"},
+		})
+	}
+
+	if len(bids) == 0 {
+		return nil, nil
+	}
+	return &openrtb.BidResponse{
+		ID:      request.ID,
+		Cur:     "USD",
+		SeatBid: []openrtb.SeatBid{{Bid: bids, Seat: BidderCode}},
+	}, nil
+}
+
+// ParseAccounts parses a comma-separated list of publisher IDs
+func ParseAccounts(value string) map[string]bool {
+	accounts := make(map[string]bool)
+	for _, id := range strings.Split(value, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			accounts[id] = true
+		}
+	}
+	return accounts
+}
+
+// Info returns bidder information
+func Info() adapters.BidderInfo {
+	return adapters.BidderInfo{
+		Enabled:     true,
+		GVLVendorID: 0, // Never calls out, no GDPR vendor
+		Capabilities: &adapters.CapabilitiesInfo{
+			App:  &adapters.PlatformInfo{MediaTypes: []adapters.BidType{adapters.BidTypeBanner, adapters.BidTypeVideo}},
+			Site: &adapters.PlatformInfo{MediaTypes: []adapters.BidType{adapters.BidTypeBanner, adapters.BidTypeVideo}},
+		},
+		// Shown under its own seat so test line items can target it
+		DemandType: adapters.DemandTypePublisher,
+	}
+}
@@ -0,0 +1,148 @@
+package debugbidder
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+func testRequest(test int, publisherID string) *openrtb.BidRequest {
+	return &openrtb.BidRequest{
+		ID:   "req-1",
+		Test: test,
+		Imp: []openrtb.Imp{
+			{ID: "imp-1", Banner: &openrtb.Banner{W: 728, H: 90}},
+			{ID: "imp-2", Banner: &openrtb.Banner{Format: []openrtb.Format{{W: 160, H: 600}}}},
+			{ID: "imp-3", Video: &openrtb.Video{Mimes: []string{"video/mp4"}, W: 640, H: 480}},
+		},
+		Site: &openrtb.Site{Domain: "example.com", Publisher: &openrtb.Publisher{ID: publisherID}},
+	}
+}
+
+// runAuction passes MakeRequests output straight to MakeBids, as the exchange does for MOCK requests
+func runAuction(t *testing.T, a *Adapter, req *openrtb.BidRequest) *adapters.BidderResponse {
+	t.Helper()
+	requests, errs := a.MakeRequests(req, nil)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if len(requests) == 0 {
+		return nil
+	}
+	if requests[0].Method != "MOCK" {
+		t.Fatalf("expected MOCK request, got %s", requests[0].Method)
+	}
+	resp, errs := a.MakeBids(req, &adapters.ResponseData{StatusCode: 200, Body: requests[0].Body})
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	return resp
+}
+
+func TestNoBidsWithoutTestOrAccount(t *testing.T) {
+	a, err := New(nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if resp := runAuction(t, a, testRequest(0, "pub-1")); resp != nil {
+		t.Errorf("expected no requests for live traffic, got %+v", resp)
+	}
+}
+
+func TestDeterministicBidsOnTestRequest(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.CPM = 2.5
+	a, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	resp := runAuction(t, a, testRequest(1, ""))
+	if resp == nil || len(resp.Bids) != 3 {
+		t.Fatalf("expected 3 bids, got %+v", resp)
+	}
+	if resp.ResponseID != "req-1" || resp.Currency != "USD" {
+		t.Errorf("unexpected response id/currency %q/%q", resp.ResponseID, resp.Currency)
+	}
+
+	want := []struct {
+		id      string
+		w, h    int
+		bidType adapters.BidType
+	}{
+		{"debugbidder-imp-1", 728, 90, adapters.BidTypeBanner},
+		{"debugbidder-imp-2", 160, 600, adapters.BidTypeBanner},
+		{"debugbidder-imp-3", 640, 480, adapters.BidTypeVideo},
+	}
+	for i, w := range want {
+		bid := resp.Bids[i]
+		if bid.Bid.ID != w.id || bid.Bid.W != w.w || bid.Bid.H != w.h || bid.BidType != w.bidType {
+			t.Errorf("bid %d: got id=%s %dx%d %s", i, bid.Bid.ID, bid.Bid.W, bid.Bid.H, bid.BidType)
+		}
+		if bid.Bid.Price != 2.5 {
+			t.Errorf("bid %d: expected price 2.5, got %v", i, bid.Bid.Price)
+		}
+	}
+	if !strings.Contains(resp.Bids[0].Bid.AdM, "728x90 $2.50 imp=imp-1") {
+		t.Errorf("unexpected banner adm %q", resp.Bids[0].Bid.AdM)
+	}
+	if !strings.HasPrefix(resp.Bids[2].Bid.AdM, "<VAST") {
+		t.Errorf("expected VAST adm for video, got %q", resp.Bids[2].Bid.AdM)
+	}
+
+	// Same request, same bids
+	again := runAuction(t, a, testRequest(1, ""))
+	if again.Bids[0].Bid.AdM != resp.Bids[0].Bid.AdM || again.Bids[0].Bid.ID != resp.Bids[0].Bid.ID {
+		t.Error("expected identical bids for identical requests")
+	}
+}
+
+func TestAccountAllowList(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Accounts = ParseAccounts(" pub-1 , pub-2,")
+	a, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	if len(cfg.Accounts) != 2 {
+		t.Fatalf("expected 2 accounts, got %v", cfg.Accounts)
+	}
+	if resp := runAuction(t, a, testRequest(0, "pub-2")); resp == nil || len(resp.Bids) != 3 {
+		t.Errorf("expected bids for allow-listed account, got %+v", resp)
+	}
+	if resp := runAuction(t, a, testRequest(0, "pub-3")); resp != nil {
+		t.Errorf("expected no bids for other accounts, got %+v", resp)
+	}
+}
+
+func TestCustomAdmTemplate(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.W, cfg.H = 320, 50
+	cfg.AdmTemplate = `<img src="https://cdn.example.com/{{.W}}x{{.H}}.png" data-req="{{.RequestID}}">`
+	a, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	req := testRequest(1, "")
+	req.Imp = []openrtb.Imp{{ID: "imp-1", Banner: &openrtb.Banner{}}}
+	resp := runAuction(t, a, req)
+	if resp == nil || len(resp.Bids) != 1 {
+		t.Fatalf("expected 1 bid, got %+v", resp)
+	}
+	want := `<img src="https://cdn.example.com/320x50.png" data-req="req-1">`
+	if resp.Bids[0].Bid.AdM != want {
+		t.Errorf("expected adm %q, got %q", want, resp.Bids[0].Bid.AdM)
+	}
+}
+
+func TestInvalidAdmTemplate(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AdmTemplate = "{{.W"
+	if _, err := New(cfg); err == nil {
+		t.Error("expected error for invalid template")
+	}
+}
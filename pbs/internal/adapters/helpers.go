@@ -3,10 +3,12 @@ package adapters
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/jsoncodec"
 )
 
 // P3-3: Standard error codes for consistent error handling
@@ -19,6 +21,7 @@ const (
 	ErrorCodeParse      BidderErrorCode = "PARSE_ERROR"
 	ErrorCodeTimeout    BidderErrorCode = "TIMEOUT"
 	ErrorCodeConnection BidderErrorCode = "CONNECTION_ERROR"
+	ErrorCodeBadInput   BidderErrorCode = "BAD_INPUT"
 )
 
 // BidderError represents a standardized adapter error
@@ -80,6 +83,77 @@ func NewParseError(bidderCode string, cause error) *BidderError {
 	}
 }
 
+// NewBadInputError creates a standardized error for a request the bidder
+// can't be sent, such as an imp with invalid bidder params
+func NewBadInputError(bidderCode string, message string) *BidderError {
+	return &BidderError{
+		BidderCode: bidderCode,
+		Code:       ErrorCodeBadInput,
+		Message:    message,
+	}
+}
+
+// ErrNoParams is returned by ImpParams when an imp carries no params for the
+// bidder. Adapters skip such imps without reporting an error, as the imp
+// wasn't meant for them.
+var ErrNoParams = errors.New("imp has no params for bidder")
+
+// ImpParams decodes a bidder's params from imp.ext.prebid.bidder.<code>,
+// falling back to the legacy imp.ext.<code>
+func ImpParams(imp *openrtb.Imp, bidderCode string, params any) error {
+	if len(imp.Ext) == 0 {
+		return ErrNoParams
+	}
+	var ext map[string]json.RawMessage
+	if err := json.Unmarshal(imp.Ext, &ext); err != nil {
+		return NewBadInputError(bidderCode, fmt.Sprintf("imp %s: invalid ext: %v", imp.ID, err))
+	}
+
+	raw := ext[bidderCode]
+	if prebid := ext["prebid"]; len(prebid) > 0 {
+		var prebidExt struct {
+			Bidder map[string]json.RawMessage `json:"bidder"`
+		}
+		if err := json.Unmarshal(prebid, &prebidExt); err != nil {
+			return NewBadInputError(bidderCode, fmt.Sprintf("imp %s: invalid ext.prebid: %v", imp.ID, err))
+		}
+		if p, ok := prebidExt.Bidder[bidderCode]; ok {
+			raw = p
+		}
+	}
+	if len(raw) == 0 || string(raw) == "null" {
+		return ErrNoParams
+	}
+	if err := json.Unmarshal(raw, params); err != nil {
+		return NewBadInputError(bidderCode, fmt.Sprintf("imp %s: invalid params: %v", imp.ID, err))
+	}
+	return nil
+}
+
+// WithPublisherID returns a copy of request whose site or app publisher has
+// the given ID. The original request, shared with other bidders, is untouched.
+func WithPublisherID(request *openrtb.BidRequest, id string) *openrtb.BidRequest {
+	reqCopy := *request
+	setID := func(pub *openrtb.Publisher) *openrtb.Publisher {
+		var p openrtb.Publisher
+		if pub != nil {
+			p = *pub
+		}
+		p.ID = id
+		return &p
+	}
+	if request.Site != nil {
+		site := *request.Site
+		site.Publisher = setID(site.Publisher)
+		reqCopy.Site = &site
+	} else if request.App != nil {
+		app := *request.App
+		app.Publisher = setID(app.Publisher)
+		reqCopy.App = &app
+	}
+	return &reqCopy
+}
+
 // P2-3: BuildImpMap creates a map of impression ID to impression for O(1) lookups
 // Use this instead of iterating through impressions for each bid
 func BuildImpMap(imps []openrtb.Imp) map[string]*openrtb.Imp {
@@ -90,8 +164,28 @@ func BuildImpMap(imps []openrtb.Imp) map[string]*openrtb.Imp {
 	return impMap
 }
 
+// BidTypeFromMType maps an OpenRTB 2.6 bid.mtype to a bid type. A bidder that sets
+// mtype knows what it returned, which beats guessing from a multi-format imp.
+func BidTypeFromMType(mtype int) (BidType, bool) {
+	switch mtype {
+	case 1:
+		return BidTypeBanner, true
+	case 2:
+		return BidTypeVideo, true
+	case 3:
+		return BidTypeAudio, true
+	case 4:
+		return BidTypeNative, true
+	}
+	return "", false
+}
+
 // P2-3: GetBidTypeFromMap determines bid type using pre-built impression map (O(1))
 func GetBidTypeFromMap(bid *openrtb.Bid, impMap map[string]*openrtb.Imp) BidType {
+	if bidType, ok := BidTypeFromMType(bid.MType); ok {
+		return bidType
+	}
+
 	imp, ok := impMap[bid.ImpID]
 	if !ok {
 		return BidTypeBanner
@@ -112,6 +206,9 @@ func GetBidTypeFromMap(bid *openrtb.Bid, impMap map[string]*openrtb.Imp) BidType
 // P2-3: GetBidType determines bid type from impression (convenience wrapper)
 // Note: For multiple bids, use BuildImpMap + GetBidTypeFromMap for better performance
 func GetBidType(bid *openrtb.Bid, request *openrtb.BidRequest) BidType {
+	if bidType, ok := BidTypeFromMType(bid.MType); ok {
+		return bidType
+	}
 	for _, imp := range request.Imp {
 		if imp.ID == bid.ImpID {
 			if imp.Video != nil {
@@ -149,7 +246,7 @@ func NewSimpleAdapter(bidderCode, endpoint string, defaultBidType BidType) *Simp
 
 // MakeRequests implements the standard ORTB JSON POST pattern
 func (a *SimpleAdapter) MakeRequests(request *openrtb.BidRequest, extraInfo *ExtraRequestInfo) ([]*RequestData, []error) {
-	body, err := json.Marshal(request)
+	body, err := jsoncodec.Marshal(request)
 	if err != nil {
 		return nil, []error{NewMarshalError(a.BidderCode, err)}
 	}
@@ -176,7 +273,7 @@ func (a *SimpleAdapter) MakeBids(request *openrtb.BidRequest, responseData *Resp
 	}
 
 	var bidResp openrtb.BidResponse
-	if err := json.Unmarshal(responseData.Body, &bidResp); err != nil {
+	if err := jsoncodec.Unmarshal(responseData.Body, &bidResp); err != nil {
 		return nil, []error{NewParseError(a.BidderCode, err)}
 	}
 
@@ -143,6 +143,69 @@ func TestNewParseError(t *testing.T) {
 	}
 }
 
+func TestNewBadInputError(t *testing.T) {
+	err := NewBadInputError("openx", "imp 1: missing unit")
+	if err.Code != ErrorCodeBadInput {
+		t.Errorf("expected BAD_INPUT, got %s", err.Code)
+	}
+	if err.Error() != "[BAD_INPUT] openx: imp 1: missing unit" {
+		t.Errorf("unexpected message: %s", err.Error())
+	}
+}
+
+func TestImpParams(t *testing.T) {
+	type params struct {
+		Unit string `json:"unit"`
+	}
+	tests := []struct {
+		name    string
+		ext     string
+		want    string
+		wantErr error
+		badExt  bool
+	}{
+		{name: "prebid bidder", ext: `{"prebid":{"bidder":{"openx":{"unit":"1"}}}}`, want: "1"},
+		{name: "legacy", ext: `{"openx":{"unit":"2"}}`, want: "2"},
+		{name: "prebid wins", ext: `{"openx":{"unit":"2"},"prebid":{"bidder":{"openx":{"unit":"1"}}}}`, want: "1"},
+		{name: "other bidder", ext: `{"prebid":{"bidder":{"ix":{"siteId":"3"}}}}`, wantErr: ErrNoParams},
+		{name: "no ext", wantErr: ErrNoParams},
+		{name: "null params", ext: `{"openx":null}`, wantErr: ErrNoParams},
+		{name: "invalid ext", ext: `[1]`, badExt: true},
+		{name: "wrong type", ext: `{"openx":{"unit":5}}`, badExt: true},
+	}
+	for _, tt := range tests {
+		imp := &openrtb.Imp{ID: "1", Ext: []byte(tt.ext)}
+		var p params
+		err := ImpParams(imp, "openx", &p)
+		if tt.badExt {
+			var bidderErr *BidderError
+			if !errors.As(err, &bidderErr) || bidderErr.Code != ErrorCodeBadInput {
+				t.Errorf("%s: expected a bad input error, got %v", tt.name, err)
+			}
+			continue
+		}
+		if !errors.Is(err, tt.wantErr) || p.Unit != tt.want {
+			t.Errorf("%s: expected %q/%v, got %q/%v", tt.name, tt.want, tt.wantErr, p.Unit, err)
+		}
+	}
+}
+
+func TestWithPublisherID(t *testing.T) {
+	request := &openrtb.BidRequest{Site: &openrtb.Site{Domain: "example.com", Publisher: &openrtb.Publisher{ID: "pub", Name: "Pub"}}}
+	got := WithPublisherID(request, "123")
+	if got.Site.Publisher.ID != "123" || got.Site.Publisher.Name != "Pub" || got.Site.Domain != "example.com" {
+		t.Errorf("unexpected site %+v / %+v", got.Site, got.Site.Publisher)
+	}
+	if request.Site.Publisher.ID != "pub" {
+		t.Error("expected the original request untouched")
+	}
+
+	app := WithPublisherID(&openrtb.BidRequest{App: &openrtb.App{Bundle: "com.example"}}, "456")
+	if app.App.Publisher == nil || app.App.Publisher.ID != "456" {
+		t.Errorf("expected an app publisher created, got %+v", app.App)
+	}
+}
+
 func TestErrorCodeConstants(t *testing.T) {
 	tests := []struct {
 		code     BidderErrorCode
@@ -154,6 +217,7 @@ func TestErrorCodeConstants(t *testing.T) {
 		{ErrorCodeParse, "PARSE_ERROR"},
 		{ErrorCodeTimeout, "TIMEOUT"},
 		{ErrorCodeConnection, "CONNECTION_ERROR"},
+		{ErrorCodeBadInput, "BAD_INPUT"},
 	}
 
 	for _, tt := range tests {
@@ -351,6 +415,37 @@ func TestGetBidType_Audio(t *testing.T) {
 	}
 }
 
+func TestGetBidTypeFromMap_MTypeWins(t *testing.T) {
+	// Multi-format imp would guess video; mtype says the bidder returned audio
+	impMap := map[string]*openrtb.Imp{
+		"imp-1": {ID: "imp-1", Video: &openrtb.Video{}, Audio: &openrtb.Audio{}},
+	}
+
+	if got := GetBidTypeFromMap(&openrtb.Bid{ImpID: "imp-1", MType: 3}, impMap); got != BidTypeAudio {
+		t.Errorf("expected audio from mtype, got %s", got)
+	}
+	if got := GetBidTypeFromMap(&openrtb.Bid{ImpID: "imp-1", MType: 9}, impMap); got != BidTypeVideo {
+		t.Errorf("expected unknown mtype to fall back to imp, got %s", got)
+	}
+}
+
+func TestBidderInfo_SupportsMediaType(t *testing.T) {
+	info := BidderInfo{Capabilities: &CapabilitiesInfo{
+		Site: &PlatformInfo{MediaTypes: []BidType{BidTypeBanner}},
+		App:  &PlatformInfo{MediaTypes: []BidType{BidTypeBanner, BidTypeAudio}},
+	}}
+
+	if info.SupportsMediaType(false, BidTypeAudio) {
+		t.Error("expected site audio to be unsupported")
+	}
+	if !info.SupportsMediaType(true, BidTypeAudio) {
+		t.Error("expected app audio to be supported")
+	}
+	if !(BidderInfo{}).SupportsMediaType(false, BidTypeAudio) {
+		t.Error("expected bidder without capabilities to support everything")
+	}
+}
+
 func TestGetBidType_NotFound(t *testing.T) {
 	request := &openrtb.BidRequest{
 		Imp: []openrtb.Imp{
@@ -0,0 +1,72 @@
+package adapters
+
+import (
+	"net/http"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/config"
+)
+
+// Outbound identification header names
+const (
+	HeaderPrebidVersion   = "X-Prebid"
+	HeaderExchangeName    = "X-Exchange-Name"
+	HeaderExchangeVersion = "X-Exchange-Version"
+	HeaderFrom            = "From"
+	HeaderUserAgent       = "User-Agent"
+)
+
+// Identification describes how PBS identifies itself on outbound bidder calls.
+// Several SSPs require identification for server-to-server traffic and
+// throttle unknown callers.
+type Identification struct {
+	Name      string            // Exchange name, e.g. "thenexusengine"
+	Version   string            // Engine version
+	Contact   string            // Operator contact sent in the From header (optional)
+	UserAgent string            // Defaults to "<Name>/<Version>" when empty
+	Headers   map[string]string // Additional static headers sent to every bidder
+}
+
+// DefaultIdentification returns identification using the build's engine name and version
+func DefaultIdentification() *Identification {
+	return &Identification{
+		Name:    config.EngineName,
+		Version: config.Version,
+	}
+}
+
+// userAgent returns the configured user agent or one derived from name/version
+func (id *Identification) userAgent() string {
+	if id.UserAgent != "" {
+		return id.UserAgent
+	}
+	if id.Version == "" {
+		return id.Name
+	}
+	return id.Name + "/" + id.Version
+}
+
+// Apply adds identification headers to h. Headers already set by the
+// adapter are left untouched so bidder-specific requirements win.
+func (id *Identification) Apply(h http.Header) {
+	if id == nil || h == nil {
+		return
+	}
+
+	setIfMissing := func(key, value string) {
+		if value != "" && h.Get(key) == "" {
+			h.Set(key, value)
+		}
+	}
+
+	setIfMissing(HeaderUserAgent, id.userAgent())
+	if id.Version != "" {
+		setIfMissing(HeaderPrebidVersion, id.Name+"/"+id.Version)
+	}
+	setIfMissing(HeaderExchangeName, id.Name)
+	setIfMissing(HeaderExchangeVersion, id.Version)
+	setIfMissing(HeaderFrom, id.Contact)
+
+	for k, v := range id.Headers {
+		setIfMissing(k, v)
+	}
+}
@@ -0,0 +1,90 @@
+package adapters
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIdentification_Apply(t *testing.T) {
+	id := &Identification{
+		Name:    "thenexusengine",
+		Version: "1.2.3",
+		Contact: "ops@example.com",
+		Headers: map[string]string{"X-Partner-Id": "tne"},
+	}
+
+	h := http.Header{}
+	id.Apply(h)
+
+	expected := map[string]string{
+		HeaderUserAgent:       "thenexusengine/1.2.3",
+		HeaderPrebidVersion:   "thenexusengine/1.2.3",
+		HeaderExchangeName:    "thenexusengine",
+		HeaderExchangeVersion: "1.2.3",
+		HeaderFrom:            "ops@example.com",
+		"X-Partner-Id":        "tne",
+	}
+	for k, v := range expected {
+		if got := h.Get(k); got != v {
+			t.Errorf("expected %s=%q, got %q", k, v, got)
+		}
+	}
+}
+
+func TestIdentification_Apply_AdapterHeadersWin(t *testing.T) {
+	id := &Identification{Name: "thenexusengine", Version: "1.2.3", UserAgent: "custom-ua"}
+
+	h := http.Header{}
+	h.Set(HeaderUserAgent, "adapter-ua")
+	id.Apply(h)
+
+	if got := h.Get(HeaderUserAgent); got != "adapter-ua" {
+		t.Errorf("expected adapter user agent to be preserved, got %q", got)
+	}
+	if h.Get(HeaderFrom) != "" {
+		t.Error("expected no From header without contact")
+	}
+}
+
+func TestIdentification_Apply_Nil(t *testing.T) {
+	var id *Identification
+	h := http.Header{}
+	id.Apply(h) // must not panic
+	if len(h) != 0 {
+		t.Errorf("expected no headers, got %v", h)
+	}
+}
+
+func TestDefaultIdentification(t *testing.T) {
+	id := DefaultIdentification()
+	if id.Name == "" || id.Version == "" {
+		t.Errorf("expected name and version to be populated, got %+v", id)
+	}
+}
+
+func TestHTTPClientDo_SendsIdentification(t *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(5 * time.Second)
+	client.SetIdentification(&Identification{Name: "thenexusengine", Version: "9.9.9", Contact: "ops@example.com"})
+
+	_, err := client.Do(context.Background(), &RequestData{Method: "POST", URI: server.URL, Body: []byte(`{}`)}, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got.Get(HeaderUserAgent) != "thenexusengine/9.9.9" {
+		t.Errorf("expected identifying user agent, got %q", got.Get(HeaderUserAgent))
+	}
+	if got.Get(HeaderFrom) != "ops@example.com" {
+		t.Errorf("expected From header, got %q", got.Get(HeaderFrom))
+	}
+}
@@ -3,14 +3,39 @@ package ix
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/jsoncodec"
 )
 
-const defaultEndpoint = "https://htlb.casalemedia.com/openrtb/pbjs"
+const (
+	bidderCode      = "ix"
+	defaultEndpoint = "https://htlb.casalemedia.com/openrtb/pbjs"
+)
+
+// ImpParams are the Index Exchange params in imp.ext.prebid.bidder.ix
+type ImpParams struct {
+	SiteID string `json:"siteId"`
+}
+
+// paramsSchema is the JSON schema for ImpParams
+const paramsSchema = `{
+	"title": "Index Exchange Adapter Params",
+	"type": "object",
+	"properties": {
+		"siteId": {"type": "string", "minLength": 1, "description": "Site ID, also sent as the publisher ID"}
+	},
+	"required": ["siteId"]
+}`
+
+// impExt is the imp.ext Index Exchange expects
+type impExt struct {
+	SiteID string `json:"siteID"`
+}
 
 // Adapter implements the Index Exchange bidder
 type Adapter struct {
@@ -25,11 +50,45 @@ func New(endpoint string) *Adapter {
 	return &Adapter{endpoint: endpoint}
 }
 
-// MakeRequests builds HTTP requests for Index Exchange
+// MakeRequests builds HTTP requests for Index Exchange. All imps go in one
+// request, with the first imp's site ID as the publisher ID.
 func (a *Adapter) MakeRequests(request *openrtb.BidRequest, extraInfo *adapters.ExtraRequestInfo) ([]*adapters.RequestData, []error) {
-	requestBody, err := json.Marshal(request)
+	var errs []error
+	var publisherID string
+	imps := make([]openrtb.Imp, 0, len(request.Imp))
+	for _, imp := range request.Imp {
+		var params ImpParams
+		if err := adapters.ImpParams(&imp, bidderCode, &params); err != nil {
+			if !errors.Is(err, adapters.ErrNoParams) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if params.SiteID == "" {
+			errs = append(errs, adapters.NewBadInputError(bidderCode, fmt.Sprintf("imp %s: siteId is required", imp.ID)))
+			continue
+		}
+		ext, err := json.Marshal(impExt{SiteID: params.SiteID})
+		if err != nil {
+			errs = append(errs, adapters.NewMarshalError(bidderCode, err))
+			continue
+		}
+		imp.Ext = ext
+		if publisherID == "" {
+			publisherID = params.SiteID
+		}
+		imps = append(imps, imp)
+	}
+	if len(imps) == 0 {
+		return nil, errs
+	}
+
+	reqCopy := adapters.WithPublisherID(request, publisherID)
+	reqCopy.Imp = imps
+
+	requestBody, err := jsoncodec.Marshal(reqCopy)
 	if err != nil {
-		return nil, []error{fmt.Errorf("failed to marshal request: %v", err)}
+		return nil, append(errs, adapters.NewMarshalError(bidderCode, err))
 	}
 
 	headers := http.Header{}
@@ -38,7 +97,7 @@ func (a *Adapter) MakeRequests(request *openrtb.BidRequest, extraInfo *adapters.
 
 	return []*adapters.RequestData{
 		{Method: "POST", URI: a.endpoint, Body: requestBody, Headers: headers},
-	}, nil
+	}, errs
 }
 
 // MakeBids parses Index Exchange responses into bids
@@ -47,12 +106,12 @@ func (a *Adapter) MakeBids(request *openrtb.BidRequest, responseData *adapters.R
 		return nil, nil
 	}
 	if responseData.StatusCode != http.StatusOK {
-		return nil, []error{fmt.Errorf("unexpected status: %d", responseData.StatusCode)}
+		return nil, []error{adapters.NewBadStatusError(bidderCode, responseData.StatusCode)}
 	}
 
 	var bidResp openrtb.BidResponse
-	if err := json.Unmarshal(responseData.Body, &bidResp); err != nil {
-		return nil, []error{fmt.Errorf("failed to parse response: %v", err)}
+	if err := jsoncodec.Unmarshal(responseData.Body, &bidResp); err != nil {
+		return nil, []error{adapters.NewParseError(bidderCode, err)}
 	}
 
 	response := &adapters.BidderResponse{Currency: bidResp.Cur, ResponseID: bidResp.ID, Bids: make([]*adapters.TypedBid, 0)}
@@ -74,10 +133,11 @@ func (a *Adapter) MakeBids(request *openrtb.BidRequest, responseData *adapters.R
 // Info returns bidder information
 func Info() adapters.BidderInfo {
 	return adapters.BidderInfo{
-		Enabled:     true,
-		GVLVendorID: 10,
-		Endpoint:    defaultEndpoint,
-		Maintainer:  &adapters.MaintainerInfo{Email: "prebid.support@indexexchange.com"},
+		Enabled:      true,
+		GVLVendorID:  10,
+		Endpoint:     defaultEndpoint,
+		ParamsSchema: json.RawMessage(paramsSchema),
+		Maintainer:   &adapters.MaintainerInfo{Email: "prebid.support@indexexchange.com"},
 		Capabilities: &adapters.CapabilitiesInfo{
 			Site: &adapters.PlatformInfo{MediaTypes: []adapters.BidType{adapters.BidTypeBanner, adapters.BidTypeVideo, adapters.BidTypeNative}},
 			App:  &adapters.PlatformInfo{MediaTypes: []adapters.BidType{adapters.BidTypeBanner, adapters.BidTypeVideo, adapters.BidTypeNative}},
@@ -86,5 +146,5 @@ func Info() adapters.BidderInfo {
 }
 
 func init() {
-	adapters.RegisterAdapter("ix", New(""), Info())
+	adapters.RegisterAdapter(bidderCode, New(""), Info())
 }
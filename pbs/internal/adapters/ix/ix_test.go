@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters/adapterstest"
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
 )
 
@@ -22,7 +23,7 @@ func TestMakeRequests(t *testing.T) {
 	request := &openrtb.BidRequest{
 		ID: "test-request-1",
 		Imp: []openrtb.Imp{
-			{ID: "imp-1", Banner: &openrtb.Banner{W: 300, H: 250}},
+			{ID: "imp-1", Banner: &openrtb.Banner{W: 300, H: 250}, Ext: []byte(`{"prebid":{"bidder":{"ix":{"siteId":"569749"}}}}`)},
 		},
 		Site: &openrtb.Site{Domain: "example.com"},
 	}
@@ -98,3 +99,16 @@ func TestInfo(t *testing.T) {
 	}
 }
 
+func TestJSONSamples(t *testing.T) {
+	adapterstest.RunJSONBidderTest(t, "ixtest", New(""))
+}
+
+func TestParamsSchema(t *testing.T) {
+	adapterstest.RunParamsSchemaTest(t, Info(), []string{
+		`{"siteId":"123"}`,
+	}, []string{
+		`{}`,
+		`{"siteId":""}`,
+		`{"siteId":123}`,
+	})
+}
@@ -3,13 +3,36 @@ package medianet
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/jsoncodec"
 )
 
-const defaultEndpoint = "https://prebid.media.net/rtb/prebid"
+const (
+	bidderCode      = "medianet"
+	defaultEndpoint = "https://prebid.media.net/rtb/prebid"
+)
+
+// ImpParams are the Media.net params in imp.ext.prebid.bidder.medianet
+type ImpParams struct {
+	CID  string `json:"cid"`
+	CRID string `json:"crid,omitempty"`
+}
+
+// paramsSchema is the JSON schema for ImpParams
+const paramsSchema = `{
+	"title": "Media.net Adapter Params",
+	"type": "object",
+	"properties": {
+		"cid": {"type": "string", "minLength": 1, "description": "Customer ID, sent as the publisher ID"},
+		"crid": {"type": "string", "description": "Creative ID, sent as imp.tagid"}
+	},
+	"required": ["cid"]
+}`
 
 type Adapter struct{ endpoint string }
 
@@ -20,28 +43,65 @@ func New(endpoint string) *Adapter {
 	return &Adapter{endpoint: endpoint}
 }
 
+// MakeRequests sends all imps in one request, with the first imp's customer
+// ID as the publisher ID and each creative ID as its imp's tag ID
 func (a *Adapter) MakeRequests(request *openrtb.BidRequest, extraInfo *adapters.ExtraRequestInfo) ([]*adapters.RequestData, []error) {
-	body, err := json.Marshal(request)
+	var errs []error
+	var customerID string
+	imps := make([]openrtb.Imp, 0, len(request.Imp))
+	for _, imp := range request.Imp {
+		var params ImpParams
+		if err := adapters.ImpParams(&imp, bidderCode, &params); err != nil {
+			if !errors.Is(err, adapters.ErrNoParams) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if params.CID == "" {
+			errs = append(errs, adapters.NewBadInputError(bidderCode, fmt.Sprintf("imp %s: cid is required", imp.ID)))
+			continue
+		}
+		if customerID == "" {
+			customerID = params.CID
+		}
+		if params.CRID != "" {
+			imp.TagID = params.CRID
+		}
+		imp.Ext = nil
+		imps = append(imps, imp)
+	}
+	if len(imps) == 0 {
+		return nil, errs
+	}
+
+	reqCopy := adapters.WithPublisherID(request, customerID)
+	reqCopy.Imp = imps
+	body, err := jsoncodec.Marshal(reqCopy)
 	if err != nil {
-		return nil, []error{err}
+		return nil, append(errs, adapters.NewMarshalError(bidderCode, err))
 	}
 	headers := http.Header{}
 	headers.Set("Content-Type", "application/json")
-	return []*adapters.RequestData{{Method: "POST", URI: a.endpoint, Body: body, Headers: headers}}, nil
+	return []*adapters.RequestData{{Method: "POST", URI: a.endpoint, Body: body, Headers: headers}}, errs
 }
 
 func (a *Adapter) MakeBids(request *openrtb.BidRequest, responseData *adapters.ResponseData) (*adapters.BidderResponse, []error) {
-	if responseData.StatusCode != http.StatusOK {
+	if responseData.StatusCode == http.StatusNoContent {
 		return nil, nil
 	}
+	if responseData.StatusCode != http.StatusOK {
+		return nil, []error{adapters.NewBadStatusError(bidderCode, responseData.StatusCode)}
+	}
 	var bidResp openrtb.BidResponse
-	if err := json.Unmarshal(responseData.Body, &bidResp); err != nil {
-		return nil, []error{err}
+	if err := jsoncodec.Unmarshal(responseData.Body, &bidResp); err != nil {
+		return nil, []error{adapters.NewParseError(bidderCode, err)}
 	}
 	response := &adapters.BidderResponse{Currency: bidResp.Cur, ResponseID: bidResp.ID, Bids: make([]*adapters.TypedBid, 0)}
+	impMap := adapters.BuildImpMap(request.Imp)
 	for _, sb := range bidResp.SeatBid {
 		for i := range sb.Bid {
-			response.Bids = append(response.Bids, &adapters.TypedBid{Bid: &sb.Bid[i], BidType: adapters.BidTypeBanner})
+			bid := &sb.Bid[i]
+			response.Bids = append(response.Bids, &adapters.TypedBid{Bid: bid, BidType: adapters.GetBidTypeFromMap(bid, impMap)})
 		}
 	}
 	return response, nil
@@ -50,11 +110,12 @@ func (a *Adapter) MakeBids(request *openrtb.BidRequest, responseData *adapters.R
 func Info() adapters.BidderInfo {
 	return adapters.BidderInfo{
 		Enabled: true, GVLVendorID: 142, Endpoint: defaultEndpoint,
-		Maintainer: &adapters.MaintainerInfo{Email: "prebid-support@media.net"},
+		ParamsSchema: json.RawMessage(paramsSchema),
+		Maintainer:   &adapters.MaintainerInfo{Email: "prebid-support@media.net"},
 		Capabilities: &adapters.CapabilitiesInfo{
 			Site: &adapters.PlatformInfo{MediaTypes: []adapters.BidType{adapters.BidTypeBanner, adapters.BidTypeVideo, adapters.BidTypeNative}},
 		},
 	}
 }
 
-func init() { adapters.RegisterAdapter("medianet", New(""), Info()) }
+func init() { adapters.RegisterAdapter(bidderCode, New(""), Info()) }
@@ -0,0 +1,22 @@
+package medianet
+
+import (
+	"testing"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters/adapterstest"
+)
+
+func TestJSONSamples(t *testing.T) {
+	adapterstest.RunJSONBidderTest(t, "medianettest", New(""))
+}
+
+func TestParamsSchema(t *testing.T) {
+	adapterstest.RunParamsSchemaTest(t, Info(), []string{
+		`{"cid":"c"}`,
+		`{"cid":"c","crid":"cr"}`,
+	}, []string{
+		`{}`,
+		`{"cid":""}`,
+		`{"cid":"c","crid":1}`,
+	})
+}
@@ -3,14 +3,56 @@ package openx
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/jsoncodec"
 )
 
-const defaultEndpoint = "https://rtb.openx.net/openrtb/prebid"
+const (
+	bidderCode      = "openx"
+	defaultEndpoint = "https://rtb.openx.net/openrtb/prebid"
+)
+
+// ImpParams are the OpenX params in imp.ext.prebid.bidder.openx
+type ImpParams struct {
+	Unit         string         `json:"unit"`
+	DelDomain    string         `json:"delDomain,omitempty"`
+	Platform     string         `json:"platform,omitempty"`
+	CustomFloor  float64        `json:"customFloor,omitempty"`
+	CustomParams map[string]any `json:"customParams,omitempty"`
+}
+
+// paramsSchema is the JSON schema for ImpParams
+const paramsSchema = `{
+	"title": "OpenX Adapter Params",
+	"type": "object",
+	"properties": {
+		"unit": {"type": "string", "minLength": 1, "description": "Ad unit ID, sent as imp.tagid"},
+		"delDomain": {"type": "string", "minLength": 1, "description": "Publisher delivery domain, e.g. se-demo-d.openx.net"},
+		"platform": {"type": "string", "minLength": 1, "description": "Platform ID, instead of delDomain"},
+		"customFloor": {"type": "number", "minimum": 0, "description": "Floor used when the imp has none"},
+		"customParams": {"type": "object", "description": "Custom targeting"}
+	},
+	"required": ["unit"],
+	"anyOf": [{"required": ["delDomain"]}, {"required": ["platform"]}]
+}`
+
+// requestExt is the request.ext OpenX expects, identifying the publisher's
+// delivery domain or platform
+type requestExt struct {
+	DelDomain string `json:"delDomain,omitempty"`
+	Platform  string `json:"platform,omitempty"`
+	BidderCfg string `json:"bc"`
+}
+
+// impExt carries custom targeting on each imp
+type impExt struct {
+	CustomParams map[string]any `json:"customParams,omitempty"`
+}
 
 // Adapter implements the OpenX bidder
 type Adapter struct {
@@ -25,20 +67,91 @@ func New(endpoint string) *Adapter {
 	return &Adapter{endpoint: endpoint}
 }
 
-// MakeRequests builds HTTP requests for OpenX
+// MakeRequests builds HTTP requests for OpenX. Banner imps share one request;
+// OpenX takes each video imp in a request of its own.
 func (a *Adapter) MakeRequests(request *openrtb.BidRequest, extraInfo *adapters.ExtraRequestInfo) ([]*adapters.RequestData, []error) {
-	requestBody, err := json.Marshal(request)
+	var errs []error
+	var bannerImps, videoImps []openrtb.Imp
+	var reqExt requestExt
+
+	for _, imp := range request.Imp {
+		var params ImpParams
+		if err := adapters.ImpParams(&imp, bidderCode, &params); err != nil {
+			if !errors.Is(err, adapters.ErrNoParams) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if params.Unit == "" {
+			errs = append(errs, adapters.NewBadInputError(bidderCode, fmt.Sprintf("imp %s: unit is required", imp.ID)))
+			continue
+		}
+		if params.DelDomain == "" && params.Platform == "" {
+			errs = append(errs, adapters.NewBadInputError(bidderCode, fmt.Sprintf("imp %s: delDomain or platform is required", imp.ID)))
+			continue
+		}
+		if reqExt.DelDomain == "" && reqExt.Platform == "" {
+			// The first valid imp identifies the publisher
+			reqExt.DelDomain = params.DelDomain
+			reqExt.Platform = params.Platform
+		}
+
+		imp.TagID = params.Unit
+		if imp.BidFloor == 0 && params.CustomFloor > 0 {
+			imp.BidFloor = params.CustomFloor
+		}
+		imp.Ext = nil
+		if len(params.CustomParams) > 0 {
+			ext, err := json.Marshal(impExt{CustomParams: params.CustomParams})
+			if err != nil {
+				errs = append(errs, adapters.NewMarshalError(bidderCode, err))
+				continue
+			}
+			imp.Ext = ext
+		}
+
+		if imp.Video != nil {
+			videoImps = append(videoImps, imp)
+		} else if imp.Banner != nil {
+			bannerImps = append(bannerImps, imp)
+		} else {
+			errs = append(errs, adapters.NewBadInputError(bidderCode, fmt.Sprintf("imp %s: OpenX only supports banner and video", imp.ID)))
+		}
+	}
+
+	reqExt.BidderCfg = "pbs"
+	ext, err := json.Marshal(reqExt)
 	if err != nil {
-		return nil, []error{fmt.Errorf("failed to marshal request: %v", err)}
+		return nil, append(errs, adapters.NewMarshalError(bidderCode, err))
+	}
+
+	var groups [][]openrtb.Imp
+	if len(bannerImps) > 0 {
+		groups = append(groups, bannerImps)
+	}
+	for _, imp := range videoImps {
+		groups = append(groups, []openrtb.Imp{imp})
 	}
 
-	headers := http.Header{}
-	headers.Set("Content-Type", "application/json;charset=utf-8")
-	headers.Set("Accept", "application/json")
+	requests := make([]*adapters.RequestData, 0, len(groups))
+	for _, imps := range groups {
+		reqCopy := *request
+		reqCopy.Imp = imps
+		reqCopy.Ext = ext
+
+		requestBody, err := jsoncodec.Marshal(reqCopy)
+		if err != nil {
+			errs = append(errs, adapters.NewMarshalError(bidderCode, err))
+			continue
+		}
 
-	return []*adapters.RequestData{
-		{Method: "POST", URI: a.endpoint, Body: requestBody, Headers: headers},
-	}, nil
+		headers := http.Header{}
+		headers.Set("Content-Type", "application/json;charset=utf-8")
+		headers.Set("Accept", "application/json")
+
+		requests = append(requests, &adapters.RequestData{Method: "POST", URI: a.endpoint, Body: requestBody, Headers: headers})
+	}
+	return requests, errs
 }
 
 // MakeBids parses OpenX responses into bids
@@ -46,13 +159,16 @@ func (a *Adapter) MakeBids(request *openrtb.BidRequest, responseData *adapters.R
 	if responseData.StatusCode == http.StatusNoContent {
 		return nil, nil
 	}
+	if responseData.StatusCode == http.StatusBadRequest {
+		return nil, []error{adapters.NewBadRequestError(bidderCode, string(responseData.Body))}
+	}
 	if responseData.StatusCode != http.StatusOK {
-		return nil, []error{fmt.Errorf("unexpected status: %d", responseData.StatusCode)}
+		return nil, []error{adapters.NewBadStatusError(bidderCode, responseData.StatusCode)}
 	}
 
 	var bidResp openrtb.BidResponse
-	if err := json.Unmarshal(responseData.Body, &bidResp); err != nil {
-		return nil, []error{fmt.Errorf("failed to parse response: %v", err)}
+	if err := jsoncodec.Unmarshal(responseData.Body, &bidResp); err != nil {
+		return nil, []error{adapters.NewParseError(bidderCode, err)}
 	}
 
 	response := &adapters.BidderResponse{Currency: bidResp.Cur, ResponseID: bidResp.ID, Bids: make([]*adapters.TypedBid, 0)}
@@ -74,10 +190,11 @@ func (a *Adapter) MakeBids(request *openrtb.BidRequest, responseData *adapters.R
 // Info returns bidder information
 func Info() adapters.BidderInfo {
 	return adapters.BidderInfo{
-		Enabled:     true,
-		GVLVendorID: 69,
-		Endpoint:    defaultEndpoint,
-		Maintainer:  &adapters.MaintainerInfo{Email: "prebid@openx.com"},
+		Enabled:      true,
+		GVLVendorID:  69,
+		Endpoint:     defaultEndpoint,
+		ParamsSchema: json.RawMessage(paramsSchema),
+		Maintainer:   &adapters.MaintainerInfo{Email: "prebid@openx.com"},
 		Capabilities: &adapters.CapabilitiesInfo{
 			Site: &adapters.PlatformInfo{MediaTypes: []adapters.BidType{adapters.BidTypeBanner, adapters.BidTypeVideo}},
 			App:  &adapters.PlatformInfo{MediaTypes: []adapters.BidType{adapters.BidTypeBanner, adapters.BidTypeVideo}},
@@ -86,5 +203,5 @@ func Info() adapters.BidderInfo {
 }
 
 func init() {
-	adapters.RegisterAdapter("openx", New(""), Info())
+	adapters.RegisterAdapter(bidderCode, New(""), Info())
 }
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters/adapterstest"
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
 )
 
@@ -22,7 +23,7 @@ func TestMakeRequests(t *testing.T) {
 	request := &openrtb.BidRequest{
 		ID: "test-request-1",
 		Imp: []openrtb.Imp{
-			{ID: "imp-1", Banner: &openrtb.Banner{W: 300, H: 250}},
+			{ID: "imp-1", Banner: &openrtb.Banner{W: 300, H: 250}, Ext: []byte(`{"prebid":{"bidder":{"openx":{"unit":"539439964","delDomain":"se-demo-d.openx.net"}}}}`)},
 		},
 		Site: &openrtb.Site{Domain: "example.com"},
 	}
@@ -98,3 +99,18 @@ func TestInfo(t *testing.T) {
 	}
 }
 
+func TestJSONSamples(t *testing.T) {
+	adapterstest.RunJSONBidderTest(t, "openxtest", New(""))
+}
+
+func TestParamsSchema(t *testing.T) {
+	adapterstest.RunParamsSchemaTest(t, Info(), []string{
+		`{"unit":"1","delDomain":"se-demo-d.openx.net"}`,
+		`{"unit":"1","platform":"p","customFloor":0.5,"customParams":{"k":"v"}}`,
+	}, []string{
+		`{"delDomain":"se-demo-d.openx.net"}`,
+		`{"unit":"1"}`,
+		`{"unit":1,"platform":"p"}`,
+		`{"unit":"1","platform":"p","customFloor":-1}`,
+	})
+}
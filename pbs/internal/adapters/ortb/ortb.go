@@ -10,12 +10,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/jsoncodec"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/vast"
 )
 
 // BidderConfig represents a dynamic bidder configuration
@@ -37,48 +42,201 @@ type BidderConfig struct {
 	BlockedPublishers []string                `json:"blocked_publishers"`
 	AllowedCountries  []string                `json:"allowed_countries"`
 	BlockedCountries  []string                `json:"blocked_countries"`
-	DemandType        string                  `json:"demand_type"` // "platform" or "publisher"
+	DemandType        string                  `json:"demand_type"`               // "platform" or "publisher"
+	TrafficPercent    *int                    `json:"traffic_percent,omitempty"` // Share of eligible auctions that call the bidder (0-100, nil = 100)
+	Sandbox           bool                    `json:"sandbox,omitempty"`         // Run adapter work under the exchange's sandbox limits
+	Probe             string                  `json:"probe,omitempty"`           // Availability probe: head (default), options, test_bid or none
+}
+
+// Availability probe modes for BidderConfig.Probe
+const (
+	ProbeHead    = "head"     // HEAD the endpoint; any non-5xx response counts as available
+	ProbeOptions = "options"  // OPTIONS the endpoint, for bidders that reject HEAD
+	ProbeTestBid = "test_bid" // POST a one-imp test request; only 200/204 counts as available
+	ProbeNone    = "none"     // Don't probe
+)
+
+// validate rejects config values that would be silently misapplied
+func (c *BidderConfig) validate() error {
+	if c.TrafficPercent != nil && (*c.TrafficPercent < 0 || *c.TrafficPercent > 100) {
+		return fmt.Errorf("traffic_percent must be between 0 and 100, got %d", *c.TrafficPercent)
+	}
+	switch c.Probe {
+	case "", ProbeHead, ProbeOptions, ProbeTestBid, ProbeNone:
+	default:
+		return fmt.Errorf("probe must be head, options, test_bid or none, got %q", c.Probe)
+	}
+	for _, field := range c.Capabilities.IgnoredFields {
+		if !slices.Contains(adapters.IgnorableFields, field) {
+			return fmt.Errorf("ignored_fields must be one of %s, got %q", strings.Join(adapters.IgnorableFields, ", "), field)
+		}
+	}
+	for _, kind := range c.Capabilities.UnsupportedInventory {
+		if !slices.Contains(adapters.InventoryKinds, kind) {
+			return fmt.Errorf("unsupported_inventory must be one of %s, got %q", strings.Join(adapters.InventoryKinds, ", "), kind)
+		}
+	}
+	for _, source := range c.Capabilities.EIDSources {
+		if strings.TrimSpace(source) == "" {
+			return fmt.Errorf("eid_sources must not contain empty sources")
+		}
+	}
+	return nil
+}
+
+// Bounds for EndpointConfig.TimeoutMS; 0 uses the auction's timeout
+const (
+	MinTimeoutMS = 10
+	MaxTimeoutMS = 5000
+)
+
+// Bidder statuses; active and testing bidders receive traffic
+var bidderStatuses = []string{"active", "paused", "testing", "disabled"}
+
+var bidderCodePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// Validate checks a config before it's written to Redis. It's stricter than
+// the checks applied when configs are loaded, which must keep accepting
+// configs written by older versions of the admin service.
+func (c *BidderConfig) Validate() error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+	if !bidderCodePattern.MatchString(c.BidderCode) {
+		return fmt.Errorf("bidder_code must be 1-64 lowercase letters, digits, _ or -, got %q", c.BidderCode)
+	}
+	if !slices.Contains(bidderStatuses, c.Status) {
+		return fmt.Errorf("status must be one of %s, got %q", strings.Join(bidderStatuses, ", "), c.Status)
+	}
+	switch c.DemandType {
+	case "", string(adapters.DemandTypePlatform), string(adapters.DemandTypePublisher):
+	default:
+		return fmt.Errorf("demand_type must be platform or publisher, got %q", c.DemandType)
+	}
+	if err := c.Endpoint.validate(); err != nil {
+		return fmt.Errorf("endpoint: %w", err)
+	}
+	if err := c.RequestTransform.validate(); err != nil {
+		return fmt.Errorf("request_transform: %w", err)
+	}
+	if l := c.RateLimits; l.QPSLimit < 0 || l.DailyLimit < 0 || l.ConcurrentLimit < 0 {
+		return fmt.Errorf("rate_limits cannot be negative")
+	}
+	if c.ResponseTransform.PriceAdjustment < 0 {
+		return fmt.Errorf("response_transform: price_adjustment cannot be negative, got %v", c.ResponseTransform.PriceAdjustment)
+	}
+	return nil
+}
+
+func (e *EndpointConfig) validate() error {
+	u, err := url.Parse(e.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url must be an absolute http or https URL, got %q", e.URL)
+	}
+	if e.Method != http.MethodPost {
+		return fmt.Errorf("method must be POST, got %q", e.Method)
+	}
+	if e.TimeoutMS != 0 && (e.TimeoutMS < MinTimeoutMS || e.TimeoutMS > MaxTimeoutMS) {
+		return fmt.Errorf("timeout_ms must be 0 or between %d and %d, got %d", MinTimeoutMS, MaxTimeoutMS, e.TimeoutMS)
+	}
+	switch e.ProtocolVersion {
+	case "", ProtocolVersion25, ProtocolVersion26:
+	default:
+		return fmt.Errorf("protocol_version must be %s or %s, got %q", ProtocolVersion25, ProtocolVersion26, e.ProtocolVersion)
+	}
+	switch e.AuthType {
+	case "", "none":
+	case "basic":
+		if e.AuthUsername == "" {
+			return fmt.Errorf("auth_username is required for basic auth")
+		}
+	case "bearer":
+		if e.AuthToken == "" {
+			return fmt.Errorf("auth_token is required for bearer auth")
+		}
+	case "header":
+		if e.AuthHeaderName == "" || e.AuthHeaderValue == "" {
+			return fmt.Errorf("auth_header_name and auth_header_value are required for header auth")
+		}
+	default:
+		return fmt.Errorf("auth_type must be none, basic, bearer or header, got %q", e.AuthType)
+	}
+	if e.MaxImpsPerRequest < 0 {
+		return fmt.Errorf("max_imps_per_request cannot be negative, got %d", e.MaxImpsPerRequest)
+	}
+	if e.RequestCompression != "" && e.RequestCompression != adapters.EncodingGzip {
+		return fmt.Errorf("request_compression must be empty or %s, got %q", adapters.EncodingGzip, e.RequestCompression)
+	}
+	return nil
+}
+
+func (t *RequestTransformConfig) validate() error {
+	for from, to := range t.FieldMappings {
+		if from == "" || to == "" {
+			return fmt.Errorf("field_mappings cannot have empty paths")
+		}
+	}
+	if slices.Contains(t.FieldRemovals, "") {
+		return fmt.Errorf("field_removals cannot have empty paths")
+	}
+	if t.SChainAugment.Enabled {
+		for i, node := range t.SChainAugment.Nodes {
+			if node.ASI == "" || node.SID == "" {
+				return fmt.Errorf("schain_augment.nodes[%d]: asi and sid are required", i)
+			}
+			if node.HP != 0 && node.HP != 1 {
+				return fmt.Errorf("schain_augment.nodes[%d]: hp must be 0 or 1, got %d", i, node.HP)
+			}
+		}
+	}
+	return nil
 }
 
 // EndpointConfig holds endpoint configuration
 type EndpointConfig struct {
-	URL             string            `json:"url"`
-	Method          string            `json:"method"`
-	TimeoutMS       int               `json:"timeout_ms"`
-	ProtocolVersion string            `json:"protocol_version"`
-	AuthType        string            `json:"auth_type"`
-	AuthUsername    string            `json:"auth_username"`
-	AuthPassword    string            `json:"auth_password"`
-	AuthToken       string            `json:"auth_token"`
-	AuthHeaderName  string            `json:"auth_header_name"`
-	AuthHeaderValue string            `json:"auth_header_value"`
-	CustomHeaders   map[string]string `json:"custom_headers"`
+	URL               string            `json:"url"`
+	Method            string            `json:"method"`
+	TimeoutMS         int               `json:"timeout_ms"`
+	ProtocolVersion   string            `json:"protocol_version"`
+	AuthType          string            `json:"auth_type"`
+	AuthUsername      string            `json:"auth_username"`
+	AuthPassword      string            `json:"auth_password"`
+	AuthToken         string            `json:"auth_token"`
+	AuthHeaderName    string            `json:"auth_header_name"`
+	AuthHeaderValue   string            `json:"auth_header_value"`
+	CustomHeaders     map[string]string `json:"custom_headers"`
+	MaxImpsPerRequest int               `json:"max_imps_per_request"` // 0 = unlimited
+	// RequestCompression compresses request bodies for endpoints that accept it ("gzip"; "" sends them as is)
+	RequestCompression string `json:"request_compression"`
 }
 
 // CapabilitiesConfig holds capability information
 type CapabilitiesConfig struct {
-	MediaTypes     []string `json:"media_types"`
-	Currencies     []string `json:"currencies"`
-	SiteEnabled    bool     `json:"site_enabled"`
-	AppEnabled     bool     `json:"app_enabled"`
-	VideoProtocols []int    `json:"video_protocols"`
-	VideoMimes     []string `json:"video_mimes"`
-	SupportsGDPR   bool     `json:"supports_gdpr"`
-	SupportsCCPA   bool     `json:"supports_ccpa"`
-	SupportsCOPPA  bool     `json:"supports_coppa"`
-	SupportsGPP    bool     `json:"supports_gpp"`
-	SupportsSChain bool     `json:"supports_schain"`
-	SupportsEIDs   bool     `json:"supports_eids"`
-	SupportsFPD    bool     `json:"supports_first_party_data"`
-	SupportsCTV    bool     `json:"supports_ctv"`
-	SupportsAdPods bool     `json:"supports_ad_pods"`
-}
-
-// RateLimitsConfig holds rate limiting configuration
+	MediaTypes           []string `json:"media_types"`
+	Currencies           []string `json:"currencies"`
+	SiteEnabled          bool     `json:"site_enabled"`
+	AppEnabled           bool     `json:"app_enabled"`
+	VideoProtocols       []int    `json:"video_protocols"`
+	VideoMimes           []string `json:"video_mimes"`
+	SupportsGDPR         bool     `json:"supports_gdpr"`
+	SupportsCCPA         bool     `json:"supports_ccpa"`
+	SupportsCOPPA        bool     `json:"supports_coppa"`
+	SupportsGPP          bool     `json:"supports_gpp"`
+	SupportsSChain       bool     `json:"supports_schain"`
+	SupportsEIDs         bool     `json:"supports_eids"`
+	SupportsFPD          bool     `json:"supports_first_party_data"`
+	SupportsCTV          bool     `json:"supports_ctv"`
+	SupportsAdPods       bool     `json:"supports_ad_pods"`
+	IgnoredFields        []string `json:"ignored_fields"`        // adapters.IgnorableFields the bidder doesn't read
+	UnsupportedInventory []string `json:"unsupported_inventory"` // adapters.InventoryKinds the bidder doesn't buy
+	EIDSources           []string `json:"eid_sources"`           // user.eids sources the bidder may receive; empty allows all
+}
+
+// RateLimitsConfig caps how often each server instance calls the bidder; 0 is unlimited
 type RateLimitsConfig struct {
-	QPSLimit        int `json:"qps_limit"`
-	DailyLimit      int `json:"daily_limit"`
-	ConcurrentLimit int `json:"concurrent_limit"`
+	QPSLimit        int `json:"qps_limit"`        // Calls per second
+	DailyLimit      int `json:"daily_limit"`      // Calls per UTC day
+	ConcurrentLimit int `json:"concurrent_limit"` // Auctions calling the bidder at once
 }
 
 // SChainNodeConfig holds a single supply chain node configuration
@@ -177,7 +335,7 @@ func (a *GenericAdapter) MakeRequests(request *openrtb.BidRequest, extraInfo *ad
 	reqCopy := a.transformRequest(request, config)
 
 	// Marshal request body
-	requestBody, err := json.Marshal(reqCopy)
+	requestBody, err := jsoncodec.Marshal(reqCopy)
 	if err != nil {
 		return nil, []error{fmt.Errorf("failed to marshal request: %v", err)}
 	}
@@ -185,6 +343,13 @@ func (a *GenericAdapter) MakeRequests(request *openrtb.BidRequest, extraInfo *ad
 	// Build headers
 	headers := a.buildHeaders(config)
 
+	if config.Endpoint.RequestCompression == adapters.EncodingGzip {
+		if requestBody, err = adapters.GzipBody(requestBody); err != nil {
+			return nil, []error{fmt.Errorf("failed to compress request: %v", err)}
+		}
+		headers.Set("Content-Encoding", adapters.EncodingGzip)
+	}
+
 	return []*adapters.RequestData{
 		{
 			Method:  config.Endpoint.Method,
@@ -218,7 +383,7 @@ func (a *GenericAdapter) MakeBids(request *openrtb.BidRequest, responseData *ada
 
 	// Parse response
 	var bidResp openrtb.BidResponse
-	if err := json.Unmarshal(responseData.Body, &bidResp); err != nil {
+	if err := jsoncodec.Unmarshal(responseData.Body, &bidResp); err != nil {
 		return nil, []error{fmt.Errorf("failed to parse response from %s: %v", config.BidderCode, err)}
 	}
 
@@ -255,6 +420,11 @@ func (a *GenericAdapter) transformRequest(request *openrtb.BidRequest, config *B
 	// Create a copy to modify
 	reqCopy := *request
 
+	// Normalize to 2.6 so the transforms below see each signal in one place
+	if config.Endpoint.ProtocolVersion == ProtocolVersion25 || config.Endpoint.ProtocolVersion == ProtocolVersion26 {
+		upgradeTo26(&reqCopy)
+	}
+
 	// Apply request extension template
 	if len(config.RequestTransform.RequestExtTemplate) > 0 {
 		reqCopy.Ext = mergeJSONExt(reqCopy.Ext, config.RequestTransform.RequestExtTemplate)
@@ -290,6 +460,10 @@ func (a *GenericAdapter) transformRequest(request *openrtb.BidRequest, config *B
 		reqCopy.Source = a.augmentSChain(reqCopy.Source, &config.RequestTransform.SChainAugment)
 	}
 
+	if config.Endpoint.ProtocolVersion == ProtocolVersion25 {
+		downgradeTo25(&reqCopy)
+	}
+
 	return &reqCopy
 }
 
@@ -388,6 +562,13 @@ func (a *GenericAdapter) transformBid(bid *openrtb.Bid, config *BidderConfig) {
 	if config.ResponseTransform.PriceAdjustment != 0 && config.ResponseTransform.PriceAdjustment != 1.0 {
 		bid.Price = bid.Price * config.ResponseTransform.PriceAdjustment
 	}
+
+	// Fill in dur from the VAST for bidders that don't send it
+	if config.ResponseTransform.ExtractDurationFromVAST && bid.Dur == 0 && bid.AdM != "" {
+		if info, err := vast.Parse(bid.AdM); err == nil {
+			bid.Dur = info.Duration
+		}
+	}
 }
 
 // buildHeaders creates HTTP headers for the request
@@ -436,7 +617,12 @@ func (a *GenericAdapter) Info() adapters.BidderInfo {
 		Maintainer: &adapters.MaintainerInfo{
 			Email: config.MaintainerEmail,
 		},
-		Endpoint: config.Endpoint.URL,
+		Endpoint:             config.Endpoint.URL,
+		MaxImpsPerRequest:    config.Endpoint.MaxImpsPerRequest,
+		IgnoredFields:        config.Capabilities.IgnoredFields,
+		UnsupportedInventory: config.Capabilities.UnsupportedInventory,
+		EIDSources:           config.Capabilities.EIDSources,
+		SupportsCOPPA:        config.Capabilities.SupportsCOPPA,
 	}
 
 	// Set GVL Vendor ID if present
@@ -490,6 +676,80 @@ func (a *GenericAdapter) GetTimeout() time.Duration {
 	return time.Duration(a.config.Endpoint.TimeoutMS) * time.Millisecond
 }
 
+// GetTrafficPercent returns the share of eligible auctions (0-100) that call this bidder
+func (a *GenericAdapter) GetTrafficPercent() int {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.config.TrafficPercent == nil {
+		return 100
+	}
+	return *a.config.TrafficPercent
+}
+
+// GetRateLimits returns the bidder's call limits; zero values are unlimited
+func (a *GenericAdapter) GetRateLimits() RateLimitsConfig {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.config.RateLimits
+}
+
+// IsSandboxed reports whether the bidder's config asks for sandboxed execution
+func (a *GenericAdapter) IsSandboxed() bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.config.Sandbox
+}
+
+// ProbeRequest returns the availability probe for the bidder's endpoint, with
+// the bidder's auth headers, or nil when the bidder opts out of probing
+func (a *GenericAdapter) ProbeRequest() *adapters.RequestData {
+	a.mu.RLock()
+	config := a.config
+	a.mu.RUnlock()
+
+	if config.Endpoint.URL == "" {
+		return nil
+	}
+	req := &adapters.RequestData{
+		Method:  http.MethodHead,
+		URI:     config.Endpoint.URL,
+		Headers: a.buildHeaders(config),
+	}
+	switch config.Probe {
+	case ProbeNone:
+		return nil
+	case ProbeOptions:
+		req.Method = http.MethodOptions
+	case ProbeTestBid:
+		body, err := json.Marshal(probeBidRequest())
+		if err != nil {
+			return nil
+		}
+		req.Method = http.MethodPost
+		req.Body = body
+	}
+	return req
+}
+
+// probeBidRequest is the smallest valid test request: bidders must not bill or
+// record test=1 traffic
+func probeBidRequest() *openrtb.BidRequest {
+	return &openrtb.BidRequest{
+		ID:   "availability-probe",
+		Test: 1,
+		TMax: 200,
+		Imp:  []openrtb.Imp{{ID: "1", Banner: &openrtb.Banner{W: 300, H: 250}}},
+		Site: &openrtb.Site{Domain: "probe.invalid", Page: "https://probe.invalid/"},
+	}
+}
+
+// GetMaxImpsPerRequest returns the bidder's imp batch limit (0 = unlimited)
+func (a *GenericAdapter) GetMaxImpsPerRequest() int {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.config.Endpoint.MaxImpsPerRequest
+}
+
 // CanBidForPublisher checks if this bidder can bid for a specific publisher
 func (a *GenericAdapter) CanBidForPublisher(publisherID string) bool {
 	a.mu.RLock()
@@ -1,11 +1,15 @@
 package ortb
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"maps"
 	"net/http"
+	"slices"
 	"strings"
 	"sync"
 	"testing"
@@ -134,6 +138,28 @@ func TestGenericAdapter_MakeRequests_Headers(t *testing.T) {
 	}
 }
 
+func TestGenericAdapter_MakeRequests_Gzip(t *testing.T) {
+	config := basicConfig()
+	config.Endpoint.RequestCompression = adapters.EncodingGzip
+	requests, errs := New(config).MakeRequests(testBidRequest(), nil)
+	if len(errs) > 0 || len(requests) != 1 {
+		t.Fatalf("unexpected result: %v", errs)
+	}
+
+	req := requests[0]
+	if req.Headers.Get("Content-Encoding") != "gzip" {
+		t.Error("expected Content-Encoding: gzip")
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(req.Body))
+	if err != nil {
+		t.Fatalf("expected a gzip body: %v", err)
+	}
+	var decoded openrtb.BidRequest
+	if err := json.NewDecoder(zr).Decode(&decoded); err != nil || decoded.ID != testBidRequest().ID {
+		t.Errorf("expected the bid request compressed, got %+v (%v)", decoded, err)
+	}
+}
+
 func TestGenericAdapter_MakeBids_Success(t *testing.T) {
 	config := basicConfig()
 	adapter := New(config)
@@ -364,6 +390,35 @@ func TestGenericAdapter_MakeBids_BidType(t *testing.T) {
 	}
 }
 
+func TestGenericAdapter_TransformBid_ExtractDurationFromVAST(t *testing.T) {
+	vastXML := `<VAST version="3.0"><Ad><InLine><Creatives><Creative><Linear><Duration>00:00:30</Duration></Linear></Creative></Creatives></InLine></Ad></VAST>`
+	bidResp := openrtb.BidResponse{
+		SeatBid: []openrtb.SeatBid{{Bid: []openrtb.Bid{
+			{ID: "bid-1", ImpID: "imp-1", Price: 1, AdM: vastXML},
+			{ID: "bid-2", ImpID: "imp-1", Price: 1, AdM: vastXML, Dur: 15},
+			{ID: "bid-3", ImpID: "imp-1", Price: 1, AdM: "<div>banner</div>"},
+		}}},
+	}
+	respBody, _ := json.Marshal(bidResp)
+	responseData := &adapters.ResponseData{StatusCode: http.StatusOK, Body: respBody}
+
+	for _, extract := range []bool{false, true} {
+		config := basicConfig()
+		config.ResponseTransform.ExtractDurationFromVAST = extract
+		response, _ := New(config).MakeBids(testBidRequest(), responseData)
+
+		want := map[string]int{"bid-1": 0, "bid-2": 15, "bid-3": 0}
+		if extract {
+			want["bid-1"] = 30
+		}
+		for _, tb := range response.Bids {
+			if tb.Bid.Dur != want[tb.Bid.ID] {
+				t.Errorf("extract=%v: expected %s dur %d, got %d", extract, tb.Bid.ID, want[tb.Bid.ID], tb.Bid.Dur)
+			}
+		}
+	}
+}
+
 func TestGenericAdapter_TransformBid_PriceAdjustment(t *testing.T) {
 	config := basicConfig()
 	config.ResponseTransform.PriceAdjustment = 0.9 // 10% discount
@@ -988,6 +1043,429 @@ func TestDynamicRegistry_Refresh_InvalidJSON(t *testing.T) {
 	}
 }
 
+func TestDynamicRegistry_Refresh_TrafficPercent(t *testing.T) {
+	redis := newMockRedisClient()
+	percent := 25
+	config := basicConfig()
+	config.BidderCode = "bidder1"
+	config.TrafficPercent = &percent
+	redis.setBidder("bidder1", config)
+	redis.setBidder("bidder2", basicConfig())
+
+	registry := NewDynamicRegistry(redis, 1*time.Minute)
+	registry.Refresh(context.Background())
+
+	adapter, _ := registry.Get("bidder1")
+	if adapter.GetTrafficPercent() != 25 {
+		t.Errorf("expected traffic percent 25, got %d", adapter.GetTrafficPercent())
+	}
+	if unset, _ := registry.Get("bidder2"); unset.GetTrafficPercent() != 100 {
+		t.Errorf("expected unset traffic percent to default to 100, got %d", unset.GetTrafficPercent())
+	}
+
+	// Out-of-range values are rejected and the previous config stays in effect
+	redis.hashData["bidder1"] = `{"bidder_code":"bidder1","status":"active","traffic_percent":150}`
+	registry.Refresh(context.Background())
+
+	adapter, ok := registry.Get("bidder1")
+	if !ok || adapter.GetTrafficPercent() != 25 {
+		t.Error("expected invalid traffic_percent to keep the previous config")
+	}
+}
+
+func TestGenericAdapter_ProbeRequest(t *testing.T) {
+	config := basicConfig()
+	config.Endpoint.AuthType = "bearer"
+	config.Endpoint.AuthToken = "secret"
+
+	req := New(config).ProbeRequest()
+	if req == nil || req.Method != http.MethodHead || req.URI != config.Endpoint.URL || len(req.Body) != 0 {
+		t.Fatalf("expected default HEAD probe of the endpoint, got %+v", req)
+	}
+	if req.Headers.Get("Authorization") != "Bearer secret" {
+		t.Errorf("expected probe to carry the bidder's auth, got %v", req.Headers)
+	}
+
+	config.Probe = ProbeOptions
+	if req := New(config).ProbeRequest(); req.Method != http.MethodOptions {
+		t.Errorf("expected OPTIONS probe, got %s", req.Method)
+	}
+
+	config.Probe = ProbeTestBid
+	req = New(config).ProbeRequest()
+	var bidReq openrtb.BidRequest
+	if err := json.Unmarshal(req.Body, &bidReq); err != nil || req.Method != http.MethodPost {
+		t.Fatalf("expected POSTed test bid, got %s %s: %v", req.Method, req.Body, err)
+	}
+	if bidReq.Test != 1 || len(bidReq.Imp) != 1 {
+		t.Errorf("expected a one-imp test request, got %+v", bidReq)
+	}
+
+	config.Probe = ProbeNone
+	if req := New(config).ProbeRequest(); req != nil {
+		t.Errorf("expected no probe, got %+v", req)
+	}
+}
+
+func TestBidderConfig_ValidateProbe(t *testing.T) {
+	config := basicConfig()
+	for _, mode := range []string{"", ProbeHead, ProbeOptions, ProbeTestBid, ProbeNone} {
+		config.Probe = mode
+		if err := config.validate(); err != nil {
+			t.Errorf("probe %q: unexpected error %v", mode, err)
+		}
+	}
+	config.Probe = "ping"
+	if err := config.validate(); err == nil {
+		t.Error("expected unknown probe mode to be rejected")
+	}
+}
+
+func TestBidderConfig_IgnoredFields(t *testing.T) {
+	config := basicConfig()
+	config.Capabilities.IgnoredFields = []string{adapters.IgnoredSiteContent, adapters.IgnoredDeviceLegacyIDs}
+	if err := config.validate(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if info := New(config).Info(); len(info.IgnoredFields) != 2 || info.IgnoredFields[1] != adapters.IgnoredDeviceLegacyIDs {
+		t.Errorf("expected ignored fields in bidder info, got %v", info.IgnoredFields)
+	}
+
+	config.Capabilities.IgnoredFields = []string{"imp.ext"}
+	if err := config.validate(); err == nil {
+		t.Error("expected unknown ignored field to be rejected")
+	}
+}
+
+func TestBidderConfig_UnsupportedInventory(t *testing.T) {
+	config := basicConfig()
+	config.Capabilities.UnsupportedInventory = []string{adapters.InventoryRewarded}
+	if err := config.validate(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if info := New(config).Info(); info.SupportsInventory(adapters.InventoryRewarded) || !info.SupportsInventory(adapters.InventoryInterstitial) {
+		t.Errorf("expected only rewarded unsupported, got %v", info.UnsupportedInventory)
+	}
+
+	config.Capabilities.UnsupportedInventory = []string{"outstream"}
+	if err := config.validate(); err == nil {
+		t.Error("expected unknown inventory kind to be rejected")
+	}
+}
+
+func TestBidderConfig_Validate(t *testing.T) {
+	if err := basicConfig().Validate(); err != nil {
+		t.Fatalf("expected basic config valid, got %v", err)
+	}
+	tests := []struct {
+		name   string
+		modify func(c *BidderConfig)
+		want   string
+	}{
+		{"bidder code", func(c *BidderConfig) { c.BidderCode = "Test Bidder" }, "bidder_code"},
+		{"status", func(c *BidderConfig) { c.Status = "on" }, "status"},
+		{"demand type", func(c *BidderConfig) { c.DemandType = "house" }, "demand_type"},
+		{"relative url", func(c *BidderConfig) { c.Endpoint.URL = "/bid" }, "endpoint: url"},
+		{"ftp url", func(c *BidderConfig) { c.Endpoint.URL = "ftp://bidder.example.com" }, "endpoint: url"},
+		{"method", func(c *BidderConfig) { c.Endpoint.Method = "GET" }, "endpoint: method"},
+		{"short timeout", func(c *BidderConfig) { c.Endpoint.TimeoutMS = 5 }, "endpoint: timeout_ms"},
+		{"long timeout", func(c *BidderConfig) { c.Endpoint.TimeoutMS = 60000 }, "endpoint: timeout_ms"},
+		{"protocol", func(c *BidderConfig) { c.Endpoint.ProtocolVersion = "3.0" }, "endpoint: protocol_version"},
+		{"auth type", func(c *BidderConfig) { c.Endpoint.AuthType = "oauth" }, "endpoint: auth_type"},
+		{"bearer token", func(c *BidderConfig) { c.Endpoint.AuthType = "bearer" }, "endpoint: auth_token"},
+		{"mapping", func(c *BidderConfig) { c.RequestTransform.FieldMappings = map[string]string{"site.id": ""} }, "request_transform: field_mappings"},
+		{"schain node", func(c *BidderConfig) {
+			c.RequestTransform.SChainAugment = SChainAugmentConfig{Enabled: true, Nodes: []SChainNodeConfig{{ASI: "example.com"}}}
+		}, "request_transform: schain_augment.nodes[0]"},
+		{"price adjustment", func(c *BidderConfig) { c.ResponseTransform.PriceAdjustment = -1 }, "response_transform: price_adjustment"},
+		{"traffic percent", func(c *BidderConfig) { p := 150; c.TrafficPercent = &p }, "traffic_percent"},
+		{"rate limits", func(c *BidderConfig) { c.RateLimits.QPSLimit = -1 }, "rate_limits"},
+		{"request compression", func(c *BidderConfig) { c.Endpoint.RequestCompression = "br" }, "endpoint: request_compression"},
+	}
+	for _, tt := range tests {
+		config := basicConfig()
+		tt.modify(config)
+		if err := config.Validate(); err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+			t.Errorf("%s: expected error starting %q, got %v", tt.name, tt.want, err)
+		}
+	}
+}
+
+// writableRedis is an in-memory RedisClient and RedisWriter
+type writableRedis struct {
+	hashes map[string]map[string]string
+	sets   map[string]map[string]bool
+	zsets  map[string]map[string]float64
+}
+
+func newWritableRedis() *writableRedis {
+	return &writableRedis{hashes: map[string]map[string]string{}, sets: map[string]map[string]bool{}, zsets: map[string]map[string]float64{}}
+}
+
+func (m *writableRedis) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	return maps.Clone(m.hashes[key]), nil
+}
+
+func (m *writableRedis) HGet(ctx context.Context, key, field string) (string, error) {
+	return m.hashes[key][field], nil
+}
+
+func (m *writableRedis) SMembers(ctx context.Context, key string) ([]string, error) {
+	return slices.Collect(maps.Keys(m.sets[key])), nil
+}
+
+func (m *writableRedis) HSet(ctx context.Context, key, field, value string) error {
+	if m.hashes[key] == nil {
+		m.hashes[key] = map[string]string{}
+	}
+	m.hashes[key][field] = value
+	return nil
+}
+
+func (m *writableRedis) HDel(ctx context.Context, key string, fields ...string) error {
+	for _, f := range fields {
+		delete(m.hashes[key], f)
+	}
+	return nil
+}
+
+func (m *writableRedis) SAdd(ctx context.Context, key string, members ...string) error {
+	if m.sets[key] == nil {
+		m.sets[key] = map[string]bool{}
+	}
+	for _, v := range members {
+		m.sets[key][v] = true
+	}
+	return nil
+}
+
+func (m *writableRedis) SRem(ctx context.Context, key string, members ...string) error {
+	for _, v := range members {
+		delete(m.sets[key], v)
+	}
+	return nil
+}
+
+func (m *writableRedis) ZAdd(ctx context.Context, key, member string, score float64) error {
+	if m.zsets[key] == nil {
+		m.zsets[key] = map[string]float64{}
+	}
+	m.zsets[key][member] = score
+	return nil
+}
+
+func (m *writableRedis) ZRem(ctx context.Context, key string, members ...string) error {
+	for _, v := range members {
+		delete(m.zsets[key], v)
+	}
+	return nil
+}
+
+func TestDynamicRegistry_SaveAndDelete(t *testing.T) {
+	redis := newWritableRedis()
+	registry := NewDynamicRegistry(redis, time.Minute)
+	ctx := context.Background()
+
+	config := basicConfig()
+	config.Endpoint.Method = ""
+	config.Priority = 7
+	created, err := registry.Save(ctx, config)
+	if err != nil || !created {
+		t.Fatalf("expected bidder created, got %v, %v", created, err)
+	}
+	if !redis.sets[redisBiddersActive]["testbidder"] || redis.zsets[redisBiddersIndex]["testbidder"] != 7 {
+		t.Error("expected the active set and priority index updated")
+	}
+	if err := registry.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+	adapter, ok := registry.Get("testbidder")
+	if !ok || adapter.GetConfig().Endpoint.Method != http.MethodPost {
+		t.Fatalf("expected the saved bidder loaded with method POST, got %+v", adapter)
+	}
+
+	config.Status = "paused"
+	if created, err := registry.Save(ctx, config); err != nil || created {
+		t.Fatalf("expected bidder updated, got %v, %v", created, err)
+	}
+	if redis.sets[redisBiddersActive]["testbidder"] {
+		t.Error("expected a paused bidder removed from the active set")
+	}
+
+	config.Endpoint.URL = "not a url"
+	if _, err := registry.Save(ctx, config); err == nil {
+		t.Error("expected an invalid config rejected")
+	}
+
+	if deleted, err := registry.Delete(ctx, "testbidder"); err != nil || !deleted {
+		t.Fatalf("expected bidder deleted, got %v, %v", deleted, err)
+	}
+	if deleted, _ := registry.Delete(ctx, "testbidder"); deleted {
+		t.Error("expected deleting a missing bidder to report false")
+	}
+	registry.Refresh(ctx)
+	if _, ok := registry.Get("testbidder"); ok || len(redis.zsets[redisBiddersIndex]) != 0 {
+		t.Error("expected the bidder gone from the registry and index")
+	}
+}
+
+func TestDynamicRegistry_SaveReadOnly(t *testing.T) {
+	registry := NewDynamicRegistry(newMockRedisClient(), time.Minute)
+	if _, err := registry.Save(context.Background(), basicConfig()); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expected ErrReadOnly, got %v", err)
+	}
+	if _, err := registry.Delete(context.Background(), "testbidder"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expected ErrReadOnly, got %v", err)
+	}
+}
+
+// mockRefreshMetrics records calls made through the RefreshMetrics interface
+type mockRefreshMetrics struct {
+	successes   int
+	failures    int
+	lastLoaded  int
+	lastFailed  int
+	parseErrors []string
+	stale       []bool
+}
+
+func (m *mockRefreshMetrics) RecordDynamicRegistryRefresh(success bool, duration time.Duration, loaded, failed int) {
+	if success {
+		m.successes++
+	} else {
+		m.failures++
+	}
+	m.lastLoaded = loaded
+	m.lastFailed = failed
+}
+
+func (m *mockRefreshMetrics) IncDynamicRegistryParseError(bidderCode string) {
+	m.parseErrors = append(m.parseErrors, bidderCode)
+}
+
+func (m *mockRefreshMetrics) SetDynamicRegistryStale(stale bool) {
+	m.stale = append(m.stale, stale)
+}
+
+func TestDynamicRegistry_Refresh_ParseErrorMetrics(t *testing.T) {
+	redis := newMockRedisClient()
+	redis.hashData["invalid"] = "not json"
+	redis.hashData["valid"] = `{"bidder_code":"valid","name":"Valid","status":"active"}`
+
+	registry := NewDynamicRegistry(redis, 1*time.Minute)
+	pm := &mockRefreshMetrics{}
+	registry.SetMetrics(pm)
+
+	if err := registry.Refresh(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	metrics := registry.GetRegistryMetrics()
+	if metrics.ConfigsLoaded != 1 || metrics.ConfigsFailed != 1 {
+		t.Errorf("expected 1 loaded and 1 failed, got %d/%d", metrics.ConfigsLoaded, metrics.ConfigsFailed)
+	}
+	if metrics.ParseErrors["invalid"] != 1 {
+		t.Errorf("expected 1 parse error for invalid, got %v", metrics.ParseErrors)
+	}
+
+	if pm.successes != 1 || pm.lastLoaded != 1 || pm.lastFailed != 1 {
+		t.Errorf("unexpected refresh metrics: %+v", pm)
+	}
+	if len(pm.parseErrors) != 1 || pm.parseErrors[0] != "invalid" {
+		t.Errorf("expected parse error for invalid, got %v", pm.parseErrors)
+	}
+}
+
+func TestDynamicRegistry_Refresh_ErrorTracking(t *testing.T) {
+	redis := newMockRedisClient()
+	redis.setBidder("bidder1", basicConfig())
+
+	registry := NewDynamicRegistry(redis, 1*time.Minute)
+	pm := &mockRefreshMetrics{}
+	registry.SetMetrics(pm)
+	registry.Refresh(context.Background())
+
+	redis.hgetAllErr = errors.New("redis down")
+	registry.Refresh(context.Background())
+	registry.Refresh(context.Background())
+
+	metrics := registry.GetRegistryMetrics()
+	if metrics.ConsecutiveErrors != 2 {
+		t.Errorf("expected 2 consecutive errors, got %d", metrics.ConsecutiveErrors)
+	}
+	if !strings.Contains(metrics.LastError, "redis down") {
+		t.Errorf("expected last error to mention cause, got %q", metrics.LastError)
+	}
+	if !metrics.LastRefreshAttempt.After(metrics.LastRefreshTime) {
+		t.Error("expected last attempt to be after last success")
+	}
+	if pm.failures != 2 {
+		t.Errorf("expected 2 failed refreshes recorded, got %d", pm.failures)
+	}
+
+	// Recovery resets the failure streak
+	redis.hgetAllErr = nil
+	registry.Refresh(context.Background())
+	metrics = registry.GetRegistryMetrics()
+	if metrics.ConsecutiveErrors != 0 || metrics.LastError != "" {
+		t.Errorf("expected error state cleared, got %d %q", metrics.ConsecutiveErrors, metrics.LastError)
+	}
+}
+
+func TestDynamicRegistry_StaleAlert(t *testing.T) {
+	redis := newMockRedisClient()
+	redis.setBidder("bidder1", basicConfig())
+
+	registry := NewDynamicRegistry(redis, 10*time.Millisecond)
+	pm := &mockRefreshMetrics{}
+	registry.SetMetrics(pm)
+
+	alerts := 0
+	registry.SetStaleAlert(2, func(age time.Duration) {
+		alerts++
+		if age <= 20*time.Millisecond {
+			t.Errorf("expected age beyond threshold, got %v", age)
+		}
+	})
+
+	registry.Refresh(context.Background())
+	registry.checkStaleness()
+	if _, stale := registry.Staleness(); stale {
+		t.Fatal("expected fresh registry after refresh")
+	}
+
+	redis.hgetAllErr = errors.New("redis down")
+	time.Sleep(30 * time.Millisecond)
+	registry.Refresh(context.Background())
+	registry.checkStaleness()
+	registry.checkStaleness()
+
+	if alerts != 1 {
+		t.Errorf("expected stale alert to fire once, got %d", alerts)
+	}
+	if !registry.Stats().Stale {
+		t.Error("expected stats to report stale")
+	}
+
+	redis.hgetAllErr = nil
+	registry.Refresh(context.Background())
+	registry.checkStaleness()
+
+	if len(pm.stale) != 2 || !pm.stale[0] || pm.stale[1] {
+		t.Errorf("expected stale then recovered, got %v", pm.stale)
+	}
+}
+
+func TestDynamicRegistry_StaleAlert_Disabled(t *testing.T) {
+	registry := NewDynamicRegistry(newMockRedisClient(), time.Nanosecond)
+	registry.SetStaleAlert(0, nil)
+
+	time.Sleep(time.Millisecond)
+	if _, stale := registry.Staleness(); stale {
+		t.Error("expected staleness check to be disabled")
+	}
+}
+
 func TestDynamicRegistry_Get(t *testing.T) {
 	redis := newMockRedisClient()
 	config := basicConfig()
@@ -1405,12 +1883,12 @@ func TestMetrics_ConcurrentAccess(t *testing.T) {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			m.recordRefreshSuccess(10*time.Millisecond, 5, 3)
+			m.recordRefreshSuccess(10*time.Millisecond, 5, 3, 5, 0)
 		}()
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			m.recordRefreshError()
+			m.recordRefreshError(errors.New("refresh failed"))
 		}()
 		wg.Add(1)
 		go func() {
@@ -1939,3 +2417,19 @@ func TestTransformRequest_SChainEmptyNodes(t *testing.T) {
 		t.Error("expected no SChain when nodes are empty")
 	}
 }
+
+func TestBidderConfig_EIDSources(t *testing.T) {
+	config := basicConfig()
+	config.Capabilities.EIDSources = []string{"uidapi.com"}
+	if err := config.validate(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if info := New(config).Info(); !info.AllowsEIDSource("UIDAPI.com") || info.AllowsEIDSource("liveramp.com") {
+		t.Errorf("expected only uidapi.com allowed, got %v", info.EIDSources)
+	}
+
+	config.Capabilities.EIDSources = []string{"uidapi.com", " "}
+	if err := config.validate(); err == nil {
+		t.Error("expected an empty source to be rejected")
+	}
+}
@@ -5,7 +5,9 @@ package ortb
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"sync"
 	"time"
 
@@ -17,6 +19,11 @@ const (
 	// Redis keys for bidder storage
 	redisBiddersHash   = "nexus:bidders"
 	redisBiddersActive = "nexus:bidders:active"
+	redisBiddersIndex  = "nexus:bidders:index" // Sorted by priority, for the admin service
+
+	// DefaultStalePeriods is how many refresh periods may pass without a
+	// successful refresh before the registry is considered stale
+	DefaultStalePeriods = 3
 )
 
 // P3-NEW-1: Metrics tracks operational metrics for the dynamic registry
@@ -28,6 +35,14 @@ type Metrics struct {
 	RefreshErrors      int64         // Failed refresh operations
 	LastRefreshTime    time.Time     // Time of last successful refresh
 	LastRefreshLatency time.Duration // Duration of last refresh
+	LastRefreshAttempt time.Time     // Time of last refresh attempt, successful or not
+	ConsecutiveErrors  int64         // Failed refreshes since the last success
+	LastError          string        // Error from the most recent failed refresh
+
+	// Config parsing
+	ConfigsLoaded int              // Configs parsed on the last successful refresh
+	ConfigsFailed int              // Configs rejected on the last successful refresh
+	ParseErrors   map[string]int64 // Cumulative parse failures by bidder code
 
 	// Lookup operations
 	GetHits   int64 // Successful adapter lookups
@@ -42,11 +57,23 @@ type Metrics struct {
 func (m *Metrics) GetMetrics() Metrics {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
+
+	parseErrors := make(map[string]int64, len(m.ParseErrors))
+	for bidder, count := range m.ParseErrors {
+		parseErrors[bidder] = count
+	}
+
 	return Metrics{
 		RefreshCount:       m.RefreshCount,
 		RefreshErrors:      m.RefreshErrors,
 		LastRefreshTime:    m.LastRefreshTime,
 		LastRefreshLatency: m.LastRefreshLatency,
+		LastRefreshAttempt: m.LastRefreshAttempt,
+		ConsecutiveErrors:  m.ConsecutiveErrors,
+		LastError:          m.LastError,
+		ConfigsLoaded:      m.ConfigsLoaded,
+		ConfigsFailed:      m.ConfigsFailed,
+		ParseErrors:        parseErrors,
 		GetHits:            m.GetHits,
 		GetMisses:          m.GetMisses,
 		TotalAdapters:      m.TotalAdapters,
@@ -54,21 +81,45 @@ func (m *Metrics) GetMetrics() Metrics {
 	}
 }
 
-func (m *Metrics) recordRefreshSuccess(latency time.Duration, total, enabled int) {
+func (m *Metrics) recordRefreshSuccess(latency time.Duration, total, enabled, loaded, failed int) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	now := time.Now()
 	m.RefreshCount++
-	m.LastRefreshTime = time.Now()
+	m.LastRefreshTime = now
+	m.LastRefreshAttempt = now
 	m.LastRefreshLatency = latency
+	m.ConsecutiveErrors = 0
+	m.LastError = ""
+	m.ConfigsLoaded = loaded
+	m.ConfigsFailed = failed
 	m.TotalAdapters = total
 	m.EnabledAdapters = enabled
 }
 
-func (m *Metrics) recordRefreshError() {
+func (m *Metrics) recordRefreshError(err error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.RefreshCount++
 	m.RefreshErrors++
+	m.ConsecutiveErrors++
+	m.LastRefreshAttempt = time.Now()
+	m.LastError = err.Error()
+}
+
+func (m *Metrics) recordParseError(bidderCode string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.ParseErrors == nil {
+		m.ParseErrors = make(map[string]int64)
+	}
+	m.ParseErrors[bidderCode]++
+}
+
+func (m *Metrics) lastSuccess() time.Time {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.LastRefreshTime
 }
 
 func (m *Metrics) recordGetHit() {
@@ -83,6 +134,36 @@ func (m *Metrics) recordGetMiss() {
 	m.GetMisses++
 }
 
+// RefreshMetrics is implemented by metrics backends that export registry refresh health
+type RefreshMetrics interface {
+	RecordDynamicRegistryRefresh(success bool, duration time.Duration, loaded, failed int)
+	IncDynamicRegistryParseError(bidderCode string)
+	SetDynamicRegistryStale(stale bool)
+}
+
+// StaleAlertFunc is called once when the registry becomes stale, with the
+// time elapsed since the last successful refresh
+type StaleAlertFunc func(age time.Duration)
+
+// RegistryStats is a JSON-friendly snapshot of registry health for admin endpoints
+type RegistryStats struct {
+	Stale              bool             `json:"stale"`
+	StaleThresholdMs   int64            `json:"stale_threshold_ms"`
+	SinceLastSuccessMs int64            `json:"since_last_success_ms"`
+	LastSuccess        *time.Time       `json:"last_success,omitempty"`
+	LastAttempt        *time.Time       `json:"last_attempt,omitempty"`
+	LastDurationMs     float64          `json:"last_duration_ms"`
+	LastError          string           `json:"last_error,omitempty"`
+	RefreshCount       int64            `json:"refresh_count"`
+	RefreshErrors      int64            `json:"refresh_errors"`
+	ConsecutiveErrors  int64            `json:"consecutive_errors"`
+	ConfigsLoaded      int              `json:"configs_loaded"`
+	ConfigsFailed      int              `json:"configs_failed"`
+	ParseErrors        map[string]int64 `json:"parse_errors"`
+	TotalAdapters      int              `json:"total_adapters"`
+	EnabledAdapters    int              `json:"enabled_adapters"`
+}
+
 // RedisClient interface for Redis operations
 type RedisClient interface {
 	HGetAll(ctx context.Context, key string) (map[string]string, error)
@@ -90,6 +171,24 @@ type RedisClient interface {
 	HGet(ctx context.Context, key, field string) (string, error)
 }
 
+// RedisWriter is the Redis access Save and Delete need on top of RedisClient
+type RedisWriter interface {
+	HSet(ctx context.Context, key, field, value string) error
+	HDel(ctx context.Context, key string, fields ...string) error
+	SAdd(ctx context.Context, key string, members ...string) error
+	SRem(ctx context.Context, key string, members ...string) error
+	ZAdd(ctx context.Context, key, member string, score float64) error
+	ZRem(ctx context.Context, key string, members ...string) error
+}
+
+var (
+	// ErrReadOnly is returned by Save and Delete when the registry's Redis
+	// client can't write
+	ErrReadOnly = errors.New("dynamic registry redis client is read-only")
+	// ErrInvalidConfig wraps Validate failures from Save
+	ErrInvalidConfig = errors.New("invalid bidder config")
+)
+
 // DynamicRegistry manages dynamically configured bidders
 type DynamicRegistry struct {
 	mu            sync.RWMutex
@@ -99,6 +198,13 @@ type DynamicRegistry struct {
 	stopChan      chan struct{}
 	onUpdate      func(string, *BidderConfig) // Callback when a bidder is updated
 	metrics       *Metrics                    // P3-NEW-1: Operational metrics
+
+	// Refresh health reporting
+	createdAt    time.Time
+	promMetrics  RefreshMetrics
+	stalePeriods int
+	onStale      StaleAlertFunc
+	stale        bool // Only touched by the refresh loop
 }
 
 // NewDynamicRegistry creates a new dynamic registry
@@ -109,6 +215,8 @@ func NewDynamicRegistry(redis RedisClient, refreshPeriod time.Duration) *Dynamic
 		refreshPeriod: refreshPeriod,
 		stopChan:      make(chan struct{}),
 		metrics:       &Metrics{}, // P3-NEW-1: Initialize metrics
+		createdAt:     time.Now(),
+		stalePeriods:  DefaultStalePeriods,
 	}
 }
 
@@ -117,6 +225,92 @@ func (r *DynamicRegistry) GetRegistryMetrics() Metrics {
 	return r.metrics.GetMetrics()
 }
 
+// SetMetrics sets the metrics backend for refresh health reporting
+func (r *DynamicRegistry) SetMetrics(m RefreshMetrics) {
+	r.promMetrics = m
+}
+
+// SetStaleAlert configures how many refresh periods may pass without a
+// successful refresh before fn is called. Periods <= 0 disables the check.
+func (r *DynamicRegistry) SetStaleAlert(periods int, fn StaleAlertFunc) {
+	r.stalePeriods = periods
+	r.onStale = fn
+}
+
+// Staleness returns the time since the last successful refresh and whether
+// that exceeds the configured stale threshold
+func (r *DynamicRegistry) Staleness() (time.Duration, bool) {
+	last := r.metrics.lastSuccess()
+	if last.IsZero() {
+		last = r.createdAt
+	}
+	age := time.Since(last)
+	if r.stalePeriods <= 0 {
+		return age, false
+	}
+	return age, age > r.staleThreshold()
+}
+
+func (r *DynamicRegistry) staleThreshold() time.Duration {
+	return time.Duration(r.stalePeriods) * r.refreshPeriod
+}
+
+// Stats returns a snapshot of refresh health for admin endpoints
+func (r *DynamicRegistry) Stats() RegistryStats {
+	m := r.metrics.GetMetrics()
+	age, stale := r.Staleness()
+
+	stats := RegistryStats{
+		Stale:              stale,
+		StaleThresholdMs:   r.staleThreshold().Milliseconds(),
+		SinceLastSuccessMs: age.Milliseconds(),
+		LastDurationMs:     float64(m.LastRefreshLatency.Microseconds()) / 1000,
+		LastError:          m.LastError,
+		RefreshCount:       m.RefreshCount,
+		RefreshErrors:      m.RefreshErrors,
+		ConsecutiveErrors:  m.ConsecutiveErrors,
+		ConfigsLoaded:      m.ConfigsLoaded,
+		ConfigsFailed:      m.ConfigsFailed,
+		ParseErrors:        m.ParseErrors,
+		TotalAdapters:      m.TotalAdapters,
+		EnabledAdapters:    m.EnabledAdapters,
+	}
+	if !m.LastRefreshTime.IsZero() {
+		stats.LastSuccess = &m.LastRefreshTime
+	}
+	if !m.LastRefreshAttempt.IsZero() {
+		stats.LastAttempt = &m.LastRefreshAttempt
+	}
+	return stats
+}
+
+// checkStaleness fires the stale alert on the transition into the stale state
+// and logs recovery on the way out, so a stuck registry is not silently missing demand
+func (r *DynamicRegistry) checkStaleness() {
+	age, stale := r.Staleness()
+	if stale == r.stale {
+		return
+	}
+	r.stale = stale
+
+	if r.promMetrics != nil {
+		r.promMetrics.SetDynamicRegistryStale(stale)
+	}
+
+	if stale {
+		logger.Log.Error().
+			Dur("since_last_success", age).
+			Dur("threshold", r.staleThreshold()).
+			Msg("Dynamic bidder registry is stale, serving last known configs")
+		if r.onStale != nil {
+			r.onStale(age)
+		}
+		return
+	}
+
+	logger.Log.Info().Msg("Dynamic bidder registry recovered from stale state")
+}
+
 // SetUpdateCallback sets a callback function to be called when a bidder is updated
 func (r *DynamicRegistry) SetUpdateCallback(fn func(string, *BidderConfig)) {
 	r.onUpdate = fn
@@ -158,6 +352,7 @@ func (r *DynamicRegistry) refreshLoop(ctx context.Context) {
 				logger.Log.Warn().Err(err).Msg("Failed to refresh dynamic bidders")
 			}
 			cancel()
+			r.checkStaleness()
 		case <-r.stopChan:
 			return
 		case <-ctx.Done():
@@ -173,8 +368,12 @@ func (r *DynamicRegistry) Refresh(ctx context.Context) error {
 	// Get all bidder configs from Redis
 	configs, err := r.redis.HGetAll(ctx, redisBiddersHash)
 	if err != nil {
-		r.metrics.recordRefreshError() // P3-NEW-1: Record error
-		return fmt.Errorf("failed to get bidders from Redis: %w", err)
+		err = fmt.Errorf("failed to get bidders from Redis: %w", err)
+		r.metrics.recordRefreshError(err) // P3-NEW-1: Record error
+		if r.promMetrics != nil {
+			r.promMetrics.RecordDynamicRegistryRefresh(false, time.Since(start), 0, 0)
+		}
+		return err
 	}
 
 	r.mu.Lock()
@@ -182,16 +381,28 @@ func (r *DynamicRegistry) Refresh(ctx context.Context) error {
 
 	// Track which bidders we've seen
 	seen := make(map[string]bool)
+	loaded, failed := 0, 0
 
 	// Update or create adapters
 	for bidderCode, jsonStr := range configs {
 		seen[bidderCode] = true
 
 		var config BidderConfig
-		if err := json.Unmarshal([]byte(jsonStr), &config); err != nil {
+		err := json.Unmarshal([]byte(jsonStr), &config)
+		if err == nil {
+			err = config.validate()
+		}
+		if err != nil {
+			// The previous config, if any, stays in effect
 			logger.Log.Warn().Err(err).Str("bidder", bidderCode).Msg("Failed to parse bidder config")
+			failed++
+			r.metrics.recordParseError(bidderCode)
+			if r.promMetrics != nil {
+				r.promMetrics.IncDynamicRegistryParseError(bidderCode)
+			}
 			continue
 		}
+		loaded++
 
 		existing, exists := r.adapters[bidderCode]
 		if exists {
@@ -222,11 +433,84 @@ func (r *DynamicRegistry) Refresh(ctx context.Context) error {
 			enabledCount++
 		}
 	}
-	r.metrics.recordRefreshSuccess(time.Since(start), len(r.adapters), enabledCount)
+	latency := time.Since(start)
+	r.metrics.recordRefreshSuccess(latency, len(r.adapters), enabledCount, loaded, failed)
+	if r.promMetrics != nil {
+		r.promMetrics.RecordDynamicRegistryRefresh(true, latency, loaded, failed)
+	}
 
 	return nil
 }
 
+// Save validates config and writes it to Redis the way the admin service
+// does: the config hash, the active set and the priority index. Returns
+// whether the bidder is new. The registry serves the change after its next
+// Refresh.
+func (r *DynamicRegistry) Save(ctx context.Context, config *BidderConfig) (created bool, err error) {
+	w, ok := r.redis.(RedisWriter)
+	if !ok {
+		return false, ErrReadOnly
+	}
+	if config.Endpoint.Method == "" {
+		config.Endpoint.Method = http.MethodPost
+	}
+	if err := config.Validate(); err != nil {
+		return false, fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return false, err
+	}
+
+	existing, err := r.redis.HGet(ctx, redisBiddersHash, config.BidderCode)
+	if err != nil {
+		return false, fmt.Errorf("failed to read bidder config: %w", err)
+	}
+	if err := w.HSet(ctx, redisBiddersHash, config.BidderCode, string(data)); err != nil {
+		return false, fmt.Errorf("failed to write bidder config: %w", err)
+	}
+	if config.Status == "active" || config.Status == "testing" {
+		err = w.SAdd(ctx, redisBiddersActive, config.BidderCode)
+	} else {
+		err = w.SRem(ctx, redisBiddersActive, config.BidderCode)
+	}
+	if err == nil {
+		err = w.ZAdd(ctx, redisBiddersIndex, config.BidderCode, float64(config.Priority))
+	}
+	if err != nil {
+		// The config itself is saved; only the admin service's indexes lag
+		logger.Log.Warn().Err(err).Str("bidder", config.BidderCode).Msg("Failed to update bidder indexes")
+	}
+	return existing == "", nil
+}
+
+// Delete removes a bidder's config from Redis. Returns false if there was no
+// config to remove. The registry drops the bidder on its next Refresh.
+func (r *DynamicRegistry) Delete(ctx context.Context, bidderCode string) (bool, error) {
+	w, ok := r.redis.(RedisWriter)
+	if !ok {
+		return false, ErrReadOnly
+	}
+	existing, err := r.redis.HGet(ctx, redisBiddersHash, bidderCode)
+	if err != nil {
+		return false, fmt.Errorf("failed to read bidder config: %w", err)
+	}
+	if existing == "" {
+		return false, nil
+	}
+	if err := w.HDel(ctx, redisBiddersHash, bidderCode); err != nil {
+		return false, fmt.Errorf("failed to delete bidder config: %w", err)
+	}
+	err = w.SRem(ctx, redisBiddersActive, bidderCode)
+	if err == nil {
+		err = w.ZRem(ctx, redisBiddersIndex, bidderCode)
+	}
+	if err != nil {
+		logger.Log.Warn().Err(err).Str("bidder", bidderCode).Msg("Failed to update bidder indexes")
+	}
+	return true, nil
+}
+
 // Get retrieves an adapter by bidder code
 func (r *DynamicRegistry) Get(bidderCode string) (*GenericAdapter, bool) {
 	// P1-NEW-5: Release registry lock before acquiring metrics lock to avoid lock ordering issues
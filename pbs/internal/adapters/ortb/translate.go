@@ -0,0 +1,344 @@
+package ortb
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+// OpenRTB versions a bidder can declare in endpoint.protocol_version. Requests
+// to bidders on either version are translated; other values are sent as received.
+const (
+	ProtocolVersion25 = "2.5"
+	ProtocolVersion26 = "2.6"
+)
+
+// OpenRTB 2.6 promoted these 2.5 extensions to first-class fields:
+//
+//	source.ext.schain                 -> source.schain
+//	regs.ext.gdpr, regs.ext.us_privacy -> regs.gdpr, regs.us_privacy
+//	regs.ext.gpp, regs.ext.gpp_sid     -> regs.gpp, regs.gpp_sid
+//	user.ext.consent, user.ext.eids    -> user.consent, user.eids
+//
+// Inbound requests may use either location, or both. upgradeTo26 moves every
+// signal to its 2.6 field, and downgradeTo25 moves them back into ext. Both
+// work on copies of the objects they touch, never on the caller's request.
+
+// upgradeTo26 moves 2.5 extension signals to their 2.6 fields. When a signal
+// is present in both places the 2.6 field wins and the ext copy is dropped.
+func upgradeTo26(req *openrtb.BidRequest) {
+	if req.Source != nil && len(req.Source.Ext) > 0 {
+		source := *req.Source
+		var schain *openrtb.SupplyChain
+		if ext, ok := takeExt(source.Ext, "schain", &schain); ok {
+			source.Ext = ext
+			if source.SChain == nil {
+				source.SChain = schain
+			}
+		}
+		req.Source = &source
+	}
+
+	if req.Regs != nil && len(req.Regs.Ext) > 0 {
+		regs := *req.Regs
+		var gdpr *int
+		if ext, ok := takeExt(regs.Ext, "gdpr", &gdpr); ok {
+			regs.Ext = ext
+			if regs.GDPR == nil {
+				regs.GDPR = gdpr
+			}
+		}
+		var usPrivacy, gpp string
+		if ext, ok := takeExt(regs.Ext, "us_privacy", &usPrivacy); ok {
+			regs.Ext = ext
+			if regs.USPrivacy == "" {
+				regs.USPrivacy = usPrivacy
+			}
+		}
+		if ext, ok := takeExt(regs.Ext, "gpp", &gpp); ok {
+			regs.Ext = ext
+			if regs.GPP == "" {
+				regs.GPP = gpp
+			}
+		}
+		var gppSID []int
+		if ext, ok := takeExt(regs.Ext, "gpp_sid", &gppSID); ok {
+			regs.Ext = ext
+			if len(regs.GPPSID) == 0 {
+				regs.GPPSID = gppSID
+			}
+		}
+		req.Regs = &regs
+	}
+
+	if req.User != nil && len(req.User.Ext) > 0 {
+		user := *req.User
+		var consent string
+		if ext, ok := takeExt(user.Ext, "consent", &consent); ok {
+			user.Ext = ext
+			if user.Consent == "" {
+				user.Consent = consent
+			}
+		}
+		var eids []openrtb.EID
+		if ext, ok := takeExt(user.Ext, "eids", &eids); ok {
+			user.Ext = ext
+			if len(user.EIDs) == 0 {
+				user.EIDs = eids
+			}
+		}
+		req.User = &user
+	}
+}
+
+// downgradeTo25 moves 2.6 first-class signals into their 2.5 extensions and
+// maps or drops the fields 2.5 doesn't have
+func downgradeTo25(req *openrtb.BidRequest) {
+	if req.Source != nil && req.Source.SChain != nil {
+		source := *req.Source
+		source.Ext = putExt(source.Ext, "schain", source.SChain)
+		source.SChain = nil
+		req.Source = &source
+	}
+
+	if req.Regs != nil {
+		regs := *req.Regs
+		if regs.GDPR != nil {
+			regs.Ext = putExt(regs.Ext, "gdpr", *regs.GDPR)
+			regs.GDPR = nil
+		}
+		if regs.USPrivacy != "" {
+			regs.Ext = putExt(regs.Ext, "us_privacy", regs.USPrivacy)
+			regs.USPrivacy = ""
+		}
+		if regs.GPP != "" {
+			regs.Ext = putExt(regs.Ext, "gpp", regs.GPP)
+			regs.GPP = ""
+		}
+		if len(regs.GPPSID) > 0 {
+			regs.Ext = putExt(regs.Ext, "gpp_sid", regs.GPPSID)
+			regs.GPPSID = nil
+		}
+		req.Regs = &regs
+	}
+
+	if req.User != nil {
+		user := *req.User
+		if user.Consent != "" {
+			user.Ext = putExt(user.Ext, "consent", user.Consent)
+			user.Consent = ""
+		}
+		if len(user.EIDs) > 0 {
+			user.Ext = putExt(user.Ext, "eids", user.EIDs)
+			user.EIDs = nil
+		}
+		req.User = &user
+	}
+
+	downgradeNewFieldsTo25(req)
+}
+
+// OpenRTB 2.6 also added fields with no 2.5 extension. Those with a 2.5
+// counterpart are mapped to it, unless the request already sets it:
+//
+//	imp.rwdd                      -> imp.ext.prebid.is_rewarded_inventory
+//	imp.video.plcmt               -> imp.video.placement (instream 1, interstitial 5)
+//	imp.video/audio.rqddurs       -> minduration, maxduration (the range they span)
+//	wlangb                        -> wlang (the language subtags)
+//
+// Everything else is dropped: dooh, imp.ssai, qty, dt and refresh, the other
+// ad pod fields, and cattax. Category lists in a taxonomy other than IAB 1.0
+// (cattax > 1) are dropped along with their cattax, as a 2.5 bidder would
+// read them as IAB 1.0 codes; the exchange still enforces bcat on the bids.
+
+// downgradeNewFieldsTo25 maps or drops the 2.6 fields without a 2.5 extension
+func downgradeNewFieldsTo25(req *openrtb.BidRequest) {
+	if len(req.WLangB) > 0 {
+		if len(req.WLang) == 0 {
+			req.WLang = languageSubtags(req.WLangB)
+		}
+		req.WLangB = nil
+	}
+	clearCatTax(&req.CatTax, &req.BCat)
+	req.DOOH = nil
+
+	if req.Site != nil {
+		site := *req.Site
+		clearCatTax(&site.CatTax, &site.Cat, &site.SectionCat, &site.PageCat)
+		site.Publisher = publisherTo25(site.Publisher)
+		site.Content = contentTo25(site.Content)
+		req.Site = &site
+	}
+	if req.App != nil {
+		app := *req.App
+		clearCatTax(&app.CatTax, &app.Cat, &app.SectionCat, &app.PageCat)
+		app.Publisher = publisherTo25(app.Publisher)
+		app.Content = contentTo25(app.Content)
+		req.App = &app
+	}
+
+	imps := make([]openrtb.Imp, len(req.Imp))
+	copy(imps, req.Imp)
+	for i := range imps {
+		impTo25(&imps[i])
+	}
+	req.Imp = imps
+}
+
+// impTo25 maps or drops the 2.6 fields of a copied imp
+func impTo25(imp *openrtb.Imp) {
+	if imp.Rwdd == 1 {
+		prebid := putExt(getExt(imp.Ext, "prebid"), "is_rewarded_inventory", 1)
+		imp.Ext = putExt(imp.Ext, "prebid", prebid)
+	}
+	imp.Rwdd = 0
+	imp.SSAI = 0
+	imp.Qty = nil
+	imp.DT = 0
+	imp.Refresh = nil
+
+	if imp.Video != nil {
+		video := *imp.Video
+		if video.Placement == 0 {
+			switch video.Plcmt {
+			case 1: // Instream
+				video.Placement = 1
+			case 3: // Interstitial
+				video.Placement = 5
+			}
+		}
+		video.MinDuration, video.MaxDuration = durationsTo25(video.RqdDurs, video.MinDuration, video.MaxDuration)
+		video.Plcmt = 0
+		video.RqdDurs = nil
+		video.MaxSeq = 0
+		video.PodDur = 0
+		video.PodID = ""
+		video.PodSeq = 0
+		video.SlotInPod = 0
+		video.MinCPMPerSec = 0
+		imp.Video = &video
+	}
+
+	if imp.Audio != nil {
+		audio := *imp.Audio
+		audio.MinDuration, audio.MaxDuration = durationsTo25(audio.RqdDurs, audio.MinDuration, audio.MaxDuration)
+		audio.RqdDurs = nil
+		audio.PodDur = 0
+		audio.PodID = ""
+		audio.PodSeq = 0
+		audio.SlotInPod = 0
+		audio.MinCPMPerSec = 0
+		imp.Audio = &audio
+	}
+}
+
+func publisherTo25(publisher *openrtb.Publisher) *openrtb.Publisher {
+	if publisher == nil {
+		return nil
+	}
+	p := *publisher
+	clearCatTax(&p.CatTax, &p.Cat)
+	return &p
+}
+
+func contentTo25(content *openrtb.Content) *openrtb.Content {
+	if content == nil {
+		return nil
+	}
+	c := *content
+	clearCatTax(&c.CatTax, &c.Cat)
+	if c.Producer != nil {
+		producer := *c.Producer
+		clearCatTax(&producer.CatTax, &producer.Cat)
+		c.Producer = &producer
+	}
+	return &c
+}
+
+// clearCatTax zeroes cattax, first dropping the category lists it applies to
+// unless they use IAB 1.0, the only taxonomy 2.5 has (cattax 0 or 1)
+func clearCatTax(cattax *int, cats ...*[]string) {
+	if *cattax > 1 {
+		for _, cat := range cats {
+			*cat = nil
+		}
+	}
+	*cattax = 0
+}
+
+// durationsTo25 returns the range rqddurs spans in place of an unset
+// minduration and maxduration
+func durationsTo25(rqddurs []int, minDuration, maxDuration int) (int, int) {
+	if len(rqddurs) == 0 || minDuration != 0 || maxDuration != 0 {
+		return minDuration, maxDuration
+	}
+	return slices.Min(rqddurs), slices.Max(rqddurs)
+}
+
+// languageSubtags returns the distinct, lowercased language subtags of BCP-47
+// tags: "en-US" and "en-GB" both give "en"
+func languageSubtags(tags []string) []string {
+	var langs []string
+	for _, tag := range tags {
+		lang, _, _ := strings.Cut(tag, "-")
+		lang = strings.ToLower(lang)
+		if lang != "" && !slices.Contains(langs, lang) {
+			langs = append(langs, lang)
+		}
+	}
+	return langs
+}
+
+// takeExt decodes ext[key] into dst and returns ext without the key. ok is
+// false, and ext should be left as is, when the key is missing or malformed.
+func takeExt(ext json.RawMessage, key string, dst interface{}) (json.RawMessage, bool) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(ext, &fields); err != nil {
+		return ext, false
+	}
+	raw, found := fields[key]
+	if !found || json.Unmarshal(raw, dst) != nil {
+		return ext, false
+	}
+	delete(fields, key)
+	if len(fields) == 0 {
+		return nil, true
+	}
+	result, err := json.Marshal(fields)
+	if err != nil {
+		return ext, false
+	}
+	return result, true
+}
+
+// getExt returns ext[key], or nil when ext isn't a JSON object or lacks key
+func getExt(ext json.RawMessage, key string) json.RawMessage {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(ext, &fields) != nil {
+		return nil
+	}
+	return fields[key]
+}
+
+// putExt returns ext with key set to value. An ext that isn't a JSON object
+// is returned unchanged.
+func putExt(ext json.RawMessage, key string, value interface{}) json.RawMessage {
+	fields := make(map[string]json.RawMessage)
+	if len(ext) > 0 {
+		if err := json.Unmarshal(ext, &fields); err != nil {
+			return ext
+		}
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return ext
+	}
+	fields[key] = raw
+	result, err := json.Marshal(fields)
+	if err != nil {
+		return ext
+	}
+	return result
+}
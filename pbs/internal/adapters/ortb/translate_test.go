@@ -0,0 +1,228 @@
+package ortb
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+// request25 carries every translated signal in its 2.5 extension
+func request25() *openrtb.BidRequest {
+	req := testBidRequest()
+	req.Source = &openrtb.Source{TID: "tid-1", Ext: json.RawMessage(`{"schain":{"complete":1,"ver":"1.0","nodes":[{"asi":"pub.example","sid":"1","hp":1}]}}`)}
+	req.Regs = &openrtb.Regs{Ext: json.RawMessage(`{"gdpr":1,"us_privacy":"1YNN","gpp":"DBACNY","gpp_sid":[2,6],"other":true}`)}
+	req.User = &openrtb.User{ID: "u1", Ext: json.RawMessage(`{"consent":"CONSENT","eids":[{"source":"id5-sync.com","uids":[{"id":"abc","atype":1}]}]}`)}
+	return req
+}
+
+// request26 carries every translated signal in its 2.6 field
+func request26() *openrtb.BidRequest {
+	gdpr := 1
+	req := testBidRequest()
+	req.Source = &openrtb.Source{TID: "tid-1", SChain: &openrtb.SupplyChain{Complete: 1, Ver: "1.0", Nodes: []openrtb.SupplyChainNode{{ASI: "pub.example", SID: "1", HP: 1}}}}
+	req.Regs = &openrtb.Regs{GDPR: &gdpr, USPrivacy: "1YNN", GPP: "DBACNY", GPPSID: []int{2, 6}, Ext: json.RawMessage(`{"other":true}`)}
+	req.User = &openrtb.User{ID: "u1", Consent: "CONSENT", EIDs: []openrtb.EID{{Source: "id5-sync.com", UIDs: []openrtb.UID{{ID: "abc", AType: 1}}}}}
+	return req
+}
+
+// sentRequest returns the request body the adapter would send
+func sentRequest(t *testing.T, config *BidderConfig, req *openrtb.BidRequest) *openrtb.BidRequest {
+	t.Helper()
+	requests, errs := New(config).MakeRequests(req, nil)
+	if len(errs) > 0 || len(requests) != 1 {
+		t.Fatalf("unexpected MakeRequests result %v / %v", requests, errs)
+	}
+	var sent openrtb.BidRequest
+	if err := json.Unmarshal(requests[0].Body, &sent); err != nil {
+		t.Fatalf("invalid request body: %v", err)
+	}
+	return &sent
+}
+
+func assert26(t *testing.T, req *openrtb.BidRequest) {
+	t.Helper()
+	if req.Source.SChain == nil || len(req.Source.SChain.Nodes) != 1 || req.Source.Ext != nil {
+		t.Errorf("expected schain in source.schain only, got %+v", req.Source)
+	}
+	if req.Regs.GDPR == nil || *req.Regs.GDPR != 1 || req.Regs.USPrivacy != "1YNN" || req.Regs.GPP != "DBACNY" || len(req.Regs.GPPSID) != 2 {
+		t.Errorf("expected regs signals in 2.6 fields, got %+v", req.Regs)
+	}
+	if string(req.Regs.Ext) != `{"other":true}` {
+		t.Errorf("expected only unrelated regs.ext keys left, got %s", req.Regs.Ext)
+	}
+	if req.User.Consent != "CONSENT" || len(req.User.EIDs) != 1 || req.User.EIDs[0].UIDs[0].ID != "abc" || req.User.Ext != nil {
+		t.Errorf("expected user signals in 2.6 fields, got %+v", req.User)
+	}
+}
+
+func assert25(t *testing.T, req *openrtb.BidRequest) {
+	t.Helper()
+	var source struct {
+		SChain openrtb.SupplyChain `json:"schain"`
+	}
+	if req.Source.SChain != nil || json.Unmarshal(req.Source.Ext, &source) != nil || len(source.SChain.Nodes) != 1 {
+		t.Errorf("expected schain in source.ext only, got %+v", req.Source)
+	}
+
+	var regs struct {
+		GDPR      int    `json:"gdpr"`
+		USPrivacy string `json:"us_privacy"`
+		GPP       string `json:"gpp"`
+		GPPSID    []int  `json:"gpp_sid"`
+		Other     bool   `json:"other"`
+	}
+	if req.Regs.GDPR != nil || req.Regs.USPrivacy != "" || req.Regs.GPP != "" || req.Regs.GPPSID != nil {
+		t.Errorf("expected no 2.6 regs fields, got %+v", req.Regs)
+	}
+	if err := json.Unmarshal(req.Regs.Ext, &regs); err != nil || regs.GDPR != 1 || regs.USPrivacy != "1YNN" || regs.GPP != "DBACNY" || len(regs.GPPSID) != 2 || !regs.Other {
+		t.Errorf("expected regs signals in regs.ext, got %s", req.Regs.Ext)
+	}
+
+	var user struct {
+		Consent string        `json:"consent"`
+		EIDs    []openrtb.EID `json:"eids"`
+	}
+	if req.User.Consent != "" || req.User.EIDs != nil {
+		t.Errorf("expected no 2.6 user fields, got %+v", req.User)
+	}
+	if err := json.Unmarshal(req.User.Ext, &user); err != nil || user.Consent != "CONSENT" || len(user.EIDs) != 1 {
+		t.Errorf("expected user signals in user.ext, got %s", req.User.Ext)
+	}
+}
+
+func TestTransformRequest_UpgradesTo26(t *testing.T) {
+	config := basicConfig()
+	config.Endpoint.ProtocolVersion = ProtocolVersion26
+
+	original := request25()
+	assert26(t, sentRequest(t, config, original))
+	assert26(t, sentRequest(t, config, request26()))
+
+	if original.Source.SChain != nil || original.Regs.GDPR != nil || original.User.Consent != "" {
+		t.Error("expected the inbound request not to be modified")
+	}
+}
+
+func TestTransformRequest_DowngradesTo25(t *testing.T) {
+	config := basicConfig()
+	config.Endpoint.ProtocolVersion = ProtocolVersion25
+
+	original := request26()
+	assert25(t, sentRequest(t, config, original))
+	assert25(t, sentRequest(t, config, request25()))
+
+	if original.Source.SChain == nil || original.Regs.GDPR == nil || original.User.Consent == "" {
+		t.Error("expected the inbound request not to be modified")
+	}
+}
+
+func TestTransformRequest_SChainAugmentOn25(t *testing.T) {
+	config := basicConfig()
+	config.RequestTransform.SChainAugment = SChainAugmentConfig{
+		Enabled: true,
+		Nodes:   []SChainNodeConfig{{ASI: "nexus.example", SID: "n1", HP: 1}},
+	}
+
+	// The inbound 2.5 chain is extended, not replaced, and stays in source.ext
+	sent := sentRequest(t, config, request25())
+	var source struct {
+		SChain openrtb.SupplyChain `json:"schain"`
+	}
+	if err := json.Unmarshal(sent.Source.Ext, &source); err != nil || len(source.SChain.Nodes) != 2 || source.SChain.Nodes[1].ASI != "nexus.example" {
+		t.Errorf("expected augmented chain in source.ext, got %s", sent.Source.Ext)
+	}
+}
+
+func TestTransformRequest_UnknownVersionUntouched(t *testing.T) {
+	config := basicConfig()
+	config.Endpoint.ProtocolVersion = ""
+
+	sent := sentRequest(t, config, request25())
+	if sent.Source.SChain != nil || sent.Regs.GDPR != nil || sent.User.Consent != "" {
+		t.Errorf("expected no translation without a known protocol version, got %+v", sent)
+	}
+}
+
+// request26Only carries 2.6 fields that have no 2.5 extension
+func request26Only() *openrtb.BidRequest {
+	req := testBidRequest()
+	req.WLangB = []string{"en-US", "en-GB", "fr"}
+	req.CatTax = 6
+	req.BCat = []string{"150"}
+	req.DOOH = &openrtb.DOOH{ID: "screen-1"}
+	req.Site.CatTax = 1
+	req.Site.Cat = []string{"IAB1"}
+	req.Site.Publisher = &openrtb.Publisher{ID: "pub-1", CatTax: 7, Cat: []string{"483"}}
+	req.Imp[0].Rwdd = 1
+	req.Imp[0].SSAI = 2
+	req.Imp[0].Qty = &openrtb.Qty{Multiplier: 2}
+	req.Imp[0].DT = 1700000000000
+	req.Imp[0].Refresh = &openrtb.Refresh{Count: 1}
+	req.Imp[0].Ext = json.RawMessage(`{"prebid":{"storedrequest":{"id":"s1"}}}`)
+	req.Imp[0].Video = &openrtb.Video{Mimes: []string{"video/mp4"}, Plcmt: 3, RqdDurs: []int{30, 15}, MaxSeq: 2, PodDur: 45, PodID: "pod-1", PodSeq: 1, SlotInPod: 1, MinCPMPerSec: 0.1}
+	req.Imp[0].Audio = &openrtb.Audio{Mimes: []string{"audio/mp4"}, MinDuration: 10, MaxDuration: 60, RqdDurs: []int{30}, PodID: "pod-2"}
+	return req
+}
+
+func TestTransformRequest_Downgrades26OnlyFields(t *testing.T) {
+	config := basicConfig()
+	config.Endpoint.ProtocolVersion = ProtocolVersion25
+
+	original := request26Only()
+	sent := sentRequest(t, config, original)
+
+	if !reflect.DeepEqual(sent.WLang, []string{"en", "fr"}) || sent.WLangB != nil {
+		t.Errorf("expected wlangb mapped to wlang, got %v / %v", sent.WLang, sent.WLangB)
+	}
+	if sent.CatTax != 0 || sent.BCat != nil || sent.DOOH != nil {
+		t.Errorf("expected cattax 6 bcat and dooh dropped, got %d %v %+v", sent.CatTax, sent.BCat, sent.DOOH)
+	}
+	if sent.Site.CatTax != 0 || len(sent.Site.Cat) != 1 {
+		t.Errorf("expected IAB 1.0 site categories kept, got %+v", sent.Site)
+	}
+	if sent.Site.Publisher.CatTax != 0 || sent.Site.Publisher.Cat != nil {
+		t.Errorf("expected publisher cattax 7 categories dropped, got %+v", sent.Site.Publisher)
+	}
+
+	imp := sent.Imp[0]
+	if imp.Rwdd != 0 || imp.SSAI != 0 || imp.Qty != nil || imp.DT != 0 || imp.Refresh != nil {
+		t.Errorf("expected 2.6 imp fields dropped, got %+v", imp)
+	}
+	if string(imp.Ext) != `{"prebid":{"is_rewarded_inventory":1,"storedrequest":{"id":"s1"}}}` {
+		t.Errorf("expected rwdd mapped into imp.ext.prebid, got %s", imp.Ext)
+	}
+	want := openrtb.Video{Mimes: []string{"video/mp4"}, Placement: 5, MinDuration: 15, MaxDuration: 30}
+	if !reflect.DeepEqual(*imp.Video, want) {
+		t.Errorf("expected video %+v, got %+v", want, *imp.Video)
+	}
+	if imp.Audio.MinDuration != 10 || imp.Audio.MaxDuration != 60 || imp.Audio.RqdDurs != nil || imp.Audio.PodID != "" {
+		t.Errorf("expected audio pod fields dropped and its range kept, got %+v", imp.Audio)
+	}
+
+	if original.DOOH == nil || original.Imp[0].Rwdd != 1 || original.Imp[0].Video.Plcmt != 3 || original.Site.Publisher.Cat == nil {
+		t.Error("expected the inbound request not to be modified")
+	}
+}
+
+func TestTransformRequest_Keeps26OnlyFieldsOn26(t *testing.T) {
+	config := basicConfig()
+	config.Endpoint.ProtocolVersion = ProtocolVersion26
+
+	sent := sentRequest(t, config, request26Only())
+	if sent.CatTax != 6 || sent.DOOH == nil || sent.Imp[0].Rwdd != 1 || sent.Imp[0].Video.Plcmt != 3 || len(sent.Imp[0].Video.RqdDurs) != 2 {
+		t.Errorf("expected 2.6 fields sent as received, got %+v", sent)
+	}
+}
+
+func TestTakeExt_MalformedLeftInPlace(t *testing.T) {
+	ext := json.RawMessage(`{"gdpr":"yes"}`)
+	var gdpr *int
+	if got, ok := takeExt(ext, "gdpr", &gdpr); ok || string(got) != string(ext) {
+		t.Errorf("expected malformed value kept, got %s / %v", got, ok)
+	}
+	if got := putExt(json.RawMessage(`[1]`), "gdpr", 1); string(got) != `[1]` {
+		t.Errorf("expected non-object ext unchanged, got %s", got)
+	}
+}
@@ -2,12 +2,12 @@
 package pubmatic
 
 import (
-	"encoding/json"
 	"fmt"
 	"net/http"
 
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/jsoncodec"
 )
 
 const (
@@ -31,7 +31,7 @@ func New(endpoint string) *Adapter {
 func (a *Adapter) MakeRequests(request *openrtb.BidRequest, extraInfo *adapters.ExtraRequestInfo) ([]*adapters.RequestData, []error) {
 	var errors []error
 
-	requestBody, err := json.Marshal(request)
+	requestBody, err := jsoncodec.Marshal(request)
 	if err != nil {
 		return nil, []error{fmt.Errorf("failed to marshal request: %v", err)}
 	}
@@ -65,7 +65,7 @@ func (a *Adapter) MakeBids(request *openrtb.BidRequest, responseData *adapters.R
 	}
 
 	var bidResp openrtb.BidResponse
-	if err := json.Unmarshal(responseData.Body, &bidResp); err != nil {
+	if err := jsoncodec.Unmarshal(responseData.Body, &bidResp); err != nil {
 		return nil, []error{fmt.Errorf("failed to parse response: %v", err)}
 	}
 
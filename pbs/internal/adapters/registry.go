@@ -1,10 +1,14 @@
 package adapters
 
 import (
+	"errors"
 	"fmt"
 	"sync"
 )
 
+// ErrNotRegistered is returned for bidder codes the registry doesn't know
+var ErrNotRegistered = errors.New("adapter not registered")
+
 // Registry holds all registered bidder adapters
 type Registry struct {
 	mu       sync.RWMutex
@@ -81,6 +85,22 @@ func (r *Registry) ListEnabledBidders() []string {
 	return bidders
 }
 
+// SetEnabled turns a registered bidder on or off at runtime. A disabled
+// bidder stays registered but is left out of ListEnabledBidders, so auctions
+// stop calling it.
+func (r *Registry) SetEnabled(bidderCode string, enabled bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	awi, ok := r.adapters[bidderCode]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotRegistered, bidderCode)
+	}
+	awi.Info.Enabled = enabled
+	r.adapters[bidderCode] = awi
+	return nil
+}
+
 // DefaultRegistry is the global adapter registry
 var DefaultRegistry = NewRegistry()
 
@@ -1,6 +1,7 @@
 package adapters
 
 import (
+	"errors"
 	"sync"
 	"testing"
 
@@ -269,6 +270,31 @@ func TestRegistry_ListEnabledBidders_Empty(t *testing.T) {
 	}
 }
 
+func TestRegistry_SetEnabled(t *testing.T) {
+	r := NewRegistry()
+	r.Register("bidder1", &mockAdapter{}, BidderInfo{Enabled: true, Endpoint: "https://bidder1.example.com"})
+
+	if err := r.SetEnabled("bidder1", false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if enabled := r.ListEnabledBidders(); len(enabled) != 0 {
+		t.Errorf("expected no enabled bidders, got %v", enabled)
+	}
+	awi, _ := r.Get("bidder1")
+	if awi.Info.Enabled || awi.Info.Endpoint != "https://bidder1.example.com" {
+		t.Errorf("expected only Enabled to change, got %+v", awi.Info)
+	}
+
+	r.SetEnabled("bidder1", true)
+	if enabled := r.ListEnabledBidders(); len(enabled) != 1 {
+		t.Errorf("expected bidder1 enabled again, got %v", enabled)
+	}
+
+	if err := r.SetEnabled("unknown", false); !errors.Is(err, ErrNotRegistered) {
+		t.Errorf("expected ErrNotRegistered, got %v", err)
+	}
+}
+
 func TestRegisterAdapter_DefaultRegistry(t *testing.T) {
 	// Create a unique bidder name to avoid conflicts with other tests
 	bidderCode := "test_default_registry_bidder"
@@ -2,12 +2,12 @@
 package rubicon
 
 import (
-	"encoding/json"
 	"fmt"
 	"net/http"
 
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/jsoncodec"
 )
 
 const (
@@ -37,7 +37,7 @@ func (a *Adapter) MakeRequests(request *openrtb.BidRequest, extraInfo *adapters.
 		reqCopy := *request
 		reqCopy.Imp = []openrtb.Imp{imp}
 
-		requestBody, err := json.Marshal(reqCopy)
+		requestBody, err := jsoncodec.Marshal(reqCopy)
 		if err != nil {
 			errors = append(errors, fmt.Errorf("failed to marshal request for imp %s: %v", imp.ID, err))
 			continue
@@ -73,7 +73,7 @@ func (a *Adapter) MakeBids(request *openrtb.BidRequest, responseData *adapters.R
 	}
 
 	var bidResp openrtb.BidResponse
-	if err := json.Unmarshal(responseData.Body, &bidResp); err != nil {
+	if err := jsoncodec.Unmarshal(responseData.Body, &bidResp); err != nil {
 		return nil, []error{fmt.Errorf("failed to parse response: %v", err)}
 	}
 
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters/adapterstest"
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
 )
 
@@ -142,3 +143,7 @@ func TestInfo(t *testing.T) {
 	}
 }
 
+
+func TestJSONSamples(t *testing.T) {
+	adapterstest.RunJSONBidderTest(t, "rubicontest", New(""))
+}
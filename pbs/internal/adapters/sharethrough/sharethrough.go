@@ -3,13 +3,41 @@ package sharethrough
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
 
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/jsoncodec"
 )
 
-const defaultEndpoint = "https://btlr.sharethrough.com/universal/v1"
+const (
+	bidderCode      = "sharethrough"
+	defaultEndpoint = "https://btlr.sharethrough.com/universal/v1"
+)
+
+// ImpParams are the Sharethrough params in imp.ext.prebid.bidder.sharethrough
+type ImpParams struct {
+	Pkey     string   `json:"pkey"`
+	BCat     []string `json:"bcat,omitempty"`
+	BAdv     []string `json:"badv,omitempty"`
+	BidFloor float64  `json:"bidfloor,omitempty"`
+}
+
+// paramsSchema is the JSON schema for ImpParams
+const paramsSchema = `{
+	"title": "Sharethrough Adapter Params",
+	"type": "object",
+	"properties": {
+		"pkey": {"type": "string", "minLength": 1, "description": "Placement key, sent as imp.tagid"},
+		"bcat": {"type": "array", "items": {"type": "string"}, "description": "Blocked categories, added to the request's"},
+		"badv": {"type": "array", "items": {"type": "string"}, "description": "Blocked advertiser domains, added to the request's"},
+		"bidfloor": {"type": "number", "minimum": 0, "description": "Floor used when the imp has none"}
+	},
+	"required": ["pkey"]
+}`
 
 type Adapter struct{ endpoint string }
 
@@ -20,28 +48,74 @@ func New(endpoint string) *Adapter {
 	return &Adapter{endpoint: endpoint}
 }
 
+// MakeRequests sends each imp in a request of its own, with the placement
+// key as the tag ID and the imp's blocklists added to the request's
 func (a *Adapter) MakeRequests(request *openrtb.BidRequest, extraInfo *adapters.ExtraRequestInfo) ([]*adapters.RequestData, []error) {
-	body, err := json.Marshal(request)
-	if err != nil {
-		return nil, []error{err}
+	var errs []error
+	requests := make([]*adapters.RequestData, 0, len(request.Imp))
+	for _, imp := range request.Imp {
+		var params ImpParams
+		if err := adapters.ImpParams(&imp, bidderCode, &params); err != nil {
+			if !errors.Is(err, adapters.ErrNoParams) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if params.Pkey == "" {
+			errs = append(errs, adapters.NewBadInputError(bidderCode, fmt.Sprintf("imp %s: pkey is required", imp.ID)))
+			continue
+		}
+		imp.TagID = params.Pkey
+		if imp.BidFloor == 0 && params.BidFloor > 0 {
+			imp.BidFloor = params.BidFloor
+		}
+		imp.Ext = nil
+
+		reqCopy := *request
+		reqCopy.Imp = []openrtb.Imp{imp}
+		reqCopy.BCat = mergeList(request.BCat, params.BCat)
+		reqCopy.BAdv = mergeList(request.BAdv, params.BAdv)
+		body, err := jsoncodec.Marshal(reqCopy)
+		if err != nil {
+			errs = append(errs, adapters.NewMarshalError(bidderCode, err))
+			continue
+		}
+		headers := http.Header{}
+		headers.Set("Content-Type", "application/json")
+		requests = append(requests, &adapters.RequestData{Method: "POST", URI: a.endpoint, Body: body, Headers: headers})
+	}
+	return requests, errs
+}
+
+// mergeList returns base plus the entries of extra it lacks, without
+// modifying base
+func mergeList(base, extra []string) []string {
+	merged := base
+	for _, v := range extra {
+		if !slices.Contains(merged, v) {
+			merged = append(slices.Clip(merged), v)
+		}
 	}
-	headers := http.Header{}
-	headers.Set("Content-Type", "application/json")
-	return []*adapters.RequestData{{Method: "POST", URI: a.endpoint, Body: body, Headers: headers}}, nil
+	return merged
 }
 
 func (a *Adapter) MakeBids(request *openrtb.BidRequest, responseData *adapters.ResponseData) (*adapters.BidderResponse, []error) {
-	if responseData.StatusCode != http.StatusOK {
+	if responseData.StatusCode == http.StatusNoContent {
 		return nil, nil
 	}
+	if responseData.StatusCode != http.StatusOK {
+		return nil, []error{adapters.NewBadStatusError(bidderCode, responseData.StatusCode)}
+	}
 	var bidResp openrtb.BidResponse
-	if err := json.Unmarshal(responseData.Body, &bidResp); err != nil {
-		return nil, []error{err}
+	if err := jsoncodec.Unmarshal(responseData.Body, &bidResp); err != nil {
+		return nil, []error{adapters.NewParseError(bidderCode, err)}
 	}
 	response := &adapters.BidderResponse{Currency: bidResp.Cur, ResponseID: bidResp.ID, Bids: make([]*adapters.TypedBid, 0)}
+	impMap := adapters.BuildImpMap(request.Imp)
 	for _, sb := range bidResp.SeatBid {
 		for i := range sb.Bid {
-			response.Bids = append(response.Bids, &adapters.TypedBid{Bid: &sb.Bid[i], BidType: adapters.BidTypeNative})
+			bid := &sb.Bid[i]
+			response.Bids = append(response.Bids, &adapters.TypedBid{Bid: bid, BidType: adapters.GetBidTypeFromMap(bid, impMap)})
 		}
 	}
 	return response, nil
@@ -50,11 +124,12 @@ func (a *Adapter) MakeBids(request *openrtb.BidRequest, responseData *adapters.R
 func Info() adapters.BidderInfo {
 	return adapters.BidderInfo{
 		Enabled: true, GVLVendorID: 80, Endpoint: defaultEndpoint,
-		Maintainer: &adapters.MaintainerInfo{Email: "pubgrowth.engineering@sharethrough.com"},
+		ParamsSchema: json.RawMessage(paramsSchema),
+		Maintainer:   &adapters.MaintainerInfo{Email: "pubgrowth.engineering@sharethrough.com"},
 		Capabilities: &adapters.CapabilitiesInfo{
 			Site: &adapters.PlatformInfo{MediaTypes: []adapters.BidType{adapters.BidTypeBanner, adapters.BidTypeVideo, adapters.BidTypeNative}},
 		},
 	}
 }
 
-func init() { adapters.RegisterAdapter("sharethrough", New(""), Info()) }
+func init() { adapters.RegisterAdapter(bidderCode, New(""), Info()) }
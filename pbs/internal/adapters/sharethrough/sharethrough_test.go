@@ -0,0 +1,23 @@
+package sharethrough
+
+import (
+	"testing"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters/adapterstest"
+)
+
+func TestJSONSamples(t *testing.T) {
+	adapterstest.RunJSONBidderTest(t, "sharethroughtest", New(""))
+}
+
+func TestParamsSchema(t *testing.T) {
+	adapterstest.RunParamsSchemaTest(t, Info(), []string{
+		`{"pkey":"abc"}`,
+		`{"pkey":"abc","bcat":["IAB1"],"badv":["a.com"],"bidfloor":1}`,
+	}, []string{
+		`{}`,
+		`{"pkey":""}`,
+		`{"pkey":"abc","bcat":"IAB1"}`,
+		`{"pkey":"abc","badv":[1]}`,
+	})
+}
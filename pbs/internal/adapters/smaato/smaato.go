@@ -0,0 +1,224 @@
+// Package smaato implements the Smaato bidder adapter
+package smaato
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/jsoncodec"
+)
+
+const (
+	bidderCode      = "smaato"
+	defaultEndpoint = "https://prebid.ad.smaato.net/oapi/prebid"
+
+	// adTypeHeader is the response header Smaato reports each ad's format in
+	adTypeHeader = "X-Smt-Adtype"
+)
+
+// ImpParams are the Smaato params in imp.ext.prebid.bidder.smaato
+type ImpParams struct {
+	PublisherID string `json:"publisherId"`
+	AdspaceID   string `json:"adspaceId"`
+}
+
+// paramsSchema is the JSON schema for ImpParams
+const paramsSchema = `{
+	"title": "Smaato Adapter Params",
+	"type": "object",
+	"properties": {
+		"publisherId": {"type": "string", "minLength": 1, "description": "Publisher ID"},
+		"adspaceId": {"type": "string", "minLength": 1, "description": "Adspace ID, sent as imp.tagid"}
+	},
+	"required": ["publisherId", "adspaceId"]
+}`
+
+// Adapter implements the Smaato bidder
+type Adapter struct {
+	endpoint string
+}
+
+// New creates a new Smaato adapter
+func New(endpoint string) *Adapter {
+	if endpoint == "" {
+		endpoint = defaultEndpoint
+	}
+	return &Adapter{endpoint: endpoint}
+}
+
+// MakeRequests sends each imp in a request of its own, as Smaato answers
+// one ad per request, with the adspace as the tag ID and the imp's
+// publisher as the publisher ID
+func (a *Adapter) MakeRequests(request *openrtb.BidRequest, extraInfo *adapters.ExtraRequestInfo) ([]*adapters.RequestData, []error) {
+	var errs []error
+	requests := make([]*adapters.RequestData, 0, len(request.Imp))
+	for _, imp := range request.Imp {
+		var params ImpParams
+		if err := adapters.ImpParams(&imp, bidderCode, &params); err != nil {
+			if !errors.Is(err, adapters.ErrNoParams) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if params.PublisherID == "" || params.AdspaceID == "" {
+			errs = append(errs, adapters.NewBadInputError(bidderCode, fmt.Sprintf("imp %s: publisherId and adspaceId are required", imp.ID)))
+			continue
+		}
+		imp.TagID = params.AdspaceID
+		imp.Ext = nil
+
+		reqCopy := adapters.WithPublisherID(request, params.PublisherID)
+		reqCopy.Imp = []openrtb.Imp{imp}
+		requestBody, err := jsoncodec.Marshal(reqCopy)
+		if err != nil {
+			errs = append(errs, adapters.NewMarshalError(bidderCode, err))
+			continue
+		}
+
+		headers := http.Header{}
+		headers.Set("Content-Type", "application/json;charset=utf-8")
+		headers.Set("Accept", "application/json")
+
+		requests = append(requests, &adapters.RequestData{Method: "POST", URI: a.endpoint, Body: requestBody, Headers: headers})
+	}
+	return requests, errs
+}
+
+// MakeBids parses Smaato responses into bids. Image and rich media ads come
+// back as JSON and are rendered to HTML markup.
+func (a *Adapter) MakeBids(request *openrtb.BidRequest, responseData *adapters.ResponseData) (*adapters.BidderResponse, []error) {
+	if responseData.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+	if responseData.StatusCode == http.StatusBadRequest {
+		return nil, []error{adapters.NewBadRequestError(bidderCode, string(responseData.Body))}
+	}
+	if responseData.StatusCode != http.StatusOK {
+		return nil, []error{adapters.NewBadStatusError(bidderCode, responseData.StatusCode)}
+	}
+
+	var bidResp openrtb.BidResponse
+	if err := jsoncodec.Unmarshal(responseData.Body, &bidResp); err != nil {
+		return nil, []error{adapters.NewParseError(bidderCode, err)}
+	}
+
+	adType := responseData.Headers.Get(adTypeHeader)
+	var errs []error
+	response := &adapters.BidderResponse{Currency: bidResp.Cur, ResponseID: bidResp.ID, Bids: make([]*adapters.TypedBid, 0)}
+	impMap := adapters.BuildImpMap(request.Imp)
+	for _, seatBid := range bidResp.SeatBid {
+		for i := range seatBid.Bid {
+			bid := &seatBid.Bid[i]
+			bidType := adapters.GetBidTypeFromMap(bid, impMap)
+			switch adType {
+			case "Img", "Richmedia":
+				bidType = adapters.BidTypeBanner
+				adm, err := renderAdMarkup(adType, bid.AdM)
+				if err != nil {
+					errs = append(errs, adapters.NewParseError(bidderCode, fmt.Errorf("bid %s: %w", bid.ID, err)))
+					continue
+				}
+				bid.AdM = adm
+			case "Video":
+				bidType = adapters.BidTypeVideo
+			case "Native":
+				bidType = adapters.BidTypeNative
+			}
+			response.Bids = append(response.Bids, &adapters.TypedBid{Bid: bid, BidType: bidType})
+		}
+	}
+	return response, errs
+}
+
+// imageAd and richMediaAd are Smaato's JSON banner markups
+type imageAd struct {
+	Image struct {
+		Img struct {
+			URL    string `json:"url"`
+			W      int    `json:"w"`
+			H      int    `json:"h"`
+			CTAURL string `json:"ctaurl"`
+		} `json:"img"`
+		ImpressionTrackers []string `json:"impressiontrackers"`
+		ClickTrackers      []string `json:"clicktrackers"`
+	} `json:"image"`
+}
+
+type richMediaAd struct {
+	RichMedia struct {
+		MediaData struct {
+			Content string `json:"content"`
+			W       int    `json:"w"`
+			H       int    `json:"h"`
+		} `json:"mediadata"`
+		ImpressionTrackers []string `json:"impressiontrackers"`
+		ClickTrackers      []string `json:"clicktrackers"`
+	} `json:"richmedia"`
+}
+
+// renderAdMarkup turns an image or rich media JSON markup into HTML. Markup
+// that isn't JSON is already HTML and is returned as-is.
+func renderAdMarkup(adType, adm string) (string, error) {
+	if !strings.HasPrefix(strings.TrimSpace(adm), "{") {
+		return adm, nil
+	}
+	var b strings.Builder
+	var impressionTrackers, clickTrackers []string
+	switch adType {
+	case "Img":
+		var ad imageAd
+		if err := json.Unmarshal([]byte(adm), &ad); err != nil {
+			return "", err
+		}
+		img := ad.Image.Img
+		fmt.Fprintf(&b, `<a rel="nofollow" href="%s"><img src="%s" width="%d" height="%d"/></a>`,
+			html.EscapeString(img.CTAURL), html.EscapeString(img.URL), img.W, img.H)
+		impressionTrackers, clickTrackers = ad.Image.ImpressionTrackers, ad.Image.ClickTrackers
+	default:
+		var ad richMediaAd
+		if err := json.Unmarshal([]byte(adm), &ad); err != nil {
+			return "", err
+		}
+		b.WriteString(ad.RichMedia.MediaData.Content)
+		impressionTrackers, clickTrackers = ad.RichMedia.ImpressionTrackers, ad.RichMedia.ClickTrackers
+	}
+
+	for _, tracker := range impressionTrackers {
+		fmt.Fprintf(&b, `<img src="%s" alt="" width="0" height="0"/>`, html.EscapeString(tracker))
+	}
+	if len(clickTrackers) == 0 {
+		return b.String(), nil
+	}
+	var onclick strings.Builder
+	for _, tracker := range clickTrackers {
+		// Percent-encoded, the URL can't break out of the script string
+		fmt.Fprintf(&onclick, "fetch(decodeURIComponent('%s'),{cache:'no-cache'});", url.PathEscape(tracker))
+	}
+	return fmt.Sprintf(`<div style="cursor:pointer" onclick="%s">%s</div>`, html.EscapeString(onclick.String()), b.String()), nil
+}
+
+// Info returns bidder information
+func Info() adapters.BidderInfo {
+	return adapters.BidderInfo{
+		Enabled:      true,
+		GVLVendorID:  82,
+		Endpoint:     defaultEndpoint,
+		ParamsSchema: json.RawMessage(paramsSchema),
+		Maintainer:   &adapters.MaintainerInfo{Email: "prebid@smaato.com"},
+		Capabilities: &adapters.CapabilitiesInfo{
+			Site: &adapters.PlatformInfo{MediaTypes: []adapters.BidType{adapters.BidTypeBanner, adapters.BidTypeVideo, adapters.BidTypeNative}},
+			App:  &adapters.PlatformInfo{MediaTypes: []adapters.BidType{adapters.BidTypeBanner, adapters.BidTypeVideo, adapters.BidTypeNative}},
+		},
+	}
+}
+
+func init() {
+	adapters.RegisterAdapter(bidderCode, New(""), Info())
+}
@@ -0,0 +1,21 @@
+package smaato
+
+import (
+	"testing"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters/adapterstest"
+)
+
+func TestJSONSamples(t *testing.T) {
+	adapterstest.RunJSONBidderTest(t, "smaatotest", New(""))
+}
+
+func TestParamsSchema(t *testing.T) {
+	adapterstest.RunParamsSchemaTest(t, Info(), []string{
+		`{"publisherId":"p","adspaceId":"a"}`,
+	}, []string{
+		`{"publisherId":"p"}`,
+		`{"adspaceId":"a"}`,
+		`{"publisherId":1,"adspaceId":"a"}`,
+	})
+}
@@ -3,13 +3,41 @@ package sovrn
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/jsoncodec"
 )
 
-const defaultEndpoint = "https://ap.lijit.com/rtb/bid"
+const (
+	bidderCode      = "sovrn"
+	defaultEndpoint = "https://ap.lijit.com/rtb/bid"
+)
+
+// ImpParams are the Sovrn params in imp.ext.prebid.bidder.sovrn. Older
+// integrations spell the tag ID tagId.
+type ImpParams struct {
+	TagID    string  `json:"tagid,omitempty"`
+	TagIDAlt string  `json:"tagId,omitempty"`
+	BidFloor float64 `json:"bidfloor,omitempty"`
+}
+
+// paramsSchema is the JSON schema for ImpParams
+const paramsSchema = `{
+	"title": "Sovrn Adapter Params",
+	"type": "object",
+	"properties": {
+		"tagid": {"type": "string", "minLength": 1, "description": "Tag ID, sent as imp.tagid"},
+		"tagId": {"type": "string", "minLength": 1, "description": "Older spelling of tagid"},
+		"bidfloor": {"type": "number", "minimum": 0, "description": "Floor used when the imp has none"}
+	},
+	"anyOf": [{"required": ["tagid"]}, {"required": ["tagId"]}]
+}`
 
 type Adapter struct{ endpoint string }
 
@@ -20,41 +48,106 @@ func New(endpoint string) *Adapter {
 	return &Adapter{endpoint: endpoint}
 }
 
+// MakeRequests sends every imp with a tag ID in one request. Sovrn reads the
+// user from its ljt_reader cookie and the device from the forwarded headers.
 func (a *Adapter) MakeRequests(request *openrtb.BidRequest, extraInfo *adapters.ExtraRequestInfo) ([]*adapters.RequestData, []error) {
-	body, err := json.Marshal(request)
+	var errs []error
+	imps := make([]openrtb.Imp, 0, len(request.Imp))
+	for _, imp := range request.Imp {
+		var params ImpParams
+		if err := adapters.ImpParams(&imp, bidderCode, &params); err != nil {
+			if !errors.Is(err, adapters.ErrNoParams) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		tagID := params.TagID
+		if tagID == "" {
+			tagID = params.TagIDAlt
+		}
+		if tagID == "" {
+			errs = append(errs, adapters.NewBadInputError(bidderCode, fmt.Sprintf("imp %s: tagid is required", imp.ID)))
+			continue
+		}
+		imp.TagID = tagID
+		if imp.BidFloor == 0 && params.BidFloor > 0 {
+			imp.BidFloor = params.BidFloor
+		}
+		imp.Ext = nil
+		imps = append(imps, imp)
+	}
+	if len(imps) == 0 {
+		return nil, errs
+	}
+
+	reqCopy := *request
+	reqCopy.Imp = imps
+	body, err := jsoncodec.Marshal(reqCopy)
 	if err != nil {
-		return nil, []error{err}
+		return nil, append(errs, adapters.NewMarshalError(bidderCode, err))
 	}
+
 	headers := http.Header{}
 	headers.Set("Content-Type", "application/json")
-	return []*adapters.RequestData{{Method: "POST", URI: a.endpoint, Body: body, Headers: headers}}, nil
+	if d := request.Device; d != nil {
+		if d.UA != "" {
+			headers.Set("User-Agent", d.UA)
+		}
+		if d.IP != "" {
+			headers.Set("X-Forwarded-For", d.IP)
+		}
+		if d.DNT != nil {
+			headers.Set("DNT", strconv.Itoa(*d.DNT))
+		}
+	}
+	if request.User != nil && request.User.BuyerUID != "" {
+		headers.Set("Cookie", (&http.Cookie{Name: "ljt_reader", Value: request.User.BuyerUID}).String())
+	}
+	return []*adapters.RequestData{{Method: "POST", URI: a.endpoint, Body: body, Headers: headers}}, errs
 }
 
+// MakeBids parses Sovrn responses. Sovrn URL-encodes its markup.
 func (a *Adapter) MakeBids(request *openrtb.BidRequest, responseData *adapters.ResponseData) (*adapters.BidderResponse, []error) {
-	if responseData.StatusCode != http.StatusOK {
+	if responseData.StatusCode == http.StatusNoContent {
 		return nil, nil
 	}
+	if responseData.StatusCode == http.StatusBadRequest {
+		return nil, []error{adapters.NewBadRequestError(bidderCode, string(responseData.Body))}
+	}
+	if responseData.StatusCode != http.StatusOK {
+		return nil, []error{adapters.NewBadStatusError(bidderCode, responseData.StatusCode)}
+	}
 	var bidResp openrtb.BidResponse
-	if err := json.Unmarshal(responseData.Body, &bidResp); err != nil {
-		return nil, []error{err}
+	if err := jsoncodec.Unmarshal(responseData.Body, &bidResp); err != nil {
+		return nil, []error{adapters.NewParseError(bidderCode, err)}
 	}
+	var errs []error
 	response := &adapters.BidderResponse{Currency: bidResp.Cur, ResponseID: bidResp.ID, Bids: make([]*adapters.TypedBid, 0)}
+	impMap := adapters.BuildImpMap(request.Imp)
 	for _, sb := range bidResp.SeatBid {
 		for i := range sb.Bid {
-			response.Bids = append(response.Bids, &adapters.TypedBid{Bid: &sb.Bid[i], BidType: adapters.BidTypeBanner})
+			bid := &sb.Bid[i]
+			adm, err := url.QueryUnescape(bid.AdM)
+			if err != nil {
+				errs = append(errs, adapters.NewParseError(bidderCode, fmt.Errorf("bid %s: %w", bid.ID, err)))
+				continue
+			}
+			bid.AdM = adm
+			response.Bids = append(response.Bids, &adapters.TypedBid{Bid: bid, BidType: adapters.GetBidTypeFromMap(bid, impMap)})
 		}
 	}
-	return response, nil
+	return response, errs
 }
 
 func Info() adapters.BidderInfo {
 	return adapters.BidderInfo{
 		Enabled: true, GVLVendorID: 13, Endpoint: defaultEndpoint,
-		Maintainer: &adapters.MaintainerInfo{Email: "prebid@sovrn.com"},
+		ParamsSchema: json.RawMessage(paramsSchema),
+		Maintainer:   &adapters.MaintainerInfo{Email: "prebid@sovrn.com"},
 		Capabilities: &adapters.CapabilitiesInfo{
 			Site: &adapters.PlatformInfo{MediaTypes: []adapters.BidType{adapters.BidTypeBanner, adapters.BidTypeVideo}},
 		},
 	}
 }
 
-func init() { adapters.RegisterAdapter("sovrn", New(""), Info()) }
+func init() { adapters.RegisterAdapter(bidderCode, New(""), Info()) }
@@ -0,0 +1,23 @@
+package sovrn
+
+import (
+	"testing"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters/adapterstest"
+)
+
+func TestJSONSamples(t *testing.T) {
+	adapterstest.RunJSONBidderTest(t, "sovrntest", New(""))
+}
+
+func TestParamsSchema(t *testing.T) {
+	adapterstest.RunParamsSchemaTest(t, Info(), []string{
+		`{"tagid":"123"}`,
+		`{"tagId":"123","bidfloor":0.5}`,
+	}, []string{
+		`{}`,
+		`{"tagid":123}`,
+		`{"tagid":""}`,
+		`{"tagid":"1","bidfloor":-1}`,
+	})
+}
@@ -0,0 +1,211 @@
+package adapters
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// TransportConfig tunes the connection pool used for bidder calls. Reusing
+// connections saves bidders a TCP and TLS handshake per call, which otherwise
+// dominates tail latency.
+type TransportConfig struct {
+	MaxIdleConns          int           // Idle connections kept across all bidder hosts
+	MaxIdleConnsPerHost   int           // Idle connections kept per bidder host
+	MaxConnsPerHost       int           // Connections open at once per bidder host (0 = unlimited)
+	IdleConnTimeout       time.Duration // How long an idle connection is kept
+	DialTimeout           time.Duration // TCP connect timeout
+	KeepAlive             time.Duration // TCP keep-alive probe interval
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+	TLSSessionCacheSize   int           // TLS sessions kept for resumption
+	HTTP2                 bool          // Negotiate HTTP/2 with bidders that support it
+	DNSCacheTTL           time.Duration // How long resolved bidder hosts are reused (0 = resolve per dial)
+}
+
+// DefaultTransportConfig returns the default bidder connection pool settings
+func DefaultTransportConfig() *TransportConfig {
+	return &TransportConfig{
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   10,
+		MaxConnsPerHost:       50,
+		IdleConnTimeout:       90 * time.Second,
+		DialTimeout:           5 * time.Second,
+		KeepAlive:             30 * time.Second,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ResponseHeaderTimeout: 10 * time.Second,
+		TLSSessionCacheSize:   100,
+	}
+}
+
+// ConnectionMetrics receives how each bidder call got its connection
+type ConnectionMetrics interface {
+	RecordBidderConnection(host string, reused bool)
+	RecordBidderTLSHandshake(host string, duration time.Duration)
+}
+
+// newTransport builds the bidder transport from config, filling in defaults
+// for unset values
+func newTransport(config *TransportConfig) *http.Transport {
+	defaults := DefaultTransportConfig()
+	if config == nil {
+		config = defaults
+	}
+	orDefault := func(v, d time.Duration) time.Duration {
+		if v <= 0 {
+			return d
+		}
+		return v
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   orDefault(config.DialTimeout, defaults.DialTimeout),
+		KeepAlive: orDefault(config.KeepAlive, defaults.KeepAlive),
+	}
+	dial := dialer.DialContext
+	if config.DNSCacheTTL > 0 {
+		dial = newDNSCache(config.DNSCacheTTL, dialer).dialContext
+	}
+	sessions := config.TLSSessionCacheSize
+	if sessions <= 0 {
+		sessions = defaults.TLSSessionCacheSize
+	}
+	idle := config.MaxIdleConns
+	if idle <= 0 {
+		idle = defaults.MaxIdleConns
+	}
+	idlePerHost := config.MaxIdleConnsPerHost
+	if idlePerHost <= 0 {
+		idlePerHost = defaults.MaxIdleConnsPerHost
+	}
+
+	return &http.Transport{
+		MaxIdleConns:        idle,
+		MaxIdleConnsPerHost: idlePerHost,
+		MaxConnsPerHost:     max(config.MaxConnsPerHost, 0),
+		IdleConnTimeout:     orDefault(config.IdleConnTimeout, defaults.IdleConnTimeout),
+
+		// TLS session caching reduces handshake overhead for repeated connections
+		TLSClientConfig: &tls.Config{
+			ClientSessionCache: tls.NewLRUClientSessionCache(sessions),
+			MinVersion:         tls.VersionTLS12, // Require TLS 1.2+
+		},
+		// A custom dialer turns HTTP/2 off unless it's asked for
+		ForceAttemptHTTP2: config.HTTP2,
+
+		DialContext:           dial,
+		TLSHandshakeTimeout:   orDefault(config.TLSHandshakeTimeout, defaults.TLSHandshakeTimeout),
+		ExpectContinueTimeout: 1 * time.Second,
+		ResponseHeaderTimeout: orDefault(config.ResponseHeaderTimeout, defaults.ResponseHeaderTimeout),
+
+		// Disable compression to reduce latency (bidder responses are usually small)
+		DisableCompression: true,
+	}
+}
+
+// withConnectionTrace reports how the request's connection was obtained
+func withConnectionTrace(ctx context.Context, host string, metrics ConnectionMetrics) context.Context {
+	var tlsStart time.Time
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			metrics.RecordBidderConnection(host, info.Reused)
+		},
+		TLSHandshakeStart: func() {
+			tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			if !tlsStart.IsZero() {
+				metrics.RecordBidderTLSHandshake(host, time.Since(tlsStart))
+			}
+		},
+	})
+}
+
+// dnsCache resolves bidder hosts once per TTL instead of on every new
+// connection. A host that can't be re-resolved keeps its last addresses.
+type dnsCache struct {
+	ttl    time.Duration
+	dialer *net.Dialer
+	lookup func(ctx context.Context, host string) ([]string, error)
+	now    func() time.Time
+
+	mu      sync.RWMutex
+	entries map[string]dnsEntry
+}
+
+type dnsEntry struct {
+	addrs   []string
+	expires time.Time
+}
+
+func newDNSCache(ttl time.Duration, dialer *net.Dialer) *dnsCache {
+	return &dnsCache{
+		ttl:     ttl,
+		dialer:  dialer,
+		lookup:  net.DefaultResolver.LookupHost,
+		now:     time.Now,
+		entries: make(map[string]dnsEntry),
+	}
+}
+
+// resolve returns host's cached addresses, looking them up when expired
+func (c *dnsCache) resolve(ctx context.Context, host string) ([]string, error) {
+	c.mu.RLock()
+	entry, ok := c.entries[host]
+	c.mu.RUnlock()
+	if ok && c.now().Before(entry.expires) {
+		return entry.addrs, nil
+	}
+
+	addrs, err := c.lookup(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		if ok {
+			return entry.addrs, nil
+		}
+		if err == nil {
+			err = &net.DNSError{Err: "no addresses", Name: host, IsNotFound: true}
+		}
+		return nil, err
+	}
+	c.mu.Lock()
+	c.entries[host] = dnsEntry{addrs: addrs, expires: c.now().Add(c.ttl)}
+	c.mu.Unlock()
+	return addrs, nil
+}
+
+// dialContext dials addr through the cache, trying each resolved address. A
+// host none of whose addresses answer is resolved afresh on the next dial.
+func (c *dnsCache) dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || net.ParseIP(host) != nil {
+		return c.dialer.DialContext(ctx, network, addr)
+	}
+	addrs, err := c.resolve(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	var firstErr error
+	for _, ip := range addrs {
+		conn, err := c.dialer.DialContext(ctx, network, net.JoinHostPort(ip, port))
+		if err == nil {
+			return conn, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	if ctx.Err() == nil {
+		c.mu.Lock()
+		delete(c.entries, host)
+		c.mu.Unlock()
+	}
+	return nil, firstErr
+}
@@ -0,0 +1,147 @@
+package adapters
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeConnectionMetrics struct {
+	mu         sync.Mutex
+	reused     int
+	fresh      int
+	handshakes int
+}
+
+func (f *fakeConnectionMetrics) RecordBidderConnection(host string, reused bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if reused {
+		f.reused++
+	} else {
+		f.fresh++
+	}
+}
+
+func (f *fakeConnectionMetrics) RecordBidderTLSHandshake(host string, duration time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.handshakes++
+}
+
+func TestNewTransport(t *testing.T) {
+	defaults := newTransport(nil)
+	if defaults.MaxIdleConnsPerHost != 10 || defaults.IdleConnTimeout != 90*time.Second || defaults.ForceAttemptHTTP2 {
+		t.Errorf("expected the default pool, got %+v", defaults)
+	}
+
+	tuned := newTransport(&TransportConfig{MaxIdleConnsPerHost: 64, IdleConnTimeout: 5 * time.Minute, HTTP2: true})
+	if tuned.MaxIdleConnsPerHost != 64 || tuned.IdleConnTimeout != 5*time.Minute || !tuned.ForceAttemptHTTP2 {
+		t.Errorf("expected the tuned values applied, got %+v", tuned)
+	}
+	if tuned.MaxIdleConns != 100 || tuned.TLSHandshakeTimeout != 5*time.Second {
+		t.Errorf("expected unset values defaulted, got %+v", tuned)
+	}
+}
+
+func TestHTTPClientDo_ConnectionMetrics(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(5 * time.Second)
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	client.client.Transport.(*http.Transport).TLSClientConfig.RootCAs = pool
+	metrics := &fakeConnectionMetrics{}
+	client.SetConnectionMetrics(metrics)
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.Do(context.Background(), &RequestData{Method: "POST", URI: server.URL, Body: []byte(`{}`)}, time.Second); err != nil {
+			t.Fatalf("call %d: unexpected error: %v", i, err)
+		}
+	}
+	if metrics.fresh != 1 || metrics.reused != 2 {
+		t.Errorf("expected one new connection then reuse, got %d new and %d reused", metrics.fresh, metrics.reused)
+	}
+	if metrics.handshakes != 1 {
+		t.Errorf("expected one TLS handshake, got %d", metrics.handshakes)
+	}
+
+	client.SetConnectionMetrics(nil)
+	if _, err := client.Do(context.Background(), &RequestData{Method: "POST", URI: server.URL}, time.Second); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if metrics.reused != 2 {
+		t.Error("expected no metrics once removed")
+	}
+}
+
+func TestDNSCache(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	now := time.Now()
+	lookups := 0
+	var lookupErr error
+	cache := newDNSCache(time.Minute, &net.Dialer{Timeout: time.Second})
+	cache.now = func() time.Time { return now }
+	cache.lookup = func(ctx context.Context, host string) ([]string, error) {
+		lookups++
+		return []string{"127.0.0.1"}, lookupErr
+	}
+	dial := func() error {
+		conn, err := cache.dialContext(context.Background(), "tcp", net.JoinHostPort("bidder.test", port))
+		if err == nil {
+			conn.Close()
+		}
+		return err
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := dial(); err != nil {
+			t.Fatalf("dial %d failed: %v", i, err)
+		}
+	}
+	if lookups != 1 {
+		t.Errorf("expected one lookup within the TTL, got %d", lookups)
+	}
+
+	now = now.Add(2 * time.Minute)
+	lookupErr = errors.New("resolver down")
+	if err := dial(); err != nil {
+		t.Errorf("expected the last addresses kept when re-resolving fails, got %v", err)
+	}
+	if lookups != 2 {
+		t.Errorf("expected an expired entry re-resolved, got %d lookups", lookups)
+	}
+
+	// Addresses that no longer answer are dropped so the next dial re-resolves
+	listener.Close()
+	if err := dial(); err == nil {
+		t.Fatal("expected a dial to a closed port to fail")
+	}
+	if _, cached := cache.entries["bidder.test"]; cached {
+		t.Error("expected unreachable addresses dropped from the cache")
+	}
+}
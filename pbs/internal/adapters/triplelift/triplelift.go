@@ -3,14 +3,36 @@ package triplelift
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/jsoncodec"
 )
 
-const defaultEndpoint = "https://tlx.3lift.com/s2s/auction"
+const (
+	bidderCode      = "triplelift"
+	defaultEndpoint = "https://tlx.3lift.com/s2s/auction"
+)
+
+// ImpParams are the TripleLift params in imp.ext.prebid.bidder.triplelift
+type ImpParams struct {
+	InventoryCode string  `json:"inventoryCode"`
+	Floor         float64 `json:"floor,omitempty"`
+}
+
+// paramsSchema is the JSON schema for ImpParams
+const paramsSchema = `{
+	"title": "TripleLift Adapter Params",
+	"type": "object",
+	"properties": {
+		"inventoryCode": {"type": "string", "minLength": 1, "description": "Inventory code, sent as imp.tagid"},
+		"floor": {"type": "number", "minimum": 0, "description": "Floor used when the imp has none"}
+	},
+	"required": ["inventoryCode"]
+}`
 
 // Adapter implements the TripleLift bidder
 type Adapter struct {
@@ -24,10 +46,45 @@ func New(endpoint string) *Adapter {
 	return &Adapter{endpoint: endpoint}
 }
 
+// MakeRequests sends the banner and native imps in one request, with the
+// inventory code as the tag ID. Other formats are dropped.
 func (a *Adapter) MakeRequests(request *openrtb.BidRequest, extraInfo *adapters.ExtraRequestInfo) ([]*adapters.RequestData, []error) {
-	requestBody, err := json.Marshal(request)
+	var errs []error
+	imps := make([]openrtb.Imp, 0, len(request.Imp))
+	for _, imp := range request.Imp {
+		var params ImpParams
+		if err := adapters.ImpParams(&imp, bidderCode, &params); err != nil {
+			if !errors.Is(err, adapters.ErrNoParams) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if params.InventoryCode == "" {
+			errs = append(errs, adapters.NewBadInputError(bidderCode, fmt.Sprintf("imp %s: inventoryCode is required", imp.ID)))
+			continue
+		}
+		if imp.Banner == nil && imp.Native == nil {
+			errs = append(errs, adapters.NewBadInputError(bidderCode, fmt.Sprintf("imp %s: TripleLift only supports banner and native", imp.ID)))
+			continue
+		}
+		imp.TagID = params.InventoryCode
+		if imp.BidFloor == 0 && params.Floor > 0 {
+			imp.BidFloor = params.Floor
+		}
+		imp.Video = nil
+		imp.Audio = nil
+		imp.Ext = nil
+		imps = append(imps, imp)
+	}
+	if len(imps) == 0 {
+		return nil, errs
+	}
+
+	reqCopy := *request
+	reqCopy.Imp = imps
+	requestBody, err := jsoncodec.Marshal(reqCopy)
 	if err != nil {
-		return nil, []error{fmt.Errorf("failed to marshal request: %v", err)}
+		return nil, append(errs, adapters.NewMarshalError(bidderCode, err))
 	}
 
 	headers := http.Header{}
@@ -36,7 +93,7 @@ func (a *Adapter) MakeRequests(request *openrtb.BidRequest, extraInfo *adapters.
 
 	return []*adapters.RequestData{
 		{Method: "POST", URI: a.endpoint, Body: requestBody, Headers: headers},
-	}, nil
+	}, errs
 }
 
 func (a *Adapter) MakeBids(request *openrtb.BidRequest, responseData *adapters.ResponseData) (*adapters.BidderResponse, []error) {
@@ -44,32 +101,46 @@ func (a *Adapter) MakeBids(request *openrtb.BidRequest, responseData *adapters.R
 		return nil, nil
 	}
 	if responseData.StatusCode != http.StatusOK {
-		return nil, []error{fmt.Errorf("unexpected status: %d", responseData.StatusCode)}
+		return nil, []error{adapters.NewBadStatusError(bidderCode, responseData.StatusCode)}
 	}
 
 	var bidResp openrtb.BidResponse
-	if err := json.Unmarshal(responseData.Body, &bidResp); err != nil {
-		return nil, []error{fmt.Errorf("failed to parse response: %v", err)}
+	if err := jsoncodec.Unmarshal(responseData.Body, &bidResp); err != nil {
+		return nil, []error{adapters.NewParseError(bidderCode, err)}
 	}
 
 	response := &adapters.BidderResponse{Currency: bidResp.Cur, ResponseID: bidResp.ID, Bids: make([]*adapters.TypedBid, 0)}
+	impMap := adapters.BuildImpMap(request.Imp)
 	for _, seatBid := range bidResp.SeatBid {
 		for i := range seatBid.Bid {
+			bid := &seatBid.Bid[i]
 			response.Bids = append(response.Bids, &adapters.TypedBid{
-				Bid:     &seatBid.Bid[i],
-				BidType: adapters.BidTypeNative,
+				Bid:     bid,
+				BidType: bidType(bid, impMap),
 			})
 		}
 	}
 	return response, nil
 }
 
+// bidType picks native or banner; video parts of an imp were never sent
+func bidType(bid *openrtb.Bid, impMap map[string]*openrtb.Imp) adapters.BidType {
+	if bidType, ok := adapters.BidTypeFromMType(bid.MType); ok {
+		return bidType
+	}
+	if imp, ok := impMap[bid.ImpID]; ok && imp.Native != nil && imp.Banner == nil {
+		return adapters.BidTypeNative
+	}
+	return adapters.BidTypeBanner
+}
+
 func Info() adapters.BidderInfo {
 	return adapters.BidderInfo{
-		Enabled:     true,
-		GVLVendorID: 28,
-		Endpoint:    defaultEndpoint,
-		Maintainer:  &adapters.MaintainerInfo{Email: "prebid@triplelift.com"},
+		Enabled:      true,
+		GVLVendorID:  28,
+		Endpoint:     defaultEndpoint,
+		ParamsSchema: json.RawMessage(paramsSchema),
+		Maintainer:   &adapters.MaintainerInfo{Email: "prebid@triplelift.com"},
 		Capabilities: &adapters.CapabilitiesInfo{
 			Site: &adapters.PlatformInfo{MediaTypes: []adapters.BidType{adapters.BidTypeBanner, adapters.BidTypeNative}},
 			App:  &adapters.PlatformInfo{MediaTypes: []adapters.BidType{adapters.BidTypeBanner, adapters.BidTypeNative}},
@@ -78,5 +149,5 @@ func Info() adapters.BidderInfo {
 }
 
 func init() {
-	adapters.RegisterAdapter("triplelift", New(""), Info())
+	adapters.RegisterAdapter(bidderCode, New(""), Info())
 }
@@ -0,0 +1,22 @@
+package triplelift
+
+import (
+	"testing"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters/adapterstest"
+)
+
+func TestJSONSamples(t *testing.T) {
+	adapterstest.RunJSONBidderTest(t, "triplelifttest", New(""))
+}
+
+func TestParamsSchema(t *testing.T) {
+	adapterstest.RunParamsSchemaTest(t, Info(), []string{
+		`{"inventoryCode":"inv"}`,
+		`{"inventoryCode":"inv","floor":1.5}`,
+	}, []string{
+		`{}`,
+		`{"inventoryCode":""}`,
+		`{"inventoryCode":"inv","floor":"1"}`,
+	})
+}
@@ -3,7 +3,12 @@
 package unruly
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
 )
 
 const (
@@ -12,6 +17,26 @@ const (
 	gvlVendorID     = 36
 )
 
+// ImpParams are the Unruly params in imp.ext.prebid.bidder.unruly
+type ImpParams struct {
+	SiteID int `json:"siteId"`
+}
+
+// paramsSchema is the JSON schema for ImpParams
+const paramsSchema = `{
+	"title": "Unruly Adapter Params",
+	"type": "object",
+	"properties": {
+		"siteId": {"type": "integer", "minimum": 1, "description": "Site ID"}
+	},
+	"required": ["siteId"]
+}`
+
+// impExt is the imp.ext Unruly expects, holding only its own params
+type impExt struct {
+	Bidder ImpParams `json:"bidder"`
+}
+
 // Adapter wraps SimpleAdapter for Unruly
 type Adapter struct {
 	*adapters.SimpleAdapter
@@ -23,17 +48,52 @@ func New(endpoint string) *Adapter {
 		endpoint = defaultEndpoint
 	}
 	return &Adapter{
-		SimpleAdapter: adapters.NewSimpleAdapter(bidderCode, endpoint, adapters.BidTypeVideo),
+		SimpleAdapter: adapters.NewSimpleAdapter(bidderCode, endpoint, ""),
 	}
 }
 
+// MakeRequests forwards the imps that carry an Unruly site ID
+func (a *Adapter) MakeRequests(request *openrtb.BidRequest, extraInfo *adapters.ExtraRequestInfo) ([]*adapters.RequestData, []error) {
+	var errs []error
+	imps := make([]openrtb.Imp, 0, len(request.Imp))
+	for _, imp := range request.Imp {
+		var params ImpParams
+		if err := adapters.ImpParams(&imp, bidderCode, &params); err != nil {
+			if !errors.Is(err, adapters.ErrNoParams) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if params.SiteID <= 0 {
+			errs = append(errs, adapters.NewBadInputError(bidderCode, fmt.Sprintf("imp %s: siteId is required", imp.ID)))
+			continue
+		}
+		ext, err := json.Marshal(impExt{Bidder: params})
+		if err != nil {
+			errs = append(errs, adapters.NewMarshalError(bidderCode, err))
+			continue
+		}
+		imp.Ext = ext
+		imps = append(imps, imp)
+	}
+	if len(imps) == 0 {
+		return nil, errs
+	}
+
+	reqCopy := *request
+	reqCopy.Imp = imps
+	requests, reqErrs := a.SimpleAdapter.MakeRequests(&reqCopy, extraInfo)
+	return requests, append(errs, reqErrs...)
+}
+
 // Info returns Unruly bidder information
 func Info() adapters.BidderInfo {
 	return adapters.BidderInfo{
-		Enabled:     true,
-		GVLVendorID: gvlVendorID,
-		Endpoint:    defaultEndpoint,
-		Maintainer:  &adapters.MaintainerInfo{Email: "prebid@unruly.co"},
+		Enabled:      true,
+		GVLVendorID:  gvlVendorID,
+		Endpoint:     defaultEndpoint,
+		ParamsSchema: json.RawMessage(paramsSchema),
+		Maintainer:   &adapters.MaintainerInfo{Email: "prebid@unruly.co"},
 		Capabilities: &adapters.CapabilitiesInfo{
 			Site: &adapters.PlatformInfo{MediaTypes: []adapters.BidType{adapters.BidTypeBanner, adapters.BidTypeVideo}},
 		},
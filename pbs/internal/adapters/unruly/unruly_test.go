@@ -0,0 +1,21 @@
+package unruly
+
+import (
+	"testing"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters/adapterstest"
+)
+
+func TestJSONSamples(t *testing.T) {
+	adapterstest.RunJSONBidderTest(t, "unrulytest", New(""))
+}
+
+func TestParamsSchema(t *testing.T) {
+	adapterstest.RunParamsSchemaTest(t, Info(), []string{
+		`{"siteId":123}`,
+	}, []string{
+		`{}`,
+		`{"siteId":"123"}`,
+		`{"siteId":0}`,
+	})
+}
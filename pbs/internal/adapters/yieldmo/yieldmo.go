@@ -0,0 +1,163 @@
+// Package yieldmo implements the Yieldmo bidder adapter
+package yieldmo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/jsoncodec"
+)
+
+const (
+	bidderCode      = "yieldmo"
+	defaultEndpoint = "https://ads.yieldmo.com/exchange/prebid-server"
+)
+
+// ImpParams are the Yieldmo params in imp.ext.prebid.bidder.yieldmo
+type ImpParams struct {
+	PlacementID string `json:"placementId"`
+}
+
+// paramsSchema is the JSON schema for ImpParams
+const paramsSchema = `{
+	"title": "Yieldmo Adapter Params",
+	"type": "object",
+	"properties": {
+		"placementId": {"type": "string", "minLength": 1, "description": "Placement ID, sent as imp.tagid"}
+	},
+	"required": ["placementId"]
+}`
+
+// impExt is the imp.ext Yieldmo expects
+type impExt struct {
+	PlacementID string `json:"placement_id"`
+}
+
+// bidExt is where Yieldmo reports each bid's media type
+type bidExt struct {
+	MediaType adapters.BidType `json:"mediatype"`
+}
+
+// Adapter implements the Yieldmo bidder
+type Adapter struct {
+	endpoint string
+}
+
+// New creates a new Yieldmo adapter
+func New(endpoint string) *Adapter {
+	if endpoint == "" {
+		endpoint = defaultEndpoint
+	}
+	return &Adapter{endpoint: endpoint}
+}
+
+// MakeRequests sends all imps in one request, with the placement as the tag ID
+func (a *Adapter) MakeRequests(request *openrtb.BidRequest, extraInfo *adapters.ExtraRequestInfo) ([]*adapters.RequestData, []error) {
+	var errs []error
+	imps := make([]openrtb.Imp, 0, len(request.Imp))
+	for _, imp := range request.Imp {
+		var params ImpParams
+		if err := adapters.ImpParams(&imp, bidderCode, &params); err != nil {
+			if !errors.Is(err, adapters.ErrNoParams) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if params.PlacementID == "" {
+			errs = append(errs, adapters.NewBadInputError(bidderCode, fmt.Sprintf("imp %s: placementId is required", imp.ID)))
+			continue
+		}
+		ext, err := json.Marshal(impExt{PlacementID: params.PlacementID})
+		if err != nil {
+			errs = append(errs, adapters.NewMarshalError(bidderCode, err))
+			continue
+		}
+		imp.TagID = params.PlacementID
+		imp.Ext = ext
+		imps = append(imps, imp)
+	}
+	if len(imps) == 0 {
+		return nil, errs
+	}
+
+	reqCopy := *request
+	reqCopy.Imp = imps
+	requestBody, err := jsoncodec.Marshal(reqCopy)
+	if err != nil {
+		return nil, append(errs, adapters.NewMarshalError(bidderCode, err))
+	}
+
+	headers := http.Header{}
+	headers.Set("Content-Type", "application/json;charset=utf-8")
+	headers.Set("Accept", "application/json")
+
+	return []*adapters.RequestData{
+		{Method: "POST", URI: a.endpoint, Body: requestBody, Headers: headers},
+	}, errs
+}
+
+// MakeBids parses Yieldmo responses into bids
+func (a *Adapter) MakeBids(request *openrtb.BidRequest, responseData *adapters.ResponseData) (*adapters.BidderResponse, []error) {
+	if responseData.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+	if responseData.StatusCode == http.StatusBadRequest {
+		return nil, []error{adapters.NewBadRequestError(bidderCode, string(responseData.Body))}
+	}
+	if responseData.StatusCode != http.StatusOK {
+		return nil, []error{adapters.NewBadStatusError(bidderCode, responseData.StatusCode)}
+	}
+
+	var bidResp openrtb.BidResponse
+	if err := jsoncodec.Unmarshal(responseData.Body, &bidResp); err != nil {
+		return nil, []error{adapters.NewParseError(bidderCode, err)}
+	}
+
+	response := &adapters.BidderResponse{Currency: bidResp.Cur, ResponseID: bidResp.ID, Bids: make([]*adapters.TypedBid, 0)}
+	impMap := adapters.BuildImpMap(request.Imp)
+	for _, seatBid := range bidResp.SeatBid {
+		for i := range seatBid.Bid {
+			bid := &seatBid.Bid[i]
+			response.Bids = append(response.Bids, &adapters.TypedBid{
+				Bid:     bid,
+				BidType: bidType(bid, impMap),
+			})
+		}
+	}
+	return response, nil
+}
+
+// bidType prefers the media type Yieldmo reports in bid.ext.mediatype
+func bidType(bid *openrtb.Bid, impMap map[string]*openrtb.Imp) adapters.BidType {
+	var ext bidExt
+	if len(bid.Ext) > 0 && json.Unmarshal(bid.Ext, &ext) == nil {
+		switch ext.MediaType {
+		case adapters.BidTypeBanner, adapters.BidTypeVideo:
+			return ext.MediaType
+		}
+	}
+	return adapters.GetBidTypeFromMap(bid, impMap)
+}
+
+// Info returns bidder information
+func Info() adapters.BidderInfo {
+	return adapters.BidderInfo{
+		Enabled:      true,
+		GVLVendorID:  173,
+		Endpoint:     defaultEndpoint,
+		ParamsSchema: json.RawMessage(paramsSchema),
+		Maintainer:   &adapters.MaintainerInfo{Email: "prebid@yieldmo.com"},
+		Capabilities: &adapters.CapabilitiesInfo{
+			Site: &adapters.PlatformInfo{MediaTypes: []adapters.BidType{adapters.BidTypeBanner, adapters.BidTypeVideo}},
+			App:  &adapters.PlatformInfo{MediaTypes: []adapters.BidType{adapters.BidTypeBanner, adapters.BidTypeVideo}},
+		},
+	}
+}
+
+func init() {
+	adapters.RegisterAdapter(bidderCode, New(""), Info())
+}
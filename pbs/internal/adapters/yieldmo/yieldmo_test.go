@@ -0,0 +1,21 @@
+package yieldmo
+
+import (
+	"testing"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters/adapterstest"
+)
+
+func TestJSONSamples(t *testing.T) {
+	adapterstest.RunJSONBidderTest(t, "yieldmotest", New(""))
+}
+
+func TestParamsSchema(t *testing.T) {
+	adapterstest.RunParamsSchemaTest(t, Info(), []string{
+		`{"placementId":"123"}`,
+	}, []string{
+		`{}`,
+		`{"placementId":""}`,
+		`{"placementId":123}`,
+	})
+}
@@ -0,0 +1,185 @@
+// Package analytics passes auction, user sync and event outcomes to pluggable
+// modules, e.g. to load them into a data warehouse. Modules are registered on
+// a Runner, which the endpoints call after handling each request.
+package analytics
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/logger"
+)
+
+// Module receives request outcomes. Calls are made on the request path, so
+// modules must return quickly and hand slow work (disk, network) elsewhere.
+// Objects must not be modified or kept after the call returns.
+type Module interface {
+	LogAuctionObject(ao *AuctionObject)
+	LogCookieSyncObject(co *CookieSyncObject)
+	LogSetUIDObject(so *SetUIDObject)
+	LogNotificationEvent(ne *NotificationEvent)
+}
+
+// AuctionObject is an /openrtb2/auction request and its outcome; AMP and video
+// auctions are forwarded there and logged the same way
+type AuctionObject struct {
+	Status    int                  `json:"status"`
+	Errors    []string             `json:"errors,omitempty"`
+	Account   string               `json:"account,omitempty"`
+	Request   *openrtb.BidRequest  `json:"request,omitempty"`  // nil when the body couldn't be parsed
+	Response  *openrtb.BidResponse `json:"response,omitempty"` // nil when the auction didn't run
+	Bidders   []BidderOutcome      `json:"bidders,omitempty"`
+	StartTime time.Time            `json:"start_time"`
+}
+
+// BidderOutcome summarises one called bidder's part in an auction
+type BidderOutcome struct {
+	Bidder    string `json:"bidder"`
+	Bids      int    `json:"bids"`
+	LatencyMS int64  `json:"latency_ms"`
+	TimedOut  bool   `json:"timed_out,omitempty"`
+	Errors    int    `json:"errors,omitempty"`
+}
+
+// CookieSyncObject is a /cookie_sync request and the syncs returned
+type CookieSyncObject struct {
+	Status  int                `json:"status"`
+	Errors  []string           `json:"errors,omitempty"`
+	OptOut  bool               `json:"opt_out,omitempty"`
+	Bidders []CookieSyncBidder `json:"bidders,omitempty"`
+}
+
+// CookieSyncBidder is one bidder's status in a cookie sync response
+type CookieSyncBidder struct {
+	Bidder   string `json:"bidder"`
+	NoCookie bool   `json:"no_cookie,omitempty"` // A sync was returned for the bidder
+	Error    string `json:"error,omitempty"`
+}
+
+// SetUIDObject is a /setuid request
+type SetUIDObject struct {
+	Status  int      `json:"status"`
+	Errors  []string `json:"errors,omitempty"`
+	Bidder  string   `json:"bidder"`
+	UID     string   `json:"uid,omitempty"`
+	Success bool     `json:"success"` // The cookie was updated with the bidder's UID (or its removal)
+}
+
+// NotificationEvent is a win or imp notification received on /event
+type NotificationEvent struct {
+	Type      string    `json:"type"` // win or imp
+	BidID     string    `json:"bid_id"`
+	Bidder    string    `json:"bidder,omitempty"`
+	Account   string    `json:"account,omitempty"`
+	AuctionID string    `json:"auction_id,omitempty"` // Empty when the bid couldn't be matched to its auction
+	Result    string    `json:"result"`               // recorded, duplicate or unmatched
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Record types
+const (
+	RecordAuction      = "auction"
+	RecordCookieSync   = "cookie_sync"
+	RecordSetUID       = "setuid"
+	RecordNotification = "notification"
+)
+
+// Record is the envelope modules that export objects write them in, one per
+// line for files or as a JSON array per batch
+type Record struct {
+	Type      string          `json:"type"`
+	Timestamp time.Time       `json:"timestamp"` // When the record was logged
+	Data      json.RawMessage `json:"data"`
+}
+
+// NewRecord serializes an object into a record of the given type
+func NewRecord(recordType string, object any) (Record, error) {
+	data, err := json.Marshal(object)
+	if err != nil {
+		return Record{}, err
+	}
+	return Record{Type: recordType, Timestamp: time.Now().UTC(), Data: data}, nil
+}
+
+// Runner fans each object out to every registered module. A module that
+// panics is logged and skipped so it can't fail the request.
+type Runner struct {
+	modules []namedModule
+}
+
+type namedModule struct {
+	name   string
+	module Module
+}
+
+// NewRunner creates a runner without modules
+func NewRunner() *Runner {
+	return &Runner{}
+}
+
+// Register adds a module. Must be called before the runner is shared across goroutines.
+func (r *Runner) Register(name string, m Module) {
+	r.modules = append(r.modules, namedModule{name: name, module: m})
+}
+
+// Names returns the registered modules' names in registration order
+func (r *Runner) Names() []string {
+	names := make([]string, len(r.modules))
+	for i, m := range r.modules {
+		names[i] = m.name
+	}
+	return names
+}
+
+// LogAuctionObject implements Module
+func (r *Runner) LogAuctionObject(ao *AuctionObject) {
+	r.each(RecordAuction, func(m Module) { m.LogAuctionObject(ao) })
+}
+
+// LogCookieSyncObject implements Module
+func (r *Runner) LogCookieSyncObject(co *CookieSyncObject) {
+	r.each(RecordCookieSync, func(m Module) { m.LogCookieSyncObject(co) })
+}
+
+// LogSetUIDObject implements Module
+func (r *Runner) LogSetUIDObject(so *SetUIDObject) {
+	r.each(RecordSetUID, func(m Module) { m.LogSetUIDObject(so) })
+}
+
+// LogNotificationEvent implements Module
+func (r *Runner) LogNotificationEvent(ne *NotificationEvent) {
+	r.each(RecordNotification, func(m Module) { m.LogNotificationEvent(ne) })
+}
+
+func (r *Runner) each(recordType string, log func(Module)) {
+	for _, m := range r.modules {
+		func() {
+			defer func() {
+				if p := recover(); p != nil {
+					logger.Log.Error().
+						Str("module", m.name).
+						Str("record", recordType).
+						Interface("panic", p).
+						Msg("analytics module panicked")
+				}
+			}()
+			log(m.module)
+		}()
+	}
+}
+
+// Close closes every module that implements io.Closer, flushing what they hold
+func (r *Runner) Close() error {
+	var errs []error
+	for _, m := range r.modules {
+		if c, ok := m.module.(io.Closer); ok {
+			if err := c.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,88 @@
+package analytics
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+type recordingModule struct {
+	logged []string
+	closed bool
+	err    error
+}
+
+func (m *recordingModule) LogAuctionObject(ao *AuctionObject) {
+	m.logged = append(m.logged, RecordAuction)
+}
+
+func (m *recordingModule) LogCookieSyncObject(co *CookieSyncObject) {
+	m.logged = append(m.logged, RecordCookieSync)
+}
+
+func (m *recordingModule) LogSetUIDObject(so *SetUIDObject) {
+	m.logged = append(m.logged, RecordSetUID)
+}
+
+func (m *recordingModule) LogNotificationEvent(ne *NotificationEvent) {
+	m.logged = append(m.logged, RecordNotification)
+}
+
+func (m *recordingModule) Close() error {
+	m.closed = true
+	return m.err
+}
+
+// panickingModule fails every call; it doesn't implement io.Closer
+type panickingModule struct{}
+
+func (panickingModule) LogAuctionObject(*AuctionObject)         { panic("auction") }
+func (panickingModule) LogCookieSyncObject(*CookieSyncObject)   { panic("cookie sync") }
+func (panickingModule) LogSetUIDObject(*SetUIDObject)           { panic("setuid") }
+func (panickingModule) LogNotificationEvent(*NotificationEvent) { panic("notification") }
+
+func TestRunner(t *testing.T) {
+	first, last := &recordingModule{}, &recordingModule{err: errors.New("flush failed")}
+	r := NewRunner()
+	r.Register("first", first)
+	r.Register("broken", panickingModule{})
+	r.Register("last", last)
+
+	r.LogAuctionObject(&AuctionObject{})
+	r.LogCookieSyncObject(&CookieSyncObject{})
+	r.LogSetUIDObject(&SetUIDObject{})
+	r.LogNotificationEvent(&NotificationEvent{})
+
+	want := []string{RecordAuction, RecordCookieSync, RecordSetUID, RecordNotification}
+	for _, m := range []*recordingModule{first, last} {
+		if len(m.logged) != len(want) {
+			t.Fatalf("expected every object logged past a panicking module, got %v", m.logged)
+		}
+		for i := range want {
+			if m.logged[i] != want[i] {
+				t.Errorf("call %d: expected %s, got %s", i, want[i], m.logged[i])
+			}
+		}
+	}
+	if names := r.Names(); len(names) != 3 || names[0] != "first" || names[2] != "last" {
+		t.Errorf("expected names in registration order, got %v", names)
+	}
+
+	if err := r.Close(); err == nil || !first.closed || !last.closed {
+		t.Errorf("expected every closer closed and its error returned, got %v", err)
+	}
+}
+
+func TestNewRecord(t *testing.T) {
+	record, err := NewRecord(RecordSetUID, &SetUIDObject{Status: 200, Bidder: "appnexus", Success: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if record.Type != RecordSetUID || record.Timestamp.IsZero() {
+		t.Errorf("expected a typed, timestamped record, got %+v", record)
+	}
+	var so SetUIDObject
+	if err := json.Unmarshal(record.Data, &so); err != nil || so.Bidder != "appnexus" || !so.Success {
+		t.Errorf("expected the object serialized, got %s", record.Data)
+	}
+}
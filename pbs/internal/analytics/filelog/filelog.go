@@ -0,0 +1,76 @@
+// Package filelog is an analytics module that appends every object to a file
+// as a line of JSON, for log shippers to pick up
+package filelog
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/analytics"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/logger"
+)
+
+// Module writes analytics records to a file, one JSON object per line
+type Module struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// New opens path for appending, creating it if needed
+func New(path string) (*Module, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &Module{file: file}, nil
+}
+
+// LogAuctionObject implements analytics.Module
+func (m *Module) LogAuctionObject(ao *analytics.AuctionObject) {
+	m.write(analytics.RecordAuction, ao)
+}
+
+// LogCookieSyncObject implements analytics.Module
+func (m *Module) LogCookieSyncObject(co *analytics.CookieSyncObject) {
+	m.write(analytics.RecordCookieSync, co)
+}
+
+// LogSetUIDObject implements analytics.Module
+func (m *Module) LogSetUIDObject(so *analytics.SetUIDObject) {
+	m.write(analytics.RecordSetUID, so)
+}
+
+// LogNotificationEvent implements analytics.Module
+func (m *Module) LogNotificationEvent(ne *analytics.NotificationEvent) {
+	m.write(analytics.RecordNotification, ne)
+}
+
+// write appends one line; O_APPEND keeps lines whole when several processes
+// share the file
+func (m *Module) write(recordType string, object any) {
+	record, err := analytics.NewRecord(recordType, object)
+	if err != nil {
+		logger.Log.Warn().Err(err).Str("record", recordType).Msg("analytics file: failed to encode record")
+		return
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		logger.Log.Warn().Err(err).Str("record", recordType).Msg("analytics file: failed to encode record")
+		return
+	}
+	line = append(line, '\n')
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, err := m.file.Write(line); err != nil {
+		logger.Log.Warn().Err(err).Str("file", m.file.Name()).Msg("analytics file: write failed")
+	}
+}
+
+// Close closes the file
+func (m *Module) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.file.Close()
+}
@@ -0,0 +1,61 @@
+package filelog
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/analytics"
+)
+
+func TestModule(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "analytics.jsonl")
+	if err := os.WriteFile(path, []byte(`{"type":"earlier"}`+"\n"), 0o644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	m, err := New(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m.LogAuctionObject(&analytics.AuctionObject{Status: 200, Account: "pub-1"})
+	m.LogCookieSyncObject(&analytics.CookieSyncObject{Status: 200})
+	m.LogSetUIDObject(&analytics.SetUIDObject{Status: 200, Bidder: "appnexus"})
+	m.LogNotificationEvent(&analytics.NotificationEvent{Type: "win", BidID: "bid-1"})
+	if err := m.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	defer file.Close()
+	var types []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record analytics.Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("expected a JSON record per line, got %q", scanner.Text())
+		}
+		types = append(types, record.Type)
+	}
+
+	want := []string{"earlier", analytics.RecordAuction, analytics.RecordCookieSync, analytics.RecordSetUID, analytics.RecordNotification}
+	if len(types) != len(want) {
+		t.Fatalf("expected records appended after existing lines, got %v", types)
+	}
+	for i := range want {
+		if types[i] != want[i] {
+			t.Errorf("line %d: expected %s, got %s", i, want[i], types[i])
+		}
+	}
+}
+
+func TestNew_BadPath(t *testing.T) {
+	if _, err := New(filepath.Join(t.TempDir(), "missing", "analytics.jsonl")); err == nil {
+		t.Error("expected an error for a directory that doesn't exist")
+	}
+}
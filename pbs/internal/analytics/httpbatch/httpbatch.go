@@ -0,0 +1,211 @@
+// Package httpbatch is an analytics module that POSTs objects in batches to an
+// HTTP collector, such as a data warehouse ingestion endpoint
+package httpbatch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/analytics"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/logger"
+)
+
+// Config controls batching and delivery
+type Config struct {
+	URL           string        // Collector the batches are POSTed to
+	BatchSize     int           // Records per batch
+	FlushInterval time.Duration // A partial batch is sent after this long
+	BufferSize    int           // Records queued for sending; more are dropped
+	Timeout       time.Duration // Per-batch request timeout
+}
+
+// DefaultConfig returns the default batching settings for a collector URL
+func DefaultConfig(url string) *Config {
+	return &Config{
+		URL:           url,
+		BatchSize:     100,
+		FlushInterval: 5 * time.Second,
+		BufferSize:    10000,
+		Timeout:       5 * time.Second,
+	}
+}
+
+// Stats counts records by fate
+type Stats struct {
+	Sent    int64 `json:"sent"`
+	Dropped int64 `json:"dropped"` // Queue full
+	Failed  int64 `json:"failed"`  // In batches the collector didn't accept
+}
+
+// Module queues records and sends them as a JSON array of analytics.Record
+// from a single background worker. Nothing is retried: the collector losing
+// a batch loses its records, and the request path never waits on it.
+type Module struct {
+	config *Config
+	client *http.Client
+	queue  chan analytics.Record
+	stop   chan struct{}
+	once   sync.Once
+	wg     sync.WaitGroup
+
+	sent    atomic.Int64
+	dropped atomic.Int64
+	failed  atomic.Int64
+}
+
+// New starts a module sending to config.URL; unset values use DefaultConfig
+func New(config *Config) (*Module, error) {
+	if config == nil || config.URL == "" {
+		return nil, fmt.Errorf("analytics collector URL is required")
+	}
+	defaults := DefaultConfig(config.URL)
+	c := *config
+	if c.BatchSize <= 0 {
+		c.BatchSize = defaults.BatchSize
+	}
+	if c.FlushInterval <= 0 {
+		c.FlushInterval = defaults.FlushInterval
+	}
+	if c.BufferSize <= 0 {
+		c.BufferSize = defaults.BufferSize
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = defaults.Timeout
+	}
+
+	m := &Module{
+		config: &c,
+		client: &http.Client{Timeout: c.Timeout},
+		queue:  make(chan analytics.Record, c.BufferSize),
+		stop:   make(chan struct{}),
+	}
+	m.wg.Add(1)
+	go m.run()
+	return m, nil
+}
+
+// LogAuctionObject implements analytics.Module
+func (m *Module) LogAuctionObject(ao *analytics.AuctionObject) {
+	m.enqueue(analytics.RecordAuction, ao)
+}
+
+// LogCookieSyncObject implements analytics.Module
+func (m *Module) LogCookieSyncObject(co *analytics.CookieSyncObject) {
+	m.enqueue(analytics.RecordCookieSync, co)
+}
+
+// LogSetUIDObject implements analytics.Module
+func (m *Module) LogSetUIDObject(so *analytics.SetUIDObject) {
+	m.enqueue(analytics.RecordSetUID, so)
+}
+
+// LogNotificationEvent implements analytics.Module
+func (m *Module) LogNotificationEvent(ne *analytics.NotificationEvent) {
+	m.enqueue(analytics.RecordNotification, ne)
+}
+
+// Stats returns the record counts so far
+func (m *Module) Stats() Stats {
+	return Stats{Sent: m.sent.Load(), Dropped: m.dropped.Load(), Failed: m.failed.Load()}
+}
+
+// enqueue serializes the object now, since the caller may reuse it, and
+// drops it if the queue is full
+func (m *Module) enqueue(recordType string, object any) {
+	record, err := analytics.NewRecord(recordType, object)
+	if err != nil {
+		logger.Log.Warn().Err(err).Str("record", recordType).Msg("analytics collector: failed to encode record")
+		return
+	}
+	select {
+	case m.queue <- record:
+	default:
+		m.dropped.Add(1)
+	}
+}
+
+// run batches queued records until Close, then sends what's left
+func (m *Module) run() {
+	defer m.wg.Done()
+	ticker := time.NewTicker(m.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]analytics.Record, 0, m.config.BatchSize)
+	add := func(record analytics.Record) {
+		batch = append(batch, record)
+		if len(batch) >= m.config.BatchSize {
+			m.send(batch)
+			batch = make([]analytics.Record, 0, m.config.BatchSize)
+		}
+	}
+	for {
+		select {
+		case record := <-m.queue:
+			add(record)
+		case <-ticker.C:
+			if len(batch) > 0 {
+				m.send(batch)
+				batch = make([]analytics.Record, 0, m.config.BatchSize)
+			}
+		case <-m.stop:
+			for {
+				select {
+				case record := <-m.queue:
+					add(record)
+				default:
+					if len(batch) > 0 {
+						m.send(batch)
+					}
+					return
+				}
+			}
+		}
+	}
+}
+
+// send POSTs one batch, counting its records as sent or failed
+func (m *Module) send(batch []analytics.Record) {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		m.failed.Add(int64(len(batch)))
+		logger.Log.Warn().Err(err).Msg("analytics collector: failed to encode batch")
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), m.config.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.config.URL, bytes.NewReader(body))
+	if err != nil {
+		m.failed.Add(int64(len(batch)))
+		logger.Log.Warn().Err(err).Msg("analytics collector: invalid request")
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		m.failed.Add(int64(len(batch)))
+		logger.Log.Warn().Err(err).Int("records", len(batch)).Msg("analytics collector: batch not delivered")
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		m.failed.Add(int64(len(batch)))
+		logger.Log.Warn().Int("status", resp.StatusCode).Int("records", len(batch)).Msg("analytics collector: batch rejected")
+		return
+	}
+	m.sent.Add(int64(len(batch)))
+}
+
+// Close sends the records still queued and stops the worker. Records logged
+// afterwards are dropped.
+func (m *Module) Close() error {
+	m.once.Do(func() { close(m.stop) })
+	m.wg.Wait()
+	return nil
+}
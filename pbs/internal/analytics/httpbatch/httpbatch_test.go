@@ -0,0 +1,131 @@
+package httpbatch
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/analytics"
+)
+
+// collector records the batches it receives
+type collector struct {
+	mu      sync.Mutex
+	batches [][]analytics.Record
+	status  int
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var batch []analytics.Record
+	if err := json.NewDecoder(r.Body).Decode(&batch); err != nil || r.Header.Get("Content-Type") != "application/json" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.batches = append(c.batches, batch)
+	if c.status != 0 {
+		w.WriteHeader(c.status)
+	}
+}
+
+func (c *collector) sizes() []int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sizes := make([]int, len(c.batches))
+	for i, b := range c.batches {
+		sizes[i] = len(b)
+	}
+	return sizes
+}
+
+func TestModule_Batches(t *testing.T) {
+	c := &collector{}
+	server := httptest.NewServer(c)
+	defer server.Close()
+
+	m, err := New(&Config{URL: server.URL, BatchSize: 2, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m.LogAuctionObject(&analytics.AuctionObject{Status: 200})
+	m.LogSetUIDObject(&analytics.SetUIDObject{Status: 200})
+	m.LogNotificationEvent(&analytics.NotificationEvent{Type: "imp"})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(c.sizes()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if sizes := c.sizes(); len(sizes) != 1 || sizes[0] != 2 {
+		t.Fatalf("expected a full batch sent without waiting for the interval, got %v", sizes)
+	}
+
+	// Close sends the partial batch
+	if err := m.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	if sizes := c.sizes(); len(sizes) != 2 || sizes[1] != 1 {
+		t.Fatalf("expected the remaining record sent on close, got %v", sizes)
+	}
+	if c.batches[0][0].Type != analytics.RecordAuction || c.batches[1][0].Type != analytics.RecordNotification {
+		t.Errorf("expected records in logging order, got %+v", c.batches)
+	}
+	if stats := m.Stats(); stats.Sent != 3 || stats.Failed != 0 || stats.Dropped != 0 {
+		t.Errorf("expected 3 records sent, got %+v", stats)
+	}
+}
+
+func TestModule_FlushInterval(t *testing.T) {
+	c := &collector{}
+	server := httptest.NewServer(c)
+	defer server.Close()
+
+	m, err := New(&Config{URL: server.URL, BatchSize: 100, FlushInterval: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer m.Close()
+	m.LogCookieSyncObject(&analytics.CookieSyncObject{Status: 200})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(c.sizes()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if sizes := c.sizes(); len(sizes) != 1 || sizes[0] != 1 {
+		t.Errorf("expected a partial batch sent after the flush interval, got %v", sizes)
+	}
+}
+
+func TestModule_Rejected(t *testing.T) {
+	c := &collector{status: http.StatusServiceUnavailable}
+	server := httptest.NewServer(c)
+	defer server.Close()
+
+	m, err := New(&Config{URL: server.URL, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m.LogAuctionObject(&analytics.AuctionObject{Status: 200})
+	m.Close()
+	if stats := m.Stats(); stats.Failed != 1 || stats.Sent != 0 {
+		t.Errorf("expected a rejected batch counted as failed, got %+v", stats)
+	}
+}
+
+func TestModule_QueueFull(t *testing.T) {
+	m := &Module{config: DefaultConfig("http://collector.test"), queue: make(chan analytics.Record, 1)}
+	m.LogAuctionObject(&analytics.AuctionObject{})
+	m.LogAuctionObject(&analytics.AuctionObject{})
+	if stats := m.Stats(); stats.Dropped != 1 {
+		t.Errorf("expected the record past the buffer dropped, got %+v", stats)
+	}
+}
+
+func TestNew_RequiresURL(t *testing.T) {
+	if _, err := New(&Config{}); err == nil {
+		t.Error("expected an error without a collector URL")
+	}
+}
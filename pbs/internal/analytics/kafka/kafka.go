@@ -0,0 +1,296 @@
+// Package kafka is an analytics module that publishes auction summaries and
+// win/imp notifications to a Kafka topic as JSON messages
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/analytics"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/logger"
+)
+
+// Compression codecs
+const (
+	CompressionNone = "none"
+	CompressionGzip = "gzip"
+)
+
+// Acknowledgement levels
+const (
+	AcksNone   = "0"   // Don't wait for the broker
+	AcksLeader = "1"   // The partition leader has written the batch
+	AcksAll    = "all" // Every in-sync replica has
+)
+
+// Message types
+const (
+	MessageAuction = "auction"
+	MessageWin     = "win"
+	MessageImp     = "imp"
+)
+
+// Config controls the brokers, topic and batching
+type Config struct {
+	Brokers       []string      // Bootstrap brokers, host:port
+	Topic         string        // Topic every message is produced to
+	ClientID      string        // Client ID reported to the brokers
+	BatchSize     int           // Messages per produce request
+	FlushInterval time.Duration // A partial batch is sent after this long
+	BufferSize    int           // Messages queued for sending; more are dropped
+	Compression   string        // CompressionNone or CompressionGzip
+	Acks          string        // AcksNone, AcksLeader or AcksAll
+	Timeout       time.Duration // Dial, request and broker acknowledgement timeout
+}
+
+// DefaultConfig returns the default settings for brokers and a topic
+func DefaultConfig(brokers []string, topic string) *Config {
+	return &Config{
+		Brokers:       brokers,
+		Topic:         topic,
+		ClientID:      "thenexusengine-pbs",
+		BatchSize:     100,
+		FlushInterval: time.Second,
+		BufferSize:    10000,
+		Compression:   CompressionNone,
+		Acks:          AcksLeader,
+		Timeout:       10 * time.Second,
+	}
+}
+
+// Stats counts messages by fate
+type Stats struct {
+	Sent    int64 `json:"sent"`
+	Dropped int64 `json:"dropped"` // Queue full
+	Failed  int64 `json:"failed"`  // Not acknowledged after a retry
+}
+
+// AuctionMessage summarises an auction: who was called and what they bid
+type AuctionMessage struct {
+	Type       string                    `json:"type"` // MessageAuction
+	AuctionID  string                    `json:"auction_id"`
+	Account    string                    `json:"account,omitempty"`
+	Status     int                       `json:"status"`
+	Timestamp  time.Time                 `json:"timestamp"`
+	DurationMS int64                     `json:"duration_ms"`
+	Currency   string                    `json:"currency,omitempty"`
+	Bidders    []analytics.BidderOutcome `json:"bidders,omitempty"`
+	Bids       []BidMessage              `json:"bids,omitempty"`
+}
+
+// BidMessage is one bid returned in an auction response
+type BidMessage struct {
+	Seat   string  `json:"seat"`
+	ImpID  string  `json:"imp_id"`
+	BidID  string  `json:"bid_id"`
+	Price  float64 `json:"price"`
+	DealID string  `json:"deal_id,omitempty"`
+}
+
+// Module queues messages and produces them in batches from a single
+// background worker. Messages are keyed by auction ID, so an auction and its
+// notifications land on the same partition. Batches a broker rejects are
+// retried once after refreshing metadata, so a message may be delivered twice.
+type Module struct {
+	config   *Config
+	producer *producer
+	queue    chan message
+	stop     chan struct{}
+	once     sync.Once
+	wg       sync.WaitGroup
+
+	sent    atomic.Int64
+	dropped atomic.Int64
+	failed  atomic.Int64
+}
+
+// New starts a module producing to config.Topic; unset values use DefaultConfig
+func New(config *Config) (*Module, error) {
+	if config == nil || len(config.Brokers) == 0 {
+		return nil, fmt.Errorf("kafka brokers are required")
+	}
+	if config.Topic == "" {
+		return nil, fmt.Errorf("kafka topic is required")
+	}
+	defaults := DefaultConfig(config.Brokers, config.Topic)
+	c := *config
+	if c.ClientID == "" {
+		c.ClientID = defaults.ClientID
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = defaults.BatchSize
+	}
+	if c.FlushInterval <= 0 {
+		c.FlushInterval = defaults.FlushInterval
+	}
+	if c.BufferSize <= 0 {
+		c.BufferSize = defaults.BufferSize
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = defaults.Timeout
+	}
+	switch c.Compression {
+	case "":
+		c.Compression = defaults.Compression
+	case CompressionNone, CompressionGzip:
+	default:
+		return nil, fmt.Errorf("unsupported kafka compression %q (want %s or %s)", c.Compression, CompressionNone, CompressionGzip)
+	}
+	switch c.Acks {
+	case "":
+		c.Acks = defaults.Acks
+	case "-1":
+		c.Acks = AcksAll
+	case AcksNone, AcksLeader, AcksAll:
+	default:
+		return nil, fmt.Errorf("invalid kafka acks %q (want %s, %s or %s)", c.Acks, AcksNone, AcksLeader, AcksAll)
+	}
+
+	m := &Module{
+		config:   &c,
+		producer: newProducer(&c),
+		queue:    make(chan message, c.BufferSize),
+		stop:     make(chan struct{}),
+	}
+	m.wg.Add(1)
+	go m.run()
+	return m, nil
+}
+
+// LogAuctionObject implements analytics.Module
+func (m *Module) LogAuctionObject(ao *analytics.AuctionObject) {
+	msg := AuctionMessage{
+		Type:       MessageAuction,
+		Account:    ao.Account,
+		Status:     ao.Status,
+		Timestamp:  ao.StartTime.UTC(),
+		DurationMS: time.Since(ao.StartTime).Milliseconds(),
+		Bidders:    ao.Bidders,
+	}
+	if ao.Request != nil {
+		msg.AuctionID = ao.Request.ID
+	}
+	if ao.Response != nil {
+		msg.Currency = ao.Response.Cur
+		for _, sb := range ao.Response.SeatBid {
+			for _, bid := range sb.Bid {
+				msg.Bids = append(msg.Bids, BidMessage{
+					Seat:   sb.Seat,
+					ImpID:  bid.ImpID,
+					BidID:  bid.ID,
+					Price:  bid.Price,
+					DealID: bid.DealID,
+				})
+			}
+		}
+	}
+	m.enqueue(msg.AuctionID, msg.Timestamp, msg)
+}
+
+// LogCookieSyncObject implements analytics.Module; user syncs aren't published
+func (m *Module) LogCookieSyncObject(co *analytics.CookieSyncObject) {}
+
+// LogSetUIDObject implements analytics.Module; user syncs aren't published
+func (m *Module) LogSetUIDObject(so *analytics.SetUIDObject) {}
+
+// LogNotificationEvent implements analytics.Module. The event's type (win or
+// imp) is the message type.
+func (m *Module) LogNotificationEvent(ne *analytics.NotificationEvent) {
+	m.enqueue(ne.AuctionID, ne.Timestamp, ne)
+}
+
+// Stats returns the message counts so far
+func (m *Module) Stats() Stats {
+	return Stats{Sent: m.sent.Load(), Dropped: m.dropped.Load(), Failed: m.failed.Load()}
+}
+
+// enqueue serializes the message now, since the caller may reuse the object,
+// and drops it if the queue is full
+func (m *Module) enqueue(auctionID string, ts time.Time, object any) {
+	value, err := json.Marshal(object)
+	if err != nil {
+		logger.Log.Warn().Err(err).Msg("kafka analytics: failed to encode message")
+		return
+	}
+	msg := message{value: value, time: ts}
+	if msg.time.IsZero() {
+		msg.time = time.Now()
+	}
+	if auctionID != "" {
+		msg.key = []byte(auctionID)
+	}
+	select {
+	case m.queue <- msg:
+	default:
+		m.dropped.Add(1)
+	}
+}
+
+// run batches queued messages until Close, then sends what's left
+func (m *Module) run() {
+	defer m.wg.Done()
+	defer m.producer.close()
+	ticker := time.NewTicker(m.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]message, 0, m.config.BatchSize)
+	add := func(msg message) {
+		batch = append(batch, msg)
+		if len(batch) >= m.config.BatchSize {
+			m.send(batch)
+			batch = make([]message, 0, m.config.BatchSize)
+		}
+	}
+	for {
+		select {
+		case msg := <-m.queue:
+			add(msg)
+		case <-ticker.C:
+			if len(batch) > 0 {
+				m.send(batch)
+				batch = make([]message, 0, m.config.BatchSize)
+			}
+		case <-m.stop:
+			for {
+				select {
+				case msg := <-m.queue:
+					add(msg)
+				default:
+					if len(batch) > 0 {
+						m.send(batch)
+					}
+					return
+				}
+			}
+		}
+	}
+}
+
+// send produces one batch, retrying what failed once with fresh metadata,
+// and counts its messages as sent or failed
+func (m *Module) send(batch []message) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*m.config.Timeout)
+	defer cancel()
+
+	failed, err := m.producer.produce(ctx, batch)
+	if len(failed) > 0 {
+		failed, err = m.producer.produce(ctx, failed)
+	}
+	m.sent.Add(int64(len(batch) - len(failed)))
+	if len(failed) > 0 {
+		m.failed.Add(int64(len(failed)))
+		logger.Log.Warn().Err(err).Int("messages", len(failed)).Str("topic", m.config.Topic).Msg("kafka analytics: messages not delivered")
+	}
+}
+
+// Close produces the messages still queued and stops the worker. Messages
+// logged afterwards are dropped.
+func (m *Module) Close() error {
+	m.once.Do(func() { close(m.stop) })
+	m.wg.Wait()
+	return nil
+}
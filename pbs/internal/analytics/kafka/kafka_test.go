@@ -0,0 +1,451 @@
+package kafka
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"hash/crc32"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/analytics"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+// fakeBroker is a single-node cluster answering Metadata v1 and Produce v3
+type fakeBroker struct {
+	t          *testing.T
+	listener   net.Listener
+	partitions int
+
+	mu       sync.Mutex
+	records  map[int32][]received // By partition
+	codecs   []int16
+	failNext int16 // Error code for the next produce request's partitions
+	produces int
+}
+
+type received struct {
+	key, value []byte
+}
+
+func newFakeBroker(t *testing.T, partitions int) *fakeBroker {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	b := &fakeBroker{t: t, listener: listener, partitions: partitions, records: make(map[int32][]received)}
+	go b.serve()
+	t.Cleanup(func() { listener.Close() })
+	return b
+}
+
+func (b *fakeBroker) addr() string { return b.listener.Addr().String() }
+
+func (b *fakeBroker) serve() {
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			return
+		}
+		go b.handle(conn)
+	}
+}
+
+func (b *fakeBroker) handle(conn net.Conn) {
+	defer conn.Close()
+	for {
+		var size [4]byte
+		if _, err := io.ReadFull(conn, size[:]); err != nil {
+			return
+		}
+		req := make([]byte, binary.BigEndian.Uint32(size[:]))
+		if _, err := io.ReadFull(conn, req); err != nil {
+			return
+		}
+		d := &decoder{b: req}
+		apiKey, version, correlationID := d.int16(), d.int16(), d.int32()
+		d.string() // client ID
+
+		var resp *encoder
+		switch {
+		case apiKey == apiMetadata && version == metadataVersion:
+			resp = b.metadata(correlationID, d)
+		case apiKey == apiProduce && version == produceVersion:
+			resp = b.produce(correlationID, d)
+		default:
+			b.t.Errorf("unexpected request: api %d v%d", apiKey, version)
+			return
+		}
+		if d.err != nil {
+			b.t.Errorf("malformed request: %v", d.err)
+			return
+		}
+		if resp != nil {
+			conn.Write(resp.frame())
+		}
+	}
+}
+
+func (b *fakeBroker) metadata(correlationID int32, d *decoder) *encoder {
+	var topic string
+	for i, n := 0, d.arrayLen(); i < n; i++ {
+		topic = d.string()
+	}
+	host, portStr, _ := net.SplitHostPort(b.addr())
+	port, _ := strconv.Atoi(portStr)
+
+	e := &encoder{b: make([]byte, 4)}
+	e.int32(correlationID)
+	e.int32(1) // brokers
+	e.int32(1)
+	e.string(host)
+	e.int32(int32(port))
+	e.nullString()
+	e.int32(1) // controller
+	e.int32(1) // topics
+	e.int16(0)
+	e.string(topic)
+	e.int8(0)
+	e.int32(int32(b.partitions))
+	for p := 0; p < b.partitions; p++ {
+		e.int16(0)
+		e.int32(int32(p))
+		e.int32(1) // leader
+		e.int32(1)
+		e.int32(1)
+		e.int32(1)
+		e.int32(1)
+	}
+	return e
+}
+
+func (b *fakeBroker) produce(correlationID int32, d *decoder) *encoder {
+	d.int16() // null transactional ID
+	acks := d.int16()
+	d.int32() // timeout
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.produces++
+	code := b.failNext
+	b.failNext = 0
+
+	e := &encoder{b: make([]byte, 4)}
+	e.int32(correlationID)
+	n := d.arrayLen()
+	e.int32(int32(n))
+	for i := 0; i < n; i++ {
+		e.string(d.string())
+		m := d.arrayLen()
+		e.int32(int32(m))
+		for j := 0; j < m; j++ {
+			partition := d.int32()
+			batch := d.take(int(d.int32()))
+			if code == 0 {
+				b.records[partition] = append(b.records[partition], b.decodeBatch(batch)...)
+			}
+			e.int32(partition)
+			e.int16(code)
+			e.int64(0)
+			e.int64(-1)
+		}
+	}
+	e.int32(0) // throttle time
+	if acks == 0 {
+		return nil
+	}
+	return e
+}
+
+// decodeBatch checks a v2 record batch's framing and CRC and returns its records
+func (b *fakeBroker) decodeBatch(batch []byte) []received {
+	d := &decoder{b: batch}
+	d.int64() // base offset
+	if length := d.int32(); int(length) != len(d.b) {
+		b.t.Errorf("batch length %d doesn't match %d bytes", length, len(d.b))
+	}
+	d.int32() // leader epoch
+	if magic := d.int8(); magic != recordBatchMagic {
+		b.t.Errorf("expected magic 2, got %d", magic)
+	}
+	if crc := uint32(d.int32()); crc != crc32.Checksum(d.b, castagnoli) {
+		b.t.Error("batch CRC mismatch")
+	}
+	codec := d.int16()
+	b.codecs = append(b.codecs, codec)
+	d.int32() // last offset delta
+	d.int64() // first timestamp
+	d.int64() // max timestamp
+	d.take(8 + 2 + 4)
+	count := int(d.int32())
+
+	payload := d.b
+	if codec == codecGzip {
+		zr, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			b.t.Errorf("gzip: %v", err)
+			return nil
+		}
+		if payload, err = io.ReadAll(zr); err != nil {
+			b.t.Errorf("gzip: %v", err)
+			return nil
+		}
+	}
+
+	varint := func() int64 {
+		v, n := binary.Varint(payload)
+		payload = payload[n:]
+		return v
+	}
+	varBytes := func() []byte {
+		n := varint()
+		if n < 0 {
+			return nil
+		}
+		v := payload[:n]
+		payload = payload[n:]
+		return v
+	}
+	records := make([]received, 0, count)
+	for i := 0; i < count; i++ {
+		varint()              // length
+		payload = payload[1:] // attributes
+		varint()              // timestamp delta
+		varint()              // offset delta
+		key := varBytes()
+		value := varBytes()
+		varint() // headers
+		records = append(records, received{key: key, value: value})
+	}
+	return records
+}
+
+func (b *fakeBroker) all() []received {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var all []received
+	for p := 0; p < b.partitions; p++ {
+		all = append(all, b.records[int32(p)]...)
+	}
+	return all
+}
+
+func (b *fakeBroker) partitionOf(key string) int32 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for p, records := range b.records {
+		for _, r := range records {
+			if string(r.key) == key {
+				return p
+			}
+		}
+	}
+	return -1
+}
+
+func auctionObject(id string, price float64) *analytics.AuctionObject {
+	return &analytics.AuctionObject{
+		Status:  200,
+		Account: "pub-1",
+		Request: &openrtb.BidRequest{ID: id},
+		Response: &openrtb.BidResponse{
+			ID:  id,
+			Cur: "USD",
+			SeatBid: []openrtb.SeatBid{{
+				Seat: "appnexus",
+				Bid:  []openrtb.Bid{{ID: "bid-" + id, ImpID: "imp-1", Price: price, DealID: "deal-1"}},
+			}},
+		},
+		Bidders:   []analytics.BidderOutcome{{Bidder: "appnexus", Bids: 1, LatencyMS: 42}},
+		StartTime: time.Now().Add(-50 * time.Millisecond),
+	}
+}
+
+func TestModule_PublishesAuctionsAndEvents(t *testing.T) {
+	for _, compression := range []string{CompressionNone, CompressionGzip} {
+		t.Run(compression, func(t *testing.T) {
+			broker := newFakeBroker(t, 4)
+			m, err := New(&Config{Brokers: []string{broker.addr()}, Topic: "pbs-events", Compression: compression, FlushInterval: time.Hour})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			m.LogAuctionObject(auctionObject("auction-1", 1.25))
+			m.LogNotificationEvent(&analytics.NotificationEvent{Type: "win", BidID: "bid-auction-1", AuctionID: "auction-1", Result: "recorded"})
+			m.LogSetUIDObject(&analytics.SetUIDObject{Status: 200})
+			if err := m.Close(); err != nil {
+				t.Fatalf("close failed: %v", err)
+			}
+
+			records := broker.all()
+			if len(records) != 2 {
+				t.Fatalf("expected the auction and the win published on close, got %d messages", len(records))
+			}
+			for _, r := range records {
+				if string(r.key) != "auction-1" {
+					t.Errorf("expected messages keyed by auction ID, got %q", r.key)
+				}
+			}
+			if want := int32(int(murmur2([]byte("auction-1"))&0x7fffffff) % 4); broker.partitionOf("auction-1") != want {
+				t.Errorf("expected the key hashed to partition %d", want)
+			}
+
+			var auction AuctionMessage
+			var win analytics.NotificationEvent
+			if err := json.Unmarshal(records[0].value, &auction); err != nil {
+				t.Fatalf("bad auction message: %v", err)
+			}
+			if err := json.Unmarshal(records[1].value, &win); err != nil {
+				t.Fatalf("bad win message: %v", err)
+			}
+			if auction.Type != MessageAuction || auction.AuctionID != "auction-1" || auction.Currency != "USD" || auction.DurationMS < 50 {
+				t.Errorf("unexpected auction summary: %+v", auction)
+			}
+			if len(auction.Bids) != 1 || auction.Bids[0].Price != 1.25 || auction.Bids[0].Seat != "appnexus" || auction.Bids[0].DealID != "deal-1" {
+				t.Errorf("expected the bid and its price, got %+v", auction.Bids)
+			}
+			if len(auction.Bidders) != 1 || auction.Bidders[0].LatencyMS != 42 {
+				t.Errorf("expected bidder latencies, got %+v", auction.Bidders)
+			}
+			if win.Type != MessageWin || win.BidID != "bid-auction-1" {
+				t.Errorf("unexpected win message: %+v", win)
+			}
+
+			want := codecNone
+			if compression == CompressionGzip {
+				want = codecGzip
+			}
+			for _, codec := range broker.codecs {
+				if codec != want {
+					t.Errorf("expected codec %d, got %d", want, codec)
+				}
+			}
+			if stats := m.Stats(); stats.Sent != 2 || stats.Failed != 0 {
+				t.Errorf("expected 2 messages sent, got %+v", stats)
+			}
+		})
+	}
+}
+
+func TestModule_Batches(t *testing.T) {
+	broker := newFakeBroker(t, 1)
+	m, err := New(&Config{Brokers: []string{broker.addr()}, Topic: "pbs-events", BatchSize: 2, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer m.Close()
+	m.LogNotificationEvent(&analytics.NotificationEvent{Type: "imp", BidID: "b1"})
+	m.LogNotificationEvent(&analytics.NotificationEvent{Type: "imp", BidID: "b2"})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(broker.all()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if records := broker.all(); len(records) != 2 || records[0].key != nil {
+		t.Errorf("expected a full batch of keyless messages sent without waiting for the interval, got %d", len(records))
+	}
+}
+
+func TestModule_RetriesOnce(t *testing.T) {
+	broker := newFakeBroker(t, 2)
+	broker.failNext = 6 // not leader for partition
+	m, err := New(&Config{Brokers: []string{broker.addr()}, Topic: "pbs-events", FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m.LogAuctionObject(auctionObject("auction-1", 2))
+	m.Close()
+
+	if records := broker.all(); len(records) != 1 {
+		t.Fatalf("expected the rejected batch retried, got %d messages", len(records))
+	}
+	if broker.produces != 2 {
+		t.Errorf("expected 2 produce requests, got %d", broker.produces)
+	}
+	if stats := m.Stats(); stats.Sent != 1 || stats.Failed != 0 {
+		t.Errorf("expected the retried message counted as sent, got %+v", stats)
+	}
+}
+
+func TestModule_AcksNone(t *testing.T) {
+	broker := newFakeBroker(t, 1)
+	m, err := New(&Config{Brokers: []string{broker.addr()}, Topic: "pbs-events", Acks: AcksNone, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m.LogAuctionObject(auctionObject("auction-1", 2))
+	m.Close()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(broker.all()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if records := broker.all(); len(records) != 1 {
+		t.Errorf("expected the message produced without waiting for a response, got %d", len(records))
+	}
+}
+
+func TestModule_BrokerDown(t *testing.T) {
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	addr := listener.Addr().String()
+	listener.Close()
+
+	m, err := New(&Config{Brokers: []string{addr}, Topic: "pbs-events", FlushInterval: time.Hour, Timeout: time.Second})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m.LogAuctionObject(auctionObject("auction-1", 2))
+	m.Close()
+	if stats := m.Stats(); stats.Failed != 1 || stats.Sent != 0 {
+		t.Errorf("expected the message counted as failed, got %+v", stats)
+	}
+}
+
+func TestModule_QueueFull(t *testing.T) {
+	m := &Module{config: DefaultConfig([]string{"kafka:9092"}, "pbs-events"), queue: make(chan message, 1)}
+	m.LogAuctionObject(auctionObject("a1", 1))
+	m.LogAuctionObject(auctionObject("a2", 1))
+	if stats := m.Stats(); stats.Dropped != 1 {
+		t.Errorf("expected the message past the buffer dropped, got %+v", stats)
+	}
+}
+
+func TestNew_Validation(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *Config
+	}{
+		{"no brokers", &Config{Topic: "t"}},
+		{"no topic", &Config{Brokers: []string{"kafka:9092"}}},
+		{"unknown compression", &Config{Brokers: []string{"kafka:9092"}, Topic: "t", Compression: "snappy"}},
+		{"invalid acks", &Config{Brokers: []string{"kafka:9092"}, Topic: "t", Acks: "2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.config); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestMurmur2(t *testing.T) {
+	// Values shared by the Java client and librdkafka
+	tests := map[string]int32{
+		"21":    -973932308,
+		"kafka": -798503068,
+		"1234":  -1614185708,
+		"4":     1514888353,
+		"":      275646681,
+	}
+	for key, want := range tests {
+		if got := murmur2([]byte(key)); got != want {
+			t.Errorf("murmur2(%q) = %d, want %d", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package kafka
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// maxResponseSize bounds broker responses; metadata for one topic and produce
+// acknowledgements are far smaller
+const maxResponseSize = 1 << 20
+
+// producer sends record batches to each partition's leader. It keeps one
+// connection per broker and is only used from the module's worker goroutine.
+type producer struct {
+	config        *Config
+	codec         int16
+	acks          int16
+	dialer        *net.Dialer
+	conns         map[string]net.Conn // by broker address
+	metadata      *topicMetadata      // nil until fetched, and again after a failure
+	correlationID int32
+	next          int // Partition for the next keyless message
+}
+
+func newProducer(config *Config) *producer {
+	codec := codecNone
+	if config.Compression == CompressionGzip {
+		codec = codecGzip
+	}
+	acks := int16(1)
+	switch config.Acks {
+	case AcksNone:
+		acks = 0
+	case AcksAll:
+		acks = -1
+	}
+	return &producer{
+		config: config,
+		codec:  codec,
+		acks:   acks,
+		dialer: &net.Dialer{Timeout: config.Timeout},
+		conns:  make(map[string]net.Conn),
+	}
+}
+
+// produce sends msgs, returning the ones that weren't acknowledged with
+// the errors that failed them. A failure drops the cached metadata so the
+// next attempt finds partitions whose leader moved.
+func (p *producer) produce(ctx context.Context, msgs []message) ([]message, error) {
+	if p.metadata == nil {
+		if err := p.refreshMetadata(ctx); err != nil {
+			return msgs, err
+		}
+	}
+	md := p.metadata
+
+	byPartition := make(map[int32][]message)
+	for _, m := range msgs {
+		partition := p.partition(m.key, len(md.leaders))
+		byPartition[partition] = append(byPartition[partition], m)
+	}
+
+	var failed []message
+	var errs []error
+	byLeader := make(map[int32]map[int32][]byte)
+	for partition, pm := range byPartition {
+		leader := md.leaders[partition]
+		if _, known := md.brokers[leader]; !known {
+			failed = append(failed, pm...)
+			errs = append(errs, fmt.Errorf("partition %d: %w", partition, kafkaError(5)))
+			continue
+		}
+		batch, err := recordBatch(pm, p.codec)
+		if err != nil {
+			failed = append(failed, pm...)
+			errs = append(errs, err)
+			continue
+		}
+		if byLeader[leader] == nil {
+			byLeader[leader] = make(map[int32][]byte)
+		}
+		byLeader[leader][partition] = batch
+	}
+
+	for leader, batches := range byLeader {
+		failedPartitions, err := p.send(ctx, md.brokers[leader], batches)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		for _, partition := range failedPartitions {
+			failed = append(failed, byPartition[partition]...)
+		}
+	}
+	if len(errs) > 0 {
+		p.metadata = nil
+	}
+	return failed, errors.Join(errs...)
+}
+
+// send produces one leader's batches, returning the partitions that failed
+func (p *producer) send(ctx context.Context, addr string, batches map[int32][]byte) ([]int32, error) {
+	all := func() []int32 {
+		partitions := make([]int32, 0, len(batches))
+		for partition := range batches {
+			partitions = append(partitions, partition)
+		}
+		return partitions
+	}
+
+	p.correlationID++
+	req := produceRequest(p.correlationID, p.config.ClientID, p.config.Topic, p.acks, p.config.Timeout, batches)
+	body, err := p.roundTrip(ctx, addr, p.correlationID, req, p.acks != 0)
+	if err != nil {
+		return all(), err
+	}
+	if p.acks == 0 { // Brokers don't answer unacknowledged produce requests
+		return nil, nil
+	}
+	results, err := parseProduceResponse(body)
+	if err != nil {
+		p.drop(addr)
+		return all(), err
+	}
+
+	var failed []int32
+	var errs []error
+	for partition := range batches {
+		perr, answered := results[partition]
+		if !answered {
+			perr = fmt.Errorf("kafka: no acknowledgement")
+		}
+		if perr != nil {
+			failed = append(failed, partition)
+			errs = append(errs, fmt.Errorf("partition %d: %w", partition, perr))
+		}
+	}
+	return failed, errors.Join(errs...)
+}
+
+// refreshMetadata finds the topic's partition leaders from the first broker
+// that answers, trying the configured brokers and then the ones last seen
+func (p *producer) refreshMetadata(ctx context.Context) error {
+	addrs := append([]string(nil), p.config.Brokers...)
+	for _, known := range p.knownBrokers() {
+		addrs = append(addrs, known)
+	}
+
+	var errs []error
+	for _, addr := range addrs {
+		p.correlationID++
+		body, err := p.roundTrip(ctx, addr, p.correlationID, metadataRequest(p.correlationID, p.config.ClientID, p.config.Topic), true)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		md, err := parseMetadataResponse(body, p.config.Topic)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		p.metadata = md
+		return nil
+	}
+	return fmt.Errorf("kafka: no broker returned metadata for %s: %w", p.config.Topic, errors.Join(errs...))
+}
+
+// knownBrokers returns the broker addresses from the last metadata, if any
+func (p *producer) knownBrokers() []string {
+	if p.metadata == nil {
+		return nil
+	}
+	addrs := make([]string, 0, len(p.metadata.brokers))
+	for _, addr := range p.metadata.brokers {
+		addrs = append(addrs, addr)
+	}
+	return addrs
+}
+
+// partition picks a message's partition: by key hash like Kafka's default
+// partitioner, or round-robin for keyless messages
+func (p *producer) partition(key []byte, partitions int) int32 {
+	if key != nil {
+		return int32(int(murmur2(key)&0x7fffffff) % partitions)
+	}
+	p.next = (p.next + 1) % partitions
+	return int32(p.next)
+}
+
+// roundTrip writes a framed request and, when one is expected, reads the
+// response body that follows its correlation ID. Connections that fail are
+// closed and redialled on next use.
+func (p *producer) roundTrip(ctx context.Context, addr string, correlationID int32, req []byte, expectResponse bool) ([]byte, error) {
+	conn, err := p.conn(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(p.config.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+
+	if _, err := conn.Write(req); err != nil {
+		p.drop(addr)
+		return nil, err
+	}
+	if !expectResponse {
+		return nil, nil
+	}
+
+	var size [4]byte
+	if _, err := io.ReadFull(conn, size[:]); err != nil {
+		p.drop(addr)
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n < 4 || n > maxResponseSize {
+		p.drop(addr)
+		return nil, fmt.Errorf("kafka: invalid response size %d from %s", n, addr)
+	}
+	body := make([]byte, n)
+	if _, err := io.ReadFull(conn, body); err != nil {
+		p.drop(addr)
+		return nil, err
+	}
+	if got := int32(binary.BigEndian.Uint32(body)); got != correlationID {
+		p.drop(addr)
+		return nil, fmt.Errorf("kafka: response %d from %s doesn't match request %d", got, addr, correlationID)
+	}
+	return body[4:], nil
+}
+
+func (p *producer) conn(ctx context.Context, addr string) (net.Conn, error) {
+	if conn, ok := p.conns[addr]; ok {
+		return conn, nil
+	}
+	conn, err := p.dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	p.conns[addr] = conn
+	return conn, nil
+}
+
+func (p *producer) drop(addr string) {
+	if conn, ok := p.conns[addr]; ok {
+		conn.Close()
+		delete(p.conns, addr)
+	}
+}
+
+// close closes every broker connection
+func (p *producer) close() {
+	for addr := range p.conns {
+		p.drop(addr)
+	}
+}
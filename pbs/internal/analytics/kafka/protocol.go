@@ -0,0 +1,380 @@
+package kafka
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"time"
+)
+
+// Kafka wire protocol, the subset a producer needs: Metadata v1 to find each
+// partition's leader and Produce v3 carrying v2 record batches. Every broker
+// since 0.11 accepts these versions. See https://kafka.apache.org/protocol
+const (
+	apiProduce  int16 = 0
+	apiMetadata int16 = 3
+
+	produceVersion  int16 = 3
+	metadataVersion int16 = 1
+
+	recordBatchMagic int8 = 2
+)
+
+// Record batch compression codecs (attribute bits 0-2)
+const (
+	codecNone int16 = 0
+	codecGzip int16 = 1
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// errShortResponse is returned for responses that end before their fields do
+var errShortResponse = errors.New("kafka: truncated response")
+
+// kafkaError is a non-zero protocol error code
+type kafkaError int16
+
+func (e kafkaError) Error() string {
+	switch e {
+	case 3:
+		return "kafka: unknown topic or partition"
+	case 5:
+		return "kafka: leader not available"
+	case 6:
+		return "kafka: not leader for partition"
+	case 7:
+		return "kafka: request timed out"
+	case 10:
+		return "kafka: message too large"
+	case 19:
+		return "kafka: not enough replicas"
+	case 29:
+		return "kafka: topic authorization failed"
+	}
+	return fmt.Sprintf("kafka: error code %d", int16(e))
+}
+
+// encoder appends big-endian protocol fields
+type encoder struct {
+	b []byte
+}
+
+func (e *encoder) int8(v int8)   { e.b = append(e.b, byte(v)) }
+func (e *encoder) int16(v int16) { e.b = binary.BigEndian.AppendUint16(e.b, uint16(v)) }
+func (e *encoder) int32(v int32) { e.b = binary.BigEndian.AppendUint32(e.b, uint32(v)) }
+func (e *encoder) int64(v int64) { e.b = binary.BigEndian.AppendUint64(e.b, uint64(v)) }
+
+func (e *encoder) string(s string) {
+	e.int16(int16(len(s)))
+	e.b = append(e.b, s...)
+}
+
+func (e *encoder) nullString() { e.int16(-1) }
+
+func (e *encoder) bytes(b []byte) {
+	e.int32(int32(len(b)))
+	e.b = append(e.b, b...)
+}
+
+// varint appends a zigzag varint, as record fields use
+func (e *encoder) varint(v int64) { e.b = binary.AppendVarint(e.b, v) }
+
+func (e *encoder) varBytes(b []byte) {
+	if b == nil {
+		e.varint(-1)
+		return
+	}
+	e.varint(int64(len(b)))
+	e.b = append(e.b, b...)
+}
+
+// decoder reads big-endian protocol fields, remembering the first error
+type decoder struct {
+	b   []byte
+	err error
+}
+
+func (d *decoder) take(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || len(d.b) < n {
+		d.err = errShortResponse
+		return nil
+	}
+	v := d.b[:n]
+	d.b = d.b[n:]
+	return v
+}
+
+func (d *decoder) int8() int8 {
+	if b := d.take(1); b != nil {
+		return int8(b[0])
+	}
+	return 0
+}
+
+func (d *decoder) int16() int16 {
+	if b := d.take(2); b != nil {
+		return int16(binary.BigEndian.Uint16(b))
+	}
+	return 0
+}
+
+func (d *decoder) int32() int32 {
+	if b := d.take(4); b != nil {
+		return int32(binary.BigEndian.Uint32(b))
+	}
+	return 0
+}
+
+func (d *decoder) int64() int64 {
+	if b := d.take(8); b != nil {
+		return int64(binary.BigEndian.Uint64(b))
+	}
+	return 0
+}
+
+func (d *decoder) string() string {
+	n := d.int16()
+	if n < 0 {
+		return ""
+	}
+	return string(d.take(int(n)))
+}
+
+// arrayLen reads an array length; null arrays are empty
+func (d *decoder) arrayLen() int {
+	n := d.int32()
+	if n < 0 {
+		return 0
+	}
+	if int(n) > len(d.b) { // Every element takes at least a byte
+		d.err = errShortResponse
+		return 0
+	}
+	return int(n)
+}
+
+// requestHeader starts a request: api key, version, correlation ID and client ID
+func requestHeader(apiKey, version int16, correlationID int32, clientID string) *encoder {
+	e := &encoder{b: make([]byte, 4, 256)} // Size prefix, filled in by frame
+	e.int16(apiKey)
+	e.int16(version)
+	e.int32(correlationID)
+	e.string(clientID)
+	return e
+}
+
+// frame fills in the size prefix and returns the request bytes
+func (e *encoder) frame() []byte {
+	binary.BigEndian.PutUint32(e.b[:4], uint32(len(e.b)-4))
+	return e.b
+}
+
+// topicMetadata is a topic's partition leaders from a metadata response
+type topicMetadata struct {
+	brokers map[int32]string // Node ID to host:port
+	leaders []int32          // Leader node ID by partition index; -1 while leaderless
+}
+
+func metadataRequest(correlationID int32, clientID, topic string) []byte {
+	e := requestHeader(apiMetadata, metadataVersion, correlationID, clientID)
+	e.int32(1)
+	e.string(topic)
+	return e.frame()
+}
+
+func parseMetadataResponse(body []byte, topic string) (*topicMetadata, error) {
+	d := &decoder{b: body}
+	md := &topicMetadata{brokers: make(map[int32]string)}
+	for i, n := 0, d.arrayLen(); i < n; i++ {
+		id := d.int32()
+		host := d.string()
+		port := d.int32()
+		d.string() // rack
+		md.brokers[id] = fmt.Sprintf("%s:%d", host, port)
+	}
+	d.int32() // controller ID
+
+	found := false
+	for i, n := 0, d.arrayLen(); i < n; i++ {
+		code := d.int16()
+		name := d.string()
+		d.int8() // is_internal
+		var leaders []int32
+		for j, m := 0, d.arrayLen(); j < m; j++ {
+			d.int16() // partition error; a leaderless partition reports -1 below
+			index := d.int32()
+			leader := d.int32()
+			for k, r := 0, d.arrayLen(); k < r; k++ {
+				d.int32() // replicas
+			}
+			for k, r := 0, d.arrayLen(); k < r; k++ {
+				d.int32() // in-sync replicas
+			}
+			if index < 0 || int(index) >= m {
+				return nil, fmt.Errorf("kafka: partition index %d out of range", index)
+			}
+			if leaders == nil {
+				leaders = make([]int32, m)
+			}
+			leaders[index] = leader
+		}
+		if name != topic {
+			continue
+		}
+		if code != 0 {
+			return nil, kafkaError(code)
+		}
+		found = true
+		md.leaders = leaders
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	if !found || len(md.leaders) == 0 {
+		return nil, fmt.Errorf("kafka: topic %s has no partitions", topic)
+	}
+	return md, nil
+}
+
+// message is one record to produce
+type message struct {
+	key   []byte // nil spreads keyless messages across partitions
+	value []byte
+	time  time.Time
+}
+
+// recordBatch encodes messages as a v2 record batch
+func recordBatch(msgs []message, codec int16) ([]byte, error) {
+	first := msgs[0].time.UnixMilli()
+	maxTime := first
+	records := &encoder{}
+	for i, m := range msgs {
+		ts := m.time.UnixMilli()
+		maxTime = max(maxTime, ts)
+		r := &encoder{}
+		r.int8(0) // attributes
+		r.varint(ts - first)
+		r.varint(int64(i))
+		r.varBytes(m.key)
+		r.varBytes(m.value)
+		r.varint(0) // headers
+		records.varint(int64(len(r.b)))
+		records.b = append(records.b, r.b...)
+	}
+	payload := records.b
+	if codec == codecGzip {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(payload); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		payload = buf.Bytes()
+	}
+
+	// The CRC covers attributes through the records
+	body := &encoder{b: make([]byte, 0, 40+len(payload))}
+	body.int16(codec)
+	body.int32(int32(len(msgs) - 1)) // last offset delta
+	body.int64(first)
+	body.int64(maxTime)
+	body.int64(-1) // producer ID: not idempotent
+	body.int16(-1) // producer epoch
+	body.int32(-1) // base sequence
+	body.int32(int32(len(msgs)))
+	body.b = append(body.b, payload...)
+
+	batch := &encoder{b: make([]byte, 0, 21+len(body.b))}
+	batch.int64(0)                              // base offset, assigned by the broker
+	batch.int32(int32(4 + 1 + 4 + len(body.b))) // batch length: leader epoch, magic, CRC and body
+	batch.int32(-1)                             // partition leader epoch
+	batch.int8(recordBatchMagic)
+	batch.b = binary.BigEndian.AppendUint32(batch.b, crc32.Checksum(body.b, castagnoli))
+	batch.b = append(batch.b, body.b...)
+	return batch.b, nil
+}
+
+// produceRequest encodes one topic's batches, keyed by partition
+func produceRequest(correlationID int32, clientID, topic string, acks int16, timeout time.Duration, batches map[int32][]byte) []byte {
+	e := requestHeader(apiProduce, produceVersion, correlationID, clientID)
+	e.nullString() // transactional ID
+	e.int16(acks)
+	e.int32(int32(timeout.Milliseconds()))
+	e.int32(1)
+	e.string(topic)
+	e.int32(int32(len(batches)))
+	for partition, batch := range batches {
+		e.int32(partition)
+		e.bytes(batch)
+	}
+	return e.frame()
+}
+
+// parseProduceResponse returns each partition's error; nil entries succeeded
+func parseProduceResponse(body []byte) (map[int32]error, error) {
+	d := &decoder{b: body}
+	results := make(map[int32]error)
+	for i, n := 0, d.arrayLen(); i < n; i++ {
+		d.string() // topic
+		for j, m := 0, d.arrayLen(); j < m; j++ {
+			partition := d.int32()
+			code := d.int16()
+			d.int64() // base offset
+			d.int64() // log append time
+			if code != 0 {
+				results[partition] = kafkaError(code)
+			} else {
+				results[partition] = nil
+			}
+		}
+	}
+	d.int32() // throttle time
+	if d.err != nil {
+		return nil, d.err
+	}
+	return results, nil
+}
+
+// murmur2 is the hash Kafka's default partitioner applies to message keys, so
+// keyed messages land on the same partitions as with the Java client
+func murmur2(data []byte) int32 {
+	const (
+		seed uint32 = 0x9747b28c
+		m    uint32 = 0x5bd1e995
+		r           = 24
+	)
+	length := len(data)
+	h := seed ^ uint32(length)
+	for i := 0; i+4 <= length; i += 4 {
+		k := binary.LittleEndian.Uint32(data[i:])
+		k *= m
+		k ^= k >> r
+		k *= m
+		h *= m
+		h ^= k
+	}
+	tail := data[length&^3:]
+	switch len(tail) {
+	case 3:
+		h ^= uint32(tail[2]) << 16
+		fallthrough
+	case 2:
+		h ^= uint32(tail[1]) << 8
+		fallthrough
+	case 1:
+		h ^= uint32(tail[0])
+		h *= m
+	}
+	h ^= h >> 13
+	h *= m
+	h ^= h >> 15
+	return int32(h)
+}
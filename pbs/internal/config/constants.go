@@ -16,6 +16,10 @@ const (
 
 	// ShutdownTimeout is the maximum time to wait for graceful shutdown
 	ShutdownTimeout = 30 * time.Second
+
+	// DrainWindow is how long a draining server fails health checks and
+	// refuses auctions before it shuts down
+	DrainWindow = 15 * time.Second
 )
 
 // CORS defaults
@@ -89,14 +93,28 @@ const (
 	// DefaultMaxBidders is the maximum number of bidders per request
 	DefaultMaxBidders = 50
 
-	// DefaultMaxConcurrentBidders is the default concurrent bidder limit
-	DefaultMaxConcurrentBidders = 10
-
 	// DefaultEventBufferSize is the default event buffer size
 	DefaultEventBufferSize = 100
 
 	// DynamicRefreshPeriod is how often to refresh dynamic bidders
 	DynamicRefreshPeriod = 30 * time.Second
+
+	// DynamicStalePeriods is how many refresh periods may pass without a
+	// successful refresh before the dynamic registry is reported stale
+	DynamicStalePeriods = 3
+
+	// FlagSyncPeriod is how often feature flag overrides are synced from Redis
+	FlagSyncPeriod = 10 * time.Second
+
+	// IDRSelectionCacheTTL is how long IDR partner selections are reused per
+	// publisher+country+device type+media type bucket
+	IDRSelectionCacheTTL = 3 * time.Second
+
+	// WarmupTimeout bounds the startup warmup before /ready reports ready
+	WarmupTimeout = 10 * time.Second
+
+	// WarmupGzipWriters is how many gzip writers are pre-allocated during warmup
+	WarmupGzipWriters = 16
 )
 
 // Cookie sync defaults
@@ -0,0 +1,277 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Kind is the type of value a setting holds, and how it is checked
+type Kind int
+
+const (
+	KindString   Kind = iota
+	KindBool          // true or false; the env var also accepts 1/0 and yes/no
+	KindInt           // Whole number
+	KindFloat         // Any number
+	KindRate          // Number from 0 to 1
+	KindDuration      // Go duration such as "500ms" or "5m"
+	KindList          // File list or string; comma-separated in the env var
+	KindPairs         // File map or string; "key:value,..." in the env var
+	KindJSON          // File object or array; JSON in the env var
+)
+
+// Setting maps a config file key onto the env var the server reads it from
+type Setting struct {
+	Key  string   // Dotted path in the file, e.g. "server.port"
+	Env  string   // Env var the value is exported as
+	Kind Kind     // How the value is checked
+	Enum []string // Allowed values, when restricted
+	Live bool     // Applied by a reload without a restart
+}
+
+// File is a loaded config file, flattened to env var values
+type File struct {
+	Path   string
+	values map[string]string // By env var
+}
+
+// Load reads a YAML or JSON config file (by extension; .json is JSON,
+// anything else YAML) whose keys are the dotted paths in Settings. Every
+// unknown key and invalid value is reported in the returned error.
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var root map[string]any
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		err = decoder.Decode(&root)
+	} else {
+		err = yaml.Unmarshal(data, &root)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	f := &File{Path: path, values: make(map[string]string)}
+	var errs []error
+	f.flatten("", root, &errs)
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return f, nil
+}
+
+// flatten walks a section of the file, converting each setting it finds to
+// its env var form
+func (f *File) flatten(prefix string, section map[string]any, errs *[]error) {
+	keys := make([]string, 0, len(section))
+	for k := range section {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		value := section[k]
+		if s, ok := settingsByKey[key]; ok {
+			if value == nil {
+				continue // Empty in YAML; leave the default
+			}
+			env, err := fileValue(s, value)
+			if err == nil {
+				err = s.check(env)
+			}
+			if err != nil {
+				*errs = append(*errs, fmt.Errorf("%s: %w", key, err))
+				continue
+			}
+			f.values[s.Env] = env
+			continue
+		}
+		if sub, ok := value.(map[string]any); ok && isSection(key) {
+			f.flatten(key, sub, errs)
+			continue
+		}
+		*errs = append(*errs, fmt.Errorf("%s: unknown setting", key))
+	}
+}
+
+// Len returns the number of settings in the file
+func (f *File) Len() int {
+	return len(f.values)
+}
+
+// Apply exports the file's settings as env vars, leaving any already set in
+// the environment alone so env vars override the file. It returns the env
+// vars that did.
+func (f *File) Apply() (overridden []string) {
+	for _, s := range Settings {
+		value, ok := f.values[s.Env]
+		if !ok {
+			continue
+		}
+		if os.Getenv(s.Env) != "" {
+			overridden = append(overridden, s.Env)
+			continue
+		}
+		os.Setenv(s.Env, value)
+	}
+	return overridden
+}
+
+// ValidateEnv checks every setting's env var, whether it came from the
+// environment or a config file, reporting all invalid values
+func ValidateEnv() error {
+	var errs []error
+	for _, s := range Settings {
+		if value := os.Getenv(s.Env); value != "" {
+			if err := s.check(value); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", s.Env, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// fileValue converts a decoded file value to the setting's env var form
+func fileValue(s Setting, value any) (string, error) {
+	switch s.Kind {
+	case KindList:
+		if items, ok := value.([]any); ok {
+			parts := make([]string, 0, len(items))
+			for _, item := range items {
+				part, err := scalar(item)
+				if err != nil {
+					return "", err
+				}
+				parts = append(parts, part)
+			}
+			return strings.Join(parts, ","), nil
+		}
+	case KindPairs:
+		if pairs, ok := value.(map[string]any); ok {
+			keys := make([]string, 0, len(pairs))
+			for k := range pairs {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			parts := make([]string, 0, len(keys))
+			for _, k := range keys {
+				if pairs[k] == nil {
+					parts = append(parts, k)
+					continue
+				}
+				v, err := scalar(pairs[k])
+				if err != nil {
+					return "", err
+				}
+				parts = append(parts, k+":"+v)
+			}
+			return strings.Join(parts, ","), nil
+		}
+	case KindJSON:
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return "", fmt.Errorf("can't encode as JSON: %w", err)
+		}
+		return string(data), nil
+	}
+	return scalar(value)
+}
+
+// scalar formats a string, number or bool as it would be written in an env var
+func scalar(value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case json.Number:
+		return v.String(), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	default:
+		return "", fmt.Errorf("expected a single value, got %T", value)
+	}
+}
+
+// check validates an env var value for the setting
+func (s Setting) check(value string) error {
+	if len(s.Enum) > 0 && !slices.Contains(s.Enum, value) {
+		return fmt.Errorf("invalid value %q (want %s)", value, strings.Join(s.Enum, ", "))
+	}
+	switch s.Kind {
+	case KindBool:
+		switch strings.ToLower(value) {
+		case "true", "false", "1", "0", "yes", "no":
+		default:
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+	case KindInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+	case KindFloat, KindRate:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil || math.IsNaN(f) {
+			return fmt.Errorf("invalid number %q", value)
+		}
+		if s.Kind == KindRate && (f < 0 || f > 1) {
+			return fmt.Errorf("%v is outside 0-1", f)
+		}
+	case KindDuration:
+		if _, err := time.ParseDuration(value); err != nil {
+			return fmt.Errorf("invalid duration %q (e.g. 500ms, 5s, 1m)", value)
+		}
+	case KindJSON:
+		if !json.Valid([]byte(value)) {
+			return fmt.Errorf("invalid JSON")
+		}
+	}
+	return nil
+}
+
+// isSection reports whether key is a prefix of any setting's key
+func isSection(key string) bool {
+	_, ok := sections[key]
+	return ok
+}
+
+var (
+	settingsByKey = make(map[string]Setting, len(Settings))
+	sections      = make(map[string]struct{})
+)
+
+func init() {
+	for _, s := range Settings {
+		settingsByKey[s.Key] = s
+		parts := strings.Split(s.Key, ".")
+		for i := 1; i < len(parts); i++ {
+			sections[strings.Join(parts[:i], ".")] = struct{}{}
+		}
+	}
+}
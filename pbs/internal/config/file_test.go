@@ -0,0 +1,181 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad_YAML(t *testing.T) {
+	path := writeConfig(t, "pbs.yaml", `
+server:
+  port: 9000
+  tls:
+    client_accounts:
+      cn-a: pub-1
+exchange:
+  timeout: 800ms
+  idr:
+    enabled: false
+  currency:
+    rates:
+      EUR: 0.92
+middleware:
+  cors:
+    allowed_origins:
+      - https://a.example
+      - https://b.example
+privacy:
+  violation_mode: scrub
+log:
+  sample:
+    auctions: 0.01
+`)
+	f, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	want := map[string]string{
+		"PBS_PORT":                   "9000",
+		"TLS_CLIENT_ACCOUNTS":        "cn-a:pub-1",
+		"PBS_AUCTION_TIMEOUT":        "800ms",
+		"IDR_ENABLED":                "false",
+		"CURRENCY_RATES":             `{"EUR":0.92}`,
+		"CORS_ALLOWED_ORIGINS":       "https://a.example,https://b.example",
+		"PBS_PRIVACY_VIOLATION_MODE": "scrub",
+		"LOG_SAMPLE_AUCTIONS":        "0.01",
+	}
+	if f.Len() != len(want) {
+		t.Errorf("expected %d settings, got %v", len(want), f.values)
+	}
+	for env, v := range want {
+		if f.values[env] != v {
+			t.Errorf("%s: expected %q, got %q", env, v, f.values[env])
+		}
+	}
+}
+
+func TestLoad_JSON(t *testing.T) {
+	path := writeConfig(t, "pbs.json", `{
+		"exchange": {"events": {"sample_rate": 0.25, "recording_accounts": {"pub-1": {"enabled": false}}}},
+		"cookie_sync": {"max_syncs": 5, "rate_limit": {"window": "1m"}}
+	}`)
+	f, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if f.values["EVENT_SAMPLE_RATE"] != "0.25" || f.values["COOKIE_SYNC_MAX_SYNCS"] != "5" || f.values["SYNC_RATE_LIMIT_WINDOW"] != "1m" {
+		t.Errorf("unexpected values %v", f.values)
+	}
+	if f.values["EVENT_RECORDING_ACCOUNTS"] != `{"pub-1":{"enabled":false}}` {
+		t.Errorf("expected JSON object, got %q", f.values["EVENT_RECORDING_ACCOUNTS"])
+	}
+}
+
+func TestLoad_ReportsEveryError(t *testing.T) {
+	path := writeConfig(t, "pbs.yaml", `
+server:
+  port: eighty
+  colour: blue
+exchange:
+  timeout: 5
+  early_exit:
+    min_elapsed: 2
+privacy:
+  violation_mode: ignore
+bogus: true
+`)
+	_, err := Load(path)
+	if err == nil {
+		t.Fatal("expected errors")
+	}
+	for _, key := range []string{"server.port", "server.colour", "exchange.timeout", "exchange.early_exit.min_elapsed", "privacy.violation_mode", "bogus"} {
+		if !strings.Contains(err.Error(), key+":") {
+			t.Errorf("expected an error for %s, got:\n%v", key, err)
+		}
+	}
+}
+
+func TestLoad_Unparseable(t *testing.T) {
+	if _, err := Load(writeConfig(t, "pbs.json", `{"server":`)); err == nil {
+		t.Error("expected parse error")
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func TestApply_EnvOverridesFile(t *testing.T) {
+	path := writeConfig(t, "pbs.yaml", "server:\n  port: 9000\n  host_url: https://file.example\n")
+	f, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("PBS_PORT", "7000")
+	t.Setenv("PBS_HOST_URL", "")
+	overridden := f.Apply()
+
+	if got := os.Getenv("PBS_PORT"); got != "7000" {
+		t.Errorf("expected env to win, got PBS_PORT=%s", got)
+	}
+	if got := os.Getenv("PBS_HOST_URL"); got != "https://file.example" {
+		t.Errorf("expected file value for unset env, got PBS_HOST_URL=%s", got)
+	}
+	if !slices.Equal(overridden, []string{"PBS_PORT"}) {
+		t.Errorf("expected PBS_PORT overridden, got %v", overridden)
+	}
+}
+
+func TestValidateEnv(t *testing.T) {
+	t.Setenv("RATE_LIMIT_RPS", "lots")
+	t.Setenv("PBS_ENFORCE_GDPR", "maybe")
+	t.Setenv("CURRENCY_RATES_REFRESH", "1h")
+
+	err := ValidateEnv()
+	if err == nil {
+		t.Fatal("expected errors")
+	}
+	if !strings.Contains(err.Error(), "RATE_LIMIT_RPS") || !strings.Contains(err.Error(), "PBS_ENFORCE_GDPR") {
+		t.Errorf("expected both invalid vars reported, got:\n%v", err)
+	}
+	if strings.Contains(err.Error(), "CURRENCY_RATES_REFRESH") {
+		t.Errorf("valid duration reported as invalid:\n%v", err)
+	}
+}
+
+func TestSettings_Unique(t *testing.T) {
+	keys := make(map[string]bool)
+	envs := make(map[string]bool)
+	for _, s := range Settings {
+		if keys[s.Key] || envs[s.Env] {
+			t.Errorf("duplicate setting %s (%s)", s.Key, s.Env)
+		}
+		if isSection(s.Key) {
+			t.Errorf("%s is both a setting and a section", s.Key)
+		}
+		keys[s.Key], envs[s.Env] = true, true
+	}
+}
+
+func TestLoad_Example(t *testing.T) {
+	f, err := Load("../../../config/pbs.example.yaml")
+	if err != nil {
+		t.Fatalf("example config is invalid: %v", err)
+	}
+	if f.values["REGISTERED_PUBLISHERS"] != "pub-1:publisher.example" {
+		t.Errorf("unexpected REGISTERED_PUBLISHERS %q", f.values["REGISTERED_PUBLISHERS"])
+	}
+}
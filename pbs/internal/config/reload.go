@@ -0,0 +1,101 @@
+package config
+
+import (
+	"os"
+	"sync"
+)
+
+// Source is the config file a server started with, tracked so it can be
+// reloaded: env vars set at startup keep overriding it, and Live settings
+// removed from it fall back to their defaults
+type Source struct {
+	path    string
+	environ map[string]bool // Settings the environment set before the file was applied
+
+	mu   sync.Mutex
+	file map[string]string // Values as of the last load, by env var
+}
+
+// ReloadResult lists the settings a reload found changed, by file key
+type ReloadResult struct {
+	Applied         []string `json:"applied"`
+	RestartRequired []string `json:"restart_required,omitempty"` // Only read at startup; still pending
+	Overridden      []string `json:"overridden,omitempty"`       // Set by an env var, which wins
+}
+
+// NewSource tracks the config file at path. It must be created before the
+// file is first applied, to tell env vars from file values.
+func NewSource(path string) *Source {
+	s := &Source{path: path, environ: make(map[string]bool)}
+	for _, setting := range Settings {
+		if os.Getenv(setting.Env) != "" {
+			s.environ[setting.Env] = true
+		}
+	}
+	return s
+}
+
+// Path returns the config file's path
+func (s *Source) Path() string {
+	return s.path
+}
+
+// Load reads the file and exports its settings, returning the env vars that
+// override it
+func (s *Source) Load() (*File, []string, error) {
+	f, err := Load(s.path)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.file = f.values
+	return f, f.Apply(), nil
+}
+
+// Reload re-reads the file and exports the Live settings that changed since
+// the last load. Nothing is exported when the file is invalid. Callers then
+// hand the new values to the components that use them.
+func (s *Source) Reload() (*ReloadResult, error) {
+	f, err := Load(s.path)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file := make(map[string]string, len(f.values))
+	for env, v := range s.file {
+		file[env] = v
+	}
+
+	result := &ReloadResult{Applied: []string{}}
+	for _, setting := range Settings {
+		prev, had := s.file[setting.Env]
+		next, has := f.values[setting.Env]
+		if had == has && prev == next {
+			continue
+		}
+		switch {
+		case !setting.Live:
+			// Reported until a restart picks it up
+			result.RestartRequired = append(result.RestartRequired, setting.Key)
+			continue
+		case s.environ[setting.Env]:
+			result.Overridden = append(result.Overridden, setting.Key)
+		case has:
+			os.Setenv(setting.Env, next)
+			result.Applied = append(result.Applied, setting.Key)
+		default:
+			os.Unsetenv(setting.Env)
+			result.Applied = append(result.Applied, setting.Key)
+		}
+		if has {
+			file[setting.Env] = next
+		} else {
+			delete(file, setting.Env)
+		}
+	}
+	s.file = file
+	return result, nil
+}
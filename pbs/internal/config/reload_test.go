@@ -0,0 +1,91 @@
+package config
+
+import (
+	"os"
+	"slices"
+	"testing"
+)
+
+func TestSource_Reload(t *testing.T) {
+	// Registered so the test restores them
+	t.Setenv("PBS_AUCTION_TIMEOUT", "")
+	t.Setenv("RATE_LIMIT_RPS", "")
+	t.Setenv("PBS_PORT", "")
+	t.Setenv("PBS_ENFORCE_GDPR", "false")
+
+	path := writeConfig(t, "pbs.yaml", `
+server:
+  port: 9000
+exchange:
+  timeout: 800ms
+middleware:
+  rate_limit:
+    rps: 100
+privacy:
+  enforce_gdpr: true
+`)
+	source := NewSource(path)
+	if _, _, err := source.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	writeConfig := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeConfig(`
+server:
+  port: 9100
+exchange:
+  timeout: 1s
+privacy:
+  enforce_gdpr: false
+`)
+	result, err := source.Reload()
+	if err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+
+	if !slices.Equal(result.Applied, []string{"exchange.timeout", "middleware.rate_limit.rps"}) {
+		t.Errorf("unexpected applied %v", result.Applied)
+	}
+	if !slices.Equal(result.RestartRequired, []string{"server.port"}) {
+		t.Errorf("unexpected restart_required %v", result.RestartRequired)
+	}
+	if !slices.Equal(result.Overridden, []string{"privacy.enforce_gdpr"}) {
+		t.Errorf("unexpected overridden %v", result.Overridden)
+	}
+	if got := os.Getenv("PBS_AUCTION_TIMEOUT"); got != "1s" {
+		t.Errorf("expected timeout reloaded, got %q", got)
+	}
+	if _, ok := os.LookupEnv("RATE_LIMIT_RPS"); ok {
+		t.Error("expected removed setting unset")
+	}
+	if got := os.Getenv("PBS_PORT"); got != "9000" {
+		t.Errorf("expected port kept until restart, got %q", got)
+	}
+	if got := os.Getenv("PBS_ENFORCE_GDPR"); got != "false" {
+		t.Errorf("expected env var kept, got %q", got)
+	}
+
+	// An invalid file changes nothing
+	writeConfig("exchange:\n  timeout: soon\n")
+	if _, err := source.Reload(); err == nil {
+		t.Error("expected error for invalid file")
+	}
+	if got := os.Getenv("PBS_AUCTION_TIMEOUT"); got != "1s" {
+		t.Errorf("expected timeout unchanged, got %q", got)
+	}
+
+	// Still pending until a restart, but nothing new to apply
+	writeConfig("server:\n  port: 9100\nexchange:\n  timeout: 1s\nprivacy:\n  enforce_gdpr: false\n")
+	result, err = source.Reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Applied) != 0 || !slices.Equal(result.RestartRequired, []string{"server.port"}) {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
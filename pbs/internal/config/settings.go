@@ -0,0 +1,197 @@
+package config
+
+// Settings lists every config file key and the env var it sets. The README's
+// environment variable table documents each one; Live settings are applied by
+// a reload, the rest at startup only.
+var Settings = []Setting{
+	// Server
+	{Key: "server.port", Env: "PBS_PORT", Kind: KindInt},
+	{Key: "server.host_url", Env: "PBS_HOST_URL"},
+	{Key: "server.dev_mode", Env: "PBS_DEV_MODE", Kind: KindBool},
+	{Key: "server.redis_url", Env: "REDIS_URL"},
+	{Key: "server.warmup_timeout", Env: "WARMUP_TIMEOUT", Kind: KindDuration},
+	{Key: "server.drain.window", Env: "DRAIN_WINDOW", Kind: KindDuration},
+	{Key: "server.drain.on_signal", Env: "DRAIN_ON_SIGNAL", Kind: KindBool},
+	{Key: "server.auction_v1_deprecated", Env: "AUCTION_V1_DEPRECATED", Kind: KindBool},
+	{Key: "server.auction_v1_sunset", Env: "AUCTION_V1_SUNSET"},
+	{Key: "server.tls.cert_file", Env: "TLS_CERT_FILE"},
+	{Key: "server.tls.key_file", Env: "TLS_KEY_FILE"},
+	{Key: "server.tls.client_ca_file", Env: "TLS_CLIENT_CA_FILE"},
+	{Key: "server.tls.client_auth", Env: "TLS_CLIENT_AUTH", Enum: []string{"none", "optional", "require"}},
+	{Key: "server.tls.client_accounts", Env: "TLS_CLIENT_ACCOUNTS", Kind: KindPairs},
+
+	// Logging
+	{Key: "log.level", Env: "LOG_LEVEL", Enum: []string{"trace", "debug", "info", "warn", "error", "fatal", "panic", "disabled"}},
+	{Key: "log.format", Env: "LOG_FORMAT", Enum: []string{"json", "console"}},
+	{Key: "log.sample.debug", Env: "LOG_SAMPLE_DEBUG", Kind: KindRate},
+	{Key: "log.sample.info", Env: "LOG_SAMPLE_INFO", Kind: KindRate},
+	{Key: "log.sample.auctions", Env: "LOG_SAMPLE_AUCTIONS", Kind: KindRate},
+
+	// Exchange
+	{Key: "exchange.timeout", Env: "PBS_AUCTION_TIMEOUT", Kind: KindDuration, Live: true},
+	{Key: "exchange.tmax_network_buffer", Env: "TMAX_NETWORK_BUFFER", Kind: KindDuration},
+	{Key: "exchange.name", Env: "EXCHANGE_NAME"},
+	{Key: "exchange.contact", Env: "EXCHANGE_CONTACT"},
+	{Key: "exchange.user_agent", Env: "OUTBOUND_USER_AGENT"},
+	{Key: "exchange.request_shaping", Env: "REQUEST_SHAPING", Kind: KindBool},
+	{Key: "exchange.prefer_deals", Env: "PREFER_DEALS", Kind: KindBool},
+	{Key: "exchange.creative_validation.enabled", Env: "CREATIVE_VALIDATION", Kind: KindBool},
+	{Key: "exchange.creative_validation.block_document_write", Env: "CREATIVE_BLOCK_DOCUMENT_WRITE", Kind: KindBool},
+	{Key: "exchange.creative_validation.require_https", Env: "CREATIVE_REQUIRE_HTTPS", Kind: KindBool},
+	{Key: "exchange.native_validation", Env: "NATIVE_VALIDATION", Enum: []string{"off", "flag", "reject"}},
+	{Key: "exchange.request_signing_keys", Env: "REQUEST_SIGNING_KEYS", Kind: KindList},
+	{Key: "exchange.account_defaults", Env: "ACCOUNT_REQUEST_DEFAULTS", Kind: KindJSON},
+	{Key: "exchange.early_exit.enabled", Env: "AUCTION_EARLY_EXIT", Kind: KindBool},
+	{Key: "exchange.early_exit.min_bids", Env: "AUCTION_EARLY_EXIT_MIN_BIDS", Kind: KindInt},
+	{Key: "exchange.early_exit.min_elapsed", Env: "AUCTION_EARLY_EXIT_MIN_ELAPSED", Kind: KindRate},
+	{Key: "exchange.partial_response.enabled", Env: "AUCTION_PARTIAL_RESPONSE", Kind: KindBool},
+	{Key: "exchange.partial_response.min_responded", Env: "AUCTION_PARTIAL_MIN_RESPONDED", Kind: KindRate},
+	{Key: "exchange.partial_response.soft_deadline", Env: "AUCTION_PARTIAL_SOFT_DEADLINE", Kind: KindRate},
+	{Key: "exchange.idr.enabled", Env: "IDR_ENABLED", Kind: KindBool},
+	{Key: "exchange.idr.url", Env: "IDR_URL"},
+	{Key: "exchange.idr.api_key", Env: "IDR_API_KEY"},
+	{Key: "exchange.idr.selection_cache_ttl", Env: "IDR_SELECTION_CACHE_TTL", Kind: KindDuration},
+	{Key: "exchange.currency.conversion_enabled", Env: "CURRENCY_CONVERSION_ENABLED", Kind: KindBool},
+	{Key: "exchange.currency.rates", Env: "CURRENCY_RATES", Kind: KindJSON},
+	{Key: "exchange.currency.rates_url", Env: "CURRENCY_RATES_URL"},
+	{Key: "exchange.currency.refresh", Env: "CURRENCY_RATES_REFRESH", Kind: KindDuration},
+	{Key: "exchange.currency.stale_after", Env: "CURRENCY_RATES_STALE_AFTER", Kind: KindDuration},
+	{Key: "exchange.events.enabled", Env: "EVENTS_ENABLED", Kind: KindBool},
+	{Key: "exchange.events.bid_ttl", Env: "EVENTS_BID_TTL", Kind: KindDuration},
+	{Key: "exchange.events.fire_pixels", Env: "EVENTS_FIRE_PIXELS", Kind: KindBool},
+	{Key: "exchange.events.win_notices", Env: "WIN_NOTICES_ENABLED", Kind: KindBool},
+	{Key: "exchange.events.notice_timeout", Env: "NOTICE_URL_TIMEOUT", Kind: KindDuration},
+	{Key: "exchange.events.notice_retries", Env: "NOTICE_URL_RETRIES", Kind: KindInt},
+	{Key: "exchange.events.sample_rate", Env: "EVENT_SAMPLE_RATE", Kind: KindRate},
+	{Key: "exchange.events.recording_accounts", Env: "EVENT_RECORDING_ACCOUNTS", Kind: KindJSON},
+	{Key: "exchange.events.spill_dir", Env: "EVENT_SPILL_DIR"},
+	{Key: "exchange.events.spill_max_mb", Env: "EVENT_SPILL_MAX_MB", Kind: KindInt},
+	{Key: "exchange.prebid_cache.url", Env: "PREBID_CACHE_URL"},
+	{Key: "exchange.prebid_cache.public_url", Env: "PREBID_CACHE_PUBLIC_URL"},
+	{Key: "exchange.prebid_cache.timeout", Env: "PREBID_CACHE_TIMEOUT", Kind: KindDuration},
+	{Key: "exchange.prebid_cache.ttl", Env: "PREBID_CACHE_TTL", Kind: KindDuration},
+	{Key: "exchange.accounts.dir", Env: "ACCOUNTS_DIR"},
+	{Key: "exchange.accounts.cache_ttl", Env: "ACCOUNTS_CACHE_TTL", Kind: KindDuration},
+	{Key: "exchange.stored_requests.cache_size", Env: "STORED_REQUESTS_CACHE_SIZE", Kind: KindInt},
+	{Key: "exchange.stored_requests.cache_ttl", Env: "STORED_REQUESTS_CACHE_TTL", Kind: KindDuration},
+
+	// Bidders
+	{Key: "bidders.adaptive_timeouts.enabled", Env: "ADAPTIVE_TIMEOUTS", Kind: KindBool},
+	{Key: "bidders.adaptive_timeouts.percentile", Env: "ADAPTIVE_TIMEOUT_PERCENTILE", Kind: KindRate},
+	{Key: "bidders.adaptive_timeouts.buffer", Env: "ADAPTIVE_TIMEOUT_BUFFER", Kind: KindDuration},
+	{Key: "bidders.adaptive_timeouts.min", Env: "ADAPTIVE_TIMEOUT_MIN", Kind: KindDuration},
+	{Key: "bidders.adaptive_timeouts.max", Env: "ADAPTIVE_TIMEOUT_MAX", Kind: KindDuration},
+	{Key: "bidders.adaptive_timeouts.window", Env: "ADAPTIVE_TIMEOUT_WINDOW", Kind: KindInt},
+	{Key: "bidders.adaptive_timeouts.min_samples", Env: "ADAPTIVE_TIMEOUT_MIN_SAMPLES", Kind: KindInt},
+	{Key: "bidders.pool.workers", Env: "BIDDER_POOL_WORKERS", Kind: KindInt},
+	{Key: "bidders.pool.queue_size", Env: "BIDDER_POOL_QUEUE_SIZE", Kind: KindInt},
+	{Key: "bidders.max_parallel_requests", Env: "BIDDER_MAX_PARALLEL_REQUESTS", Kind: KindInt},
+	{Key: "bidders.transport.max_idle_conns", Env: "BIDDER_MAX_IDLE_CONNS", Kind: KindInt},
+	{Key: "bidders.transport.max_idle_conns_per_host", Env: "BIDDER_MAX_IDLE_CONNS_PER_HOST", Kind: KindInt},
+	{Key: "bidders.transport.max_conns_per_host", Env: "BIDDER_MAX_CONNS_PER_HOST", Kind: KindInt},
+	{Key: "bidders.transport.idle_conn_timeout", Env: "BIDDER_IDLE_CONN_TIMEOUT", Kind: KindDuration},
+	{Key: "bidders.transport.tls_handshake_timeout", Env: "BIDDER_TLS_HANDSHAKE_TIMEOUT", Kind: KindDuration},
+	{Key: "bidders.transport.http2", Env: "BIDDER_HTTP2", Kind: KindBool},
+	{Key: "bidders.transport.dns_cache_ttl", Env: "BIDDER_DNS_CACHE_TTL", Kind: KindDuration},
+	{Key: "bidders.circuit_breaker.enabled", Env: "BIDDER_CIRCUIT_BREAKER", Kind: KindBool},
+	{Key: "bidders.circuit_breaker.failures", Env: "BIDDER_CIRCUIT_FAILURES", Kind: KindInt},
+	{Key: "bidders.circuit_breaker.open_duration", Env: "BIDDER_CIRCUIT_OPEN_DURATION", Kind: KindDuration},
+	{Key: "bidders.circuit_breaker.probes", Env: "BIDDER_CIRCUIT_PROBES", Kind: KindInt},
+	{Key: "bidders.sandbox.enabled", Env: "DYNAMIC_BIDDER_SANDBOX", Kind: KindBool},
+	{Key: "bidders.sandbox.budget", Env: "DYNAMIC_BIDDER_SANDBOX_BUDGET", Kind: KindDuration},
+	{Key: "bidders.dynamic.enabled", Env: "DYNAMIC_BIDDERS_ENABLED", Kind: KindBool},
+	{Key: "bidders.dynamic.stale_periods", Env: "DYNAMIC_REGISTRY_STALE_PERIODS", Kind: KindInt},
+	{Key: "bidders.probe.enabled", Env: "BIDDER_PROBE_ENABLED", Kind: KindBool},
+	{Key: "bidders.probe.interval", Env: "BIDDER_PROBE_INTERVAL", Kind: KindDuration},
+	{Key: "bidders.probe.timeout", Env: "BIDDER_PROBE_TIMEOUT", Kind: KindDuration},
+	{Key: "bidders.debug_bidder.enabled", Env: "DEBUG_BIDDER_ENABLED", Kind: KindBool},
+	{Key: "bidders.debug_bidder.cpm", Env: "DEBUG_BIDDER_CPM", Kind: KindFloat},
+	{Key: "bidders.debug_bidder.size", Env: "DEBUG_BIDDER_SIZE"},
+	{Key: "bidders.debug_bidder.adm_template", Env: "DEBUG_BIDDER_ADM_TEMPLATE"},
+	{Key: "bidders.debug_bidder.accounts", Env: "DEBUG_BIDDER_ACCOUNTS", Kind: KindList},
+
+	// Middleware
+	{Key: "middleware.cors.enabled", Env: "CORS_ENABLED", Kind: KindBool},
+	{Key: "middleware.cors.allowed_origins", Env: "CORS_ALLOWED_ORIGINS", Kind: KindList},
+	{Key: "middleware.cors.allow_all", Env: "CORS_ALLOW_ALL", Kind: KindBool},
+	{Key: "middleware.cors.allow_credentials", Env: "CORS_ALLOW_CREDENTIALS", Kind: KindBool},
+	{Key: "middleware.security.enabled", Env: "SECURITY_HEADERS_ENABLED", Kind: KindBool},
+	{Key: "middleware.security.x_frame_options", Env: "SECURITY_X_FRAME_OPTIONS"},
+	{Key: "middleware.security.csp", Env: "SECURITY_CSP"},
+	{Key: "middleware.security.referrer_policy", Env: "SECURITY_REFERRER_POLICY"},
+	{Key: "middleware.security.hsts", Env: "SECURITY_HSTS"},
+	{Key: "middleware.security.permissions_policy", Env: "SECURITY_PERMISSIONS_POLICY"},
+	{Key: "middleware.security.cache_control", Env: "SECURITY_CACHE_CONTROL"},
+	{Key: "middleware.security.route_headers", Env: "SECURITY_ROUTE_HEADERS", Kind: KindJSON},
+	{Key: "middleware.auth.enabled", Env: "AUTH_ENABLED", Kind: KindBool},
+	{Key: "middleware.auth.api_keys", Env: "API_KEYS", Kind: KindPairs},
+	{Key: "middleware.auth.use_redis", Env: "AUTH_USE_REDIS", Kind: KindBool},
+	{Key: "middleware.publisher_auth.enabled", Env: "PUBLISHER_AUTH_ENABLED", Kind: KindBool},
+	{Key: "middleware.publisher_auth.allow_unregistered", Env: "PUBLISHER_ALLOW_UNREGISTERED", Kind: KindBool},
+	{Key: "middleware.publisher_auth.registered_publishers", Env: "REGISTERED_PUBLISHERS", Kind: KindPairs},
+	{Key: "middleware.publisher_auth.validate_domain", Env: "PUBLISHER_VALIDATE_DOMAIN", Kind: KindBool},
+	{Key: "middleware.publisher_auth.use_redis", Env: "PUBLISHER_AUTH_USE_REDIS", Kind: KindBool},
+	{Key: "middleware.publisher_auth.domain_mismatch_action", Env: "PUBLISHER_DOMAIN_MISMATCH_ACTION", Enum: []string{"reject", "flag"}},
+	{Key: "middleware.rate_limit.enabled", Env: "RATE_LIMIT_ENABLED", Kind: KindBool, Live: true},
+	{Key: "middleware.rate_limit.rps", Env: "RATE_LIMIT_RPS", Kind: KindInt, Live: true},
+	{Key: "middleware.rate_limit.burst", Env: "RATE_LIMIT_BURST", Kind: KindInt, Live: true},
+	{Key: "middleware.rate_limit.trusted_proxies", Env: "TRUSTED_PROXIES", Kind: KindList},
+	{Key: "middleware.size_limit.max_request_size", Env: "MAX_REQUEST_SIZE", Kind: KindInt},
+	{Key: "middleware.size_limit.max_decompressed_request_size", Env: "MAX_DECOMPRESSED_REQUEST_SIZE", Kind: KindInt},
+	{Key: "middleware.size_limit.max_url_length", Env: "MAX_URL_LENGTH", Kind: KindInt},
+
+	// Privacy
+	{Key: "privacy.enforce_gdpr", Env: "PBS_ENFORCE_GDPR", Kind: KindBool, Live: true},
+	{Key: "privacy.enforce_coppa", Env: "PBS_ENFORCE_COPPA", Kind: KindBool, Live: true},
+	{Key: "privacy.enforce_ccpa", Env: "PBS_ENFORCE_CCPA", Kind: KindBool, Live: true},
+	{Key: "privacy.coppa_mode", Env: "PBS_COPPA_MODE", Enum: []string{"block", "transform"}, Live: true},
+	{Key: "privacy.strict_mode", Env: "PBS_PRIVACY_STRICT_MODE", Kind: KindBool, Live: true},
+	{Key: "privacy.violation_mode", Env: "PBS_PRIVACY_VIOLATION_MODE", Enum: []string{"block", "scrub"}, Live: true},
+	{Key: "privacy.anonymize_ip", Env: "PBS_ANONYMIZE_IP", Kind: KindBool, Live: true},
+	{Key: "privacy.audit_log", Env: "PBS_PRIVACY_AUDIT_LOG", Kind: KindBool, Live: true},
+	{Key: "privacy.gdpr_geo_inference", Env: "PBS_GDPR_GEO_INFERENCE", Kind: KindBool, Live: true},
+	{Key: "privacy.gdpr_countries", Env: "PBS_GDPR_COUNTRIES", Kind: KindList, Live: true},
+	{Key: "privacy.vendor_consent", Env: "GDPR_VENDOR_CONSENT", Enum: []string{"off", "skip", "strip"}},
+	{Key: "privacy.geoip_db_path", Env: "GEOIP_DB_PATH"},
+	{Key: "privacy.geo_service.url", Env: "GEO_SERVICE_URL"},
+	{Key: "privacy.geo_service.field", Env: "GEO_SERVICE_FIELD"},
+	{Key: "privacy.geo_service.timeout", Env: "GEO_SERVICE_TIMEOUT", Kind: KindDuration},
+	{Key: "privacy.geo_service.cache_ttl", Env: "GEO_SERVICE_CACHE_TTL", Kind: KindDuration},
+
+	// Cookie sync
+	{Key: "cookie_sync.max_syncs", Env: "COOKIE_SYNC_MAX_SYNCS", Kind: KindInt},
+	{Key: "cookie_sync.cookie_keys", Env: "UIDS_COOKIE_KEYS", Kind: KindList},
+	{Key: "cookie_sync.cookie_encrypt", Env: "UIDS_COOKIE_ENCRYPT", Kind: KindBool},
+	{Key: "cookie_sync.cookie_accept_plain", Env: "UIDS_COOKIE_ACCEPT_PLAIN", Kind: KindBool},
+	{Key: "cookie_sync.uid_store", Env: "UIDS_STORE", Enum: []string{"cookie", "redis"}},
+	{Key: "cookie_sync.uid_store_ttl", Env: "UIDS_STORE_TTL", Kind: KindDuration},
+	{Key: "cookie_sync.syncers_file", Env: "SYNCERS_FILE"},
+	{Key: "cookie_sync.syncers_from_redis", Env: "SYNCERS_FROM_REDIS", Kind: KindBool},
+	{Key: "cookie_sync.syncers_refresh_period", Env: "SYNCERS_REFRESH_PERIOD", Kind: KindDuration},
+	{Key: "cookie_sync.rate_limit.enabled", Env: "SYNC_RATE_LIMIT_ENABLED", Kind: KindBool, Live: true},
+	{Key: "cookie_sync.rate_limit.per_ip", Env: "SYNC_RATE_LIMIT_PER_IP", Kind: KindInt, Live: true},
+	{Key: "cookie_sync.rate_limit.per_publisher", Env: "SYNC_RATE_LIMIT_PER_PUBLISHER", Kind: KindInt, Live: true},
+	{Key: "cookie_sync.rate_limit.window", Env: "SYNC_RATE_LIMIT_WINDOW", Kind: KindDuration},
+
+	// Analytics
+	{Key: "analytics.file.path", Env: "ANALYTICS_FILE_PATH"},
+	{Key: "analytics.http.url", Env: "ANALYTICS_HTTP_URL"},
+	{Key: "analytics.http.batch_size", Env: "ANALYTICS_HTTP_BATCH_SIZE", Kind: KindInt},
+	{Key: "analytics.http.flush_interval", Env: "ANALYTICS_HTTP_FLUSH_INTERVAL", Kind: KindDuration},
+	{Key: "analytics.http.buffer_size", Env: "ANALYTICS_HTTP_BUFFER_SIZE", Kind: KindInt},
+	{Key: "analytics.kafka.brokers", Env: "ANALYTICS_KAFKA_BROKERS", Kind: KindList},
+	{Key: "analytics.kafka.topic", Env: "ANALYTICS_KAFKA_TOPIC"},
+	{Key: "analytics.kafka.batch_size", Env: "ANALYTICS_KAFKA_BATCH_SIZE", Kind: KindInt},
+	{Key: "analytics.kafka.flush_interval", Env: "ANALYTICS_KAFKA_FLUSH_INTERVAL", Kind: KindDuration},
+	{Key: "analytics.kafka.buffer_size", Env: "ANALYTICS_KAFKA_BUFFER_SIZE", Kind: KindInt},
+	{Key: "analytics.kafka.compression", Env: "ANALYTICS_KAFKA_COMPRESSION", Enum: []string{"none", "gzip"}},
+	{Key: "analytics.kafka.acks", Env: "ANALYTICS_KAFKA_ACKS", Enum: []string{"0", "1", "all", "-1"}},
+
+	// Tracing, under the OpenTelemetry SDK's standard env vars
+	{Key: "tracing.endpoint", Env: "OTEL_EXPORTER_OTLP_ENDPOINT"},
+	{Key: "tracing.headers", Env: "OTEL_EXPORTER_OTLP_HEADERS"},
+	{Key: "tracing.service_name", Env: "OTEL_SERVICE_NAME"},
+	{Key: "tracing.resource_attributes", Env: "OTEL_RESOURCE_ATTRIBUTES"},
+	{Key: "tracing.sampler", Env: "OTEL_TRACES_SAMPLER"},
+	{Key: "tracing.sampler_arg", Env: "OTEL_TRACES_SAMPLER_ARG", Kind: KindFloat},
+}
@@ -0,0 +1,12 @@
+package config
+
+// Build identification
+// Version and EngineName are vars so release builds can stamp them via
+// -ldflags "-X github.com/StreetsDigital/thenexusengine/pbs/internal/config.Version=1.2.3"
+var (
+	// Version is the PBS engine version reported on health checks and to bidders
+	Version = "1.0.0"
+
+	// EngineName identifies this exchange to demand partners
+	EngineName = "thenexusengine"
+)
@@ -0,0 +1,125 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+// AccountQueryParam identifies the account for thin integrations (pixels, SDK lite)
+// that don't send site.publisher.id or app.publisher.id
+const AccountQueryParam = "account"
+
+// maxDefaultTMax matches the exchange's tmax upper bound
+const maxDefaultTMax = 30000
+
+// AccountDefaults are request values an account fills in when the client omits them.
+// Values present in the request always win.
+type AccountDefaults struct {
+	TMax   int             `json:"tmax,omitempty"`
+	Cur    []string        `json:"cur,omitempty"`
+	Test   int             `json:"test,omitempty"` // 1 marks all of the account's auctions as test traffic
+	Site   *SiteDefaults   `json:"site,omitempty"`
+	Device *DeviceDefaults `json:"device,omitempty"`
+}
+
+// SiteDefaults fill site fields. A request with neither site nor app gets a site
+// built from these, so pixel integrations still pass site/app validation.
+type SiteDefaults struct {
+	Domain string `json:"domain,omitempty"`
+	Page   string `json:"page,omitempty"`
+}
+
+// DeviceDefaults fill device fields
+type DeviceDefaults struct {
+	DeviceType int    `json:"devicetype,omitempty"`
+	Language   string `json:"language,omitempty"`
+}
+
+// ParseAccountDefaults parses a JSON object of account ID to defaults, e.g.
+// {"pub-1":{"tmax":800,"cur":["USD"],"site":{"domain":"example.com"}}}
+func ParseAccountDefaults(raw string) (map[string]AccountDefaults, error) {
+	defaults := make(map[string]AccountDefaults)
+	if raw == "" {
+		return defaults, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &defaults); err != nil {
+		return nil, fmt.Errorf("invalid account defaults: %w", err)
+	}
+	for account, d := range defaults {
+		if d.TMax < 0 || d.TMax > maxDefaultTMax {
+			return nil, fmt.Errorf("account %s: tmax must be between 0 and %d, got %d", account, maxDefaultTMax, d.TMax)
+		}
+		if d.Test != 0 && d.Test != 1 {
+			return nil, fmt.Errorf("account %s: test must be 0 or 1, got %d", account, d.Test)
+		}
+	}
+	return defaults, nil
+}
+
+// requestAccountID returns the publisher ID from the request, falling back to the
+// account query parameter
+func requestAccountID(r *http.Request, req *openrtb.BidRequest) string {
+	if req.Site != nil && req.Site.Publisher != nil && req.Site.Publisher.ID != "" {
+		return req.Site.Publisher.ID
+	}
+	if req.App != nil && req.App.Publisher != nil && req.App.Publisher.ID != "" {
+		return req.App.Publisher.ID
+	}
+	return r.URL.Query().Get(AccountQueryParam)
+}
+
+// apply merges the defaults into fields the request left empty. The account ID
+// also becomes the publisher ID when the request names none.
+func (d AccountDefaults) apply(req *openrtb.BidRequest, accountID string) {
+	if req.TMax == 0 {
+		req.TMax = d.TMax
+	}
+	if len(req.Cur) == 0 && len(d.Cur) > 0 {
+		req.Cur = append([]string(nil), d.Cur...)
+	}
+	if req.Test == 0 {
+		req.Test = d.Test
+	}
+
+	if req.Site == nil && req.App == nil && d.Site != nil {
+		req.Site = &openrtb.Site{}
+	}
+	if req.Site != nil {
+		if d.Site != nil {
+			if req.Site.Domain == "" {
+				req.Site.Domain = d.Site.Domain
+			}
+			if req.Site.Page == "" {
+				req.Site.Page = d.Site.Page
+			}
+		}
+		if req.Site.Publisher == nil {
+			req.Site.Publisher = &openrtb.Publisher{}
+		}
+		if req.Site.Publisher.ID == "" {
+			req.Site.Publisher.ID = accountID
+		}
+	} else if req.App != nil {
+		if req.App.Publisher == nil {
+			req.App.Publisher = &openrtb.Publisher{}
+		}
+		if req.App.Publisher.ID == "" {
+			req.App.Publisher.ID = accountID
+		}
+	}
+
+	if d.Device != nil {
+		if req.Device == nil {
+			req.Device = &openrtb.Device{}
+		}
+		if req.Device.DeviceType == 0 {
+			req.Device.DeviceType = d.Device.DeviceType
+		}
+		if req.Device.Language == "" {
+			req.Device.Language = d.Device.Language
+		}
+	}
+}
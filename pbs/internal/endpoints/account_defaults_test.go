@@ -0,0 +1,116 @@
+package endpoints
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/exchange"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+func TestParseAccountDefaults(t *testing.T) {
+	defaults, err := ParseAccountDefaults(`{"pub-1":{"tmax":800,"cur":["EUR"],"test":1,"site":{"domain":"example.com"}}}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	d := defaults["pub-1"]
+	if d.TMax != 800 || d.Cur[0] != "EUR" || d.Test != 1 || d.Site.Domain != "example.com" {
+		t.Errorf("unexpected defaults %+v", d)
+	}
+
+	if empty, err := ParseAccountDefaults(""); err != nil || len(empty) != 0 {
+		t.Errorf("expected empty defaults, got %v, %v", empty, err)
+	}
+
+	for _, raw := range []string{
+		`{"pub-1":`,
+		`{"pub-1":{"tmax":-1}}`,
+		`{"pub-1":{"tmax":60000}}`,
+		`{"pub-1":{"test":2}}`,
+	} {
+		if _, err := ParseAccountDefaults(raw); err == nil {
+			t.Errorf("expected error for %s", raw)
+		}
+	}
+}
+
+func TestAccountDefaults_Apply(t *testing.T) {
+	d := AccountDefaults{
+		TMax:   800,
+		Cur:    []string{"EUR"},
+		Test:   1,
+		Site:   &SiteDefaults{Domain: "example.com", Page: "https://example.com/"},
+		Device: &DeviceDefaults{DeviceType: 2, Language: "en"},
+	}
+
+	// A pixel request with nothing but an imp gets a complete site/device
+	req := &openrtb.BidRequest{ID: "r1", Imp: []openrtb.Imp{{ID: "imp-1"}}}
+	d.apply(req, "pub-1")
+	if req.TMax != 800 || req.Cur[0] != "EUR" || req.Test != 1 {
+		t.Errorf("expected top-level defaults, got tmax=%d cur=%v test=%d", req.TMax, req.Cur, req.Test)
+	}
+	if req.Site == nil || req.Site.Domain != "example.com" || req.Site.Publisher.ID != "pub-1" {
+		t.Errorf("expected default site with account publisher, got %+v", req.Site)
+	}
+	if req.Device.DeviceType != 2 || req.Device.Language != "en" {
+		t.Errorf("expected device defaults, got %+v", req.Device)
+	}
+
+	// Values sent by the client are never overwritten
+	req = &openrtb.BidRequest{
+		ID:     "r2",
+		TMax:   300,
+		Cur:    []string{"USD"},
+		Site:   &openrtb.Site{Domain: "other.com", Publisher: &openrtb.Publisher{ID: "pub-2"}},
+		Device: &openrtb.Device{DeviceType: 1},
+	}
+	d.apply(req, "pub-1")
+	if req.TMax != 300 || req.Cur[0] != "USD" || req.Site.Domain != "other.com" ||
+		req.Site.Publisher.ID != "pub-2" || req.Device.DeviceType != 1 {
+		t.Errorf("expected client values to win, got %+v", req)
+	}
+
+	// App requests get the publisher ID but no site
+	req = &openrtb.BidRequest{ID: "r3", App: &openrtb.App{Bundle: "com.example"}}
+	d.apply(req, "pub-1")
+	if req.Site != nil || req.App.Publisher == nil || req.App.Publisher.ID != "pub-1" {
+		t.Errorf("expected app publisher from account, got site=%+v app=%+v", req.Site, req.App)
+	}
+}
+
+func TestAuctionHandler_AccountDefaults(t *testing.T) {
+	ex := exchange.New(adapters.NewRegistry(), &exchange.Config{DefaultTimeout: 100 * time.Millisecond})
+	handler := NewVersionedAuctionHandler(ex, AuctionV2)
+
+	body := `{"id":"pixel-1","imp":[{"id":"imp-1","banner":{"w":300,"h":250}}]}`
+
+	// Without defaults the strict handler rejects a request with no site or app
+	req := httptest.NewRequest("POST", "/openrtb2/auction/v2?account=pub-1", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without defaults, got %d", w.Code)
+	}
+
+	handler.SetAccountDefaults(map[string]AccountDefaults{
+		"pub-1": {Site: &SiteDefaults{Domain: "example.com"}},
+	})
+	req = httptest.NewRequest("POST", "/openrtb2/auction/v2?account=pub-1", strings.NewReader(body))
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200 with account defaults, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Other accounts are unaffected
+	req = httptest.NewRequest("POST", "/openrtb2/auction/v2?account=pub-2", strings.NewReader(body))
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for account without defaults, got %d", w.Code)
+	}
+}
@@ -0,0 +1,290 @@
+package endpoints
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/storedrequests"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/logger"
+)
+
+// AMPPath is the amp-ad (Prebid Server rtc-config) endpoint
+const AMPPath = "/openrtb2/amp"
+
+// AMP response headers; amp-ad only reads the response when the source origin is echoed
+const (
+	ampSourceOriginParam  = "__amp_source_origin"
+	ampSourceOriginHeader = "AMP-Access-Control-Allow-Source-Origin"
+)
+
+// StoredRequestResolver expands stored request references; *storedrequests.Resolver implements it
+type StoredRequestResolver interface {
+	Resolve(ctx context.Context, body []byte) ([]byte, error)
+}
+
+// AMPResponse is what amp-ad reads: the targeting to set on the ad slot
+type AMPResponse struct {
+	Targeting map[string]string `json:"targeting"`
+	Ext       json.RawMessage   `json:"ext,omitempty"` // Debug and diagnostics, when requested
+}
+
+// AMPHandler handles GET /openrtb2/amp requests. The stored request named by
+// tag_id supplies the OpenRTB request, query parameters override parts of it,
+// and the result is run through auction, an http.Handler chain serving the
+// regular OpenRTB endpoint, so AMP traffic gets the same publisher auth,
+// privacy enforcement and validation as other auctions.
+type AMPHandler struct {
+	stored  StoredRequestResolver // nil without Redis; AMP is then unavailable
+	auction http.Handler
+}
+
+// NewAMPHandler creates an AMP handler; stored may be nil
+func NewAMPHandler(stored StoredRequestResolver, auction http.Handler) *AMPHandler {
+	return &AMPHandler{stored: stored, auction: auction}
+}
+
+// ServeHTTP handles the AMP request
+func (h *AMPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if origin := query.Get(ampSourceOriginParam); origin != "" {
+		w.Header().Set(ampSourceOriginHeader, origin)
+		w.Header().Set("Access-Control-Expose-Headers", ampSourceOriginHeader)
+	}
+
+	if h.stored == nil {
+		writeError(w, "AMP requires stored requests, which are unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	tagID := query.Get("tag_id")
+	if tagID == "" {
+		writeError(w, "tag_id is required", http.StatusBadRequest)
+		return
+	}
+
+	bidRequest, err := h.buildRequest(r.Context(), tagID, query)
+	if err != nil {
+		var notFound *storedrequests.NotFoundError
+		var invalid *ValidationError
+		switch {
+		case errors.As(err, &notFound), errors.As(err, &invalid):
+			writeError(w, err.Error(), http.StatusBadRequest)
+		default:
+			logger.Log.Error().Err(err).Str("tag_id", tagID).Msg("Failed to build AMP request")
+			writeError(w, "Failed to load AMP request", http.StatusInternalServerError)
+		}
+		return
+	}
+	body, err := json.Marshal(bidRequest)
+	if err != nil {
+		writeError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	rec := forwardAuction(r, body, h.auction)
+	if rec.status != http.StatusOK {
+		rec.writeTo(w)
+		return
+	}
+
+	var bidResponse openrtb.BidResponse
+	if err := json.Unmarshal(rec.body.Bytes(), &bidResponse); err != nil {
+		logger.Log.Error().Err(err).Str("tag_id", tagID).Msg("Failed to parse AMP auction response")
+		writeError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(AMPResponse{Targeting: ampTargeting(&bidResponse), Ext: bidResponse.Ext}); err != nil {
+		logger.Log.Error().Err(err).Str("tag_id", tagID).Msg("failed to encode AMP response")
+	}
+}
+
+// buildRequest loads the stored request for tagID and applies the query overrides
+func (h *AMPHandler) buildRequest(ctx context.Context, tagID string, query url.Values) (*openrtb.BidRequest, error) {
+	ref, err := json.Marshal(map[string]interface{}{
+		"ext": map[string]interface{}{"prebid": map[string]interface{}{"storedrequest": map[string]string{"id": tagID}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	resolved, err := h.stored.Resolve(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	var req openrtb.BidRequest
+	if err := json.Unmarshal(resolved, &req); err != nil {
+		return nil, fmt.Errorf("stored request %s: %w", tagID, err)
+	}
+	if len(req.Imp) != 1 {
+		return nil, &ValidationError{Field: "imp", Message: fmt.Sprintf("AMP stored request %s must have exactly one imp, has %d", tagID, len(req.Imp)), Index: -1}
+	}
+	if req.ID == "" {
+		req.ID = newRequestID()
+	}
+	if err := applyAMPOverrides(&req, query); err != nil {
+		return nil, err
+	}
+	if req.Ext, err = ampRequestExt(req.Ext); err != nil {
+		return nil, fmt.Errorf("stored request %s ext: %w", tagID, err)
+	}
+	return &req, nil
+}
+
+// applyAMPOverrides applies the amp-ad query parameters: sizes (ow/oh override
+// w/h, and ms adds sizes), slot, curl, consent_string, gdpr_applies and timeout
+func applyAMPOverrides(req *openrtb.BidRequest, query url.Values) error {
+	imp := &req.Imp[0]
+	formats, err := ampFormats(query)
+	if err != nil {
+		return err
+	}
+	if len(formats) > 0 && imp.Banner != nil {
+		imp.Banner.Format = formats
+	}
+	if slot := query.Get("slot"); slot != "" {
+		imp.TagID = slot
+	}
+
+	if pageURL := query.Get("curl"); pageURL != "" && req.App == nil {
+		if req.Site == nil {
+			req.Site = &openrtb.Site{}
+		}
+		req.Site.Page = pageURL
+		if u, err := url.Parse(pageURL); err == nil && req.Site.Domain == "" {
+			req.Site.Domain = u.Hostname()
+		}
+	}
+
+	if consent := query.Get("consent_string"); consent != "" {
+		if req.User == nil {
+			req.User = &openrtb.User{}
+		}
+		req.User.Consent = consent
+	}
+	if applies := query.Get("gdpr_applies"); applies != "" {
+		gdpr := 0
+		if applies == "true" {
+			gdpr = 1
+		}
+		if req.Regs == nil {
+			req.Regs = &openrtb.Regs{}
+		}
+		req.Regs.GDPR = &gdpr
+	}
+
+	if timeout := query.Get("timeout"); timeout != "" {
+		tmax, err := strconv.Atoi(timeout)
+		if err != nil || tmax <= 0 {
+			return &ValidationError{Field: "timeout", Message: "must be a positive number of milliseconds", Index: -1}
+		}
+		req.TMax = tmax
+	}
+	return nil
+}
+
+// ampFormats returns the banner sizes the query asks for: ow/oh override w/h
+// one dimension at a time, and ms adds "WxH" sizes separated by commas. Nil
+// when the query sets no size.
+func ampFormats(query url.Values) ([]openrtb.Format, error) {
+	dims := make(map[string]int, 4)
+	for _, param := range []string{"w", "h", "ow", "oh"} {
+		value := query.Get(param)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return nil, &ValidationError{Field: param, Message: "must be a non-negative integer", Index: -1}
+		}
+		dims[param] = n
+	}
+
+	var formats []openrtb.Format
+	w, h := dims["w"], dims["h"]
+	if dims["ow"] > 0 {
+		w = dims["ow"]
+	}
+	if dims["oh"] > 0 {
+		h = dims["oh"]
+	}
+	if w > 0 && h > 0 {
+		formats = append(formats, openrtb.Format{W: w, H: h})
+	}
+
+	if ms := query.Get("ms"); ms != "" {
+		for _, size := range strings.Split(ms, ",") {
+			var fw, fh int
+			if _, err := fmt.Sscanf(strings.TrimSpace(size), "%dx%d", &fw, &fh); err != nil || fw <= 0 || fh <= 0 {
+				return nil, &ValidationError{Field: "ms", Message: "sizes must be WxH separated by commas", Index: -1}
+			}
+			formats = append(formats, openrtb.Format{W: fw, H: fh})
+		}
+	}
+	return formats, nil
+}
+
+// ampRequestExt turns on targeting and bid caching unless the stored request
+// configures them: amp-ad needs the targeting keys, and the creative is
+// rendered from the cache by hb_cache_id
+func ampRequestExt(ext json.RawMessage) (json.RawMessage, error) {
+	fields := make(map[string]json.RawMessage)
+	if len(ext) > 0 {
+		if err := json.Unmarshal(ext, &fields); err != nil {
+			return nil, err
+		}
+	}
+	prebid := make(map[string]json.RawMessage)
+	if raw, ok := fields["prebid"]; ok {
+		if err := json.Unmarshal(raw, &prebid); err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := prebid["targeting"]; !ok {
+		prebid["targeting"] = json.RawMessage(`{}`)
+	}
+	if _, ok := prebid["cache"]; !ok {
+		prebid["cache"] = json.RawMessage(`{"bids":{}}`)
+	}
+	raw, err := json.Marshal(prebid)
+	if err != nil {
+		return nil, err
+	}
+	fields["prebid"] = raw
+	return json.Marshal(fields)
+}
+
+// ampTargeting collects the targeting keys of every returned bid. There is
+// one imp, so the winner's unsuffixed keys appear once.
+func ampTargeting(resp *openrtb.BidResponse) map[string]string {
+	targeting := make(map[string]string)
+	for _, sb := range resp.SeatBid {
+		for _, bid := range sb.Bid {
+			var ext openrtb.BidExt
+			if len(bid.Ext) == 0 || json.Unmarshal(bid.Ext, &ext) != nil || ext.Prebid == nil {
+				continue
+			}
+			for k, v := range ext.Prebid.Targeting {
+				targeting[k] = v
+			}
+		}
+	}
+	return targeting
+}
+
+// newRequestID generates an ID for requests built from stored ones that don't carry one
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "amp"
+	}
+	return hex.EncodeToString(b)
+}
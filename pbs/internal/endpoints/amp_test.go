@@ -0,0 +1,160 @@
+package endpoints
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/exchange"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/storedrequests"
+)
+
+// mockStoredFetcher serves stored requests from a map
+type mockStoredFetcher map[string]string
+
+func (f mockStoredFetcher) Fetch(ctx context.Context, kind storedrequests.Kind, id string) (json.RawMessage, error) {
+	data, ok := f[id]
+	if !ok {
+		return nil, &storedrequests.NotFoundError{Kind: kind, ID: id}
+	}
+	return json.RawMessage(data), nil
+}
+
+func ampResolver() *storedrequests.Resolver {
+	return storedrequests.New(mockStoredFetcher{
+		"amp-top":  `{"id":"stored","site":{"domain":"example.com","publisher":{"id":"pub1"}},"imp":[{"id":"top","banner":{"format":[{"w":300,"h":250}]}}]}`,
+		"two-imps": `{"id":"stored","imp":[{"id":"a","banner":{}},{"id":"b","banner":{}}]}`,
+	}, storedrequests.Config{})
+}
+
+// mockBidAdapter returns one banner bid per request without any HTTP
+type mockBidAdapter struct {
+	price float64
+}
+
+func (m *mockBidAdapter) MakeRequests(request *openrtb.BidRequest, reqInfo *adapters.ExtraRequestInfo) ([]*adapters.RequestData, []error) {
+	return []*adapters.RequestData{{Method: "MOCK", Body: []byte(`{}`)}}, nil
+}
+
+func (m *mockBidAdapter) MakeBids(request *openrtb.BidRequest, response *adapters.ResponseData) (*adapters.BidderResponse, []error) {
+	bid := &openrtb.Bid{ID: "bid-1", ImpID: request.Imp[0].ID, Price: m.price, W: 300, H: 250, AdM: "<div/>"}
+	return &adapters.BidderResponse{Bids: []*adapters.TypedBid{{Bid: bid, BidType: adapters.BidTypeBanner}}, Currency: "USD"}, nil
+}
+
+func TestAMPHandler_Targeting(t *testing.T) {
+	registry := adapters.NewRegistry()
+	registry.Register("appnexus", &mockBidAdapter{price: 1.5}, adapters.BidderInfo{Enabled: true, DemandType: adapters.DemandTypePublisher})
+	ex := exchange.New(registry, &exchange.Config{DefaultTimeout: time.Second, DefaultCurrency: "USD"})
+	handler := NewAMPHandler(ampResolver(), NewAuctionHandler(ex))
+
+	req := httptest.NewRequest(http.MethodGet, AMPPath+"?tag_id=amp-top&__amp_source_origin=https%3A%2F%2Fexample.com", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get(ampSourceOriginHeader) != "https://example.com" {
+		t.Errorf("expected the source origin echoed, got %q", w.Header().Get(ampSourceOriginHeader))
+	}
+	var resp AMPResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if resp.Targeting["hb_pb"] != "1.50" || resp.Targeting["hb_bidder"] != "appnexus" || resp.Targeting["hb_size"] != "300x250" {
+		t.Errorf("expected winner targeting, got %v", resp.Targeting)
+	}
+}
+
+func TestAMPHandler_Overrides(t *testing.T) {
+	var forwarded openrtb.BidRequest
+	var forwardedURL *url.URL
+	auction := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwardedURL = r.URL
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &forwarded)
+		w.Write([]byte(`{"id":"stored"}`))
+	})
+	handler := NewAMPHandler(ampResolver(), auction)
+
+	query := "?tag_id=amp-top&w=320&h=50&oh=100&ms=300x250,728x90&slot=%2F1234%2Ftop&curl=https%3A%2F%2Fnews.example.com%2Fa" +
+		"&consent_string=CONSENT&gdpr_applies=true&timeout=900&debug=1"
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, AMPPath+query, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"targeting":{}`) {
+		t.Errorf("expected empty targeting without bids, got %s", w.Body.String())
+	}
+
+	if forwardedURL.Path != AuctionV1Path || forwardedURL.Query().Get("debug") != "1" {
+		t.Errorf("expected a forward to the auction keeping the query, got %s", forwardedURL)
+	}
+	imp := forwarded.Imp[0]
+	want := []openrtb.Format{{W: 320, H: 100}, {W: 300, H: 250}, {W: 728, H: 90}}
+	if len(imp.Banner.Format) != len(want) {
+		t.Fatalf("expected formats %v, got %v", want, imp.Banner.Format)
+	}
+	for i, f := range want {
+		if imp.Banner.Format[i].W != f.W || imp.Banner.Format[i].H != f.H {
+			t.Errorf("format %d: expected %v, got %v", i, f, imp.Banner.Format[i])
+		}
+	}
+	if imp.TagID != "/1234/top" || forwarded.TMax != 900 {
+		t.Errorf("expected slot and timeout applied, got tagid %q tmax %d", imp.TagID, forwarded.TMax)
+	}
+	if forwarded.Site.Page != "https://news.example.com/a" || forwarded.Site.Domain != "example.com" {
+		t.Errorf("expected curl as the page and the stored domain kept, got %+v", forwarded.Site)
+	}
+	if forwarded.User == nil || forwarded.User.Consent != "CONSENT" || forwarded.Regs == nil || *forwarded.Regs.GDPR != 1 {
+		t.Errorf("expected consent and gdpr applied, got user %+v regs %+v", forwarded.User, forwarded.Regs)
+	}
+	if !strings.Contains(string(forwarded.Ext), `"targeting":{}`) || !strings.Contains(string(forwarded.Ext), `"cache":{"bids":{}}`) {
+		t.Errorf("expected targeting and bid caching turned on, got %s", forwarded.Ext)
+	}
+}
+
+func TestAMPHandler_Errors(t *testing.T) {
+	auction := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, "Privacy compliance violation", http.StatusBadRequest)
+	})
+	handler := NewAMPHandler(ampResolver(), auction)
+
+	tests := []struct {
+		name   string
+		query  string
+		status int
+		body   string
+	}{
+		{name: "missing tag_id", query: "", status: http.StatusBadRequest, body: "tag_id is required"},
+		{name: "unknown tag_id", query: "?tag_id=nope", status: http.StatusBadRequest, body: "stored request not found: nope"},
+		{name: "several imps", query: "?tag_id=two-imps", status: http.StatusBadRequest, body: "exactly one imp"},
+		{name: "bad size", query: "?tag_id=amp-top&ms=big", status: http.StatusBadRequest, body: "WxH"},
+		{name: "bad timeout", query: "?tag_id=amp-top&timeout=-5", status: http.StatusBadRequest, body: "timeout"},
+		{name: "auction rejection", query: "?tag_id=amp-top", status: http.StatusBadRequest, body: "Privacy compliance violation"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, AMPPath+tt.query, nil))
+			if w.Code != tt.status || !strings.Contains(w.Body.String(), tt.body) {
+				t.Errorf("expected %d containing %q, got %d %s", tt.status, tt.body, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	NewAMPHandler(nil, auction).ServeHTTP(w, httptest.NewRequest(http.MethodGet, AMPPath+"?tag_id=amp-top", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 without stored requests, got %d", w.Code)
+	}
+}
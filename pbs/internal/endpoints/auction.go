@@ -2,17 +2,24 @@
 package endpoints
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/analytics"
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/exchange"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/middleware"
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/jsoncodec"
 	"github.com/StreetsDigital/thenexusengine/pbs/pkg/logger"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/tracing"
 
 	log "github.com/rs/zerolog/log"
 )
@@ -23,12 +30,56 @@ var debugRequiresAuth = os.Getenv("DEBUG_REQUIRES_AUTH") != "false"
 
 // AuctionHandler handles /openrtb2/auction requests
 type AuctionHandler struct {
-	exchange *exchange.Exchange
+	exchange    *exchange.Exchange
+	version     APIVersion
+	policy      versionPolicy
+	deprecation *Deprecation // nil while the version is current
+	metrics     AuctionVersionMetrics
+	defaults    map[string]AccountDefaults // keyed by account (publisher) ID
+	analytics   analytics.Module           // nil logs nothing
+	drain       DrainState                 // nil never refuses auctions
 }
 
-// NewAuctionHandler creates a new auction handler
+// NewAuctionHandler creates a new auction handler serving the legacy v1 contract
 func NewAuctionHandler(ex *exchange.Exchange) *AuctionHandler {
-	return &AuctionHandler{exchange: ex}
+	return NewVersionedAuctionHandler(ex, AuctionV1)
+}
+
+// NewVersionedAuctionHandler creates an auction handler for a specific API version
+func NewVersionedAuctionHandler(ex *exchange.Exchange, version APIVersion) *AuctionHandler {
+	return &AuctionHandler{exchange: ex, version: version, policy: policyFor(version)}
+}
+
+// SetDeprecation marks this version deprecated; responses then carry Deprecation/Sunset headers
+func (h *AuctionHandler) SetDeprecation(d *Deprecation) {
+	h.deprecation = d
+}
+
+// SetMetrics sets the per-version request metrics recorder
+func (h *AuctionHandler) SetMetrics(m AuctionVersionMetrics) {
+	h.metrics = m
+}
+
+// SetAccountDefaults sets per-account values merged into requests that omit them
+func (h *AuctionHandler) SetAccountDefaults(defaults map[string]AccountDefaults) {
+	h.defaults = defaults
+}
+
+// SetAnalytics sets the analytics modules every auction is logged to
+func (h *AuctionHandler) SetAnalytics(m analytics.Module) {
+	h.analytics = m
+}
+
+// SetDrain refuses new auctions while the server drains
+func (h *AuctionHandler) SetDrain(d DrainState) {
+	h.drain = d
+}
+
+// recordOutcome reports the request outcome for this handler's version
+func (h *AuctionHandler) recordOutcome(outcome string) {
+	if h.metrics != nil {
+		h.metrics.RecordAuctionAPIRequest(string(h.version), outcome)
+	}
 }
 
 // ServeHTTP handles the auction request
@@ -38,31 +89,97 @@ func (h *AuctionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Version headers go on every response, including errors, so clients notice sunsets early
+	w.Header().Set(APIVersionHeader, string(h.version))
+	if h.deprecation != nil {
+		h.deprecation.apply(w.Header())
+	}
+
+	// Analytics see every auction, including rejected ones
+	ao := &analytics.AuctionObject{Status: http.StatusOK, StartTime: time.Now()}
+	if h.analytics != nil {
+		defer h.analytics.LogAuctionObject(ao)
+	}
+	var selectedBidders []string
+	defer func() { logger.LogAuction(auctionAudit(ao, selectedBidders)) }()
+
+	// The auction's root span continues the caller's trace, if any
+	ctx, span := tracing.Start(tracing.Extract(r.Context(), r.Header), "auction", tracing.SpanKindServer)
+	defer func() {
+		span.SetAttributes(
+			tracing.String("http.route", r.URL.Path),
+			tracing.Int("http.status_code", ao.Status),
+			tracing.String("account", ao.Account),
+			tracing.Int("bidders", len(ao.Bidders)),
+		)
+		if ao.Request != nil {
+			span.SetAttributes(tracing.String("auction.id", ao.Request.ID))
+		}
+		if len(ao.Errors) > 0 {
+			span.SetError(errors.New(ao.Errors[0]))
+		}
+		span.End()
+	}()
+	reject := func(message string) {
+		ao.Status = http.StatusBadRequest
+		ao.Errors = append(ao.Errors, message)
+		h.recordOutcome(outcomeRejected)
+		writeError(w, message, http.StatusBadRequest)
+	}
+
 	// Read request body
 	defer r.Body.Close()
-	body, err := io.ReadAll(r.Body)
+	body, err := jsoncodec.ReadAll(r.Body)
 	if err != nil {
-		writeError(w, "Failed to read request body", http.StatusBadRequest)
+		reject("Failed to read request body")
 		return
 	}
 
 	// Parse OpenRTB request
 	var bidRequest openrtb.BidRequest
-	if err := json.Unmarshal(body, &bidRequest); err != nil {
+	if err := jsoncodec.Unmarshal(body, &bidRequest); err != nil {
 		logger.Log.Warn().Err(err).Msg("Invalid JSON in bid request")
-		writeError(w, "Invalid JSON in request body", http.StatusBadRequest)
+		reject("Invalid JSON in request body")
 		return
 	}
+	ao.Request = &bidRequest
+
+	// Fill account defaults before validation so thin integrations produce complete requests
+	accountID := requestAccountID(r, &bidRequest)
+	ao.Account = accountID
+
+	// Draining: in-flight auctions finish, new ones go to another instance
+	if h.drain != nil && h.drain.Draining() {
+		ao.Status = http.StatusServiceUnavailable
+		ao.Errors = append(ao.Errors, "server draining")
+		h.recordOutcome(outcomeRejected)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Connection", "close")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		if err := json.NewEncoder(w).Encode(&openrtb.BidResponse{ID: bidRequest.ID, NBR: int(openrtb.NoBidTimeout)}); err != nil {
+			log.Error().Err(err).Msg("failed to encode draining response")
+		}
+		return
+	}
+
+	if d, ok := h.defaults[accountID]; ok && accountID != "" {
+		d.apply(&bidRequest, accountID)
+	}
 
 	// Validate request
-	if err := validateBidRequest(&bidRequest); err != nil {
-		writeError(w, err.Error(), http.StatusBadRequest)
+	validate := validateBidRequest
+	if h.policy.strictValidation {
+		validate = validateBidRequestStrict
+	}
+	if err := validate(&bidRequest); err != nil {
+		reject(err.Error())
 		return
 	}
 
 	// Build auction request
-	// P2-1: Debug mode requires authentication to prevent information disclosure
-	debugRequested := r.URL.Query().Get("debug") == "1"
+	// P2-1: Debug mode requires authentication to prevent information disclosure.
+	// Prebid.js asks for it with test=1 or ext.prebid.debug as well as ?debug=1.
+	debugRequested := r.URL.Query().Get("debug") == "1" || exchange.PrebidDebug(&bidRequest)
 	debugEnabled := false
 	if debugRequested {
 		if debugRequiresAuth {
@@ -77,37 +194,64 @@ func (h *AuctionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Diagnostics explain empty auctions to publishers; unlike debug they
+	// expose no bidder messages or other bids, so need no API key
 	auctionReq := &exchange.AuctionRequest{
-		BidRequest: &bidRequest,
-		Debug:      debugEnabled,
+		BidRequest:  &bidRequest,
+		Account:     accountID,
+		Debug:       debugEnabled,
+		Diagnostics: r.URL.Query().Get("diagnostics") == "1",
 	}
 
 	// Run auction
-	ctx := r.Context()
 	result, err := h.exchange.RunAuction(ctx, auctionReq)
 	if err != nil {
 		logger.Log.Error().
 			Err(err).
 			Str("request_id", bidRequest.ID).
 			Msg("Auction failed")
+		ao.Status = http.StatusInternalServerError
+		ao.Errors = append(ao.Errors, err.Error())
+		h.recordOutcome(outcomeError)
 		writeError(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
 	// Build response with extensions
 	response := result.BidResponse
+	ao.Response = response
+	ao.Bidders = bidderOutcomes(result)
+	if result.DebugInfo != nil {
+		selectedBidders = result.DebugInfo.SelectedBidders
+	}
+	// Response times, warnings and error counts go on every response so
+	// clients can monitor bidder health; bidder error messages stay debug-only
+	ext := standardResponseExt(result)
 	if auctionReq.Debug && result.DebugInfo != nil {
-		// Add debug info to extension
-		ext := buildResponseExt(result)
-		if extBytes, err := json.Marshal(ext); err == nil {
-			response.Ext = extBytes
+		ext = buildResponseExt(result)
+		addPrivacyWarnings(ctx, ext)
+		addPrivacyAudit(ctx, ext)
+	}
+	ext.TMMaxRequest = bidRequest.TMax
+	if result.Diagnostics != nil {
+		addNoBidDiagnostics(ctx, ext, result.Diagnostics)
+	}
+	// Echo ext.prebid.passthrough so clients can match the response to their own context
+	if passthrough := exchange.PrebidPassthrough(bidRequest.Ext); passthrough != nil {
+		if ext.Prebid == nil {
+			ext.Prebid = &openrtb.ExtBidResponsePrebid{}
 		}
+		ext.Prebid.Passthrough = passthrough
+	}
+	if extBytes, err := json.Marshal(ext); err == nil {
+		response.Ext = extBytes
 	}
+	h.recordOutcome(outcomeOK)
 
 	// Write response
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(response); err != nil {
+	if err := jsoncodec.Encode(w, response); err != nil {
 		log.Error().Err(err).Str("request_id", bidRequest.ID).Msg("failed to encode auction response")
 	}
 }
@@ -145,11 +289,21 @@ func (e *ValidationError) Error() string {
 	return e.Field + ": " + e.Message
 }
 
-// buildResponseExt builds response extensions with debug info
-func buildResponseExt(result *exchange.AuctionResponse) *openrtb.BidResponseExt {
+// standardResponseExt builds the extensions returned on every auction
+// response: per-bidder response times, skip warnings and errors. Errors carry
+// Prebid Server's error codes with a generic message per category, since the
+// bidders' own messages can disclose configuration and are debug-only.
+func standardResponseExt(result *exchange.AuctionResponse) *openrtb.BidResponseExt {
 	ext := &openrtb.BidResponseExt{
 		ResponseTimeMillis: make(map[string]int),
 		Errors:             make(map[string][]openrtb.ExtBidderMessage),
+		ErrorCounts:        bidderErrorCounts(result),
+	}
+
+	for bidder, br := range result.BidderResults {
+		if len(br.Errors) > 0 {
+			ext.Errors[bidder] = bidderErrorMessages(br)
+		}
 	}
 
 	if result.DebugInfo != nil {
@@ -157,6 +311,69 @@ func buildResponseExt(result *exchange.AuctionResponse) *openrtb.BidResponseExt
 			ext.ResponseTimeMillis[bidder] = int(latency.Milliseconds())
 		}
 
+		for _, bidder := range result.DebugInfo.RolloutHeldBack {
+			if ext.Warnings == nil {
+				ext.Warnings = make(map[string][]openrtb.ExtBidderMessage)
+			}
+			ext.Warnings[bidder] = append(ext.Warnings[bidder], openrtb.ExtBidderMessage{
+				Code:    rolloutWarningCode,
+				Message: "not called: auction outside the bidder's traffic_percent rollout",
+			})
+		}
+
+		for _, bidder := range result.DebugInfo.Throttled {
+			if ext.Warnings == nil {
+				ext.Warnings = make(map[string][]openrtb.ExtBidderMessage)
+			}
+			ext.Warnings[bidder] = append(ext.Warnings[bidder], openrtb.ExtBidderMessage{
+				Code:    rateLimitWarningCode,
+				Message: "not called: bidder's rate_limits exceeded",
+			})
+		}
+
+		for _, bidder := range result.DebugInfo.CircuitOpen {
+			if ext.Warnings == nil {
+				ext.Warnings = make(map[string][]openrtb.ExtBidderMessage)
+			}
+			ext.Warnings[bidder] = append(ext.Warnings[bidder], openrtb.ExtBidderMessage{
+				Code:    circuitOpenWarningCode,
+				Message: "not called: bidder's circuit breaker is open after repeated failures",
+			})
+		}
+
+		for _, bidder := range result.DebugInfo.ConsentDenied {
+			if ext.Warnings == nil {
+				ext.Warnings = make(map[string][]openrtb.ExtBidderMessage)
+			}
+			message := "not called: no GDPR consent for the bidder's GVL vendor ID"
+			if slices.Contains(result.DebugInfo.SelectedBidders, bidder) {
+				message = "called without personal data: no GDPR consent for the bidder's GVL vendor ID"
+			}
+			ext.Warnings[bidder] = append(ext.Warnings[bidder], openrtb.ExtBidderMessage{Code: privacyWarningCode, Message: message})
+		}
+
+		for _, bidder := range result.DebugInfo.COPPAUnsupported {
+			if ext.Warnings == nil {
+				ext.Warnings = make(map[string][]openrtb.ExtBidderMessage)
+			}
+			ext.Warnings[bidder] = append(ext.Warnings[bidder], openrtb.ExtBidderMessage{
+				Code:    privacyWarningCode,
+				Message: "called for a COPPA request without declaring COPPA support",
+			})
+		}
+	}
+
+	return ext
+}
+
+// buildResponseExt builds response extensions with debug info: the standard
+// extensions with the bidders' own error messages, plus the bid landscape and
+// HTTP calls
+func buildResponseExt(result *exchange.AuctionResponse) *openrtb.BidResponseExt {
+	ext := standardResponseExt(result)
+
+	if result.DebugInfo != nil {
+		ext.Errors = make(map[string][]openrtb.ExtBidderMessage)
 		for bidder, errs := range result.DebugInfo.Errors {
 			messages := make([]openrtb.ExtBidderMessage, len(errs))
 			for i, e := range errs {
@@ -165,12 +382,176 @@ func buildResponseExt(result *exchange.AuctionResponse) *openrtb.BidResponseExt
 			ext.Errors[bidder] = messages
 		}
 
-		ext.TMMaxRequest = int(result.DebugInfo.TotalLatency.Milliseconds())
+		if len(result.DebugInfo.BidLandscape) > 0 || len(result.DebugInfo.HTTPCalls) > 0 {
+			ext.Debug = &openrtb.ExtResponseDebug{
+				BidLandscape: result.DebugInfo.BidLandscape,
+				HTTPCalls:    result.DebugInfo.HTTPCalls,
+			}
+		}
 	}
 
 	return ext
 }
 
+// bidderOutcomes summarises each called bidder for analytics, sorted by bidder
+func bidderOutcomes(result *exchange.AuctionResponse) []analytics.BidderOutcome {
+	if len(result.BidderResults) == 0 {
+		return nil
+	}
+	outcomes := make([]analytics.BidderOutcome, 0, len(result.BidderResults))
+	for code, r := range result.BidderResults {
+		outcomes = append(outcomes, analytics.BidderOutcome{
+			Bidder:    code,
+			Bids:      len(r.Bids),
+			LatencyMS: r.Latency.Milliseconds(),
+			TimedOut:  r.TimedOut,
+			Errors:    len(r.Errors),
+		})
+	}
+	slices.SortFunc(outcomes, func(a, b analytics.BidderOutcome) int {
+		return strings.Compare(a.Bidder, b.Bidder)
+	})
+	return outcomes
+}
+
+// auctionAudit summarises a finished auction for its audit log line, taking
+// the highest-priced bid in the response as the winner
+func auctionAudit(ao *analytics.AuctionObject, selectedBidders []string) *logger.AuctionAudit {
+	audit := &logger.AuctionAudit{
+		Account:         ao.Account,
+		Status:          ao.Status,
+		SelectedBidders: selectedBidders,
+		Latency:         time.Since(ao.StartTime),
+	}
+	if ao.Request != nil {
+		audit.RequestID = ao.Request.ID
+	}
+	if len(ao.Errors) > 0 {
+		audit.Error = ao.Errors[0]
+	}
+	if ao.Response == nil {
+		return audit
+	}
+	audit.Currency = ao.Response.Cur
+	for _, sb := range ao.Response.SeatBid {
+		for _, bid := range sb.Bid {
+			audit.Bids++
+			if audit.WinningBidID == "" || bid.Price > audit.WinningPrice {
+				audit.WinningBidder = sb.Seat
+				audit.WinningBidID = bid.ID
+				audit.WinningPrice = bid.Price
+			}
+		}
+	}
+	return audit
+}
+
+// bidderErrorCounts returns each erroring bidder's error counts by category,
+// or nil when no bidder had errors
+func bidderErrorCounts(result *exchange.AuctionResponse) map[string]openrtb.ExtBidderErrorCounts {
+	var counts map[string]openrtb.ExtBidderErrorCounts
+	for bidder, br := range result.BidderResults {
+		if len(br.Errors) == 0 {
+			continue
+		}
+		if counts == nil {
+			counts = make(map[string]openrtb.ExtBidderErrorCounts)
+		}
+		counts[bidder] = br.ErrorCounts()
+	}
+	return counts
+}
+
+// bidderErrorMessages describes a bidder's errors for ext.errors without
+// their text: one message per error, coded as Prebid Server does
+func bidderErrorMessages(br *exchange.BidderResult) []openrtb.ExtBidderMessage {
+	messages := make([]openrtb.ExtBidderMessage, 0, len(br.Errors))
+	for range br.InputErrors {
+		messages = append(messages, openrtb.ExtBidderMessage{Code: errorCodeBadInput, Message: "request to the bidder could not be built"})
+	}
+	for _, err := range br.TransportErrors {
+		if errors.Is(err, context.DeadlineExceeded) {
+			messages = append(messages, openrtb.ExtBidderMessage{Code: errorCodeTimeout, Message: "bidder timed out"})
+		} else {
+			messages = append(messages, openrtb.ExtBidderMessage{Code: errorCodeFailedToRequestBids, Message: "bidder could not be reached"})
+		}
+	}
+	for range br.ResponseErrors {
+		messages = append(messages, openrtb.ExtBidderMessage{Code: errorCodeBadServerResponse, Message: "bidder response was unusable"})
+	}
+	return messages
+}
+
+// Prebid Server error codes for ext.errors
+const (
+	errorCodeTimeout             = 1
+	errorCodeBadInput            = 2
+	errorCodeBadServerResponse   = 3
+	errorCodeFailedToRequestBids = 4
+)
+
+// privacyWarningCode identifies privacy scope decisions in ext.warnings
+const privacyWarningCode = 10
+
+// rolloutWarningCode identifies bidders held back by gradual rollout in ext.warnings
+const rolloutWarningCode = 11
+
+// rateLimitWarningCode identifies bidders skipped for exceeding their rate_limits in ext.warnings
+const rateLimitWarningCode = 12
+
+// circuitOpenWarningCode identifies bidders skipped by their circuit breaker in ext.warnings
+const circuitOpenWarningCode = 13
+
+// addPrivacyWarnings records privacy decisions the middleware made implicitly, such as
+// treating a request without regs.gdpr as GDPR-scoped because of its country
+func addPrivacyWarnings(ctx context.Context, ext *openrtb.BidResponseExt) {
+	inference, ok := middleware.GDPRInferenceFromContext(ctx)
+	if !ok {
+		return
+	}
+	message := "regs.gdpr absent; GDPR applied based on geo country " + inference.Country
+	if inference.FromIP {
+		message += " (resolved from device IP)"
+	}
+	if ext.Warnings == nil {
+		ext.Warnings = make(map[string][]openrtb.ExtBidderMessage)
+	}
+	ext.Warnings["privacy"] = append(ext.Warnings["privacy"], openrtb.ExtBidderMessage{
+		Code:    privacyWarningCode,
+		Message: message,
+	})
+}
+
+// addPrivacyAudit returns the privacy middleware's decisions trail in ext.prebid.privacy
+func addPrivacyAudit(ctx context.Context, ext *openrtb.BidResponseExt) {
+	audit, ok := middleware.PrivacyAuditFromContext(ctx)
+	if !ok {
+		return
+	}
+	auditJSON, err := json.Marshal(audit)
+	if err != nil {
+		return
+	}
+	if ext.Prebid == nil {
+		ext.Prebid = &openrtb.ExtBidResponsePrebid{}
+	}
+	ext.Prebid.Privacy = auditJSON
+}
+
+// addNoBidDiagnostics adds an empty auction's explanation to ext.prebid.diagnostics,
+// with the privacy enforcement decisions taken on the request
+func addNoBidDiagnostics(ctx context.Context, ext *openrtb.BidResponseExt, diag *openrtb.ExtNoBidDiagnostics) {
+	if audit, ok := middleware.PrivacyAuditFromContext(ctx); ok && len(audit.Decisions) > 0 {
+		if decisions, err := json.Marshal(audit.Decisions); err == nil {
+			diag.Privacy = decisions
+		}
+	}
+	if ext.Prebid == nil {
+		ext.Prebid = &openrtb.ExtBidResponsePrebid{}
+	}
+	ext.Prebid.Diagnostics = diag
+}
+
 // writeError writes an error response
 func writeError(w http.ResponseWriter, message string, status int) {
 	w.Header().Set("Content-Type", "application/json")
@@ -224,6 +605,17 @@ type DynamicBidderLister interface {
 	ListBidderCodes() []string
 }
 
+// enabledBidderLister is implemented by static registries that can list only
+// enabled bidders, for /info/bidders?enabledonly=true
+type enabledBidderLister interface {
+	ListEnabledBidders() []string
+}
+
+// enabledDynamicBidderLister is the dynamic registry equivalent
+type enabledDynamicBidderLister interface {
+	ListEnabledBidderCodes() []string
+}
+
 // InfoBiddersHandler handles /info/bidders requests
 type InfoBiddersHandler struct {
 	staticRegistry  BidderLister
@@ -244,21 +636,39 @@ func NewDynamicInfoBiddersHandler(staticRegistry BidderLister, dynamicRegistry D
 	}
 }
 
-// ServeHTTP handles info/bidders requests
+// ServeHTTP handles info/bidders requests. With enabledonly=true, bidders
+// disabled in config or at runtime via /admin/bidders are left out.
 func (h *InfoBiddersHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	enabledOnly := false
+	if v := r.URL.Query().Get("enabledonly"); v != "" {
+		var err error
+		if enabledOnly, err = strconv.ParseBool(v); err != nil {
+			writeError(w, "enabledonly must be true or false", http.StatusBadRequest)
+			return
+		}
+	}
+
 	// Collect bidders from both registries at request time
 	bidderSet := make(map[string]bool)
 
 	// Add static bidders
 	if h.staticRegistry != nil {
-		for _, bidder := range h.staticRegistry.ListBidders() {
+		list := h.staticRegistry.ListBidders
+		if lister, ok := h.staticRegistry.(enabledBidderLister); ok && enabledOnly {
+			list = lister.ListEnabledBidders
+		}
+		for _, bidder := range list() {
 			bidderSet[bidder] = true
 		}
 	}
 
 	// Add dynamic bidders
 	if h.dynamicRegistry != nil {
-		for _, bidder := range h.dynamicRegistry.ListBidderCodes() {
+		list := h.dynamicRegistry.ListBidderCodes
+		if lister, ok := h.dynamicRegistry.(enabledDynamicBidderLister); ok && enabledOnly {
+			list = lister.ListEnabledBidderCodes
+		}
+		for _, bidder := range list() {
 			bidderSet[bidder] = true
 		}
 	}
@@ -5,16 +5,23 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/analytics"
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/exchange"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/lifecycle"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/middleware"
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/tracing"
 )
 
 // Mock adapter for testing
@@ -272,6 +279,38 @@ func TestAuctionHandler_ValidRequest_NoBidders(t *testing.T) {
 	}
 }
 
+func TestAuctionHandler_Draining(t *testing.T) {
+	ex := exchange.New(adapters.NewRegistry(), &exchange.Config{DefaultTimeout: 100 * time.Millisecond})
+	handler := NewAuctionHandler(ex)
+	drainer := lifecycle.NewDrainer(time.Minute)
+	handler.SetDrain(drainer)
+
+	bidReq := validBidRequest()
+	body, _ := json.Marshal(bidReq)
+	serve := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/openrtb2/auction", bytes.NewReader(body)))
+		return w
+	}
+
+	if w := serve(); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 before draining, got %d", w.Code)
+	}
+
+	drainer.Drain()
+	w := serve()
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 while draining, got %d", w.Code)
+	}
+	var resp openrtb.BidResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if resp.ID != bidReq.ID || resp.NBR != 501 {
+		t.Errorf("expected no-bid 501 for %s, got %+v", bidReq.ID, resp)
+	}
+}
+
 func TestAuctionHandler_DebugMode(t *testing.T) {
 	registry := adapters.NewRegistry()
 	mock := &mockAdapter{bids: []*adapters.TypedBid{}}
@@ -307,6 +346,194 @@ func TestAuctionHandler_DebugMode(t *testing.T) {
 	}
 }
 
+func TestAuctionHandler_PassthroughEchoed(t *testing.T) {
+	ex := exchange.New(adapters.NewRegistry(), &exchange.Config{
+		DefaultTimeout: 100 * time.Millisecond,
+	})
+	handler := NewAuctionHandler(ex)
+
+	for _, debug := range []bool{false, true} {
+		bidReq := validBidRequest()
+		bidReq.Ext = json.RawMessage(`{"prebid":{"passthrough":{"session":"abc","n":[1,2]}}}`)
+		body, _ := json.Marshal(bidReq)
+
+		url := "/openrtb2/auction"
+		if debug {
+			url += "?debug=1"
+		}
+		req := httptest.NewRequest("POST", url, bytes.NewReader(body))
+		req.Header.Set("X-API-Key", "test-key")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		var resp openrtb.BidResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to parse response: %v", err)
+		}
+		var ext openrtb.BidResponseExt
+		if err := json.Unmarshal(resp.Ext, &ext); err != nil || ext.Prebid == nil {
+			t.Fatalf("debug=%v: expected ext.prebid, got %s", debug, resp.Ext)
+		}
+		if string(ext.Prebid.Passthrough) != `{"session":"abc","n":[1,2]}` {
+			t.Errorf("debug=%v: expected passthrough echoed, got %s", debug, ext.Prebid.Passthrough)
+		}
+	}
+}
+
+func TestAuctionHandler_NoBidDiagnostics(t *testing.T) {
+	ex := exchange.New(adapters.NewRegistry(), &exchange.Config{
+		DefaultTimeout: 100 * time.Millisecond,
+	})
+	handler := NewAuctionHandler(ex)
+
+	audit := &middleware.PrivacyAudit{Decisions: []middleware.PrivacyDecision{
+		{Action: middleware.PrivacyActionScrubbed, Regulation: "GDPR", Fields: []string{"user.eids"}},
+	}}
+
+	for _, query := range []string{"", "?diagnostics=1"} {
+		body, _ := json.Marshal(validBidRequest())
+		req := httptest.NewRequest("POST", "/openrtb2/auction"+query, bytes.NewReader(body))
+		req = req.WithContext(middleware.WithPrivacyAudit(req.Context(), audit))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		var resp openrtb.BidResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to parse response: %v", err)
+		}
+		var ext openrtb.BidResponseExt
+		if err := json.Unmarshal(resp.Ext, &ext); err != nil {
+			t.Fatalf("failed to parse ext: %v", err)
+		}
+		if query == "" {
+			if ext.Prebid != nil {
+				t.Errorf("expected no diagnostics without ?diagnostics=1, got %s", resp.Ext)
+			}
+			continue
+		}
+
+		if ext.Prebid == nil || ext.Prebid.Diagnostics == nil {
+			t.Fatalf("expected ext.prebid.diagnostics, got %s", resp.Ext)
+		}
+		diag := ext.Prebid.Diagnostics
+		if diag.Reason != exchange.NoFillNoBidders {
+			t.Errorf("expected reason %s, got %s", exchange.NoFillNoBidders, diag.Reason)
+		}
+		if !strings.Contains(string(diag.Privacy), "user.eids") {
+			t.Errorf("expected privacy decisions, got %s", diag.Privacy)
+		}
+		if ext.Errors != nil || ext.Debug != nil {
+			t.Error("expected diagnostics without debug details")
+		}
+	}
+}
+
+type mockAnalytics struct {
+	auctions      []*analytics.AuctionObject
+	cookieSyncs   []*analytics.CookieSyncObject
+	setUIDs       []*analytics.SetUIDObject
+	notifications []*analytics.NotificationEvent
+}
+
+func (m *mockAnalytics) LogAuctionObject(ao *analytics.AuctionObject) {
+	m.auctions = append(m.auctions, ao)
+}
+
+func (m *mockAnalytics) LogCookieSyncObject(co *analytics.CookieSyncObject) {
+	m.cookieSyncs = append(m.cookieSyncs, co)
+}
+
+func (m *mockAnalytics) LogSetUIDObject(so *analytics.SetUIDObject) {
+	m.setUIDs = append(m.setUIDs, so)
+}
+
+func (m *mockAnalytics) LogNotificationEvent(ne *analytics.NotificationEvent) {
+	m.notifications = append(m.notifications, ne)
+}
+
+func TestAuctionHandler_Analytics(t *testing.T) {
+	registry := adapters.NewRegistry()
+	registry.Register("testbidder", &mockAdapter{}, adapters.BidderInfo{Enabled: true})
+	ex := exchange.New(registry, &exchange.Config{
+		DefaultTimeout: 100 * time.Millisecond,
+	})
+	handler := NewAuctionHandler(ex)
+	logged := &mockAnalytics{}
+	handler.SetAnalytics(logged)
+
+	body, _ := json.Marshal(validBidRequest())
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/openrtb2/auction?account=pub-1", bytes.NewReader(body)))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/openrtb2/auction", strings.NewReader("{")))
+
+	if len(logged.auctions) != 2 {
+		t.Fatalf("expected both auctions logged, got %d", len(logged.auctions))
+	}
+	ok := logged.auctions[0]
+	if ok.Status != http.StatusOK || ok.Account != "pub-1" || ok.Request == nil || ok.Response == nil || ok.StartTime.IsZero() {
+		t.Errorf("expected the auction and its response logged, got %+v", ok)
+	}
+	if len(ok.Bidders) != 1 || ok.Bidders[0].Bidder != "testbidder" {
+		t.Errorf("expected the called bidder summarised, got %+v", ok.Bidders)
+	}
+	rejected := logged.auctions[1]
+	if rejected.Status != http.StatusBadRequest || rejected.Request != nil || len(rejected.Errors) != 1 {
+		t.Errorf("expected the rejected request logged with its error, got %+v", rejected)
+	}
+}
+
+// spanRecorder keeps exported spans
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans []*tracing.SpanData
+}
+
+func (r *spanRecorder) ExportSpan(span *tracing.SpanData) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, span)
+}
+
+func TestAuctionHandler_Tracing(t *testing.T) {
+	rec := &spanRecorder{}
+	tracing.SetTracer(tracing.NewTracer(rec, 1))
+	defer tracing.SetTracer(nil)
+
+	registry := adapters.NewRegistry()
+	registry.Register("testbidder", &mockAdapter{}, adapters.BidderInfo{Enabled: true})
+	ex := exchange.New(registry, &exchange.Config{
+		DefaultTimeout: 100 * time.Millisecond,
+	})
+	handler := NewAuctionHandler(ex)
+
+	body, _ := json.Marshal(validBidRequest())
+	req := httptest.NewRequest("POST", "/openrtb2/auction?account=pub-1", bytes.NewReader(body))
+	req.Header.Set(tracing.TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/openrtb2/auction", strings.NewReader("{")))
+
+	var roots []*tracing.SpanData
+	for _, span := range rec.spans {
+		if span.Name == "auction" {
+			roots = append(roots, span)
+		}
+	}
+	if len(roots) != 2 {
+		t.Fatalf("expected a root span per auction, got %d", len(roots))
+	}
+	traced := roots[0]
+	if traced.Kind != tracing.SpanKindServer || traced.Context.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || traced.Parent.String() != "00f067aa0ba902b7" {
+		t.Errorf("expected the caller's trace continued, got %+v", traced)
+	}
+	for _, span := range rec.spans {
+		if span != traced && span.Context.TraceID == traced.Context.TraceID && span.Parent == (tracing.SpanID{}) {
+			t.Errorf("expected %s parented within the auction", span.Name)
+		}
+	}
+	if rejected := roots[1]; !rejected.Failed || rejected.Context.TraceID == traced.Context.TraceID {
+		t.Errorf("expected the rejected request as a failed span in a new trace, got %+v", rejected)
+	}
+}
+
 // P2-1: Test debug mode authentication requirements
 func TestAuctionHandler_DebugMode_RequiresAuth(t *testing.T) {
 	registry := adapters.NewRegistry()
@@ -341,6 +568,59 @@ func TestAuctionHandler_DebugMode_RequiresAuth(t *testing.T) {
 	// (Ext may still be nil or empty since debug was disabled)
 }
 
+func TestAuctionHandler_PrebidDebugHTTPCalls(t *testing.T) {
+	registry := adapters.NewRegistry()
+	registry.Register("testbidder", &mockAdapter{}, adapters.BidderInfo{Enabled: true})
+	ex := exchange.New(registry, &exchange.Config{
+		DefaultTimeout: 100 * time.Millisecond,
+	})
+	handler := NewAuctionHandler(ex)
+
+	for _, tt := range []struct {
+		name   string
+		ext    string
+		test   int
+		apiKey bool
+		want   bool
+	}{
+		{name: "test=1", test: 1, apiKey: true, want: true},
+		{name: "ext.prebid.debug", ext: `{"prebid":{"debug":true}}`, apiKey: true, want: true},
+		{name: "without API key", test: 1},
+		{name: "not requested", apiKey: true},
+	} {
+		bidReq := validBidRequest()
+		bidReq.Test = tt.test
+		if tt.ext != "" {
+			bidReq.Ext = json.RawMessage(tt.ext)
+		}
+		body, _ := json.Marshal(bidReq)
+		req := httptest.NewRequest("POST", "/openrtb2/auction", bytes.NewReader(body))
+		if tt.apiKey {
+			req.Header.Set("X-API-Key", "test-key")
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		var resp openrtb.BidResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s: failed to parse response: %v", tt.name, err)
+		}
+		var ext openrtb.BidResponseExt
+		if len(resp.Ext) > 0 {
+			if err := json.Unmarshal(resp.Ext, &ext); err != nil {
+				t.Fatalf("%s: failed to parse ext: %v", tt.name, err)
+			}
+		}
+		got := ext.Debug != nil && len(ext.Debug.HTTPCalls["testbidder"]) == 1
+		if got != tt.want {
+			t.Errorf("%s: expected httpcalls %v, got ext %s", tt.name, tt.want, resp.Ext)
+		}
+		if got && ext.Debug.HTTPCalls["testbidder"][0].URI != "http://test.com" {
+			t.Errorf("%s: expected the bidder call described, got %+v", tt.name, ext.Debug.HTTPCalls)
+		}
+	}
+}
+
 func TestAuctionHandler_DebugMode_WithAPIKey(t *testing.T) {
 	registry := adapters.NewRegistry()
 	ex := exchange.New(registry, &exchange.Config{
@@ -642,8 +922,25 @@ func TestBuildResponseExt_WithLatencies(t *testing.T) {
 	if ext.ResponseTimeMillis["bidder2"] != 100 {
 		t.Errorf("expected bidder2 latency 100, got %d", ext.ResponseTimeMillis["bidder2"])
 	}
-	if ext.TMMaxRequest != 150 {
-		t.Errorf("expected TMMaxRequest 150, got %d", ext.TMMaxRequest)
+}
+
+func TestBuildResponseExt_WithBidLandscape(t *testing.T) {
+	result := &exchange.AuctionResponse{
+		DebugInfo: &exchange.DebugInfo{
+			BidLandscape: map[string][]openrtb.ExtLandscapeBid{
+				"imp1": {{Bidder: "bidder1", BidID: "b1", Price: 1.5, AdjustedPrice: 1.5, Rank: 1, Status: exchange.LandscapeWon}},
+			},
+		},
+	}
+	ext := buildResponseExt(result)
+
+	if ext.Debug == nil || len(ext.Debug.BidLandscape["imp1"]) != 1 {
+		t.Fatalf("expected bid landscape in ext.debug, got %+v", ext.Debug)
+	}
+
+	// No landscape, no debug object
+	if ext := buildResponseExt(&exchange.AuctionResponse{DebugInfo: &exchange.DebugInfo{}}); ext.Debug != nil {
+		t.Errorf("expected nil ext.debug without landscape, got %+v", ext.Debug)
 	}
 }
 
@@ -670,6 +967,84 @@ func TestBuildResponseExt_WithErrors(t *testing.T) {
 	}
 }
 
+func TestBuildResponseExt_WithErrorCounts(t *testing.T) {
+	paramErr, timeoutErr := errors.New("missing placement_id"), context.DeadlineExceeded
+	result := &exchange.AuctionResponse{
+		DebugInfo: &exchange.DebugInfo{},
+		BidderResults: map[string]*exchange.BidderResult{
+			"bidder1": {Errors: []error{paramErr, timeoutErr}, InputErrors: []error{paramErr}, TransportErrors: []error{timeoutErr}},
+			"bidder2": {},
+		},
+	}
+	ext := buildResponseExt(result)
+
+	if len(ext.ErrorCounts) != 1 {
+		t.Fatalf("expected counts for the erroring bidder only, got %+v", ext.ErrorCounts)
+	}
+	if got := ext.ErrorCounts["bidder1"]; got != (openrtb.ExtBidderErrorCounts{Input: 1, Transport: 1}) {
+		t.Errorf("unexpected counts %+v", got)
+	}
+}
+
+func TestStandardResponseExt_ErrorsWithoutMessages(t *testing.T) {
+	paramErr, timeoutErr, connErr, bidsErr := errors.New("missing placement_id"), fmt.Errorf("call: %w", context.DeadlineExceeded), errors.New("connection refused"), errors.New("bad json")
+	result := &exchange.AuctionResponse{
+		DebugInfo: &exchange.DebugInfo{
+			Errors:          map[string][]string{"bidder1": {"missing placement_id"}},
+			BidderLatencies: map[string]time.Duration{"bidder1": 40 * time.Millisecond},
+			Throttled:       []string{"bidder2"},
+		},
+		BidderResults: map[string]*exchange.BidderResult{
+			"bidder1": {
+				Errors:          []error{paramErr, timeoutErr, connErr, bidsErr},
+				InputErrors:     []error{paramErr},
+				TransportErrors: []error{timeoutErr, connErr},
+				ResponseErrors:  []error{bidsErr},
+			},
+		},
+	}
+	ext := standardResponseExt(result)
+
+	var codes []int
+	for _, m := range ext.Errors["bidder1"] {
+		codes = append(codes, m.Code)
+		if strings.Contains(m.Message, "placement_id") || strings.Contains(m.Message, "refused") {
+			t.Errorf("expected bidder error text to stay debug-only, got %q", m.Message)
+		}
+	}
+	want := []int{errorCodeBadInput, errorCodeTimeout, errorCodeFailedToRequestBids, errorCodeBadServerResponse}
+	if !slices.Equal(codes, want) {
+		t.Errorf("expected codes %v, got %v", want, codes)
+	}
+	if ext.ResponseTimeMillis["bidder1"] != 40 || len(ext.Warnings["bidder2"]) != 1 || ext.ErrorCounts["bidder1"].Transport != 2 {
+		t.Errorf("expected timing, warnings and error counts, got %+v", ext)
+	}
+
+	// Debug swaps in the bidders' own messages
+	if debugExt := buildResponseExt(result); debugExt.Errors["bidder1"][0].Message != "missing placement_id" {
+		t.Errorf("expected raw messages in debug, got %+v", debugExt.Errors)
+	}
+}
+
+func TestBuildResponseExt_WithPrivacyWarnings(t *testing.T) {
+	result := &exchange.AuctionResponse{
+		DebugInfo: &exchange.DebugInfo{
+			SelectedBidders:  []string{"stripped"},
+			ConsentDenied:    []string{"skipped", "stripped"},
+			COPPAUnsupported: []string{"stripped"},
+		},
+	}
+	ext := buildResponseExt(result)
+
+	skipped, stripped := ext.Warnings["skipped"], ext.Warnings["stripped"]
+	if len(skipped) != 1 || skipped[0].Code != privacyWarningCode || !strings.HasPrefix(skipped[0].Message, "not called") {
+		t.Errorf("expected a not-called warning, got %+v", skipped)
+	}
+	if len(stripped) != 2 || !strings.HasPrefix(stripped[0].Message, "called without personal data") || !strings.Contains(stripped[1].Message, "COPPA") {
+		t.Errorf("expected stripped and COPPA warnings, got %+v", stripped)
+	}
+}
+
 // Test writeError
 func TestWriteError(t *testing.T) {
 	w := httptest.NewRecorder()
@@ -927,6 +1302,33 @@ func TestInfoBiddersHandler_NilRegistries(t *testing.T) {
 	}
 }
 
+func TestInfoBiddersHandler_EnabledOnly(t *testing.T) {
+	static := adapters.NewRegistry()
+	static.Register("enabled", nil, adapters.BidderInfo{Enabled: true})
+	static.Register("disabled", nil, adapters.BidderInfo{Enabled: false})
+	dynamic := &mockDynamicRegistry{bidders: []string{"dynamic1"}}
+	handler := NewDynamicInfoBiddersHandler(static, dynamic)
+
+	list := func(target string) (int, []string) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+		var bidders []string
+		json.Unmarshal(w.Body.Bytes(), &bidders)
+		return w.Code, bidders
+	}
+
+	if _, bidders := list("/info/bidders"); len(bidders) != 3 {
+		t.Errorf("expected all 3 bidders by default, got %v", bidders)
+	}
+	// The mock dynamic registry can't filter, so its bidders are all listed
+	if _, bidders := list("/info/bidders?enabledonly=true"); len(bidders) != 2 || slices.Contains(bidders, "disabled") {
+		t.Errorf("expected the disabled bidder left out, got %v", bidders)
+	}
+	if code, _ := list("/info/bidders?enabledonly=maybe"); code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid enabledonly, got %d", code)
+	}
+}
+
 func TestInfoBiddersHandler_ContentType(t *testing.T) {
 	handler := NewDynamicInfoBiddersHandler(nil, nil)
 
@@ -1031,3 +1433,79 @@ func BenchmarkAuctionHandler_ValidRequest(b *testing.B) {
 		handler.ServeHTTP(w, req)
 	}
 }
+
+func TestAddPrivacyWarnings_GDPRInference(t *testing.T) {
+	ext := &openrtb.BidResponseExt{}
+	addPrivacyWarnings(context.Background(), ext)
+	if ext.Warnings != nil {
+		t.Errorf("expected no warnings without inference, got %v", ext.Warnings)
+	}
+
+	ctx := middleware.WithGDPRInference(context.Background(), &middleware.GDPRInference{Country: "DEU"})
+	addPrivacyWarnings(ctx, ext)
+	warnings := ext.Warnings["privacy"]
+	if len(warnings) != 1 || !strings.Contains(warnings[0].Message, "DEU") || strings.Contains(warnings[0].Message, "device IP") {
+		t.Errorf("expected privacy warning mentioning DEU, got %v", warnings)
+	}
+
+	ext = &openrtb.BidResponseExt{}
+	ctx = middleware.WithGDPRInference(context.Background(), &middleware.GDPRInference{Country: "FRA", FromIP: true})
+	addPrivacyWarnings(ctx, ext)
+	if warnings := ext.Warnings["privacy"]; len(warnings) != 1 || !strings.HasSuffix(warnings[0].Message, "FRA (resolved from device IP)") {
+		t.Errorf("expected the warning to say the country came from the IP, got %v", warnings)
+	}
+}
+
+func TestAddPrivacyAudit(t *testing.T) {
+	ext := &openrtb.BidResponseExt{}
+	addPrivacyAudit(context.Background(), ext)
+	if ext.Prebid != nil {
+		t.Errorf("expected no ext.prebid without an audit, got %+v", ext.Prebid)
+	}
+
+	audit := &middleware.PrivacyAudit{
+		Regulations: []middleware.RegulationAudit{{Regulation: "GDPR", Outcome: middleware.PrivacyOutcomeAllowed}},
+		Decisions:   []middleware.PrivacyDecision{{Action: middleware.PrivacyActionScrubbed, Regulation: "GDPR", Fields: []string{"device.ip"}}},
+	}
+	addPrivacyAudit(middleware.WithPrivacyAudit(context.Background(), audit), ext)
+
+	var got middleware.PrivacyAudit
+	if ext.Prebid == nil || json.Unmarshal(ext.Prebid.Privacy, &got) != nil {
+		t.Fatalf("expected ext.prebid.privacy, got %+v", ext.Prebid)
+	}
+	if len(got.Regulations) != 1 || got.Decisions[0].Fields[0] != "device.ip" {
+		t.Errorf("unexpected audit %+v", got)
+	}
+}
+
+func TestAuctionAudit(t *testing.T) {
+	ao := &analytics.AuctionObject{
+		Status:    http.StatusOK,
+		Account:   "acct",
+		Request:   &openrtb.BidRequest{ID: "req-1"},
+		StartTime: time.Now().Add(-50 * time.Millisecond),
+		Response: &openrtb.BidResponse{
+			Cur: "USD",
+			SeatBid: []openrtb.SeatBid{
+				{Seat: "appnexus", Bid: []openrtb.Bid{{ID: "a1", Price: 1.2}}},
+				{Seat: "rubicon", Bid: []openrtb.Bid{{ID: "r1", Price: 2.5}, {ID: "r2", Price: 0.4}}},
+			},
+		},
+	}
+	audit := auctionAudit(ao, []string{"appnexus", "rubicon"})
+
+	if audit.RequestID != "req-1" || audit.Account != "acct" || audit.Bids != 3 {
+		t.Errorf("unexpected audit %+v", audit)
+	}
+	if audit.WinningBidder != "rubicon" || audit.WinningBidID != "r1" || audit.WinningPrice != 2.5 || audit.Currency != "USD" {
+		t.Errorf("expected rubicon r1 at 2.5 USD to win, got %+v", audit)
+	}
+	if audit.Latency < 50*time.Millisecond {
+		t.Errorf("expected latency from the start time, got %v", audit.Latency)
+	}
+
+	rejected := auctionAudit(&analytics.AuctionObject{Status: http.StatusBadRequest, Errors: []string{"bad"}, StartTime: time.Now()}, nil)
+	if rejected.Error != "bad" || rejected.WinningBidID != "" || !rejected.Failed() {
+		t.Errorf("unexpected audit for rejected auction %+v", rejected)
+	}
+}
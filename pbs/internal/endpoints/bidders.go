@@ -0,0 +1,315 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters/ortb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/logger"
+)
+
+// Where a registered bidder comes from
+const (
+	BidderSourceStatic  = "static"  // Compiled-in adapter
+	BidderSourceDynamic = "dynamic" // Redis-configured OpenRTB bidder
+)
+
+// redacted replaces credentials in admin output
+const redacted = "[redacted]"
+
+// maxBidderStatusRequestSize bounds admin bidder status request bodies
+const maxBidderStatusRequestSize = 4096
+
+// RegisteredBidder is one bidder as the registries know it
+type RegisteredBidder struct {
+	Code   string
+	Source string
+	Info   adapters.BidderInfo
+	Config *ortb.BidderConfig // Dynamic bidders only
+}
+
+// BidderFinder looks a bidder up by code
+type BidderFinder func(code string) (RegisteredBidder, bool)
+
+// RegistryBidders finds bidders in the dynamic registry first, then the static
+// one, so a dynamic config that overrides a static adapter is what's reported.
+// dynamic may be nil.
+func RegistryBidders(static *adapters.Registry, dynamic *ortb.DynamicRegistry) BidderFinder {
+	return func(code string) (RegisteredBidder, bool) {
+		if dynamic != nil {
+			if adapter, ok := dynamic.Get(code); ok {
+				info := adapter.Info()
+				info.DemandType = adapter.GetDemandType()
+				return RegisteredBidder{Code: code, Source: BidderSourceDynamic, Info: info, Config: adapter.GetConfig()}, true
+			}
+		}
+		if static != nil {
+			if awi, ok := static.Get(code); ok {
+				return RegisteredBidder{Code: code, Source: BidderSourceStatic, Info: awi.Info}, true
+			}
+		}
+		return RegisteredBidder{}, false
+	}
+}
+
+// BidderInfoResponse is the public description of a bidder
+type BidderInfoResponse struct {
+	Status       string                    `json:"status"` // ACTIVE or DISABLED
+	DemandType   string                    `json:"demandType,omitempty"`
+	Maintainer   *BidderMaintainerResponse `json:"maintainer,omitempty"`
+	Capabilities *BidderCapabilities       `json:"capabilities,omitempty"`
+	GVLVendorID  int                       `json:"gvlVendorId,omitempty"`
+}
+
+// BidderMaintainerResponse is who maintains a bidder
+type BidderMaintainerResponse struct {
+	Email string `json:"email"`
+}
+
+// BidderCapabilities lists the media types a bidder accepts per platform
+type BidderCapabilities struct {
+	App  *BidderPlatform `json:"app,omitempty"`
+	Site *BidderPlatform `json:"site,omitempty"`
+}
+
+// BidderPlatform lists a platform's media types
+type BidderPlatform struct {
+	MediaTypes []adapters.BidType `json:"mediaTypes"`
+}
+
+// newBidderInfoResponse builds the public description from a bidder's info
+func newBidderInfoResponse(info adapters.BidderInfo) BidderInfoResponse {
+	resp := BidderInfoResponse{
+		Status:      bidderStatus(info.Enabled),
+		DemandType:  string(info.DemandType),
+		GVLVendorID: info.GVLVendorID,
+	}
+	if info.Maintainer != nil && info.Maintainer.Email != "" {
+		resp.Maintainer = &BidderMaintainerResponse{Email: info.Maintainer.Email}
+	}
+	if c := info.Capabilities; c != nil && (c.App != nil || c.Site != nil) {
+		resp.Capabilities = &BidderCapabilities{}
+		if c.App != nil {
+			resp.Capabilities.App = &BidderPlatform{MediaTypes: c.App.MediaTypes}
+		}
+		if c.Site != nil {
+			resp.Capabilities.Site = &BidderPlatform{MediaTypes: c.Site.MediaTypes}
+		}
+	}
+	return resp
+}
+
+// bidderStatus is the public status for a bidder's enabled flag
+func bidderStatus(enabled bool) string {
+	if enabled {
+		return "ACTIVE"
+	}
+	return "DISABLED"
+}
+
+// InfoBidderHandler handles /info/bidders/{name}: one bidder's public details
+type InfoBidderHandler struct {
+	find BidderFinder
+}
+
+// NewInfoBidderHandler creates a bidder details handler
+func NewInfoBidderHandler(find BidderFinder) *InfoBidderHandler {
+	return &InfoBidderHandler{find: find}
+}
+
+// ServeHTTP handles info/bidders/{name} requests
+func (h *InfoBidderHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bidder, ok := h.find(r.PathValue("name"))
+	if !ok {
+		http.Error(w, `{"error":"unknown bidder"}`, http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(newBidderInfoResponse(bidder.Info)); err != nil {
+		logger.Log.Error().Err(err).Msg("failed to encode bidder info response")
+	}
+}
+
+// InfoBidderParamsHandler handles /info/bidders/{name}/params: the JSON
+// schema a bidder's imp.ext params are validated against
+type InfoBidderParamsHandler struct {
+	find BidderFinder
+}
+
+// NewInfoBidderParamsHandler creates a bidder params schema handler
+func NewInfoBidderParamsHandler(find BidderFinder) *InfoBidderParamsHandler {
+	return &InfoBidderParamsHandler{find: find}
+}
+
+// ServeHTTP handles info/bidders/{name}/params requests
+func (h *InfoBidderParamsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bidder, ok := h.find(r.PathValue("name"))
+	if !ok {
+		http.Error(w, `{"error":"unknown bidder"}`, http.StatusNotFound)
+		return
+	}
+	if len(bidder.Info.ParamsSchema) == 0 {
+		http.Error(w, `{"error":"bidder has no params schema"}`, http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(bidder.Info.ParamsSchema); err != nil {
+		logger.Log.Error().Err(err).Msg("failed to write bidder params schema")
+	}
+}
+
+// AdminBidderResponse is the operator view of a bidder
+type AdminBidderResponse struct {
+	Code                 string             `json:"code"`
+	Source               string             `json:"source"`
+	Info                 BidderInfoResponse `json:"info"`
+	Endpoint             string             `json:"endpoint,omitempty"`
+	MaxImpsPerRequest    int                `json:"max_imps_per_request,omitempty"`
+	IgnoredFields        []string           `json:"ignored_fields,omitempty"`
+	UnsupportedInventory []string           `json:"unsupported_inventory,omitempty"`
+	EIDSources           []string           `json:"eid_sources,omitempty"`
+	Config               *ortb.BidderConfig `json:"config,omitempty"` // Dynamic bidders, credentials redacted
+}
+
+// AdminBidderHandler handles /admin/bidders/{code}: one bidder's full
+// configuration, for operators checking what the server is actually running
+type AdminBidderHandler struct {
+	find BidderFinder
+}
+
+// NewAdminBidderHandler creates an admin bidder handler
+func NewAdminBidderHandler(find BidderFinder) *AdminBidderHandler {
+	return &AdminBidderHandler{find: find}
+}
+
+// ServeHTTP handles admin/bidders/{code} requests
+func (h *AdminBidderHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bidder, ok := h.find(r.PathValue("code"))
+	if !ok {
+		http.Error(w, `{"error":"unknown bidder"}`, http.StatusNotFound)
+		return
+	}
+	resp := AdminBidderResponse{
+		Code:                 bidder.Code,
+		Source:               bidder.Source,
+		Info:                 newBidderInfoResponse(bidder.Info),
+		Endpoint:             bidder.Info.Endpoint,
+		MaxImpsPerRequest:    bidder.Info.MaxImpsPerRequest,
+		IgnoredFields:        bidder.Info.IgnoredFields,
+		UnsupportedInventory: bidder.Info.UnsupportedInventory,
+		EIDSources:           bidder.Info.EIDSources,
+		Config:               redactBidderConfig(bidder.Config),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logger.Log.Error().Err(err).Msg("failed to encode admin bidder response")
+	}
+}
+
+// AdminBidderStatus is a static bidder's runtime status
+type AdminBidderStatus struct {
+	Code   string `json:"code"`
+	Status string `json:"status"` // ACTIVE or DISABLED
+}
+
+// BidderStatusUpdate is the body accepted by PUT /admin/bidders/{code}
+type BidderStatusUpdate struct {
+	Enabled *bool  `json:"enabled"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// AdminBiddersHandler handles runtime enabling and disabling of static
+// bidders, so a misbehaving adapter can be switched off without a redeploy.
+// Changes last until the next restart.
+//
+//	GET /admin/bidders         list each static bidder's status
+//	PUT /admin/bidders/{code}  enable or disable one: {"enabled","reason"}
+type AdminBiddersHandler struct {
+	registry *adapters.Registry
+}
+
+// NewAdminBiddersHandler creates a static bidder status handler
+func NewAdminBiddersHandler(registry *adapters.Registry) *AdminBiddersHandler {
+	return &AdminBiddersHandler{registry: registry}
+}
+
+// ServeHTTP handles admin/bidders status requests
+func (h *AdminBiddersHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, h.list())
+
+	case http.MethodPut:
+		code := r.PathValue("code")
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxBidderStatusRequestSize))
+		if err != nil {
+			writeError(w, "Failed to read request body", http.StatusBadRequest)
+			return
+		}
+		var req BidderStatusUpdate
+		if err := json.Unmarshal(body, &req); err != nil {
+			writeError(w, "Invalid JSON in request body", http.StatusBadRequest)
+			return
+		}
+		if req.Enabled == nil {
+			writeError(w, "enabled is required", http.StatusBadRequest)
+			return
+		}
+
+		if err := h.registry.SetEnabled(code, *req.Enabled); err != nil {
+			if errors.Is(err, adapters.ErrNotRegistered) {
+				writeError(w, "unknown bidder", http.StatusNotFound)
+				return
+			}
+			writeError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		logger.Log.Info().
+			Str("bidder", code).
+			Bool("enabled", *req.Enabled).
+			Str("actor", flagActor(r)).
+			Str("reason", req.Reason).
+			Msg("Bidder status changed")
+		writeJSON(w, AdminBidderStatus{Code: code, Status: bidderStatus(*req.Enabled)})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// list returns every static bidder's status, sorted by code
+func (h *AdminBiddersHandler) list() []AdminBidderStatus {
+	all := h.registry.GetAll()
+	statuses := make([]AdminBidderStatus, 0, len(all))
+	for code, awi := range all {
+		statuses = append(statuses, AdminBidderStatus{Code: code, Status: bidderStatus(awi.Info.Enabled)})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Code < statuses[j].Code })
+	return statuses
+}
+
+// redactBidderConfig returns a copy of config with its credentials and custom
+// header values replaced, since admin output ends up in terminals and tickets
+func redactBidderConfig(config *ortb.BidderConfig) *ortb.BidderConfig {
+	if config == nil {
+		return nil
+	}
+	c := *config
+	for _, secret := range []*string{&c.Endpoint.AuthPassword, &c.Endpoint.AuthToken, &c.Endpoint.AuthHeaderValue} {
+		if *secret != "" {
+			*secret = redacted
+		}
+	}
+	if len(c.Endpoint.CustomHeaders) > 0 {
+		headers := make(map[string]string, len(c.Endpoint.CustomHeaders))
+		for name := range c.Endpoint.CustomHeaders {
+			headers[name] = redacted
+		}
+		c.Endpoint.CustomHeaders = headers
+	}
+	return &c
+}
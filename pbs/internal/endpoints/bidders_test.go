@@ -0,0 +1,183 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters/ortb"
+)
+
+// bidderRequest builds a request with the path parameter the router would set
+func bidderRequest(param, code string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/bidders/"+code, nil)
+	req.SetPathValue(param, code)
+	return req
+}
+
+func TestInfoBidderHandler(t *testing.T) {
+	registry := adapters.NewRegistry()
+	registry.Register("appnexus", nil, adapters.BidderInfo{
+		Enabled:      true,
+		Maintainer:   &adapters.MaintainerInfo{Email: "prebid@example.com"},
+		Capabilities: &adapters.CapabilitiesInfo{Site: &adapters.PlatformInfo{MediaTypes: []adapters.BidType{adapters.BidTypeBanner}}},
+		GVLVendorID:  32,
+		DemandType:   adapters.DemandTypePublisher,
+		Endpoint:     "https://internal.example.com/bid",
+	})
+	handler := NewInfoBidderHandler(RegistryBidders(registry, nil))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, bidderRequest("name", "appnexus"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var resp BidderInfoResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if resp.Status != "ACTIVE" || resp.GVLVendorID != 32 || resp.DemandType != "publisher" || resp.Maintainer.Email != "prebid@example.com" {
+		t.Errorf("unexpected bidder info %+v", resp)
+	}
+	if resp.Capabilities == nil || resp.Capabilities.App != nil || len(resp.Capabilities.Site.MediaTypes) != 1 {
+		t.Errorf("expected site banner capabilities, got %+v", resp.Capabilities)
+	}
+	if strings.Contains(w.Body.String(), "internal.example.com") {
+		t.Error("expected the public view to leave out the endpoint")
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, bidderRequest("name", "unknown"))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown bidder, got %d", w.Code)
+	}
+}
+
+func TestInfoBidderParamsHandler(t *testing.T) {
+	schema := `{"type":"object","properties":{"placementId":{"type":"string"}},"required":["placementId"]}`
+	registry := adapters.NewRegistry()
+	registry.Register("withparams", nil, adapters.BidderInfo{Enabled: true, ParamsSchema: json.RawMessage(schema)})
+	registry.Register("noparams", nil, adapters.BidderInfo{Enabled: true})
+	handler := NewInfoBidderParamsHandler(RegistryBidders(registry, nil))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, bidderRequest("name", "withparams"))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("expected 200 JSON, got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if w.Body.String() != schema {
+		t.Errorf("expected the schema, got %s", w.Body.String())
+	}
+
+	for _, code := range []string{"noparams", "unknown"} {
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, bidderRequest("name", code))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s: expected 404, got %d", code, w.Code)
+		}
+	}
+}
+
+func TestAdminBidderHandler(t *testing.T) {
+	config := &ortb.BidderConfig{
+		BidderCode: "custom",
+		Status:     "active",
+		Endpoint: ortb.EndpointConfig{
+			URL:           "https://custom.example.com/bid",
+			AuthType:      "bearer",
+			AuthToken:     "secret-token",
+			CustomHeaders: map[string]string{"X-Api-Key": "secret-key"},
+		},
+	}
+	find := func(code string) (RegisteredBidder, bool) {
+		if code != "custom" {
+			return RegisteredBidder{}, false
+		}
+		info := adapters.BidderInfo{Enabled: true, Endpoint: config.Endpoint.URL}
+		return RegisteredBidder{Code: code, Source: BidderSourceDynamic, Info: info, Config: config}, true
+	}
+	handler := NewAdminBidderHandler(find)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, bidderRequest("code", "custom"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var resp AdminBidderResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if resp.Source != BidderSourceDynamic || resp.Endpoint != "https://custom.example.com/bid" || resp.Config == nil {
+		t.Errorf("unexpected admin view %+v", resp)
+	}
+	if strings.Contains(w.Body.String(), "secret") {
+		t.Errorf("expected credentials redacted, got %s", w.Body.String())
+	}
+	if resp.Config.Endpoint.AuthType != "bearer" || resp.Config.Endpoint.CustomHeaders["X-Api-Key"] != redacted {
+		t.Errorf("expected non-secret fields kept and header values redacted, got %+v", resp.Config.Endpoint)
+	}
+	if config.Endpoint.AuthToken != "secret-token" {
+		t.Error("expected the live config left untouched")
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, bidderRequest("code", "missing"))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown bidder, got %d", w.Code)
+	}
+}
+
+func TestAdminBiddersHandler(t *testing.T) {
+	registry := adapters.NewRegistry()
+	registry.Register("beta", nil, adapters.BidderInfo{Enabled: true})
+	registry.Register("alpha", nil, adapters.BidderInfo{Enabled: false})
+	handler := NewAdminBiddersHandler(registry)
+	infoHandler := NewInfoBidderHandler(RegistryBidders(registry, nil))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/bidders", nil))
+	var list []AdminBidderStatus
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if len(list) != 2 || list[0] != (AdminBidderStatus{Code: "alpha", Status: "DISABLED"}) || list[1] != (AdminBidderStatus{Code: "beta", Status: "ACTIVE"}) {
+		t.Errorf("unexpected bidder list %+v", list)
+	}
+
+	put := func(code, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/admin/bidders/"+code, strings.NewReader(body))
+		req.SetPathValue("code", code)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w = put("beta", `{"enabled":false,"reason":"timing out"}`)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"status":"DISABLED"`) {
+		t.Fatalf("expected beta disabled, got %d %s", w.Code, w.Body.String())
+	}
+	if enabled := registry.ListEnabledBidders(); len(enabled) != 0 {
+		t.Errorf("expected no enabled bidders, got %v", enabled)
+	}
+	w = httptest.NewRecorder()
+	infoHandler.ServeHTTP(w, bidderRequest("name", "beta"))
+	if !strings.Contains(w.Body.String(), `"status":"DISABLED"`) {
+		t.Errorf("expected /info/bidders/beta to show the change, got %s", w.Body.String())
+	}
+
+	for _, tt := range []struct {
+		code, body string
+		want       int
+	}{
+		{"unknown", `{"enabled":true}`, http.StatusNotFound},
+		{"beta", `{}`, http.StatusBadRequest},
+		{"beta", `{"enabled":"yes"}`, http.StatusBadRequest},
+	} {
+		if w := put(tt.code, tt.body); w.Code != tt.want {
+			t.Errorf("%s %s: expected %d, got %d", tt.code, tt.body, tt.want, w.Code)
+		}
+	}
+}
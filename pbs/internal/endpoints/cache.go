@@ -0,0 +1,101 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/invalidation"
+
+	log "github.com/rs/zerolog/log"
+)
+
+// maxCacheRequestSize bounds admin cache invalidation request bodies
+const maxCacheRequestSize = 16 * 1024
+
+// CacheInvalidationHandler handles /admin/cache/invalidate requests
+//
+//	GET  /admin/cache/invalidate  list caches that can be invalidated
+//	POST /admin/cache/invalidate  invalidate: {"cache","patterns"}
+type CacheInvalidationHandler struct {
+	manager *invalidation.Manager
+}
+
+// NewCacheInvalidationHandler creates a new cache invalidation admin handler
+func NewCacheInvalidationHandler(manager *invalidation.Manager) *CacheInvalidationHandler {
+	return &CacheInvalidationHandler{manager: manager}
+}
+
+// CacheInvalidationRequest is the body accepted by POST /admin/cache/invalidate
+type CacheInvalidationRequest struct {
+	Cache    string   `json:"cache,omitempty"`    // Cache name; empty or "*" means all caches
+	Patterns []string `json:"patterns,omitempty"` // Glob patterns over cache keys; empty means every key
+}
+
+// CacheInvalidationResponse reports what this instance dropped; other instances
+// apply the same invalidation when they receive the broadcast
+type CacheInvalidationResponse struct {
+	Cache    string         `json:"cache"`
+	Patterns []string       `json:"patterns,omitempty"`
+	Dropped  map[string]int `json:"dropped"`
+}
+
+// ServeHTTP handles cache invalidation requests
+func (h *CacheInvalidationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, map[string][]string{"caches": h.manager.Caches()})
+
+	case http.MethodPost:
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxCacheRequestSize))
+		if err != nil {
+			writeError(w, "Failed to read request body", http.StatusBadRequest)
+			return
+		}
+
+		var req CacheInvalidationRequest
+		if len(body) > 0 {
+			if err := json.Unmarshal(body, &req); err != nil {
+				writeError(w, "Invalid JSON in request body", http.StatusBadRequest)
+				return
+			}
+		}
+		if req.Cache == "" {
+			req.Cache = invalidation.AllCaches
+		}
+		if req.Cache != invalidation.AllCaches && !h.known(req.Cache) {
+			writeError(w, "unknown cache: "+req.Cache, http.StatusBadRequest)
+			return
+		}
+		if err := invalidation.ValidatePatterns(req.Patterns); err != nil {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		dropped, err := h.manager.Invalidate(r.Context(), req.Cache, req.Patterns)
+		if err != nil {
+			log.Error().Err(err).Str("cache", req.Cache).Msg("cache invalidation failed")
+			writeError(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		log.Info().
+			Str("cache", req.Cache).
+			Strs("patterns", req.Patterns).
+			Str("actor", flagActor(r)).
+			Interface("dropped", dropped).
+			Msg("Cache invalidated")
+		writeJSON(w, CacheInvalidationResponse{Cache: req.Cache, Patterns: req.Patterns, Dropped: dropped})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *CacheInvalidationHandler) known(cache string) bool {
+	for _, name := range h.manager.Caches() {
+		if name == cache {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package endpoints
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/invalidation"
+)
+
+func newTestCacheHandler(calls map[string][]string) *CacheInvalidationHandler {
+	m := invalidation.NewManager()
+	for _, name := range []string{"idr_selection", "feature_flags"} {
+		name := name
+		m.Register(name, func(_ context.Context, patterns []string) (int, error) {
+			calls[name] = patterns
+			return 3, nil
+		})
+	}
+	return NewCacheInvalidationHandler(m)
+}
+
+func TestCacheInvalidationHandler_ListCaches(t *testing.T) {
+	h := newTestCacheHandler(map[string][]string{})
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/cache/invalidate", nil))
+
+	var resp map[string][]string
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if got := strings.Join(resp["caches"], ","); got != "feature_flags,idr_selection" {
+		t.Errorf("unexpected caches %q", got)
+	}
+}
+
+func TestCacheInvalidationHandler_Invalidate(t *testing.T) {
+	calls := map[string][]string{}
+	h := newTestCacheHandler(calls)
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/cache/invalidate",
+		strings.NewReader(`{"cache":"idr_selection","patterns":["pub-*"]}`))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp CacheInvalidationResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if resp.Dropped["idr_selection"] != 3 || len(resp.Dropped) != 1 {
+		t.Errorf("unexpected dropped counts %v", resp.Dropped)
+	}
+	if _, ok := calls["feature_flags"]; ok || len(calls["idr_selection"]) != 1 {
+		t.Errorf("expected only idr_selection invalidated, got %v", calls)
+	}
+
+	// An empty body invalidates everything
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/cache/invalidate", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, ok := calls["feature_flags"]; !ok {
+		t.Error("expected feature_flags invalidated by empty request")
+	}
+}
+
+func TestCacheInvalidationHandler_BadRequests(t *testing.T) {
+	h := newTestCacheHandler(map[string][]string{})
+
+	for _, body := range []string{`{"cache":`, `{"cache":"stored_requests"}`, `{"patterns":["["]}`} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/cache/invalidate", strings.NewReader(body)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/admin/cache/invalidate", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", w.Code)
+	}
+}
@@ -0,0 +1,37 @@
+package endpoints
+
+import (
+	"net/http"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/config"
+
+	log "github.com/rs/zerolog/log"
+)
+
+// ConfigReloader re-reads the config file and applies its live settings
+type ConfigReloader interface {
+	Reload() (*config.ReloadResult, error)
+}
+
+// ConfigReloadHandler handles POST /admin/config/reload, which does what
+// SIGHUP does: apply the config file's live settings without a restart
+type ConfigReloadHandler struct {
+	reloader ConfigReloader
+}
+
+// NewConfigReloadHandler creates a new config reload admin handler
+func NewConfigReloadHandler(reloader ConfigReloader) *ConfigReloadHandler {
+	return &ConfigReloadHandler{reloader: reloader}
+}
+
+// ServeHTTP reloads the config, reporting what changed. An invalid file is
+// rejected as a whole and leaves the running config alone.
+func (h *ConfigReloadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Info().Str("actor", flagActor(r)).Msg("Config reload requested")
+	result, err := h.reloader.Reload()
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, result)
+}
@@ -0,0 +1,59 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/config"
+)
+
+type fakeReloader struct {
+	result *config.ReloadResult
+	err    error
+	calls  int
+}
+
+func (f *fakeReloader) Reload() (*config.ReloadResult, error) {
+	f.calls++
+	return f.result, f.err
+}
+
+func TestConfigReloadHandler(t *testing.T) {
+	reloader := &fakeReloader{result: &config.ReloadResult{
+		Applied:         []string{"exchange.timeout"},
+		RestartRequired: []string{"server.port"},
+	}}
+	h := NewConfigReloadHandler(reloader)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/config/reload", nil))
+
+	if w.Code != http.StatusOK || reloader.calls != 1 {
+		t.Fatalf("expected 200 after one reload, got %d after %d: %s", w.Code, reloader.calls, w.Body.String())
+	}
+	var resp config.ReloadResult
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if len(resp.Applied) != 1 || resp.Applied[0] != "exchange.timeout" || len(resp.RestartRequired) != 1 {
+		t.Errorf("unexpected response %+v", resp)
+	}
+}
+
+func TestConfigReloadHandler_InvalidFile(t *testing.T) {
+	h := NewConfigReloadHandler(&fakeReloader{err: errors.New("exchange.timeout: invalid duration")})
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/config/reload", nil))
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "exchange.timeout") {
+		t.Errorf("expected the error reported, got %s", w.Body.String())
+	}
+}
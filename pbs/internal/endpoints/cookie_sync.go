@@ -1,10 +1,21 @@
 package endpoints
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/accounts"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/analytics"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/exchange"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/middleware"
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/usersync"
 	"github.com/StreetsDigital/thenexusengine/pbs/pkg/logger"
 )
@@ -25,6 +36,10 @@ type CookieSyncRequest struct {
 	CooperativeSync bool `json:"coopSync,omitempty"`
 	// FilterSettings controls which sync types to use
 	FilterSettings *FilterSettings `json:"filterSettings,omitempty"`
+	// Debug lists bidders left out for lack of consent, with the reason
+	Debug bool `json:"debug,omitempty"`
+	// Account is the publisher account; its allowed bidders are synced first
+	Account string `json:"account,omitempty"`
 }
 
 // FilterSettings controls sync type filtering
@@ -55,9 +70,21 @@ type BidderSyncStatus struct {
 
 // CookieSyncHandler handles cookie sync requests
 type CookieSyncHandler struct {
-	syncers   map[string]*usersync.Syncer
+	mu        sync.RWMutex
+	syncers   map[string]*usersync.Syncer // Replaced, never modified, once shared
 	hostURL   string
 	maxSyncs  int
+	analytics analytics.Module // nil logs nothing
+	// vendorID returns a bidder's GVL vendor ID (0 if none); nil doesn't enforce TCF consent
+	vendorID func(bidder string) int
+	cookies  *usersync.CookieCodec  // nil reads and writes plain cookies
+	store    *usersync.UIDStore     // nil keeps UIDs in the cookie
+	accounts exchange.AccountSource // nil doesn't prioritize account bidders
+
+	// lastSynced is when each bidder was last handed out for syncing, so
+	// cooperative syncs rotate through bidders beyond the limit
+	syncedMu   sync.Mutex
+	lastSynced map[string]time.Time
 }
 
 // CookieSyncConfig holds configuration for the cookie sync handler
@@ -85,9 +112,10 @@ func NewCookieSyncHandler(config *CookieSyncConfig) *CookieSyncHandler {
 	}
 
 	return &CookieSyncHandler{
-		syncers:  syncers,
-		hostURL:  config.HostURL,
-		maxSyncs: config.MaxSyncs,
+		syncers:    syncers,
+		hostURL:    config.HostURL,
+		maxSyncs:   config.MaxSyncs,
+		lastSynced: make(map[string]time.Time),
 	}
 }
 
@@ -111,17 +139,20 @@ func (h *CookieSyncHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		req.Limit = h.maxSyncs
 	}
 
+	syncers := h.currentSyncers()
+
 	// Parse existing cookie to see what's already synced
-	cookie := usersync.ParseCookie(r)
+	cookie := h.store.ParseCookie(r, h.cookies)
 
 	// Check for opt-out
 	if cookie.IsOptOut() {
+		h.log(&analytics.CookieSyncObject{Status: http.StatusOK, OptOut: true})
 		h.respondJSON(w, CookieSyncResponse{Status: "ok"})
 		return
 	}
 
 	// Determine which bidders to sync
-	biddersToSync := h.getBiddersToSync(req, cookie)
+	biddersToSync := h.getBiddersToSync(req, syncers, h.lookupAccount(r.Context(), req.Account))
 
 	// Build response
 	response := CookieSyncResponse{
@@ -135,13 +166,20 @@ func (h *CookieSyncHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		gdprStr = "1"
 	}
 
+	// Under GDPR, syncs need TCF consent; a missing or unparseable string has none
+	enforceTCF := h.vendorID != nil && req.GDPR == 1
+	var consent *middleware.TCFv2Data
+	if enforceTCF {
+		consent, _ = middleware.ParseTCFv2(req.GDPRConsent)
+	}
+
 	syncCount := 0
 	for _, bidderCode := range biddersToSync {
 		if syncCount >= req.Limit {
 			break
 		}
 
-		syncer, ok := h.syncers[strings.ToLower(bidderCode)]
+		syncer, ok := syncers[strings.ToLower(bidderCode)]
 		if !ok {
 			response.BidderStatus = append(response.BidderStatus, BidderSyncStatus{
 				Bidder: bidderCode,
@@ -159,6 +197,18 @@ func (h *CookieSyncHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			continue
 		}
 
+		if enforceTCF {
+			if reason := h.consentDenied(bidderCode, consent); reason != "" {
+				if req.Debug {
+					response.BidderStatus = append(response.BidderStatus, BidderSyncStatus{
+						Bidder: bidderCode,
+						Error:  reason,
+					})
+				}
+				continue
+			}
+		}
+
 		// Get sync URL
 		syncInfo, err := syncer.GetSync(usersync.SyncTypeRedirect, gdprStr, req.GDPRConsent, req.USPrivacy)
 		if err != nil {
@@ -175,37 +225,146 @@ func (h *CookieSyncHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			NoCookie: true,
 			UserSync: syncInfo,
 		})
+		h.markSynced(bidderCode)
 		syncCount++
 	}
 
 	// Set cookie
-	if httpCookie, err := cookie.ToHTTPCookie(h.getCookieDomain(r)); err == nil {
+	if httpCookie, err := h.store.HTTPCookie(r.Context(), cookie, h.getCookieDomain(r), h.cookies); err == nil {
 		http.SetCookie(w, httpCookie)
 	}
 
+	h.log(cookieSyncObject(response))
 	h.respondJSON(w, response)
 }
 
-// getBiddersToSync determines which bidders need syncing
-func (h *CookieSyncHandler) getBiddersToSync(req CookieSyncRequest, cookie *usersync.Cookie) []string {
-	var bidders []string
+// SetTCFEnforcement requires TCF consent for syncs when the request says GDPR
+// applies: purpose 1 (storing information on the device) for any sync, and
+// for each bidder, its GVL vendor ID as returned by vendorID. Bidders without
+// a vendor ID (0) only need purpose 1. nil stops enforcing consent.
+func (h *CookieSyncHandler) SetTCFEnforcement(vendorID func(bidder string) int) {
+	h.vendorID = vendorID
+}
+
+// consentDenied returns why consent doesn't allow syncing a bidder, or ""
+func (h *CookieSyncHandler) consentDenied(bidderCode string, consent *middleware.TCFv2Data) string {
+	if consent == nil || !consent.HasPurposeConsent(middleware.PurposeStorageAccess) {
+		return "no TCF purpose 1 consent"
+	}
+	if id := h.vendorID(strings.ToLower(bidderCode)); id != 0 && !consent.HasVendorConsent(id) {
+		return fmt.Sprintf("no TCF vendor consent for GVL ID %d", id)
+	}
+	return ""
+}
+
+// SetCookieCodec sets how the uids cookie is signed or encrypted
+func (h *CookieSyncHandler) SetCookieCodec(codec *usersync.CookieCodec) {
+	h.cookies = codec
+}
+
+// SetUIDStore keeps UIDs server-side, with the cookie only carrying their ID
+func (h *CookieSyncHandler) SetUIDStore(store *usersync.UIDStore) {
+	h.store = store
+}
 
-	if len(req.Bidders) > 0 {
-		// Use requested bidders
-		bidders = req.Bidders
-	} else if req.CooperativeSync {
-		// Sync all configured bidders
-		for code := range h.syncers {
+// SetAnalytics sets the analytics modules every cookie sync is logged to
+func (h *CookieSyncHandler) SetAnalytics(m analytics.Module) {
+	h.analytics = m
+}
+
+func (h *CookieSyncHandler) log(co *analytics.CookieSyncObject) {
+	if h.analytics != nil {
+		h.analytics.LogCookieSyncObject(co)
+	}
+}
+
+// cookieSyncObject describes a cookie sync response for analytics
+func cookieSyncObject(response CookieSyncResponse) *analytics.CookieSyncObject {
+	co := &analytics.CookieSyncObject{
+		Status:  http.StatusOK,
+		Bidders: make([]analytics.CookieSyncBidder, len(response.BidderStatus)),
+	}
+	for i, status := range response.BidderStatus {
+		co.Bidders[i] = analytics.CookieSyncBidder{Bidder: status.Bidder, NoCookie: status.NoCookie, Error: status.Error}
+		if status.Error != "" {
+			co.Errors = append(co.Errors, status.Bidder+": "+status.Error)
+		}
+	}
+	return co
+}
+
+// SetAccounts sets where account configuration is read from, so the
+// request account's allowed bidders are synced first
+func (h *CookieSyncHandler) SetAccounts(src exchange.AccountSource) {
+	h.accounts = src
+}
+
+// lookupAccount returns the request's account, or nil when it has none or
+// the lookup fails
+func (h *CookieSyncHandler) lookupAccount(ctx context.Context, id string) *accounts.Account {
+	if h.accounts == nil || id == "" {
+		return nil
+	}
+	account, err := h.accounts.Account(ctx, id)
+	var notFound *accounts.NotFoundError
+	if err != nil && !errors.As(err, &notFound) {
+		logger.Log.Warn().Err(err).Str("account", id).Msg("Account lookup failed for cookie sync")
+	}
+	return account
+}
+
+// getBiddersToSync determines which bidders need syncing, in priority order:
+// the account's allowed bidders, then the requested bidders in request
+// order, then with cooperative sync every other bidder, least recently
+// synced first
+func (h *CookieSyncHandler) getBiddersToSync(req CookieSyncRequest, syncers map[string]*usersync.Syncer, account *accounts.Account) []string {
+	var bidders []string
+	seen := make(map[string]bool)
+	for _, code := range req.Bidders {
+		if !seen[strings.ToLower(code)] {
+			seen[strings.ToLower(code)] = true
 			bidders = append(bidders, code)
 		}
-	} else {
+	}
+
+	if req.CooperativeSync {
+		// Sync the other configured bidders too, rotating through them
+		var others []string
+		for code := range syncers {
+			if !seen[code] {
+				others = append(others, code)
+			}
+		}
+		h.syncedMu.Lock()
+		sort.Slice(others, func(i, j int) bool {
+			ti, tj := h.lastSynced[others[i]], h.lastSynced[others[j]]
+			if !ti.Equal(tj) {
+				return ti.Before(tj)
+			}
+			return others[i] < others[j]
+		})
+		h.syncedMu.Unlock()
+		bidders = append(bidders, others...)
+	} else if len(bidders) == 0 {
 		// No bidders specified and no coop sync - return common bidders
 		bidders = []string{"appnexus", "rubicon", "pubmatic", "openx", "triplelift"}
 	}
 
+	if account != nil && len(account.AllowedBidders) > 0 {
+		sort.SliceStable(bidders, func(i, j int) bool {
+			return account.AllowsBidder(bidders[i]) && !account.AllowsBidder(bidders[j])
+		})
+	}
 	return bidders
 }
 
+// markSynced records that a bidder was just handed out for syncing
+func (h *CookieSyncHandler) markSynced(bidderCode string) {
+	h.syncedMu.Lock()
+	h.lastSynced[strings.ToLower(bidderCode)] = time.Now()
+	h.syncedMu.Unlock()
+}
+
 // getCookieDomain extracts the domain for cookies
 func (h *CookieSyncHandler) getCookieDomain(r *http.Request) string {
 	host := r.Host
@@ -225,13 +384,63 @@ func (h *CookieSyncHandler) respondJSON(w http.ResponseWriter, data interface{})
 
 // AddSyncer adds a syncer for a bidder
 func (h *CookieSyncHandler) AddSyncer(config usersync.SyncerConfig) {
-	h.syncers[strings.ToLower(config.BidderCode)] = usersync.NewSyncer(config, h.hostURL)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	syncers := make(map[string]*usersync.Syncer, len(h.syncers)+1)
+	for code, syncer := range h.syncers {
+		syncers[code] = syncer
+	}
+	syncers[strings.ToLower(config.BidderCode)] = usersync.NewSyncer(config, h.hostURL)
+	h.syncers = syncers
+}
+
+// SetSyncers replaces every syncer, e.g. when a SyncerReloader reloads them
+func (h *CookieSyncHandler) SetSyncers(configs map[string]usersync.SyncerConfig) {
+	syncers := make(map[string]*usersync.Syncer, len(configs))
+	for code, config := range configs {
+		syncers[strings.ToLower(code)] = usersync.NewSyncer(config, h.hostURL)
+	}
+	h.mu.Lock()
+	h.syncers = syncers
+	h.mu.Unlock()
+}
+
+// currentSyncers returns the syncers in effect
+func (h *CookieSyncHandler) currentSyncers() map[string]*usersync.Syncer {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.syncers
+}
+
+// Warmup builds every enabled syncer's URL once and checks it parses, so template
+// mistakes surface at startup instead of on the first cookie_sync call
+func (h *CookieSyncHandler) Warmup() error {
+	var broken []string
+	for code, syncer := range h.currentSyncers() {
+		if !syncer.IsEnabled() {
+			continue
+		}
+		info, err := syncer.GetSync("", "0", "", "")
+		if err != nil {
+			broken = append(broken, code+": "+err.Error())
+			continue
+		}
+		if _, err := url.Parse(info.URL); err != nil {
+			broken = append(broken, code+": "+err.Error())
+		}
+	}
+	if len(broken) > 0 {
+		sort.Strings(broken)
+		return fmt.Errorf("invalid syncers: %s", strings.Join(broken, "; "))
+	}
+	return nil
 }
 
 // ListBidders returns all configured bidder codes
 func (h *CookieSyncHandler) ListBidders() []string {
-	bidders := make([]string, 0, len(h.syncers))
-	for code := range h.syncers {
+	syncers := h.currentSyncers()
+	bidders := make([]string, 0, len(syncers))
+	for code := range syncers {
 		bidders = append(bidders, code)
 	}
 	return bidders
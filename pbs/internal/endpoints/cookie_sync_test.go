@@ -0,0 +1,191 @@
+package endpoints
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/accounts"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/usersync"
+)
+
+// tcfConsent encodes a TCF v2 core string consenting to purpose 1 when
+// storage is set, and to the given vendors
+func tcfConsent(storage bool, vendors ...int) string {
+	var bits []bool
+	write := func(value, n int) {
+		for i := n - 1; i >= 0; i-- {
+			bits = append(bits, value>>i&1 == 1)
+		}
+	}
+	write(2, 6)   // Version
+	write(0, 146) // Metadata up to the purpose consents
+	if storage {
+		write(1, 1)
+	} else {
+		write(0, 1)
+	}
+	write(0, 23+37) // Other purposes, legitimate interests and publisher fields
+	write(100, 16)  // MaxVendorId
+	write(0, 1)     // Bitfield encoding
+	for id := 1; id <= 100; id++ {
+		bit := 0
+		for _, v := range vendors {
+			if v == id {
+				bit = 1
+			}
+		}
+		write(bit, 1)
+	}
+	data := make([]byte, (len(bits)+7)/8)
+	for i, bit := range bits {
+		if bit {
+			data[i/8] |= 1 << (7 - i%8)
+		}
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func TestCookieSync_TCFEnforcement(t *testing.T) {
+	syncers := make(map[string]usersync.SyncerConfig)
+	for _, code := range []string{"consented", "unconsented", "novendor"} {
+		syncers[code] = usersync.SyncerConfig{BidderCode: code, RedirectSyncURL: "https://" + code + ".example/sync?gdpr={{gdpr}}", Enabled: true}
+	}
+	h := NewCookieSyncHandler(&CookieSyncConfig{HostURL: "https://pbs.example", MaxSyncs: 8, SyncConfigs: syncers})
+	h.SetTCFEnforcement(func(bidder string) int {
+		return map[string]int{"consented": 10, "unconsented": 20}[bidder]
+	})
+
+	sync := func(req CookieSyncRequest) map[string]string {
+		req.Bidders = []string{"consented", "Unconsented", "novendor"}
+		body, _ := json.Marshal(req)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/cookie_sync", strings.NewReader(string(body))))
+
+		var resp CookieSyncResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		statuses := make(map[string]string)
+		for _, s := range resp.BidderStatus {
+			if s.UserSync != nil {
+				statuses[s.Bidder] = "sync"
+			} else {
+				statuses[s.Bidder] = s.Error
+			}
+		}
+		return statuses
+	}
+
+	got := sync(CookieSyncRequest{GDPR: 1, GDPRConsent: tcfConsent(true, 10), Debug: true})
+	want := map[string]string{"consented": "sync", "Unconsented": "no TCF vendor consent for GVL ID 20", "novendor": "sync"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// Rejections are only listed for debug requests
+	got = sync(CookieSyncRequest{GDPR: 1, GDPRConsent: tcfConsent(true, 10)})
+	if !reflect.DeepEqual(got, map[string]string{"consented": "sync", "novendor": "sync"}) {
+		t.Errorf("expected the rejection left out without debug, got %v", got)
+	}
+
+	// Without purpose 1, without a consent string, or with a retired TCF v1
+	// string, nobody syncs
+	for _, consent := range []string{tcfConsent(false, 10, 20), "", "BOEFEAyOEFEAyAHABDENAI4AAAB9vABAASA"} {
+		got = sync(CookieSyncRequest{GDPR: 1, GDPRConsent: consent, Debug: true})
+		for bidder, status := range got {
+			if status != "no TCF purpose 1 consent" {
+				t.Errorf("expected %s rejected for purpose 1, got %q", bidder, status)
+			}
+		}
+	}
+
+	// Consent isn't checked when GDPR doesn't apply
+	got = sync(CookieSyncRequest{Debug: true})
+	if len(got) != 3 || got["Unconsented"] != "sync" {
+		t.Errorf("expected every bidder synced outside GDPR, got %v", got)
+	}
+}
+
+func TestCookieSync_SetSyncers(t *testing.T) {
+	h := NewCookieSyncHandler(DefaultCookieSyncConfig("https://pbs.example"))
+	h.SetSyncers(map[string]usersync.SyncerConfig{
+		"NewBidder": {BidderCode: "newbidder", RedirectSyncURL: "https://new.example/sync?r={{redirect_url}}", Enabled: true},
+	})
+	if got := h.ListBidders(); !reflect.DeepEqual(got, []string{"newbidder"}) {
+		t.Fatalf("expected the syncers replaced, got %v", got)
+	}
+
+	body := `{"bidders": ["newbidder", "appnexus"]}`
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/cookie_sync", strings.NewReader(body)))
+	var resp CookieSyncResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.BidderStatus) != 2 || resp.BidderStatus[0].UserSync == nil || resp.BidderStatus[1].Error != "unsupported bidder" {
+		t.Errorf("expected newbidder synced and appnexus unsupported, got %+v", resp.BidderStatus)
+	}
+}
+
+type fakeAccountSource map[string]*accounts.Account
+
+func (f fakeAccountSource) Account(ctx context.Context, id string) (*accounts.Account, error) {
+	if account, ok := f[id]; ok {
+		return account, nil
+	}
+	return nil, &accounts.NotFoundError{ID: id}
+}
+
+func TestCookieSync_Prioritization(t *testing.T) {
+	syncers := make(map[string]usersync.SyncerConfig)
+	for _, code := range []string{"a", "b", "c", "d", "e"} {
+		syncers[code] = usersync.SyncerConfig{BidderCode: code, RedirectSyncURL: "https://" + code + ".example/sync", Enabled: true}
+	}
+	h := NewCookieSyncHandler(&CookieSyncConfig{HostURL: "https://pbs.example", MaxSyncs: 8, SyncConfigs: syncers})
+	h.SetAccounts(fakeAccountSource{"pub-1": {ID: "pub-1", AllowedBidders: []string{"D", "e"}}})
+
+	sync := func(req CookieSyncRequest) []string {
+		body, _ := json.Marshal(req)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/cookie_sync", strings.NewReader(string(body))))
+		var resp CookieSyncResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		var bidders []string
+		for _, s := range resp.BidderStatus {
+			bidders = append(bidders, s.Bidder)
+		}
+		return bidders
+	}
+
+	// The account's bidders first, then the requested ones in request order
+	got := sync(CookieSyncRequest{Bidders: []string{"c", "a", "e"}, Account: "pub-1", Limit: 2})
+	if !reflect.DeepEqual(got, []string{"e", "c"}) {
+		t.Errorf("expected the account bidder first, got %v", got)
+	}
+	got = sync(CookieSyncRequest{Bidders: []string{"c", "a"}, Account: "unknown", Limit: 2})
+	if !reflect.DeepEqual(got, []string{"c", "a"}) {
+		t.Errorf("expected request order without an account, got %v", got)
+	}
+
+	// Cooperative syncs rotate through the bidders beyond the limit
+	h = NewCookieSyncHandler(&CookieSyncConfig{HostURL: "https://pbs.example", MaxSyncs: 8, SyncConfigs: syncers})
+	seen := make(map[string]int)
+	for i := 0; i < 5; i++ {
+		got = sync(CookieSyncRequest{Bidders: []string{"a"}, CooperativeSync: true, Limit: 2})
+		if len(got) != 2 || got[0] != "a" {
+			t.Fatalf("expected the requested bidder first, got %v", got)
+		}
+		seen[got[1]]++
+	}
+	if !reflect.DeepEqual(seen, map[string]int{"b": 2, "c": 1, "d": 1, "e": 1}) {
+		t.Errorf("expected the other bidders rotated, got %v", seen)
+	}
+}
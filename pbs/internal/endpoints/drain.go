@@ -0,0 +1,57 @@
+package endpoints
+
+import (
+	"net/http"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/lifecycle"
+
+	log "github.com/rs/zerolog/log"
+)
+
+// DrainState reports whether the server is draining ahead of shutdown
+type DrainState interface {
+	Draining() bool
+}
+
+// DrainHandler handles /admin/drain, taking the server out of service for a
+// zero-downtime deploy
+//
+//	GET  /admin/drain  drain status
+//	POST /admin/drain  start draining; the server stops when the window ends
+type DrainHandler struct {
+	drainer *lifecycle.Drainer
+}
+
+// NewDrainHandler creates a new drain admin handler
+func NewDrainHandler(drainer *lifecycle.Drainer) *DrainHandler {
+	return &DrainHandler{drainer: drainer}
+}
+
+// ServeHTTP reports or starts a drain
+func (h *DrainHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, h.drainer.Status())
+	case http.MethodPost:
+		if !h.drainer.Draining() {
+			log.Info().Str("actor", flagActor(r)).Dur("window", h.drainer.Window()).Msg("Drain requested")
+		}
+		writeJSON(w, h.drainer.Drain())
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// UnavailableWhileDraining answers health checks with 503 once draining, so
+// load balancers stop routing to the server
+func UnavailableWhileDraining(drain DrainState, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if drain.Draining() {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"status":"draining"}` + "\n"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
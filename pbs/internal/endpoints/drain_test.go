@@ -0,0 +1,58 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/lifecycle"
+)
+
+func TestDrainHandler(t *testing.T) {
+	drainer := lifecycle.NewDrainer(time.Minute)
+	h := NewDrainHandler(drainer)
+	health := UnavailableWhileDraining(drainer, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	status := func(method string) lifecycle.DrainStatus {
+		t.Helper()
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(method, "/admin/drain", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", method, w.Code)
+		}
+		var resp lifecycle.DrainStatus
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to parse response: %v", err)
+		}
+		return resp
+	}
+	healthCode := func() int {
+		w := httptest.NewRecorder()
+		health.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+		return w.Code
+	}
+
+	if s := status(http.MethodGet); s.Draining || drainer.Draining() {
+		t.Fatalf("expected not draining, got %+v", s)
+	}
+	if code := healthCode(); code != http.StatusOK {
+		t.Errorf("expected healthy before draining, got %d", code)
+	}
+
+	if s := status(http.MethodPost); !s.Draining || s.ShutdownAt == nil {
+		t.Errorf("expected draining with a shutdown time, got %+v", s)
+	}
+	if code := healthCode(); code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 while draining, got %d", code)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/admin/drain", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", w.Code)
+	}
+}
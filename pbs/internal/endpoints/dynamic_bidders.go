@@ -0,0 +1,189 @@
+package endpoints
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters/ortb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/logger"
+)
+
+// maxDynamicBidderRequestSize bounds dynamic bidder config bodies
+const maxDynamicBidderRequestSize = 64 * 1024
+
+// DynamicBidderStore is the dynamic registry as the write API uses it
+type DynamicBidderStore interface {
+	Get(code string) (*ortb.GenericAdapter, bool)
+	GetAll() map[string]*ortb.GenericAdapter
+	Save(ctx context.Context, config *ortb.BidderConfig) (created bool, err error)
+	Delete(ctx context.Context, code string) (bool, error)
+}
+
+// DynamicBiddersHandler handles /admin/dynamic-bidders: managing dynamic
+// bidder configs in Redis without going through the admin service
+//
+//	GET    /admin/dynamic-bidders         list loaded configs, credentials redacted
+//	GET    /admin/dynamic-bidders/{code}  one loaded config, credentials redacted
+//	PUT    /admin/dynamic-bidders/{code}  create or replace a config
+//	DELETE /admin/dynamic-bidders/{code}  remove a config
+//
+// A PUT may send back "[redacted]" for a credential or custom header value to
+// keep the current one. After each write refresh reloads the registry.
+type DynamicBiddersHandler struct {
+	store   DynamicBidderStore
+	refresh func(ctx context.Context) error
+}
+
+// NewDynamicBiddersHandler creates a dynamic bidder admin handler. refresh
+// reloads the registry after a write, here and on other instances.
+func NewDynamicBiddersHandler(store DynamicBidderStore, refresh func(ctx context.Context) error) *DynamicBiddersHandler {
+	return &DynamicBiddersHandler{store: store, refresh: refresh}
+}
+
+// ServeHTTP handles dynamic bidder admin requests
+func (h *DynamicBiddersHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	code := r.PathValue("code")
+	switch {
+	case r.Method == http.MethodGet && code == "":
+		writeJSON(w, h.list())
+
+	case r.Method == http.MethodGet:
+		adapter, ok := h.store.Get(code)
+		if !ok {
+			writeError(w, "unknown bidder", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, redactBidderConfig(adapter.GetConfig()))
+
+	case r.Method == http.MethodPut && code != "":
+		h.put(w, r, code)
+
+	case r.Method == http.MethodDelete && code != "":
+		deleted, err := h.store.Delete(r.Context(), code)
+		if err != nil {
+			logger.Log.Error().Err(err).Str("bidder", code).Msg("failed to delete dynamic bidder")
+			writeError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !deleted {
+			writeError(w, "unknown bidder", http.StatusNotFound)
+			return
+		}
+		logger.Log.Info().Str("bidder", code).Str("actor", flagActor(r)).Msg("Dynamic bidder deleted")
+		if !h.reload(w, r) {
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// put creates or replaces code's config
+func (h *DynamicBiddersHandler) put(w http.ResponseWriter, r *http.Request, code string) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxDynamicBidderRequestSize))
+	if err != nil {
+		writeError(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+	var config ortb.BidderConfig
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&config); err != nil {
+		writeError(w, "Invalid JSON in request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if config.BidderCode == "" {
+		config.BidderCode = code
+	}
+	if config.BidderCode != code {
+		writeError(w, "bidder_code must match the path", http.StatusBadRequest)
+		return
+	}
+	if current, ok := h.store.Get(code); ok {
+		keepRedactedSecrets(&config, current.GetConfig())
+	}
+
+	created, err := h.store.Save(r.Context(), &config)
+	if err != nil {
+		if errors.Is(err, ortb.ErrInvalidConfig) {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		logger.Log.Error().Err(err).Str("bidder", code).Msg("failed to save dynamic bidder")
+		writeError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	logger.Log.Info().
+		Str("bidder", code).
+		Bool("created", created).
+		Str("status", config.Status).
+		Str("actor", flagActor(r)).
+		Msg("Dynamic bidder saved")
+	if !h.reload(w, r) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if created {
+		w.WriteHeader(http.StatusCreated)
+	}
+	if err := json.NewEncoder(w).Encode(redactBidderConfig(&config)); err != nil {
+		logger.Log.Error().Err(err).Msg("failed to encode dynamic bidder response")
+	}
+}
+
+// reload refreshes the registry after a write, reporting a failure to the
+// client. The write itself is in Redis either way and is picked up by the next
+// periodic refresh.
+func (h *DynamicBiddersHandler) reload(w http.ResponseWriter, r *http.Request) bool {
+	if h.refresh == nil {
+		return true
+	}
+	if err := h.refresh(r.Context()); err != nil {
+		logger.Log.Error().Err(err).Msg("failed to refresh dynamic registry after write")
+		writeError(w, "saved, but the registry refresh failed: "+err.Error(), http.StatusBadGateway)
+		return false
+	}
+	return true
+}
+
+// list returns every loaded config, credentials redacted, sorted by code
+func (h *DynamicBiddersHandler) list() []*ortb.BidderConfig {
+	all := h.store.GetAll()
+	configs := make([]*ortb.BidderConfig, 0, len(all))
+	for _, adapter := range all {
+		configs = append(configs, redactBidderConfig(adapter.GetConfig()))
+	}
+	sort.Slice(configs, func(i, j int) bool { return configs[i].BidderCode < configs[j].BidderCode })
+	return configs
+}
+
+// keepRedactedSecrets restores credentials and custom header values a client
+// sent back as redacted, so a GET response can be edited and PUT back
+func keepRedactedSecrets(config, current *ortb.BidderConfig) {
+	if current == nil {
+		return
+	}
+	e, c := &config.Endpoint, &current.Endpoint
+	for _, pair := range [][2]*string{
+		{&e.AuthPassword, &c.AuthPassword},
+		{&e.AuthToken, &c.AuthToken},
+		{&e.AuthHeaderValue, &c.AuthHeaderValue},
+	} {
+		if *pair[0] == redacted {
+			*pair[0] = *pair[1]
+		}
+	}
+	for name, value := range e.CustomHeaders {
+		if value == redacted {
+			e.CustomHeaders[name] = c.CustomHeaders[name]
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package endpoints
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters/ortb"
+)
+
+// mockDynamicBidderStore keeps configs in memory, validating like the registry
+type mockDynamicBidderStore struct {
+	configs map[string]*ortb.BidderConfig
+}
+
+func (m *mockDynamicBidderStore) Get(code string) (*ortb.GenericAdapter, bool) {
+	config, ok := m.configs[code]
+	if !ok {
+		return nil, false
+	}
+	return ortb.New(config), true
+}
+
+func (m *mockDynamicBidderStore) GetAll() map[string]*ortb.GenericAdapter {
+	all := make(map[string]*ortb.GenericAdapter, len(m.configs))
+	for code, config := range m.configs {
+		all[code] = ortb.New(config)
+	}
+	return all
+}
+
+func (m *mockDynamicBidderStore) Save(ctx context.Context, config *ortb.BidderConfig) (bool, error) {
+	if err := config.Validate(); err != nil {
+		return false, errors.Join(ortb.ErrInvalidConfig, err)
+	}
+	_, exists := m.configs[config.BidderCode]
+	m.configs[config.BidderCode] = config
+	return !exists, nil
+}
+
+func (m *mockDynamicBidderStore) Delete(ctx context.Context, code string) (bool, error) {
+	_, exists := m.configs[code]
+	delete(m.configs, code)
+	return exists, nil
+}
+
+const dynamicBidderBody = `{
+	"name": "Custom",
+	"status": "active",
+	"endpoint": {"url": "https://custom.example.com/bid", "method": "POST", "timeout_ms": 300, "auth_type": "bearer", "auth_token": "secret-token"}
+}`
+
+func dynamicBidderRequest(method, code, body string) *http.Request {
+	req := httptest.NewRequest(method, "/admin/dynamic-bidders/"+code, strings.NewReader(body))
+	if code != "" {
+		req.SetPathValue("code", code)
+	}
+	return req
+}
+
+func TestDynamicBiddersHandler(t *testing.T) {
+	store := &mockDynamicBidderStore{configs: map[string]*ortb.BidderConfig{}}
+	refreshes := 0
+	handler := NewDynamicBiddersHandler(store, func(context.Context) error {
+		refreshes++
+		return nil
+	})
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve(dynamicBidderRequest(http.MethodPut, "custom", dynamicBidderBody))
+	if w.Code != http.StatusCreated || refreshes != 1 {
+		t.Fatalf("expected 201 and a refresh, got %d %s (%d refreshes)", w.Code, w.Body.String(), refreshes)
+	}
+	if strings.Contains(w.Body.String(), "secret-token") || store.configs["custom"].BidderCode != "custom" {
+		t.Errorf("expected the code taken from the path and the token redacted, got %s", w.Body.String())
+	}
+
+	// A GET response PUT back keeps the stored token
+	w = serve(dynamicBidderRequest(http.MethodGet, "custom", ""))
+	var got ortb.BidderConfig
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil || got.Endpoint.AuthToken != redacted {
+		t.Fatalf("expected a redacted config, got %s", w.Body.String())
+	}
+	got.Endpoint.TimeoutMS = 400
+	body, _ := json.Marshal(got)
+	if w := serve(dynamicBidderRequest(http.MethodPut, "custom", string(body))); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 on update, got %d %s", w.Code, w.Body.String())
+	}
+	if c := store.configs["custom"]; c.Endpoint.AuthToken != "secret-token" || c.Endpoint.TimeoutMS != 400 {
+		t.Errorf("expected the token kept and timeout updated, got %+v", c.Endpoint)
+	}
+
+	w = serve(dynamicBidderRequest(http.MethodGet, "", ""))
+	var list []ortb.BidderConfig
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || len(list) != 1 {
+		t.Errorf("expected one config listed, got %s", w.Body.String())
+	}
+
+	for _, tt := range []struct {
+		name, code, body string
+		want             int
+	}{
+		{"invalid config", "custom", `{"status":"active","endpoint":{"url":"not a url"}}`, http.StatusBadRequest},
+		{"unknown field", "custom", `{"status":"active","endpont":{}}`, http.StatusBadRequest},
+		{"code mismatch", "custom", `{"bidder_code":"other","status":"active"}`, http.StatusBadRequest},
+		{"bad JSON", "custom", `{`, http.StatusBadRequest},
+	} {
+		if w := serve(dynamicBidderRequest(http.MethodPut, tt.code, tt.body)); w.Code != tt.want {
+			t.Errorf("%s: expected %d, got %d %s", tt.name, tt.want, w.Code, w.Body.String())
+		}
+	}
+
+	if w := serve(dynamicBidderRequest(http.MethodDelete, "custom", "")); w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 on delete, got %d", w.Code)
+	}
+	if w := serve(dynamicBidderRequest(http.MethodDelete, "custom", "")); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 deleting a missing bidder, got %d", w.Code)
+	}
+	if w := serve(dynamicBidderRequest(http.MethodGet, "custom", "")); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a deleted bidder, got %d", w.Code)
+	}
+	if refreshes != 3 {
+		t.Errorf("expected a refresh per successful write, got %d", refreshes)
+	}
+}
+
+func TestDynamicBiddersHandler_RefreshFailure(t *testing.T) {
+	store := &mockDynamicBidderStore{configs: map[string]*ortb.BidderConfig{}}
+	handler := NewDynamicBiddersHandler(store, func(context.Context) error { return errors.New("redis down") })
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, dynamicBidderRequest(http.MethodPut, "custom", dynamicBidderBody))
+	if w.Code != http.StatusBadGateway || store.configs["custom"] == nil {
+		t.Errorf("expected 502 with the config saved, got %d %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,178 @@
+package endpoints
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/analytics"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/exchange"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/idr"
+)
+
+// EventPath serves the win and imp URLs the exchange puts on returned bids
+const EventPath = exchange.EventPath
+
+// maxEventIDLength bounds the IDs in event URLs; the endpoint is unauthenticated
+const maxEventIDLength = 128
+
+// Event notification results, labelling metrics
+const (
+	EventResultRecorded  = "recorded"  // A returned bid's first notification of the type
+	EventResultDuplicate = "duplicate" // Repeat notification; neither recorded nor fired again
+	EventResultUnmatched = "unmatched" // Bid unknown or expired; recorded without its auction
+	EventResultInvalid   = "invalid"
+)
+
+// transparentGIF is served for f=i, so event URLs can be used as image pixels
+var transparentGIF, _ = base64.StdEncoding.DecodeString("R0lGODlhAQABAIAAAAAAAP///yH5BAEAAAAALAAAAAABAAEAAAIBRAA7")
+
+// EventRecorder receives win and imp notifications; *idr.EventRecorder implements it
+type EventRecorder interface {
+	RecordNotification(eventType, auctionID, bidID, bidderCode string, cpm *float64, publisherID string, ts time.Time)
+}
+
+// EventMetrics counts event notifications by type and result
+type EventMetrics interface {
+	IncEventNotification(eventType, result string)
+}
+
+// EventHandler handles /event: win and imp notifications for returned bids.
+// Notifications are tied back to their auction through the exchange's bid
+// notices and recorded for IDR. With a notifier set, a bid's nurl is fired on
+// its first win notification (unless the exchange already fired it) and its
+// burl on its first imp notification.
+type EventHandler struct {
+	notices   *exchange.BidNotices // nil records notifications without their auction
+	recorder  EventRecorder        // nil accepts and discards notifications
+	metrics   EventMetrics
+	notifier  *exchange.Notifier // nil leaves notice URLs to the client
+	analytics analytics.Module   // nil logs nothing
+}
+
+// NewEventHandler creates an event handler; notices and recorder may be nil
+// when events or event recording are off
+func NewEventHandler(notices *exchange.BidNotices, recorder EventRecorder) *EventHandler {
+	return &EventHandler{notices: notices, recorder: recorder}
+}
+
+// SetMetrics sets the event metrics recorder
+func (h *EventHandler) SetMetrics(m EventMetrics) {
+	h.metrics = m
+}
+
+// SetNotifier fires bids' notice URLs server-side on their notifications.
+// Only set it when clients don't fire them too, or bidders see wins and
+// billing twice.
+func (h *EventHandler) SetNotifier(n *exchange.Notifier) {
+	h.notifier = n
+}
+
+// SetAnalytics sets the analytics modules every valid notification is logged to
+func (h *EventHandler) SetAnalytics(m analytics.Module) {
+	h.analytics = m
+}
+
+// ServeHTTP handles event notifications
+func (h *EventHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	eventType, bidID, account, bidder := q.Get("t"), q.Get("b"), q.Get("a"), q.Get("bidder")
+	if msg := validateEvent(eventType, bidID, account, bidder, q.Get("f")); msg != "" {
+		h.count(eventType, EventResultInvalid)
+		writeError(w, msg, http.StatusBadRequest)
+		return
+	}
+	ts := time.Now()
+	if raw := q.Get("ts"); raw != "" {
+		ms, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || ms <= 0 {
+			h.count(eventType, EventResultInvalid)
+			writeError(w, "ts: must be a Unix timestamp in milliseconds", http.StatusBadRequest)
+			return
+		}
+		ts = time.UnixMilli(ms)
+	}
+
+	var notice *exchange.BidNotice
+	first := false
+	if h.notices != nil {
+		notice, first = h.notices.Notify(bidder, bidID, eventType)
+	}
+	result := EventResultUnmatched
+	auctionID, bidderCode, publisherID := "", bidder, account
+	var cpm *float64
+	if notice != nil {
+		result = EventResultDuplicate
+		if first {
+			result = EventResultRecorded
+		}
+		price := notice.Price
+		auctionID, bidderCode, publisherID, cpm = notice.AuctionID, notice.Bidder, notice.Account, &price
+	}
+	if h.recorder != nil && result != EventResultDuplicate {
+		h.recorder.RecordNotification(eventType, auctionID, bidID, bidderCode, cpm, publisherID, ts)
+	}
+	h.count(eventType, result)
+	if h.analytics != nil {
+		h.analytics.LogNotificationEvent(&analytics.NotificationEvent{
+			Type:      eventType,
+			BidID:     bidID,
+			Bidder:    bidderCode,
+			Account:   publisherID,
+			AuctionID: auctionID,
+			Result:    result,
+			Timestamp: ts,
+		})
+	}
+
+	if first && h.notifier != nil {
+		switch {
+		case eventType == idr.NotificationWin && notice.NURL != "" && !notice.NURLFired:
+			h.notifier.Fire(exchange.NoticeNURL, notice, notice.NURL)
+		case eventType == idr.NotificationImp && notice.BURL != "":
+			h.notifier.Fire(exchange.NoticeBURL, notice, notice.BURL)
+		}
+	}
+
+	if q.Get("f") == "i" {
+		w.Header().Set("Content-Type", "image/gif")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Write(transparentGIF)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *EventHandler) count(eventType, result string) {
+	if h.metrics == nil {
+		return
+	}
+	if eventType != idr.NotificationWin && eventType != idr.NotificationImp {
+		eventType = "unknown"
+	}
+	h.metrics.IncEventNotification(eventType, result)
+}
+
+// validateEvent checks the notification's parameters, returning an error
+// message or "" when they're valid
+func validateEvent(eventType, bidID, account, bidder, format string) string {
+	if eventType != idr.NotificationWin && eventType != idr.NotificationImp {
+		return "t: must be win or imp"
+	}
+	if bidID == "" {
+		return "b: bid ID is required"
+	}
+	if len(bidID) > maxEventIDLength || len(account) > maxEventIDLength || len(bidder) > maxEventIDLength {
+		return "parameters too long"
+	}
+	if format != "" && format != "b" && format != "i" {
+		return "f: must be b or i"
+	}
+	return ""
+}
@@ -0,0 +1,170 @@
+package endpoints
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/exchange"
+)
+
+type recordedEvent struct {
+	eventType, auctionID, bidID, bidder, publisherID string
+	cpm                                              *float64
+	ts                                               time.Time
+}
+
+type mockEventRecorder struct {
+	events []recordedEvent
+}
+
+func (m *mockEventRecorder) RecordNotification(eventType, auctionID, bidID, bidderCode string, cpm *float64, publisherID string, ts time.Time) {
+	m.events = append(m.events, recordedEvent{eventType, auctionID, bidID, bidderCode, publisherID, cpm, ts})
+}
+
+type mockEventMetrics struct {
+	counts map[string]int
+}
+
+func (m *mockEventMetrics) IncEventNotification(eventType, result string) {
+	m.counts[eventType+"/"+result]++
+}
+
+func getEvent(h http.Handler, query string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, EventPath+"?"+query, nil))
+	return w
+}
+
+func TestEventHandler_RecordsNotifications(t *testing.T) {
+	notices := exchange.NewBidNotices(time.Minute)
+	notices.Add(&exchange.BidNotice{AuctionID: "a1", BidID: "b1", Bidder: "appnexus", Seat: "thenexusengine", Account: "pub-1", Price: 1.5})
+	recorder := &mockEventRecorder{}
+	metrics := &mockEventMetrics{counts: make(map[string]int)}
+	h := NewEventHandler(notices, recorder)
+	h.SetMetrics(metrics)
+
+	if w := getEvent(h, "t=win&b=b1&a=pub-1&bidder=thenexusengine&ts=1700000000000"); w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	w := getEvent(h, "t=imp&b=b1&bidder=thenexusengine&f=i")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/gif" || w.Body.Len() == 0 {
+		t.Errorf("expected a pixel for f=i, got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	getEvent(h, "t=win&b=b1&bidder=thenexusengine")
+	getEvent(h, "t=win&b=unknown&a=pub-2&bidder=rubicon")
+
+	if len(recorder.events) != 3 {
+		t.Fatalf("expected win, imp and the unmatched win recorded, got %+v", recorder.events)
+	}
+	win := recorder.events[0]
+	if win.eventType != "win" || win.auctionID != "a1" || win.bidder != "appnexus" || win.publisherID != "pub-1" ||
+		win.cpm == nil || *win.cpm != 1.5 || win.ts.UnixMilli() != 1700000000000 {
+		t.Errorf("expected the win tied to its auction and real bidder, got %+v", win)
+	}
+	if unmatched := recorder.events[2]; unmatched.auctionID != "" || unmatched.bidder != "rubicon" || unmatched.publisherID != "pub-2" || unmatched.cpm != nil {
+		t.Errorf("expected the unmatched win recorded from its URL, got %+v", unmatched)
+	}
+	want := map[string]int{"win/recorded": 1, "imp/recorded": 1, "win/duplicate": 1, "win/unmatched": 1}
+	for key, n := range want {
+		if metrics.counts[key] != n {
+			t.Errorf("%s: expected %d, got %d", key, n, metrics.counts[key])
+		}
+	}
+}
+
+func TestEventHandler_Analytics(t *testing.T) {
+	notices := exchange.NewBidNotices(time.Minute)
+	notices.Add(&exchange.BidNotice{AuctionID: "a1", BidID: "b1", Bidder: "appnexus", Seat: "thenexusengine", Account: "pub-1", Price: 1.5})
+	logged := &mockAnalytics{}
+	h := NewEventHandler(notices, nil)
+	h.SetAnalytics(logged)
+
+	getEvent(h, "t=win&b=b1&bidder=thenexusengine")
+	getEvent(h, "t=win&b=b1&bidder=thenexusengine")
+	getEvent(h, "t=click&b=b1")
+
+	if len(logged.notifications) != 2 {
+		t.Fatalf("expected valid notifications logged, got %+v", logged.notifications)
+	}
+	first := logged.notifications[0]
+	if first.Type != "win" || first.AuctionID != "a1" || first.Bidder != "appnexus" || first.Account != "pub-1" || first.Result != EventResultRecorded {
+		t.Errorf("expected the notification tied to its auction, got %+v", first)
+	}
+	if logged.notifications[1].Result != EventResultDuplicate {
+		t.Errorf("expected the repeat logged as a duplicate, got %+v", logged.notifications[1])
+	}
+}
+
+func TestEventHandler_FiresNoticeURLs(t *testing.T) {
+	fired := make(chan string, 4)
+	bidder := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fired <- r.URL.RequestURI()
+	}))
+	defer bidder.Close()
+
+	notices := exchange.NewBidNotices(time.Minute)
+	notices.Add(&exchange.BidNotice{
+		AuctionID: "a1", ImpID: "imp1", BidID: "b1", Bidder: "appnexus", Seat: "appnexus", Price: 2.25, Currency: "USD",
+		NURL: bidder.URL + "/win?p=${AUCTION_PRICE}&id=${AUCTION_ID}",
+		BURL: bidder.URL + "/bill?p=${AUCTION_PRICE}&imp=${AUCTION_IMP_ID}",
+	})
+	// The exchange already fired this one's nurl when the auction closed
+	notices.Add(&exchange.BidNotice{BidID: "b2", Bidder: "appnexus", Seat: "appnexus", NURL: bidder.URL + "/win2", NURLFired: true})
+	h := NewEventHandler(notices, nil)
+	h.SetNotifier(exchange.NewNotifier(nil))
+
+	getEvent(h, "t=win&b=b1&bidder=appnexus")
+	getEvent(h, "t=win&b=b1&bidder=appnexus")
+	getEvent(h, "t=imp&b=b1&bidder=appnexus")
+	getEvent(h, "t=win&b=b2&bidder=appnexus")
+
+	got := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		select {
+		case uri := <-fired:
+			got[uri] = true
+		case <-time.After(2 * time.Second):
+			t.Fatal("expected the nurl and burl fired")
+		}
+	}
+	if !got["/win?p=2.25&id=a1"] || !got["/bill?p=2.25&imp=imp1"] {
+		t.Errorf("expected macros expanded in the fired URLs, got %v", got)
+	}
+	select {
+	case uri := <-fired:
+		t.Errorf("expected neither the repeat win nor an exchange-fired nurl to fire, got %s", uri)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestEventHandler_Errors(t *testing.T) {
+	h := NewEventHandler(nil, nil)
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{name: "unknown type", query: "t=click&b=b1", want: "t: must be win or imp"},
+		{name: "no bid", query: "t=win", want: "b: bid ID is required"},
+		{name: "long account", query: "t=win&b=b1&a=" + strings.Repeat("x", 200), want: "too long"},
+		{name: "bad format", query: "t=win&b=b1&f=x", want: "f: must be b or i"},
+		{name: "bad timestamp", query: "t=win&b=b1&ts=yesterday", want: "ts:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := getEvent(h, tt.query)
+			if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), tt.want) {
+				t.Errorf("expected 400 containing %q, got %d %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, EventPath+"?t=win&b=b1", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", w.Code)
+	}
+}
@@ -0,0 +1,147 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/idr"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/logger"
+)
+
+// Feedback request limits; the endpoint is unauthenticated so batches stay small
+const (
+	maxFeedbackBodyBytes = 64 * 1024
+	maxFeedbackEvents    = 50
+	maxFeedbackIDLength  = 128
+	maxFeedbackReason    = 256
+)
+
+// feedbackOutcomeInvalid labels events rejected by validation in metrics
+const feedbackOutcomeInvalid = "invalid"
+
+// FeedbackRequest is the request body for /feedback. Prebid.js and SDK clients
+// batch render outcomes, typically via navigator.sendBeacon.
+type FeedbackRequest struct {
+	// Account is the publisher ID the auctions ran for
+	Account string          `json:"account,omitempty"`
+	Events  []FeedbackEvent `json:"events"`
+}
+
+// FeedbackEvent is one client-reported outcome for a bid
+type FeedbackEvent struct {
+	AuctionID string   `json:"auction_id"`       // BidRequest.ID of the auction
+	BidID     string   `json:"bid_id,omitempty"` // Bid.ID as returned in the auction response
+	Bidder    string   `json:"bidder,omitempty"` // Seat the bid was returned under
+	Outcome   string   `json:"outcome"`          // won, lost, rendered, render_failed or viewable
+	Reason    string   `json:"reason,omitempty"` // Free-form detail, e.g. the render error
+	CPM       *float64 `json:"cpm,omitempty"`    // Price the client saw, if known
+}
+
+// FeedbackResponse reports how many events were accepted
+type FeedbackResponse struct {
+	Accepted int `json:"accepted"`
+	Rejected int `json:"rejected"`
+}
+
+// FeedbackRecorder receives validated feedback events
+type FeedbackRecorder interface {
+	RecordFeedback(auctionID, bidID, bidderCode, outcome, reason string, cpm *float64, publisherID string)
+}
+
+// FeedbackMetrics counts feedback events by outcome
+type FeedbackMetrics interface {
+	IncFeedbackEvent(outcome string)
+}
+
+// FeedbackHandler handles /feedback, closing the loop between auction decisions
+// and what actually rendered so IDR can train on final outcomes
+type FeedbackHandler struct {
+	recorder FeedbackRecorder // nil accepts and discards events
+	metrics  FeedbackMetrics
+}
+
+// NewFeedbackHandler creates a feedback handler; recorder may be nil when event
+// recording is disabled so clients don't see errors
+func NewFeedbackHandler(recorder FeedbackRecorder) *FeedbackHandler {
+	return &FeedbackHandler{recorder: recorder}
+}
+
+// SetMetrics sets the feedback metrics recorder
+func (h *FeedbackHandler) SetMetrics(m FeedbackMetrics) {
+	h.metrics = m
+}
+
+// ServeHTTP handles feedback requests
+func (h *FeedbackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// sendBeacon posts text/plain, so the content type is not checked
+	var req FeedbackRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxFeedbackBodyBytes)).Decode(&req); err != nil {
+		writeError(w, "Invalid JSON in request body", http.StatusBadRequest)
+		return
+	}
+	if len(req.Events) == 0 {
+		writeError(w, "events: at least one event required", http.StatusBadRequest)
+		return
+	}
+	if len(req.Events) > maxFeedbackEvents {
+		writeError(w, "events: too many events in one request", http.StatusBadRequest)
+		return
+	}
+	if len(req.Account) > maxFeedbackIDLength {
+		writeError(w, "account: too long", http.StatusBadRequest)
+		return
+	}
+
+	var resp FeedbackResponse
+	for _, ev := range req.Events {
+		if !validFeedbackEvent(&ev) {
+			resp.Rejected++
+			h.count(feedbackOutcomeInvalid)
+			continue
+		}
+		if h.recorder != nil {
+			h.recorder.RecordFeedback(ev.AuctionID, ev.BidID, ev.Bidder, ev.Outcome, ev.Reason, ev.CPM, req.Account)
+		}
+		resp.Accepted++
+		h.count(ev.Outcome)
+	}
+
+	if resp.Rejected > 0 {
+		logger.Log.Debug().
+			Str("account", req.Account).
+			Int("accepted", resp.Accepted).
+			Int("rejected", resp.Rejected).
+			Msg("Rejected invalid feedback events")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logger.Log.Error().Err(err).Msg("failed to encode feedback response")
+	}
+}
+
+func (h *FeedbackHandler) count(outcome string) {
+	if h.metrics != nil {
+		h.metrics.IncFeedbackEvent(outcome)
+	}
+}
+
+// validFeedbackEvent checks required fields and bounds client-supplied strings
+func validFeedbackEvent(ev *FeedbackEvent) bool {
+	if ev.AuctionID == "" || !idr.ValidFeedbackOutcome(ev.Outcome) {
+		return false
+	}
+	if len(ev.AuctionID) > maxFeedbackIDLength || len(ev.BidID) > maxFeedbackIDLength ||
+		len(ev.Bidder) > maxFeedbackIDLength || len(ev.Reason) > maxFeedbackReason {
+		return false
+	}
+	if ev.CPM != nil && *ev.CPM < 0 {
+		return false
+	}
+	return true
+}
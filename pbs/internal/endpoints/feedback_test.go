@@ -0,0 +1,116 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type recordedFeedback struct {
+	auctionID, bidID, bidder, outcome, reason, publisherID string
+}
+
+type mockFeedbackRecorder struct {
+	events []recordedFeedback
+}
+
+func (m *mockFeedbackRecorder) RecordFeedback(auctionID, bidID, bidderCode, outcome, reason string, cpm *float64, publisherID string) {
+	m.events = append(m.events, recordedFeedback{auctionID, bidID, bidderCode, outcome, reason, publisherID})
+}
+
+type mockFeedbackMetrics struct {
+	counts map[string]int
+}
+
+func (m *mockFeedbackMetrics) IncFeedbackEvent(outcome string) {
+	m.counts[outcome]++
+}
+
+func postFeedback(h http.Handler, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/feedback", strings.NewReader(body))
+	// sendBeacon default content type
+	req.Header.Set("Content-Type", "text/plain;charset=UTF-8")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestFeedbackHandler_RecordsValidEvents(t *testing.T) {
+	recorder := &mockFeedbackRecorder{}
+	metrics := &mockFeedbackMetrics{counts: make(map[string]int)}
+	h := NewFeedbackHandler(recorder)
+	h.SetMetrics(metrics)
+
+	w := postFeedback(h, `{"account":"pub-1","events":[
+		{"auction_id":"a1","bid_id":"b1","bidder":"appnexus","outcome":"rendered"},
+		{"auction_id":"a1","bid_id":"b2","bidder":"rubicon","outcome":"render_failed","reason":"blocked"},
+		{"auction_id":"a1","outcome":"clicked"},
+		{"bid_id":"b3","outcome":"viewable"}
+	]}`)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp FeedbackResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if resp.Accepted != 2 || resp.Rejected != 2 {
+		t.Errorf("expected 2 accepted and 2 rejected, got %+v", resp)
+	}
+
+	if len(recorder.events) != 2 {
+		t.Fatalf("expected 2 recorded events, got %+v", recorder.events)
+	}
+	want := recordedFeedback{"a1", "b2", "rubicon", "render_failed", "blocked", "pub-1"}
+	if recorder.events[1] != want {
+		t.Errorf("expected %+v, got %+v", want, recorder.events[1])
+	}
+
+	if metrics.counts["rendered"] != 1 || metrics.counts["render_failed"] != 1 || metrics.counts["invalid"] != 2 {
+		t.Errorf("unexpected metric counts %v", metrics.counts)
+	}
+}
+
+func TestFeedbackHandler_BadRequests(t *testing.T) {
+	h := NewFeedbackHandler(&mockFeedbackRecorder{})
+
+	tooMany := make([]string, maxFeedbackEvents+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf(`{"auction_id":"a%d","outcome":"won"}`, i)
+	}
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"invalid json", `{"events":`},
+		{"no events", `{"events":[]}`},
+		{"too many events", `{"events":[` + strings.Join(tooMany, ",") + `]}`},
+		{"oversized body", `{"events":[{"auction_id":"a1","outcome":"won","reason":"` + strings.Repeat("x", maxFeedbackBodyBytes) + `"}]}`},
+	}
+	for _, tt := range tests {
+		if w := postFeedback(h, tt.body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", tt.name, w.Code)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/feedback", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for GET, got %d", w.Code)
+	}
+}
+
+func TestFeedbackHandler_NilRecorder(t *testing.T) {
+	h := NewFeedbackHandler(nil)
+
+	w := postFeedback(h, `{"events":[{"auction_id":"a1","outcome":"won"}]}`)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200 with recording disabled, got %d", w.Code)
+	}
+}
@@ -0,0 +1,116 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/flags"
+
+	log "github.com/rs/zerolog/log"
+)
+
+// maxFlagRequestSize bounds admin flag request bodies
+const maxFlagRequestSize = 4096
+
+// FlagsHandler handles /admin/flags requests
+//
+//	GET    /admin/flags              list effective flag states
+//	GET    /admin/flags?audit=1      list the change history
+//	POST   /admin/flags              set an override: {"flag","enabled","ttl","reason"}
+//	DELETE /admin/flags?flag=<name>  reset a flag to its default
+type FlagsHandler struct {
+	registry *flags.Registry
+}
+
+// NewFlagsHandler creates a new flags admin handler
+func NewFlagsHandler(registry *flags.Registry) *FlagsHandler {
+	return &FlagsHandler{registry: registry}
+}
+
+// FlagUpdateRequest is the body accepted by POST /admin/flags
+type FlagUpdateRequest struct {
+	Flag    string `json:"flag"`
+	Enabled bool   `json:"enabled"`
+	TTL     string `json:"ttl,omitempty"` // Go duration, e.g. "30m"; empty means until reset
+	Reason  string `json:"reason,omitempty"`
+}
+
+// ServeHTTP handles flag admin requests
+func (h *FlagsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		if r.URL.Query().Get("audit") == "1" {
+			writeJSON(w, h.registry.Audit())
+			return
+		}
+		writeJSON(w, h.registry.Snapshot())
+
+	case http.MethodPost, http.MethodPut:
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxFlagRequestSize))
+		if err != nil {
+			writeError(w, "Failed to read request body", http.StatusBadRequest)
+			return
+		}
+
+		var req FlagUpdateRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			writeError(w, "Invalid JSON in request body", http.StatusBadRequest)
+			return
+		}
+		if !flags.Known(flags.Flag(req.Flag)) {
+			writeError(w, "unknown flag: "+req.Flag, http.StatusBadRequest)
+			return
+		}
+
+		var ttl time.Duration
+		if req.TTL != "" {
+			ttl, err = time.ParseDuration(req.TTL)
+			if err != nil || ttl <= 0 {
+				writeError(w, "ttl must be a positive duration", http.StatusBadRequest)
+				return
+			}
+		}
+
+		if err := h.registry.Set(r.Context(), flags.Flag(req.Flag), req.Enabled, ttl, flagActor(r), req.Reason); err != nil {
+			// The local override is in place; only cross-instance sync failed
+			log.Error().Err(err).Str("flag", req.Flag).Msg("failed to sync flag override")
+			writeError(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		writeJSON(w, h.registry.Snapshot())
+
+	case http.MethodDelete:
+		name := r.URL.Query().Get("flag")
+		if !flags.Known(flags.Flag(name)) {
+			writeError(w, "unknown flag: "+name, http.StatusBadRequest)
+			return
+		}
+		if err := h.registry.Reset(r.Context(), flags.Flag(name), flagActor(r), r.URL.Query().Get("reason")); err != nil {
+			log.Error().Err(err).Str("flag", name).Msg("failed to sync flag reset")
+			writeError(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		writeJSON(w, h.registry.Snapshot())
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// flagActor identifies who made a change for the audit trail
+func flagActor(r *http.Request) string {
+	if actor := r.Header.Get("X-Admin-User"); actor != "" {
+		return actor
+	}
+	return r.RemoteAddr
+}
+
+// writeJSON writes a 200 JSON response
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Error().Err(err).Msg("failed to encode response")
+	}
+}
@@ -0,0 +1,101 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/flags"
+)
+
+func TestFlagsHandler_List(t *testing.T) {
+	handler := NewFlagsHandler(flags.NewRegistry())
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/flags", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var states []flags.State
+	if err := json.Unmarshal(w.Body.Bytes(), &states); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if len(states) == 0 {
+		t.Error("expected flag states")
+	}
+}
+
+func TestFlagsHandler_SetAndReset(t *testing.T) {
+	registry := flags.NewRegistry()
+	handler := NewFlagsHandler(registry)
+
+	body := `{"flag":"floor_enforcement","enabled":false,"ttl":"15m","reason":"bad floors"}`
+	req := httptest.NewRequest(http.MethodPost, "/admin/flags", strings.NewReader(body))
+	req.Header.Set("X-Admin-User", "oncall")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if registry.Enabled(flags.FloorEnforcement) {
+		t.Error("expected floor enforcement disabled")
+	}
+	audit := registry.Audit()
+	if len(audit) != 1 || audit[0].Actor != "oncall" || audit[0].ExpiresAt == nil {
+		t.Errorf("unexpected audit: %+v", audit)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/admin/flags?flag=floor_enforcement", nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if !registry.Enabled(flags.FloorEnforcement) {
+		t.Error("expected floor enforcement restored")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/admin/flags?audit=1", nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	var entries []flags.AuditEntry
+	if err := json.Unmarshal(w.Body.Bytes(), &entries); err != nil || len(entries) != 2 {
+		t.Errorf("expected 2 audit entries, got %s", w.Body.String())
+	}
+}
+
+func TestFlagsHandler_BadRequests(t *testing.T) {
+	handler := NewFlagsHandler(flags.NewRegistry())
+
+	tests := []struct {
+		name   string
+		method string
+		url    string
+		body   string
+		want   int
+	}{
+		{"invalid json", http.MethodPost, "/admin/flags", "{", http.StatusBadRequest},
+		{"unknown flag", http.MethodPost, "/admin/flags", `{"flag":"nope","enabled":true}`, http.StatusBadRequest},
+		{"bad ttl", http.MethodPost, "/admin/flags", `{"flag":"deal_validation","enabled":true,"ttl":"soon"}`, http.StatusBadRequest},
+		{"unknown reset", http.MethodDelete, "/admin/flags?flag=nope", "", http.StatusBadRequest},
+		{"bad method", http.MethodPatch, "/admin/flags", "", http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package endpoints
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+)
+
+// forwardAuction runs an OpenRTB request built by another endpoint (AMP,
+// video) through auction, the handler chain serving the regular OpenRTB
+// endpoint, and captures the response. The original query carries debug,
+// diagnostics and account through unchanged.
+func forwardAuction(r *http.Request, body []byte, auction http.Handler) *forwardedResponse {
+	fwd := r.Clone(r.Context())
+	fwd.Method = http.MethodPost
+	fwd.URL.Path = AuctionV1Path
+	fwd.Body = io.NopCloser(bytes.NewReader(body))
+	fwd.ContentLength = int64(len(body))
+	fwd.Header.Set("Content-Type", "application/json")
+	rec := &forwardedResponse{header: make(http.Header)}
+	auction.ServeHTTP(rec, fwd)
+	return rec
+}
+
+// forwardedResponse captures a forwarded auction's response
+type forwardedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *forwardedResponse) Header() http.Header {
+	return r.header
+}
+
+func (r *forwardedResponse) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+	}
+}
+
+func (r *forwardedResponse) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(b)
+}
+
+// writeTo passes a rejection (privacy, publisher auth, validation) to the
+// client as is
+func (r *forwardedResponse) writeTo(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", r.header.Get("Content-Type"))
+	w.WriteHeader(r.status)
+	w.Write(r.body.Bytes())
+}
@@ -3,14 +3,20 @@ package endpoints
 import (
 	"net/http"
 	"strings"
+	"sync"
 
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/analytics"
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/usersync"
 	"github.com/StreetsDigital/thenexusengine/pbs/pkg/logger"
 )
 
 // SetUIDHandler handles the /setuid endpoint for storing bidder user IDs
 type SetUIDHandler struct {
+	mu           sync.RWMutex
 	validBidders map[string]bool
+	analytics    analytics.Module      // nil logs nothing
+	cookies      *usersync.CookieCodec // nil reads and writes plain cookies
+	store        *usersync.UIDStore    // nil keeps UIDs in the cookie
 }
 
 // NewSetUIDHandler creates a new setuid handler
@@ -36,23 +42,35 @@ func (h *SetUIDHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	bidder := query.Get("bidder")
 	uid := query.Get("uid")
 
+	// Analytics see every call, including rejected ones
+	so := &analytics.SetUIDObject{Status: http.StatusOK, Bidder: bidder, UID: uid}
+	if h.analytics != nil {
+		defer h.analytics.LogSetUIDObject(so)
+	}
+
 	// Validate bidder
 	if bidder == "" {
+		so.Status = http.StatusBadRequest
+		so.Errors = append(so.Errors, "missing bidder parameter")
 		http.Error(w, "missing bidder parameter", http.StatusBadRequest)
 		return
 	}
 
 	bidderLower := strings.ToLower(bidder)
-	if !h.validBidders[bidderLower] {
+	h.mu.RLock()
+	known := h.validBidders[bidderLower]
+	h.mu.RUnlock()
+	if !known {
 		logger.Log.Warn().Str("bidder", bidder).Msg("Unknown bidder in setuid request")
 		// Still process - bidder might be dynamically registered
 	}
 
 	// Parse existing cookie
-	cookie := usersync.ParseCookie(r)
+	cookie := h.store.ParseCookie(r, h.cookies)
 
 	// Check for opt-out
 	if cookie.IsOptOut() {
+		so.Errors = append(so.Errors, "user has opted out")
 		h.respondWithPixel(w)
 		return
 	}
@@ -74,9 +92,11 @@ func (h *SetUIDHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	// Set the updated cookie
 	domain := h.getCookieDomain(r)
-	if httpCookie, err := cookie.ToHTTPCookie(domain); err == nil {
+	if httpCookie, err := h.store.HTTPCookie(r.Context(), cookie, domain, h.cookies); err == nil {
 		http.SetCookie(w, httpCookie)
+		so.Success = true
 	} else {
+		so.Errors = append(so.Errors, err.Error())
 		logger.Log.Error().Err(err).Msg("Failed to create cookie")
 	}
 
@@ -84,6 +104,21 @@ func (h *SetUIDHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.respondWithPixel(w)
 }
 
+// SetAnalytics sets the analytics modules every setuid call is logged to
+func (h *SetUIDHandler) SetAnalytics(m analytics.Module) {
+	h.analytics = m
+}
+
+// SetCookieCodec sets how the uids cookie is signed or encrypted
+func (h *SetUIDHandler) SetCookieCodec(codec *usersync.CookieCodec) {
+	h.cookies = codec
+}
+
+// SetUIDStore keeps UIDs server-side, with the cookie only carrying their ID
+func (h *SetUIDHandler) SetUIDStore(store *usersync.UIDStore) {
+	h.store = store
+}
+
 // getCookieDomain extracts the domain for cookies
 func (h *SetUIDHandler) getCookieDomain(r *http.Request) string {
 	host := r.Host
@@ -115,21 +150,47 @@ func (h *SetUIDHandler) respondWithPixel(w http.ResponseWriter) {
 
 // AddBidder adds a valid bidder code
 func (h *SetUIDHandler) AddBidder(bidder string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
 	h.validBidders[strings.ToLower(bidder)] = true
 }
 
+// SetBidders replaces the valid bidder codes, e.g. when syncers are reloaded
+func (h *SetUIDHandler) SetBidders(bidders []string) {
+	bidderMap := make(map[string]bool, len(bidders))
+	for _, b := range bidders {
+		bidderMap[strings.ToLower(b)] = true
+	}
+	h.mu.Lock()
+	h.validBidders = bidderMap
+	h.mu.Unlock()
+}
+
 // OptOutHandler handles opt-out requests
-type OptOutHandler struct{}
+type OptOutHandler struct {
+	cookies *usersync.CookieCodec // nil reads and writes plain cookies
+	store   *usersync.UIDStore    // nil keeps UIDs in the cookie
+}
 
 // NewOptOutHandler creates a new opt-out handler
 func NewOptOutHandler() *OptOutHandler {
 	return &OptOutHandler{}
 }
 
+// SetCookieCodec sets how the uids cookie is signed or encrypted
+func (h *OptOutHandler) SetCookieCodec(codec *usersync.CookieCodec) {
+	h.cookies = codec
+}
+
+// SetUIDStore keeps UIDs server-side, with the cookie only carrying their ID
+func (h *OptOutHandler) SetUIDStore(store *usersync.UIDStore) {
+	h.store = store
+}
+
 // ServeHTTP handles the /optout endpoint
 func (h *OptOutHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Parse existing cookie
-	cookie := usersync.ParseCookie(r)
+	cookie := h.store.ParseCookie(r, h.cookies)
 
 	// Set opt-out
 	cookie.SetOptOut(true)
@@ -140,7 +201,7 @@ func (h *OptOutHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		domain = domain[:idx]
 	}
 
-	if httpCookie, err := cookie.ToHTTPCookie(domain); err == nil {
+	if httpCookie, err := h.store.HTTPCookie(r.Context(), cookie, domain, h.cookies); err == nil {
 		http.SetCookie(w, httpCookie)
 	}
 
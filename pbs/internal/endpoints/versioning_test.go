@@ -0,0 +1,181 @@
+package endpoints
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/exchange"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+type mockVersionMetrics struct {
+	outcomes map[string]int
+}
+
+func (m *mockVersionMetrics) RecordAuctionAPIRequest(version, outcome string) {
+	if m.outcomes == nil {
+		m.outcomes = make(map[string]int)
+	}
+	m.outcomes[version+":"+outcome]++
+}
+
+// noBidAdapter sends requests to a local server that always answers 204
+type noBidAdapter struct {
+	url string
+}
+
+func (a *noBidAdapter) MakeRequests(request *openrtb.BidRequest, reqInfo *adapters.ExtraRequestInfo) ([]*adapters.RequestData, []error) {
+	return []*adapters.RequestData{{Method: "POST", URI: a.url, Body: []byte("{}")}}, nil
+}
+
+func (a *noBidAdapter) MakeBids(request *openrtb.BidRequest, response *adapters.ResponseData) (*adapters.BidderResponse, []error) {
+	return nil, nil
+}
+
+func postAuction(t *testing.T, h http.Handler, path string, bidReq *openrtb.BidRequest) *httptest.ResponseRecorder {
+	t.Helper()
+	body, _ := json.Marshal(bidReq)
+	req := httptest.NewRequest("POST", path, bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func newTestExchange() *exchange.Exchange {
+	return exchange.New(adapters.NewRegistry(), &exchange.Config{
+		DefaultTimeout: 100 * time.Millisecond,
+	})
+}
+
+func TestVersionedAuctionHandler_VersionHeader(t *testing.T) {
+	ex := newTestExchange()
+	for _, version := range []APIVersion{AuctionV1, AuctionV2} {
+		w := postAuction(t, NewVersionedAuctionHandler(ex, version), AuctionV1Path, validBidRequest())
+		if got := w.Header().Get(APIVersionHeader); got != string(version) {
+			t.Errorf("expected %s header %q, got %q", APIVersionHeader, version, got)
+		}
+		if w.Header().Get("Deprecation") != "" {
+			t.Errorf("%s: expected no Deprecation header by default", version)
+		}
+	}
+}
+
+func TestAuctionHandler_StrictValidationOnlyInV2(t *testing.T) {
+	ex := newTestExchange()
+	v1 := NewAuctionHandler(ex)
+	v2 := NewVersionedAuctionHandler(ex, AuctionV2)
+
+	tests := []struct {
+		name   string
+		modify func(*openrtb.BidRequest)
+	}{
+		{"duplicate imp ids", func(r *openrtb.BidRequest) {
+			r.Imp = append(r.Imp, openrtb.Imp{ID: "imp-1", Banner: &openrtb.Banner{W: 728, H: 90}})
+		}},
+		{"site and app", func(r *openrtb.BidRequest) { r.App = &openrtb.App{ID: "app-1"} }},
+		{"negative tmax", func(r *openrtb.BidRequest) { r.TMax = -1 }},
+		{"negative bidfloor", func(r *openrtb.BidRequest) { r.Imp[0].BidFloor = -0.5 }},
+		{"invalid currency", func(r *openrtb.BidRequest) { r.Cur = []string{"DOLLARS"} }},
+		{"banner without size", func(r *openrtb.BidRequest) { r.Imp[0].Banner = &openrtb.Banner{} }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := validBidRequest()
+			tt.modify(req)
+
+			// Legacy behavior is preserved: these pass endpoint validation in v1
+			if w := postAuction(t, v1, AuctionV1Path, req); w.Code == http.StatusBadRequest {
+				t.Errorf("v1: expected legacy status, got 400: %s", w.Body.String())
+			}
+			if w := postAuction(t, v2, AuctionV2Path, req); w.Code != http.StatusBadRequest {
+				t.Errorf("v2: expected 400, got %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestAuctionHandler_AlwaysIncludesResponseExt(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	registry := adapters.NewRegistry()
+	registry.Register("testbidder", &noBidAdapter{url: server.URL}, adapters.BidderInfo{Enabled: true})
+	ex := exchange.New(registry, &exchange.Config{
+		DefaultTimeout: 500 * time.Millisecond,
+	})
+
+	for path, handler := range map[string]http.Handler{
+		AuctionV1Path: NewAuctionHandler(ex),
+		AuctionV2Path: NewVersionedAuctionHandler(ex, AuctionV2),
+	} {
+		bidReq := validBidRequest()
+		bidReq.TMax = 400
+		w := postAuction(t, handler, path, bidReq)
+		var resp openrtb.BidResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s: failed to parse response: %v", path, err)
+		}
+		var ext openrtb.BidResponseExt
+		if err := json.Unmarshal(resp.Ext, &ext); err != nil {
+			t.Fatalf("%s: expected ext without debug, got %s", path, resp.Ext)
+		}
+		if _, ok := ext.ResponseTimeMillis["testbidder"]; !ok {
+			t.Errorf("%s: expected responsetimemillis for testbidder, got %v", path, ext.ResponseTimeMillis)
+		}
+		if ext.TMMaxRequest != 400 {
+			t.Errorf("%s: expected tmaxrequest 400, got %d", path, ext.TMMaxRequest)
+		}
+		if len(ext.Errors) != 0 {
+			t.Errorf("%s: expected no errors, got %v", path, ext.Errors)
+		}
+	}
+}
+
+func TestAuctionHandler_DeprecationHeaders(t *testing.T) {
+	handler := NewAuctionHandler(newTestExchange())
+	handler.SetDeprecation(&Deprecation{
+		Sunset:    time.Date(2027, 6, 30, 0, 0, 0, 0, time.UTC),
+		Successor: AuctionV2Path,
+	})
+
+	// Headers are sent even when the request is rejected
+	req := validBidRequest()
+	req.ID = ""
+	w := postAuction(t, handler, AuctionV1Path, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+	if got := w.Header().Get("Deprecation"); got != "true" {
+		t.Errorf("expected Deprecation: true, got %q", got)
+	}
+	if got := w.Header().Get("Sunset"); got != "Wed, 30 Jun 2027 00:00:00 GMT" {
+		t.Errorf("unexpected Sunset header %q", got)
+	}
+	if got := w.Header().Get("Link"); got != `</openrtb2/auction/v2>; rel="successor-version"` {
+		t.Errorf("unexpected Link header %q", got)
+	}
+}
+
+func TestAuctionHandler_VersionMetrics(t *testing.T) {
+	metrics := &mockVersionMetrics{}
+	handler := NewVersionedAuctionHandler(newTestExchange(), AuctionV2)
+	handler.SetMetrics(metrics)
+
+	postAuction(t, handler, AuctionV2Path, validBidRequest())
+	bad := validBidRequest()
+	bad.Imp = nil
+	postAuction(t, handler, AuctionV2Path, bad)
+
+	if metrics.outcomes["v2:ok"] != 1 || metrics.outcomes["v2:rejected"] != 1 {
+		t.Errorf("expected one ok and one rejected v2 request, got %v", metrics.outcomes)
+	}
+}
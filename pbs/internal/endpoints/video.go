@@ -0,0 +1,476 @@
+package endpoints
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/storedrequests"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/logger"
+)
+
+// VideoPath is the long-form (CTV) video endpoint
+const VideoPath = "/openrtb2/video"
+
+// maxVideoImps caps the impressions a request's pods expand into
+const maxVideoImps = 100
+
+// VideoRequest is the long-form video request: pods to fill rather than
+// impressions, with the OpenRTB context they share
+type VideoRequest struct {
+	StoredRequestID  string          `json:"storedrequestid,omitempty"`
+	PodConfig        PodConfig       `json:"podconfig"`
+	Site             *openrtb.Site   `json:"site,omitempty"`
+	App              *openrtb.App    `json:"app,omitempty"`
+	Device           *openrtb.Device `json:"device,omitempty"`
+	User             *openrtb.User   `json:"user,omitempty"`
+	Regs             *openrtb.Regs   `json:"regs,omitempty"`
+	Video            *openrtb.Video  `json:"video,omitempty"` // Template for every pod impression
+	BCat             []string        `json:"bcat,omitempty"`
+	BAdv             []string        `json:"badv,omitempty"`
+	PriceGranularity json.RawMessage `json:"pricegranularity,omitempty"`
+	CacheConfig      *VideoCache     `json:"cacheconfig,omitempty"`
+	TMax             int             `json:"tmax,omitempty"`
+}
+
+// PodConfig describes the ad pods to fill
+type PodConfig struct {
+	DurationRangeSec     []int `json:"durationrangesec"` // Allowed creative durations
+	RequireExactDuration bool  `json:"requireexactduration,omitempty"`
+	Pods                 []Pod `json:"pods"`
+}
+
+// Pod is one ad break
+type Pod struct {
+	PodID            int    `json:"podid"`
+	AdPodDurationSec int    `json:"adpoddurationsec"`
+	ConfigID         string `json:"configid,omitempty"` // Stored imp applied to the pod's impressions
+}
+
+// VideoCache controls how long cached VAST lives
+type VideoCache struct {
+	TTL int `json:"ttl,omitempty"` // Seconds; 0 uses the cache's default
+}
+
+// VideoResponse is the ad pod response: the targeting for each pod's selected bids
+type VideoResponse struct {
+	AdPods []AdPod         `json:"adPods"`
+	Ext    json.RawMessage `json:"ext,omitempty"` // Debug and diagnostics, when requested
+}
+
+// AdPod is the targeting for one pod, in price order
+type AdPod struct {
+	PodID     int              `json:"podid"`
+	Targeting []VideoTargeting `json:"targeting"`
+	Errors    []string         `json:"errors,omitempty"`
+}
+
+// VideoTargeting is the ad server targeting for one selected bid
+type VideoTargeting struct {
+	PriceBucket string `json:"hb_pb"`
+	PriceCatDur string `json:"hb_pb_cat_dur"` // <hb_pb>_<category>_<duration>s, without the category when the bid has none
+	CacheID     string `json:"hb_cache_id,omitempty"`
+	DealID      string `json:"hb_deal,omitempty"`
+}
+
+// VideoHandler handles POST /openrtb2/video requests. Each pod is expanded
+// into as many video impressions as its shortest allowed creative fits, the
+// request is run through auction like AMP's, and the returned bids are
+// packed into their pods by price without repeating a category or
+// advertiser domain within a pod.
+type VideoHandler struct {
+	stored  StoredRequestResolver // nil without Redis; storedrequestid is then rejected
+	auction http.Handler
+}
+
+// NewVideoHandler creates a video handler; stored may be nil
+func NewVideoHandler(stored StoredRequestResolver, auction http.Handler) *VideoHandler {
+	return &VideoHandler{stored: stored, auction: auction}
+}
+
+// videoPod is a pod expanded into impressions
+type videoPod struct {
+	Pod
+	impDurations map[string]int // imp ID -> max duration
+}
+
+// ServeHTTP handles the video request
+func (h *VideoHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	videoReq, err := h.parseRequest(r.Context(), body)
+	if err == nil {
+		err = validateVideoRequest(videoReq)
+	}
+	var bidRequest *openrtb.BidRequest
+	var pods []videoPod
+	if err == nil {
+		bidRequest, pods, err = h.buildRequest(r.Context(), videoReq)
+	}
+	if err != nil {
+		var notFound *storedrequests.NotFoundError
+		var invalid *ValidationError
+		var syntax *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &notFound), errors.As(err, &invalid):
+			writeError(w, err.Error(), http.StatusBadRequest)
+		case errors.As(err, &syntax), errors.As(err, &typeErr):
+			writeError(w, "Invalid JSON in request body", http.StatusBadRequest)
+		default:
+			logger.Log.Error().Err(err).Msg("Failed to build video request")
+			writeError(w, "Failed to load video request", http.StatusInternalServerError)
+		}
+		return
+	}
+	forwardBody, err := json.Marshal(bidRequest)
+	if err != nil {
+		writeError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	rec := forwardAuction(r, forwardBody, h.auction)
+	if rec.status != http.StatusOK {
+		rec.writeTo(w)
+		return
+	}
+
+	var bidResponse openrtb.BidResponse
+	if err := json.Unmarshal(rec.body.Bytes(), &bidResponse); err != nil {
+		logger.Log.Error().Err(err).Str("request_id", bidRequest.ID).Msg("Failed to parse video auction response")
+		writeError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	resp := VideoResponse{
+		AdPods: buildAdPods(pods, videoReq.PodConfig, &bidResponse),
+		Ext:    bidResponse.Ext,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logger.Log.Error().Err(err).Str("request_id", bidRequest.ID).Msg("failed to encode video response")
+	}
+}
+
+// parseRequest reads the video request, merged over its stored request when
+// it names one
+func (h *VideoHandler) parseRequest(ctx context.Context, body []byte) (*VideoRequest, error) {
+	var req VideoRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, err
+	}
+	if req.StoredRequestID == "" {
+		return &req, nil
+	}
+	if h.stored == nil {
+		return nil, &ValidationError{Field: "storedrequestid", Message: "stored requests are unavailable", Index: -1}
+	}
+
+	// The resolver merges the stored request under the body it references
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+	ref, err := json.Marshal(map[string]interface{}{"prebid": map[string]interface{}{"storedrequest": map[string]string{"id": req.StoredRequestID}}})
+	if err != nil {
+		return nil, err
+	}
+	fields["ext"] = ref
+	withRef, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	resolved, err := h.stored.Resolve(ctx, withRef)
+	if err != nil {
+		return nil, err
+	}
+	var merged VideoRequest
+	if err := json.Unmarshal(resolved, &merged); err != nil {
+		return nil, fmt.Errorf("stored request %s: %w", req.StoredRequestID, err)
+	}
+	return &merged, nil
+}
+
+// validateVideoRequest checks the pod configuration
+func validateVideoRequest(req *VideoRequest) error {
+	if req.Video == nil {
+		return &ValidationError{Field: "video", Message: "required", Index: -1}
+	}
+	if len(req.PodConfig.DurationRangeSec) == 0 {
+		return &ValidationError{Field: "podconfig.durationrangesec", Message: "at least one duration required", Index: -1}
+	}
+	for i, d := range req.PodConfig.DurationRangeSec {
+		if d <= 0 {
+			return &ValidationError{Field: "podconfig.durationrangesec", Message: "durations must be positive", Index: i}
+		}
+	}
+	if len(req.PodConfig.Pods) == 0 {
+		return &ValidationError{Field: "podconfig.pods", Message: "at least one pod required", Index: -1}
+	}
+	seen := make(map[int]bool, len(req.PodConfig.Pods))
+	for i, pod := range req.PodConfig.Pods {
+		if pod.AdPodDurationSec <= 0 {
+			return &ValidationError{Field: "podconfig.pods.adpoddurationsec", Message: "must be positive", Index: i}
+		}
+		if seen[pod.PodID] {
+			return &ValidationError{Field: "podconfig.pods.podid", Message: fmt.Sprintf("duplicate pod ID %d", pod.PodID), Index: i}
+		}
+		seen[pod.PodID] = true
+	}
+	return nil
+}
+
+// buildRequest expands the pods into an OpenRTB request. A pod of
+// adpoddurationsec gets one impression per shortest allowed duration that
+// fits, named "<podid>_<n>"; with requireexactduration the impressions cycle
+// through the allowed durations exactly.
+func (h *VideoHandler) buildRequest(ctx context.Context, req *VideoRequest) (*openrtb.BidRequest, []videoPod, error) {
+	ranges := sortedDurations(req.PodConfig.DurationRangeSec)
+	minDur, maxDur := ranges[0], ranges[len(ranges)-1]
+
+	bidRequest := &openrtb.BidRequest{
+		ID:     newRequestID(),
+		Site:   req.Site,
+		App:    req.App,
+		Device: req.Device,
+		User:   req.User,
+		Regs:   req.Regs,
+		BCat:   req.BCat,
+		BAdv:   req.BAdv,
+		TMax:   req.TMax,
+	}
+	pods := make([]videoPod, 0, len(req.PodConfig.Pods))
+	for _, pod := range req.PodConfig.Pods {
+		count := pod.AdPodDurationSec / minDur
+		if len(bidRequest.Imp)+count > maxVideoImps {
+			return nil, nil, &ValidationError{Field: "podconfig.pods", Message: fmt.Sprintf("pods expand to more than %d impressions", maxVideoImps), Index: -1}
+		}
+		vp := videoPod{Pod: pod, impDurations: make(map[string]int, count)}
+		for i := 0; i < count; i++ {
+			video := *req.Video
+			video.MinDuration, video.MaxDuration = 0, maxDur
+			if req.PodConfig.RequireExactDuration {
+				video.MinDuration = ranges[i%len(ranges)]
+				video.MaxDuration = video.MinDuration
+			}
+			imp := openrtb.Imp{ID: fmt.Sprintf("%d_%d", pod.PodID, i), Video: &video}
+			if pod.ConfigID != "" {
+				imp.Ext = storedImpRef(pod.ConfigID)
+			}
+			vp.impDurations[imp.ID] = video.MaxDuration
+			bidRequest.Imp = append(bidRequest.Imp, imp)
+		}
+		pods = append(pods, vp)
+	}
+	if len(bidRequest.Imp) == 0 {
+		return nil, nil, &ValidationError{Field: "podconfig.pods", Message: "no pod fits a creative of the allowed durations", Index: -1}
+	}
+
+	ext, err := videoRequestExt(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	bidRequest.Ext = ext
+
+	// Pods with a configid reference stored imps, which the resolver expands
+	if h.stored != nil && hasConfigIDs(req.PodConfig.Pods) {
+		body, err := json.Marshal(bidRequest)
+		if err != nil {
+			return nil, nil, err
+		}
+		if body, err = h.stored.Resolve(ctx, body); err != nil {
+			return nil, nil, err
+		}
+		var resolved openrtb.BidRequest
+		if err := json.Unmarshal(body, &resolved); err != nil {
+			return nil, nil, fmt.Errorf("stored imps: %w", err)
+		}
+		bidRequest = &resolved
+	}
+	return bidRequest, pods, nil
+}
+
+// videoRequestExt turns on targeting, with the request's price granularity,
+// and VAST caching: ad servers fetch pod creatives by hb_cache_id
+func videoRequestExt(req *VideoRequest) (json.RawMessage, error) {
+	targeting := map[string]json.RawMessage{}
+	if len(req.PriceGranularity) > 0 {
+		targeting["pricegranularity"] = req.PriceGranularity
+	}
+	vastXML := map[string]int{}
+	if req.CacheConfig != nil && req.CacheConfig.TTL > 0 {
+		vastXML["ttlseconds"] = req.CacheConfig.TTL
+	}
+	return json.Marshal(map[string]interface{}{
+		"prebid": map[string]interface{}{
+			"targeting": targeting,
+			"cache":     map[string]interface{}{"vastxml": vastXML},
+		},
+	})
+}
+
+// storedImpRef is an imp ext referencing a stored imp
+func storedImpRef(id string) json.RawMessage {
+	ref, _ := json.Marshal(map[string]interface{}{"prebid": map[string]interface{}{"storedrequest": map[string]string{"id": id}}})
+	return ref
+}
+
+func hasConfigIDs(pods []Pod) bool {
+	for _, pod := range pods {
+		if pod.ConfigID != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedDurations returns the allowed durations ascending, without duplicates
+func sortedDurations(durations []int) []int {
+	sorted := append([]int(nil), durations...)
+	sort.Ints(sorted)
+	out := sorted[:0]
+	for i, d := range sorted {
+		if i == 0 || d != sorted[i-1] {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// podBid is a returned bid with what pod selection needs
+type podBid struct {
+	bid      *openrtb.Bid
+	seat     string
+	ext      openrtb.BidExt
+	category string
+	domain   string
+	duration int // Rounded up to an allowed duration
+}
+
+// buildAdPods fills each pod from the bids on its impressions: highest price
+// first, skipping bids whose category or advertiser domain the pod already
+// has, until the pod's duration or impression count is used up
+func buildAdPods(pods []videoPod, config PodConfig, resp *openrtb.BidResponse) []AdPod {
+	ranges := sortedDurations(config.DurationRangeSec)
+	candidates := make(map[int][]podBid, len(pods))
+	podErrors := make(map[int][]string)
+	for _, sb := range resp.SeatBid {
+		for i := range sb.Bid {
+			bid := &sb.Bid[i]
+			for _, pod := range pods {
+				maxDur, ok := pod.impDurations[bid.ImpID]
+				if !ok {
+					continue
+				}
+				pb := newPodBid(bid, sb.Seat, maxDur)
+				duration, ok := allowedDuration(pb.duration, ranges, config.RequireExactDuration)
+				if !ok {
+					podErrors[pod.PodID] = append(podErrors[pod.PodID], fmt.Sprintf("bid %s from %s: duration %ds is not allowed", bid.ID, sb.Seat, pb.duration))
+					break
+				}
+				pb.duration = duration
+				candidates[pod.PodID] = append(candidates[pod.PodID], pb)
+				break
+			}
+		}
+	}
+
+	adPods := make([]AdPod, 0, len(pods))
+	for _, pod := range pods {
+		bids := candidates[pod.PodID]
+		sort.SliceStable(bids, func(i, j int) bool { return bids[i].bid.Price > bids[j].bid.Price })
+
+		adPod := AdPod{PodID: pod.PodID, Targeting: []VideoTargeting{}, Errors: podErrors[pod.PodID]}
+		usedCategories := make(map[string]bool)
+		usedDomains := make(map[string]bool)
+		remaining := pod.AdPodDurationSec
+		for _, pb := range bids {
+			if len(adPod.Targeting) == len(pod.impDurations) {
+				break
+			}
+			if pb.duration > remaining || (pb.category != "" && usedCategories[pb.category]) || (pb.domain != "" && usedDomains[pb.domain]) {
+				continue
+			}
+			if pb.category != "" {
+				usedCategories[pb.category] = true
+			}
+			if pb.domain != "" {
+				usedDomains[pb.domain] = true
+			}
+			remaining -= pb.duration
+			adPod.Targeting = append(adPod.Targeting, pb.targeting())
+		}
+		adPods = append(adPods, adPod)
+	}
+	return adPods
+}
+
+// newPodBid reads a bid's category, domain and duration; a bid without a
+// duration is taken to run for its impression's maximum
+func newPodBid(bid *openrtb.Bid, seat string, impMaxDuration int) podBid {
+	pb := podBid{bid: bid, seat: seat, duration: bid.Dur}
+	if len(bid.Ext) > 0 {
+		json.Unmarshal(bid.Ext, &pb.ext)
+	}
+	if pb.ext.Prebid != nil && pb.ext.Prebid.Video != nil {
+		pb.category = pb.ext.Prebid.Video.PrimaryCategory
+		if pb.duration == 0 {
+			pb.duration = pb.ext.Prebid.Video.Duration
+		}
+	}
+	if pb.category == "" && len(bid.Cat) > 0 {
+		pb.category = bid.Cat[0]
+	}
+	if len(bid.ADomain) > 0 {
+		pb.domain = bid.ADomain[0]
+	}
+	if pb.duration == 0 {
+		pb.duration = impMaxDuration
+	}
+	return pb
+}
+
+// allowedDuration rounds duration up to the nearest allowed one; with exact
+// durations it must match one
+func allowedDuration(duration int, ranges []int, exact bool) (int, bool) {
+	for _, d := range ranges {
+		if d == duration || (!exact && d > duration) {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// targeting builds the bid's pod targeting from the keys the exchange set on it
+func (pb podBid) targeting() VideoTargeting {
+	var keys map[string]string
+	var cacheID string
+	if pb.ext.Prebid != nil {
+		keys = pb.ext.Prebid.Targeting
+		if c := pb.ext.Prebid.Cache; c != nil && c.VastXML != nil {
+			cacheID = c.VastXML.CacheID
+		}
+	}
+	priceBucket := keys["hb_pb_"+pb.seat]
+	if priceBucket == "" {
+		priceBucket = keys["hb_pb"]
+	}
+	if priceBucket == "" {
+		priceBucket = fmt.Sprintf("%.2f", pb.bid.Price)
+	}
+
+	catDur := fmt.Sprintf("%s_%ds", priceBucket, pb.duration)
+	if pb.category != "" {
+		catDur = fmt.Sprintf("%s_%s_%ds", priceBucket, pb.category, pb.duration)
+	}
+	return VideoTargeting{PriceBucket: priceBucket, PriceCatDur: catDur, CacheID: cacheID, DealID: pb.bid.DealID}
+}
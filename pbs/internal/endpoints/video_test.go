@@ -0,0 +1,186 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/storedrequests"
+)
+
+func videoResolver() *storedrequests.Resolver {
+	return storedrequests.New(mockStoredFetcher{
+		"ctv-home":    `{"site":{"domain":"example.com","publisher":{"id":"pub1"}},"video":{"mimes":["video/mp4"],"w":1920,"h":1080},"tmax":900}`,
+		"ctv-preroll": `{"ext":{"appnexus":{"placementId":7}}}`,
+	}, storedrequests.Config{})
+}
+
+func TestVideoHandler_ExpandsPods(t *testing.T) {
+	var forwarded openrtb.BidRequest
+	auction := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &forwarded)
+		w.Write([]byte(`{"id":"r"}`))
+	})
+	handler := NewVideoHandler(videoResolver(), auction)
+
+	body := `{"storedrequestid":"ctv-home","podconfig":{"durationrangesec":[30,15],"pods":[` +
+		`{"podid":1,"adpoddurationsec":60,"configid":"ctv-preroll"},{"podid":2,"adpoddurationsec":30}]},` +
+		`"pricegranularity":"high","cacheconfig":{"ttl":600}}`
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, VideoPath, strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `{"podid":1,"targeting":[]}`) {
+		t.Errorf("expected empty pods without bids, got %s", w.Body.String())
+	}
+
+	if len(forwarded.Imp) != 6 {
+		t.Fatalf("expected 4 + 2 imps, got %d", len(forwarded.Imp))
+	}
+	first := forwarded.Imp[0]
+	if first.ID != "1_0" || first.Video.MaxDuration != 30 || first.Video.W != 1920 {
+		t.Errorf("expected the stored video template with the longest duration, got %s %+v", first.ID, first.Video)
+	}
+	if !strings.Contains(string(first.Ext), "placementId") || forwarded.Imp[4].ID != "2_0" || len(forwarded.Imp[4].Ext) != 0 {
+		t.Errorf("expected the configid stored imp on pod 1 only, got %s / %s", first.Ext, forwarded.Imp[4].Ext)
+	}
+	if forwarded.Site == nil || forwarded.Site.Publisher.ID != "pub1" || forwarded.TMax != 900 {
+		t.Errorf("expected the stored request merged, got site %+v tmax %d", forwarded.Site, forwarded.TMax)
+	}
+	if !strings.Contains(string(forwarded.Ext), `"pricegranularity":"high"`) || !strings.Contains(string(forwarded.Ext), `"vastxml":{"ttlseconds":600}`) {
+		t.Errorf("expected targeting and VAST caching turned on, got %s", forwarded.Ext)
+	}
+}
+
+func TestVideoHandler_ExactDuration(t *testing.T) {
+	var forwarded openrtb.BidRequest
+	auction := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &forwarded)
+		w.Write([]byte(`{"id":"r"}`))
+	})
+	body := `{"video":{"mimes":["video/mp4"]},"podconfig":{"durationrangesec":[15,30],"requireexactduration":true,"pods":[{"podid":1,"adpoddurationsec":45}]}}`
+	w := httptest.NewRecorder()
+	NewVideoHandler(nil, auction).ServeHTTP(w, httptest.NewRequest(http.MethodPost, VideoPath, strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	want := []int{15, 30, 15}
+	if len(forwarded.Imp) != len(want) {
+		t.Fatalf("expected %d imps, got %d", len(want), len(forwarded.Imp))
+	}
+	for i, d := range want {
+		if v := forwarded.Imp[i].Video; v.MinDuration != d || v.MaxDuration != d {
+			t.Errorf("imp %d: expected exactly %ds, got %d-%d", i, d, v.MinDuration, v.MaxDuration)
+		}
+	}
+}
+
+func TestVideoHandler_SelectsPodBids(t *testing.T) {
+	bid := func(id, imp string, price float64, cat, domain string, dur int, seat string) string {
+		return fmt.Sprintf(`{"id":%q,"impid":%q,"price":%g,"cat":[%q],"adomain":[%q],"dur":%d,`+
+			`"ext":{"prebid":{"targeting":{"hb_pb_%s":"%.2f"},"cache":{"vastXml":{"cacheId":"uuid-%s"}}}}}`,
+			id, imp, price, cat, domain, dur, seat, price, id)
+	}
+	response := `{"id":"r","seatbid":[{"seat":"appnexus","bid":[` +
+		bid("a", "1_0", 10, "IAB1", "a.com", 30, "appnexus") + `,` +
+		bid("b", "1_1", 8, "IAB1", "b.com", 15, "appnexus") + `,` + // same category as a
+		bid("c", "1_2", 6, "IAB2", "a.com", 15, "appnexus") + `,` + // same domain as a
+		bid("d", "1_3", 5, "IAB3", "d.com", 10, "appnexus") + `,` + // rounded up to 15s
+		bid("e", "1_0", 4, "IAB4", "e.com", 45, "appnexus") + // longer than any allowed duration
+		`]},{"seat":"rubicon","bid":[` +
+		bid("f", "1_1", 7, "IAB5", "f.com", 30, "rubicon") + `,` + // fills the pod's last 30s, leaving no room for d
+		bid("g", "2_0", 3, "IAB1", "a.com", 15, "rubicon") + // another pod, no conflict with pod 1
+		`]}]}`
+	auction := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(response))
+	})
+
+	body := `{"video":{"mimes":["video/mp4"]},"podconfig":{"durationrangesec":[15,30],"pods":[{"podid":1,"adpoddurationsec":60},{"podid":2,"adpoddurationsec":15}]}}`
+	w := httptest.NewRecorder()
+	NewVideoHandler(nil, auction).ServeHTTP(w, httptest.NewRequest(http.MethodPost, VideoPath, strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp VideoResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if len(resp.AdPods) != 2 {
+		t.Fatalf("expected 2 pods, got %d", len(resp.AdPods))
+	}
+
+	pod := resp.AdPods[0]
+	want := []VideoTargeting{
+		{PriceBucket: "10.00", PriceCatDur: "10.00_IAB1_30s", CacheID: "uuid-a"},
+		{PriceBucket: "7.00", PriceCatDur: "7.00_IAB5_30s", CacheID: "uuid-f"},
+	}
+	if len(pod.Targeting) != len(want) {
+		t.Fatalf("expected %d bids in pod 1, got %+v", len(want), pod.Targeting)
+	}
+	for i, tg := range want {
+		if pod.Targeting[i] != tg {
+			t.Errorf("pod 1 bid %d: expected %+v, got %+v", i, tg, pod.Targeting[i])
+		}
+	}
+	if len(pod.Errors) != 1 || !strings.Contains(pod.Errors[0], "bid e") {
+		t.Errorf("expected an error for the over-long bid, got %v", pod.Errors)
+	}
+
+	if p := resp.AdPods[1]; p.PodID != 2 || len(p.Targeting) != 1 || p.Targeting[0].PriceCatDur != "3.00_IAB1_15s" {
+		t.Errorf("expected pod 2 filled independently, got %+v", p)
+	}
+}
+
+func TestVideoHandler_RoundsUpDurations(t *testing.T) {
+	pods := []videoPod{{Pod: Pod{PodID: 1, AdPodDurationSec: 30}, impDurations: map[string]int{"1_0": 30, "1_1": 30}}}
+	resp := &openrtb.BidResponse{SeatBid: []openrtb.SeatBid{{Seat: "appnexus", Bid: []openrtb.Bid{
+		{ID: "a", ImpID: "1_0", Price: 2, Dur: 10},
+		{ID: "b", ImpID: "1_1", Price: 1, Dur: 12},
+	}}}}
+	adPods := buildAdPods(pods, PodConfig{DurationRangeSec: []int{15, 30}}, resp)
+	got := adPods[0].Targeting
+	if len(got) != 2 || got[0].PriceCatDur != "2.00_15s" || got[1].PriceCatDur != "1.00_15s" {
+		t.Errorf("expected both bids rounded up to 15s, got %+v", got)
+	}
+}
+
+func TestVideoHandler_Errors(t *testing.T) {
+	auction := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, "Privacy compliance violation", http.StatusBadRequest)
+	})
+	handler := NewVideoHandler(videoResolver(), auction)
+	pods := `"podconfig":{"durationrangesec":[15],"pods":[{"podid":1,"adpoddurationsec":30}]}`
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		want   string
+	}{
+		{name: "invalid JSON", body: `{`, status: http.StatusBadRequest, want: "Invalid JSON"},
+		{name: "no video", body: `{` + pods + `}`, status: http.StatusBadRequest, want: "video: required"},
+		{name: "no durations", body: `{"video":{},"podconfig":{"pods":[{"podid":1,"adpoddurationsec":30}]}}`, status: http.StatusBadRequest, want: "durationrangesec"},
+		{name: "no pods", body: `{"video":{},"podconfig":{"durationrangesec":[15]}}`, status: http.StatusBadRequest, want: "at least one pod"},
+		{name: "duplicate pods", body: `{"video":{},"podconfig":{"durationrangesec":[15],"pods":[{"podid":1,"adpoddurationsec":30},{"podid":1,"adpoddurationsec":30}]}}`, status: http.StatusBadRequest, want: "duplicate pod ID 1"},
+		{name: "too many imps", body: `{"video":{},"podconfig":{"durationrangesec":[1],"pods":[{"podid":1,"adpoddurationsec":500}]}}`, status: http.StatusBadRequest, want: "more than 100"},
+		{name: "unknown stored request", body: `{"storedrequestid":"nope","video":{},` + pods + `}`, status: http.StatusBadRequest, want: "stored request not found: nope"},
+		{name: "auction rejection", body: `{"video":{},` + pods + `}`, status: http.StatusBadRequest, want: "Privacy compliance violation"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, VideoPath, strings.NewReader(tt.body)))
+			if w.Code != tt.status || !strings.Contains(w.Body.String(), tt.want) {
+				t.Errorf("expected %d containing %q, got %d %s", tt.status, tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
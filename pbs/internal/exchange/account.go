@@ -0,0 +1,110 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/accounts"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/currency"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/floors"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/idr"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/logger"
+)
+
+// AccountSource looks up publisher account configuration, returning an
+// *accounts.NotFoundError for publishers without one; *accounts.Store implements it
+type AccountSource interface {
+	Account(ctx context.Context, id string) (*accounts.Account, error)
+}
+
+// SetAccounts sets where per-publisher account configuration is read from
+func (e *Exchange) SetAccounts(src AccountSource) {
+	e.configMu.Lock()
+	defer e.configMu.Unlock()
+	e.accounts = src
+}
+
+// auctionAccountID is the account an auction runs under: AuctionRequest.Account,
+// else the site or app publisher ID
+func auctionAccountID(req *AuctionRequest) string {
+	if req.Account != "" {
+		return req.Account
+	}
+	if site := req.BidRequest.Site; site != nil && site.Publisher != nil {
+		return site.Publisher.ID
+	}
+	if app := req.BidRequest.App; app != nil && app.Publisher != nil {
+		return app.Publisher.ID
+	}
+	return ""
+}
+
+// lookupAccount returns the auction's account, or nil when it has none. A
+// failed lookup runs the auction on the global configuration rather than
+// failing it.
+func lookupAccount(ctx context.Context, src AccountSource, req *AuctionRequest, debug *DebugInfo) *accounts.Account {
+	id := auctionAccountID(req)
+	if src == nil || id == "" {
+		return nil
+	}
+	account, err := src.Account(ctx, id)
+	var notFound *accounts.NotFoundError
+	if err != nil && !errors.As(err, &notFound) {
+		logger.Log.Warn().Err(err).Str("account", id).Msg("Account lookup failed, using global configuration")
+		debug.AddError("account", []string{err.Error()})
+	}
+	return account
+}
+
+// filterAccountBidders drops the bidders the account doesn't allow
+func filterAccountBidders(account *accounts.Account, bidders []string) []string {
+	if account == nil || len(account.AllowedBidders) == 0 {
+		return bidders
+	}
+	allowed := make([]string, 0, len(bidders))
+	for _, code := range bidders {
+		if account.AllowsBidder(code) {
+			allowed = append(allowed, code)
+		}
+	}
+	return allowed
+}
+
+// applyAccountFloors sets the account's default floor on imps without one
+// and raises imp floors below its minimum. Floors compare in cur, the
+// exchange's default currency; a floor that can't be converted is left alone.
+func applyAccountFloors(account *accounts.Account, req *openrtb.BidRequest, cur string, conv currency.Converter) {
+	if account == nil || account.Floors == nil {
+		return
+	}
+	f := account.Floors
+	for i := range req.Imp {
+		imp := &req.Imp[i]
+		if imp.BidFloor <= 0 {
+			if floor := max(f.Default, f.Min); floor > 0 {
+				imp.BidFloor, imp.BidFloorCur = floor, cur
+			}
+			continue
+		}
+		floor, err := floors.Resolve(imp.BidFloor, imp.BidFloorCur, cur, conv)
+		if err == nil && floor < f.Min {
+			imp.BidFloor, imp.BidFloorCur = f.Min, cur
+		}
+	}
+}
+
+// accountRecordsEvents reports whether the account lets the auction's events
+// be recorded; the recorder's own policy still applies afterwards
+func accountRecordsEvents(account *accounts.Account, auctionID string) bool {
+	if account == nil || account.Events == nil {
+		return true
+	}
+	if account.Events.Enabled != nil && !*account.Events.Enabled {
+		return false
+	}
+	if account.Events.SampleRate != nil {
+		return idr.Sampled(auctionID, *account.Events.SampleRate)
+	}
+	return true
+}
@@ -0,0 +1,198 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/accounts"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/idr"
+)
+
+// mockAccounts serves accounts from a map
+type mockAccounts struct {
+	accounts map[string]*accounts.Account
+	err      error
+	lookups  []string
+}
+
+func (m *mockAccounts) Account(ctx context.Context, id string) (*accounts.Account, error) {
+	m.lookups = append(m.lookups, id)
+	if m.err != nil {
+		return nil, m.err
+	}
+	if a, ok := m.accounts[id]; ok {
+		return a, nil
+	}
+	return nil, &accounts.NotFoundError{ID: id}
+}
+
+func boolPtr(b bool) *bool { return &b }
+
+func TestRunAuction_AppliesAccount(t *testing.T) {
+	registry := adapters.NewRegistry()
+	for _, code := range []string{"appnexus", "rubicon", "pubmatic"} {
+		registry.Register(code, &mockAdapter{makeErr: errors.New("no request")}, adapters.BidderInfo{Enabled: true})
+	}
+	ex := New(registry, &Config{DefaultTimeout: 100 * time.Millisecond, DefaultCurrency: "USD"})
+	src := &mockAccounts{accounts: map[string]*accounts.Account{
+		"pub1": {
+			ID:             "pub1",
+			AllowedBidders: []string{"appnexus", "Rubicon"},
+			Floors:         &accounts.Floors{Default: 0.5, Min: 0.2},
+			DebugAllowed:   boolPtr(false),
+		},
+	}}
+	ex.SetAccounts(src)
+
+	req := &AuctionRequest{
+		BidRequest: &openrtb.BidRequest{
+			ID:   "acct-1",
+			Site: &openrtb.Site{ID: "s", Publisher: &openrtb.Publisher{ID: "pub1"}},
+			Imp: []openrtb.Imp{
+				{ID: "imp1", Banner: &openrtb.Banner{W: 300, H: 250}},
+				{ID: "imp2", Banner: &openrtb.Banner{W: 300, H: 250}, BidFloor: 0.1, BidFloorCur: "USD"},
+				{ID: "imp3", Banner: &openrtb.Banner{W: 300, H: 250}, BidFloor: 1, BidFloorCur: "USD"},
+			},
+		},
+		Debug: true,
+	}
+	resp, err := ex.RunAuction(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(src.lookups) != 1 || src.lookups[0] != "pub1" {
+		t.Errorf("expected the site publisher looked up, got %v", src.lookups)
+	}
+	if _, called := resp.BidderResults["pubmatic"]; called || len(resp.BidderResults) != 2 {
+		t.Errorf("expected only the allowed bidders called, got %v", resp.DebugInfo.SelectedBidders)
+	}
+	want := []float64{0.5, 0.2, 1}
+	for i, floor := range want {
+		if got := req.BidRequest.Imp[i].BidFloor; got != floor {
+			t.Errorf("imp %d: expected floor %v, got %v", i, floor, got)
+		}
+	}
+	if req.Debug {
+		t.Error("expected debug turned off for an account that doesn't allow it")
+	}
+}
+
+func TestRunAuction_AccountFallbacks(t *testing.T) {
+	registry := adapters.NewRegistry()
+	registry.Register("appnexus", &mockAdapter{makeErr: errors.New("no request")}, adapters.BidderInfo{Enabled: true})
+	ex := New(registry, &Config{DefaultTimeout: 100 * time.Millisecond, DefaultCurrency: "USD"})
+	src := &mockAccounts{err: errors.New("redis down")}
+	ex.SetAccounts(src)
+
+	req := &AuctionRequest{
+		BidRequest: &openrtb.BidRequest{
+			ID:  "acct-2",
+			App: &openrtb.App{ID: "a", Publisher: &openrtb.Publisher{ID: "app-pub"}},
+			Imp: []openrtb.Imp{{ID: "imp1", Banner: &openrtb.Banner{W: 300, H: 250}}},
+		},
+		Account: "explicit",
+		Debug:   true,
+	}
+	resp, err := ex.RunAuction(context.Background(), req)
+	if err != nil {
+		t.Fatalf("expected the auction to run on a failed lookup, got %v", err)
+	}
+	if len(src.lookups) != 1 || src.lookups[0] != "explicit" {
+		t.Errorf("expected AuctionRequest.Account preferred, got %v", src.lookups)
+	}
+	if _, called := resp.BidderResults["appnexus"]; !called || !req.Debug {
+		t.Error("expected the global configuration used")
+	}
+	if len(resp.DebugInfo.Errors["account"]) != 1 {
+		t.Errorf("expected the lookup error in debug info, got %v", resp.DebugInfo.Errors)
+	}
+}
+
+func TestRunAuction_AccountTimeout(t *testing.T) {
+	done := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+		}
+	}))
+	defer slow.Close()
+	defer close(done)
+
+	registry := adapters.NewRegistry()
+	registry.Register("slow", &mockAdapter{requests: []*adapters.RequestData{{Method: "POST", URI: slow.URL, Body: []byte(`{}`)}}}, adapters.BidderInfo{Enabled: true})
+	ex := New(registry, &Config{DefaultTimeout: time.Second})
+	ex.SetAccounts(&mockAccounts{accounts: map[string]*accounts.Account{"pub1": {ID: "pub1", TimeoutMS: 50}}})
+
+	start := time.Now()
+	_, err := ex.RunAuction(context.Background(), &AuctionRequest{Account: "pub1", BidRequest: &openrtb.BidRequest{
+		ID:   "acct-3",
+		Site: testSite(),
+		Imp:  []openrtb.Imp{{ID: "imp1", Banner: &openrtb.Banner{W: 300, H: 250}}},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected the account's 50ms timeout over the 1s default, took %v", elapsed)
+	}
+}
+
+func TestSetDefaultTimeout(t *testing.T) {
+	done := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+		}
+	}))
+	defer slow.Close()
+	defer close(done)
+
+	registry := adapters.NewRegistry()
+	registry.Register("slow", &mockAdapter{requests: []*adapters.RequestData{{Method: "POST", URI: slow.URL, Body: []byte(`{}`)}}}, adapters.BidderInfo{Enabled: true})
+	ex := New(registry, &Config{DefaultTimeout: time.Second})
+	ex.SetDefaultTimeout(50 * time.Millisecond)
+	ex.SetDefaultTimeout(0) // Ignored
+
+	start := time.Now()
+	_, err := ex.RunAuction(context.Background(), &AuctionRequest{BidRequest: &openrtb.BidRequest{
+		ID:   "timeout-1",
+		Site: testSite(),
+		Imp:  []openrtb.Imp{{ID: "imp1", Banner: &openrtb.Banner{W: 300, H: 250}}},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected the new 50ms default timeout, took %v", elapsed)
+	}
+}
+
+func TestAccountRecordsEvents(t *testing.T) {
+	rate := 0.0
+	tests := []struct {
+		name    string
+		account *accounts.Account
+		want    bool
+	}{
+		{name: "no account", account: nil, want: true},
+		{name: "no event settings", account: &accounts.Account{ID: "a"}, want: true},
+		{name: "opted out", account: &accounts.Account{ID: "a", Events: &idr.AccountRecording{Enabled: boolPtr(false)}}, want: false},
+		{name: "sampled out", account: &accounts.Account{ID: "a", Events: &idr.AccountRecording{SampleRate: &rate}}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := accountRecordsEvents(tt.account, "auction-1"); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
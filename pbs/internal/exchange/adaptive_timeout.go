@@ -0,0 +1,190 @@
+package exchange
+
+import (
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Default adaptive timeout tuning
+const (
+	defaultAdaptivePercentile = 0.95
+	defaultAdaptiveBuffer     = 20 * time.Millisecond
+	defaultAdaptiveMinTimeout = 50 * time.Millisecond
+	defaultAdaptiveWindow     = 200 // Latency samples kept per bidder
+	defaultAdaptiveMinSamples = 20  // Samples needed before a bidder is tuned
+	adaptiveRecomputeEvery    = 10  // Samples between percentile recomputes
+)
+
+// AdaptiveTimeoutConfig tunes each bidder's timeout from its recent latency
+// instead of giving every bidder the whole auction timeout
+type AdaptiveTimeoutConfig struct {
+	Enabled    bool
+	Percentile float64       // Latency percentile to cover, e.g. 0.95
+	Buffer     time.Duration // Added to the percentile
+	MinTimeout time.Duration // Lower bound for a tuned timeout
+	MaxTimeout time.Duration // Upper bound; 0 = the auction timeout
+	Window     int           // Recent samples kept per bidder
+	MinSamples int           // Bidders with fewer samples get the auction timeout
+}
+
+// DefaultAdaptiveTimeoutConfig returns disabled adaptive timeouts with default tuning
+func DefaultAdaptiveTimeoutConfig() *AdaptiveTimeoutConfig {
+	return &AdaptiveTimeoutConfig{
+		Percentile: defaultAdaptivePercentile,
+		Buffer:     defaultAdaptiveBuffer,
+		MinTimeout: defaultAdaptiveMinTimeout,
+		Window:     defaultAdaptiveWindow,
+		MinSamples: defaultAdaptiveMinSamples,
+	}
+}
+
+// BidderTimeoutStats is a snapshot of one bidder's tuned timeout
+type BidderTimeoutStats struct {
+	Samples      int   `json:"samples"`
+	TimedOut     int   `json:"timed_out"`     // Samples in the window that timed out
+	PercentileMs int64 `json:"percentile_ms"` // Latency at the configured percentile
+	TimeoutMs    int64 `json:"timeout_ms"`    // Tuned timeout; 0 until MinSamples is reached
+}
+
+// AdaptiveTimeoutStats lists tuned timeouts for admin inspection
+type AdaptiveTimeoutStats struct {
+	Enabled    bool                          `json:"enabled"`
+	Percentile float64                       `json:"percentile"`
+	BufferMs   int64                         `json:"buffer_ms"`
+	MinMs      int64                         `json:"min_ms"`
+	MaxMs      int64                         `json:"max_ms"`
+	Window     int                           `json:"window"`
+	MinSamples int                           `json:"min_samples"`
+	Bidders    map[string]BidderTimeoutStats `json:"bidders"`
+}
+
+// bidderLatency is a ring buffer of one bidder's recent call latencies
+type bidderLatency struct {
+	samples    []time.Duration
+	timedOut   []bool
+	next       int
+	count      int
+	sinceTune  int
+	tuned      bool          // Set once MinSamples is reached
+	percentile time.Duration // Cached percentile, valid when tuned
+}
+
+// adaptiveTimeouts tracks per-bidder latency and derives per-bidder timeouts.
+// A timed-out call only shows how long the bidder was allowed, not how long it
+// needed, so it counts as MaxTimeout: a bidder that times out often is pushed
+// to the upper bound instead of being tuned ever lower by its own cut-offs.
+type adaptiveTimeouts struct {
+	config  *AdaptiveTimeoutConfig
+	mu      sync.RWMutex
+	bidders map[string]*bidderLatency
+}
+
+func newAdaptiveTimeouts(config *AdaptiveTimeoutConfig) *adaptiveTimeouts {
+	return &adaptiveTimeouts{config: config, bidders: make(map[string]*bidderLatency)}
+}
+
+// record adds a finished bidder call to its latency window
+func (a *adaptiveTimeouts) record(bidder string, latency time.Duration, timedOut bool, auctionTimeout time.Duration) {
+	if timedOut {
+		latency = a.maxTimeout(auctionTimeout)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	b, ok := a.bidders[bidder]
+	if !ok {
+		b = &bidderLatency{
+			samples:  make([]time.Duration, a.config.Window),
+			timedOut: make([]bool, a.config.Window),
+		}
+		a.bidders[bidder] = b
+	}
+	b.samples[b.next] = latency
+	b.timedOut[b.next] = timedOut
+	b.next = (b.next + 1) % len(b.samples)
+	b.count = min(b.count+1, len(b.samples))
+	b.sinceTune++
+
+	if b.count >= a.config.MinSamples && (!b.tuned || b.sinceTune >= adaptiveRecomputeEvery) {
+		b.percentile = percentileOf(b.samples[:b.count], a.config.Percentile)
+		b.tuned = true
+		b.sinceTune = 0
+	}
+}
+
+// timeoutFor returns the bidder's tuned timeout, never more than auctionTimeout.
+// Bidders without enough samples get auctionTimeout.
+func (a *adaptiveTimeouts) timeoutFor(bidder string, auctionTimeout time.Duration) time.Duration {
+	a.mu.RLock()
+	b, ok := a.bidders[bidder]
+	tuned := ok && b.tuned
+	var p time.Duration
+	if tuned {
+		p = b.percentile
+	}
+	a.mu.RUnlock()
+
+	if !tuned {
+		return auctionTimeout
+	}
+	return a.clamp(p, auctionTimeout)
+}
+
+func (a *adaptiveTimeouts) clamp(percentile, auctionTimeout time.Duration) time.Duration {
+	timeout := percentile + a.config.Buffer
+	timeout = max(timeout, a.config.MinTimeout)
+	return min(timeout, a.maxTimeout(auctionTimeout))
+}
+
+func (a *adaptiveTimeouts) maxTimeout(auctionTimeout time.Duration) time.Duration {
+	if a.config.MaxTimeout > 0 && a.config.MaxTimeout < auctionTimeout {
+		return a.config.MaxTimeout
+	}
+	return auctionTimeout
+}
+
+// stats snapshots every tracked bidder, with tuned timeouts bounded by defaultTimeout
+func (a *adaptiveTimeouts) stats(defaultTimeout time.Duration) AdaptiveTimeoutStats {
+	stats := AdaptiveTimeoutStats{
+		Enabled:    a.config.Enabled,
+		Percentile: a.config.Percentile,
+		BufferMs:   a.config.Buffer.Milliseconds(),
+		MinMs:      a.config.MinTimeout.Milliseconds(),
+		MaxMs:      a.maxTimeout(defaultTimeout).Milliseconds(),
+		Window:     a.config.Window,
+		MinSamples: a.config.MinSamples,
+		Bidders:    make(map[string]BidderTimeoutStats),
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	for code, b := range a.bidders {
+		s := BidderTimeoutStats{
+			Samples:      b.count,
+			PercentileMs: b.percentile.Milliseconds(),
+		}
+		for _, t := range b.timedOut[:b.count] {
+			if t {
+				s.TimedOut++
+			}
+		}
+		if b.tuned {
+			s.TimeoutMs = a.clamp(b.percentile, defaultTimeout).Milliseconds()
+		}
+		stats.Bidders[code] = s
+	}
+	return stats
+}
+
+// percentileOf returns the nearest-rank percentile of samples
+func percentileOf(samples []time.Duration, p float64) time.Duration {
+	if len(samples) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(samples)
+	slices.Sort(sorted)
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[max(0, min(rank, len(sorted)-1))]
+}
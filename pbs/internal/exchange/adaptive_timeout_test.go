@@ -0,0 +1,121 @@
+package exchange
+
+import (
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+)
+
+func TestPercentileOf(t *testing.T) {
+	samples := make([]time.Duration, 100)
+	for i := range samples {
+		samples[len(samples)-1-i] = time.Duration(i+1) * time.Millisecond
+	}
+	if got := percentileOf(samples, 0.95); got != 95*time.Millisecond {
+		t.Errorf("expected p95 of 95ms, got %v", got)
+	}
+	if got := percentileOf(samples, 1); got != 100*time.Millisecond {
+		t.Errorf("expected p100 of 100ms, got %v", got)
+	}
+	if percentileOf(nil, 0.95) != 0 {
+		t.Error("expected 0 for no samples")
+	}
+}
+
+func TestAdaptiveTimeouts_Tuning(t *testing.T) {
+	config := DefaultAdaptiveTimeoutConfig()
+	config.Enabled = true
+	config.MinSamples = 10
+	config.Window = 50
+	a := newAdaptiveTimeouts(config)
+	auctionTimeout := 1000 * time.Millisecond
+
+	for i := 0; i < 9; i++ {
+		a.record("fast", 40*time.Millisecond, false, auctionTimeout)
+	}
+	if got := a.timeoutFor("fast", auctionTimeout); got != auctionTimeout {
+		t.Errorf("expected auction timeout before MinSamples, got %v", got)
+	}
+
+	a.record("fast", 40*time.Millisecond, false, auctionTimeout)
+	if got := a.timeoutFor("fast", auctionTimeout); got != 60*time.Millisecond {
+		t.Errorf("expected p95 + 20ms buffer = 60ms, got %v", got)
+	}
+	// Never more than the auction allows
+	if got := a.timeoutFor("fast", 30*time.Millisecond); got != 30*time.Millisecond {
+		t.Errorf("expected auction timeout cap, got %v", got)
+	}
+
+	// Tuned values are bounded below
+	for i := 0; i < 10; i++ {
+		a.record("instant", time.Millisecond, false, auctionTimeout)
+	}
+	if got := a.timeoutFor("instant", auctionTimeout); got != config.MinTimeout {
+		t.Errorf("expected min timeout, got %v", got)
+	}
+
+	// Unknown bidders get the auction timeout
+	if got := a.timeoutFor("unknown", auctionTimeout); got != auctionTimeout {
+		t.Errorf("expected auction timeout for unknown bidder, got %v", got)
+	}
+}
+
+func TestAdaptiveTimeouts_TimeoutsPushToMax(t *testing.T) {
+	config := DefaultAdaptiveTimeoutConfig()
+	config.MinSamples = 20
+	config.MaxTimeout = 400 * time.Millisecond
+	a := newAdaptiveTimeouts(config)
+	auctionTimeout := time.Second
+
+	// 10% of calls are cut off at their (short) tuned timeout
+	for i := 0; i < 20; i++ {
+		a.record("slow", 50*time.Millisecond, i%10 == 0, auctionTimeout)
+	}
+	if got := a.timeoutFor("slow", auctionTimeout); got != 400*time.Millisecond {
+		t.Errorf("expected timed-out samples to push the timeout to the max, got %v", got)
+	}
+
+	stats := a.stats(auctionTimeout)
+	slow := stats.Bidders["slow"]
+	if slow.Samples != 20 || slow.TimedOut != 2 || slow.TimeoutMs != 400 || stats.MaxMs != 400 {
+		t.Errorf("unexpected stats %+v / %+v", stats, slow)
+	}
+}
+
+func TestAdaptiveTimeouts_WindowSlides(t *testing.T) {
+	config := DefaultAdaptiveTimeoutConfig()
+	config.Window = 20
+	config.MinSamples = 20
+	a := newAdaptiveTimeouts(config)
+
+	for i := 0; i < 20; i++ {
+		a.record("recovering", 500*time.Millisecond, false, time.Second)
+	}
+	for i := 0; i < 20; i++ {
+		a.record("recovering", 80*time.Millisecond, false, time.Second)
+	}
+	if got := a.timeoutFor("recovering", time.Second); got != 100*time.Millisecond {
+		t.Errorf("expected old samples to age out, got %v", got)
+	}
+}
+
+func TestExchange_BidderTimeout(t *testing.T) {
+	ex := New(adapters.NewRegistry(), &Config{DefaultTimeout: 500 * time.Millisecond})
+	for i := 0; i < defaultAdaptiveMinSamples; i++ {
+		ex.recordBidderLatency(&BidderResult{BidderCode: "fast", Latency: 30 * time.Millisecond}, 500*time.Millisecond)
+	}
+
+	// Disabled: latency is tracked but the auction timeout applies
+	if got := ex.bidderTimeout("fast", 500*time.Millisecond); got != 500*time.Millisecond {
+		t.Errorf("expected auction timeout while disabled, got %v", got)
+	}
+	if stats := ex.AdaptiveTimeoutStats(); stats.Enabled || stats.Bidders["fast"].TimeoutMs != 50 {
+		t.Errorf("expected tracked tuning while disabled, got %+v", stats)
+	}
+
+	ex.config.AdaptiveTimeouts.Enabled = true
+	if got := ex.bidderTimeout("fast", 500*time.Millisecond); got != 50*time.Millisecond {
+		t.Errorf("expected tuned timeout of 50ms, got %v", got)
+	}
+}
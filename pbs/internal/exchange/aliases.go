@@ -0,0 +1,196 @@
+package exchange
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+// bidderAliases maps the bidder codes a request defines in ext.prebid.aliases
+// to the bidders whose adapters they call
+type bidderAliases map[string]string
+
+// core returns the bidder whose adapter a bidder code calls
+func (a bidderAliases) core(code string) string {
+	if core, ok := a[code]; ok {
+		return core
+	}
+	return code
+}
+
+// requestBidders holds the request's ext.prebid aliases and global bidder params
+type requestBidders struct {
+	aliases bidderAliases
+	params  map[string]json.RawMessage
+}
+
+// parseRequestBidders reads ext.prebid.aliases and ext.prebid.bidderparams.
+// Aliases that shadow a bidder, point at another alias or at an unknown
+// bidder are dropped with an error each.
+func parseRequestBidders(ext json.RawMessage, known func(code string) bool) (requestBidders, []string) {
+	var rb requestBidders
+	if len(ext) == 0 {
+		return rb, nil
+	}
+	var parsed struct {
+		Prebid *struct {
+			Aliases      map[string]string          `json:"aliases"`
+			BidderParams map[string]json.RawMessage `json:"bidderparams"`
+		} `json:"prebid"`
+	}
+	if err := json.Unmarshal(ext, &parsed); err != nil || parsed.Prebid == nil {
+		return rb, nil
+	}
+	rb.params = parsed.Prebid.BidderParams
+
+	var errs []string
+	for alias, core := range parsed.Prebid.Aliases {
+		var reason string
+		switch {
+		case alias == "" || core == "":
+			reason = "empty alias or bidder"
+		case known(alias):
+			reason = "shadows a bidder of the same code"
+		case parsed.Prebid.Aliases[core] != "":
+			reason = fmt.Sprintf("points at alias %q", core)
+		case !known(core):
+			reason = fmt.Sprintf("points at unknown bidder %q", core)
+		}
+		if reason != "" {
+			errs = append(errs, fmt.Sprintf("alias %q %s", alias, reason))
+			continue
+		}
+		if rb.aliases == nil {
+			rb.aliases = make(bidderAliases)
+		}
+		rb.aliases[alias] = core
+	}
+	sort.Strings(errs)
+	return rb, errs
+}
+
+// withAliases adds to the bidders to call the aliases of those being called.
+// Aliases share their bidder's eligibility, so the aliases of a bidder left
+// out of the auction are left out too.
+func withAliases(bidders []string, aliases bidderAliases) []string {
+	if len(aliases) == 0 {
+		return bidders
+	}
+	called := make(map[string]bool, len(bidders))
+	for _, code := range bidders {
+		called[code] = true
+	}
+	var added []string
+	for alias, core := range aliases {
+		if called[core] && !called[alias] {
+			added = append(added, alias)
+		}
+	}
+	sort.Strings(added)
+	return append(bidders[:len(bidders):len(bidders)], added...)
+}
+
+// mergeBidderParams merges the request's global params for a bidder into its
+// params on every imp of its request copy. Params set on the imp win.
+func mergeBidderParams(req *openrtb.BidRequest, code string, global json.RawMessage) {
+	if len(global) == 0 {
+		return
+	}
+	for i := range req.Imp {
+		imp := &req.Imp[i]
+		var params json.RawMessage
+		if err := adapters.ImpParams(imp, code, &params); err != nil && !errors.Is(err, adapters.ErrNoParams) {
+			continue // left for params validation to report
+		}
+		if ext, ok := setImpBidderParams(imp.Ext, code, mergeParams(global, params)); ok {
+			imp.Ext = ext
+		}
+	}
+}
+
+// mergeParams overlays imp params on global params. Params that aren't both
+// objects aren't merged; the imp's win if it has any.
+func mergeParams(global, imp json.RawMessage) json.RawMessage {
+	if len(imp) == 0 {
+		return global
+	}
+	var merged, overlay map[string]json.RawMessage
+	if json.Unmarshal(global, &merged) != nil || json.Unmarshal(imp, &overlay) != nil || merged == nil {
+		return imp
+	}
+	for k, v := range overlay {
+		merged[k] = v
+	}
+	out, err := json.Marshal(merged)
+	if err != nil {
+		return imp
+	}
+	return out
+}
+
+// aliasImpParams moves an alias's params on each imp of its request copy to
+// where its bidder's adapter reads them. Imps without params for the alias
+// get null params, so the adapter doesn't bid on them with its own.
+func aliasImpParams(req *openrtb.BidRequest, alias, core string) {
+	for i := range req.Imp {
+		imp := &req.Imp[i]
+		var params json.RawMessage
+		if err := adapters.ImpParams(imp, alias, &params); err != nil {
+			params = nil
+		}
+		if ext, ok := setImpBidderParams(imp.Ext, core, params); ok {
+			imp.Ext = ext
+		}
+	}
+}
+
+// setImpBidderParams sets imp ext.prebid.bidder.<code>, preserving all other
+// ext fields. Empty params are set as null. Unparseable ext isn't changed.
+func setImpBidderParams(ext json.RawMessage, code string, params json.RawMessage) (json.RawMessage, bool) {
+	root := make(map[string]json.RawMessage)
+	if len(ext) > 0 && string(ext) != "null" {
+		if err := json.Unmarshal(ext, &root); err != nil {
+			return ext, false
+		}
+	}
+	prebid := make(map[string]json.RawMessage)
+	if raw, ok := root["prebid"]; ok && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &prebid); err != nil {
+			return ext, false
+		}
+	}
+	bidder := make(map[string]json.RawMessage)
+	if raw, ok := prebid["bidder"]; ok && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &bidder); err != nil {
+			return ext, false
+		}
+	}
+
+	if len(params) == 0 {
+		params = json.RawMessage("null")
+	}
+	bidder[code] = params
+
+	var err error
+	if prebid["bidder"], err = json.Marshal(bidder); err != nil {
+		return ext, false
+	}
+	if root["prebid"], err = json.Marshal(prebid); err != nil {
+		return ext, false
+	}
+	out, err := json.Marshal(root)
+	if err != nil {
+		return ext, false
+	}
+	return out, true
+}
+
+// aliasedAdapter calls a bidder's adapter under one of its aliases
+type aliasedAdapter struct {
+	adapters.Adapter
+	core string
+}
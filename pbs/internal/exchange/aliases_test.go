@@ -0,0 +1,150 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+func TestParseRequestBidders(t *testing.T) {
+	known := func(code string) bool { return code == "appnexus" || code == "rubicon" }
+	ext := json.RawMessage(`{"prebid":{
+		"aliases":{"apn":"appnexus","rubicon":"appnexus","chained":"apn","ghost":"nobody"},
+		"bidderparams":{"apn":{"member":"958"}}
+	}}`)
+
+	rb, errs := parseRequestBidders(ext, known)
+	if !reflect.DeepEqual(rb.aliases, bidderAliases{"apn": "appnexus"}) {
+		t.Errorf("expected only apn aliased, got %v", rb.aliases)
+	}
+	if len(errs) != 3 {
+		t.Errorf("expected the shadowing, chained and unknown aliases reported, got %v", errs)
+	}
+	if string(rb.params["apn"]) != `{"member":"958"}` {
+		t.Errorf("expected apn's global params, got %s", rb.params["apn"])
+	}
+	if rb.aliases.core("apn") != "appnexus" || rb.aliases.core("rubicon") != "rubicon" {
+		t.Error("expected aliases to resolve to their bidder and other codes to themselves")
+	}
+
+	got := withAliases([]string{"appnexus", "rubicon"}, bidderAliases{"apn": "appnexus", "tel": "telaria"})
+	if !reflect.DeepEqual(got, []string{"appnexus", "rubicon", "apn"}) {
+		t.Errorf("expected only the called bidder's alias added, got %v", got)
+	}
+}
+
+func TestBidderParamsOnImps(t *testing.T) {
+	req := &openrtb.BidRequest{Imp: []openrtb.Imp{
+		{ID: "both", Ext: json.RawMessage(`{"apn":{"placementId":1,"member":"imp"},"gpid":"/home"}`)},
+		{ID: "global", Ext: json.RawMessage(`{"prebid":{"bidder":{"appnexus":{"placementId":2}}}}`)},
+	}}
+	mergeBidderParams(req, "apn", json.RawMessage(`{"member":"958","reserve":0.5}`))
+
+	var params map[string]any
+	if err := adapters.ImpParams(&req.Imp[0], "apn", &params); err != nil || params["member"] != "imp" || params["reserve"] != 0.5 {
+		t.Errorf("expected imp params to win over global ones, got %v, %v", params, err)
+	}
+	params = nil
+	if err := adapters.ImpParams(&req.Imp[1], "apn", &params); err != nil || params["member"] != "958" {
+		t.Errorf("expected global params on an imp without its own, got %v, %v", params, err)
+	}
+
+	aliasImpParams(req, "apn", "appnexus")
+	params = nil
+	if err := adapters.ImpParams(&req.Imp[0], "appnexus", &params); err != nil || params["placementId"] != float64(1) {
+		t.Errorf("expected the alias's params under its bidder, got %v, %v", params, err)
+	}
+	var ext map[string]json.RawMessage
+	if err := json.Unmarshal(req.Imp[0].Ext, &ext); err != nil || string(ext["gpid"]) != `"/home"` {
+		t.Errorf("expected other imp ext fields kept, got %s", req.Imp[0].Ext)
+	}
+
+	// An imp without params for the alias doesn't carry its bidder's own
+	req = &openrtb.BidRequest{Imp: []openrtb.Imp{{ID: "own", Ext: json.RawMessage(`{"appnexus":{"placementId":3}}`)}}}
+	aliasImpParams(req, "apn", "appnexus")
+	if err := adapters.ImpParams(&req.Imp[0], "appnexus", &params); err != adapters.ErrNoParams {
+		t.Errorf("expected no params for the alias's bidder, got %v", err)
+	}
+}
+
+// priceParamsAdapter bids each imp's params price, recording the core name it's called with
+type priceParamsAdapter struct {
+	mu        sync.Mutex
+	coreNames []string
+}
+
+func (a *priceParamsAdapter) MakeRequests(request *openrtb.BidRequest, reqInfo *adapters.ExtraRequestInfo) ([]*adapters.RequestData, []error) {
+	a.mu.Lock()
+	a.coreNames = append(a.coreNames, reqInfo.BidderCoreName)
+	a.mu.Unlock()
+
+	prices := make(map[string]float64)
+	for i := range request.Imp {
+		var params struct {
+			Price float64 `json:"price"`
+		}
+		if adapters.ImpParams(&request.Imp[i], "core", &params) == nil {
+			prices[request.Imp[i].ID] = params.Price
+		}
+	}
+	body, _ := json.Marshal(prices)
+	return []*adapters.RequestData{{Method: "MOCK", Body: body}}, nil
+}
+
+func (a *priceParamsAdapter) MakeBids(request *openrtb.BidRequest, response *adapters.ResponseData) (*adapters.BidderResponse, []error) {
+	var prices map[string]float64
+	if err := json.Unmarshal(response.Body, &prices); err != nil {
+		return nil, []error{err}
+	}
+	resp := &adapters.BidderResponse{Currency: "USD"}
+	for impID, price := range prices {
+		resp.Bids = append(resp.Bids, &adapters.TypedBid{
+			Bid:     &openrtb.Bid{ID: fmt.Sprintf("%s-%v", impID, price), ImpID: impID, Price: price, W: 300, H: 250, AdM: "<div/>"},
+			BidType: adapters.BidTypeBanner,
+		})
+	}
+	return resp, nil
+}
+
+func TestRunAuction_Aliases(t *testing.T) {
+	adapter := &priceParamsAdapter{}
+	registry := adapters.NewRegistry()
+	registry.Register("core", adapter, adapters.BidderInfo{Enabled: true, DemandType: adapters.DemandTypePublisher})
+	ex := New(registry, &Config{DefaultTimeout: time.Second, DefaultCurrency: "USD", AuctionType: FirstPriceAuction})
+
+	req := &openrtb.BidRequest{
+		ID:   "alias-req",
+		Site: testSite(),
+		Ext:  json.RawMessage(`{"prebid":{"aliases":{"alt":"core"},"bidderparams":{"alt":{"price":3,"seat":"global"}}}}`),
+		Imp: []openrtb.Imp{
+			{ID: "imp1", Banner: &openrtb.Banner{W: 300, H: 250}, Ext: json.RawMessage(`{"prebid":{"bidder":{"core":{"price":1},"alt":{"seat":"imp"}}}}`)},
+			{ID: "imp2", Banner: &openrtb.Banner{W: 300, H: 250}, Ext: json.RawMessage(`{"core":{"price":2}}`)},
+		},
+	}
+	resp, err := ex.RunAuction(context.Background(), &AuctionRequest{BidRequest: req})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	seatPrices := make(map[string]float64)
+	for _, seat := range resp.BidResponse.SeatBid {
+		for _, bid := range seat.Bid {
+			seatPrices[seat.Seat+"/"+bid.ImpID] = bid.Price
+		}
+	}
+	// The alias bids the global price on every imp; the bidder only its own
+	want := map[string]float64{"core/imp1": 1, "core/imp2": 2, "alt/imp1": 3, "alt/imp2": 3}
+	if !reflect.DeepEqual(seatPrices, want) {
+		t.Errorf("expected bids %v, got %v (%v)", want, seatPrices, resp.DebugInfo.Errors)
+	}
+	if !reflect.DeepEqual(adapter.coreNames, []string{"core", "core"}) {
+		t.Errorf("expected both calls to carry the core name, got %v", adapter.coreNames)
+	}
+}
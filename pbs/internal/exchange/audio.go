@@ -0,0 +1,115 @@
+package exchange
+
+import (
+	"fmt"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+// impMediaType labels an imp for metrics and event recording. Multi-format imps
+// take the first of banner, video, audio, native so labels stay low-cardinality.
+func impMediaType(imp *openrtb.Imp) string {
+	switch {
+	case imp.Banner != nil:
+		return string(adapters.BidTypeBanner)
+	case imp.Video != nil:
+		return string(adapters.BidTypeVideo)
+	case imp.Audio != nil:
+		return string(adapters.BidTypeAudio)
+	case imp.Native != nil:
+		return string(adapters.BidTypeNative)
+	}
+	return "unknown"
+}
+
+// validateAudioImp checks the audio fields a bidder needs to build a response
+func validateAudioImp(audio *openrtb.Audio) string {
+	if len(audio.Mimes) == 0 {
+		return "audio.mimes is required"
+	}
+	if audio.MinDuration < 0 || audio.MaxDuration < 0 {
+		return "audio durations cannot be negative"
+	}
+	if audio.MaxDuration > 0 && audio.MinDuration > audio.MaxDuration {
+		return fmt.Sprintf("audio.minduration %d exceeds maxduration %d", audio.MinDuration, audio.MaxDuration)
+	}
+	return ""
+}
+
+// buildImpAudioMap creates a map of impression IDs to their audio objects
+func buildImpAudioMap(req *openrtb.BidRequest) map[string]*openrtb.Audio {
+	impAudio := make(map[string]*openrtb.Audio)
+	for i := range req.Imp {
+		if req.Imp[i].Audio != nil {
+			impAudio[req.Imp[i].ID] = req.Imp[i].Audio
+		}
+	}
+	return impAudio
+}
+
+// validateAudioBid checks an audio bid's duration and protocol against its imp.
+// Bids that omit dur or protocol are accepted; the ad server enforces the rest.
+func validateAudioBid(tb *adapters.TypedBid, bidderCode string, impAudio map[string]*openrtb.Audio) *BidValidationError {
+	if tb.BidType != adapters.BidTypeAudio {
+		return nil
+	}
+	audio, ok := impAudio[tb.Bid.ImpID]
+	if !ok {
+		return nil
+	}
+
+	bid := tb.Bid
+	invalid := func(reason string) *BidValidationError {
+		return &BidValidationError{BidID: bid.ID, ImpID: bid.ImpID, BidderCode: bidderCode, Reason: reason}
+	}
+
+	if bid.Dur > 0 {
+		if bid.Dur < audio.MinDuration {
+			return invalid(fmt.Sprintf("audio duration %ds below minduration %ds", bid.Dur, audio.MinDuration))
+		}
+		if audio.MaxDuration > 0 && bid.Dur > audio.MaxDuration {
+			return invalid(fmt.Sprintf("audio duration %ds exceeds maxduration %ds", bid.Dur, audio.MaxDuration))
+		}
+	}
+
+	if bid.Protocol > 0 && len(audio.Protocols) > 0 {
+		for _, p := range audio.Protocols {
+			if p == bid.Protocol {
+				return nil
+			}
+		}
+		return invalid(fmt.Sprintf("audio protocol %d not allowed on imp", bid.Protocol))
+	}
+
+	return nil
+}
+
+// stripAudio removes audio from a bidder's request copy for bidders that don't
+// support it. Audio-only imps are dropped; multi-format imps keep their other
+// formats. Returns the number of imps left.
+func stripAudio(req *openrtb.BidRequest) int {
+	imps := req.Imp[:0]
+	for _, imp := range req.Imp {
+		if imp.Audio == nil {
+			imps = append(imps, imp)
+			continue
+		}
+		imp.Audio = nil
+		if imp.Banner != nil || imp.Video != nil || imp.Native != nil {
+			imps = append(imps, imp)
+		}
+	}
+	req.Imp = imps
+	return len(imps)
+}
+
+// hasAudioImp reports whether any imp offers audio
+func hasAudioImp(req *openrtb.BidRequest) bool {
+	for i := range req.Imp {
+		if req.Imp[i].Audio != nil {
+			return true
+		}
+	}
+	return false
+}
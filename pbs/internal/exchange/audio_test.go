@@ -0,0 +1,185 @@
+package exchange
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+type fakeAuctionMetrics struct {
+	mu       sync.Mutex
+	auctions []string // status/mediaType
+	bids     []string // bidder/mediaType
+}
+
+func (f *fakeAuctionMetrics) RecordAuction(status, mediaType string, _ time.Duration, _, _ int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.auctions = append(f.auctions, status+"/"+mediaType)
+}
+
+func (f *fakeAuctionMetrics) RecordBid(bidder, mediaType string, _ float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.bids = append(f.bids, bidder+"/"+mediaType)
+}
+
+func audioBidder(bidID string, dur, protocol int) *mockAdapter {
+	return &mockAdapter{
+		requests: []*adapters.RequestData{{Method: "MOCK"}},
+		bids: []*adapters.TypedBid{{
+			Bid:     &openrtb.Bid{ID: bidID, ImpID: "imp1", Price: 2.00, AdM: "<VAST/>", Dur: dur, Protocol: protocol},
+			BidType: adapters.BidTypeAudio,
+		}},
+	}
+}
+
+func audioRequest() *openrtb.BidRequest {
+	return &openrtb.BidRequest{
+		ID:   "audio-req",
+		Site: testSite(),
+		Imp: []openrtb.Imp{{
+			ID: "imp1",
+			Audio: &openrtb.Audio{
+				Mimes:       []string{"audio/mp4"},
+				MinDuration: 15,
+				MaxDuration: 30,
+				Protocols:   []int{2, 3},
+			},
+		}},
+	}
+}
+
+func TestValidateRequest_Audio(t *testing.T) {
+	tests := []struct {
+		name    string
+		audio   *openrtb.Audio
+		wantErr string
+	}{
+		{"valid", &openrtb.Audio{Mimes: []string{"audio/mp4"}, MinDuration: 5, MaxDuration: 30}, ""},
+		{"no max duration", &openrtb.Audio{Mimes: []string{"audio/mp4"}, MinDuration: 5}, ""},
+		{"missing mimes", &openrtb.Audio{MaxDuration: 30}, "mimes"},
+		{"inverted durations", &openrtb.Audio{Mimes: []string{"audio/mp4"}, MinDuration: 60, MaxDuration: 30}, "exceeds maxduration"},
+		{"negative duration", &openrtb.Audio{Mimes: []string{"audio/mp4"}, MinDuration: -1}, "negative"},
+	}
+	for _, tt := range tests {
+		req := &openrtb.BidRequest{ID: "r", Site: testSite(), Imp: []openrtb.Imp{{ID: "imp1", Audio: tt.audio}}}
+		err := ValidateRequest(req)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || err.Field != "imp[0].audio" || !strings.Contains(err.Reason, tt.wantErr) {
+			t.Errorf("%s: expected imp[0].audio error containing %q, got %v", tt.name, tt.wantErr, err)
+		}
+	}
+}
+
+func TestValidateAudioBid(t *testing.T) {
+	impAudio := buildImpAudioMap(audioRequest())
+
+	tests := []struct {
+		name     string
+		bidType  adapters.BidType
+		dur      int
+		protocol int
+		wantErr  string
+	}{
+		{"in range", adapters.BidTypeAudio, 20, 2, ""},
+		{"no dur or protocol", adapters.BidTypeAudio, 0, 0, ""},
+		{"too short", adapters.BidTypeAudio, 10, 0, "below minduration"},
+		{"too long", adapters.BidTypeAudio, 45, 0, "exceeds maxduration"},
+		{"protocol not allowed", adapters.BidTypeAudio, 20, 7, "protocol 7"},
+		{"non-audio bid ignored", adapters.BidTypeBanner, 45, 7, ""},
+	}
+	for _, tt := range tests {
+		tb := &adapters.TypedBid{
+			Bid:     &openrtb.Bid{ID: "b1", ImpID: "imp1", Dur: tt.dur, Protocol: tt.protocol},
+			BidType: tt.bidType,
+		}
+		err := validateAudioBid(tb, "alpha", impAudio)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Reason, tt.wantErr) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.wantErr, err)
+		}
+	}
+}
+
+func TestStripAudio(t *testing.T) {
+	req := &openrtb.BidRequest{Imp: []openrtb.Imp{
+		{ID: "audio-only", Audio: &openrtb.Audio{}},
+		{ID: "multi", Audio: &openrtb.Audio{}, Banner: &openrtb.Banner{W: 300, H: 250}},
+		{ID: "banner", Banner: &openrtb.Banner{W: 728, H: 90}},
+	}}
+
+	if n := stripAudio(req); n != 2 {
+		t.Fatalf("expected 2 imps left, got %d", n)
+	}
+	if req.Imp[0].ID != "multi" || req.Imp[0].Audio != nil || req.Imp[0].Banner == nil {
+		t.Errorf("expected multi-format imp to keep banner without audio, got %+v", req.Imp[0])
+	}
+	if req.Imp[1].ID != "banner" {
+		t.Errorf("expected banner imp untouched, got %+v", req.Imp[1])
+	}
+}
+
+func TestAudioAuction(t *testing.T) {
+	noAudio := &adapters.CapabilitiesInfo{
+		Site: &adapters.PlatformInfo{MediaTypes: []adapters.BidType{adapters.BidTypeBanner, adapters.BidTypeVideo}},
+	}
+	withAudio := &adapters.CapabilitiesInfo{
+		Site: &adapters.PlatformInfo{MediaTypes: []adapters.BidType{adapters.BidTypeAudio}},
+	}
+
+	registry := adapters.NewRegistry()
+	registry.Register("alpha", audioBidder("a-1", 20, 2), adapters.BidderInfo{Enabled: true, Capabilities: withAudio})
+	registry.Register("bravo", audioBidder("b-1", 60, 2), adapters.BidderInfo{Enabled: true})
+	registry.Register("charlie", audioBidder("c-1", 20, 2), adapters.BidderInfo{Enabled: true, Capabilities: noAudio})
+
+	ex := New(registry, &Config{
+		DefaultTimeout:  500 * time.Millisecond,
+		DefaultCurrency: "USD",
+	})
+	metrics := &fakeAuctionMetrics{}
+	ex.SetAuctionMetrics(metrics)
+
+	resp, err := ex.RunAuction(context.Background(), &AuctionRequest{BidRequest: audioRequest(), Debug: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, called := resp.BidderResults["charlie"]; called {
+		t.Error("expected bidder without audio support to be skipped")
+	}
+
+	landscape := resp.DebugInfo.BidLandscape["imp1"]
+	if len(landscape) != 2 {
+		t.Fatalf("expected 2 landscape entries, got %+v", landscape)
+	}
+	if landscape[0].Bidder != "alpha" || landscape[0].Status != LandscapeWon {
+		t.Errorf("expected alpha to win, got %+v", landscape[0])
+	}
+	if landscape[1].Bidder != "bravo" || landscape[1].Status != LandscapeRejected ||
+		!strings.Contains(landscape[1].Reason, "exceeds maxduration") {
+		t.Errorf("expected bravo rejected for duration, got %+v", landscape[1])
+	}
+
+	if len(metrics.auctions) != 1 || metrics.auctions[0] != "success/audio" {
+		t.Errorf("expected one success/audio auction, got %v", metrics.auctions)
+	}
+	if len(metrics.bids) != 1 || metrics.bids[0] != "alpha/audio" {
+		t.Errorf("expected one alpha/audio bid, got %v", metrics.bids)
+	}
+}
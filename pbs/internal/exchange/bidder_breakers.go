@@ -0,0 +1,232 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Default bidder circuit breaker tuning
+const (
+	defaultBreakerFailureThreshold = 10               // Consecutive failed calls
+	defaultBreakerOpenDuration     = 30 * time.Second // Before a probe auction is let through
+	defaultBreakerHalfOpenProbes   = 3                // Successful calls that close the circuit
+)
+
+// Bidder circuit states, as in the IDR client's circuit breaker
+const (
+	CircuitClosed   = "closed"    // Bidder called normally
+	CircuitOpen     = "open"      // Bidder skipped after repeated failures
+	CircuitHalfOpen = "half-open" // One auction at a time probes whether it recovered
+)
+
+// BidderCircuitBreakerConfig skips bidders whose endpoint keeps failing, so a
+// down SSP costs neither a concurrency slot nor its timeout in every auction.
+// A call fails on a transport error, including the bidder timing out, or a 5xx
+// status; auctions cut short don't count.
+type BidderCircuitBreakerConfig struct {
+	Enabled          bool
+	FailureThreshold int           // Consecutive failed calls that open a bidder's circuit
+	OpenDuration     time.Duration // How long an open circuit skips the bidder
+	HalfOpenProbes   int           // Successful calls in probe auctions that close it again
+}
+
+// DefaultBidderCircuitBreakerConfig returns disabled circuit breakers with default tuning
+func DefaultBidderCircuitBreakerConfig() *BidderCircuitBreakerConfig {
+	return &BidderCircuitBreakerConfig{
+		FailureThreshold: defaultBreakerFailureThreshold,
+		OpenDuration:     defaultBreakerOpenDuration,
+		HalfOpenProbes:   defaultBreakerHalfOpenProbes,
+	}
+}
+
+// CircuitBreakerMetrics receives bidder circuit state changes and skipped bidders
+type CircuitBreakerMetrics interface {
+	RecordBidderCircuitState(bidder, state string)
+	RecordBidderCircuitSkipped(bidder string)
+}
+
+// BidderCircuitStats is a snapshot of one bidder's circuit
+type BidderCircuitStats struct {
+	State    string    `json:"state"`
+	Failures int       `json:"consecutive_failures"`
+	OpenedAt time.Time `json:"opened_at,omitempty"` // Last time the circuit opened
+}
+
+// bidderBreakers tracks a circuit per bidder
+type bidderBreakers struct {
+	config  *BidderCircuitBreakerConfig
+	now     func() time.Time
+	metrics func() CircuitBreakerMetrics
+
+	mu       sync.Mutex
+	circuits map[string]*bidderCircuit
+}
+
+type bidderCircuit struct {
+	state     string
+	failures  int // Consecutive failed calls
+	successes int // Successful calls while half-open
+	openedAt  time.Time
+	probing   bool // A half-open probe auction is running
+}
+
+func newBidderBreakers(config *BidderCircuitBreakerConfig, metrics func() CircuitBreakerMetrics) *bidderBreakers {
+	return &bidderBreakers{
+		config:   config,
+		now:      time.Now,
+		metrics:  metrics,
+		circuits: make(map[string]*bidderCircuit),
+	}
+}
+
+// circuit returns code's circuit, creating it closed. Callers hold mu.
+func (b *bidderBreakers) circuit(code string) *bidderCircuit {
+	c, ok := b.circuits[code]
+	if !ok {
+		c = &bidderCircuit{state: CircuitClosed}
+		b.circuits[code] = c
+	}
+	return c
+}
+
+// setState moves c to state, returning whether it changed. Callers hold mu.
+func (b *bidderBreakers) setState(c *bidderCircuit, state string) bool {
+	if c.state == state {
+		return false
+	}
+	c.state = state
+	c.successes = 0
+	if state == CircuitOpen {
+		c.openedAt = b.now()
+		c.probing = false
+	}
+	return true
+}
+
+func (b *bidderBreakers) reportState(code, state string) {
+	if m := b.metrics(); m != nil {
+		m.RecordBidderCircuitState(code, state)
+	}
+}
+
+// allow reports whether code may be called in this auction. An open circuit
+// past OpenDuration turns half-open and lets this auction through as the
+// probe; probe reports whether it did, so the caller ends the probe with done.
+func (b *bidderBreakers) allow(code string) (allowed, probe bool) {
+	b.mu.Lock()
+	c := b.circuit(code)
+	changed := false
+	switch c.state {
+	case CircuitOpen:
+		if b.now().Sub(c.openedAt) < b.config.OpenDuration {
+			b.mu.Unlock()
+			return false, false
+		}
+		changed = b.setState(c, CircuitHalfOpen)
+		fallthrough
+	case CircuitHalfOpen:
+		if c.probing {
+			b.mu.Unlock()
+			return false, false
+		}
+		c.probing = true
+		probe = true
+	}
+	b.mu.Unlock()
+
+	if changed {
+		b.reportState(code, CircuitHalfOpen)
+	}
+	return true, probe
+}
+
+// done ends a probe auction, letting the next auction probe if the circuit is
+// still half-open (e.g. the probe made no calls)
+func (b *bidderBreakers) done(code string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.circuit(code).probing = false
+}
+
+// record feeds one bidder HTTP call's outcome into its circuit
+func (b *bidderBreakers) record(code string, failed bool) {
+	b.mu.Lock()
+	c := b.circuit(code)
+	state := ""
+	switch {
+	case failed && c.state == CircuitClosed:
+		c.failures++
+		if c.failures >= b.config.FailureThreshold && b.setState(c, CircuitOpen) {
+			state = CircuitOpen
+		}
+	case failed && c.state == CircuitHalfOpen:
+		c.failures++
+		if b.setState(c, CircuitOpen) {
+			state = CircuitOpen
+		}
+	case !failed && c.state == CircuitClosed:
+		c.failures = 0
+	case !failed && c.state == CircuitHalfOpen:
+		c.successes++
+		if c.successes >= b.config.HalfOpenProbes && b.setState(c, CircuitClosed) {
+			c.failures = 0
+			state = CircuitClosed
+		}
+	}
+	// Calls finishing while the circuit is open were started before it opened
+	b.mu.Unlock()
+
+	if state != "" {
+		b.reportState(code, state)
+	}
+}
+
+// stats returns every tracked bidder's circuit
+func (b *bidderBreakers) stats() map[string]BidderCircuitStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	stats := make(map[string]BidderCircuitStats, len(b.circuits))
+	for code, c := range b.circuits {
+		stats[code] = BidderCircuitStats{State: c.state, Failures: c.failures, OpenedAt: c.openedAt}
+	}
+	return stats
+}
+
+// callFailed reports whether a bidder HTTP call counts against its circuit.
+// Cancellation means the auction closed, not that the bidder is down.
+func callFailed(resp int, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled)
+	}
+	return resp >= http.StatusInternalServerError
+}
+
+// applyCircuitBreakers drops bidders whose circuit is open and returns the
+// bidders to call, those skipped, and a release ending any probes once the
+// calls are done
+func (e *Exchange) applyCircuitBreakers(bidders []string) (called, open []string, release func()) {
+	called = bidders[:0:0]
+	var probes []string
+	for _, code := range bidders {
+		allowed, probe := e.breakers.allow(code)
+		if !allowed {
+			open = append(open, code)
+			if m := e.breakers.metrics(); m != nil {
+				m.RecordBidderCircuitSkipped(code)
+			}
+			continue
+		}
+		called = append(called, code)
+		if probe {
+			probes = append(probes, code)
+		}
+	}
+	return called, open, func() {
+		for _, code := range probes {
+			e.breakers.done(code)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+type fakeCircuitMetrics struct {
+	mu      sync.Mutex
+	states  []string
+	skipped map[string]int
+}
+
+func (f *fakeCircuitMetrics) RecordBidderCircuitState(bidder, state string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.states = append(f.states, bidder+"="+state)
+}
+
+func (f *fakeCircuitMetrics) RecordBidderCircuitSkipped(bidder string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.skipped[bidder]++
+}
+
+func newTestBreakers(metrics CircuitBreakerMetrics, now *time.Time) *bidderBreakers {
+	b := newBidderBreakers(&BidderCircuitBreakerConfig{
+		Enabled:          true,
+		FailureThreshold: 3,
+		OpenDuration:     time.Minute,
+		HalfOpenProbes:   2,
+	}, func() CircuitBreakerMetrics { return metrics })
+	b.now = func() time.Time { return *now }
+	return b
+}
+
+func TestBidderBreakers(t *testing.T) {
+	now := time.Now()
+	metrics := &fakeCircuitMetrics{skipped: map[string]int{}}
+	b := newTestBreakers(metrics, &now)
+	state := func() string { return b.stats()["flaky"].State }
+
+	// A success resets the consecutive failure count
+	b.record("flaky", true)
+	b.record("flaky", true)
+	b.record("flaky", false)
+	b.record("flaky", true)
+	if state() != CircuitClosed {
+		t.Fatalf("expected non-consecutive failures to leave the circuit closed, got %s", state())
+	}
+	b.record("flaky", true)
+	b.record("flaky", true)
+	if state() != CircuitOpen {
+		t.Fatalf("expected 3 consecutive failures to open the circuit, got %s", state())
+	}
+	if allowed, _ := b.allow("flaky"); allowed {
+		t.Error("expected an open circuit to skip the bidder")
+	}
+
+	// Past OpenDuration one auction at a time probes
+	now = now.Add(time.Minute)
+	allowed, probe := b.allow("flaky")
+	if !allowed || !probe || state() != CircuitHalfOpen {
+		t.Fatalf("expected a probe once the circuit was open long enough, got %v %v %s", allowed, probe, state())
+	}
+	if allowed, _ := b.allow("flaky"); allowed {
+		t.Error("expected a second auction not to probe alongside the first")
+	}
+	b.record("flaky", false)
+	b.done("flaky")
+	if state() != CircuitHalfOpen {
+		t.Fatalf("expected one successful call short of HalfOpenProbes to stay half-open, got %s", state())
+	}
+
+	// A failed probe reopens the circuit
+	b.allow("flaky")
+	b.record("flaky", true)
+	b.done("flaky")
+	if state() != CircuitOpen {
+		t.Fatalf("expected a failed probe to reopen the circuit, got %s", state())
+	}
+
+	now = now.Add(time.Minute)
+	b.allow("flaky")
+	b.record("flaky", false)
+	b.record("flaky", false)
+	b.done("flaky")
+	if state() != CircuitClosed || b.stats()["flaky"].Failures != 0 {
+		t.Fatalf("expected successful probes to close the circuit, got %+v", b.stats()["flaky"])
+	}
+	if allowed, probe := b.allow("flaky"); !allowed || probe {
+		t.Error("expected a closed circuit to call the bidder without probing")
+	}
+
+	want := []string{"flaky=open", "flaky=half-open", "flaky=open", "flaky=half-open", "flaky=closed"}
+	if len(metrics.states) != len(want) {
+		t.Fatalf("expected state changes %v, got %v", want, metrics.states)
+	}
+	for i := range want {
+		if metrics.states[i] != want[i] {
+			t.Errorf("state change %d: expected %s, got %s", i, want[i], metrics.states[i])
+		}
+	}
+}
+
+func TestCallFailed(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		status int
+		err    error
+		want   bool
+	}{
+		{"no bid", http.StatusNoContent, nil, false},
+		{"bid", http.StatusOK, nil, false},
+		{"bad request", http.StatusBadRequest, nil, false},
+		{"server error", http.StatusServiceUnavailable, nil, true},
+		{"transport error", 0, errors.New("connection refused"), true},
+		{"timeout", 0, context.DeadlineExceeded, true},
+		{"auction closed", 0, context.Canceled, false},
+	} {
+		if got := callFailed(tt.status, tt.err); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestRunAuction_CircuitOpen(t *testing.T) {
+	var calls int
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	registry := adapters.NewRegistry()
+	registry.Register("flaky", &mockAdapter{requests: []*adapters.RequestData{{Method: "POST", URI: server.URL, Body: []byte(`{}`)}}}, adapters.BidderInfo{Enabled: true})
+	ex := New(registry, &Config{
+		DefaultTimeout:       100 * time.Millisecond,
+		BidderCircuitBreaker: &BidderCircuitBreakerConfig{Enabled: true, FailureThreshold: 2},
+	})
+	metrics := &fakeCircuitMetrics{skipped: map[string]int{}}
+	ex.SetCircuitBreakerMetrics(metrics)
+
+	auction := func(id string) *AuctionResponse {
+		resp, err := ex.RunAuction(context.Background(), &AuctionRequest{BidRequest: &openrtb.BidRequest{
+			ID:   id,
+			Site: testSite(),
+			Imp:  []openrtb.Imp{{ID: "imp1", Banner: &openrtb.Banner{W: 300, H: 250}}},
+		}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return resp
+	}
+
+	auction("circuit-1")
+	auction("circuit-2")
+	resp := auction("circuit-3")
+	if calls != 2 {
+		t.Errorf("expected the bidder called until its circuit opened, got %d calls", calls)
+	}
+	if len(resp.DebugInfo.CircuitOpen) != 1 || resp.DebugInfo.CircuitOpen[0] != "flaky" {
+		t.Errorf("expected flaky skipped with its circuit open, got %v", resp.DebugInfo.CircuitOpen)
+	}
+	if _, called := resp.BidderResults["flaky"]; called {
+		t.Error("expected a bidder with an open circuit not to be called")
+	}
+	if metrics.skipped["flaky"] != 1 {
+		t.Errorf("expected one skip recorded, got %v", metrics.skipped)
+	}
+	if stats := ex.BidderCircuitStats(); stats["flaky"].State != CircuitOpen {
+		t.Errorf("expected an open circuit in stats, got %+v", stats)
+	}
+}
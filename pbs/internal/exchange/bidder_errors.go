@@ -0,0 +1,62 @@
+package exchange
+
+import "github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+
+// Bidder error categories, used as the metrics error_type label
+const (
+	// BidderErrorInput: our request to the bidder couldn't be built (MakeRequests
+	// failures, imps dropped by request limits)
+	BidderErrorInput = "input"
+	// BidderErrorTransport: the bidder couldn't be reached in time (HTTP failures, timeouts)
+	BidderErrorTransport = "transport"
+	// BidderErrorResponse: the bidder answered but the answer was unusable (MakeBids
+	// failures, response ID or currency mismatches)
+	BidderErrorResponse = "response"
+)
+
+// BidderErrorMetrics receives per-bidder error counts by category
+type BidderErrorMetrics interface {
+	RecordBidderErrors(bidder, category string, count int)
+}
+
+// addErrors records errs under category and in the combined Errors list
+func (r *BidderResult) addErrors(category string, errs ...error) {
+	if len(errs) == 0 {
+		return
+	}
+	r.Errors = append(r.Errors, errs...)
+	switch category {
+	case BidderErrorInput:
+		r.InputErrors = append(r.InputErrors, errs...)
+	case BidderErrorTransport:
+		r.TransportErrors = append(r.TransportErrors, errs...)
+	case BidderErrorResponse:
+		r.ResponseErrors = append(r.ResponseErrors, errs...)
+	}
+}
+
+// ErrorCounts returns the number of errors in each category
+func (r *BidderResult) ErrorCounts() openrtb.ExtBidderErrorCounts {
+	return openrtb.ExtBidderErrorCounts{
+		Input:     len(r.InputErrors),
+		Transport: len(r.TransportErrors),
+		Response:  len(r.ResponseErrors),
+	}
+}
+
+// recordBidderErrors reports a bidder call's categorized error counts
+func recordBidderErrors(m BidderErrorMetrics, result *BidderResult) {
+	if m == nil {
+		return
+	}
+	counts := result.ErrorCounts()
+	for category, count := range map[string]int{
+		BidderErrorInput:     counts.Input,
+		BidderErrorTransport: counts.Transport,
+		BidderErrorResponse:  counts.Response,
+	} {
+		if count > 0 {
+			m.RecordBidderErrors(result.BidderCode, category, count)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+type fakeBidderErrorMetrics struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (f *fakeBidderErrorMetrics) RecordBidderErrors(bidder, category string, count int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.counts[bidder+"/"+category] += count
+}
+
+// erroringAdapter fails in every stage: one imp can't be mapped, one request
+// goes to an unreachable endpoint and the bidder's answer can't be parsed
+type erroringAdapter struct {
+	unreachable string
+}
+
+func (a *erroringAdapter) MakeRequests(*openrtb.BidRequest, *adapters.ExtraRequestInfo) ([]*adapters.RequestData, []error) {
+	return []*adapters.RequestData{
+		{Method: "POST", URI: a.unreachable, Body: []byte(`{}`)},
+		{Method: "MOCK", Body: []byte(`{}`)},
+	}, []error{errors.New("imp2: missing placement_id")}
+}
+
+func (a *erroringAdapter) MakeBids(*openrtb.BidRequest, *adapters.ResponseData) (*adapters.BidderResponse, []error) {
+	return nil, []error{errors.New("malformed response body")}
+}
+
+func TestCallBidder_CategorizesErrors(t *testing.T) {
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	ex := New(adapters.NewRegistry(), &Config{DefaultTimeout: time.Second, DefaultCurrency: "USD"})
+	result := ex.callBidder(context.Background(), podRequest(2), "bidder", &erroringAdapter{unreachable: closed.URL}, time.Second)
+
+	if len(result.InputErrors) != 1 || len(result.TransportErrors) != 1 || len(result.ResponseErrors) != 1 {
+		t.Fatalf("expected one error per category, got input=%v transport=%v response=%v",
+			result.InputErrors, result.TransportErrors, result.ResponseErrors)
+	}
+	if len(result.Errors) != 3 {
+		t.Errorf("expected all errors in the combined list, got %v", result.Errors)
+	}
+	if counts := result.ErrorCounts(); counts != (openrtb.ExtBidderErrorCounts{Input: 1, Transport: 1, Response: 1}) {
+		t.Errorf("unexpected counts %+v", counts)
+	}
+}
+
+func TestMergeChunkResults_KeepsCategories(t *testing.T) {
+	first := &BidderResult{}
+	first.addErrors(BidderErrorInput, errors.New("bad imp"))
+	second := &BidderResult{}
+	second.addErrors(BidderErrorTransport, context.DeadlineExceeded)
+	second.addErrors(BidderErrorResponse, errors.New("bad body"))
+
+	merged := mergeChunkResults("bidder", []*BidderResult{first, second})
+
+	if counts := merged.ErrorCounts(); counts != (openrtb.ExtBidderErrorCounts{Input: 1, Transport: 1, Response: 1}) {
+		t.Errorf("unexpected merged counts %+v", counts)
+	}
+	if len(merged.Errors) != 3 || !errors.Is(merged.TransportErrors[0], context.DeadlineExceeded) {
+		t.Errorf("expected wrapped batch errors, got %v", merged.Errors)
+	}
+}
+
+func TestRunAuction_RecordsBidderErrors(t *testing.T) {
+	registry := adapters.NewRegistry()
+	registry.Register("broken", &mockAdapter{makeErr: errors.New("missing param")}, adapters.BidderInfo{Enabled: true})
+	registry.Register("healthy", &mockAdapter{requests: []*adapters.RequestData{{Method: "MOCK", Body: []byte(`{}`)}}}, adapters.BidderInfo{Enabled: true})
+
+	ex := New(registry, &Config{DefaultTimeout: time.Second, DefaultCurrency: "USD"})
+	metrics := &fakeBidderErrorMetrics{counts: map[string]int{}}
+	ex.SetBidderErrorMetrics(metrics)
+
+	req := podRequest(1)
+	req.Site = testSite()
+	if _, err := ex.RunAuction(context.Background(), &AuctionRequest{BidRequest: req}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(metrics.counts) != 1 || metrics.counts["broken/"+BidderErrorInput] != 1 {
+		t.Errorf("expected one input error for the broken bidder only, got %v", metrics.counts)
+	}
+}
@@ -0,0 +1,129 @@
+package exchange
+
+import (
+	"sync"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters/ortb"
+)
+
+// Limits a throttled bidder was over
+const (
+	ThrottleQPS         = "qps"
+	ThrottleDaily       = "daily"
+	ThrottleConcurrency = "concurrency"
+)
+
+// ThrottleMetrics receives bidders skipped for exceeding their rate_limits
+type ThrottleMetrics interface {
+	RecordBidderThrottled(bidder, limit string)
+}
+
+// bidderLimiter enforces dynamic bidders' rate_limits on this instance: a
+// token bucket holding up to one second of qps_limit, a per-UTC-day count for
+// daily_limit and an in-flight count for concurrent_limit. Limits are read on
+// every call, so config changes apply immediately.
+type bidderLimiter struct {
+	mu     sync.Mutex
+	now    func() time.Time
+	states map[string]*bidderLimitState
+}
+
+type bidderLimitState struct {
+	tokens   float64
+	refilled time.Time
+	day      int // Unix day of calls
+	calls    int
+	inFlight int
+}
+
+func newBidderLimiter() *bidderLimiter {
+	return &bidderLimiter{now: time.Now, states: make(map[string]*bidderLimitState)}
+}
+
+// acquire reserves a call to code under limits. On success it returns a
+// release to run once the call is done; otherwise it returns the limit that
+// was exceeded and reserves nothing.
+func (l *bidderLimiter) acquire(code string, limits ortb.RateLimitsConfig) (release func(), exceeded string) {
+	if limits.QPSLimit <= 0 && limits.DailyLimit <= 0 && limits.ConcurrentLimit <= 0 {
+		return func() {}, ""
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	s, ok := l.states[code]
+	if !ok {
+		s = &bidderLimitState{tokens: float64(limits.QPSLimit), refilled: now}
+		l.states[code] = s
+	}
+
+	if limits.QPSLimit > 0 {
+		burst := float64(limits.QPSLimit)
+		s.tokens = min(burst, s.tokens+now.Sub(s.refilled).Seconds()*burst)
+		s.refilled = now
+	}
+	if day := int(now.Unix() / 86400); day != s.day {
+		s.day, s.calls = day, 0
+	}
+
+	switch {
+	case limits.ConcurrentLimit > 0 && s.inFlight >= limits.ConcurrentLimit:
+		return nil, ThrottleConcurrency
+	case limits.QPSLimit > 0 && s.tokens < 1:
+		return nil, ThrottleQPS
+	case limits.DailyLimit > 0 && s.calls >= limits.DailyLimit:
+		return nil, ThrottleDaily
+	}
+
+	if limits.QPSLimit > 0 {
+		s.tokens--
+	}
+	s.calls++
+	s.inFlight++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			s.inFlight--
+			l.mu.Unlock()
+		})
+	}, ""
+}
+
+// applyRateLimits drops dynamic bidders over their rate_limits and returns the
+// bidders to call, those throttled, and a release to run once the calls are
+// done. Static bidders take precedence over dynamic ones of the same code and
+// are never throttled.
+func (e *Exchange) applyRateLimits(bidders []string, dynamicRegistry *ortb.DynamicRegistry, metrics ThrottleMetrics) (called, throttled []string, release func()) {
+	called = bidders[:0:0]
+	var releases []func()
+	for _, code := range bidders {
+		if _, static := e.registry.Get(code); static {
+			called = append(called, code)
+			continue
+		}
+		da, ok := dynamicRegistry.Get(code)
+		if !ok {
+			called = append(called, code)
+			continue
+		}
+
+		done, exceeded := e.limiter.acquire(code, da.GetRateLimits())
+		if exceeded != "" {
+			throttled = append(throttled, code)
+			if metrics != nil {
+				metrics.RecordBidderThrottled(code, exceeded)
+			}
+			continue
+		}
+		called = append(called, code)
+		releases = append(releases, done)
+	}
+	return called, throttled, func() {
+		for _, done := range releases {
+			done()
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters/ortb"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+type fakeThrottleMetrics struct {
+	throttled map[string]int // bidder/limit -> count
+}
+
+func (f *fakeThrottleMetrics) RecordBidderThrottled(bidder, limit string) {
+	f.throttled[bidder+"/"+limit]++
+}
+
+func newLimitedRegistry(t *testing.T, url string, limits map[string]ortb.RateLimitsConfig) *ortb.DynamicRegistry {
+	t.Helper()
+	store := &fakeBidderStore{configs: make(map[string]string)}
+	for code, l := range limits {
+		data, _ := json.Marshal(&ortb.BidderConfig{
+			BidderCode: code,
+			Status:     "active",
+			Endpoint:   ortb.EndpointConfig{URL: url, Method: "POST", TimeoutMS: 100},
+			RateLimits: l,
+		})
+		store.configs[code] = string(data)
+	}
+	registry := ortb.NewDynamicRegistry(store, time.Minute)
+	if err := registry.Refresh(context.Background()); err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+	return registry
+}
+
+func TestBidderLimiter(t *testing.T) {
+	now := time.Date(2026, 3, 1, 23, 59, 58, 0, time.UTC)
+	l := newBidderLimiter()
+	l.now = func() time.Time { return now }
+
+	t.Run("qps", func(t *testing.T) {
+		limits := ortb.RateLimitsConfig{QPSLimit: 2}
+		for i := 0; i < 2; i++ {
+			if _, exceeded := l.acquire("qps", limits); exceeded != "" {
+				t.Fatalf("call %d: expected a burst of 2 allowed, got %s", i, exceeded)
+			}
+		}
+		if _, exceeded := l.acquire("qps", limits); exceeded != ThrottleQPS {
+			t.Errorf("expected the third call in a second throttled, got %q", exceeded)
+		}
+		now = now.Add(500 * time.Millisecond)
+		if _, exceeded := l.acquire("qps", limits); exceeded != "" {
+			t.Errorf("expected a token refilled after half a second, got %s", exceeded)
+		}
+	})
+
+	t.Run("concurrency", func(t *testing.T) {
+		limits := ortb.RateLimitsConfig{ConcurrentLimit: 1}
+		release, _ := l.acquire("conc", limits)
+		if _, exceeded := l.acquire("conc", limits); exceeded != ThrottleConcurrency {
+			t.Errorf("expected a second call in flight throttled, got %q", exceeded)
+		}
+		release()
+		release() // Releasing twice frees one slot
+		next, exceeded := l.acquire("conc", limits)
+		if exceeded != "" {
+			t.Fatalf("expected the slot freed by release, got %s", exceeded)
+		}
+		if _, exceeded := l.acquire("conc", limits); exceeded != ThrottleConcurrency {
+			t.Errorf("expected a double release not to free an extra slot, got %q", exceeded)
+		}
+		next()
+	})
+
+	t.Run("daily", func(t *testing.T) {
+		limits := ortb.RateLimitsConfig{DailyLimit: 1}
+		if _, exceeded := l.acquire("daily", limits); exceeded != "" {
+			t.Fatalf("expected the first call allowed, got %s", exceeded)
+		}
+		if _, exceeded := l.acquire("daily", limits); exceeded != ThrottleDaily {
+			t.Errorf("expected the second call today throttled, got %q", exceeded)
+		}
+		now = now.Add(2 * time.Second) // Past UTC midnight
+		if _, exceeded := l.acquire("daily", limits); exceeded != "" {
+			t.Errorf("expected the count reset at UTC midnight, got %s", exceeded)
+		}
+	})
+
+	t.Run("unlimited", func(t *testing.T) {
+		for i := 0; i < 100; i++ {
+			if _, exceeded := l.acquire("free", ortb.RateLimitsConfig{}); exceeded != "" {
+				t.Fatalf("expected no limits to never throttle, got %s", exceeded)
+			}
+		}
+		if _, tracked := l.states["free"]; tracked {
+			t.Error("expected no state kept for an unlimited bidder")
+		}
+	})
+}
+
+func TestApplyRateLimits(t *testing.T) {
+	dynamic := newLimitedRegistry(t, "https://bidder.example.com", map[string]ortb.RateLimitsConfig{
+		"limited":  {ConcurrentLimit: 1},
+		"free":     {},
+		"shadowed": {ConcurrentLimit: 1}, // Also registered statically, which wins
+	})
+	static := adapters.NewRegistry()
+	static.Register("shadowed", &mockAdapter{}, adapters.BidderInfo{Enabled: true})
+
+	ex := New(static, &Config{DefaultTimeout: 100 * time.Millisecond})
+	metrics := &fakeThrottleMetrics{throttled: map[string]int{}}
+	bidders := []string{"limited", "free", "shadowed", "unknown"}
+
+	called, throttled, release := ex.applyRateLimits(bidders, dynamic, metrics)
+	if len(called) != 4 || throttled != nil {
+		t.Fatalf("expected every bidder called first, got %v throttled %v", called, throttled)
+	}
+
+	called, throttled, _ = ex.applyRateLimits(bidders, dynamic, metrics)
+	if len(called) != 3 || len(throttled) != 1 || throttled[0] != "limited" {
+		t.Errorf("expected limited throttled while its call is in flight, got %v throttled %v", called, throttled)
+	}
+	if metrics.throttled["limited/concurrency"] != 1 || len(metrics.throttled) != 1 {
+		t.Errorf("expected one concurrency throttle recorded, got %v", metrics.throttled)
+	}
+	if bidders[0] != "limited" {
+		t.Error("expected the selected bidders not to be modified")
+	}
+
+	release()
+	if _, throttled, _ = ex.applyRateLimits(bidders, dynamic, metrics); throttled != nil {
+		t.Errorf("expected limited callable once released, got %v throttled", throttled)
+	}
+}
+
+func TestRunAuction_RateLimited(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	ex := New(adapters.NewRegistry(), &Config{
+		DefaultTimeout:        100 * time.Millisecond,
+		DynamicBiddersEnabled: true,
+	})
+	ex.SetDynamicRegistry(newLimitedRegistry(t, server.URL, map[string]ortb.RateLimitsConfig{
+		"limited": {DailyLimit: 1},
+	}))
+	metrics := &fakeThrottleMetrics{throttled: map[string]int{}}
+	ex.SetThrottleMetrics(metrics)
+
+	auction := func(id string) *AuctionResponse {
+		resp, err := ex.RunAuction(context.Background(), &AuctionRequest{BidRequest: &openrtb.BidRequest{
+			ID:   id,
+			Site: testSite(),
+			Imp:  []openrtb.Imp{{ID: "imp1", Banner: &openrtb.Banner{W: 300, H: 250}}},
+		}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return resp
+	}
+
+	if resp := auction("limit-1"); resp.DebugInfo.Throttled != nil || resp.BidderResults["limited"] == nil {
+		t.Fatalf("expected limited called in the first auction, got throttled %v", resp.DebugInfo.Throttled)
+	}
+	resp := auction("limit-2")
+	if len(resp.DebugInfo.Throttled) != 1 || resp.DebugInfo.Throttled[0] != "limited" {
+		t.Errorf("expected limited throttled in the second auction, got %v", resp.DebugInfo.Throttled)
+	}
+	if _, called := resp.BidderResults["limited"]; called {
+		t.Error("expected a throttled bidder not to be called")
+	}
+	if metrics.throttled["limited/daily"] != 1 {
+		t.Errorf("expected a daily throttle recorded, got %v", metrics.throttled)
+	}
+}
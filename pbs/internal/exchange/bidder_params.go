@@ -0,0 +1,125 @@
+package exchange
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters/ortb"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/jsonschema"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/logger"
+)
+
+// paramsSchemas caches compiled params schemas by their source text, so a
+// bidder whose schema changes (e.g. a reloaded dynamic bidder) is recompiled
+var paramsSchemas sync.Map // string -> *jsonschema.Schema, nil if it didn't compile
+
+// compiledParamsSchema returns the compiled form of a bidder's params schema,
+// or nil if it has none or it doesn't compile. Compile failures are logged once.
+func compiledParamsSchema(code string, raw json.RawMessage) *jsonschema.Schema {
+	if len(raw) == 0 {
+		return nil
+	}
+	if cached, ok := paramsSchemas.Load(string(raw)); ok {
+		return cached.(*jsonschema.Schema)
+	}
+	schema, err := jsonschema.Compile(raw)
+	if err != nil {
+		logger.Log.Error().Err(err).Str("bidder", code).Msg("invalid params schema; params won't be validated")
+	}
+	cached, _ := paramsSchemas.LoadOrStore(string(raw), schema)
+	return cached.(*jsonschema.Schema)
+}
+
+// validateBidderParams drops the imps of a bidder's request copy whose params
+// don't match the bidder's schema, returning an error for each so the
+// publisher sees why the bidder didn't bid on them. Imps without params for
+// the bidder are left for the adapter to skip.
+func validateBidderParams(req *openrtb.BidRequest, code string, info adapters.BidderInfo) []error {
+	schema := compiledParamsSchema(code, info.ParamsSchema)
+	if schema == nil {
+		return nil
+	}
+
+	var errs []error
+	imps := req.Imp[:0]
+	for _, imp := range req.Imp {
+		var params json.RawMessage
+		err := adapters.ImpParams(&imp, code, &params)
+		if err == nil {
+			err = schema.Validate(params)
+			if err != nil {
+				err = adapters.NewBadInputError(code, fmt.Sprintf("imp %s: invalid params: %v", imp.ID, err))
+			}
+		}
+		if err != nil && !errors.Is(err, adapters.ErrNoParams) {
+			errs = append(errs, err)
+			continue
+		}
+		imps = append(imps, imp)
+	}
+	req.Imp = imps
+	return errs
+}
+
+// requestParamCodes returns the bidder codes the request carries params for,
+// on any imp or in ext.prebid.bidderparams
+func requestParamCodes(req *openrtb.BidRequest, global map[string]json.RawMessage) map[string]bool {
+	codes := make(map[string]bool)
+	hasParams := func(raw json.RawMessage) bool {
+		return len(raw) > 0 && string(raw) != "null"
+	}
+	for code, params := range global {
+		if hasParams(params) {
+			codes[code] = true
+		}
+	}
+	for i := range req.Imp {
+		var ext map[string]json.RawMessage
+		if json.Unmarshal(req.Imp[i].Ext, &ext) != nil {
+			continue
+		}
+		for code, params := range ext {
+			if code != "prebid" && hasParams(params) {
+				codes[code] = true
+			}
+		}
+		var prebid struct {
+			Bidder map[string]json.RawMessage `json:"bidder"`
+		}
+		if json.Unmarshal(ext["prebid"], &prebid) != nil {
+			continue
+		}
+		for code, params := range prebid.Bidder {
+			if hasParams(params) {
+				codes[code] = true
+			}
+		}
+	}
+	return codes
+}
+
+// filterBiddersWithoutParams drops the bidders that take params, declaring a
+// params schema, but that the request carries none for, directly or through
+// one of its aliases; they couldn't bid on any imp. Bidders that take no
+// params are kept.
+func (e *Exchange) filterBiddersWithoutParams(req *openrtb.BidRequest, bidders []string, rb requestBidders, dynamicRegistry *ortb.DynamicRegistry) (kept, dropped []string) {
+	codes := requestParamCodes(req, rb.params)
+	for alias, core := range rb.aliases {
+		if codes[alias] {
+			codes[core] = true
+		}
+	}
+	kept = bidders[:0:0]
+	for _, code := range bidders {
+		if info, _ := e.bidderInfo(code, dynamicRegistry); len(info.ParamsSchema) > 0 && !codes[code] {
+			dropped = append(dropped, code)
+			continue
+		}
+		kept = append(kept, code)
+	}
+	return kept, dropped
+}
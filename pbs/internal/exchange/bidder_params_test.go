@@ -0,0 +1,156 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+const testParamsSchema = `{"type":"object","properties":{"placement":{"type":"string","minLength":1}},"required":["placement"]}`
+
+func paramsInfo() adapters.BidderInfo {
+	return adapters.BidderInfo{Enabled: true, ParamsSchema: json.RawMessage(testParamsSchema)}
+}
+
+func paramsRequest(exts ...string) *openrtb.BidRequest {
+	req := &openrtb.BidRequest{ID: "params-req", Site: &openrtb.Site{ID: "site1"}}
+	for i, ext := range exts {
+		imp := openrtb.Imp{ID: string(rune('1' + i)), Banner: &openrtb.Banner{W: 300, H: 250}}
+		if ext != "" {
+			imp.Ext = json.RawMessage(ext)
+		}
+		req.Imp = append(req.Imp, imp)
+	}
+	return req
+}
+
+func TestValidateBidderParams(t *testing.T) {
+	req := paramsRequest(
+		`{"prebid":{"bidder":{"acme":{"placement":"p1"}}}}`,
+		`{"prebid":{"bidder":{"acme":{"placement":1}}}}`,
+		`{"acme":{}}`,
+		`{"prebid":{"bidder":{"other":{}}}}`,
+		"",
+	)
+	errs := validateBidderParams(req, "acme", paramsInfo())
+
+	var ids []string
+	for _, imp := range req.Imp {
+		ids = append(ids, imp.ID)
+	}
+	if strings.Join(ids, ",") != "1,4,5" {
+		t.Errorf("expected imps 1, 4 and 5 kept, got %v", ids)
+	}
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got %v", errs)
+	}
+	if want := "imp 2: invalid params: placement: expected string, got number"; !strings.Contains(errs[0].Error(), want) {
+		t.Errorf("expected %q, got %q", want, errs[0])
+	}
+	if want := "imp 3: invalid params: placement: is required"; !strings.Contains(errs[1].Error(), want) {
+		t.Errorf("expected %q, got %q", want, errs[1])
+	}
+}
+
+func TestValidateBidderParams_NoSchema(t *testing.T) {
+	req := paramsRequest(`{"prebid":{"bidder":{"acme":{"placement":1}}}}`)
+	if errs := validateBidderParams(req, "acme", adapters.BidderInfo{Enabled: true}); errs != nil || len(req.Imp) != 1 {
+		t.Errorf("expected params left unchecked without a schema, got %v", errs)
+	}
+	errs := validateBidderParams(req, "acme", adapters.BidderInfo{ParamsSchema: json.RawMessage(`{"type":"bogus"}`)})
+	if errs != nil || len(req.Imp) != 1 {
+		t.Errorf("expected params left unchecked with an invalid schema, got %v", errs)
+	}
+}
+
+func TestBidderParams_Auction(t *testing.T) {
+	partial := &recordingAdapter{}
+	rejected := &recordingAdapter{}
+	registry := adapters.NewRegistry()
+	registry.Register("partial", partial, paramsInfo())
+	registry.Register("rejected", rejected, paramsInfo())
+
+	ex := New(registry, &Config{DefaultTimeout: time.Second, DefaultCurrency: "USD"})
+	req := paramsRequest(
+		`{"prebid":{"bidder":{"partial":{"placement":"p1"},"rejected":{"placement":""}}}}`,
+		`{"prebid":{"bidder":{"partial":{"placement":2},"rejected":{}}}}`,
+	)
+	resp, err := ex.RunAuction(context.Background(), &AuctionRequest{BidRequest: req})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if partial.req == nil || len(partial.req.Imp) != 1 || partial.req.Imp[0].ID != "1" {
+		t.Errorf("expected partial to be sent only imp 1, got %+v", partial.req)
+	}
+	if result := resp.BidderResults["partial"]; result == nil || len(result.InputErrors) != 1 {
+		t.Errorf("expected an input error for partial's invalid imp, got %+v", result)
+	}
+
+	if rejected.req != nil {
+		t.Error("expected a bidder with no valid imps not to be called")
+	}
+	result := resp.BidderResults["rejected"]
+	if result == nil || len(result.InputErrors) != 2 || !strings.Contains(result.Errors[0].Error(), "placement: must not be empty") {
+		t.Fatalf("expected rejected's params errors in its result, got %+v", result)
+	}
+	if len(req.Imp) != 2 {
+		t.Error("expected the inbound request not to be modified")
+	}
+}
+
+func TestFilterBiddersWithoutParams(t *testing.T) {
+	registry := adapters.NewRegistry()
+	for _, code := range []string{"direct", "legacy", "global", "aliased", "absent"} {
+		registry.Register(code, &mockAdapter{}, paramsInfo())
+	}
+	registry.Register("paramless", &mockAdapter{}, adapters.BidderInfo{Enabled: true})
+	ex := New(registry, &Config{DefaultTimeout: time.Second, DefaultCurrency: "USD"})
+
+	req := paramsRequest(
+		`{"prebid":{"bidder":{"direct":{"placement":"p1"},"absent":null}}}`,
+		`{"legacy":{"placement":"p2"},"gpid":"/home"}`,
+		`{"alt":{"placement":"p3"}}`,
+	)
+	rb := requestBidders{
+		aliases: bidderAliases{"alt": "aliased"},
+		params:  map[string]json.RawMessage{"global": json.RawMessage(`{"placement":"p4"}`)},
+	}
+	kept, dropped := ex.filterBiddersWithoutParams(req, []string{"direct", "legacy", "global", "aliased", "absent", "paramless"}, rb, nil)
+	if strings.Join(kept, ",") != "direct,legacy,global,aliased,paramless" {
+		t.Errorf("expected the bidders with params and those taking none kept, got %v", kept)
+	}
+	if strings.Join(dropped, ",") != "absent" {
+		t.Errorf("expected the bidder without params dropped, got %v", dropped)
+	}
+}
+
+func TestRunAuction_SkipsBiddersWithoutParams(t *testing.T) {
+	absent := &recordingAdapter{}
+	registry := adapters.NewRegistry()
+	registry.Register("present", &recordingAdapter{}, paramsInfo())
+	registry.Register("absent", absent, paramsInfo())
+	ex := New(registry, &Config{DefaultTimeout: time.Second, DefaultCurrency: "USD"})
+
+	req := paramsRequest(`{"prebid":{"bidder":{"present":{"placement":"p1"}}}}`)
+	resp, err := ex.RunAuction(context.Background(), &AuctionRequest{BidRequest: req, Diagnostics: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if absent.req != nil {
+		t.Error("expected the bidder without params not to be called")
+	}
+	if _, called := resp.BidderResults["present"]; !called {
+		t.Error("expected the bidder with params to be called")
+	}
+	excluded := resp.Diagnostics.Excluded
+	if len(excluded) != 1 || excluded[0].Bidder != "absent" || excluded[0].Stage != ExclusionNoParams {
+		t.Errorf("expected absent excluded for lacking params, got %+v", excluded)
+	}
+}
@@ -0,0 +1,129 @@
+package exchange
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Default size of the bidder worker pool
+const (
+	defaultBidderPoolWorkers   = 1024 // Bidder calls running at once across all auctions
+	defaultBidderPoolQueueSize = 4096
+)
+
+// BidderPoolConfig sizes the worker pool every auction's bidder calls run on
+type BidderPoolConfig struct {
+	Workers   int // Bidder calls running at once across all auctions
+	QueueSize int // Calls waiting for a worker; auctions wait for room beyond it
+}
+
+// DefaultBidderPoolConfig returns the default bidder worker pool size
+func DefaultBidderPoolConfig() *BidderPoolConfig {
+	return &BidderPoolConfig{
+		Workers:   defaultBidderPoolWorkers,
+		QueueSize: defaultBidderPoolQueueSize,
+	}
+}
+
+// BidderPoolMetrics receives the bidder worker pool's queue depth and how
+// long calls waited in it for a worker
+type BidderPoolMetrics interface {
+	SetBidderQueueDepth(depth int)
+	RecordBidderQueueWait(wait time.Duration)
+}
+
+// bidderPool runs bidder calls on a bounded set of long-lived workers shared
+// by all auctions, instead of a goroutine per call. Workers are started as
+// calls need them, up to Workers, and then kept; a call with no idle worker
+// waits in a FIFO queue. A queued call runs even if its auction is done by
+// the time a worker takes it; callers check for that themselves.
+type bidderPool struct {
+	config  *BidderPoolConfig
+	handoff chan bidderTask // Unbuffered; a send succeeds only if a worker is idle
+	queue   chan bidderTask
+	workers atomic.Int32
+	metrics func() BidderPoolMetrics
+}
+
+type bidderTask struct {
+	run    func()
+	queued time.Time
+}
+
+func newBidderPool(config *BidderPoolConfig, metrics func() BidderPoolMetrics) *bidderPool {
+	return &bidderPool{
+		config:  config,
+		handoff: make(chan bidderTask),
+		queue:   make(chan bidderTask, config.QueueSize),
+		metrics: metrics,
+	}
+}
+
+// submit runs fn on a worker: an idle one, a new one while there are fewer
+// than Workers, or the first free one through the queue. It returns false
+// without running fn if ctx is done while waiting for room in the queue.
+func (p *bidderPool) submit(ctx context.Context, fn func()) bool {
+	task := bidderTask{run: fn, queued: time.Now()}
+	select {
+	case p.handoff <- task:
+		return true
+	default:
+	}
+	if p.startWorker(task) {
+		return true
+	}
+	select {
+	case p.queue <- task:
+		p.recordDepth()
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// startWorker starts a worker with task as its first call, unless the pool
+// is full
+func (p *bidderPool) startWorker(task bidderTask) bool {
+	for {
+		n := p.workers.Load()
+		if int(n) >= p.config.Workers {
+			return false
+		}
+		if p.workers.CompareAndSwap(n, n+1) {
+			go p.work(task)
+			return true
+		}
+	}
+}
+
+// work runs task, then queued calls ahead of new ones
+func (p *bidderPool) work(task bidderTask) {
+	for {
+		p.run(task)
+		select {
+		case task = <-p.queue:
+			p.recordDepth()
+			continue
+		default:
+		}
+		select {
+		case task = <-p.queue:
+			p.recordDepth()
+		case task = <-p.handoff:
+		}
+	}
+}
+
+func (p *bidderPool) run(task bidderTask) {
+	if m := p.metrics(); m != nil {
+		m.RecordBidderQueueWait(time.Since(task.queued))
+	}
+	task.run()
+}
+
+func (p *bidderPool) recordDepth() {
+	if m := p.metrics(); m != nil {
+		m.SetBidderQueueDepth(len(p.queue))
+	}
+}
@@ -0,0 +1,127 @@
+package exchange
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+type fakePoolMetrics struct {
+	mu     sync.Mutex
+	depths []int
+	waits  int
+}
+
+func (m *fakePoolMetrics) SetBidderQueueDepth(depth int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.depths = append(m.depths, depth)
+}
+
+func (m *fakePoolMetrics) RecordBidderQueueWait(wait time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.waits++
+}
+
+func TestBidderPool_BoundsWorkers(t *testing.T) {
+	metrics := &fakePoolMetrics{}
+	pool := newBidderPool(&BidderPoolConfig{Workers: 2, QueueSize: 8}, func() BidderPoolMetrics { return metrics })
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 6)
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		ok := pool.submit(context.Background(), func() {
+			defer wg.Done()
+			started <- struct{}{}
+			<-release
+		})
+		if !ok {
+			t.Fatalf("call %d not submitted", i)
+		}
+	}
+	<-started
+	<-started
+	select {
+	case <-started:
+		t.Error("expected no more than 2 calls running at once")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+	wg.Wait()
+
+	if n := pool.workers.Load(); n != 2 {
+		t.Errorf("expected 2 workers, got %d", n)
+	}
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	if metrics.waits != 6 || len(metrics.depths) == 0 || metrics.depths[0] != 1 {
+		t.Errorf("expected a wait per call and depths from 1, got %d waits and depths %v", metrics.waits, metrics.depths)
+	}
+}
+
+func TestBidderPool_ReusesIdleWorkers(t *testing.T) {
+	pool := newBidderPool(&BidderPoolConfig{Workers: 4, QueueSize: 4}, func() BidderPoolMetrics { return nil })
+
+	for i := 0; i < 10; i++ {
+		done := make(chan struct{})
+		pool.submit(context.Background(), func() { close(done) })
+		<-done
+	}
+	if n := pool.workers.Load(); n > 2 {
+		t.Errorf("expected sequential calls to reuse workers, started %d", n)
+	}
+}
+
+func TestBidderPool_FullQueueWaitsForContext(t *testing.T) {
+	pool := newBidderPool(&BidderPoolConfig{Workers: 1, QueueSize: 1}, func() BidderPoolMetrics { return nil })
+
+	release := make(chan struct{})
+	defer close(release)
+	pool.submit(context.Background(), func() { <-release })
+	pool.submit(context.Background(), func() {})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	ran := false
+	if pool.submit(ctx, func() { ran = true }) {
+		t.Fatal("expected a call that found the queue full to give up with its context")
+	}
+	if ran {
+		t.Error("expected the call not to run")
+	}
+}
+
+func TestCallBidders_PoolBusyTimesOut(t *testing.T) {
+	registry := adapters.NewRegistry()
+	mock := &mockAdapter{}
+	registry.Register("bidder1", mock, adapters.BidderInfo{Enabled: true})
+	ex := New(registry, &Config{
+		DefaultTimeout: 100 * time.Millisecond,
+		BidderPool:     &BidderPoolConfig{Workers: 1, QueueSize: 1},
+	})
+	calls := 0
+	ex.httpClient = &countingHTTPClient{calls: &calls}
+
+	release := make(chan struct{})
+	defer close(release)
+	ex.bidderPool.submit(context.Background(), func() { <-release })
+
+	req := &openrtb.BidRequest{ID: "req1", Site: testSite(), Imp: []openrtb.Imp{{ID: "imp1", Banner: &openrtb.Banner{W: 300, H: 250}}}}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	results := ex.callBidders(ctx, req, []string{"bidder1"}, 30*time.Millisecond)
+
+	if r := results["bidder1"]; r == nil || !r.TimedOut {
+		t.Errorf("expected the bidder waiting on a busy pool to time out, got %+v", r)
+	}
+	if calls != 0 {
+		t.Errorf("expected no call once the auction gave up on it, got %d", calls)
+	}
+}
@@ -0,0 +1,130 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/cache"
+)
+
+// Targeting keys for cached creatives
+const (
+	TargetingKeyCacheID = "hb_cache_id" // Cached bid JSON
+	TargetingKeyUUID    = "hb_uuid"     // Cached VAST XML
+)
+
+// BidCache stores creatives for clients to fetch by ID; *cache.Client implements it
+type BidCache interface {
+	Put(ctx context.Context, entries []cache.Entry) ([]string, error)
+	URL(id string) string
+}
+
+// cacheSettings is what a request asked to cache via ext.prebid.cache
+type cacheSettings struct {
+	bids       bool
+	vastXML    bool
+	bidsTTL    int // Seconds; 0 uses the cache's default
+	vastXMLTTL int
+}
+
+// parseCacheSettings reads request ext.prebid.cache: {"bids": {}} caches each
+// returned bid's JSON and {"vastxml": {}} each video bid's VAST, either with an
+// optional "ttlseconds". ok is false when nothing is to be cached.
+func parseCacheSettings(ext json.RawMessage) (settings cacheSettings, ok bool) {
+	if len(ext) == 0 {
+		return settings, false
+	}
+	type cacheTarget struct {
+		TTLSeconds int `json:"ttlseconds"`
+	}
+	var parsed struct {
+		Prebid *struct {
+			Cache *struct {
+				Bids    *cacheTarget `json:"bids"`
+				VastXML *cacheTarget `json:"vastxml"`
+			} `json:"cache"`
+		} `json:"prebid"`
+	}
+	if err := json.Unmarshal(ext, &parsed); err != nil || parsed.Prebid == nil || parsed.Prebid.Cache == nil {
+		return settings, false
+	}
+	if c := parsed.Prebid.Cache.Bids; c != nil {
+		settings.bids = true
+		settings.bidsTTL = c.TTLSeconds
+	}
+	if c := parsed.Prebid.Cache.VastXML; c != nil {
+		settings.vastXML = true
+		settings.vastXMLTTL = c.TTLSeconds
+	}
+	return settings, settings.bids || settings.vastXML
+}
+
+// vastXML returns the VAST to cache for a video bid: its adm, or a wrapper
+// around its nurl. Empty when the bid has neither.
+func vastXML(bid *openrtb.Bid) string {
+	if bid.AdM != "" {
+		return bid.AdM
+	}
+	if bid.NURL == "" {
+		return ""
+	}
+	return `<VAST version="3.0"><Ad><Wrapper><AdSystem>prebid.org wrapper</AdSystem><VASTAdTagURI><![CDATA[` +
+		bid.NURL + `]]></VASTAdTagURI><Impression></Impression><Creatives></Creatives></Wrapper></Ad></VAST>`
+}
+
+// cacheBids stores the creatives of the bids being returned and returns each
+// cached bid's cache info. The put runs on its own short timeout rather than
+// the auction's, which may be nearly spent; if it fails the bids are returned
+// uncached along with the error.
+func cacheBids(ctx context.Context, bidCache BidCache, settings cacheSettings, bids []ValidatedBid) (map[*openrtb.Bid]*openrtb.ExtBidPrebidCache, error) {
+	type slot struct {
+		bid  *openrtb.Bid
+		vast bool
+	}
+	var entries []cache.Entry
+	var slots []slot
+	for _, vb := range bids {
+		bid := vb.Bid.Bid
+		if settings.bids {
+			if value, err := json.Marshal(bid); err == nil {
+				entries = append(entries, cache.Entry{Type: cache.TypeJSON, Value: value, TTLSeconds: settings.bidsTTL})
+				slots = append(slots, slot{bid: bid})
+			}
+		}
+		if settings.vastXML && vb.Bid.BidType == adapters.BidTypeVideo {
+			if vast := vastXML(bid); vast != "" {
+				value, _ := json.Marshal(vast)
+				entries = append(entries, cache.Entry{Type: cache.TypeXML, Value: value, TTLSeconds: settings.vastXMLTTL})
+				slots = append(slots, slot{bid: bid, vast: true})
+			}
+		}
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	ids, err := bidCache.Put(context.WithoutCancel(ctx), entries)
+	if err != nil {
+		return nil, err
+	}
+
+	cached := make(map[*openrtb.Bid]*openrtb.ExtBidPrebidCache, len(bids))
+	for i, s := range slots {
+		info := cached[s.bid]
+		if info == nil {
+			info = &openrtb.ExtBidPrebidCache{}
+			cached[s.bid] = info
+		}
+		entry := &openrtb.CacheInfo{CacheID: ids[i], URL: bidCache.URL(ids[i])}
+		if s.vast {
+			info.VastXML = entry
+		} else {
+			info.Bids = entry
+			info.Key = entry.CacheID
+			info.URL = entry.URL
+		}
+	}
+	return cached, nil
+}
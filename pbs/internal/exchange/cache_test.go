@@ -0,0 +1,148 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/cache"
+)
+
+type mockBidCache struct {
+	mu      sync.Mutex
+	err     error
+	entries []cache.Entry
+	puts    int
+}
+
+func (c *mockBidCache) Put(ctx context.Context, entries []cache.Entry) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.puts++
+	if c.err != nil {
+		return nil, c.err
+	}
+	ids := make([]string, len(entries))
+	for i, entry := range entries {
+		ids[i] = fmt.Sprintf("%s-%d", entry.Type, len(c.entries))
+		c.entries = append(c.entries, entry)
+	}
+	return ids, nil
+}
+
+func (c *mockBidCache) URL(id string) string {
+	return "https://cache.example.com/cache?uuid=" + id
+}
+
+func TestParseCacheSettings(t *testing.T) {
+	if _, ok := parseCacheSettings(json.RawMessage(`{"prebid":{"targeting":{}}}`)); ok {
+		t.Error("expected no caching without ext.prebid.cache")
+	}
+	if _, ok := parseCacheSettings(json.RawMessage(`{"prebid":{"cache":{}}}`)); ok {
+		t.Error("expected no caching with an empty ext.prebid.cache")
+	}
+	settings, ok := parseCacheSettings(json.RawMessage(`{"prebid":{"cache":{"bids":{},"vastxml":{"ttlseconds":600}}}}`))
+	if !ok || !settings.bids || !settings.vastXML || settings.bidsTTL != 0 || settings.vastXMLTTL != 600 {
+		t.Errorf("unexpected settings %+v", settings)
+	}
+}
+
+func TestVastXML(t *testing.T) {
+	if got := vastXML(&openrtb.Bid{AdM: "<VAST/>", NURL: "https://win"}); got != "<VAST/>" {
+		t.Errorf("expected adm, got %s", got)
+	}
+	if got := vastXML(&openrtb.Bid{NURL: "https://win"}); !strings.Contains(got, "<VASTAdTagURI><![CDATA[https://win]]>") {
+		t.Errorf("expected a wrapper around nurl, got %s", got)
+	}
+	if got := vastXML(&openrtb.Bid{}); got != "" {
+		t.Errorf("expected nothing to cache, got %s", got)
+	}
+}
+
+// cacheAuction runs a one-imp video auction with the given request ext
+func cacheAuction(t *testing.T, bidCache BidCache, ext string) (*AuctionResponse, *openrtb.BidExt) {
+	t.Helper()
+	registry := adapters.NewRegistry()
+	registry.Register("videobidder", &mockAdapter{
+		requests: []*adapters.RequestData{{Method: "MOCK", Body: []byte(`{}`)}},
+		bids: []*adapters.TypedBid{{
+			Bid:     &openrtb.Bid{ID: "vbid", ImpID: "imp1", Price: 5, AdM: "<VAST version=\"4.0\"/>"},
+			BidType: adapters.BidTypeVideo,
+		}},
+	}, adapters.BidderInfo{Enabled: true})
+
+	ex := New(registry, &Config{DefaultTimeout: time.Second, DefaultCurrency: "USD", BidCache: bidCache})
+	req := &openrtb.BidRequest{
+		ID:   "cache-req",
+		Site: testSite(),
+		Imp:  []openrtb.Imp{{ID: "imp1", Video: &openrtb.Video{W: 640, H: 480}}},
+		Ext:  json.RawMessage(ext),
+	}
+	resp, err := ex.RunAuction(context.Background(), &AuctionRequest{BidRequest: req})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.BidResponse.SeatBid) != 1 || len(resp.BidResponse.SeatBid[0].Bid) != 1 {
+		t.Fatalf("expected one bid, got %+v", resp.BidResponse.SeatBid)
+	}
+	var bidExt openrtb.BidExt
+	if err := json.Unmarshal(resp.BidResponse.SeatBid[0].Bid[0].Ext, &bidExt); err != nil {
+		t.Fatalf("invalid bid ext: %v", err)
+	}
+	return resp, &bidExt
+}
+
+func TestCacheBids_Auction(t *testing.T) {
+	bidCache := &mockBidCache{}
+	_, ext := cacheAuction(t, bidCache, `{"prebid":{"cache":{"bids":{},"vastxml":{"ttlseconds":900}}}}`)
+
+	if bidCache.puts != 1 || len(bidCache.entries) != 2 {
+		t.Fatalf("expected bid JSON and VAST in one put, got %d puts %+v", bidCache.puts, bidCache.entries)
+	}
+	var vast string
+	json.Unmarshal(bidCache.entries[1].Value, &vast)
+	if bidCache.entries[1].Type != cache.TypeXML || bidCache.entries[1].TTLSeconds != 900 || vast != `<VAST version="4.0"/>` {
+		t.Errorf("unexpected VAST entry %+v", bidCache.entries[1])
+	}
+
+	cached := ext.Prebid.Cache
+	if cached == nil || cached.Bids == nil || cached.VastXML == nil {
+		t.Fatalf("expected bids and vastXml cache info, got %+v", cached)
+	}
+	if cached.VastXML.CacheID != "xml-1" || cached.VastXML.URL != "https://cache.example.com/cache?uuid=xml-1" {
+		t.Errorf("unexpected vastXml cache info %+v", cached.VastXML)
+	}
+	if cached.Key != cached.Bids.CacheID || cached.URL != cached.Bids.URL {
+		t.Errorf("expected key/url to mirror the bids entry, got %+v", cached)
+	}
+	if ext.Prebid.Targeting["hb_uuid"] != "xml-1" || ext.Prebid.Targeting["hb_cache_id"] != "json-0" {
+		t.Errorf("expected cache targeting keys, got %v", ext.Prebid.Targeting)
+	}
+}
+
+func TestCacheBids_NotRequested(t *testing.T) {
+	bidCache := &mockBidCache{}
+	_, ext := cacheAuction(t, bidCache, `{}`)
+	if bidCache.puts != 0 || ext.Prebid.Cache != nil {
+		t.Errorf("expected nothing cached without ext.prebid.cache, got %d puts", bidCache.puts)
+	}
+}
+
+func TestCacheBids_FailureKeepsBids(t *testing.T) {
+	bidCache := &mockBidCache{err: errors.New("cache unavailable")}
+	resp, ext := cacheAuction(t, bidCache, `{"prebid":{"cache":{"vastxml":{}}}}`)
+
+	if ext.Prebid.Cache != nil || ext.Prebid.Targeting["hb_uuid"] != "" {
+		t.Errorf("expected the bid returned uncached, got %+v", ext.Prebid)
+	}
+	if len(resp.DebugInfo.Errors["cache"]) != 1 {
+		t.Errorf("expected the cache error in debug info, got %v", resp.DebugInfo.Errors)
+	}
+}
@@ -0,0 +1,116 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/logger"
+)
+
+// maxImpChunks bounds the outbound fan-out for a single bidder.
+// Imps beyond maxImpsPerRequest*maxImpChunks are not sent.
+const maxImpChunks = 20
+
+// callBidderChunked calls a bidder, splitting the request into batches of at most
+// maxImps impressions for partners that limit imps per request (e.g. CTV pods).
+// Batches run in parallel; bids from successful batches are kept when others fail.
+func (e *Exchange) callBidderChunked(ctx context.Context, req *openrtb.BidRequest, bidderCode string, adapter adapters.Adapter, timeout time.Duration, maxImps int) *BidderResult {
+	if maxImps <= 0 || len(req.Imp) <= maxImps {
+		return e.callBidder(ctx, req, bidderCode, adapter, timeout)
+	}
+
+	start := time.Now()
+	chunks := chunkRequest(req, maxImps, e.config.CloneLimits)
+
+	var droppedErr error
+	if len(chunks) > maxImpChunks {
+		dropped := 0
+		for _, c := range chunks[maxImpChunks:] {
+			dropped += len(c.Imp)
+		}
+		droppedErr = fmt.Errorf("%s: %d imps not sent, exceeds %d batches of %d imps", bidderCode, dropped, maxImpChunks, maxImps)
+		chunks = chunks[:maxImpChunks]
+	}
+
+	logger.Log.Debug().
+		Str("bidder", bidderCode).
+		Int("imps", len(req.Imp)).
+		Int("max_imps", maxImps).
+		Int("batches", len(chunks)).
+		Msg("splitting bidder request into imp batches")
+
+	results := make([]*BidderResult, len(chunks))
+	var wg sync.WaitGroup
+	for i, chunk := range chunks {
+		wg.Add(1)
+		go func(i int, chunk *openrtb.BidRequest) {
+			defer wg.Done()
+			results[i] = e.callBidder(ctx, chunk, bidderCode, adapter, timeout)
+		}(i, chunk)
+	}
+	wg.Wait()
+
+	merged := mergeChunkResults(bidderCode, results)
+	if droppedErr != nil {
+		merged.addErrors(BidderErrorInput, droppedErr)
+	}
+	merged.Latency = time.Since(start)
+	return merged
+}
+
+// chunkRequest splits req into requests of at most maxImps impressions each.
+// Every batch is a deep clone so adapters may mutate their request independently.
+func chunkRequest(req *openrtb.BidRequest, maxImps int, limits *CloneLimits) []*openrtb.BidRequest {
+	imps := capSlice(req.Imp, limits.MaxImpressionsPerRequest)
+	chunks := make([]*openrtb.BidRequest, 0, (len(imps)+maxImps-1)/maxImps)
+	for start := 0; start < len(imps); start += maxImps {
+		end := min(start+maxImps, len(imps))
+		// Each batch only copies its own imps
+		chunks = append(chunks, cloneRequestWithImps(req, limits, imps[start:end]))
+	}
+	return chunks
+}
+
+// mergeChunkResults combines per-batch results into a single bidder result.
+// The bidder only counts as timed out when every batch timed out, so a slow
+// batch doesn't discard bids from the others. It is cancelled or late when
+// any batch was.
+func mergeChunkResults(bidderCode string, results []*BidderResult) *BidderResult {
+	merged := &BidderResult{
+		BidderCode: bidderCode,
+		Selected:   true,
+		TimedOut:   len(results) > 0,
+	}
+
+	for i, r := range results {
+		if r == nil {
+			continue
+		}
+		merged.Bids = append(merged.Bids, r.Bids...)
+		merged.RequestBytes += r.RequestBytes
+		merged.BytesSaved += r.BytesSaved
+		merged.Cancelled = merged.Cancelled || r.Cancelled
+		merged.Late = merged.Late || r.Late
+		merged.HTTPCalls = append(merged.HTTPCalls, r.HTTPCalls...)
+		batch := func(errs []error) []error {
+			wrapped := make([]error, len(errs))
+			for j, err := range errs {
+				wrapped[j] = fmt.Errorf("batch %d: %w", i+1, err)
+			}
+			return wrapped
+		}
+		merged.addErrors(BidderErrorInput, batch(r.InputErrors)...)
+		merged.addErrors(BidderErrorTransport, batch(r.TransportErrors)...)
+		merged.addErrors(BidderErrorResponse, batch(r.ResponseErrors)...)
+		if !r.TimedOut {
+			merged.TimedOut = false
+		}
+	}
+	// Late results are timed out too
+	merged.TimedOut = merged.TimedOut || merged.Late
+	return merged
+}
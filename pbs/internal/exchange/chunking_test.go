@@ -0,0 +1,156 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+// batchAdapter bids on every imp it receives and records batch sizes.
+// Batches containing failImp return an error instead of bids.
+type batchAdapter struct {
+	mu      sync.Mutex
+	batches []int
+	failImp string
+}
+
+func (a *batchAdapter) MakeRequests(request *openrtb.BidRequest, reqInfo *adapters.ExtraRequestInfo) ([]*adapters.RequestData, []error) {
+	a.mu.Lock()
+	a.batches = append(a.batches, len(request.Imp))
+	a.mu.Unlock()
+	return []*adapters.RequestData{{Method: "MOCK", Body: []byte(`{}`)}}, nil
+}
+
+func (a *batchAdapter) MakeBids(request *openrtb.BidRequest, response *adapters.ResponseData) (*adapters.BidderResponse, []error) {
+	resp := &adapters.BidderResponse{Currency: "USD"}
+	for _, imp := range request.Imp {
+		if imp.ID == a.failImp {
+			return nil, []error{errors.New("bidder rejected batch")}
+		}
+		resp.Bids = append(resp.Bids, &adapters.TypedBid{
+			Bid:     &openrtb.Bid{ID: "bid-" + imp.ID, ImpID: imp.ID, Price: 1.0, AdM: "<div/>"},
+			BidType: adapters.BidTypeVideo,
+		})
+	}
+	return resp, nil
+}
+
+func podRequest(imps int) *openrtb.BidRequest {
+	req := &openrtb.BidRequest{ID: "pod-req"}
+	for i := 0; i < imps; i++ {
+		req.Imp = append(req.Imp, openrtb.Imp{ID: fmt.Sprintf("imp%d", i+1), Video: &openrtb.Video{W: 1920, H: 1080}})
+	}
+	return req
+}
+
+func TestCallBidderChunked_SplitsAndMerges(t *testing.T) {
+	ex := New(adapters.NewRegistry(), &Config{DefaultTimeout: time.Second, DefaultCurrency: "USD"})
+	adapter := &batchAdapter{}
+
+	result := ex.callBidderChunked(context.Background(), podRequest(25), "ctvbidder", adapter, time.Second, 10)
+
+	sort.Ints(adapter.batches)
+	if fmt.Sprint(adapter.batches) != "[5 10 10]" {
+		t.Errorf("expected batches of 10, 10 and 5, got %v", adapter.batches)
+	}
+	if len(result.Bids) != 25 {
+		t.Errorf("expected 25 merged bids, got %d", len(result.Bids))
+	}
+	if len(result.Errors) != 0 || result.TimedOut {
+		t.Errorf("unexpected errors %v / timeout %v", result.Errors, result.TimedOut)
+	}
+}
+
+func TestCallBidderChunked_NoSplitUnderLimit(t *testing.T) {
+	ex := New(adapters.NewRegistry(), &Config{DefaultTimeout: time.Second, DefaultCurrency: "USD"})
+
+	for _, maxImps := range []int{0, 5} {
+		adapter := &batchAdapter{}
+		ex.callBidderChunked(context.Background(), podRequest(5), "bidder", adapter, time.Second, maxImps)
+		if len(adapter.batches) != 1 || adapter.batches[0] != 5 {
+			t.Errorf("maxImps=%d: expected a single request, got %v", maxImps, adapter.batches)
+		}
+	}
+}
+
+func TestCallBidderChunked_PartialFailure(t *testing.T) {
+	ex := New(adapters.NewRegistry(), &Config{DefaultTimeout: time.Second, DefaultCurrency: "USD"})
+	adapter := &batchAdapter{failImp: "imp4"}
+
+	result := ex.callBidderChunked(context.Background(), podRequest(6), "bidder", adapter, time.Second, 3)
+
+	if len(result.Bids) != 3 {
+		t.Errorf("expected bids from the healthy batch only, got %d", len(result.Bids))
+	}
+	if len(result.Errors) != 1 {
+		t.Fatalf("expected 1 batch error, got %v", result.Errors)
+	}
+}
+
+func TestCallBidderChunked_DropsBeyondMaxChunks(t *testing.T) {
+	ex := New(adapters.NewRegistry(), &Config{DefaultTimeout: time.Second, DefaultCurrency: "USD"})
+	adapter := &batchAdapter{}
+
+	result := ex.callBidderChunked(context.Background(), podRequest(maxImpChunks+5), "bidder", adapter, time.Second, 1)
+
+	if len(adapter.batches) != maxImpChunks {
+		t.Errorf("expected %d batches, got %d", maxImpChunks, len(adapter.batches))
+	}
+	if len(result.Errors) != 1 {
+		t.Errorf("expected an error reporting dropped imps, got %v", result.Errors)
+	}
+}
+
+func TestChunkRequest_ClonesIndependently(t *testing.T) {
+	req := podRequest(4)
+	chunks := chunkRequest(req, 2, DefaultCloneLimits())
+
+	if len(chunks) != 2 {
+		t.Fatalf("expected 2 chunks, got %d", len(chunks))
+	}
+	chunks[0].Imp[0].TagID = "mutated"
+	if req.Imp[0].TagID != "" {
+		t.Error("expected chunk mutation not to affect the original request")
+	}
+	if chunks[1].Imp[0].ID != "imp3" {
+		t.Errorf("expected second chunk to start at imp3, got %s", chunks[1].Imp[0].ID)
+	}
+}
+
+func TestMergeChunkResults_TimedOut(t *testing.T) {
+	merged := mergeChunkResults("bidder", []*BidderResult{{TimedOut: true}, {TimedOut: false}})
+	if merged.TimedOut {
+		t.Error("expected partial timeout not to mark the bidder as timed out")
+	}
+
+	merged = mergeChunkResults("bidder", []*BidderResult{{TimedOut: true}, {TimedOut: true}})
+	if !merged.TimedOut {
+		t.Error("expected bidder timed out when every batch timed out")
+	}
+}
+
+func TestMergeChunkResults_CancelledAndLateBatches(t *testing.T) {
+	bid := &adapters.TypedBid{Bid: &openrtb.Bid{ID: "b1", ImpID: "imp1", Price: 1}}
+	merged := mergeChunkResults("bidder", []*BidderResult{
+		{Bids: []*adapters.TypedBid{bid}, BytesSaved: 10},
+		{Cancelled: true, BytesSaved: 5},
+	})
+	if !merged.Cancelled || merged.TimedOut || merged.Late {
+		t.Errorf("expected a cancelled batch to mark the bidder cancelled, got %+v", merged)
+	}
+	if merged.BytesSaved != 15 || len(merged.Bids) != 1 {
+		t.Errorf("expected bytes saved summed and bids kept, got %d saved and %d bids", merged.BytesSaved, len(merged.Bids))
+	}
+
+	merged = mergeChunkResults("bidder", []*BidderResult{{}, {Late: true, TimedOut: true}})
+	if !merged.Late || !merged.TimedOut {
+		t.Errorf("expected a late batch to mark the bidder late and timed out, got %+v", merged)
+	}
+}
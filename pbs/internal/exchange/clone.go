@@ -0,0 +1,313 @@
+package exchange
+
+import (
+	"slices"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+// Request cloning
+//
+// Every bidder goroutine gets its own copy of the bid request. Most of a
+// request is the same for every bidder, so cowCloneRequest only copies the
+// parts the per-bidder steps change - the imps, whose floors, ext and media
+// objects are rewritten or filtered, and the site, app, user and device,
+// whose FPD, personal data and shaped fields are - and shares the rest with
+// the original. The steps copy on write: a shared section (a media object,
+// an ext, the EIDs) is replaced with a new value, never written through, and
+// adapters don't modify their request at all. TestCowCloneRequest_BidderSteps
+// runs the steps on clones of a fully populated request to hold them to it.
+//
+// deepCloneRequest copies every pointer, slice and json.RawMessage ext
+// reachable from the request instead. Chunked batches use it, as do requests
+// over the clone limits, whose capped sections can't be shared. Fields the
+// request doesn't carry are nil or empty and cost nothing: empty slices are
+// re-sliced to zero capacity rather than allocated, so a later append can't
+// write into the original's backing array.
+//
+// TestDeepCloneRequest_NoSharedMemory walks every field by reflection, so a
+// reference field added to an openrtb type fails it until it is handled here.
+
+// cowCloneRequest creates a bidder's copy of req that shares every section
+// the per-bidder steps only read with req. Requests over limits are deep
+// copied instead, so the sections are capped without writing to req.
+func cowCloneRequest(req *openrtb.BidRequest, limits *CloneLimits) *openrtb.BidRequest {
+	if exceedsCloneLimits(req, limits) {
+		return deepCloneRequest(req, limits)
+	}
+	clone := *req
+	clone.Imp = slices.Clone(req.Imp)
+	clone.Site = clonePtr(req.Site, nil)
+	clone.App = clonePtr(req.App, nil)
+	clone.User = clonePtr(req.User, nil)
+	clone.Device = clonePtr(req.Device, nil)
+	return &clone
+}
+
+// exceedsCloneLimits reports whether req carries more of anything than
+// limits lets a clone have
+func exceedsCloneLimits(req *openrtb.BidRequest, limits *CloneLimits) bool {
+	if len(req.Imp) > limits.MaxImpressionsPerRequest {
+		return true
+	}
+	for i := range req.Imp {
+		if pmp := req.Imp[i].PMP; pmp != nil && len(pmp.Deals) > limits.MaxDealsPerImp {
+			return true
+		}
+	}
+	if u := req.User; u != nil && (len(u.EIDs) > limits.MaxEIDsPerUser || len(u.Data) > limits.MaxDataPerUser) {
+		return true
+	}
+	for _, c := range []*openrtb.Content{siteContent(req.Site), appContent(req.App), doohContent(req.DOOH)} {
+		if c != nil && len(c.Data) > limits.MaxDataPerUser {
+			return true
+		}
+	}
+	if s := req.Source; s != nil && s.SChain != nil && len(s.SChain.Nodes) > limits.MaxSChainNodes {
+		return true
+	}
+	return false
+}
+
+func siteContent(s *openrtb.Site) *openrtb.Content {
+	if s == nil {
+		return nil
+	}
+	return s.Content
+}
+
+func appContent(a *openrtb.App) *openrtb.Content {
+	if a == nil {
+		return nil
+	}
+	return a.Content
+}
+
+func doohContent(d *openrtb.DOOH) *openrtb.Content {
+	if d == nil {
+		return nil
+	}
+	return d.Content
+}
+
+// deepCloneRequest creates a deep copy of the BidRequest to avoid race conditions
+// when multiple bidders modify request data concurrently
+// P3-1: Uses configurable limits to bound allocations
+func deepCloneRequest(req *openrtb.BidRequest, limits *CloneLimits) *openrtb.BidRequest {
+	return cloneRequestWithImps(req, limits, capSlice(req.Imp, limits.MaxImpressionsPerRequest))
+}
+
+// cloneRequestWithImps deep copies req with imps in place of req.Imp, so
+// chunked requests only copy the impressions of their own batch
+func cloneRequestWithImps(req *openrtb.BidRequest, limits *CloneLimits, imps []openrtb.Imp) *openrtb.BidRequest {
+	clone := *req
+
+	// P1-NEW-2: Deep copy top-level string slices to prevent shared references
+	clone.Cur = slices.Clone(req.Cur)
+	clone.WSeat = slices.Clone(req.WSeat)
+	clone.BSeat = slices.Clone(req.BSeat)
+	clone.WLang = slices.Clone(req.WLang)
+	clone.WLangB = slices.Clone(req.WLangB)
+	clone.BCat = slices.Clone(req.BCat)
+	clone.BAdv = slices.Clone(req.BAdv)
+	clone.BApp = slices.Clone(req.BApp)
+	clone.Ext = slices.Clone(req.Ext)
+
+	clone.Site = clonePtr(req.Site, func(s *openrtb.Site) { deepenSite(s, limits) })
+	clone.App = clonePtr(req.App, func(a *openrtb.App) { deepenApp(a, limits) })
+	clone.DOOH = clonePtr(req.DOOH, func(d *openrtb.DOOH) { deepenDOOH(d, limits) })
+	clone.User = clonePtr(req.User, func(u *openrtb.User) { deepenUser(u, limits) })
+	clone.Device = clonePtr(req.Device, deepenDevice)
+	clone.Regs = clonePtr(req.Regs, deepenRegs)
+	clone.Source = clonePtr(req.Source, func(s *openrtb.Source) { deepenSource(s, limits) })
+
+	// P1-3: bounded allocation, applied by the caller
+	clone.Imp = cloneEach(imps, func(imp *openrtb.Imp) { deepenImp(imp, limits) })
+
+	return &clone
+}
+
+// capSlice truncates s to at most limit elements without copying
+func capSlice[S ~[]E, E any](s S, limit int) S {
+	return s[:min(len(s), limit)]
+}
+
+// clonePtr returns a copy of *p with deepen applied to the copy, or nil
+func clonePtr[T any](p *T, deepen func(*T)) *T {
+	if p == nil {
+		return nil
+	}
+	c := *p
+	if deepen != nil {
+		deepen(&c)
+	}
+	return &c
+}
+
+// cloneEach copies s and applies deepen to every element of the copy
+func cloneEach[S ~[]E, E any](s S, deepen func(*E)) S {
+	out := slices.Clone(s)
+	for i := range out {
+		deepen(&out[i])
+	}
+	return out
+}
+
+// The deepen* functions replace the reference fields of a shallow copy with
+// copies of their own, leaving the value the copy was made from untouched.
+
+func deepenImp(imp *openrtb.Imp, limits *CloneLimits) {
+	imp.Metric = cloneEach(imp.Metric, func(m *openrtb.Metric) { m.Ext = slices.Clone(m.Ext) })
+	imp.Banner = clonePtr(imp.Banner, deepenBanner)
+	imp.Video = clonePtr(imp.Video, deepenVideo)
+	imp.Audio = clonePtr(imp.Audio, deepenAudio)
+	imp.Native = clonePtr(imp.Native, deepenNative)
+	imp.PMP = clonePtr(imp.PMP, func(p *openrtb.PMP) { deepenPMP(p, limits) })
+	imp.Secure = clonePtr(imp.Secure, nil)
+	imp.IframeBuster = slices.Clone(imp.IframeBuster)
+	imp.Qty = clonePtr(imp.Qty, func(q *openrtb.Qty) { q.Ext = slices.Clone(q.Ext) })
+	imp.Refresh = clonePtr(imp.Refresh, func(r *openrtb.Refresh) {
+		r.RefSettings = cloneEach(r.RefSettings, func(rs *openrtb.RefSettings) { rs.Ext = slices.Clone(rs.Ext) })
+		r.Ext = slices.Clone(r.Ext)
+	})
+	imp.Ext = slices.Clone(imp.Ext)
+}
+
+func deepenBanner(b *openrtb.Banner) {
+	b.Format = cloneEach(b.Format, func(f *openrtb.Format) { f.Ext = slices.Clone(f.Ext) })
+	b.BType = slices.Clone(b.BType)
+	b.BAttr = slices.Clone(b.BAttr)
+	b.Mimes = slices.Clone(b.Mimes)
+	b.ExpDir = slices.Clone(b.ExpDir)
+	b.API = slices.Clone(b.API)
+	b.Ext = slices.Clone(b.Ext)
+}
+
+func deepenVideo(v *openrtb.Video) {
+	v.Mimes = slices.Clone(v.Mimes)
+	v.Protocols = slices.Clone(v.Protocols)
+	v.StartDelay = clonePtr(v.StartDelay, nil)
+	v.Skip = clonePtr(v.Skip, nil)
+	v.BAttr = slices.Clone(v.BAttr)
+	v.PlaybackMethod = slices.Clone(v.PlaybackMethod)
+	v.Delivery = slices.Clone(v.Delivery)
+	v.CompanionAd = cloneEach(v.CompanionAd, deepenBanner)
+	v.API = slices.Clone(v.API)
+	v.CompanionType = slices.Clone(v.CompanionType)
+	v.RqdDurs = slices.Clone(v.RqdDurs)
+	v.Ext = slices.Clone(v.Ext)
+}
+
+func deepenAudio(a *openrtb.Audio) {
+	a.Mimes = slices.Clone(a.Mimes)
+	a.Protocols = slices.Clone(a.Protocols)
+	a.StartDelay = clonePtr(a.StartDelay, nil)
+	a.BAttr = slices.Clone(a.BAttr)
+	a.Delivery = slices.Clone(a.Delivery)
+	a.CompanionAd = cloneEach(a.CompanionAd, deepenBanner)
+	a.API = slices.Clone(a.API)
+	a.CompanionType = slices.Clone(a.CompanionType)
+	a.RqdDurs = slices.Clone(a.RqdDurs)
+	a.Ext = slices.Clone(a.Ext)
+}
+
+func deepenNative(n *openrtb.Native) {
+	n.API = slices.Clone(n.API)
+	n.BAttr = slices.Clone(n.BAttr)
+	n.Ext = slices.Clone(n.Ext)
+}
+
+func deepenPMP(p *openrtb.PMP, limits *CloneLimits) {
+	// P1-3: bounded allocation for deals
+	p.Deals = cloneEach(capSlice(p.Deals, limits.MaxDealsPerImp), func(d *openrtb.Deal) {
+		d.WSeat = slices.Clone(d.WSeat)
+		d.WADomain = slices.Clone(d.WADomain)
+		d.Ext = slices.Clone(d.Ext)
+	})
+	p.Ext = slices.Clone(p.Ext)
+}
+
+func deepenSite(s *openrtb.Site, limits *CloneLimits) {
+	s.Cat = slices.Clone(s.Cat)
+	s.SectionCat = slices.Clone(s.SectionCat)
+	s.PageCat = slices.Clone(s.PageCat)
+	s.Publisher = clonePtr(s.Publisher, deepenPublisher)
+	s.Content = clonePtr(s.Content, func(c *openrtb.Content) { deepenContent(c, limits) })
+	s.Ext = slices.Clone(s.Ext)
+}
+
+func deepenApp(a *openrtb.App, limits *CloneLimits) {
+	a.Cat = slices.Clone(a.Cat)
+	a.SectionCat = slices.Clone(a.SectionCat)
+	a.PageCat = slices.Clone(a.PageCat)
+	a.Publisher = clonePtr(a.Publisher, deepenPublisher)
+	a.Content = clonePtr(a.Content, func(c *openrtb.Content) { deepenContent(c, limits) })
+	a.Ext = slices.Clone(a.Ext)
+}
+
+func deepenDOOH(d *openrtb.DOOH, limits *CloneLimits) {
+	d.VenueType = slices.Clone(d.VenueType)
+	d.Publisher = clonePtr(d.Publisher, deepenPublisher)
+	d.Content = clonePtr(d.Content, func(c *openrtb.Content) { deepenContent(c, limits) })
+	d.Ext = slices.Clone(d.Ext)
+}
+
+func deepenPublisher(p *openrtb.Publisher) {
+	p.Cat = slices.Clone(p.Cat)
+	p.Ext = slices.Clone(p.Ext)
+}
+
+func deepenContent(c *openrtb.Content, limits *CloneLimits) {
+	c.Producer = clonePtr(c.Producer, func(p *openrtb.Producer) {
+		p.Cat = slices.Clone(p.Cat)
+		p.Ext = slices.Clone(p.Ext)
+	})
+	c.Cat = slices.Clone(c.Cat)
+	// P2-5: Clone and limit Content.Data segments
+	c.Data = cloneEach(capSlice(c.Data, limits.MaxDataPerUser), deepenData)
+	c.Ext = slices.Clone(c.Ext)
+}
+
+func deepenUser(u *openrtb.User, limits *CloneLimits) {
+	u.Geo = clonePtr(u.Geo, deepenGeo)
+	// P1-3: bounded allocation for EIDs and data segments
+	u.Data = cloneEach(capSlice(u.Data, limits.MaxDataPerUser), deepenData)
+	u.EIDs = cloneEach(capSlice(u.EIDs, limits.MaxEIDsPerUser), func(e *openrtb.EID) {
+		e.UIDs = cloneEach(e.UIDs, func(uid *openrtb.UID) { uid.Ext = slices.Clone(uid.Ext) })
+		e.Ext = slices.Clone(e.Ext)
+	})
+	u.Ext = slices.Clone(u.Ext)
+}
+
+func deepenData(d *openrtb.Data) {
+	d.Segment = cloneEach(d.Segment, func(s *openrtb.Segment) { s.Ext = slices.Clone(s.Ext) })
+	d.Ext = slices.Clone(d.Ext)
+}
+
+func deepenDevice(d *openrtb.Device) {
+	d.Geo = clonePtr(d.Geo, deepenGeo)
+	d.DNT = clonePtr(d.DNT, nil)
+	d.Lmt = clonePtr(d.Lmt, nil)
+	d.Ext = slices.Clone(d.Ext)
+}
+
+func deepenGeo(g *openrtb.Geo) {
+	g.Ext = slices.Clone(g.Ext)
+}
+
+func deepenRegs(r *openrtb.Regs) {
+	r.GDPR = clonePtr(r.GDPR, nil)
+	r.GPPSID = slices.Clone(r.GPPSID)
+	r.Ext = slices.Clone(r.Ext)
+}
+
+func deepenSource(s *openrtb.Source, limits *CloneLimits) {
+	// P1-3: bounded allocation for supply chain nodes
+	s.SChain = clonePtr(s.SChain, func(sc *openrtb.SupplyChain) {
+		sc.Nodes = cloneEach(capSlice(sc.Nodes, limits.MaxSChainNodes), func(n *openrtb.SupplyChainNode) {
+			n.Ext = slices.Clone(n.Ext)
+		})
+		sc.Ext = slices.Clone(sc.Ext)
+	})
+	s.Ext = slices.Clone(s.Ext)
+}
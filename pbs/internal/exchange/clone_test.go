@@ -0,0 +1,315 @@
+package exchange
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/fpd"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/middleware"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+// unboundedCloneLimits keeps the clone limits out of the way of aliasing checks
+func unboundedCloneLimits() *CloneLimits {
+	return &CloneLimits{
+		MaxImpressionsPerRequest: 100,
+		MaxEIDsPerUser:           100,
+		MaxDataPerUser:           100,
+		MaxDealsPerImp:           100,
+		MaxSChainNodes:           100,
+	}
+}
+
+var rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+
+// populate sets every field reachable from v, allocating pointers and giving
+// slices two elements, so no reference field is left nil
+func populate(v reflect.Value, seed int) {
+	if v.Type() == rawMessageType {
+		// A JSON string, so mutateInPlace can rewrite its letters and keep it valid
+		v.SetBytes([]byte{'"', byte('a' + seed%26), byte('a' + (seed+1)%26), '"'})
+		return
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		v.Set(reflect.New(v.Type().Elem()))
+		populate(v.Elem(), seed)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			populate(v.Field(i), seed+i)
+		}
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), 2, 2))
+		for i := 0; i < v.Len(); i++ {
+			populate(v.Index(i), seed+i)
+		}
+	case reflect.String:
+		v.SetString("v" + string(rune('a'+seed%26)))
+	case reflect.Int, reflect.Int64:
+		v.SetInt(int64(seed + 1))
+	case reflect.Float64:
+		v.SetFloat(float64(seed) + 0.5)
+	}
+}
+
+// assertNoSharedMemory fails for every pointer or slice backing array the
+// clone shares with the original
+func assertNoSharedMemory(t *testing.T, path string, orig, clone reflect.Value) {
+	t.Helper()
+	switch orig.Kind() {
+	case reflect.Ptr:
+		if orig.IsNil() {
+			return
+		}
+		if orig.Pointer() == clone.Pointer() {
+			t.Errorf("%s: pointer shared between original and clone", path)
+			return
+		}
+		assertNoSharedMemory(t, path, orig.Elem(), clone.Elem())
+	case reflect.Struct:
+		for i := 0; i < orig.NumField(); i++ {
+			assertNoSharedMemory(t, path+"."+orig.Type().Field(i).Name, orig.Field(i), clone.Field(i))
+		}
+	case reflect.Slice:
+		if orig.Len() == 0 {
+			return
+		}
+		if orig.Pointer() == clone.Pointer() {
+			t.Errorf("%s: slice backing array shared between original and clone", path)
+			return
+		}
+		for i := 0; i < orig.Len(); i++ {
+			assertNoSharedMemory(t, path+"[]", orig.Index(i), clone.Index(i))
+		}
+	}
+}
+
+func fullyPopulatedRequest() *openrtb.BidRequest {
+	req := &openrtb.BidRequest{}
+	populate(reflect.ValueOf(req).Elem(), 0)
+	return req
+}
+
+func TestDeepCloneRequest_NoSharedMemory(t *testing.T) {
+	req := fullyPopulatedRequest()
+	clone := deepCloneRequest(req, unboundedCloneLimits())
+
+	if !reflect.DeepEqual(req, clone) {
+		t.Fatal("expected clone to equal the original")
+	}
+	assertNoSharedMemory(t, "BidRequest", reflect.ValueOf(req), reflect.ValueOf(clone))
+}
+
+func TestDeepCloneRequest_EmptySlicesNotAllocated(t *testing.T) {
+	req := &openrtb.BidRequest{
+		ID:   "empty",
+		Cur:  make([]string, 0, 4),
+		Imp:  []openrtb.Imp{{ID: "imp1", Banner: &openrtb.Banner{}}},
+		Site: &openrtb.Site{Cat: []string{}},
+	}
+	clone := deepCloneRequest(req, DefaultCloneLimits())
+
+	if clone.Site.Cat == nil || cap(clone.Site.Cat) != 0 {
+		t.Errorf("expected empty slice preserved without capacity, got %#v", clone.Site.Cat)
+	}
+	// Appending to an empty clone slice must not write into the original's spare capacity
+	clone.Cur = append(clone.Cur, "EUR")
+	if got := req.Cur[:1]; got[0] == "EUR" {
+		t.Error("append to cloned Cur wrote into the original backing array")
+	}
+	if clone.Imp[0].Banner.Format != nil || clone.Device != nil {
+		t.Error("expected absent fields to stay nil")
+	}
+}
+
+func TestChunkRequest_CopiesOnlyBatchImps(t *testing.T) {
+	req := fullyPopulatedRequest()
+	req.Imp = make([]openrtb.Imp, 5)
+	for i := range req.Imp {
+		populate(reflect.ValueOf(&req.Imp[i]).Elem(), i)
+	}
+
+	chunks := chunkRequest(req, 2, unboundedCloneLimits())
+	if len(chunks) != 3 || len(chunks[2].Imp) != 1 {
+		t.Fatalf("expected batches of 2/2/1, got %d chunks", len(chunks))
+	}
+	for i, chunk := range chunks {
+		if cap(chunk.Imp) != len(chunk.Imp) {
+			t.Errorf("chunk %d: expected imps sized to the batch, got cap %d for %d imps", i, cap(chunk.Imp), len(chunk.Imp))
+		}
+		orig := *req
+		orig.Imp = req.Imp[i*2 : i*2+len(chunk.Imp)]
+		assertNoSharedMemory(t, "chunk", reflect.ValueOf(&orig), reflect.ValueOf(chunk))
+	}
+}
+
+// TestDeepCloneRequest_ConcurrentMutation rewrites every field of per-bidder
+// clones while the original is read, as bidder goroutines do. Run with -race.
+func TestDeepCloneRequest_ConcurrentMutation(t *testing.T) {
+	req := fullyPopulatedRequest()
+	want, err := json.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for bidder := 0; bidder < 8; bidder++ {
+		clone := deepCloneRequest(req, unboundedCloneLimits())
+		wg.Add(2)
+		go func(seed int) {
+			defer wg.Done()
+			mutateInPlace(reflect.ValueOf(clone).Elem(), seed)
+		}(bidder + 7)
+		go func() {
+			defer wg.Done()
+			_, _ = json.Marshal(req)
+		}()
+	}
+	wg.Wait()
+
+	got, err := json.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Error("mutating clones changed the original request")
+	}
+}
+
+// mutateInPlace overwrites leaf values through existing pointers and slices,
+// so any memory still shared with the original is modified too
+func mutateInPlace(v reflect.Value, seed int) {
+	if v.Type() == rawMessageType {
+		if v.Len() > 2 {
+			v.Index(1).SetUint(uint64('a' + seed%26))
+		}
+		return
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			mutateInPlace(v.Elem(), seed)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			mutateInPlace(v.Field(i), seed+i)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			mutateInPlace(v.Index(i), seed+i)
+		}
+	case reflect.String, reflect.Int, reflect.Int64, reflect.Float64:
+		populate(v, seed)
+	}
+}
+
+func TestCowCloneRequest_CopiesOnlyBidderSections(t *testing.T) {
+	req := fullyPopulatedRequest()
+	clone := cowCloneRequest(req, unboundedCloneLimits())
+
+	if !reflect.DeepEqual(req, clone) {
+		t.Fatal("expected clone to equal the original")
+	}
+	if &clone.Imp[0] == &req.Imp[0] || clone.Site == req.Site || clone.App == req.App || clone.User == req.User || clone.Device == req.Device {
+		t.Error("expected imps, site, app, user and device to be copied")
+	}
+	if clone.Imp[0].Banner != req.Imp[0].Banner || clone.Site.Content != req.Site.Content || clone.User.Geo != req.User.Geo || clone.Source != req.Source {
+		t.Error("expected read-only sections to be shared")
+	}
+}
+
+func TestCowCloneRequest_OverLimitsDeepCopies(t *testing.T) {
+	req := fullyPopulatedRequest()
+	limits := unboundedCloneLimits()
+	limits.MaxEIDsPerUser = 1
+
+	clone := cowCloneRequest(req, limits)
+	if len(clone.User.EIDs) != 1 || len(req.User.EIDs) != 2 {
+		t.Errorf("expected the clone's EIDs capped to 1 and the original's kept, got %d and %d", len(clone.User.EIDs), len(req.User.EIDs))
+	}
+	if clone.Imp[0].Banner == req.Imp[0].Banner {
+		t.Error("expected an over-limit request to be deep copied")
+	}
+}
+
+// TestCowCloneRequest_BidderSteps runs the per-bidder steps on copy-on-write
+// clones, configured so each changes the request, while the original is
+// read, as bidder goroutines do. Run with -race.
+func TestCowCloneRequest_BidderSteps(t *testing.T) {
+	req := fullyPopulatedRequest()
+	for i := range req.Imp {
+		req.Imp[i].Ext = json.RawMessage(`{"prebid":{"bidder":{"alias":{"placement":1}}}}`)
+	}
+	want := deepCloneRequest(req, unboundedCloneLimits())
+
+	ex := New(adapters.NewRegistry(), DefaultConfig())
+	ex.config.Identification = &adapters.Identification{Name: "nexus", Version: "1"}
+	data := json.RawMessage(`{"segment":"x"}`)
+	bidderFPD := fpd.BidderFPD{"alias": &fpd.ResolvedFPD{
+		Site: data, App: data, User: data,
+		Imp: map[string]json.RawMessage{req.Imp[0].ID: data},
+	}}
+	info := adapters.BidderInfo{
+		Capabilities:         &adapters.CapabilitiesInfo{Site: &adapters.PlatformInfo{MediaTypes: []adapters.BidType{adapters.BidTypeBanner}}},
+		IgnoredFields:        adapters.IgnorableFields,
+		UnsupportedInventory: []string{adapters.InventoryRewarded},
+		EIDSources:           []string{req.User.EIDs[0].Source},
+		ParamsSchema:         json.RawMessage(`{"type":"object"}`),
+	}
+
+	var wg sync.WaitGroup
+	for bidder := 0; bidder < 8; bidder++ {
+		wg.Add(2)
+		go func(scrub bool) {
+			defer wg.Done()
+			bidderReq := ex.cloneRequestWithFPD(req, "alias", bidderFPD)
+			if scrub {
+				middleware.ScrubPersonalData(bidderReq)
+			}
+			filterBidderEIDs(bidderReq, info)
+			mergeBidderParams(bidderReq, "alias", json.RawMessage(`{"site":"s1"}`))
+			validateBidderParams(bidderReq, "alias", info)
+			aliasImpParams(bidderReq, "alias", "core")
+			stripAudio(bidderReq)
+			stripUnsupportedInventory(bidderReq, info)
+			shapeRequest(bidderReq, info)
+			_, _ = json.Marshal(bidderReq)
+		}(bidder%2 == 0)
+		go func() {
+			defer wg.Done()
+			_, _ = json.Marshal(req)
+		}()
+	}
+	wg.Wait()
+
+	if !reflect.DeepEqual(req, want) {
+		t.Error("per-bidder steps changed the original request")
+	}
+}
+
+// BenchmarkCloneRequestPerBidder compares cloning a request for each of a
+// dozen bidders deeply and copy-on-write
+func BenchmarkCloneRequestPerBidder(b *testing.B) {
+	req := fullyPopulatedRequest()
+	limits := unboundedCloneLimits()
+	for _, bench := range []struct {
+		name  string
+		clone func(*openrtb.BidRequest, *CloneLimits) *openrtb.BidRequest
+	}{
+		{"deep", deepCloneRequest},
+		{"cow", cowCloneRequest},
+	} {
+		b.Run(fmt.Sprintf("%s/12_bidders", bench.name), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				for bidder := 0; bidder < 12; bidder++ {
+					bench.clone(req, limits)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package exchange
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+// Creative rejection reasons, used as the metrics reason label
+const (
+	CreativeRejectSize          = "size"
+	CreativeRejectAdvertiser    = "badv"
+	CreativeRejectCategory      = "bcat"
+	CreativeRejectAttribute     = "battr"
+	CreativeRejectDocumentWrite = "document_write"
+	CreativeRejectInsecure      = "insecure"
+	CreativeRejectVAST          = "invalid_vast"
+	CreativeRejectNative        = "invalid_native"
+)
+
+// CreativeValidationConfig checks bid creatives against the request before
+// the auction, so a creative the publisher blocked never wins and the next
+// best bid can take its place. The request's badv and bcat are enforced
+// regardless, under the blocklist_enforcement flag.
+type CreativeValidationConfig struct {
+	// Enabled checks banner sizes against the imp's sizes and enforces the
+	// imp's battr
+	Enabled bool
+	// BlockDocumentWrite rejects banner markup calling document.write, which
+	// breaks in asynchronously loaded ad slots
+	BlockDocumentWrite bool
+	// RequireHTTPS rejects banner markup loading http:// resources on
+	// imps with secure=1, which browsers block as mixed content
+	RequireHTTPS bool
+}
+
+// CreativeMetrics receives bids rejected by creative validation, by reason
+type CreativeMetrics interface {
+	RecordCreativeRejected(bidder, reason string)
+}
+
+// insecureResource matches http:// URLs a creative loads: src attributes and CSS url()
+var insecureResource = regexp.MustCompile(`(?i)(\bsrc\s*=\s*["']?|url\(\s*["']?)http://`)
+
+// creativeRules holds one request's creative restrictions. A nil
+// *creativeRules accepts every bid, so callers don't need to branch on
+// whether validation is on.
+type creativeRules struct {
+	config CreativeValidationConfig
+	badv   []string        // Normalized blocked advertiser domains
+	bcat   map[string]bool // Normalized blocked categories
+	imps   map[string]*openrtb.Imp
+}
+
+// newCreativeRules returns the creative rules for req, or nil when no check
+// applies. blocklists enforces the request's badv and bcat.
+func newCreativeRules(config *CreativeValidationConfig, req *openrtb.BidRequest, blocklists bool) *creativeRules {
+	r := &creativeRules{}
+	if config != nil {
+		r.config = *config
+	}
+	if blocklists {
+		for _, domain := range req.BAdv {
+			if d := normalizeAdvertiserDomain(domain); d != "" {
+				r.badv = append(r.badv, d)
+			}
+		}
+		for _, cat := range req.BCat {
+			if c := strings.ToUpper(strings.TrimSpace(cat)); c != "" {
+				if r.bcat == nil {
+					r.bcat = make(map[string]bool, len(req.BCat))
+				}
+				r.bcat[c] = true
+			}
+		}
+	}
+	if !r.config.Enabled && !r.config.BlockDocumentWrite && !r.config.RequireHTTPS && len(r.badv) == 0 && len(r.bcat) == 0 {
+		return nil
+	}
+	r.imps = make(map[string]*openrtb.Imp, len(req.Imp))
+	for i := range req.Imp {
+		r.imps[req.Imp[i].ID] = &req.Imp[i]
+	}
+	return r
+}
+
+// validate checks a bid's creative, returning the rejection reason label
+// and error for a rejected bid
+func (r *creativeRules) validate(tb *adapters.TypedBid, bidderCode string) (string, *BidValidationError) {
+	if r == nil {
+		return "", nil
+	}
+	imp, ok := r.imps[tb.Bid.ImpID]
+	if !ok {
+		return "", nil
+	}
+
+	bid := tb.Bid
+	invalid := func(label, reason string) (string, *BidValidationError) {
+		return label, &BidValidationError{BidID: bid.ID, ImpID: bid.ImpID, BidderCode: bidderCode, Reason: reason}
+	}
+
+	for _, domain := range bid.ADomain {
+		if blocked := r.blockedAdvertiser(domain); blocked != "" {
+			return invalid(CreativeRejectAdvertiser, fmt.Sprintf("advertiser domain %q blocked by badv %q", domain, blocked))
+		}
+	}
+	for _, cat := range bid.Cat {
+		if blocked := r.blockedCategory(cat); blocked != "" {
+			return invalid(CreativeRejectCategory, fmt.Sprintf("category %q blocked by bcat %q", cat, blocked))
+		}
+	}
+
+	if r.config.Enabled {
+		if tb.BidType == adapters.BidTypeBanner && !bannerSizeAllowed(imp, bid.W, bid.H) {
+			return invalid(CreativeRejectSize, fmt.Sprintf("creative size %dx%d not in imp sizes", bid.W, bid.H))
+		}
+		if battr := impBlockedAttrs(imp, tb.BidType); len(battr) > 0 {
+			for _, attr := range bid.Attr {
+				if slices.Contains(battr, attr) {
+					return invalid(CreativeRejectAttribute, fmt.Sprintf("creative attribute %d blocked by battr", attr))
+				}
+			}
+		}
+	}
+
+	// Markup checks only apply to HTML; video, audio and native markup is VAST or JSON
+	if tb.BidType != adapters.BidTypeBanner {
+		return "", nil
+	}
+	if r.config.BlockDocumentWrite && strings.Contains(strings.ToLower(bid.AdM), "document.write") {
+		return invalid(CreativeRejectDocumentWrite, "creative calls document.write")
+	}
+	if r.config.RequireHTTPS && imp.Secure != nil && *imp.Secure == 1 && insecureResource.MatchString(bid.AdM) {
+		return invalid(CreativeRejectInsecure, "creative loads http:// resources on a secure imp")
+	}
+	return "", nil
+}
+
+// blockedAdvertiser returns the badv entry blocking domain (itself or a
+// parent domain), or "" if none does
+func (r *creativeRules) blockedAdvertiser(domain string) string {
+	if len(r.badv) == 0 {
+		return ""
+	}
+	d := normalizeAdvertiserDomain(domain)
+	if d == "" {
+		return ""
+	}
+	for _, blocked := range r.badv {
+		if d == blocked || strings.HasSuffix(d, "."+blocked) {
+			return blocked
+		}
+	}
+	return ""
+}
+
+// blockedCategory returns the bcat entry blocking cat (itself or, for an IAB
+// subcategory such as IAB7-3, its tier-1 parent IAB7), or "" if none does
+func (r *creativeRules) blockedCategory(cat string) string {
+	c := strings.ToUpper(strings.TrimSpace(cat))
+	if r.bcat[c] {
+		return c
+	}
+	if parent, _, ok := strings.Cut(c, "-"); ok && r.bcat[parent] {
+		return parent
+	}
+	return ""
+}
+
+// normalizeAdvertiserDomain lowercases a domain and strips any scheme, path and www. prefix
+func normalizeAdvertiserDomain(domain string) string {
+	d := strings.ToLower(strings.TrimSpace(domain))
+	if _, rest, ok := strings.Cut(d, "://"); ok {
+		d = rest
+	}
+	d, _, _ = strings.Cut(d, "/")
+	return strings.TrimPrefix(d, "www.")
+}
+
+// bannerSizeAllowed reports whether a w x h banner fits the imp. Bids
+// without a size, interstitials and imps without fixed sizes pass.
+func bannerSizeAllowed(imp *openrtb.Imp, w, h int) bool {
+	if imp.Banner == nil || imp.Instl == 1 || w == 0 || h == 0 {
+		return true
+	}
+	banner := imp.Banner
+	sized := false
+	if banner.W > 0 && banner.H > 0 {
+		sized = true
+		if banner.W == w && banner.H == h {
+			return true
+		}
+	}
+	for _, f := range banner.Format {
+		if f.W > 0 && f.H > 0 {
+			sized = true
+			if f.W == w && f.H == h {
+				return true
+			}
+		}
+	}
+	return !sized
+}
+
+// impBlockedAttrs returns the imp's battr for the bid's media type
+func impBlockedAttrs(imp *openrtb.Imp, bidType adapters.BidType) []int {
+	switch {
+	case bidType == adapters.BidTypeBanner && imp.Banner != nil:
+		return imp.Banner.BAttr
+	case bidType == adapters.BidTypeVideo && imp.Video != nil:
+		return imp.Video.BAttr
+	case bidType == adapters.BidTypeAudio && imp.Audio != nil:
+		return imp.Audio.BAttr
+	case bidType == adapters.BidTypeNative && imp.Native != nil:
+		return imp.Native.BAttr
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package exchange
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/flags"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+type creativeMetricsRecorder struct {
+	mu       sync.Mutex
+	rejected map[string]int // bidder/reason -> count
+}
+
+func (r *creativeMetricsRecorder) RecordCreativeRejected(bidder, reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.rejected == nil {
+		r.rejected = make(map[string]int)
+	}
+	r.rejected[bidder+"/"+reason]++
+}
+
+func TestCreativeRules_Validate(t *testing.T) {
+	secure := 1
+	req := &openrtb.BidRequest{
+		BAdv: []string{"Blocked.com", "https://www.other-blocked.com/path"},
+		BCat: []string{"IAB7", "IAB25-3"},
+		Imp: []openrtb.Imp{
+			{ID: "banner", Secure: &secure, Banner: &openrtb.Banner{
+				W: 300, H: 250, Format: []openrtb.Format{{W: 728, H: 90}}, BAttr: []int{1, 3},
+			}},
+			{ID: "unsized", Banner: &openrtb.Banner{}},
+			{ID: "instl", Instl: 1, Banner: &openrtb.Banner{W: 320, H: 480}},
+			{ID: "insecure", Banner: &openrtb.Banner{W: 300, H: 250}},
+			{ID: "video", Video: &openrtb.Video{BAttr: []int{16}}},
+		},
+	}
+	all := &CreativeValidationConfig{Enabled: true, BlockDocumentWrite: true, RequireHTTPS: true}
+
+	tests := []struct {
+		name    string
+		bidType adapters.BidType
+		bid     openrtb.Bid
+		want    string
+	}{
+		{"clean banner", adapters.BidTypeBanner, openrtb.Bid{ImpID: "banner", W: 300, H: 250, ADomain: []string{"fine.com"}, Cat: []string{"IAB1"}, Attr: []int{2}, AdM: `<img src="https://cdn.example/a.png">`}, ""},
+		{"format size", adapters.BidTypeBanner, openrtb.Bid{ImpID: "banner", W: 728, H: 90}, ""},
+		{"unsized bid", adapters.BidTypeBanner, openrtb.Bid{ImpID: "banner"}, ""},
+		{"wrong size", adapters.BidTypeBanner, openrtb.Bid{ImpID: "banner", W: 160, H: 600}, CreativeRejectSize},
+		{"unsized imp", adapters.BidTypeBanner, openrtb.Bid{ImpID: "unsized", W: 160, H: 600}, ""},
+		{"interstitial", adapters.BidTypeBanner, openrtb.Bid{ImpID: "instl", W: 300, H: 600}, ""},
+		{"blocked domain", adapters.BidTypeBanner, openrtb.Bid{ImpID: "banner", ADomain: []string{"blocked.com"}}, CreativeRejectAdvertiser},
+		{"blocked subdomain", adapters.BidTypeBanner, openrtb.Bid{ImpID: "banner", ADomain: []string{"https://ads.Other-Blocked.com"}}, CreativeRejectAdvertiser},
+		{"lookalike domain", adapters.BidTypeBanner, openrtb.Bid{ImpID: "banner", ADomain: []string{"notblocked.com"}}, ""},
+		{"blocked category", adapters.BidTypeBanner, openrtb.Bid{ImpID: "banner", Cat: []string{"IAB25-3"}}, CreativeRejectCategory},
+		{"blocked parent category", adapters.BidTypeBanner, openrtb.Bid{ImpID: "banner", Cat: []string{"iab7-12"}}, CreativeRejectCategory},
+		{"sibling category", adapters.BidTypeBanner, openrtb.Bid{ImpID: "banner", Cat: []string{"IAB25-1"}}, ""},
+		{"blocked attribute", adapters.BidTypeBanner, openrtb.Bid{ImpID: "banner", Attr: []int{3}}, CreativeRejectAttribute},
+		{"video attribute", adapters.BidTypeVideo, openrtb.Bid{ImpID: "video", Attr: []int{16}}, CreativeRejectAttribute},
+		{"banner battr on video", adapters.BidTypeVideo, openrtb.Bid{ImpID: "video", Attr: []int{3}}, ""},
+		{"document.write", adapters.BidTypeBanner, openrtb.Bid{ImpID: "banner", AdM: `<script>Document.Write("x")</script>`}, CreativeRejectDocumentWrite},
+		{"http resource", adapters.BidTypeBanner, openrtb.Bid{ImpID: "banner", AdM: `<img src='http://cdn.example/a.png'>`}, CreativeRejectInsecure},
+		{"http css", adapters.BidTypeBanner, openrtb.Bid{ImpID: "banner", AdM: `<div style="background:url(http://cdn.example/a.png)">`}, CreativeRejectInsecure},
+		{"http click link", adapters.BidTypeBanner, openrtb.Bid{ImpID: "banner", AdM: `<a href="http://landing.example">`}, ""},
+		{"http on non-secure imp", adapters.BidTypeBanner, openrtb.Bid{ImpID: "insecure", AdM: `<img src="http://cdn.example/a.png">`}, ""},
+		{"VAST markup", adapters.BidTypeVideo, openrtb.Bid{ImpID: "video", AdM: `<VAST><MediaFile>http://cdn.example/v.mp4</MediaFile></VAST>`}, ""},
+	}
+	rules := newCreativeRules(all, req, true)
+	for _, tt := range tests {
+		label, err := rules.validate(&adapters.TypedBid{Bid: &tt.bid, BidType: tt.bidType}, "bidder")
+		if label != tt.want || (err != nil) != (tt.want != "") {
+			t.Errorf("%s: expected %q, got %q (%v)", tt.name, tt.want, label, err)
+		}
+	}
+
+	// Only the checks that are on apply
+	blockedBid := &adapters.TypedBid{Bid: &openrtb.Bid{ImpID: "banner", W: 1, H: 1, ADomain: []string{"blocked.com"}}, BidType: adapters.BidTypeBanner}
+	if label, _ := newCreativeRules(&CreativeValidationConfig{BlockDocumentWrite: true}, req, false).validate(blockedBid, "bidder"); label != "" {
+		t.Errorf("expected badv unchecked with blocklists off, got %q", label)
+	}
+	if label, _ := newCreativeRules(nil, req, true).validate(blockedBid, "bidder"); label != CreativeRejectAdvertiser {
+		t.Errorf("expected badv enforced without creative validation, got %q", label)
+	}
+
+	if rules := newCreativeRules(&CreativeValidationConfig{}, req, false); rules != nil {
+		t.Error("expected nil rules with every check off")
+	}
+	if rules := newCreativeRules(nil, &openrtb.BidRequest{Imp: req.Imp}, true); rules != nil {
+		t.Error("expected nil rules for a request without badv or bcat")
+	}
+	if label, err := (*creativeRules)(nil).validate(&adapters.TypedBid{Bid: &openrtb.Bid{ImpID: "banner", W: 1, H: 1}}, "bidder"); label != "" || err != nil {
+		t.Errorf("expected nil rules to accept every bid, got %q", label)
+	}
+}
+
+func TestRunAuction_BlocklistEnforcement(t *testing.T) {
+	newBidder := func(id string, price float64, adomain string) *mockAdapter {
+		return &mockAdapter{
+			requests: []*adapters.RequestData{{Method: "MOCK", Body: []byte(`{}`)}},
+			bids: []*adapters.TypedBid{{
+				Bid:     &openrtb.Bid{ID: id, ImpID: "imp1", Price: price, W: 300, H: 250, AdM: "<div/>", ADomain: []string{adomain}},
+				BidType: adapters.BidTypeBanner,
+			}},
+		}
+	}
+	registry := adapters.NewRegistry()
+	registry.Register("blocked", newBidder("blocked-bid", 5.00, "blocked.com"), adapters.BidderInfo{Enabled: true, DemandType: adapters.DemandTypePublisher})
+	registry.Register("clean", newBidder("clean-bid", 2.00, "clean.com"), adapters.BidderInfo{Enabled: true, DemandType: adapters.DemandTypePublisher})
+	ex := New(registry, &Config{DefaultTimeout: time.Second, DefaultCurrency: "USD", AuctionType: FirstPriceAuction})
+	metrics := &creativeMetricsRecorder{}
+	ex.SetCreativeMetrics(metrics)
+
+	req := &openrtb.BidRequest{
+		ID:   "creative-req",
+		Site: testSite(),
+		BAdv: []string{"blocked.com"},
+		Imp:  []openrtb.Imp{{ID: "imp1", Banner: &openrtb.Banner{W: 300, H: 250}}},
+	}
+	resp, err := ex.RunAuction(context.Background(), &AuctionRequest{BidRequest: req})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(resp.BidResponse.SeatBid) != 1 || resp.BidResponse.SeatBid[0].Seat != "clean" {
+		t.Fatalf("expected only the clean bid returned, got %+v", resp.BidResponse.SeatBid)
+	}
+	if metrics.rejected["blocked/badv"] != 1 {
+		t.Errorf("expected one badv rejection recorded, got %v", metrics.rejected)
+	}
+	if errs := resp.DebugInfo.Errors["blocked"]; len(errs) != 1 || !strings.Contains(errs[0], `advertiser domain "blocked.com" blocked by badv`) {
+		t.Errorf("expected the rejection in debug errors, got %v", resp.DebugInfo.Errors)
+	}
+
+	// Turning the flag off lets the blocked bid through
+	reg := flags.NewRegistry()
+	reg.Set(context.Background(), flags.BlocklistEnforcement, false, 0, "test", "")
+	ex.SetFlags(reg)
+	resp, err = ex.RunAuction(context.Background(), &AuctionRequest{BidRequest: req})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.BidResponse.SeatBid) != 2 {
+		t.Errorf("expected both bids with blocklist_enforcement off, got %+v", resp.BidResponse.SeatBid)
+	}
+}
@@ -0,0 +1,42 @@
+package exchange
+
+import (
+	"errors"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/currency"
+)
+
+// errNoRates is returned for bids in another currency when conversion is off
+var errNoRates = errors.New("currency conversion not configured")
+
+// currencyConverter returns the rates floors and bids are converted with, nil
+// when currency conversion is off
+func (e *Exchange) currencyConverter() currency.Converter {
+	if !e.config.CurrencyConv {
+		return nil
+	}
+	return e.config.CurrencyRates
+}
+
+// convertBids reprices bids from one currency into another, keeping each
+// bid's original price and currency for its ext. Bids are left untouched
+// when there is no rate.
+func convertBids(bids []*adapters.TypedBid, from, to string, conv currency.Converter) error {
+	if conv == nil {
+		return errNoRates
+	}
+	rate, err := conv.Rate(from, to)
+	if err != nil {
+		return err
+	}
+	for _, tb := range bids {
+		if tb == nil || tb.Bid == nil {
+			continue
+		}
+		tb.OriginalCPM = tb.Bid.Price
+		tb.OriginalCurrency = from
+		tb.Bid.Price *= rate
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/currency"
+)
+
+// eurAuction runs a one-imp auction against a bidder responding in EUR
+func eurAuction(t *testing.T, rates currency.Converter, price float64) *AuctionResponse {
+	t.Helper()
+	registry := adapters.NewRegistry()
+	registry.Register("eurbidder", &mockAdapter{
+		requests: []*adapters.RequestData{{Method: "MOCK", Body: []byte(`{}`)}},
+		currency: "EUR",
+		bids: []*adapters.TypedBid{{
+			Bid:     &openrtb.Bid{ID: "bid1", ImpID: "imp1", Price: price, AdM: "<div/>"},
+			BidType: adapters.BidTypeBanner,
+		}},
+	}, adapters.BidderInfo{Enabled: true})
+
+	ex := New(registry, &Config{DefaultTimeout: time.Second, DefaultCurrency: "USD", CurrencyConv: true, CurrencyRates: rates})
+	req := &openrtb.BidRequest{ID: "cur-req", Site: testSite(), Imp: []openrtb.Imp{{ID: "imp1", Banner: &openrtb.Banner{W: 300, H: 250}, BidFloor: 1}}}
+	resp, err := ex.RunAuction(context.Background(), &AuctionRequest{BidRequest: req})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return resp
+}
+
+func TestCurrency_ConvertsBids(t *testing.T) {
+	rates, _ := currency.NewRates("USD", map[string]float64{"EUR": 0.8})
+	resp := eurAuction(t, rates, 2.0)
+
+	if len(resp.BidResponse.SeatBid) != 1 || len(resp.BidResponse.SeatBid[0].Bid) != 1 {
+		t.Fatalf("expected the converted bid to win, got %+v", resp.BidResponse.SeatBid)
+	}
+	bid := resp.BidResponse.SeatBid[0].Bid[0]
+	if math.Abs(bid.Price-2.5) > 1e-9 {
+		t.Errorf("expected 2 EUR converted to 2.50 USD, got %v", bid.Price)
+	}
+
+	var ext openrtb.BidExt
+	if err := json.Unmarshal(bid.Ext, &ext); err != nil {
+		t.Fatalf("invalid bid ext: %v", err)
+	}
+	if ext.OrigBidCPM != 2.0 || ext.OrigBidCur != "EUR" {
+		t.Errorf("expected original price and currency in ext, got %v %s", ext.OrigBidCPM, ext.OrigBidCur)
+	}
+	if ext.Prebid.Targeting["hb_pb"] != "2.50" {
+		t.Errorf("expected targeting on the converted price, got %s", ext.Prebid.Targeting["hb_pb"])
+	}
+}
+
+func TestCurrency_ConvertedPriceMeetsFloor(t *testing.T) {
+	// 0.9 EUR is above the 1.00 USD floor only after conversion
+	rates, _ := currency.NewRates("USD", map[string]float64{"EUR": 0.8})
+	if resp := eurAuction(t, rates, 0.9); len(resp.BidResponse.SeatBid) != 1 {
+		t.Errorf("expected 0.9 EUR (1.125 USD) to clear a 1.00 USD floor, got %+v", resp.BidResponse.SeatBid)
+	}
+}
+
+func TestCurrency_RejectedWithoutRates(t *testing.T) {
+	for name, rates := range map[string]currency.Converter{
+		"no rates":     nil,
+		"unknown rate": mustRates(t, map[string]float64{"GBP": 0.8}),
+	} {
+		resp := eurAuction(t, rates, 2.0)
+		if len(resp.BidResponse.SeatBid) != 0 {
+			t.Errorf("%s: expected EUR bids rejected under strict currency", name)
+		}
+		result := resp.BidderResults["eurbidder"]
+		if result == nil || len(result.ResponseErrors) != 1 || !strings.Contains(result.ResponseErrors[0].Error(), "currency mismatch") {
+			t.Errorf("%s: expected a currency mismatch error, got %+v", name, result)
+		}
+	}
+}
+
+func mustRates(t *testing.T, perUSD map[string]float64) *currency.Rates {
+	t.Helper()
+	rates, err := currency.NewRates("USD", perUSD)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return rates
+}
+
+func TestConvertBids_NoRateLeavesBids(t *testing.T) {
+	bids := []*adapters.TypedBid{{Bid: &openrtb.Bid{Price: 3}}, nil}
+	if err := convertBids(bids, "EUR", "USD", nil); err != errNoRates {
+		t.Errorf("expected errNoRates, got %v", err)
+	}
+	if bids[0].Bid.Price != 3 || bids[0].OriginalCurrency != "" {
+		t.Errorf("expected bid untouched, got %+v", bids[0])
+	}
+}
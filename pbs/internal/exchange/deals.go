@@ -0,0 +1,61 @@
+package exchange
+
+import (
+	"slices"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/currency"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/floors"
+)
+
+// impDealFloors maps imp ID to the deals offered in its PMP, with each deal's
+// floor in the auction currency (0 for deals without one)
+type impDealFloors map[string]map[string]float64
+
+// buildImpDealFloors collects the deals offered on each imp. Deal floors that
+// can't be converted are kept as sent.
+func buildImpDealFloors(req *openrtb.BidRequest, auctionCur string, conv currency.Converter) impDealFloors {
+	var deals impDealFloors
+	for _, imp := range req.Imp {
+		if imp.PMP == nil || len(imp.PMP.Deals) == 0 {
+			continue
+		}
+		if deals == nil {
+			deals = make(impDealFloors)
+		}
+		impDeals := make(map[string]float64, len(imp.PMP.Deals))
+		for _, deal := range imp.PMP.Deals {
+			floor, err := floors.Resolve(deal.BidFloor, deal.BidFloorCur, auctionCur, conv)
+			if err != nil {
+				floor = deal.BidFloor
+			}
+			impDeals[deal.ID] = floor
+		}
+		deals[imp.ID] = impDeals
+	}
+	return deals
+}
+
+// floor returns the deal floor a bid must meet instead of its imp's floor,
+// when the bid is for a deal offered on its imp
+func (d impDealFloors) floor(bid *openrtb.Bid) (float64, bool) {
+	if bid.DealID == "" {
+		return 0, false
+	}
+	floor, ok := d[bid.ImpID][bid.DealID]
+	return floor, ok
+}
+
+// preferDeals moves preferred deal bids ahead of open-auction bids, keeping
+// each group in price order
+func preferDeals(bids []ValidatedBid) {
+	slices.SortStableFunc(bids, func(a, b ValidatedBid) int {
+		switch {
+		case a.PreferredDeal && !b.PreferredDeal:
+			return -1
+		case !a.PreferredDeal && b.PreferredDeal:
+			return 1
+		}
+		return 0
+	})
+}
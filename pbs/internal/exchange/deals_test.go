@@ -0,0 +1,138 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+func TestImpDealFloors(t *testing.T) {
+	req := &openrtb.BidRequest{Imp: []openrtb.Imp{
+		{ID: "imp1", BidFloor: 2, PMP: &openrtb.PMP{Deals: []openrtb.Deal{
+			{ID: "deal-floored", BidFloor: 4},
+			{ID: "deal-open"},
+		}}},
+		{ID: "imp2", BidFloor: 2},
+	}}
+	deals := buildImpDealFloors(req, "USD", nil)
+
+	tests := []struct {
+		bid       openrtb.Bid
+		wantFloor float64
+		wantDeal  bool
+	}{
+		{openrtb.Bid{ImpID: "imp1", DealID: "deal-floored"}, 4, true},
+		{openrtb.Bid{ImpID: "imp1", DealID: "deal-open"}, 0, true},
+		{openrtb.Bid{ImpID: "imp1", DealID: "unknown"}, 0, false},
+		{openrtb.Bid{ImpID: "imp2", DealID: "deal-floored"}, 0, false},
+		{openrtb.Bid{ImpID: "imp1"}, 0, false},
+	}
+	for _, tt := range tests {
+		floor, ok := deals.floor(&tt.bid)
+		if floor != tt.wantFloor || ok != tt.wantDeal {
+			t.Errorf("%s/%q: expected (%v, %v), got (%v, %v)", tt.bid.ImpID, tt.bid.DealID, tt.wantFloor, tt.wantDeal, floor, ok)
+		}
+	}
+
+	if deals := buildImpDealFloors(&openrtb.BidRequest{Imp: []openrtb.Imp{{ID: "imp1"}}}, "USD", nil); deals != nil {
+		t.Errorf("expected nil without deals, got %v", deals)
+	}
+}
+
+func TestAuctionLogic_PreferredDeals(t *testing.T) {
+	bids := func() []ValidatedBid {
+		return []ValidatedBid{
+			{Bid: &adapters.TypedBid{Bid: &openrtb.Bid{ID: "open", ImpID: "imp1", Price: 5.00}}, BidderCode: "open"},
+			{Bid: &adapters.TypedBid{Bid: &openrtb.Bid{ID: "deal", ImpID: "imp1", Price: 3.00, DealID: "d1"}}, BidderCode: "deal", PreferredDeal: true},
+			{Bid: &adapters.TypedBid{Bid: &openrtb.Bid{ID: "open2", ImpID: "imp1", Price: 4.00}}, BidderCode: "open2"},
+		}
+	}
+
+	for _, auctionType := range []AuctionType{FirstPriceAuction, SecondPriceAuction} {
+		ex := New(adapters.NewRegistry(), &Config{AuctionType: auctionType, PriceIncrement: 0.01})
+		result := ex.runAuctionLogic(bids(), map[string]float64{})
+
+		var order []string
+		for _, vb := range result["imp1"] {
+			order = append(order, vb.Bid.Bid.ID)
+		}
+		if len(order) != 3 || order[0] != "deal" || order[1] != "open" || order[2] != "open2" {
+			t.Errorf("auction type %d: expected the deal first then open bids by price, got %v", auctionType, order)
+		}
+		if price := result["imp1"][0].Bid.Bid.Price; price != 3.00 {
+			t.Errorf("auction type %d: expected the preferred deal to pay its bid, got %.2f", auctionType, price)
+		}
+	}
+}
+
+func TestRunAuction_DealFloorsAndPreference(t *testing.T) {
+	newBid := func(id string, price float64, dealID string) *adapters.TypedBid {
+		return &adapters.TypedBid{
+			Bid:     &openrtb.Bid{ID: id, ImpID: "imp1", Price: price, DealID: dealID, W: 300, H: 250, AdM: "<div/>"},
+			BidType: adapters.BidTypeBanner,
+		}
+	}
+	adapter := func(bids ...*adapters.TypedBid) *mockAdapter {
+		return &mockAdapter{requests: []*adapters.RequestData{{Method: "MOCK", Body: []byte(`{}`)}}, bids: bids}
+	}
+
+	req := func() *openrtb.BidRequest {
+		return &openrtb.BidRequest{
+			ID:   "deal-req",
+			Site: testSite(),
+			Imp: []openrtb.Imp{{
+				ID:       "imp1",
+				Banner:   &openrtb.Banner{W: 300, H: 250},
+				BidFloor: 2.00,
+				PMP: &openrtb.PMP{Deals: []openrtb.Deal{
+					{ID: "cheap-deal", BidFloor: 1.00},
+					{ID: "premium-deal", BidFloor: 10.00},
+				}},
+			}},
+		}
+	}
+
+	for _, preferDeals := range []bool{false, true} {
+		registry := adapters.NewRegistry()
+		// Below the imp floor but above its deal floor
+		registry.Register("dealer", adapter(newBid("deal-bid", 1.50, "cheap-deal")), adapters.BidderInfo{Enabled: true, DemandType: adapters.DemandTypePublisher})
+		// Above the imp floor but below its deal floor
+		registry.Register("premium", adapter(newBid("premium-bid", 8.00, "premium-deal")), adapters.BidderInfo{Enabled: true, DemandType: adapters.DemandTypePublisher})
+		registry.Register("open", adapter(newBid("open-bid", 3.00, "")), adapters.BidderInfo{Enabled: true, DemandType: adapters.DemandTypePublisher})
+		ex := New(registry, &Config{DefaultTimeout: time.Second, DefaultCurrency: "USD", AuctionType: FirstPriceAuction, PreferDeals: preferDeals})
+
+		resp, err := ex.RunAuction(context.Background(), &AuctionRequest{BidRequest: req()})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		seats := make(map[string]openrtb.Bid)
+		for _, sb := range resp.BidResponse.SeatBid {
+			for _, bid := range sb.Bid {
+				seats[sb.Seat] = bid
+			}
+		}
+		if _, ok := seats["premium"]; ok {
+			t.Errorf("prefer=%v: expected the bid below its deal floor rejected", preferDeals)
+		}
+		if _, ok := seats["dealer"]; !ok {
+			t.Fatalf("prefer=%v: expected the deal bid held to its deal floor, not the imp floor, got %+v", preferDeals, seats)
+		}
+
+		winner := "open"
+		if preferDeals {
+			winner = "dealer"
+		}
+		var ext openrtb.BidExt
+		if err := json.Unmarshal(seats[winner].Ext, &ext); err != nil {
+			t.Fatalf("invalid bid ext: %v", err)
+		}
+		if ext.Prebid.Targeting["hb_bidder"] != winner {
+			t.Errorf("prefer=%v: expected %s to win, got %v", preferDeals, winner, ext.Prebid.Targeting)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package exchange
+
+import (
+	"sort"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+// Auction-level reasons an auction returned no bids
+const (
+	NoFillNoBidders    = "no_bidders_available" // No enabled bidders
+	NoFillAllExcluded  = "all_bidders_excluded" // Every eligible bidder was left out before the call
+	NoFillTimeout      = "auction_timeout"      // The auction ran out of time before bids were collected
+	NoFillAllTimedOut  = "all_bidders_timed_out"
+	NoFillBidsRejected = "bids_rejected" // Bids came back but none passed validation
+	NoFillNoWinner     = "no_winner"     // Valid bids, none cleared the auction
+	NoFillNoBids       = "no_bids"       // Every bidder called returned no bids
+)
+
+// Stages at which an eligible bidder is left out of an auction
+const (
+	ExclusionIDR         = "idr"
+	ExclusionRollout     = "rollout"
+	ExclusionCapability  = "capability"
+	ExclusionPrivacy     = "privacy"
+	ExclusionRateLimit   = "rate_limit"
+	ExclusionCircuitOpen = "circuit_open"
+	ExclusionNoParams    = "no_params"
+)
+
+// Per-bidder outcomes in no-bid diagnostics
+const (
+	OutcomeNoBid     = "no_bid"
+	OutcomeTimeout   = "timeout"
+	OutcomeCancelled = "cancelled"
+	OutcomeError     = "error"
+	OutcomeRejected  = "rejected"
+	OutcomeNotWon    = "not_won"
+)
+
+// noBidDiagnostics collects why an auction may come back empty, for
+// publishers investigating fill. A nil *noBidDiagnostics records nothing, so
+// callers don't need to branch on diagnostics mode.
+type noBidDiagnostics struct {
+	eligible   []string
+	excluded   []openrtb.ExtExcludedBidder
+	rejections map[string][]string
+}
+
+func newNoBidDiagnostics() *noBidDiagnostics {
+	return &noBidDiagnostics{rejections: make(map[string][]string)}
+}
+
+// setEligible records the bidders the auction could call
+func (d *noBidDiagnostics) setEligible(bidders []string) {
+	if d == nil {
+		return
+	}
+	d.eligible = append([]string(nil), bidders...)
+	sort.Strings(d.eligible)
+}
+
+// exclude records an eligible bidder left out before the call
+func (d *noBidDiagnostics) exclude(bidder, stage, reason string) {
+	if d == nil {
+		return
+	}
+	d.excluded = append(d.excluded, openrtb.ExtExcludedBidder{Bidder: bidder, Stage: stage, Reason: reason})
+}
+
+// reject records a bid dropped by validation
+func (d *noBidDiagnostics) reject(bidder string, err *BidValidationError) {
+	if d == nil {
+		return
+	}
+	reason := err.Reason
+	if err.ImpID != "" {
+		reason = err.ImpID + ": " + reason
+	}
+	d.rejections[bidder] = append(d.rejections[bidder], reason)
+}
+
+// build explains the auction. selected are the bidders the auction tried to
+// call and results what came back; an eligible bidder neither selected nor
+// otherwise excluded was left out by IDR, and a selected bidder without a
+// result was skipped for supporting none of the request's imps. reason
+// overrides the derived auction-level reason when the auction ended early.
+func (d *noBidDiagnostics) build(reason string, selected []string, results map[string]*BidderResult) *openrtb.ExtNoBidDiagnostics {
+	if d == nil {
+		return nil
+	}
+	diag := &openrtb.ExtNoBidDiagnostics{
+		Eligible: d.eligible,
+		Excluded: append([]openrtb.ExtExcludedBidder(nil), d.excluded...),
+	}
+	if diag.Eligible == nil {
+		diag.Eligible = []string{}
+	}
+
+	listed := make(map[string]bool, len(diag.Excluded)+len(selected))
+	for _, ex := range diag.Excluded {
+		listed[ex.Bidder] = true
+	}
+	for _, code := range selected {
+		listed[code] = true
+	}
+	for _, code := range diag.Eligible {
+		if !listed[code] {
+			diag.Excluded = append(diag.Excluded, openrtb.ExtExcludedBidder{
+				Bidder: code,
+				Stage:  ExclusionIDR,
+				Reason: "not selected",
+			})
+		}
+	}
+	for _, code := range selected {
+		if _, ok := results[code]; !ok {
+			diag.Excluded = append(diag.Excluded, openrtb.ExtExcludedBidder{
+				Bidder: code,
+				Stage:  ExclusionCapability,
+				Reason: "supports none of the request's imp formats",
+			})
+		}
+	}
+	sort.SliceStable(diag.Excluded, func(i, j int) bool { return diag.Excluded[i].Bidder < diag.Excluded[j].Bidder })
+
+	counts := make(map[string]int)
+	if len(results) > 0 {
+		diag.Bidders = make(map[string]openrtb.ExtBidderDiagnostic, len(results))
+		for code, result := range results {
+			outcome := d.outcome(code, result)
+			diag.Bidders[code] = outcome
+			counts[outcome.Outcome]++
+		}
+	}
+
+	diag.Reason = reason
+	if diag.Reason == "" {
+		switch {
+		case len(diag.Eligible) == 0:
+			diag.Reason = NoFillNoBidders
+		case len(results) == 0:
+			diag.Reason = NoFillAllExcluded
+		case counts[OutcomeRejected] > 0:
+			diag.Reason = NoFillBidsRejected
+		case counts[OutcomeNotWon] > 0:
+			diag.Reason = NoFillNoWinner
+		case counts[OutcomeTimeout] == len(results):
+			diag.Reason = NoFillAllTimedOut
+		default:
+			diag.Reason = NoFillNoBids
+		}
+	}
+	return diag
+}
+
+// outcome summarizes one bidder's call
+func (d *noBidDiagnostics) outcome(code string, result *BidderResult) openrtb.ExtBidderDiagnostic {
+	switch {
+	case result.Cancelled:
+		return openrtb.ExtBidderDiagnostic{Outcome: OutcomeCancelled}
+	case len(d.rejections[code]) > 0:
+		return openrtb.ExtBidderDiagnostic{Outcome: OutcomeRejected, Reasons: d.rejections[code]}
+	case len(result.Bids) > 0:
+		return openrtb.ExtBidderDiagnostic{Outcome: OutcomeNotWon}
+	case result.TimedOut:
+		return openrtb.ExtBidderDiagnostic{Outcome: OutcomeTimeout}
+	case len(result.Errors) > 0:
+		var categories []string
+		for _, c := range []struct {
+			name string
+			errs []error
+		}{
+			{BidderErrorInput, result.InputErrors},
+			{BidderErrorTransport, result.TransportErrors},
+			{BidderErrorResponse, result.ResponseErrors},
+		} {
+			if len(c.errs) > 0 {
+				categories = append(categories, c.name)
+			}
+		}
+		return openrtb.ExtBidderDiagnostic{Outcome: OutcomeError, Reasons: categories}
+	default:
+		return openrtb.ExtBidderDiagnostic{Outcome: OutcomeNoBid}
+	}
+}
@@ -0,0 +1,140 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+// diagnosticsAuction runs a one-imp banner auction with a 1.00 floor against
+// a no-bid bidder, a bidder under the floor, a failing bidder and a video-only
+// bidder
+func diagnosticsAuction(t *testing.T, diagnostics bool, lowPrice float64) *AuctionResponse {
+	t.Helper()
+	mockReq := []*adapters.RequestData{{Method: "MOCK", Body: []byte(`{}`)}}
+
+	registry := adapters.NewRegistry()
+	registry.Register("nobid", &mockAdapter{requests: mockReq}, adapters.BidderInfo{Enabled: true})
+	registry.Register("low", &mockAdapter{
+		requests: mockReq,
+		bids: []*adapters.TypedBid{{
+			Bid:     &openrtb.Bid{ID: "low-bid", ImpID: "imp1", Price: lowPrice, AdM: "<div/>"},
+			BidType: adapters.BidTypeBanner,
+		}},
+	}, adapters.BidderInfo{Enabled: true})
+	registry.Register("broken", &mockAdapter{makeErr: errors.New("missing placement id")}, adapters.BidderInfo{Enabled: true})
+	registry.Register("videoonly", &mockAdapter{requests: mockReq}, adapters.BidderInfo{
+		Enabled:      true,
+		Capabilities: &adapters.CapabilitiesInfo{Site: &adapters.PlatformInfo{MediaTypes: []adapters.BidType{adapters.BidTypeVideo}}},
+	})
+
+	ex := New(registry, &Config{DefaultTimeout: time.Second, DefaultCurrency: "USD", RequestShaping: true})
+	req := &openrtb.BidRequest{
+		ID:   "diag-req",
+		Site: testSite(),
+		Imp:  []openrtb.Imp{{ID: "imp1", Banner: &openrtb.Banner{W: 300, H: 250}, BidFloor: 1}},
+	}
+	resp, err := ex.RunAuction(context.Background(), &AuctionRequest{BidRequest: req, Diagnostics: diagnostics})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return resp
+}
+
+func TestNoBidDiagnostics_EmptyAuction(t *testing.T) {
+	diag := diagnosticsAuction(t, true, 0.5).Diagnostics
+	if diag == nil {
+		t.Fatal("expected diagnostics for an empty auction")
+	}
+
+	if diag.Reason != NoFillBidsRejected {
+		t.Errorf("expected reason %s, got %s", NoFillBidsRejected, diag.Reason)
+	}
+	if want := []string{"broken", "low", "nobid", "videoonly"}; !reflect.DeepEqual(diag.Eligible, want) {
+		t.Errorf("expected eligible %v, got %v", want, diag.Eligible)
+	}
+	if len(diag.Excluded) != 1 || diag.Excluded[0].Bidder != "videoonly" || diag.Excluded[0].Stage != ExclusionCapability {
+		t.Errorf("expected videoonly excluded for capability, got %+v", diag.Excluded)
+	}
+
+	if got := diag.Bidders["nobid"]; got.Outcome != OutcomeNoBid {
+		t.Errorf("expected nobid outcome no_bid, got %+v", got)
+	}
+	if got := diag.Bidders["low"]; got.Outcome != OutcomeRejected || len(got.Reasons) != 1 || !strings.Contains(got.Reasons[0], "below floor") {
+		t.Errorf("expected low rejected below floor, got %+v", got)
+	}
+	if got := diag.Bidders["broken"]; got.Outcome != OutcomeError || !reflect.DeepEqual(got.Reasons, []string{BidderErrorInput}) {
+		t.Errorf("expected broken to fail with an input error, got %+v", got)
+	}
+	for _, reason := range diag.Bidders["broken"].Reasons {
+		if strings.Contains(reason, "placement") {
+			t.Error("expected error categories, not bidder messages")
+		}
+	}
+}
+
+func TestNoBidDiagnostics_OptIn(t *testing.T) {
+	if diag := diagnosticsAuction(t, false, 0.5).Diagnostics; diag != nil {
+		t.Errorf("expected no diagnostics without opting in, got %+v", diag)
+	}
+}
+
+func TestNoBidDiagnostics_FilledAuction(t *testing.T) {
+	resp := diagnosticsAuction(t, true, 2)
+	if len(resp.BidResponse.SeatBid) == 0 {
+		t.Fatal("expected the bid above the floor to win")
+	}
+	if resp.Diagnostics != nil {
+		t.Errorf("expected no diagnostics when the auction filled, got %+v", resp.Diagnostics)
+	}
+}
+
+func TestNoBidDiagnostics_NoBidders(t *testing.T) {
+	ex := New(adapters.NewRegistry(), &Config{DefaultTimeout: time.Second, DefaultCurrency: "USD"})
+	req := &openrtb.BidRequest{ID: "r", Site: testSite(), Imp: []openrtb.Imp{{ID: "imp1", Banner: &openrtb.Banner{W: 300, H: 250}}}}
+	resp, err := ex.RunAuction(context.Background(), &AuctionRequest{BidRequest: req, Diagnostics: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Diagnostics == nil || resp.Diagnostics.Reason != NoFillNoBidders || resp.Diagnostics.Eligible == nil {
+		t.Errorf("expected no_bidders_available with an empty eligible list, got %+v", resp.Diagnostics)
+	}
+}
+
+func TestNoBidDiagnostics_Build(t *testing.T) {
+	d := newNoBidDiagnostics()
+	d.setEligible([]string{"c", "a", "b", "d"})
+	d.exclude("b", ExclusionIDR, "LOW_SCORE")
+	d.exclude("d", ExclusionRollout, "held back")
+
+	diag := d.build("", []string{"a"}, map[string]*BidderResult{"a": {BidderCode: "a", TimedOut: true}})
+
+	want := []openrtb.ExtExcludedBidder{
+		{Bidder: "b", Stage: ExclusionIDR, Reason: "LOW_SCORE"},
+		{Bidder: "c", Stage: ExclusionIDR, Reason: "not selected"},
+		{Bidder: "d", Stage: ExclusionRollout, Reason: "held back"},
+	}
+	if !reflect.DeepEqual(diag.Excluded, want) {
+		t.Errorf("expected exclusions %+v, got %+v", want, diag.Excluded)
+	}
+	if diag.Reason != NoFillAllTimedOut || diag.Bidders["a"].Outcome != OutcomeTimeout {
+		t.Errorf("expected every bidder timed out, got %s / %+v", diag.Reason, diag.Bidders)
+	}
+
+	if got := d.build("", nil, nil).Reason; got != NoFillAllExcluded {
+		t.Errorf("expected all_bidders_excluded, got %s", got)
+	}
+
+	var none *noBidDiagnostics
+	none.exclude("a", ExclusionIDR, "")
+	none.reject("a", &BidValidationError{Reason: "x"})
+	if none.build("", nil, nil) != nil {
+		t.Error("expected a nil collector to build nothing")
+	}
+}
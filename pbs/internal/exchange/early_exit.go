@@ -0,0 +1,275 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+// Default early exit and partial response thresholds
+const (
+	defaultEarlyExitMinBids    = 1
+	defaultEarlyExitMinElapsed = 0.5
+	defaultPartialMinResponded = 0.9
+	defaultPartialSoftDeadline = 0.8
+)
+
+// errBidderLate is the error of a bidder a partial response left out
+var errBidderLate = errors.New("auction closed with a partial response before the bidder responded")
+
+// EarlyExitConfig closes an auction before its timeout once every imp is
+// covered, cancelling the bidders still in flight. This trades the price
+// discovery of slow bidders for lower tail latency.
+type EarlyExitConfig struct {
+	Enabled       bool
+	MinBidsPerImp int     // Valid bids every imp needs before the auction may close
+	MinElapsed    float64 // Fraction of the auction timeout that must pass first (0-1)
+}
+
+// DefaultEarlyExitConfig returns disabled early exit with default thresholds
+func DefaultEarlyExitConfig() *EarlyExitConfig {
+	return &EarlyExitConfig{
+		MinBidsPerImp: defaultEarlyExitMinBids,
+		MinElapsed:    defaultEarlyExitMinElapsed,
+	}
+}
+
+// PartialResponseConfig closes an auction before its timeout once enough of
+// the bidders called have responded, or at a soft deadline, with the bids
+// gathered so far. Bidders still in flight are cancelled and reported as
+// timed out, so one slow bidder doesn't hold every auction to its full tmax.
+type PartialResponseConfig struct {
+	Enabled      bool
+	MinResponded float64 // Fraction of the bidders called that must have responded (0-1, 0 waits for the soft deadline)
+	SoftDeadline float64 // Fraction of the auction timeout after which it closes with what it has (0-1, 0 has none)
+}
+
+// DefaultPartialResponseConfig returns disabled partial responses with default thresholds
+func DefaultPartialResponseConfig() *PartialResponseConfig {
+	return &PartialResponseConfig{
+		MinResponded: defaultPartialMinResponded,
+		SoftDeadline: defaultPartialSoftDeadline,
+	}
+}
+
+// earlyExit counts valid bids per imp and responded bidders as bidder
+// results arrive and cancels the remaining bidder calls once the auction can
+// close, either early or with a partial response. A nil *earlyExit (both
+// disabled) ignores every call.
+type earlyExit struct {
+	cancel  context.CancelFunc
+	isValid func(bid *openrtb.Bid, bidderCode string) bool
+	timers  []*time.Timer
+
+	mu      sync.Mutex
+	cover   bool           // Early exit is on
+	needed  map[string]int // Imp ID -> valid bids still needed
+	short   int            // Imps still needing bids
+	elapsed bool           // MinElapsed has passed
+
+	minResponded float64 // 0 without partial responses by count
+	called       int     // Bidders called so far
+	responded    int
+	allCalled    bool
+
+	fired   bool
+	partial bool // Closed with a partial response
+}
+
+// startEarlyExit returns the context bidder calls should use and the tracker
+// that may cancel it. Call stop once every bidder has returned. Bids only
+// count once they clear the floors dynFloors enforces for their bidder.
+func (e *Exchange) startEarlyExit(ctx context.Context, req *openrtb.BidRequest, timeout time.Duration, dynFloors *auctionFloors) (context.Context, *earlyExit) {
+	config := e.config.EarlyExit
+	partial := e.config.PartialResponse
+	cover := config != nil && config.Enabled
+	cutoff := partial != nil && partial.Enabled
+	if (!cover && !cutoff) || len(req.Imp) == 0 {
+		return ctx, nil
+	}
+
+	bidderCtx, cancel := context.WithCancel(ctx)
+	x := &earlyExit{cancel: cancel, cover: cover}
+
+	if cover {
+		impFloors := buildImpFloorMap(req, e.config.DefaultCurrency, e.currencyConverter())
+		x.isValid = func(bid *openrtb.Bid, bidderCode string) bool {
+			return bid != nil && bid.Price > 0 && e.validateBid(bid, bidderCode, dynFloors.enforced(bidderCode, impFloors)) == nil
+		}
+		x.needed = make(map[string]int, len(req.Imp))
+		for _, imp := range req.Imp {
+			if _, dup := x.needed[imp.ID]; !dup {
+				x.needed[imp.ID] = config.MinBidsPerImp
+				x.short++
+			}
+		}
+		x.afterFraction(config.MinElapsed, timeout, func() { x.elapsed = true })
+	}
+
+	if cutoff {
+		x.minResponded = partial.MinResponded
+		if partial.SoftDeadline > 0 {
+			x.afterFraction(partial.SoftDeadline, timeout, func() { x.fire(true) })
+		}
+	}
+	return bidderCtx, x
+}
+
+// afterFraction runs fn under x.mu once fraction of timeout has passed,
+// then checks whether the auction can close
+func (x *earlyExit) afterFraction(fraction float64, timeout time.Duration, fn func()) {
+	wait := time.Duration(fraction * float64(timeout))
+	if wait <= 0 {
+		fn()
+		return
+	}
+	x.timers = append(x.timers, time.AfterFunc(wait, func() {
+		x.mu.Lock()
+		defer x.mu.Unlock()
+		fn()
+		x.maybeFire()
+	}))
+}
+
+// calling counts a bidder about to be called
+func (x *earlyExit) calling() {
+	if x == nil {
+		return
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.called++
+}
+
+// finished counts a called bidder's call ending; one that didn't respond,
+// having been skipped, no longer counts as called
+func (x *earlyExit) finished(responded bool) {
+	if x == nil {
+		return
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if responded {
+		x.responded++
+	} else {
+		x.called--
+	}
+	x.maybeFire()
+}
+
+// callsStarted marks every bidder as called, so the share that responded
+// can close the auction
+func (x *earlyExit) callsStarted() {
+	if x == nil {
+		return
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.allCalled = true
+	x.maybeFire()
+}
+
+// observe counts a finished bidder's valid bids, closing the auction if that
+// covers every imp. Results cut short by an earlier close are marked Cancelled
+// instead, so they aren't mistaken for bidder timeouts, or late when the close
+// was a partial response. A call that finished before the close but is
+// observed after it keeps its bids.
+func (x *earlyExit) observe(result *BidderResult) {
+	if x == nil || result == nil {
+		return
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if x.fired {
+		result.clearCancellation()
+		if x.partial && result.Cancelled {
+			result.markLate()
+		}
+		return
+	}
+	if !x.cover {
+		return
+	}
+	for _, tb := range result.Bids {
+		if tb == nil || !x.isValid(tb.Bid, result.BidderCode) {
+			continue
+		}
+		if n, ok := x.needed[tb.Bid.ImpID]; ok && n > 0 {
+			x.needed[tb.Bid.ImpID] = n - 1
+			if n == 1 {
+				x.short--
+			}
+		}
+	}
+	x.maybeFire()
+}
+
+// maybeFire cancels the bidder calls once every imp is covered and
+// MinElapsed has passed, or once MinResponded of the bidders called have
+// responded. x.mu must be held.
+func (x *earlyExit) maybeFire() {
+	switch {
+	case x.cover && x.elapsed && x.short == 0:
+		x.fire(false)
+	case x.minResponded > 0 && x.allCalled && x.called > 0 && float64(x.responded) >= x.minResponded*float64(x.called):
+		x.fire(true)
+	}
+}
+
+// fire cancels the bidder calls still in flight. x.mu must be held.
+func (x *earlyExit) fire(partial bool) {
+	if x.fired {
+		return
+	}
+	x.fired = true
+	x.partial = partial
+	x.cancel()
+}
+
+// stop releases the tracker's timers and context
+func (x *earlyExit) stop() {
+	if x == nil {
+		return
+	}
+	for _, timer := range x.timers {
+		timer.Stop()
+	}
+	x.cancel()
+}
+
+// clearCancellation drops the context.Canceled errors an early close caused
+// and marks the result Cancelled rather than TimedOut
+func (r *BidderResult) clearCancellation() {
+	keep := func(errs []error) []error {
+		var kept []error
+		for _, err := range errs {
+			if errors.Is(err, context.Canceled) {
+				r.Cancelled = true
+				continue
+			}
+			kept = append(kept, err)
+		}
+		return kept
+	}
+	r.Errors = keep(r.Errors)
+	r.InputErrors = keep(r.InputErrors)
+	r.TransportErrors = keep(r.TransportErrors)
+	r.ResponseErrors = keep(r.ResponseErrors)
+	if r.Cancelled {
+		r.TimedOut = false
+	}
+}
+
+// markLate reports a result a partial response cut short as timed out,
+// leaving out any bids it gathered before the cancellation
+func (r *BidderResult) markLate() {
+	r.Cancelled = false
+	r.Late = true
+	r.TimedOut = true
+	r.Bids = nil
+	r.addErrors(BidderErrorTransport, errBidderLate)
+}
@@ -0,0 +1,213 @@
+package exchange
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+// earlyExitAuction runs a two-imp auction with a 2s timeout between a bidder
+// bidding at once on fastImps and a bidder that no-bids after slowDelay
+func earlyExitAuction(t *testing.T, config *EarlyExitConfig, slowDelay time.Duration, fastImps ...string) (*AuctionResponse, time.Duration) {
+	t.Helper()
+	return closingAuction(t, &Config{EarlyExit: config}, slowDelay, fastImps...)
+}
+
+// closingAuction runs earlyExitAuction's auction with config's early exit
+// and partial response settings
+func closingAuction(t *testing.T, config *Config, slowDelay time.Duration, fastImps ...string) (*AuctionResponse, time.Duration) {
+	t.Helper()
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(slowDelay):
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	t.Cleanup(slow.Close)
+
+	fast := &mockAdapter{requests: []*adapters.RequestData{{Method: "MOCK", Body: []byte(`{}`)}}}
+	for _, imp := range fastImps {
+		fast.bids = append(fast.bids, &adapters.TypedBid{
+			Bid:     &openrtb.Bid{ID: "bid-" + imp, ImpID: imp, Price: 1.5, AdM: `<VAST version="3.0"/>`},
+			BidType: adapters.BidTypeVideo,
+		})
+	}
+
+	registry := adapters.NewRegistry()
+	registry.Register("fast", fast, adapters.BidderInfo{Enabled: true})
+	registry.Register("slow", &mockAdapter{requests: []*adapters.RequestData{{Method: "POST", URI: slow.URL, Body: []byte(`{}`)}}}, adapters.BidderInfo{Enabled: true})
+
+	config.DefaultTimeout = 2 * time.Second
+	config.DefaultCurrency = "USD"
+	ex := New(registry, config)
+	req := podRequest(2)
+	req.Site = testSite()
+
+	start := time.Now()
+	resp, err := ex.RunAuction(context.Background(), &AuctionRequest{BidRequest: req})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return resp, time.Since(start)
+}
+
+func TestEarlyExit_ClosesOnceEveryImpHasBids(t *testing.T) {
+	resp, elapsed := earlyExitAuction(t, &EarlyExitConfig{Enabled: true, MinBidsPerImp: 1, MinElapsed: 0.1}, time.Second, "imp1", "imp2")
+
+	if elapsed > 700*time.Millisecond {
+		t.Errorf("expected the auction to close early, took %v", elapsed)
+	}
+	if elapsed < 200*time.Millisecond {
+		t.Errorf("expected the auction to wait for MinElapsed, took %v", elapsed)
+	}
+	slow := resp.BidderResults["slow"]
+	if slow == nil || !slow.Cancelled || slow.TimedOut || len(slow.Errors) != 0 {
+		t.Errorf("expected slow bidder cancelled without errors, got %+v", slow)
+	}
+	if resp.BidderResults["fast"].Cancelled {
+		t.Error("expected the finished bidder not to be marked cancelled")
+	}
+	if len(resp.BidResponse.SeatBid) != 1 || len(resp.BidResponse.SeatBid[0].Bid) != 2 {
+		t.Errorf("expected both fast bids in the response, got %+v", resp.BidResponse.SeatBid)
+	}
+}
+
+func TestEarlyExit_WaitsForUncoveredImps(t *testing.T) {
+	resp, elapsed := earlyExitAuction(t, &EarlyExitConfig{Enabled: true, MinBidsPerImp: 1}, 300*time.Millisecond, "imp1")
+
+	if elapsed < 250*time.Millisecond {
+		t.Errorf("expected the auction to wait for the slow bidder with imp2 uncovered, took %v", elapsed)
+	}
+	if slow := resp.BidderResults["slow"]; slow == nil || slow.Cancelled || len(slow.Errors) != 0 {
+		t.Errorf("expected slow bidder to finish, got %+v", slow)
+	}
+}
+
+func TestEarlyExit_MinBidsPerImp(t *testing.T) {
+	// One bidder can't reach two bids per imp alone
+	resp, elapsed := earlyExitAuction(t, &EarlyExitConfig{Enabled: true, MinBidsPerImp: 2}, 300*time.Millisecond, "imp1", "imp2")
+
+	if elapsed < 250*time.Millisecond || resp.BidderResults["slow"].Cancelled {
+		t.Errorf("expected the auction to wait for the slow bidder, took %v", elapsed)
+	}
+}
+
+func TestEarlyExit_DisabledByDefault(t *testing.T) {
+	ex := New(adapters.NewRegistry(), nil)
+	ctx := context.Background()
+	bidderCtx, exit := ex.startEarlyExit(ctx, podRequest(1), time.Second, nil)
+	if exit != nil || bidderCtx != ctx {
+		t.Error("expected no early exit tracker when disabled")
+	}
+	exit.observe(&BidderResult{}) // nil tracker is a no-op
+	exit.stop()
+}
+
+func TestEarlyExit_IgnoresInvalidBids(t *testing.T) {
+	ex := New(adapters.NewRegistry(), &Config{EarlyExit: &EarlyExitConfig{Enabled: true}})
+	req := podRequest(1)
+	req.Imp[0].BidFloor = 2.0
+	_, exit := ex.startEarlyExit(context.Background(), req, time.Second, nil)
+	defer exit.stop()
+
+	exit.observe(&BidderResult{BidderCode: "b", Bids: []*adapters.TypedBid{
+		{Bid: &openrtb.Bid{ID: "below-floor", ImpID: "imp1", Price: 1.0}},
+		{Bid: &openrtb.Bid{ID: "unknown-imp", ImpID: "imp9", Price: 3.0}},
+		{Bid: &openrtb.Bid{ID: "zero", ImpID: "imp1", Price: 0}},
+	}})
+	if exit.short != 1 || exit.fired {
+		t.Errorf("expected invalid bids not to cover the imp, got short=%d fired=%v", exit.short, exit.fired)
+	}
+}
+
+func TestPartialResponse_ClosesOnceEnoughBiddersRespond(t *testing.T) {
+	resp, elapsed := closingAuction(t, &Config{PartialResponse: &PartialResponseConfig{Enabled: true, MinResponded: 0.5}}, time.Second, "imp1")
+
+	if elapsed > 700*time.Millisecond {
+		t.Errorf("expected the auction to close once half the bidders responded, took %v", elapsed)
+	}
+	slow := resp.BidderResults["slow"]
+	if slow == nil || !slow.TimedOut || !slow.Late || slow.Cancelled {
+		t.Errorf("expected the slow bidder reported late and timed out, got %+v", slow)
+	}
+	if fast := resp.BidderResults["fast"]; fast.Late || fast.TimedOut {
+		t.Error("expected the bidder that responded not to be marked late")
+	}
+	if len(resp.BidResponse.SeatBid) != 1 || len(resp.BidResponse.SeatBid[0].Bid) != 1 {
+		t.Errorf("expected the fast bid in the response, got %+v", resp.BidResponse.SeatBid)
+	}
+}
+
+func TestPartialResponse_SoftDeadline(t *testing.T) {
+	resp, elapsed := closingAuction(t, &Config{PartialResponse: &PartialResponseConfig{Enabled: true, MinResponded: 1, SoftDeadline: 0.2}}, time.Second, "imp1")
+
+	if elapsed < 350*time.Millisecond || elapsed > 900*time.Millisecond {
+		t.Errorf("expected the auction to close at the 400ms soft deadline, took %v", elapsed)
+	}
+	if slow := resp.BidderResults["slow"]; slow == nil || !slow.Late || !slow.TimedOut {
+		t.Errorf("expected the slow bidder reported late, got %+v", slow)
+	}
+}
+
+func TestPartialResponse_WaitsForMinResponded(t *testing.T) {
+	resp, elapsed := closingAuction(t, &Config{PartialResponse: &PartialResponseConfig{Enabled: true, MinResponded: 1, SoftDeadline: 0.9}}, 300*time.Millisecond, "imp1")
+
+	if elapsed < 250*time.Millisecond {
+		t.Errorf("expected the auction to wait for every bidder, took %v", elapsed)
+	}
+	if slow := resp.BidderResults["slow"]; slow == nil || slow.Late || slow.TimedOut || len(slow.Errors) != 0 {
+		t.Errorf("expected the slow bidder to finish, got %+v", slow)
+	}
+}
+
+func TestPartialResponse_SkippedBiddersDontCount(t *testing.T) {
+	ex := New(adapters.NewRegistry(), &Config{PartialResponse: &PartialResponseConfig{Enabled: true, MinResponded: 1}})
+	_, exit := ex.startEarlyExit(context.Background(), podRequest(1), time.Second, nil)
+	defer exit.stop()
+
+	exit.calling()
+	exit.calling()
+	exit.callsStarted()
+	exit.finished(false) // Skipped without being called
+	if exit.fired {
+		t.Fatal("expected no close before the called bidder responds")
+	}
+	exit.finished(true)
+	if !exit.fired || !exit.partial {
+		t.Error("expected a partial close once every bidder actually called responded")
+	}
+
+	late := &BidderResult{BidderCode: "late", Bids: []*adapters.TypedBid{{Bid: &openrtb.Bid{ID: "b", ImpID: "imp1", Price: 1}}}}
+	late.addErrors(BidderErrorTransport, context.Canceled)
+	exit.observe(late)
+	if !late.Late || !late.TimedOut || late.Cancelled || len(late.Bids) != 0 || len(late.Errors) != 1 || late.Errors[0] != errBidderLate {
+		t.Errorf("expected a late result without bids or cancellation errors, got %+v", late)
+	}
+}
+
+func TestPartialResponse_KeepsCallsFinishedBeforeClose(t *testing.T) {
+	ex := New(adapters.NewRegistry(), &Config{PartialResponse: &PartialResponseConfig{Enabled: true, SoftDeadline: 0.01}})
+	_, exit := ex.startEarlyExit(context.Background(), podRequest(1), time.Second, nil)
+	defer exit.stop()
+
+	// The bidder's call finished just before the soft deadline but its result
+	// is only observed after the auction closed
+	done := &BidderResult{BidderCode: "done", Bids: []*adapters.TypedBid{{Bid: &openrtb.Bid{ID: "b", ImpID: "imp1", Price: 1}}}}
+	time.Sleep(30 * time.Millisecond)
+	exit.mu.Lock()
+	fired := exit.fired && exit.partial
+	exit.mu.Unlock()
+	if !fired {
+		t.Fatal("expected the soft deadline to close the auction")
+	}
+	exit.observe(done)
+	if done.Late || done.TimedOut || done.Cancelled || len(done.Bids) != 1 || len(done.Errors) != 0 {
+		t.Errorf("expected the finished call to keep its bids, got %+v", done)
+	}
+}
@@ -0,0 +1,22 @@
+package exchange
+
+import (
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+// filterBidderEIDs removes the user.eids a bidder may not receive, per its
+// BidderInfo.EIDSources, from its request copy. It runs after the global
+// FPD EID filter, so a bidder only gets sources both allow.
+func filterBidderEIDs(req *openrtb.BidRequest, info adapters.BidderInfo) {
+	if len(info.EIDSources) == 0 || req.User == nil || len(req.User.EIDs) == 0 {
+		return
+	}
+	eids := make([]openrtb.EID, 0, len(req.User.EIDs))
+	for _, eid := range req.User.EIDs {
+		if info.AllowsEIDSource(eid.Source) {
+			eids = append(eids, eid)
+		}
+	}
+	req.User.EIDs = eids
+}
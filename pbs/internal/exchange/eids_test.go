@@ -0,0 +1,65 @@
+package exchange
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+func eidSources(user *openrtb.User) []string {
+	var sources []string
+	for _, eid := range user.EIDs {
+		sources = append(sources, eid.Source)
+	}
+	return sources
+}
+
+func TestFilterBidderEIDs(t *testing.T) {
+	req := &openrtb.BidRequest{User: &openrtb.User{EIDs: []openrtb.EID{{Source: "liveramp.com"}, {Source: "UIDAPI.com"}, {Source: "id5-sync.com"}}}}
+	filterBidderEIDs(req, adapters.BidderInfo{EIDSources: []string{"uidapi.com", " ID5-sync.com "}})
+	if got := eidSources(req.User); len(got) != 2 || got[0] != "UIDAPI.com" || got[1] != "id5-sync.com" {
+		t.Errorf("expected only the allowed sources, got %v", got)
+	}
+
+	// No list allows every source
+	req = &openrtb.BidRequest{User: &openrtb.User{EIDs: []openrtb.EID{{Source: "liveramp.com"}}}}
+	filterBidderEIDs(req, adapters.BidderInfo{})
+	if len(req.User.EIDs) != 1 {
+		t.Error("expected every source allowed without a list")
+	}
+	filterBidderEIDs(&openrtb.BidRequest{}, adapters.BidderInfo{EIDSources: []string{"uidapi.com"}})
+}
+
+func TestRunAuction_FiltersEIDsPerBidder(t *testing.T) {
+	restricted := &recordingAdapter{}
+	open := &recordingAdapter{}
+	registry := adapters.NewRegistry()
+	registry.Register("restricted", restricted, adapters.BidderInfo{Enabled: true, EIDSources: []string{"uidapi.com"}})
+	registry.Register("open", open, adapters.BidderInfo{Enabled: true})
+	ex := New(registry, &Config{DefaultTimeout: time.Second, DefaultCurrency: "USD"})
+
+	req := &openrtb.BidRequest{
+		ID:   "eid-req",
+		Site: testSite(),
+		Imp:  []openrtb.Imp{{ID: "imp1", Banner: &openrtb.Banner{W: 300, H: 250}}},
+		User: &openrtb.User{ID: "u1", EIDs: []openrtb.EID{
+			{Source: "liveramp.com", UIDs: []openrtb.UID{{ID: "lr-1"}}},
+			{Source: "uidapi.com", UIDs: []openrtb.UID{{ID: "uid2-1"}}},
+			{Source: "unknown.example", UIDs: []openrtb.UID{{ID: "x-1"}}},
+		}},
+	}
+	if _, err := ex.RunAuction(context.Background(), &AuctionRequest{BidRequest: req}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if restricted.req == nil || len(restricted.req.User.EIDs) != 1 || restricted.req.User.EIDs[0].Source != "uidapi.com" {
+		t.Errorf("expected the restricted bidder to get only uidapi.com, got %+v", restricted.req)
+	}
+	// The global filter still applies to bidders without a list
+	if got := eidSources(open.req.User); len(got) != 2 {
+		t.Errorf("expected the open bidder to get the globally allowed sources, got %v", got)
+	}
+}
@@ -0,0 +1,131 @@
+package exchange
+
+import (
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/idr"
+)
+
+// EventPath is where bid event URLs point
+const EventPath = "/event"
+
+// DefaultBidNoticeTTL is how long after an auction its bids can be notified about
+const DefaultBidNoticeTTL = time.Hour
+
+// maxBidNotices bounds the returned bids kept for event notifications
+const maxBidNotices = 100000
+
+// EventsConfig puts win and imp notification URLs on returned bids
+type EventsConfig struct {
+	ExternalURL string        // Public base URL of this server, e.g. https://pbs.example.com
+	TTL         time.Duration // How long bids stay known to /event (DefaultBidNoticeTTL when 0)
+}
+
+// BidNotice is a returned bid as /event knows it: who bid what in which
+// auction, and the bidder's own win and billing notice URLs
+type BidNotice struct {
+	AuctionID string
+	ImpID     string
+	BidID     string
+	Bidder    string // Real bidder code, even when the bid was returned under the platform seat
+	Seat      string // Seat the bid was returned under, as in its event URLs
+	Account   string
+	Price     float64
+	Currency  string
+	NURL      string
+	BURL      string
+	NURLFired bool // The exchange fired NURL when the auction closed
+}
+
+// bidNoticeEntry is a BidNotice with its expiry and the notifications seen so far
+type bidNoticeEntry struct {
+	notice   *BidNotice
+	expires  time.Time
+	notified map[string]bool
+}
+
+// BidNotices remembers recently returned bids by seat and bid ID, so event
+// notifications can be tied back to their auction
+type BidNotices struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*bidNoticeEntry
+}
+
+// NewBidNotices creates a store keeping bids for ttl (DefaultBidNoticeTTL when 0)
+func NewBidNotices(ttl time.Duration) *BidNotices {
+	if ttl <= 0 {
+		ttl = DefaultBidNoticeTTL
+	}
+	return &BidNotices{ttl: ttl, now: time.Now, entries: make(map[string]*bidNoticeEntry)}
+}
+
+func bidNoticeKey(seat, bidID string) string {
+	return seat + "|" + bidID
+}
+
+// Add remembers a returned bid
+func (n *BidNotices) Add(notice *BidNotice) {
+	now := n.now()
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if len(n.entries) >= maxBidNotices {
+		for key, entry := range n.entries {
+			if !now.Before(entry.expires) {
+				delete(n.entries, key)
+			}
+		}
+		if len(n.entries) >= maxBidNotices {
+			n.entries = make(map[string]*bidNoticeEntry)
+		}
+	}
+	n.entries[bidNoticeKey(notice.Seat, notice.BidID)] = &bidNoticeEntry{notice: notice, expires: now.Add(n.ttl)}
+}
+
+// Notify looks up the bid a notification of eventType is about. first is
+// false when the bid was already notified of eventType, so its notice URLs
+// aren't fired twice. notice is nil for unknown or expired bids.
+func (n *BidNotices) Notify(seat, bidID, eventType string) (notice *BidNotice, first bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	entry, ok := n.entries[bidNoticeKey(seat, bidID)]
+	if !ok || !n.now().Before(entry.expires) {
+		return nil, false
+	}
+	if entry.notified[eventType] {
+		return entry.notice, false
+	}
+	if entry.notified == nil {
+		entry.notified = make(map[string]bool, 2)
+	}
+	entry.notified[eventType] = true
+	return entry.notice, true
+}
+
+// bidEventURLs builds a returned bid's win and imp notification URLs
+func bidEventURLs(externalURL, seat, bidID, account string, ts time.Time) *openrtb.ExtBidPrebidEvents {
+	eventURL := func(eventType string) string {
+		q := url.Values{}
+		q.Set("t", eventType)
+		q.Set("b", bidID)
+		if account != "" {
+			q.Set("a", account)
+		}
+		q.Set("bidder", seat)
+		q.Set("ts", strconv.FormatInt(ts.UnixMilli(), 10))
+		return externalURL + EventPath + "?" + q.Encode()
+	}
+	return &openrtb.ExtBidPrebidEvents{Win: eventURL(idr.NotificationWin), Imp: eventURL(idr.NotificationImp)}
+}
+
+// BidNotices returns the bids event URLs were issued for, or nil when
+// events are off
+func (e *Exchange) BidNotices() *BidNotices {
+	return e.bidNotices
+}
@@ -0,0 +1,85 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+func TestBidNotices(t *testing.T) {
+	n := NewBidNotices(time.Minute)
+	now := time.Now()
+	n.now = func() time.Time { return now }
+	n.Add(&BidNotice{AuctionID: "a1", BidID: "b1", Seat: "appnexus", Bidder: "appnexus", Price: 1.5})
+
+	notice, first := n.Notify("appnexus", "b1", "win")
+	if notice == nil || !first || notice.AuctionID != "a1" {
+		t.Fatalf("expected the first win notification matched, got %+v, %v", notice, first)
+	}
+	if notice, first := n.Notify("appnexus", "b1", "win"); notice == nil || first {
+		t.Errorf("expected a repeat win flagged, got %+v, %v", notice, first)
+	}
+	if _, first := n.Notify("appnexus", "b1", "imp"); !first {
+		t.Error("expected the imp notification tracked separately from the win")
+	}
+	if notice, _ := n.Notify("rubicon", "b1", "win"); notice != nil {
+		t.Error("expected bids keyed by seat")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if notice, _ := n.Notify("appnexus", "b1", "imp"); notice != nil {
+		t.Error("expected an expired bid unmatched")
+	}
+}
+
+func TestRunAuction_AddsEventURLs(t *testing.T) {
+	registry := adapters.NewRegistry()
+	registry.Register("appnexus", &mockAdapter{
+		requests: []*adapters.RequestData{{Method: "MOCK", Body: []byte(`{}`)}},
+		bids: []*adapters.TypedBid{{
+			Bid:     &openrtb.Bid{ID: "bid-1", ImpID: "imp1", Price: 2, AdM: "<div/>", BURL: "https://bidder.example/bill?p=${AUCTION_PRICE}"},
+			BidType: adapters.BidTypeBanner,
+		}},
+	}, adapters.BidderInfo{Enabled: true})
+	ex := New(registry, &Config{
+		DefaultTimeout:  time.Second,
+		DefaultCurrency: "USD",
+		Events:          &EventsConfig{ExternalURL: "https://pbs.example.com/"},
+	})
+
+	resp, err := ex.RunAuction(context.Background(), &AuctionRequest{Account: "pub1", BidRequest: &openrtb.BidRequest{
+		ID:   "events-1",
+		Site: testSite(),
+		Imp:  []openrtb.Imp{{ID: "imp1", Banner: &openrtb.Banner{W: 300, H: 250}}},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.BidResponse.SeatBid) != 1 {
+		t.Fatalf("expected one seat, got %d", len(resp.BidResponse.SeatBid))
+	}
+	seat := resp.BidResponse.SeatBid[0]
+	var ext openrtb.BidExt
+	if err := json.Unmarshal(seat.Bid[0].Ext, &ext); err != nil || ext.Prebid.Events == nil {
+		t.Fatalf("expected event URLs on the bid, got %s", seat.Bid[0].Ext)
+	}
+
+	win, err := url.Parse(ext.Prebid.Events.Win)
+	if err != nil || win.Host != "pbs.example.com" || win.Path != EventPath {
+		t.Fatalf("unexpected win URL %q", ext.Prebid.Events.Win)
+	}
+	q := win.Query()
+	if q.Get("t") != "win" || q.Get("b") != "bid-1" || q.Get("a") != "pub1" || q.Get("bidder") != seat.Seat || q.Get("ts") == "" {
+		t.Errorf("unexpected win URL parameters %v", q)
+	}
+
+	notice, first := ex.BidNotices().Notify(seat.Seat, "bid-1", "imp")
+	if notice == nil || !first || notice.Bidder != "appnexus" || notice.AuctionID != "events-1" || notice.BURL == "" {
+		t.Errorf("expected the returned bid remembered, got %+v", notice)
+	}
+}
@@ -8,14 +8,23 @@ import (
 	"math"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters/ortb"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/flags"
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/fpd"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/middleware"
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/currency"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/floors"
 	"github.com/StreetsDigital/thenexusengine/pbs/pkg/idr"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/jsoncodec"
 	"github.com/StreetsDigital/thenexusengine/pbs/pkg/logger"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/native"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/signing"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/tracing"
 )
 
 // Exchange orchestrates the auction process
@@ -28,12 +37,41 @@ type Exchange struct {
 	config           *Config
 	fpdProcessor     *fpd.Processor
 	eidFilter        *fpd.EIDFilter
-
-	// configMu protects dynamicRegistry, fpdProcessor, eidFilter, and config.FPD
+	flags            *flags.Registry // Runtime toggles; nil means all flags at defaults
+	idrCache         *idrSelectionCache // nil when IDRSelectionCacheTTL is 0
+	idrCacheMetrics  IDRCacheMetrics
+	auctionMetrics   AuctionMetrics
+	rolloutMetrics   RolloutMetrics
+	limiter          *bidderLimiter
+	throttleMetrics  ThrottleMetrics
+	breakers         *bidderBreakers // nil when BidderCircuitBreaker is off
+	circuitMetrics   CircuitBreakerMetrics
+	sandbox          *adapterSandbox
+	sandboxMetrics   SandboxMetrics
+	bidderPool       *bidderPool
+	poolMetrics      BidderPoolMetrics
+	adaptiveTimeouts *adaptiveTimeouts
+	errorMetrics     BidderErrorMetrics
+	creativeMetrics  CreativeMetrics
+	sizeMetrics      RequestSizeMetrics
+	overheadMetrics  MiddlewareOverheadMetrics
+	privacyMetrics   PrivacyMetrics
+	accounts         AccountSource // Per-publisher configuration; nil uses the global config for everyone
+	bidNotices       *BidNotices   // Bids issued event URLs; nil when Config.Events is off
+
+	// configMu protects dynamicRegistry, fpdProcessor, eidFilter, flags, idrCacheMetrics,
+	// auctionMetrics, rolloutMetrics, throttleMetrics, circuitMetrics, sandboxMetrics, poolMetrics, errorMetrics,
+	// creativeMetrics, sizeMetrics, overheadMetrics, privacyMetrics, accounts, config.FPD and config.DefaultTimeout
 	// for safe concurrent access during runtime config updates
 	configMu sync.RWMutex
 }
 
+// AuctionMetrics receives per-auction and per-bid outcomes labelled by media type
+type AuctionMetrics interface {
+	RecordAuction(status, mediaType string, duration time.Duration, biddersSelected, biddersExcluded int)
+	RecordBid(bidder, mediaType string, cpm float64)
+}
+
 // AuctionType defines the type of auction to run
 type AuctionType int
 
@@ -58,6 +96,12 @@ const (
 const (
 	minBidderTimeout = 10 * time.Millisecond  // Minimum reasonable timeout
 	maxBidderTimeout = 5 * time.Second        // Maximum to prevent resource exhaustion
+
+	// Default Config.MaxParallelBidderRequests
+	defaultMaxParallelBidderRequests = 4
+
+	// DefaultTMaxNetworkBuffer is the default Config.TMaxNetworkBuffer
+	DefaultTMaxNetworkBuffer = 20 * time.Millisecond
 )
 
 // P2-7: NBR codes consolidated in openrtb/response.go
@@ -87,13 +131,20 @@ func DefaultCloneLimits() *CloneLimits {
 type Config struct {
 	DefaultTimeout       time.Duration
 	MaxBidders           int
-	MaxConcurrentBidders int // P0-4: Limit concurrent bidder goroutines (0 = unlimited)
+	// Deprecated: MaxConcurrentBidders is ignored. Bidder calls run on the
+	// shared BidderPool, which has no per-auction limit.
+	MaxConcurrentBidders int
+	BidderPool           *BidderPoolConfig // Bounds bidder calls across all auctions
 	IDREnabled           bool
 	IDRServiceURL        string
 	IDRAPIKey            string // Internal API key for IDR service-to-service calls
 	EventRecordEnabled   bool
 	EventBufferSize      int
+	// Per-account recording opt-out and sampling (nil records every auction)
+	EventRecordingPolicy *idr.RecordingPolicy
+	IDRSigner            *signing.Signer // Optional HMAC signing of IDR calls and event batches
 	CurrencyConv         bool
+	CurrencyRates        currency.Converter // Rates for floors and bids in other currencies when CurrencyConv is on
 	DefaultCurrency      string
 	FPD                  *fpd.Config
 	CloneLimits          *CloneLimits // P3-1: Configurable clone limits
@@ -104,27 +155,80 @@ type Config struct {
 	AuctionType    AuctionType
 	PriceIncrement float64 // For second-price auctions (typically 0.01)
 	MinBidPrice    float64 // Minimum valid bid price
+	// Outbound identification headers and request ext stamp (nil disables)
+	Identification *adapters.Identification
+	// Connection pool for bidder calls (nil uses adapters.DefaultTransportConfig)
+	Transport *adapters.TransportConfig
+	// IDR selection memoization per publisher+country+device type+media type bucket (0 disables)
+	IDRSelectionCacheTTL time.Duration
+	// Resource limits for sandboxed dynamic bidders
+	Sandbox *SandboxConfig
+	// Taken off the time left for a bidder in the tmax it is sent, for the
+	// network round trip (0 sends the time left as is)
+	TMaxNetworkBuffer time.Duration
+	// Per-bidder timeouts tuned from recent latency
+	AdaptiveTimeouts *AdaptiveTimeoutConfig
+	// Skip bidders whose endpoint keeps failing
+	BidderCircuitBreaker *BidderCircuitBreakerConfig
+	// Close the auction once every imp has enough bids
+	EarlyExit *EarlyExitConfig
+	// Close the auction with the bids so far once most bidders have responded
+	PartialResponse *PartialResponseConfig
+	// HTTP requests of one bidder call sent at once, for adapters that split
+	// their request (1 sends them in turn)
+	MaxParallelBidderRequests int
+	// Leave imp formats and fields each bidder's capabilities don't use out of its requests
+	RequestShaping bool
+	// Reject creatives that break the request's restrictions (nil disables)
+	CreativeValidation *CreativeValidationConfig
+	// Rank bids for deals offered in the imp's PMP above open-auction bids;
+	// a preferred deal that wins pays its bid, even in second-price auctions
+	PreferDeals bool
+	// Prebid Cache for requests opting in via ext.prebid.cache (nil disables caching)
+	BidCache BidCache
+	// Win and imp notification URLs on returned bids (nil disables them)
+	Events *EventsConfig
+	// Fires winning bids' nurls as the auction closes (nil leaves them to the client)
+	WinNotifier *Notifier
+	// Skip or strip bidders whose GVL vendor ID lacks TCF consent under GDPR
+	// (VendorConsentSkip, VendorConsentStrip; VendorConsentOff by default)
+	GDPRVendorConsent string
+	// Report (NativeValidationFlag) or reject (NativeValidationReject) native
+	// bids that don't fulfil the imp's native request; NativeValidationOff by default
+	NativeValidation string
+	// Directory undelivered IDR event batches are spilled to for replay ("" drops them)
+	EventSpillDir string
+	// Size limit of the spill; its oldest events are dropped beyond it
+	EventSpillMaxBytes int64
 }
 
 // DefaultConfig returns default configuration
 func DefaultConfig() *Config {
 	return &Config{
-		DefaultTimeout:        1000 * time.Millisecond,
-		MaxBidders:            50,
-		MaxConcurrentBidders:  10, // P0-4: Limit concurrent HTTP requests per auction
-		IDREnabled:            true,
-		IDRServiceURL:         "http://localhost:5050",
-		EventRecordEnabled:    true,
-		EventBufferSize:       100,
-		CurrencyConv:          false,
-		DefaultCurrency:       "USD",
-		FPD:                   fpd.DefaultConfig(),
-		CloneLimits:           DefaultCloneLimits(), // P3-1: Configurable clone limits
-		DynamicBiddersEnabled: true,
-		DynamicRefreshPeriod:  30 * time.Second,
-		AuctionType:           FirstPriceAuction,
-		PriceIncrement:        0.01,
-		MinBidPrice:           0.0,
+		DefaultTimeout:            1000 * time.Millisecond,
+		MaxBidders:                50,
+		BidderPool:                DefaultBidderPoolConfig(), // Bounds bidder calls across all auctions
+		IDREnabled:                true,
+		IDRServiceURL:             "http://localhost:5050",
+		EventRecordEnabled:        true,
+		EventBufferSize:           100,
+		CurrencyConv:              false,
+		DefaultCurrency:           "USD",
+		FPD:                       fpd.DefaultConfig(),
+		CloneLimits:               DefaultCloneLimits(), // P3-1: Configurable clone limits
+		DynamicBiddersEnabled:     true,
+		DynamicRefreshPeriod:      30 * time.Second,
+		AuctionType:               FirstPriceAuction,
+		PriceIncrement:            0.01,
+		MinBidPrice:               0.0,
+		Identification:            adapters.DefaultIdentification(),
+		Sandbox:                   DefaultSandboxConfig(),
+		AdaptiveTimeouts:          DefaultAdaptiveTimeoutConfig(),
+		BidderCircuitBreaker:      DefaultBidderCircuitBreakerConfig(),
+		EarlyExit:                 DefaultEarlyExitConfig(),
+		PartialResponse:           DefaultPartialResponseConfig(),
+		MaxParallelBidderRequests: defaultMaxParallelBidderRequests,
+		TMaxNetworkBuffer:         DefaultTMaxNetworkBuffer,
 	}
 }
 
@@ -143,9 +247,21 @@ func validateConfig(config *Config) *Config {
 		config.MaxBidders = defaults.MaxBidders
 	}
 
-	// MaxConcurrentBidders must be non-negative (0 means unlimited)
-	if config.MaxConcurrentBidders < 0 {
-		config.MaxConcurrentBidders = defaults.MaxConcurrentBidders
+	// Bidder calls send at least one request at a time
+	if config.MaxParallelBidderRequests <= 0 {
+		config.MaxParallelBidderRequests = defaults.MaxParallelBidderRequests
+	}
+
+	// The bidder pool needs workers and a queue
+	if config.BidderPool == nil {
+		config.BidderPool = defaults.BidderPool
+	} else {
+		if config.BidderPool.Workers <= 0 {
+			config.BidderPool.Workers = defaults.BidderPool.Workers
+		}
+		if config.BidderPool.QueueSize <= 0 {
+			config.BidderPool.QueueSize = defaults.BidderPool.QueueSize
+		}
 	}
 
 	// AuctionType must be valid
@@ -158,6 +274,11 @@ func validateConfig(config *Config) *Config {
 		config.PriceIncrement = defaults.PriceIncrement
 	}
 
+	// TMaxNetworkBuffer should not be negative, which would overstate the time left
+	if config.TMaxNetworkBuffer < 0 {
+		config.TMaxNetworkBuffer = 0
+	}
+
 	// MinBidPrice should not be negative
 	if config.MinBidPrice < 0 {
 		config.MinBidPrice = 0
@@ -190,6 +311,95 @@ func validateConfig(config *Config) *Config {
 		}
 	}
 
+	// Sandbox limits must be positive or sandboxed bidders could never run
+	if config.Sandbox == nil {
+		config.Sandbox = DefaultSandboxConfig()
+	} else {
+		defaultSandbox := DefaultSandboxConfig()
+		if config.Sandbox.TimeBudget <= 0 {
+			config.Sandbox.TimeBudget = defaultSandbox.TimeBudget
+		}
+		if config.Sandbox.MaxRequestBytes <= 0 {
+			config.Sandbox.MaxRequestBytes = defaultSandbox.MaxRequestBytes
+		}
+		if config.Sandbox.MaxResponseBytes <= 0 {
+			config.Sandbox.MaxResponseBytes = defaultSandbox.MaxResponseBytes
+		}
+		if config.Sandbox.MaxBids <= 0 {
+			config.Sandbox.MaxBids = defaultSandbox.MaxBids
+		}
+		if config.Sandbox.MaxInFlight <= 0 {
+			config.Sandbox.MaxInFlight = defaultSandbox.MaxInFlight
+		}
+	}
+
+	// Adaptive timeout tuning needs a usable percentile and sample window
+	if config.AdaptiveTimeouts == nil {
+		config.AdaptiveTimeouts = DefaultAdaptiveTimeoutConfig()
+	} else {
+		defaultAdaptive := DefaultAdaptiveTimeoutConfig()
+		if config.AdaptiveTimeouts.Percentile <= 0 || config.AdaptiveTimeouts.Percentile > 1 {
+			config.AdaptiveTimeouts.Percentile = defaultAdaptive.Percentile
+		}
+		if config.AdaptiveTimeouts.Buffer < 0 {
+			config.AdaptiveTimeouts.Buffer = defaultAdaptive.Buffer
+		}
+		if config.AdaptiveTimeouts.MinTimeout < minBidderTimeout {
+			config.AdaptiveTimeouts.MinTimeout = minBidderTimeout
+		}
+		if config.AdaptiveTimeouts.MaxTimeout < 0 {
+			config.AdaptiveTimeouts.MaxTimeout = 0
+		}
+		if config.AdaptiveTimeouts.Window <= 0 {
+			config.AdaptiveTimeouts.Window = defaultAdaptive.Window
+		}
+		if config.AdaptiveTimeouts.MinSamples <= 0 || config.AdaptiveTimeouts.MinSamples > config.AdaptiveTimeouts.Window {
+			config.AdaptiveTimeouts.MinSamples = min(defaultAdaptive.MinSamples, config.AdaptiveTimeouts.Window)
+		}
+	}
+
+	// Circuit breakers need a failure threshold, an open period and a probe to close
+	if config.BidderCircuitBreaker == nil {
+		config.BidderCircuitBreaker = DefaultBidderCircuitBreakerConfig()
+	} else {
+		if config.BidderCircuitBreaker.FailureThreshold <= 0 {
+			config.BidderCircuitBreaker.FailureThreshold = defaultBreakerFailureThreshold
+		}
+		if config.BidderCircuitBreaker.OpenDuration <= 0 {
+			config.BidderCircuitBreaker.OpenDuration = defaultBreakerOpenDuration
+		}
+		if config.BidderCircuitBreaker.HalfOpenProbes <= 0 {
+			config.BidderCircuitBreaker.HalfOpenProbes = defaultBreakerHalfOpenProbes
+		}
+	}
+
+	// Early exit needs at least one bid per imp and a fraction of the timeout
+	if config.EarlyExit == nil {
+		config.EarlyExit = DefaultEarlyExitConfig()
+	} else {
+		if config.EarlyExit.MinBidsPerImp <= 0 {
+			config.EarlyExit.MinBidsPerImp = defaultEarlyExitMinBids
+		}
+		if config.EarlyExit.MinElapsed < 0 || config.EarlyExit.MinElapsed > 1 {
+			config.EarlyExit.MinElapsed = defaultEarlyExitMinElapsed
+		}
+	}
+
+	// Partial responses need a share of bidders or a soft deadline within the timeout
+	if config.PartialResponse == nil {
+		config.PartialResponse = DefaultPartialResponseConfig()
+	} else {
+		if config.PartialResponse.MinResponded < 0 || config.PartialResponse.MinResponded > 1 {
+			config.PartialResponse.MinResponded = defaultPartialMinResponded
+		}
+		if config.PartialResponse.SoftDeadline < 0 || config.PartialResponse.SoftDeadline > 1 {
+			config.PartialResponse.SoftDeadline = defaultPartialSoftDeadline
+		}
+		if config.PartialResponse.MinResponded == 0 && config.PartialResponse.SoftDeadline == 0 {
+			config.PartialResponse.MinResponded = defaultPartialMinResponded
+		}
+	}
+
 	return config
 }
 
@@ -208,20 +418,60 @@ func New(registry *adapters.Registry, config *Config) *Exchange {
 		fpdConfig = fpd.DefaultConfig()
 	}
 
+	httpClient := adapters.NewHTTPClientWithTransport(config.DefaultTimeout, config.Transport)
+	httpClient.SetIdentification(config.Identification)
+
 	ex := &Exchange{
 		registry:     registry,
-		httpClient:   adapters.NewHTTPClient(config.DefaultTimeout),
+		httpClient:   httpClient,
 		config:       config,
 		fpdProcessor: fpd.NewProcessor(fpdConfig),
 		eidFilter:    fpd.NewEIDFilter(fpdConfig),
+		limiter:      newBidderLimiter(),
 	}
 
 	if config.IDREnabled && config.IDRServiceURL != "" {
 		ex.idrClient = idr.NewClient(config.IDRServiceURL, 50*time.Millisecond, config.IDRAPIKey)
+		ex.idrClient.SetSigner(config.IDRSigner)
 	}
 
 	if config.EventRecordEnabled && config.IDRServiceURL != "" {
 		ex.eventRecorder = idr.NewEventRecorder(config.IDRServiceURL, config.EventBufferSize)
+		ex.eventRecorder.SetRecordingPolicy(config.EventRecordingPolicy)
+		ex.eventRecorder.SetSigner(config.IDRSigner)
+		if config.EventSpillDir != "" {
+			if err := ex.eventRecorder.EnableSpill(config.EventSpillDir, config.EventSpillMaxBytes); err != nil {
+				logger.Log.Error().Err(err).Str("dir", config.EventSpillDir).Msg("Failed to enable IDR event spill, undelivered events will be dropped")
+			}
+		}
+	}
+
+	if config.Events != nil && config.Events.ExternalURL != "" {
+		config.Events.ExternalURL = strings.TrimSuffix(config.Events.ExternalURL, "/")
+		ex.bidNotices = NewBidNotices(config.Events.TTL)
+	}
+
+	if config.IDRSelectionCacheTTL > 0 {
+		ex.idrCache = newIDRSelectionCache(config.IDRSelectionCacheTTL, defaultIDRCacheMaxEntries)
+	}
+
+	ex.adaptiveTimeouts = newAdaptiveTimeouts(config.AdaptiveTimeouts)
+	ex.sandbox = newAdapterSandbox(config.Sandbox, func() SandboxMetrics {
+		ex.configMu.RLock()
+		defer ex.configMu.RUnlock()
+		return ex.sandboxMetrics
+	})
+	ex.bidderPool = newBidderPool(config.BidderPool, func() BidderPoolMetrics {
+		ex.configMu.RLock()
+		defer ex.configMu.RUnlock()
+		return ex.poolMetrics
+	})
+	if config.BidderCircuitBreaker.Enabled {
+		ex.breakers = newBidderBreakers(config.BidderCircuitBreaker, func() CircuitBreakerMetrics {
+			ex.configMu.RLock()
+			defer ex.configMu.RUnlock()
+			return ex.circuitMetrics
+		})
 	}
 
 	return ex
@@ -241,6 +491,186 @@ func (e *Exchange) GetDynamicRegistry() *ortb.DynamicRegistry {
 	return e.dynamicRegistry
 }
 
+// SetIDRCacheMetrics attaches hit-rate reporting for the IDR selection cache
+func (e *Exchange) SetIDRCacheMetrics(m IDRCacheMetrics) {
+	e.configMu.Lock()
+	defer e.configMu.Unlock()
+	e.idrCacheMetrics = m
+}
+
+// SetRolloutMetrics attaches gradual rollout reporting for bidders with a traffic_percent
+func (e *Exchange) SetRolloutMetrics(m RolloutMetrics) {
+	e.configMu.Lock()
+	defer e.configMu.Unlock()
+	e.rolloutMetrics = m
+}
+
+// SetThrottleMetrics attaches reporting for bidders skipped by their rate_limits
+func (e *Exchange) SetThrottleMetrics(m ThrottleMetrics) {
+	e.configMu.Lock()
+	defer e.configMu.Unlock()
+	e.throttleMetrics = m
+}
+
+// SetConnectionMetrics attaches connection reuse reporting for bidder calls
+func (e *Exchange) SetConnectionMetrics(m adapters.ConnectionMetrics) {
+	if c, ok := e.httpClient.(*adapters.DefaultHTTPClient); ok {
+		c.SetConnectionMetrics(m)
+	}
+}
+
+// SetCircuitBreakerMetrics attaches reporting for bidder circuit breakers
+func (e *Exchange) SetCircuitBreakerMetrics(m CircuitBreakerMetrics) {
+	e.configMu.Lock()
+	defer e.configMu.Unlock()
+	e.circuitMetrics = m
+}
+
+// SetSandboxMetrics attaches fault reporting for sandboxed dynamic bidders
+func (e *Exchange) SetSandboxMetrics(m SandboxMetrics) {
+	e.configMu.Lock()
+	defer e.configMu.Unlock()
+	e.sandboxMetrics = m
+}
+
+// SetBidderPoolMetrics attaches queue depth and wait reporting for the bidder worker pool
+func (e *Exchange) SetBidderPoolMetrics(m BidderPoolMetrics) {
+	e.configMu.Lock()
+	defer e.configMu.Unlock()
+	e.poolMetrics = m
+}
+
+// SetBidderErrorMetrics attaches per-bidder error reporting by category
+func (e *Exchange) SetBidderErrorMetrics(m BidderErrorMetrics) {
+	e.configMu.Lock()
+	defer e.configMu.Unlock()
+	e.errorMetrics = m
+}
+
+// SetCreativeMetrics attaches reporting of bids rejected by creative validation
+func (e *Exchange) SetCreativeMetrics(m CreativeMetrics) {
+	e.configMu.Lock()
+	defer e.configMu.Unlock()
+	e.creativeMetrics = m
+}
+
+// SetRequestSizeMetrics attaches per-bidder outbound request size reporting
+func (e *Exchange) SetRequestSizeMetrics(m RequestSizeMetrics) {
+	e.configMu.Lock()
+	defer e.configMu.Unlock()
+	e.sizeMetrics = m
+}
+
+// SetMiddlewareOverheadMetrics attaches reporting of time spent before the auction starts
+func (e *Exchange) SetMiddlewareOverheadMetrics(m MiddlewareOverheadMetrics) {
+	e.configMu.Lock()
+	defer e.configMu.Unlock()
+	e.overheadMetrics = m
+}
+
+// SetAuctionMetrics attaches auction and bid outcome reporting
+func (e *Exchange) SetAuctionMetrics(m AuctionMetrics) {
+	e.configMu.Lock()
+	defer e.configMu.Unlock()
+	e.auctionMetrics = m
+}
+
+// IDRCacheStats returns IDR selection cache effectiveness (Enabled is false when disabled)
+func (e *Exchange) IDRCacheStats() IDRCacheStats {
+	if e.idrCache == nil {
+		return IDRCacheStats{}
+	}
+	return e.idrCache.stats()
+}
+
+// AdaptiveTimeoutStats returns per-bidder latency percentiles and tuned timeouts.
+// Latency is tracked even when tuning is disabled so values can be reviewed first.
+func (e *Exchange) AdaptiveTimeoutStats() AdaptiveTimeoutStats {
+	if e.adaptiveTimeouts == nil {
+		return AdaptiveTimeoutStats{}
+	}
+	return e.adaptiveTimeouts.stats(e.defaultTimeout())
+}
+
+// SetDefaultTimeout changes the timeout of auctions that start afterwards
+// without tmax or an account timeout
+func (e *Exchange) SetDefaultTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		return
+	}
+	e.configMu.Lock()
+	defer e.configMu.Unlock()
+	e.config.DefaultTimeout = timeout
+}
+
+func (e *Exchange) defaultTimeout() time.Duration {
+	e.configMu.RLock()
+	defer e.configMu.RUnlock()
+	return e.config.DefaultTimeout
+}
+
+// BidderCircuitStats returns each called bidder's circuit; empty when circuit
+// breakers are off
+func (e *Exchange) BidderCircuitStats() map[string]BidderCircuitStats {
+	if e.breakers == nil {
+		return map[string]BidderCircuitStats{}
+	}
+	return e.breakers.stats()
+}
+
+// bidderTimeout returns the timeout for one bidder within an auction of the given timeout
+func (e *Exchange) bidderTimeout(bidderCode string, auctionTimeout time.Duration) time.Duration {
+	if e.adaptiveTimeouts == nil || !e.config.AdaptiveTimeouts.Enabled {
+		return auctionTimeout
+	}
+	return e.adaptiveTimeouts.timeoutFor(bidderCode, auctionTimeout)
+}
+
+// recordBidderLatency feeds a finished bidder call into adaptive timeout tuning.
+// Warmup auctions have no tracker, so synthetic latencies are never recorded.
+func (e *Exchange) recordBidderLatency(result *BidderResult, auctionTimeout time.Duration) {
+	if e.adaptiveTimeouts == nil || result == nil || result.Latency <= 0 || result.Cancelled || result.Late {
+		return
+	}
+	e.adaptiveTimeouts.record(result.BidderCode, result.Latency, result.TimedOut, auctionTimeout)
+}
+
+// FlushIDRCache drops all memoized IDR selections
+func (e *Exchange) FlushIDRCache() {
+	if e.idrCache != nil {
+		e.idrCache.flush()
+	}
+}
+
+// InvalidateIDRCache drops memoized IDR selections for publishers matching match
+// and returns how many were dropped
+func (e *Exchange) InvalidateIDRCache(match func(publisherID string) bool) int {
+	if e.idrCache == nil {
+		return 0
+	}
+	return e.idrCache.invalidate(match)
+}
+
+// SetFlags attaches the runtime feature toggle registry
+func (e *Exchange) SetFlags(reg *flags.Registry) {
+	e.configMu.Lock()
+	defer e.configMu.Unlock()
+	e.flags = reg
+}
+
+// flagEnabled reports whether a runtime toggle is on, falling back to the
+// flag's default when no registry is attached
+func (e *Exchange) flagEnabled(f flags.Flag) bool {
+	e.configMu.RLock()
+	reg := e.flags
+	e.configMu.RUnlock()
+
+	if reg == nil {
+		return flags.Default(f)
+	}
+	return reg.Enabled(f)
+}
+
 // Close shuts down the exchange and flushes pending events
 func (e *Exchange) Close() error {
 	if e.eventRecorder != nil {
@@ -253,8 +683,9 @@ func (e *Exchange) Close() error {
 type AuctionRequest struct {
 	BidRequest *openrtb.BidRequest
 	Timeout    time.Duration
-	Account    string
-	Debug      bool
+	Account     string
+	Debug       bool
+	Diagnostics bool // Explain the auction in AuctionResponse.Diagnostics if it returns no bids
 }
 
 // AuctionResponse contains auction results
@@ -263,29 +694,50 @@ type AuctionResponse struct {
 	BidderResults map[string]*BidderResult
 	IDRResult     *idr.SelectPartnersResponse
 	DebugInfo     *DebugInfo
+	Diagnostics   *openrtb.ExtNoBidDiagnostics // Set on empty auctions run with AuctionRequest.Diagnostics
 }
 
 // BidderResult contains results from a single bidder
 type BidderResult struct {
-	BidderCode string
-	Bids       []*adapters.TypedBid
-	Errors     []error
-	Latency    time.Duration
-	Selected   bool
-	Score      float64
-	TimedOut   bool // P2-2: indicates if the bidder request timed out
+	BidderCode      string
+	Bids            []*adapters.TypedBid
+	Errors          []error // All errors; each is also in exactly one category below
+	InputErrors     []error // BidderErrorInput: our request couldn't be built
+	TransportErrors []error // BidderErrorTransport: the bidder couldn't be reached in time
+	ResponseErrors  []error // BidderErrorResponse: the bidder's answer was unusable
+	Latency         time.Duration
+	Selected        bool
+	Score           float64
+	TimedOut        bool // P2-2: indicates if the bidder request timed out
+	Cancelled       bool // Cut short because the auction closed early
+	Late            bool // Left out of a partial response; also TimedOut
+	RequestBytes    int  // Serialized size of the requests sent to the bidder
+	BytesSaved      int  // Approximate bytes request shaping removed
+	// HTTPCalls holds the requests sent and responses received (debug auctions only)
+	HTTPCalls []openrtb.ExtHTTPCall
 }
 
 // DebugInfo contains debug information
 type DebugInfo struct {
-	RequestTime       time.Time
-	TotalLatency      time.Duration
-	IDRLatency        time.Duration
-	BidderLatencies   map[string]time.Duration
-	SelectedBidders   []string
-	ExcludedBidders   []string
-	Errors            map[string][]string
-	errorsMu          sync.Mutex // Protects concurrent access to Errors map
+	RequestTime        time.Time
+	TotalLatency       time.Duration
+	IDRLatency         time.Duration
+	IDRCacheHit        bool          // Selection served from the IDR selection cache
+	MiddlewareOverhead time.Duration // Time spent in the HTTP middleware chain before the auction
+	BidderLatencies    map[string]time.Duration
+	SelectedBidders    []string
+	ExcludedBidders    []string
+	RolloutHeldBack    []string // Selected bidders skipped by their traffic_percent
+	Throttled          []string // Selected bidders skipped for exceeding their rate_limits
+	CircuitOpen        []string // Selected bidders skipped while their circuit breaker is open
+	ConsentDenied      []string // Selected bidders without GDPR vendor consent, skipped or stripped
+	COPPAUnsupported   []string // Bidders called for a COPPA request without declaring COPPA support
+	Errors             map[string][]string
+	errorsMu           sync.Mutex // Protects concurrent access to Errors map
+	// BidLandscape lists every bid per imp ID (debug auctions only)
+	BidLandscape map[string][]openrtb.ExtLandscapeBid
+	// HTTPCalls lists each bidder's requests and responses (debug auctions only)
+	HTTPCalls map[string][]openrtb.ExtHTTPCall
 }
 
 // AddError safely adds errors to the Errors map with mutex protection
@@ -345,6 +797,15 @@ func ValidateRequest(req *openrtb.BidRequest) *RequestValidationError {
 			}
 		}
 		impIDs[imp.ID] = struct{}{}
+
+		if imp.Audio != nil {
+			if reason := validateAudioImp(imp.Audio); reason != "" {
+				return &RequestValidationError{
+					Field:  fmt.Sprintf("imp[%d].audio", i),
+					Reason: reason,
+				}
+			}
+		}
 	}
 
 	// Validate Site XOR App (exactly one must be present, not both, not neither)
@@ -453,8 +914,8 @@ func (e *Exchange) validateBid(bid *openrtb.Bid, bidderCode string, impIDs map[s
 		}
 	}
 
-	// Check price meets floor
-	if floor > 0 && bid.Price < floor {
+	// Check price meets floor (can be relaxed at runtime during floor incidents)
+	if !floors.Meets(bid.Price, floor) && e.flagEnabled(flags.FloorEnforcement) {
 		return &BidValidationError{
 			BidID:      bid.ID,
 			ImpID:      bid.ImpID,
@@ -465,7 +926,7 @@ func (e *Exchange) validateBid(bid *openrtb.Bid, bidderCode string, impIDs map[s
 
 	// P2-1: Validate that bid has creative content (AdM or NURL required)
 	// OpenRTB 2.x requires either inline markup (adm) or a URL to fetch it (nurl)
-	if bid.AdM == "" && bid.NURL == "" {
+	if bid.AdM == "" && bid.NURL == "" && e.flagEnabled(flags.EnforceCreative) {
 		return &BidValidationError{
 			BidID:      bid.ID,
 			ImpID:      bid.ImpID,
@@ -477,20 +938,59 @@ func (e *Exchange) validateBid(bid *openrtb.Bid, bidderCode string, impIDs map[s
 	return nil
 }
 
-// buildImpFloorMap creates a map of impression IDs to their floor prices
-func buildImpFloorMap(req *openrtb.BidRequest) map[string]float64 {
+// validateDeal checks that a deal bid targets a deal offered on its impression
+func validateDeal(bid *openrtb.Bid, bidderCode string, impDeals map[string]map[string]struct{}) *BidValidationError {
+	if bid.DealID == "" {
+		return nil
+	}
+	if _, ok := impDeals[bid.ImpID][bid.DealID]; ok {
+		return nil
+	}
+	return &BidValidationError{
+		BidID:      bid.ID,
+		ImpID:      bid.ImpID,
+		BidderCode: bidderCode,
+		Reason:     fmt.Sprintf("dealid %q not offered on imp", bid.DealID),
+	}
+}
+
+// buildImpDealMap creates a map of impression IDs to the deal IDs offered in their PMP
+func buildImpDealMap(req *openrtb.BidRequest) map[string]map[string]struct{} {
+	impDeals := make(map[string]map[string]struct{}, len(req.Imp))
+	for _, imp := range req.Imp {
+		if imp.PMP == nil || len(imp.PMP.Deals) == 0 {
+			continue
+		}
+		deals := make(map[string]struct{}, len(imp.PMP.Deals))
+		for _, deal := range imp.PMP.Deals {
+			deals[deal.ID] = struct{}{}
+		}
+		impDeals[imp.ID] = deals
+	}
+	return impDeals
+}
+
+// buildImpFloorMap creates a map of impression IDs to their floor prices in
+// the auction currency. Floors that can't be converted are kept as sent.
+func buildImpFloorMap(req *openrtb.BidRequest, auctionCur string, conv currency.Converter) map[string]float64 {
 	impFloors := make(map[string]float64, len(req.Imp))
 	for _, imp := range req.Imp {
-		impFloors[imp.ID] = imp.BidFloor
+		floor, err := floors.Resolve(imp.BidFloor, imp.BidFloorCur, auctionCur, conv)
+		if err != nil {
+			floor = imp.BidFloor
+		}
+		impFloors[imp.ID] = floor
 	}
 	return impFloors
 }
 
 // ValidatedBid wraps a bid with validation status
 type ValidatedBid struct {
-	Bid        *adapters.TypedBid
-	BidderCode string
-	DemandType adapters.DemandType // platform (obfuscated) or publisher (transparent)
+	Bid           *adapters.TypedBid
+	BidderCode    string
+	DemandType    adapters.DemandType // platform (obfuscated) or publisher (transparent)
+	PreferredDeal bool                // Outranks open-auction bids (Config.PreferDeals)
+	VideoDuration int                 // Video creative seconds, from dur or the bid's VAST
 }
 
 // runAuctionLogic applies auction rules (first-price or second-price) to validated bids
@@ -509,10 +1009,11 @@ func (e *Exchange) runAuctionLogic(validBids []ValidatedBid, impFloors map[strin
 			continue
 		}
 
-		// Sort by price descending
+		// Sort by price descending, preferred deals first
 		sortBidsByPrice(bids)
+		preferDeals(bids)
 
-		if e.config.AuctionType == SecondPriceAuction {
+		if e.config.AuctionType == SecondPriceAuction && !bids[0].PreferredDeal {
 			var winningPrice float64
 			originalBidPrice := bids[0].Bid.Bid.Price
 
@@ -659,7 +1160,20 @@ func (e *Exchange) RunAuction(ctx context.Context, req *AuctionRequest) (*Auctio
 		return response, validationErr
 	}
 
-	// Get timeout from request or config
+	// The publisher's account applies over the global config
+	e.configMu.RLock()
+	accountSource := e.accounts
+	e.configMu.RUnlock()
+	account := lookupAccount(ctx, accountSource, req, response.DebugInfo)
+	if req.Debug && account != nil && !account.AllowsDebug() {
+		req.Debug = false
+	}
+	applyAccountFloors(account, req.BidRequest, e.config.DefaultCurrency, e.currencyConverter())
+
+	// Interstitial imps get sizes for the device; rewarded imps carry imp.rwdd
+	prepareInventoryImps(req.BidRequest)
+
+	// Get timeout from request, account or config
 	// P1-NEW-1: Validate TMax bounds to prevent abuse
 	timeout := req.Timeout
 	if timeout == 0 && req.BidRequest.TMax > 0 {
@@ -671,10 +1185,17 @@ func (e *Exchange) RunAuction(ctx context.Context, req *AuctionRequest) (*Auctio
 		}
 		timeout = time.Duration(tmax) * time.Millisecond
 	}
+	if timeout == 0 && account != nil && account.TimeoutMS > 0 {
+		timeout = time.Duration(account.TimeoutMS) * time.Millisecond
+	}
 	if timeout == 0 {
-		timeout = e.config.DefaultTimeout
+		timeout = e.defaultTimeout()
 	}
 
+	// The timeout budget started when the request arrived, not when the auction did
+	timeout, overhead := remainingBudget(ctx, timeout, startTime)
+	response.DebugInfo.MiddlewareOverhead = overhead
+
 	// Create timeout context
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
@@ -687,31 +1208,94 @@ func (e *Exchange) RunAuction(ctx context.Context, req *AuctionRequest) (*Auctio
 	dynamicRegistry := e.dynamicRegistry
 	fpdProcessor := e.fpdProcessor
 	eidFilter := e.eidFilter
+	idrCacheMetrics := e.idrCacheMetrics
+	auctionMetrics := e.auctionMetrics
+	rolloutMetrics := e.rolloutMetrics
+	throttleMetrics := e.throttleMetrics
+	errorMetrics := e.errorMetrics
+	creativeMetrics := e.creativeMetrics
+	sizeMetrics := e.sizeMetrics
+	overheadMetrics := e.overheadMetrics
+	privacyMetrics := e.privacyMetrics
 	e.configMu.RUnlock()
 
+	if overheadMetrics != nil && overhead > 0 {
+		overheadMetrics.RecordMiddlewareOverhead(overhead)
+	}
+
 	// Add dynamic bidders if enabled
 	if e.config.DynamicBiddersEnabled && dynamicRegistry != nil {
 		dynamicCodes := dynamicRegistry.ListEnabledBidderCodes()
 		availableBidders = append(availableBidders, dynamicCodes...)
 	}
 
+	// Bidders outside the account's allow list are not eligible at all
+	availableBidders = filterAccountBidders(account, availableBidders)
+
+	// Diagnostics auctions explain an empty result; nil records nothing
+	var diag *noBidDiagnostics
+	if req.Diagnostics {
+		diag = newNoBidDiagnostics()
+		diag.setEligible(availableBidders)
+	}
+
+	// Aliases in ext.prebid.aliases call their bidder's adapter under their own code
+	reqBidders, aliasErrs := parseRequestBidders(req.BidRequest.Ext, func(code string) bool {
+		_, known := e.bidderInfo(code, dynamicRegistry)
+		return known
+	})
+	if len(aliasErrs) > 0 {
+		response.DebugInfo.AddError("aliases", aliasErrs)
+	}
+
+	// Bidders that take params are only called when the request carries some for them
+	availableBidders, noParams := e.filterBiddersWithoutParams(req.BidRequest, availableBidders, reqBidders, dynamicRegistry)
+	for _, code := range noParams {
+		diag.exclude(code, ExclusionNoParams, "request carries no params for the bidder")
+	}
+
 	if len(availableBidders) == 0 {
 		response.BidResponse = e.buildEmptyResponse(req.BidRequest, openrtb.NoBidNoBiddersAvailable)
+		response.Diagnostics = diag.build(NoFillNoBidders, nil, nil)
 		return response, nil
 	}
 
 	// Run IDR selection if enabled
 	selectedBidders := availableBidders
+	var idrShadowExcluded map[string]bool // Bidders a shadow-mode selection would have dropped
 	if e.idrClient != nil && e.config.IDREnabled {
 		idrStart := time.Now()
+		idrCtx, idrSpan := tracing.Start(ctx, "idr.select_partners", tracing.SpanKindInternal)
 
 		// P1-15: Build minimal request to reduce payload size
 		minReq := e.buildMinimalIDRRequest(req.BidRequest)
-		idrResult, err := e.idrClient.SelectPartnersMinimal(ctx, minReq, availableBidders)
+
+		// Debug requests always go to IDR so the selection shown is live
+		var idrResult *idr.SelectPartnersResponse
+		var err error
+		var cacheKey string
+		useCache := e.idrCache != nil && !req.Debug
+		if useCache {
+			cacheKey = idrCacheKey(minReq, availableBidders)
+			idrResult, response.DebugInfo.IDRCacheHit = e.idrCache.get(cacheKey)
+			if idrCacheMetrics != nil {
+				idrCacheMetrics.RecordIDRCacheLookup(response.DebugInfo.IDRCacheHit)
+			}
+		}
+		if !response.DebugInfo.IDRCacheHit {
+			idrResult, err = e.idrClient.SelectPartnersMinimal(idrCtx, minReq, availableBidders)
+			if useCache && err == nil && idrResult != nil {
+				e.idrCache.set(cacheKey, idrResult)
+			}
+		}
 
 		response.DebugInfo.IDRLatency = time.Since(idrStart)
 
-		if err == nil && idrResult != nil {
+		if err == nil && idrResult != nil && idrResult.Mode == idrShadowMode {
+			// Shadow mode calls every bidder; the selection is only compared
+			response.IDRResult = idrResult
+			idrShadowExcluded = idrShadowExclusions(idrResult)
+		} else if err == nil && idrResult != nil {
 			response.IDRResult = idrResult
 			selectedBidders = make([]string, 0, len(idrResult.SelectedBidders))
 			for _, sb := range idrResult.SelectedBidders {
@@ -720,9 +1304,75 @@ func (e *Exchange) RunAuction(ctx context.Context, req *AuctionRequest) (*Auctio
 
 			for _, eb := range idrResult.ExcludedBidders {
 				response.DebugInfo.ExcludedBidders = append(response.DebugInfo.ExcludedBidders, eb.BidderCode)
+				diag.exclude(eb.BidderCode, ExclusionIDR, eb.Reason)
 			}
 		}
 		// If IDR fails, fall back to all bidders
+		idrSpan.SetAttributes(
+			tracing.Bool("idr.cache_hit", response.DebugInfo.IDRCacheHit),
+			tracing.Int("idr.available", len(availableBidders)),
+			tracing.Int("idr.selected", len(selectedBidders)),
+		)
+		idrSpan.SetError(err)
+		idrSpan.End()
+	}
+
+	// Bidders ramping up via traffic_percent only see their share of auctions
+	if e.config.DynamicBiddersEnabled && dynamicRegistry != nil {
+		selectedBidders, response.DebugInfo.RolloutHeldBack = e.applyTrafficRollout(
+			req.BidRequest.ID, selectedBidders, dynamicRegistry, rolloutMetrics)
+		for _, code := range response.DebugInfo.RolloutHeldBack {
+			diag.exclude(code, ExclusionRollout, "auction outside the bidder's traffic_percent rollout")
+		}
+	}
+
+	// Under GDPR, bidders whose GVL vendor ID lacks consent are skipped or called without personal data
+	var stripPII map[string]bool
+	selectedBidders, response.DebugInfo.ConsentDenied = e.applyVendorConsent(
+		req.BidRequest, selectedBidders, dynamicRegistry, privacyMetrics)
+	for _, code := range response.DebugInfo.ConsentDenied {
+		if e.config.GDPRVendorConsent == VendorConsentStrip {
+			if stripPII == nil {
+				stripPII = make(map[string]bool)
+			}
+			stripPII[code] = true
+		} else {
+			diag.exclude(code, ExclusionPrivacy, "no GDPR consent for the bidder's GVL vendor ID")
+		}
+	}
+
+	// Child-directed auctions flag bidders that don't declare COPPA support
+	if req.BidRequest.Regs != nil && req.BidRequest.Regs.COPPA == 1 {
+		response.DebugInfo.COPPAUnsupported = e.coppaUnsupported(selectedBidders, dynamicRegistry)
+	}
+
+	// Bidders that keep failing are skipped until their circuit closes again
+	if e.breakers != nil {
+		var release func()
+		selectedBidders, response.DebugInfo.CircuitOpen, release = e.applyCircuitBreakers(selectedBidders)
+		defer release()
+		for _, code := range response.DebugInfo.CircuitOpen {
+			diag.exclude(code, ExclusionCircuitOpen, "bidder's circuit breaker is open after repeated failures")
+		}
+	}
+
+	// Bidders over their rate_limits sit this auction out; their slots are held until the calls return
+	if e.config.DynamicBiddersEnabled && dynamicRegistry != nil && e.limiter != nil {
+		var release func()
+		selectedBidders, response.DebugInfo.Throttled, release = e.applyRateLimits(
+			selectedBidders, dynamicRegistry, throttleMetrics)
+		defer release()
+		for _, code := range response.DebugInfo.Throttled {
+			diag.exclude(code, ExclusionRateLimit, "bidder's rate_limits exceeded")
+		}
+	}
+
+	// Aliases call their bidder's adapter under their own code
+	selectedBidders = withAliases(selectedBidders, reqBidders.aliases)
+	for alias, core := range reqBidders.aliases {
+		if stripPII[core] {
+			stripPII[alias] = true
+		}
 	}
 
 	response.DebugInfo.SelectedBidders = selectedBidders
@@ -730,6 +1380,8 @@ func (e *Exchange) RunAuction(ctx context.Context, req *AuctionRequest) (*Auctio
 	// Process FPD and filter EIDs (using snapshotted processor/filter for consistency)
 	var bidderFPD fpd.BidderFPD
 	if fpdProcessor != nil {
+		_, fpdSpan := tracing.Start(ctx, "fpd.process", tracing.SpanKindInternal)
+
 		// Filter EIDs first
 		if eidFilter != nil {
 			eidFilter.ProcessRequestEIDs(req.BidRequest)
@@ -742,10 +1394,22 @@ func (e *Exchange) RunAuction(ctx context.Context, req *AuctionRequest) (*Auctio
 			// Log error but continue - FPD is not critical
 			response.DebugInfo.AddError("fpd", []string{err.Error()})
 		}
+		fpdSpan.SetAttributes(tracing.Int("fpd.bidders", len(bidderFPD)))
+		fpdSpan.SetError(err)
+		fpdSpan.End()
+	}
+
+	// Resolve ext.prebid.floors rules per imp and bidder; bad rules leave imp floors as sent
+	dynFloors, err := newAuctionFloors(req.BidRequest, selectedBidders, e.config.DefaultCurrency, e.currencyConverter())
+	if err != nil {
+		response.DebugInfo.AddError("floors", []string{err.Error()})
 	}
 
-	// Call bidders in parallel
-	results := e.callBiddersWithFPD(ctx, req.BidRequest, selectedBidders, timeout, bidderFPD)
+	// Call bidders in parallel; debug auctions keep every request and response
+	if req.Debug {
+		ctx = withHTTPCalls(ctx)
+	}
+	results := e.callBiddersWithFPD(ctx, req.BidRequest, selectedBidders, timeout, bidderFPD, dynFloors, stripPII, reqBidders)
 
 	// Extract request context for event recording
 	var country, deviceType, mediaType, adSize, publisherID string
@@ -765,21 +1429,16 @@ func (e *Exchange) RunAuction(ctx context.Context, req *AuctionRequest) (*Auctio
 		}
 	}
 	if len(req.BidRequest.Imp) > 0 {
-		imp := req.BidRequest.Imp[0]
-		if imp.Banner != nil {
-			mediaType = "banner"
-			if imp.Banner.W > 0 && imp.Banner.H > 0 {
-				adSize = fmt.Sprintf("%dx%d", imp.Banner.W, imp.Banner.H)
-			}
-		} else if imp.Video != nil {
-			mediaType = "video"
-		} else if imp.Native != nil {
-			mediaType = "native"
+		imp := &req.BidRequest.Imp[0]
+		mediaType = impMediaType(imp)
+		if imp.Banner != nil && imp.Banner.W > 0 && imp.Banner.H > 0 {
+			adSize = fmt.Sprintf("%dx%d", imp.Banner.W, imp.Banner.H)
 		}
 	}
 	if req.BidRequest.Site != nil && req.BidRequest.Site.Publisher != nil {
 		publisherID = req.BidRequest.Site.Publisher.ID
 	}
+	recordEvents := accountRecordsEvents(account, req.BidRequest.ID)
 
 	// P1-2: Check context deadline before expensive validation work
 	// If we've already timed out, return early with whatever we have
@@ -787,13 +1446,35 @@ func (e *Exchange) RunAuction(ctx context.Context, req *AuctionRequest) (*Auctio
 	case <-ctx.Done():
 		response.DebugInfo.TotalLatency = time.Since(startTime)
 		response.BidResponse = e.buildEmptyResponse(req.BidRequest, openrtb.NoBidTimeout)
+		response.Diagnostics = diag.build(NoFillTimeout, selectedBidders, results)
 		return response, nil // Return empty response rather than error on timeout
 	default:
 		// Context still valid, proceed with validation
 	}
 
 	// Build impression floor map for bid validation
-	impFloors := buildImpFloorMap(req.BidRequest)
+	impFloors := buildImpFloorMap(req.BidRequest, e.config.DefaultCurrency, e.currencyConverter())
+
+	// Deal bids must meet their deal's floor instead of the imp floor
+	dealFloors := buildImpDealFloors(req.BidRequest, e.config.DefaultCurrency, e.currencyConverter())
+
+	// Deal validation is a runtime toggle; only build the map when it's on
+	var impDeals map[string]map[string]struct{}
+	if e.flagEnabled(flags.DealValidation) {
+		impDeals = buildImpDealMap(req.BidRequest)
+	}
+
+	// Audio bids are checked against their imp's duration and protocol limits
+	impAudio := buildImpAudioMap(req.BidRequest)
+
+	// Native bids are checked against their imp's native request
+	var impNative map[string]*native.Request
+	if e.config.NativeValidation != NativeValidationOff {
+		impNative = buildImpNativeMap(req.BidRequest)
+	}
+
+	// Creatives are checked against the request's badv and bcat, and optionally the imp
+	creative := newCreativeRules(e.config.CreativeValidation, req.BidRequest, e.flagEnabled(flags.BlocklistEnforcement))
 
 	// Track seen bid IDs for deduplication
 	seenBidIDs := make(map[string]struct{})
@@ -802,10 +1483,25 @@ func (e *Exchange) RunAuction(ctx context.Context, req *AuctionRequest) (*Auctio
 	var validBids []ValidatedBid
 	var validationErrors []error
 
+	// Debug auctions explain every bid's outcome; nil records nothing
+	var landscape *bidLandscape
+	if req.Debug {
+		landscape = newBidLandscape()
+	}
+
 	// Collect results
+	_, validateSpan := tracing.Start(ctx, "bids.validate", tracing.SpanKindInternal)
 	for bidderCode, result := range results {
 		response.BidderResults[bidderCode] = result
 		response.DebugInfo.BidderLatencies[bidderCode] = result.Latency
+		if len(result.HTTPCalls) > 0 {
+			if response.DebugInfo.HTTPCalls == nil {
+				response.DebugInfo.HTTPCalls = make(map[string][]openrtb.ExtHTTPCall)
+			}
+			response.DebugInfo.HTTPCalls[bidderCode] = result.HTTPCalls
+		}
+		recordBidderErrors(errorMetrics, result)
+		recordRequestSize(sizeMetrics, result)
 
 		if len(result.Errors) > 0 {
 			errStrs := make([]string, len(result.Errors))
@@ -816,7 +1512,7 @@ func (e *Exchange) RunAuction(ctx context.Context, req *AuctionRequest) (*Auctio
 		}
 
 		// Record event to IDR
-		if e.eventRecorder != nil {
+		if e.eventRecorder != nil && recordEvents {
 			hadBid := len(result.Bids) > 0
 			var bidCPM *float64
 			if hadBid && len(result.Bids) > 0 {
@@ -864,7 +1560,12 @@ func (e *Exchange) RunAuction(ctx context.Context, req *AuctionRequest) (*Auctio
 			}
 
 			// Validate bid
-			if validErr := e.validateBid(tb.Bid, bidderCode, impFloors); validErr != nil {
+			bidFloors := dynFloors.enforced(bidderCode, impFloors)
+			dealFloor, isDeal := dealFloors.floor(tb.Bid)
+			if isDeal {
+				bidFloors = map[string]float64{tb.Bid.ImpID: dealFloor}
+			}
+			if validErr := e.validateBid(tb.Bid, bidderCode, bidFloors); validErr != nil {
 				// P3-1: Log bid validation failures for debugging
 				logger.Log.Debug().
 					Str("bidder", bidderCode).
@@ -875,9 +1576,67 @@ func (e *Exchange) RunAuction(ctx context.Context, req *AuctionRequest) (*Auctio
 					Msg("bid validation failed")
 				validationErrors = append(validationErrors, validErr)
 				response.DebugInfo.AppendError(bidderCode, validErr.Error())
+				landscape.reject(bidderCode, tb.Bid, validErr.Reason)
+				diag.reject(bidderCode, validErr)
+				continue
+			}
+
+			if audioErr := validateAudioBid(tb, bidderCode, impAudio); audioErr != nil {
+				validationErrors = append(validationErrors, audioErr)
+				response.DebugInfo.AppendError(bidderCode, audioErr.Error())
+				landscape.reject(bidderCode, tb.Bid, audioErr.Reason)
+				diag.reject(bidderCode, audioErr)
+				continue
+			}
+
+			// Video bids' VAST must parse; its duration goes on the bid's ext
+			videoDuration, videoErr := validateVideoBid(tb, bidderCode)
+			if videoErr != nil && e.flagEnabled(flags.EnforceCreative) {
+				validationErrors = append(validationErrors, videoErr)
+				response.DebugInfo.AppendError(bidderCode, videoErr.Error())
+				landscape.reject(bidderCode, tb.Bid, videoErr.Reason)
+				diag.reject(bidderCode, videoErr)
+				if creativeMetrics != nil {
+					creativeMetrics.RecordCreativeRejected(bidderCode, CreativeRejectVAST)
+				}
 				continue
 			}
 
+			if nativeErr := validateNativeBid(tb, bidderCode, impNative); nativeErr != nil {
+				if e.config.NativeValidation == NativeValidationReject {
+					validationErrors = append(validationErrors, nativeErr)
+					response.DebugInfo.AppendError(bidderCode, nativeErr.Error())
+					landscape.reject(bidderCode, tb.Bid, nativeErr.Reason)
+					diag.reject(bidderCode, nativeErr)
+					if creativeMetrics != nil {
+						creativeMetrics.RecordCreativeRejected(bidderCode, CreativeRejectNative)
+					}
+					continue
+				}
+				response.DebugInfo.AppendError(bidderCode, nativeErr.Error()+" (flagged, not rejected)")
+			}
+
+			if label, creativeErr := creative.validate(tb, bidderCode); creativeErr != nil {
+				validationErrors = append(validationErrors, creativeErr)
+				response.DebugInfo.AppendError(bidderCode, creativeErr.Error())
+				landscape.reject(bidderCode, tb.Bid, creativeErr.Reason)
+				diag.reject(bidderCode, creativeErr)
+				if creativeMetrics != nil {
+					creativeMetrics.RecordCreativeRejected(bidderCode, label)
+				}
+				continue
+			}
+
+			if impDeals != nil {
+				if dealErr := validateDeal(tb.Bid, bidderCode, impDeals); dealErr != nil {
+					validationErrors = append(validationErrors, dealErr)
+					response.DebugInfo.AppendError(bidderCode, dealErr.Error())
+					landscape.reject(bidderCode, tb.Bid, dealErr.Reason)
+					diag.reject(bidderCode, dealErr)
+					continue
+				}
+			}
+
 			// Check for duplicate bid IDs
 			if _, seen := seenBidIDs[tb.Bid.ID]; seen {
 				dupErr := &BidValidationError{
@@ -888,89 +1647,151 @@ func (e *Exchange) RunAuction(ctx context.Context, req *AuctionRequest) (*Auctio
 				}
 				validationErrors = append(validationErrors, dupErr)
 				response.DebugInfo.AppendError(bidderCode, dupErr.Error())
+				landscape.reject(bidderCode, tb.Bid, dupErr.Reason)
+				diag.reject(bidderCode, dupErr)
 				continue
 			}
 			seenBidIDs[tb.Bid.ID] = struct{}{}
 
 			// Add to valid bids with demand type
 			validBids = append(validBids, ValidatedBid{
-				Bid:        tb,
-				BidderCode: bidderCode,
-				DemandType: e.getDemandType(bidderCode, dynamicRegistry),
+				Bid:           tb,
+				BidderCode:    bidderCode,
+				DemandType:    e.getDemandType(reqBidders.aliases.core(bidderCode), dynamicRegistry),
+				PreferredDeal: isDeal && e.config.PreferDeals,
+				VideoDuration: videoDuration,
 			})
 		}
 	}
 
+	validateSpan.SetAttributes(
+		tracing.Int("bids.valid", len(validBids)),
+		tracing.Int("bids.rejected", len(validationErrors)),
+	)
+	validateSpan.End()
+
+	// Record submitted prices before auction logic adjusts them
+	if auctionMetrics != nil {
+		for _, vb := range validBids {
+			auctionMetrics.RecordBid(vb.BidderCode, string(vb.Bid.BidType), vb.Bid.Bid.Price)
+		}
+	}
+
+	// In IDR shadow mode, record what enforcing the selection would have cost
+	if idrShadowExcluded != nil && e.eventRecorder != nil && recordEvents {
+		e.eventRecorder.RecordShadowComparison(req.BidRequest.ID, publisherID,
+			compareIDRShadow(results, validBids, idrShadowExcluded, reqBidders.aliases))
+	}
+
 	// Apply auction logic (first-price or second-price)
-	auctionedBids := e.runAuctionLogic(validBids, impFloors)
+	landscape.snapshot(validBids)
+	auctionedBids := e.runAuctionLogic(validBids, dynFloors.clearingFloors(impFloors))
+	response.DebugInfo.BidLandscape = landscape.build(validBids, auctionedBids)
 
 	// Build seat bids with demand type obfuscation:
 	// - Platform demand: aggregated into single "thenexusengine" seat (highest bid per impression)
 	// - Publisher demand: shown transparently with original bidder codes
+	// Each seat returns its best bid per impression, or more with ext.prebid.multibid
 	seatBidMap := make(map[string]*openrtb.SeatBid)
+	passthroughs := impPassthroughs(req.BidRequest.Imp)
+
+	// Targeting keys follow request ext.prebid.targeting
+	targeting, targetingErr := parseTargeting(req.BidRequest.Ext)
+	if targetingErr != nil {
+		response.DebugInfo.AddError("targeting", []string{targetingErr.Error()})
+	}
+	multibid, multibidErr := parseMultibid(req.BidRequest.Ext)
+	if multibidErr != nil {
+		response.DebugInfo.AddError("multibid", strings.Split(multibidErr.Error(), "\n"))
+	}
+
+	// Bids returned across all imps, each imp's winner among them, and the
+	// bidder code each bid is targeted under
+	var returnedBids []ValidatedBid
+	winners := make(map[*openrtb.Bid]bool, len(auctionedBids))
+	targetCodes := make(map[*openrtb.Bid]string)
 
 	for _, impBids := range auctionedBids {
-		// Separate platform and publisher bids for this impression
-		var platformBids []ValidatedBid
-		var publisherBids []ValidatedBid
+		returned, codes := multibid.limit(impBids)
+		for i, vb := range returned {
+			targetCodes[vb.Bid.Bid] = codes[i]
+		}
+		winners[impWinner(returned)] = true
+		returnedBids = append(returnedBids, returned...)
+	}
 
-		for _, vb := range impBids {
-			if vb.DemandType == adapters.DemandTypePublisher {
-				publisherBids = append(publisherBids, vb)
-			} else {
-				// Default to platform for obfuscation
-				platformBids = append(platformBids, vb)
-			}
+	// Returned bids get event URLs, which video bids also carry in their VAST
+	accountID := auctionAccountID(req)
+	issued := time.Now()
+	var eventURLs map[*openrtb.Bid]*openrtb.ExtBidPrebidEvents
+	if e.bidNotices != nil {
+		eventURLs = make(map[*openrtb.Bid]*openrtb.ExtBidPrebidEvents, len(returnedBids))
+		for _, vb := range returnedBids {
+			eventURLs[vb.Bid.Bid] = bidEventURLs(e.config.Events.ExternalURL, displayBidderCode(vb), vb.Bid.Bid.ID, accountID, issued)
 		}
+		injectVASTImpressions(returnedBids, eventURLs)
+	}
 
-		// Add highest platform bid to "thenexusengine" seat (obfuscated)
-		if len(platformBids) > 0 {
-			// Find highest CPM platform bid for this impression
-			highestPlatformBid := platformBids[0]
-			for _, vb := range platformBids[1:] {
-				if vb.Bid.Bid.Price > highestPlatformBid.Bid.Bid.Price {
-					highestPlatformBid = vb
-				}
-			}
+	// Creatives are cached for requests opting in via ext.prebid.cache
+	var cached map[*openrtb.Bid]*openrtb.ExtBidPrebidCache
+	if cacheReq, ok := parseCacheSettings(req.BidRequest.Ext); ok && e.config.BidCache != nil {
+		var cacheErr error
+		cached, cacheErr = cacheBids(ctx, e.config.BidCache, cacheReq, returnedBids)
+		if cacheErr != nil {
+			logger.Log.Warn().Err(cacheErr).Str("requestID", req.BidRequest.ID).Msg("failed to cache bids")
+			response.DebugInfo.AddError("cache", []string{cacheErr.Error()})
+		}
+	}
 
-			// Get or create the thenexusengine seat
-			nexusSeat, ok := seatBidMap[adapters.PlatformSeatName]
-			if !ok {
-				nexusSeat = &openrtb.SeatBid{
-					Seat: adapters.PlatformSeatName,
-					Bid:  []openrtb.Bid{},
-				}
-				seatBidMap[adapters.PlatformSeatName] = nexusSeat
+	// Returned bids are remembered for /event; winners' nurls may be fired here
+	for _, vb := range returnedBids {
+		seat := displayBidderCode(vb)
+		sb, ok := seatBidMap[seat]
+		if !ok {
+			sb = &openrtb.SeatBid{
+				Seat: seat,
+				Bid:  []openrtb.Bid{},
 			}
-
-			// Create obfuscated bid with "thenexusengine" branding in targeting
-			bid := *highestPlatformBid.Bid.Bid
-			bidExt := e.buildBidExtension(highestPlatformBid)
-			if extBytes, err := json.Marshal(bidExt); err == nil {
-				bid.Ext = extBytes
+			seatBidMap[seat] = sb
+		}
+
+		// Create bid copy with Prebid extension for targeting
+		bid := *vb.Bid.Bid
+		cacheInfo := cached[vb.Bid.Bid]
+		targetCode := targetCodes[vb.Bid.Bid]
+		keys := targeting.keys(vb, targetCode, winners[vb.Bid.Bid], cacheInfo)
+		bidExt := e.buildBidExtension(vb, passthroughs[vb.Bid.Bid.ImpID], keys, cacheInfo)
+		if targetCode != seat {
+			bidExt.Prebid.TargetBidderCode = targetCode
+		}
+		if e.bidNotices != nil || e.config.WinNotifier != nil {
+			notice := &BidNotice{
+				AuctionID: req.BidRequest.ID,
+				ImpID:     bid.ImpID,
+				BidID:     bid.ID,
+				Bidder:    vb.BidderCode,
+				Seat:      seat,
+				Account:   accountID,
+				Price:     bid.Price,
+				Currency:  e.config.DefaultCurrency,
+				NURL:      bid.NURL,
+				BURL:      bid.BURL,
 			}
-			nexusSeat.Bid = append(nexusSeat.Bid, bid)
-		}
-
-		// Add all publisher bids transparently
-		for _, vb := range publisherBids {
-			sb, ok := seatBidMap[vb.BidderCode]
-			if !ok {
-				sb = &openrtb.SeatBid{
-					Seat: vb.BidderCode,
-					Bid:  []openrtb.Bid{},
-				}
-				seatBidMap[vb.BidderCode] = sb
+			// The nurl is left out of the response once fired, so clients don't fire it again
+			if e.config.WinNotifier != nil && winners[vb.Bid.Bid] && bid.NURL != "" {
+				e.config.WinNotifier.Fire(NoticeNURL, notice, bid.NURL)
+				notice.NURLFired = true
+				bid.NURL = ""
 			}
-
-			// Create bid copy with Prebid extension for targeting
-			bid := *vb.Bid.Bid
-			bidExt := e.buildBidExtension(vb)
-			if extBytes, err := json.Marshal(bidExt); err == nil {
-				bid.Ext = extBytes
+			if e.bidNotices != nil {
+				bidExt.Prebid.Events = eventURLs[vb.Bid.Bid]
+				e.bidNotices.Add(notice)
 			}
-			sb.Bid = append(sb.Bid, bid)
 		}
+		if extBytes, err := jsoncodec.Marshal(bidExt); err == nil {
+			bid.Ext = extBytes
+		}
+		sb.Bid = append(sb.Bid, bid)
 	}
 
 	// Convert seat bid map to slice
@@ -1001,18 +1822,34 @@ func (e *Exchange) RunAuction(ctx context.Context, req *AuctionRequest) (*Auctio
 		Dur("latency", response.DebugInfo.TotalLatency).
 		Msg("auction completed")
 
+	if auctionMetrics != nil {
+		status := "success"
+		if totalBids == 0 {
+			status = "nobid"
+		}
+		auctionMetrics.RecordAuction(status, mediaType, response.DebugInfo.TotalLatency,
+			len(selectedBidders), len(response.DebugInfo.ExcludedBidders))
+	}
+
+	if totalBids == 0 {
+		response.Diagnostics = diag.build("", selectedBidders, results)
+	}
+
 	return response, nil
 }
 
 // callBidders calls all selected bidders in parallel (legacy, without FPD)
 func (e *Exchange) callBidders(ctx context.Context, req *openrtb.BidRequest, bidders []string, timeout time.Duration) map[string]*BidderResult {
-	return e.callBiddersWithFPD(ctx, req, bidders, timeout, nil)
+	return e.callBiddersWithFPD(ctx, req, bidders, timeout, nil, nil, nil, requestBidders{})
 }
 
-// callBiddersWithFPD calls all selected bidders in parallel with FPD support
+// callBiddersWithFPD calls all selected bidders in parallel with FPD support,
+// sending each the floors dynFloors resolved for it. Bidders in stripPII get
+// their copy of the request without personal data. reqBidders resolves the
+// request's aliases and the global params each bidder gets on every imp.
 // P0-1: Uses sync.Map for thread-safe result collection
-// P0-4: Uses semaphore to limit concurrent bidder goroutines
-func (e *Exchange) callBiddersWithFPD(ctx context.Context, req *openrtb.BidRequest, bidders []string, timeout time.Duration, bidderFPD fpd.BidderFPD) map[string]*BidderResult {
+// Calls run on the shared, bounded bidder pool
+func (e *Exchange) callBiddersWithFPD(ctx context.Context, req *openrtb.BidRequest, bidders []string, timeout time.Duration, bidderFPD fpd.BidderFPD, dynFloors *auctionFloors, stripPII map[string]bool, reqBidders requestBidders) map[string]*BidderResult {
 	var results sync.Map // P0-1: Thread-safe map for concurrent writes
 	var wg sync.WaitGroup
 
@@ -1021,73 +1858,181 @@ func (e *Exchange) callBiddersWithFPD(ctx context.Context, req *openrtb.BidReque
 	dynamicRegistry := e.dynamicRegistry
 	e.configMu.RUnlock()
 
-	// P0-4: Create semaphore to limit concurrent bidder calls
-	maxConcurrent := e.config.MaxConcurrentBidders
-	if maxConcurrent <= 0 {
-		maxConcurrent = 10 // Default limit
+	// Bidders get a context that early exit can cancel once every imp is
+	// covered, and a partial response once enough bidders have responded
+	ctx, exit := e.startEarlyExit(ctx, req, timeout, dynFloors)
+	defer exit.stop()
+
+	// Bidder calls run on the shared worker pool. A call whose auction is done
+	// before it gets a worker is given up on, whichever of the two claims it
+	// first, and reported as timed out without waiting for the worker.
+	callBidder := func(code string, call func()) {
+		timedOut := func() {
+			result := &BidderResult{BidderCode: code, TimedOut: true}
+			result.addErrors(BidderErrorTransport, ctx.Err())
+			exit.observe(result)
+			results.Store(code, result)
+		}
+		var claimed atomic.Bool
+		wg.Add(1)
+		exit.calling()
+		stop := context.AfterFunc(ctx, func() {
+			if claimed.CompareAndSwap(false, true) {
+				timedOut()
+				wg.Done()
+			}
+		})
+		// A call that can't be queued before ctx is done is left to AfterFunc
+		e.bidderPool.submit(ctx, func() {
+			if !claimed.CompareAndSwap(false, true) {
+				return
+			}
+			stop()
+			defer wg.Done()
+			if ctx.Err() != nil {
+				timedOut()
+				return
+			}
+			call()
+			_, responded := results.Load(code)
+			exit.finished(responded)
+		})
+	}
+
+	// Bidders that don't declare audio support only see the rest of the request
+	hasAudio := hasAudioImp(req)
+	isApp := req.App != nil
+	skipForAudio := func(code string, info adapters.BidderInfo, bidderReq *openrtb.BidRequest) bool {
+		if !hasAudio || info.SupportsMediaType(isApp, adapters.BidTypeAudio) {
+			return false
+		}
+		if stripAudio(bidderReq) > 0 {
+			return false
+		}
+		logger.Log.Debug().
+			Str("bidder", code).
+			Str("requestID", req.ID).
+			Msg("skipping bidder without audio support for audio-only request")
+		return true
+	}
+
+	// Bidders only see the interstitial and rewarded imps they buy
+	skipForInventory := func(code string, info adapters.BidderInfo, bidderReq *openrtb.BidRequest) bool {
+		if stripUnsupportedInventory(bidderReq, info) > 0 {
+			return false
+		}
+		logger.Log.Debug().
+			Str("bidder", code).
+			Str("requestID", req.ID).
+			Msg("skipping bidder that doesn't buy the request's interstitial or rewarded inventory")
+		return true
+	}
+
+	// With request shaping on, bidders only receive what their capabilities use
+	shape := func(code string, info adapters.BidderInfo, bidderReq *openrtb.BidRequest) (saved int, ok bool) {
+		if !e.config.RequestShaping {
+			return 0, true
+		}
+		saved = shapeRequest(bidderReq, info)
+		if len(bidderReq.Imp) == 0 {
+			logger.Log.Debug().
+				Str("bidder", code).
+				Str("requestID", req.ID).
+				Msg("skipping bidder that supports none of the request's imps")
+			return 0, false
+		}
+		return saved, true
+	}
+
+	// Imps whose params don't match the bidder's schema are dropped with an
+	// error; a bidder left with no imps isn't called. The request's global
+	// params are merged in first, and an alias's params are then moved to
+	// where its bidder's adapter reads them.
+	checkParams := func(code string, info adapters.BidderInfo, bidderReq *openrtb.BidRequest) (errs []error, ok bool) {
+		mergeBidderParams(bidderReq, code, reqBidders.params[code])
+		errs = validateBidderParams(bidderReq, code, info)
+		if len(bidderReq.Imp) == 0 {
+			result := &BidderResult{BidderCode: code, Selected: true}
+			result.addErrors(BidderErrorInput, errs...)
+			results.Store(code, result)
+			return nil, false
+		}
+		if core := reqBidders.aliases.core(code); core != code {
+			aliasImpParams(bidderReq, code, core)
+		}
+		return errs, true
 	}
-	sem := make(chan struct{}, maxConcurrent)
 
 	for _, bidderCode := range bidders {
+		// Aliases call their bidder's adapter
+		coreCode := reqBidders.aliases.core(bidderCode)
+
 		// Try static registry first
-		adapterWithInfo, ok := e.registry.Get(bidderCode)
+		adapterWithInfo, ok := e.registry.Get(coreCode)
 		if ok {
-			wg.Add(1)
-			go func(code string, awi adapters.AdapterWithInfo) {
-				defer wg.Done()
-
-				// P0-4: Acquire semaphore (blocks if at capacity)
-				select {
-				case sem <- struct{}{}:
-					defer func() { <-sem }() // Release on completion
-				case <-ctx.Done():
-					// Context cancelled while waiting for semaphore
-					results.Store(code, &BidderResult{
-						BidderCode: code,
-						Errors:     []error{ctx.Err()},
-						TimedOut:   true,
-					})
-					return
-				}
-
+			if coreCode != bidderCode {
+				adapterWithInfo.Adapter = &aliasedAdapter{Adapter: adapterWithInfo.Adapter, core: coreCode}
+			}
+			code, awi := bidderCode, adapterWithInfo
+			callBidder(code, func() {
 				// Clone request and apply bidder-specific FPD
 				bidderReq := e.cloneRequestWithFPD(req, code, bidderFPD)
+				dynFloors.signal(bidderReq, code)
+				if stripPII[code] {
+					middleware.ScrubPersonalData(bidderReq)
+				}
+				filterBidderEIDs(bidderReq, awi.Info)
+				paramErrs, ok := checkParams(code, awi.Info, bidderReq)
+				if !ok {
+					return
+				}
+				if skipForAudio(code, awi.Info, bidderReq) || skipForInventory(code, awi.Info, bidderReq) {
+					return
+				}
+				saved, ok := shape(code, awi.Info, bidderReq)
+				if !ok {
+					return
+				}
 
-				result := e.callBidder(ctx, bidderReq, code, awi.Adapter, timeout)
+				result := e.callBidderChunked(ctx, bidderReq, code, awi.Adapter, e.bidderTimeout(code, timeout), awi.Info.MaxImpsPerRequest)
+				result.addErrors(BidderErrorInput, paramErrs...)
+				result.BytesSaved += saved
+				exit.observe(result)
+				e.recordBidderLatency(result, timeout)
 
 				results.Store(code, result) // P0-1: Thread-safe store
-			}(bidderCode, adapterWithInfo)
+			})
 			continue
 		}
 
 		// Try dynamic registry (using snapshotted reference)
 		if dynamicRegistry != nil {
-			dynamicAdapter, found := dynamicRegistry.Get(bidderCode)
+			dynamicAdapter, found := dynamicRegistry.Get(coreCode)
 			if found {
-				wg.Add(1)
-				go func(code string, da *ortb.GenericAdapter) {
-					defer wg.Done()
-
-					// P0-4: Acquire semaphore (blocks if at capacity)
-					select {
-					case sem <- struct{}{}:
-						defer func() { <-sem }() // Release on completion
-					case <-ctx.Done():
-						// Context cancelled while waiting for semaphore
-						results.Store(code, &BidderResult{
-							BidderCode: code,
-							Errors:     []error{ctx.Err()},
-							TimedOut:   true,
-						})
-						return
-					}
-
+				code, da := bidderCode, dynamicAdapter
+				callBidder(code, func() {
 					// Clone request and apply bidder-specific FPD
 					bidderReq := e.cloneRequestWithFPD(req, code, bidderFPD)
+					dynFloors.signal(bidderReq, code)
+					if stripPII[code] {
+						middleware.ScrubPersonalData(bidderReq)
+					}
+					filterBidderEIDs(bidderReq, da.Info())
+					paramErrs, ok := checkParams(code, da.Info(), bidderReq)
+					if !ok {
+						return
+					}
+					if skipForAudio(code, da.Info(), bidderReq) || skipForInventory(code, da.Info(), bidderReq) {
+						return
+					}
+					saved, ok := shape(code, da.Info(), bidderReq)
+					if !ok {
+						return
+					}
 
 					// P1-4: Use dynamic adapter's timeout with validation bounds
 					// P2-4: Always validate bounds, then use smaller of dynamic or parent timeout
-					bidderTimeout := timeout
+					bidderTimeout := e.bidderTimeout(code, timeout)
 					if da.GetTimeout() > 0 {
 						dynamicTimeout := da.GetTimeout()
 						// Enforce minimum timeout to prevent crashes
@@ -1104,14 +2049,28 @@ func (e *Exchange) callBiddersWithFPD(ctx context.Context, req *openrtb.BidReque
 						}
 					}
 
-					result := e.callBidder(ctx, bidderReq, code, da, bidderTimeout)
+					// Third-party configs may opt in (or be forced) into the adapter sandbox
+					var adapter adapters.Adapter = da
+					if e.config.Sandbox.Enabled || da.IsSandboxed() {
+						adapter = e.sandbox.wrap(code, da)
+					}
+					if core := reqBidders.aliases.core(code); core != code {
+						adapter = &aliasedAdapter{Adapter: adapter, core: core}
+					}
+
+					result := e.callBidderChunked(ctx, bidderReq, code, adapter, bidderTimeout, da.GetMaxImpsPerRequest())
+					result.addErrors(BidderErrorInput, paramErrs...)
+					result.BytesSaved += saved
+					exit.observe(result)
+					e.recordBidderLatency(result, timeout)
 
 					results.Store(code, result) // P0-1: Thread-safe store
-				}(bidderCode, dynamicAdapter)
+				})
 			}
 		}
 	}
 
+	exit.callsStarted()
 	wg.Wait()
 
 	// P0-1: Convert sync.Map to regular map for return
@@ -1123,23 +2082,25 @@ func (e *Exchange) callBiddersWithFPD(ctx context.Context, req *openrtb.BidReque
 	return finalResults
 }
 
-// cloneRequestWithFPD creates a deep copy of the request with bidder-specific FPD applied
+// cloneRequestWithFPD creates a copy-on-write copy of the request with bidder-specific FPD applied
 // and enforces USD currency for all bid requests
 func (e *Exchange) cloneRequestWithFPD(req *openrtb.BidRequest, bidderCode string, bidderFPD fpd.BidderFPD) *openrtb.BidRequest {
 	// Always clone to ensure thread safety and allow currency normalization
-	clone := deepCloneRequest(req, e.config.CloneLimits)
+	clone := cowCloneRequest(req, e.config.CloneLimits)
 
 	// Enforce USD currency on all outgoing requests
 	// This ensures all bidders compete in the same currency without needing forex conversion
 	clone.Cur = []string{e.config.DefaultCurrency}
 
-	// Normalize bid floor currencies to USD
+	// Normalize bid floors to USD, converting them when rates are configured.
+	// Without rates the currency is relabelled - publishers should specify floors in USD
+	conv := e.currencyConverter()
 	for i := range clone.Imp {
-		if clone.Imp[i].BidFloorCur == "" || clone.Imp[i].BidFloorCur != e.config.DefaultCurrency {
-			// If floor currency differs from USD and we had conversion, we'd convert here
-			// For now, we just set the currency - publishers should specify floors in USD
-			clone.Imp[i].BidFloorCur = e.config.DefaultCurrency
+		imp := &clone.Imp[i]
+		if floor, err := floors.Resolve(imp.BidFloor, imp.BidFloorCur, e.config.DefaultCurrency, conv); err == nil && imp.BidFloor > 0 {
+			imp.BidFloor = floor
 		}
+		imp.BidFloorCur = e.config.DefaultCurrency
 	}
 
 	// Apply FPD if available
@@ -1151,198 +2112,55 @@ func (e *Exchange) cloneRequestWithFPD(req *openrtb.BidRequest, bidderCode strin
 		}
 	}
 
+	// Identify the engine in ext.prebid.server so partners can attribute S2S traffic
+	if id := e.config.Identification; id != nil {
+		clone.Ext = stampServerExt(clone.Ext, id)
+	}
+
 	return clone
 }
 
-// deepCloneRequest creates a deep copy of the BidRequest to avoid race conditions
-// when multiple bidders modify request data concurrently
-// P3-1: Uses configurable limits to bound allocations
-func deepCloneRequest(req *openrtb.BidRequest, limits *CloneLimits) *openrtb.BidRequest {
-	clone := *req
-
-	// P1-NEW-2: Deep copy top-level string slices to prevent shared references
-	if len(req.Cur) > 0 {
-		clone.Cur = make([]string, len(req.Cur))
-		copy(clone.Cur, req.Cur)
-	}
-	if len(req.WSeat) > 0 {
-		clone.WSeat = make([]string, len(req.WSeat))
-		copy(clone.WSeat, req.WSeat)
-	}
-	if len(req.BSeat) > 0 {
-		clone.BSeat = make([]string, len(req.BSeat))
-		copy(clone.BSeat, req.BSeat)
-	}
-	if len(req.WLang) > 0 {
-		clone.WLang = make([]string, len(req.WLang))
-		copy(clone.WLang, req.WLang)
-	}
-	if len(req.BCat) > 0 {
-		clone.BCat = make([]string, len(req.BCat))
-		copy(clone.BCat, req.BCat)
-	}
-	if len(req.BAdv) > 0 {
-		clone.BAdv = make([]string, len(req.BAdv))
-		copy(clone.BAdv, req.BAdv)
-	}
-	if len(req.BApp) > 0 {
-		clone.BApp = make([]string, len(req.BApp))
-		copy(clone.BApp, req.BApp)
-	}
-
-	// Deep copy Site
-	if req.Site != nil {
-		siteCopy := *req.Site
-		if req.Site.Publisher != nil {
-			pubCopy := *req.Site.Publisher
-			siteCopy.Publisher = &pubCopy
-		}
-		if req.Site.Content != nil {
-			contentCopy := *req.Site.Content
-			// P2-5: Clone and limit Content.Data segments
-			if len(req.Site.Content.Data) > 0 {
-				dataCount := len(req.Site.Content.Data)
-				if dataCount > limits.MaxDataPerUser {
-					dataCount = limits.MaxDataPerUser
-				}
-				contentCopy.Data = make([]openrtb.Data, dataCount)
-				copy(contentCopy.Data, req.Site.Content.Data[:dataCount])
-			}
-			siteCopy.Content = &contentCopy
+// stampServerExt sets ext.prebid.server.name/version, preserving all other ext fields.
+// Unparseable ext is returned unchanged rather than dropped.
+func stampServerExt(ext json.RawMessage, id *adapters.Identification) json.RawMessage {
+	root := make(map[string]json.RawMessage)
+	if len(ext) > 0 {
+		if err := jsoncodec.Unmarshal(ext, &root); err != nil {
+			return ext
 		}
-		clone.Site = &siteCopy
 	}
 
-	// Deep copy App
-	if req.App != nil {
-		appCopy := *req.App
-		if req.App.Publisher != nil {
-			pubCopy := *req.App.Publisher
-			appCopy.Publisher = &pubCopy
-		}
-		if req.App.Content != nil {
-			contentCopy := *req.App.Content
-			// P2-5: Clone and limit Content.Data segments
-			if len(req.App.Content.Data) > 0 {
-				dataCount := len(req.App.Content.Data)
-				if dataCount > limits.MaxDataPerUser {
-					dataCount = limits.MaxDataPerUser
-				}
-				contentCopy.Data = make([]openrtb.Data, dataCount)
-				copy(contentCopy.Data, req.App.Content.Data[:dataCount])
-			}
-			appCopy.Content = &contentCopy
+	prebid := make(map[string]json.RawMessage)
+	if raw, ok := root["prebid"]; ok {
+		if err := jsoncodec.Unmarshal(raw, &prebid); err != nil {
+			return ext
 		}
-		clone.App = &appCopy
 	}
 
-	// Deep copy User
-	if req.User != nil {
-		userCopy := *req.User
-		if req.User.Geo != nil {
-			geoCopy := *req.User.Geo
-			userCopy.Geo = &geoCopy
-		}
-		// Deep copy EIDs slice (P1-3: bounded allocation)
-		if len(req.User.EIDs) > 0 {
-			eidCount := len(req.User.EIDs)
-			if eidCount > limits.MaxEIDsPerUser {
-				eidCount = limits.MaxEIDsPerUser
-			}
-			userCopy.EIDs = make([]openrtb.EID, eidCount)
-			copy(userCopy.EIDs, req.User.EIDs[:eidCount])
-		}
-		// Deep copy Data slice (P1-3: bounded allocation)
-		if len(req.User.Data) > 0 {
-			dataCount := len(req.User.Data)
-			if dataCount > limits.MaxDataPerUser {
-				dataCount = limits.MaxDataPerUser
-			}
-			userCopy.Data = make([]openrtb.Data, dataCount)
-			copy(userCopy.Data, req.User.Data[:dataCount])
+	server := make(map[string]json.RawMessage)
+	if raw, ok := prebid["server"]; ok {
+		if err := jsoncodec.Unmarshal(raw, &server); err != nil {
+			return ext
 		}
-		clone.User = &userCopy
 	}
 
-	// Deep copy Device
-	if req.Device != nil {
-		deviceCopy := *req.Device
-		if req.Device.Geo != nil {
-			geoCopy := *req.Device.Geo
-			deviceCopy.Geo = &geoCopy
-		}
-		clone.Device = &deviceCopy
-	}
-
-	// Deep copy Regs
-	if req.Regs != nil {
-		regsCopy := *req.Regs
-		clone.Regs = &regsCopy
-	}
-
-	// Deep copy Source
-	if req.Source != nil {
-		sourceCopy := *req.Source
-		if req.Source.SChain != nil {
-			schainCopy := *req.Source.SChain
-			// P1-3: bounded allocation for supply chain nodes
-			if len(req.Source.SChain.Nodes) > 0 {
-				nodeCount := len(req.Source.SChain.Nodes)
-				if nodeCount > limits.MaxSChainNodes {
-					nodeCount = limits.MaxSChainNodes
-				}
-				schainCopy.Nodes = make([]openrtb.SupplyChainNode, nodeCount)
-				copy(schainCopy.Nodes, req.Source.SChain.Nodes[:nodeCount])
-			}
-			sourceCopy.SChain = &schainCopy
-		}
-		clone.Source = &sourceCopy
-	}
+	name, _ := jsoncodec.Marshal(id.Name)
+	version, _ := jsoncodec.Marshal(id.Version)
+	server["name"] = name
+	server["version"] = version
 
-	// Deep copy Imp slice (P1-3: bounded allocation)
-	if len(req.Imp) > 0 {
-		impCount := len(req.Imp)
-		if impCount > limits.MaxImpressionsPerRequest {
-			impCount = limits.MaxImpressionsPerRequest
-		}
-		clone.Imp = make([]openrtb.Imp, impCount)
-		for i := 0; i < impCount; i++ {
-			imp := req.Imp[i]
-			impCopy := imp
-			if imp.Banner != nil {
-				bannerCopy := *imp.Banner
-				impCopy.Banner = &bannerCopy
-			}
-			if imp.Video != nil {
-				videoCopy := *imp.Video
-				impCopy.Video = &videoCopy
-			}
-			if imp.Audio != nil {
-				audioCopy := *imp.Audio
-				impCopy.Audio = &audioCopy
-			}
-			if imp.Native != nil {
-				nativeCopy := *imp.Native
-				impCopy.Native = &nativeCopy
-			}
-			if imp.PMP != nil {
-				pmpCopy := *imp.PMP
-				// P1-3: bounded allocation for deals
-				if len(imp.PMP.Deals) > 0 {
-					dealCount := len(imp.PMP.Deals)
-					if dealCount > limits.MaxDealsPerImp {
-						dealCount = limits.MaxDealsPerImp
-					}
-					pmpCopy.Deals = make([]openrtb.Deal, dealCount)
-					copy(pmpCopy.Deals, imp.PMP.Deals[:dealCount])
-				}
-				impCopy.PMP = &pmpCopy
-			}
-			clone.Imp[i] = impCopy
-		}
+	var err error
+	if prebid["server"], err = jsoncodec.Marshal(server); err != nil {
+		return ext
 	}
-
-	return &clone
+	if root["prebid"], err = jsoncodec.Marshal(prebid); err != nil {
+		return ext
+	}
+	stamped, err := jsoncodec.Marshal(root)
+	if err != nil {
+		return ext
+	}
+	return stamped
 }
 
 // callBidder calls a single bidder
@@ -1353,15 +2171,22 @@ func (e *Exchange) callBidder(ctx context.Context, req *openrtb.BidRequest, bidd
 		Selected:   true,
 	}
 
+	// Aliases report their bidder's code as the core name and share its circuit
+	coreName := bidderCode
+	if aliased, ok := adapter.(*aliasedAdapter); ok {
+		coreName = aliased.core
+	}
+
 	// Build requests
 	extraInfo := &adapters.ExtraRequestInfo{
-		BidderCoreName: bidderCode,
+		BidderCoreName: coreName,
 	}
 
+	// Bidders see the time actually left for them, not the original tmax
+	req.TMax = bidderTMax(ctx, req.TMax, timeout, e.config.TMaxNetworkBuffer)
+
 	requests, errs := adapter.MakeRequests(req, extraInfo)
-	if len(errs) > 0 {
-		result.Errors = append(result.Errors, errs...)
-	}
+	result.addErrors(BidderErrorInput, errs...)
 
 	// P1-NEW-6: Check context after potentially expensive MakeRequests operation
 	select {
@@ -1371,7 +2196,7 @@ func (e *Exchange) callBidder(ctx context.Context, req *openrtb.BidRequest, bidd
 			Str("bidder", bidderCode).
 			Dur("elapsed", time.Since(start)).
 			Msg("bidder timed out after MakeRequests")
-		result.Errors = append(result.Errors, ctx.Err())
+		result.addErrors(BidderErrorTransport, ctx.Err())
 		result.Latency = time.Since(start)
 		result.TimedOut = true
 		return result
@@ -1383,92 +2208,54 @@ func (e *Exchange) callBidder(ctx context.Context, req *openrtb.BidRequest, bidd
 		result.Latency = time.Since(start)
 		return result
 	}
-
-	// Execute requests (could parallelize for multi-request adapters)
-	allBids := make([]*adapters.TypedBid, 0)
 	for _, reqData := range requests {
-		// Check if context has expired before each request to avoid wasted work
-		select {
-		case <-ctx.Done():
-			result.Errors = append(result.Errors, ctx.Err())
-			result.Latency = time.Since(start)
-			result.TimedOut = true // P2-2: mark as timed out
-			return result
-		default:
-			// Context still valid, proceed with request
-		}
+		result.RequestBytes += len(reqData.Body)
+	}
 
-		// Handle mock requests (e.g., demo adapter) - use request body as response
-		var resp *adapters.ResponseData
-		if reqData.Method == "MOCK" {
-			resp = &adapters.ResponseData{
-				StatusCode: 200,
-				Body:       reqData.Body,
-				Headers:    reqData.Headers,
-			}
-		} else {
-			var err error
-			resp, err = e.httpClient.Do(ctx, reqData, timeout)
-			if err != nil {
-				// P3-1: Log HTTP request failures with context
-				isTimeout := err == context.DeadlineExceeded || err == context.Canceled
-				logger.Log.Debug().
-					Str("bidder", bidderCode).
-					Str("uri", reqData.URI).
-					Dur("elapsed", time.Since(start)).
-					Bool("timeout", isTimeout).
-					Err(err).
-					Msg("bidder HTTP request failed")
-				result.Errors = append(result.Errors, err)
-				// P2-2: Check if this was a timeout error
-				if isTimeout {
-					result.TimedOut = true
-				}
-				continue
-			}
+	// Multi-request adapters' calls run in parallel, up to
+	// MaxParallelBidderRequests at a time, and are combined in request order
+	outcomes := make([]requestOutcome, len(requests))
+	send := func(i int) {
+		outcomes[i] = e.sendBidderRequest(ctx, req, requests[i], bidderCode, coreName, adapter, timeout, start)
+	}
+	if parallel := min(e.config.MaxParallelBidderRequests, len(requests)); parallel <= 1 {
+		for i := range requests {
+			send(i)
 		}
-
-		bidderResp, errs := adapter.MakeBids(req, resp)
-		if len(errs) > 0 {
-			result.Errors = append(result.Errors, errs...)
+	} else {
+		slots := make(chan struct{}, parallel)
+		var wg sync.WaitGroup
+		for i := range requests {
+			slots <- struct{}{}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-slots }()
+				send(i)
+			}()
 		}
+		wg.Wait()
+	}
 
-		if bidderResp != nil {
-			// P2-5: Validate BidResponse.ID matches BidRequest.ID (OpenRTB 2.x requirement)
-			// Per spec, response ID must echo request ID - reject on mismatch
-			if bidderResp.ResponseID != "" && bidderResp.ResponseID != req.ID {
-				result.Errors = append(result.Errors, fmt.Errorf(
-					"response ID mismatch from %s: expected %q, got %q (bids rejected)",
-					bidderCode, req.ID, bidderResp.ResponseID,
-				))
-				continue // Reject all bids from this response
-			}
-
-			// P1-NEW-3: Normalize and validate response currency
-			// Per OpenRTB 2.5 spec section 7.2, empty currency means USD
-			responseCurrency := bidderResp.Currency
-			if responseCurrency == "" {
-				responseCurrency = "USD" // OpenRTB 2.5 default
-			}
-
-			// P1-NEW-4: Defensive check for exchange currency misconfiguration
-			// Normalize exchange currency to USD if empty to prevent silent validation bypass
-			exchangeCurrency := e.config.DefaultCurrency
-			if exchangeCurrency == "" {
-				exchangeCurrency = "USD" // Fallback if misconfigured
-			}
-
-			if responseCurrency != exchangeCurrency {
-				result.Errors = append(result.Errors, fmt.Errorf(
-					"currency mismatch from %s: expected %s, got %s (bids rejected)",
-					bidderCode, exchangeCurrency, responseCurrency,
-				))
-				// Skip bids with wrong currency - can't safely compare prices
-				continue
-			}
-
-			allBids = append(allBids, bidderResp.Bids...)
+	allBids := make([]*adapters.TypedBid, 0)
+	expired := false
+	for _, o := range outcomes {
+		if o.expired {
+			expired = true
+			continue
+		}
+		if o.httpCall != nil {
+			result.HTTPCalls = append(result.HTTPCalls, *o.httpCall)
 		}
+		result.addErrors(BidderErrorTransport, o.transportErrs...)
+		result.addErrors(BidderErrorResponse, o.responseErrs...)
+		result.TimedOut = result.TimedOut || o.timedOut
+		allBids = append(allBids, o.bids...)
+	}
+	// Requests not sent because the bidder's time ran out; bids from the others are kept
+	if expired {
+		result.addErrors(BidderErrorTransport, ctx.Err())
+		result.TimedOut = true // P2-2: mark as timed out
 	}
 
 	result.Bids = allBids
@@ -1476,6 +2263,122 @@ func (e *Exchange) callBidder(ctx context.Context, req *openrtb.BidRequest, bidd
 	return result
 }
 
+// requestOutcome is what one of a bidder call's HTTP requests returned
+type requestOutcome struct {
+	bids          []*adapters.TypedBid
+	transportErrs []error
+	responseErrs  []error
+	timedOut      bool
+	expired       bool // Not sent; the bidder's time had run out
+	httpCall      *openrtb.ExtHTTPCall
+}
+
+// sendBidderRequest makes one of a bidder call's HTTP requests and turns the
+// response into bids. It may run alongside the call's other requests.
+func (e *Exchange) sendBidderRequest(ctx context.Context, req *openrtb.BidRequest, reqData *adapters.RequestData, bidderCode, coreName string, adapter adapters.Adapter, timeout time.Duration, start time.Time) (o requestOutcome) {
+	// Check if context has expired before each request to avoid wasted work
+	if ctx.Err() != nil {
+		o.expired = true
+		return o
+	}
+
+	// Handle mock requests (e.g., demo adapter) - use request body as response
+	var resp *adapters.ResponseData
+	if reqData.Method == "MOCK" {
+		resp = &adapters.ResponseData{
+			StatusCode: 200,
+			Body:       reqData.Body,
+			Headers:    reqData.Headers,
+		}
+	} else {
+		var err error
+		callCtx, callSpan := tracing.Start(ctx, "bidder.request", tracing.SpanKindClient)
+		resp, err = e.httpClient.Do(callCtx, reqData, timeout)
+		endBidderSpan(callSpan, bidderCode, reqData, resp, err)
+		if e.breakers != nil {
+			status := 0
+			if resp != nil {
+				status = resp.StatusCode
+			}
+			e.breakers.record(coreName, callFailed(status, err))
+		}
+		if capturesHTTPCalls(ctx) {
+			call := newHTTPCall(reqData, resp)
+			o.httpCall = &call
+		}
+		if err != nil {
+			// P3-1: Log HTTP request failures with context
+			isTimeout := err == context.DeadlineExceeded || err == context.Canceled
+			logger.Log.Debug().
+				Str("bidder", bidderCode).
+				Str("uri", reqData.URI).
+				Dur("elapsed", time.Since(start)).
+				Bool("timeout", isTimeout).
+				Err(err).
+				Msg("bidder HTTP request failed")
+			o.transportErrs = append(o.transportErrs, err)
+			// P2-2: Check if this was a timeout error
+			o.timedOut = isTimeout
+			return o
+		}
+	}
+
+	bidderResp, errs := adapter.MakeBids(req, resp)
+	o.responseErrs = append(o.responseErrs, errs...)
+	if bidderResp == nil {
+		return o
+	}
+
+	// P2-5: Validate BidResponse.ID matches BidRequest.ID (OpenRTB 2.x requirement)
+	// Per spec, response ID must echo request ID - reject on mismatch
+	if bidderResp.ResponseID != "" && bidderResp.ResponseID != req.ID {
+		o.responseErrs = append(o.responseErrs, fmt.Errorf(
+			"response ID mismatch from %s: expected %q, got %q (bids rejected)",
+			bidderCode, req.ID, bidderResp.ResponseID,
+		))
+		return o // Reject all bids from this response
+	}
+
+	// P1-NEW-3: Normalize and validate response currency
+	// Per OpenRTB 2.5 spec section 7.2, empty currency means USD
+	responseCurrency := bidderResp.Currency
+	if responseCurrency == "" {
+		responseCurrency = "USD" // OpenRTB 2.5 default
+	}
+
+	// P1-NEW-4: Defensive check for exchange currency misconfiguration
+	// Normalize exchange currency to USD if empty to prevent silent validation bypass
+	exchangeCurrency := e.config.DefaultCurrency
+	if exchangeCurrency == "" {
+		exchangeCurrency = "USD" // Fallback if misconfigured
+	}
+
+	// Bids in another currency are converted when rates are available
+	if responseCurrency != exchangeCurrency {
+		convErr := convertBids(bidderResp.Bids, responseCurrency, exchangeCurrency, e.currencyConverter())
+		if convErr != nil && e.flagEnabled(flags.StrictCurrency) {
+			o.responseErrs = append(o.responseErrs, fmt.Errorf(
+				"currency mismatch from %s: expected %s, got %s (bids rejected): %w",
+				bidderCode, exchangeCurrency, responseCurrency, convErr,
+			))
+			// Skip bids with wrong currency - can't safely compare prices
+			return o
+		}
+		if convErr != nil {
+			// Strict currency relaxed at runtime: accept the bids but keep the mismatch visible
+			logger.Log.Warn().
+				Str("bidder", bidderCode).
+				Str("expected", exchangeCurrency).
+				Str("got", responseCurrency).
+				Err(convErr).
+				Msg("currency mismatch accepted (strict_currency disabled)")
+		}
+	}
+
+	o.bids = bidderResp.Bids
+	return o
+}
+
 // buildEmptyResponse creates an empty bid response with optional NBR code
 // P2-7: Using consolidated NoBidReason type from openrtb package
 func (e *Exchange) buildEmptyResponse(req *openrtb.BidRequest, nbr openrtb.NoBidReason) *openrtb.BidResponse {
@@ -1487,77 +2390,34 @@ func (e *Exchange) buildEmptyResponse(req *openrtb.BidRequest, nbr openrtb.NoBid
 	}
 }
 
-// buildBidExtension creates the Prebid extension for a bid including targeting keys
-// This is required for Prebid.js integration to work correctly
-func (e *Exchange) buildBidExtension(vb ValidatedBid) *openrtb.BidExt {
-	bid := vb.Bid.Bid
+// buildBidExtension creates the Prebid extension for a bid with its targeting
+// keys, which Prebid.js needs to pass the bid to the ad server, and where its
+// creative was cached (nil if it wasn't). passthrough is the bid's imp
+// ext.prebid.passthrough, echoed untouched.
+func (e *Exchange) buildBidExtension(vb ValidatedBid, passthrough json.RawMessage, targeting map[string]string, cached *openrtb.ExtBidPrebidCache) *openrtb.BidExt {
 	bidType := string(vb.Bid.BidType)
 
-	// Generate price bucket using medium granularity
-	priceBucket := formatPriceBucket(bid.Price)
-
-	// Determine display bidder code based on demand type:
-	// - Platform demand: use "thenexusengine" (obfuscated)
-	// - Publisher demand: use original bidder code (transparent)
-	displayBidderCode := vb.BidderCode
-	if vb.DemandType != adapters.DemandTypePublisher {
-		displayBidderCode = adapters.PlatformSeatName // "thenexusengine"
-	}
-
-	// Build targeting keys that Prebid.js expects
-	targeting := map[string]string{
-		"hb_pb":     priceBucket,
-		"hb_bidder": displayBidderCode,
-		"hb_size":   fmt.Sprintf("%dx%d", bid.W, bid.H),
-		"hb_pb_" + displayBidderCode:     priceBucket,
-		"hb_bidder_" + displayBidderCode: displayBidderCode,
-		"hb_size_" + displayBidderCode:   fmt.Sprintf("%dx%d", bid.W, bid.H),
-	}
-
-	// Add deal ID if present
-	if bid.DealID != "" {
-		targeting["hb_deal"] = bid.DealID
-		targeting["hb_deal_"+displayBidderCode] = bid.DealID
+	var video *openrtb.ExtBidPrebidVideo
+	if vb.VideoDuration > 0 {
+		video = &openrtb.ExtBidPrebidVideo{Duration: vb.VideoDuration}
 	}
 
 	return &openrtb.BidExt{
 		Prebid: &openrtb.ExtBidPrebid{
+			Cache:     cached,
 			Type:      bidType,
 			Targeting: targeting,
+			Video:     video,
 			Meta: &openrtb.ExtBidPrebidMeta{
 				MediaType: bidType,
 			},
+			Passthrough: passthrough,
 		},
+		OrigBidCPM: vb.Bid.OriginalCPM,
+		OrigBidCur: vb.Bid.OriginalCurrency,
 	}
 }
 
-// formatPriceBucket formats price using medium granularity (per Prebid.js spec)
-// - $0.01 increments up to $5
-// - $0.05 increments from $5-$10
-// - $0.50 increments from $10-$20
-// - Caps at $20
-func formatPriceBucket(price float64) string {
-	if price <= 0 {
-		return "0.00"
-	}
-	if price > 20 {
-		price = 20
-	}
-
-	var bucket float64
-	switch {
-	case price <= 5:
-		bucket = float64(int(price*100)) / 100 // $0.01 increments
-	case price <= 10:
-		bucket = float64(int(price*20)) / 20 // $0.05 increments
-	case price <= 20:
-		bucket = float64(int(price*2)) / 2 // $0.50 increments
-	default:
-		bucket = 20
-	}
-	return fmt.Sprintf("%.2f", bucket)
-}
-
 // buildMinimalIDRRequest extracts only essential fields for IDR partner selection
 // P1-15: Significantly reduces payload size vs sending full OpenRTB request
 func (e *Exchange) buildMinimalIDRRequest(req *openrtb.BidRequest) *idr.MinimalRequest {
@@ -1691,6 +2551,11 @@ func (e *Exchange) GetIDRClient() *idr.Client {
 	return e.idrClient
 }
 
+// GetEventRecorder returns the IDR event recorder, or nil when event recording is off
+func (e *Exchange) GetEventRecorder() *idr.EventRecorder {
+	return e.eventRecorder
+}
+
 // getDemandType returns the demand type for a bidder (platform or publisher)
 // Platform demand is obfuscated under "thenexusengine" seat, publisher demand is transparent
 // Checks static registry first, then dynamic registry, defaults to platform
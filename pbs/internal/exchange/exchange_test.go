@@ -5,12 +5,15 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/flags"
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/fpd"
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/currency"
 )
 
 // mockAdapter implements adapters.Adapter for testing
@@ -19,6 +22,7 @@ type mockAdapter struct {
 	makeErr  error
 	bidsErr  error
 	requests []*adapters.RequestData
+	currency string // Response currency ("" = USD)
 }
 
 func (m *mockAdapter) MakeRequests(request *openrtb.BidRequest, reqInfo *adapters.ExtraRequestInfo) ([]*adapters.RequestData, []error) {
@@ -43,7 +47,8 @@ func (m *mockAdapter) MakeBids(internalRequest *openrtb.BidRequest, response *ad
 		return nil, []error{m.bidsErr}
 	}
 	return &adapters.BidderResponse{
-		Bids: m.bids,
+		Bids:     m.bids,
+		Currency: m.currency,
 	}, nil
 }
 
@@ -803,7 +808,7 @@ func TestBuildImpFloorMap(t *testing.T) {
 		},
 	}
 
-	floors := buildImpFloorMap(req)
+	floors := buildImpFloorMap(req, "USD", nil)
 
 	if len(floors) != 3 {
 		t.Errorf("expected 3 floor entries, got %d", len(floors))
@@ -1056,9 +1061,16 @@ func TestValidateConfig(t *testing.T) {
 			check:  func(c *Config) bool { return c.MaxBidders > 0 },
 		},
 		{
-			name:   "negative max concurrent uses default",
-			config: &Config{MaxConcurrentBidders: -1},
-			check:  func(c *Config) bool { return c.MaxConcurrentBidders >= 0 },
+			name:   "negative bidder pool size uses default",
+			config: &Config{BidderPool: &BidderPoolConfig{Workers: -1}},
+			check: func(c *Config) bool {
+				return c.BidderPool.Workers == defaultBidderPoolWorkers && c.BidderPool.QueueSize == defaultBidderPoolQueueSize
+			},
+		},
+		{
+			name:   "nil bidder pool uses default",
+			config: &Config{},
+			check:  func(c *Config) bool { return c.BidderPool != nil && c.BidderPool.Workers > 0 },
 		},
 		{
 			name:   "invalid auction type uses first price",
@@ -1752,6 +1764,157 @@ func ptrInt(v int) *int {
 
 // Benchmark tests
 
+func TestStampServerExt(t *testing.T) {
+	id := &adapters.Identification{Name: "thenexusengine", Version: "1.2.3"}
+
+	tests := []struct {
+		name string
+		ext  string
+	}{
+		{"empty", ""},
+		{"existing prebid", `{"prebid":{"debug":true},"custom":1}`},
+		{"existing server", `{"prebid":{"server":{"datacenter":"eu"}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := stampServerExt(json.RawMessage(tt.ext), id)
+
+			var parsed struct {
+				Prebid struct {
+					Debug  bool              `json:"debug"`
+					Server map[string]string `json:"server"`
+				} `json:"prebid"`
+				Custom int `json:"custom"`
+			}
+			if err := json.Unmarshal(out, &parsed); err != nil {
+				t.Fatalf("invalid ext: %v (%s)", err, out)
+			}
+			if parsed.Prebid.Server["name"] != "thenexusengine" || parsed.Prebid.Server["version"] != "1.2.3" {
+				t.Errorf("expected server identification, got %v", parsed.Prebid.Server)
+			}
+			if tt.name == "existing prebid" && (!parsed.Prebid.Debug || parsed.Custom != 1) {
+				t.Errorf("expected existing fields preserved, got %s", out)
+			}
+			if tt.name == "existing server" && parsed.Prebid.Server["datacenter"] != "eu" {
+				t.Errorf("expected existing server fields preserved, got %s", out)
+			}
+		})
+	}
+}
+
+func TestStampServerExt_InvalidExtUnchanged(t *testing.T) {
+	ext := json.RawMessage(`not json`)
+	out := stampServerExt(ext, &adapters.Identification{Name: "x", Version: "1"})
+	if string(out) != "not json" {
+		t.Errorf("expected invalid ext to be returned unchanged, got %s", out)
+	}
+}
+
+func TestCloneRequestWithFPD_StampsIdentification(t *testing.T) {
+	ex := New(adapters.NewRegistry(), DefaultConfig())
+	req := &openrtb.BidRequest{ID: "req1", Imp: []openrtb.Imp{{ID: "imp1"}}}
+
+	clone := ex.cloneRequestWithFPD(req, "bidder1", nil)
+	if len(req.Ext) != 0 {
+		t.Error("expected original request ext to be untouched")
+	}
+	if !json.Valid(clone.Ext) || !strings.Contains(string(clone.Ext), `"server"`) {
+		t.Errorf("expected ext.prebid.server on clone, got %s", clone.Ext)
+	}
+}
+
+func TestFloorCurrencyConversion(t *testing.T) {
+	rates, _ := currency.NewRates("USD", map[string]float64{"EUR": 0.8})
+	config := DefaultConfig()
+	config.CurrencyConv = true
+	config.CurrencyRates = rates
+	ex := New(adapters.NewRegistry(), config)
+	req := &openrtb.BidRequest{ID: "req1", Imp: []openrtb.Imp{
+		{ID: "imp1", BidFloor: 2, BidFloorCur: "EUR"},
+		{ID: "imp2", BidFloor: 1, BidFloorCur: "JPY"}, // No rate: kept as sent
+	}}
+
+	impFloors := buildImpFloorMap(req, "USD", ex.currencyConverter())
+	if impFloors["imp1"] != 2.5 || impFloors["imp2"] != 1 {
+		t.Errorf("unexpected floors %v", impFloors)
+	}
+
+	clone := ex.cloneRequestWithFPD(req, "bidder1", nil)
+	if clone.Imp[0].BidFloor != 2.5 || clone.Imp[0].BidFloorCur != "USD" || clone.Imp[1].BidFloor != 1 || clone.Imp[1].BidFloorCur != "USD" {
+		t.Errorf("expected floors converted to USD, got %+v", clone.Imp)
+	}
+	if req.Imp[0].BidFloor != 2 || req.Imp[0].BidFloorCur != "EUR" {
+		t.Error("expected the original request to be untouched")
+	}
+
+	config.CurrencyConv = false
+	if impFloors := buildImpFloorMap(req, "USD", ex.currencyConverter()); impFloors["imp1"] != 2 {
+		t.Errorf("expected floors as sent with conversion off, got %v", impFloors)
+	}
+}
+
+func TestBidValidation_RuntimeFlags(t *testing.T) {
+	ex := New(adapters.NewRegistry(), &Config{
+		DefaultTimeout:  100 * time.Millisecond,
+		DefaultCurrency: "USD",
+	})
+	reg := flags.NewRegistry()
+	ex.SetFlags(reg)
+	ctx := context.Background()
+
+	impFloors := map[string]float64{"imp1": 1.00}
+	belowFloor := &openrtb.Bid{ID: "b1", ImpID: "imp1", Price: 0.50, AdM: "<div/>"}
+	noCreative := &openrtb.Bid{ID: "b2", ImpID: "imp1", Price: 2.00}
+
+	if ex.validateBid(belowFloor, "bidder", impFloors) == nil {
+		t.Error("expected below-floor bid rejected by default")
+	}
+	if ex.validateBid(noCreative, "bidder", impFloors) == nil {
+		t.Error("expected bid without creative rejected by default")
+	}
+
+	reg.Set(ctx, flags.FloorEnforcement, false, 0, "test", "")
+	reg.Set(ctx, flags.EnforceCreative, false, 0, "test", "")
+
+	if err := ex.validateBid(belowFloor, "bidder", impFloors); err != nil {
+		t.Errorf("expected floor check skipped, got %v", err)
+	}
+	if err := ex.validateBid(noCreative, "bidder", impFloors); err != nil {
+		t.Errorf("expected creative check skipped, got %v", err)
+	}
+}
+
+func TestValidateDeal(t *testing.T) {
+	req := &openrtb.BidRequest{
+		Imp: []openrtb.Imp{
+			{ID: "imp1", PMP: &openrtb.PMP{Deals: []openrtb.Deal{{ID: "deal-a"}}}},
+			{ID: "imp2"},
+		},
+	}
+	impDeals := buildImpDealMap(req)
+
+	tests := []struct {
+		name    string
+		bid     *openrtb.Bid
+		wantErr bool
+	}{
+		{"open market bid", &openrtb.Bid{ID: "b1", ImpID: "imp2"}, false},
+		{"offered deal", &openrtb.Bid{ID: "b2", ImpID: "imp1", DealID: "deal-a"}, false},
+		{"unknown deal", &openrtb.Bid{ID: "b3", ImpID: "imp1", DealID: "deal-x"}, true},
+		{"deal on imp without pmp", &openrtb.Bid{ID: "b4", ImpID: "imp2", DealID: "deal-a"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateDeal(tt.bid, "bidder", impDeals)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error=%v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
 func BenchmarkDeepCloneRequest(b *testing.B) {
 	limits := DefaultCloneLimits()
 	req := &openrtb.BidRequest{
@@ -0,0 +1,212 @@
+package exchange
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/currency"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/floors"
+)
+
+// auctionFloors are an auction's floors after applying ext.prebid.floors
+// rules, by bidder and imp ID, in the exchange's default currency. A nil
+// *auctionFloors means the request has no rules and imp floors stand as sent.
+type auctionFloors struct {
+	enforce  bool
+	shared   map[string]float64            // Imp ID -> floor, when rules don't vary by bidder
+	byBidder map[string]map[string]float64 // Bidder -> imp ID -> floor, when they do
+	clearing map[string]float64            // Imp ID -> lowest floor any bidder was sent
+}
+
+// parseFloorRules reads ext.prebid.floors from a request ext
+func parseFloorRules(ext json.RawMessage) (*floors.Rules, error) {
+	if len(ext) == 0 {
+		return nil, nil
+	}
+	var parsed struct {
+		Prebid *struct {
+			Floors json.RawMessage `json:"floors"`
+		} `json:"prebid"`
+	}
+	if err := json.Unmarshal(ext, &parsed); err != nil || parsed.Prebid == nil {
+		return nil, nil
+	}
+	return floors.ParseRules(parsed.Prebid.Floors)
+}
+
+// newAuctionFloors resolves the request's floor rules for each imp and
+// bidder. Rules that can't be parsed or converted to cur are ignored with an
+// error, leaving imp floors as sent.
+func newAuctionFloors(req *openrtb.BidRequest, bidders []string, cur string, conv currency.Converter) (*auctionFloors, error) {
+	rules, err := parseFloorRules(req.Ext)
+	if err != nil || rules == nil {
+		return nil, err
+	}
+	rulesCur := rules.Currency
+	if rulesCur == "" {
+		rulesCur = cur
+	}
+	// Rule values share a currency, so one conversion checks them all
+	if _, err := floors.Resolve(1, rulesCur, cur, conv); err != nil {
+		return nil, fmt.Errorf("floors currency: %w", err)
+	}
+	floorMin, _ := floors.Resolve(rules.FloorMin, rulesCur, cur, conv)
+
+	base := buildImpFloorMap(req, cur, conv)
+	impValues := make([]map[string]string, len(req.Imp))
+	for i := range req.Imp {
+		impValues[i] = floorFieldValues(req, &req.Imp[i])
+	}
+	resolve := func(bidder string) map[string]float64 {
+		resolved := make(map[string]float64, len(req.Imp))
+		for i := range req.Imp {
+			imp := &req.Imp[i]
+			floor := base[imp.ID]
+			values := impValues[i]
+			if bidder != "" {
+				values[floors.FieldBidder] = bidder
+			}
+			if ruleFloor, ok := rules.Lookup(values); ok {
+				floor, _ = floors.Resolve(ruleFloor, rulesCur, cur, conv)
+			}
+			resolved[imp.ID] = max(floor, floorMin)
+		}
+		return resolved
+	}
+
+	f := &auctionFloors{enforce: rules.Enforce}
+	if !rules.VariesByBidder() {
+		f.shared = resolve("")
+		f.clearing = f.shared
+		return f, nil
+	}
+	f.byBidder = make(map[string]map[string]float64, len(bidders))
+	f.clearing = make(map[string]float64, len(req.Imp))
+	for _, code := range bidders {
+		resolved := resolve(code)
+		f.byBidder[code] = resolved
+		for impID, floor := range resolved {
+			if lowest, ok := f.clearing[impID]; !ok || floor < lowest {
+				f.clearing[impID] = floor
+			}
+		}
+	}
+	return f, nil
+}
+
+// forBidder returns the floors bidderCode is sent, nil without rules
+func (f *auctionFloors) forBidder(bidderCode string) map[string]float64 {
+	if f == nil {
+		return nil
+	}
+	if f.shared != nil {
+		return f.shared
+	}
+	return f.byBidder[bidderCode]
+}
+
+// signal sets the resolved floors on a bidder's cloned request, whose imp
+// floors are already in the default currency
+func (f *auctionFloors) signal(bidderReq *openrtb.BidRequest, bidderCode string) {
+	resolved := f.forBidder(bidderCode)
+	if resolved == nil {
+		return
+	}
+	for i := range bidderReq.Imp {
+		if floor, ok := resolved[bidderReq.Imp[i].ID]; ok {
+			bidderReq.Imp[i].BidFloor = floor
+		}
+	}
+}
+
+// enforced returns the floors bidderCode's bids are validated against: the
+// resolved floors when the rules enforce them, else base
+func (f *auctionFloors) enforced(bidderCode string, base map[string]float64) map[string]float64 {
+	if f == nil || !f.enforce {
+		return base
+	}
+	if resolved := f.forBidder(bidderCode); resolved != nil {
+		return resolved
+	}
+	return base
+}
+
+// clearingFloors returns the floors a lone bid clears at in a second-price
+// auction: the lowest resolved floor per imp when rules apply, else base
+func (f *auctionFloors) clearingFloors(base map[string]float64) map[string]float64 {
+	if f == nil || len(f.clearing) == 0 {
+		return base
+	}
+	return f.clearing
+}
+
+// floorFieldValues returns an imp's value for each floors schema field it
+// has one for. Imps with several media types or sizes only match wildcards
+// on those fields.
+func floorFieldValues(req *openrtb.BidRequest, imp *openrtb.Imp) map[string]string {
+	values := make(map[string]string, 8)
+	if impMediaTypeCount(imp) == 1 {
+		values[floors.FieldMediaType] = impMediaType(imp)
+	}
+	if size := impSize(imp); size != "" {
+		values[floors.FieldSize] = size
+	}
+	if site := req.Site; site != nil {
+		values[floors.FieldDomain] = site.Domain
+	} else if app := req.App; app != nil {
+		values[floors.FieldDomain] = app.Domain
+		values[floors.FieldBundle] = app.Bundle
+	}
+	if device := req.Device; device != nil {
+		if device.Geo != nil {
+			values[floors.FieldCountry] = device.Geo.Country
+		}
+		values[floors.FieldDeviceType] = floorDeviceType(device.DeviceType)
+	}
+	values[floors.FieldAdUnitCode] = imp.TagID
+	return values
+}
+
+// impMediaTypeCount counts the media types an imp offers
+func impMediaTypeCount(imp *openrtb.Imp) int {
+	n := 0
+	for _, set := range []bool{imp.Banner != nil, imp.Video != nil, imp.Audio != nil, imp.Native != nil} {
+		if set {
+			n++
+		}
+	}
+	return n
+}
+
+// impSize is an imp's WxH when it has exactly one size
+func impSize(imp *openrtb.Imp) string {
+	if b := imp.Banner; b != nil {
+		switch {
+		case len(b.Format) == 1:
+			return fmt.Sprintf("%dx%d", b.Format[0].W, b.Format[0].H)
+		case len(b.Format) == 0 && b.W > 0 && b.H > 0:
+			return fmt.Sprintf("%dx%d", b.W, b.H)
+		}
+		return ""
+	}
+	if v := imp.Video; v != nil && v.W > 0 && v.H > 0 {
+		return fmt.Sprintf("%dx%d", v.W, v.H)
+	}
+	return ""
+}
+
+// floorDeviceType maps OpenRTB device.devicetype to the floors schema's names
+func floorDeviceType(deviceType int) string {
+	switch deviceType {
+	case 1, 4: // Mobile/tablet, phone
+		return "phone"
+	case 2: // Personal computer
+		return "desktop"
+	case 3, 7: // Connected TV, set top box
+		return "ctv"
+	case 5:
+		return "tablet"
+	}
+	return ""
+}
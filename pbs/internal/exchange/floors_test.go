@@ -0,0 +1,166 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/currency"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/floors"
+)
+
+// floorAdapter records the floor it is sent per imp and bids price on each imp
+type floorAdapter struct {
+	code  string
+	price float64
+
+	mu     sync.Mutex
+	floors map[string]float64
+}
+
+func (a *floorAdapter) MakeRequests(request *openrtb.BidRequest, reqInfo *adapters.ExtraRequestInfo) ([]*adapters.RequestData, []error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.floors = make(map[string]float64, len(request.Imp))
+	for _, imp := range request.Imp {
+		a.floors[imp.ID] = imp.BidFloor
+	}
+	return []*adapters.RequestData{{Method: "MOCK", Body: []byte(`{}`)}}, nil
+}
+
+func (a *floorAdapter) MakeBids(request *openrtb.BidRequest, response *adapters.ResponseData) (*adapters.BidderResponse, []error) {
+	resp := &adapters.BidderResponse{Currency: "USD"}
+	for _, imp := range request.Imp {
+		resp.Bids = append(resp.Bids, &adapters.TypedBid{
+			Bid:     &openrtb.Bid{ID: a.code + "-" + imp.ID, ImpID: imp.ID, Price: a.price, AdM: "<div/>"},
+			BidType: adapters.BidTypeBanner,
+		})
+	}
+	return resp, nil
+}
+
+func floorsExt(floorsJSON string) json.RawMessage {
+	return json.RawMessage(`{"prebid":{"floors":` + floorsJSON + `}}`)
+}
+
+func TestFloorFieldValues(t *testing.T) {
+	req := &openrtb.BidRequest{
+		App:    &openrtb.App{Domain: "example.com", Bundle: "com.example.app"},
+		Device: &openrtb.Device{DeviceType: 4, Geo: &openrtb.Geo{Country: "USA"}},
+	}
+	imp := &openrtb.Imp{ID: "1", TagID: "top", Banner: &openrtb.Banner{Format: []openrtb.Format{{W: 300, H: 250}}}}
+	values := floorFieldValues(req, imp)
+	want := map[string]string{
+		floors.FieldMediaType: "banner", floors.FieldSize: "300x250", floors.FieldDomain: "example.com",
+		floors.FieldBundle: "com.example.app", floors.FieldCountry: "USA", floors.FieldDeviceType: "phone",
+		floors.FieldAdUnitCode: "top",
+	}
+	for field, v := range want {
+		if values[field] != v {
+			t.Errorf("%s: expected %q, got %q", field, v, values[field])
+		}
+	}
+
+	multi := &openrtb.Imp{ID: "2", Banner: &openrtb.Banner{Format: []openrtb.Format{{W: 300, H: 250}, {W: 728, H: 90}}}, Video: &openrtb.Video{}}
+	values = floorFieldValues(req, multi)
+	if values[floors.FieldMediaType] != "" || values[floors.FieldSize] != "" {
+		t.Errorf("expected no media type or size for a multi-format imp, got %v", values)
+	}
+}
+
+func TestNewAuctionFloors(t *testing.T) {
+	rates, _ := currency.NewRates("USD", map[string]float64{"EUR": 0.5})
+	req := &openrtb.BidRequest{
+		Site: &openrtb.Site{Domain: "example.com"},
+		Imp: []openrtb.Imp{
+			{ID: "banner", Banner: &openrtb.Banner{W: 300, H: 250}, BidFloor: 0.2, BidFloorCur: "USD"},
+			{ID: "video", Video: &openrtb.Video{W: 640, H: 480}, BidFloor: 4, BidFloorCur: "USD"},
+		},
+		Ext: floorsExt(`{"floormin":0.3,"data":{"currency":"EUR","modelgroups":[{` +
+			`"schema":{"fields":["bidder","mediaType"]},"values":{"appnexus|banner":1,"*|banner":0.5}}]}}`),
+	}
+
+	f, err := newAuctionFloors(req, []string{"appnexus", "rubicon"}, "USD", rates)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := f.forBidder("appnexus")["banner"]; got != 2 {
+		t.Errorf("expected appnexus's EUR rule converted to 2, got %v", got)
+	}
+	if got := f.forBidder("rubicon")["banner"]; got != 1 {
+		t.Errorf("expected the wildcard rule for rubicon, got %v", got)
+	}
+	if got := f.forBidder("rubicon")["video"]; got != 4 {
+		t.Errorf("expected the imp's own floor without a matching rule, got %v", got)
+	}
+	if got := f.clearingFloors(nil)["banner"]; got != 1 {
+		t.Errorf("expected the lowest bidder floor to clear at, got %v", got)
+	}
+
+	bidderReq := &openrtb.BidRequest{Imp: []openrtb.Imp{{ID: "banner", BidFloor: 0.2}}}
+	f.signal(bidderReq, "appnexus")
+	if bidderReq.Imp[0].BidFloor != 2 {
+		t.Errorf("expected the resolved floor signalled, got %v", bidderReq.Imp[0].BidFloor)
+	}
+
+	// floormin lifts floors no rule sets
+	req.Ext = floorsExt(`{"floormin":0.3}`)
+	f, _ = newAuctionFloors(req, nil, "USD", rates)
+	if got := f.forBidder("any")["banner"]; got != 0.3 {
+		t.Errorf("expected floormin applied, got %v", got)
+	}
+
+	var none *auctionFloors
+	base := map[string]float64{"banner": 0.2}
+	if none.forBidder("appnexus") != nil || none.enforced("appnexus", base)["banner"] != 0.2 {
+		t.Error("expected nil floors to leave the base floors")
+	}
+
+	req.Ext = floorsExt(`{"data":{"currency":"JPY","modelgroups":[{"schema":{"fields":["size"]}}]}}`)
+	if f, err := newAuctionFloors(req, nil, "USD", rates); f != nil || err == nil {
+		t.Errorf("expected unconvertible rules rejected, got %+v, %v", f, err)
+	}
+}
+
+func TestRunAuction_DynamicFloors(t *testing.T) {
+	run := func(enforce bool) (*AuctionResponse, *floorAdapter, *floorAdapter) {
+		registry := adapters.NewRegistry()
+		appnexus, rubicon := &floorAdapter{code: "appnexus", price: 1}, &floorAdapter{code: "rubicon", price: 1}
+		registry.Register("appnexus", appnexus, adapters.BidderInfo{Enabled: true})
+		registry.Register("rubicon", rubicon, adapters.BidderInfo{Enabled: true})
+		ex := New(registry, &Config{DefaultTimeout: time.Second, DefaultCurrency: "USD", AuctionType: FirstPriceAuction})
+
+		resp, err := ex.RunAuction(context.Background(), &AuctionRequest{BidRequest: &openrtb.BidRequest{
+			ID:   "floors-1",
+			Site: testSite(),
+			Imp:  []openrtb.Imp{{ID: "imp1", Banner: &openrtb.Banner{W: 300, H: 250}}},
+			Ext: floorsExt(`{"enforcement":{"enforcepbs":` + map[bool]string{true: "true", false: "false"}[enforce] + `},` +
+				`"data":{"modelgroups":[{"schema":{"fields":["bidder","size"]},"values":{"appnexus|300x250":1.5,"*|300x250":0.5}}]}}`),
+		}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return resp, appnexus, rubicon
+	}
+
+	resp, appnexus, rubicon := run(true)
+	if appnexus.floors["imp1"] != 1.5 || rubicon.floors["imp1"] != 0.5 {
+		t.Errorf("expected per-bidder floors signalled, got appnexus %v rubicon %v", appnexus.floors, rubicon.floors)
+	}
+	if errs := resp.DebugInfo.Errors["appnexus"]; len(errs) != 1 || !strings.Contains(errs[0], "below floor 1.5000") {
+		t.Errorf("expected appnexus's bid under its floor rejected, got %v", errs)
+	}
+	if errs := resp.DebugInfo.Errors["rubicon"]; len(errs) != 0 {
+		t.Errorf("expected rubicon's bid kept, got %v", errs)
+	}
+
+	resp, _, _ = run(false)
+	if errs := resp.DebugInfo.Errors["appnexus"]; len(errs) != 0 {
+		t.Errorf("expected no rejection without enforcement, got %v", errs)
+	}
+}
@@ -0,0 +1,117 @@
+package exchange
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+// redactedHeader replaces credential header values in captured HTTP calls
+const redactedHeader = "[redacted]"
+
+// credentialHeaderHints mark header names whose values are never echoed; bidders
+// configured with auth_type "header" pick their own header name
+var credentialHeaderHints = []string{"auth", "cookie", "key", "secret", "token", "signature"}
+
+// PrebidDebug reports whether a bid request asks for debug output the way
+// Prebid.js does: test=1 or ext.prebid.debug=true. Callers still apply the
+// API key and account debug checks before honouring it.
+func PrebidDebug(req *openrtb.BidRequest) bool {
+	if req == nil {
+		return false
+	}
+	if req.Test == 1 {
+		return true
+	}
+	if len(req.Ext) == 0 {
+		return false
+	}
+	var parsed struct {
+		Prebid *struct {
+			Debug bool `json:"debug"`
+		} `json:"prebid"`
+	}
+	if err := json.Unmarshal(req.Ext, &parsed); err != nil || parsed.Prebid == nil {
+		return false
+	}
+	return parsed.Prebid.Debug
+}
+
+type httpCallsKey struct{}
+
+// withHTTPCalls asks callBidder to capture each bidder call in its result
+func withHTTPCalls(ctx context.Context) context.Context {
+	return context.WithValue(ctx, httpCallsKey{}, true)
+}
+
+// capturesHTTPCalls reports whether the auction is capturing bidder calls
+func capturesHTTPCalls(ctx context.Context) bool {
+	capture, _ := ctx.Value(httpCallsKey{}).(bool)
+	return capture
+}
+
+// newHTTPCall describes a bidder call for ext.debug.httpcalls. resp is nil
+// when the call failed before the bidder answered.
+func newHTTPCall(reqData *adapters.RequestData, resp *adapters.ResponseData) openrtb.ExtHTTPCall {
+	call := openrtb.ExtHTTPCall{
+		URI:            reqData.URI,
+		RequestBody:    string(readableBody(reqData.Body, reqData.Headers)),
+		RequestHeaders: redactHeaders(reqData.Headers),
+	}
+	if resp != nil {
+		call.ResponseBody = string(resp.Body)
+		call.Status = resp.StatusCode
+	}
+	return call
+}
+
+// readableBody undoes request_compression so the captured body is the JSON
+// that was sent; a body that won't decompress is shown as is
+func readableBody(body []byte, headers http.Header) []byte {
+	if !strings.EqualFold(headers.Get("Content-Encoding"), adapters.EncodingGzip) {
+		return body
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return body
+	}
+	defer zr.Close()
+	plain, err := io.ReadAll(zr)
+	if err != nil {
+		return body
+	}
+	return plain
+}
+
+// redactHeaders copies headers, hiding the values of any that carry credentials
+func redactHeaders(headers http.Header) map[string][]string {
+	if len(headers) == 0 {
+		return nil
+	}
+	redacted := make(map[string][]string, len(headers))
+	for name, values := range headers {
+		if isCredentialHeader(name) {
+			redacted[name] = []string{redactedHeader}
+			continue
+		}
+		redacted[name] = append([]string(nil), values...)
+	}
+	return redacted
+}
+
+func isCredentialHeader(name string) bool {
+	lower := strings.ToLower(name)
+	for _, hint := range credentialHeaderHints {
+		if strings.Contains(lower, hint) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/accounts"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+func TestPrebidDebug(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		req  *openrtb.BidRequest
+		want bool
+	}{
+		{"nil", nil, false},
+		{"plain", &openrtb.BidRequest{}, false},
+		{"test", &openrtb.BidRequest{Test: 1}, true},
+		{"ext.prebid.debug", &openrtb.BidRequest{Ext: json.RawMessage(`{"prebid":{"debug":true}}`)}, true},
+		{"debug false", &openrtb.BidRequest{Ext: json.RawMessage(`{"prebid":{"debug":false}}`)}, false},
+		{"bad ext", &openrtb.BidRequest{Ext: json.RawMessage(`{"prebid":`)}, false},
+	} {
+		if got := PrebidDebug(tt.req); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestNewHTTPCall(t *testing.T) {
+	body, err := adapters.GzipBody([]byte(`{"id":"r1"}`))
+	if err != nil {
+		t.Fatalf("gzip failed: %v", err)
+	}
+	reqData := &adapters.RequestData{
+		Method: "POST",
+		URI:    "https://bidder.test/bid",
+		Body:   body,
+		Headers: http.Header{
+			"Content-Encoding":  {"gzip"},
+			"Authorization":     {"Bearer secret"},
+			"X-Partner-Key":     {"secret"},
+			"X-Openrtb-Version": {"2.5"},
+		},
+	}
+
+	call := newHTTPCall(reqData, &adapters.ResponseData{StatusCode: http.StatusOK, Body: []byte(`{"seatbid":[]}`)})
+	if call.RequestBody != `{"id":"r1"}` {
+		t.Errorf("expected the gzip request body shown decompressed, got %q", call.RequestBody)
+	}
+	if call.ResponseBody != `{"seatbid":[]}` || call.Status != http.StatusOK {
+		t.Errorf("expected the response captured, got %+v", call)
+	}
+	for _, name := range []string{"Authorization", "X-Partner-Key"} {
+		if v := call.RequestHeaders[name]; len(v) != 1 || v[0] != redactedHeader {
+			t.Errorf("expected %s redacted, got %v", name, v)
+		}
+	}
+	if v := call.RequestHeaders["X-Openrtb-Version"]; len(v) != 1 || v[0] != "2.5" {
+		t.Errorf("expected other headers kept, got %v", v)
+	}
+	if reqData.Headers.Get("Authorization") != "Bearer secret" {
+		t.Error("expected the outgoing request's headers left alone")
+	}
+
+	failed := newHTTPCall(reqData, nil)
+	if failed.Status != 0 || failed.ResponseBody != "" {
+		t.Errorf("expected no response for a failed call, got %+v", failed)
+	}
+}
+
+func TestRunAuction_HTTPCalls(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	registry := adapters.NewRegistry()
+	registry.Register("appnexus", &mockAdapter{requests: []*adapters.RequestData{{
+		Method:  "POST",
+		URI:     server.URL,
+		Body:    []byte(`{"id":"debug"}`),
+		Headers: http.Header{"Authorization": {"Bearer secret"}},
+	}}}, adapters.BidderInfo{Enabled: true})
+	ex := New(registry, &Config{DefaultTimeout: 100 * time.Millisecond})
+	ex.SetAccounts(&mockAccounts{accounts: map[string]*accounts.Account{
+		"quiet": {ID: "quiet", DebugAllowed: boolPtr(false)},
+	}})
+
+	auction := func(debug bool, account string) *AuctionResponse {
+		resp, err := ex.RunAuction(context.Background(), &AuctionRequest{
+			BidRequest: &openrtb.BidRequest{
+				ID:   "httpcalls",
+				Site: testSite(),
+				Imp:  []openrtb.Imp{{ID: "imp1", Banner: &openrtb.Banner{W: 300, H: 250}}},
+			},
+			Account: account,
+			Debug:   debug,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return resp
+	}
+
+	calls := auction(true, "").DebugInfo.HTTPCalls["appnexus"]
+	if len(calls) != 1 {
+		t.Fatalf("expected one captured call, got %+v", calls)
+	}
+	if calls[0].URI != server.URL || calls[0].RequestBody != `{"id":"debug"}` || calls[0].Status != http.StatusNoContent {
+		t.Errorf("expected the call and its status captured, got %+v", calls[0])
+	}
+	if calls[0].RequestHeaders["Authorization"][0] != redactedHeader {
+		t.Errorf("expected credentials redacted, got %v", calls[0].RequestHeaders)
+	}
+
+	if resp := auction(false, ""); resp.DebugInfo.HTTPCalls != nil || resp.BidderResults["appnexus"].HTTPCalls != nil {
+		t.Error("expected no calls captured outside debug")
+	}
+	if resp := auction(true, "quiet"); resp.DebugInfo.HTTPCalls != nil {
+		t.Error("expected no calls captured for an account that doesn't allow debug")
+	}
+}
@@ -0,0 +1,192 @@
+package exchange
+
+import (
+	"container/list"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/idr"
+)
+
+// defaultIDRCacheMaxEntries bounds memory for the IDR selection cache
+const defaultIDRCacheMaxEntries = 10000
+
+// IDRCacheMetrics receives IDR selection cache lookups for hit-rate reporting
+type IDRCacheMetrics interface {
+	RecordIDRCacheLookup(hit bool)
+}
+
+// IDRCacheStats is a snapshot of selection cache effectiveness
+type IDRCacheStats struct {
+	Enabled bool    `json:"enabled"`
+	TTLMs   int64   `json:"ttl_ms"`
+	Entries int     `json:"entries"`
+	Hits    int64   `json:"hits"`
+	Misses  int64   `json:"misses"`
+	HitRate float64 `json:"hit_rate"`
+}
+
+type idrCacheEntry struct {
+	key       string
+	result    *idr.SelectPartnersResponse
+	expiresAt time.Time
+}
+
+// idrSelectionCache memoizes IDR partner selections per publisher+geo+device+media
+// type bucket for a short TTL, cutting IDR QPS during traffic spikes. Cached results
+// are shared between auctions and must be treated as read-only.
+type idrSelectionCache struct {
+	mu         sync.RWMutex
+	entries    map[string]*list.Element
+	order      *list.List // Front is the most recently stored
+	ttl        time.Duration
+	maxEntries int
+	hits       atomic.Int64
+	misses     atomic.Int64
+	now        func() time.Time
+}
+
+func newIDRSelectionCache(ttl time.Duration, maxEntries int) *idrSelectionCache {
+	if maxEntries <= 0 {
+		maxEntries = defaultIDRCacheMaxEntries
+	}
+	return &idrSelectionCache{
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		now:        time.Now,
+	}
+}
+
+// get returns a live cached selection for key
+func (c *idrSelectionCache) get(key string) (*idr.SelectPartnersResponse, bool) {
+	c.mu.RLock()
+	el, ok := c.entries[key]
+	var entry idrCacheEntry
+	if ok {
+		entry = el.Value.(idrCacheEntry)
+	}
+	c.mu.RUnlock()
+
+	if ok && c.now().Before(entry.expiresAt) {
+		c.hits.Add(1)
+		return entry.result, true
+	}
+	c.misses.Add(1)
+	return nil, false
+}
+
+// set stores a selection, evicting the oldest when the cache is full. All
+// selections share the TTL, so the oldest is also the first to expire.
+func (c *idrSelectionCache) set(key string, result *idr.SelectPartnersResponse) {
+	entry := idrCacheEntry{key: key, result: result, expiresAt: c.now().Add(c.ttl)}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, exists := c.entries[key]; exists {
+		el.Value = entry
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.maxEntries {
+		c.remove(c.order.Back())
+	}
+}
+
+// remove drops el; callers hold mu
+func (c *idrSelectionCache) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.entries, el.Value.(idrCacheEntry).key)
+}
+
+// flush drops all cached selections
+func (c *idrSelectionCache) flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]*list.Element)
+	c.order.Init()
+}
+
+// invalidate drops selections for publishers matching match and returns how many were dropped
+func (c *idrSelectionCache) invalidate(match func(publisherID string) bool) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	dropped := 0
+	for key, el := range c.entries {
+		publisher, _, _ := strings.Cut(key, "|")
+		if match(publisher) {
+			c.remove(el)
+			dropped++
+		}
+	}
+	return dropped
+}
+
+func (c *idrSelectionCache) stats() IDRCacheStats {
+	c.mu.RLock()
+	entries := len(c.entries)
+	c.mu.RUnlock()
+
+	hits, misses := c.hits.Load(), c.misses.Load()
+	stats := IDRCacheStats{
+		Enabled: true,
+		TTLMs:   c.ttl.Milliseconds(),
+		Entries: entries,
+		Hits:    hits,
+		Misses:  misses,
+	}
+	if total := hits + misses; total > 0 {
+		stats.HitRate = float64(hits) / float64(total)
+	}
+	return stats
+}
+
+// idrCacheKey buckets a request by publisher, country, device type and media types. The set of
+// available bidders is fingerprinted into the key so registry changes never serve
+// a selection containing bidders that are no longer available.
+func idrCacheKey(minReq *idr.MinimalRequest, availableBidders []string) string {
+	var publisher string
+	if minReq.Site != nil {
+		publisher = minReq.Site.Publisher
+	} else if minReq.App != nil {
+		publisher = minReq.App.Publisher
+	}
+
+	var country string
+	if minReq.Geo != nil {
+		country = minReq.Geo.Country
+	}
+
+	seen := make(map[string]bool, 4)
+	mediaTypes := make([]string, 0, 4)
+	for _, imp := range minReq.Imp {
+		for _, mt := range imp.MediaTypes {
+			if !seen[mt] {
+				seen[mt] = true
+				mediaTypes = append(mediaTypes, mt)
+			}
+		}
+	}
+	sort.Strings(mediaTypes)
+
+	sorted := make([]string, len(availableBidders))
+	copy(sorted, availableBidders)
+	sort.Strings(sorted)
+	h := fnv.New64a()
+	for _, b := range sorted {
+		h.Write([]byte(b))
+		h.Write([]byte{0})
+	}
+
+	return publisher + "|" + country + "|" + minReq.DeviceType + "|" + strings.Join(mediaTypes, ",") + "|" +
+		strconv.FormatUint(h.Sum64(), 36)
+}
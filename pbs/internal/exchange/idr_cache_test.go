@@ -0,0 +1,246 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/idr"
+)
+
+type mockIDRCacheMetrics struct {
+	hits   int
+	misses int
+}
+
+func (m *mockIDRCacheMetrics) RecordIDRCacheLookup(hit bool) {
+	if hit {
+		m.hits++
+	} else {
+		m.misses++
+	}
+}
+
+func testMinimalRequest(publisher, country string, mediaTypes ...string) *idr.MinimalRequest {
+	return &idr.MinimalRequest{
+		ID:   "req",
+		Site: &idr.MinimalSite{Publisher: publisher},
+		Geo:  &idr.MinimalGeo{Country: country},
+		Imp:  []idr.MinimalImp{{ID: "1", MediaTypes: mediaTypes}},
+	}
+}
+
+func TestIDRCacheKey(t *testing.T) {
+	bidders := []string{"appnexus", "rubicon"}
+	base := idrCacheKey(testMinimalRequest("pub1", "USA", "banner", "video"), bidders)
+
+	// Media type order and bidder order don't matter
+	if got := idrCacheKey(testMinimalRequest("pub1", "USA", "video", "banner"), []string{"rubicon", "appnexus"}); got != base {
+		t.Errorf("expected equal keys, got %q and %q", base, got)
+	}
+
+	differing := map[string]string{
+		"publisher":  idrCacheKey(testMinimalRequest("pub2", "USA", "banner", "video"), bidders),
+		"country":    idrCacheKey(testMinimalRequest("pub1", "GBR", "banner", "video"), bidders),
+		"media type": idrCacheKey(testMinimalRequest("pub1", "USA", "banner"), bidders),
+		"bidders":    idrCacheKey(testMinimalRequest("pub1", "USA", "banner", "video"), []string{"appnexus"}),
+	}
+	mobile := testMinimalRequest("pub1", "USA", "banner", "video")
+	mobile.DeviceType = "mobile"
+	differing["device type"] = idrCacheKey(mobile, bidders)
+	for name, key := range differing {
+		if key == base {
+			t.Errorf("expected different key when %s changes", name)
+		}
+	}
+
+	// App publishers bucket the same way as site publishers
+	appReq := &idr.MinimalRequest{App: &idr.MinimalApp{Publisher: "pub1"}, Geo: &idr.MinimalGeo{Country: "USA"},
+		Imp: []idr.MinimalImp{{MediaTypes: []string{"banner", "video"}}}}
+	if got := idrCacheKey(appReq, bidders); got != base {
+		t.Errorf("expected app publisher to share bucket, got %q", got)
+	}
+}
+
+func TestIDRSelectionCache_Expiry(t *testing.T) {
+	cache := newIDRSelectionCache(time.Second, 10)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	result := &idr.SelectPartnersResponse{Mode: "normal"}
+	cache.set("k", result)
+
+	got, ok := cache.get("k")
+	if !ok || got != result {
+		t.Fatal("expected cache hit before expiry")
+	}
+
+	now = now.Add(2 * time.Second)
+	if _, ok := cache.get("k"); ok {
+		t.Error("expected cache miss after expiry")
+	}
+	if _, ok := cache.get("missing"); ok {
+		t.Error("expected cache miss for unknown key")
+	}
+
+	stats := cache.stats()
+	if stats.Hits != 1 || stats.Misses != 2 {
+		t.Errorf("expected 1 hit and 2 misses, got %+v", stats)
+	}
+	if stats.HitRate < 0.33 || stats.HitRate > 0.34 {
+		t.Errorf("expected hit rate of 1/3, got %f", stats.HitRate)
+	}
+}
+
+func TestIDRSelectionCache_MaxEntries(t *testing.T) {
+	cache := newIDRSelectionCache(time.Second, 2)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	cache.set("a", &idr.SelectPartnersResponse{})
+	cache.set("b", &idr.SelectPartnersResponse{})
+	cache.set("c", &idr.SelectPartnersResponse{})
+	if _, ok := cache.get("c"); !ok {
+		t.Error("expected full cache to take the new entry")
+	}
+	if _, ok := cache.get("a"); ok {
+		t.Error("expected the oldest entry evicted")
+	}
+
+	// Storing a cached key again refreshes it rather than evicting another
+	cache.set("b", &idr.SelectPartnersResponse{})
+	cache.set("d", &idr.SelectPartnersResponse{})
+	if _, ok := cache.get("b"); !ok {
+		t.Error("expected the refreshed entry kept")
+	}
+	if stats := cache.stats(); stats.Entries != 2 {
+		t.Errorf("expected 2 entries, got %d", stats.Entries)
+	}
+
+	cache.flush()
+	if stats := cache.stats(); stats.Entries != 0 {
+		t.Errorf("expected empty cache after flush, got %d", stats.Entries)
+	}
+}
+
+func TestIDRSelectionCache_Invalidate(t *testing.T) {
+	cache := newIDRSelectionCache(time.Minute, 10)
+	bidders := []string{"appnexus"}
+	cache.set(idrCacheKey(testMinimalRequest("pub1", "USA", "banner"), bidders), &idr.SelectPartnersResponse{})
+	cache.set(idrCacheKey(testMinimalRequest("pub1", "GBR", "banner"), bidders), &idr.SelectPartnersResponse{})
+	cache.set(idrCacheKey(testMinimalRequest("pub2", "USA", "banner"), bidders), &idr.SelectPartnersResponse{})
+
+	if dropped := cache.invalidate(func(pub string) bool { return pub == "pub1" }); dropped != 2 {
+		t.Errorf("expected 2 pub1 selections dropped, got %d", dropped)
+	}
+	if stats := cache.stats(); stats.Entries != 1 {
+		t.Errorf("expected pub2 selection to remain, got %d entries", stats.Entries)
+	}
+}
+
+func TestRunAuction_IDRSelectionCache(t *testing.T) {
+	var idrCalls atomic.Int32
+	idrServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		idrCalls.Add(1)
+		json.NewEncoder(w).Encode(idr.SelectPartnersResponse{
+			SelectedBidders: []idr.SelectedBidder{{BidderCode: "bidder1", Score: 1}},
+			ExcludedBidders: []idr.ExcludedBidder{{BidderCode: "bidder2"}},
+			Mode:            "normal",
+		})
+	}))
+	defer idrServer.Close()
+
+	bidderServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer bidderServer.Close()
+
+	registry := adapters.NewRegistry()
+	for _, code := range []string{"bidder1", "bidder2"} {
+		registry.Register(code, &mockAdapter{
+			requests: []*adapters.RequestData{{Method: "POST", URI: bidderServer.URL, Body: []byte(`{}`)}},
+		}, adapters.BidderInfo{Enabled: true})
+	}
+
+	ex := New(registry, &Config{
+		DefaultTimeout:       500 * time.Millisecond,
+		IDREnabled:           true,
+		IDRServiceURL:        idrServer.URL,
+		DefaultCurrency:      "USD",
+		AuctionType:          FirstPriceAuction,
+		IDRSelectionCacheTTL: time.Minute,
+	})
+	metrics := &mockIDRCacheMetrics{}
+	ex.SetIDRCacheMetrics(metrics)
+
+	newRequest := func(debug bool) *AuctionRequest {
+		return &AuctionRequest{
+			BidRequest: &openrtb.BidRequest{
+				ID:   "test-idr-cache",
+				Site: testSite(),
+				Imp:  []openrtb.Imp{{ID: "imp1", Banner: &openrtb.Banner{W: 300, H: 250}}},
+			},
+			Debug: debug,
+		}
+	}
+
+	for i, wantHit := range []bool{false, true} {
+		resp, err := ex.RunAuction(context.Background(), newRequest(false))
+		if err != nil {
+			t.Fatalf("auction %d: unexpected error: %v", i, err)
+		}
+		if resp.DebugInfo.IDRCacheHit != wantHit {
+			t.Errorf("auction %d: expected cache hit %v", i, wantHit)
+		}
+		if len(resp.DebugInfo.SelectedBidders) != 1 || resp.DebugInfo.SelectedBidders[0] != "bidder1" {
+			t.Errorf("auction %d: expected bidder1 selected, got %v", i, resp.DebugInfo.SelectedBidders)
+		}
+		if len(resp.DebugInfo.ExcludedBidders) != 1 {
+			t.Errorf("auction %d: expected excluded bidders to be reported, got %v", i, resp.DebugInfo.ExcludedBidders)
+		}
+	}
+	if got := idrCalls.Load(); got != 1 {
+		t.Errorf("expected 1 IDR call with cache, got %d", got)
+	}
+
+	// Debug requests bypass the cache so the selection shown is live
+	resp, err := ex.RunAuction(context.Background(), newRequest(true))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.DebugInfo.IDRCacheHit {
+		t.Error("expected debug request to bypass cache")
+	}
+	if got := idrCalls.Load(); got != 2 {
+		t.Errorf("expected debug request to call IDR, got %d calls", got)
+	}
+
+	if metrics.hits != 1 || metrics.misses != 1 {
+		t.Errorf("expected 1 hit and 1 miss recorded, got %+v", metrics)
+	}
+	if stats := ex.IDRCacheStats(); !stats.Enabled || stats.Entries != 1 {
+		t.Errorf("expected enabled cache with 1 entry, got %+v", stats)
+	}
+
+	ex.FlushIDRCache()
+	if _, err := ex.RunAuction(context.Background(), newRequest(false)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := idrCalls.Load(); got != 3 {
+		t.Errorf("expected IDR call after flush, got %d calls", got)
+	}
+}
+
+func TestIDRCacheStats_Disabled(t *testing.T) {
+	ex := New(adapters.NewRegistry(), &Config{IDREnabled: false})
+	if stats := ex.IDRCacheStats(); stats.Enabled {
+		t.Errorf("expected disabled cache stats, got %+v", stats)
+	}
+	ex.FlushIDRCache() // must not panic
+}
@@ -0,0 +1,64 @@
+package exchange
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/idr"
+)
+
+// idrShadowMode is the IDR selection mode in which every bidder is still
+// called, and the bidders IDR would have dropped are only reported
+const idrShadowMode = "shadow"
+
+// idrBypassReason marks a bidder IDR selected without scoring it in; in
+// shadow mode, one it would otherwise have dropped
+const idrBypassReason = "bypass"
+
+// idrShadowExclusions returns the bidders a shadow-mode selection would have
+// left out: those it lists as excluded and those it only kept as bypasses
+func idrShadowExclusions(result *idr.SelectPartnersResponse) map[string]bool {
+	excluded := make(map[string]bool)
+	for _, eb := range result.ExcludedBidders {
+		excluded[eb.BidderCode] = true
+	}
+	for _, sb := range result.SelectedBidders {
+		if strings.EqualFold(sb.Reason, idrBypassReason) {
+			excluded[sb.BidderCode] = true
+		}
+	}
+	return excluded
+}
+
+// compareIDRShadow compares the auction as run, with every bidder, against
+// what it would have been with the shadow selection enforced. Aliases follow
+// their bidder. Prices are compared as bid, before auction adjustments.
+func compareIDRShadow(results map[string]*BidderResult, validBids []ValidatedBid, excluded map[string]bool, aliases bidderAliases) idr.ShadowComparison {
+	var c idr.ShadowComparison
+	for code := range results {
+		c.BiddersCalled++
+		if excluded[aliases.core(code)] {
+			c.WouldExclude = append(c.WouldExclude, code)
+		} else {
+			c.BiddersSelected++
+		}
+	}
+	sort.Strings(c.WouldExclude)
+
+	best := make(map[string]float64)
+	bestKept := make(map[string]float64)
+	for _, vb := range validBids {
+		impID, price := vb.Bid.Bid.ImpID, vb.Bid.Bid.Price
+		best[impID] = max(best[impID], price)
+		if !excluded[aliases.core(vb.BidderCode)] {
+			bestKept[impID] = max(bestKept[impID], price)
+		}
+	}
+	for impID, price := range best {
+		c.RevenueKept += bestKept[impID]
+		c.RevenueLost += price - bestKept[impID]
+	}
+	c.RevenueKept = roundToCents(c.RevenueKept)
+	c.RevenueLost = roundToCents(c.RevenueLost)
+	return c
+}
@@ -0,0 +1,132 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/idr"
+)
+
+func TestCompareIDRShadow(t *testing.T) {
+	excluded := idrShadowExclusions(&idr.SelectPartnersResponse{
+		Mode: idrShadowMode,
+		SelectedBidders: []idr.SelectedBidder{
+			{BidderCode: "kept", Reason: "HIGH_SCORE"},
+			{BidderCode: "dropped", Reason: "BYPASS"},
+		},
+		ExcludedBidders: []idr.ExcludedBidder{{BidderCode: "listed"}},
+	})
+	if !reflect.DeepEqual(excluded, map[string]bool{"dropped": true, "listed": true}) {
+		t.Fatalf("expected bypassed and listed bidders excluded, got %v", excluded)
+	}
+
+	bid := func(code, impID string, price float64) ValidatedBid {
+		return ValidatedBid{BidderCode: code, Bid: &adapters.TypedBid{Bid: &openrtb.Bid{ImpID: impID, Price: price}}}
+	}
+	results := map[string]*BidderResult{"kept": {}, "dropped": {}, "alias": {}}
+	validBids := []ValidatedBid{
+		bid("kept", "imp1", 1.50),
+		bid("dropped", "imp1", 2.00), // The kept bidder still fills imp1
+		bid("alias", "imp2", 0.80),   // Nobody else bid on imp2
+		bid("kept", "imp3", 3.00),
+	}
+	c := compareIDRShadow(results, validBids, excluded, bidderAliases{"alias": "dropped"})
+
+	want := idr.ShadowComparison{
+		BiddersCalled:   3,
+		BiddersSelected: 1,
+		RevenueKept:     4.50,
+		RevenueLost:     1.30,
+		WouldExclude:    []string{"alias", "dropped"},
+	}
+	if !reflect.DeepEqual(c, want) {
+		t.Errorf("expected %+v, got %+v", want, c)
+	}
+}
+
+func TestRunAuction_IDRShadowMode(t *testing.T) {
+	idrServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(idr.SelectPartnersResponse{
+			SelectedBidders: []idr.SelectedBidder{{BidderCode: "bidder1", Reason: "HIGH_SCORE"}},
+			ExcludedBidders: []idr.ExcludedBidder{{BidderCode: "bidder2", Reason: "EXCLUDED"}},
+			Mode:            idrShadowMode,
+		})
+	}))
+	defer idrServer.Close()
+
+	var mu sync.Mutex
+	var events []idr.BidEvent
+	eventServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Events []idr.BidEvent `json:"events"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		events = append(events, body.Events...)
+		mu.Unlock()
+	}))
+	defer eventServer.Close()
+
+	registry := adapters.NewRegistry()
+	for code, price := range map[string]float64{"bidder1": 1.00, "bidder2": 2.50} {
+		registry.Register(code, &mockAdapter{
+			requests: []*adapters.RequestData{{Method: "MOCK", Body: []byte(`{}`)}},
+			bids: []*adapters.TypedBid{{
+				Bid:     &openrtb.Bid{ID: code + "-bid", ImpID: "imp1", Price: price, W: 300, H: 250, AdM: "<div/>"},
+				BidType: adapters.BidTypeBanner,
+			}},
+		}, adapters.BidderInfo{Enabled: true})
+	}
+
+	ex := New(registry, &Config{
+		DefaultTimeout:  time.Second,
+		IDREnabled:      true,
+		IDRServiceURL:   idrServer.URL,
+		DefaultCurrency: "USD",
+		AuctionType:     FirstPriceAuction,
+	})
+	// Events go to their own server so the IDR one only answers selections
+	ex.eventRecorder = idr.NewEventRecorder(eventServer.URL, 100)
+	defer ex.Close()
+
+	resp, err := ex.RunAuction(context.Background(), &AuctionRequest{BidRequest: &openrtb.BidRequest{
+		ID:   "shadow-auction",
+		Site: testSite(),
+		Imp:  []openrtb.Imp{{ID: "imp1", Banner: &openrtb.Banner{W: 300, H: 250}}},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(resp.BidderResults) != 2 || len(resp.DebugInfo.ExcludedBidders) != 0 {
+		t.Errorf("expected every bidder called in shadow mode, got %v excluded %v",
+			resp.BidderResults, resp.DebugInfo.ExcludedBidders)
+	}
+
+	if err := ex.eventRecorder.Flush(context.Background()); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	var shadow *idr.BidEvent
+	for i := range events {
+		if events[i].EventType == "idr_shadow" {
+			shadow = &events[i]
+		}
+	}
+	if shadow == nil {
+		t.Fatalf("expected a shadow comparison event, got %+v", events)
+	}
+	if shadow.AuctionID != "shadow-auction" || shadow.BiddersCalled != 2 || shadow.BiddersSelected != 1 ||
+		*shadow.RevenueKept != 1.00 || *shadow.RevenueLost != 1.50 || !reflect.DeepEqual(shadow.WouldExclude, []string{"bidder2"}) {
+		t.Errorf("unexpected shadow comparison %+v", shadow)
+	}
+}
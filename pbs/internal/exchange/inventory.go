@@ -0,0 +1,149 @@
+package exchange
+
+import (
+	"encoding/json"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+// defaultInterstitialMinPerc is how much of the screen, per dimension, an
+// interstitial size must cover without ext.prebid.interstitial
+const defaultInterstitialMinPerc = 50
+
+// maxInterstitialSizes bounds the sizes offered on an interstitial imp
+const maxInterstitialSizes = 10
+
+// interstitialSizes are the sizes offered on interstitial imps that don't
+// list their own, largest first
+var interstitialSizes = []openrtb.Format{
+	{W: 1024, H: 768}, {W: 768, H: 1024},
+	{W: 480, H: 320}, {W: 320, H: 480},
+	{W: 300, H: 600}, {W: 336, H: 280},
+	{W: 320, H: 250}, {W: 300, H: 250},
+	{W: 250, H: 250},
+}
+
+// prepareInventoryImps fills in interstitial banner sizes and normalizes the
+// rewarded signal to imp.rwdd, so every bidder reads them in one place
+func prepareInventoryImps(req *openrtb.BidRequest) {
+	minW, minH := interstitialMinPerc(req.Ext)
+	for i := range req.Imp {
+		imp := &req.Imp[i]
+		if imp.Rwdd == 0 && impRewarded(imp.Ext) {
+			imp.Rwdd = 1
+		}
+		if imp.Instl == 1 && imp.Banner != nil {
+			fillInterstitialSizes(imp, req.Device, minW, minH)
+		}
+	}
+}
+
+// interstitialMinPerc reads request ext.prebid.interstitial's minwidthperc
+// and minheightperc, defaulting each to defaultInterstitialMinPerc
+func interstitialMinPerc(ext json.RawMessage) (int, int) {
+	minW, minH := defaultInterstitialMinPerc, defaultInterstitialMinPerc
+	if len(ext) == 0 {
+		return minW, minH
+	}
+	var parsed struct {
+		Prebid *struct {
+			Interstitial *struct {
+				MinWidthPerc  int `json:"minwidthperc"`
+				MinHeightPerc int `json:"minheightperc"`
+			} `json:"interstitial"`
+		} `json:"prebid"`
+	}
+	if err := json.Unmarshal(ext, &parsed); err != nil || parsed.Prebid == nil || parsed.Prebid.Interstitial == nil {
+		return minW, minH
+	}
+	if p := parsed.Prebid.Interstitial.MinWidthPerc; p > 0 && p <= 100 {
+		minW = p
+	}
+	if p := parsed.Prebid.Interstitial.MinHeightPerc; p > 0 && p <= 100 {
+		minH = p
+	}
+	return minW, minH
+}
+
+// impRewarded reports whether imp ext.prebid.is_rewarded_inventory is set,
+// Prebid's signal for rewarded inventory before OpenRTB 2.6 added imp.rwdd
+func impRewarded(ext json.RawMessage) bool {
+	if len(ext) == 0 {
+		return false
+	}
+	var parsed struct {
+		Prebid *struct {
+			IsRewardedInventory int `json:"is_rewarded_inventory"`
+		} `json:"prebid"`
+	}
+	return json.Unmarshal(ext, &parsed) == nil && parsed.Prebid != nil && parsed.Prebid.IsRewardedInventory == 1
+}
+
+// fillInterstitialSizes offers an interstitial imp every standard size that
+// fits its screen and covers at least minW/minH percent of it. The screen is
+// the banner's one declared size, which stays first, or the device's size in
+// CSS pixels. Imps that already list several sizes are left alone.
+func fillInterstitialSizes(imp *openrtb.Imp, device *openrtb.Device, minW, minH int) {
+	banner := imp.Banner
+	var formats []openrtb.Format
+	var screenW, screenH int
+	switch {
+	case len(banner.Format) > 1:
+		return
+	case len(banner.Format) == 1:
+		formats = []openrtb.Format{banner.Format[0]}
+		screenW, screenH = banner.Format[0].W, banner.Format[0].H
+	case banner.W > 0 && banner.H > 0:
+		formats = []openrtb.Format{{W: banner.W, H: banner.H}}
+		screenW, screenH = banner.W, banner.H
+	case device != nil && device.W > 0 && device.H > 0:
+		screenW, screenH = device.W, device.H
+		if device.PxRatio > 1 {
+			screenW = int(float64(screenW) / device.PxRatio)
+			screenH = int(float64(screenH) / device.PxRatio)
+		}
+	default:
+		return
+	}
+
+	declared := len(formats)
+	for _, size := range interstitialSizes {
+		if len(formats) >= maxInterstitialSizes {
+			break
+		}
+		if size.W > screenW || size.H > screenH || size.W*100 < screenW*minW || size.H*100 < screenH*minH {
+			continue
+		}
+		if declared == 1 && size.W == screenW && size.H == screenH {
+			continue
+		}
+		formats = append(formats, size)
+	}
+	if len(formats) == declared {
+		return
+	}
+	// The banner may be shared with the caller, so the sizes go on a copy
+	filled := *banner
+	filled.Format = formats
+	imp.Banner = &filled
+}
+
+// stripUnsupportedInventory removes the imps a bidder doesn't buy from its
+// request copy, returning the number of imps left
+func stripUnsupportedInventory(req *openrtb.BidRequest, info adapters.BidderInfo) int {
+	if len(info.UnsupportedInventory) == 0 {
+		return len(req.Imp)
+	}
+	interstitial := info.SupportsInventory(adapters.InventoryInterstitial)
+	rewarded := info.SupportsInventory(adapters.InventoryRewarded)
+	imps := req.Imp[:0]
+	for _, imp := range req.Imp {
+		if (imp.Instl == 1 && !interstitial) || (imp.Rwdd == 1 && !rewarded) {
+			continue
+		}
+		imps = append(imps, imp)
+	}
+	req.Imp = imps
+	return len(imps)
+}
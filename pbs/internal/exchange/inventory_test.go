@@ -0,0 +1,135 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+func formatList(formats []openrtb.Format) string {
+	var s string
+	for i, f := range formats {
+		if i > 0 {
+			s += " "
+		}
+		s += fmt.Sprintf("%dx%d", f.W, f.H)
+	}
+	return s
+}
+
+func TestPrepareInventoryImps(t *testing.T) {
+	shared := &openrtb.Banner{}
+	req := &openrtb.BidRequest{
+		Device: &openrtb.Device{W: 1080, H: 1920, PxRatio: 3}, // 360x640 CSS pixels
+		Imp: []openrtb.Imp{
+			{ID: "device", Instl: 1, Banner: shared},
+			{ID: "declared", Instl: 1, Banner: &openrtb.Banner{W: 320, H: 480}},
+			{ID: "listed", Instl: 1, Banner: &openrtb.Banner{Format: []openrtb.Format{{W: 320, H: 480}, {W: 300, H: 250}}}},
+			{ID: "inline", Banner: &openrtb.Banner{}},
+			{ID: "rewarded", Video: &openrtb.Video{}, Ext: json.RawMessage(`{"prebid":{"is_rewarded_inventory":1}}`)},
+			{ID: "rwdd", Rwdd: 1, Video: &openrtb.Video{}},
+		},
+	}
+	prepareInventoryImps(req)
+
+	want := map[string]string{
+		"device":   "320x480 300x600",
+		"declared": "320x480 320x250 300x250 250x250",
+		"listed":   "320x480 300x250",
+		"inline":   "",
+	}
+	for i := range req.Imp[:4] {
+		imp := &req.Imp[i]
+		if got := formatList(imp.Banner.Format); got != want[imp.ID] {
+			t.Errorf("%s: expected sizes %q, got %q", imp.ID, want[imp.ID], got)
+		}
+	}
+	if len(shared.Format) != 0 {
+		t.Error("expected the caller's banner left unchanged")
+	}
+	if req.Imp[4].Rwdd != 1 || req.Imp[5].Rwdd != 1 {
+		t.Errorf("expected rewarded imps to carry rwdd, got %d and %d", req.Imp[4].Rwdd, req.Imp[5].Rwdd)
+	}
+
+	// ext.prebid.interstitial tightens how much of the screen a size must cover
+	req = &openrtb.BidRequest{
+		Ext:    json.RawMessage(`{"prebid":{"interstitial":{"minwidthperc":90,"minheightperc":70}}}`),
+		Device: &openrtb.Device{W: 360, H: 640},
+		Imp:    []openrtb.Imp{{ID: "device", Instl: 1, Banner: &openrtb.Banner{}}},
+	}
+	prepareInventoryImps(req)
+	if got := formatList(req.Imp[0].Banner.Format); got != "" {
+		t.Errorf("expected no size to cover 90%%x70%% of the screen, got %q", got)
+	}
+}
+
+func TestStripUnsupportedInventory(t *testing.T) {
+	newReq := func() *openrtb.BidRequest {
+		return &openrtb.BidRequest{Imp: []openrtb.Imp{
+			{ID: "instl", Instl: 1},
+			{ID: "rewarded", Rwdd: 1},
+			{ID: "plain"},
+		}}
+	}
+
+	tests := []struct {
+		unsupported []string
+		want        int
+	}{
+		{nil, 3},
+		{[]string{adapters.InventoryInterstitial}, 2},
+		{[]string{adapters.InventoryRewarded}, 2},
+		{[]string{adapters.InventoryInterstitial, adapters.InventoryRewarded}, 1},
+	}
+	for _, tt := range tests {
+		req := newReq()
+		if n := stripUnsupportedInventory(req, adapters.BidderInfo{UnsupportedInventory: tt.unsupported}); n != tt.want || len(req.Imp) != tt.want {
+			t.Errorf("%v: expected %d imps left, got %d", tt.unsupported, tt.want, n)
+		}
+	}
+}
+
+func TestRunAuction_SkipsBiddersWithoutRewardedSupport(t *testing.T) {
+	bidder := func(id string) *mockAdapter {
+		return &mockAdapter{
+			requests: []*adapters.RequestData{{Method: "MOCK", Body: []byte(`{}`)}},
+			bids: []*adapters.TypedBid{{
+				Bid:     &openrtb.Bid{ID: id, ImpID: "imp1", Price: 1, W: 300, H: 250, AdM: "<div/>"},
+				BidType: adapters.BidTypeBanner,
+			}},
+		}
+	}
+	registry := adapters.NewRegistry()
+	registry.Register("rewarded", bidder("r-1"), adapters.BidderInfo{Enabled: true})
+	registry.Register("standard", bidder("s-1"), adapters.BidderInfo{Enabled: true, UnsupportedInventory: []string{adapters.InventoryRewarded}})
+	ex := New(registry, &Config{DefaultTimeout: time.Second, DefaultCurrency: "USD"})
+
+	req := &openrtb.BidRequest{
+		ID:   "rewarded-req",
+		Site: testSite(),
+		Imp: []openrtb.Imp{{
+			ID:     "imp1",
+			Banner: &openrtb.Banner{W: 300, H: 250},
+			Ext:    json.RawMessage(`{"prebid":{"is_rewarded_inventory":1}}`),
+		}},
+	}
+	resp, err := ex.RunAuction(context.Background(), &AuctionRequest{BidRequest: req})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, called := resp.BidderResults["standard"]; called {
+		t.Error("expected the bidder without rewarded support to be skipped")
+	}
+	if _, called := resp.BidderResults["rewarded"]; !called {
+		t.Error("expected the rewarded bidder to be called")
+	}
+	if req.Imp[0].Rwdd != 1 {
+		t.Error("expected the rewarded imp to carry rwdd")
+	}
+}
@@ -0,0 +1,123 @@
+package exchange
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+// Bid landscape statuses
+const (
+	LandscapeWon      = "won"
+	LandscapeLost     = "lost"
+	LandscapeRejected = "rejected"
+)
+
+// bidLandscape collects every bid seen in a debug auction so "why did X lose"
+// can be answered from the response. A nil *bidLandscape records nothing, so
+// callers don't need to branch on debug mode.
+type bidLandscape struct {
+	rejected       map[string][]openrtb.ExtLandscapeBid
+	originalPrices map[*openrtb.Bid]float64
+}
+
+func newBidLandscape() *bidLandscape {
+	return &bidLandscape{
+		rejected:       make(map[string][]openrtb.ExtLandscapeBid),
+		originalPrices: make(map[*openrtb.Bid]float64),
+	}
+}
+
+// reject records a bid dropped before the auction ran
+func (l *bidLandscape) reject(bidderCode string, bid *openrtb.Bid, reason string) {
+	if l == nil || bid == nil {
+		return
+	}
+	l.rejected[bid.ImpID] = append(l.rejected[bid.ImpID], openrtb.ExtLandscapeBid{
+		Bidder: bidderCode,
+		BidID:  bid.ID,
+		Price:  bid.Price,
+		Status: LandscapeRejected,
+		Reason: reason,
+		DealID: bid.DealID,
+	})
+}
+
+// snapshot remembers submitted prices; must run before auction logic rewrites them
+func (l *bidLandscape) snapshot(validBids []ValidatedBid) {
+	if l == nil {
+		return
+	}
+	for _, vb := range validBids {
+		l.originalPrices[vb.Bid.Bid] = vb.Bid.Bid.Price
+	}
+}
+
+// build ranks the valid bids per imp by submitted price, preferred deals first,
+// and appends the rejected ones.
+// auctioned is the result of runAuctionLogic; an imp with valid bids but no auctioned
+// bids had its top bid rejected by second-price clearing.
+func (l *bidLandscape) build(validBids []ValidatedBid, auctioned map[string][]ValidatedBid) map[string][]openrtb.ExtLandscapeBid {
+	if l == nil {
+		return nil
+	}
+
+	byImp := make(map[string][]ValidatedBid)
+	for _, vb := range validBids {
+		byImp[vb.Bid.Bid.ImpID] = append(byImp[vb.Bid.Bid.ImpID], vb)
+	}
+
+	result := make(map[string][]openrtb.ExtLandscapeBid, len(byImp)+len(l.rejected))
+	for impID, bids := range byImp {
+		// Preferred deals rank first, as in the auction. Stable ordering for
+		// equal prices keeps debug output reproducible.
+		sort.SliceStable(bids, func(i, j int) bool {
+			if bids[i].PreferredDeal != bids[j].PreferredDeal {
+				return bids[i].PreferredDeal
+			}
+			pi, pj := l.originalPrices[bids[i].Bid.Bid], l.originalPrices[bids[j].Bid.Bid]
+			if pi != pj {
+				return pi > pj
+			}
+			return bids[i].BidderCode < bids[j].BidderCode
+		})
+		clearingRejected := len(auctioned[impID]) == 0
+
+		entries := make([]openrtb.ExtLandscapeBid, 0, len(bids))
+		for i, vb := range bids {
+			bid := vb.Bid.Bid
+			entry := openrtb.ExtLandscapeBid{
+				Bidder:        vb.BidderCode,
+				BidID:         bid.ID,
+				Price:         l.originalPrices[bid],
+				AdjustedPrice: bid.Price,
+				Rank:          i + 1,
+				Status:        LandscapeLost,
+				DealID:        bid.DealID,
+			}
+			switch {
+			case i == 0 && clearingRejected:
+				entry.Status = LandscapeRejected
+				entry.Reason = "clearing price exceeds bid"
+			case i == 0:
+				entry.Status = LandscapeWon
+			case clearingRejected:
+				entry.Reason = "no winner: top bid failed clearing price"
+			case bids[0].PreferredDeal && !vb.PreferredDeal:
+				entry.Reason = fmt.Sprintf("outranked by %s's preferred deal %s", bids[0].BidderCode, bids[0].Bid.Bid.DealID)
+			default:
+				top := bids[0]
+				entry.Reason = fmt.Sprintf("outbid by %s at %.2f", top.BidderCode, l.originalPrices[top.Bid.Bid])
+			}
+			entries = append(entries, entry)
+		}
+		result[impID] = entries
+	}
+
+	for impID, rejected := range l.rejected {
+		sort.SliceStable(rejected, func(i, j int) bool { return rejected[i].Bidder < rejected[j].Bidder })
+		result[impID] = append(result[impID], rejected...)
+	}
+	return result
+}
@@ -0,0 +1,136 @@
+package exchange
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+// mockBidder returns a mock adapter that bids price on imp1 without an HTTP call
+func mockBidder(bidID string, price float64) *mockAdapter {
+	return &mockAdapter{
+		requests: []*adapters.RequestData{{Method: "MOCK"}},
+		bids: []*adapters.TypedBid{{
+			Bid:     &openrtb.Bid{ID: bidID, ImpID: "imp1", Price: price, AdM: "<div>ad</div>", W: 300, H: 250},
+			BidType: adapters.BidTypeBanner,
+		}},
+	}
+}
+
+func landscapeAuction(t *testing.T, debug bool) *AuctionResponse {
+	t.Helper()
+	registry := adapters.NewRegistry()
+	registry.Register("alpha", mockBidder("a-1", 2.00), adapters.BidderInfo{Enabled: true})
+	registry.Register("bravo", mockBidder("b-1", 3.00), adapters.BidderInfo{Enabled: true})
+	registry.Register("charlie", mockBidder("c-1", 0.50), adapters.BidderInfo{Enabled: true})
+
+	ex := New(registry, &Config{
+		DefaultTimeout:  500 * time.Millisecond,
+		IDREnabled:      false,
+		DefaultCurrency: "USD",
+	})
+
+	resp, err := ex.RunAuction(context.Background(), &AuctionRequest{
+		BidRequest: &openrtb.BidRequest{
+			ID:   "landscape-req",
+			Site: testSite(),
+			Imp:  []openrtb.Imp{{ID: "imp1", Banner: &openrtb.Banner{W: 300, H: 250}, BidFloor: 1.00}},
+		},
+		Debug: debug,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return resp
+}
+
+func TestBidLandscape_DebugAuction(t *testing.T) {
+	resp := landscapeAuction(t, true)
+
+	bids := resp.DebugInfo.BidLandscape["imp1"]
+	if len(bids) != 3 {
+		t.Fatalf("expected 3 landscape entries, got %+v", bids)
+	}
+
+	want := []struct {
+		bidder string
+		rank   int
+		status string
+	}{
+		{"bravo", 1, LandscapeWon},
+		{"alpha", 2, LandscapeLost},
+		{"charlie", 0, LandscapeRejected},
+	}
+	for i, w := range want {
+		got := bids[i]
+		if got.Bidder != w.bidder || got.Rank != w.rank || got.Status != w.status {
+			t.Errorf("entry %d: expected %s rank %d %s, got %+v", i, w.bidder, w.rank, w.status, got)
+		}
+	}
+	if bids[1].Reason != "outbid by bravo at 3.00" {
+		t.Errorf("unexpected lost reason %q", bids[1].Reason)
+	}
+	if bids[2].Reason == "" || bids[2].Price != 0.50 {
+		t.Errorf("expected rejected bid with price and reason, got %+v", bids[2])
+	}
+	if bids[0].Price != 3.00 || bids[0].AdjustedPrice != 3.00 {
+		t.Errorf("expected first-price winner to keep its price, got %+v", bids[0])
+	}
+}
+
+func TestBidLandscape_OnlyInDebug(t *testing.T) {
+	resp := landscapeAuction(t, false)
+	if resp.DebugInfo.BidLandscape != nil {
+		t.Errorf("expected no landscape without debug, got %+v", resp.DebugInfo.BidLandscape)
+	}
+}
+
+func TestBidLandscape_SecondPrice(t *testing.T) {
+	ex := New(adapters.NewRegistry(), &Config{
+		AuctionType:    SecondPriceAuction,
+		PriceIncrement: 0.01,
+	})
+
+	valid := func(bidder, impID string, price float64) ValidatedBid {
+		return ValidatedBid{
+			BidderCode: bidder,
+			Bid:        &adapters.TypedBid{Bid: &openrtb.Bid{ID: bidder + "-" + impID, ImpID: impID, Price: price}},
+		}
+	}
+	validBids := []ValidatedBid{
+		valid("alpha", "imp1", 2.00),
+		valid("bravo", "imp1", 3.00),
+		// Single bid below floor+increment fails clearing
+		valid("alpha", "imp2", 1.00),
+	}
+	floors := map[string]float64{"imp1": 0, "imp2": 1.00}
+
+	l := newBidLandscape()
+	l.snapshot(validBids)
+	landscape := l.build(validBids, ex.runAuctionLogic(validBids, floors))
+
+	imp1 := landscape["imp1"]
+	if imp1[0].Bidder != "bravo" || imp1[0].Price != 3.00 || imp1[0].AdjustedPrice != 2.01 {
+		t.Errorf("expected bravo to win at 2.01 from 3.00, got %+v", imp1[0])
+	}
+	if imp1[1].AdjustedPrice != 2.00 || imp1[1].Status != LandscapeLost {
+		t.Errorf("expected alpha to lose unadjusted, got %+v", imp1[1])
+	}
+
+	imp2 := landscape["imp2"]
+	if len(imp2) != 1 || imp2[0].Status != LandscapeRejected || imp2[0].Reason != "clearing price exceeds bid" {
+		t.Errorf("expected clearing price rejection, got %+v", imp2)
+	}
+}
+
+func TestBidLandscape_NilIsNoop(t *testing.T) {
+	var l *bidLandscape
+	l.reject("alpha", &openrtb.Bid{ID: "x"}, "reason")
+	l.snapshot(nil)
+	if got := l.build(nil, nil); got != nil {
+		t.Errorf("expected nil landscape, got %v", got)
+	}
+}
@@ -0,0 +1,120 @@
+package exchange
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Multibid bounds, as in Prebid Server: without ext.prebid.multibid a seat
+// returns its best bid per imp, and at most maxMultibid with it
+const (
+	defaultMaxBids = 1
+	maxMultibid    = 9
+)
+
+// multibidRule is one seat's ext.prebid.multibid entry
+type multibidRule struct {
+	maxBids int
+	// prefix names the seat's extra bids in targeting (<prefix>2, <prefix>3, ...);
+	// without one, extra bids are returned without targeting keys
+	prefix string
+}
+
+// multibidSettings maps seat code (the bidder code shown to the page) to its rule
+type multibidSettings map[string]multibidRule
+
+// parseMultibid reads request ext.prebid.multibid, a list of
+// {"bidder" or "bidders", "maxbids", "targetbiddercodeprefix"} entries.
+// maxbids is clamped to 1-9. Entries without a bidder or maxbids are skipped,
+// and a seat named twice keeps its first entry; both are reported in the
+// returned error, alongside the settings that did parse.
+func parseMultibid(ext json.RawMessage) (multibidSettings, error) {
+	if len(ext) == 0 {
+		return nil, nil
+	}
+	var parsed struct {
+		Prebid *struct {
+			Multibid []struct {
+				Bidder                 string   `json:"bidder"`
+				Bidders                []string `json:"bidders"`
+				MaxBids                *int     `json:"maxbids"`
+				TargetBidderCodePrefix string   `json:"targetbiddercodeprefix"`
+			} `json:"multibid"`
+		} `json:"prebid"`
+	}
+	if err := json.Unmarshal(ext, &parsed); err != nil || parsed.Prebid == nil || len(parsed.Prebid.Multibid) == 0 {
+		return nil, nil
+	}
+
+	settings := make(multibidSettings)
+	var errs []error
+	for i, entry := range parsed.Prebid.Multibid {
+		if entry.MaxBids == nil {
+			errs = append(errs, fmt.Errorf("ext.prebid.multibid[%d]: maxbids is required", i))
+			continue
+		}
+		bidders := entry.Bidders
+		if entry.Bidder != "" {
+			bidders = []string{entry.Bidder}
+		}
+		if len(bidders) == 0 {
+			errs = append(errs, fmt.Errorf("ext.prebid.multibid[%d]: bidder or bidders is required", i))
+			continue
+		}
+		rule := multibidRule{maxBids: min(max(*entry.MaxBids, 1), maxMultibid)}
+		// A prefix can only name one seat's extra bids
+		if entry.Bidder != "" {
+			rule.prefix = entry.TargetBidderCodePrefix
+		}
+		for _, bidder := range bidders {
+			if _, dup := settings[bidder]; dup {
+				errs = append(errs, fmt.Errorf("ext.prebid.multibid[%d]: %s already configured", i, bidder))
+				continue
+			}
+			settings[bidder] = rule
+		}
+	}
+	return settings, errors.Join(errs...)
+}
+
+// maxBids returns how many bids seat may return per imp
+func (m multibidSettings) maxBids(seat string) int {
+	if rule, ok := m[seat]; ok {
+		return rule.maxBids
+	}
+	return defaultMaxBids
+}
+
+// targetBidderCode returns the code a seat's rank-th bid (1-based) is
+// targeted under: the seat itself for its best bid, <prefix><rank> for the
+// others. Empty means the bid gets no targeting keys.
+func (m multibidSettings) targetBidderCode(seat string, rank int) string {
+	if rank == 1 {
+		return seat
+	}
+	if prefix := m[seat].prefix; prefix != "" {
+		return prefix + strconv.Itoa(rank)
+	}
+	return ""
+}
+
+// limit returns the bids each seat may return for one imp, with each bid's
+// targeting code. bids must be sorted by price, highest first, as
+// runAuctionLogic leaves them.
+func (m multibidSettings) limit(bids []ValidatedBid) ([]ValidatedBid, []string) {
+	returned := make([]ValidatedBid, 0, len(bids))
+	codes := make([]string, 0, len(bids))
+	perSeat := make(map[string]int)
+	for _, vb := range bids {
+		seat := displayBidderCode(vb)
+		if perSeat[seat] >= m.maxBids(seat) {
+			continue
+		}
+		perSeat[seat]++
+		returned = append(returned, vb)
+		codes = append(codes, m.targetBidderCode(seat, perSeat[seat]))
+	}
+	return returned, codes
+}
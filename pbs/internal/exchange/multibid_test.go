@@ -0,0 +1,132 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+func TestParseMultibid(t *testing.T) {
+	settings, err := parseMultibid(json.RawMessage(`{"prebid":{"multibid":[
+		{"bidder":"pubA","maxbids":3,"targetbiddercodeprefix":"pa"},
+		{"bidders":["pubB","pubC"],"maxbids":20,"targetbiddercodeprefix":"ignored"},
+		{"bidder":"pubA","maxbids":2},
+		{"bidder":"pubD"},
+		{"maxbids":2}
+	]}}`))
+	if err == nil {
+		t.Error("expected errors for the duplicate and incomplete entries")
+	}
+	if got := settings["pubA"]; got != (multibidRule{maxBids: 3, prefix: "pa"}) {
+		t.Errorf("expected the first pubA entry, got %+v", got)
+	}
+	if got := settings["pubB"]; got != (multibidRule{maxBids: maxMultibid}) {
+		t.Errorf("expected maxbids clamped and no prefix for a bidders list, got %+v", got)
+	}
+	if settings.maxBids("pubD") != defaultMaxBids || settings.maxBids("unlisted") != defaultMaxBids {
+		t.Errorf("expected the default for unconfigured seats")
+	}
+
+	for _, ext := range []string{``, `{}`, `{"prebid":{}}`, `not json`} {
+		if settings, err := parseMultibid(json.RawMessage(ext)); settings != nil || err != nil {
+			t.Errorf("%q: expected no settings, got %v, %v", ext, settings, err)
+		}
+	}
+}
+
+func TestMultibidSettings_Limit(t *testing.T) {
+	bid := func(bidder string, demand adapters.DemandType, price float64) ValidatedBid {
+		return ValidatedBid{
+			Bid:        &adapters.TypedBid{Bid: &openrtb.Bid{ID: fmt.Sprintf("%s-%v", bidder, price), Price: price}},
+			BidderCode: bidder,
+			DemandType: demand,
+		}
+	}
+	bids := []ValidatedBid{
+		bid("pubA", adapters.DemandTypePublisher, 5),
+		bid("platform1", adapters.DemandTypePlatform, 4),
+		bid("pubA", adapters.DemandTypePublisher, 3),
+		bid("platform2", adapters.DemandTypePlatform, 2),
+		bid("pubA", adapters.DemandTypePublisher, 1),
+		bid("pubB", adapters.DemandTypePublisher, 1),
+	}
+
+	m := multibidSettings{"pubA": {maxBids: 2, prefix: "pa"}}
+	returned, codes := m.limit(bids)
+	var got []string
+	for i, vb := range returned {
+		got = append(got, vb.Bid.Bid.ID+"="+codes[i])
+	}
+	want := fmt.Sprint([]string{"pubA-5=pubA", "platform1-4=" + adapters.PlatformSeatName, "pubA-3=pa2", "pubB-1=pubB"})
+	if fmt.Sprint(got) != want {
+		t.Errorf("expected %s, got %v", want, got)
+	}
+
+	// Without a prefix, extra bids are returned untargeted
+	if _, codes := (multibidSettings{"pubA": {maxBids: 3}}).limit(bids); codes[2] != "" {
+		t.Errorf("expected no targeting code for an unprefixed extra bid, got %q", codes[2])
+	}
+}
+
+func TestRunAuction_Multibid(t *testing.T) {
+	adapter := &mockAdapter{
+		requests: []*adapters.RequestData{{Method: "MOCK", Body: []byte(`{}`)}},
+	}
+	for i, price := range []float64{1.5, 3.5, 2.5} {
+		adapter.bids = append(adapter.bids, &adapters.TypedBid{
+			Bid:     &openrtb.Bid{ID: fmt.Sprintf("bid%d", i), ImpID: "imp1", Price: price, W: 300, H: 250, AdM: "<div/>"},
+			BidType: adapters.BidTypeBanner,
+		})
+	}
+	registry := adapters.NewRegistry()
+	registry.Register("pub", adapter, adapters.BidderInfo{Enabled: true, DemandType: adapters.DemandTypePublisher})
+	ex := New(registry, &Config{DefaultTimeout: time.Second, DefaultCurrency: "USD", AuctionType: FirstPriceAuction})
+
+	run := func(ext string) []openrtb.Bid {
+		t.Helper()
+		req := &openrtb.BidRequest{
+			ID:   "multibid-req",
+			Site: testSite(),
+			Imp:  []openrtb.Imp{{ID: "imp1", Banner: &openrtb.Banner{W: 300, H: 250}}},
+			Ext:  json.RawMessage(ext),
+		}
+		resp, err := ex.RunAuction(context.Background(), &AuctionRequest{BidRequest: req})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(resp.BidResponse.SeatBid) != 1 {
+			t.Fatalf("expected one seat, got %+v", resp.BidResponse.SeatBid)
+		}
+		return resp.BidResponse.SeatBid[0].Bid
+	}
+
+	if bids := run(`{}`); len(bids) != 1 || bids[0].Price != 3.5 {
+		t.Fatalf("expected only the best bid without multibid, got %+v", bids)
+	}
+
+	bids := run(`{"prebid":{"multibid":[{"bidder":"pub","maxbids":2,"targetbiddercodeprefix":"pubm"}]}}`)
+	if len(bids) != 2 {
+		t.Fatalf("expected two bids, got %+v", bids)
+	}
+	var best, extra openrtb.BidExt
+	if err := json.Unmarshal(bids[0].Ext, &best); err != nil {
+		t.Fatalf("invalid bid ext: %v", err)
+	}
+	if err := json.Unmarshal(bids[1].Ext, &extra); err != nil {
+		t.Fatalf("invalid bid ext: %v", err)
+	}
+	if bids[0].Price != 3.5 || best.Prebid.Targeting["hb_pb"] == "" || best.Prebid.TargetBidderCode != "" {
+		t.Errorf("expected the best bid to win under its own code, got %+v", best.Prebid)
+	}
+	if bids[1].Price != 2.5 || extra.Prebid.TargetBidderCode != "pubm2" || extra.Prebid.Targeting["hb_pb_pubm2"] != "2.50" || extra.Prebid.Targeting["hb_bidder_pubm2"] != "pubm2" {
+		t.Errorf("expected the second bid targeted as pubm2, got %+v", extra.Prebid)
+	}
+	if _, ok := extra.Prebid.Targeting["hb_pb"]; ok {
+		t.Errorf("expected no winner keys on the extra bid, got %v", extra.Prebid.Targeting)
+	}
+}
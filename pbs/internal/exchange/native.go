@@ -0,0 +1,54 @@
+package exchange
+
+import (
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/native"
+)
+
+// Native validation modes, for native bids whose adm doesn't fulfil the imp's
+// native request
+const (
+	NativeValidationOff    = ""       // Native bids aren't checked
+	NativeValidationFlag   = "flag"   // Malformed native bids are reported but kept
+	NativeValidationReject = "reject" // Malformed native bids are rejected
+)
+
+// buildImpNativeMap parses each native imp's request. Imps whose request
+// doesn't parse map to nil, so their bids' responses are checked on their own.
+func buildImpNativeMap(req *openrtb.BidRequest) map[string]*native.Request {
+	var impNative map[string]*native.Request
+	for i := range req.Imp {
+		imp := &req.Imp[i]
+		if imp.Native == nil {
+			continue
+		}
+		if impNative == nil {
+			impNative = make(map[string]*native.Request)
+		}
+		nativeReq, _ := native.ParseRequest(imp.Native.Request)
+		impNative[imp.ID] = nativeReq
+	}
+	return impNative
+}
+
+// validateNativeBid checks a native bid's adm against its imp's native
+// request. Bids without markup are served from their nurl and pass.
+func validateNativeBid(tb *adapters.TypedBid, bidderCode string, impNative map[string]*native.Request) *BidValidationError {
+	bid := tb.Bid
+	if tb.BidType != adapters.BidTypeNative || bid.AdM == "" {
+		return nil
+	}
+	nativeReq, ok := impNative[bid.ImpID]
+	if !ok {
+		return nil
+	}
+	resp, err := native.ParseResponse(bid.AdM)
+	if err == nil {
+		err = native.Validate(nativeReq, resp)
+	}
+	if err != nil {
+		return &BidValidationError{BidID: bid.ID, ImpID: bid.ImpID, BidderCode: bidderCode, Reason: err.Error()}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package exchange
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+const (
+	testNativeRequest = `{"ver":"1.2","assets":[{"id":1,"required":1,"title":{"len":30}},{"id":2,"required":1,"img":{"type":3}}]}`
+	testNativeAdM     = `{"link":{"url":"https://adv.example"},"assets":[{"id":1,"title":{"text":"Hello"}},{"id":2,"img":{"url":"https://cdn.example/a.jpg"}}]}`
+	testNativeNoImage = `{"link":{"url":"https://adv.example"},"assets":[{"id":1,"title":{"text":"Hello"}}]}`
+)
+
+func TestValidateNativeBid(t *testing.T) {
+	impNative := buildImpNativeMap(&openrtb.BidRequest{Imp: []openrtb.Imp{
+		{ID: "native", Native: &openrtb.Native{Request: testNativeRequest}},
+		{ID: "unparsed", Native: &openrtb.Native{Request: `{"assets":`}},
+		{ID: "banner", Banner: &openrtb.Banner{W: 300, H: 250}},
+	}})
+	if len(impNative) != 2 || impNative["native"] == nil || impNative["unparsed"] != nil {
+		t.Fatalf("expected the native imps mapped, got %v", impNative)
+	}
+
+	tests := []struct {
+		name    string
+		bidType adapters.BidType
+		bid     openrtb.Bid
+		wantErr bool
+	}{
+		{"complete", adapters.BidTypeNative, openrtb.Bid{ImpID: "native", AdM: testNativeAdM}, false},
+		{"missing required asset", adapters.BidTypeNative, openrtb.Bid{ImpID: "native", AdM: testNativeNoImage}, true},
+		{"not JSON", adapters.BidTypeNative, openrtb.Bid{ImpID: "native", AdM: "<div/>"}, true},
+		{"nurl served", adapters.BidTypeNative, openrtb.Bid{ImpID: "native", NURL: "https://ads.example/native"}, false},
+		{"unparsed request checks the link", adapters.BidTypeNative, openrtb.Bid{ImpID: "unparsed", AdM: testNativeNoImage}, false},
+		{"banner bid", adapters.BidTypeBanner, openrtb.Bid{ImpID: "banner", AdM: "<div/>"}, false},
+	}
+	for _, tt := range tests {
+		err := validateNativeBid(&adapters.TypedBid{Bid: &tt.bid, BidType: tt.bidType}, "bidder", impNative)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: expected error %v, got %v", tt.name, tt.wantErr, err)
+		}
+	}
+}
+
+func TestRunAuction_NativeValidation(t *testing.T) {
+	newBidder := func(id string, price float64, adm string) *mockAdapter {
+		return &mockAdapter{
+			requests: []*adapters.RequestData{{Method: "MOCK", Body: []byte(`{}`)}},
+			bids: []*adapters.TypedBid{{
+				Bid:     &openrtb.Bid{ID: id, ImpID: "imp1", Price: price, AdM: adm},
+				BidType: adapters.BidTypeNative,
+			}},
+		}
+	}
+
+	for _, mode := range []string{NativeValidationFlag, NativeValidationReject} {
+		registry := adapters.NewRegistry()
+		registry.Register("broken", newBidder("broken-bid", 5.00, testNativeNoImage), adapters.BidderInfo{Enabled: true, DemandType: adapters.DemandTypePublisher})
+		registry.Register("native", newBidder("native-bid", 2.00, testNativeAdM), adapters.BidderInfo{Enabled: true, DemandType: adapters.DemandTypePublisher})
+		ex := New(registry, &Config{DefaultTimeout: time.Second, DefaultCurrency: "USD", AuctionType: FirstPriceAuction, NativeValidation: mode})
+		metrics := &creativeMetricsRecorder{}
+		ex.SetCreativeMetrics(metrics)
+
+		req := &openrtb.BidRequest{
+			ID:   "native-req",
+			Site: testSite(),
+			Imp:  []openrtb.Imp{{ID: "imp1", Native: &openrtb.Native{Request: testNativeRequest, Ver: "1.2"}}},
+		}
+		resp, err := ex.RunAuction(context.Background(), &AuctionRequest{BidRequest: req})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", mode, err)
+		}
+
+		errs := resp.DebugInfo.Errors["broken"]
+		if len(errs) != 1 || !strings.Contains(errs[0], "required native img asset 2 missing") {
+			t.Errorf("%s: expected the missing asset reported, got %v", mode, resp.DebugInfo.Errors)
+		}
+		wantSeats, wantRejected := 2, 0
+		if mode == NativeValidationReject {
+			wantSeats, wantRejected = 1, 1
+		}
+		if len(resp.BidResponse.SeatBid) != wantSeats {
+			t.Errorf("%s: expected %d seats, got %+v", mode, wantSeats, resp.BidResponse.SeatBid)
+		}
+		if metrics.rejected["broken/invalid_native"] != wantRejected {
+			t.Errorf("%s: expected %d invalid_native rejections, got %v", mode, wantRejected, metrics.rejected)
+		}
+	}
+}
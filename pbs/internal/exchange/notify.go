@@ -0,0 +1,167 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/logger"
+)
+
+// Notice URL kinds, labelling metrics
+const (
+	NoticeNURL = "nurl" // Win notice
+	NoticeBURL = "burl" // Billing notice
+)
+
+// Notice URL firing results, labelling metrics
+const (
+	NoticeSuccess = "success"
+	NoticeFailure = "failure" // Failed after every retry
+	NoticeDropped = "dropped" // Too many notices already in flight
+)
+
+// NotifierConfig controls how notice URLs are fired
+type NotifierConfig struct {
+	Timeout      time.Duration // Per attempt
+	Retries      int           // Further attempts after a network error or 5xx
+	RetryBackoff time.Duration // Wait before the first retry, doubling after each
+	MaxInFlight  int           // Notices being fired at once; more are dropped
+}
+
+// DefaultNotifierConfig returns the default notice firing configuration
+func DefaultNotifierConfig() *NotifierConfig {
+	return &NotifierConfig{
+		Timeout:      2 * time.Second,
+		Retries:      2,
+		RetryBackoff: 100 * time.Millisecond,
+		MaxInFlight:  256,
+	}
+}
+
+// NoticeMetrics counts fired notice URLs by bidder, kind and result
+type NoticeMetrics interface {
+	RecordNoticeURL(bidder, kind, result string)
+}
+
+// Notifier fires bidders' nurl and burl notice URLs server-side, in the
+// background, so auctions and event notifications never wait on them
+type Notifier struct {
+	config   *NotifierConfig
+	client   *http.Client
+	inFlight chan struct{}
+	metrics  NoticeMetrics
+}
+
+// NewNotifier creates a notifier (DefaultNotifierConfig when config is nil)
+func NewNotifier(config *NotifierConfig) *Notifier {
+	defaults := DefaultNotifierConfig()
+	if config == nil {
+		config = defaults
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaults.Timeout
+	}
+	if config.Retries < 0 {
+		config.Retries = 0
+	}
+	if config.MaxInFlight <= 0 {
+		config.MaxInFlight = defaults.MaxInFlight
+	}
+	return &Notifier{
+		config:   config,
+		client:   &http.Client{Timeout: config.Timeout},
+		inFlight: make(chan struct{}, config.MaxInFlight),
+	}
+}
+
+// SetMetrics sets the notice metrics recorder; call before firing
+func (n *Notifier) SetMetrics(m NoticeMetrics) {
+	n.metrics = m
+}
+
+// Fire calls a bid's notice URL of the given kind in the background, with
+// the auction macros expanded. Notices over MaxInFlight are dropped rather
+// than queued, so a slow bidder can't pile up goroutines.
+func (n *Notifier) Fire(kind string, notice *BidNotice, noticeURL string) {
+	select {
+	case n.inFlight <- struct{}{}:
+	default:
+		logger.Log.Warn().Str("bidder", notice.Bidder).Str("kind", kind).Msg("Dropped notice URL, too many in flight")
+		n.record(notice.Bidder, kind, NoticeDropped)
+		return
+	}
+	noticeURL = ExpandAuctionMacros(noticeURL, notice)
+	go func() {
+		defer func() { <-n.inFlight }()
+		result := NoticeSuccess
+		if err := n.send(noticeURL); err != nil {
+			logger.Log.Debug().Err(err).Str("bidder", notice.Bidder).Str("kind", kind).Msg("Notice URL failed")
+			result = NoticeFailure
+		}
+		n.record(notice.Bidder, kind, result)
+	}()
+}
+
+// send calls noticeURL, retrying network errors and 5xx responses
+func (n *Notifier) send(noticeURL string) error {
+	backoff := n.config.RetryBackoff
+	var err error
+	for attempt := 0; attempt <= n.config.Retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+		var retry bool
+		if retry, err = n.attempt(noticeURL); !retry {
+			return err
+		}
+	}
+	return err
+}
+
+// attempt calls noticeURL once, reporting whether a failure is worth retrying
+func (n *Notifier) attempt(noticeURL string) (retry bool, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), n.config.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, noticeURL, nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode >= 500:
+		return true, fmt.Errorf("notice URL returned status %d", resp.StatusCode)
+	case resp.StatusCode >= 400:
+		return false, fmt.Errorf("notice URL returned status %d", resp.StatusCode)
+	}
+	return false, nil
+}
+
+func (n *Notifier) record(bidder, kind, result string) {
+	if n.metrics != nil {
+		n.metrics.RecordNoticeURL(bidder, kind, result)
+	}
+}
+
+// ExpandAuctionMacros substitutes the OpenRTB auction macros in a notice URL
+func ExpandAuctionMacros(noticeURL string, notice *BidNotice) string {
+	if !strings.Contains(noticeURL, "${") {
+		return noticeURL
+	}
+	return strings.NewReplacer(
+		"${AUCTION_PRICE}", strconv.FormatFloat(notice.Price, 'f', -1, 64),
+		"${AUCTION_CURRENCY}", notice.Currency,
+		"${AUCTION_ID}", notice.AuctionID,
+		"${AUCTION_BID_ID}", notice.BidID,
+		"${AUCTION_IMP_ID}", notice.ImpID,
+		"${AUCTION_SEAT_ID}", notice.Bidder,
+	).Replace(noticeURL)
+}
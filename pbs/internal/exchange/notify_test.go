@@ -0,0 +1,139 @@
+package exchange
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+// noticeResults collects notice metrics as they're recorded
+type noticeResults chan string
+
+func (c noticeResults) RecordNoticeURL(bidder, kind, result string) {
+	c <- bidder + "/" + kind + "/" + result
+}
+
+func (c noticeResults) next(t *testing.T) string {
+	t.Helper()
+	select {
+	case result := <-c:
+		return result
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected a notice result")
+		return ""
+	}
+}
+
+func testNotifier(results noticeResults) *Notifier {
+	n := NewNotifier(&NotifierConfig{Timeout: time.Second, Retries: 2, RetryBackoff: time.Millisecond})
+	n.SetMetrics(results)
+	return n
+}
+
+func TestNotifier_Retries(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/flaky" && calls.Add(1) < 3:
+			w.WriteHeader(http.StatusBadGateway)
+		case r.URL.Path == "/gone":
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	results := make(noticeResults, 4)
+	n := testNotifier(results)
+	notice := &BidNotice{Bidder: "appnexus"}
+
+	n.Fire(NoticeNURL, notice, server.URL+"/flaky")
+	if got := results.next(t); got != "appnexus/nurl/success" || calls.Load() != 3 {
+		t.Errorf("expected success on the third attempt, got %s after %d calls", got, calls.Load())
+	}
+	n.Fire(NoticeBURL, notice, server.URL+"/gone")
+	if got := results.next(t); got != "appnexus/burl/failure" {
+		t.Errorf("expected a 4xx to fail without retrying, got %s", got)
+	}
+}
+
+func TestNotifier_DropsOverMaxInFlight(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	results := make(noticeResults, 4)
+	n := NewNotifier(&NotifierConfig{Timeout: time.Second, MaxInFlight: 1})
+	n.SetMetrics(results)
+	n.Fire(NoticeNURL, &BidNotice{Bidder: "slow"}, server.URL)
+	n.Fire(NoticeNURL, &BidNotice{Bidder: "slow"}, server.URL)
+	if got := results.next(t); got != "slow/nurl/dropped" {
+		t.Errorf("expected the second notice dropped, got %s", got)
+	}
+}
+
+func TestExpandAuctionMacros(t *testing.T) {
+	notice := &BidNotice{AuctionID: "a1", ImpID: "imp1", BidID: "b1", Bidder: "appnexus", Price: 1.25, Currency: "USD"}
+	got := ExpandAuctionMacros("https://b.example/win?p=${AUCTION_PRICE}&a=${AUCTION_ID}&b=${AUCTION_BID_ID}&s=${AUCTION_SEAT_ID}&c=${AUCTION_CURRENCY}&i=${AUCTION_IMP_ID}", notice)
+	if want := "https://b.example/win?p=1.25&a=a1&b=b1&s=appnexus&c=USD&i=imp1"; got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
+func TestRunAuction_FiresWinNotices(t *testing.T) {
+	fired := make(chan string, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fired <- r.URL.RequestURI()
+	}))
+	defer server.Close()
+
+	bid := func(id string, price float64) *adapters.TypedBid {
+		return &adapters.TypedBid{
+			Bid:     &openrtb.Bid{ID: id, ImpID: "imp1", Price: price, AdM: "<div/>", NURL: server.URL + "/win/" + id + "?p=${AUCTION_PRICE}"},
+			BidType: adapters.BidTypeBanner,
+		}
+	}
+	registry := adapters.NewRegistry()
+	registry.Register("high", &mockAdapter{requests: []*adapters.RequestData{{Method: "MOCK"}}, bids: []*adapters.TypedBid{bid("h", 3)}},
+		adapters.BidderInfo{Enabled: true, DemandType: adapters.DemandTypePublisher})
+	registry.Register("low", &mockAdapter{requests: []*adapters.RequestData{{Method: "MOCK"}}, bids: []*adapters.TypedBid{bid("l", 1)}},
+		adapters.BidderInfo{Enabled: true, DemandType: adapters.DemandTypePublisher})
+	results := make(noticeResults, 4)
+	ex := New(registry, &Config{DefaultTimeout: time.Second, DefaultCurrency: "USD", WinNotifier: testNotifier(results)})
+
+	resp, err := ex.RunAuction(context.Background(), &AuctionRequest{BidRequest: &openrtb.BidRequest{
+		ID:   "notice-1",
+		Site: testSite(),
+		Imp:  []openrtb.Imp{{ID: "imp1", Banner: &openrtb.Banner{W: 300, H: 250}}},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := results.next(t); got != "high/nurl/success" {
+		t.Errorf("expected the winner's nurl fired, got %s", got)
+	}
+	if uri := <-fired; uri != "/win/h?p=3" {
+		t.Errorf("expected the price macro expanded, got %s", uri)
+	}
+	select {
+	case uri := <-fired:
+		t.Errorf("expected only the winner's nurl fired, got %s", uri)
+	case <-time.After(50 * time.Millisecond):
+	}
+	for _, sb := range resp.BidResponse.SeatBid {
+		for _, b := range sb.Bid {
+			if (b.ID == "h") == (b.NURL != "") {
+				t.Errorf("expected only the fired nurl left out of the response, bid %s has %q", b.ID, b.NURL)
+			}
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+// splittingAdapter sends a request per imp and bids the imp's ID back
+type splittingAdapter struct{}
+
+func (a *splittingAdapter) MakeRequests(request *openrtb.BidRequest, reqInfo *adapters.ExtraRequestInfo) ([]*adapters.RequestData, []error) {
+	requests := make([]*adapters.RequestData, 0, len(request.Imp))
+	for _, imp := range request.Imp {
+		requests = append(requests, &adapters.RequestData{
+			Method: "POST",
+			URI:    "http://test.bidder.com/bid",
+			Body:   []byte(imp.ID),
+		})
+	}
+	return requests, nil
+}
+
+func (a *splittingAdapter) MakeBids(request *openrtb.BidRequest, response *adapters.ResponseData) (*adapters.BidderResponse, []error) {
+	impID := string(response.Body)
+	return &adapters.BidderResponse{Bids: []*adapters.TypedBid{{
+		Bid:     &openrtb.Bid{ID: "bid-" + impID, ImpID: impID, Price: 1},
+		BidType: adapters.BidTypeBanner,
+	}}}, nil
+}
+
+// slowHTTPClient answers with the request body after a delay, the first
+// request slowest, and keeps the most requests it saw in flight at once
+type slowHTTPClient struct {
+	delay time.Duration
+
+	mu       sync.Mutex
+	calls    int
+	inFlight int
+	maxAtOne int
+}
+
+func (c *slowHTTPClient) Do(ctx context.Context, req *adapters.RequestData, timeout time.Duration) (*adapters.ResponseData, error) {
+	c.mu.Lock()
+	delay := c.delay
+	if c.calls == 0 {
+		delay *= 2
+	}
+	c.calls++
+	c.inFlight++
+	c.maxAtOne = max(c.maxAtOne, c.inFlight)
+	c.mu.Unlock()
+
+	time.Sleep(delay)
+
+	c.mu.Lock()
+	c.inFlight--
+	c.mu.Unlock()
+	return &adapters.ResponseData{StatusCode: 200, Body: req.Body}, nil
+}
+
+func splitRequest(imps int) *openrtb.BidRequest {
+	req := &openrtb.BidRequest{ID: "req1", Site: testSite()}
+	for i := 0; i < imps; i++ {
+		req.Imp = append(req.Imp, openrtb.Imp{ID: fmt.Sprintf("imp%d", i), Banner: &openrtb.Banner{W: 300, H: 250}})
+	}
+	return req
+}
+
+func TestCallBidder_ParallelRequestsKeepOrder(t *testing.T) {
+	registry := adapters.NewRegistry()
+	registry.Register("splitter", &splittingAdapter{}, adapters.BidderInfo{Enabled: true})
+	ex := New(registry, &Config{DefaultTimeout: time.Second, MaxParallelBidderRequests: 3})
+	client := &slowHTTPClient{delay: 20 * time.Millisecond}
+	ex.httpClient = client
+
+	results := ex.callBidders(context.Background(), splitRequest(6), []string{"splitter"}, time.Second)
+
+	r := results["splitter"]
+	if r == nil || len(r.Bids) != 6 {
+		t.Fatalf("expected a bid per request, got %+v", r)
+	}
+	for i, bid := range r.Bids {
+		if want := fmt.Sprintf("imp%d", i); bid.Bid.ImpID != want {
+			t.Errorf("bid %d: expected %s in request order, got %s", i, want, bid.Bid.ImpID)
+		}
+	}
+	if client.maxAtOne != 3 {
+		t.Errorf("expected 3 requests in flight at once, got %d", client.maxAtOne)
+	}
+}
+
+func TestCallBidder_OneParallelRequestIsSequential(t *testing.T) {
+	registry := adapters.NewRegistry()
+	registry.Register("splitter", &splittingAdapter{}, adapters.BidderInfo{Enabled: true})
+	ex := New(registry, &Config{DefaultTimeout: time.Second, MaxParallelBidderRequests: 1})
+	client := &slowHTTPClient{delay: 5 * time.Millisecond}
+	ex.httpClient = client
+
+	results := ex.callBidders(context.Background(), splitRequest(3), []string{"splitter"}, time.Second)
+
+	if r := results["splitter"]; r == nil || len(r.Bids) != 3 {
+		t.Fatalf("expected a bid per request, got %+v", r)
+	}
+	if client.maxAtOne != 1 {
+		t.Errorf("expected one request at a time, got %d", client.maxAtOne)
+	}
+}
+
+func TestCallBidder_ExpiredRequestsKeepEarlierBids(t *testing.T) {
+	registry := adapters.NewRegistry()
+	registry.Register("splitter", &splittingAdapter{}, adapters.BidderInfo{Enabled: true})
+	ex := New(registry, &Config{DefaultTimeout: time.Second, MaxParallelBidderRequests: 1})
+	ex.httpClient = &slowHTTPClient{delay: 30 * time.Millisecond}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 40*time.Millisecond)
+	defer cancel()
+	results := ex.callBidders(ctx, splitRequest(3), []string{"splitter"}, 40*time.Millisecond)
+
+	r := results["splitter"]
+	if r == nil || !r.TimedOut {
+		t.Fatalf("expected the bidder to time out, got %+v", r)
+	}
+	if len(r.Bids) != 1 || r.Bids[0].Bid.ImpID != "imp0" {
+		t.Errorf("expected the bid from the request that was sent, got %+v", r.Bids)
+	}
+}
@@ -0,0 +1,44 @@
+package exchange
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+// PrebidPassthrough returns ext.prebid.passthrough from a request or imp ext.
+// Clients use it to tie auctions back to their own context objects, so it is
+// echoed into the response byte for byte and never interpreted. Absent, null
+// or unparseable ext yields nil.
+func PrebidPassthrough(ext json.RawMessage) json.RawMessage {
+	if len(ext) == 0 {
+		return nil
+	}
+	var parsed struct {
+		Prebid *struct {
+			Passthrough json.RawMessage `json:"passthrough"`
+		} `json:"prebid"`
+	}
+	if err := json.Unmarshal(ext, &parsed); err != nil || parsed.Prebid == nil {
+		return nil
+	}
+	if len(parsed.Prebid.Passthrough) == 0 || bytes.Equal(parsed.Prebid.Passthrough, []byte("null")) {
+		return nil
+	}
+	return parsed.Prebid.Passthrough
+}
+
+// impPassthroughs maps imp ID to the imp's ext.prebid.passthrough, for echoing on its bids
+func impPassthroughs(imps []openrtb.Imp) map[string]json.RawMessage {
+	var passthroughs map[string]json.RawMessage
+	for i := range imps {
+		if p := PrebidPassthrough(imps[i].Ext); p != nil {
+			if passthroughs == nil {
+				passthroughs = make(map[string]json.RawMessage)
+			}
+			passthroughs[imps[i].ID] = p
+		}
+	}
+	return passthroughs
+}
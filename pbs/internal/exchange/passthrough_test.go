@@ -0,0 +1,74 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+func TestPrebidPassthrough(t *testing.T) {
+	tests := []struct {
+		ext  string
+		want string
+	}{
+		{`{"prebid":{"passthrough":{"ctx":{"id":7,"tags":["a"]}}}}`, `{"ctx":{"id":7,"tags":["a"]}}`},
+		{`{"prebid":{"passthrough":"opaque"},"other":1}`, `"opaque"`},
+		{`{"prebid":{"passthrough":null}}`, ``},
+		{`{"prebid":{}}`, ``},
+		{`{"gpid":"x"}`, ``},
+		{`not json`, ``},
+		{``, ``},
+	}
+	for _, tt := range tests {
+		if got := PrebidPassthrough(json.RawMessage(tt.ext)); string(got) != tt.want {
+			t.Errorf("PrebidPassthrough(%s) = %s, want %s", tt.ext, got, tt.want)
+		}
+	}
+}
+
+func TestRunAuction_ImpPassthroughEchoedOnBids(t *testing.T) {
+	registry := adapters.NewRegistry()
+	registry.Register("alpha", &mockAdapter{
+		requests: []*adapters.RequestData{{Method: "MOCK"}},
+		bids: []*adapters.TypedBid{
+			{Bid: &openrtb.Bid{ID: "a-1", ImpID: "imp1", Price: 2, AdM: "<div>ad</div>", W: 300, H: 250}, BidType: adapters.BidTypeBanner},
+			{Bid: &openrtb.Bid{ID: "a-2", ImpID: "imp2", Price: 2, AdM: "<div>ad</div>", W: 300, H: 250}, BidType: adapters.BidTypeBanner},
+		},
+	}, adapters.BidderInfo{Enabled: true})
+	ex := New(registry, &Config{DefaultTimeout: 500 * time.Millisecond, DefaultCurrency: "USD"})
+
+	// Key order and values must survive untouched
+	passthrough := `{"z":1,"a":{"adUnit":"top"}}`
+	resp, err := ex.RunAuction(context.Background(), &AuctionRequest{BidRequest: &openrtb.BidRequest{
+		ID:   "passthrough-1",
+		Site: testSite(),
+		Imp: []openrtb.Imp{
+			{ID: "imp1", Banner: &openrtb.Banner{W: 300, H: 250}, Ext: json.RawMessage(`{"prebid":{"passthrough":` + passthrough + `}}`)},
+			{ID: "imp2", Banner: &openrtb.Banner{W: 300, H: 250}},
+		},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := map[string]string{}
+	for _, sb := range resp.BidResponse.SeatBid {
+		for _, bid := range sb.Bid {
+			var ext openrtb.BidExt
+			if err := json.Unmarshal(bid.Ext, &ext); err != nil || ext.Prebid == nil {
+				t.Fatalf("bad bid ext %s: %v", bid.Ext, err)
+			}
+			got[bid.ImpID] = string(ext.Prebid.Passthrough)
+		}
+	}
+	if got["imp1"] != passthrough {
+		t.Errorf("expected imp1 bid to echo %s, got %s", passthrough, got["imp1"])
+	}
+	if v, ok := got["imp2"]; !ok || v != "" {
+		t.Errorf("expected imp2 bid without passthrough, got %q (present=%v)", v, ok)
+	}
+}
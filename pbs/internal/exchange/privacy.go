@@ -0,0 +1,86 @@
+package exchange
+
+import (
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters/ortb"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/middleware"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+// Vendor consent enforcement modes, for bidders whose GVL vendor ID lacks
+// consent in the request's TCF string when GDPR applies
+const (
+	VendorConsentOff   = ""      // Bidders are called regardless of vendor consent
+	VendorConsentSkip  = "skip"  // Bidders without consent aren't called
+	VendorConsentStrip = "strip" // Bidders without consent get no personal data
+)
+
+// privacyFilterGDPR labels bidders filtered for lacking GDPR vendor consent
+const privacyFilterGDPR = "gdpr"
+
+// PrivacyMetrics receives bidders skipped or stripped for privacy reasons
+type PrivacyMetrics interface {
+	RecordPrivacyFiltered(bidder, reason string)
+}
+
+// SetPrivacyMetrics attaches reporting of bidders filtered for lacking vendor consent
+func (e *Exchange) SetPrivacyMetrics(m PrivacyMetrics) {
+	e.configMu.Lock()
+	defer e.configMu.Unlock()
+	e.privacyMetrics = m
+}
+
+// applyVendorConsent finds the bidders whose GVL vendor ID lacks consent when
+// GDPR applies. In skip mode they're dropped from the bidders to call; in strip
+// mode they're still called, and the caller strips their personal data.
+// Bidders that don't declare a GVL vendor ID aren't checked. A missing or
+// unparseable consent string consents to no vendor.
+func (e *Exchange) applyVendorConsent(req *openrtb.BidRequest, bidders []string, dynamicRegistry *ortb.DynamicRegistry, metrics PrivacyMetrics) (called, denied []string) {
+	mode := e.config.GDPRVendorConsent
+	if mode == VendorConsentOff || !middleware.GDPRApplies(req) {
+		return bidders, nil
+	}
+
+	consent, _ := middleware.ParseTCFv2(middleware.GDPRConsent(req))
+	called = bidders[:0:0]
+	for _, code := range bidders {
+		info, _ := e.bidderInfo(code, dynamicRegistry)
+		if info.GVLVendorID == 0 || (consent != nil && consent.HasVendorConsent(info.GVLVendorID)) {
+			called = append(called, code)
+			continue
+		}
+
+		denied = append(denied, code)
+		if metrics != nil {
+			metrics.RecordPrivacyFiltered(code, privacyFilterGDPR)
+		}
+		if mode == VendorConsentStrip {
+			called = append(called, code)
+		}
+	}
+	return called, denied
+}
+
+// coppaUnsupported returns the bidders that don't declare COPPA support
+func (e *Exchange) coppaUnsupported(bidders []string, dynamicRegistry *ortb.DynamicRegistry) []string {
+	var unsupported []string
+	for _, code := range bidders {
+		if info, _ := e.bidderInfo(code, dynamicRegistry); !info.SupportsCOPPA {
+			unsupported = append(unsupported, code)
+		}
+	}
+	return unsupported
+}
+
+// bidderInfo returns a bidder's declared info, checking the static registry first
+func (e *Exchange) bidderInfo(bidderCode string, dynamicRegistry *ortb.DynamicRegistry) (adapters.BidderInfo, bool) {
+	if awi, ok := e.registry.Get(bidderCode); ok {
+		return awi.Info, true
+	}
+	if dynamicRegistry != nil {
+		if da, ok := dynamicRegistry.Get(bidderCode); ok {
+			return da.Info(), true
+		}
+	}
+	return adapters.BidderInfo{}, false
+}
@@ -0,0 +1,177 @@
+package exchange
+
+import (
+	"context"
+	"encoding/base64"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+// consentString encodes a TCF v2 core string consenting to the given vendors
+func consentString(vendors ...int) string {
+	var bits []bool
+	write := func(value, n int) {
+		for i := n - 1; i >= 0; i-- {
+			bits = append(bits, value>>i&1 == 1)
+		}
+	}
+	write(2, 6)    // Version
+	write(0, 207)  // Metadata, purposes and publisher fields
+	write(100, 16) // MaxVendorId
+	write(0, 1)    // Bitfield encoding
+	for id := 1; id <= 100; id++ {
+		bit := 0
+		for _, v := range vendors {
+			if v == id {
+				bit = 1
+			}
+		}
+		write(bit, 1)
+	}
+	data := make([]byte, (len(bits)+7)/8)
+	for i, bit := range bits {
+		if bit {
+			data[i/8] |= 1 << (7 - i%8)
+		}
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// userAdapter records the user and device each request was sent with
+type userAdapter struct {
+	mu     sync.Mutex
+	user   *openrtb.User
+	device *openrtb.Device
+}
+
+func (a *userAdapter) MakeRequests(request *openrtb.BidRequest, reqInfo *adapters.ExtraRequestInfo) ([]*adapters.RequestData, []error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.user, a.device = request.User, request.Device
+	return []*adapters.RequestData{{Method: "MOCK", Body: []byte(`{}`)}}, nil
+}
+
+func (a *userAdapter) MakeBids(request *openrtb.BidRequest, response *adapters.ResponseData) (*adapters.BidderResponse, []error) {
+	return &adapters.BidderResponse{Currency: "USD"}, nil
+}
+
+type fakePrivacyMetrics struct {
+	mu       sync.Mutex
+	filtered map[string]string
+}
+
+func (m *fakePrivacyMetrics) RecordPrivacyFiltered(bidder, reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.filtered[bidder] = reason
+}
+
+func vendorConsentAuction(t *testing.T, mode string, consent string) (*AuctionResponse, map[string]*userAdapter, *fakePrivacyMetrics) {
+	t.Helper()
+	registry := adapters.NewRegistry()
+	bidders := map[string]*userAdapter{}
+	for code, gvlID := range map[string]int{"consented": 32, "denied": 52, "novendor": 0} {
+		bidders[code] = &userAdapter{}
+		registry.Register(code, bidders[code], adapters.BidderInfo{Enabled: true, GVLVendorID: gvlID})
+	}
+	ex := New(registry, &Config{DefaultTimeout: time.Second, DefaultCurrency: "USD", GDPRVendorConsent: mode})
+	metrics := &fakePrivacyMetrics{filtered: map[string]string{}}
+	ex.SetPrivacyMetrics(metrics)
+
+	gdpr := 1
+	resp, err := ex.RunAuction(context.Background(), &AuctionRequest{Diagnostics: true, BidRequest: &openrtb.BidRequest{
+		ID:     "consent-1",
+		Site:   testSite(),
+		Imp:    []openrtb.Imp{{ID: "imp1", Banner: &openrtb.Banner{W: 300, H: 250}}},
+		Regs:   &openrtb.Regs{GDPR: &gdpr},
+		User:   &openrtb.User{ID: "u1", BuyerUID: "buyer-1", Consent: consent},
+		Device: &openrtb.Device{IP: "203.0.113.77", IFA: "ifa-1"},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return resp, bidders, metrics
+}
+
+func TestRunAuction_VendorConsentSkip(t *testing.T) {
+	resp, bidders, metrics := vendorConsentAuction(t, VendorConsentSkip, consentString(32))
+
+	if bidders["denied"].user != nil {
+		t.Error("expected the bidder without vendor consent not called")
+	}
+	if bidders["consented"].user == nil || bidders["novendor"].user == nil {
+		t.Error("expected the consented bidder and the bidder without a GVL vendor ID called")
+	}
+	if len(resp.DebugInfo.ConsentDenied) != 1 || resp.DebugInfo.ConsentDenied[0] != "denied" || metrics.filtered["denied"] != "gdpr" {
+		t.Errorf("expected denied reported, got %v / %v", resp.DebugInfo.ConsentDenied, metrics.filtered)
+	}
+	excluded := false
+	for _, ex := range resp.Diagnostics.Excluded {
+		excluded = excluded || (ex.Bidder == "denied" && ex.Stage == ExclusionPrivacy)
+	}
+	if !excluded {
+		t.Errorf("expected a privacy exclusion in diagnostics, got %+v", resp.Diagnostics.Excluded)
+	}
+}
+
+func TestRunAuction_VendorConsentStrip(t *testing.T) {
+	_, bidders, _ := vendorConsentAuction(t, VendorConsentStrip, consentString(32))
+
+	denied := bidders["denied"]
+	if denied.user == nil {
+		t.Fatal("expected the bidder without vendor consent still called")
+	}
+	if denied.user.ID != "" || denied.user.BuyerUID != "" || denied.device.IFA != "" || denied.device.IP != "203.0.113.0" {
+		t.Errorf("expected personal data stripped, got %+v / %+v", denied.user, denied.device)
+	}
+	if consented := bidders["consented"]; consented.user.BuyerUID != "buyer-1" || consented.device.IP != "203.0.113.77" {
+		t.Errorf("expected the consented bidder's request untouched, got %+v / %+v", consented.user, consented.device)
+	}
+}
+
+func TestRunAuction_VendorConsentMissingConsent(t *testing.T) {
+	resp, bidders, _ := vendorConsentAuction(t, VendorConsentSkip, "")
+	if bidders["consented"].user != nil || bidders["denied"].user != nil || bidders["novendor"].user == nil {
+		t.Errorf("expected only the bidder without a GVL vendor ID called, denied %v", resp.DebugInfo.ConsentDenied)
+	}
+
+	_, bidders, metrics := vendorConsentAuction(t, VendorConsentOff, "")
+	if bidders["denied"].user == nil || len(metrics.filtered) != 0 {
+		t.Error("expected every bidder called with enforcement off")
+	}
+}
+
+func TestRunAuction_FlagsCOPPAUnsupported(t *testing.T) {
+	registry := adapters.NewRegistry()
+	registry.Register("kidsafe", &userAdapter{}, adapters.BidderInfo{Enabled: true, SupportsCOPPA: true})
+	registry.Register("general", &userAdapter{}, adapters.BidderInfo{Enabled: true})
+	ex := New(registry, &Config{DefaultTimeout: time.Second, DefaultCurrency: "USD"})
+
+	request := func(coppa int) *AuctionRequest {
+		return &AuctionRequest{BidRequest: &openrtb.BidRequest{
+			ID:   "coppa-1",
+			Site: testSite(),
+			Imp:  []openrtb.Imp{{ID: "imp1", Banner: &openrtb.Banner{W: 300, H: 250}}},
+			Regs: &openrtb.Regs{COPPA: coppa},
+		}}
+	}
+	resp, err := ex.RunAuction(context.Background(), request(1))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.DebugInfo.COPPAUnsupported) != 1 || resp.DebugInfo.COPPAUnsupported[0] != "general" {
+		t.Errorf("expected general flagged, got %v", resp.DebugInfo.COPPAUnsupported)
+	}
+	if len(resp.DebugInfo.SelectedBidders) != 2 {
+		t.Errorf("expected flagged bidders still called, got %v", resp.DebugInfo.SelectedBidders)
+	}
+
+	resp, _ = ex.RunAuction(context.Background(), request(0))
+	if len(resp.DebugInfo.COPPAUnsupported) != 0 {
+		t.Errorf("expected nothing flagged outside COPPA, got %v", resp.DebugInfo.COPPAUnsupported)
+	}
+}
@@ -0,0 +1,68 @@
+package exchange
+
+import (
+	"context"
+	"time"
+)
+
+// MiddlewareOverheadMetrics receives the time a request spent in the HTTP
+// middleware chain before its auction started
+type MiddlewareOverheadMetrics interface {
+	RecordMiddlewareOverhead(overhead time.Duration)
+}
+
+type requestStartKey struct{}
+
+// WithRequestStart records when the server received a request. Set it in the
+// outermost middleware so the auction timeout accounts for time already spent
+// on body parsing, auth and the rest of the chain.
+func WithRequestStart(ctx context.Context, start time.Time) context.Context {
+	return context.WithValue(ctx, requestStartKey{}, start)
+}
+
+// RequestStartFromContext returns the time set by WithRequestStart
+func RequestStartFromContext(ctx context.Context) (time.Time, bool) {
+	start, ok := ctx.Value(requestStartKey{}).(time.Time)
+	return start, ok && !start.IsZero()
+}
+
+// remainingBudget takes the time elapsed since the request start from an
+// auction timeout, never going below minBidderTimeout. Returns the reduced
+// timeout and the elapsed middleware overhead (0 without a request start).
+func remainingBudget(ctx context.Context, timeout time.Duration, auctionStart time.Time) (time.Duration, time.Duration) {
+	start, ok := RequestStartFromContext(ctx)
+	if !ok {
+		return timeout, 0
+	}
+	overhead := auctionStart.Sub(start)
+	if overhead <= 0 {
+		return timeout, 0
+	}
+	remaining := timeout - overhead
+	if remaining < minBidderTimeout {
+		remaining = minBidderTimeout
+	}
+	return remaining, overhead
+}
+
+// bidderTMax returns the tmax to send a bidder: the time left before we stop
+// waiting for it (the sooner of ctx's deadline and timeout from now), less
+// buffer for the network round trip. It never raises the request's own tmax
+// and never goes below minBidderTimeout.
+func bidderTMax(ctx context.Context, tmax int, timeout, buffer time.Duration) int {
+	remaining := timeout
+	if deadline, ok := ctx.Deadline(); ok {
+		if left := time.Until(deadline); left < remaining {
+			remaining = left
+		}
+	}
+	remaining -= buffer
+	if remaining < minBidderTimeout {
+		remaining = minBidderTimeout
+	}
+	ms := int(remaining.Milliseconds())
+	if tmax > 0 && tmax < ms {
+		return tmax
+	}
+	return ms
+}
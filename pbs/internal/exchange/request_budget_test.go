@@ -0,0 +1,161 @@
+package exchange
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+func TestRemainingBudget(t *testing.T) {
+	now := time.Now()
+
+	if timeout, overhead := remainingBudget(context.Background(), 200*time.Millisecond, now); timeout != 200*time.Millisecond || overhead != 0 {
+		t.Errorf("expected full timeout without a request start, got %v / %v", timeout, overhead)
+	}
+
+	ctx := WithRequestStart(context.Background(), now.Add(-30*time.Millisecond))
+	if timeout, overhead := remainingBudget(ctx, 200*time.Millisecond, now); timeout != 170*time.Millisecond || overhead != 30*time.Millisecond {
+		t.Errorf("expected 170ms left after 30ms overhead, got %v / %v", timeout, overhead)
+	}
+
+	ctx = WithRequestStart(context.Background(), now.Add(-time.Second))
+	if timeout, _ := remainingBudget(ctx, 200*time.Millisecond, now); timeout != minBidderTimeout {
+		t.Errorf("expected an exhausted budget to clamp to %v, got %v", minBidderTimeout, timeout)
+	}
+
+	ctx = WithRequestStart(context.Background(), now.Add(time.Second))
+	if timeout, overhead := remainingBudget(ctx, 200*time.Millisecond, now); timeout != 200*time.Millisecond || overhead != 0 {
+		t.Errorf("expected a start in the future to be ignored, got %v / %v", timeout, overhead)
+	}
+}
+
+func TestRequestStartFromContext(t *testing.T) {
+	if _, ok := RequestStartFromContext(context.Background()); ok {
+		t.Error("expected no request start")
+	}
+	if _, ok := RequestStartFromContext(WithRequestStart(context.Background(), time.Time{})); ok {
+		t.Error("expected a zero request start to be ignored")
+	}
+	start := time.Now()
+	if got, ok := RequestStartFromContext(WithRequestStart(context.Background(), start)); !ok || !got.Equal(start) {
+		t.Errorf("expected %v, got %v", start, got)
+	}
+}
+
+// deadlineHTTPClient keeps the deadline of the context a bidder call ran under
+type deadlineHTTPClient struct {
+	mu       sync.Mutex
+	deadline time.Time
+}
+
+func (c *deadlineHTTPClient) Do(ctx context.Context, req *adapters.RequestData, timeout time.Duration) (*adapters.ResponseData, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deadline, _ = ctx.Deadline()
+	return &adapters.ResponseData{StatusCode: 204}, nil
+}
+
+type mockOverheadMetrics struct {
+	mu       sync.Mutex
+	observed []time.Duration
+}
+
+func (m *mockOverheadMetrics) RecordMiddlewareOverhead(overhead time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.observed = append(m.observed, overhead)
+}
+
+func TestRunAuction_DeductsMiddlewareOverhead(t *testing.T) {
+	registry := adapters.NewRegistry()
+	registry.Register("bidder", &mockAdapter{}, adapters.BidderInfo{Enabled: true})
+
+	client := &deadlineHTTPClient{}
+	ex := New(registry, &Config{DefaultTimeout: time.Second, DefaultCurrency: "USD"})
+	ex.httpClient = client
+	metrics := &mockOverheadMetrics{}
+	ex.SetMiddlewareOverheadMetrics(metrics)
+
+	req := &openrtb.BidRequest{
+		ID:   "budget",
+		Imp:  []openrtb.Imp{{ID: "imp1", Banner: &openrtb.Banner{W: 300, H: 250}}},
+		Site: testSite(),
+		TMax: 500,
+	}
+	before := time.Now()
+	ctx := WithRequestStart(context.Background(), before.Add(-200*time.Millisecond))
+	resp, err := ex.RunAuction(ctx, &AuctionRequest{BidRequest: req})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if resp.DebugInfo.MiddlewareOverhead < 200*time.Millisecond {
+		t.Errorf("expected at least 200ms overhead, got %v", resp.DebugInfo.MiddlewareOverhead)
+	}
+	if client.deadline.IsZero() || client.deadline.Sub(before) > 350*time.Millisecond {
+		t.Errorf("expected the bidder deadline near the remaining 300ms, got %v", client.deadline.Sub(before))
+	}
+	if len(metrics.observed) != 1 || metrics.observed[0] < 200*time.Millisecond {
+		t.Errorf("expected the overhead recorded once, got %v", metrics.observed)
+	}
+}
+
+func TestBidderTMax(t *testing.T) {
+	if got := bidderTMax(context.Background(), 0, 300*time.Millisecond, 20*time.Millisecond); got != 280 {
+		t.Errorf("expected timeout less buffer, got %d", got)
+	}
+	if got := bidderTMax(context.Background(), 100, 300*time.Millisecond, 20*time.Millisecond); got != 100 {
+		t.Errorf("expected the request's lower tmax kept, got %d", got)
+	}
+	if got := bidderTMax(context.Background(), 0, 15*time.Millisecond, 20*time.Millisecond); got != int(minBidderTimeout.Milliseconds()) {
+		t.Errorf("expected clamp to the minimum, got %d", got)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	if got := bidderTMax(ctx, 0, time.Second, 20*time.Millisecond); got > 130 || got < 100 {
+		t.Errorf("expected the context deadline to bound tmax near 130, got %d", got)
+	}
+}
+
+// tmaxAdapter keeps the tmax of the request it was asked to build
+type tmaxAdapter struct {
+	mockAdapter
+	tmax int
+}
+
+func (a *tmaxAdapter) MakeRequests(request *openrtb.BidRequest, reqInfo *adapters.ExtraRequestInfo) ([]*adapters.RequestData, []error) {
+	a.tmax = request.TMax
+	return a.mockAdapter.MakeRequests(request, reqInfo)
+}
+
+func TestRunAuction_BidderTMax(t *testing.T) {
+	adapter := &tmaxAdapter{}
+	registry := adapters.NewRegistry()
+	registry.Register("bidder", adapter, adapters.BidderInfo{Enabled: true})
+	ex := New(registry, &Config{DefaultTimeout: time.Second, DefaultCurrency: "USD", TMaxNetworkBuffer: 50 * time.Millisecond})
+	ex.httpClient = &deadlineHTTPClient{}
+
+	req := &openrtb.BidRequest{
+		ID:   "tmax",
+		Imp:  []openrtb.Imp{{ID: "imp1", Banner: &openrtb.Banner{W: 300, H: 250}}},
+		Site: testSite(),
+		TMax: 500,
+	}
+	ctx := WithRequestStart(context.Background(), time.Now().Add(-200*time.Millisecond))
+	if _, err := ex.RunAuction(ctx, &AuctionRequest{BidRequest: req}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 500ms, less 200ms already spent and the 50ms buffer
+	if adapter.tmax > 250 || adapter.tmax < 200 {
+		t.Errorf("expected the bidder's tmax near 250, got %d", adapter.tmax)
+	}
+	if req.TMax != 500 {
+		t.Errorf("expected the publisher's request left alone, got tmax %d", req.TMax)
+	}
+}
@@ -0,0 +1,103 @@
+package exchange
+
+import (
+	"encoding/json"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+// RequestSizeMetrics receives the size of each bidder's outbound requests and
+// the bytes request shaping kept out of them
+type RequestSizeMetrics interface {
+	RecordBidderRequestSize(bidder string, bytes, savedBytes int)
+}
+
+// shapeRequest trims a bidder's request copy to what the bidder uses: imp
+// media objects for types it doesn't support on this platform, imps left with
+// no supported type, and the fields listed in info.IgnoredFields. Returns the
+// approximate serialized bytes removed; the request has no imps left if the
+// bidder supports none of them.
+func shapeRequest(req *openrtb.BidRequest, info adapters.BidderInfo) int {
+	saved := 0
+	isApp := req.App != nil
+	unsupported := func(mediaType adapters.BidType) bool {
+		return !info.SupportsMediaType(isApp, mediaType)
+	}
+
+	imps := req.Imp[:0]
+	for _, imp := range req.Imp {
+		if imp.Banner != nil && unsupported(adapters.BidTypeBanner) {
+			saved += jsonSize(imp.Banner)
+			imp.Banner = nil
+		}
+		if imp.Video != nil && unsupported(adapters.BidTypeVideo) {
+			saved += jsonSize(imp.Video)
+			imp.Video = nil
+		}
+		if imp.Audio != nil && unsupported(adapters.BidTypeAudio) {
+			saved += jsonSize(imp.Audio)
+			imp.Audio = nil
+		}
+		if imp.Native != nil && unsupported(adapters.BidTypeNative) {
+			saved += jsonSize(imp.Native)
+			imp.Native = nil
+		}
+		if imp.Banner == nil && imp.Video == nil && imp.Audio == nil && imp.Native == nil {
+			saved += jsonSize(imp)
+			continue
+		}
+		imps = append(imps, imp)
+	}
+	req.Imp = imps
+
+	for _, field := range info.IgnoredFields {
+		switch field {
+		case adapters.IgnoredSiteContent:
+			if req.Site != nil && req.Site.Content != nil {
+				saved += jsonSize(req.Site.Content)
+				req.Site.Content = nil
+			}
+		case adapters.IgnoredAppContent:
+			if req.App != nil && req.App.Content != nil {
+				saved += jsonSize(req.App.Content)
+				req.App.Content = nil
+			}
+		case adapters.IgnoredDeviceExt:
+			if req.Device != nil && req.Device.Ext != nil {
+				saved += len(req.Device.Ext)
+				req.Device.Ext = nil
+			}
+		case adapters.IgnoredDeviceLegacyIDs:
+			if d := req.Device; d != nil {
+				for _, id := range []*string{&d.IDSHA1, &d.IDMD5, &d.DPIDSHA1, &d.DPIDMD5, &d.MacSHA1, &d.MacMD5} {
+					saved += len(*id)
+					*id = ""
+				}
+			}
+		case adapters.IgnoredUserData:
+			if req.User != nil && req.User.Data != nil {
+				saved += jsonSize(req.User.Data)
+				req.User.Data = nil
+			}
+		}
+	}
+	return saved
+}
+
+// jsonSize returns the length of v's JSON encoding
+func jsonSize(v interface{}) int {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return 0
+	}
+	return len(b)
+}
+
+// recordRequestSize reports a bidder call's outbound request size
+func recordRequestSize(m RequestSizeMetrics, result *BidderResult) {
+	if m == nil || result.RequestBytes == 0 {
+		return
+	}
+	m.RecordBidderRequestSize(result.BidderCode, result.RequestBytes, result.BytesSaved)
+}
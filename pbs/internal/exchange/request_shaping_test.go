@@ -0,0 +1,169 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+// shapingRequest is a site request with a banner+native imp, a native-only
+// imp and every ignorable field populated
+func shapingRequest() *openrtb.BidRequest {
+	return &openrtb.BidRequest{
+		ID: "shape-req",
+		Imp: []openrtb.Imp{
+			{ID: "imp1", Banner: &openrtb.Banner{W: 300, H: 250}, Native: &openrtb.Native{Request: `{"ver":"1.2","assets":[]}`}},
+			{ID: "imp2", Native: &openrtb.Native{Request: `{"ver":"1.2","assets":[]}`}},
+		},
+		Site:   &openrtb.Site{ID: "site1", Content: &openrtb.Content{ID: "article-1", Title: "A long article title"}},
+		Device: &openrtb.Device{UA: "ua", IDSHA1: "da39a3ee5e6b4b0d3255bfef95601890afd80709", MacMD5: "d41d8cd98f00b204e9800998ecf8427e", Ext: json.RawMessage(`{"atts":3}`)},
+		User:   &openrtb.User{ID: "u1", Data: []openrtb.Data{{ID: "seg-provider"}}},
+	}
+}
+
+func bannerOnlyInfo(ignored ...string) adapters.BidderInfo {
+	return adapters.BidderInfo{
+		Enabled:       true,
+		Capabilities:  &adapters.CapabilitiesInfo{Site: &adapters.PlatformInfo{MediaTypes: []adapters.BidType{adapters.BidTypeBanner}}},
+		IgnoredFields: ignored,
+	}
+}
+
+func TestShapeRequest_StripsUnsupportedFormats(t *testing.T) {
+	req := shapingRequest()
+	saved := shapeRequest(req, bannerOnlyInfo())
+
+	if len(req.Imp) != 1 || req.Imp[0].ID != "imp1" || req.Imp[0].Native != nil || req.Imp[0].Banner == nil {
+		t.Errorf("expected only imp1's banner left, got %+v", req.Imp)
+	}
+	if saved == 0 {
+		t.Error("expected the removed native objects to be counted")
+	}
+	if req.Site.Content == nil || req.Device.IDSHA1 == "" || req.User.Data == nil {
+		t.Error("expected fields the bidder doesn't ignore to be kept")
+	}
+}
+
+func TestShapeRequest_StripsIgnoredFields(t *testing.T) {
+	req := shapingRequest()
+	before := jsonSize(req)
+	saved := shapeRequest(req, adapters.BidderInfo{IgnoredFields: adapters.IgnorableFields})
+
+	if len(req.Imp) != 2 {
+		t.Errorf("expected imps untouched without media type capabilities, got %d", len(req.Imp))
+	}
+	if req.Site.Content != nil || req.Device.Ext != nil || req.Device.IDSHA1 != "" || req.Device.MacMD5 != "" || req.User.Data != nil {
+		t.Errorf("expected ignored fields removed, got site %+v device %+v user %+v", req.Site, req.Device, req.User)
+	}
+	if req.Device.UA != "ua" || req.User.ID != "u1" {
+		t.Error("expected other device and user fields kept")
+	}
+	if after := jsonSize(req); saved < (before-after)/2 || saved > before-after {
+		t.Errorf("expected saved bytes close to the size change %d, got %d", before-after, saved)
+	}
+}
+
+func TestShapeRequest_NoCapabilities(t *testing.T) {
+	req := shapingRequest()
+	if saved := shapeRequest(req, adapters.BidderInfo{}); saved != 0 || len(req.Imp) != 2 || req.Imp[0].Native == nil {
+		t.Errorf("expected request unchanged, saved %d", saved)
+	}
+}
+
+// recordingAdapter keeps the request it was asked to bid on
+type recordingAdapter struct {
+	mockAdapter
+	mu  sync.Mutex
+	req *openrtb.BidRequest
+}
+
+func (a *recordingAdapter) MakeRequests(request *openrtb.BidRequest, reqInfo *adapters.ExtraRequestInfo) ([]*adapters.RequestData, []error) {
+	a.mu.Lock()
+	a.req = request
+	a.mu.Unlock()
+	body, _ := json.Marshal(request)
+	return []*adapters.RequestData{{Method: "MOCK", Body: body}}, nil
+}
+
+type mockSizeMetrics struct {
+	mu    sync.Mutex
+	bytes map[string]int
+	saved map[string]int
+}
+
+func (m *mockSizeMetrics) RecordBidderRequestSize(bidder string, bytes, savedBytes int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.bytes[bidder] += bytes
+	m.saved[bidder] += savedBytes
+}
+
+func TestRequestShaping_Auction(t *testing.T) {
+	banner := &recordingAdapter{}
+	video := &recordingAdapter{}
+	full := &recordingAdapter{}
+
+	registry := adapters.NewRegistry()
+	registry.Register("banner", banner, bannerOnlyInfo(adapters.IgnoredSiteContent))
+	registry.Register("video", video, adapters.BidderInfo{
+		Enabled:      true,
+		Capabilities: &adapters.CapabilitiesInfo{Site: &adapters.PlatformInfo{MediaTypes: []adapters.BidType{adapters.BidTypeVideo}}},
+	})
+	registry.Register("full", full, adapters.BidderInfo{Enabled: true})
+
+	ex := New(registry, &Config{DefaultTimeout: time.Second, DefaultCurrency: "USD", RequestShaping: true})
+	metrics := &mockSizeMetrics{bytes: map[string]int{}, saved: map[string]int{}}
+	ex.SetRequestSizeMetrics(metrics)
+
+	req := shapingRequest()
+	resp, err := ex.RunAuction(context.Background(), &AuctionRequest{BidRequest: req})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if banner.req == nil || len(banner.req.Imp) != 1 || banner.req.Site.Content != nil {
+		t.Errorf("expected banner bidder to get a shaped request, got %+v", banner.req)
+	}
+	if video.req != nil {
+		t.Error("expected video-only bidder to be skipped for a request without video")
+	}
+	if full.req == nil || len(full.req.Imp) != 2 || full.req.Site.Content == nil {
+		t.Errorf("expected bidder without capabilities to get the full request, got %+v", full.req)
+	}
+	if len(req.Imp) != 2 || req.Site.Content == nil || req.Imp[0].Native == nil {
+		t.Error("expected the inbound request not to be modified")
+	}
+
+	result := resp.BidderResults["banner"]
+	if result == nil || result.BytesSaved == 0 || result.RequestBytes == 0 {
+		t.Fatalf("expected request size stats on the banner result, got %+v", result)
+	}
+	if metrics.bytes["banner"] != result.RequestBytes || metrics.saved["banner"] != result.BytesSaved {
+		t.Errorf("expected banner stats recorded, got %d / %d", metrics.bytes["banner"], metrics.saved["banner"])
+	}
+	if metrics.bytes["full"] <= metrics.bytes["banner"] || metrics.saved["full"] != 0 {
+		t.Errorf("expected the unshaped request to be larger with nothing saved, got %d / %d", metrics.bytes["full"], metrics.saved["full"])
+	}
+}
+
+func TestRequestShaping_OffByDefault(t *testing.T) {
+	video := &recordingAdapter{}
+	registry := adapters.NewRegistry()
+	registry.Register("video", video, adapters.BidderInfo{
+		Enabled:      true,
+		Capabilities: &adapters.CapabilitiesInfo{Site: &adapters.PlatformInfo{MediaTypes: []adapters.BidType{adapters.BidTypeVideo}}},
+	})
+
+	ex := New(registry, &Config{DefaultTimeout: time.Second, DefaultCurrency: "USD"})
+	if _, err := ex.RunAuction(context.Background(), &AuctionRequest{BidRequest: shapingRequest()}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if video.req == nil || len(video.req.Imp) != 2 {
+		t.Errorf("expected the full request without shaping, got %+v", video.req)
+	}
+}
@@ -0,0 +1,54 @@
+package exchange
+
+import (
+	"hash/fnv"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters/ortb"
+)
+
+// RolloutMetrics receives per-bidder gradual rollout decisions so a ramping
+// bidder's called and held-back auctions can be compared
+type RolloutMetrics interface {
+	RecordBidderRollout(bidder string, trafficPercent int, called bool)
+}
+
+// rolloutBucket places an auction in 0-99 for one bidder. Hashing the bidder
+// in keeps each bidder's holdout independent of the others, and the same
+// auction ID always lands in the same bucket.
+func rolloutBucket(auctionID, bidderCode string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(bidderCode))
+	_, _ = h.Write([]byte{'|'})
+	_, _ = h.Write([]byte(auctionID))
+	return int(h.Sum32() % 100)
+}
+
+// applyTrafficRollout drops dynamic bidders whose traffic_percent excludes this
+// auction and returns the bidders to call plus those held back. Static bidders
+// take precedence over dynamic ones of the same code and always receive traffic.
+func (e *Exchange) applyTrafficRollout(auctionID string, bidders []string, dynamicRegistry *ortb.DynamicRegistry, metrics RolloutMetrics) (called, heldBack []string) {
+	called = bidders[:0:0]
+	for _, code := range bidders {
+		percent := 100
+		if _, static := e.registry.Get(code); !static {
+			if da, ok := dynamicRegistry.Get(code); ok {
+				percent = da.GetTrafficPercent()
+			}
+		}
+		if percent >= 100 {
+			called = append(called, code)
+			continue
+		}
+
+		include := rolloutBucket(auctionID, code) < percent
+		if metrics != nil {
+			metrics.RecordBidderRollout(code, percent, include)
+		}
+		if include {
+			called = append(called, code)
+		} else {
+			heldBack = append(heldBack, code)
+		}
+	}
+	return called, heldBack
+}
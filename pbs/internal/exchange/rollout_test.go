@@ -0,0 +1,136 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters/ortb"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+// fakeBidderStore serves dynamic bidder configs to ortb.DynamicRegistry
+type fakeBidderStore struct {
+	configs map[string]string
+}
+
+func (f *fakeBidderStore) HGetAll(context.Context, string) (map[string]string, error) {
+	return f.configs, nil
+}
+
+func (f *fakeBidderStore) SMembers(context.Context, string) ([]string, error) { return nil, nil }
+
+func (f *fakeBidderStore) HGet(context.Context, string, string) (string, error) { return "", nil }
+
+func newRolloutRegistry(t *testing.T, percents map[string]*int) *ortb.DynamicRegistry {
+	t.Helper()
+	store := &fakeBidderStore{configs: make(map[string]string)}
+	for code, percent := range percents {
+		data, _ := json.Marshal(&ortb.BidderConfig{BidderCode: code, Status: "active", TrafficPercent: percent})
+		store.configs[code] = string(data)
+	}
+	registry := ortb.NewDynamicRegistry(store, time.Minute)
+	if err := registry.Refresh(context.Background()); err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+	return registry
+}
+
+type fakeRolloutMetrics struct {
+	called, heldBack map[string]int
+}
+
+func (f *fakeRolloutMetrics) RecordBidderRollout(bidder string, _ int, called bool) {
+	if called {
+		f.called[bidder]++
+	} else {
+		f.heldBack[bidder]++
+	}
+}
+
+func intPtr(v int) *int { return &v }
+
+func TestRolloutBucket(t *testing.T) {
+	if rolloutBucket("auction-1", "newbidder") != rolloutBucket("auction-1", "newbidder") {
+		t.Error("expected the same auction to land in the same bucket")
+	}
+
+	const auctions = 10000
+	included := 0
+	for i := 0; i < auctions; i++ {
+		if rolloutBucket(fmt.Sprintf("auction-%d", i), "newbidder") < 25 {
+			included++
+		}
+	}
+	if share := float64(included) / auctions; share < 0.23 || share > 0.27 {
+		t.Errorf("expected ~25%% of auctions in the first 25 buckets, got %.3f", share)
+	}
+}
+
+func TestApplyTrafficRollout(t *testing.T) {
+	dynamic := newRolloutRegistry(t, map[string]*int{
+		"ramping":  intPtr(30),
+		"paused":   intPtr(0),
+		"full":     nil,
+		"shadowed": intPtr(0), // Also registered statically, which wins
+	})
+	static := adapters.NewRegistry()
+	static.Register("shadowed", &mockAdapter{}, adapters.BidderInfo{Enabled: true})
+
+	ex := New(static, &Config{DefaultTimeout: 100 * time.Millisecond})
+	metrics := &fakeRolloutMetrics{called: map[string]int{}, heldBack: map[string]int{}}
+
+	const auctions = 1000
+	calls := map[string]int{}
+	for i := 0; i < auctions; i++ {
+		called, _ := ex.applyTrafficRollout(fmt.Sprintf("auction-%d", i),
+			[]string{"ramping", "paused", "full", "shadowed", "unknown"}, dynamic, metrics)
+		for _, code := range called {
+			calls[code]++
+		}
+	}
+
+	for _, code := range []string{"full", "shadowed", "unknown"} {
+		if calls[code] != auctions {
+			t.Errorf("expected %s called in every auction, got %d", code, calls[code])
+		}
+	}
+	if calls["paused"] != 0 || metrics.heldBack["paused"] != auctions {
+		t.Errorf("expected paused never called, got %d calls", calls["paused"])
+	}
+	if calls["ramping"] < 250 || calls["ramping"] > 350 {
+		t.Errorf("expected ~30%% of auctions to call ramping, got %d", calls["ramping"])
+	}
+	if metrics.called["ramping"] != calls["ramping"] || metrics.called["ramping"]+metrics.heldBack["ramping"] != auctions {
+		t.Errorf("expected metrics to match decisions, got %+v", metrics)
+	}
+	if _, ok := metrics.called["full"]; ok {
+		t.Error("expected no rollout metrics for bidders at full traffic")
+	}
+}
+
+func TestRunAuction_RolloutHeldBack(t *testing.T) {
+	ex := New(adapters.NewRegistry(), &Config{
+		DefaultTimeout:        100 * time.Millisecond,
+		DynamicBiddersEnabled: true,
+	})
+	ex.SetDynamicRegistry(newRolloutRegistry(t, map[string]*int{"paused": intPtr(0)}))
+
+	resp, err := ex.RunAuction(context.Background(), &AuctionRequest{BidRequest: &openrtb.BidRequest{
+		ID:   "rollout-1",
+		Site: testSite(),
+		Imp:  []openrtb.Imp{{ID: "imp1", Banner: &openrtb.Banner{W: 300, H: 250}}},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.DebugInfo.RolloutHeldBack) != 1 || resp.DebugInfo.RolloutHeldBack[0] != "paused" {
+		t.Errorf("expected paused held back, got %v", resp.DebugInfo.RolloutHeldBack)
+	}
+	if _, called := resp.BidderResults["paused"]; called {
+		t.Error("expected held back bidder not to be called")
+	}
+}
@@ -0,0 +1,191 @@
+package exchange
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/logger"
+)
+
+// Default sandbox limits for dynamic bidders configured by third parties
+const (
+	defaultSandboxTimeBudget       = 20 * time.Millisecond // Per MakeRequests/MakeBids call
+	defaultSandboxMaxRequestBytes  = 512 * 1024
+	defaultSandboxMaxResponseBytes = 256 * 1024 // Checked before the response is decoded
+	defaultSandboxMaxBids          = 100        // Per bidder response
+	defaultSandboxMaxInFlight      = 256        // Sandboxed calls running across all auctions
+)
+
+// Sandbox fault reasons reported to SandboxMetrics
+const (
+	SandboxFaultPanic            = "panic"
+	SandboxFaultTimeout          = "timeout"
+	SandboxFaultBusy             = "busy"
+	SandboxFaultRequestTooLarge  = "request_too_large"
+	SandboxFaultResponseTooLarge = "response_too_large"
+	SandboxFaultTooManyBids      = "too_many_bids"
+)
+
+// errSandboxBusy is returned when MaxInFlight sandboxed calls are already running
+var errSandboxBusy = errors.New("adapter sandbox at capacity")
+
+// SandboxConfig bounds the adapter work of sandboxed dynamic bidders. A bidder
+// is sandboxed when Enabled is set or its config has "sandbox": true.
+type SandboxConfig struct {
+	Enabled          bool          // Sandbox every dynamic bidder
+	TimeBudget       time.Duration // Max time for one MakeRequests or MakeBids call
+	MaxRequestBytes  int           // Larger outbound request bodies are dropped
+	MaxResponseBytes int           // Larger responses are rejected before decoding
+	MaxBids          int           // Bids beyond this per response are dropped
+	MaxInFlight      int           // Sandboxed calls allowed to run at once, including overrunning ones
+}
+
+// DefaultSandboxConfig returns default sandbox limits with the sandbox applied
+// only to bidders that opt in
+func DefaultSandboxConfig() *SandboxConfig {
+	return &SandboxConfig{
+		TimeBudget:       defaultSandboxTimeBudget,
+		MaxRequestBytes:  defaultSandboxMaxRequestBytes,
+		MaxResponseBytes: defaultSandboxMaxResponseBytes,
+		MaxBids:          defaultSandboxMaxBids,
+		MaxInFlight:      defaultSandboxMaxInFlight,
+	}
+}
+
+// SandboxMetrics receives per-bidder sandbox faults
+type SandboxMetrics interface {
+	RecordAdapterSandboxFault(bidder, reason string)
+}
+
+// adapterSandbox runs adapter code on its own goroutine so a panic, runaway
+// loop or oversized payload costs the bidder its bids rather than the auction.
+// Go can't stop a goroutine, so a call that overruns its budget is abandoned
+// and keeps its in-flight slot until it returns; MaxInFlight bounds how many
+// such goroutines can pile up.
+type adapterSandbox struct {
+	config  *SandboxConfig
+	slots   chan struct{}
+	metrics func() SandboxMetrics
+}
+
+func newAdapterSandbox(config *SandboxConfig, metrics func() SandboxMetrics) *adapterSandbox {
+	return &adapterSandbox{
+		config:  config,
+		slots:   make(chan struct{}, config.MaxInFlight),
+		metrics: metrics,
+	}
+}
+
+func (s *adapterSandbox) fault(bidder, reason string) {
+	if m := s.metrics(); m != nil {
+		m.RecordAdapterSandboxFault(bidder, reason)
+	}
+}
+
+// run executes fn within the time budget. Results written by fn may only be
+// read when run returns nil.
+func (s *adapterSandbox) run(bidder, op string, fn func()) error {
+	select {
+	case s.slots <- struct{}{}:
+	default:
+		s.fault(bidder, SandboxFaultBusy)
+		return fmt.Errorf("%s %s: %w", bidder, op, errSandboxBusy)
+	}
+
+	done := make(chan error, 1) // Buffered so an abandoned call never blocks
+	go func() {
+		defer func() { <-s.slots }()
+		defer func() {
+			if p := recover(); p != nil {
+				logger.Log.Error().
+					Str("bidder", bidder).
+					Str("op", op).
+					Interface("panic", p).
+					Bytes("stack", debug.Stack()).
+					Msg("sandboxed adapter panicked")
+				done <- fmt.Errorf("%s %s panicked: %v", bidder, op, p)
+			}
+		}()
+		fn()
+		done <- nil
+	}()
+
+	timer := time.NewTimer(s.config.TimeBudget)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		if err != nil {
+			s.fault(bidder, SandboxFaultPanic)
+		}
+		return err
+	case <-timer.C:
+		s.fault(bidder, SandboxFaultTimeout)
+		return fmt.Errorf("%s %s exceeded sandbox time budget of %v", bidder, op, s.config.TimeBudget)
+	}
+}
+
+// wrap returns adapter with its request building and response parsing sandboxed
+func (s *adapterSandbox) wrap(bidder string, adapter adapters.Adapter) adapters.Adapter {
+	return &sandboxedAdapter{bidder: bidder, adapter: adapter, sandbox: s}
+}
+
+// sandboxedAdapter applies the sandbox limits around another adapter
+type sandboxedAdapter struct {
+	bidder  string
+	adapter adapters.Adapter
+	sandbox *adapterSandbox
+}
+
+// MakeRequests builds the bidder's requests within the time budget and drops
+// request bodies over MaxRequestBytes
+func (a *sandboxedAdapter) MakeRequests(request *openrtb.BidRequest, extraInfo *adapters.ExtraRequestInfo) ([]*adapters.RequestData, []error) {
+	var requests []*adapters.RequestData
+	var errs []error
+	if err := a.sandbox.run(a.bidder, "MakeRequests", func() {
+		requests, errs = a.adapter.MakeRequests(request, extraInfo)
+	}); err != nil {
+		return nil, []error{err}
+	}
+
+	kept := requests[:0]
+	for _, req := range requests {
+		if req != nil && len(req.Body) > a.sandbox.config.MaxRequestBytes {
+			a.sandbox.fault(a.bidder, SandboxFaultRequestTooLarge)
+			errs = append(errs, fmt.Errorf("%s request body of %d bytes exceeds sandbox limit of %d",
+				a.bidder, len(req.Body), a.sandbox.config.MaxRequestBytes))
+			continue
+		}
+		kept = append(kept, req)
+	}
+	return kept, errs
+}
+
+// MakeBids rejects oversized responses before decoding, parses within the time
+// budget and keeps at most MaxBids bids
+func (a *sandboxedAdapter) MakeBids(request *openrtb.BidRequest, responseData *adapters.ResponseData) (*adapters.BidderResponse, []error) {
+	if responseData != nil && len(responseData.Body) > a.sandbox.config.MaxResponseBytes {
+		a.sandbox.fault(a.bidder, SandboxFaultResponseTooLarge)
+		return nil, []error{fmt.Errorf("%s response of %d bytes exceeds sandbox limit of %d",
+			a.bidder, len(responseData.Body), a.sandbox.config.MaxResponseBytes)}
+	}
+
+	var response *adapters.BidderResponse
+	var errs []error
+	if err := a.sandbox.run(a.bidder, "MakeBids", func() {
+		response, errs = a.adapter.MakeBids(request, responseData)
+	}); err != nil {
+		return nil, []error{err}
+	}
+
+	if response != nil && len(response.Bids) > a.sandbox.config.MaxBids {
+		a.sandbox.fault(a.bidder, SandboxFaultTooManyBids)
+		errs = append(errs, fmt.Errorf("%s returned %d bids, kept the first %d (sandbox limit)",
+			a.bidder, len(response.Bids), a.sandbox.config.MaxBids))
+		response.Bids = response.Bids[:a.sandbox.config.MaxBids]
+	}
+	return response, errs
+}
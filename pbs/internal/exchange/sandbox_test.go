@@ -0,0 +1,189 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters/ortb"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+type fakeSandboxMetrics struct {
+	mu     sync.Mutex
+	faults map[string]int
+}
+
+func (f *fakeSandboxMetrics) RecordAdapterSandboxFault(bidder, reason string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.faults[bidder+"/"+reason]++
+}
+
+func (f *fakeSandboxMetrics) count(key string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.faults[key]
+}
+
+func newTestSandbox(config *SandboxConfig) (*adapterSandbox, *fakeSandboxMetrics) {
+	metrics := &fakeSandboxMetrics{faults: map[string]int{}}
+	return newAdapterSandbox(config, func() SandboxMetrics { return metrics }), metrics
+}
+
+// faultyAdapter misbehaves in configurable ways
+type faultyAdapter struct {
+	panicOn   string        // "MakeRequests" or "MakeBids"
+	delay     time.Duration // Applied to every call
+	bodyBytes int
+	bids      int
+}
+
+func (f *faultyAdapter) MakeRequests(*openrtb.BidRequest, *adapters.ExtraRequestInfo) ([]*adapters.RequestData, []error) {
+	time.Sleep(f.delay)
+	if f.panicOn == "MakeRequests" {
+		panic("bad field mapping")
+	}
+	return []*adapters.RequestData{
+		{Method: "POST", URI: "http://bidder.test", Body: make([]byte, f.bodyBytes)},
+	}, nil
+}
+
+func (f *faultyAdapter) MakeBids(*openrtb.BidRequest, *adapters.ResponseData) (*adapters.BidderResponse, []error) {
+	time.Sleep(f.delay)
+	if f.panicOn == "MakeBids" {
+		var cfg *ortb.BidderConfig
+		_ = cfg.BidderCode // nil pointer dereference
+	}
+	resp := &adapters.BidderResponse{}
+	for i := 0; i < f.bids; i++ {
+		resp.Bids = append(resp.Bids, &adapters.TypedBid{Bid: &openrtb.Bid{ID: "b"}})
+	}
+	return resp, nil
+}
+
+func TestSandboxedAdapter_ContainsPanics(t *testing.T) {
+	sandbox, metrics := newTestSandbox(DefaultSandboxConfig())
+
+	for _, op := range []string{"MakeRequests", "MakeBids"} {
+		adapter := sandbox.wrap("thirdparty", &faultyAdapter{panicOn: op})
+		var errs []error
+		if op == "MakeRequests" {
+			_, errs = adapter.MakeRequests(&openrtb.BidRequest{}, nil)
+		} else {
+			_, errs = adapter.MakeBids(&openrtb.BidRequest{}, &adapters.ResponseData{StatusCode: http.StatusOK})
+		}
+		if len(errs) != 1 || !strings.Contains(errs[0].Error(), op+" panicked") {
+			t.Errorf("%s: expected panic reported as error, got %v", op, errs)
+		}
+	}
+	if metrics.count("thirdparty/panic") != 2 {
+		t.Errorf("expected 2 panic faults, got %v", metrics.faults)
+	}
+}
+
+func TestSandboxedAdapter_TimeBudget(t *testing.T) {
+	config := DefaultSandboxConfig()
+	config.TimeBudget = 5 * time.Millisecond
+	sandbox, metrics := newTestSandbox(config)
+
+	start := time.Now()
+	requests, errs := sandbox.wrap("slow", &faultyAdapter{delay: 200 * time.Millisecond}).MakeRequests(&openrtb.BidRequest{}, nil)
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("expected the call abandoned at the budget, took %v", elapsed)
+	}
+	if requests != nil || len(errs) != 1 || metrics.count("slow/timeout") != 1 {
+		t.Errorf("expected a timeout fault, got %v / %v", errs, metrics.faults)
+	}
+}
+
+func TestSandboxedAdapter_SizeAndBidLimits(t *testing.T) {
+	config := DefaultSandboxConfig()
+	config.MaxRequestBytes = 100
+	config.MaxResponseBytes = 100
+	config.MaxBids = 2
+	sandbox, metrics := newTestSandbox(config)
+
+	requests, errs := sandbox.wrap("big", &faultyAdapter{bodyBytes: 101}).MakeRequests(&openrtb.BidRequest{}, nil)
+	if len(requests) != 0 || len(errs) != 1 || metrics.count("big/request_too_large") != 1 {
+		t.Errorf("expected oversized request dropped, got %d requests, %v", len(requests), errs)
+	}
+
+	adapter := sandbox.wrap("big", &faultyAdapter{bids: 5})
+	if _, errs := adapter.MakeBids(&openrtb.BidRequest{}, &adapters.ResponseData{Body: make([]byte, 101)}); len(errs) != 1 {
+		t.Errorf("expected oversized response rejected, got %v", errs)
+	}
+	resp, errs := adapter.MakeBids(&openrtb.BidRequest{}, &adapters.ResponseData{Body: []byte(`{}`)})
+	if resp == nil || len(resp.Bids) != 2 || len(errs) != 1 {
+		t.Errorf("expected bids capped at 2, got %+v, %v", resp, errs)
+	}
+	if metrics.count("big/response_too_large") != 1 || metrics.count("big/too_many_bids") != 1 {
+		t.Errorf("unexpected faults %v", metrics.faults)
+	}
+}
+
+func TestSandboxedAdapter_Busy(t *testing.T) {
+	config := DefaultSandboxConfig()
+	config.TimeBudget = time.Millisecond
+	config.MaxInFlight = 1
+	sandbox, metrics := newTestSandbox(config)
+
+	// The abandoned call keeps its slot until it returns
+	adapter := sandbox.wrap("stuck", &faultyAdapter{delay: 50 * time.Millisecond})
+	_, _ = adapter.MakeRequests(&openrtb.BidRequest{}, nil)
+
+	_, errs := adapter.MakeRequests(&openrtb.BidRequest{}, nil)
+	if len(errs) != 1 || !errors.Is(errs[0], errSandboxBusy) || metrics.count("stuck/busy") != 1 {
+		t.Errorf("expected sandbox busy, got %v", errs)
+	}
+}
+
+func TestRunAuction_SandboxedDynamicBidder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id":"sandbox-1","seatbid":[{"bid":[{"id":"b1","impid":"imp1","price":1}]}],"pad":"` + strings.Repeat("x", 200) + `"}`))
+	}))
+	defer server.Close()
+
+	data, _ := json.Marshal(&ortb.BidderConfig{
+		BidderCode: "thirdparty",
+		Status:     "active",
+		Sandbox:    true,
+		Endpoint:   ortb.EndpointConfig{URL: server.URL, Method: "POST", TimeoutMS: 200},
+	})
+	registry := ortb.NewDynamicRegistry(&fakeBidderStore{configs: map[string]string{"thirdparty": string(data)}}, time.Minute)
+	if err := registry.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ex := New(adapters.NewRegistry(), &Config{
+		DefaultTimeout:        500 * time.Millisecond,
+		DynamicBiddersEnabled: true,
+		Sandbox:               &SandboxConfig{MaxResponseBytes: 100},
+	})
+	ex.SetDynamicRegistry(registry)
+	metrics := &fakeSandboxMetrics{faults: map[string]int{}}
+	ex.SetSandboxMetrics(metrics)
+
+	resp, err := ex.RunAuction(context.Background(), &AuctionRequest{BidRequest: &openrtb.BidRequest{
+		ID:   "sandbox-1",
+		Site: testSite(),
+		Imp:  []openrtb.Imp{{ID: "imp1", Banner: &openrtb.Banner{W: 300, H: 250}}},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result := resp.BidderResults["thirdparty"]
+	if result == nil || len(result.Bids) != 0 || len(result.Errors) == 0 {
+		t.Fatalf("expected the oversized response rejected, got %+v", result)
+	}
+	if metrics.count("thirdparty/response_too_large") != 1 {
+		t.Errorf("expected response_too_large fault, got %v", metrics.faults)
+	}
+}
@@ -0,0 +1,160 @@
+package exchange
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/pricebucket"
+)
+
+// Targeting keys Prebid.js and ad servers read from bid.ext.prebid.targeting
+const (
+	TargetingKeyPriceBucket = "hb_pb"
+	TargetingKeyBidder      = "hb_bidder"
+	TargetingKeySize        = "hb_size"
+	TargetingKeyDeal        = "hb_deal"
+)
+
+// defaultTargetingPrecision is used for custom granularities that omit precision
+const defaultTargetingPrecision = 2
+
+// targetingSettings controls the targeting keys put on bids
+type targetingSettings struct {
+	granularity       pricebucket.Granularity
+	includeWinners    bool // Unsuffixed keys on each imp's winning bid
+	includeBidderKeys bool // Bidder-suffixed keys (hb_pb_<bidder>, ...) on every bid
+}
+
+// defaultTargeting applies to requests without ext.prebid.targeting
+var defaultTargeting = targetingSettings{
+	granularity:       pricebucket.Default,
+	includeWinners:    true,
+	includeBidderKeys: true,
+}
+
+// parseTargeting reads request ext.prebid.targeting. pricegranularity is a
+// standard granularity name or a custom {"precision", "ranges"} object;
+// includewinners and includebidderkeys default to true. Without
+// ext.prebid.targeting the defaults apply. An invalid object returns the
+// defaults with an error.
+func parseTargeting(ext json.RawMessage) (targetingSettings, error) {
+	if len(ext) == 0 {
+		return defaultTargeting, nil
+	}
+	var parsed struct {
+		Prebid *struct {
+			Targeting *struct {
+				PriceGranularity  json.RawMessage `json:"pricegranularity"`
+				IncludeWinners    *bool           `json:"includewinners"`
+				IncludeBidderKeys *bool           `json:"includebidderkeys"`
+			} `json:"targeting"`
+		} `json:"prebid"`
+	}
+	if err := json.Unmarshal(ext, &parsed); err != nil || parsed.Prebid == nil || parsed.Prebid.Targeting == nil {
+		return defaultTargeting, nil
+	}
+	t := parsed.Prebid.Targeting
+
+	settings := defaultTargeting
+	if t.IncludeWinners != nil {
+		settings.includeWinners = *t.IncludeWinners
+	}
+	if t.IncludeBidderKeys != nil {
+		settings.includeBidderKeys = *t.IncludeBidderKeys
+	}
+	if len(t.PriceGranularity) > 0 && string(t.PriceGranularity) != "null" {
+		granularity, err := parseGranularity(t.PriceGranularity)
+		if err != nil {
+			return defaultTargeting, fmt.Errorf("ext.prebid.targeting.pricegranularity: %w", err)
+		}
+		settings.granularity = granularity
+	}
+	return settings, nil
+}
+
+// parseGranularity accepts a granularity name or a custom granularity object
+func parseGranularity(raw json.RawMessage) (pricebucket.Granularity, error) {
+	var name string
+	if err := json.Unmarshal(raw, &name); err == nil {
+		granularity, ok := pricebucket.ByName(name)
+		if !ok {
+			return pricebucket.Granularity{}, fmt.Errorf("unknown granularity %q", name)
+		}
+		return granularity, nil
+	}
+
+	var custom struct {
+		Precision *int                `json:"precision"`
+		Ranges    []pricebucket.Range `json:"ranges"`
+	}
+	if err := json.Unmarshal(raw, &custom); err != nil {
+		return pricebucket.Granularity{}, err
+	}
+	granularity := pricebucket.Granularity{Precision: defaultTargetingPrecision, Ranges: custom.Ranges}
+	if custom.Precision != nil {
+		granularity.Precision = *custom.Precision
+	}
+	if err := granularity.Validate(); err != nil {
+		return pricebucket.Granularity{}, err
+	}
+	return granularity, nil
+}
+
+// displayBidderCode is the bidder code shown to the page: platform demand is
+// reported under the platform seat, publisher demand under its own code
+func displayBidderCode(vb ValidatedBid) string {
+	if vb.DemandType != adapters.DemandTypePublisher {
+		return adapters.PlatformSeatName
+	}
+	return vb.BidderCode
+}
+
+// keys returns the targeting for one bid under bidder, the code it is
+// targeted as (its seat, or a multibid code for a seat's extra bids); winner
+// marks the imp's winning bid and cached is where its creative was cached, if
+// it was. Returns nil when the settings produce no keys for the bid, or
+// bidder is empty.
+func (s targetingSettings) keys(vb ValidatedBid, bidder string, winner bool, cached *openrtb.ExtBidPrebidCache) map[string]string {
+	withWinner := winner && s.includeWinners
+	if bidder == "" || (!withWinner && !s.includeBidderKeys) {
+		return nil
+	}
+
+	bid := vb.Bid.Bid
+	values := map[string]string{
+		TargetingKeyPriceBucket: s.granularity.Bucket(bid.Price),
+		TargetingKeyBidder:      bidder,
+		TargetingKeySize:        fmt.Sprintf("%dx%d", bid.W, bid.H),
+	}
+	if bid.DealID != "" {
+		values[TargetingKeyDeal] = bid.DealID
+	}
+	if cached != nil && cached.Bids != nil {
+		values[TargetingKeyCacheID] = cached.Bids.CacheID
+	}
+	if cached != nil && cached.VastXML != nil {
+		values[TargetingKeyUUID] = cached.VastXML.CacheID
+	}
+
+	targeting := make(map[string]string, 2*len(values))
+	for key, value := range values {
+		if withWinner {
+			targeting[key] = value
+		}
+		if s.includeBidderKeys {
+			targeting[key+"_"+bidder] = value
+		}
+	}
+	return targeting
+}
+
+// impWinner returns the winning bid among the bids returned for one imp,
+// which are in auction order: the first, nil for none
+func impWinner(bids []ValidatedBid) *openrtb.Bid {
+	if len(bids) == 0 {
+		return nil
+	}
+	return bids[0].Bid.Bid
+}
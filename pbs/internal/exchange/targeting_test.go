@@ -0,0 +1,140 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/pricebucket"
+)
+
+func TestParseTargeting(t *testing.T) {
+	tests := []struct {
+		name        string
+		ext         string
+		wantBucket  string // Bucket for a 1.37 CPM
+		winners     bool
+		bidderKeys  bool
+		expectError bool
+	}{
+		{name: "absent", ext: ``, wantBucket: "1.37", winners: true, bidderKeys: true},
+		{name: "no targeting", ext: `{"prebid":{}}`, wantBucket: "1.37", winners: true, bidderKeys: true},
+		{name: "empty targeting", ext: `{"prebid":{"targeting":{}}}`, wantBucket: "1.37", winners: true, bidderKeys: true},
+		{name: "named", ext: `{"prebid":{"targeting":{"pricegranularity":"low"}}}`, wantBucket: "1.00", winners: true, bidderKeys: true},
+		{name: "custom", ext: `{"prebid":{"targeting":{"pricegranularity":{"precision":1,"ranges":[{"max":10,"increment":0.25}]}}}}`, wantBucket: "1.2", winners: true, bidderKeys: true},
+		{name: "custom default precision", ext: `{"prebid":{"targeting":{"pricegranularity":{"ranges":[{"max":10,"increment":0.25}]}}}}`, wantBucket: "1.25", winners: true, bidderKeys: true},
+		{name: "flags", ext: `{"prebid":{"targeting":{"includewinners":false,"includebidderkeys":false}}}`, wantBucket: "1.37"},
+		{name: "unknown name", ext: `{"prebid":{"targeting":{"pricegranularity":"ultra"}}}`, wantBucket: "1.37", winners: true, bidderKeys: true, expectError: true},
+		{name: "invalid ranges", ext: `{"prebid":{"targeting":{"pricegranularity":{"ranges":[{"max":10,"increment":0}]}}}}`, wantBucket: "1.37", winners: true, bidderKeys: true, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings, err := parseTargeting(json.RawMessage(tt.ext))
+			if (err != nil) != tt.expectError {
+				t.Fatalf("expected error %v, got %v", tt.expectError, err)
+			}
+			if got := settings.granularity.Bucket(1.37); got != tt.wantBucket {
+				t.Errorf("expected bucket %s, got %s", tt.wantBucket, got)
+			}
+			if settings.includeWinners != tt.winners || settings.includeBidderKeys != tt.bidderKeys {
+				t.Errorf("expected winners=%v bidderkeys=%v, got %+v", tt.winners, tt.bidderKeys, settings)
+			}
+		})
+	}
+}
+
+func TestTargetingKeys(t *testing.T) {
+	vb := ValidatedBid{
+		Bid:        &adapters.TypedBid{Bid: &openrtb.Bid{ID: "b", ImpID: "imp1", Price: 2.345, W: 728, H: 90, DealID: "deal-1"}},
+		BidderCode: "pubbidder",
+		DemandType: adapters.DemandTypePublisher,
+	}
+
+	keys := defaultTargeting.keys(vb, displayBidderCode(vb), true, nil)
+	want := map[string]string{
+		"hb_pb": "2.34", "hb_bidder": "pubbidder", "hb_size": "728x90", "hb_deal": "deal-1",
+		"hb_pb_pubbidder": "2.34", "hb_bidder_pubbidder": "pubbidder", "hb_size_pubbidder": "728x90", "hb_deal_pubbidder": "deal-1",
+	}
+	if len(keys) != len(want) {
+		t.Errorf("expected %d keys, got %v", len(want), keys)
+	}
+	for k, v := range want {
+		if keys[k] != v {
+			t.Errorf("expected %s=%s, got %q", k, v, keys[k])
+		}
+	}
+
+	if keys := defaultTargeting.keys(vb, displayBidderCode(vb), false, nil); keys["hb_pb"] != "" || keys["hb_pb_pubbidder"] != "2.34" {
+		t.Errorf("expected only bidder keys on a losing bid, got %v", keys)
+	}
+
+	winnersOnly := targetingSettings{granularity: pricebucket.Default, includeWinners: true}
+	if keys := winnersOnly.keys(vb, displayBidderCode(vb), false, nil); keys != nil {
+		t.Errorf("expected no keys on a losing bid without bidder keys, got %v", keys)
+	}
+	if keys := winnersOnly.keys(vb, displayBidderCode(vb), true, nil); len(keys) != 4 || keys["hb_pb_pubbidder"] != "" {
+		t.Errorf("expected only the unsuffixed keys, got %v", keys)
+	}
+
+	platform := vb
+	platform.DemandType = adapters.DemandTypePlatform
+	if keys := defaultTargeting.keys(platform, displayBidderCode(platform), true, nil); keys["hb_bidder"] != adapters.PlatformSeatName || keys["hb_pb_"+adapters.PlatformSeatName] == "" {
+		t.Errorf("expected platform demand under the platform seat, got %v", keys)
+	}
+}
+
+func TestTargeting_Auction(t *testing.T) {
+	bidder := func(price float64) *mockAdapter {
+		return &mockAdapter{
+			requests: []*adapters.RequestData{{Method: "MOCK", Body: []byte(`{}`)}},
+			bids: []*adapters.TypedBid{{
+				Bid:     &openrtb.Bid{ID: "bid", ImpID: "imp1", Price: price, W: 300, H: 250, AdM: "<div/>"},
+				BidType: adapters.BidTypeBanner,
+			}},
+		}
+	}
+	// Bid IDs must be unique across bidders
+	high, low := bidder(3.33), bidder(1.11)
+	low.bids[0].Bid.ID = "bid-low"
+
+	registry := adapters.NewRegistry()
+	registry.Register("high", high, adapters.BidderInfo{Enabled: true, DemandType: adapters.DemandTypePublisher})
+	registry.Register("low", low, adapters.BidderInfo{Enabled: true, DemandType: adapters.DemandTypePublisher})
+	ex := New(registry, &Config{DefaultTimeout: time.Second, DefaultCurrency: "USD", AuctionType: FirstPriceAuction})
+
+	req := &openrtb.BidRequest{
+		ID:   "targeting-req",
+		Site: testSite(),
+		Imp:  []openrtb.Imp{{ID: "imp1", Banner: &openrtb.Banner{W: 300, H: 250}}},
+		Ext:  json.RawMessage(`{"prebid":{"targeting":{"pricegranularity":"low"}}}`),
+	}
+	resp, err := ex.RunAuction(context.Background(), &AuctionRequest{BidRequest: req})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	targeting := make(map[string]map[string]string)
+	for _, sb := range resp.BidResponse.SeatBid {
+		for _, bid := range sb.Bid {
+			var ext openrtb.BidExt
+			if err := json.Unmarshal(bid.Ext, &ext); err != nil {
+				t.Fatalf("invalid bid ext: %v", err)
+			}
+			targeting[sb.Seat] = ext.Prebid.Targeting
+		}
+	}
+
+	if targeting["high"]["hb_pb"] != "3.00" || targeting["high"]["hb_bidder"] != "high" {
+		t.Errorf("expected low-granularity winner keys on the high bid, got %v", targeting["high"])
+	}
+	if _, ok := targeting["low"]["hb_pb"]; ok {
+		t.Errorf("expected no winner keys on the losing bid, got %v", targeting["low"])
+	}
+	if targeting["low"]["hb_pb_low"] != "1.00" {
+		t.Errorf("expected bidder keys on the losing bid, got %v", targeting["low"])
+	}
+}
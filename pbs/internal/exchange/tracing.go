@@ -0,0 +1,32 @@
+package exchange
+
+import (
+	"net/url"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/tracing"
+)
+
+// endBidderSpan describes a bidder HTTP call on its span and ends it. Only the
+// host is recorded: bidder URIs may carry credentials in the query.
+func endBidderSpan(span *tracing.Span, bidderCode string, reqData *adapters.RequestData, resp *adapters.ResponseData, err error) {
+	if span == nil {
+		return
+	}
+	span.SetAttributes(
+		tracing.String("bidder", bidderCode),
+		tracing.String("http.method", reqData.Method),
+		tracing.Int("http.request.body.size", len(reqData.Body)),
+	)
+	if u, parseErr := url.Parse(reqData.URI); parseErr == nil {
+		span.SetAttributes(tracing.String("server.address", u.Host))
+	}
+	if resp != nil {
+		span.SetAttributes(
+			tracing.Int("http.status_code", resp.StatusCode),
+			tracing.Int("http.response.body.size", len(resp.Body)),
+		)
+	}
+	span.SetError(err)
+	span.End()
+}
@@ -0,0 +1,115 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/idr"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/tracing"
+)
+
+// spanRecorder keeps exported spans by name
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans map[string]*tracing.SpanData
+}
+
+func (r *spanRecorder) ExportSpan(span *tracing.SpanData) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans[span.Name] = span
+}
+
+func (r *spanRecorder) get(name string) *tracing.SpanData {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.spans[name]
+}
+
+func attribute(span *tracing.SpanData, key string) any {
+	for _, a := range span.Attributes {
+		if a.Key == key {
+			return a.Value
+		}
+	}
+	return nil
+}
+
+func TestRunAuction_Tracing(t *testing.T) {
+	rec := &spanRecorder{spans: make(map[string]*tracing.SpanData)}
+	tracing.SetTracer(tracing.NewTracer(rec, 1))
+	defer tracing.SetTracer(nil)
+
+	var idrTraceparent string
+	idrServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		idrTraceparent = r.Header.Get(tracing.TraceparentHeader)
+		json.NewEncoder(w).Encode(idr.SelectPartnersResponse{
+			SelectedBidders: []idr.SelectedBidder{{BidderCode: "appnexus", Score: 1}},
+			Mode:            "normal",
+		})
+	}))
+	defer idrServer.Close()
+
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get(tracing.TraceparentHeader)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	registry := adapters.NewRegistry()
+	registry.Register("appnexus", &mockAdapter{requests: []*adapters.RequestData{{
+		Method: "POST",
+		URI:    server.URL + "/bid?key=secret",
+		Body:   []byte(`{"id":"traced"}`),
+	}}}, adapters.BidderInfo{Enabled: true})
+	ex := New(registry, &Config{DefaultTimeout: 500 * time.Millisecond, IDREnabled: true, IDRServiceURL: idrServer.URL})
+
+	ctx, root := tracing.Start(context.Background(), "auction", tracing.SpanKindServer)
+	_, err := ex.RunAuction(ctx, &AuctionRequest{BidRequest: &openrtb.BidRequest{
+		ID:   "traced",
+		Site: testSite(),
+		Imp:  []openrtb.Imp{{ID: "imp1", Banner: &openrtb.Banner{W: 300, H: 250}}},
+	}})
+	root.End()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	selection := rec.get("idr.select_partners")
+	if selection == nil || selection.Parent != root.Context().SpanID || attribute(selection, "idr.selected") != int64(1) {
+		t.Fatalf("expected an IDR selection span under the auction, got %+v", selection)
+	}
+	if want := "00-" + selection.Context.TraceID.String() + "-" + selection.Context.SpanID.String() + "-01"; idrTraceparent != want {
+		t.Errorf("expected traceparent %q sent to IDR, got %q", want, idrTraceparent)
+	}
+
+	call := rec.get("bidder.request")
+	if call == nil {
+		t.Fatal("expected a span for the bidder call")
+	}
+	if call.Kind != tracing.SpanKindClient || call.Parent != root.Context().SpanID || call.Context.TraceID != root.Context().TraceID {
+		t.Errorf("expected a client span under the auction, got %+v", call)
+	}
+	if attribute(call, "bidder") != "appnexus" || attribute(call, "http.status_code") != int64(http.StatusNoContent) {
+		t.Errorf("expected the bidder and status recorded, got %+v", call.Attributes)
+	}
+	if host := attribute(call, "server.address"); host != server.Listener.Addr().String() {
+		t.Errorf("expected only the host recorded, got %v", host)
+	}
+	if want := "00-" + call.Context.TraceID.String() + "-" + call.Context.SpanID.String() + "-01"; traceparent != want {
+		t.Errorf("expected traceparent %q sent to the bidder, got %q", want, traceparent)
+	}
+
+	validate := rec.get("bids.validate")
+	if validate == nil || validate.Parent != root.Context().SpanID || attribute(validate, "bids.valid") != int64(0) {
+		t.Errorf("expected a bid validation span under the auction, got %+v", validate)
+	}
+}
@@ -0,0 +1,42 @@
+package exchange
+
+import (
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/vast"
+)
+
+// validateVideoBid checks that a video bid's markup parses as VAST and returns
+// the creative's duration in seconds: the bid's dur, or the VAST's when it
+// sends none. Bids without markup are served from their nurl and pass.
+func validateVideoBid(tb *adapters.TypedBid, bidderCode string) (int, *BidValidationError) {
+	bid := tb.Bid
+	if tb.BidType != adapters.BidTypeVideo {
+		return 0, nil
+	}
+	if bid.AdM == "" {
+		return bid.Dur, nil
+	}
+	info, err := vast.Parse(bid.AdM)
+	if err != nil {
+		return bid.Dur, &BidValidationError{BidID: bid.ID, ImpID: bid.ImpID, BidderCode: bidderCode, Reason: err.Error()}
+	}
+	if bid.Dur > 0 {
+		return bid.Dur, nil
+	}
+	return info.Duration, nil
+}
+
+// injectVASTImpressions adds each returned video bid's imp event URL to its
+// VAST as an <Impression>, so the player fires it. It runs before bids are
+// cached, so cached VAST carries the tracker too.
+func injectVASTImpressions(bids []ValidatedBid, events map[*openrtb.Bid]*openrtb.ExtBidPrebidEvents) {
+	for _, vb := range bids {
+		bid := vb.Bid.Bid
+		urls := events[bid]
+		if vb.Bid.BidType != adapters.BidTypeVideo || urls == nil || bid.AdM == "" {
+			continue
+		}
+		bid.AdM, _ = vast.InjectImpression(bid.AdM, urls.Imp)
+	}
+}
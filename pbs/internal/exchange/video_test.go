@@ -0,0 +1,86 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+const testInlineVAST = `<VAST version="3.0"><Ad><InLine><AdSystem>x</AdSystem><Creatives><Creative><Linear><Duration>00:00:15</Duration></Linear></Creative></Creatives></InLine></Ad></VAST>`
+
+func TestValidateVideoBid(t *testing.T) {
+	tests := []struct {
+		name         string
+		bidType      adapters.BidType
+		bid          openrtb.Bid
+		wantDuration int
+		wantErr      bool
+	}{
+		{"VAST duration", adapters.BidTypeVideo, openrtb.Bid{AdM: testInlineVAST}, 15, false},
+		{"dur wins", adapters.BidTypeVideo, openrtb.Bid{AdM: testInlineVAST, Dur: 30}, 30, false},
+		{"nurl served", adapters.BidTypeVideo, openrtb.Bid{NURL: "https://ads.example/vast", Dur: 10}, 10, false},
+		{"not VAST", adapters.BidTypeVideo, openrtb.Bid{AdM: "<div/>"}, 0, true},
+		{"malformed", adapters.BidTypeVideo, openrtb.Bid{AdM: "<VAST><Ad>"}, 0, true},
+		{"banner", adapters.BidTypeBanner, openrtb.Bid{AdM: "<div/>", Dur: 5}, 0, false},
+	}
+	for _, tt := range tests {
+		duration, err := validateVideoBid(&adapters.TypedBid{Bid: &tt.bid, BidType: tt.bidType}, "bidder")
+		if duration != tt.wantDuration || (err != nil) != tt.wantErr {
+			t.Errorf("%s: expected (%d, error %v), got (%d, %v)", tt.name, tt.wantDuration, tt.wantErr, duration, err)
+		}
+	}
+}
+
+func TestRunAuction_VASTProcessing(t *testing.T) {
+	newBidder := func(id string, price float64, adm string) *mockAdapter {
+		return &mockAdapter{
+			requests: []*adapters.RequestData{{Method: "MOCK", Body: []byte(`{}`)}},
+			bids: []*adapters.TypedBid{{
+				Bid:     &openrtb.Bid{ID: id, ImpID: "imp1", Price: price, AdM: adm},
+				BidType: adapters.BidTypeVideo,
+			}},
+		}
+	}
+	registry := adapters.NewRegistry()
+	registry.Register("broken", newBidder("broken-bid", 5.00, "<VAST><Ad>"), adapters.BidderInfo{Enabled: true, DemandType: adapters.DemandTypePublisher})
+	registry.Register("video", newBidder("video-bid", 2.00, testInlineVAST), adapters.BidderInfo{Enabled: true, DemandType: adapters.DemandTypePublisher})
+	ex := New(registry, &Config{
+		DefaultTimeout:  time.Second,
+		DefaultCurrency: "USD",
+		AuctionType:     FirstPriceAuction,
+		Events:          &EventsConfig{ExternalURL: "https://pbs.example.com"},
+	})
+	metrics := &creativeMetricsRecorder{}
+	ex.SetCreativeMetrics(metrics)
+
+	req := podRequest(1)
+	req.Site = testSite()
+	resp, err := ex.RunAuction(context.Background(), &AuctionRequest{BidRequest: req})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(resp.BidResponse.SeatBid) != 1 || resp.BidResponse.SeatBid[0].Seat != "video" {
+		t.Fatalf("expected only the valid VAST bid returned, got %+v", resp.BidResponse.SeatBid)
+	}
+	if metrics.rejected["broken/invalid_vast"] != 1 {
+		t.Errorf("expected one invalid_vast rejection recorded, got %v", metrics.rejected)
+	}
+
+	bid := resp.BidResponse.SeatBid[0].Bid[0]
+	var ext openrtb.BidExt
+	if err := json.Unmarshal(bid.Ext, &ext); err != nil {
+		t.Fatalf("invalid bid ext: %v", err)
+	}
+	if ext.Prebid.Video == nil || ext.Prebid.Video.Duration != 15 {
+		t.Errorf("expected ext.prebid.video.duration 15, got %+v", ext.Prebid.Video)
+	}
+	if ext.Prebid.Events == nil || !strings.Contains(bid.AdM, "<Impression><![CDATA["+ext.Prebid.Events.Imp+"]]></Impression></InLine>") {
+		t.Errorf("expected the imp event URL injected into the VAST, got %s", bid.AdM)
+	}
+}
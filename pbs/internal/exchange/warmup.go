@@ -0,0 +1,87 @@
+package exchange
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+// warmupTimeout bounds the synthetic auction
+const warmupTimeout = 2 * time.Second
+
+// warmupHTTPClient answers every bidder call with a no-bid so warmup never reaches real demand
+type warmupHTTPClient struct{}
+
+func (warmupHTTPClient) Do(ctx context.Context, req *adapters.RequestData, timeout time.Duration) (*adapters.ResponseData, error) {
+	return &adapters.ResponseData{StatusCode: http.StatusNoContent}, nil
+}
+
+// Warmup runs one synthetic auction through every enabled adapter's request and
+// response path, without IDR calls, event recording or outbound HTTP. It exercises
+// request cloning, FPD processing and adapter request building so the first real
+// auctions after startup don't pay for cold code paths. Fields of e not in
+// warmupSharedFields are left unset on the warm copy: no IDR client, event
+// recording, rate limits, circuit breakers, timeout tuning or metrics.
+func (e *Exchange) Warmup(ctx context.Context) (*AuctionResponse, error) {
+	e.configMu.RLock()
+	warm := &Exchange{
+		registry:        e.registry,
+		dynamicRegistry: e.dynamicRegistry,
+		httpClient:      warmupHTTPClient{},
+		config:          e.config,
+		fpdProcessor:    e.fpdProcessor,
+		eidFilter:       e.eidFilter,
+		flags:           e.flags,
+		sandbox:         e.sandbox,
+		bidderPool:      e.bidderPool,
+	}
+	e.configMu.RUnlock()
+
+	return warm.RunAuction(ctx, &AuctionRequest{
+		BidRequest: warmupBidRequest(),
+		Timeout:    warmupTimeout,
+	})
+}
+
+// warmupSharedFields are the Exchange fields Warmup's copy shares with the
+// real exchange. A field added to Exchange must be listed here or in
+// warmupOmittedFields, which the tests check.
+var warmupSharedFields = []string{
+	"registry", "dynamicRegistry", "config", "fpdProcessor", "eidFilter",
+	"flags", "sandbox", "bidderPool",
+}
+
+// warmupOmittedFields are the Exchange fields Warmup's copy leaves unset or
+// replaces
+var warmupOmittedFields = []string{
+	"httpClient", "idrClient", "eventRecorder", "idrCache", "idrCacheMetrics",
+	"auctionMetrics", "rolloutMetrics", "limiter", "throttleMetrics", "breakers",
+	"circuitMetrics", "sandboxMetrics", "poolMetrics", "adaptiveTimeouts", "errorMetrics",
+	"creativeMetrics", "sizeMetrics", "overheadMetrics", "privacyMetrics",
+	"accounts", "bidNotices", "configMu",
+}
+
+// warmupBidRequest builds a synthetic request covering each media type
+func warmupBidRequest() *openrtb.BidRequest {
+	return &openrtb.BidRequest{
+		ID: "warmup",
+		Imp: []openrtb.Imp{
+			{ID: "warmup-banner", Banner: &openrtb.Banner{W: 300, H: 250}},
+			{ID: "warmup-video", Video: &openrtb.Video{W: 640, H: 480, Mimes: []string{"video/mp4"}}},
+			{ID: "warmup-native", Native: &openrtb.Native{Request: "{}"}},
+		},
+		Site: &openrtb.Site{
+			ID:        "warmup",
+			Domain:    "warmup.invalid",
+			Page:      "https://warmup.invalid/",
+			Publisher: &openrtb.Publisher{ID: "warmup"},
+		},
+		Device: &openrtb.Device{UA: "warmup", IP: "192.0.2.1"},
+		User:   &openrtb.User{ID: "warmup"},
+		Test:   1,
+		Cur:    []string{"USD"},
+	}
+}
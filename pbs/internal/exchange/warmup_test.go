@@ -0,0 +1,101 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters/ortb"
+)
+
+func TestWarmup_NoOutboundCalls(t *testing.T) {
+	registry := adapters.NewRegistry()
+	mock := &mockAdapter{}
+	registry.Register("bidder1", mock, adapters.BidderInfo{Enabled: true})
+
+	var realCalls int
+	ex := New(registry, &Config{
+		DefaultTimeout:  500 * time.Millisecond,
+		DefaultCurrency: "USD",
+		AuctionType:     FirstPriceAuction,
+	})
+	ex.httpClient = &countingHTTPClient{calls: &realCalls}
+
+	resp, err := ex.Warmup(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if realCalls != 0 {
+		t.Errorf("expected warmup to bypass the real HTTP client, got %d calls", realCalls)
+	}
+	if _, ok := resp.BidderResults["bidder1"]; !ok {
+		t.Error("expected warmup auction to exercise bidder1")
+	}
+}
+
+type countingHTTPClient struct {
+	calls *int
+}
+
+func (c *countingHTTPClient) Do(ctx context.Context, req *adapters.RequestData, timeout time.Duration) (*adapters.ResponseData, error) {
+	*c.calls++
+	return &adapters.ResponseData{StatusCode: 204}, nil
+}
+
+func TestWarmup_LeavesRateLimitsAlone(t *testing.T) {
+	ex := New(adapters.NewRegistry(), &Config{
+		DefaultTimeout:        500 * time.Millisecond,
+		DynamicBiddersEnabled: true,
+	})
+	ex.SetDynamicRegistry(newLimitedRegistry(t, "https://bidder.example.com", map[string]ortb.RateLimitsConfig{
+		"limited": {DailyLimit: 1},
+	}))
+
+	if _, err := ex.Warmup(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, exceeded := ex.limiter.acquire("limited", ortb.RateLimitsConfig{DailyLimit: 1}); exceeded != "" {
+		t.Errorf("expected warmup not to use the bidder's daily limit, got %s", exceeded)
+	}
+}
+
+func TestWarmup_SandboxedDynamicBidder(t *testing.T) {
+	data, _ := json.Marshal(&ortb.BidderConfig{
+		BidderCode: "thirdparty",
+		Status:     "active",
+		Sandbox:    true,
+		Endpoint:   ortb.EndpointConfig{URL: "https://bidder.example.com", Method: "POST", TimeoutMS: 200},
+	})
+	registry := ortb.NewDynamicRegistry(&fakeBidderStore{configs: map[string]string{"thirdparty": string(data)}}, time.Minute)
+	if err := registry.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ex := New(adapters.NewRegistry(), &Config{
+		DefaultTimeout:        500 * time.Millisecond,
+		DynamicBiddersEnabled: true,
+	})
+	ex.SetDynamicRegistry(registry)
+
+	resp, err := ex.Warmup(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := resp.BidderResults["thirdparty"]; !ok {
+		t.Error("expected warmup auction to exercise the sandboxed bidder")
+	}
+}
+
+func TestWarmup_ListsEveryExchangeField(t *testing.T) {
+	fields := reflect.TypeOf(Exchange{})
+	for i := 0; i < fields.NumField(); i++ {
+		name := fields.Field(i).Name
+		shared, omitted := slices.Contains(warmupSharedFields, name), slices.Contains(warmupOmittedFields, name)
+		if shared == omitted {
+			t.Errorf("Exchange.%s must be in exactly one of warmupSharedFields and warmupOmittedFields", name)
+		}
+	}
+}
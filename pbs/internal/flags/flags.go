@@ -0,0 +1,404 @@
+// Package flags provides runtime toggles for auction behaviors that can be
+// flipped instantly during incidents without a redeploy
+package flags
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/logger"
+)
+
+// Flag identifies a runtime toggle
+type Flag string
+
+const (
+	// EnforceCreative rejects bids that carry neither adm nor nurl, and video bids whose adm isn't valid VAST
+	EnforceCreative Flag = "enforce_creative"
+	// StrictCurrency rejects bidder responses in a currency other than the exchange currency
+	StrictCurrency Flag = "strict_currency"
+	// DealValidation rejects bids whose dealid was not offered in the imp's PMP
+	DealValidation Flag = "deal_validation"
+	// FloorEnforcement rejects bids priced below the imp floor
+	FloorEnforcement Flag = "floor_enforcement"
+	// BlocklistEnforcement rejects bids whose adomain or cat the request blocks in badv or bcat
+	BlocklistEnforcement Flag = "blocklist_enforcement"
+)
+
+// defaults holds the value each flag has when no override is active
+var defaults = map[Flag]bool{
+	EnforceCreative:      true,
+	StrictCurrency:       true,
+	DealValidation:       false,
+	FloorEnforcement:     true,
+	BlocklistEnforcement: true,
+}
+
+// redisFlagsHash is the Redis hash holding shared overrides (field = flag name)
+const redisFlagsHash = "nexus:flags"
+
+// maxAuditEntries bounds the in-memory change history
+const maxAuditEntries = 200
+
+// Default returns the built-in default for a flag (false for unknown flags)
+func Default(f Flag) bool {
+	return defaults[f]
+}
+
+// Known reports whether f is a recognized flag
+func Known(f Flag) bool {
+	_, ok := defaults[f]
+	return ok
+}
+
+// Override is an operator-set value that takes precedence over the default
+type Override struct {
+	Enabled   bool       `json:"enabled"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Actor     string     `json:"actor"`
+	Reason    string     `json:"reason,omitempty"`
+	ChangedAt time.Time  `json:"changed_at"`
+}
+
+func (o *Override) expired(now time.Time) bool {
+	return o.ExpiresAt != nil && !now.Before(*o.ExpiresAt)
+}
+
+// State describes the effective value of a flag
+type State struct {
+	Flag     Flag      `json:"flag"`
+	Enabled  bool      `json:"enabled"`
+	Default  bool      `json:"default"`
+	Override *Override `json:"override,omitempty"`
+}
+
+// AuditEntry records a single flag change
+type AuditEntry struct {
+	Time      time.Time  `json:"time"`
+	Flag      Flag       `json:"flag"`
+	Action    string     `json:"action"` // set, reset, expired, sync
+	Enabled   bool       `json:"enabled"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Actor     string     `json:"actor"`
+	Reason    string     `json:"reason,omitempty"`
+}
+
+// RedisClient interface for optional cross-instance flag sync
+type RedisClient interface {
+	HGetAll(ctx context.Context, key string) (map[string]string, error)
+	HSet(ctx context.Context, key, field, value string) error
+	HDel(ctx context.Context, key string, fields ...string) error
+}
+
+// Registry holds flag overrides and their change history
+type Registry struct {
+	mu        sync.RWMutex
+	overrides map[Flag]*Override
+	audit     []AuditEntry
+	redis     RedisClient
+	stopChan  chan struct{}
+	stopOnce  sync.Once
+	now       func() time.Time
+}
+
+// NewRegistry creates an in-memory flag registry with all flags at their defaults
+func NewRegistry() *Registry {
+	return &Registry{
+		overrides: make(map[Flag]*Override),
+		stopChan:  make(chan struct{}),
+		now:       time.Now,
+	}
+}
+
+// SetRedisClient enables Redis-backed sync so overrides apply across instances
+func (r *Registry) SetRedisClient(client RedisClient) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.redis = client
+}
+
+// Enabled returns the effective value of f, applying expiry lazily
+func (r *Registry) Enabled(f Flag) bool {
+	r.mu.RLock()
+	o, ok := r.overrides[f]
+	r.mu.RUnlock()
+
+	if !ok {
+		return Default(f)
+	}
+	if o.expired(r.now()) {
+		r.expire(f, o)
+		return Default(f)
+	}
+	return o.Enabled
+}
+
+// expire removes an override whose TTL has elapsed
+func (r *Registry) expire(f Flag, o *Override) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Another caller may have already replaced or removed it
+	if r.overrides[f] != o {
+		return
+	}
+	delete(r.overrides, f)
+	r.appendAudit(AuditEntry{
+		Time:    r.now(),
+		Flag:    f,
+		Action:  "expired",
+		Enabled: Default(f),
+		Actor:   "system",
+	})
+	logger.Log.Warn().
+		Str("flag", string(f)).
+		Bool("enabled", Default(f)).
+		Msg("Feature flag override expired, reverted to default")
+}
+
+// Set overrides f until reset or, if ttl > 0, until ttl elapses
+func (r *Registry) Set(ctx context.Context, f Flag, enabled bool, ttl time.Duration, actor, reason string) error {
+	if !Known(f) {
+		return fmt.Errorf("unknown flag: %s", f)
+	}
+	if ttl < 0 {
+		return fmt.Errorf("ttl must not be negative")
+	}
+
+	now := r.now()
+	o := &Override{
+		Enabled:   enabled,
+		Actor:     actor,
+		Reason:    reason,
+		ChangedAt: now,
+	}
+	if ttl > 0 {
+		expiresAt := now.Add(ttl)
+		o.ExpiresAt = &expiresAt
+	}
+
+	r.mu.Lock()
+	r.overrides[f] = o
+	r.appendAudit(AuditEntry{
+		Time:      now,
+		Flag:      f,
+		Action:    "set",
+		Enabled:   enabled,
+		ExpiresAt: o.ExpiresAt,
+		Actor:     actor,
+		Reason:    reason,
+	})
+	redis := r.redis
+	r.mu.Unlock()
+
+	logger.Log.Warn().
+		Str("flag", string(f)).
+		Bool("enabled", enabled).
+		Dur("ttl", ttl).
+		Str("actor", actor).
+		Str("reason", reason).
+		Msg("Feature flag overridden")
+
+	if redis != nil {
+		data, err := json.Marshal(o)
+		if err != nil {
+			return fmt.Errorf("failed to encode override: %w", err)
+		}
+		if err := redis.HSet(ctx, redisFlagsHash, string(f), string(data)); err != nil {
+			return fmt.Errorf("flag set locally but Redis sync failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// Reset removes any override on f, restoring its default
+func (r *Registry) Reset(ctx context.Context, f Flag, actor, reason string) error {
+	if !Known(f) {
+		return fmt.Errorf("unknown flag: %s", f)
+	}
+
+	r.mu.Lock()
+	delete(r.overrides, f)
+	r.appendAudit(AuditEntry{
+		Time:    r.now(),
+		Flag:    f,
+		Action:  "reset",
+		Enabled: Default(f),
+		Actor:   actor,
+		Reason:  reason,
+	})
+	redis := r.redis
+	r.mu.Unlock()
+
+	logger.Log.Warn().
+		Str("flag", string(f)).
+		Str("actor", actor).
+		Str("reason", reason).
+		Msg("Feature flag reset to default")
+
+	if redis != nil {
+		if err := redis.HDel(ctx, redisFlagsHash, string(f)); err != nil {
+			return fmt.Errorf("flag reset locally but Redis sync failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// Snapshot returns the effective state of every known flag, sorted by name
+func (r *Registry) Snapshot() []State {
+	names := make([]string, 0, len(defaults))
+	for f := range defaults {
+		names = append(names, string(f))
+	}
+	sort.Strings(names)
+
+	states := make([]State, 0, len(names))
+	for _, name := range names {
+		f := Flag(name)
+		enabled := r.Enabled(f) // Applies expiry before reading the override
+
+		r.mu.RLock()
+		var o *Override
+		if cur, ok := r.overrides[f]; ok {
+			copied := *cur
+			o = &copied
+		}
+		r.mu.RUnlock()
+
+		states = append(states, State{
+			Flag:     f,
+			Enabled:  enabled,
+			Default:  Default(f),
+			Override: o,
+		})
+	}
+	return states
+}
+
+// Audit returns the change history, oldest first
+func (r *Registry) Audit() []AuditEntry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	result := make([]AuditEntry, len(r.audit))
+	copy(result, r.audit)
+	return result
+}
+
+// appendAudit records an entry; caller must hold r.mu
+func (r *Registry) appendAudit(entry AuditEntry) {
+	r.audit = append(r.audit, entry)
+	if len(r.audit) > maxAuditEntries {
+		r.audit = r.audit[len(r.audit)-maxAuditEntries:]
+	}
+}
+
+// Start begins periodic sync of overrides from Redis. No-op without a Redis client.
+func (r *Registry) Start(ctx context.Context, period time.Duration) {
+	r.mu.RLock()
+	hasRedis := r.redis != nil
+	r.mu.RUnlock()
+	if !hasRedis || period <= 0 {
+		return
+	}
+
+	if err := r.Sync(ctx); err != nil {
+		logger.Log.Warn().Err(err).Msg("Initial feature flag sync failed")
+	}
+	go r.syncLoop(ctx, period)
+}
+
+// Stop stops the background sync
+func (r *Registry) Stop() {
+	r.stopOnce.Do(func() { close(r.stopChan) })
+}
+
+func (r *Registry) syncLoop(ctx context.Context, period time.Duration) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	const syncTimeout = 5 * time.Second
+
+	for {
+		select {
+		case <-ticker.C:
+			syncCtx, cancel := context.WithTimeout(ctx, syncTimeout)
+			if err := r.Sync(syncCtx); err != nil {
+				logger.Log.Warn().Err(err).Msg("Failed to sync feature flags from Redis")
+			}
+			cancel()
+		case <-r.stopChan:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Sync replaces local overrides with those stored in Redis, so changes made
+// on any instance converge everywhere
+func (r *Registry) Sync(ctx context.Context) error {
+	r.mu.RLock()
+	redis := r.redis
+	r.mu.RUnlock()
+	if redis == nil {
+		return nil
+	}
+
+	stored, err := redis.HGetAll(ctx, redisFlagsHash)
+	if err != nil {
+		return fmt.Errorf("failed to load flags from Redis: %w", err)
+	}
+
+	now := r.now()
+	remote := make(map[Flag]*Override, len(stored))
+	for name, data := range stored {
+		f := Flag(name)
+		if !Known(f) {
+			continue
+		}
+		var o Override
+		if err := json.Unmarshal([]byte(data), &o); err != nil {
+			logger.Log.Warn().Err(err).Str("flag", name).Msg("Failed to parse stored feature flag")
+			continue
+		}
+		if o.expired(now) {
+			continue
+		}
+		remote[f] = &o
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for f, o := range remote {
+		if cur, ok := r.overrides[f]; ok && cur.Enabled == o.Enabled && cur.ChangedAt.Equal(o.ChangedAt) {
+			continue
+		}
+		r.appendAudit(AuditEntry{
+			Time:      now,
+			Flag:      f,
+			Action:    "sync",
+			Enabled:   o.Enabled,
+			ExpiresAt: o.ExpiresAt,
+			Actor:     o.Actor,
+			Reason:    o.Reason,
+		})
+	}
+	for f := range r.overrides {
+		if _, ok := remote[f]; !ok {
+			r.appendAudit(AuditEntry{
+				Time:    now,
+				Flag:    f,
+				Action:  "sync",
+				Enabled: Default(f),
+				Actor:   "redis",
+			})
+		}
+	}
+	r.overrides = remote
+	return nil
+}
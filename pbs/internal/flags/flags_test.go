@@ -0,0 +1,224 @@
+package flags
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockRedisClient implements RedisClient for testing
+type mockRedisClient struct {
+	mu     sync.Mutex
+	data   map[string]string
+	setErr error
+}
+
+func newMockRedisClient() *mockRedisClient {
+	return &mockRedisClient{data: make(map[string]string)}
+}
+
+func (m *mockRedisClient) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result := make(map[string]string, len(m.data))
+	for k, v := range m.data {
+		result[k] = v
+	}
+	return result, nil
+}
+
+func (m *mockRedisClient) HSet(ctx context.Context, key, field, value string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.setErr != nil {
+		return m.setErr
+	}
+	m.data[field] = value
+	return nil
+}
+
+func (m *mockRedisClient) HDel(ctx context.Context, key string, fields ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, f := range fields {
+		delete(m.data, f)
+	}
+	return nil
+}
+
+func TestRegistry_Defaults(t *testing.T) {
+	r := NewRegistry()
+
+	if !r.Enabled(EnforceCreative) || !r.Enabled(StrictCurrency) || !r.Enabled(FloorEnforcement) {
+		t.Error("expected enforcement flags to default on")
+	}
+	if r.Enabled(DealValidation) {
+		t.Error("expected deal validation to default off")
+	}
+	if r.Enabled(Flag("unknown")) {
+		t.Error("expected unknown flag to be off")
+	}
+}
+
+func TestRegistry_SetAndReset(t *testing.T) {
+	r := NewRegistry()
+	ctx := context.Background()
+
+	if err := r.Set(ctx, FloorEnforcement, false, 0, "oncall", "floor provider outage"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Enabled(FloorEnforcement) {
+		t.Error("expected floor enforcement to be disabled")
+	}
+
+	if err := r.Reset(ctx, FloorEnforcement, "oncall", "resolved"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !r.Enabled(FloorEnforcement) {
+		t.Error("expected floor enforcement restored to default")
+	}
+
+	audit := r.Audit()
+	if len(audit) != 2 || audit[0].Action != "set" || audit[1].Action != "reset" {
+		t.Fatalf("unexpected audit trail: %+v", audit)
+	}
+	if audit[0].Actor != "oncall" || audit[0].Reason != "floor provider outage" {
+		t.Errorf("expected actor and reason recorded, got %+v", audit[0])
+	}
+}
+
+func TestRegistry_UnknownFlag(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Set(context.Background(), Flag("nope"), true, 0, "a", ""); err == nil {
+		t.Error("expected error for unknown flag")
+	}
+	if err := r.Reset(context.Background(), Flag("nope"), "a", ""); err == nil {
+		t.Error("expected error for unknown flag reset")
+	}
+}
+
+func TestRegistry_Expiry(t *testing.T) {
+	r := NewRegistry()
+	now := time.Now()
+	r.now = func() time.Time { return now }
+
+	if err := r.Set(context.Background(), StrictCurrency, false, time.Minute, "oncall", ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Enabled(StrictCurrency) {
+		t.Fatal("expected override active before expiry")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if !r.Enabled(StrictCurrency) {
+		t.Error("expected override to expire back to default")
+	}
+
+	audit := r.Audit()
+	if audit[len(audit)-1].Action != "expired" {
+		t.Errorf("expected expiry to be audited, got %+v", audit)
+	}
+}
+
+func TestRegistry_Snapshot(t *testing.T) {
+	r := NewRegistry()
+	r.Set(context.Background(), DealValidation, true, time.Hour, "oncall", "")
+
+	states := r.Snapshot()
+	if len(states) != len(defaults) {
+		t.Fatalf("expected %d states, got %d", len(defaults), len(states))
+	}
+	for _, s := range states {
+		if s.Flag == DealValidation {
+			if !s.Enabled || s.Default || s.Override == nil || s.Override.ExpiresAt == nil {
+				t.Errorf("unexpected deal validation state: %+v", s)
+			}
+		} else if s.Override != nil {
+			t.Errorf("expected no override for %s", s.Flag)
+		}
+	}
+}
+
+func TestRegistry_AuditBounded(t *testing.T) {
+	r := NewRegistry()
+	for i := 0; i < maxAuditEntries+10; i++ {
+		r.Set(context.Background(), DealValidation, i%2 == 0, 0, "a", "")
+	}
+	if len(r.Audit()) != maxAuditEntries {
+		t.Errorf("expected audit capped at %d, got %d", maxAuditEntries, len(r.Audit()))
+	}
+}
+
+func TestRegistry_RedisSync(t *testing.T) {
+	redis := newMockRedisClient()
+	ctx := context.Background()
+
+	a := NewRegistry()
+	a.SetRedisClient(redis)
+	b := NewRegistry()
+	b.SetRedisClient(redis)
+
+	if err := a.Set(ctx, EnforceCreative, false, 0, "oncall", "creative scanner down"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := b.Sync(ctx); err != nil {
+		t.Fatalf("unexpected sync error: %v", err)
+	}
+	if b.Enabled(EnforceCreative) {
+		t.Error("expected override to propagate via Redis")
+	}
+	if audit := b.Audit(); len(audit) != 1 || audit[0].Action != "sync" || audit[0].Actor != "oncall" {
+		t.Errorf("expected synced change to be audited, got %+v", audit)
+	}
+
+	// Re-syncing an unchanged override should not add audit noise
+	b.Sync(ctx)
+	if len(b.Audit()) != 1 {
+		t.Errorf("expected no duplicate audit entries, got %d", len(b.Audit()))
+	}
+
+	a.Reset(ctx, EnforceCreative, "oncall", "")
+	b.Sync(ctx)
+	if !b.Enabled(EnforceCreative) {
+		t.Error("expected reset to propagate via Redis")
+	}
+}
+
+func TestRegistry_RedisSync_SkipsExpiredAndInvalid(t *testing.T) {
+	redis := newMockRedisClient()
+	past := time.Now().Add(-time.Minute)
+	expired, _ := json.Marshal(Override{Enabled: false, ExpiresAt: &past})
+	redis.data[string(FloorEnforcement)] = string(expired)
+	redis.data[string(StrictCurrency)] = "not json"
+	redis.data["unknown_flag"] = `{"enabled":true}`
+
+	r := NewRegistry()
+	r.SetRedisClient(redis)
+	if err := r.Sync(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !r.Enabled(FloorEnforcement) || !r.Enabled(StrictCurrency) {
+		t.Error("expected expired and invalid overrides to be ignored")
+	}
+}
+
+func TestRegistry_RedisSetError(t *testing.T) {
+	redis := newMockRedisClient()
+	redis.setErr = errors.New("redis down")
+
+	r := NewRegistry()
+	r.SetRedisClient(redis)
+
+	err := r.Set(context.Background(), DealValidation, true, 0, "oncall", "")
+	if err == nil {
+		t.Fatal("expected sync error")
+	}
+	if !r.Enabled(DealValidation) {
+		t.Error("expected local override to apply even when Redis sync fails")
+	}
+}
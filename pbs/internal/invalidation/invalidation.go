@@ -0,0 +1,248 @@
+// Package invalidation drops cached data on demand and propagates the request to
+// every instance over Redis pub/sub, so edits take effect without waiting out TTLs
+package invalidation
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"sync"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/logger"
+)
+
+// Channel is the Redis pub/sub channel invalidations are broadcast on
+const Channel = "nexus:cache:invalidate"
+
+// AllCaches targets every registered cache
+const AllCaches = "*"
+
+// Handler drops entries of one cache whose keys match any of the patterns (all
+// entries when patterns is empty) and returns how many were dropped. Caches that
+// can only reload wholesale ignore patterns and return the entries reloaded.
+type Handler func(ctx context.Context, patterns []string) (int, error)
+
+// PubSub is the subset of the Redis client used for broadcasting
+type PubSub interface {
+	Publish(ctx context.Context, channel, message string) error
+	Subscribe(ctx context.Context, channel string) (<-chan string, func() error, error)
+}
+
+// Message is the broadcast payload
+type Message struct {
+	Cache    string   `json:"cache"`
+	Patterns []string `json:"patterns,omitempty"`
+	Origin   string   `json:"origin"` // Instance that applied it first; it ignores its own echo
+}
+
+// Manager routes invalidations to registered caches
+type Manager struct {
+	mu       sync.RWMutex
+	handlers map[string]Handler
+	pubsub   PubSub
+
+	instanceID string
+	closeSub   func() error
+	done       chan struct{}
+}
+
+// NewManager creates a manager with a random instance ID
+func NewManager() *Manager {
+	id := make([]byte, 8)
+	_, _ = rand.Read(id)
+	return &Manager{
+		handlers:   make(map[string]Handler),
+		instanceID: hex.EncodeToString(id),
+	}
+}
+
+// Register adds a cache under name; registering a name twice replaces the handler
+func (m *Manager) Register(name string, h Handler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handlers[name] = h
+}
+
+// SetPubSub enables cross-instance propagation
+func (m *Manager) SetPubSub(ps PubSub) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pubsub = ps
+}
+
+// Caches returns the registered cache names, sorted
+func (m *Manager) Caches() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	names := make([]string, 0, len(m.handlers))
+	for name := range m.handlers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Invalidate applies an invalidation locally, then broadcasts it to other
+// instances. cache may be AllCaches. Returns entries dropped per cache on this
+// instance; a broadcast failure is returned alongside the local result.
+func (m *Manager) Invalidate(ctx context.Context, cache string, patterns []string) (map[string]int, error) {
+	if err := ValidatePatterns(patterns); err != nil {
+		return nil, err
+	}
+
+	dropped, err := m.apply(ctx, cache, patterns)
+	if err != nil {
+		return dropped, err
+	}
+
+	m.mu.RLock()
+	ps := m.pubsub
+	m.mu.RUnlock()
+	if ps == nil {
+		return dropped, nil
+	}
+
+	payload, err := json.Marshal(Message{Cache: cache, Patterns: patterns, Origin: m.instanceID})
+	if err != nil {
+		return dropped, err
+	}
+	if err := ps.Publish(ctx, Channel, string(payload)); err != nil {
+		return dropped, fmt.Errorf("applied locally but broadcast failed: %w", err)
+	}
+	return dropped, nil
+}
+
+// apply runs the handlers for cache without broadcasting
+func (m *Manager) apply(ctx context.Context, cache string, patterns []string) (map[string]int, error) {
+	m.mu.RLock()
+	targets := make(map[string]Handler)
+	if cache == AllCaches {
+		for name, h := range m.handlers {
+			targets[name] = h
+		}
+	} else if h, ok := m.handlers[cache]; ok {
+		targets[cache] = h
+	}
+	m.mu.RUnlock()
+
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("unknown cache %q", cache)
+	}
+
+	dropped := make(map[string]int, len(targets))
+	var errs []error
+	for name, h := range targets {
+		n, err := h(ctx, patterns)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		dropped[name] = n
+	}
+	return dropped, errors.Join(errs...)
+}
+
+// Start subscribes to broadcasts from other instances. No-op without PubSub.
+func (m *Manager) Start(ctx context.Context) error {
+	m.mu.RLock()
+	ps := m.pubsub
+	m.mu.RUnlock()
+	if ps == nil {
+		return nil
+	}
+
+	// The subscription outlives the startup context
+	messages, closeSub, err := ps.Subscribe(context.WithoutCancel(ctx), Channel)
+	if err != nil {
+		return fmt.Errorf("subscribe to %s: %w", Channel, err)
+	}
+
+	done := make(chan struct{})
+	m.mu.Lock()
+	m.closeSub = closeSub
+	m.done = done
+	m.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		for payload := range messages {
+			m.handleMessage(payload)
+		}
+	}()
+	return nil
+}
+
+// Stop unsubscribes and waits for the listener to exit
+func (m *Manager) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	closeSub, done := m.closeSub, m.done
+	m.closeSub, m.done = nil, nil
+	m.mu.Unlock()
+	if closeSub == nil {
+		return nil
+	}
+
+	err := closeSub()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return err
+}
+
+func (m *Manager) handleMessage(payload string) {
+	var msg Message
+	if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+		logger.Log.Warn().Err(err).Msg("Ignoring malformed cache invalidation message")
+		return
+	}
+	if msg.Origin == m.instanceID {
+		return
+	}
+	if err := ValidatePatterns(msg.Patterns); err != nil {
+		logger.Log.Warn().Err(err).Msg("Ignoring cache invalidation with bad patterns")
+		return
+	}
+
+	dropped, err := m.apply(context.Background(), msg.Cache, msg.Patterns)
+	if err != nil {
+		logger.Log.Warn().Err(err).Str("cache", msg.Cache).Msg("Remote cache invalidation failed")
+		return
+	}
+	logger.Log.Info().
+		Str("cache", msg.Cache).
+		Strs("patterns", msg.Patterns).
+		Str("origin", msg.Origin).
+		Interface("dropped", dropped).
+		Msg("Applied remote cache invalidation")
+}
+
+// ValidatePatterns rejects malformed glob patterns
+func ValidatePatterns(patterns []string) error {
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether key matches any pattern (path.Match globs, e.g. "pub-*").
+// An empty pattern list matches every key.
+func Matches(patterns []string, key string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, key); ok {
+			return true
+		}
+	}
+	return false
+}
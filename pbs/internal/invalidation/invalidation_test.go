@@ -0,0 +1,193 @@
+package invalidation
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeBus is an in-memory pub/sub shared by several managers
+type fakeBus struct {
+	mu          sync.Mutex
+	subscribers []chan string
+	publishErr  error
+}
+
+func (b *fakeBus) Publish(_ context.Context, _ string, message string) error {
+	if b.publishErr != nil {
+		return b.publishErr
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, sub := range b.subscribers {
+		sub <- message
+	}
+	return nil
+}
+
+func (b *fakeBus) Subscribe(context.Context, string) (<-chan string, func() error, error) {
+	ch := make(chan string, 10)
+	b.mu.Lock()
+	b.subscribers = append(b.subscribers, ch)
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() error {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			for i, sub := range b.subscribers {
+				if sub == ch {
+					b.subscribers = append(b.subscribers[:i], b.subscribers[i+1:]...)
+					break
+				}
+			}
+			close(ch)
+		})
+		return nil
+	}, nil
+}
+
+// keyCache is a test cache recording what was dropped
+type keyCache struct {
+	mu   sync.Mutex
+	keys map[string]bool
+}
+
+func newKeyCache(keys ...string) *keyCache {
+	c := &keyCache{keys: make(map[string]bool)}
+	for _, k := range keys {
+		c.keys[k] = true
+	}
+	return c
+}
+
+func (c *keyCache) handler(_ context.Context, patterns []string) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	dropped := 0
+	for k := range c.keys {
+		if Matches(patterns, k) {
+			delete(c.keys, k)
+			dropped++
+		}
+	}
+	return dropped, nil
+}
+
+func (c *keyCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.keys)
+}
+
+func TestManager_InvalidateLocal(t *testing.T) {
+	accounts := newKeyCache("pub-1", "pub-2", "other")
+	selections := newKeyCache("pub-1")
+
+	m := NewManager()
+	m.Register("accounts", accounts.handler)
+	m.Register("selections", selections.handler)
+
+	dropped, err := m.Invalidate(context.Background(), "accounts", []string{"pub-*"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if dropped["accounts"] != 2 || accounts.len() != 1 || selections.len() != 1 {
+		t.Errorf("expected only matching account keys dropped, got %v", dropped)
+	}
+
+	dropped, err = m.Invalidate(context.Background(), AllCaches, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if dropped["accounts"] != 1 || dropped["selections"] != 1 {
+		t.Errorf("expected every cache flushed, got %v", dropped)
+	}
+
+	if _, err := m.Invalidate(context.Background(), "missing", nil); err == nil {
+		t.Error("expected error for unknown cache")
+	}
+	if _, err := m.Invalidate(context.Background(), "accounts", []string{"["}); err == nil {
+		t.Error("expected error for malformed pattern")
+	}
+}
+
+func TestManager_PropagatesAcrossInstances(t *testing.T) {
+	bus := &fakeBus{}
+	ctx := context.Background()
+
+	local, remote := newKeyCache("pub-1", "pub-2"), newKeyCache("pub-1", "pub-2")
+	var localCalls int
+	a, b := NewManager(), NewManager()
+	a.Register("accounts", func(ctx context.Context, p []string) (int, error) {
+		localCalls++
+		return local.handler(ctx, p)
+	})
+	b.Register("accounts", remote.handler)
+	for _, m := range []*Manager{a, b} {
+		m.SetPubSub(bus)
+		if err := m.Start(ctx); err != nil {
+			t.Fatalf("start failed: %v", err)
+		}
+	}
+
+	if _, err := a.Invalidate(ctx, "accounts", []string{"pub-1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for remote.len() != 1 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if remote.len() != 1 {
+		t.Errorf("expected remote instance to drop pub-1, has %d keys", remote.len())
+	}
+
+	for _, m := range []*Manager{a, b} {
+		if err := m.Stop(ctx); err != nil {
+			t.Errorf("stop failed: %v", err)
+		}
+	}
+	// The origin ignores its own broadcast
+	if localCalls != 1 || local.len() != 1 {
+		t.Errorf("expected origin to apply once, got %d calls", localCalls)
+	}
+}
+
+func TestManager_BroadcastFailureStillAppliesLocally(t *testing.T) {
+	cache := newKeyCache("pub-1")
+	m := NewManager()
+	m.Register("accounts", cache.handler)
+	m.SetPubSub(&fakeBus{publishErr: errors.New("redis down")})
+
+	dropped, err := m.Invalidate(context.Background(), "accounts", nil)
+	if err == nil {
+		t.Error("expected broadcast error")
+	}
+	if dropped["accounts"] != 1 || cache.len() != 0 {
+		t.Errorf("expected local invalidation despite broadcast failure, got %v", dropped)
+	}
+}
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		key      string
+		want     bool
+	}{
+		{nil, "anything", true},
+		{[]string{"pub-1"}, "pub-1", true},
+		{[]string{"pub-*"}, "pub-22", true},
+		{[]string{"pub-?"}, "pub-22", false},
+		{[]string{"a", "b"}, "b", true},
+		{[]string{"a"}, "b", false},
+	}
+	for _, tt := range tests {
+		if got := Matches(tt.patterns, tt.key); got != tt.want {
+			t.Errorf("Matches(%v, %q) = %v, want %v", tt.patterns, tt.key, got, tt.want)
+		}
+	}
+}
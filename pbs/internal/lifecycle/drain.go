@@ -0,0 +1,82 @@
+package lifecycle
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Drainer takes a server out of service ahead of shutdown. Once draining,
+// health checks report it unavailable so load balancers stop routing to it
+// and new auctions are refused, while in-flight ones finish; Done closes when
+// the drain window has passed and the server can stop.
+type Drainer struct {
+	window   time.Duration
+	draining atomic.Bool
+
+	mu      sync.Mutex
+	since   time.Time
+	started chan struct{} // Closed by Drain
+	done    chan struct{} // Closed when the window has passed
+}
+
+// DrainStatus reports whether a server is draining, and until when
+type DrainStatus struct {
+	Draining   bool       `json:"draining"`
+	Since      *time.Time `json:"since,omitempty"`
+	ShutdownAt *time.Time `json:"shutdown_at,omitempty"`
+}
+
+// NewDrainer creates a drainer whose drains last window
+func NewDrainer(window time.Duration) *Drainer {
+	return &Drainer{
+		window:  window,
+		started: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// Drain starts draining; draining again keeps the original deadline
+func (d *Drainer) Drain() DrainStatus {
+	d.mu.Lock()
+	if d.since.IsZero() {
+		d.since = time.Now()
+		d.draining.Store(true)
+		close(d.started)
+		time.AfterFunc(d.window, func() { close(d.done) })
+	}
+	d.mu.Unlock()
+	return d.Status()
+}
+
+// Draining reports whether Drain has been called
+func (d *Drainer) Draining() bool {
+	return d.draining.Load()
+}
+
+// Started closes when draining starts
+func (d *Drainer) Started() <-chan struct{} {
+	return d.started
+}
+
+// Done closes when the drain window has passed
+func (d *Drainer) Done() <-chan struct{} {
+	return d.done
+}
+
+// Window returns how long a drain lasts
+func (d *Drainer) Window() time.Duration {
+	return d.window
+}
+
+// Status reports the drain state
+func (d *Drainer) Status() DrainStatus {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.since.IsZero() {
+		return DrainStatus{}
+	}
+	since := d.since
+	shutdownAt := since.Add(d.window)
+	return DrainStatus{Draining: true, Since: &since, ShutdownAt: &shutdownAt}
+}
@@ -0,0 +1,34 @@
+package lifecycle
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDrainer(t *testing.T) {
+	d := NewDrainer(20 * time.Millisecond)
+	if d.Draining() || d.Status().Draining {
+		t.Fatal("expected not draining before Drain")
+	}
+
+	status := d.Drain()
+	if !d.Draining() || !status.Draining || status.ShutdownAt.Sub(*status.Since) != 20*time.Millisecond {
+		t.Fatalf("unexpected status %+v", status)
+	}
+	select {
+	case <-d.Started():
+	default:
+		t.Error("expected Started closed")
+	}
+
+	// Draining again keeps the deadline
+	if again := d.Drain(); !again.Since.Equal(*status.Since) {
+		t.Errorf("expected the first drain's start, got %v", again.Since)
+	}
+
+	select {
+	case <-d.Done():
+	case <-time.After(time.Second):
+		t.Fatal("expected Done after the window")
+	}
+}
@@ -0,0 +1,219 @@
+// Package lifecycle starts and stops server components in dependency order
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/logger"
+)
+
+// DefaultStopTimeout bounds a component's Stop when it sets no StopTimeout
+const DefaultStopTimeout = 5 * time.Second
+
+// Component is a unit with a managed lifetime. A component starts after every
+// component it depends on and stops before any of them.
+type Component struct {
+	Name        string
+	DependsOn   []string
+	Start       func(ctx context.Context) error // Optional; nil means nothing to start
+	Stop        func(ctx context.Context) error // Optional; nil means nothing to stop
+	StopTimeout time.Duration                   // 0 uses the manager default
+}
+
+// Manager owns component registration and ordered start/stop
+type Manager struct {
+	mu          sync.Mutex
+	components  []Component
+	byName      map[string]int
+	started     []Component // In start order
+	stopTimeout time.Duration
+}
+
+// NewManager creates a manager; stopTimeout <= 0 uses DefaultStopTimeout
+func NewManager(stopTimeout time.Duration) *Manager {
+	if stopTimeout <= 0 {
+		stopTimeout = DefaultStopTimeout
+	}
+	return &Manager{
+		byName:      make(map[string]int),
+		stopTimeout: stopTimeout,
+	}
+}
+
+// Register adds a component; names must be unique
+func (m *Manager) Register(c Component) error {
+	if c.Name == "" {
+		return errors.New("component name is required")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.byName[c.Name]; exists {
+		return fmt.Errorf("component already registered: %s", c.Name)
+	}
+	m.byName[c.Name] = len(m.components)
+	m.components = append(m.components, c)
+	return nil
+}
+
+// Order returns component names in start order
+func (m *Manager) Order() ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ordered, err := m.resolve()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(ordered))
+	for i, c := range ordered {
+		names[i] = c.Name
+	}
+	return names, nil
+}
+
+// resolve topologically sorts components, breaking ties by registration order
+// so the result is deterministic; caller must hold m.mu
+func (m *Manager) resolve() ([]Component, error) {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make([]int, len(m.components))
+	ordered := make([]Component, 0, len(m.components))
+
+	var visit func(i int, path []string) error
+	visit = func(i int, path []string) error {
+		c := m.components[i]
+		switch state[i] {
+		case done:
+			return nil
+		case visiting:
+			return fmt.Errorf("dependency cycle: %v -> %s", path, c.Name)
+		}
+		state[i] = visiting
+		for _, dep := range c.DependsOn {
+			j, ok := m.byName[dep]
+			if !ok {
+				return fmt.Errorf("component %s depends on unknown component %s", c.Name, dep)
+			}
+			if err := visit(j, append(path, c.Name)); err != nil {
+				return err
+			}
+		}
+		state[i] = done
+		ordered = append(ordered, c)
+		return nil
+	}
+
+	for i := range m.components {
+		if err := visit(i, nil); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+// Start starts every component in dependency order. If one fails, the components
+// already started are stopped in reverse order and the start error is returned.
+func (m *Manager) Start(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ordered, err := m.resolve()
+	if err != nil {
+		return err
+	}
+
+	for _, c := range ordered {
+		if c.Start != nil {
+			if err := c.Start(ctx); err != nil {
+				startErr := fmt.Errorf("failed to start %s: %w", c.Name, err)
+				logger.Log.Error().Err(err).Str("component", c.Name).Msg("Component failed to start, rolling back")
+				if stopErr := m.stopStarted(ctx); stopErr != nil {
+					return errors.Join(startErr, stopErr)
+				}
+				return startErr
+			}
+		}
+		m.started = append(m.started, c)
+		logger.Log.Debug().Str("component", c.Name).Msg("Component started")
+	}
+	return nil
+}
+
+// Stop stops started components in reverse start order. Every component is
+// attempted even if an earlier one fails or times out; all errors are returned joined.
+func (m *Manager) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stopStarted(ctx)
+}
+
+// stopStarted stops and forgets m.started; caller must hold m.mu
+func (m *Manager) stopStarted(ctx context.Context) error {
+	var errs []error
+	for i := len(m.started) - 1; i >= 0; i-- {
+		c := m.started[i]
+		if c.Stop == nil {
+			continue
+		}
+		start := time.Now()
+		if err := m.stopOne(ctx, c); err != nil {
+			logger.Log.Warn().Err(err).Str("component", c.Name).Msg("Component stop failed")
+			errs = append(errs, err)
+			continue
+		}
+		logger.Log.Info().
+			Str("component", c.Name).
+			Dur("elapsed", time.Since(start)).
+			Msg("Component stopped")
+	}
+	m.started = nil
+	return errors.Join(errs...)
+}
+
+// stopOne runs c.Stop under its own timeout. Stop functions that ignore the context
+// are abandoned when the timeout expires so one stuck component can't block shutdown.
+func (m *Manager) stopOne(ctx context.Context, c Component) error {
+	timeout := c.StopTimeout
+	if timeout <= 0 {
+		timeout = m.stopTimeout
+	}
+	stopCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		done <- c.Stop(stopCtx)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("failed to stop %s: %w", c.Name, err)
+		}
+		return nil
+	case <-stopCtx.Done():
+		return fmt.Errorf("failed to stop %s: %w", c.Name, stopCtx.Err())
+	}
+}
+
+// Wrap adapts a context-free start or stop function such as a ticker's Stop
+func Wrap(f func()) func(ctx context.Context) error {
+	return func(context.Context) error {
+		f()
+		return nil
+	}
+}
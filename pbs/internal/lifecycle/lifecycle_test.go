@@ -0,0 +1,205 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder collects start/stop events across components
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) add(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.events...)
+}
+
+func (r *recorder) component(name string, deps ...string) Component {
+	return Component{
+		Name:      name,
+		DependsOn: deps,
+		Start: func(context.Context) error {
+			r.add("start:" + name)
+			return nil
+		},
+		Stop: func(context.Context) error {
+			r.add("stop:" + name)
+			return nil
+		},
+	}
+}
+
+func mustRegister(t *testing.T, m *Manager, c Component) {
+	t.Helper()
+	if err := m.Register(c); err != nil {
+		t.Fatalf("Register(%s): %v", c.Name, err)
+	}
+}
+
+func TestManager_OrderedStartAndStop(t *testing.T) {
+	rec := &recorder{}
+	m := NewManager(time.Second)
+	// Registered out of dependency order on purpose
+	mustRegister(t, m, rec.component("http", "registry", "limiter"))
+	mustRegister(t, m, rec.component("limiter"))
+	mustRegister(t, m, rec.component("registry", "recorder"))
+	mustRegister(t, m, rec.component("recorder"))
+
+	order, err := m.Order()
+	if err != nil {
+		t.Fatalf("Order: %v", err)
+	}
+	if want := []string{"recorder", "registry", "limiter", "http"}; !reflect.DeepEqual(order, want) {
+		t.Fatalf("expected order %v, got %v", want, order)
+	}
+
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if err := m.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+
+	want := []string{
+		"start:recorder", "start:registry", "start:limiter", "start:http",
+		"stop:http", "stop:limiter", "stop:registry", "stop:recorder",
+	}
+	if got := rec.get(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected events %v, got %v", want, got)
+	}
+}
+
+func TestManager_StartFailureRollsBack(t *testing.T) {
+	rec := &recorder{}
+	m := NewManager(time.Second)
+	mustRegister(t, m, rec.component("a"))
+	mustRegister(t, m, rec.component("b", "a"))
+	mustRegister(t, m, Component{
+		Name:      "c",
+		DependsOn: []string{"b"},
+		Start:     func(context.Context) error { return errors.New("port in use") },
+		Stop: func(context.Context) error {
+			rec.add("stop:c")
+			return nil
+		},
+	})
+	mustRegister(t, m, rec.component("d", "c"))
+
+	err := m.Start(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "failed to start c: port in use") {
+		t.Fatalf("expected start error for c, got %v", err)
+	}
+
+	// c never started so it is not stopped; d never ran
+	want := []string{"start:a", "start:b", "stop:b", "stop:a"}
+	if got := rec.get(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected events %v, got %v", want, got)
+	}
+
+	// Nothing left to stop after a rollback
+	if err := m.Stop(context.Background()); err != nil {
+		t.Errorf("expected no error stopping after rollback, got %v", err)
+	}
+	if got := rec.get(); len(got) != len(want) {
+		t.Errorf("expected no further stops, got %v", got)
+	}
+}
+
+func TestManager_StopTimeoutDoesNotBlockOthers(t *testing.T) {
+	rec := &recorder{}
+	m := NewManager(time.Second)
+	mustRegister(t, m, rec.component("a"))
+	release := make(chan struct{})
+	defer close(release)
+	mustRegister(t, m, Component{
+		Name:      "stuck",
+		DependsOn: []string{"a"},
+		Stop: func(context.Context) error {
+			<-release // Ignores its context
+			return nil
+		},
+		StopTimeout: 20 * time.Millisecond,
+	})
+	mustRegister(t, m, Component{
+		Name:      "failing",
+		DependsOn: []string{"stuck"},
+		Stop:      func(context.Context) error { return errors.New("flush failed") },
+	})
+
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	start := time.Now()
+	err := m.Stop(context.Background())
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("stop took %v, expected per-component timeout to apply", elapsed)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded for stuck component, got %v", err)
+	}
+	if err == nil || !strings.Contains(err.Error(), "flush failed") {
+		t.Errorf("expected failing component error to be joined, got %v", err)
+	}
+	if got := rec.get(); !reflect.DeepEqual(got, []string{"start:a", "stop:a"}) {
+		t.Errorf("expected a to still be stopped, got %v", got)
+	}
+}
+
+func TestManager_RegistrationErrors(t *testing.T) {
+	m := NewManager(0)
+	if err := m.Register(Component{}); err == nil {
+		t.Error("expected error for unnamed component")
+	}
+	mustRegister(t, m, Component{Name: "a"})
+	if err := m.Register(Component{Name: "a"}); err == nil {
+		t.Error("expected error for duplicate component")
+	}
+}
+
+func TestManager_DependencyErrors(t *testing.T) {
+	m := NewManager(0)
+	mustRegister(t, m, Component{Name: "a", DependsOn: []string{"missing"}})
+	if err := m.Start(context.Background()); err == nil || !strings.Contains(err.Error(), "unknown component missing") {
+		t.Errorf("expected unknown dependency error, got %v", err)
+	}
+
+	m = NewManager(0)
+	mustRegister(t, m, Component{Name: "a", DependsOn: []string{"b"}})
+	mustRegister(t, m, Component{Name: "b", DependsOn: []string{"a"}})
+	if _, err := m.Order(); err == nil || !strings.Contains(err.Error(), "dependency cycle") {
+		t.Errorf("expected cycle error, got %v", err)
+	}
+}
+
+func TestManager_StopPanicIsReported(t *testing.T) {
+	m := NewManager(time.Second)
+	mustRegister(t, m, Component{Name: "a", Stop: func(context.Context) error { panic("boom") }})
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if err := m.Stop(context.Background()); err == nil || !strings.Contains(err.Error(), "panic: boom") {
+		t.Errorf("expected panic to be reported, got %v", err)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	called := false
+	if err := Wrap(func() { called = true })(context.Background()); err != nil || !called {
+		t.Errorf("expected wrapped func to run without error, called=%v err=%v", called, err)
+	}
+}
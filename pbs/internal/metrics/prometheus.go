@@ -16,9 +16,12 @@ type Metrics struct {
 	RequestsTotal    *prometheus.CounterVec
 	RequestDuration  *prometheus.HistogramVec
 	RequestsInFlight prometheus.Gauge
+	RouteRequests    *prometheus.CounterVec
+	RouteDuration    *prometheus.HistogramVec
 
 	// Auction metrics
 	AuctionsTotal       *prometheus.CounterVec
+	AuctionAPIRequests  *prometheus.CounterVec
 	AuctionDuration     *prometheus.HistogramVec
 	BidsReceived        *prometheus.CounterVec
 	BidCPM              *prometheus.HistogramVec
@@ -30,20 +33,64 @@ type Metrics struct {
 	BidderLatency      *prometheus.HistogramVec
 	BidderErrors       *prometheus.CounterVec
 	BidderTimeouts     *prometheus.CounterVec
+	BidderRequestBytes *prometheus.HistogramVec
+	BidderBytesSaved   *prometheus.CounterVec
+
+	// Time spent in the HTTP middleware chain before an auction starts
+	MiddlewareOverhead prometheus.Histogram
+
+	// Bidder worker pool metrics
+	BidderQueueDepth prometheus.Gauge
+	BidderQueueWait  prometheus.Histogram
+
+	// Gradual rollout metrics
+	BidderRollout        *prometheus.CounterVec
+	BidderTrafficPercent *prometheus.GaugeVec
+	BidderThrottled      *prometheus.CounterVec
+
+	// Bids rejected by creative validation
+	CreativeRejected *prometheus.CounterVec
+
+	// Bidder circuit breaker metrics
+	BidderCircuitState   *prometheus.GaugeVec
+	BidderCircuitSkipped *prometheus.CounterVec
+
+	// Bidder connection pool metrics
+	BidderConnections  *prometheus.CounterVec
+	BidderTLSHandshake *prometheus.HistogramVec
+
+	// Adapter sandbox metrics
+	AdapterSandboxFaults *prometheus.CounterVec
 
 	// IDR metrics
 	IDRRequests        *prometheus.CounterVec
 	IDRLatency         *prometheus.HistogramVec
 	IDRCircuitState    *prometheus.GaugeVec
+	IDRSelectionCache  *prometheus.CounterVec
+	IDREventSpill      *prometheus.CounterVec
+	FeedbackEvents     *prometheus.CounterVec
+	EventNotifications *prometheus.CounterVec
+	NoticeURLs         *prometheus.CounterVec
 
 	// Privacy metrics
 	PrivacyFiltered    *prometheus.CounterVec
 	ConsentSignals     *prometheus.CounterVec
+	GPPSections        *prometheus.CounterVec
+
+	// Dynamic registry metrics
+	DynamicRegistryRefreshes      *prometheus.CounterVec
+	DynamicRegistryRefreshLatency prometheus.Histogram
+	DynamicRegistryLastSuccess    prometheus.Gauge
+	DynamicRegistryConfigs        *prometheus.GaugeVec
+	DynamicRegistryParseErrors    *prometheus.CounterVec
+	DynamicRegistryStale          prometheus.Gauge
 
 	// System metrics
-	ActiveConnections  prometheus.Gauge
-	RateLimitRejected  prometheus.Counter
-	AuthFailures       prometheus.Counter
+	ActiveConnections     prometheus.Gauge
+	RateLimitRejected     prometheus.Counter
+	SyncRateLimitRejected *prometheus.CounterVec
+	AuthFailures          prometheus.Counter
+	InventoryMismatches   *prometheus.CounterVec
 }
 
 // NewMetrics creates and registers all Prometheus metrics
@@ -78,6 +125,23 @@ func NewMetrics(namespace string) *Metrics {
 				Help:      "Number of HTTP requests currently being served",
 			},
 		),
+		RouteRequests: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "route_requests_total",
+				Help:      "HTTP requests by route pattern",
+			},
+			[]string{"route", "method", "status"},
+		),
+		RouteDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "route_request_duration_seconds",
+				Help:      "HTTP request duration by route pattern, excluding router-wide middleware",
+				Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+			},
+			[]string{"route"},
+		),
 
 		// Auction metrics
 		AuctionsTotal: prometheus.NewCounterVec(
@@ -88,6 +152,14 @@ func NewMetrics(namespace string) *Metrics {
 			},
 			[]string{"status", "media_type"},
 		),
+		AuctionAPIRequests: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "auction_api_requests_total",
+				Help:      "Auction endpoint requests by API version and outcome (ok, rejected, error)",
+			},
+			[]string{"version", "outcome"},
+		),
 		AuctionDuration: prometheus.NewHistogramVec(
 			prometheus.HistogramOpts{
 				Namespace: namespace,
@@ -166,6 +238,46 @@ func NewMetrics(namespace string) *Metrics {
 			},
 			[]string{"bidder"},
 		),
+		BidderRequestBytes: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "bidder_request_bytes",
+				Help:      "Serialized size of the requests sent to each bidder per auction",
+				Buckets:   prometheus.ExponentialBuckets(512, 2, 10),
+			},
+			[]string{"bidder"},
+		),
+		BidderBytesSaved: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "bidder_request_bytes_saved_total",
+				Help:      "Approximate request bytes request shaping kept from each bidder",
+			},
+			[]string{"bidder"},
+		),
+		MiddlewareOverhead: prometheus.NewHistogram(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "middleware_overhead_ms",
+				Help:      "Milliseconds of the auction timeout budget spent in middleware before the auction started",
+				Buckets:   []float64{1, 2, 5, 10, 20, 50, 100, 200, 500},
+			},
+		),
+		BidderQueueDepth: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "bidder_queue_depth",
+				Help:      "Bidder calls waiting for a worker in the bidder pool",
+			},
+		),
+		BidderQueueWait: prometheus.NewHistogram(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "bidder_queue_wait_seconds",
+				Help:      "Time bidder calls waited for a worker in the bidder pool, in seconds",
+				Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5},
+			},
+		),
 
 		// IDR metrics
 		IDRRequests: prometheus.NewCounterVec(
@@ -193,6 +305,120 @@ func NewMetrics(namespace string) *Metrics {
 			},
 			[]string{},
 		),
+		IDRSelectionCache: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "idr_selection_cache_lookups_total",
+				Help:      "IDR selection cache lookups by result (hit, miss)",
+			},
+			[]string{"result"},
+		),
+		IDREventSpill: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "idr_event_spill_events_total",
+				Help:      "IDR bid events through the disk spill by result (spilled, replayed, dropped)",
+			},
+			[]string{"result"},
+		),
+		FeedbackEvents: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "feedback_events_total",
+				Help:      "Client feedback events by outcome (won, lost, rendered, render_failed, viewable, invalid)",
+			},
+			[]string{"outcome"},
+		),
+		EventNotifications: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "event_notifications_total",
+				Help:      "Bid win/imp notifications by type and result (recorded, duplicate, unmatched, invalid)",
+			},
+			[]string{"type", "result"},
+		),
+		NoticeURLs: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "notice_urls_total",
+				Help:      "Bidder nurl/burl notices fired server-side by bidder, type and result (success, failure, dropped)",
+			},
+			[]string{"bidder", "type", "result"},
+		),
+
+		BidderRollout: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "bidder_rollout_decisions_total",
+				Help:      "Auctions for bidders under gradual rollout by decision (called, held_back)",
+			},
+			[]string{"bidder", "decision"},
+		),
+		BidderTrafficPercent: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "bidder_traffic_percent",
+				Help:      "Current traffic_percent of bidders under gradual rollout",
+			},
+			[]string{"bidder"},
+		),
+		BidderThrottled: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "bidder_throttled_total",
+				Help:      "Auctions a dynamic bidder sat out for exceeding its rate_limits, by limit (qps, daily, concurrency)",
+			},
+			[]string{"bidder", "limit"},
+		),
+		CreativeRejected: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "creative_rejected_total",
+				Help:      "Bids rejected by creative validation, by reason (size, badv, bcat, battr, document_write, insecure)",
+			},
+			[]string{"bidder", "reason"},
+		),
+		BidderCircuitState: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "bidder_circuit_state",
+				Help:      "Bidder circuit breaker state (0 closed, 1 half-open, 2 open)",
+			},
+			[]string{"bidder"},
+		),
+		BidderCircuitSkipped: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "bidder_circuit_skipped_total",
+				Help:      "Auctions a bidder sat out while its circuit breaker was open",
+			},
+			[]string{"bidder"},
+		),
+		BidderConnections: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "bidder_connections_total",
+				Help:      "Bidder calls by host and whether they reused a pooled connection",
+			},
+			[]string{"host", "reused"},
+		),
+		BidderTLSHandshake: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "bidder_tls_handshake_seconds",
+				Help:      "TLS handshakes with bidder hosts for new connections, in seconds",
+				Buckets:   []float64{.005, .01, .025, .05, .075, .1, .15, .2, .3, .5},
+			},
+			[]string{"host"},
+		),
+		AdapterSandboxFaults: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "adapter_sandbox_faults_total",
+				Help:      "Sandboxed dynamic bidder faults by reason (panic, timeout, busy, request_too_large, response_too_large, too_many_bids)",
+			},
+			[]string{"bidder", "reason"},
+		),
 
 		// Privacy metrics
 		PrivacyFiltered: prometheus.NewCounterVec(
@@ -211,6 +437,62 @@ func NewMetrics(namespace string) *Metrics {
 			},
 			[]string{"type", "has_consent"},
 		),
+		GPPSections: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "privacy_gpp_sections_total",
+				Help:      "GPP sections enforced by section (header, tcfeuv2, usnat) and outcome (allowed, blocked, not_enforced, not_applicable, invalid)",
+			},
+			[]string{"section", "outcome"},
+		),
+
+		// Dynamic registry metrics
+		DynamicRegistryRefreshes: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "dynamic_registry_refreshes_total",
+				Help:      "Total dynamic bidder registry refreshes",
+			},
+			[]string{"status"},
+		),
+		DynamicRegistryRefreshLatency: prometheus.NewHistogram(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "dynamic_registry_refresh_duration_seconds",
+				Help:      "Dynamic bidder registry refresh duration in seconds",
+				Buckets:   []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+			},
+		),
+		DynamicRegistryLastSuccess: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "dynamic_registry_last_success_timestamp_seconds",
+				Help:      "Unix time of the last successful dynamic registry refresh",
+			},
+		),
+		DynamicRegistryConfigs: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "dynamic_registry_configs",
+				Help:      "Bidder configs on the last successful refresh by result (loaded, failed)",
+			},
+			[]string{"result"},
+		),
+		DynamicRegistryParseErrors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "dynamic_registry_parse_errors_total",
+				Help:      "Total bidder config parse failures by bidder",
+			},
+			[]string{"bidder"},
+		),
+		DynamicRegistryStale: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "dynamic_registry_stale",
+				Help:      "Whether the dynamic bidder registry is stale (0=fresh, 1=stale)",
+			},
+		),
 
 		// System metrics
 		ActiveConnections: prometheus.NewGauge(
@@ -227,6 +509,14 @@ func NewMetrics(namespace string) *Metrics {
 				Help:      "Total requests rejected due to rate limiting",
 			},
 		),
+		SyncRateLimitRejected: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "sync_rate_limit_rejected_total",
+				Help:      "Total cookie sync requests rejected by the sync rate limiter",
+			},
+			[]string{"scope"},
+		),
 		AuthFailures: prometheus.NewCounter(
 			prometheus.CounterOpts{
 				Namespace: namespace,
@@ -234,6 +524,14 @@ func NewMetrics(namespace string) *Metrics {
 				Help:      "Total authentication failures",
 			},
 		),
+		InventoryMismatches: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "publisher_inventory_mismatch_total",
+				Help:      "Auction requests whose site domain or app bundle is not registered to the publisher account",
+			},
+			[]string{"publisher", "inventory", "action"},
+		),
 	}
 
 	// Register all metrics
@@ -241,7 +539,10 @@ func NewMetrics(namespace string) *Metrics {
 		m.RequestsTotal,
 		m.RequestDuration,
 		m.RequestsInFlight,
+		m.RouteRequests,
+		m.RouteDuration,
 		m.AuctionsTotal,
+		m.AuctionAPIRequests,
 		m.AuctionDuration,
 		m.BidsReceived,
 		m.BidCPM,
@@ -251,14 +552,42 @@ func NewMetrics(namespace string) *Metrics {
 		m.BidderLatency,
 		m.BidderErrors,
 		m.BidderTimeouts,
+		m.BidderRequestBytes,
+		m.BidderBytesSaved,
+		m.MiddlewareOverhead,
+		m.BidderQueueDepth,
+		m.BidderQueueWait,
+		m.BidderRollout,
+		m.BidderTrafficPercent,
+		m.BidderThrottled,
+		m.CreativeRejected,
+		m.BidderCircuitState,
+		m.BidderCircuitSkipped,
+		m.BidderConnections,
+		m.BidderTLSHandshake,
+		m.AdapterSandboxFaults,
 		m.IDRRequests,
 		m.IDRLatency,
 		m.IDRCircuitState,
+		m.IDRSelectionCache,
+		m.IDREventSpill,
+		m.FeedbackEvents,
+		m.EventNotifications,
+		m.NoticeURLs,
 		m.PrivacyFiltered,
 		m.ConsentSignals,
+		m.GPPSections,
+		m.DynamicRegistryRefreshes,
+		m.DynamicRegistryRefreshLatency,
+		m.DynamicRegistryLastSuccess,
+		m.DynamicRegistryConfigs,
+		m.DynamicRegistryParseErrors,
+		m.DynamicRegistryStale,
 		m.ActiveConnections,
 		m.RateLimitRejected,
+		m.SyncRateLimitRejected,
 		m.AuthFailures,
+		m.InventoryMismatches,
 	)
 
 	return m
@@ -301,13 +630,21 @@ func (rw *responseWriter) WriteHeader(code int) {
 }
 
 // RecordAuction records auction metrics
+// Implements exchange.AuctionMetrics interface
 func (m *Metrics) RecordAuction(status, mediaType string, duration time.Duration, biddersSelected, biddersExcluded int) {
 	m.AuctionsTotal.WithLabelValues(status, mediaType).Inc()
 	m.AuctionDuration.WithLabelValues(mediaType).Observe(duration.Seconds())
 	m.BiddersSelected.WithLabelValues(mediaType).Observe(float64(biddersSelected))
 }
 
+// RecordAuctionAPIRequest records an auction endpoint request for an API version
+// Implements endpoints.AuctionVersionMetrics interface
+func (m *Metrics) RecordAuctionAPIRequest(version, outcome string) {
+	m.AuctionAPIRequests.WithLabelValues(version, outcome).Inc()
+}
+
 // RecordBid records a bid received from a bidder
+// Implements exchange.AuctionMetrics interface
 func (m *Metrics) RecordBid(bidder, mediaType string, cpm float64) {
 	m.BidsReceived.WithLabelValues(bidder, mediaType).Inc()
 	m.BidCPM.WithLabelValues(bidder, mediaType).Observe(cpm)
@@ -346,6 +683,136 @@ func (m *Metrics) SetIDRCircuitState(state string) {
 	m.IDRCircuitState.WithLabelValues().Set(value)
 }
 
+// RecordIDRCacheLookup records an IDR selection cache hit or miss
+// Implements exchange.IDRCacheMetrics interface
+func (m *Metrics) RecordIDRCacheLookup(hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	m.IDRSelectionCache.WithLabelValues(result).Inc()
+}
+
+// RecordIDREventSpill records IDR bid events spilled to disk, replayed from it or dropped from it
+// Implements idr.EventMetrics interface
+func (m *Metrics) RecordIDREventSpill(result string, events int) {
+	m.IDREventSpill.WithLabelValues(result).Add(float64(events))
+}
+
+// RecordBidderRollout records whether a bidder under gradual rollout was called
+// Implements exchange.RolloutMetrics interface
+func (m *Metrics) RecordBidderRollout(bidder string, trafficPercent int, called bool) {
+	decision := "held_back"
+	if called {
+		decision = "called"
+	}
+	m.BidderRollout.WithLabelValues(bidder, decision).Inc()
+	m.BidderTrafficPercent.WithLabelValues(bidder).Set(float64(trafficPercent))
+}
+
+// RecordBidderThrottled records a bidder skipped for exceeding one of its rate_limits
+// Implements exchange.ThrottleMetrics interface
+func (m *Metrics) RecordBidderThrottled(bidder, limit string) {
+	m.BidderThrottled.WithLabelValues(bidder, limit).Inc()
+}
+
+// circuitStateValues maps circuit states to the bidder_circuit_state gauge
+var circuitStateValues = map[string]float64{"closed": 0, "half-open": 1, "open": 2}
+
+// RecordBidderCircuitState records a bidder's circuit breaker changing state
+// Implements exchange.CircuitBreakerMetrics interface
+func (m *Metrics) RecordBidderCircuitState(bidder, state string) {
+	m.BidderCircuitState.WithLabelValues(bidder).Set(circuitStateValues[state])
+}
+
+// RecordBidderCircuitSkipped records an auction skipping a bidder with an open circuit
+// Implements exchange.CircuitBreakerMetrics interface
+func (m *Metrics) RecordBidderCircuitSkipped(bidder string) {
+	m.BidderCircuitSkipped.WithLabelValues(bidder).Inc()
+}
+
+// RecordBidderConnection records whether a bidder call reused a pooled connection
+// Implements adapters.ConnectionMetrics interface
+func (m *Metrics) RecordBidderConnection(host string, reused bool) {
+	m.BidderConnections.WithLabelValues(host, strconv.FormatBool(reused)).Inc()
+}
+
+// RecordBidderTLSHandshake records the TLS handshake of a new bidder connection
+// Implements adapters.ConnectionMetrics interface
+func (m *Metrics) RecordBidderTLSHandshake(host string, duration time.Duration) {
+	m.BidderTLSHandshake.WithLabelValues(host).Observe(duration.Seconds())
+}
+
+// RecordAdapterSandboxFault counts a contained fault of a sandboxed bidder
+// Implements exchange.SandboxMetrics interface
+func (m *Metrics) RecordAdapterSandboxFault(bidder, reason string) {
+	m.AdapterSandboxFaults.WithLabelValues(bidder, reason).Inc()
+}
+
+// RecordCreativeRejected counts a bid rejected by creative validation
+// Implements exchange.CreativeMetrics interface
+func (m *Metrics) RecordCreativeRejected(bidder, reason string) {
+	m.CreativeRejected.WithLabelValues(bidder, reason).Inc()
+}
+
+// RecordBidderErrors counts a bidder call's errors of one category
+// Implements exchange.BidderErrorMetrics interface
+func (m *Metrics) RecordBidderErrors(bidder, category string, count int) {
+	m.BidderErrors.WithLabelValues(bidder, category).Add(float64(count))
+}
+
+// RecordBidderRequestSize records a bidder call's outbound request size
+// Implements exchange.RequestSizeMetrics interface
+func (m *Metrics) RecordBidderRequestSize(bidder string, bytes, savedBytes int) {
+	m.BidderRequestBytes.WithLabelValues(bidder).Observe(float64(bytes))
+	if savedBytes > 0 {
+		m.BidderBytesSaved.WithLabelValues(bidder).Add(float64(savedBytes))
+	}
+}
+
+// RecordRoute records a request served by a route, labeled by its pattern
+// Implements router.Metrics interface
+func (m *Metrics) RecordRoute(route, method string, status int, duration time.Duration) {
+	m.RouteRequests.WithLabelValues(route, method, strconv.Itoa(status)).Inc()
+	m.RouteDuration.WithLabelValues(route).Observe(duration.Seconds())
+}
+
+// RecordMiddlewareOverhead records the time a request spent in middleware before its auction
+// Implements exchange.MiddlewareOverheadMetrics interface
+func (m *Metrics) RecordMiddlewareOverhead(overhead time.Duration) {
+	m.MiddlewareOverhead.Observe(float64(overhead) / float64(time.Millisecond))
+}
+
+// SetBidderQueueDepth records how many bidder calls are waiting for a worker
+// Implements exchange.BidderPoolMetrics interface
+func (m *Metrics) SetBidderQueueDepth(depth int) {
+	m.BidderQueueDepth.Set(float64(depth))
+}
+
+// RecordBidderQueueWait records how long a bidder call waited for a worker
+// Implements exchange.BidderPoolMetrics interface
+func (m *Metrics) RecordBidderQueueWait(wait time.Duration) {
+	m.BidderQueueWait.Observe(wait.Seconds())
+}
+
+// IncFeedbackEvent counts a client feedback event by outcome
+// Implements endpoints.FeedbackMetrics interface
+func (m *Metrics) IncFeedbackEvent(outcome string) {
+	m.FeedbackEvents.WithLabelValues(outcome).Inc()
+}
+
+// IncEventNotification counts a bid event notification by type and result
+// Implements endpoints.EventMetrics interface
+func (m *Metrics) IncEventNotification(eventType, result string) {
+	m.EventNotifications.WithLabelValues(eventType, result).Inc()
+}
+
+// RecordNoticeURL counts a notice URL fired server-side
+// Implements exchange.NoticeMetrics interface
+func (m *Metrics) RecordNoticeURL(bidder, kind, result string) {
+	m.NoticeURLs.WithLabelValues(bidder, kind, result).Inc()
+}
+
 // RecordPrivacyFiltered records when a bidder is filtered for privacy reasons
 func (m *Metrics) RecordPrivacyFiltered(bidder, reason string) {
 	m.PrivacyFiltered.WithLabelValues(bidder, reason).Inc()
@@ -360,14 +827,62 @@ func (m *Metrics) RecordConsentSignal(signalType string, hasConsent bool) {
 	m.ConsentSignals.WithLabelValues(signalType, consent).Inc()
 }
 
+// RecordGPPSection counts a GPP section enforced by the privacy middleware
+// Implements middleware.PrivacyMetrics interface
+func (m *Metrics) RecordGPPSection(section, outcome string) {
+	m.GPPSections.WithLabelValues(section, outcome).Inc()
+}
+
+// RecordDynamicRegistryRefresh records a dynamic registry refresh attempt
+// Implements ortb.RefreshMetrics interface
+func (m *Metrics) RecordDynamicRegistryRefresh(success bool, duration time.Duration, loaded, failed int) {
+	m.DynamicRegistryRefreshLatency.Observe(duration.Seconds())
+	if !success {
+		m.DynamicRegistryRefreshes.WithLabelValues("error").Inc()
+		return
+	}
+	m.DynamicRegistryRefreshes.WithLabelValues("success").Inc()
+	m.DynamicRegistryLastSuccess.SetToCurrentTime()
+	m.DynamicRegistryConfigs.WithLabelValues("loaded").Set(float64(loaded))
+	m.DynamicRegistryConfigs.WithLabelValues("failed").Set(float64(failed))
+}
+
+// IncDynamicRegistryParseError records a bidder config that failed to parse
+// Implements ortb.RefreshMetrics interface
+func (m *Metrics) IncDynamicRegistryParseError(bidderCode string) {
+	m.DynamicRegistryParseErrors.WithLabelValues(bidderCode).Inc()
+}
+
+// SetDynamicRegistryStale sets the dynamic registry staleness gauge
+// Implements ortb.RefreshMetrics interface
+func (m *Metrics) SetDynamicRegistryStale(stale bool) {
+	var value float64
+	if stale {
+		value = 1
+	}
+	m.DynamicRegistryStale.Set(value)
+}
+
 // IncRateLimitRejected increments the rate limit rejected counter
 // Implements middleware.RateLimitMetrics interface
 func (m *Metrics) IncRateLimitRejected() {
 	m.RateLimitRejected.Inc()
 }
 
+// IncSyncRateLimitRejected increments the sync rate limit rejected counter for scope (ip or publisher)
+// Implements middleware.SyncRateLimitMetrics interface
+func (m *Metrics) IncSyncRateLimitRejected(scope string) {
+	m.SyncRateLimitRejected.WithLabelValues(scope).Inc()
+}
+
 // IncAuthFailures increments the auth failures counter
 // Implements middleware.AuthMetrics interface
 func (m *Metrics) IncAuthFailures() {
 	m.AuthFailures.Inc()
 }
+
+// RecordInventoryMismatch counts a request whose inventory isn't registered to its publisher
+// Implements middleware.PublisherAuthMetrics interface
+func (m *Metrics) RecordInventoryMismatch(publisherID, inventory, action string) {
+	m.InventoryMismatches.WithLabelValues(publisherID, inventory, action).Inc()
+}
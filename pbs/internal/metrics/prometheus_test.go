@@ -45,6 +45,23 @@ func createTestMetrics(namespace string) (*Metrics, *prometheus.Registry) {
 				Help:      "Number of HTTP requests currently being served",
 			},
 		),
+		RouteRequests: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "route_requests_total",
+				Help:      "HTTP requests by route pattern",
+			},
+			[]string{"route", "method", "status"},
+		),
+		RouteDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "route_request_duration_seconds",
+				Help:      "HTTP request duration by route pattern, excluding router-wide middleware",
+				Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+			},
+			[]string{"route"},
+		),
 		AuctionsTotal: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
 				Namespace: namespace,
@@ -53,6 +70,14 @@ func createTestMetrics(namespace string) (*Metrics, *prometheus.Registry) {
 			},
 			[]string{"status", "media_type"},
 		),
+		AuctionAPIRequests: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "auction_api_requests_total",
+				Help:      "Auction endpoint requests by API version and outcome (ok, rejected, error)",
+			},
+			[]string{"version", "outcome"},
+		),
 		AuctionDuration: prometheus.NewHistogramVec(
 			prometheus.HistogramOpts{
 				Namespace: namespace,
@@ -129,6 +154,46 @@ func createTestMetrics(namespace string) (*Metrics, *prometheus.Registry) {
 			},
 			[]string{"bidder"},
 		),
+		BidderRequestBytes: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "bidder_request_bytes",
+				Help:      "Serialized size of the requests sent to each bidder per auction",
+				Buckets:   prometheus.ExponentialBuckets(512, 2, 10),
+			},
+			[]string{"bidder"},
+		),
+		BidderBytesSaved: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "bidder_request_bytes_saved_total",
+				Help:      "Approximate request bytes request shaping kept from each bidder",
+			},
+			[]string{"bidder"},
+		),
+		MiddlewareOverhead: prometheus.NewHistogram(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "middleware_overhead_ms",
+				Help:      "Milliseconds of the auction timeout budget spent in middleware before the auction started",
+				Buckets:   []float64{1, 2, 5, 10, 20, 50, 100, 200, 500},
+			},
+		),
+		BidderQueueDepth: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "bidder_queue_depth",
+				Help:      "Bidder calls waiting for a worker in the bidder pool",
+			},
+		),
+		BidderQueueWait: prometheus.NewHistogram(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "bidder_queue_wait_seconds",
+				Help:      "Time bidder calls waited for a worker in the bidder pool, in seconds",
+				Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5},
+			},
+		),
 		IDRRequests: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
 				Namespace: namespace,
@@ -154,6 +219,119 @@ func createTestMetrics(namespace string) (*Metrics, *prometheus.Registry) {
 			},
 			[]string{},
 		),
+		IDRSelectionCache: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "idr_selection_cache_lookups_total",
+				Help:      "IDR selection cache lookups by result (hit, miss)",
+			},
+			[]string{"result"},
+		),
+		IDREventSpill: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "idr_event_spill_events_total",
+				Help:      "IDR bid events through the disk spill by result (spilled, replayed, dropped)",
+			},
+			[]string{"result"},
+		),
+		FeedbackEvents: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "feedback_events_total",
+				Help:      "Client feedback events by outcome (won, lost, rendered, render_failed, viewable, invalid)",
+			},
+			[]string{"outcome"},
+		),
+		EventNotifications: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "event_notifications_total",
+				Help:      "Bid win/imp notifications by type and result (recorded, duplicate, unmatched, invalid)",
+			},
+			[]string{"type", "result"},
+		),
+		NoticeURLs: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "notice_urls_total",
+				Help:      "Bidder nurl/burl notices fired server-side by bidder, type and result (success, failure, dropped)",
+			},
+			[]string{"bidder", "type", "result"},
+		),
+		BidderRollout: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "bidder_rollout_decisions_total",
+				Help:      "Auctions for bidders under gradual rollout by decision (called, held_back)",
+			},
+			[]string{"bidder", "decision"},
+		),
+		BidderTrafficPercent: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "bidder_traffic_percent",
+				Help:      "Current traffic_percent of bidders under gradual rollout",
+			},
+			[]string{"bidder"},
+		),
+		BidderThrottled: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "bidder_throttled_total",
+				Help:      "Auctions a dynamic bidder sat out for exceeding its rate_limits, by limit (qps, daily, concurrency)",
+			},
+			[]string{"bidder", "limit"},
+		),
+		CreativeRejected: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "creative_rejected_total",
+				Help:      "Bids rejected by creative validation",
+			},
+			[]string{"bidder", "reason"},
+		),
+		BidderCircuitState: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "bidder_circuit_state",
+				Help:      "Bidder circuit breaker state (0 closed, 1 half-open, 2 open)",
+			},
+			[]string{"bidder"},
+		),
+		BidderCircuitSkipped: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "bidder_circuit_skipped_total",
+				Help:      "Auctions a bidder sat out while its circuit breaker was open",
+			},
+			[]string{"bidder"},
+		),
+		BidderConnections: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "bidder_connections_total",
+				Help:      "Bidder calls by host and whether they reused a pooled connection",
+			},
+			[]string{"host", "reused"},
+		),
+		BidderTLSHandshake: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "bidder_tls_handshake_seconds",
+				Help:      "TLS handshakes with bidder hosts for new connections, in seconds",
+				Buckets:   []float64{.005, .01, .025, .05, .075, .1, .15, .2, .3, .5},
+			},
+			[]string{"host"},
+		),
+		AdapterSandboxFaults: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "adapter_sandbox_faults_total",
+				Help:      "Sandboxed dynamic bidder faults by reason (panic, timeout, busy, request_too_large, response_too_large, too_many_bids)",
+			},
+			[]string{"bidder", "reason"},
+		),
 		PrivacyFiltered: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
 				Namespace: namespace,
@@ -170,6 +348,14 @@ func createTestMetrics(namespace string) (*Metrics, *prometheus.Registry) {
 			},
 			[]string{"type", "has_consent"},
 		),
+		GPPSections: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "privacy_gpp_sections_total",
+				Help:      "GPP sections enforced",
+			},
+			[]string{"section", "outcome"},
+		),
 		ActiveConnections: prometheus.NewGauge(
 			prometheus.GaugeOpts{
 				Namespace: namespace,
@@ -184,6 +370,14 @@ func createTestMetrics(namespace string) (*Metrics, *prometheus.Registry) {
 				Help:      "Total requests rejected due to rate limiting",
 			},
 		),
+		SyncRateLimitRejected: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "sync_rate_limit_rejected_total",
+				Help:      "Total cookie sync requests rejected by the sync rate limiter",
+			},
+			[]string{"scope"},
+		),
 		AuthFailures: prometheus.NewCounter(
 			prometheus.CounterOpts{
 				Namespace: namespace,
@@ -191,6 +385,59 @@ func createTestMetrics(namespace string) (*Metrics, *prometheus.Registry) {
 				Help:      "Total authentication failures",
 			},
 		),
+		InventoryMismatches: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "publisher_inventory_mismatch_total",
+				Help:      "Auction requests whose site domain or app bundle is not registered to the publisher account",
+			},
+			[]string{"publisher", "inventory", "action"},
+		),
+		DynamicRegistryRefreshes: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "dynamic_registry_refreshes_total",
+				Help:      "Total dynamic bidder registry refreshes",
+			},
+			[]string{"status"},
+		),
+		DynamicRegistryRefreshLatency: prometheus.NewHistogram(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "dynamic_registry_refresh_duration_seconds",
+				Help:      "Dynamic bidder registry refresh duration in seconds",
+			},
+		),
+		DynamicRegistryLastSuccess: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "dynamic_registry_last_success_timestamp_seconds",
+				Help:      "Unix time of the last successful dynamic registry refresh",
+			},
+		),
+		DynamicRegistryConfigs: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "dynamic_registry_configs",
+				Help:      "Bidder configs on the last successful refresh by result (loaded, failed)",
+			},
+			[]string{"result"},
+		),
+		DynamicRegistryParseErrors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "dynamic_registry_parse_errors_total",
+				Help:      "Total bidder config parse failures by bidder",
+			},
+			[]string{"bidder"},
+		),
+		DynamicRegistryStale: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "dynamic_registry_stale",
+				Help:      "Whether the dynamic bidder registry is stale (0=fresh, 1=stale)",
+			},
+		),
 	}
 
 	// Register with custom registry
@@ -198,7 +445,10 @@ func createTestMetrics(namespace string) (*Metrics, *prometheus.Registry) {
 		m.RequestsTotal,
 		m.RequestDuration,
 		m.RequestsInFlight,
+		m.RouteRequests,
+		m.RouteDuration,
 		m.AuctionsTotal,
+		m.AuctionAPIRequests,
 		m.AuctionDuration,
 		m.BidsReceived,
 		m.BidCPM,
@@ -208,14 +458,42 @@ func createTestMetrics(namespace string) (*Metrics, *prometheus.Registry) {
 		m.BidderLatency,
 		m.BidderErrors,
 		m.BidderTimeouts,
+		m.BidderRequestBytes,
+		m.BidderBytesSaved,
+		m.MiddlewareOverhead,
+		m.BidderQueueDepth,
+		m.BidderQueueWait,
 		m.IDRRequests,
 		m.IDRLatency,
 		m.IDRCircuitState,
 		m.PrivacyFiltered,
 		m.ConsentSignals,
+		m.GPPSections,
 		m.ActiveConnections,
 		m.RateLimitRejected,
+		m.SyncRateLimitRejected,
 		m.AuthFailures,
+		m.InventoryMismatches,
+		m.DynamicRegistryRefreshes,
+		m.DynamicRegistryRefreshLatency,
+		m.DynamicRegistryLastSuccess,
+		m.DynamicRegistryConfigs,
+		m.DynamicRegistryParseErrors,
+		m.DynamicRegistryStale,
+		m.IDRSelectionCache,
+		m.IDREventSpill,
+		m.FeedbackEvents,
+		m.EventNotifications,
+		m.NoticeURLs,
+		m.BidderRollout,
+		m.BidderTrafficPercent,
+		m.BidderThrottled,
+		m.CreativeRejected,
+		m.BidderCircuitState,
+		m.BidderCircuitSkipped,
+		m.BidderConnections,
+		m.BidderTLSHandshake,
+		m.AdapterSandboxFaults,
 	)
 
 	return m, registry
@@ -759,6 +1037,21 @@ func TestRecordConsentSignal_DifferentTypes(t *testing.T) {
 	}
 }
 
+func TestRecordGPPSection(t *testing.T) {
+	m, _ := createTestMetrics("gpp_sections")
+
+	m.RecordGPPSection("usnat", "blocked")
+	m.RecordGPPSection("usnat", "blocked")
+	m.RecordGPPSection("tcfeuv2", "allowed")
+
+	if testutil.ToFloat64(m.GPPSections.WithLabelValues("usnat", "blocked")) != 2 {
+		t.Error("expected 2 blocked US National sections")
+	}
+	if testutil.ToFloat64(m.GPPSections.WithLabelValues("tcfeuv2", "allowed")) != 1 {
+		t.Error("expected 1 allowed TCF EU v2 section")
+	}
+}
+
 func TestSystemMetrics_ActiveConnections(t *testing.T) {
 	m, _ := createTestMetrics("sys_conn")
 
@@ -826,6 +1119,330 @@ func TestSystemMetrics_AuthFailures(t *testing.T) {
 	}
 }
 
+func TestRecordDynamicRegistryRefresh_Success(t *testing.T) {
+	m, _ := createTestMetrics("dyn_ok")
+
+	m.RecordDynamicRegistryRefresh(true, 20*time.Millisecond, 5, 1)
+
+	if testutil.ToFloat64(m.DynamicRegistryRefreshes.WithLabelValues("success")) != 1 {
+		t.Error("expected 1 successful refresh")
+	}
+	if testutil.ToFloat64(m.DynamicRegistryConfigs.WithLabelValues("loaded")) != 5 {
+		t.Error("expected 5 loaded configs")
+	}
+	if testutil.ToFloat64(m.DynamicRegistryConfigs.WithLabelValues("failed")) != 1 {
+		t.Error("expected 1 failed config")
+	}
+	if testutil.ToFloat64(m.DynamicRegistryLastSuccess) == 0 {
+		t.Error("expected last success timestamp to be set")
+	}
+}
+
+func TestRecordDynamicRegistryRefresh_Error(t *testing.T) {
+	m, _ := createTestMetrics("dyn_err")
+
+	m.RecordDynamicRegistryRefresh(false, 5*time.Second, 0, 0)
+
+	if testutil.ToFloat64(m.DynamicRegistryRefreshes.WithLabelValues("error")) != 1 {
+		t.Error("expected 1 failed refresh")
+	}
+	if testutil.ToFloat64(m.DynamicRegistryLastSuccess) != 0 {
+		t.Error("expected last success timestamp to be untouched on error")
+	}
+}
+
+func TestRecordAuctionAPIRequest(t *testing.T) {
+	m, _ := createTestMetrics("test")
+
+	m.RecordAuctionAPIRequest("v1", "ok")
+	m.RecordAuctionAPIRequest("v2", "ok")
+	m.RecordAuctionAPIRequest("v2", "rejected")
+
+	if testutil.ToFloat64(m.AuctionAPIRequests.WithLabelValues("v1", "ok")) != 1 {
+		t.Error("expected 1 ok v1 request")
+	}
+	if testutil.ToFloat64(m.AuctionAPIRequests.WithLabelValues("v2", "rejected")) != 1 {
+		t.Error("expected 1 rejected v2 request")
+	}
+}
+
+func TestRecordIDRCacheLookup(t *testing.T) {
+	m, _ := createTestMetrics("test")
+
+	m.RecordIDRCacheLookup(true)
+	m.RecordIDRCacheLookup(true)
+	m.RecordIDRCacheLookup(false)
+
+	if testutil.ToFloat64(m.IDRSelectionCache.WithLabelValues("hit")) != 2 {
+		t.Error("expected 2 cache hits")
+	}
+	if testutil.ToFloat64(m.IDRSelectionCache.WithLabelValues("miss")) != 1 {
+		t.Error("expected 1 cache miss")
+	}
+}
+
+func TestRecordIDREventSpill(t *testing.T) {
+	m, _ := createTestMetrics("test")
+
+	m.RecordIDREventSpill("spilled", 100)
+	m.RecordIDREventSpill("replayed", 60)
+	m.RecordIDREventSpill("spilled", 20)
+
+	if testutil.ToFloat64(m.IDREventSpill.WithLabelValues("spilled")) != 120 {
+		t.Error("expected 120 spilled events")
+	}
+	if testutil.ToFloat64(m.IDREventSpill.WithLabelValues("replayed")) != 60 {
+		t.Error("expected 60 replayed events")
+	}
+}
+
+func TestIncFeedbackEvent(t *testing.T) {
+	m, _ := createTestMetrics("test")
+
+	m.IncFeedbackEvent("rendered")
+	m.IncFeedbackEvent("rendered")
+	m.IncFeedbackEvent("invalid")
+
+	if testutil.ToFloat64(m.FeedbackEvents.WithLabelValues("rendered")) != 2 {
+		t.Error("expected 2 rendered events")
+	}
+	if testutil.ToFloat64(m.FeedbackEvents.WithLabelValues("invalid")) != 1 {
+		t.Error("expected 1 invalid event")
+	}
+}
+
+func TestIncEventNotification(t *testing.T) {
+	m, _ := createTestMetrics("test")
+
+	m.IncEventNotification("win", "recorded")
+	m.IncEventNotification("win", "duplicate")
+	m.IncEventNotification("imp", "recorded")
+
+	if testutil.ToFloat64(m.EventNotifications.WithLabelValues("win", "recorded")) != 1 {
+		t.Error("expected 1 recorded win")
+	}
+	if testutil.ToFloat64(m.EventNotifications.WithLabelValues("imp", "recorded")) != 1 {
+		t.Error("expected 1 recorded imp")
+	}
+}
+
+func TestRecordNoticeURL(t *testing.T) {
+	m, _ := createTestMetrics("test")
+
+	m.RecordNoticeURL("appnexus", "nurl", "success")
+	m.RecordNoticeURL("appnexus", "nurl", "success")
+	m.RecordNoticeURL("rubicon", "burl", "failure")
+
+	if testutil.ToFloat64(m.NoticeURLs.WithLabelValues("appnexus", "nurl", "success")) != 2 {
+		t.Error("expected 2 successful appnexus nurls")
+	}
+	if testutil.ToFloat64(m.NoticeURLs.WithLabelValues("rubicon", "burl", "failure")) != 1 {
+		t.Error("expected 1 failed rubicon burl")
+	}
+}
+
+func TestRecordBidderRollout(t *testing.T) {
+	m, _ := createTestMetrics("test")
+
+	m.RecordBidderRollout("newbidder", 10, true)
+	m.RecordBidderRollout("newbidder", 10, false)
+	m.RecordBidderRollout("newbidder", 10, false)
+
+	if testutil.ToFloat64(m.BidderRollout.WithLabelValues("newbidder", "called")) != 1 {
+		t.Error("expected 1 called auction")
+	}
+	if testutil.ToFloat64(m.BidderRollout.WithLabelValues("newbidder", "held_back")) != 2 {
+		t.Error("expected 2 held back auctions")
+	}
+	if testutil.ToFloat64(m.BidderTrafficPercent.WithLabelValues("newbidder")) != 10 {
+		t.Error("expected traffic percent gauge of 10")
+	}
+}
+
+func TestRecordBidderThrottled(t *testing.T) {
+	m, _ := createTestMetrics("test")
+
+	m.RecordBidderThrottled("newbidder", "qps")
+	m.RecordBidderThrottled("newbidder", "qps")
+	m.RecordBidderThrottled("newbidder", "daily")
+
+	if testutil.ToFloat64(m.BidderThrottled.WithLabelValues("newbidder", "qps")) != 2 {
+		t.Error("expected 2 qps throttles")
+	}
+	if testutil.ToFloat64(m.BidderThrottled.WithLabelValues("newbidder", "daily")) != 1 {
+		t.Error("expected 1 daily throttle")
+	}
+}
+
+func TestRecordCreativeRejected(t *testing.T) {
+	m, _ := createTestMetrics("test")
+
+	m.RecordCreativeRejected("bidder", "badv")
+	m.RecordCreativeRejected("bidder", "badv")
+	m.RecordCreativeRejected("bidder", "size")
+
+	if testutil.ToFloat64(m.CreativeRejected.WithLabelValues("bidder", "badv")) != 2 {
+		t.Error("expected 2 badv rejections")
+	}
+	if testutil.ToFloat64(m.CreativeRejected.WithLabelValues("bidder", "size")) != 1 {
+		t.Error("expected 1 size rejection")
+	}
+}
+
+func TestRecordBidderCircuit(t *testing.T) {
+	m, _ := createTestMetrics("test")
+
+	m.RecordBidderCircuitState("flaky", "open")
+	m.RecordBidderCircuitSkipped("flaky")
+	m.RecordBidderCircuitSkipped("flaky")
+
+	if testutil.ToFloat64(m.BidderCircuitState.WithLabelValues("flaky")) != 2 {
+		t.Error("expected open circuit state of 2")
+	}
+	if testutil.ToFloat64(m.BidderCircuitSkipped.WithLabelValues("flaky")) != 2 {
+		t.Error("expected 2 skipped auctions")
+	}
+
+	m.RecordBidderCircuitState("flaky", "closed")
+	if testutil.ToFloat64(m.BidderCircuitState.WithLabelValues("flaky")) != 0 {
+		t.Error("expected closed circuit state of 0")
+	}
+}
+
+func TestRecordBidderConnection(t *testing.T) {
+	m, _ := createTestMetrics("test")
+
+	m.RecordBidderConnection("bid.example.com", true)
+	m.RecordBidderConnection("bid.example.com", true)
+	m.RecordBidderConnection("bid.example.com", false)
+	m.RecordBidderTLSHandshake("bid.example.com", 40*time.Millisecond)
+
+	if testutil.ToFloat64(m.BidderConnections.WithLabelValues("bid.example.com", "true")) != 2 {
+		t.Error("expected 2 reused connections")
+	}
+	if testutil.ToFloat64(m.BidderConnections.WithLabelValues("bid.example.com", "false")) != 1 {
+		t.Error("expected 1 new connection")
+	}
+	if testutil.CollectAndCount(m.BidderTLSHandshake) != 1 {
+		t.Error("expected a TLS handshake observation")
+	}
+}
+
+func TestRecordAdapterSandboxFault(t *testing.T) {
+	m, _ := createTestMetrics("test")
+
+	m.RecordAdapterSandboxFault("thirdparty", "panic")
+	m.RecordAdapterSandboxFault("thirdparty", "panic")
+	m.RecordAdapterSandboxFault("thirdparty", "timeout")
+
+	if testutil.ToFloat64(m.AdapterSandboxFaults.WithLabelValues("thirdparty", "panic")) != 2 {
+		t.Error("expected 2 panics")
+	}
+	if testutil.ToFloat64(m.AdapterSandboxFaults.WithLabelValues("thirdparty", "timeout")) != 1 {
+		t.Error("expected 1 timeout")
+	}
+}
+
+func TestRecordBidderErrors(t *testing.T) {
+	m, _ := createTestMetrics("test")
+
+	m.RecordBidderErrors("bidder", "input", 2)
+	m.RecordBidderErrors("bidder", "transport", 1)
+
+	if testutil.ToFloat64(m.BidderErrors.WithLabelValues("bidder", "input")) != 2 {
+		t.Error("expected 2 input errors")
+	}
+	if testutil.ToFloat64(m.BidderErrors.WithLabelValues("bidder", "transport")) != 1 {
+		t.Error("expected 1 transport error")
+	}
+}
+
+func TestRecordBidderRequestSize(t *testing.T) {
+	m, _ := createTestMetrics("test")
+
+	m.RecordBidderRequestSize("bidder", 4096, 512)
+	m.RecordBidderRequestSize("bidder", 2048, 0)
+
+	if testutil.CollectAndCount(m.BidderRequestBytes) != 1 {
+		t.Error("expected one request size series")
+	}
+	if testutil.ToFloat64(m.BidderBytesSaved.WithLabelValues("bidder")) != 512 {
+		t.Error("expected 512 bytes saved")
+	}
+}
+
+func TestRecordRoute(t *testing.T) {
+	m, _ := createTestMetrics("test")
+
+	m.RecordRoute("GET /info/bidders/{name}", "GET", 404, 5*time.Millisecond)
+	m.RecordRoute("GET /info/bidders/{name}", "GET", 200, 5*time.Millisecond)
+
+	if testutil.ToFloat64(m.RouteRequests.WithLabelValues("GET /info/bidders/{name}", "GET", "404")) != 1 {
+		t.Error("expected one 404 on the route")
+	}
+	if testutil.CollectAndCount(m.RouteDuration) != 1 {
+		t.Error("expected one duration series for the route")
+	}
+}
+
+func TestRecordMiddlewareOverhead(t *testing.T) {
+	m, _ := createTestMetrics("test")
+
+	m.RecordMiddlewareOverhead(15 * time.Millisecond)
+
+	if testutil.CollectAndCount(m.MiddlewareOverhead) != 1 {
+		t.Error("expected one middleware overhead series")
+	}
+}
+
+func TestBidderPoolMetrics(t *testing.T) {
+	m, _ := createTestMetrics("test")
+
+	m.SetBidderQueueDepth(3)
+	m.RecordBidderQueueWait(2 * time.Millisecond)
+
+	if testutil.ToFloat64(m.BidderQueueDepth) != 3 {
+		t.Error("expected a queue depth of 3")
+	}
+	if testutil.CollectAndCount(m.BidderQueueWait) != 1 {
+		t.Error("expected one queue wait series")
+	}
+}
+
+func TestIncSyncRateLimitRejected(t *testing.T) {
+	m, _ := createTestMetrics("test")
+
+	m.IncSyncRateLimitRejected("ip")
+	m.IncSyncRateLimitRejected("ip")
+	m.IncSyncRateLimitRejected("publisher")
+
+	if testutil.ToFloat64(m.SyncRateLimitRejected.WithLabelValues("ip")) != 2 {
+		t.Error("expected 2 ip rejections")
+	}
+	if testutil.ToFloat64(m.SyncRateLimitRejected.WithLabelValues("publisher")) != 1 {
+		t.Error("expected 1 publisher rejection")
+	}
+}
+
+func TestDynamicRegistryParseErrorsAndStale(t *testing.T) {
+	m, _ := createTestMetrics("dyn_misc")
+
+	m.IncDynamicRegistryParseError("badbidder")
+	m.IncDynamicRegistryParseError("badbidder")
+	if testutil.ToFloat64(m.DynamicRegistryParseErrors.WithLabelValues("badbidder")) != 2 {
+		t.Error("expected 2 parse errors for badbidder")
+	}
+
+	m.SetDynamicRegistryStale(true)
+	if testutil.ToFloat64(m.DynamicRegistryStale) != 1 {
+		t.Error("expected stale gauge to be 1")
+	}
+	m.SetDynamicRegistryStale(false)
+	if testutil.ToFloat64(m.DynamicRegistryStale) != 0 {
+		t.Error("expected stale gauge to be 0")
+	}
+}
+
 func TestMiddleware_DifferentMethods(t *testing.T) {
 	m, _ := createTestMetrics("mw_methods")
 
@@ -1021,3 +1638,18 @@ func TestCreateTestMetrics_DefaultNamespace(t *testing.T) {
 
 	_ = m // Silence unused variable warning
 }
+
+func TestRecordInventoryMismatch(t *testing.T) {
+	m, _ := createTestMetrics("test")
+
+	m.RecordInventoryMismatch("pub1", "site", "rejected")
+	m.RecordInventoryMismatch("pub1", "site", "rejected")
+	m.RecordInventoryMismatch("pub2", "app", "flagged")
+
+	if testutil.ToFloat64(m.InventoryMismatches.WithLabelValues("pub1", "site", "rejected")) != 2 {
+		t.Error("expected 2 rejected site mismatches for pub1")
+	}
+	if testutil.ToFloat64(m.InventoryMismatches.WithLabelValues("pub2", "app", "flagged")) != 1 {
+		t.Error("expected 1 flagged app mismatch for pub2")
+	}
+}
@@ -43,7 +43,7 @@ func DefaultAuthConfig() *AuthConfig {
 		Enabled:     os.Getenv("AUTH_ENABLED") == "true",
 		APIKeys:     parseAPIKeys(os.Getenv("API_KEYS")),
 		HeaderName:  "X-API-Key",
-		BypassPaths: []string{"/health", "/status", "/metrics", "/info/bidders", "/cookie_sync", "/setuid", "/optout", "/openrtb2/auction"},
+		BypassPaths: []string{"/health", "/ready", "/status", "/metrics", "/info/bidders", "/info/status/bidders", "/cookie_sync", "/setuid", "/optout", "/feedback", "/openrtb2/auction"},
 		// Note: /openrtb2/auction uses PublisherAuth middleware instead of API key auth
 		RedisURL:    redisURL,
 		UseRedis:    redisURL != "" && os.Getenv("AUTH_USE_REDIS") != "false",
@@ -142,6 +142,13 @@ func (a *Auth) Middleware(next http.Handler) http.Handler {
 			}
 		}
 
+		// A mapped client certificate already authenticated the account
+		if account, ok := CertAccountFromContext(r.Context()); ok {
+			r.Header.Set("X-Publisher-ID", account)
+			next.ServeHTTP(w, r)
+			return
+		}
+
 		// Get API key from header
 		apiKey := r.Header.Get(headerName)
 		if apiKey == "" {
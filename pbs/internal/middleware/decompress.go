@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// defaultMaxDecompressedSize caps an inflated request body (4MB)
+const defaultMaxDecompressedSize = 4 * 1024 * 1024
+
+var (
+	errUnsupportedEncoding  = errors.New("unsupported Content-Encoding")
+	errDecompressedTooLarge = errors.New("decompressed request body too large")
+)
+
+// decompressRequest replaces a gzip or deflate encoded body with the
+// decoded bytes, reading at most maxSize of them so a small compressed body
+// can't inflate without bound (zip bomb). Writes the error response and
+// returns false when the body can't be decoded.
+func decompressRequest(w http.ResponseWriter, r *http.Request, maxSize int64) bool {
+	encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding")))
+	if r.Body == nil || encoding == "" || encoding == "identity" {
+		return true
+	}
+
+	body, err := decompressBody(r.Body, encoding, maxSize)
+	if err != nil {
+		var maxBytes *http.MaxBytesError
+		switch {
+		case errors.Is(err, errUnsupportedEncoding):
+			http.Error(w, `{"error":"unsupported Content-Encoding"}`, http.StatusUnsupportedMediaType)
+		case errors.Is(err, errDecompressedTooLarge), errors.As(err, &maxBytes):
+			http.Error(w, `{"error":"request body too large"}`, http.StatusRequestEntityTooLarge)
+		default:
+			http.Error(w, `{"error":"invalid compressed request body"}`, http.StatusBadRequest)
+		}
+		return false
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	r.Header.Del("Content-Encoding")
+	r.Header.Del("Content-Length")
+	return true
+}
+
+// decompressBody decodes body. "deflate" should be zlib-wrapped (RFC 9110)
+// but some senders use raw deflate, so both are accepted.
+func decompressBody(body io.Reader, encoding string, maxSize int64) ([]byte, error) {
+	var decoder io.ReadCloser
+	var err error
+	switch encoding {
+	case "gzip", "x-gzip":
+		decoder, err = gzip.NewReader(body)
+	case "deflate":
+		var compressed []byte
+		if compressed, err = io.ReadAll(body); err != nil {
+			return nil, err
+		}
+		if decoder, err = zlib.NewReader(bytes.NewReader(compressed)); err != nil {
+			decoder, err = flate.NewReader(bytes.NewReader(compressed)), nil
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", errUnsupportedEncoding, encoding)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer decoder.Close()
+
+	// Read one byte past the cap to tell an exactly-full body from an oversized one
+	decoded, err := io.ReadAll(io.LimitReader(decoder, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(decoded)) > maxSize {
+		return nil, errDecompressedTooLarge
+	}
+	return decoded, nil
+}
@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func compress(t *testing.T, encoding string, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "deflate":
+		w = zlib.NewWriter(&buf)
+	case "raw-deflate":
+		w, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+	}
+	w.Write(data)
+	w.Close()
+	return buf.Bytes()
+}
+
+func TestSizeLimiter_Decompresses(t *testing.T) {
+	payload := []byte(`{"id":"req-1","imp":[{"id":"1"}]}`)
+	sl := NewSizeLimiter(&SizeLimitConfig{Enabled: true, MaxBodySize: 1024, MaxURLLength: 1000, MaxDecompressedSize: 1024})
+
+	var got []byte
+	var gotEncoding string
+	handler := sl.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = io.ReadAll(r.Body)
+		gotEncoding = r.Header.Get("Content-Encoding")
+	}))
+
+	for _, tt := range []struct{ header, format string }{
+		{"gzip", "gzip"}, {"x-gzip", "gzip"}, {"deflate", "deflate"}, {"deflate", "raw-deflate"}, {"GZIP", "gzip"},
+	} {
+		t.Run(tt.header+"/"+tt.format, func(t *testing.T) {
+			got = nil
+			req := httptest.NewRequest(http.MethodPost, "/openrtb2/auction", bytes.NewReader(compress(t, tt.format, payload)))
+			req.Header.Set("Content-Encoding", tt.header)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK || !bytes.Equal(got, payload) {
+				t.Errorf("expected the decoded body, got %d %q", rec.Code, got)
+			}
+			if gotEncoding != "" {
+				t.Errorf("expected Content-Encoding removed, got %q", gotEncoding)
+			}
+		})
+	}
+}
+
+func TestSizeLimiter_DecompressionErrors(t *testing.T) {
+	bomb := compress(t, "gzip", make([]byte, 10*1024)) // ~10KB of zeros compresses to a few dozen bytes
+	noise := make([]byte, 4096)
+	rand.New(rand.NewSource(1)).Read(noise) // Doesn't compress, so stays over the 1KB body cap
+	sl := NewSizeLimiter(&SizeLimitConfig{Enabled: true, MaxBodySize: 1024, MaxURLLength: 1000, MaxDecompressedSize: 4096})
+	handler := sl.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler should not be called")
+	}))
+
+	tests := []struct {
+		name     string
+		encoding string
+		body     []byte
+		status   int
+	}{
+		{name: "over the decompressed cap", encoding: "gzip", body: bomb, status: http.StatusRequestEntityTooLarge},
+		{name: "compressed body over the body cap", encoding: "gzip", body: compress(t, "gzip", noise), status: http.StatusRequestEntityTooLarge},
+		{name: "corrupt gzip", encoding: "gzip", body: []byte("not gzip"), status: http.StatusBadRequest},
+		{name: "unsupported", encoding: "br", body: []byte("x"), status: http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/openrtb2/auction", bytes.NewReader(tt.body))
+			req.ContentLength = -1 // Streamed, so only the MaxBytesReader sees the size
+			req.Header.Set("Content-Encoding", tt.encoding)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Errorf("expected %d, got %d %s", tt.status, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestSizeLimiter_DecompressesWhenDisabled(t *testing.T) {
+	sl := NewSizeLimiter(&SizeLimitConfig{Enabled: false, MaxDecompressedSize: 16})
+	handler := sl.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/openrtb2/auction", bytes.NewReader(compress(t, "gzip", []byte(`{"id":"1"}`))))
+	req.Header.Set("Content-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Body.String() != `{"id":"1"}` {
+		t.Errorf("expected the decoded body, got %q", rec.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/openrtb2/auction", bytes.NewReader(compress(t, "gzip", []byte(strings.Repeat("a", 100)))))
+	req.Header.Set("Content-Encoding", "gzip")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected the decompressed cap to still apply, got %d", rec.Code)
+	}
+}
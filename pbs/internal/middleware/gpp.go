@@ -0,0 +1,234 @@
+package middleware
+
+import (
+	"encoding/base64"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/logger"
+)
+
+// GPP section IDs (IAB GPP section registry) enforced by the privacy middleware
+const (
+	GPPSectionTCFEUv2 = 2 // Enforced like user.consent under GDPR
+	GPPSectionUSNat   = 7 // Enforced like a US Privacy opt-out of sale
+)
+
+// GPP section labels for metrics; "header" counts strings that don't decode
+const (
+	gppLabelHeader  = "header"
+	gppLabelTCFEUv2 = "tcfeuv2"
+	gppLabelUSNat   = "usnat"
+)
+
+// GPPOutcomeInvalid labels a GPP section (or header) that failed to decode;
+// the other outcomes are the PrivacyOutcome values
+const GPPOutcomeInvalid = "invalid"
+
+// PrivacyMetrics counts the GPP sections the middleware enforces, by section and outcome
+type PrivacyMetrics interface {
+	RecordGPPSection(section, outcome string)
+}
+
+// gppHeaderType is the type field of every GPP header
+const gppHeaderType = 3
+
+// usNatOptedOut is the US National opt-out field value for "opted out"
+// (0 is not applicable, 2 did not opt out)
+const usNatOptedOut = 1
+
+// GPP parsing errors
+var (
+	errInvalidGPPEncoding      = errors.New("invalid base64 encoding")
+	errInvalidGPPHeader        = errors.New("invalid GPP header")
+	errGPPSectionCount         = errors.New("GPP header and section count differ")
+	errInvalidUSNatLength      = errors.New("US National section too short")
+	errUnsupportedUSNatVersion = errors.New("unsupported US National version")
+)
+
+// gppString is a decoded GPP string: its sections' encoded values by section ID
+type gppString struct {
+	sections map[int]string
+}
+
+// section returns the encoded section with the given ID. Safe on a nil gppString.
+func (g *gppString) section(id int) (string, bool) {
+	if g == nil {
+		return "", false
+	}
+	s, ok := g.sections[id]
+	return s, ok
+}
+
+// parseGPP decodes a GPP string's header and splits out its sections. The
+// sections themselves are decoded when they're enforced.
+func parseGPP(gpp string) (*gppString, error) {
+	parts := strings.Split(gpp, "~")
+	header, err := decodeGPPBase64(parts[0])
+	if err != nil {
+		return nil, err
+	}
+
+	reader := newBitReader(header)
+	// Type (6 bits) and Version (6 bits)
+	if reader.readInt(6) != gppHeaderType {
+		return nil, errInvalidGPPHeader
+	}
+	reader.readInt(6)
+
+	// Section IDs: a Fibonacci-encoded integer range, each entry an offset
+	// from the previous ID
+	count := reader.readInt(12)
+	var ids []int
+	last := 0
+	for i := 0; i < count; i++ {
+		isRange := reader.readBool()
+		start, ok := reader.readFibonacci()
+		if !ok {
+			return nil, errInvalidGPPHeader
+		}
+		start += last
+		end := start
+		if isRange {
+			offset, ok := reader.readFibonacci()
+			if !ok {
+				return nil, errInvalidGPPHeader
+			}
+			end += offset
+		}
+		if len(ids)+end-start+1 > len(parts)-1 {
+			return nil, errGPPSectionCount
+		}
+		for id := start; id <= end; id++ {
+			ids = append(ids, id)
+		}
+		last = end
+	}
+	if len(ids) != len(parts)-1 {
+		return nil, errGPPSectionCount
+	}
+
+	g := &gppString{sections: make(map[int]string, len(ids))}
+	for i, id := range ids {
+		g.sections[id] = parts[i+1]
+	}
+	return g, nil
+}
+
+// decodeGPPBase64 decodes a GPP header or section. GPP uses unpadded base64url
+// of a bit string, so the length needn't fall on a byte boundary; padding
+// with 'A' appends zero bits until it does.
+func decodeGPPBase64(s string) ([]byte, error) {
+	if s == "" {
+		return nil, errInvalidGPPEncoding
+	}
+	for len(s)%4 != 0 {
+		s += "A"
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errInvalidGPPEncoding
+	}
+	return decoded, nil
+}
+
+// readFibonacci reads a Fibonacci-encoded integer (terminated by two set
+// bits), reporting false if the data runs out first
+func (r *bitReader) readFibonacci() (int, bool) {
+	value, prev := 0, false
+	for fib, next := 1, 2; r.bitPos < len(r.data)*8 && fib <= 1<<16; fib, next = next, fib+next {
+		bit := r.readBool()
+		if bit && prev {
+			return value, true
+		}
+		if bit {
+			value += fib
+		}
+		prev = bit
+	}
+	return 0, false
+}
+
+// usNatData holds the US National section fields the middleware enforces
+type usNatData struct {
+	Version                   int
+	SaleOptOut                int
+	SharingOptOut             int
+	TargetedAdvertisingOptOut int
+}
+
+// optedOut reports whether the user opted out of sale, sharing or targeted advertising
+func (d *usNatData) optedOut() bool {
+	return d.SaleOptOut == usNatOptedOut || d.SharingOptOut == usNatOptedOut || d.TargetedAdvertisingOptOut == usNatOptedOut
+}
+
+// parseUSNat decodes the core segment of a GPP US National section. Versions
+// 1 and 2 share the leading notice and opt-out fields read here.
+func parseUSNat(section string) (*usNatData, error) {
+	core, _, _ := strings.Cut(section, ".")
+	decoded, err := decodeGPPBase64(core)
+	if err != nil {
+		return nil, err
+	}
+	// Version plus the nine 2-bit fields up to TargetedAdvertisingOptOut
+	if len(decoded) < 3 {
+		return nil, errInvalidUSNatLength
+	}
+
+	reader := newBitReader(decoded)
+	data := &usNatData{Version: reader.readInt(6)}
+	if data.Version != 1 && data.Version != 2 {
+		return nil, errUnsupportedUSNatVersion
+	}
+	// SharingNotice, SaleOptOutNotice, SharingOptOutNotice,
+	// TargetedAdvertisingOptOutNotice, SensitiveDataProcessingOptOutNotice,
+	// SensitiveDataLimitUseNotice (2 bits each) - skip
+	reader.readInt(12)
+	data.SaleOptOut = reader.readInt(2)
+	data.SharingOptOut = reader.readInt(2)
+	data.TargetedAdvertisingOptOut = reader.readInt(2)
+	return data, nil
+}
+
+// parseRequestGPP decodes regs.gpp, keeping only the sections regs.gpp_sid
+// says apply (every section when gpp_sid is absent). A string that doesn't
+// decode is logged and ignored, like a malformed US Privacy string.
+func (m *PrivacyMiddleware) parseRequestGPP(req *openrtb.BidRequest) *gppString {
+	gpp, err := requestGPP(req)
+	if err != nil {
+		logger.Log.Debug().
+			Str("request_id", req.ID).
+			Err(err).
+			Msg("Invalid GPP string")
+		m.recordGPPSection(gppLabelHeader, GPPOutcomeInvalid)
+		return nil
+	}
+	return gpp
+}
+
+// requestGPP decodes regs.gpp down to its applicable sections; nil without a GPP string
+func requestGPP(req *openrtb.BidRequest) (*gppString, error) {
+	if req.Regs == nil || req.Regs.GPP == "" {
+		return nil, nil
+	}
+	gpp, err := parseGPP(req.Regs.GPP)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Regs.GPPSID) > 0 {
+		for id := range gpp.sections {
+			if !slices.Contains(req.Regs.GPPSID, id) {
+				delete(gpp.sections, id)
+			}
+		}
+	}
+	return gpp, nil
+}
+
+func (m *PrivacyMiddleware) recordGPPSection(section, outcome string) {
+	if m.config.Metrics != nil {
+		m.config.Metrics.RecordGPPSection(section, outcome)
+	}
+}
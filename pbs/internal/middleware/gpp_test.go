@@ -0,0 +1,177 @@
+package middleware
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+const (
+	gppTCFConsent  = "DBABMA~CPXxRfAPXxRfAAfKABENB-CgAAAAAAAAAAYgAAAAAAAA"
+	gppUSNatOptIn  = "DBABLA~BVQqAAAAAgA"
+	gppUSNatOptOut = "DBABLA~BVQVAAAAAgA.QA"
+)
+
+type mockPrivacyMetrics struct {
+	counts map[string]int
+}
+
+func (m *mockPrivacyMetrics) RecordGPPSection(section, outcome string) {
+	m.counts[section+"/"+outcome]++
+}
+
+func TestParseGPP(t *testing.T) {
+	tests := []struct {
+		name    string
+		gpp     string
+		want    []int
+		wantErr bool
+	}{
+		{name: "tcf eu v2", gpp: gppTCFConsent, want: []int{2}},
+		{name: "us national", gpp: gppUSNatOptIn, want: []int{7}},
+		{name: "range", gpp: "DBABrw~a~b", want: []int{7, 8}},
+		{name: "section missing", gpp: "DBACNY~a", wantErr: true},
+		{name: "not a gpp header", gpp: "CPXxRfAPXxRfAA~a", wantErr: true},
+		{name: "bad encoding", gpp: "DB+B~a", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gpp, err := parseGPP(tt.gpp)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %+v", gpp)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, id := range tt.want {
+				if _, ok := gpp.section(id); !ok {
+					t.Errorf("expected section %d in %v", id, gpp.sections)
+				}
+			}
+			if len(gpp.sections) != len(tt.want) {
+				t.Errorf("expected sections %v, got %v", tt.want, gpp.sections)
+			}
+		})
+	}
+}
+
+func TestParseUSNat(t *testing.T) {
+	optIn, err := parseUSNat("BVQqAAAAAgA")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := &usNatData{Version: 1, SaleOptOut: 2, SharingOptOut: 2, TargetedAdvertisingOptOut: 2}
+	if !reflect.DeepEqual(optIn, want) || optIn.optedOut() {
+		t.Errorf("expected no opt-outs, got %+v", optIn)
+	}
+
+	optOut, err := parseUSNat("BVQVAAAAAgA.QA")
+	if err != nil || !optOut.optedOut() {
+		t.Errorf("expected opted out, got %+v, %v", optOut, err)
+	}
+	if _, err := parseUSNat("DVQq"); err == nil {
+		t.Error("expected an unsupported version rejected")
+	}
+}
+
+func TestPrivacyMiddleware_GPP(t *testing.T) {
+	gdpr := 1
+	tests := []struct {
+		name        string
+		enforceCCPA bool
+		regs        *openrtb.Regs
+		wantCode    int
+		wantMetric  string
+	}{
+		{
+			name:       "tcf section stands in for user.consent",
+			regs:       &openrtb.Regs{GDPR: &gdpr, GPP: gppTCFConsent, GPPSID: []int{2}},
+			wantCode:   http.StatusOK,
+			wantMetric: "tcfeuv2/allowed",
+		},
+		{
+			name:       "gpp_sid signals gdpr scope",
+			regs:       &openrtb.Regs{GPP: "DBABMA~invalid-consent-string-here", GPPSID: []int{2}},
+			wantCode:   http.StatusBadRequest,
+			wantMetric: "tcfeuv2/blocked",
+		},
+		{
+			name:        "us national opt-out blocked",
+			enforceCCPA: true,
+			regs:        &openrtb.Regs{GPP: gppUSNatOptOut, GPPSID: []int{7}},
+			wantCode:    http.StatusBadRequest,
+			wantMetric:  "usnat/blocked",
+		},
+		{
+			name:       "us national opt-out not enforced",
+			regs:       &openrtb.Regs{GPP: gppUSNatOptOut},
+			wantCode:   http.StatusOK,
+			wantMetric: "usnat/not_enforced",
+		},
+		{
+			name:        "us national without opt-out",
+			enforceCCPA: true,
+			regs:        &openrtb.Regs{GPP: gppUSNatOptIn, GPPSID: []int{7}},
+			wantCode:    http.StatusOK,
+			wantMetric:  "usnat/allowed",
+		},
+		{
+			name:        "section not in gpp_sid ignored",
+			enforceCCPA: true,
+			regs:        &openrtb.Regs{GPP: gppUSNatOptOut, GPPSID: []int{8}},
+			wantCode:    http.StatusOK,
+		},
+		{
+			name:       "invalid gpp string ignored",
+			regs:       &openrtb.Regs{GPP: "not~gpp"},
+			wantCode:   http.StatusOK,
+			wantMetric: "header/invalid",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := &mockPrivacyMetrics{counts: make(map[string]int)}
+			config := DefaultPrivacyConfig()
+			config.StrictMode = false
+			config.EnforceCCPA = tt.enforceCCPA
+			config.Metrics = metrics
+
+			code, _ := serveAudit(t, config, &openrtb.BidRequest{
+				ID:   "gpp-1",
+				Imp:  []openrtb.Imp{{ID: "imp1", Banner: &openrtb.Banner{}}},
+				Regs: tt.regs,
+			})
+			if code != tt.wantCode {
+				t.Errorf("expected status %d, got %d", tt.wantCode, code)
+			}
+			if tt.wantMetric == "" {
+				if len(metrics.counts) != 0 {
+					t.Errorf("expected no GPP sections recorded, got %v", metrics.counts)
+				}
+			} else if metrics.counts[tt.wantMetric] != 1 {
+				t.Errorf("expected %s recorded, got %v", tt.wantMetric, metrics.counts)
+			}
+		})
+	}
+}
+
+func TestPrivacyAudit_GPPUSNatOptOut(t *testing.T) {
+	config := DefaultPrivacyConfig()
+	config.EnforceCCPA = false
+	code, audit := serveAudit(t, config, &openrtb.BidRequest{
+		ID:   "gpp-audit",
+		Imp:  []openrtb.Imp{{ID: "imp1", Banner: &openrtb.Banner{}}},
+		Regs: &openrtb.Regs{GPP: gppUSNatOptOut, GPPSID: []int{7}},
+	})
+	if code != http.StatusOK || audit == nil {
+		t.Fatalf("expected request forwarded with an audit, got %d / %v", code, audit)
+	}
+	if got := outcomes(audit); got != "COPPA=not_applicable,GDPR=not_applicable,CCPA=not_enforced" {
+		t.Errorf("unexpected evaluations %s", got)
+	}
+}
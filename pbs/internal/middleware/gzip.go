@@ -66,6 +66,18 @@ func NewGzip(config *GzipConfig) *Gzip {
 	}
 }
 
+// Prime pre-allocates n compressors so the first responses after startup
+// don't pay for gzip writer allocation
+func (g *Gzip) Prime(n int) {
+	writers := make([]interface{}, n)
+	for i := range writers {
+		writers[i] = g.writerPool.Get()
+	}
+	for _, w := range writers {
+		g.writerPool.Put(w)
+	}
+}
+
 // gzipResponseWriter wraps http.ResponseWriter for compression
 // It buffers the response to decide whether to compress based on size
 type gzipResponseWriter struct {
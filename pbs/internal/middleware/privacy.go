@@ -8,7 +8,9 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"slices"
 	"strings"
+	"sync/atomic"
 
 	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
 	"github.com/StreetsDigital/thenexusengine/pbs/pkg/logger"
@@ -35,12 +37,27 @@ var RequiredPurposes = []int{
 	PurposeMeasureAdPerformance, // Required for reporting
 }
 
+// Privacy violation handling modes
+const (
+	ViolationModeBlock = "block" // Reject the request with a 400
+	ViolationModeScrub = "scrub" // Strip personal data and run a contextual-only auction
+)
+
+// COPPA handling modes
+const (
+	COPPAModeBlock     = "block"     // Reject child-directed requests
+	COPPAModeTransform = "transform" // Strip personal data from them and run the auction
+)
+
 // PrivacyConfig configures the privacy middleware behavior
 type PrivacyConfig struct {
 	// EnforceGDPR requires valid consent when regs.gdpr=1
 	EnforceGDPR bool
 	// EnforceCOPPA blocks requests with COPPA=1 (child-directed)
 	EnforceCOPPA bool
+	// COPPAMode - how enforced COPPA requests are handled: COPPAModeBlock (the
+	// default) or COPPAModeTransform
+	COPPAMode string
 	// EnforceCCPA blocks/strips data when user opts out
 	EnforceCCPA bool
 	// RequiredPurposes - TCF purposes required for processing (default: 1, 2, 7)
@@ -49,24 +66,58 @@ type PrivacyConfig struct {
 	StrictMode bool
 	// AnonymizeIP - P2-2: if true, anonymize IP addresses when GDPR applies
 	AnonymizeIP bool
+	// GeoGDPRInference - treat requests without regs.gdpr as in scope when the
+	// resolved country is in GDPRCountries (many SDKs omit regs entirely)
+	GeoGDPRInference bool
+	// GDPRCountries - ISO-3166-1 alpha-3 codes where GDPR applies (default: EEA + UK)
+	GDPRCountries map[string]bool
+	// GeoResolver - resolves device.geo.country from the device IP when the
+	// request has none, ahead of geo GDPR inference (optional)
+	GeoResolver GeoResolver
+	// AuditLog - log every request's privacy signals, evaluations and enforcement
+	// decisions for regulatory audits
+	AuditLog bool
+	// Metrics - counts enforced GPP sections by outcome (optional)
+	Metrics PrivacyMetrics
+	// ViolationMode - how GDPR and CCPA violations are handled: ViolationModeBlock
+	// (the default) or ViolationModeScrub. COPPA violations always block.
+	ViolationMode string
 }
 
 // DefaultPrivacyConfig returns a sensible default config
 // It reads from environment variables if set:
 //   - PBS_ENFORCE_GDPR: "true" or "false" (default: true)
 //   - PBS_ENFORCE_COPPA: "true" or "false" (default: true)
+//   - PBS_COPPA_MODE: "block" or "transform" (default: block)
 //   - PBS_ENFORCE_CCPA: "true" or "false" (default: true)
 //   - PBS_PRIVACY_STRICT_MODE: "true" or "false" (default: true)
 //   - PBS_ANONYMIZE_IP: "true" or "false" (default: true)
+//   - PBS_GDPR_GEO_INFERENCE: "true" or "false" (default: true)
+//   - PBS_GDPR_COUNTRIES: comma-separated alpha-3 codes (default: EEA + UK)
+//   - PBS_PRIVACY_AUDIT_LOG: "true" or "false" (default: false)
+//   - PBS_PRIVACY_VIOLATION_MODE: "block" or "scrub" (default: block)
 func DefaultPrivacyConfig() PrivacyConfig {
 	return PrivacyConfig{
 		EnforceGDPR:      getEnvBool("PBS_ENFORCE_GDPR", true),
 		EnforceCOPPA:     getEnvBool("PBS_ENFORCE_COPPA", true),
+		COPPAMode:        getEnvOrDefault("PBS_COPPA_MODE", COPPAModeBlock),
 		EnforceCCPA:      getEnvBool("PBS_ENFORCE_CCPA", true),
 		RequiredPurposes: RequiredPurposes,
 		StrictMode:       getEnvBool("PBS_PRIVACY_STRICT_MODE", true),
 		AnonymizeIP:      getEnvBool("PBS_ANONYMIZE_IP", true),
+		GeoGDPRInference: getEnvBool("PBS_GDPR_GEO_INFERENCE", true),
+		GDPRCountries:    parseCountryList(os.Getenv("PBS_GDPR_COUNTRIES"), DefaultGDPRCountries),
+		AuditLog:         getEnvBool("PBS_PRIVACY_AUDIT_LOG", false),
+		ViolationMode:    getEnvOrDefault("PBS_PRIVACY_VIOLATION_MODE", ViolationModeBlock),
+	}
+}
+
+// getEnvOrDefault reads a string from environment variable with a default
+func getEnvOrDefault(key, defaultVal string) string {
+	if val := os.Getenv(key); val != "" {
+		return strings.ToLower(val)
 	}
+	return defaultVal
 }
 
 // getEnvBool reads a boolean from environment variable with a default
@@ -81,6 +132,7 @@ func getEnvBool(key string, defaultVal bool) bool {
 // PrivacyMiddleware enforces privacy regulations before auction execution
 type PrivacyMiddleware struct {
 	config PrivacyConfig
+	live   *LivePrivacyConfig // Replaces config per request when set
 	next   http.Handler
 }
 
@@ -94,8 +146,42 @@ func NewPrivacyMiddleware(config PrivacyConfig) func(http.Handler) http.Handler
 	}
 }
 
+// LivePrivacyConfig is a privacy configuration that can be replaced while
+// serving, e.g. by a config reload. Each request is enforced under one version.
+type LivePrivacyConfig struct {
+	current atomic.Pointer[PrivacyConfig]
+}
+
+// NewLivePrivacyConfig creates a live configuration starting from config
+func NewLivePrivacyConfig(config PrivacyConfig) *LivePrivacyConfig {
+	l := &LivePrivacyConfig{}
+	l.Store(config)
+	return l
+}
+
+// Load returns the current configuration
+func (l *LivePrivacyConfig) Load() PrivacyConfig {
+	return *l.current.Load()
+}
+
+// Store replaces the configuration for requests that start afterwards
+func (l *LivePrivacyConfig) Store(config PrivacyConfig) {
+	l.current.Store(&config)
+}
+
+// Middleware returns privacy enforcement under the current configuration
+func (l *LivePrivacyConfig) Middleware(next http.Handler) http.Handler {
+	return &PrivacyMiddleware{live: l, next: next}
+}
+
 // ServeHTTP implements the http.Handler interface
 func (m *PrivacyMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if m.live != nil {
+		snapshot := &PrivacyMiddleware{config: m.live.Load(), next: m.next}
+		snapshot.ServeHTTP(w, r)
+		return
+	}
+
 	// Only process POST requests to auction endpoint
 	if r.Method != http.MethodPost {
 		m.next.ServeHTTP(w, r)
@@ -118,14 +204,58 @@ func (m *PrivacyMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Audit the signals as received, before inference changes them
+	audit := newPrivacyAudit(&bidRequest)
+
+	// Resolve a missing device country from the IP, then infer GDPR scope from
+	// geo before any checks so consent enforcement and IP anonymization apply
+	// exactly as if regs.gdpr=1 had been sent
+	countryResolved := m.resolveDeviceCountry(r.Context(), &bidRequest)
+	requestModified := countryResolved
+	inference := m.inferGDPRFromGeo(&bidRequest)
+	if inference != nil {
+		inference.FromIP = countryResolved
+		applyGDPRInference(&bidRequest)
+		reason := "regs.gdpr absent; geo country " + inference.Country
+		if inference.FromIP {
+			reason += " (resolved from device IP)"
+		}
+		audit.decide(PrivacyActionScopeInferred, "GDPR", reason, "regs.gdpr")
+		requestModified = true
+		r = r.WithContext(WithGDPRInference(r.Context(), inference))
+		logger.Log.Debug().
+			Str("request_id", bidRequest.ID).
+			Str("country", inference.Country).
+			Msg("GDPR scope inferred from geo (regs.gdpr absent)")
+	}
+
 	// Check privacy compliance
-	violation := m.checkPrivacyCompliance(&bidRequest)
+	violation := m.checkPrivacyCompliance(&bidRequest, audit)
+	if violation != nil && m.scrubsViolation(violation) {
+		// Keep the auction, contextual-only, rather than losing it entirely
+		fields := ScrubPersonalData(&bidRequest)
+		audit.decide(PrivacyActionScrubbed, violation.Regulation, violation.Reason, fields...)
+		requestModified = true
+		logger.Log.Info().
+			Str("request_id", bidRequest.ID).
+			Str("violation", violation.Reason).
+			Str("regulation", violation.Regulation).
+			Strs("fields", fields).
+			Msg("Privacy compliance violation - scrubbed personal data")
+		violation = nil
+	}
 	if violation != nil {
+		if inference != nil && violation.Regulation == "GDPR" {
+			// Make clear to integrators why a request without regs.gdpr was treated as in scope
+			violation.Reason += " [GDPR inferred from geo " + inference.Country + "]"
+		}
 		logger.Log.Warn().
 			Str("request_id", bidRequest.ID).
 			Str("violation", violation.Reason).
 			Str("regulation", violation.Regulation).
 			Msg("Privacy compliance violation - blocking request")
+		audit.decide(PrivacyActionBlocked, violation.Regulation, violation.Reason)
+		m.logPrivacyAudit(bidRequest.ID, audit)
 
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
@@ -138,19 +268,29 @@ func (m *PrivacyMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Child-directed requests in transform mode go ahead without personal data
+	if m.transformsCOPPA(&bidRequest) {
+		fields := ScrubPersonalData(&bidRequest)
+		audit.decide(PrivacyActionScrubbed, "COPPA", "child-directed request", fields...)
+		requestModified = true
+	}
+
 	// P2-2: Anonymize IP addresses when GDPR applies and anonymization is enabled
-	requestModified := false
 	if m.config.AnonymizeIP && m.isGDPRApplicable(&bidRequest) {
-		m.anonymizeRequestIPs(&bidRequest)
+		if fields := m.anonymizeRequestIPs(&bidRequest); len(fields) > 0 {
+			audit.decide(PrivacyActionScrubbed, "GDPR", "IP anonymized", fields...)
+		}
 		requestModified = true
 	}
+	m.logPrivacyAudit(bidRequest.ID, audit)
+	r = r.WithContext(WithPrivacyAudit(r.Context(), audit))
 
 	// Re-create request body for downstream handler
 	if requestModified {
 		// Re-marshal the modified request
 		modifiedBody, err := json.Marshal(&bidRequest)
 		if err != nil {
-			logger.Log.Error().Err(err).Msg("Failed to marshal modified request after IP anonymization")
+			logger.Log.Error().Err(err).Msg("Failed to marshal modified request after privacy processing")
 			r.Body = io.NopCloser(strings.NewReader(string(body)))
 		} else {
 			r.Body = io.NopCloser(strings.NewReader(string(modifiedBody)))
@@ -169,55 +309,156 @@ type PrivacyViolation struct {
 	NoBidReason openrtb.NoBidReason // P2-7: Using consolidated type from openrtb
 }
 
-// checkPrivacyCompliance verifies the request meets privacy requirements
-func (m *PrivacyMiddleware) checkPrivacyCompliance(req *openrtb.BidRequest) *PrivacyViolation {
+// checkPrivacyCompliance verifies the request meets privacy requirements,
+// recording each regulation it evaluates in audit (which may be nil)
+func (m *PrivacyMiddleware) checkPrivacyCompliance(req *openrtb.BidRequest, audit *PrivacyAudit) *PrivacyViolation {
 	// Check COPPA compliance
-	if m.config.EnforceCOPPA && req.Regs != nil && req.Regs.COPPA == 1 {
+	switch {
+	case req.Regs == nil || req.Regs.COPPA != 1:
+		audit.evaluate("COPPA", PrivacyOutcomeNotApplicable, "")
+	case !m.config.EnforceCOPPA:
+		audit.evaluate("COPPA", PrivacyOutcomeNotEnforced, "")
+	case m.config.COPPAMode == COPPAModeTransform:
+		// ServeHTTP strips personal data once the other regulations pass
+		audit.evaluate("COPPA", PrivacyOutcomeScrubbed, "child-directed request")
+	default:
 		// COPPA requests require special handling - we block by default
 		// Production systems might strip identifiers instead
-		return &PrivacyViolation{
+		violation := &PrivacyViolation{
 			Regulation:  "COPPA",
 			Reason:      "Child-directed content requires COPPA-compliant handling",
 			NoBidReason: openrtb.NoBidAdsNotAllowed,
 		}
+		audit.evaluate("COPPA", PrivacyOutcomeBlocked, violation.Reason)
+		return violation
 	}
 
-	// Check GDPR compliance
-	if m.config.EnforceGDPR && m.isGDPRApplicable(req) {
-		violation := m.validateGDPRConsent(req)
-		if violation != nil {
-			return violation
+	gpp := m.parseRequestGPP(req)
+
+	// Check GDPR compliance. A GPP TCF EU v2 section stands in for
+	// user.consent when that's absent.
+	consent, fromGPP := gdprConsent(req, gpp)
+	gdprOutcome := PrivacyOutcomeAllowed
+	var violation *PrivacyViolation
+	switch {
+	case !m.isGDPRApplicable(req):
+		gdprOutcome = PrivacyOutcomeNotApplicable
+		audit.evaluate("GDPR", gdprOutcome, "")
+	case !m.config.EnforceGDPR:
+		gdprOutcome = PrivacyOutcomeNotEnforced
+		audit.evaluate("GDPR", gdprOutcome, "")
+	default:
+		if violation = m.validateGDPRConsent(req.ID, consent); violation != nil {
+			gdprOutcome = m.violationOutcome()
+			audit.evaluate("GDPR", gdprOutcome, violation.Reason)
+		} else if fromGPP {
+			audit.evaluate("GDPR", gdprOutcome, "valid TCF v2 consent in GPP")
+		} else {
+			audit.evaluate("GDPR", gdprOutcome, "valid TCF v2 consent")
 		}
 	}
+	if fromGPP {
+		m.recordGPPSection(gppLabelTCFEUv2, gdprOutcome)
+	}
+	if violation != nil {
+		return violation
+	}
 
-	// Check US Privacy (CCPA) - P0: Enforce opt-out
-	if req.Regs != nil && req.Regs.USPrivacy != "" {
-		violation := m.checkCCPACompliance(req.ID, req.Regs.USPrivacy)
-		if violation != nil {
+	// Check US Privacy (CCPA) - P0: Enforce opt-out. A GPP US National
+	// section's opt-outs are enforced the same way.
+	usPrivacy := ""
+	if req.Regs != nil {
+		usPrivacy = req.Regs.USPrivacy
+	}
+	usNat := m.usNatSection(req.ID, gpp)
+	if usPrivacy == "" && usNat == nil {
+		audit.evaluate("CCPA", PrivacyOutcomeNotApplicable, "")
+		return nil
+	}
+	if usPrivacy != "" {
+		if violation := m.checkCCPACompliance(req.ID, usPrivacy); violation != nil {
+			audit.evaluate("CCPA", m.violationOutcome(), violation.Reason)
+			return violation
+		}
+	}
+	optedOut := ccpaOptedOut(usPrivacy)
+	if usNat != nil {
+		if violation := m.checkUSNatCompliance(req.ID, usNat); violation != nil {
+			audit.evaluate("CCPA", m.violationOutcome(), violation.Reason)
 			return violation
 		}
+		optedOut = optedOut || usNat.optedOut()
+	}
+	if !m.config.EnforceCCPA && optedOut {
+		audit.evaluate("CCPA", PrivacyOutcomeNotEnforced, "user opted out of sale")
+	} else {
+		audit.evaluate("CCPA", PrivacyOutcomeAllowed, "")
 	}
 
 	return nil
 }
 
+// scrubsViolation reports whether a violation is handled by scrubbing personal
+// data rather than blocking the request
+func (m *PrivacyMiddleware) scrubsViolation(v *PrivacyViolation) bool {
+	return m.config.ViolationMode == ViolationModeScrub && v.Regulation != "COPPA"
+}
+
+// violationOutcome is the outcome recorded for a GDPR or CCPA violation
+func (m *PrivacyMiddleware) violationOutcome() string {
+	if m.config.ViolationMode == ViolationModeScrub {
+		return PrivacyOutcomeScrubbed
+	}
+	return PrivacyOutcomeBlocked
+}
+
+// transformsCOPPA reports whether req is child-directed and COPPA is enforced
+// by stripping personal data rather than blocking
+func (m *PrivacyMiddleware) transformsCOPPA(req *openrtb.BidRequest) bool {
+	return m.config.EnforceCOPPA && m.config.COPPAMode == COPPAModeTransform &&
+		req.Regs != nil && req.Regs.COPPA == 1
+}
+
 // isGDPRApplicable checks if GDPR applies to this request
 func (m *PrivacyMiddleware) isGDPRApplicable(req *openrtb.BidRequest) bool {
+	return GDPRApplies(req)
+}
+
+// GDPRApplies reports whether GDPR applies to req
+func GDPRApplies(req *openrtb.BidRequest) bool {
 	if req.Regs == nil {
 		return false
 	}
-	// GDPR applies if regs.gdpr == 1
-	return req.Regs.GDPR != nil && *req.Regs.GDPR == 1
+	// GDPR applies if regs.gdpr == 1, or regs.gdpr is absent and regs.gpp_sid
+	// lists the TCF EU v2 section
+	if req.Regs.GDPR == nil {
+		return slices.Contains(req.Regs.GPPSID, GPPSectionTCFEUv2)
+	}
+	return *req.Regs.GDPR == 1
 }
 
-// validateGDPRConsent validates the TCF consent string and purpose consents
-func (m *PrivacyMiddleware) validateGDPRConsent(req *openrtb.BidRequest) *PrivacyViolation {
-	// Get consent string
-	consentString := ""
-	if req.User != nil {
-		consentString = req.User.Consent
+// GDPRConsent returns req's TCF consent string: user.consent, or the GPP TCF
+// EU v2 section when that's absent and applies
+func GDPRConsent(req *openrtb.BidRequest) string {
+	gpp, _ := requestGPP(req)
+	consent, _ := gdprConsent(req, gpp)
+	return consent
+}
+
+// gdprConsent returns the request's TCF consent string: user.consent, or the
+// GPP TCF EU v2 section when that's absent (fromGPP is then true)
+func gdprConsent(req *openrtb.BidRequest, gpp *gppString) (consent string, fromGPP bool) {
+	if req.User != nil && req.User.Consent != "" {
+		return req.User.Consent, false
+	}
+	if section, ok := gpp.section(GPPSectionTCFEUv2); ok {
+		return section, true
 	}
+	return "", false
+}
 
+// validateGDPRConsent validates the TCF consent string and purpose consents
+func (m *PrivacyMiddleware) validateGDPRConsent(requestID, consentString string) *PrivacyViolation {
 	// No consent string when GDPR applies = violation
 	if consentString == "" {
 		return &PrivacyViolation{
@@ -242,7 +483,7 @@ func (m *PrivacyMiddleware) validateGDPRConsent(req *openrtb.BidRequest) *Privac
 		missingPurposes := m.checkPurposeConsents(tcfData, m.config.RequiredPurposes)
 		if len(missingPurposes) > 0 {
 			logger.Log.Info().
-				Str("request_id", req.ID).
+				Str("request_id", requestID).
 				Ints("missing_purposes", missingPurposes).
 				Msg("Missing required purpose consents")
 			return &PrivacyViolation{
@@ -268,10 +509,46 @@ type TCFv2Data struct {
 	VendorListVersion int
 	PurposeConsents   []bool // Indexed by purpose ID (1-based in spec, 0-based here)
 	VendorConsents    map[int]bool
+	vendorRanges      [][2]int // Range-encoded vendor consents, kept as ranges
+}
+
+// HasVendorConsent reports whether the consent string grants consent to a
+// GVL vendor ID. TCF v1 strings aren't parsed past their version and v1 is
+// retired, so they consent to no vendor.
+func (d *TCFv2Data) HasVendorConsent(vendorID int) bool {
+	if d.Version == 1 {
+		return false
+	}
+	if d.VendorConsents[vendorID] {
+		return true
+	}
+	for _, r := range d.vendorRanges {
+		if vendorID >= r[0] && vendorID <= r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPurposeConsent reports whether the consent string grants consent to a
+// TCF purpose. As with vendors, TCF v1 strings consent to no purpose.
+func (d *TCFv2Data) HasPurposeConsent(purpose int) bool {
+	if d.Version == 1 {
+		return false
+	}
+	return purpose >= 1 && purpose <= len(d.PurposeConsents) && d.PurposeConsents[purpose-1]
 }
 
 // parseTCFv2String parses a TCF v2 consent string and extracts purpose consents
 func (m *PrivacyMiddleware) parseTCFv2String(consent string) (*TCFv2Data, error) {
+	return ParseTCFv2(consent)
+}
+
+// ParseTCFv2 parses the core segment of a TCF v2 consent string: its purpose
+// and vendor consents
+func ParseTCFv2(consent string) (*TCFv2Data, error) {
+	// Only the core segment is parsed; any further segments are "."-separated
+	consent, _, _ = strings.Cut(consent, ".")
 	if consent == "" {
 		return nil, nil
 	}
@@ -345,6 +622,32 @@ func (m *PrivacyMiddleware) parseTCFv2String(consent string) (*TCFv2Data, error)
 	for i := 0; i < 24; i++ {
 		data.PurposeConsents[i] = reader.readBool()
 	}
+	// PurposesLITransparency (24 bits), PurposeOneTreatment (1 bit),
+	// PublisherCC (12 bits) - skip
+	reader.readInt(37)
+
+	// Vendor consents: MaxVendorId (16 bits), then a bitfield or a range list.
+	// A truncated section leaves the missing vendors without consent.
+	maxVendorID := reader.readInt(16)
+	if reader.readBool() {
+		// NumEntries (12 bits), each a single ID or an inclusive range
+		entries := reader.readInt(12)
+		for i := 0; i < entries && reader.bitPos < len(reader.data)*8; i++ {
+			isRange := reader.readBool()
+			start := reader.readInt(16)
+			end := start
+			if isRange {
+				end = reader.readInt(16)
+			}
+			data.vendorRanges = append(data.vendorRanges, [2]int{start, end})
+		}
+	} else {
+		for id := 1; id <= maxVendorID && reader.bitPos < len(reader.data)*8; id++ {
+			if reader.readBool() {
+				data.VendorConsents[id] = true
+			}
+		}
+	}
 
 	return data, nil
 }
@@ -487,9 +790,7 @@ func (m *PrivacyMiddleware) checkCCPACompliance(requestID, usPrivacy string) *Pr
 	}
 
 	// Check opt-out signal (position 2)
-	optOut := usPrivacy[2]
-
-	if optOut == 'Y' {
+	if ccpaOptedOut(usPrivacy) {
 		logger.Log.Info().
 			Str("request_id", requestID).
 			Str("us_privacy", usPrivacy).
@@ -517,6 +818,57 @@ func (m *PrivacyMiddleware) checkCCPACompliance(requestID, usPrivacy string) *Pr
 	return nil
 }
 
+// usNatSection decodes the request's GPP US National section, if it applies.
+// A section that doesn't decode is logged and ignored, like a malformed US
+// Privacy string.
+func (m *PrivacyMiddleware) usNatSection(requestID string, gpp *gppString) *usNatData {
+	section, ok := gpp.section(GPPSectionUSNat)
+	if !ok {
+		return nil
+	}
+	usNat, err := parseUSNat(section)
+	if err != nil {
+		logger.Log.Debug().
+			Str("request_id", requestID).
+			Err(err).
+			Msg("Invalid GPP US National section")
+		m.recordGPPSection(gppLabelUSNat, GPPOutcomeInvalid)
+		return nil
+	}
+	return usNat
+}
+
+// checkUSNatCompliance enforces a GPP US National section's opt-outs of sale,
+// sharing and targeted advertising like a US Privacy opt-out of sale
+func (m *PrivacyMiddleware) checkUSNatCompliance(requestID string, usNat *usNatData) *PrivacyViolation {
+	if !usNat.optedOut() {
+		m.recordGPPSection(gppLabelUSNat, PrivacyOutcomeAllowed)
+		return nil
+	}
+	logger.Log.Info().
+		Str("request_id", requestID).
+		Int("sale_opt_out", usNat.SaleOptOut).
+		Int("sharing_opt_out", usNat.SharingOptOut).
+		Int("targeted_advertising_opt_out", usNat.TargetedAdvertisingOptOut).
+		Msg("GPP US National opt-out signal received")
+
+	if !m.config.EnforceCCPA {
+		m.recordGPPSection(gppLabelUSNat, PrivacyOutcomeNotEnforced)
+		return nil
+	}
+	m.recordGPPSection(gppLabelUSNat, m.violationOutcome())
+	return &PrivacyViolation{
+		Regulation:  "CCPA",
+		Reason:      "User has opted out of sale, sharing or targeted advertising in GPP US National",
+		NoBidReason: openrtb.NoBidAdsNotAllowed,
+	}
+}
+
+// ccpaOptedOut reports whether a v1 US Privacy string opts out of sale
+func ccpaOptedOut(usPrivacy string) bool {
+	return len(usPrivacy) >= 4 && usPrivacy[0] == '1' && usPrivacy[2] == 'Y'
+}
+
 // P2-2: IP Anonymization for GDPR Compliance
 // These functions implement privacy-preserving IP address masking as recommended
 // by GDPR guidelines and the German DPA (Datenschutzkonferenz).
@@ -572,13 +924,87 @@ func AnonymizeIP(ipStr string) string {
 	return AnonymizeIPv6(ip)
 }
 
-// anonymizeRequestIPs modifies the bid request to anonymize IP addresses
+// ScrubPersonalData strips personal data from a bid request, leaving what a
+// contextual auction needs: user and device IDs, EIDs, user data segments,
+// year of birth and gender are removed, geo is cut down to country and region,
+// and IP addresses are truncated. It returns the fields it changed.
+func ScrubPersonalData(req *openrtb.BidRequest) []string {
+	var fields []string
+	drop := func(field string, value *string) {
+		if *value != "" {
+			*value = ""
+			fields = append(fields, field)
+		}
+	}
+
+	if user := req.User; user != nil {
+		drop("user.id", &user.ID)
+		drop("user.buyeruid", &user.BuyerUID)
+		drop("user.gender", &user.Gender)
+		if user.YOB != 0 {
+			user.YOB = 0
+			fields = append(fields, "user.yob")
+		}
+		if len(user.EIDs) > 0 {
+			user.EIDs = nil
+			fields = append(fields, "user.eids")
+		}
+		if len(user.Data) > 0 {
+			user.Data = nil
+			fields = append(fields, "user.data")
+		}
+		if coarsenGeo(&user.Geo) {
+			fields = append(fields, "user.geo")
+		}
+	}
+
+	if device := req.Device; device != nil {
+		drop("device.ifa", &device.IFA)
+		drop("device.didsha1", &device.IDSHA1)
+		drop("device.didmd5", &device.IDMD5)
+		drop("device.dpidsha1", &device.DPIDSHA1)
+		drop("device.dpidmd5", &device.DPIDMD5)
+		drop("device.macsha1", &device.MacSHA1)
+		drop("device.macmd5", &device.MacMD5)
+		if device.IP != "" {
+			device.IP = AnonymizeIP(device.IP)
+			fields = append(fields, "device.ip")
+		}
+		if device.IPv6 != "" {
+			device.IPv6 = AnonymizeIP(device.IPv6)
+			fields = append(fields, "device.ipv6")
+		}
+		if coarsenGeo(&device.Geo) {
+			fields = append(fields, "device.geo")
+		}
+	}
+	return fields
+}
+
+// coarsenGeo replaces a location with one carrying only its country and
+// region, reporting whether anything more precise was removed. The original
+// Geo isn't modified, as it may be shared with other bidders' requests.
+func coarsenGeo(geo **openrtb.Geo) bool {
+	g := *geo
+	if g == nil {
+		return false
+	}
+	coarse := openrtb.Geo{Type: g.Type, Country: g.Country, Region: g.Region}
+	precise := g.Lat != 0 || g.Lon != 0 || g.Metro != "" || g.City != "" || g.ZIP != "" || g.RegionFIPS104 != "" || len(g.Ext) > 0
+	*geo = &coarse
+	return precise
+}
+
+// anonymizeRequestIPs modifies the bid request to anonymize IP addresses and
+// returns the fields it changed.
 // This is called when GDPR applies and IP anonymization is enabled
-func (m *PrivacyMiddleware) anonymizeRequestIPs(req *openrtb.BidRequest) {
+func (m *PrivacyMiddleware) anonymizeRequestIPs(req *openrtb.BidRequest) []string {
 	if req.Device == nil {
-		return
+		return nil
 	}
 
+	var fields []string
+
 	if req.Device.IP != "" {
 		originalIP := req.Device.IP
 		req.Device.IP = AnonymizeIP(originalIP)
@@ -587,6 +1013,7 @@ func (m *PrivacyMiddleware) anonymizeRequestIPs(req *openrtb.BidRequest) {
 			Str("original_ip", originalIP).
 			Str("anonymized_ip", req.Device.IP).
 			Msg("P2-2: Anonymized IPv4 for GDPR compliance")
+		fields = append(fields, "device.ip")
 	}
 
 	if req.Device.IPv6 != "" {
@@ -597,5 +1024,7 @@ func (m *PrivacyMiddleware) anonymizeRequestIPs(req *openrtb.BidRequest) {
 			Str("original_ipv6", originalIPv6).
 			Str("anonymized_ipv6", req.Device.IPv6).
 			Msg("P2-2: Anonymized IPv6 for GDPR compliance")
+		fields = append(fields, "device.ipv6")
 	}
+	return fields
 }
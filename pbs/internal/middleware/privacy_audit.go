@@ -0,0 +1,122 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/logger"
+)
+
+// Regulation outcomes recorded in a PrivacyAudit
+const (
+	PrivacyOutcomeAllowed       = "allowed"        // Applies and the request passed
+	PrivacyOutcomeBlocked       = "blocked"        // Applies and the request was rejected
+	PrivacyOutcomeScrubbed      = "scrubbed"       // Applies; personal data was stripped instead of rejecting
+	PrivacyOutcomeNotEnforced   = "not_enforced"   // Applies but enforcement is switched off
+	PrivacyOutcomeNotApplicable = "not_applicable" // The request is out of scope
+)
+
+// Enforcement actions recorded in a PrivacyAudit
+const (
+	PrivacyActionBlocked       = "blocked"        // Request rejected before the auction
+	PrivacyActionScrubbed      = "scrubbed"       // Fields removed or truncated
+	PrivacyActionScopeInferred = "scope_inferred" // Regulation applied without an explicit signal
+)
+
+// PrivacyAudit explains how one request's privacy signals were handled. It is
+// logged per request when PrivacyConfig.AuditLog is on and returned to debug
+// requests as ext.prebid.privacy.
+type PrivacyAudit struct {
+	Signals     PrivacySignals    `json:"signals"`
+	Regulations []RegulationAudit `json:"regulations"`
+	Decisions   []PrivacyDecision `json:"decisions,omitempty"`
+}
+
+// PrivacySignals are the privacy signals as received, before any inference.
+// Consent strings are recorded as present or not, never verbatim.
+type PrivacySignals struct {
+	GDPR      *int   `json:"gdpr,omitempty"`
+	Consent   bool   `json:"consent"`
+	USPrivacy string `json:"us_privacy,omitempty"`
+	COPPA     int    `json:"coppa,omitempty"`
+	GPP       bool   `json:"gpp"`
+	GPPSID    []int  `json:"gpp_sid,omitempty"`
+	Country   string `json:"country,omitempty"`
+}
+
+// RegulationAudit is one regulation's evaluation
+type RegulationAudit struct {
+	Regulation string `json:"regulation"` // "GDPR", "COPPA", "CCPA"
+	Outcome    string `json:"outcome"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+// PrivacyDecision is one enforcement action taken on the request
+type PrivacyDecision struct {
+	Action     string   `json:"action"`
+	Regulation string   `json:"regulation"`
+	Fields     []string `json:"fields,omitempty"` // Request fields affected
+	Reason     string   `json:"reason,omitempty"`
+}
+
+// newPrivacyAudit captures req's privacy signals
+func newPrivacyAudit(req *openrtb.BidRequest) *PrivacyAudit {
+	audit := &PrivacyAudit{Signals: PrivacySignals{Country: resolvedCountry(req)}}
+	if req.Regs != nil {
+		if req.Regs.GDPR != nil {
+			gdpr := *req.Regs.GDPR
+			audit.Signals.GDPR = &gdpr
+		}
+		audit.Signals.USPrivacy = req.Regs.USPrivacy
+		audit.Signals.COPPA = req.Regs.COPPA
+		audit.Signals.GPP = req.Regs.GPP != ""
+		audit.Signals.GPPSID = req.Regs.GPPSID
+	}
+	if req.User != nil {
+		audit.Signals.Consent = req.User.Consent != ""
+	}
+	return audit
+}
+
+// evaluate records a regulation's outcome. Safe on a nil audit.
+func (a *PrivacyAudit) evaluate(regulation, outcome, reason string) {
+	if a == nil {
+		return
+	}
+	a.Regulations = append(a.Regulations, RegulationAudit{Regulation: regulation, Outcome: outcome, Reason: reason})
+}
+
+// decide records an enforcement action. Safe on a nil audit.
+func (a *PrivacyAudit) decide(action, regulation, reason string, fields ...string) {
+	if a == nil {
+		return
+	}
+	a.Decisions = append(a.Decisions, PrivacyDecision{Action: action, Regulation: regulation, Fields: fields, Reason: reason})
+}
+
+// logPrivacyAudit writes the audit trail for a request when audit logging is on
+func (m *PrivacyMiddleware) logPrivacyAudit(requestID string, audit *PrivacyAudit) {
+	if !m.config.AuditLog {
+		return
+	}
+	l := logger.PrivacyAudit()
+	l.Info().
+		Str("request_id", requestID).
+		Interface("signals", audit.Signals).
+		Interface("regulations", audit.Regulations).
+		Interface("decisions", audit.Decisions).
+		Msg("privacy decisions")
+}
+
+type privacyAuditKey struct{}
+
+// WithPrivacyAudit returns a context carrying the request's privacy audit
+func WithPrivacyAudit(ctx context.Context, audit *PrivacyAudit) context.Context {
+	return context.WithValue(ctx, privacyAuditKey{}, audit)
+}
+
+// PrivacyAuditFromContext returns the request's privacy audit, if any
+func PrivacyAuditFromContext(ctx context.Context) (*PrivacyAudit, bool) {
+	audit, ok := ctx.Value(privacyAuditKey{}).(*PrivacyAudit)
+	return audit, ok && audit != nil
+}
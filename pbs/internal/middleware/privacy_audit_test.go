@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/logger"
+	"github.com/rs/zerolog"
+)
+
+// serveAudit runs req through the middleware and returns the status code and
+// the audit seen by the downstream handler (nil if blocked)
+func serveAudit(t *testing.T, config PrivacyConfig, req *openrtb.BidRequest) (int, *PrivacyAudit) {
+	t.Helper()
+	var audit *PrivacyAudit
+	handler := NewPrivacyMiddleware(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		audit, _ = PrivacyAuditFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	body, _ := json.Marshal(req)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/openrtb2/auction", bytes.NewReader(body)))
+	return rr.Code, audit
+}
+
+// outcomes flattens an audit's evaluations for comparison
+func outcomes(audit *PrivacyAudit) string {
+	var parts []string
+	for _, r := range audit.Regulations {
+		parts = append(parts, r.Regulation+"="+r.Outcome)
+	}
+	return strings.Join(parts, ",")
+}
+
+func TestPrivacyAudit_ConsentedGDPRRequest(t *testing.T) {
+	config := DefaultPrivacyConfig()
+	config.StrictMode = false
+	gdpr := 1
+	req := &openrtb.BidRequest{
+		ID:     "audit-1",
+		Imp:    []openrtb.Imp{{ID: "imp1", Banner: &openrtb.Banner{}}},
+		Regs:   &openrtb.Regs{GDPR: &gdpr, GPP: "DBACNY", GPPSID: []int{2}},
+		User:   &openrtb.User{Consent: "CPXxRfAPXxRfAAfKABENB-CgAAAAAAAAAAYgAAAAAAAA"},
+		Device: &openrtb.Device{IP: "192.168.1.100"},
+	}
+
+	code, audit := serveAudit(t, config, req)
+	if code != http.StatusOK || audit == nil {
+		t.Fatalf("expected request forwarded with an audit, got %d / %v", code, audit)
+	}
+	if got := outcomes(audit); got != "COPPA=not_applicable,GDPR=allowed,CCPA=not_applicable" {
+		t.Errorf("unexpected evaluations %s", got)
+	}
+	if !audit.Signals.Consent || audit.Signals.GDPR == nil || !audit.Signals.GPP || len(audit.Signals.GPPSID) != 1 {
+		t.Errorf("unexpected signals %+v", audit.Signals)
+	}
+	if len(audit.Decisions) != 1 || audit.Decisions[0].Action != PrivacyActionScrubbed || audit.Decisions[0].Fields[0] != "device.ip" {
+		t.Errorf("expected IP scrubbing decision, got %+v", audit.Decisions)
+	}
+}
+
+func TestPrivacyAudit_GeoInferenceRecorded(t *testing.T) {
+	config := DefaultPrivacyConfig()
+	config.EnforceGDPR = false
+
+	code, audit := serveAudit(t, config, geoRequest("FRA"))
+	if code != http.StatusOK || audit == nil {
+		t.Fatalf("expected request forwarded with an audit, got %d", code)
+	}
+	if audit.Signals.GDPR != nil || audit.Signals.Country != "FRA" {
+		t.Errorf("expected signals as received, got %+v", audit.Signals)
+	}
+	if got := outcomes(audit); got != "COPPA=not_applicable,GDPR=not_enforced,CCPA=not_applicable" {
+		t.Errorf("unexpected evaluations %s", got)
+	}
+	if len(audit.Decisions) == 0 || audit.Decisions[0].Action != PrivacyActionScopeInferred {
+		t.Errorf("expected scope inference decision, got %+v", audit.Decisions)
+	}
+}
+
+func TestPrivacyAudit_CCPAOptOutNotEnforced(t *testing.T) {
+	config := DefaultPrivacyConfig()
+	config.EnforceCCPA = false
+	req := &openrtb.BidRequest{
+		ID:   "audit-2",
+		Imp:  []openrtb.Imp{{ID: "imp1", Banner: &openrtb.Banner{}}},
+		Regs: &openrtb.Regs{USPrivacy: "1YYN"},
+	}
+
+	_, audit := serveAudit(t, config, req)
+	if audit == nil || outcomes(audit) != "COPPA=not_applicable,GDPR=not_applicable,CCPA=not_enforced" {
+		t.Errorf("expected CCPA opt-out recorded as not enforced, got %+v", audit)
+	}
+}
+
+func TestPrivacyAudit_BlockedRequestLogged(t *testing.T) {
+	var buf bytes.Buffer
+	original := logger.Log
+	logger.Log = zerolog.New(&buf)
+	defer func() { logger.Log = original }()
+
+	config := DefaultPrivacyConfig()
+	config.AuditLog = true
+	req := &openrtb.BidRequest{
+		ID:   "audit-3",
+		Imp:  []openrtb.Imp{{ID: "imp1", Banner: &openrtb.Banner{}}},
+		Regs: &openrtb.Regs{COPPA: 1},
+	}
+
+	if code, _ := serveAudit(t, config, req); code != http.StatusBadRequest {
+		t.Fatalf("expected COPPA request blocked, got %d", code)
+	}
+
+	var entry struct {
+		Component   string            `json:"component"`
+		RequestID   string            `json:"request_id"`
+		Regulations []RegulationAudit `json:"regulations"`
+		Decisions   []PrivacyDecision `json:"decisions"`
+	}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if strings.Contains(line, `"component":"privacy_audit"`) {
+			if err := json.Unmarshal([]byte(line), &entry); err != nil {
+				t.Fatalf("invalid audit log line %s: %v", line, err)
+			}
+		}
+	}
+	if entry.RequestID != "audit-3" || len(entry.Regulations) != 1 || entry.Regulations[0].Outcome != PrivacyOutcomeBlocked {
+		t.Errorf("expected COPPA block in the audit log, got %+v", entry)
+	}
+	if len(entry.Decisions) != 1 || entry.Decisions[0].Action != PrivacyActionBlocked || entry.Decisions[0].Regulation != "COPPA" {
+		t.Errorf("expected block decision logged, got %+v", entry.Decisions)
+	}
+}
+
+func TestPrivacyAudit_LogOffByDefault(t *testing.T) {
+	var buf bytes.Buffer
+	original := logger.Log
+	logger.Log = zerolog.New(&buf)
+	defer func() { logger.Log = original }()
+
+	serveAudit(t, DefaultPrivacyConfig(), &openrtb.BidRequest{ID: "audit-4", Imp: []openrtb.Imp{{ID: "imp1", Banner: &openrtb.Banner{}}}})
+	if strings.Contains(buf.String(), "privacy_audit") {
+		t.Errorf("expected no audit log without PBS_PRIVACY_AUDIT_LOG, got %s", buf.String())
+	}
+}
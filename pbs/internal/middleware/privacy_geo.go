@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/logger"
+)
+
+// DefaultGDPRCountries lists ISO-3166-1 alpha-3 codes where GDPR (or UK GDPR) applies:
+// the EU member states, the EEA EFTA states (Iceland, Liechtenstein, Norway) and the UK
+var DefaultGDPRCountries = []string{
+	"AUT", "BEL", "BGR", "HRV", "CYP", "CZE", "DNK", "EST", "FIN", "FRA",
+	"DEU", "GRC", "HUN", "IRL", "ITA", "LVA", "LTU", "LUX", "MLT", "NLD",
+	"POL", "PRT", "ROU", "SVK", "SVN", "ESP", "SWE",
+	"ISL", "LIE", "NOR",
+	"GBR",
+}
+
+// parseCountryList parses a comma-separated country list into an upper-case set;
+// an empty value yields the fallback list
+func parseCountryList(value string, fallback []string) map[string]bool {
+	countries := make(map[string]bool)
+	for _, c := range strings.Split(value, ",") {
+		if c = strings.ToUpper(strings.TrimSpace(c)); c != "" {
+			countries[c] = true
+		}
+	}
+	if len(countries) == 0 {
+		for _, c := range fallback {
+			countries[c] = true
+		}
+	}
+	return countries
+}
+
+// GeoResolver resolves an IP address to an ISO-3166-1 alpha-3 country code.
+// geoip.Reader (MaxMind DB) and geoip.Service (HTTP) implement it.
+type GeoResolver interface {
+	Country(ctx context.Context, ip string) (string, error)
+}
+
+// GDPRInference records that GDPR scope was inferred from geo because regs.gdpr was absent
+type GDPRInference struct {
+	Country string
+	FromIP  bool // Country was resolved from the device IP, not sent in the request
+}
+
+type gdprInferenceKey struct{}
+
+// WithGDPRInference returns a context carrying a geo-based GDPR inference
+func WithGDPRInference(ctx context.Context, inf *GDPRInference) context.Context {
+	return context.WithValue(ctx, gdprInferenceKey{}, inf)
+}
+
+// GDPRInferenceFromContext returns the geo-based GDPR inference for the request, if any
+func GDPRInferenceFromContext(ctx context.Context) (*GDPRInference, bool) {
+	inf, ok := ctx.Value(gdprInferenceKey{}).(*GDPRInference)
+	return inf, ok && inf != nil
+}
+
+// resolvedCountry returns the request's country, preferring device geo over user geo
+func resolvedCountry(req *openrtb.BidRequest) string {
+	if req.Device != nil && req.Device.Geo != nil && req.Device.Geo.Country != "" {
+		return strings.ToUpper(req.Device.Geo.Country)
+	}
+	if req.User != nil && req.User.Geo != nil {
+		return strings.ToUpper(req.User.Geo.Country)
+	}
+	return ""
+}
+
+// resolveDeviceCountry fills in device.geo.country from the device IP when the
+// request has none, so geo GDPR inference and everything downstream of the
+// middleware see it. Reports whether the country was set.
+func (m *PrivacyMiddleware) resolveDeviceCountry(ctx context.Context, req *openrtb.BidRequest) bool {
+	if m.config.GeoResolver == nil || req.Device == nil {
+		return false
+	}
+	if req.Device.Geo != nil && req.Device.Geo.Country != "" {
+		return false
+	}
+	ip := req.Device.IP
+	if ip == "" {
+		ip = req.Device.IPv6
+	}
+	if ip == "" {
+		return false
+	}
+
+	country, err := m.config.GeoResolver.Country(ctx, ip)
+	if err != nil || country == "" {
+		logger.Log.Debug().
+			Str("request_id", req.ID).
+			Err(err).
+			Msg("Device country not resolved from IP")
+		return false
+	}
+	if req.Device.Geo == nil {
+		req.Device.Geo = &openrtb.Geo{}
+	}
+	req.Device.Geo.Country = country
+	return true
+}
+
+// inferGDPRFromGeo decides GDPR scope when regs.gdpr is absent. It returns nil when
+// inference is disabled, regs.gdpr is explicitly set, or the country is out of scope.
+func (m *PrivacyMiddleware) inferGDPRFromGeo(req *openrtb.BidRequest) *GDPRInference {
+	if !m.config.GeoGDPRInference {
+		return nil
+	}
+	if req.Regs != nil && req.Regs.GDPR != nil {
+		return nil
+	}
+	country := resolvedCountry(req)
+	if country == "" || !m.config.GDPRCountries[country] {
+		return nil
+	}
+	return &GDPRInference{Country: country}
+}
+
+// applyGDPRInference sets regs.gdpr=1 so the rest of the pipeline and bidders
+// see the same scope decision the middleware enforced
+func applyGDPRInference(req *openrtb.BidRequest) {
+	if req.Regs == nil {
+		req.Regs = &openrtb.Regs{}
+	}
+	gdpr := 1
+	req.Regs.GDPR = &gdpr
+}
@@ -0,0 +1,223 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+// geoRequest builds a request without regs from the given device country
+func geoRequest(country string) *openrtb.BidRequest {
+	return &openrtb.BidRequest{
+		ID:  "geo-1",
+		Imp: []openrtb.Imp{{ID: "imp1", Banner: &openrtb.Banner{}}},
+		Device: &openrtb.Device{
+			IP:  "192.168.1.100",
+			Geo: &openrtb.Geo{Country: country},
+		},
+	}
+}
+
+// serveGeo runs req through the middleware and returns the recorder, the forwarded
+// request (nil if blocked) and the inference seen by the downstream handler
+func serveGeo(t *testing.T, config PrivacyConfig, req *openrtb.BidRequest) (*httptest.ResponseRecorder, *openrtb.BidRequest, *GDPRInference) {
+	t.Helper()
+	var forwarded *openrtb.BidRequest
+	var inference *GDPRInference
+	handler := NewPrivacyMiddleware(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		forwarded = &openrtb.BidRequest{}
+		if err := json.Unmarshal(body, forwarded); err != nil {
+			t.Fatalf("invalid forwarded body: %v", err)
+		}
+		inference, _ = GDPRInferenceFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	body, _ := json.Marshal(req)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/openrtb2/auction", bytes.NewReader(body)))
+	return rr, forwarded, inference
+}
+
+func TestPrivacyMiddleware_GeoInference_BlocksEEAWithoutConsent(t *testing.T) {
+	rr, forwarded, _ := serveGeo(t, DefaultPrivacyConfig(), geoRequest("DEU"))
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for EEA request without consent, got %d", rr.Code)
+	}
+	if forwarded != nil {
+		t.Error("handler should not be called")
+	}
+
+	var resp map[string]interface{}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if reason, _ := resp["reason"].(string); !strings.Contains(reason, "inferred from geo DEU") {
+		t.Errorf("expected reason to mention geo inference, got %q", reason)
+	}
+}
+
+func TestPrivacyMiddleware_GeoInference_AppliesPolicy(t *testing.T) {
+	config := DefaultPrivacyConfig()
+	config.StrictMode = false
+
+	req := geoRequest("gbr") // Lower-case codes are normalized
+	req.User = &openrtb.User{Consent: "CPXxRfAPXxRfAAfKABENB-CgAAAAAAAAAAYgAAAAAAAA"}
+
+	rr, forwarded, inference := serveGeo(t, config, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 with consent, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if forwarded.Regs == nil || forwarded.Regs.GDPR == nil || *forwarded.Regs.GDPR != 1 {
+		t.Errorf("expected regs.gdpr=1 to be forwarded, got %+v", forwarded.Regs)
+	}
+	if forwarded.Device.IP != "192.168.1.0" {
+		t.Errorf("expected IP anonymized under inferred GDPR, got %s", forwarded.Device.IP)
+	}
+	if inference == nil || inference.Country != "GBR" {
+		t.Errorf("expected inference recorded in context, got %+v", inference)
+	}
+}
+
+func TestPrivacyMiddleware_GeoInference_NotApplied(t *testing.T) {
+	explicitNo := 0
+	tests := []struct {
+		name   string
+		req    *openrtb.BidRequest
+		config func(*PrivacyConfig)
+	}{
+		{"non-EEA country", geoRequest("USA"), nil},
+		{"no country", geoRequest(""), nil},
+		{"explicit regs.gdpr=0", func() *openrtb.BidRequest {
+			r := geoRequest("FRA")
+			r.Regs = &openrtb.Regs{GDPR: &explicitNo}
+			return r
+		}(), nil},
+		{"inference disabled", geoRequest("FRA"), func(c *PrivacyConfig) { c.GeoGDPRInference = false }},
+		{"country not in configured list", geoRequest("FRA"), func(c *PrivacyConfig) {
+			c.GDPRCountries = parseCountryList("DEU", DefaultGDPRCountries)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultPrivacyConfig()
+			if tt.config != nil {
+				tt.config(&config)
+			}
+			rr, forwarded, inference := serveGeo(t, config, tt.req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+			}
+			if inference != nil {
+				t.Errorf("expected no inference, got %+v", inference)
+			}
+			if forwarded.Device.IP != "192.168.1.100" {
+				t.Errorf("expected IP untouched, got %s", forwarded.Device.IP)
+			}
+		})
+	}
+}
+
+func TestPrivacyMiddleware_GeoInference_UserGeoFallback(t *testing.T) {
+	req := geoRequest("")
+	req.User = &openrtb.User{Geo: &openrtb.Geo{Country: "NOR"}}
+
+	rr, _, _ := serveGeo(t, DefaultPrivacyConfig(), req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected user.geo country to trigger GDPR scope, got %d", rr.Code)
+	}
+}
+
+// geoResolverFunc adapts a function to GeoResolver
+type geoResolverFunc func(ip string) (string, error)
+
+func (f geoResolverFunc) Country(ctx context.Context, ip string) (string, error) {
+	return f(ip)
+}
+
+func TestPrivacyMiddleware_GeoResolver(t *testing.T) {
+	var looked []string
+	config := DefaultPrivacyConfig()
+	config.StrictMode = false
+	config.GeoResolver = geoResolverFunc(func(ip string) (string, error) {
+		looked = append(looked, ip)
+		switch ip {
+		case "192.168.1.100", "2001:db8::1":
+			return "DEU", nil
+		case "10.0.0.1":
+			return "USA", nil
+		}
+		return "", errors.New("no country")
+	})
+
+	t.Run("resolved country drives GDPR inference", func(t *testing.T) {
+		req := geoRequest("")
+		req.User = &openrtb.User{Consent: "CPXxRfAPXxRfAAfKABENB-CgAAAAAAAAAAYgAAAAAAAA"}
+		rr, forwarded, inference := serveGeo(t, config, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		if forwarded.Device.Geo.Country != "DEU" {
+			t.Errorf("expected device.geo.country populated, got %+v", forwarded.Device.Geo)
+		}
+		if inference == nil || inference.Country != "DEU" || !inference.FromIP {
+			t.Errorf("expected inference from the device IP, got %+v", inference)
+		}
+	})
+
+	t.Run("ipv6 without geo", func(t *testing.T) {
+		req := geoRequest("")
+		req.Device = &openrtb.Device{IPv6: "2001:db8::1"}
+		rr, _, _ := serveGeo(t, config, req)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected the resolved EEA country to require consent, got %d", rr.Code)
+		}
+	})
+
+	t.Run("non-EEA country populated without inference", func(t *testing.T) {
+		req := geoRequest("")
+		req.Device.IP = "10.0.0.1"
+		_, forwarded, inference := serveGeo(t, config, req)
+		if forwarded.Device.Geo.Country != "USA" || inference != nil {
+			t.Errorf("expected USA populated and no inference, got %+v / %+v", forwarded.Device.Geo, inference)
+		}
+	})
+
+	t.Run("lookup failure leaves the request as is", func(t *testing.T) {
+		req := geoRequest("")
+		req.Device.IP = "172.16.0.1"
+		rr, forwarded, _ := serveGeo(t, config, req)
+		if rr.Code != http.StatusOK || forwarded.Device.Geo.Country != "" {
+			t.Errorf("expected the request forwarded unchanged, got %d / %+v", rr.Code, forwarded.Device.Geo)
+		}
+	})
+
+	looked = nil
+	serveGeo(t, config, geoRequest("FRA"))
+	if len(looked) != 0 {
+		t.Errorf("expected no lookup when the request has a country, got %v", looked)
+	}
+}
+
+func TestParseCountryList(t *testing.T) {
+	countries := parseCountryList(" deu, FRA ,,", DefaultGDPRCountries)
+	if len(countries) != 2 || !countries["DEU"] || !countries["FRA"] {
+		t.Errorf("unexpected parsed list: %v", countries)
+	}
+
+	defaults := parseCountryList("", DefaultGDPRCountries)
+	if len(defaults) != len(DefaultGDPRCountries) || !defaults["GBR"] || defaults["USA"] {
+		t.Errorf("expected default EEA+UK list, got %v", defaults)
+	}
+}
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -125,6 +126,35 @@ func TestPrivacyMiddleware_GDPRNoConsent(t *testing.T) {
 	}
 }
 
+func TestLivePrivacyConfig(t *testing.T) {
+	live := NewLivePrivacyConfig(DefaultPrivacyConfig())
+	handler := live.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	gdpr := 1
+	body, _ := json.Marshal(&openrtb.BidRequest{
+		ID:   "test-live",
+		Imp:  []openrtb.Imp{{ID: "imp1", Banner: &openrtb.Banner{}}},
+		Regs: &openrtb.Regs{GDPR: &gdpr},
+	})
+	serve := func() int {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/openrtb2/auction", bytes.NewReader(body)))
+		return rr.Code
+	}
+
+	if code := serve(); code != http.StatusBadRequest {
+		t.Fatalf("expected GDPR enforced, got %d", code)
+	}
+	config := live.Load()
+	config.EnforceGDPR = false
+	live.Store(config)
+	if code := serve(); code != http.StatusOK {
+		t.Errorf("expected stored config applied to the next request, got %d", code)
+	}
+}
+
 func TestPrivacyMiddleware_GDPRInvalidConsent(t *testing.T) {
 	// Request with GDPR=1 but invalid consent string should be blocked
 	config := DefaultPrivacyConfig()
@@ -201,6 +231,49 @@ func TestPrivacyMiddleware_COPPA(t *testing.T) {
 	}
 }
 
+func TestPrivacyMiddleware_COPPATransform(t *testing.T) {
+	config := DefaultPrivacyConfig()
+	config.COPPAMode = COPPAModeTransform
+
+	var forwarded openrtb.BidRequest
+	var audit *PrivacyAudit
+	handler := NewPrivacyMiddleware(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&forwarded)
+		audit, _ = PrivacyAuditFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := &openrtb.BidRequest{
+		ID:     "test-coppa-transform",
+		Imp:    []openrtb.Imp{{ID: "imp1", Banner: &openrtb.Banner{}}},
+		Regs:   &openrtb.Regs{COPPA: 1},
+		User:   &openrtb.User{ID: "child-1", YOB: 2015, EIDs: []openrtb.EID{{Source: "id5-sync.com"}}},
+		Device: &openrtb.Device{IP: "203.0.113.77", IFA: "ifa-1", Geo: &openrtb.Geo{Lat: 40.7, Lon: -74.0, Country: "USA"}},
+	}
+	body, _ := json.Marshal(req)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/openrtb2/auction", bytes.NewReader(body)))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected the auction to proceed, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if forwarded.User.ID != "" || forwarded.User.YOB != 0 || len(forwarded.User.EIDs) != 0 || forwarded.Device.IFA != "" {
+		t.Errorf("expected identifiers removed, got %+v / %+v", forwarded.User, forwarded.Device)
+	}
+	if forwarded.Device.IP != "203.0.113.0" || forwarded.Device.Geo.Lat != 0 || forwarded.Device.Geo.Country != "USA" {
+		t.Errorf("expected IP truncated and lat/lon cleared, got %+v", forwarded.Device)
+	}
+	if forwarded.Regs.COPPA != 1 {
+		t.Error("expected regs.coppa passed on to bidders")
+	}
+	if got := outcomes(audit); got != "COPPA=scrubbed,GDPR=not_applicable,CCPA=not_applicable" {
+		t.Errorf("unexpected evaluations %s", got)
+	}
+	if len(audit.Decisions) != 1 || audit.Decisions[0].Regulation != "COPPA" || audit.Decisions[0].Action != PrivacyActionScrubbed {
+		t.Errorf("expected a COPPA scrub decision, got %+v", audit.Decisions)
+	}
+}
+
 func TestPrivacyMiddleware_GETRequest(t *testing.T) {
 	// GET requests should pass through without privacy checks
 	config := DefaultPrivacyConfig()
@@ -323,6 +396,65 @@ func TestPrivacyMiddleware_CCPAOptOut(t *testing.T) {
 	}
 }
 
+func TestPrivacyMiddleware_ScrubMode(t *testing.T) {
+	config := DefaultPrivacyConfig()
+	config.EnforceCCPA = true
+	config.ViolationMode = ViolationModeScrub
+	mw := NewPrivacyMiddleware(config)
+
+	var forwarded openrtb.BidRequest
+	var audit *PrivacyAudit
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&forwarded)
+		audit, _ = PrivacyAuditFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := &openrtb.BidRequest{
+		ID:   "test-scrub-1",
+		Imp:  []openrtb.Imp{{ID: "imp1", Banner: &openrtb.Banner{}}},
+		Site: &openrtb.Site{Domain: "example.com"},
+		Regs: &openrtb.Regs{USPrivacy: "1YYN"},
+		User: &openrtb.User{ID: "u1", BuyerUID: "b1", EIDs: []openrtb.EID{{Source: "id5-sync.com"}}},
+		Device: &openrtb.Device{
+			IP:  "203.0.113.77",
+			IFA: "ifa-1",
+			UA:  "Mozilla/5.0",
+			Geo: &openrtb.Geo{Lat: 40.7, Lon: -74.0, ZIP: "10001", Country: "USA", Region: "NY"},
+		},
+	}
+	body, _ := json.Marshal(req)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/openrtb2/auction", bytes.NewReader(body)))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected the auction to proceed, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if forwarded.User.ID != "" || forwarded.User.BuyerUID != "" || len(forwarded.User.EIDs) != 0 || forwarded.Device.IFA != "" {
+		t.Errorf("expected identifiers scrubbed, got %+v / %+v", forwarded.User, forwarded.Device)
+	}
+	if geo := forwarded.Device.Geo; geo.Lat != 0 || geo.ZIP != "" || geo.Country != "USA" || geo.Region != "NY" {
+		t.Errorf("expected geo cut down to country and region, got %+v", geo)
+	}
+	if forwarded.Device.IP != "203.0.113.0" || forwarded.Device.UA == "" || forwarded.Site.Domain != "example.com" {
+		t.Errorf("expected the IP truncated and contextual data kept, got %+v", forwarded.Device)
+	}
+	if got := outcomes(audit); got != "COPPA=not_applicable,GDPR=not_applicable,CCPA=scrubbed" {
+		t.Errorf("unexpected evaluations %s", got)
+	}
+	if len(audit.Decisions) != 1 || audit.Decisions[0].Action != PrivacyActionScrubbed || audit.Decisions[0].Regulation != "CCPA" {
+		t.Errorf("expected a CCPA scrub decision, got %+v", audit.Decisions)
+	}
+
+	// COPPA still blocks
+	coppa, _ := json.Marshal(&openrtb.BidRequest{ID: "test-scrub-2", Imp: req.Imp, Regs: &openrtb.Regs{COPPA: 1}})
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/openrtb2/auction", bytes.NewReader(coppa)))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected COPPA blocked in scrub mode, got %d", rr.Code)
+	}
+}
+
 func TestPrivacyMiddleware_CCPANoOptOut(t *testing.T) {
 	// When CCPA enforcement is enabled but user doesn't opt out, request should pass
 	config := DefaultPrivacyConfig()
@@ -414,6 +546,96 @@ func TestParseTCFv2String(t *testing.T) {
 	}
 }
 
+// testTCFConsent encodes a TCF v2 core string consenting to purposes 1-10
+// and the given vendors: a bitfield, or with ranges set, [start, end] pairs
+func testTCFConsent(ranges bool, vendors ...int) string {
+	var bits []bool
+	write := func(value, n int) {
+		for i := n - 1; i >= 0; i-- {
+			bits = append(bits, value>>i&1 == 1)
+		}
+	}
+	write(2, 6)         // Version
+	write(0, 36+36)     // Created, LastUpdated
+	write(0, 12+12+6)   // CmpId, CmpVersion, ConsentScreen
+	write(0, 12+12+6)   // ConsentLanguage, VendorListVersion, TcfPolicyVersion
+	write(0, 1+1+12)    // IsServiceSpecific, UseNonStandardStacks, SpecialFeatureOptIns
+	write(0xFFC000, 24) // PurposesConsent: 1-10
+	write(0, 24+1+12)   // PurposesLITransparency, PurposeOneTreatment, PublisherCC
+	maxVendor := 0
+	for _, v := range vendors {
+		maxVendor = max(maxVendor, v)
+	}
+	write(maxVendor, 16)
+	if ranges {
+		write(1, 1)
+		write(len(vendors)/2, 12)
+		for i := 0; i+1 < len(vendors); i += 2 {
+			write(1, 1)
+			write(vendors[i], 16)
+			write(vendors[i+1], 16)
+		}
+	} else {
+		write(0, 1)
+		for id := 1; id <= maxVendor; id++ {
+			bit := 0
+			for _, v := range vendors {
+				if v == id {
+					bit = 1
+				}
+			}
+			write(bit, 1)
+		}
+	}
+
+	data := make([]byte, (len(bits)+7)/8)
+	for i, bit := range bits {
+		if bit {
+			data[i/8] |= 1 << (7 - i%8)
+		}
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func TestParseTCFv2_VendorConsents(t *testing.T) {
+	bitfield, err := ParseTCFv2(testTCFConsent(false, 32, 52) + ".YAAAAAAAAAAA")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bitfield.HasVendorConsent(32) || !bitfield.HasVendorConsent(52) || bitfield.HasVendorConsent(10) {
+		t.Errorf("unexpected bitfield vendor consents %v", bitfield.VendorConsents)
+	}
+	if !bitfield.PurposeConsents[PurposeStorageAccess-1] || bitfield.PurposeConsents[10] {
+		t.Errorf("unexpected purpose consents %v", bitfield.PurposeConsents)
+	}
+	if !bitfield.HasPurposeConsent(PurposeStorageAccess) || bitfield.HasPurposeConsent(11) || bitfield.HasPurposeConsent(0) {
+		t.Error("expected consent to purposes 1-10 only")
+	}
+
+	ranged, err := ParseTCFv2(testTCFConsent(true, 10, 20, 76, 76))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for id, want := range map[int]bool{10: true, 15: true, 20: true, 21: false, 76: true, 91: false} {
+		if ranged.HasVendorConsent(id) != want {
+			t.Errorf("vendor %d: expected consent %v", id, want)
+		}
+	}
+}
+
+func TestParseTCFv2_V1ConsentsToNothing(t *testing.T) {
+	data, err := ParseTCFv2("BOEFEAyOEFEAyAHABDENAI4AAAB9vABAASA")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data.Version != 1 {
+		t.Fatalf("expected a v1 string, got version %d", data.Version)
+	}
+	if data.HasVendorConsent(32) || data.HasPurposeConsent(PurposeStorageAccess) {
+		t.Error("expected a TCF v1 string to consent to no vendor or purpose")
+	}
+}
+
 func TestCheckPurposeConsents(t *testing.T) {
 	m := &PrivacyMiddleware{config: DefaultPrivacyConfig()}
 
@@ -5,8 +5,10 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
 	"sync"
@@ -23,6 +25,19 @@ type PublisherAuthConfig struct {
 	ValidateDomain     bool              // Validate request domain matches registered domains
 	RateLimitPerPub    int               // Requests per second per publisher (0 = unlimited)
 	UseRedis           bool              // Use Redis for publisher validation
+	MismatchAction     string            // MismatchActionReject (default) or MismatchActionFlag
+}
+
+// Actions for inventory that isn't registered to the publisher account
+const (
+	MismatchActionReject = "reject" // Respond 403
+	MismatchActionFlag   = "flag"   // Log and count, then serve the auction
+)
+
+// PublisherAuthMetrics receives requests whose site domain or app bundle isn't
+// registered to the publisher account
+type PublisherAuthMetrics interface {
+	RecordInventoryMismatch(publisherID, inventory, action string)
 }
 
 // DefaultPublisherAuthConfig returns default config
@@ -44,6 +59,7 @@ func DefaultPublisherAuthConfig() *PublisherAuthConfig {
 		ValidateDomain:    os.Getenv("PUBLISHER_VALIDATE_DOMAIN") == "true",
 		RateLimitPerPub:   100, // Default 100 RPS per publisher
 		UseRedis:          os.Getenv("PUBLISHER_AUTH_USE_REDIS") != "false",
+		MismatchAction:    os.Getenv("PUBLISHER_DOMAIN_MISMATCH_ACTION"),
 	}
 }
 
@@ -71,6 +87,7 @@ func parsePublishers(envValue string) map[string]string {
 type minimalBidRequest struct {
 	Site *struct {
 		Domain    string `json:"domain"`
+		Page      string `json:"page"`
 		Publisher *struct {
 			ID string `json:"id"`
 		} `json:"publisher"`
@@ -87,6 +104,7 @@ type minimalBidRequest struct {
 type PublisherAuth struct {
 	config      *PublisherAuthConfig
 	redisClient RedisClient
+	metrics     PublisherAuthMetrics
 	mu          sync.RWMutex
 
 	// Rate limiting per publisher
@@ -120,6 +138,13 @@ func (p *PublisherAuth) SetRedisClient(client RedisClient) {
 	p.redisClient = client
 }
 
+// SetMetrics sets the metrics interface for inventory mismatches
+func (p *PublisherAuth) SetMetrics(m PublisherAuthMetrics) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.metrics = m
+}
+
 // Middleware returns the publisher authentication middleware handler
 func (p *PublisherAuth) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -156,18 +181,50 @@ func (p *PublisherAuth) Middleware(next http.Handler) http.Handler {
 			return
 		}
 
-		// Extract publisher ID
-		publisherID, domain := p.extractPublisherInfo(&minReq)
+		// Extract publisher ID; thin integrations name the account in the query instead
+		publisherID, domain, inventory := p.extractPublisherInfo(&minReq)
+		if publisherID == "" {
+			publisherID = r.URL.Query().Get("account")
+		}
+
+		// A client certificate bound to an account may only bid on its behalf
+		if certAccount, ok := CertAccountFromContext(r.Context()); ok {
+			if publisherID == "" {
+				publisherID = certAccount
+			} else if publisherID != certAccount {
+				log.Warn().
+					Str("publisher_id", publisherID).
+					Str("cert_account", certAccount).
+					Msg("Publisher does not match client certificate")
+				http.Error(w, `{"error":"publisher does not match client certificate"}`, http.StatusForbidden)
+				return
+			}
+		}
 
 		// Validate publisher
-		if err := p.validatePublisher(r.Context(), publisherID, domain); err != nil {
+		if err := p.validatePublisher(r.Context(), publisherID, domain, inventory); err != nil {
+			var authErr *PublisherAuthError
+			mismatch := errors.As(err, &authErr) && authErr.Code == "domain_mismatch"
+			flagged := mismatch && p.flagMismatches()
+			if mismatch {
+				p.recordMismatch(publisherID, inventory, flagged)
+			}
+			if !flagged {
+				log.Warn().
+					Str("publisher_id", publisherID).
+					Str("domain", domain).
+					Str("error", err.Error()).
+					Msg("Publisher validation failed")
+				http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusForbidden)
+				return
+			}
+
+			// Flag-only: serve the auction so mismatches can be reviewed before enforcing
 			log.Warn().
 				Str("publisher_id", publisherID).
 				Str("domain", domain).
-				Str("error", err.Error()).
-				Msg("Publisher validation failed")
-			http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusForbidden)
-			return
+				Str("inventory", inventory).
+				Msg("Publisher inventory not registered to account (flagged)")
 		}
 
 		// Apply rate limiting per publisher
@@ -188,14 +245,23 @@ func (p *PublisherAuth) Middleware(next http.Handler) http.Handler {
 	})
 }
 
-// extractPublisherInfo extracts publisher ID and domain from request
-func (p *PublisherAuth) extractPublisherInfo(req *minimalBidRequest) (publisherID, domain string) {
+// extractPublisherInfo extracts publisher ID, domain (app bundle for apps) and
+// inventory type ("site" or "app") from request. Sites without a domain are
+// checked against the host of their page URL.
+func (p *PublisherAuth) extractPublisherInfo(req *minimalBidRequest) (publisherID, domain, inventory string) {
 	if req.Site != nil {
+		inventory = "site"
 		domain = req.Site.Domain
+		if domain == "" && req.Site.Page != "" {
+			if u, err := url.Parse(req.Site.Page); err == nil {
+				domain = u.Hostname()
+			}
+		}
 		if req.Site.Publisher != nil {
 			publisherID = req.Site.Publisher.ID
 		}
 	} else if req.App != nil {
+		inventory = "app"
 		domain = req.App.Bundle
 		if req.App.Publisher != nil {
 			publisherID = req.App.Publisher.ID
@@ -205,7 +271,7 @@ func (p *PublisherAuth) extractPublisherInfo(req *minimalBidRequest) (publisherI
 }
 
 // validatePublisher validates the publisher ID and domain
-func (p *PublisherAuth) validatePublisher(ctx context.Context, publisherID, domain string) error {
+func (p *PublisherAuth) validatePublisher(ctx context.Context, publisherID, domain, inventory string) error {
 	p.mu.RLock()
 	allowUnregistered := p.config.AllowUnregistered
 	validateDomain := p.config.ValidateDomain
@@ -229,7 +295,7 @@ func (p *PublisherAuth) validatePublisher(ctx context.Context, publisherID, doma
 			if !validateDomain || allowedDomains == "*" || p.domainMatches(domain, allowedDomains) {
 				return nil
 			}
-			return &PublisherAuthError{Code: "domain_mismatch", Message: "domain not allowed for publisher"}
+			return mismatchError(inventory)
 		}
 		// Fall through to local config
 	}
@@ -246,21 +312,53 @@ func (p *PublisherAuth) validatePublisher(ctx context.Context, publisherID, doma
 	// Validate domain if required
 	if validateDomain && allowedDomains != "" && allowedDomains != "*" {
 		if !p.domainMatches(domain, allowedDomains) {
-			return &PublisherAuthError{Code: "domain_mismatch", Message: "domain not allowed for publisher"}
+			return mismatchError(inventory)
 		}
 	}
 
 	return nil
 }
 
-// domainMatches checks if domain matches allowed domains (comma-separated)
+// mismatchError reports inventory that isn't registered to the publisher
+func mismatchError(inventory string) error {
+	if inventory == "app" {
+		return &PublisherAuthError{Code: "domain_mismatch", Message: "app bundle not allowed for publisher"}
+	}
+	return &PublisherAuthError{Code: "domain_mismatch", Message: "domain not allowed for publisher"}
+}
+
+// flagMismatches reports whether mismatched inventory is flagged rather than rejected
+func (p *PublisherAuth) flagMismatches() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.config.MismatchAction == MismatchActionFlag
+}
+
+// recordMismatch counts a mismatch if metrics are available
+func (p *PublisherAuth) recordMismatch(publisherID, inventory string, flagged bool) {
+	p.mu.RLock()
+	m := p.metrics
+	p.mu.RUnlock()
+	if m == nil {
+		return
+	}
+	action := "rejected"
+	if flagged {
+		action = "flagged"
+	}
+	m.RecordInventoryMismatch(publisherID, inventory, action)
+}
+
+// domainMatches checks if domain matches allowed domains ("|"-separated).
+// Domains and bundles compare case-insensitively, ignoring a trailing dot.
 func (p *PublisherAuth) domainMatches(domain, allowedDomains string) bool {
+	domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
 	if domain == "" {
 		return false
 	}
 
 	for _, allowed := range strings.Split(allowedDomains, "|") {
-		allowed = strings.TrimSpace(allowed)
+		allowed = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(allowed)), ".")
 		if allowed == "" {
 			continue
 		}
@@ -166,6 +166,33 @@ func TestPublisherAuth_RegisteredPublisher(t *testing.T) {
 	}
 }
 
+func TestPublisherAuth_AccountQueryParam(t *testing.T) {
+	auth := NewPublisherAuth(&PublisherAuthConfig{
+		Enabled:        true,
+		RegisteredPubs: map[string]string{"pub123": ""},
+	})
+
+	var gotPublisher string
+	handler := auth.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPublisher = r.Header.Get("X-Publisher-ID")
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// Thin integrations send no publisher object and name the account in the URL
+	body := []byte(`{"id":"test-1","imp":[{"id":"imp1","banner":{}}]}`)
+	req := httptest.NewRequest(http.MethodPost, "/openrtb2/auction?account=pub123", bytes.NewReader(body))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", rr.Code)
+	}
+	if gotPublisher != "pub123" {
+		t.Errorf("Expected publisher pub123 from account param, got %q", gotPublisher)
+	}
+}
+
 func TestPublisherAuth_UnregisteredPublisher(t *testing.T) {
 	config := &PublisherAuthConfig{
 		Enabled:           true,
@@ -400,3 +427,93 @@ func TestParsePublishers(t *testing.T) {
 		})
 	}
 }
+
+type mockInventoryMetrics struct {
+	mismatches []string
+}
+
+func (m *mockInventoryMetrics) RecordInventoryMismatch(publisherID, inventory, action string) {
+	m.mismatches = append(m.mismatches, publisherID+"/"+inventory+"/"+action)
+}
+
+func TestPublisherAuth_InventoryVerification(t *testing.T) {
+	tests := []struct {
+		name     string
+		action   string
+		bidReq   string
+		wantCode int
+		wantRec  string // Recorded mismatch, empty for none
+	}{
+		{
+			name:     "registered domain, case and trailing dot ignored",
+			bidReq:   `{"site":{"domain":"News.Allowed.com.","publisher":{"id":"pub123"}}}`,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "page host used when domain is missing",
+			bidReq:   `{"site":{"page":"https://allowed.com/article","publisher":{"id":"pub123"}}}`,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "spoofed page host rejected",
+			bidReq:   `{"site":{"page":"https://spoofed.com/","publisher":{"id":"pub123"}}}`,
+			wantCode: http.StatusForbidden,
+			wantRec:  "pub123/site/rejected",
+		},
+		{
+			name:     "registered bundle",
+			bidReq:   `{"app":{"bundle":"com.example.app","publisher":{"id":"pub123"}}}`,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "unregistered bundle rejected",
+			bidReq:   `{"app":{"bundle":"com.other.app","publisher":{"id":"pub123"}}}`,
+			wantCode: http.StatusForbidden,
+			wantRec:  "pub123/app/rejected",
+		},
+		{
+			name:     "unregistered domain flagged",
+			action:   MismatchActionFlag,
+			bidReq:   `{"site":{"domain":"spoofed.com","publisher":{"id":"pub123"}}}`,
+			wantCode: http.StatusOK,
+			wantRec:  "pub123/site/flagged",
+		},
+		{
+			name:     "unknown publisher is rejected even when flagging",
+			action:   MismatchActionFlag,
+			bidReq:   `{"site":{"domain":"allowed.com","publisher":{"id":"other"}}}`,
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := NewPublisherAuth(&PublisherAuthConfig{
+				Enabled:        true,
+				RegisteredPubs: map[string]string{"pub123": "*.allowed.com|com.example.app"},
+				ValidateDomain: true,
+				MismatchAction: tt.action,
+			})
+			metrics := &mockInventoryMetrics{}
+			auth.SetMetrics(metrics)
+
+			handler := auth.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			req := httptest.NewRequest(http.MethodPost, "/openrtb2/auction", bytes.NewReader([]byte(tt.bidReq)))
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantCode {
+				t.Errorf("Expected status %d, got %d (%s)", tt.wantCode, rr.Code, rr.Body.String())
+			}
+			var want []string
+			if tt.wantRec != "" {
+				want = []string{tt.wantRec}
+			}
+			if len(metrics.mismatches) != len(want) || (len(want) == 1 && metrics.mismatches[0] != want[0]) {
+				t.Errorf("Expected mismatches %v, got %v", want, metrics.mismatches)
+			}
+		})
+	}
+}
@@ -19,6 +19,7 @@ type RateLimitConfig struct {
 	WindowSize        time.Duration // Time window for rate limiting
 	TrustedProxies    []*net.IPNet  // CIDR ranges of trusted proxies
 	TrustXFF          bool          // Whether to trust X-Forwarded-For at all
+	SkipPaths         []string      // Path prefixes limited elsewhere (e.g. by SyncRateLimiter)
 }
 
 // DefaultRateLimitConfig returns default rate limit configuration
@@ -35,27 +36,7 @@ func DefaultRateLimitConfig() *RateLimitConfig {
 
 	// Parse trusted proxies from env (comma-separated CIDR ranges)
 	// Example: TRUSTED_PROXIES=10.0.0.0/8,172.16.0.0/12,192.168.0.0/16,127.0.0.1/32
-	var trustedProxies []*net.IPNet
-	if proxyStr := os.Getenv("TRUSTED_PROXIES"); proxyStr != "" {
-		for _, cidr := range strings.Split(proxyStr, ",") {
-			cidr = strings.TrimSpace(cidr)
-			if cidr == "" {
-				continue
-			}
-			// Handle single IPs by adding /32 or /128
-			if !strings.Contains(cidr, "/") {
-				if strings.Contains(cidr, ":") {
-					cidr += "/128"
-				} else {
-					cidr += "/32"
-				}
-			}
-			_, network, err := net.ParseCIDR(cidr)
-			if err == nil {
-				trustedProxies = append(trustedProxies, network)
-			}
-		}
-	}
+	trustedProxies := parseTrustedProxies(os.Getenv("TRUSTED_PROXIES"))
 
 	// Only trust XFF header if trusted proxies are configured
 	trustXFF := len(trustedProxies) > 0
@@ -73,6 +54,30 @@ func DefaultRateLimitConfig() *RateLimitConfig {
 	}
 }
 
+// parseTrustedProxies parses comma-separated CIDR ranges; bare IPs become /32 or /128
+func parseTrustedProxies(proxyStr string) []*net.IPNet {
+	var trustedProxies []*net.IPNet
+	for _, cidr := range strings.Split(proxyStr, ",") {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+		// Handle single IPs by adding /32 or /128
+		if !strings.Contains(cidr, "/") {
+			if strings.Contains(cidr, ":") {
+				cidr += "/128"
+			} else {
+				cidr += "/32"
+			}
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err == nil {
+			trustedProxies = append(trustedProxies, network)
+		}
+	}
+	return trustedProxies
+}
+
 // clientState tracks rate limit state for a single client
 type clientState struct {
 	tokens    float64
@@ -144,7 +149,11 @@ func (rl *RateLimiter) Stop() {
 // Middleware returns the rate limiting middleware handler
 func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if !rl.config.Enabled {
+		// Limits may be changed by a config reload while serving
+		rl.mu.Lock()
+		enabled, rps := rl.config.Enabled, rl.config.RequestsPerSecond
+		rl.mu.Unlock()
+		if !enabled || hasPathPrefix(r.URL.Path, rl.config.SkipPaths) {
 			next.ServeHTTP(w, r)
 			return
 		}
@@ -162,14 +171,14 @@ func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
 				rl.metrics.IncRateLimitRejected()
 			}
 			w.Header().Set("Retry-After", "1")
-			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(rl.config.RequestsPerSecond))
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(rps))
 			w.Header().Set("X-RateLimit-Remaining", "0")
 			http.Error(w, `{"error":"rate limit exceeded"}`, http.StatusTooManyRequests)
 			return
 		}
 
 		// Add rate limit headers
-		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(rl.config.RequestsPerSecond))
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(rps))
 
 		next.ServeHTTP(w, r)
 	})
@@ -214,11 +223,17 @@ func (rl *RateLimiter) allow(clientID string) bool {
 
 // getClientIP extracts the client IP from the request with secure XFF handling
 func (rl *RateLimiter) getClientIP(r *http.Request) string {
+	return resolveClientIP(r, rl.config.TrustXFF, rl.config.TrustedProxies)
+}
+
+// resolveClientIP returns the client IP, honoring X-Forwarded-For / X-Real-IP
+// only when the direct peer is a trusted proxy
+func resolveClientIP(r *http.Request, trustXFF bool, trustedProxies []*net.IPNet) string {
 	// Get the direct connection IP (RemoteAddr)
 	remoteIP := extractIP(r.RemoteAddr)
 
 	// Only trust XFF if configured and remote IP is from a trusted proxy
-	if rl.config.TrustXFF && rl.isTrustedProxy(remoteIP) {
+	if trustXFF && isTrustedProxy(remoteIP, trustedProxies) {
 		// Check X-Forwarded-For header
 		xff := r.Header.Get("X-Forwarded-For")
 		if xff != "" {
@@ -232,7 +247,7 @@ func (rl *RateLimiter) getClientIP(r *http.Request) string {
 					continue
 				}
 				// If this IP is not a trusted proxy, it's the client
-				if !rl.isTrustedProxy(ip) {
+				if !isTrustedProxy(ip, trustedProxies) {
 					return ip
 				}
 			}
@@ -250,8 +265,8 @@ func (rl *RateLimiter) getClientIP(r *http.Request) string {
 }
 
 // isTrustedProxy checks if an IP is in the trusted proxy list
-func (rl *RateLimiter) isTrustedProxy(ipStr string) bool {
-	if len(rl.config.TrustedProxies) == 0 {
+func isTrustedProxy(ipStr string, trustedProxies []*net.IPNet) bool {
+	if len(trustedProxies) == 0 {
 		return false
 	}
 
@@ -260,7 +275,7 @@ func (rl *RateLimiter) isTrustedProxy(ipStr string) bool {
 		return false
 	}
 
-	for _, network := range rl.config.TrustedProxies {
+	for _, network := range trustedProxies {
 		if network.Contains(ip) {
 			return true
 		}
@@ -268,6 +283,16 @@ func (rl *RateLimiter) isTrustedProxy(ipStr string) bool {
 	return false
 }
 
+// hasPathPrefix reports whether path starts with any of the prefixes
+func hasPathPrefix(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
 // extractIP extracts the IP from an address that may include a port
 func extractIP(addr string) string {
 	// Handle IPv6 with port: [::1]:8080
@@ -311,6 +336,16 @@ func (rl *RateLimiter) SetBurstSize(burst int) {
 	rl.config.BurstSize = burst
 }
 
+// SetLimits replaces the enabled state, requests per second and burst size
+// together, as a config reload does. Clients keep their current tokens.
+func (rl *RateLimiter) SetLimits(enabled bool, rps, burst int) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.config.Enabled = enabled
+	rl.config.RequestsPerSecond = rps
+	rl.config.BurstSize = burst
+}
+
 // SetMetrics sets the metrics interface for the rate limiter
 func (rl *RateLimiter) SetMetrics(m RateLimitMetrics) {
 	rl.mu.Lock()
@@ -316,3 +316,69 @@ func TestRateLimiterSetters(t *testing.T) {
 		t.Errorf("expected burst 50, got %d", rl.config.BurstSize)
 	}
 }
+
+func TestRateLimiterSetLimits(t *testing.T) {
+	rl := NewRateLimiter(&RateLimitConfig{
+		Enabled:           false,
+		RequestsPerSecond: 1,
+		BurstSize:         1,
+		WindowSize:        time.Second,
+		CleanupInterval:   time.Minute,
+	})
+	defer rl.Stop()
+	handler := rl.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// Applies to requests already being served by the middleware
+	rl.SetLimits(true, 20, 1)
+	for i, want := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		req := httptest.NewRequest("POST", "/openrtb2/auction", nil)
+		req.RemoteAddr = "192.168.1.1:12345"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("request %d: expected %d, got %d", i, want, rec.Code)
+		}
+		if got := rec.Header().Get("X-RateLimit-Limit"); got != "20" {
+			t.Errorf("request %d: expected limit header 20, got %q", i, got)
+		}
+	}
+}
+
+func TestRateLimiterSkipPaths(t *testing.T) {
+	rl := NewRateLimiter(&RateLimitConfig{
+		Enabled:           true,
+		RequestsPerSecond: 1,
+		BurstSize:         1,
+		WindowSize:        time.Second,
+		CleanupInterval:   time.Minute,
+		SkipPaths:         []string{"/cookie_sync"},
+	})
+	defer rl.Stop()
+
+	handler := rl.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// Skipped paths never consume tokens
+	for i := 0; i < 5; i++ {
+		req := httptest.NewRequest("POST", "/cookie_sync", nil)
+		req.RemoteAddr = "192.168.1.1:12345"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("skipped request %d: expected 200, got %d", i, rec.Code)
+		}
+	}
+
+	for i, want := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		req := httptest.NewRequest("POST", "/openrtb2/auction", nil)
+		req.RemoteAddr = "192.168.1.1:12345"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("auction request %d: expected %d, got %d", i, want, rec.Code)
+		}
+	}
+}
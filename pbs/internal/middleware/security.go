@@ -2,8 +2,11 @@
 package middleware
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"os"
+	"sort"
 	"strings"
 	"sync"
 )
@@ -36,6 +39,58 @@ type SecurityConfig struct {
 
 	// CacheControl for API responses
 	CacheControl string
+
+	// RoutePolicies override headers per path prefix, applied after the global set
+	RoutePolicies []HeaderPolicy
+}
+
+// HeaderPolicy overrides response headers for requests whose path starts with
+// Prefix. An empty value removes the header. Policies are applied when the
+// response is written, so they take precedence over headers set by handlers;
+// when several prefixes match, the longest is applied last.
+type HeaderPolicy struct {
+	Prefix  string
+	Headers map[string]string
+}
+
+// DefaultHeaderPolicies returns the built-in per-route header policies
+func DefaultHeaderPolicies() []HeaderPolicy {
+	return []HeaderPolicy{
+		// Auction responses carry per-user bids and must never be reused
+		{Prefix: "/openrtb2/auction", Headers: map[string]string{"Cache-Control": "no-store"}},
+	}
+}
+
+// ParseHeaderPolicies parses SECURITY_ROUTE_HEADERS, a JSON object of path
+// prefix to header values, e.g.
+// {"/static/sync/":{"Cache-Control":"public, max-age=86400"},"/event":{"Timing-Allow-Origin":"https://measure.example"}}
+func ParseHeaderPolicies(raw string) ([]HeaderPolicy, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	var byPrefix map[string]map[string]string
+	if err := json.Unmarshal([]byte(raw), &byPrefix); err != nil {
+		return nil, fmt.Errorf("invalid route header policies: %w", err)
+	}
+
+	policies := make([]HeaderPolicy, 0, len(byPrefix))
+	for prefix, headers := range byPrefix {
+		if !strings.HasPrefix(prefix, "/") {
+			return nil, fmt.Errorf("route header prefix %q must start with /", prefix)
+		}
+		canonical := make(map[string]string, len(headers))
+		for name, value := range headers {
+			if name == "" || strings.ContainsAny(name, " \t:\r\n") {
+				return nil, fmt.Errorf("route %s: invalid header name %q", prefix, name)
+			}
+			if strings.ContainsAny(value, "\r\n") {
+				return nil, fmt.Errorf("route %s: header %s value contains a line break", prefix, name)
+			}
+			canonical[http.CanonicalHeaderKey(name)] = value
+		}
+		policies = append(policies, HeaderPolicy{Prefix: prefix, Headers: canonical})
+	}
+	return policies, nil
 }
 
 // DefaultSecurityConfig returns production-ready security headers
@@ -71,6 +126,9 @@ func DefaultSecurityConfig() *SecurityConfig {
 		// API responses should not be cached by browsers
 		CacheControl: envOrDefault("SECURITY_CACHE_CONTROL",
 			"no-store, no-cache, must-revalidate, private"),
+
+		// Extended from SECURITY_ROUTE_HEADERS via AddRoutePolicies
+		RoutePolicies: DefaultHeaderPolicies(),
 	}
 }
 
@@ -93,9 +151,19 @@ func NewSecurity(config *SecurityConfig) *Security {
 	if config == nil {
 		config = DefaultSecurityConfig()
 	}
+	config.RoutePolicies = sortPolicies(config.RoutePolicies)
 	return &Security{config: config}
 }
 
+// sortPolicies orders policies by prefix length so more specific routes apply last
+func sortPolicies(policies []HeaderPolicy) []HeaderPolicy {
+	sorted := append([]HeaderPolicy(nil), policies...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return len(sorted[i].Prefix) < len(sorted[j].Prefix)
+	})
+	return sorted
+}
+
 // Middleware returns the security headers middleware handler
 func (s *Security) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -110,6 +178,7 @@ func (s *Security) Middleware(next http.Handler) http.Handler {
 		hsts := s.config.StrictTransportSecurity
 		permissionsPolicy := s.config.PermissionsPolicy
 		cacheControl := s.config.CacheControl
+		routePolicies := s.config.RoutePolicies
 		s.mu.RUnlock()
 
 		if !enabled {
@@ -155,10 +224,63 @@ func (s *Security) Middleware(next http.Handler) http.Handler {
 			}
 		}
 
-		next.ServeHTTP(w, r)
+		var matched []HeaderPolicy
+		for _, p := range routePolicies {
+			if strings.HasPrefix(r.URL.Path, p.Prefix) {
+				matched = append(matched, p)
+			}
+		}
+		if len(matched) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		pw := &headerPolicyWriter{ResponseWriter: w, policies: matched}
+		next.ServeHTTP(pw, r)
+		// Handlers that never write still send headers once they return
+		pw.apply()
 	})
 }
 
+// headerPolicyWriter applies route header policies just before headers are sent
+type headerPolicyWriter struct {
+	http.ResponseWriter
+	policies []HeaderPolicy
+	applied  bool
+}
+
+func (pw *headerPolicyWriter) apply() {
+	if pw.applied {
+		return
+	}
+	pw.applied = true
+	h := pw.ResponseWriter.Header()
+	for _, p := range pw.policies {
+		for name, value := range p.Headers {
+			if value == "" {
+				h.Del(name)
+			} else {
+				h.Set(name, value)
+			}
+		}
+	}
+}
+
+func (pw *headerPolicyWriter) WriteHeader(code int) {
+	pw.apply()
+	pw.ResponseWriter.WriteHeader(code)
+}
+
+func (pw *headerPolicyWriter) Write(b []byte) (int, error) {
+	pw.apply()
+	return pw.ResponseWriter.Write(b)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (pw *headerPolicyWriter) Unwrap() http.ResponseWriter {
+	return pw.ResponseWriter
+}
+
 // isStaticPath checks if path is for static content that can be cached
 func isStaticPath(path string) bool {
 	// Metrics endpoint can be cached briefly
@@ -193,6 +315,37 @@ func (s *Security) SetCSP(value string) {
 	s.config.ContentSecurityPolicy = value
 }
 
+// AddRoutePolicies adds per-route header policies. A policy for a prefix that
+// already has one is merged into it, with the new values winning.
+func (s *Security) AddRoutePolicies(policies ...HeaderPolicy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	merged := append([]HeaderPolicy(nil), s.config.RoutePolicies...)
+	for _, p := range policies {
+		found := false
+		for i := range merged {
+			if merged[i].Prefix != p.Prefix {
+				continue
+			}
+			headers := make(map[string]string, len(merged[i].Headers)+len(p.Headers))
+			for k, v := range merged[i].Headers {
+				headers[k] = v
+			}
+			for k, v := range p.Headers {
+				headers[k] = v
+			}
+			merged[i].Headers = headers
+			found = true
+			break
+		}
+		if !found {
+			merged = append(merged, p)
+		}
+	}
+	s.config.RoutePolicies = sortPolicies(merged)
+}
+
 // GetConfig returns a copy of the current configuration
 func (s *Security) GetConfig() SecurityConfig {
 	s.mu.RLock()
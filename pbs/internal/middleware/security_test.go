@@ -180,3 +180,104 @@ func TestSecurityMiddleware_EmptyHeadersSkipped(t *testing.T) {
 		t.Errorf("X-Content-Type-Options = %q, want nosniff", got)
 	}
 }
+
+func TestSecurityMiddleware_RoutePolicies(t *testing.T) {
+	security := NewSecurity(&SecurityConfig{
+		Enabled:       true,
+		XFrameOptions: "DENY",
+		CacheControl:  "no-store, private",
+		RoutePolicies: []HeaderPolicy{
+			{Prefix: "/static/", Headers: map[string]string{"Cache-Control": "public, max-age=60", "X-Frame-Options": ""}},
+			{Prefix: "/static/sync/", Headers: map[string]string{"Cache-Control": "public, max-age=86400"}},
+			{Prefix: "/event", Headers: map[string]string{"Timing-Allow-Origin": "https://measure.example"}},
+		},
+	})
+
+	handler := security.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Route policies win over headers the handler sets itself
+		w.Header().Set("Cache-Control", "no-cache")
+		if r.URL.Path != "/event" {
+			_, _ = w.Write([]byte("ok"))
+		}
+	}))
+
+	tests := []struct {
+		path         string
+		cacheControl string
+		frameOptions string
+		timingOrigin string
+	}{
+		{"/static/logo.gif", "public, max-age=60", "", ""},
+		{"/static/sync/pixel.gif", "public, max-age=86400", "", ""},
+		{"/event", "no-cache", "DENY", "https://measure.example"}, // Never writes a body
+		{"/openrtb2/auction", "no-cache", "DENY", ""},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", tt.path, nil))
+
+		if got := rr.Header().Get("Cache-Control"); got != tt.cacheControl {
+			t.Errorf("%s: Cache-Control = %q, want %q", tt.path, got, tt.cacheControl)
+		}
+		if got := rr.Header().Get("X-Frame-Options"); got != tt.frameOptions {
+			t.Errorf("%s: X-Frame-Options = %q, want %q", tt.path, got, tt.frameOptions)
+		}
+		if got := rr.Header().Get("Timing-Allow-Origin"); got != tt.timingOrigin {
+			t.Errorf("%s: Timing-Allow-Origin = %q, want %q", tt.path, got, tt.timingOrigin)
+		}
+	}
+}
+
+func TestSecurityMiddleware_DefaultAuctionNoStore(t *testing.T) {
+	t.Setenv("SECURITY_CACHE_CONTROL", "public, max-age=30")
+	security := NewSecurity(nil)
+
+	handler := security.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("POST", "/openrtb2/auction", nil))
+	if got := rr.Header().Get("Cache-Control"); got != "no-store" {
+		t.Errorf("auction Cache-Control = %q, want no-store", got)
+	}
+}
+
+func TestSecurity_AddRoutePolicies(t *testing.T) {
+	security := NewSecurity(&SecurityConfig{
+		Enabled:       true,
+		RoutePolicies: DefaultHeaderPolicies(),
+	})
+
+	policies, err := ParseHeaderPolicies(`{"/openrtb2/auction":{"timing-allow-origin":"*"},"/":{"X-Served-By":"pbs"}}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	security.AddRoutePolicies(policies...)
+
+	got := security.GetConfig().RoutePolicies
+	if len(got) != 2 || got[0].Prefix != "/" || got[1].Prefix != "/openrtb2/auction" {
+		t.Fatalf("expected policies sorted by prefix length, got %+v", got)
+	}
+	auction := got[1].Headers
+	if auction["Cache-Control"] != "no-store" || auction["Timing-Allow-Origin"] != "*" {
+		t.Errorf("expected default and added auction headers merged, got %v", auction)
+	}
+}
+
+func TestParseHeaderPolicies_Invalid(t *testing.T) {
+	for _, raw := range []string{
+		`not json`,
+		`{"static/":{"Cache-Control":"public"}}`,
+		`{"/static/":{"Bad Header":"x"}}`,
+		`{"/static/":{"X-Test":"a\r\nSet-Cookie: b"}}`,
+	} {
+		if _, err := ParseHeaderPolicies(raw); err == nil {
+			t.Errorf("expected error for %q", raw)
+		}
+	}
+
+	if policies, err := ParseHeaderPolicies(""); err != nil || policies != nil {
+		t.Errorf("expected no policies for empty config, got %v, %v", policies, err)
+	}
+}
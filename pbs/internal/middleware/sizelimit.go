@@ -12,6 +12,8 @@ type SizeLimitConfig struct {
 	Enabled      bool
 	MaxBodySize  int64 // Max request body size in bytes
 	MaxURLLength int   // Max URL length
+	// Max size of a gzip or deflate encoded body once decompressed (0 uses 4MB)
+	MaxDecompressedSize int64
 }
 
 // DefaultSizeLimitConfig returns default size limit configuration
@@ -26,10 +28,16 @@ func DefaultSizeLimitConfig() *SizeLimitConfig {
 		maxURL = 8192 // Default: 8KB
 	}
 
+	maxDecompressed, _ := strconv.ParseInt(os.Getenv("MAX_DECOMPRESSED_REQUEST_SIZE"), 10, 64)
+	if maxDecompressed <= 0 {
+		maxDecompressed = defaultMaxDecompressedSize
+	}
+
 	return &SizeLimitConfig{
-		Enabled:      true, // Enabled by default for security
-		MaxBodySize:  maxBody,
-		MaxURLLength: maxURL,
+		Enabled:             true, // Enabled by default for security
+		MaxBodySize:         maxBody,
+		MaxURLLength:        maxURL,
+		MaxDecompressedSize: maxDecompressed,
 	}
 }
 
@@ -55,10 +63,18 @@ func (sl *SizeLimiter) Middleware(next http.Handler) http.Handler {
 		enabled := sl.config.Enabled
 		maxURLLength := sl.config.MaxURLLength
 		maxBodySize := sl.config.MaxBodySize
+		maxDecompressed := sl.config.MaxDecompressedSize
 		sl.mu.RUnlock()
+		if maxDecompressed <= 0 {
+			maxDecompressed = defaultMaxDecompressedSize
+		}
 
+		// Compressed bodies are still decoded, with the decompressed cap, so
+		// handlers always read plain JSON
 		if !enabled {
-			next.ServeHTTP(w, r)
+			if decompressRequest(w, r, maxDecompressed) {
+				next.ServeHTTP(w, r)
+			}
 			return
 		}
 
@@ -79,6 +95,11 @@ func (sl *SizeLimiter) Middleware(next http.Handler) http.Handler {
 			r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
 		}
 
+		// MaxBodySize applies to the compressed bytes, MaxDecompressedSize to the decoded ones
+		if !decompressRequest(w, r, maxDecompressed) {
+			return
+		}
+
 		next.ServeHTTP(w, r)
 	})
 }
@@ -90,6 +111,13 @@ func (sl *SizeLimiter) SetMaxBodySize(size int64) {
 	sl.config.MaxBodySize = size
 }
 
+// SetMaxDecompressedSize sets the max decompressed body size
+func (sl *SizeLimiter) SetMaxDecompressedSize(size int64) {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	sl.config.MaxDecompressedSize = size
+}
+
 // SetMaxURLLength sets the max URL length
 func (sl *SizeLimiter) SetMaxURLLength(length int) {
 	sl.mu.Lock()
@@ -0,0 +1,290 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/logger"
+)
+
+// SyncRateLimitPaths are the user sync endpoints governed by SyncRateLimiter
+var SyncRateLimitPaths = []string{"/cookie_sync", "/setuid"}
+
+// syncRateLimitKeyPrefix namespaces the shared Redis counters
+const syncRateLimitKeyPrefix = "nexus:syncrl:"
+
+// syncRedisTimeout bounds each Redis counter round trip so a slow Redis never stalls syncs
+const syncRedisTimeout = 50 * time.Millisecond
+
+// SyncRateLimitConfig configures per-IP and per-publisher limits for user sync endpoints.
+// Limits are fixed windows, kept separate from the auction token bucket because sync
+// endpoints are cheap to call, easy to abuse and have very different traffic shapes.
+type SyncRateLimitConfig struct {
+	Enabled           bool
+	PerIPLimit        int           // Requests per window per client IP (0 = unlimited)
+	PerPublisherLimit int           // Requests per window per publisher/account (0 = unlimited)
+	Window            time.Duration // Counting window
+	Paths             []string      // Path prefixes this limiter applies to
+	TrustedProxies    []*net.IPNet  // CIDR ranges of trusted proxies
+	TrustXFF          bool          // Whether to trust X-Forwarded-For at all
+}
+
+// DefaultSyncRateLimitConfig returns sync rate limit configuration from the environment:
+//   - SYNC_RATE_LIMIT_ENABLED: "true" or "false" (default: true)
+//   - SYNC_RATE_LIMIT_PER_IP: requests per window per IP (default: 60)
+//   - SYNC_RATE_LIMIT_PER_PUBLISHER: requests per window per publisher (default: 6000)
+//   - SYNC_RATE_LIMIT_WINDOW: window duration (default: 1m)
+func DefaultSyncRateLimitConfig() *SyncRateLimitConfig {
+	perIP, err := strconv.Atoi(os.Getenv("SYNC_RATE_LIMIT_PER_IP"))
+	if err != nil || perIP < 0 {
+		perIP = 60
+	}
+	perPublisher, err := strconv.Atoi(os.Getenv("SYNC_RATE_LIMIT_PER_PUBLISHER"))
+	if err != nil || perPublisher < 0 {
+		perPublisher = 6000
+	}
+	window, err := time.ParseDuration(os.Getenv("SYNC_RATE_LIMIT_WINDOW"))
+	if err != nil || window <= 0 {
+		window = time.Minute
+	}
+
+	trustedProxies := parseTrustedProxies(os.Getenv("TRUSTED_PROXIES"))
+
+	return &SyncRateLimitConfig{
+		Enabled:           getEnvBool("SYNC_RATE_LIMIT_ENABLED", true),
+		PerIPLimit:        perIP,
+		PerPublisherLimit: perPublisher,
+		Window:            window,
+		Paths:             SyncRateLimitPaths,
+		TrustedProxies:    trustedProxies,
+		TrustXFF:          len(trustedProxies) > 0,
+	}
+}
+
+// SyncRateLimitRedis is the Redis subset used to share counters across instances
+type SyncRateLimitRedis interface {
+	IncrWithTTL(ctx context.Context, key string, ttl time.Duration) (int64, error)
+}
+
+// SyncRateLimitMetrics defines the metrics interface for the sync rate limiter
+type SyncRateLimitMetrics interface {
+	IncSyncRateLimitRejected(scope string)
+}
+
+// windowCounter is an in-memory fixed-window counter
+type windowCounter struct {
+	window int64
+	count  int64
+}
+
+// SyncRateLimiter limits /cookie_sync and /setuid per client IP and per publisher.
+// Counters live in Redis when available so limits hold across instances, with a
+// local fallback when Redis is unset or failing.
+type SyncRateLimiter struct {
+	config  *SyncRateLimitConfig
+	redis   SyncRateLimitRedis
+	metrics SyncRateLimitMetrics
+
+	mu       sync.Mutex
+	counters map[string]*windowCounter
+	stopCh   chan struct{}
+	now      func() time.Time
+}
+
+// NewSyncRateLimiter creates a sync rate limiter
+func NewSyncRateLimiter(config *SyncRateLimitConfig) *SyncRateLimiter {
+	if config == nil {
+		config = DefaultSyncRateLimitConfig()
+	}
+	if config.Window <= 0 {
+		config.Window = time.Minute
+	}
+
+	sl := &SyncRateLimiter{
+		config:   config,
+		counters: make(map[string]*windowCounter),
+		stopCh:   make(chan struct{}),
+		now:      time.Now,
+	}
+	go sl.cleanup()
+	return sl
+}
+
+// SetRedisClient shares counters across instances through Redis
+func (sl *SyncRateLimiter) SetRedisClient(client SyncRateLimitRedis) {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	sl.redis = client
+}
+
+// SetMetrics sets the metrics interface for the sync rate limiter
+func (sl *SyncRateLimiter) SetMetrics(m SyncRateLimitMetrics) {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	sl.metrics = m
+}
+
+// SetLimits replaces the enabled state and per-IP and per-publisher limits
+// together, as a config reload does. The window can't change while running.
+func (sl *SyncRateLimiter) SetLimits(enabled bool, perIP, perPublisher int) {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	sl.config.Enabled = enabled
+	sl.config.PerIPLimit = perIP
+	sl.config.PerPublisherLimit = perPublisher
+}
+
+// Stop stops the cleanup goroutine
+func (sl *SyncRateLimiter) Stop() {
+	close(sl.stopCh)
+}
+
+// cleanup periodically drops local counters from past windows
+func (sl *SyncRateLimiter) cleanup() {
+	ticker := time.NewTicker(sl.config.Window)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			current := sl.windowIndex()
+			sl.mu.Lock()
+			for key, c := range sl.counters {
+				if c.window < current {
+					delete(sl.counters, key)
+				}
+			}
+			sl.mu.Unlock()
+		case <-sl.stopCh:
+			return
+		}
+	}
+}
+
+// Middleware returns the sync rate limiting middleware handler
+func (sl *SyncRateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Limits may be changed by a config reload while serving
+		sl.mu.Lock()
+		enabled, perIP, perPublisher := sl.config.Enabled, sl.config.PerIPLimit, sl.config.PerPublisherLimit
+		sl.mu.Unlock()
+		if !enabled || !hasPathPrefix(r.URL.Path, sl.config.Paths) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if perIP > 0 {
+			ip := resolveClientIP(r, sl.config.TrustXFF, sl.config.TrustedProxies)
+			if !sl.allow(r.Context(), "ip:"+ip, perIP) {
+				sl.reject(w, r, "ip", perIP)
+				return
+			}
+		}
+
+		if perPublisher > 0 {
+			if publisherID := syncPublisherID(r); publisherID != "" {
+				if !sl.allow(r.Context(), "pub:"+publisherID, perPublisher) {
+					sl.reject(w, r, "publisher", perPublisher)
+					return
+				}
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// reject writes a 429 telling the client when the current window ends
+func (sl *SyncRateLimiter) reject(w http.ResponseWriter, r *http.Request, scope string, limit int) {
+	sl.mu.Lock()
+	metrics := sl.metrics
+	sl.mu.Unlock()
+	if metrics != nil {
+		metrics.IncSyncRateLimitRejected(scope)
+	}
+
+	logger.Log.Debug().
+		Str("path", r.URL.Path).
+		Str("scope", scope).
+		Msg("Sync rate limit exceeded")
+
+	windowEnd := time.Unix(0, (sl.windowIndex()+1)*int64(sl.config.Window))
+	retryAfter := int(windowEnd.Sub(sl.now()).Seconds()) + 1
+
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(limit))
+	w.Header().Set("X-RateLimit-Remaining", "0")
+	http.Error(w, `{"error":"rate limit exceeded"}`, http.StatusTooManyRequests)
+}
+
+// windowIndex returns the current fixed window number
+func (sl *SyncRateLimiter) windowIndex() int64 {
+	return sl.now().UnixNano() / int64(sl.config.Window)
+}
+
+// allow counts a request against key and reports whether it is within limit
+func (sl *SyncRateLimiter) allow(ctx context.Context, key string, limit int) bool {
+	window := sl.windowIndex()
+
+	sl.mu.Lock()
+	redisClient := sl.redis
+	sl.mu.Unlock()
+
+	if redisClient != nil {
+		rctx, cancel := context.WithTimeout(ctx, syncRedisTimeout)
+		defer cancel()
+		// Window number in the key makes each window a fresh counter; the TTL only garbage-collects
+		redisKey := syncRateLimitKeyPrefix + key + ":" + strconv.FormatInt(window, 10)
+		count, err := redisClient.IncrWithTTL(rctx, redisKey, 2*sl.config.Window)
+		if err == nil {
+			return count <= int64(limit)
+		}
+		logger.Log.Debug().Err(err).Msg("Sync rate limit Redis error, using local counter")
+	}
+
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	c, ok := sl.counters[key]
+	if !ok || c.window != window {
+		c = &windowCounter{window: window}
+		sl.counters[key] = c
+	}
+	c.count++
+	return c.count <= int64(limit)
+}
+
+// syncPublisherID identifies the publisher of a sync request: the X-Publisher-ID
+// header, the account query parameter (setuid), or the account body field (cookie_sync)
+func syncPublisherID(r *http.Request) string {
+	if id := r.Header.Get("X-Publisher-ID"); id != "" {
+		return id
+	}
+	if id := r.URL.Query().Get("account"); id != "" {
+		return id
+	}
+	if r.Method != http.MethodPost || r.Body == nil {
+		return ""
+	}
+
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return ""
+	}
+	var req struct {
+		Account string `json:"account"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return ""
+	}
+	return req.Account
+}
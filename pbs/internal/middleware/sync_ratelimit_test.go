@@ -0,0 +1,238 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type mockSyncRedis struct {
+	counts map[string]int64
+	ttls   map[string]time.Duration
+	err    error
+}
+
+func (m *mockSyncRedis) IncrWithTTL(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	if m.err != nil {
+		return 0, m.err
+	}
+	if m.counts == nil {
+		m.counts = make(map[string]int64)
+		m.ttls = make(map[string]time.Duration)
+	}
+	m.counts[key]++
+	m.ttls[key] = ttl
+	return m.counts[key], nil
+}
+
+type mockSyncMetrics struct {
+	rejected map[string]int
+}
+
+func (m *mockSyncMetrics) IncSyncRateLimitRejected(scope string) {
+	if m.rejected == nil {
+		m.rejected = make(map[string]int)
+	}
+	m.rejected[scope]++
+}
+
+func newTestSyncLimiter(perIP, perPublisher int) *SyncRateLimiter {
+	return NewSyncRateLimiter(&SyncRateLimitConfig{
+		Enabled:           true,
+		PerIPLimit:        perIP,
+		PerPublisherLimit: perPublisher,
+		Window:            time.Minute,
+		Paths:             SyncRateLimitPaths,
+	})
+}
+
+func serveSync(handler http.Handler, method, target, remoteAddr, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.RemoteAddr = remoteAddr
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func okHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func TestSyncRateLimiter_PerIP(t *testing.T) {
+	sl := newTestSyncLimiter(3, 0)
+	defer sl.Stop()
+	metrics := &mockSyncMetrics{}
+	sl.SetMetrics(metrics)
+	handler := sl.Middleware(okHandler())
+
+	for i := 0; i < 3; i++ {
+		if rec := serveSync(handler, "GET", "/setuid?bidder=appnexus", "10.0.0.1:1234", ""); rec.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i, rec.Code)
+		}
+	}
+
+	rec := serveSync(handler, "GET", "/setuid?bidder=appnexus", "10.0.0.1:1234", "")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("expected Retry-After header")
+	}
+	if rec.Header().Get("X-RateLimit-Limit") != "3" {
+		t.Errorf("expected X-RateLimit-Limit 3, got %q", rec.Header().Get("X-RateLimit-Limit"))
+	}
+	if metrics.rejected["ip"] != 1 {
+		t.Errorf("expected 1 ip rejection, got %v", metrics.rejected)
+	}
+
+	// Other clients have their own budget
+	if rec := serveSync(handler, "GET", "/setuid?bidder=appnexus", "10.0.0.2:1234", ""); rec.Code != http.StatusOK {
+		t.Errorf("expected 200 for different IP, got %d", rec.Code)
+	}
+}
+
+func TestSyncRateLimiter_PerPublisher(t *testing.T) {
+	sl := newTestSyncLimiter(0, 2)
+	defer sl.Stop()
+	metrics := &mockSyncMetrics{}
+	sl.SetMetrics(metrics)
+
+	var bodies []string
+	handler := sl.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	body := `{"account":"pub-1","bidders":["appnexus"]}`
+	for i, ip := range []string{"10.0.0.1:1", "10.0.0.2:1"} {
+		if rec := serveSync(handler, "POST", "/cookie_sync", ip, body); rec.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i, rec.Code)
+		}
+	}
+	if bodies[0] != body {
+		t.Errorf("expected body to be passed through, got %q", bodies[0])
+	}
+
+	// Same publisher from a new IP is still limited; account query param counts too
+	if rec := serveSync(handler, "GET", "/setuid?account=pub-1", "10.0.0.3:1", ""); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("expected 429 for exhausted publisher, got %d", rec.Code)
+	}
+	if metrics.rejected["publisher"] != 1 {
+		t.Errorf("expected 1 publisher rejection, got %v", metrics.rejected)
+	}
+
+	// Requests without a publisher are only subject to the IP limit
+	if rec := serveSync(handler, "POST", "/cookie_sync", "10.0.0.3:1", `{}`); rec.Code != http.StatusOK {
+		t.Errorf("expected 200 without publisher, got %d", rec.Code)
+	}
+}
+
+func TestSyncRateLimiter_IgnoresOtherPaths(t *testing.T) {
+	sl := newTestSyncLimiter(1, 1)
+	defer sl.Stop()
+	handler := sl.Middleware(okHandler())
+
+	for i := 0; i < 5; i++ {
+		if rec := serveSync(handler, "POST", "/openrtb2/auction", "10.0.0.1:1", ""); rec.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i, rec.Code)
+		}
+	}
+}
+
+func TestSyncRateLimiter_Disabled(t *testing.T) {
+	sl := NewSyncRateLimiter(&SyncRateLimitConfig{Enabled: false, PerIPLimit: 1, Paths: SyncRateLimitPaths})
+	defer sl.Stop()
+	handler := sl.Middleware(okHandler())
+
+	for i := 0; i < 5; i++ {
+		if rec := serveSync(handler, "GET", "/setuid", "10.0.0.1:1", ""); rec.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i, rec.Code)
+		}
+	}
+}
+
+func TestSyncRateLimiter_SetLimits(t *testing.T) {
+	sl := newTestSyncLimiter(5, 0)
+	defer sl.Stop()
+	handler := sl.Middleware(okHandler())
+
+	sl.SetLimits(true, 1, 0)
+	serveSync(handler, "GET", "/setuid", "10.0.0.1:1", "")
+	if rec := serveSync(handler, "GET", "/setuid", "10.0.0.1:1", ""); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("expected lowered limit applied, got %d", rec.Code)
+	}
+
+	sl.SetLimits(false, 1, 0)
+	if rec := serveSync(handler, "GET", "/setuid", "10.0.0.1:1", ""); rec.Code != http.StatusOK {
+		t.Errorf("expected disabled limiter to pass, got %d", rec.Code)
+	}
+}
+
+func TestSyncRateLimiter_Redis(t *testing.T) {
+	sl := newTestSyncLimiter(2, 0)
+	defer sl.Stop()
+	redis := &mockSyncRedis{}
+	sl.SetRedisClient(redis)
+	handler := sl.Middleware(okHandler())
+
+	for i := 0; i < 2; i++ {
+		serveSync(handler, "GET", "/setuid", "10.0.0.1:1", "")
+	}
+	if rec := serveSync(handler, "GET", "/setuid", "10.0.0.1:1", ""); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("expected 429 from shared counter, got %d", rec.Code)
+	}
+	if len(redis.counts) != 1 {
+		t.Fatalf("expected one Redis counter, got %v", redis.counts)
+	}
+	for key, ttl := range redis.ttls {
+		if !strings.HasPrefix(key, syncRateLimitKeyPrefix+"ip:10.0.0.1:") {
+			t.Errorf("unexpected key %q", key)
+		}
+		if ttl != 2*time.Minute {
+			t.Errorf("expected TTL of two windows, got %v", ttl)
+		}
+	}
+	if len(sl.counters) != 0 {
+		t.Error("expected no local counters while Redis is healthy")
+	}
+}
+
+func TestSyncRateLimiter_RedisFailureFallsBackToLocal(t *testing.T) {
+	sl := newTestSyncLimiter(1, 0)
+	defer sl.Stop()
+	sl.SetRedisClient(&mockSyncRedis{err: errors.New("connection refused")})
+	handler := sl.Middleware(okHandler())
+
+	if rec := serveSync(handler, "GET", "/setuid", "10.0.0.1:1", ""); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if rec := serveSync(handler, "GET", "/setuid", "10.0.0.1:1", ""); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("expected local fallback to enforce limit, got %d", rec.Code)
+	}
+}
+
+func TestSyncRateLimiter_WindowReset(t *testing.T) {
+	sl := newTestSyncLimiter(1, 0)
+	defer sl.Stop()
+	now := time.Unix(1700000000, 0)
+	sl.now = func() time.Time { return now }
+	handler := sl.Middleware(okHandler())
+
+	serveSync(handler, "GET", "/setuid", "10.0.0.1:1", "")
+	if rec := serveSync(handler, "GET", "/setuid", "10.0.0.1:1", ""); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", rec.Code)
+	}
+
+	now = now.Add(time.Minute)
+	if rec := serveSync(handler, "GET", "/setuid", "10.0.0.1:1", ""); rec.Code != http.StatusOK {
+		t.Errorf("expected 200 in next window, got %d", rec.Code)
+	}
+}
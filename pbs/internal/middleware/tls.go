@@ -0,0 +1,164 @@
+// Package middleware provides HTTP middleware for PBS
+package middleware
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Client certificate verification modes for TLS_CLIENT_AUTH
+const (
+	ClientAuthNone     = "none"     // No client certificates requested
+	ClientAuthOptional = "optional" // Verify a certificate if the client presents one
+	ClientAuthRequire  = "require"  // Reject handshakes without a valid certificate
+)
+
+// TLSConfig holds TLS termination settings for the public listener
+type TLSConfig struct {
+	CertFile     string            // PEM server certificate (chain); empty = plain HTTP
+	KeyFile      string            // PEM private key for CertFile
+	ClientCAFile string            // PEM CA bundle used to verify client certificates
+	ClientAuth   string            // none, optional or require
+	CNAccounts   map[string]string // client certificate CN -> account (publisher) ID
+}
+
+// DefaultTLSConfig returns TLS config from the environment
+func DefaultTLSConfig() *TLSConfig {
+	clientAuth := strings.ToLower(strings.TrimSpace(os.Getenv("TLS_CLIENT_AUTH")))
+	if clientAuth == "" {
+		clientAuth = ClientAuthNone
+	}
+	return &TLSConfig{
+		CertFile:     os.Getenv("TLS_CERT_FILE"),
+		KeyFile:      os.Getenv("TLS_KEY_FILE"),
+		ClientCAFile: os.Getenv("TLS_CLIENT_CA_FILE"),
+		ClientAuth:   clientAuth,
+		CNAccounts:   parsePublishers(os.Getenv("TLS_CLIENT_ACCOUNTS")), // "cn1:pub1,cn2:pub2"
+	}
+}
+
+// Enabled reports whether the listener should terminate TLS
+func (c *TLSConfig) Enabled() bool {
+	return c != nil && (c.CertFile != "" || c.KeyFile != "")
+}
+
+// Validate checks the config is internally consistent
+func (c *TLSConfig) Validate() error {
+	if !c.Enabled() {
+		if c != nil && (c.ClientCAFile != "" || len(c.CNAccounts) > 0 || (c.ClientAuth != "" && c.ClientAuth != ClientAuthNone)) {
+			return fmt.Errorf("client certificate settings require TLS_CERT_FILE and TLS_KEY_FILE")
+		}
+		return nil
+	}
+	if c.CertFile == "" || c.KeyFile == "" {
+		return fmt.Errorf("both TLS_CERT_FILE and TLS_KEY_FILE must be set")
+	}
+	switch c.ClientAuth {
+	case "", ClientAuthNone:
+		if c.ClientCAFile != "" || len(c.CNAccounts) > 0 {
+			return fmt.Errorf("client CA and account mapping need TLS_CLIENT_AUTH=optional or require")
+		}
+	case ClientAuthOptional, ClientAuthRequire:
+		if c.ClientCAFile == "" {
+			return fmt.Errorf("TLS_CLIENT_AUTH=%s requires TLS_CLIENT_CA_FILE", c.ClientAuth)
+		}
+	default:
+		return fmt.Errorf("invalid TLS_CLIENT_AUTH %q (want none, optional or require)", c.ClientAuth)
+	}
+	for cn, account := range c.CNAccounts {
+		if cn == "" || account == "" {
+			return fmt.Errorf("TLS_CLIENT_ACCOUNTS entries must be cn:account, got %q:%q", cn, account)
+		}
+	}
+	return nil
+}
+
+// ServerTLS loads the certificates and builds the listener's tls.Config
+func (c *TLSConfig) ServerTLS() (*tls.Config, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	if !c.Enabled() {
+		return nil, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load server certificate: %w", err)
+	}
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if c.ClientCAFile == "" {
+		return cfg, nil
+	}
+	pem, err := os.ReadFile(c.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("read client CA bundle: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in client CA bundle %s", c.ClientCAFile)
+	}
+	cfg.ClientCAs = pool
+	cfg.ClientAuth = tls.VerifyClientCertIfGiven
+	if c.ClientAuth == ClientAuthRequire {
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return cfg, nil
+}
+
+type certAccountKey struct{}
+
+// WithCertAccount returns a context carrying the account bound to the client certificate
+func WithCertAccount(ctx context.Context, account string) context.Context {
+	return context.WithValue(ctx, certAccountKey{}, account)
+}
+
+// CertAccountFromContext returns the account authenticated by client certificate, if any
+func CertAccountFromContext(ctx context.Context) (string, bool) {
+	account, ok := ctx.Value(certAccountKey{}).(string)
+	return account, ok && account != ""
+}
+
+// ClientCertAuth maps verified client certificates to accounts. A mapped
+// certificate authenticates the request in place of an API key, and
+// PublisherAuth rejects auctions naming a different publisher.
+type ClientCertAuth struct {
+	cnAccounts map[string]string
+}
+
+// NewClientCertAuth creates client certificate auth from a CN -> account mapping
+func NewClientCertAuth(cnAccounts map[string]string) *ClientCertAuth {
+	return &ClientCertAuth{cnAccounts: cnAccounts}
+}
+
+// Middleware returns the client certificate auth middleware handler
+func (c *ClientCertAuth) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Only chains verified against the client CA bundle count
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cn := r.TLS.VerifiedChains[0][0].Subject.CommonName
+		account, ok := c.cnAccounts[cn]
+		if !ok {
+			log.Debug().Str("client_cn", cn).Msg("Client certificate has no account mapping")
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithCertAccount(r.Context(), account)))
+	})
+}
@@ -0,0 +1,256 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testPKI is a throwaway CA with a server certificate and client certificates
+type testPKI struct {
+	dir    string
+	ca     *x509.Certificate
+	caKey  *ecdsa.PrivateKey
+	caFile string
+	serial int64
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	t.Helper()
+	p := &testPKI{dir: t.TempDir(), serial: 1}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(p.serial),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.ca, _ = x509.ParseCertificate(der)
+	p.caKey = key
+	p.caFile = p.write(t, "ca.pem", "CERTIFICATE", der)
+	return p
+}
+
+func (p *testPKI) write(t *testing.T, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(p.dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// issue signs a leaf certificate and returns its cert and key file paths
+func (p *testPKI) issue(t *testing.T, cn string, usage x509.ExtKeyUsage) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.serial++
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(p.serial),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, p.ca, &key.PublicKey, p.caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p.write(t, cn+".pem", "CERTIFICATE", der), p.write(t, cn+"-key.pem", "EC PRIVATE KEY", keyDER)
+}
+
+func TestTLSConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *TLSConfig
+		wantErr bool
+	}{
+		{"disabled", &TLSConfig{ClientAuth: ClientAuthNone}, false},
+		{"server only", &TLSConfig{CertFile: "c", KeyFile: "k", ClientAuth: ClientAuthNone}, false},
+		{"mtls", &TLSConfig{CertFile: "c", KeyFile: "k", ClientCAFile: "ca", ClientAuth: ClientAuthRequire, CNAccounts: map[string]string{"cn": "pub"}}, false},
+		{"missing key", &TLSConfig{CertFile: "c", ClientAuth: ClientAuthNone}, true},
+		{"client auth without ca", &TLSConfig{CertFile: "c", KeyFile: "k", ClientAuth: ClientAuthOptional}, true},
+		{"ca without client auth", &TLSConfig{CertFile: "c", KeyFile: "k", ClientCAFile: "ca", ClientAuth: ClientAuthNone}, true},
+		{"unknown client auth", &TLSConfig{CertFile: "c", KeyFile: "k", ClientCAFile: "ca", ClientAuth: "always"}, true},
+		{"client settings without tls", &TLSConfig{ClientCAFile: "ca", ClientAuth: ClientAuthRequire}, true},
+		{"account without cn", &TLSConfig{CertFile: "c", KeyFile: "k", ClientCAFile: "ca", ClientAuth: ClientAuthOptional, CNAccounts: map[string]string{"cn": ""}}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestTLSConfig_ServerTLSDisabled(t *testing.T) {
+	cfg, err := (&TLSConfig{ClientAuth: ClientAuthNone}).ServerTLS()
+	if err != nil || cfg != nil {
+		t.Errorf("expected no TLS config, got %v, %v", cfg, err)
+	}
+}
+
+// startMTLSServer serves handler behind ClientCertAuth with mutual TLS
+func startMTLSServer(t *testing.T, p *testPKI, clientAuth string, handler http.Handler) *httptest.Server {
+	t.Helper()
+	certFile, keyFile := p.issue(t, "server", x509.ExtKeyUsageServerAuth)
+	config := &TLSConfig{
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		ClientCAFile: p.caFile,
+		ClientAuth:   clientAuth,
+		CNAccounts:   map[string]string{"s2s-partner": "pub-1"},
+	}
+	serverTLS, err := config.ServerTLS()
+	if err != nil {
+		t.Fatalf("ServerTLS failed: %v", err)
+	}
+
+	srv := httptest.NewUnstartedServer(NewClientCertAuth(config.CNAccounts).Middleware(handler))
+	srv.TLS = serverTLS
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func mtlsClient(t *testing.T, p *testPKI, cn string) *http.Client {
+	t.Helper()
+	roots := x509.NewCertPool()
+	roots.AddCert(p.ca)
+	cfg := &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}
+	if cn != "" {
+		certFile, keyFile := p.issue(t, cn, x509.ExtKeyUsageClientAuth)
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: cfg}, Timeout: 5 * time.Second}
+}
+
+func TestClientCertAuth_MapsCNToAccount(t *testing.T) {
+	p := newTestPKI(t)
+	srv := startMTLSServer(t, p, ClientAuthOptional, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		account, _ := CertAccountFromContext(r.Context())
+		_, _ = io.WriteString(w, account)
+	}))
+
+	tests := []struct {
+		cn   string
+		want string
+	}{
+		{"s2s-partner", "pub-1"},
+		{"unmapped-partner", ""},
+		{"", ""}, // optional mode accepts clients without certificates
+	}
+	for _, tt := range tests {
+		resp, err := mtlsClient(t, p, tt.cn).Get(srv.URL)
+		if err != nil {
+			t.Fatalf("cn %q: request failed: %v", tt.cn, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != tt.want {
+			t.Errorf("cn %q: expected account %q, got %q", tt.cn, tt.want, body)
+		}
+	}
+}
+
+func TestClientCertAuth_RequireRejectsMissingCert(t *testing.T) {
+	p := newTestPKI(t)
+	srv := startMTLSServer(t, p, ClientAuthRequire, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	if resp, err := mtlsClient(t, p, "").Get(srv.URL); err == nil {
+		resp.Body.Close()
+		t.Error("expected handshake failure without client certificate")
+	}
+
+	resp, err := mtlsClient(t, p, "s2s-partner").Get(srv.URL)
+	if err != nil {
+		t.Fatalf("expected request with client certificate to succeed: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestAuth_CertAccountSkipsAPIKey(t *testing.T) {
+	auth := NewAuth(&AuthConfig{Enabled: true, HeaderName: "X-API-Key", APIKeys: map[string]string{}})
+
+	var publisherID string
+	handler := auth.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		publisherID = r.Header.Get("X-Publisher-ID")
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/flags", nil)
+	req = req.WithContext(WithCertAccount(req.Context(), "pub-1"))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK || publisherID != "pub-1" {
+		t.Errorf("expected cert account to authenticate, got %d with publisher %q", rr.Code, publisherID)
+	}
+}
+
+func TestPublisherAuth_CertAccountBindsPublisher(t *testing.T) {
+	auth := NewPublisherAuth(&PublisherAuthConfig{Enabled: true, AllowUnregistered: true})
+
+	var publisherID string
+	handler := auth.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		publisherID = r.Header.Get("X-Publisher-ID")
+	}))
+
+	tests := []struct {
+		body       string
+		wantStatus int
+		wantPub    string
+	}{
+		{`{"site":{"publisher":{"id":"pub-1"}}}`, http.StatusOK, "pub-1"},
+		{`{"site":{"domain":"example.com"}}`, http.StatusOK, "pub-1"},
+		{`{"site":{"publisher":{"id":"pub-2"}}}`, http.StatusForbidden, ""},
+	}
+	for _, tt := range tests {
+		publisherID = ""
+		req := httptest.NewRequest(http.MethodPost, "/openrtb2/auction", bytes.NewBufferString(tt.body))
+		req = req.WithContext(WithCertAccount(req.Context(), "pub-1"))
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != tt.wantStatus || publisherID != tt.wantPub {
+			t.Errorf("%s: expected %d/%q, got %d/%q", tt.body, tt.wantStatus, tt.wantPub, rr.Code, publisherID)
+		}
+	}
+}
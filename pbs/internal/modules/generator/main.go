@@ -0,0 +1,131 @@
+// Command generator writes one build-tagged file per module declared in
+// modules.json. Run it through go generate from internal/modules.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"text/template"
+)
+
+// moduleSpec is one modules.json entry
+type moduleSpec struct {
+	Name    string `json:"name"`
+	Kind    string `json:"kind"`
+	Package string `json:"package"`
+	Default bool   `json:"default"` // Linked unless built with no_<name>
+}
+
+// Tag returns the build constraint that links the module in
+func (s moduleSpec) Tag() string {
+	if s.Default {
+		return "!no_" + s.Name
+	}
+	return "with_" + s.Name
+}
+
+var validName = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+var moduleTemplate = template.Must(template.New("module").Parse(`// Code generated by go generate; DO NOT EDIT.
+
+//go:build {{.Tag}}
+
+package modules
+
+import _ "{{.Package}}"
+
+func init() {
+	add(Module{Name: "{{.Name}}", Kind: "{{.Kind}}", Package: "{{.Package}}"})
+}
+`))
+
+func main() {
+	config := flag.String("config", "modules.json", "module list")
+	dir := flag.String("dir", ".", "output directory")
+	flag.Parse()
+
+	specs, err := loadSpecs(*config)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := generate(specs, *dir); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// loadSpecs reads and validates the module list
+func loadSpecs(path string) ([]moduleSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var specs []moduleSpec
+	if err := json.Unmarshal(data, &specs); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	seen := make(map[string]bool, len(specs))
+	for _, s := range specs {
+		if !validName.MatchString(s.Name) {
+			return nil, fmt.Errorf("module name %q must be lowercase letters, digits and underscores", s.Name)
+		}
+		if seen[s.Name] {
+			return nil, fmt.Errorf("duplicate module %q", s.Name)
+		}
+		if s.Kind == "" || s.Package == "" {
+			return nil, fmt.Errorf("module %q needs a kind and a package", s.Name)
+		}
+		seen[s.Name] = true
+	}
+	return specs, nil
+}
+
+// render returns the generated source for one module
+func render(s moduleSpec) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := moduleTemplate.Execute(&buf, s); err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
+
+// fileName is the generated file for a module
+func fileName(s moduleSpec) string {
+	return "module_" + s.Name + ".go"
+}
+
+// generate writes every module's file into dir and removes files left over
+// from modules no longer listed
+func generate(specs []moduleSpec, dir string) error {
+	keep := make(map[string]bool, len(specs))
+	for _, s := range specs {
+		src, err := render(s)
+		if err != nil {
+			return fmt.Errorf("render %s: %w", s.Name, err)
+		}
+		name := fileName(s)
+		if err := os.WriteFile(filepath.Join(dir, name), src, 0o644); err != nil {
+			return err
+		}
+		keep[name] = true
+	}
+
+	existing, err := filepath.Glob(filepath.Join(dir, "module_*.go"))
+	if err != nil {
+		return err
+	}
+	for _, path := range existing {
+		if !keep[filepath.Base(path)] {
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestGeneratedFilesUpToDate fails when modules.json changed without re-running go generate
+func TestGeneratedFilesUpToDate(t *testing.T) {
+	specs, err := loadSpecs("../modules.json")
+	if err != nil {
+		t.Fatalf("invalid modules.json: %v", err)
+	}
+
+	want := make(map[string]bool, len(specs))
+	for _, s := range specs {
+		src, err := render(s)
+		if err != nil {
+			t.Fatalf("render %s: %v", s.Name, err)
+		}
+		got, err := os.ReadFile(filepath.Join("..", fileName(s)))
+		if err != nil || string(got) != string(src) {
+			t.Errorf("%s is stale, run go generate ./internal/modules", fileName(s))
+		}
+		want[fileName(s)] = true
+	}
+
+	existing, _ := filepath.Glob("../module_*.go")
+	for _, path := range existing {
+		if !want[filepath.Base(path)] {
+			t.Errorf("%s has no modules.json entry, run go generate ./internal/modules", filepath.Base(path))
+		}
+	}
+}
+
+func TestModuleTags(t *testing.T) {
+	if tag := (moduleSpec{Name: "demo", Default: true}).Tag(); tag != "!no_demo" {
+		t.Errorf("expected default module excluded by no_ tag, got %s", tag)
+	}
+	if tag := (moduleSpec{Name: "openx"}).Tag(); tag != "with_openx" {
+		t.Errorf("expected optional module included by with_ tag, got %s", tag)
+	}
+}
+
+func TestLoadSpecs_Invalid(t *testing.T) {
+	for name, config := range map[string]string{
+		"duplicate":  `[{"name":"a","kind":"adapter","package":"p"},{"name":"a","kind":"adapter","package":"p"}]`,
+		"bad name":   `[{"name":"Bad-Name","kind":"adapter","package":"p"}]`,
+		"no package": `[{"name":"a","kind":"adapter"}]`,
+	} {
+		path := filepath.Join(t.TempDir(), "modules.json")
+		os.WriteFile(path, []byte(config), 0o644)
+		if _, err := loadSpecs(path); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestGenerate_RemovesStaleFiles(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "module_gone.go"), []byte("package modules\n"), 0o644)
+
+	if err := generate([]moduleSpec{{Name: "demo", Kind: "adapter", Package: "example.com/demo", Default: true}}, dir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "module_gone.go")); !os.IsNotExist(err) {
+		t.Error("expected stale module file removed")
+	}
+	src, err := os.ReadFile(filepath.Join(dir, "module_demo.go"))
+	if err != nil || !strings.Contains(string(src), "//go:build !no_demo") {
+		t.Errorf("expected build-tagged module file, got %s", src)
+	}
+}
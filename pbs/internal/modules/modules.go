@@ -0,0 +1,58 @@
+// Package modules is the compile-time list of optional subsystems linked into
+// the server binary. Each module is declared in modules.json and gets its own
+// generated file, guarded by a build tag, that imports the module's package
+// and records it here:
+//
+//	go generate ./internal/modules
+//
+// Default modules are left out with a no_<name> tag; the rest are opt-in with
+// a with_<name> tag:
+//
+//	go build -tags no_demo,with_openx,with_ix ./cmd/server
+package modules
+
+import "sort"
+
+//go:generate go run ./generator
+
+// Module kinds
+const (
+	KindAdapter = "adapter" // Static bidder adapter, registered in adapters.DefaultRegistry
+)
+
+// Module is a subsystem compiled into the binary
+type Module struct {
+	Name    string `json:"name"`
+	Kind    string `json:"kind"`
+	Package string `json:"package"`
+}
+
+var compiled []Module
+
+// add records a module; called from the generated files' init functions
+func add(m Module) {
+	compiled = append(compiled, m)
+}
+
+// List returns the modules compiled into this binary, sorted by kind and name
+func List() []Module {
+	modules := make([]Module, len(compiled))
+	copy(modules, compiled)
+	sort.Slice(modules, func(i, j int) bool {
+		if modules[i].Kind != modules[j].Kind {
+			return modules[i].Kind < modules[j].Kind
+		}
+		return modules[i].Name < modules[j].Name
+	})
+	return modules
+}
+
+// Names returns the names of the compiled modules in List order
+func Names() []string {
+	modules := List()
+	names := make([]string, len(modules))
+	for i, m := range modules {
+		names[i] = m.Name
+	}
+	return names
+}
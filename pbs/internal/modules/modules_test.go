@@ -0,0 +1,33 @@
+package modules
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+)
+
+func TestDefaultModules(t *testing.T) {
+	if got := strings.Join(Names(), ","); got != "appnexus,demo,pubmatic,rubicon" {
+		t.Errorf("unexpected default modules %s", got)
+	}
+	for _, m := range List() {
+		if m.Kind != KindAdapter {
+			continue
+		}
+		if _, ok := adapters.DefaultRegistry.Get(m.Name); !ok {
+			t.Errorf("expected adapter module %s to register itself", m.Name)
+		}
+	}
+	if _, ok := adapters.DefaultRegistry.Get("openx"); ok {
+		t.Error("expected opt-in adapter to stay out of the default build")
+	}
+}
+
+func TestListReturnsCopy(t *testing.T) {
+	list := List()
+	list[0].Name = "changed"
+	if List()[0].Name == "changed" {
+		t.Error("expected List to return a copy")
+	}
+}
@@ -9,6 +9,7 @@ type BidRequest struct {
 	Imp    []Imp           `json:"imp"`
 	Site   *Site           `json:"site,omitempty"`
 	App    *App            `json:"app,omitempty"`
+	DOOH   *DOOH           `json:"dooh,omitempty"` // Digital out-of-home placement (2.6)
 	Device *Device         `json:"device,omitempty"`
 	User   *User           `json:"user,omitempty"`
 	Test   int             `json:"test,omitempty"`
@@ -19,6 +20,8 @@ type BidRequest struct {
 	AllImp int             `json:"allimps,omitempty"`
 	Cur    []string        `json:"cur,omitempty"`    // Allowed currencies
 	WLang  []string        `json:"wlang,omitempty"`  // Allowed languages
+	WLangB []string        `json:"wlangb,omitempty"` // Allowed languages as BCP-47 tags (2.6)
+	CatTax int             `json:"cattax,omitempty"` // Taxonomy of bcat; 0 means the default, IAB 1.0 (2.6)
 	BCat   []string        `json:"bcat,omitempty"`   // Blocked categories
 	BAdv   []string        `json:"badv,omitempty"`   // Blocked advertisers
 	BApp   []string        `json:"bapp,omitempty"`   // Blocked apps
@@ -46,9 +49,36 @@ type Imp struct {
 	Secure            *int            `json:"secure,omitempty"`
 	IframeBuster      []string        `json:"iframebuster,omitempty"`
 	Exp               int             `json:"exp,omitempty"`
+	Rwdd              int             `json:"rwdd,omitempty"`    // Rewarded inventory (2.6)
+	SSAI              int             `json:"ssai,omitempty"`    // Server-side ad insertion: 1 client, 2 server, 3 both (2.6)
+	Qty               *Qty            `json:"qty,omitempty"`     // DOOH impression multiplier (2.6)
+	DT                float64         `json:"dt,omitempty"`      // Impression timestamp in ms since epoch (2.6)
+	Refresh           *Refresh        `json:"refresh,omitempty"` // Ad slot refresh settings (2.6)
 	Ext               json.RawMessage `json:"ext,omitempty"`
 }
 
+// Qty represents the number of people an impression is seen by (2.6)
+type Qty struct {
+	Multiplier float64         `json:"multiplier"`
+	SourceType int             `json:"sourcetype,omitempty"` // 1 measurement vendor, 2 publisher, 3 exchange
+	Vendor     string          `json:"vendor,omitempty"`
+	Ext        json.RawMessage `json:"ext,omitempty"`
+}
+
+// Refresh represents how an ad slot is refreshed (2.6)
+type Refresh struct {
+	RefSettings []RefSettings   `json:"refsettings,omitempty"`
+	Count       int             `json:"count,omitempty"` // Times the slot has refreshed since the page loaded
+	Ext         json.RawMessage `json:"ext,omitempty"`
+}
+
+// RefSettings represents one refresh trigger (2.6)
+type RefSettings struct {
+	RefType int             `json:"reftype,omitempty"` // 0 other, 1 user action, 2 event, 3 time
+	MinInt  int             `json:"minint,omitempty"`  // Minimum seconds between refreshes
+	Ext     json.RawMessage `json:"ext,omitempty"`
+}
+
 // Banner represents a banner impression
 type Banner struct {
 	Format   []Format        `json:"format,omitempty"`
@@ -90,7 +120,8 @@ type Video struct {
 	W              int             `json:"w,omitempty"`
 	H              int             `json:"h,omitempty"`
 	StartDelay     *int            `json:"startdelay,omitempty"`
-	Placement      int             `json:"placement,omitempty"`
+	Placement      int             `json:"placement,omitempty"` // Deprecated in 2.6 for plcmt
+	Plcmt          int             `json:"plcmt,omitempty"`     // Placement subtype: 1 instream, 2 accompanying, 3 interstitial, 4 standalone (2.6)
 	Linearity      int             `json:"linearity,omitempty"`
 	Skip           *int            `json:"skip,omitempty"`
 	SkipMin        int             `json:"skipmin,omitempty"`
@@ -108,6 +139,13 @@ type Video struct {
 	CompanionAd    []Banner        `json:"companionad,omitempty"`
 	API            []int           `json:"api,omitempty"`
 	CompanionType  []int           `json:"companiontype,omitempty"`
+	MaxSeq         int             `json:"maxseq,omitempty"`       // Max ads in a dynamic pod (2.6)
+	PodDur         int             `json:"poddur,omitempty"`       // Total seconds of a dynamic pod (2.6)
+	RqdDurs        []int           `json:"rqddurs,omitempty"`      // Exact durations allowed, instead of min/maxduration (2.6)
+	PodID          string          `json:"podid,omitempty"`        // Pod the impression belongs to (2.6)
+	PodSeq         int             `json:"podseq,omitempty"`       // Pod position in the content stream (2.6)
+	SlotInPod      int             `json:"slotinpod,omitempty"`    // Slot position in the pod (2.6)
+	MinCPMPerSec   float64         `json:"mincpmpersec,omitempty"` // Floor per second of a dynamic pod (2.6)
 	Ext            json.RawMessage `json:"ext,omitempty"`
 }
 
@@ -128,6 +166,12 @@ type Audio struct {
 	API           []int           `json:"api,omitempty"`
 	CompanionType []int           `json:"companiontype,omitempty"`
 	MaxSeq        int             `json:"maxseq,omitempty"`
+	PodDur        int             `json:"poddur,omitempty"`
+	RqdDurs       []int           `json:"rqddurs,omitempty"`
+	PodID         string          `json:"podid,omitempty"`
+	PodSeq        int             `json:"podseq,omitempty"`
+	SlotInPod     int             `json:"slotinpod,omitempty"`
+	MinCPMPerSec  float64         `json:"mincpmpersec,omitempty"`
 	Feed          int             `json:"feed,omitempty"`
 	Stitched      int             `json:"stitched,omitempty"`
 	NVol          int             `json:"nvol,omitempty"`
@@ -174,6 +218,7 @@ type Site struct {
 	ID            string          `json:"id,omitempty"`
 	Name          string          `json:"name,omitempty"`
 	Domain        string          `json:"domain,omitempty"`
+	CatTax        int             `json:"cattax,omitempty"` // Taxonomy of cat, sectioncat and pagecat (2.6)
 	Cat           []string        `json:"cat,omitempty"`
 	SectionCat    []string        `json:"sectioncat,omitempty"`
 	PageCat       []string        `json:"pagecat,omitempty"`
@@ -195,6 +240,7 @@ type App struct {
 	Bundle        string          `json:"bundle,omitempty"`
 	Domain        string          `json:"domain,omitempty"`
 	StoreURL      string          `json:"storeurl,omitempty"`
+	CatTax        int             `json:"cattax,omitempty"` // Taxonomy of cat, sectioncat and pagecat (2.6)
 	Cat           []string        `json:"cat,omitempty"`
 	SectionCat    []string        `json:"sectioncat,omitempty"`
 	PageCat       []string        `json:"pagecat,omitempty"`
@@ -207,10 +253,24 @@ type App struct {
 	Ext           json.RawMessage `json:"ext,omitempty"`
 }
 
+// DOOH represents a digital out-of-home placement, in place of site or app (2.6)
+type DOOH struct {
+	ID           string          `json:"id,omitempty"`
+	Name         string          `json:"name,omitempty"`
+	VenueType    []string        `json:"venuetype,omitempty"`
+	VenueTypeTax int             `json:"venuetypetax,omitempty"` // Taxonomy of venuetype; 0 means the default, OpenOOH 1.0
+	Publisher    *Publisher      `json:"publisher,omitempty"`
+	Domain       string          `json:"domain,omitempty"`
+	Keywords     string          `json:"keywords,omitempty"`
+	Content      *Content        `json:"content,omitempty"`
+	Ext          json.RawMessage `json:"ext,omitempty"`
+}
+
 // Publisher represents a publisher
 type Publisher struct {
 	ID     string          `json:"id,omitempty"`
 	Name   string          `json:"name,omitempty"`
+	CatTax int             `json:"cattax,omitempty"` // Taxonomy of cat (2.6)
 	Cat    []string        `json:"cat,omitempty"`
 	Domain string          `json:"domain,omitempty"`
 	Ext    json.RawMessage `json:"ext,omitempty"`
@@ -229,6 +289,7 @@ type Content struct {
 	ISRC               string          `json:"isrc,omitempty"`
 	Producer           *Producer       `json:"producer,omitempty"`
 	URL                string          `json:"url,omitempty"`
+	CatTax             int             `json:"cattax,omitempty"` // Taxonomy of cat (2.6)
 	Cat                []string        `json:"cat,omitempty"`
 	ProdQ              int             `json:"prodq,omitempty"`
 	VideoQuality       int             `json:"videoquality,omitempty"` // Deprecated
@@ -250,6 +311,7 @@ type Content struct {
 type Producer struct {
 	ID     string          `json:"id,omitempty"`
 	Name   string          `json:"name,omitempty"`
+	CatTax int             `json:"cattax,omitempty"` // Taxonomy of cat (2.6)
 	Cat    []string        `json:"cat,omitempty"`
 	Domain string          `json:"domain,omitempty"`
 	Ext    json.RawMessage `json:"ext,omitempty"`
@@ -362,22 +424,24 @@ type Source struct {
 	Ext    json.RawMessage `json:"ext,omitempty"`
 }
 
-// SupplyChain represents supply chain
+// SupplyChain represents supply chain. complete, nodes and ver are required,
+// and complete=0 is meaningful, so they are always written.
 type SupplyChain struct {
-	Complete int                `json:"complete,omitempty"`
-	Nodes    []SupplyChainNode  `json:"nodes,omitempty"`
-	Ver      string             `json:"ver,omitempty"`
-	Ext      json.RawMessage    `json:"ext,omitempty"`
+	Complete int               `json:"complete"`
+	Nodes    []SupplyChainNode `json:"nodes"`
+	Ver      string            `json:"ver"`
+	Ext      json.RawMessage   `json:"ext,omitempty"`
 }
 
-// SupplyChainNode represents a node in supply chain
+// SupplyChainNode represents a node in supply chain. asi, sid and hp are
+// required and always written.
 type SupplyChainNode struct {
-	ASI    string          `json:"asi,omitempty"`
-	SID    string          `json:"sid,omitempty"`
+	ASI    string          `json:"asi"`
+	SID    string          `json:"sid"`
 	RID    string          `json:"rid,omitempty"`
 	Name   string          `json:"name,omitempty"`
 	Domain string          `json:"domain,omitempty"`
-	HP     int             `json:"hp,omitempty"`
+	HP     int             `json:"hp"`
 	Ext    json.RawMessage `json:"ext,omitempty"`
 }
 
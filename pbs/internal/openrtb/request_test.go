@@ -2,7 +2,10 @@ package openrtb
 
 import (
 	"encoding/json"
+	"reflect"
 	"testing"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/jsoncodec"
 )
 
 func TestBidRequest_JSONRoundTrip(t *testing.T) {
@@ -735,6 +738,95 @@ func TestBidRequest_RealWorld(t *testing.T) {
 	}
 }
 
+func TestBidRequest_OpenRTB26Fields(t *testing.T) {
+	jsonStr := `{
+		"id": "req-26",
+		"imp": [{
+			"id": "1",
+			"ssai": 2,
+			"dt": 1700000000000,
+			"qty": {"multiplier": 12.5, "sourcetype": 1, "vendor": "measure.example"},
+			"refresh": {"refsettings": [{"reftype": 3, "minint": 30}], "count": 2},
+			"video": {
+				"mimes": ["video/mp4"],
+				"plcmt": 1,
+				"maxseq": 4,
+				"poddur": 60,
+				"rqddurs": [15, 30],
+				"podid": "pod-1",
+				"podseq": 1,
+				"mincpmpersec": 0.5
+			},
+			"audio": {"mimes": ["audio/mp4"], "poddur": 90, "rqddurs": [30], "podid": "pod-2", "podseq": -1, "slotinpod": 2}
+		}],
+		"dooh": {
+			"id": "screen-1",
+			"venuetype": ["transit.airports"],
+			"venuetypetax": 1,
+			"publisher": {"id": "pub-1", "cattax": 2, "cat": ["IAB1"]},
+			"content": {"id": "loop-1", "cattax": 6, "cat": ["150"]}
+		},
+		"cattax": 6,
+		"bcat": ["1"],
+		"wlangb": ["en-US"],
+		"source": {"schain": {"complete": 0, "ver": "1.0", "nodes": [{"asi": "pub.example", "sid": "1", "hp": 1}]}}
+	}`
+
+	var req BidRequest
+	if err := json.Unmarshal([]byte(jsonStr), &req); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+
+	imp := req.Imp[0]
+	if imp.SSAI != 2 || imp.DT != 1700000000000 || imp.Qty == nil || imp.Qty.Multiplier != 12.5 {
+		t.Errorf("imp 2.6 fields mismatch: %+v", imp)
+	}
+	if imp.Refresh == nil || imp.Refresh.Count != 2 || len(imp.Refresh.RefSettings) != 1 || imp.Refresh.RefSettings[0].MinInt != 30 {
+		t.Errorf("refresh mismatch: %+v", imp.Refresh)
+	}
+	video := imp.Video
+	if video.Plcmt != 1 || video.MaxSeq != 4 || video.PodDur != 60 || video.PodID != "pod-1" || video.PodSeq != 1 || video.MinCPMPerSec != 0.5 {
+		t.Errorf("video pod fields mismatch: %+v", video)
+	}
+	if !reflect.DeepEqual(video.RqdDurs, []int{15, 30}) {
+		t.Errorf("expected rqddurs [15 30], got %v", video.RqdDurs)
+	}
+	if imp.Audio.PodDur != 90 || imp.Audio.PodSeq != -1 || imp.Audio.SlotInPod != 2 {
+		t.Errorf("audio pod fields mismatch: %+v", imp.Audio)
+	}
+	if req.DOOH == nil || req.DOOH.VenueType[0] != "transit.airports" || req.DOOH.Publisher.CatTax != 2 || req.DOOH.Content.CatTax != 6 {
+		t.Errorf("dooh mismatch: %+v", req.DOOH)
+	}
+	if req.CatTax != 6 || req.WLangB[0] != "en-US" {
+		t.Errorf("expected cattax 6 and wlangb en-US, got %d %v", req.CatTax, req.WLangB)
+	}
+
+	// Marshalling writes back exactly what was read
+	data, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	var want, got map[string]interface{}
+	json.Unmarshal([]byte(jsonStr), &want)
+	json.Unmarshal(data, &got)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip mismatch:\n got %s\nwant %s", data, jsonStr)
+	}
+}
+
+func TestSupplyChain_RequiredFields(t *testing.T) {
+	data, err := json.Marshal(SupplyChain{Ver: "1.0", Nodes: []SupplyChainNode{{ASI: "pub.example", SID: "1"}}})
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+
+	// complete=0 and hp=0 carry meaning, so they are written rather than dropped
+	want := `{"complete":0,"nodes":[{"asi":"pub.example","sid":"1","hp":0}],"ver":"1.0"}`
+	if string(data) != want {
+		t.Errorf("expected %s, got %s", want, data)
+	}
+}
+
 // Benchmark JSON parsing
 func BenchmarkBidRequest_Unmarshal(b *testing.B) {
 	jsonStr := `{"id":"test-1","imp":[{"id":"imp-1","banner":{"w":300,"h":250}}],"site":{"domain":"example.com"}}`
@@ -761,3 +853,52 @@ func BenchmarkBidRequest_Marshal(b *testing.B) {
 		json.Marshal(req)
 	}
 }
+
+// benchRequestJSON is a typical multi-format request as sent to a bidder
+const benchRequestJSON = `{
+	"id": "80ce30c53c16e6ede735f123ef6e32361bfc7b22",
+	"imp": [
+		{"id": "1", "tagid": "top", "bidfloor": 0.5, "bidfloorcur": "USD", "secure": 1,
+		 "banner": {"format": [{"w": 300, "h": 250}, {"w": 300, "h": 600}], "pos": 1},
+		 "ext": {"prebid": {"bidder": {"appnexus": {"placementId": 13144370}}}, "gpid": "/1234/home/top"}},
+		{"id": "2", "bidfloor": 2.5, "secure": 1,
+		 "video": {"mimes": ["video/mp4", "video/webm"], "minduration": 5, "maxduration": 30, "protocols": [2, 3, 5, 6], "w": 640, "h": 480, "startdelay": 0, "plcmt": 1, "playbackmethod": [2], "api": [2, 7]},
+		 "ext": {"prebid": {"bidder": {"appnexus": {"placementId": 13144371}}}}}
+	],
+	"site": {"id": "102855", "domain": "www.example.com", "cat": ["IAB3-1"], "page": "https://www.example.com/articles/1234.html", "ref": "https://www.google.com/",
+		"publisher": {"id": "8953", "name": "example.com"}, "content": {"language": "en", "keywords": "sports,football"}},
+	"device": {"ua": "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+		"ip": "64.124.253.1", "devicetype": 2, "language": "en", "geo": {"country": "USA", "region": "CA", "city": "San Francisco", "type": 2}},
+	"user": {"id": "55816b39711f9b5acf3b90e313ed29e51665623f", "buyeruid": "8471295732", "consent": "CPXxRfAPXxRfAAfKABENB-CgAAAAAAAAAAYgAAAAAAAA",
+		"eids": [{"source": "id5-sync.com", "uids": [{"id": "ID5*abcdefghijklmnop", "atype": 1}]}, {"source": "liveramp.com", "uids": [{"id": "XY1000bIVBVah9ium-sZ3ykhPiXQbEcUpn4GjCtxrrw2BRDGM", "atype": 3}]}]},
+	"at": 1, "tmax": 800, "cur": ["USD"],
+	"source": {"tid": "2b8a7c3e-5f1d-4e9a-8b2c-7d6e5f4a3b2c", "schain": {"complete": 1, "ver": "1.0", "nodes": [{"asi": "example.com", "sid": "8953", "hp": 1}]}},
+	"regs": {"gdpr": 1, "us_privacy": "1YNN"},
+	"ext": {"prebid": {"targeting": {"includewinners": true, "includebidderkeys": true}, "cache": {"bids": {}}}}
+}`
+
+// Compare encoding/json with jsoniter:
+//
+//	go test -run '^$' -bench Codec -benchmem ./internal/openrtb/
+//	go test -run '^$' -bench Codec -benchmem -tags jsoniter ./internal/openrtb/
+func BenchmarkBidRequest_Codec(b *testing.B) {
+	var req BidRequest
+	if err := jsoncodec.Unmarshal([]byte(benchRequestJSON), &req); err != nil {
+		b.Fatal(err)
+	}
+	data, _ := jsoncodec.Marshal(&req)
+
+	b.Run(jsoncodec.Name()+"/marshal", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			jsoncodec.Marshal(&req)
+		}
+	})
+	b.Run(jsoncodec.Name()+"/unmarshal", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var decoded BidRequest
+			jsoncodec.Unmarshal(data, &decoded)
+		}
+	})
+}
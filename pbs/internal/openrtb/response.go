@@ -37,18 +37,24 @@ type Bid struct {
 	CID            string          `json:"cid,omitempty"`
 	CRID           string          `json:"crid,omitempty"`
 	Tactic         string          `json:"tactic,omitempty"`
+	CatTax         int             `json:"cattax,omitempty"` // Taxonomy of cat (2.6)
 	Cat            []string        `json:"cat,omitempty"`
 	Attr           []int           `json:"attr,omitempty"`
-	API            int             `json:"api,omitempty"`
+	APIs           []int           `json:"apis,omitempty"` // Supersedes api (2.6)
+	API            int             `json:"api,omitempty"`  // Deprecated in 2.6 for apis
 	Protocol       int             `json:"protocol,omitempty"`
 	QAGMediaRating int             `json:"qagmediarating,omitempty"`
 	Language       string          `json:"language,omitempty"`
+	LangB          string          `json:"langb,omitempty"` // Language as a BCP-47 tag (2.6)
 	DealID         string          `json:"dealid,omitempty"`
 	W              int             `json:"w,omitempty"`
 	H              int             `json:"h,omitempty"`
 	WRatio         int             `json:"wratio,omitempty"`
 	HRatio         int             `json:"hratio,omitempty"`
 	Exp            int             `json:"exp,omitempty"`
+	Dur            int             `json:"dur,omitempty"`       // Video/audio creative duration in seconds (2.6)
+	SlotInPod      int             `json:"slotinpod,omitempty"` // Pod slot the bid is for: 1 first, -1 last, 2 first or last (2.6)
+	MType          int             `json:"mtype,omitempty"`     // Markup type: 1 banner, 2 video, 3 audio, 4 native (2.6)
 	Ext            json.RawMessage `json:"ext,omitempty"`
 }
 
@@ -75,7 +81,7 @@ const (
 
 	// Exchange-specific codes (500+)
 	NoBidNoBiddersAvailable NoBidReason = 500 // No bidders configured or available
-	NoBidTimeout            NoBidReason = 501 // Request processing timed out
+	NoBidTimeout            NoBidReason = 501 // Request processing timed out, or refused while the server drains
 )
 
 // BidResponseExt represents PBS-specific response extensions
@@ -85,6 +91,47 @@ type BidResponseExt struct {
 	Warnings           map[string][]ExtBidderMessage `json:"warnings,omitempty"`
 	TMMaxRequest       int               `json:"tmaxrequest,omitempty"`
 	Prebid             *ExtBidResponsePrebid `json:"prebid,omitempty"`
+	Debug              *ExtResponseDebug     `json:"debug,omitempty"`
+	ErrorCounts        map[string]ExtBidderErrorCounts `json:"errorcounts,omitempty"` // Per bidder, only bidders with errors
+}
+
+// ExtBidderErrorCounts separates a bidder's errors by cause: input (our
+// request to the bidder was bad), transport (the bidder was unreachable or
+// too slow) and response (the bidder's answer was unusable)
+type ExtBidderErrorCounts struct {
+	Input     int `json:"input,omitempty"`
+	Transport int `json:"transport,omitempty"`
+	Response  int `json:"response,omitempty"`
+}
+
+// ExtResponseDebug carries auction internals returned only in debug mode
+type ExtResponseDebug struct {
+	// BidLandscape lists every bid per imp ID: valid bids ranked by price, then rejected bids
+	BidLandscape map[string][]ExtLandscapeBid `json:"bidlandscape,omitempty"`
+	// HTTPCalls lists each bidder's outgoing requests and their responses
+	HTTPCalls map[string][]ExtHTTPCall `json:"httpcalls,omitempty"`
+}
+
+// ExtHTTPCall is one request sent to a bidder, as Prebid Server reports it in
+// ext.debug.httpcalls. Credentials in the headers are redacted.
+type ExtHTTPCall struct {
+	URI            string              `json:"uri"`
+	RequestBody    string              `json:"requestbody"`
+	RequestHeaders map[string][]string `json:"requestheaders,omitempty"`
+	ResponseBody   string              `json:"responsebody"`
+	Status         int                 `json:"status"` // 0 when the bidder didn't answer
+}
+
+// ExtLandscapeBid describes one bid's fate in the auction
+type ExtLandscapeBid struct {
+	Bidder        string  `json:"bidder"`
+	BidID         string  `json:"bidid"`
+	Price         float64 `json:"price"`                   // As submitted by the bidder
+	AdjustedPrice float64 `json:"adjustedprice,omitempty"` // After auction logic (e.g. second-price clearing); omitted for rejected bids
+	Rank          int     `json:"rank,omitempty"`          // 1 = highest valid bid; omitted for rejected bids
+	Status        string  `json:"status"`                  // won, lost or rejected
+	Reason        string  `json:"reason,omitempty"`        // Why the bid was rejected
+	DealID        string  `json:"dealid,omitempty"`
 }
 
 // ExtBidderMessage represents bidder message
@@ -97,11 +144,37 @@ type ExtBidderMessage struct {
 type ExtBidResponsePrebid struct {
 	AuctionTimestamp int64                     `json:"auctiontimestamp,omitempty"`
 	Passthrough      json.RawMessage           `json:"passthrough,omitempty"`
+	Privacy          json.RawMessage           `json:"privacy,omitempty"` // Debug only: privacy signals, evaluations and enforcement decisions
+	Diagnostics      *ExtNoBidDiagnostics      `json:"diagnostics,omitempty"` // Diagnostics mode only, on auctions with no bids
+}
+
+// ExtNoBidDiagnostics explains why an auction returned no bids
+type ExtNoBidDiagnostics struct {
+	Reason   string                         `json:"reason"`             // Auction-level cause, e.g. no_bids or bids_rejected
+	Eligible []string                       `json:"eligible"`           // Enabled bidders the auction could call
+	Excluded []ExtExcludedBidder            `json:"excluded,omitempty"` // Eligible bidders that were not called
+	Bidders  map[string]ExtBidderDiagnostic `json:"bidders,omitempty"`  // Outcome of each bidder called
+	Privacy  json.RawMessage                `json:"privacy,omitempty"`  // Privacy enforcement decisions taken on the request
+}
+
+// ExtExcludedBidder is an eligible bidder the auction did not call
+type ExtExcludedBidder struct {
+	Bidder string `json:"bidder"`
+	Stage  string `json:"stage"` // idr, rollout or capability
+	Reason string `json:"reason,omitempty"`
+}
+
+// ExtBidderDiagnostic is a called bidder's outcome
+type ExtBidderDiagnostic struct {
+	Outcome string   `json:"outcome"`           // no_bid, timeout, cancelled, error, rejected or not_won
+	Reasons []string `json:"reasons,omitempty"` // Rejected bids' reasons, or error categories
 }
 
 // BidExt represents bid extension
 type BidExt struct {
-	Prebid *ExtBidPrebid `json:"prebid,omitempty"`
+	Prebid     *ExtBidPrebid `json:"prebid,omitempty"`
+	OrigBidCPM float64       `json:"origbidcpm,omitempty"` // Price before currency conversion
+	OrigBidCur string        `json:"origbidcur,omitempty"` // Currency the bidder bid in
 }
 
 // ExtBidPrebid represents prebid bid extension
@@ -112,6 +185,8 @@ type ExtBidPrebid struct {
 	Video       *ExtBidPrebidVideo `json:"video,omitempty"`
 	Events      *ExtBidPrebidEvents `json:"events,omitempty"`
 	Meta        *ExtBidPrebidMeta  `json:"meta,omitempty"`
+	Passthrough json.RawMessage    `json:"passthrough,omitempty"` // Echo of the imp's ext.prebid.passthrough
+	TargetBidderCode string        `json:"targetbiddercode,omitempty"` // Multibid code of a seat's extra bid, which its targeting keys are suffixed with
 }
 
 // ExtBidPrebidCache represents cache info
@@ -2,7 +2,11 @@ package openrtb
 
 import (
 	"encoding/json"
+	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/jsoncodec"
 )
 
 func TestBidResponse_JSONRoundTrip(t *testing.T) {
@@ -237,6 +241,32 @@ func TestBid_AllFields(t *testing.T) {
 	}
 }
 
+func TestBid_OpenRTB26Fields(t *testing.T) {
+	jsonStr := `{"id":"bid-26","impid":"1","price":1.5,"cattax":6,"cat":["150"],"apis":[7],"langb":"en-US","dur":30,"slotinpod":-1,"mtype":2}`
+
+	var bid Bid
+	if err := json.Unmarshal([]byte(jsonStr), &bid); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if bid.CatTax != 6 || len(bid.APIs) != 1 || bid.APIs[0] != 7 || bid.LangB != "en-US" {
+		t.Errorf("bid 2.6 fields mismatch: %+v", bid)
+	}
+	if bid.Dur != 30 || bid.SlotInPod != -1 || bid.MType != 2 {
+		t.Errorf("bid pod fields mismatch: %+v", bid)
+	}
+
+	data, err := json.Marshal(bid)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	var want, got map[string]interface{}
+	json.Unmarshal([]byte(jsonStr), &want)
+	json.Unmarshal(data, &got)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip mismatch:\n got %s\nwant %s", data, jsonStr)
+	}
+}
+
 func TestBid_MinimalValid(t *testing.T) {
 	bid := Bid{
 		ID:    "bid-min",
@@ -743,3 +773,19 @@ func BenchmarkBid_Marshal(b *testing.B) {
 		_, _ = json.Marshal(bid)
 	}
 }
+
+// BenchmarkBidResponse_Codec parses a bidder response with ten bids; see
+// BenchmarkBidRequest_Codec
+func BenchmarkBidResponse_Codec(b *testing.B) {
+	bid := `{"id":"bid-1","impid":"1","price":1.23,"adm":"<div><script src=\"https://cdn.example/ad.js\"></script></div>","adomain":["advertiser.com"],"crid":"creative-1","w":300,"h":250,"mtype":1,"ext":{"prebid":{"type":"banner"}}}`
+	bids := strings.TrimSuffix(strings.Repeat(bid+",", 10), ",")
+	data := []byte(`{"id":"resp-1","cur":"USD","seatbid":[{"seat":"appnexus","bid":[` + bids + `]}]}`)
+
+	b.Run(jsoncodec.Name()+"/unmarshal", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var resp BidResponse
+			jsoncodec.Unmarshal(data, &resp)
+		}
+	})
+}
@@ -0,0 +1,339 @@
+// Package probe periodically checks each enabled bidder's endpoint and keeps
+// an availability history suitable for powering a public status page
+package probe
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters/ortb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/logger"
+)
+
+// Default probing settings
+const (
+	DefaultInterval    = 60 * time.Second
+	DefaultTimeout     = 5 * time.Second
+	DefaultHistory     = 60 // Checks kept per bidder; an hour at the default interval
+	DefaultConcurrency = 8
+)
+
+// Availability statuses
+const (
+	StatusUp       = "up"       // Endpoint answered as expected
+	StatusDegraded = "degraded" // Endpoint answered with a server error, or rejected the test bid
+	StatusDown     = "down"     // No answer: connection failure or timeout
+	StatusUnknown  = "unknown"  // Not probed yet
+)
+
+// Config controls the background prober
+type Config struct {
+	Enabled     bool
+	Interval    time.Duration // Time between probe rounds
+	Timeout     time.Duration // Per-check timeout
+	History     int           // Checks kept per bidder
+	Concurrency int           // Checks in flight at once
+}
+
+// DefaultConfig returns a disabled prober with default settings. Probing sends
+// traffic to partners, so it must be switched on explicitly.
+func DefaultConfig() *Config {
+	return &Config{
+		Interval:    DefaultInterval,
+		Timeout:     DefaultTimeout,
+		History:     DefaultHistory,
+		Concurrency: DefaultConcurrency,
+	}
+}
+
+// Target is one bidder endpoint check
+type Target struct {
+	Bidder  string
+	Request *adapters.RequestData
+}
+
+// TargetSource lists the bidders to probe; it is called at the start of every round
+type TargetSource func() []Target
+
+// Check is the outcome of a single probe. Error details are logged but never
+// exposed, since transport errors carry partner endpoint URLs.
+type Check struct {
+	Time       time.Time `json:"time"`
+	Status     string    `json:"status"`
+	LatencyMs  int64     `json:"latency_ms"`
+	StatusCode int       `json:"status_code,omitempty"`
+}
+
+// BidderStatus is one bidder's current availability and recent history
+type BidderStatus struct {
+	Status      string     `json:"status"`
+	LastChecked *time.Time `json:"last_checked,omitempty"`
+	Since       *time.Time `json:"since,omitempty"` // When the current status began, within the history
+	Uptime      float64    `json:"uptime"`          // Share of checks in the history that were up
+	LatencyMs   int64      `json:"latency_ms"`      // Latency of the last check
+	History     []Check    `json:"history"`         // Oldest first
+}
+
+// Report is the /info/status/bidders response
+type Report struct {
+	Enabled         bool                    `json:"enabled"`
+	IntervalSeconds int                     `json:"interval_seconds"`
+	GeneratedAt     time.Time               `json:"generated_at"`
+	Bidders         map[string]BidderStatus `json:"bidders"`
+}
+
+// Prober checks bidder endpoints on an interval and serves their availability
+type Prober struct {
+	config  *Config
+	targets TargetSource
+	client  *http.Client
+
+	mu      sync.RWMutex
+	history map[string][]Check
+
+	stopChan chan struct{}
+	stopOnce sync.Once
+}
+
+// New creates a prober over targets
+func New(config *Config, targets TargetSource) *Prober {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	return &Prober{
+		config:  config,
+		targets: targets,
+		client: &http.Client{
+			Timeout: config.Timeout,
+			// A redirect still proves the endpoint answers
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		history:  make(map[string][]Check),
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start runs a probe round immediately and then every Interval. No-op when disabled.
+func (p *Prober) Start(ctx context.Context) {
+	if !p.config.Enabled || p.config.Interval <= 0 {
+		return
+	}
+	go p.loop(ctx)
+}
+
+// Stop stops background probing
+func (p *Prober) Stop() {
+	p.stopOnce.Do(func() { close(p.stopChan) })
+}
+
+func (p *Prober) loop(ctx context.Context) {
+	p.ProbeAll(ctx)
+
+	ticker := time.NewTicker(p.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.ProbeAll(ctx)
+		case <-p.stopChan:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// ProbeAll checks every target once and records the results. Bidders no
+// longer listed by the target source are dropped from the history.
+func (p *Prober) ProbeAll(ctx context.Context) {
+	targets := p.targets()
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, max(1, p.config.Concurrency))
+	for _, t := range targets {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(t Target) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			p.record(t.Bidder, p.check(ctx, t))
+		}(t)
+	}
+	wg.Wait()
+
+	listed := make(map[string]bool, len(targets))
+	for _, t := range targets {
+		listed[t.Bidder] = true
+	}
+	p.mu.Lock()
+	for bidder := range p.history {
+		if !listed[bidder] {
+			delete(p.history, bidder)
+		}
+	}
+	p.mu.Unlock()
+}
+
+// check performs one probe request
+func (p *Prober) check(ctx context.Context, t Target) Check {
+	started := time.Now()
+	result := Check{Time: started, Status: StatusDown}
+
+	req, err := http.NewRequestWithContext(ctx, t.Request.Method, t.Request.URI, bytes.NewReader(t.Request.Body))
+	if err != nil {
+		logger.Log.Warn().Err(err).Str("bidder", t.Bidder).Msg("Invalid bidder probe request")
+		return result
+	}
+	for k, v := range t.Request.Headers {
+		req.Header[k] = v
+	}
+
+	resp, err := p.client.Do(req)
+	result.LatencyMs = time.Since(started).Milliseconds()
+	if err != nil {
+		logger.Log.Debug().Err(err).Str("bidder", t.Bidder).Msg("Bidder probe failed")
+		return result
+	}
+	resp.Body.Close()
+
+	result.StatusCode = resp.StatusCode
+	result.Status = classify(t.Request.Method, resp.StatusCode)
+	return result
+}
+
+// classify maps a probe response to a status. Any answer below 500 shows a
+// HEAD/OPTIONS endpoint is reachable (many bidders 404 or 405 such requests);
+// a test bid must be accepted with 200 or 204.
+func classify(method string, statusCode int) string {
+	if method == http.MethodPost {
+		if statusCode == http.StatusOK || statusCode == http.StatusNoContent {
+			return StatusUp
+		}
+		return StatusDegraded
+	}
+	if statusCode >= 500 {
+		return StatusDegraded
+	}
+	return StatusUp
+}
+
+// record appends a check to the bidder's history
+func (p *Prober) record(bidder string, c Check) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	h := append(p.history[bidder], c)
+	if limit := max(1, p.config.History); len(h) > limit {
+		h = h[len(h)-limit:]
+	}
+	p.history[bidder] = h
+}
+
+// Report snapshots availability for every probed bidder
+func (p *Prober) Report() Report {
+	report := Report{
+		Enabled:         p.config.Enabled,
+		IntervalSeconds: int(p.config.Interval.Seconds()),
+		GeneratedAt:     time.Now().UTC(),
+		Bidders:         make(map[string]BidderStatus),
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for bidder, h := range p.history {
+		report.Bidders[bidder] = summarize(h)
+	}
+	return report
+}
+
+// summarize derives a bidder's status from its history (oldest first)
+func summarize(h []Check) BidderStatus {
+	status := BidderStatus{Status: StatusUnknown, History: append([]Check(nil), h...)}
+	if len(h) == 0 {
+		return status
+	}
+
+	last := h[len(h)-1]
+	status.Status = last.Status
+	status.LastChecked = &last.Time
+	status.LatencyMs = last.LatencyMs
+
+	up := 0
+	for _, c := range h {
+		if c.Status == StatusUp {
+			up++
+		}
+	}
+	status.Uptime = float64(up) / float64(len(h))
+
+	since := h[0].Time
+	for i := len(h) - 1; i > 0; i-- {
+		if h[i-1].Status != last.Status {
+			since = h[i].Time
+			break
+		}
+	}
+	status.Since = &since
+	return status
+}
+
+// ServeHTTP serves the availability report as JSON
+func (p *Prober) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=30")
+	if err := json.NewEncoder(w).Encode(p.Report()); err != nil {
+		logger.Log.Error().Err(err).Msg("failed to encode bidder status")
+	}
+}
+
+// RegistryTargets probes the enabled static bidders with HEAD and the enabled
+// dynamic bidders as their config's probe mode asks. Endpoints that are
+// templates (filled per request) can't be probed and are skipped. dynamic may be nil.
+func RegistryTargets(static *adapters.Registry, dynamic *ortb.DynamicRegistry) TargetSource {
+	return func() []Target {
+		var targets []Target
+		seen := make(map[string]bool)
+
+		if dynamic != nil {
+			for _, adapter := range dynamic.GetEnabled() {
+				code := adapter.GetConfig().BidderCode
+				seen[code] = true
+				if req := adapter.ProbeRequest(); req != nil && probeable(req.URI) {
+					targets = append(targets, Target{Bidder: code, Request: req})
+				}
+			}
+		}
+
+		if static != nil {
+			for _, code := range static.ListEnabledBidders() {
+				awi, ok := static.Get(code)
+				if !ok || seen[code] || !probeable(awi.Info.Endpoint) {
+					continue
+				}
+				targets = append(targets, Target{
+					Bidder:  code,
+					Request: &adapters.RequestData{Method: http.MethodHead, URI: awi.Info.Endpoint},
+				})
+			}
+		}
+
+		sort.Slice(targets, func(i, j int) bool { return targets[i].Bidder < targets[j].Bidder })
+		return targets
+	}
+}
+
+// probeable reports whether endpoint is a concrete http(s) URL
+func probeable(endpoint string) bool {
+	return (strings.HasPrefix(endpoint, "https://") || strings.HasPrefix(endpoint, "http://")) &&
+		!strings.Contains(endpoint, "{{")
+}
@@ -0,0 +1,191 @@
+package probe
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters/ortb"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+type noopAdapter struct{}
+
+func (noopAdapter) MakeRequests(*openrtb.BidRequest, *adapters.ExtraRequestInfo) ([]*adapters.RequestData, []error) {
+	return nil, nil
+}
+
+func (noopAdapter) MakeBids(*openrtb.BidRequest, *adapters.ResponseData) (*adapters.BidderResponse, []error) {
+	return nil, nil
+}
+
+type fakeBidderStore struct {
+	configs map[string]string
+}
+
+func (f *fakeBidderStore) HGetAll(context.Context, string) (map[string]string, error) {
+	return f.configs, nil
+}
+
+func (f *fakeBidderStore) SMembers(context.Context, string) ([]string, error) { return nil, nil }
+
+func (f *fakeBidderStore) HGet(context.Context, string, string) (string, error) { return "", nil }
+
+func staticTargets(targets ...Target) TargetSource {
+	return func() []Target { return targets }
+}
+
+func TestProber_ProbeAll(t *testing.T) {
+	var gotAuth, gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/head-rejected":
+			w.WriteHeader(http.StatusMethodNotAllowed) // Reachable, just no HEAD support
+		case "/failing":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/test-bid":
+			gotAuth = r.Header.Get("Authorization")
+			body, _ := io.ReadAll(r.Body)
+			gotBody = string(body)
+			w.WriteHeader(http.StatusNoContent)
+		case "/test-bid-rejected":
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	p := New(&Config{Timeout: time.Second, History: 10, Concurrency: 2}, staticTargets(
+		Target{Bidder: "head", Request: &adapters.RequestData{Method: http.MethodHead, URI: server.URL + "/head-rejected"}},
+		Target{Bidder: "failing", Request: &adapters.RequestData{Method: http.MethodHead, URI: server.URL + "/failing"}},
+		Target{Bidder: "unreachable", Request: &adapters.RequestData{Method: http.MethodHead, URI: closed.URL}},
+		Target{Bidder: "testbid", Request: &adapters.RequestData{
+			Method:  http.MethodPost,
+			URI:     server.URL + "/test-bid",
+			Body:    []byte(`{"id":"availability-probe","test":1}`),
+			Headers: http.Header{"Authorization": []string{"Bearer secret"}},
+		}},
+		Target{Bidder: "testbid-rejected", Request: &adapters.RequestData{Method: http.MethodPost, URI: server.URL + "/test-bid-rejected"}},
+	))
+	p.ProbeAll(context.Background())
+
+	want := map[string]string{
+		"head":             StatusUp,
+		"failing":          StatusDegraded,
+		"unreachable":      StatusDown,
+		"testbid":          StatusUp,
+		"testbid-rejected": StatusDegraded,
+	}
+	report := p.Report()
+	for bidder, status := range want {
+		got := report.Bidders[bidder]
+		if got.Status != status || len(got.History) != 1 || got.LastChecked == nil {
+			t.Errorf("%s: expected %s with one check, got %+v", bidder, status, got)
+		}
+	}
+	if report.Bidders["failing"].History[0].StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected status code recorded, got %+v", report.Bidders["failing"].History[0])
+	}
+	if gotAuth != "Bearer secret" || gotBody != `{"id":"availability-probe","test":1}` {
+		t.Errorf("expected test bid with auth header, got %q / %q", gotAuth, gotBody)
+	}
+}
+
+func TestProber_HistoryAndUptime(t *testing.T) {
+	p := New(&Config{History: 4}, staticTargets())
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, status := range []string{StatusDown, StatusUp, StatusDown, StatusUp, StatusUp} {
+		p.record("bidder", Check{Time: start.Add(time.Duration(i) * time.Minute), Status: status, LatencyMs: int64(10 * i)})
+	}
+
+	got := p.Report().Bidders["bidder"]
+	if len(got.History) != 4 || !got.History[0].Time.Equal(start.Add(time.Minute)) {
+		t.Fatalf("expected the 4 most recent checks, got %+v", got.History)
+	}
+	if got.Status != StatusUp || got.Uptime != 0.75 || got.LatencyMs != 40 {
+		t.Errorf("unexpected summary %+v", got)
+	}
+	if !got.Since.Equal(start.Add(3 * time.Minute)) {
+		t.Errorf("expected up since the 4th check, got %v", got.Since)
+	}
+}
+
+func TestProber_DropsUnlistedBidders(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	targets := []Target{
+		{Bidder: "kept", Request: &adapters.RequestData{Method: http.MethodHead, URI: server.URL}},
+		{Bidder: "removed", Request: &adapters.RequestData{Method: http.MethodHead, URI: server.URL}},
+	}
+	p := New(&Config{Timeout: time.Second, History: 5}, func() []Target { return targets })
+	p.ProbeAll(context.Background())
+
+	targets = targets[:1]
+	p.ProbeAll(context.Background())
+
+	report := p.Report()
+	if _, ok := report.Bidders["removed"]; ok || len(report.Bidders["kept"].History) != 2 {
+		t.Errorf("expected only the listed bidder kept, got %+v", report.Bidders)
+	}
+}
+
+func TestProber_ServeHTTP(t *testing.T) {
+	config := DefaultConfig()
+	config.Enabled = true
+	p := New(config, staticTargets())
+	p.record("bidder", Check{Time: time.Now(), Status: StatusUp})
+
+	rr := httptest.NewRecorder()
+	p.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/info/status/bidders", nil))
+	var report Report
+	if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if !report.Enabled || report.IntervalSeconds != 60 || report.Bidders["bidder"].Status != StatusUp {
+		t.Errorf("unexpected report %+v", report)
+	}
+
+	rr = httptest.NewRecorder()
+	p.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/info/status/bidders", nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for POST, got %d", rr.Code)
+	}
+}
+
+func TestRegistryTargets(t *testing.T) {
+	static := adapters.NewRegistry()
+	static.Register("plain", noopAdapter{}, adapters.BidderInfo{Enabled: true, Endpoint: "https://plain.example/bid"})
+	static.Register("templated", noopAdapter{}, adapters.BidderInfo{Enabled: true, Endpoint: "https://{{.Host}}/bid"})
+	static.Register("disabled", noopAdapter{}, adapters.BidderInfo{Enabled: false, Endpoint: "https://disabled.example/bid"})
+
+	store := &fakeBidderStore{configs: map[string]string{
+		"dyn":    `{"bidder_code":"dyn","status":"active","probe":"test_bid","endpoint":{"url":"https://dyn.example/bid"}}`,
+		"silent": `{"bidder_code":"silent","status":"active","probe":"none","endpoint":{"url":"https://silent.example/bid"}}`,
+	}}
+	dynamic := ortb.NewDynamicRegistry(store, time.Minute)
+	if err := dynamic.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	targets := RegistryTargets(static, dynamic)()
+	if len(targets) != 2 {
+		t.Fatalf("expected dyn and plain, got %+v", targets)
+	}
+	if targets[0].Bidder != "dyn" || targets[0].Request.Method != http.MethodPost {
+		t.Errorf("expected dynamic test bid probe, got %+v", targets[0])
+	}
+	if targets[1].Bidder != "plain" || targets[1].Request.Method != http.MethodHead {
+		t.Errorf("expected static HEAD probe, got %+v", targets[1])
+	}
+
+	if targets := RegistryTargets(static, nil)(); len(targets) != 1 {
+		t.Errorf("expected static targets without a dynamic registry, got %+v", targets)
+	}
+}
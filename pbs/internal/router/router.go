@@ -0,0 +1,147 @@
+// Package router composes the server's HTTP routes. Every request passes
+// through the router-wide middleware; each route adds only the middleware
+// that applies to it (auth on admin routes, privacy on auction routes, and so
+// on), so middleware no longer needs to match paths itself. Patterns use
+// net/http's syntax, including methods and {name} path parameters read with
+// r.PathValue, and requests are measured per route pattern rather than per
+// raw path.
+package router
+
+import (
+	"net/http"
+	"sort"
+	"time"
+)
+
+// Middleware wraps a handler
+type Middleware func(http.Handler) http.Handler
+
+// Metrics records per-route request metrics. route is the registered pattern,
+// so path parameters don't multiply label values.
+type Metrics interface {
+	RecordRoute(route, method string, status int, duration time.Duration)
+}
+
+// Router dispatches requests to routes by pattern
+type Router struct {
+	mux     *http.ServeMux
+	global  []Middleware
+	handler http.Handler // mux wrapped in the router-wide middleware
+	metrics Metrics
+	routes  []string
+}
+
+// New creates an empty router
+func New() *Router {
+	mux := http.NewServeMux()
+	return &Router{mux: mux, handler: mux}
+}
+
+// SetMetrics sets the route metrics recorder. Call before serving.
+func (rt *Router) SetMetrics(m Metrics) {
+	rt.metrics = m
+}
+
+// Use adds router-wide middleware, which also runs for requests that match no
+// route. Middleware added first is outermost.
+func (rt *Router) Use(mw ...Middleware) {
+	rt.global = append(rt.global, mw...)
+	rt.handler = chain(rt.mux, rt.global)
+}
+
+// Handle registers a route with its own middleware, the first outermost.
+// It panics if the pattern is invalid or already registered, as
+// http.ServeMux does.
+func (rt *Router) Handle(pattern string, h http.Handler, mw ...Middleware) {
+	rt.mux.Handle(pattern, rt.measure(pattern, chain(h, mw)))
+	rt.routes = append(rt.routes, pattern)
+}
+
+// HandleFunc registers a handler function as a route
+func (rt *Router) HandleFunc(pattern string, fn http.HandlerFunc, mw ...Middleware) {
+	rt.Handle(pattern, fn, mw...)
+}
+
+// Group returns a group whose routes all run mw ahead of their own middleware
+func (rt *Router) Group(mw ...Middleware) *Group {
+	return &Group{router: rt, mw: mw}
+}
+
+// Routes returns the registered patterns, sorted
+func (rt *Router) Routes() []string {
+	routes := append([]string(nil), rt.routes...)
+	sort.Strings(routes)
+	return routes
+}
+
+// ServeHTTP implements http.Handler
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rt.handler.ServeHTTP(w, r)
+}
+
+// measure records the route's metrics around h
+func (rt *Router) measure(route string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m := rt.metrics
+		if m == nil {
+			h.ServeHTTP(w, r)
+			return
+		}
+		start := time.Now()
+		wrapped := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		h.ServeHTTP(wrapped, r)
+		m.RecordRoute(route, r.Method, wrapped.status, time.Since(start))
+	})
+}
+
+// Group is a set of routes sharing middleware
+type Group struct {
+	router *Router
+	mw     []Middleware
+}
+
+// Handle registers a route in the group; mw runs inside the group's middleware
+func (g *Group) Handle(pattern string, h http.Handler, mw ...Middleware) {
+	g.router.Handle(pattern, h, g.with(mw)...)
+}
+
+// HandleFunc registers a handler function as a route in the group
+func (g *Group) HandleFunc(pattern string, fn http.HandlerFunc, mw ...Middleware) {
+	g.Handle(pattern, fn, mw...)
+}
+
+// Group returns a subgroup that adds mw inside this group's middleware
+func (g *Group) Group(mw ...Middleware) *Group {
+	return &Group{router: g.router, mw: g.with(mw)}
+}
+
+// with returns the group's middleware followed by mw, without aliasing either
+func (g *Group) with(mw []Middleware) []Middleware {
+	all := make([]Middleware, 0, len(g.mw)+len(mw))
+	all = append(all, g.mw...)
+	return append(all, mw...)
+}
+
+// chain wraps h so that mw[0] is outermost
+func chain(h http.Handler, mw []Middleware) http.Handler {
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i](h)
+	}
+	return h
+}
+
+// statusWriter captures the response status for metrics
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sw *statusWriter) WriteHeader(code int) {
+	sw.status = code
+	sw.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (sw *statusWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
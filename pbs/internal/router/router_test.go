@@ -0,0 +1,121 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// tag returns middleware that appends name to the X-Chain response header
+func tag(name string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("X-Chain", name)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type recordedRoute struct {
+	route, method string
+	status        int
+}
+
+type mockMetrics struct {
+	mu     sync.Mutex
+	routes []recordedRoute
+}
+
+func (m *mockMetrics) RecordRoute(route, method string, status int, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.routes = append(m.routes, recordedRoute{route, method, status})
+}
+
+func serve(rt *Router, method, target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+	return w
+}
+
+func TestRouter_MiddlewareComposition(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	rt := New()
+	rt.Use(tag("global1"))
+	rt.Use(tag("global2"))
+	admin := rt.Group(tag("admin"))
+	admin.Handle("/admin/flags", ok, tag("route"))
+	admin.Group(tag("nested")).Handle("/admin/nested", ok)
+	rt.Handle("/health", ok)
+
+	tests := []struct {
+		target string
+		want   []string
+	}{
+		{"/admin/flags", []string{"global1", "global2", "admin", "route"}},
+		{"/admin/nested", []string{"global1", "global2", "admin", "nested"}},
+		{"/health", []string{"global1", "global2"}},
+		{"/unknown", []string{"global1", "global2"}},
+	}
+	for _, tt := range tests {
+		if got := serve(rt, http.MethodGet, tt.target).Header().Values("X-Chain"); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: expected chain %v, got %v", tt.target, tt.want, got)
+		}
+	}
+	if w := serve(rt, http.MethodGet, "/unknown"); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unmatched path, got %d", w.Code)
+	}
+}
+
+func TestRouter_PathParams(t *testing.T) {
+	rt := New()
+	rt.HandleFunc("GET /info/bidders/{name}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.PathValue("name")))
+	})
+
+	if w := serve(rt, http.MethodGet, "/info/bidders/appnexus"); w.Body.String() != "appnexus" {
+		t.Errorf("expected the path parameter, got %q", w.Body.String())
+	}
+	if w := serve(rt, http.MethodPost, "/info/bidders/appnexus"); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for the wrong method, got %d", w.Code)
+	}
+}
+
+func TestRouter_RouteMetrics(t *testing.T) {
+	m := &mockMetrics{}
+	rt := New()
+	rt.SetMetrics(m)
+	rt.HandleFunc("GET /admin/bidders/{code}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	rt.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {})
+
+	serve(rt, http.MethodGet, "/admin/bidders/a")
+	serve(rt, http.MethodGet, "/admin/bidders/b")
+	serve(rt, http.MethodGet, "/status")
+	serve(rt, http.MethodGet, "/unmatched")
+
+	want := []recordedRoute{
+		{"GET /admin/bidders/{code}", http.MethodGet, http.StatusNotFound},
+		{"GET /admin/bidders/{code}", http.MethodGet, http.StatusNotFound},
+		{"/status", http.MethodGet, http.StatusOK},
+	}
+	if !reflect.DeepEqual(m.routes, want) {
+		t.Errorf("expected metrics by pattern %v, got %v", want, m.routes)
+	}
+}
+
+func TestRouter_Routes(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	rt := New()
+	rt.Handle("/status", ok)
+	rt.Group().Handle("/admin/flags", ok)
+
+	if got := strings.Join(rt.Routes(), ","); got != "/admin/flags,/status" {
+		t.Errorf("unexpected routes %s", got)
+	}
+}
@@ -0,0 +1,52 @@
+package storedrequests
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLRUCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := newLRUCache(2, time.Minute)
+	c.set("request/a", "a", json.RawMessage(`{}`))
+	c.set("request/b", "b", json.RawMessage(`{}`))
+	c.get("request/a") // b is now least recently used
+	c.set("request/c", "c", json.RawMessage(`{}`))
+
+	if _, ok := c.get("request/b"); ok {
+		t.Error("expected b evicted")
+	}
+	for _, key := range []string{"request/a", "request/c"} {
+		if _, ok := c.get(key); !ok {
+			t.Errorf("expected %s kept", key)
+		}
+	}
+}
+
+func TestLRUCache_Expiry(t *testing.T) {
+	now := time.Now()
+	c := newLRUCache(10, time.Minute)
+	c.now = func() time.Time { return now }
+	c.set("imp/a", "a", json.RawMessage(`{}`))
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := c.get("imp/a"); ok {
+		t.Error("expected the entry expired")
+	}
+	if c.len() != 0 {
+		t.Errorf("expected the expired entry removed, have %d", c.len())
+	}
+}
+
+func TestLRUCache_Invalidate(t *testing.T) {
+	c := newLRUCache(10, time.Minute)
+	c.set("request/pub1-home", "pub1-home", json.RawMessage(`{}`))
+	c.set("imp/pub1-top", "pub1-top", json.RawMessage(`{}`))
+	c.set("request/pub2-home", "pub2-home", json.RawMessage(`{}`))
+
+	dropped := c.invalidate(func(id string) bool { return strings.HasPrefix(id, "pub1-") })
+	if dropped != 2 || c.len() != 1 {
+		t.Errorf("expected 2 dropped and 1 kept, got %d dropped, %d kept", dropped, c.len())
+	}
+}
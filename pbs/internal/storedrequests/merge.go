@@ -0,0 +1,55 @@
+package storedrequests
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// mergePatch applies patch to target as a JSON merge patch (RFC 7386), the
+// way Prebid Server merges incoming requests over stored ones: objects merge
+// recursively, null removes a field, and anything else (arrays included)
+// replaces the target's value.
+func mergePatch(target, patch json.RawMessage) (json.RawMessage, error) {
+	patchValue, err := decode(patch)
+	if err != nil {
+		return nil, err
+	}
+	var targetValue interface{}
+	if len(bytes.TrimSpace(target)) > 0 {
+		if targetValue, err = decode(target); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(merge(targetValue, patchValue))
+}
+
+func merge(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = make(map[string]interface{}, len(patchObject))
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = merge(targetObject[key], value)
+	}
+	return targetObject
+}
+
+// decode parses JSON keeping numbers as written, so IDs and prices survive
+// the round trip unchanged
+func decode(data json.RawMessage) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
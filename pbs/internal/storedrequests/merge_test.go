@@ -0,0 +1,44 @@
+package storedrequests
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		patch  string
+		want   string
+	}{
+		{name: "patch wins", target: `{"tmax":500,"cur":["USD"]}`, patch: `{"tmax":800}`, want: `{"cur":["USD"],"tmax":800}`},
+		{name: "objects merge", target: `{"site":{"domain":"a.com","page":"https://a.com"}}`, patch: `{"site":{"page":"https://a.com/x"}}`, want: `{"site":{"domain":"a.com","page":"https://a.com/x"}}`},
+		{name: "arrays replace", target: `{"imp":[{"id":"1"},{"id":"2"}]}`, patch: `{"imp":[{"id":"3"}]}`, want: `{"imp":[{"id":"3"}]}`},
+		{name: "null removes", target: `{"test":1,"tmax":500}`, patch: `{"test":null}`, want: `{"tmax":500}`},
+		{name: "empty target", target: ``, patch: `{"id":"r"}`, want: `{"id":"r"}`},
+		{name: "non-object target", target: `[1]`, patch: `{"id":"r"}`, want: `{"id":"r"}`},
+		{name: "numbers kept", target: `{"bidfloor":0.10}`, patch: `{"id":"12345678901234567890"}`, want: `{"bidfloor":0.10,"id":"12345678901234567890"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := mergePatch(json.RawMessage(tt.target), json.RawMessage(tt.patch))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestMergePatch_InvalidJSON(t *testing.T) {
+	if _, err := mergePatch(json.RawMessage(`{`), json.RawMessage(`{}`)); err == nil {
+		t.Error("expected an error for an invalid target")
+	}
+	if _, err := mergePatch(json.RawMessage(`{}`), json.RawMessage(`{`)); err == nil {
+		t.Error("expected an error for an invalid patch")
+	}
+}
@@ -0,0 +1,245 @@
+// Package storedrequests expands auction requests that reference stored
+// configs, so publishers can send a small request and keep the rest in Redis.
+// A request's ext.prebid.storedrequest.id names a stored request, and each
+// imp's ext.prebid.storedrequest.id a stored imp. As in Prebid Server, the
+// incoming JSON is merged over the stored JSON (RFC 7386 merge patch): fields
+// sent in the request win, objects merge, and arrays replace.
+package storedrequests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/logger"
+)
+
+// Redis hashes of stored ID -> JSON
+const (
+	RedisRequestsHash = "nexus:stored_requests"
+	RedisImpsHash     = "nexus:stored_imps"
+)
+
+// Cache defaults
+const (
+	DefaultCacheSize = 10000
+	DefaultCacheTTL  = 5 * time.Minute
+)
+
+// Kind is what a stored ID refers to
+type Kind string
+
+const (
+	KindRequest Kind = "request"
+	KindImp     Kind = "imp"
+)
+
+// NotFoundError is returned when a referenced stored ID doesn't exist
+type NotFoundError struct {
+	Kind Kind
+	ID   string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("stored %s not found: %s", e.Kind, e.ID)
+}
+
+// Fetcher loads stored JSON by ID, returning a *NotFoundError for unknown IDs
+type Fetcher interface {
+	Fetch(ctx context.Context, kind Kind, id string) (json.RawMessage, error)
+}
+
+// RedisClient is the subset of the Redis client the fetcher uses
+type RedisClient interface {
+	HGet(ctx context.Context, key, field string) (string, error)
+}
+
+// RedisFetcher reads stored requests and imps from Redis hashes
+type RedisFetcher struct {
+	client RedisClient
+}
+
+// NewRedisFetcher creates a fetcher reading RedisRequestsHash and RedisImpsHash
+func NewRedisFetcher(client RedisClient) *RedisFetcher {
+	return &RedisFetcher{client: client}
+}
+
+// Fetch implements Fetcher
+func (f *RedisFetcher) Fetch(ctx context.Context, kind Kind, id string) (json.RawMessage, error) {
+	hash := RedisRequestsHash
+	if kind == KindImp {
+		hash = RedisImpsHash
+	}
+	value, err := f.client.HGet(ctx, hash, id)
+	if err != nil {
+		return nil, fmt.Errorf("fetch stored %s %s: %w", kind, id, err)
+	}
+	if value == "" {
+		return nil, &NotFoundError{Kind: kind, ID: id}
+	}
+	if !json.Valid([]byte(value)) {
+		return nil, fmt.Errorf("stored %s %s is not valid JSON", kind, id)
+	}
+	return json.RawMessage(value), nil
+}
+
+// Config configures a Resolver
+type Config struct {
+	CacheSize int           // Stored requests and imps kept in memory
+	CacheTTL  time.Duration // How long a cached entry is used before re-reading it
+}
+
+// Resolver merges stored requests and imps into incoming requests. A nil
+// *Resolver leaves requests unchanged.
+type Resolver struct {
+	fetcher Fetcher
+	cache   *lruCache
+}
+
+// New creates a resolver caching what fetcher loads
+func New(fetcher Fetcher, config Config) *Resolver {
+	if config.CacheSize <= 0 {
+		config.CacheSize = DefaultCacheSize
+	}
+	if config.CacheTTL <= 0 {
+		config.CacheTTL = DefaultCacheTTL
+	}
+	return &Resolver{fetcher: fetcher, cache: newLRUCache(config.CacheSize, config.CacheTTL)}
+}
+
+// storedRef is the ext.prebid.storedrequest reference on a request or imp
+type storedRef struct {
+	Ext struct {
+		Prebid struct {
+			StoredRequest struct {
+				ID string `json:"id"`
+			} `json:"storedrequest"`
+		} `json:"prebid"`
+	} `json:"ext"`
+}
+
+// storedID returns the stored ID data references, if any
+func storedID(data json.RawMessage) string {
+	var ref storedRef
+	if err := json.Unmarshal(data, &ref); err != nil {
+		return ""
+	}
+	return ref.Ext.Prebid.StoredRequest.ID
+}
+
+// Resolve returns body with its stored request and stored imps merged in.
+// Bodies without references, or that aren't JSON objects, are returned as is
+// for the auction handler to validate.
+func (r *Resolver) Resolve(ctx context.Context, body []byte) ([]byte, error) {
+	if r == nil || !bytes.Contains(body, []byte(`"storedrequest"`)) {
+		return body, nil
+	}
+
+	if id := storedID(body); id != "" {
+		stored, err := r.get(ctx, KindRequest, id)
+		if err != nil {
+			return nil, err
+		}
+		if body, err = mergePatch(stored, body); err != nil {
+			return nil, fmt.Errorf("merge stored request %s: %w", id, err)
+		}
+	}
+
+	var request map[string]json.RawMessage
+	if err := json.Unmarshal(body, &request); err != nil {
+		return body, nil
+	}
+	var imps []json.RawMessage
+	if err := json.Unmarshal(request["imp"], &imps); err != nil {
+		return body, nil
+	}
+	changed := false
+	for i, imp := range imps {
+		id := storedID(imp)
+		if id == "" {
+			continue
+		}
+		stored, err := r.get(ctx, KindImp, id)
+		if err != nil {
+			return nil, err
+		}
+		if imps[i], err = mergePatch(stored, imp); err != nil {
+			return nil, fmt.Errorf("merge stored imp %s: %w", id, err)
+		}
+		changed = true
+	}
+	if !changed {
+		return body, nil
+	}
+	impJSON, err := json.Marshal(imps)
+	if err != nil {
+		return nil, err
+	}
+	request["imp"] = impJSON
+	return json.Marshal(request)
+}
+
+// get returns stored JSON from the cache, fetching it on a miss. Unknown IDs
+// aren't cached, so newly stored configs are usable immediately.
+func (r *Resolver) get(ctx context.Context, kind Kind, id string) (json.RawMessage, error) {
+	key := string(kind) + "/" + id
+	if data, ok := r.cache.get(key); ok {
+		return data, nil
+	}
+	data, err := r.fetcher.Fetch(ctx, kind, id)
+	if err != nil {
+		return nil, err
+	}
+	r.cache.set(key, id, data)
+	return data, nil
+}
+
+// Invalidate drops cached stored requests and imps whose ID matches and
+// returns how many were dropped
+func (r *Resolver) Invalidate(match func(id string) bool) int {
+	if r == nil {
+		return 0
+	}
+	return r.cache.invalidate(match)
+}
+
+// Middleware expands stored references in auction request bodies before the
+// rest of the auction chain reads them, so publisher auth and privacy
+// enforcement see the full request. Unknown IDs are rejected with 400.
+func (r *Resolver) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if r == nil || req.Method != http.MethodPost || req.Body == nil {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			http.Error(w, `{"error":"failed to read request body"}`, http.StatusBadRequest)
+			return
+		}
+
+		resolved, err := r.Resolve(req.Context(), body)
+		if err != nil {
+			var notFound *NotFoundError
+			if errors.As(err, &notFound) {
+				msg, _ := json.Marshal(map[string]string{"error": notFound.Error()})
+				http.Error(w, string(msg), http.StatusBadRequest)
+				return
+			}
+			logger.Log.Error().Err(err).Msg("Failed to resolve stored request")
+			http.Error(w, `{"error":"failed to load stored request"}`, http.StatusInternalServerError)
+			return
+		}
+
+		req.Body = io.NopCloser(bytes.NewReader(resolved))
+		req.ContentLength = int64(len(resolved))
+		next.ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,222 @@
+package storedrequests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type mockFetcher struct {
+	mu       sync.Mutex
+	requests map[string]string
+	imps     map[string]string
+	err      error
+	fetches  int
+}
+
+func (f *mockFetcher) Fetch(ctx context.Context, kind Kind, id string) (json.RawMessage, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fetches++
+	if f.err != nil {
+		return nil, f.err
+	}
+	stored := f.requests
+	if kind == KindImp {
+		stored = f.imps
+	}
+	data, ok := stored[id]
+	if !ok {
+		return nil, &NotFoundError{Kind: kind, ID: id}
+	}
+	return json.RawMessage(data), nil
+}
+
+func testFetcher() *mockFetcher {
+	return &mockFetcher{
+		requests: map[string]string{
+			"home": `{"tmax":800,"cur":["USD"],"site":{"domain":"example.com","publisher":{"id":"pub1"}},` +
+				`"imp":[{"id":"top","ext":{"prebid":{"storedrequest":{"id":"top-banner"}}}}]}`,
+		},
+		imps: map[string]string{
+			"top-banner": `{"banner":{"format":[{"w":728,"h":90}]},"bidfloor":0.5,"ext":{"appnexus":{"placementId":1}}}`,
+		},
+	}
+}
+
+func TestResolve_StoredRequest(t *testing.T) {
+	r := New(testFetcher(), Config{})
+	body := `{"id":"req1","tmax":300,"site":{"page":"https://example.com/a"},"ext":{"prebid":{"storedrequest":{"id":"home"}}}}`
+
+	resolved, err := r.Resolve(context.Background(), []byte(body))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var req struct {
+		ID   string `json:"id"`
+		TMax int    `json:"tmax"`
+		Site struct {
+			Domain string `json:"domain"`
+			Page   string `json:"page"`
+		} `json:"site"`
+		Imp []struct {
+			ID       string   `json:"id"`
+			BidFloor float64  `json:"bidfloor"`
+			Banner   struct{} `json:"banner"`
+		} `json:"imp"`
+	}
+	if err := json.Unmarshal(resolved, &req); err != nil {
+		t.Fatalf("invalid resolved request: %v", err)
+	}
+	if req.ID != "req1" || req.TMax != 300 {
+		t.Errorf("expected incoming fields to win, got %+v", req)
+	}
+	if req.Site.Domain != "example.com" || req.Site.Page != "https://example.com/a" {
+		t.Errorf("expected site merged, got %+v", req.Site)
+	}
+	if len(req.Imp) != 1 || req.Imp[0].ID != "top" || req.Imp[0].BidFloor != 0.5 {
+		t.Errorf("expected the stored imp expanded from the stored request, got %+v", req.Imp)
+	}
+}
+
+func TestResolve_StoredImp(t *testing.T) {
+	r := New(testFetcher(), Config{})
+	body := `{"id":"req1","imp":[{"id":"a","bidfloor":1.25,"ext":{"prebid":{"storedrequest":{"id":"top-banner"}}}},{"id":"b","video":{}}]}`
+
+	resolved, err := r.Resolve(context.Background(), []byte(body))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var req struct {
+		Imp []map[string]json.RawMessage `json:"imp"`
+	}
+	if err := json.Unmarshal(resolved, &req); err != nil {
+		t.Fatalf("invalid resolved request: %v", err)
+	}
+	if len(req.Imp) != 2 {
+		t.Fatalf("expected both imps, got %d", len(req.Imp))
+	}
+	first := req.Imp[0]
+	if string(first["bidfloor"]) != "1.25" || first["banner"] == nil || !strings.Contains(string(first["ext"]), "placementId") {
+		t.Errorf("expected the stored imp merged under the incoming one, got %v", first)
+	}
+	if req.Imp[1]["video"] == nil || req.Imp[1]["banner"] != nil {
+		t.Errorf("expected the plain imp unchanged, got %v", req.Imp[1])
+	}
+}
+
+func TestResolve_Unchanged(t *testing.T) {
+	fetcher := testFetcher()
+	r := New(fetcher, Config{})
+	for _, body := range []string{`{"id":"plain","imp":[{"id":"1"}]}`, `not json "storedrequest"`} {
+		resolved, err := r.Resolve(context.Background(), []byte(body))
+		if err != nil || string(resolved) != body {
+			t.Errorf("expected %s unchanged, got %s (%v)", body, resolved, err)
+		}
+	}
+	if fetcher.fetches != 0 {
+		t.Errorf("expected no fetches, got %d", fetcher.fetches)
+	}
+
+	var nilResolver *Resolver
+	if resolved, _ := nilResolver.Resolve(context.Background(), []byte(`{}`)); string(resolved) != `{}` {
+		t.Error("expected a nil resolver to pass the body through")
+	}
+}
+
+func TestResolve_NotFound(t *testing.T) {
+	r := New(testFetcher(), Config{})
+	_, err := r.Resolve(context.Background(), []byte(`{"imp":[{"id":"1","ext":{"prebid":{"storedrequest":{"id":"missing"}}}}]}`))
+	var notFound *NotFoundError
+	if !errors.As(err, &notFound) || notFound.Kind != KindImp || notFound.ID != "missing" {
+		t.Errorf("expected a stored imp not found error, got %v", err)
+	}
+}
+
+func TestResolve_CachesAndInvalidates(t *testing.T) {
+	fetcher := testFetcher()
+	r := New(fetcher, Config{})
+	body := []byte(`{"id":"r","ext":{"prebid":{"storedrequest":{"id":"home"}}}}`)
+
+	for i := 0; i < 3; i++ {
+		if _, err := r.Resolve(context.Background(), body); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if fetcher.fetches != 2 {
+		t.Errorf("expected one fetch each for the request and its imp, got %d", fetcher.fetches)
+	}
+
+	if dropped := r.Invalidate(func(id string) bool { return id == "home" }); dropped != 1 {
+		t.Errorf("expected the stored request dropped, got %d", dropped)
+	}
+	r.Resolve(context.Background(), body)
+	if fetcher.fetches != 3 {
+		t.Errorf("expected a refetch after invalidation, got %d fetches", fetcher.fetches)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	fetcher := testFetcher()
+	var got string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got = string(body)
+	})
+	handler := New(fetcher, Config{}).Middleware(next)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/openrtb2/auction", strings.NewReader(`{"id":"r","ext":{"prebid":{"storedrequest":{"id":"home"}}}}`)))
+	if w.Code != http.StatusOK || !strings.Contains(got, `"publisher":{"id":"pub1"}`) {
+		t.Errorf("expected the handler to see the resolved request, got %d %s", w.Code, got)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/openrtb2/auction", strings.NewReader(`{"ext":{"prebid":{"storedrequest":{"id":"nope"}}}}`)))
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "stored request not found: nope") {
+		t.Errorf("expected 400 for an unknown stored request, got %d %s", w.Code, w.Body.String())
+	}
+
+	fetcher.err = errors.New("redis down")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/openrtb2/auction", strings.NewReader(`{"imp":[{"ext":{"prebid":{"storedrequest":{"id":"other"}}}}]}`)))
+	if w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), "redis down") {
+		t.Errorf("expected 500 without internal detail on fetch failure, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+type mockRedis struct {
+	hashes map[string]map[string]string
+}
+
+func (m *mockRedis) HGet(ctx context.Context, key, field string) (string, error) {
+	return m.hashes[key][field], nil
+}
+
+func TestRedisFetcher(t *testing.T) {
+	f := NewRedisFetcher(&mockRedis{hashes: map[string]map[string]string{
+		RedisRequestsHash: {"home": `{"tmax":800}`, "broken": `{`},
+		RedisImpsHash:     {"top": `{"banner":{}}`},
+	}})
+	ctx := context.Background()
+
+	if data, err := f.Fetch(ctx, KindRequest, "home"); err != nil || string(data) != `{"tmax":800}` {
+		t.Errorf("unexpected stored request %s (%v)", data, err)
+	}
+	if data, err := f.Fetch(ctx, KindImp, "top"); err != nil || string(data) != `{"banner":{}}` {
+		t.Errorf("unexpected stored imp %s (%v)", data, err)
+	}
+	var notFound *NotFoundError
+	if _, err := f.Fetch(ctx, KindImp, "home"); !errors.As(err, &notFound) {
+		t.Errorf("expected not found for a request ID looked up as an imp, got %v", err)
+	}
+	if _, err := f.Fetch(ctx, KindRequest, "broken"); err == nil || errors.As(err, &notFound) {
+		t.Errorf("expected an invalid JSON error, got %v", err)
+	}
+}
@@ -0,0 +1,191 @@
+package usersync
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/signing"
+)
+
+// Cookie value formats. Plain cookies are base64 JSON with no separator;
+// signed and encrypted ones are "."-separated and name the key they used.
+const (
+	signedCookiePrefix    = "s1." // s1.<key ID>.<base64 JSON>.<base64 HMAC>
+	encryptedCookiePrefix = "e1." // e1.<key ID>.<base64 nonce + AES-GCM ciphertext>
+)
+
+// Cookie decoding errors
+var (
+	ErrCookieUnknownKey = errors.New("uids cookie was encoded with an unknown key")
+	ErrCookieTampered   = errors.New("uids cookie failed verification")
+	ErrCookiePlain      = errors.New("plain uids cookies are not accepted")
+)
+
+// CookieCodec signs the uids cookie with HMAC-SHA256, or encrypts it with
+// AES-GCM, so clients can't forge or edit their UIDs. Cookies are encoded
+// with the first key and decoded with whichever key they name, so keys can be
+// rotated: add the new key first, and drop the old one once its cookies have
+// been rewritten or expired. A nil codec reads and writes plain cookies.
+type CookieCodec struct {
+	keys        []cookieKey
+	keyIndex    map[string]int
+	encrypt     bool
+	acceptPlain bool
+}
+
+// cookieKey holds the MAC and encryption keys derived from one secret
+type cookieKey struct {
+	id   string
+	mac  []byte
+	aead cipher.AEAD
+}
+
+// NewCookieCodec creates a codec; the first key encodes. With encrypt, cookies
+// are written encrypted; either format is read. With acceptPlain, plain cookies
+// from before signing was enabled are still read, and written back encoded.
+func NewCookieCodec(keys []signing.Key, encrypt, acceptPlain bool) (*CookieCodec, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("uids cookie codec requires at least one key")
+	}
+	c := &CookieCodec{keyIndex: make(map[string]int, len(keys)), encrypt: encrypt, acceptPlain: acceptPlain}
+	for _, k := range keys {
+		if k.ID == "" || len(k.Secret) == 0 || strings.Contains(k.ID, ".") {
+			return nil, fmt.Errorf("uids cookie key %q must have an ID without dots and a secret", k.ID)
+		}
+		if _, dup := c.keyIndex[k.ID]; dup {
+			return nil, fmt.Errorf("duplicate uids cookie key ID %q", k.ID)
+		}
+		block, err := aes.NewCipher(deriveCookieKey(k.Secret, "uids-cookie-encryption"))
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		c.keyIndex[k.ID] = len(c.keys)
+		c.keys = append(c.keys, cookieKey{id: k.ID, mac: deriveCookieKey(k.Secret, "uids-cookie-signing"), aead: aead})
+	}
+	return c, nil
+}
+
+// deriveCookieKey derives a 256-bit key for one purpose from a shared secret,
+// so signing and encryption never use the same key
+func deriveCookieKey(secret []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+// ParseCookie reads the uids cookie from a request. A missing, malformed,
+// tampered or unknown-key cookie reads as a new, empty one.
+func (c *CookieCodec) ParseCookie(r *http.Request) *Cookie {
+	cookie, err := r.Cookie(CookieName)
+	if err != nil {
+		return NewCookie()
+	}
+	data, err := c.decode(cookie.Value)
+	if err != nil {
+		return NewCookie()
+	}
+	return unmarshalCookie(data)
+}
+
+// HTTPCookie encodes a cookie for setting in a response
+func (c *CookieCodec) HTTPCookie(cookie *Cookie, domain string) (*http.Cookie, error) {
+	return cookie.toHTTPCookie(domain, c)
+}
+
+// encode encodes a cookie's JSON
+func (c *CookieCodec) encode(data []byte) (string, error) {
+	if c == nil {
+		return base64.URLEncoding.EncodeToString(data), nil
+	}
+	key := c.keys[0]
+	if c.encrypt {
+		prefix := encryptedCookiePrefix + key.id
+		nonce := make([]byte, key.aead.NonceSize(), key.aead.NonceSize()+len(data)+key.aead.Overhead())
+		if _, err := rand.Read(nonce); err != nil {
+			return "", fmt.Errorf("failed to generate cookie nonce: %w", err)
+		}
+		sealed := key.aead.Seal(nonce, nonce, data, []byte(prefix))
+		return prefix + "." + base64.RawURLEncoding.EncodeToString(sealed), nil
+	}
+	signed := signedCookiePrefix + key.id + "." + base64.RawURLEncoding.EncodeToString(data)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(cookieMAC(key.mac, signed)), nil
+}
+
+// decode returns the JSON of an encoded cookie value, verifying it
+func (c *CookieCodec) decode(value string) ([]byte, error) {
+	if !strings.Contains(value, ".") {
+		if c != nil && !c.acceptPlain {
+			return nil, ErrCookiePlain
+		}
+		return base64.URLEncoding.DecodeString(value)
+	}
+	if c == nil {
+		return nil, ErrCookieUnknownKey
+	}
+
+	parts := strings.Split(value, ".")
+	key, ok := c.key(parts)
+	if !ok {
+		return nil, ErrCookieUnknownKey
+	}
+	switch parts[0] + "." {
+	case signedCookiePrefix:
+		if len(parts) != 4 {
+			return nil, ErrCookieTampered
+		}
+		mac, err := base64.RawURLEncoding.DecodeString(parts[3])
+		if err != nil || !hmac.Equal(mac, cookieMAC(key.mac, strings.Join(parts[:3], "."))) {
+			return nil, ErrCookieTampered
+		}
+		data, err := base64.RawURLEncoding.DecodeString(parts[2])
+		if err != nil {
+			return nil, ErrCookieTampered
+		}
+		return data, nil
+	case encryptedCookiePrefix:
+		if len(parts) != 3 {
+			return nil, ErrCookieTampered
+		}
+		sealed, err := base64.RawURLEncoding.DecodeString(parts[2])
+		if err != nil || len(sealed) < key.aead.NonceSize() {
+			return nil, ErrCookieTampered
+		}
+		nonce, ciphertext := sealed[:key.aead.NonceSize()], sealed[key.aead.NonceSize():]
+		data, err := key.aead.Open(nil, nonce, ciphertext, []byte(parts[0]+"."+parts[1]))
+		if err != nil {
+			return nil, ErrCookieTampered
+		}
+		return data, nil
+	}
+	return nil, ErrCookieTampered
+}
+
+// key returns the key a cookie value's parts name
+func (c *CookieCodec) key(parts []string) (cookieKey, bool) {
+	if len(parts) < 2 {
+		return cookieKey{}, false
+	}
+	i, ok := c.keyIndex[parts[1]]
+	if !ok {
+		return cookieKey{}, false
+	}
+	return c.keys[i], true
+}
+
+func cookieMAC(key []byte, signed string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(signed))
+	return mac.Sum(nil)
+}
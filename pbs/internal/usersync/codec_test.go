@@ -0,0 +1,147 @@
+package usersync
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/signing"
+)
+
+func requestWithCookie(c *http.Cookie) *http.Request {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: c.Name, Value: c.Value})
+	return req
+}
+
+func testCodec(t *testing.T, encrypt, acceptPlain bool, keys ...signing.Key) *CookieCodec {
+	t.Helper()
+	codec, err := NewCookieCodec(keys, encrypt, acceptPlain)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return codec
+}
+
+func TestCookieCodec_RoundTrip(t *testing.T) {
+	key := signing.Key{ID: "k1", Secret: []byte("secret-1")}
+	for _, encrypt := range []bool{false, true} {
+		codec := testCodec(t, encrypt, false, key)
+		c := NewCookie()
+		c.SetUID("appnexus", "user-123")
+
+		httpCookie, err := codec.HTTPCookie(c, "example.com")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		parts := strings.Split(httpCookie.Value, ".")
+		payload, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		if strings.Contains(string(payload), "user-123") == encrypt {
+			t.Errorf("encrypt=%v: expected the UID readable only in signed cookies", encrypt)
+		}
+
+		if got := codec.ParseCookie(requestWithCookie(httpCookie)).GetUID("appnexus"); got != "user-123" {
+			t.Errorf("encrypt=%v: expected the UID back, got %q", encrypt, got)
+		}
+		// Plain readers can't use encoded cookies
+		if ParseCookie(requestWithCookie(httpCookie)).SyncCount() != 0 {
+			t.Errorf("encrypt=%v: expected an encoded cookie unreadable without the codec", encrypt)
+		}
+	}
+}
+
+func TestCookieCodec_RejectsTampering(t *testing.T) {
+	codec := testCodec(t, false, true, signing.Key{ID: "k1", Secret: []byte("secret-1")})
+	c := NewCookie()
+	c.SetUID("appnexus", "user-123")
+	httpCookie, _ := codec.HTTPCookie(c, "example.com")
+
+	parts := strings.Split(httpCookie.Value, ".")
+	forged := NewCookie()
+	forged.SetUID("appnexus", "someone-else")
+	forgedCookie, _ := forged.ToHTTPCookie("example.com")
+	parts[2] = strings.TrimRight(forgedCookie.Value, "=")
+
+	for name, value := range map[string]string{
+		"edited payload":  strings.Join(parts, "."),
+		"unknown key":     strings.Replace(httpCookie.Value, "s1.k1.", "s1.k9.", 1),
+		"truncated":       httpCookie.Value[:len(httpCookie.Value)-4],
+		"unknown version": strings.Replace(httpCookie.Value, "s1.", "s9.", 1),
+	} {
+		if _, err := codec.decode(value); err == nil {
+			t.Errorf("%s: expected the cookie rejected", name)
+		}
+		tampered := &http.Cookie{Name: CookieName, Value: value}
+		if codec.ParseCookie(requestWithCookie(tampered)).SyncCount() != 0 {
+			t.Errorf("%s: expected an empty cookie", name)
+		}
+	}
+
+	// Another deployment's key doesn't verify
+	other := testCodec(t, true, true, signing.Key{ID: "k1", Secret: []byte("other")})
+	encrypted, _ := other.HTTPCookie(c, "example.com")
+	if _, err := codec.decode(encrypted.Value); err != ErrCookieTampered {
+		t.Errorf("expected a cookie encrypted with another secret rejected, got %v", err)
+	}
+}
+
+func TestCookieCodec_Rotation(t *testing.T) {
+	oldKey := signing.Key{ID: "old", Secret: []byte("old-secret")}
+	newKey := signing.Key{ID: "new", Secret: []byte("new-secret")}
+
+	c := NewCookie()
+	c.SetUID("rubicon", "r-1")
+	oldCookie, _ := testCodec(t, false, false, oldKey).HTTPCookie(c, "example.com")
+
+	// Rotating: the new key encodes, the old one still decodes
+	rotating := testCodec(t, false, false, newKey, oldKey)
+	parsed := rotating.ParseCookie(requestWithCookie(oldCookie))
+	if parsed.GetUID("rubicon") != "r-1" {
+		t.Fatal("expected a cookie signed with the old key read during rotation")
+	}
+	rewritten, _ := rotating.HTTPCookie(parsed, "example.com")
+	if !strings.HasPrefix(rewritten.Value, "s1.new.") {
+		t.Errorf("expected the cookie rewritten with the new key, got %q", rewritten.Value)
+	}
+
+	// Once the old key is dropped, its cookies no longer read
+	if testCodec(t, false, false, newKey).ParseCookie(requestWithCookie(oldCookie)).SyncCount() != 0 {
+		t.Error("expected a cookie signed with a retired key rejected")
+	}
+}
+
+func TestCookieCodec_MigratesPlainCookies(t *testing.T) {
+	c := NewCookie()
+	c.SetUID("pubmatic", "p-1")
+	plain, _ := c.ToHTTPCookie("example.com")
+	key := signing.Key{ID: "k1", Secret: []byte("secret-1")}
+
+	migrating := testCodec(t, false, true, key)
+	parsed := migrating.ParseCookie(requestWithCookie(plain))
+	if parsed.GetUID("pubmatic") != "p-1" {
+		t.Fatal("expected a plain cookie read while migrating")
+	}
+	signed, _ := migrating.HTTPCookie(parsed, "example.com")
+	if !strings.HasPrefix(signed.Value, "s1.k1.") {
+		t.Errorf("expected the plain cookie written back signed, got %q", signed.Value)
+	}
+
+	if testCodec(t, false, false, key).ParseCookie(requestWithCookie(plain)).SyncCount() != 0 {
+		t.Error("expected plain cookies rejected once migration is over")
+	}
+}
+
+func TestNewCookieCodec_InvalidKeys(t *testing.T) {
+	for name, keys := range map[string][]signing.Key{
+		"none":      nil,
+		"dotted ID": {{ID: "a.b", Secret: []byte("s")}},
+		"no secret": {{ID: "a"}},
+		"duplicate": {{ID: "a", Secret: []byte("s")}, {ID: "a", Secret: []byte("t")}},
+	} {
+		if _, err := NewCookieCodec(keys, false, true); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
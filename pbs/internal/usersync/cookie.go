@@ -2,7 +2,6 @@
 package usersync
 
 import (
-	"encoding/base64"
 	"encoding/json"
 	"net/http"
 	"sync"
@@ -20,7 +19,11 @@ type Cookie struct {
 	UIDs    map[string]UID `json:"uids"`
 	OptOut  bool           `json:"optout,omitempty"`
 	Created time.Time      `json:"created"`
-	mu      sync.RWMutex
+	// StoreID names the server-side record holding the UIDs; see UIDStore
+	StoreID string `json:"sid,omitempty"`
+	// loadFailed is set when the stored UIDs couldn't be read, so they aren't overwritten
+	loadFailed bool
+	mu         sync.RWMutex
 }
 
 const (
@@ -40,21 +43,16 @@ func NewCookie() *Cookie {
 	}
 }
 
-// ParseCookie parses a cookie from an HTTP request
+// ParseCookie parses a plain cookie from an HTTP request; see CookieCodec
+// for signed ones
 func ParseCookie(r *http.Request) *Cookie {
-	cookie, err := r.Cookie(CookieName)
-	if err != nil {
-		return NewCookie()
-	}
-
-	// Decode base64
-	decoded, err := base64.URLEncoding.DecodeString(cookie.Value)
-	if err != nil {
-		return NewCookie()
-	}
+	return (*CookieCodec)(nil).ParseCookie(r)
+}
 
+// unmarshalCookie parses a cookie's JSON
+func unmarshalCookie(data []byte) *Cookie {
 	var c Cookie
-	if err := json.Unmarshal(decoded, &c); err != nil {
+	if err := json.Unmarshal(data, &c); err != nil {
 		return NewCookie()
 	}
 
@@ -155,9 +153,15 @@ func (c *Cookie) cleanExpired() {
 	}
 }
 
-// ToHTTPCookie converts to an http.Cookie for setting in response
-// Note: Uses Lock() instead of RLock() because trimToFit() may modify c.UIDs
+// ToHTTPCookie converts to a plain http.Cookie for setting in response; see
+// CookieCodec for signed ones
 func (c *Cookie) ToHTTPCookie(domain string) (*http.Cookie, error) {
+	return c.toHTTPCookie(domain, nil)
+}
+
+// toHTTPCookie encodes the cookie with codec
+// Note: Uses Lock() instead of RLock() because trimToFit() may modify c.UIDs
+func (c *Cookie) toHTTPCookie(domain string, codec *CookieCodec) (*http.Cookie, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -166,14 +170,19 @@ func (c *Cookie) ToHTTPCookie(domain string) (*http.Cookie, error) {
 		return nil, err
 	}
 
-	encoded := base64.URLEncoding.EncodeToString(data)
+	encoded, err := codec.encode(data)
+	if err != nil {
+		return nil, err
+	}
 
 	// Check size limit
 	if len(encoded) > MaxCookieSize {
 		// Trim oldest UIDs to fit
-		c.trimToFit()
+		c.trimToFit(codec)
 		data, _ = json.Marshal(c)
-		encoded = base64.URLEncoding.EncodeToString(data)
+		if encoded, err = codec.encode(data); err != nil {
+			return nil, err
+		}
 	}
 
 	return &http.Cookie{
@@ -190,12 +199,12 @@ func (c *Cookie) ToHTTPCookie(domain string) (*http.Cookie, error) {
 }
 
 // trimToFit removes oldest UIDs to fit within cookie size limit
-func (c *Cookie) trimToFit() {
+func (c *Cookie) trimToFit(codec *CookieCodec) {
 	// Simple approach: remove UIDs with earliest expiry until we fit
 	for len(c.UIDs) > 0 {
 		data, _ := json.Marshal(c)
-		encoded := base64.URLEncoding.EncodeToString(data)
-		if len(encoded) <= MaxCookieSize {
+		encoded, err := codec.encode(data)
+		if err == nil && len(encoded) <= MaxCookieSize {
 			break
 		}
 
@@ -0,0 +1,249 @@
+package usersync
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/logger"
+)
+
+// RedisSyncersHash is the Redis hash of syncer definitions: bidder code -> JSON
+const RedisSyncersHash = "nexus:syncers"
+
+// DefaultSyncerRefreshPeriod is how often SyncerReloader reloads by default
+const DefaultSyncerRefreshPeriod = time.Minute
+
+// syncerDefinition is a syncer as written in a file or Redis
+type syncerDefinition struct {
+	IframeURL   string `json:"iframe_url" yaml:"iframe_url"`
+	RedirectURL string `json:"redirect_url" yaml:"redirect_url"`
+	SupportCORS bool   `json:"support_cors" yaml:"support_cors"`
+	Enabled     *bool  `json:"enabled" yaml:"enabled"` // Defaults to true
+}
+
+func (d syncerDefinition) config(bidderCode string) SyncerConfig {
+	return SyncerConfig{
+		BidderCode:      bidderCode,
+		IframeSyncURL:   d.IframeURL,
+		RedirectSyncURL: d.RedirectURL,
+		SupportCORS:     d.SupportCORS,
+		Enabled:         d.Enabled == nil || *d.Enabled,
+	}
+}
+
+// SyncerErrors lists the syncer definitions that failed to parse or
+// validate, by bidder code. Sources return it alongside the valid ones.
+type SyncerErrors map[string]error
+
+func (e SyncerErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	sort.Strings(msgs)
+	return "invalid syncers: " + strings.Join(msgs, "; ")
+}
+
+// SyncerSource loads syncer definitions by bidder code. Definitions that
+// fail to parse or validate are left out and reported in a SyncerErrors;
+// any other error means nothing was loaded.
+type SyncerSource interface {
+	Load(ctx context.Context) (map[string]SyncerConfig, error)
+}
+
+// FileSyncerSource reads syncer definitions from a YAML or JSON file (by
+// extension; .json is JSON, anything else YAML) mapping bidder codes to
+// iframe_url, redirect_url, support_cors and enabled
+type FileSyncerSource struct {
+	path string
+}
+
+// NewFileSyncerSource creates a source reading path
+func NewFileSyncerSource(path string) *FileSyncerSource {
+	return &FileSyncerSource{path: path}
+}
+
+// Load implements SyncerSource
+func (s *FileSyncerSource) Load(ctx context.Context) (map[string]SyncerConfig, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var definitions map[string]syncerDefinition
+	if strings.EqualFold(filepath.Ext(s.path), ".json") {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&definitions)
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err = decoder.Decode(&definitions); errors.Is(err, io.EOF) {
+			err = nil // Empty file
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", s.path, err)
+	}
+
+	configs := make(map[string]SyncerConfig, len(definitions))
+	invalid := make(SyncerErrors)
+	for code, definition := range definitions {
+		addSyncer(configs, invalid, definition.config(strings.ToLower(code)))
+	}
+	return configs, invalid.orNil()
+}
+
+// RedisSyncerClient is the subset of the Redis client RedisSyncerSource uses
+type RedisSyncerClient interface {
+	HGetAll(ctx context.Context, key string) (map[string]string, error)
+}
+
+// RedisSyncerSource reads syncer definitions from RedisSyncersHash, each a
+// JSON object with the fields FileSyncerSource reads
+type RedisSyncerSource struct {
+	client RedisSyncerClient
+}
+
+// NewRedisSyncerSource creates a source reading RedisSyncersHash
+func NewRedisSyncerSource(client RedisSyncerClient) *RedisSyncerSource {
+	return &RedisSyncerSource{client: client}
+}
+
+// Load implements SyncerSource
+func (s *RedisSyncerSource) Load(ctx context.Context) (map[string]SyncerConfig, error) {
+	values, err := s.client.HGetAll(ctx, RedisSyncersHash)
+	if err != nil {
+		return nil, fmt.Errorf("load syncers: %w", err)
+	}
+
+	configs := make(map[string]SyncerConfig, len(values))
+	invalid := make(SyncerErrors)
+	for code, value := range values {
+		code = strings.ToLower(code)
+		var definition syncerDefinition
+		decoder := json.NewDecoder(strings.NewReader(value))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&definition); err != nil {
+			invalid[code] = fmt.Errorf("%s: %w", code, err)
+			continue
+		}
+		addSyncer(configs, invalid, definition.config(code))
+	}
+	return configs, invalid.orNil()
+}
+
+// addSyncer adds config to configs if it validates, else to invalid
+func addSyncer(configs map[string]SyncerConfig, invalid SyncerErrors, config SyncerConfig) {
+	if err := config.Validate(); err != nil {
+		invalid[config.BidderCode] = err
+		return
+	}
+	configs[config.BidderCode] = config
+}
+
+func (e SyncerErrors) orNil() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// SyncerReloader loads syncers from a source over DefaultSyncerConfigs and
+// reloads them periodically, passing the full set to apply whenever it
+// changes. Loaded definitions replace the defaults for their bidder; set
+// enabled: false to turn a default syncer off. A definition that fails to
+// load keeps the bidder's previous syncer, and a source that fails to load
+// keeps them all.
+type SyncerReloader struct {
+	source   SyncerSource
+	period   time.Duration
+	apply    func(map[string]SyncerConfig)
+	mu       sync.Mutex // Serializes reloads
+	current  map[string]SyncerConfig
+	stopChan chan struct{}
+}
+
+// NewSyncerReloader creates a reloader; period <= 0 uses
+// DefaultSyncerRefreshPeriod
+func NewSyncerReloader(source SyncerSource, period time.Duration, apply func(map[string]SyncerConfig)) *SyncerReloader {
+	if period <= 0 {
+		period = DefaultSyncerRefreshPeriod
+	}
+	return &SyncerReloader{
+		source:   source,
+		period:   period,
+		apply:    apply,
+		current:  DefaultSyncerConfigs(),
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Reload loads the source and applies the result if it changed. A
+// SyncerErrors means the other definitions were still applied.
+func (r *SyncerReloader) Reload(ctx context.Context) error {
+	loaded, err := r.source.Load(ctx)
+	var invalid SyncerErrors
+	if err != nil && !errors.As(err, &invalid) {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next := DefaultSyncerConfigs()
+	for code, config := range loaded {
+		next[code] = config
+	}
+	for code := range invalid {
+		if previous, ok := r.current[code]; ok {
+			next[code] = previous
+		}
+	}
+	if !reflect.DeepEqual(next, r.current) {
+		r.current = next
+		r.apply(next)
+		logger.Log.Info().Int("syncers", len(next)).Msg("Syncers reloaded")
+	}
+	return err
+}
+
+// Start reloads every period until ctx is done or Stop is called
+func (r *SyncerReloader) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(r.period)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				reloadCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+				if err := r.Reload(reloadCtx); err != nil {
+					logger.Log.Warn().Err(err).Msg("Failed to reload syncers")
+				}
+				cancel()
+			case <-r.stopChan:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops reloading
+func (r *SyncerReloader) Stop() {
+	close(r.stopChan)
+}
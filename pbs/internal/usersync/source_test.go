@@ -0,0 +1,133 @@
+package usersync
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type fakeSyncerRedis struct {
+	values map[string]string
+	err    error
+}
+
+func (f *fakeSyncerRedis) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	if key != RedisSyncersHash {
+		return nil, nil
+	}
+	return f.values, f.err
+}
+
+func TestFileSyncerSource(t *testing.T) {
+	dir := t.TempDir()
+	yamlPath := filepath.Join(dir, "syncers.yaml")
+	os.WriteFile(yamlPath, []byte(`
+NewBidder:
+  redirect_url: "https://new.example/sync?gdpr={{gdpr}}&r={{redirect_url}}"
+  support_cors: true
+appnexus:
+  enabled: false
+broken:
+  redirect_url: "https://broken.example/sync?consent={{consent}}&r={{redirect_url}}"
+`), 0o644)
+
+	configs, err := NewFileSyncerSource(yamlPath).Load(context.Background())
+	var invalid SyncerErrors
+	if !errors.As(err, &invalid) || len(invalid) != 1 || invalid["broken"] == nil {
+		t.Fatalf("expected only broken reported invalid, got %v", err)
+	}
+	want := SyncerConfig{BidderCode: "newbidder", RedirectSyncURL: "https://new.example/sync?gdpr={{gdpr}}&r={{redirect_url}}", SupportCORS: true, Enabled: true}
+	if configs["newbidder"] != want {
+		t.Errorf("expected %+v, got %+v", want, configs["newbidder"])
+	}
+	if configs["appnexus"].Enabled {
+		t.Error("expected appnexus disabled")
+	}
+
+	jsonPath := filepath.Join(dir, "syncers.json")
+	os.WriteFile(jsonPath, []byte(`{"ix": {"redirect_url": "https://ix.example/?r={{redirect_url}}"}}`), 0o644)
+	if configs, err := NewFileSyncerSource(jsonPath).Load(context.Background()); err != nil || !configs["ix"].Enabled {
+		t.Errorf("expected the JSON file loaded, got %v, %v", configs, err)
+	}
+
+	// Unknown fields and unreadable files fail the whole load
+	os.WriteFile(yamlPath, []byte("ix:\n  redirect: \"https://ix.example/?r={{redirect_url}}\"\n"), 0o644)
+	if _, err := NewFileSyncerSource(yamlPath).Load(context.Background()); err == nil || errors.As(err, &invalid) {
+		t.Errorf("expected an unknown field to fail the file, got %v", err)
+	}
+	if _, err := NewFileSyncerSource(filepath.Join(dir, "missing.yaml")).Load(context.Background()); err == nil {
+		t.Error("expected a missing file to fail")
+	}
+}
+
+func TestRedisSyncerSource(t *testing.T) {
+	redis := &fakeSyncerRedis{values: map[string]string{
+		"ix":     `{"redirect_url": "https://ix.example/?r={{redirect_url}}"}`,
+		"broken": `{"redirect_url": `,
+	}}
+	configs, err := NewRedisSyncerSource(redis).Load(context.Background())
+	var invalid SyncerErrors
+	if !errors.As(err, &invalid) || invalid["broken"] == nil {
+		t.Errorf("expected broken reported invalid, got %v", err)
+	}
+	if configs["ix"].RedirectSyncURL != "https://ix.example/?r={{redirect_url}}" {
+		t.Errorf("expected ix loaded, got %+v", configs["ix"])
+	}
+
+	redis.err = errors.New("connection refused")
+	if _, err := NewRedisSyncerSource(redis).Load(context.Background()); err == nil || errors.As(err, &invalid) {
+		t.Errorf("expected a Redis error to fail the load, got %v", err)
+	}
+}
+
+func TestSyncerReloader(t *testing.T) {
+	redis := &fakeSyncerRedis{values: map[string]string{}}
+	var applied []map[string]SyncerConfig
+	reloader := NewSyncerReloader(NewRedisSyncerSource(redis), 0, func(configs map[string]SyncerConfig) {
+		applied = append(applied, configs)
+	})
+
+	// Nothing loaded: the defaults stay, so there's nothing to apply
+	if err := reloader.Reload(context.Background()); err != nil || len(applied) != 0 {
+		t.Fatalf("expected nothing applied, got %d applies, %v", len(applied), err)
+	}
+
+	redis.values["ix"] = `{"redirect_url": "https://ix.example/v1?r={{redirect_url}}"}`
+	redis.values["newbidder"] = `{"iframe_url": "https://new.example/sync"}`
+	reloader.Reload(context.Background())
+	if len(applied) != 1 || applied[0]["ix"].RedirectSyncURL != "https://ix.example/v1?r={{redirect_url}}" || !applied[0]["newbidder"].Enabled {
+		t.Fatalf("expected the loaded syncers applied over the defaults, got %v", applied)
+	}
+	if applied[0]["appnexus"] != DefaultSyncerConfigs()["appnexus"] {
+		t.Error("expected the other defaults kept")
+	}
+
+	// A definition that breaks keeps its previous version
+	redis.values["ix"] = `{"redirect_url": "https://ix.example/v2?r={{redirect_urls}}"}`
+	if err := reloader.Reload(context.Background()); err == nil {
+		t.Error("expected the broken definition reported")
+	}
+	if len(applied) != 1 {
+		t.Errorf("expected nothing new applied, got %d applies", len(applied))
+	}
+
+	// A failing source keeps everything
+	redis.err = errors.New("connection refused")
+	if err := reloader.Reload(context.Background()); err == nil || len(applied) != 1 {
+		t.Errorf("expected the failure reported and nothing applied, got %v", err)
+	}
+	redis.err = nil
+
+	// A removed definition reverts to the default, or goes if there's none
+	delete(redis.values, "ix")
+	delete(redis.values, "newbidder")
+	reloader.Reload(context.Background())
+	if len(applied) != 2 || applied[1]["ix"] != DefaultSyncerConfigs()["ix"] {
+		t.Fatalf("expected ix reverted to its default, got %v", applied)
+	}
+	if _, ok := applied[1]["newbidder"]; ok {
+		t.Error("expected newbidder removed")
+	}
+}
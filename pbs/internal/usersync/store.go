@@ -0,0 +1,124 @@
+package usersync
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/logger"
+)
+
+// RedisUIDKeyPrefix prefixes the Redis keys UIDStore records are kept under
+const RedisUIDKeyPrefix = "nexus:uids:"
+
+// ErrUIDsNotLoaded is returned when writing a cookie whose stored UIDs
+// couldn't be read
+var ErrUIDsNotLoaded = errors.New("stored UIDs were not loaded")
+
+// RedisClient is the subset of the Redis client UIDStore uses
+type RedisClient interface {
+	GetWithTTL(ctx context.Context, key string, ttl time.Duration) (string, error)
+	SetWithTTL(ctx context.Context, key, value string, ttl time.Duration) error
+}
+
+// UIDStore keeps the uid map in Redis instead of the uids cookie, which then
+// only carries a random first-party ID (and the opt-out), so UIDs survive
+// browsers that cap or drop third-party cookies. Records expire ttl after
+// they were last read or written.
+//
+// Cookies from before the store was enabled still carry their UIDs; they are
+// read as usual and moved into the store on the next write. A nil store keeps
+// the UIDs in the cookie.
+type UIDStore struct {
+	client RedisClient
+	ttl    time.Duration
+}
+
+// NewUIDStore creates a store; ttl <= 0 uses DefaultTTL
+func NewUIDStore(client RedisClient, ttl time.Duration) *UIDStore {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &UIDStore{client: client, ttl: ttl}
+}
+
+// ParseCookie reads the uids cookie with codec and loads the UIDs it refers
+// to. A missing record leaves the UIDs empty but keeps the ID, so the next
+// write recreates it; one that can't be read also leaves them empty, and
+// HTTPCookie then fails rather than overwrite it.
+func (s *UIDStore) ParseCookie(r *http.Request, codec *CookieCodec) *Cookie {
+	cookie := codec.ParseCookie(r)
+	if s == nil || cookie.StoreID == "" {
+		return cookie
+	}
+	if !validStoreID(cookie.StoreID) {
+		cookie.StoreID = ""
+		return cookie
+	}
+
+	value, err := s.client.GetWithTTL(r.Context(), RedisUIDKeyPrefix+cookie.StoreID, s.ttl)
+	if err != nil {
+		logger.Log.Warn().Err(err).Msg("Failed to load stored UIDs")
+	}
+	stored := NewCookie()
+	if value != "" {
+		stored = unmarshalCookie([]byte(value))
+	}
+	stored.StoreID = cookie.StoreID
+	stored.loadFailed = err != nil
+	stored.OptOut = stored.OptOut || cookie.OptOut
+	return stored
+}
+
+// HTTPCookie saves the cookie's UIDs and returns a cookie, encoded with
+// codec, carrying only its ID and opt-out
+func (s *UIDStore) HTTPCookie(ctx context.Context, cookie *Cookie, domain string, codec *CookieCodec) (*http.Cookie, error) {
+	if s == nil {
+		return codec.HTTPCookie(cookie, domain)
+	}
+
+	cookie.mu.Lock()
+	if cookie.loadFailed {
+		cookie.mu.Unlock()
+		return nil, ErrUIDsNotLoaded
+	}
+	if cookie.StoreID == "" {
+		id, err := newStoreID()
+		if err != nil {
+			cookie.mu.Unlock()
+			return nil, err
+		}
+		cookie.StoreID = id
+	}
+	data, err := json.Marshal(cookie)
+	ref := &Cookie{UIDs: make(map[string]UID), OptOut: cookie.OptOut, Created: cookie.Created, StoreID: cookie.StoreID}
+	cookie.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.client.SetWithTTL(ctx, RedisUIDKeyPrefix+ref.StoreID, string(data), s.ttl); err != nil {
+		return nil, fmt.Errorf("failed to store UIDs: %w", err)
+	}
+	return codec.HTTPCookie(ref, domain)
+}
+
+// newStoreID returns a random 128-bit ID
+func newStoreID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate UID store ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// validStoreID reports whether id looks like one newStoreID made
+func validStoreID(id string) bool {
+	b, err := hex.DecodeString(id)
+	return err == nil && len(b) == 16
+}
@@ -0,0 +1,170 @@
+package usersync
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/signing"
+)
+
+type fakeRedis struct {
+	values map[string]string
+	ttls   map[string]time.Duration
+	err    error
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{values: make(map[string]string), ttls: make(map[string]time.Duration)}
+}
+
+func (f *fakeRedis) GetWithTTL(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	if f.err != nil {
+		return "", f.err
+	}
+	if _, ok := f.values[key]; ok {
+		f.ttls[key] = ttl
+	}
+	return f.values[key], nil
+}
+
+func (f *fakeRedis) SetWithTTL(ctx context.Context, key, value string, ttl time.Duration) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.values[key] = value
+	f.ttls[key] = ttl
+	return nil
+}
+
+func TestUIDStore_KeepsUIDsServerSide(t *testing.T) {
+	redis := newFakeRedis()
+	store := NewUIDStore(redis, time.Hour)
+	codec := testCodec(t, false, false, signing.Key{ID: "k1", Secret: []byte("secret-1")})
+
+	c := NewCookie()
+	c.SetUID("appnexus", "user-123")
+	httpCookie, err := store.HTTPCookie(context.Background(), c, "example.com", codec)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ParseCookie(requestWithCookie(httpCookie)).SyncCount() != 0 || codec.ParseCookie(requestWithCookie(httpCookie)).SyncCount() != 0 {
+		t.Error("expected no UIDs in the cookie itself")
+	}
+	key := RedisUIDKeyPrefix + c.StoreID
+	if !strings.Contains(redis.values[key], "user-123") || redis.ttls[key] != time.Hour {
+		t.Errorf("expected the UIDs stored for an hour, got %q for %v", redis.values[key], redis.ttls[key])
+	}
+
+	// Reading refreshes the TTL
+	redis.ttls[key] = 0
+	parsed := store.ParseCookie(requestWithCookie(httpCookie), codec)
+	if parsed.GetUID("appnexus") != "user-123" || parsed.StoreID != c.StoreID {
+		t.Fatalf("expected the stored UIDs read back, got %v", parsed.GetAllUIDs())
+	}
+	if redis.ttls[key] != time.Hour {
+		t.Error("expected reading to refresh the TTL")
+	}
+
+	// Writing again keeps the same ID
+	parsed.SetUID("rubicon", "r-1")
+	if _, err := store.HTTPCookie(context.Background(), parsed, "example.com", codec); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(redis.values) != 1 || !strings.Contains(redis.values[key], "r-1") {
+		t.Errorf("expected the record updated in place, got %v", redis.values)
+	}
+}
+
+func TestUIDStore_MigratesCookieUIDs(t *testing.T) {
+	redis := newFakeRedis()
+	store := NewUIDStore(redis, 0)
+
+	legacy := NewCookie()
+	legacy.SetUID("pubmatic", "p-1")
+	legacyCookie, _ := legacy.ToHTTPCookie("example.com")
+
+	parsed := store.ParseCookie(requestWithCookie(legacyCookie), nil)
+	if parsed.GetUID("pubmatic") != "p-1" {
+		t.Fatal("expected UIDs read from a cookie written before the store")
+	}
+	rewritten, err := store.HTTPCookie(context.Background(), parsed, "example.com", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ParseCookie(requestWithCookie(rewritten)).SyncCount() != 0 {
+		t.Error("expected the UIDs moved out of the cookie")
+	}
+	if got := store.ParseCookie(requestWithCookie(rewritten), nil).GetUID("pubmatic"); got != "p-1" {
+		t.Errorf("expected the migrated UID read from the store, got %q", got)
+	}
+	if redis.ttls[RedisUIDKeyPrefix+parsed.StoreID] != DefaultTTL {
+		t.Error("expected DefaultTTL used without a TTL")
+	}
+}
+
+func TestUIDStore_Failures(t *testing.T) {
+	redis := newFakeRedis()
+	store := NewUIDStore(redis, time.Hour)
+
+	c := NewCookie()
+	c.SetUID("appnexus", "user-123")
+	httpCookie, _ := store.HTTPCookie(context.Background(), c, "example.com", nil)
+
+	// An expired record reads as empty and is recreated under the same ID
+	redis.values = make(map[string]string)
+	parsed := store.ParseCookie(requestWithCookie(httpCookie), nil)
+	if parsed.SyncCount() != 0 || parsed.StoreID != c.StoreID {
+		t.Errorf("expected an empty cookie keeping its ID, got %v %q", parsed.GetAllUIDs(), parsed.StoreID)
+	}
+	if _, err := store.HTTPCookie(context.Background(), parsed, "example.com", nil); err != nil {
+		t.Errorf("expected an expired record recreated, got %v", err)
+	}
+
+	// A record that can't be read isn't overwritten
+	redis.err = errors.New("connection refused")
+	parsed = store.ParseCookie(requestWithCookie(httpCookie), nil)
+	redis.err = nil
+	parsed.SetUID("rubicon", "r-1")
+	if _, err := store.HTTPCookie(context.Background(), parsed, "example.com", nil); err != ErrUIDsNotLoaded {
+		t.Errorf("expected ErrUIDsNotLoaded, got %v", err)
+	}
+
+	// IDs that newStoreID couldn't have made are ignored
+	forged := NewCookie()
+	forged.StoreID = "../../admin"
+	forgedCookie, _ := forged.ToHTTPCookie("example.com")
+	if got := store.ParseCookie(requestWithCookie(forgedCookie), nil).StoreID; got != "" {
+		t.Errorf("expected a malformed ID dropped, got %q", got)
+	}
+}
+
+func TestUIDStore_KeepsOptOutInCookie(t *testing.T) {
+	redis := newFakeRedis()
+	store := NewUIDStore(redis, time.Hour)
+
+	c := NewCookie()
+	c.SetOptOut(true)
+	httpCookie, _ := store.HTTPCookie(context.Background(), c, "example.com", nil)
+
+	// The opt-out holds even if the record is lost
+	redis.values = make(map[string]string)
+	if !store.ParseCookie(requestWithCookie(httpCookie), nil).IsOptOut() {
+		t.Error("expected the opt-out kept in the cookie")
+	}
+}
+
+func TestUIDStore_Nil(t *testing.T) {
+	var store *UIDStore
+	c := NewCookie()
+	c.SetUID("appnexus", "user-123")
+	httpCookie, err := store.HTTPCookie(context.Background(), c, "example.com", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if store.ParseCookie(requestWithCookie(httpCookie), nil).GetUID("appnexus") != "user-123" || c.StoreID != "" {
+		t.Error("expected a nil store to keep UIDs in the cookie")
+	}
+}
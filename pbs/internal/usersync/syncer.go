@@ -32,6 +32,50 @@ type SyncerConfig struct {
 	Enabled bool
 }
 
+// syncMacros are the placeholders GetSync fills in, with sample values for
+// checking that templates make valid URLs
+var syncMacros = map[string]string{
+	"{{gdpr}}":         "1",
+	"{{gdpr_consent}}": "consent",
+	"{{us_privacy}}":   "1YNN",
+	"{{redirect_url}}": url.QueryEscape("https://pbs.example/setuid?bidder=x&uid=$UID"),
+}
+
+// Validate checks the URL templates: an enabled syncer needs one, every
+// {{...}} must be a known macro, a redirect sync must pass {{redirect_url}},
+// and each template must make an absolute http(s) URL once filled in
+func (c SyncerConfig) Validate() error {
+	if c.Enabled && c.IframeSyncURL == "" && c.RedirectSyncURL == "" {
+		return fmt.Errorf("%s: no sync URL", c.BidderCode)
+	}
+	if c.RedirectSyncURL != "" && !strings.Contains(c.RedirectSyncURL, "{{redirect_url}}") {
+		return fmt.Errorf("%s: redirect sync URL doesn't pass {{redirect_url}}", c.BidderCode)
+	}
+	for _, template := range []string{c.IframeSyncURL, c.RedirectSyncURL} {
+		if template == "" {
+			continue
+		}
+		filled := template
+		for macro, sample := range syncMacros {
+			filled = strings.ReplaceAll(filled, macro, sample)
+		}
+		if i := strings.Index(filled, "{{"); i != -1 {
+			return fmt.Errorf("%s: unknown or unclosed macro at %q", c.BidderCode, filled[i:min(i+20, len(filled))])
+		}
+		if strings.Contains(filled, "}}") {
+			return fmt.Errorf("%s: unopened macro in %q", c.BidderCode, template)
+		}
+		u, err := url.Parse(filled)
+		if err != nil {
+			return fmt.Errorf("%s: %w", c.BidderCode, err)
+		}
+		if (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("%s: sync URL %q isn't an absolute http(s) URL", c.BidderCode, template)
+		}
+	}
+	return nil
+}
+
 // Syncer handles user sync URL generation for a bidder
 type Syncer struct {
 	config   SyncerConfig
@@ -162,3 +162,29 @@ func TestSyncerUSPrivacy(t *testing.T) {
 		t.Errorf("URL should contain US privacy string, got: %s", syncInfo.URL)
 	}
 }
+
+func TestSyncerConfigValidate(t *testing.T) {
+	for code, config := range DefaultSyncerConfigs() {
+		if err := config.Validate(); err != nil {
+			t.Errorf("%s: expected the default syncer valid, got %v", code, err)
+		}
+	}
+
+	for name, config := range map[string]SyncerConfig{
+		"no URL":          {BidderCode: "x", Enabled: true},
+		"unknown macro":   {BidderCode: "x", RedirectSyncURL: "https://x.example/sync?gdpr={{gdpr_applies}}&r={{redirect_url}}"},
+		"unclosed macro":  {BidderCode: "x", RedirectSyncURL: "https://x.example/sync?gdpr={{gdpr&r={{redirect_url}}"},
+		"unopened macro":  {BidderCode: "x", IframeSyncURL: "https://x.example/sync?gdpr=gdpr}}"},
+		"no redirect_url": {BidderCode: "x", RedirectSyncURL: "https://x.example/sync?gdpr={{gdpr}}"},
+		"relative URL":    {BidderCode: "x", IframeSyncURL: "/sync?gdpr={{gdpr}}"},
+		"non-http scheme": {BidderCode: "x", IframeSyncURL: "javascript:alert(1)//{{gdpr}}"},
+	} {
+		if err := config.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	if err := (SyncerConfig{BidderCode: "x"}).Validate(); err != nil {
+		t.Errorf("expected a disabled syncer without URLs valid, got %v", err)
+	}
+}
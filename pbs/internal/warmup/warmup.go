@@ -0,0 +1,180 @@
+// Package warmup runs startup work (parsing, registry snapshots, pool priming,
+// a synthetic auction) before the instance reports ready, so the first real
+// requests after a deploy don't pay for cold caches
+package warmup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/logger"
+)
+
+// StepFunc performs one warmup step
+type StepFunc func(ctx context.Context) error
+
+type step struct {
+	name string
+	fn   StepFunc
+}
+
+// StepResult is the outcome of a single warmup step
+type StepResult struct {
+	Name       string  `json:"name"`
+	DurationMs float64 `json:"duration_ms"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// Report summarizes a warmup run
+type Report struct {
+	Ready       bool         `json:"ready"`
+	StartedAt   *time.Time   `json:"started_at,omitempty"`
+	CompletedAt *time.Time   `json:"completed_at,omitempty"`
+	Steps       []StepResult `json:"steps,omitempty"`
+}
+
+// Runner executes warmup steps in order and gates readiness on their completion.
+// Step failures are logged and reported but do not block readiness: a cold
+// instance is still better than one that never takes traffic.
+type Runner struct {
+	timeout time.Duration
+	steps   []step
+	ready   atomic.Bool
+
+	mu     sync.RWMutex
+	report Report
+}
+
+// NewRunner creates a runner whose steps share a total time budget (0 = no limit)
+func NewRunner(timeout time.Duration) *Runner {
+	return &Runner{timeout: timeout}
+}
+
+// Add registers a step; steps run in the order they were added
+func (r *Runner) Add(name string, fn StepFunc) {
+	r.steps = append(r.steps, step{name: name, fn: fn})
+}
+
+// Run executes all steps then marks the runner ready
+func (r *Runner) Run(ctx context.Context) Report {
+	if r.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+		defer cancel()
+	}
+
+	started := time.Now()
+	r.mu.Lock()
+	r.report = Report{StartedAt: &started}
+	r.mu.Unlock()
+
+	for _, s := range r.steps {
+		result := r.runStep(ctx, s)
+
+		r.mu.Lock()
+		r.report.Steps = append(r.report.Steps, result)
+		r.mu.Unlock()
+	}
+
+	completed := time.Now()
+	r.mu.Lock()
+	r.report.Ready = true
+	r.report.CompletedAt = &completed
+	report := r.snapshotLocked()
+	r.mu.Unlock()
+	r.ready.Store(true)
+
+	logger.Log.Info().
+		Dur("duration", completed.Sub(started)).
+		Int("steps", len(report.Steps)).
+		Msg("Warmup complete, instance ready")
+
+	return report
+}
+
+// runStep runs a single step, converting panics into step errors
+func (r *Runner) runStep(ctx context.Context, s step) (result StepResult) {
+	start := time.Now()
+	result.Name = s.name
+
+	defer func() {
+		if p := recover(); p != nil {
+			result.Error = fmt.Sprintf("panic: %v", p)
+		}
+		result.DurationMs = float64(time.Since(start).Microseconds()) / 1000
+		if result.Error != "" {
+			logger.Log.Warn().
+				Str("step", s.name).
+				Str("error", result.Error).
+				Msg("Warmup step failed")
+		} else {
+			logger.Log.Debug().
+				Str("step", s.name).
+				Float64("duration_ms", result.DurationMs).
+				Msg("Warmup step complete")
+		}
+	}()
+
+	if err := ctx.Err(); err != nil {
+		result.Error = "skipped: " + err.Error()
+		return result
+	}
+	if err := s.fn(ctx); err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// Ready reports whether warmup has finished
+func (r *Runner) Ready() bool {
+	return r.ready.Load()
+}
+
+// Report returns the current warmup report
+func (r *Runner) Report() Report {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.snapshotLocked()
+}
+
+func (r *Runner) snapshotLocked() Report {
+	report := r.report
+	report.Steps = append([]StepResult(nil), r.report.Steps...)
+	return report
+}
+
+// ServeHTTP implements /ready: 503 while warming up, 200 once ready
+func (r *Runner) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	status := http.StatusServiceUnavailable
+	if r.Ready() {
+		status = http.StatusOK
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(r.Report()); err != nil {
+		logger.Log.Error().Err(err).Msg("failed to encode readiness response")
+	}
+}
+
+// PrimeJSON round-trips each sample (which must be a pointer) through encoding/json,
+// populating the package's per-type encoder and decoder caches
+func PrimeJSON(samples ...interface{}) error {
+	for _, sample := range samples {
+		data, err := json.Marshal(sample)
+		if err != nil {
+			return fmt.Errorf("marshal %T: %w", sample, err)
+		}
+		target := reflect.New(reflect.TypeOf(sample).Elem()).Interface()
+		if err := json.Unmarshal(data, target); err != nil {
+			return fmt.Errorf("unmarshal %T: %w", sample, err)
+		}
+	}
+	return nil
+}
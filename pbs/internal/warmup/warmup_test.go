@@ -0,0 +1,119 @@
+package warmup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRunner_ReadyAfterRun(t *testing.T) {
+	r := NewRunner(time.Second)
+	var order []string
+	r.Add("first", func(ctx context.Context) error {
+		order = append(order, "first")
+		return nil
+	})
+	r.Add("second", func(ctx context.Context) error {
+		order = append(order, "second")
+		return errors.New("boom")
+	})
+
+	if r.Ready() {
+		t.Fatal("expected not ready before Run")
+	}
+
+	report := r.Run(context.Background())
+
+	if !r.Ready() || !report.Ready {
+		t.Error("expected ready after Run, even with a failed step")
+	}
+	if len(order) != 2 || order[0] != "first" || order[1] != "second" {
+		t.Errorf("expected steps to run in order, got %v", order)
+	}
+	if len(report.Steps) != 2 || report.Steps[0].Error != "" || report.Steps[1].Error != "boom" {
+		t.Errorf("unexpected step results: %+v", report.Steps)
+	}
+	if report.StartedAt == nil || report.CompletedAt == nil {
+		t.Error("expected start and completion times")
+	}
+}
+
+func TestRunner_PanicIsReportedAsError(t *testing.T) {
+	r := NewRunner(0)
+	r.Add("panics", func(ctx context.Context) error {
+		panic("bad step")
+	})
+	r.Add("after", func(ctx context.Context) error { return nil })
+
+	report := r.Run(context.Background())
+
+	if report.Steps[0].Error != "panic: bad step" {
+		t.Errorf("expected panic to be recorded, got %q", report.Steps[0].Error)
+	}
+	if report.Steps[1].Error != "" {
+		t.Errorf("expected later steps to still run, got %q", report.Steps[1].Error)
+	}
+}
+
+func TestRunner_TimeoutSkipsRemainingSteps(t *testing.T) {
+	r := NewRunner(20 * time.Millisecond)
+	r.Add("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	r.Add("skipped", func(ctx context.Context) error {
+		t.Error("expected step to be skipped after budget is spent")
+		return nil
+	})
+
+	report := r.Run(context.Background())
+
+	if !report.Ready {
+		t.Error("expected ready once the budget is exhausted")
+	}
+	if report.Steps[1].Error == "" {
+		t.Error("expected skipped step to report why")
+	}
+}
+
+func TestRunner_ServeHTTP(t *testing.T) {
+	r := NewRunner(time.Second)
+	r.Add("noop", func(ctx context.Context) error { return nil })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/ready", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 while warming up, got %d", w.Code)
+	}
+
+	r.Run(context.Background())
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/ready", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200 after warmup, got %d", w.Code)
+	}
+	var report Report
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if !report.Ready || len(report.Steps) != 1 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+}
+
+func TestPrimeJSON(t *testing.T) {
+	type sample struct {
+		Name string `json:"name"`
+	}
+	if err := PrimeJSON(&sample{Name: "x"}, &map[string]int{"a": 1}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := PrimeJSON(&struct{ C chan int }{}); err == nil {
+		t.Error("expected error for unencodable type")
+	}
+}
@@ -0,0 +1,149 @@
+// Package cache is a client for Prebid Cache, which stores creatives (VAST XML
+// for video players, bid JSON for Prebid.js) so clients can fetch them by ID.
+// It has no dependencies on the rest of the server.
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Entry value types
+const (
+	TypeXML  = "xml"
+	TypeJSON = "json"
+)
+
+// Client defaults
+const (
+	DefaultTimeout = 100 * time.Millisecond
+	DefaultTTL     = 5 * time.Minute
+
+	maxResponseBody = 1 << 20
+)
+
+// Entry is one value to store
+type Entry struct {
+	Type       string          `json:"type"`
+	Value      json.RawMessage `json:"value"` // A JSON string for TypeXML
+	TTLSeconds int             `json:"ttlseconds,omitempty"`
+}
+
+// Config configures a Client
+type Config struct {
+	Endpoint  string        // Prebid Cache POST endpoint, e.g. https://cache.example.com/cache
+	PublicURL string        // Where clients fetch entries, if not the endpoint
+	Timeout   time.Duration // Per-put timeout
+	TTL       time.Duration // Applied to entries without their own TTL
+}
+
+// Client stores entries in Prebid Cache. Safe for concurrent use.
+type Client struct {
+	config    Config
+	client    *http.Client
+	publicURL *url.URL
+}
+
+type putRequest struct {
+	Puts []Entry `json:"puts"`
+}
+
+type putResponse struct {
+	Responses []struct {
+		UUID string `json:"uuid"`
+	} `json:"responses"`
+}
+
+// NewClient creates a client; client may be nil to use a default client
+func NewClient(config Config, client *http.Client) (*Client, error) {
+	if _, err := url.ParseRequestURI(config.Endpoint); err != nil {
+		return nil, fmt.Errorf("invalid cache endpoint: %w", err)
+	}
+	if config.PublicURL == "" {
+		config.PublicURL = config.Endpoint
+	}
+	publicURL, err := url.ParseRequestURI(config.PublicURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cache public URL: %w", err)
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTimeout
+	}
+	if config.TTL <= 0 {
+		config.TTL = DefaultTTL
+	}
+	if client == nil {
+		client = &http.Client{}
+	}
+	return &Client{config: config, client: client, publicURL: publicURL}, nil
+}
+
+// Put stores entries and returns their IDs in the same order. It gives up
+// after the configured timeout, so callers on a latency budget aren't held up
+// by a slow cache.
+func (c *Client) Put(ctx context.Context, entries []Entry) ([]string, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	ttl := int(c.config.TTL / time.Second)
+	puts := make([]Entry, len(entries))
+	for i, entry := range entries {
+		if entry.TTLSeconds <= 0 {
+			entry.TTLSeconds = ttl
+		}
+		puts[i] = entry
+	}
+	body, err := json.Marshal(putRequest{Puts: puts})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.config.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("cache returned %d", resp.StatusCode)
+	}
+
+	var parsed putResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseBody)).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("parse cache response: %w", err)
+	}
+	if len(parsed.Responses) != len(entries) {
+		return nil, fmt.Errorf("cache stored %d of %d entries", len(parsed.Responses), len(entries))
+	}
+	ids := make([]string, len(entries))
+	for i, r := range parsed.Responses {
+		if r.UUID == "" {
+			return nil, errors.New("cache returned an empty ID")
+		}
+		ids[i] = r.UUID
+	}
+	return ids, nil
+}
+
+// URL returns where clients fetch the entry with the given ID
+func (c *Client) URL(id string) string {
+	u := *c.publicURL
+	query := u.Query()
+	query.Set("uuid", id)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
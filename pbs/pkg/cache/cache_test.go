@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// cacheServer answers puts with sequential IDs and keeps the last request
+func cacheServer(t *testing.T, delay time.Duration, last *putRequest) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req putRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if last != nil {
+			*last = req
+		}
+		time.Sleep(delay)
+		var resp putResponse
+		for i := range req.Puts {
+			resp.Responses = append(resp.Responses, struct {
+				UUID string `json:"uuid"`
+			}{UUID: fmt.Sprintf("id-%d", i)})
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClient_Put(t *testing.T) {
+	var got putRequest
+	srv := cacheServer(t, 0, &got)
+	c, err := NewClient(Config{Endpoint: srv.URL + "/cache", TTL: time.Minute}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ids, err := c.Put(context.Background(), []Entry{
+		{Type: TypeXML, Value: json.RawMessage(`"<VAST/>"`)},
+		{Type: TypeJSON, Value: json.RawMessage(`{"id":"bid1"}`), TTLSeconds: 30},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ids) != 2 || ids[0] != "id-0" || ids[1] != "id-1" {
+		t.Errorf("expected IDs in entry order, got %v", ids)
+	}
+	if len(got.Puts) != 2 || got.Puts[0].TTLSeconds != 60 || got.Puts[1].TTLSeconds != 30 {
+		t.Errorf("expected the default TTL only where unset, got %+v", got.Puts)
+	}
+}
+
+func TestClient_PutTimeout(t *testing.T) {
+	srv := cacheServer(t, 200*time.Millisecond, nil)
+	c, _ := NewClient(Config{Endpoint: srv.URL, Timeout: 20 * time.Millisecond}, nil)
+
+	start := time.Now()
+	if _, err := c.Put(context.Background(), []Entry{{Type: TypeXML, Value: json.RawMessage(`"<VAST/>"`)}}); err == nil {
+		t.Error("expected a timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("expected the put to give up at its timeout, took %v", elapsed)
+	}
+}
+
+func TestClient_PutErrors(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	short := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"responses":[]}`))
+	}))
+	defer short.Close()
+
+	for _, srv := range []*httptest.Server{failing, short} {
+		c, _ := NewClient(Config{Endpoint: srv.URL}, nil)
+		if _, err := c.Put(context.Background(), []Entry{{Type: TypeJSON, Value: json.RawMessage(`{}`)}}); err == nil {
+			t.Errorf("expected an error from %s", srv.URL)
+		}
+	}
+}
+
+func TestClient_URL(t *testing.T) {
+	c, _ := NewClient(Config{Endpoint: "http://cache.internal/cache"}, nil)
+	if got := c.URL("abc"); got != "http://cache.internal/cache?uuid=abc" {
+		t.Errorf("unexpected URL %s", got)
+	}
+
+	c, _ = NewClient(Config{Endpoint: "http://cache.internal/cache", PublicURL: "https://cache.example.com/get?v=1"}, nil)
+	if got := c.URL("abc"); got != "https://cache.example.com/get?uuid=abc&v=1" {
+		t.Errorf("unexpected URL %s", got)
+	}
+}
+
+func TestNewClient_InvalidEndpoint(t *testing.T) {
+	if _, err := NewClient(Config{Endpoint: "not a url"}, nil); err == nil {
+		t.Error("expected an error for an invalid endpoint")
+	}
+}
@@ -0,0 +1,90 @@
+// Package currency converts amounts between ISO 4217 currencies from a table
+// of exchange rates against one base currency. It has no dependencies on the
+// rest of the server so other services convert prices with the same rates
+// and rules as the exchange.
+package currency
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DefaultCurrency is assumed when a currency is omitted, as in OpenRTB
+const DefaultCurrency = "USD"
+
+// ErrUnknownCurrency is returned for a currency missing from the rate table
+var ErrUnknownCurrency = errors.New("unknown currency")
+
+// Converter returns the rate that converts an amount in from into to
+type Converter interface {
+	Rate(from, to string) (float64, error)
+}
+
+// Normalize upper-cases a currency code and fills in DefaultCurrency when it is empty
+func Normalize(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return DefaultCurrency
+	}
+	return code
+}
+
+// Rates is an immutable rate table: units of each currency per one unit of
+// the base currency. Safe for concurrent use.
+type Rates struct {
+	base    string
+	perBase map[string]float64
+}
+
+// NewRates builds a rate table. perBase maps currency codes to the units of
+// that currency one unit of base buys, e.g. base USD with {"EUR": 0.92}.
+func NewRates(base string, perBase map[string]float64) (*Rates, error) {
+	base = Normalize(base)
+	r := &Rates{base: base, perBase: map[string]float64{base: 1}}
+	for code, rate := range perBase {
+		code = Normalize(code)
+		if len(code) != 3 {
+			return nil, fmt.Errorf("currency code must have 3 letters, got %q", code)
+		}
+		if rate <= 0 {
+			return nil, fmt.Errorf("rate for %s must be positive, got %v", code, rate)
+		}
+		if code == base && rate != 1 {
+			return nil, fmt.Errorf("base currency %s must have rate 1, got %v", code, rate)
+		}
+		r.perBase[code] = rate
+	}
+	return r, nil
+}
+
+// Base returns the base currency
+func (r *Rates) Base() string {
+	return r.base
+}
+
+// Rate returns the rate that converts an amount in from into to
+func (r *Rates) Rate(from, to string) (float64, error) {
+	from, to = Normalize(from), Normalize(to)
+	if from == to {
+		return 1, nil
+	}
+	fromRate, ok := r.perBase[from]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownCurrency, from)
+	}
+	toRate, ok := r.perBase[to]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownCurrency, to)
+	}
+	return toRate / fromRate, nil
+}
+
+// Convert converts amount from one currency to another with conv
+func Convert(conv Converter, amount float64, from, to string) (float64, error) {
+	rate, err := conv.Rate(from, to)
+	if err != nil {
+		return 0, err
+	}
+	return amount * rate, nil
+}
@@ -0,0 +1,68 @@
+package currency
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func testRates(t *testing.T) *Rates {
+	t.Helper()
+	r, err := NewRates("usd", map[string]float64{"EUR": 0.9, "gbp": 0.8})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return r
+}
+
+func TestRates_Rate(t *testing.T) {
+	r := testRates(t)
+	for _, tc := range []struct {
+		from, to string
+		want     float64
+	}{
+		{"USD", "EUR", 0.9},
+		{"EUR", "USD", 1 / 0.9},
+		{"EUR", "GBP", 0.8 / 0.9},
+		{"gbp", "GBP", 1},
+		{"", "EUR", 0.9}, // empty means USD
+		{"JPY", "JPY", 1},
+	} {
+		got, err := r.Rate(tc.from, tc.to)
+		if err != nil || math.Abs(got-tc.want) > 1e-12 {
+			t.Errorf("Rate(%s, %s) = %v, %v; want %v", tc.from, tc.to, got, err, tc.want)
+		}
+	}
+	if r.Base() != "USD" {
+		t.Errorf("expected normalized base, got %s", r.Base())
+	}
+}
+
+func TestRates_UnknownCurrency(t *testing.T) {
+	r := testRates(t)
+	if _, err := r.Rate("USD", "JPY"); !errors.Is(err, ErrUnknownCurrency) {
+		t.Errorf("expected ErrUnknownCurrency, got %v", err)
+	}
+	if _, err := Convert(r, 1, "CHF", "USD"); !errors.Is(err, ErrUnknownCurrency) {
+		t.Errorf("expected ErrUnknownCurrency, got %v", err)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	got, err := Convert(testRates(t), 2, "EUR", "USD")
+	if err != nil || math.Abs(got-2/0.9) > 1e-12 {
+		t.Errorf("expected 2 EUR in USD, got %v, %v", got, err)
+	}
+}
+
+func TestNewRates_Invalid(t *testing.T) {
+	for name, perBase := range map[string]map[string]float64{
+		"zero rate":  {"EUR": 0},
+		"bad code":   {"EURO": 1.1},
+		"base not 1": {"USD": 2},
+	} {
+		if _, err := NewRates("USD", perBase); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
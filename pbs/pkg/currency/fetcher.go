@@ -0,0 +1,201 @@
+package currency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Fetcher defaults
+const (
+	DefaultRefreshInterval = time.Hour
+	DefaultStaleAfter      = 24 * time.Hour
+	DefaultFetchTimeout    = 10 * time.Second
+
+	maxRatesBody = 1 << 20
+)
+
+// ErrNoRates is returned when neither fresh fetched rates nor a fallback are available
+var ErrNoRates = errors.New("no current exchange rates")
+
+// FetcherConfig configures a Fetcher
+type FetcherConfig struct {
+	URL             string          // Rate file in the Prebid currency file format
+	Base            string          // Currency rates are kept against (default USD)
+	RefreshInterval time.Duration   // Time between fetches
+	StaleAfter      time.Duration   // Fetched rates older than this are not used (0 = never stale)
+	Timeout         time.Duration   // Per-fetch timeout
+	Fallback        Converter       // Used before the first fetch and once rates go stale (optional)
+	OnRefresh       func(err error) // Called after every fetch, e.g. for logging (optional)
+}
+
+// Fetcher keeps exchange rates from a remote rate file, refreshed in the
+// background. A failed refresh keeps the last good rates until they are
+// StaleAfter old, then Rate uses the fallback converter. Safe for
+// concurrent use; implements Converter.
+type Fetcher struct {
+	config FetcherConfig
+	client *http.Client
+	now    func() time.Time
+
+	mu        sync.RWMutex
+	rates     *Rates
+	fetchedAt time.Time
+
+	stopOnce sync.Once
+	stopChan chan struct{}
+}
+
+// rateFile is the Prebid currency file format: conversions[from][to] = rate
+type rateFile struct {
+	Conversions map[string]map[string]float64 `json:"conversions"`
+}
+
+// NewFetcher creates a fetcher; call Start to begin refreshing. client may be
+// nil to use a default client.
+func NewFetcher(config FetcherConfig, client *http.Client) *Fetcher {
+	config.Base = Normalize(config.Base)
+	if config.RefreshInterval <= 0 {
+		config.RefreshInterval = DefaultRefreshInterval
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultFetchTimeout
+	}
+	if client == nil {
+		client = &http.Client{}
+	}
+	return &Fetcher{
+		config:   config,
+		client:   client,
+		now:      time.Now,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start fetches rates once and then refreshes them every RefreshInterval
+// until Stop is called or ctx ends. The first fetch's error is returned;
+// the fallback covers Rate calls until a fetch succeeds.
+func (f *Fetcher) Start(ctx context.Context) error {
+	err := f.Refresh(ctx)
+	go f.loop(ctx)
+	return err
+}
+
+// Stop stops background refreshes
+func (f *Fetcher) Stop() {
+	f.stopOnce.Do(func() { close(f.stopChan) })
+}
+
+func (f *Fetcher) loop(ctx context.Context) {
+	ticker := time.NewTicker(f.config.RefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			f.Refresh(ctx)
+		case <-f.stopChan:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Refresh fetches the rate file once, replacing the cached rates on success
+func (f *Fetcher) Refresh(ctx context.Context) error {
+	rates, err := f.fetch(ctx)
+
+	f.mu.Lock()
+	if err == nil {
+		f.rates = rates
+		f.fetchedAt = f.now()
+	}
+	f.mu.Unlock()
+
+	if f.config.OnRefresh != nil {
+		f.config.OnRefresh(err)
+	}
+	return err
+}
+
+func (f *Fetcher) fetch(ctx context.Context) (*Rates, error) {
+	ctx, cancel := context.WithTimeout(ctx, f.config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.config.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("rate source returned %d", resp.StatusCode)
+	}
+
+	var file rateFile
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxRatesBody)).Decode(&file); err != nil {
+		return nil, fmt.Errorf("parse rate file: %w", err)
+	}
+	return file.rates(f.config.Base)
+}
+
+// rates builds a table against base from the file's conversions, using the
+// base's own row or deriving it from any row that quotes the base
+func (file rateFile) rates(base string) (*Rates, error) {
+	for from, row := range file.Conversions {
+		if Normalize(from) == base {
+			return NewRates(base, row)
+		}
+	}
+	for from, row := range file.Conversions {
+		baseRate, ok := row[base]
+		if !ok || baseRate <= 0 {
+			continue
+		}
+		perBase := make(map[string]float64, len(row)+1)
+		for code, rate := range row {
+			if Normalize(code) != base {
+				perBase[code] = rate / baseRate
+			}
+		}
+		perBase[from] = 1 / baseRate
+		return NewRates(base, perBase)
+	}
+	return nil, fmt.Errorf("rate file has no rates for %s", base)
+}
+
+// current returns the fetched rates if they are fresh
+func (f *Fetcher) current() *Rates {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if f.rates == nil {
+		return nil
+	}
+	if f.config.StaleAfter > 0 && f.now().Sub(f.fetchedAt) > f.config.StaleAfter {
+		return nil
+	}
+	return f.rates
+}
+
+// Rate converts with the fetched rates, or the fallback when there are none
+// or they are stale
+func (f *Fetcher) Rate(from, to string) (float64, error) {
+	if Normalize(from) == Normalize(to) {
+		return 1, nil
+	}
+	if rates := f.current(); rates != nil {
+		return rates.Rate(from, to)
+	}
+	if f.config.Fallback != nil {
+		return f.config.Fallback.Rate(from, to)
+	}
+	return 0, ErrNoRates
+}
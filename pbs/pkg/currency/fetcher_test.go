@@ -0,0 +1,125 @@
+package currency
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// rateServer serves body until failing is set
+func rateServer(t *testing.T, body string, failing *atomic.Bool) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing != nil && failing.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func assertRate(t *testing.T, conv Converter, from, to string, want float64) {
+	t.Helper()
+	got, err := conv.Rate(from, to)
+	if err != nil || math.Abs(got-want) > 1e-9 {
+		t.Errorf("Rate(%s, %s) = %v, %v; want %v", from, to, got, err, want)
+	}
+}
+
+func TestFetcher_Refresh(t *testing.T) {
+	srv := rateServer(t, `{"dataAsOf":"2026-10-01","conversions":{"USD":{"EUR":0.9,"GBP":0.8,"JPY":150}}}`, nil)
+	f := NewFetcher(FetcherConfig{URL: srv.URL}, nil)
+
+	if _, err := f.Rate("EUR", "USD"); !errors.Is(err, ErrNoRates) {
+		t.Errorf("expected ErrNoRates before the first fetch, got %v", err)
+	}
+	if err := f.Refresh(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertRate(t, f, "EUR", "USD", 1/0.9)
+	assertRate(t, f, "JPY", "GBP", 0.8/150)
+	assertRate(t, f, "SEK", "SEK", 1)
+}
+
+func TestFetcher_DerivesBaseFromOtherRow(t *testing.T) {
+	srv := rateServer(t, `{"conversions":{"EUR":{"USD":1.25,"GBP":0.875}}}`, nil)
+	f := NewFetcher(FetcherConfig{URL: srv.URL}, nil)
+	if err := f.Refresh(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertRate(t, f, "EUR", "USD", 1.25)
+	assertRate(t, f, "GBP", "USD", 1.25/0.875)
+}
+
+func TestFetcher_FailedRefreshKeepsRates(t *testing.T) {
+	var failing atomic.Bool
+	srv := rateServer(t, `{"conversions":{"USD":{"EUR":0.9}}}`, &failing)
+	var refreshErrs []error
+	f := NewFetcher(FetcherConfig{URL: srv.URL, OnRefresh: func(err error) { refreshErrs = append(refreshErrs, err) }}, nil)
+
+	f.Refresh(context.Background())
+	failing.Store(true)
+	if err := f.Refresh(context.Background()); err == nil {
+		t.Error("expected refresh error")
+	}
+	assertRate(t, f, "EUR", "USD", 1/0.9)
+	if len(refreshErrs) != 2 || refreshErrs[0] != nil || refreshErrs[1] == nil {
+		t.Errorf("expected OnRefresh after each fetch, got %v", refreshErrs)
+	}
+}
+
+func TestFetcher_StaleRatesUseFallback(t *testing.T) {
+	srv := rateServer(t, `{"conversions":{"USD":{"EUR":0.9}}}`, nil)
+	fallback, _ := NewRates("USD", map[string]float64{"EUR": 0.5})
+	f := NewFetcher(FetcherConfig{URL: srv.URL, StaleAfter: time.Hour, Fallback: fallback}, nil)
+	now := time.Now()
+	f.now = func() time.Time { return now }
+
+	assertRate(t, f, "EUR", "USD", 2) // Fallback before the first fetch
+	f.Refresh(context.Background())
+	assertRate(t, f, "EUR", "USD", 1/0.9)
+
+	now = now.Add(2 * time.Hour)
+	assertRate(t, f, "EUR", "USD", 2)
+}
+
+func TestFetcher_InvalidFiles(t *testing.T) {
+	for name, body := range map[string]string{
+		"not json":    `<html>`,
+		"no base":     `{"conversions":{"EUR":{"GBP":0.9}}}`,
+		"bad rate":    `{"conversions":{"USD":{"EUR":-1}}}`,
+		"empty table": `{}`,
+	} {
+		f := NewFetcher(FetcherConfig{URL: rateServer(t, body, nil).URL}, nil)
+		if err := f.Refresh(context.Background()); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestFetcher_StartStop(t *testing.T) {
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		w.Write([]byte(`{"conversions":{"USD":{"EUR":0.9}}}`))
+	}))
+	defer srv.Close()
+
+	f := NewFetcher(FetcherConfig{URL: srv.URL, RefreshInterval: 10 * time.Millisecond}, nil)
+	if err := f.Start(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	f.Stop()
+	f.Stop() // Idempotent
+	if fetches.Load() < 2 {
+		t.Errorf("expected periodic refreshes, got %d fetches", fetches.Load())
+	}
+}
@@ -0,0 +1,35 @@
+// Package floors resolves bid floors into the currency an auction runs in and
+// checks prices against them. It depends only on pkg/currency so other
+// services apply floors exactly as the exchange does.
+package floors
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/currency"
+)
+
+// ErrNoConverter is returned when a floor needs converting but no rates are available
+var ErrNoConverter = errors.New("floor currency differs from target and no converter is configured")
+
+// Resolve returns a floor set in floorCur expressed in target. An empty
+// currency means USD, as in OpenRTB. Floors of zero or below mean no floor and
+// resolve to 0. conv may be nil when no conversion is needed.
+func Resolve(floor float64, floorCur, target string, conv currency.Converter) (float64, error) {
+	if floor <= 0 {
+		return 0, nil
+	}
+	if currency.Normalize(floorCur) == currency.Normalize(target) {
+		return floor, nil
+	}
+	if conv == nil {
+		return 0, fmt.Errorf("%w: %s to %s", ErrNoConverter, currency.Normalize(floorCur), currency.Normalize(target))
+	}
+	return currency.Convert(conv, floor, floorCur, target)
+}
+
+// Meets reports whether price clears floor. A floor of zero or below is no floor.
+func Meets(price, floor float64) bool {
+	return floor <= 0 || price >= floor
+}
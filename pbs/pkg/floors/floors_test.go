@@ -0,0 +1,50 @@
+package floors
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/currency"
+)
+
+func TestResolve(t *testing.T) {
+	rates, _ := currency.NewRates("USD", map[string]float64{"EUR": 0.8})
+
+	for _, tc := range []struct {
+		name     string
+		floor    float64
+		floorCur string
+		want     float64
+	}{
+		{"same currency", 1.5, "USD", 1.5},
+		{"empty means USD", 1.5, "", 1.5},
+		{"converted", 2, "EUR", 2.5},
+		{"no floor", 0, "EUR", 0},
+		{"negative", -1, "USD", 0},
+	} {
+		got, err := Resolve(tc.floor, tc.floorCur, "USD", rates)
+		if err != nil || math.Abs(got-tc.want) > 1e-12 {
+			t.Errorf("%s: got %v, %v; want %v", tc.name, got, err, tc.want)
+		}
+	}
+}
+
+func TestResolve_Errors(t *testing.T) {
+	if _, err := Resolve(1, "EUR", "USD", nil); !errors.Is(err, ErrNoConverter) {
+		t.Errorf("expected ErrNoConverter, got %v", err)
+	}
+	if got, err := Resolve(1, "usd", "USD", nil); err != nil || got != 1 {
+		t.Errorf("expected no converter needed for the same currency, got %v, %v", got, err)
+	}
+	rates, _ := currency.NewRates("USD", nil)
+	if _, err := Resolve(1, "JPY", "USD", rates); !errors.Is(err, currency.ErrUnknownCurrency) {
+		t.Errorf("expected ErrUnknownCurrency, got %v", err)
+	}
+}
+
+func TestMeets(t *testing.T) {
+	if !Meets(1.5, 1.5) || !Meets(0.01, 0) || Meets(1.49, 1.5) {
+		t.Error("unexpected floor comparison")
+	}
+}
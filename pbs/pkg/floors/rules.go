@@ -0,0 +1,191 @@
+package floors
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Schema fields a floor rule can match on
+const (
+	FieldMediaType  = "mediaType"  // banner, video, audio or native
+	FieldSize       = "size"       // WxH
+	FieldDomain     = "domain"     // Site or app domain
+	FieldBundle     = "bundle"     // App bundle
+	FieldCountry    = "country"    // Device country (ISO-3166-1 alpha-3, as in OpenRTB)
+	FieldDeviceType = "deviceType" // desktop, phone, tablet or ctv
+	FieldAdUnitCode = "adUnitCode" // Imp tagid
+	FieldBidder     = "bidder"     // Bidder code, for floors that differ by bidder
+)
+
+// Wildcard matches any value of a field, including an unknown one
+const Wildcard = "*"
+
+// defaultDelimiter separates field values in rule keys
+const defaultDelimiter = "|"
+
+var knownFields = map[string]bool{
+	FieldMediaType: true, FieldSize: true, FieldDomain: true, FieldBundle: true,
+	FieldCountry: true, FieldDeviceType: true, FieldAdUnitCode: true, FieldBidder: true,
+}
+
+// Rules are the dynamic floors of a request's ext.prebid.floors, in the
+// Prebid floors module format: a schema naming the fields rule keys are built
+// from, rule values by key, and a default for requests no rule matches.
+type Rules struct {
+	Currency    string  // Currency of the rule values, default and floor minimum
+	FloorMin    float64 // No resolved floor is lower than this
+	Enforce     bool    // Reject bids under the resolved floor (enforcement.enforcepbs)
+	Default     float64 // Floor when no rule matches; 0 keeps the imp's own floor
+	fields      []string
+	rules       []rule
+	bidderField bool
+}
+
+// rule is one rule key split into field values, lowercased
+type rule struct {
+	values []string
+	floor  float64
+}
+
+// rulesJSON is ext.prebid.floors. Only the first model group is used.
+type rulesJSON struct {
+	Enabled     *bool   `json:"enabled"`
+	FloorMin    float64 `json:"floormin"`
+	FloorMinCur string  `json:"floormincur"`
+	Enforcement *struct {
+		EnforcePBS *bool `json:"enforcepbs"`
+	} `json:"enforcement"`
+	Data *struct {
+		Currency    string `json:"currency"`
+		ModelGroups []struct {
+			Currency string `json:"currency"`
+			Schema   struct {
+				Fields    []string `json:"fields"`
+				Delimiter string   `json:"delimiter"`
+			} `json:"schema"`
+			Values  map[string]float64 `json:"values"`
+			Default float64            `json:"default"`
+		} `json:"modelgroups"`
+	} `json:"data"`
+}
+
+// ParseRules reads a request's ext.prebid.floors object. It returns nil
+// without an error when floors are absent or disabled.
+func ParseRules(raw json.RawMessage) (*Rules, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var parsed rulesJSON
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		return nil, fmt.Errorf("invalid floors: %w", err)
+	}
+	if parsed.Enabled != nil && !*parsed.Enabled {
+		return nil, nil
+	}
+	if parsed.FloorMin < 0 || math.IsNaN(parsed.FloorMin) {
+		return nil, fmt.Errorf("floormin must not be negative")
+	}
+
+	r := &Rules{FloorMin: parsed.FloorMin, Enforce: true}
+	if parsed.Enforcement != nil && parsed.Enforcement.EnforcePBS != nil {
+		r.Enforce = *parsed.Enforcement.EnforcePBS
+	}
+	if parsed.Data == nil || len(parsed.Data.ModelGroups) == 0 {
+		if r.FloorMin == 0 {
+			return nil, nil
+		}
+		r.Currency = parsed.FloorMinCur
+		return r, nil
+	}
+
+	group := parsed.Data.ModelGroups[0]
+	r.Currency = group.Currency
+	if r.Currency == "" {
+		r.Currency = parsed.Data.Currency
+	}
+	if parsed.FloorMinCur != "" && r.Currency != "" && !strings.EqualFold(parsed.FloorMinCur, r.Currency) {
+		return nil, fmt.Errorf("floormincur %s differs from the rules currency %s", parsed.FloorMinCur, r.Currency)
+	}
+	if r.Currency == "" {
+		r.Currency = parsed.FloorMinCur
+	}
+	if group.Default < 0 || math.IsNaN(group.Default) {
+		return nil, fmt.Errorf("default floor must not be negative")
+	}
+	r.Default = group.Default
+
+	if len(group.Schema.Fields) == 0 {
+		return nil, fmt.Errorf("schema.fields is required")
+	}
+	for _, field := range group.Schema.Fields {
+		if !knownFields[field] {
+			return nil, fmt.Errorf("unknown schema field %q", field)
+		}
+		r.bidderField = r.bidderField || field == FieldBidder
+	}
+	r.fields = group.Schema.Fields
+
+	delimiter := group.Schema.Delimiter
+	if delimiter == "" {
+		delimiter = defaultDelimiter
+	}
+	r.rules = make([]rule, 0, len(group.Values))
+	for key, floor := range group.Values {
+		values := strings.Split(strings.ToLower(key), delimiter)
+		if len(values) != len(r.fields) {
+			return nil, fmt.Errorf("rule %q has %d values for %d schema fields", key, len(values), len(r.fields))
+		}
+		if floor < 0 || math.IsNaN(floor) {
+			return nil, fmt.Errorf("rule %q: floor must not be negative", key)
+		}
+		r.rules = append(r.rules, rule{values: values, floor: floor})
+	}
+	return r, nil
+}
+
+// Fields returns the schema fields rule keys are built from
+func (r *Rules) Fields() []string {
+	return r.fields
+}
+
+// VariesByBidder reports whether the schema includes the bidder, so floors
+// must be resolved per bidder rather than once per imp
+func (r *Rules) VariesByBidder() bool {
+	return r.bidderField
+}
+
+// Lookup returns the floor for the given field values, in Rules.Currency.
+// Missing values only match wildcards. The most specific matching rule
+// wins: one exact on an earlier schema field beats any number of exact
+// matches on later ones. Without a match the default applies; ok is false
+// when there is neither, and the imp's own floor should stand. FloorMin is
+// applied by the caller, to whichever floor stands.
+func (r *Rules) Lookup(values map[string]string) (floor float64, ok bool) {
+	best := -1
+	for _, rl := range r.rules {
+		score := 0
+		for i, want := range rl.values {
+			if want == Wildcard {
+				continue
+			}
+			if got := values[r.fields[i]]; got == "" || strings.ToLower(got) != want {
+				score = -1
+				break
+			}
+			score |= 1 << (len(r.fields) - 1 - i)
+		}
+		if score > best {
+			best, floor = score, rl.floor
+		}
+	}
+
+	if best >= 0 {
+		return floor, true
+	}
+	if r.Default > 0 {
+		return r.Default, true
+	}
+	return 0, false
+}
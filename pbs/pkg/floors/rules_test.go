@@ -0,0 +1,104 @@
+package floors
+
+import (
+	"strings"
+	"testing"
+)
+
+const testRules = `{
+	"floormin": 0.1,
+	"data": {"currency": "EUR", "modelgroups": [{
+		"schema": {"fields": ["mediaType", "size", "domain"]},
+		"values": {
+			"banner|300x250|example.com": 2,
+			"banner|300x250|*": 1.5,
+			"banner|*|example.com": 1.2,
+			"*|*|*": 0.5,
+			"video|*|*": 3
+		},
+		"default": 0.25
+	}]}
+}`
+
+func TestParseRules(t *testing.T) {
+	r, err := ParseRules([]byte(testRules))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Currency != "EUR" || r.FloorMin != 0.1 || !r.Enforce || r.Default != 0.25 {
+		t.Errorf("unexpected rules: %+v", r)
+	}
+	if len(r.Fields()) != 3 || r.VariesByBidder() {
+		t.Errorf("unexpected schema: %v", r.Fields())
+	}
+
+	for _, raw := range []string{``, `null`, `{"enabled":false,"floormin":1}`, `{"enforcement":{"enforcepbs":false}}`} {
+		if r, err := ParseRules([]byte(raw)); r != nil || err != nil {
+			t.Errorf("%s: expected no rules, got %+v, %v", raw, r, err)
+		}
+	}
+
+	r, err = ParseRules([]byte(`{"floormin":0.5,"floormincur":"GBP","enforcement":{"enforcepbs":false}}`))
+	if err != nil || r.Currency != "GBP" || r.Enforce {
+		t.Errorf("expected a floormin-only rule set, got %+v, %v", r, err)
+	}
+	if _, ok := r.Lookup(map[string]string{FieldMediaType: "banner"}); ok {
+		t.Error("expected no floor from a floormin-only rule set")
+	}
+}
+
+func TestParseRules_Errors(t *testing.T) {
+	group := func(body string) string {
+		return `{"data":{"modelgroups":[` + body + `]}}`
+	}
+	for _, tc := range []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{"invalid JSON", `{`, "invalid floors"},
+		{"negative floormin", `{"floormin":-1}`, "floormin"},
+		{"no fields", group(`{"values":{"a":1}}`), "schema.fields"},
+		{"unknown field", group(`{"schema":{"fields":["color"]}}`), `unknown schema field "color"`},
+		{"wrong arity", group(`{"schema":{"fields":["mediaType","size"]},"values":{"banner":1}}`), "1 values for 2 schema fields"},
+		{"negative rule", group(`{"schema":{"fields":["mediaType"]},"values":{"banner":-1}}`), "must not be negative"},
+		{"negative default", group(`{"schema":{"fields":["mediaType"]},"default":-1}`), "default floor"},
+		{"currency mismatch", `{"floormincur":"USD","data":{"currency":"EUR","modelgroups":[{"schema":{"fields":["size"]}}]}}`, "differs"},
+	} {
+		if _, err := ParseRules([]byte(tc.raw)); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: expected error containing %q, got %v", tc.name, tc.want, err)
+		}
+	}
+}
+
+func TestRules_Lookup(t *testing.T) {
+	r, err := ParseRules([]byte(testRules))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, tc := range []struct {
+		name   string
+		values map[string]string
+		want   float64
+	}{
+		{"exact", map[string]string{FieldMediaType: "banner", FieldSize: "300x250", FieldDomain: "example.com"}, 2},
+		{"case-insensitive", map[string]string{FieldMediaType: "Banner", FieldSize: "300x250", FieldDomain: "EXAMPLE.com"}, 2},
+		{"earlier field outweighs later", map[string]string{FieldMediaType: "banner", FieldSize: "300x250", FieldDomain: "other.com"}, 1.5},
+		{"skips a field", map[string]string{FieldMediaType: "banner", FieldSize: "728x90", FieldDomain: "example.com"}, 1.2},
+		{"missing value matches wildcard only", map[string]string{FieldMediaType: "banner", FieldDomain: "example.com"}, 1.2},
+		{"catch-all", map[string]string{FieldMediaType: "native"}, 0.5},
+		{"media type", map[string]string{FieldMediaType: "video", FieldSize: "640x480"}, 3},
+	} {
+		if got, ok := r.Lookup(tc.values); !ok || got != tc.want {
+			t.Errorf("%s: expected %v, got %v (ok=%v)", tc.name, tc.want, got, ok)
+		}
+	}
+
+	r, _ = ParseRules([]byte(`{"data":{"modelgroups":[{"schema":{"fields":["bidder","mediaType"]},"values":{"appnexus|banner":1},"default":0.3}]}}`))
+	if !r.VariesByBidder() {
+		t.Error("expected a bidder schema to vary by bidder")
+	}
+	if got, ok := r.Lookup(map[string]string{FieldBidder: "rubicon", FieldMediaType: "banner"}); !ok || got != 0.3 {
+		t.Errorf("expected the default without a match, got %v (ok=%v)", got, ok)
+	}
+}
@@ -0,0 +1,19 @@
+package geoip
+
+import "testing"
+
+func TestAlpha3(t *testing.T) {
+	tests := map[string]string{
+		"DE":   "DEU",
+		" gb ": "GBR",
+		"fra":  "FRA",
+		"XX":   "",
+		"ZZZ":  "",
+		"":     "",
+	}
+	for code, want := range tests {
+		if got := Alpha3(code); got != want {
+			t.Errorf("Alpha3(%q) = %q, want %q", code, got, want)
+		}
+	}
+}
@@ -0,0 +1,354 @@
+package geoip
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"os"
+)
+
+// MaxMind DB format: a binary search tree over address bits whose leaf
+// records point into a data section, followed by a metadata map. See
+// https://maxmind.github.io/MaxMind-DB/
+var mmdbMetadataMarker = []byte("\xAB\xCD\xEFMaxMind.com")
+
+// mmdbDataSeparator is the run of zero bytes between the search tree and the data section
+const mmdbDataSeparator = 16
+
+// maxDecodeDepth bounds nesting in the data section so a corrupt file can't
+// recurse without end
+const maxDecodeDepth = 32
+
+// Data section field types
+const (
+	mmdbExtended = iota
+	mmdbPointer
+	mmdbString
+	mmdbDouble
+	mmdbBytes
+	mmdbUint16
+	mmdbUint32
+	mmdbMap
+	mmdbInt32
+	mmdbUint64
+	mmdbUint128
+	mmdbArray
+	mmdbContainer
+	mmdbEndMarker
+	mmdbBool
+	mmdbFloat
+)
+
+var errInvalidDatabase = errors.New("invalid MaxMind DB")
+
+// Reader looks up countries in a MaxMind DB file. The file is read into
+// memory once; safe for concurrent use.
+type Reader struct {
+	tree       []byte
+	data       []byte
+	nodeCount  uint
+	recordSize uint
+	ipVersion  uint
+	ipv4Start  uint
+
+	// DatabaseType is the metadata's database_type, e.g. "GeoLite2-Country"
+	DatabaseType string
+}
+
+// Open reads a MaxMind DB file
+func Open(path string) (*Reader, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewReader(buf)
+}
+
+// NewReader parses a MaxMind DB held in memory
+func NewReader(buf []byte) (*Reader, error) {
+	end := bytes.LastIndex(buf, mmdbMetadataMarker)
+	if end < 0 {
+		return nil, fmt.Errorf("%w: no metadata", errInvalidDatabase)
+	}
+	raw, _, err := decoder(buf[end+len(mmdbMetadataMarker):]).decode(0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("%w: metadata: %v", errInvalidDatabase, err)
+	}
+	meta, ok := raw.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: metadata is not a map", errInvalidDatabase)
+	}
+
+	r := &Reader{
+		nodeCount:  metadataUint(meta, "node_count"),
+		recordSize: metadataUint(meta, "record_size"),
+		ipVersion:  metadataUint(meta, "ip_version"),
+	}
+	r.DatabaseType, _ = meta["database_type"].(string)
+	if r.recordSize != 24 && r.recordSize != 28 && r.recordSize != 32 {
+		return nil, fmt.Errorf("%w: unsupported record size %d", errInvalidDatabase, r.recordSize)
+	}
+	if r.ipVersion != 4 && r.ipVersion != 6 {
+		return nil, fmt.Errorf("%w: unsupported IP version %d", errInvalidDatabase, r.ipVersion)
+	}
+	treeSize := r.nodeCount * r.recordSize / 4
+	if treeSize+mmdbDataSeparator > uint(end) {
+		return nil, fmt.Errorf("%w: search tree exceeds file", errInvalidDatabase)
+	}
+	r.tree = buf[:treeSize]
+	r.data = buf[treeSize+mmdbDataSeparator : end]
+
+	// IPv4 addresses live under ::/96 in an IPv6 tree
+	if r.ipVersion == 6 {
+		for i := 0; i < 96 && r.ipv4Start < r.nodeCount; i++ {
+			r.ipv4Start = r.record(r.ipv4Start, 0)
+		}
+	}
+	return r, nil
+}
+
+// metadataUint reads an unsigned metadata field, 0 if missing
+func metadataUint(meta map[string]any, key string) uint {
+	v, _ := meta[key].(uint64)
+	return uint(v)
+}
+
+// Country returns the alpha-3 code of the address's country, falling back to
+// the registered country (e.g. for anycast or satellite ranges)
+func (r *Reader) Country(ctx context.Context, ip string) (string, error) {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return "", errInvalidIP
+	}
+	record, err := r.lookup(addr)
+	if err != nil {
+		return "", err
+	}
+	for _, field := range []string{"country", "registered_country"} {
+		if country, ok := record[field].(map[string]any); ok {
+			if code, _ := country["iso_code"].(string); Alpha3(code) != "" {
+				return Alpha3(code), nil
+			}
+		}
+	}
+	return "", ErrNotFound
+}
+
+// lookup walks the search tree for addr and decodes its data record
+func (r *Reader) lookup(addr net.IP) (map[string]any, error) {
+	node := uint(0)
+	if ip4 := addr.To4(); ip4 != nil {
+		addr = ip4
+		node = r.ipv4Start
+	} else if r.ipVersion == 4 {
+		return nil, ErrNotFound
+	}
+
+	for i := 0; i < len(addr)*8 && node < r.nodeCount; i++ {
+		bit := uint(addr[i/8]>>(7-i%8)) & 1
+		node = r.record(node, bit)
+	}
+	if node == r.nodeCount {
+		return nil, ErrNotFound
+	}
+	if node < r.nodeCount {
+		return nil, fmt.Errorf("%w: search tree deeper than the address", errInvalidDatabase)
+	}
+
+	offset := node - r.nodeCount - mmdbDataSeparator
+	value, _, err := decoder(r.data).decode(offset, 0)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidDatabase, err)
+	}
+	record, ok := value.(map[string]any)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return record, nil
+}
+
+// record reads the left (bit 0) or right (bit 1) record of a tree node
+func (r *Reader) record(node, bit uint) uint {
+	switch r.recordSize {
+	case 24:
+		b := r.tree[node*6+bit*3:]
+		return uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+	case 28:
+		b := r.tree[node*7:]
+		if bit == 0 {
+			return uint(b[3]&0xF0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+		}
+		return uint(b[3]&0x0F)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6])
+	default:
+		return uint(binary.BigEndian.Uint32(r.tree[node*8+bit*4:]))
+	}
+}
+
+// decoder decodes fields from a data section (or the metadata, which uses
+// the same encoding). Pointers are offsets from the start of the section.
+type decoder []byte
+
+// decode returns the field at offset and the offset after it
+func (d decoder) decode(offset uint, depth int) (any, uint, error) {
+	if depth > maxDecodeDepth {
+		return nil, 0, errors.New("data nested too deeply")
+	}
+	ctrl, offset, err := d.byteAt(offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	kind := uint(ctrl >> 5)
+	if kind == mmdbPointer {
+		target, next, err := d.pointer(ctrl, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		value, _, err := d.decode(target, depth+1)
+		return value, next, err
+	}
+	if kind == mmdbExtended {
+		var ext byte
+		if ext, offset, err = d.byteAt(offset); err != nil {
+			return nil, 0, err
+		}
+		kind = 7 + uint(ext)
+	}
+	size, offset, err := d.size(ctrl, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch kind {
+	case mmdbMap:
+		m := make(map[string]any, min(size, uint(len(d))))
+		for i := uint(0); i < size; i++ {
+			var key, value any
+			if key, offset, err = d.decode(offset, depth+1); err != nil {
+				return nil, 0, err
+			}
+			k, ok := key.(string)
+			if !ok {
+				return nil, 0, errors.New("map key is not a string")
+			}
+			if value, offset, err = d.decode(offset, depth+1); err != nil {
+				return nil, 0, err
+			}
+			m[k] = value
+		}
+		return m, offset, nil
+	case mmdbArray:
+		a := make([]any, 0, min(size, uint(len(d))))
+		for i := uint(0); i < size; i++ {
+			var value any
+			if value, offset, err = d.decode(offset, depth+1); err != nil {
+				return nil, 0, err
+			}
+			a = append(a, value)
+		}
+		return a, offset, nil
+	case mmdbBool:
+		return size != 0, offset, nil
+	}
+
+	b, err := d.slice(offset, size)
+	if err != nil {
+		return nil, 0, err
+	}
+	next := offset + size
+	switch kind {
+	case mmdbString:
+		return string(b), next, nil
+	case mmdbBytes:
+		return bytes.Clone(b), next, nil
+	case mmdbDouble:
+		if size != 8 {
+			return nil, 0, errors.New("invalid double size")
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), next, nil
+	case mmdbFloat:
+		if size != 4 {
+			return nil, 0, errors.New("invalid float size")
+		}
+		return math.Float32frombits(binary.BigEndian.Uint32(b)), next, nil
+	case mmdbUint16, mmdbUint32, mmdbUint64:
+		if size > 8 {
+			return nil, 0, errors.New("invalid integer size")
+		}
+		return uintValue(b), next, nil
+	case mmdbInt32:
+		if size > 4 {
+			return nil, 0, errors.New("invalid integer size")
+		}
+		// Shorter encodings are zero-padded, so only 4 bytes can be negative
+		return int32(uint32(uintValue(b))), next, nil
+	case mmdbUint128:
+		// Not used by country data; keep the raw bytes
+		return bytes.Clone(b), next, nil
+	}
+	return nil, 0, fmt.Errorf("unsupported field type %d", kind)
+}
+
+// size reads a field's payload size from its control byte and any extra size bytes
+func (d decoder) size(ctrl byte, offset uint) (uint, uint, error) {
+	size := uint(ctrl & 0x1F)
+	if size < 29 {
+		return size, offset, nil
+	}
+	extra := size - 28
+	b, err := d.slice(offset, extra)
+	if err != nil {
+		return 0, 0, err
+	}
+	base := [...]uint{29, 285, 65821}[extra-1]
+	return base + uint(uintValue(b)), offset + extra, nil
+}
+
+// pointer reads a pointer's target offset
+func (d decoder) pointer(ctrl byte, offset uint) (uint, uint, error) {
+	sizeBits := uint(ctrl>>3) & 3
+	b, err := d.slice(offset, sizeBits+1)
+	if err != nil {
+		return 0, 0, err
+	}
+	next := offset + sizeBits + 1
+	high := uint(ctrl & 7)
+	v := uint(uintValue(b))
+	switch sizeBits {
+	case 0:
+		return high<<8 | v, next, nil
+	case 1:
+		return (high<<16 | v) + 2048, next, nil
+	case 2:
+		return (high<<24 | v) + 526336, next, nil
+	default:
+		return v, next, nil
+	}
+}
+
+func (d decoder) byteAt(offset uint) (byte, uint, error) {
+	if offset >= uint(len(d)) {
+		return 0, 0, errors.New("unexpected end of data")
+	}
+	return d[offset], offset + 1, nil
+}
+
+func (d decoder) slice(offset, n uint) ([]byte, error) {
+	if offset > uint(len(d)) || n > uint(len(d))-offset {
+		return nil, errors.New("unexpected end of data")
+	}
+	return d[offset : offset+n], nil
+}
+
+// uintValue reads a big-endian unsigned integer of up to 8 bytes
+func uintValue(b []byte) uint64 {
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v
+}
@@ -0,0 +1,168 @@
+package geoip
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// Data section field encoders for building test databases
+
+func mmdbField(kind int, payload []byte, size int) []byte {
+	if kind > 7 {
+		return append([]byte{byte(size), byte(kind - 7)}, payload...)
+	}
+	return append([]byte{byte(kind<<5 | size)}, payload...)
+}
+
+func mmdbStr(s string) []byte { return mmdbField(mmdbString, []byte(s), len(s)) }
+
+func mmdbU32(v uint32) []byte {
+	return mmdbField(mmdbUint32, binary.BigEndian.AppendUint32(nil, v), 4)
+}
+
+func mmdbPtr(offset int) []byte {
+	return []byte{byte(mmdbPointer<<5 | offset>>8), byte(offset)}
+}
+
+func mmdbMapOf(pairs ...[]byte) []byte {
+	b := mmdbField(mmdbMap, nil, len(pairs)/2)
+	for _, p := range pairs {
+		b = append(b, p...)
+	}
+	return b
+}
+
+func countryRecord(field string, code []byte) []byte {
+	return mmdbMapOf(mmdbStr(field), mmdbMapOf(mmdbStr("iso_code"), code))
+}
+
+// testMMDB builds an IPv6 database: 0.0.0.0/1 is Germany, 128.0.0.0/1 France
+// (through a pointer), IPv6 8000::/1 is registered to the US and everything
+// else is unknown. Nodes 0-95 walk ::/96 down to the IPv4 root, node 96.
+func testMMDB(recordSize int) []byte {
+	const nodeCount = 97
+	var data []byte
+	offsets := map[string]int{}
+	add := func(name string, field []byte) {
+		offsets[name] = len(data)
+		data = append(data, field...)
+	}
+	add("fr-code", mmdbStr("FR"))
+	add("de", countryRecord("country", mmdbStr("DE")))
+	add("fr", countryRecord("country", mmdbPtr(offsets["fr-code"])))
+	add("us", countryRecord("registered_country", mmdbStr("US")))
+	leaf := func(name string) uint32 { return uint32(nodeCount + mmdbDataSeparator + offsets[name]) }
+
+	records := make([][2]uint32, nodeCount)
+	for i := 0; i < 96; i++ {
+		records[i] = [2]uint32{uint32(i + 1), nodeCount}
+	}
+	records[0][1] = leaf("us")
+	records[96] = [2]uint32{leaf("de"), leaf("fr")}
+
+	var tree []byte
+	for _, r := range records {
+		switch recordSize {
+		case 24:
+			tree = append(tree, byte(r[0]>>16), byte(r[0]>>8), byte(r[0]), byte(r[1]>>16), byte(r[1]>>8), byte(r[1]))
+		case 28:
+			tree = append(tree, byte(r[0]>>16), byte(r[0]>>8), byte(r[0]), byte(r[0]>>20&0xF0|r[1]>>24&0x0F), byte(r[1]>>16), byte(r[1]>>8), byte(r[1]))
+		case 32:
+			tree = binary.BigEndian.AppendUint32(tree, r[0])
+			tree = binary.BigEndian.AppendUint32(tree, r[1])
+		}
+	}
+
+	buf := append(tree, make([]byte, mmdbDataSeparator)...)
+	buf = append(buf, data...)
+	buf = append(buf, mmdbMetadataMarker...)
+	return append(buf, mmdbMapOf(
+		mmdbStr("node_count"), mmdbU32(nodeCount),
+		mmdbStr("record_size"), mmdbField(mmdbUint16, []byte{byte(recordSize)}, 1),
+		mmdbStr("ip_version"), mmdbField(mmdbUint16, []byte{6}, 1),
+		mmdbStr("database_type"), mmdbStr("Test-Country"),
+		mmdbStr("languages"), mmdbField(mmdbArray, nil, 0),
+	)...)
+}
+
+func TestReader_Country(t *testing.T) {
+	for _, size := range []int{24, 28, 32} {
+		r, err := NewReader(testMMDB(size))
+		if err != nil {
+			t.Fatalf("record size %d: %v", size, err)
+		}
+		if r.DatabaseType != "Test-Country" {
+			t.Errorf("expected the database type read, got %q", r.DatabaseType)
+		}
+		tests := []struct {
+			ip      string
+			want    string
+			wantErr error
+		}{
+			{ip: "10.1.2.3", want: "DEU"},
+			{ip: "203.0.113.9", want: "FRA"},
+			{ip: "::ffff:10.1.2.3", want: "DEU"},
+			{ip: "8000::1", want: "USA"},
+			{ip: "2001:db8::1", wantErr: ErrNotFound},
+			{ip: "not-an-ip", wantErr: errInvalidIP},
+		}
+		for _, tt := range tests {
+			got, err := r.Country(context.Background(), tt.ip)
+			if got != tt.want || !errors.Is(err, tt.wantErr) {
+				t.Errorf("record size %d, %s: expected %q/%v, got %q/%v", size, tt.ip, tt.want, tt.wantErr, got, err)
+			}
+		}
+	}
+}
+
+func TestNewReader_Invalid(t *testing.T) {
+	valid := testMMDB(24)
+	metadata := func(recordSize byte) []byte {
+		return append(append([]byte{}, mmdbMetadataMarker...), mmdbMapOf(
+			mmdbStr("node_count"), mmdbU32(0),
+			mmdbStr("record_size"), mmdbField(mmdbUint16, []byte{recordSize}, 1),
+			mmdbStr("ip_version"), mmdbField(mmdbUint16, []byte{6}, 1),
+		)...)
+	}
+	tests := map[string][]byte{
+		"no metadata":     valid[:bytes.LastIndex(valid, mmdbMetadataMarker)],
+		"truncated tree":  valid[500:],
+		"bad record size": metadata(20),
+		"empty metadata":  mmdbMetadataMarker,
+	}
+	for name, buf := range tests {
+		if _, err := NewReader(buf); !errors.Is(err, errInvalidDatabase) {
+			t.Errorf("%s: expected an invalid database error, got %v", name, err)
+		}
+	}
+}
+
+func TestDecoder_Types(t *testing.T) {
+	long := make([]byte, 300)
+	for i := range long {
+		long[i] = 'x'
+	}
+	tests := []struct {
+		name  string
+		field []byte
+		want  any
+	}{
+		{name: "int32", field: mmdbField(mmdbInt32, []byte{0xFF, 0xFF, 0xFF, 0xFE}, 4), want: int32(-2)},
+		{name: "bool", field: mmdbField(mmdbBool, nil, 1), want: true},
+		{name: "uint64", field: mmdbField(mmdbUint64, []byte{1, 0, 0, 0, 0}, 5), want: uint64(1 << 32)},
+		{name: "long string", field: append([]byte{mmdbString<<5 | 30, 0, 15}, long...), want: string(long)},
+	}
+	for _, tt := range tests {
+		got, next, err := decoder(tt.field).decode(0, 0)
+		if err != nil || got != tt.want || next != uint(len(tt.field)) {
+			t.Errorf("%s: expected %v, got %v (next %d, err %v)", tt.name, tt.want, got, next, err)
+		}
+	}
+
+	if _, _, err := decoder(mmdbPtr(0)).decode(0, 0); err == nil {
+		t.Error("expected a self-referencing pointer rejected")
+	}
+}
@@ -0,0 +1,169 @@
+package geoip
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Service defaults
+const (
+	DefaultServiceTimeout   = 50 * time.Millisecond
+	DefaultServiceField     = "country_code"
+	DefaultServiceCacheSize = 100000
+	DefaultServiceCacheTTL  = time.Hour
+
+	maxServiceBody = 64 << 10
+)
+
+// ServiceConfig configures a Service
+type ServiceConfig struct {
+	URL       string        // Lookup URL; {ip} is replaced with the escaped address
+	Field     string        // JSON response field holding an alpha-2 or alpha-3 country code
+	Timeout   time.Duration // Per-lookup timeout
+	CacheSize int           // Addresses whose country is kept in memory
+	CacheTTL  time.Duration // How long a looked-up country is kept
+}
+
+// Service looks up countries from an HTTP geo service that answers
+// GET URL with a JSON object, e.g. {"country_code":"DE"}. Answers, including
+// "not found", are cached per address. Safe for concurrent use.
+type Service struct {
+	config ServiceConfig
+	client *http.Client
+	now    func() time.Time
+
+	mu    sync.RWMutex
+	cache map[string]*list.Element
+	order *list.List // Front is the most recently stored
+}
+
+type serviceEntry struct {
+	ip        string
+	country   string // "" when the service had no country
+	expiresAt time.Time
+}
+
+// NewService creates a geo service client. client may be nil to use a
+// default client.
+func NewService(config ServiceConfig, client *http.Client) *Service {
+	if config.Field == "" {
+		config.Field = DefaultServiceField
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultServiceTimeout
+	}
+	if config.CacheSize <= 0 {
+		config.CacheSize = DefaultServiceCacheSize
+	}
+	if config.CacheTTL <= 0 {
+		config.CacheTTL = DefaultServiceCacheTTL
+	}
+	if client == nil {
+		client = &http.Client{}
+	}
+	return &Service{
+		config: config,
+		client: client,
+		now:    time.Now,
+		cache:  make(map[string]*list.Element),
+		order:  list.New(),
+	}
+}
+
+// Country returns the alpha-3 code of the address's country
+func (s *Service) Country(ctx context.Context, ip string) (string, error) {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return "", errInvalidIP
+	}
+	key := addr.String()
+	if country, ok := s.cached(key); ok {
+		if country == "" {
+			return "", ErrNotFound
+		}
+		return country, nil
+	}
+
+	country, err := s.fetch(ctx, key)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		// Transport and service errors aren't cached so the next request retries
+		return "", err
+	}
+	s.store(key, country)
+	return country, err
+}
+
+func (s *Service) fetch(ctx context.Context, ip string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.config.Timeout)
+	defer cancel()
+
+	lookupURL := strings.ReplaceAll(s.config.URL, "{ip}", url.PathEscape(ip))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, lookupURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return "", ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("geo service returned %d", resp.StatusCode)
+	}
+
+	var body map[string]any
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxServiceBody)).Decode(&body); err != nil {
+		return "", fmt.Errorf("parse geo response: %w", err)
+	}
+	code, _ := body[s.config.Field].(string)
+	if country := Alpha3(code); country != "" {
+		return country, nil
+	}
+	return "", ErrNotFound
+}
+
+func (s *Service) cached(key string) (string, bool) {
+	s.mu.RLock()
+	el, ok := s.cache[key]
+	var entry serviceEntry
+	if ok {
+		entry = el.Value.(serviceEntry)
+	}
+	s.mu.RUnlock()
+	if !ok || !s.now().Before(entry.expiresAt) {
+		return "", false
+	}
+	return entry.country, true
+}
+
+// store caches a country, evicting the oldest entry when the cache is full.
+// Entries share one TTL, so the oldest is also the first to expire.
+func (s *Service) store(key, country string) {
+	entry := serviceEntry{ip: key, country: country, expiresAt: s.now().Add(s.config.CacheTTL)}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if el, ok := s.cache[key]; ok {
+		el.Value = entry
+		s.order.MoveToFront(el)
+		return
+	}
+	s.cache[key] = s.order.PushFront(entry)
+	for s.order.Len() > s.config.CacheSize {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.cache, oldest.Value.(serviceEntry).ip)
+	}
+}
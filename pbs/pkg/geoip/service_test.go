@@ -0,0 +1,82 @@
+package geoip
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestService_Country(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		switch r.URL.Path {
+		case "/lookup/10.0.0.1":
+			w.Write([]byte(`{"country_code":"de","city":"Berlin"}`))
+		case "/lookup/2001:db8::1":
+			w.Write([]byte(`{"country_code":"USA"}`))
+		case "/lookup/10.0.0.2":
+			w.Write([]byte(`{"country_code":""}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	s := NewService(ServiceConfig{URL: server.URL + "/lookup/{ip}"}, nil)
+	ctx := context.Background()
+	tests := []struct {
+		ip      string
+		want    string
+		wantErr error
+	}{
+		{ip: "10.0.0.1", want: "DEU"},
+		{ip: "2001:DB8::1", want: "USA"},
+		{ip: "10.0.0.2", wantErr: ErrNotFound},
+		{ip: "bad", wantErr: errInvalidIP},
+	}
+	for _, tt := range tests {
+		got, err := s.Country(ctx, tt.ip)
+		if got != tt.want || !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: expected %q/%v, got %q/%v", tt.ip, tt.want, tt.wantErr, got, err)
+		}
+	}
+
+	// Answers, including not-found, are cached; failures are retried
+	before := calls.Load()
+	s.Country(ctx, "10.0.0.1")
+	s.Country(ctx, "10.0.0.2")
+	if calls.Load() != before {
+		t.Errorf("expected cached answers, got %d more calls", calls.Load()-before)
+	}
+	if _, err := s.Country(ctx, "10.0.0.3"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("expected a service error, got %v", err)
+	}
+	s.Country(ctx, "10.0.0.3")
+	if calls.Load() != before+2 {
+		t.Errorf("expected failed lookups retried, got %d calls", calls.Load()-before)
+	}
+}
+
+func TestService_CacheFull(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"cc":"FR"}`))
+	}))
+	defer server.Close()
+
+	s := NewService(ServiceConfig{URL: server.URL + "?ip={ip}", Field: "cc", CacheSize: 1}, nil)
+	for _, ip := range []string{"10.0.0.1", "10.0.0.2"} {
+		if got, err := s.Country(context.Background(), ip); got != "FRA" || err != nil {
+			t.Errorf("%s: expected FRA, got %q/%v", ip, got, err)
+		}
+	}
+	if len(s.cache) != 1 {
+		t.Errorf("expected the cache bounded at 1 entry, got %d", len(s.cache))
+	}
+	if _, ok := s.cached("10.0.0.2"); !ok {
+		t.Error("expected the newest address cached in place of the oldest")
+	}
+}
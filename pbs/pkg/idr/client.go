@@ -9,6 +9,9 @@ import (
 	"io"
 	"net/http"
 	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/signing"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/tracing"
 )
 
 // P2-4: Maximum IDR response size to prevent OOM from malformed responses
@@ -21,6 +24,7 @@ type Client struct {
 	httpClient     *http.Client
 	timeout        time.Duration
 	circuitBreaker *CircuitBreaker
+	signer         *signing.Signer // Optional HMAC request signing
 }
 
 // newIDRTransport creates a connection-pooled transport for IDR requests
@@ -79,6 +83,12 @@ func NewClientWithCircuitBreaker(baseURL string, timeout time.Duration, apiKey s
 	}
 }
 
+// SetSigner enables HMAC signing of every request to the IDR service so it can
+// verify calls came from this engine. Call before the client is used.
+func (c *Client) SetSigner(s *signing.Signer) {
+	c.signer = s
+}
+
 // SelectPartnersRequest is the request to select partners
 type SelectPartnersRequest struct {
 	Request          json.RawMessage `json:"request"`           // OpenRTB request
@@ -173,6 +183,8 @@ func (c *Client) SelectPartners(ctx context.Context, ortbRequest json.RawMessage
 		if c.apiKey != "" {
 			req.Header.Set("X-Internal-API-Key", c.apiKey)
 		}
+		tracing.Inject(ctx, req.Header)
+		c.signer.Sign(req, body)
 
 		resp, err := c.httpClient.Do(req)
 		if err != nil {
@@ -243,6 +255,8 @@ func (c *Client) SelectPartnersMinimal(ctx context.Context, minReq *MinimalReque
 		if c.apiKey != "" {
 			req.Header.Set("X-Internal-API-Key", c.apiKey)
 		}
+		tracing.Inject(ctx, req.Header)
+		c.signer.Sign(req, body)
 
 		resp, err := c.httpClient.Do(req)
 		if err != nil {
@@ -297,6 +311,7 @@ func (c *Client) GetConfig(ctx context.Context) (map[string]interface{}, error)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
+	c.signer.Sign(req, nil)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -331,6 +346,7 @@ func (c *Client) setMode(ctx context.Context, path string, enabled bool) error {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	c.signer.Sign(req, body)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -357,6 +373,7 @@ func (c *Client) HealthCheck(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	c.signer.Sign(req, nil)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -3,10 +3,13 @@ package idr
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/signing"
 )
 
 func TestNewClient(t *testing.T) {
@@ -109,6 +112,31 @@ func TestSelectPartnersSuccess(t *testing.T) {
 	}
 }
 
+func TestSelectPartnersSigned(t *testing.T) {
+	signer, err := signing.FromEnv("k1:secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var verifyErr error
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		verifyErr = signer.Verify(r, body, signing.DefaultMaxSkew)
+		json.NewEncoder(w).Encode(SelectPartnersResponse{Mode: "normal"})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, 100*time.Millisecond, "test-key")
+	client.SetSigner(signer)
+
+	if _, err := client.SelectPartners(context.Background(), json.RawMessage(`{"id":"test-1"}`), []string{"appnexus"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if verifyErr != nil {
+		t.Errorf("expected signed request, got %v", verifyErr)
+	}
+}
+
 func TestSelectPartnersServerError(t *testing.T) {
 	// Create mock server that returns 500
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
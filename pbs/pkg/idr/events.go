@@ -10,6 +10,8 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/signing"
 )
 
 const (
@@ -19,8 +21,23 @@ const (
 	flushQueueSize = 10
 	// flushTimeout is the max time to wait for a flush operation
 	flushTimeout = 2 * time.Second
+	// spillReplayInterval is how often spilled events are retried while no
+	// live send has succeeded
+	spillReplayInterval = 5 * time.Second
 )
 
+// Spill results reported to EventMetrics
+const (
+	SpillResultSpilled  = "spilled"  // Written to disk after a failed or queued-out send
+	SpillResultReplayed = "replayed" // Delivered from disk
+	SpillResultDropped  = "dropped"  // Lost: the spill was full, or couldn't be written or read
+)
+
+// EventMetrics receives counts of events going through the disk spill
+type EventMetrics interface {
+	RecordIDREventSpill(result string, events int)
+}
+
 // EventRecorder sends auction events to the IDR service
 // Uses a bounded worker pool to prevent goroutine leaks
 type EventRecorder struct {
@@ -40,13 +57,32 @@ type EventRecorder struct {
 	droppedBatches atomic.Int64 // Count of batches dropped
 	totalEvents    atomic.Int64 // Total events recorded
 	flushedEvents  atomic.Int64 // Total events successfully queued for flush
+	skippedEvents  atomic.Int64 // Events not recorded due to account policy or sampling
+
+	// policy is consulted before enqueueing; nil records everything
+	policy atomic.Pointer[RecordingPolicy]
+
+	// signer signs each event batch; nil sends unsigned
+	signer atomic.Pointer[signing.Signer]
+
+	// spill keeps batches that couldn't be delivered on disk for replay; nil
+	// drops them
+	spill              atomic.Pointer[spillQueue]
+	replayCh           chan struct{}
+	spilledEvents      atomic.Int64
+	replayedEvents     atomic.Int64
+	spillDroppedEvents atomic.Int64
+	metrics            atomic.Pointer[EventMetrics]
 }
 
 // BidEvent represents a bid event to record
 type BidEvent struct {
 	AuctionID   string   `json:"auction_id"`
 	BidderCode  string   `json:"bidder_code"`
-	EventType   string   `json:"event_type"` // "bid_response" or "win"
+	EventType   string   `json:"event_type"` // "bid_response", "win", "imp", "feedback" or "idr_shadow"
+	BidID       string   `json:"bid_id,omitempty"`
+	Outcome     string   `json:"outcome,omitempty"` // feedback only: one of the Feedback* outcomes
+	Reason      string   `json:"reason,omitempty"`  // feedback only: e.g. why a render failed
 	LatencyMs   float64  `json:"latency_ms,omitempty"`
 	HadBid      bool     `json:"had_bid,omitempty"`
 	BidCPM      *float64 `json:"bid_cpm,omitempty"`
@@ -60,6 +96,14 @@ type BidEvent struct {
 	TimedOut    bool     `json:"timed_out,omitempty"`
 	HadError    bool     `json:"had_error,omitempty"`
 	ErrorMsg    string   `json:"error_message,omitempty"`
+	Timestamp   int64    `json:"timestamp,omitempty"` // Notifications only: when the client saw it, Unix ms
+
+	// IDR shadow comparisons only
+	BiddersCalled   int      `json:"bidders_called,omitempty"`
+	BiddersSelected int      `json:"bidders_selected,omitempty"`
+	RevenueKept     *float64 `json:"revenue_kept,omitempty"`
+	RevenueLost     *float64 `json:"revenue_lost,omitempty"`
+	WouldExclude    []string `json:"would_exclude,omitempty"`
 }
 
 // NewEventRecorder creates a new event recorder with a bounded worker pool
@@ -77,6 +121,7 @@ func NewEventRecorder(baseURL string, bufferSize int) *EventRecorder {
 		bufferSize: bufferSize,
 		flushQueue: make(chan []BidEvent, flushQueueSize),
 		stopCh:     make(chan struct{}),
+		replayCh:   make(chan struct{}, 1),
 	}
 
 	// Start worker pool for flush operations
@@ -88,6 +133,55 @@ func NewEventRecorder(baseURL string, bufferSize int) *EventRecorder {
 	return er
 }
 
+// SetRecordingPolicy sets per-account recording controls; nil records every event.
+// Safe to call while events are being recorded.
+func (r *EventRecorder) SetRecordingPolicy(p *RecordingPolicy) {
+	r.policy.Store(p)
+}
+
+// SetSigner enables HMAC signing of event batches so the IDR service can
+// verify they came from this engine; nil disables signing
+func (r *EventRecorder) SetSigner(s *signing.Signer) {
+	r.signer.Store(s)
+}
+
+// SetMetrics attaches spill reporting; nil disables it
+func (r *EventRecorder) SetMetrics(m EventMetrics) {
+	if m == nil {
+		r.metrics.Store(nil)
+		return
+	}
+	r.metrics.Store(&m)
+}
+
+// EnableSpill keeps event batches that can't be delivered, because IDR is
+// down or the flush queue is full, in segment files under dir, and replays
+// them once IDR takes events again. Batches spilled before a restart are
+// picked up from dir. Once the spill reaches maxBytes, its oldest events are
+// dropped. Call it once, before recording events.
+func (r *EventRecorder) EnableSpill(dir string, maxBytes int64) error {
+	q, err := openSpillQueue(dir, maxBytes)
+	if err != nil {
+		return err
+	}
+	r.spill.Store(q)
+
+	r.wg.Add(1)
+	go r.replayWorker(q)
+	r.signalReplay()
+	return nil
+}
+
+// shouldRecord consults the recording policy and counts skipped events
+func (r *EventRecorder) shouldRecord(auctionID, publisherID string) bool {
+	p := r.policy.Load()
+	if p == nil || p.ShouldRecord(auctionID, publisherID) {
+		return true
+	}
+	r.skippedEvents.Add(1)
+	return false
+}
+
 // flushWorker processes flush requests from the queue
 func (r *EventRecorder) flushWorker() {
 	defer r.wg.Done()
@@ -100,12 +194,124 @@ func (r *EventRecorder) flushWorker() {
 				return
 			}
 			ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
-			r.sendEvents(ctx, events)
+			err := r.sendEvents(ctx, events)
 			cancel()
+			if err != nil {
+				r.spillEvents(events)
+			} else {
+				r.signalReplay()
+			}
 		}
 	}
 }
 
+// spillEvents writes a batch that couldn't be delivered to the spill,
+// reporting whether it was kept
+func (r *EventRecorder) spillEvents(events []BidEvent) bool {
+	q := r.spill.Load()
+	if q == nil || len(events) == 0 {
+		return false
+	}
+	dropped, err := q.write(events)
+	if dropped > 0 {
+		r.spillDroppedEvents.Add(int64(dropped))
+		r.recordSpill(SpillResultDropped, dropped)
+	}
+	if err != nil {
+		r.spillDroppedEvents.Add(int64(len(events)))
+		r.recordSpill(SpillResultDropped, len(events))
+		return false
+	}
+	r.spilledEvents.Add(int64(len(events)))
+	r.recordSpill(SpillResultSpilled, len(events))
+	return true
+}
+
+// signalReplay wakes the replay worker when there are spilled events, so
+// they follow as soon as IDR takes events again
+func (r *EventRecorder) signalReplay() {
+	q := r.spill.Load()
+	if q == nil {
+		return
+	}
+	if events, _ := q.pending(); events == 0 {
+		return
+	}
+	select {
+	case r.replayCh <- struct{}{}:
+	default:
+	}
+}
+
+// replayWorker replays spilled events when signalled, and periodically to
+// find out when IDR is back
+func (r *EventRecorder) replayWorker(q *spillQueue) {
+	defer r.wg.Done()
+	ticker := time.NewTicker(spillReplayInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stopCh:
+			return
+		case <-ticker.C:
+		case <-r.replayCh:
+		}
+		r.replay(q)
+	}
+}
+
+// replay sends spilled batches oldest first until the spill is empty, a send
+// fails or the recorder stops. Delivery is at least once: a segment being
+// replayed when the process dies is replayed in full after the restart.
+func (r *EventRecorder) replay(q *spillQueue) {
+	for {
+		seg, batches, err := q.take()
+		if err != nil {
+			r.spillDroppedEvents.Add(int64(seg.events))
+			r.recordSpill(SpillResultDropped, seg.events)
+			continue
+		}
+		if seg == nil {
+			return
+		}
+
+		for i, batch := range batches {
+			select {
+			case <-r.stopCh:
+				r.putBack(q, seg, batches[i:])
+				return
+			default:
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+			err := r.sendEvents(ctx, batch)
+			cancel()
+			if err != nil {
+				r.putBack(q, seg, batches[i:])
+				return
+			}
+			r.replayedEvents.Add(int64(len(batch)))
+			r.recordSpill(SpillResultReplayed, len(batch))
+		}
+		q.done(seg)
+	}
+}
+
+// putBack returns the batches of a segment that weren't replayed to the spill
+func (r *EventRecorder) putBack(q *spillQueue, seg *spillSegment, remaining [][]BidEvent) {
+	if err := q.putBack(seg, remaining); err != nil {
+		events := countEvents(remaining)
+		r.spillDroppedEvents.Add(int64(events))
+		r.recordSpill(SpillResultDropped, events)
+	}
+}
+
+func (r *EventRecorder) recordSpill(result string, events int) {
+	if m := r.metrics.Load(); m != nil {
+		(*m).RecordIDREventSpill(result, events)
+	}
+}
+
 // sendEvents sends a batch of events to the IDR service
 func (r *EventRecorder) sendEvents(ctx context.Context, events []BidEvent) error {
 	if len(events) == 0 {
@@ -127,6 +333,7 @@ func (r *EventRecorder) sendEvents(ctx context.Context, events []BidEvent) error
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	r.signer.Load().Sign(req, body)
 
 	resp, err := r.httpClient.Do(req)
 	if err != nil {
@@ -158,6 +365,10 @@ func (r *EventRecorder) RecordBidResponse(
 	hadError bool,
 	errorMsg string,
 ) {
+	if !r.shouldRecord(auctionID, publisherID) {
+		return
+	}
+
 	event := BidEvent{
 		AuctionID:   auctionID,
 		BidderCode:  bidderCode,
@@ -176,32 +387,7 @@ func (r *EventRecorder) RecordBidResponse(
 		ErrorMsg:    errorMsg,
 	}
 
-	r.totalEvents.Add(1)
-
-	r.mu.Lock()
-	r.buffer = append(r.buffer, event)
-	shouldFlush := len(r.buffer) >= r.bufferSize
-	var eventsToFlush []BidEvent
-	if shouldFlush {
-		eventsToFlush = r.buffer
-		r.buffer = make([]BidEvent, 0, r.bufferSize)
-	}
-	r.mu.Unlock()
-
-	// Queue flush if buffer was full (non-blocking send)
-	if eventsToFlush != nil {
-		batchSize := int64(len(eventsToFlush))
-		select {
-		case r.flushQueue <- eventsToFlush:
-			// Queued successfully
-			r.flushedEvents.Add(batchSize)
-		default:
-			// Queue full - drop events rather than block or leak goroutines
-			// Track dropped events for monitoring/alerting
-			r.droppedEvents.Add(batchSize)
-			r.droppedBatches.Add(1)
-		}
-	}
+	r.enqueue(event)
 }
 
 // RecordWin records a win event
@@ -215,6 +401,10 @@ func (r *EventRecorder) RecordWin(
 	adSize string,
 	publisherID string,
 ) {
+	if !r.shouldRecord(auctionID, publisherID) {
+		return
+	}
+
 	event := BidEvent{
 		AuctionID:   auctionID,
 		BidderCode:  bidderCode,
@@ -227,6 +417,125 @@ func (r *EventRecorder) RecordWin(
 		PublisherID: publisherID,
 	}
 
+	r.enqueue(event)
+}
+
+// Notification events reported through bid event URLs
+const (
+	NotificationWin = "win" // Bid won the client-side decision
+	NotificationImp = "imp" // Bid's creative was displayed
+)
+
+// RecordNotification records a win or imp notification for a returned bid.
+// cpm is the price the bid was returned at, nil when the bid is no longer
+// known; auctionID may then be empty too.
+func (r *EventRecorder) RecordNotification(
+	eventType string,
+	auctionID string,
+	bidID string,
+	bidderCode string,
+	cpm *float64,
+	publisherID string,
+	ts time.Time,
+) {
+	if !r.shouldRecord(auctionID, publisherID) {
+		return
+	}
+
+	event := BidEvent{
+		AuctionID:   auctionID,
+		BidID:       bidID,
+		BidderCode:  bidderCode,
+		EventType:   eventType,
+		PublisherID: publisherID,
+		Timestamp:   ts.UnixMilli(),
+	}
+	if eventType == NotificationWin {
+		event.WinCPM = cpm
+	} else {
+		event.BidCPM = cpm
+	}
+	r.enqueue(event)
+}
+
+// Client feedback outcomes reported after the auction
+const (
+	FeedbackWon          = "won"           // Bid won the client-side decision (e.g. ad server line item)
+	FeedbackLost         = "lost"          // Bid lost the client-side decision
+	FeedbackRendered     = "rendered"      // Creative rendered
+	FeedbackRenderFailed = "render_failed" // Creative failed to render
+	FeedbackViewable     = "viewable"      // Creative met the viewability threshold
+)
+
+// ValidFeedbackOutcome reports whether outcome is one of the Feedback* outcomes
+func ValidFeedbackOutcome(outcome string) bool {
+	switch outcome {
+	case FeedbackWon, FeedbackLost, FeedbackRendered, FeedbackRenderFailed, FeedbackViewable:
+		return true
+	}
+	return false
+}
+
+// RecordFeedback records a client-reported outcome for a bid, such as a render or
+// viewability signal. Sampling follows the auction so feedback joins its bid events.
+func (r *EventRecorder) RecordFeedback(
+	auctionID string,
+	bidID string,
+	bidderCode string,
+	outcome string,
+	reason string,
+	cpm *float64,
+	publisherID string,
+) {
+	if !r.shouldRecord(auctionID, publisherID) {
+		return
+	}
+
+	r.enqueue(BidEvent{
+		AuctionID:   auctionID,
+		BidID:       bidID,
+		BidderCode:  bidderCode,
+		EventType:   "feedback",
+		Outcome:     outcome,
+		Reason:      reason,
+		BidCPM:      cpm,
+		PublisherID: publisherID,
+	})
+}
+
+// ShadowComparison is how an auction run with every bidder compares with the
+// IDR selection it ran in shadow mode. Revenue is each imp's best bid price:
+// kept is what the selected bidders would still have bid, lost the rest.
+type ShadowComparison struct {
+	BiddersCalled   int
+	BiddersSelected int // Called bidders IDR would have selected
+	RevenueKept     float64
+	RevenueLost     float64
+	WouldExclude    []string
+}
+
+// RecordShadowComparison records an auction's IDR shadow comparison, so IDR
+// can be evaluated before it's enforced
+func (r *EventRecorder) RecordShadowComparison(auctionID, publisherID string, c ShadowComparison) {
+	if !r.shouldRecord(auctionID, publisherID) {
+		return
+	}
+
+	kept, lost := c.RevenueKept, c.RevenueLost
+	r.enqueue(BidEvent{
+		AuctionID:       auctionID,
+		EventType:       "idr_shadow",
+		PublisherID:     publisherID,
+		BiddersCalled:   c.BiddersCalled,
+		BiddersSelected: c.BiddersSelected,
+		RevenueKept:     &kept,
+		RevenueLost:     &lost,
+		WouldExclude:    c.WouldExclude,
+	})
+}
+
+// enqueue buffers an event and hands a full buffer to the flush workers
+func (r *EventRecorder) enqueue(event BidEvent) {
 	r.totalEvents.Add(1)
 
 	r.mu.Lock()
@@ -247,15 +556,18 @@ func (r *EventRecorder) RecordWin(
 			// Queued successfully
 			r.flushedEvents.Add(batchSize)
 		default:
-			// Queue full - drop events rather than block or leak goroutines
+			// Queue full - spill or drop events rather than block or leak goroutines
 			// Track dropped events for monitoring/alerting
-			r.droppedEvents.Add(batchSize)
-			r.droppedBatches.Add(1)
+			if !r.spillEvents(eventsToFlush) {
+				r.droppedEvents.Add(batchSize)
+				r.droppedBatches.Add(1)
+			}
 		}
 	}
 }
 
-// Flush sends buffered events to the IDR service synchronously
+// Flush sends buffered events to the IDR service synchronously. With a spill,
+// events that can't be sent are spilled.
 func (r *EventRecorder) Flush(ctx context.Context) error {
 	r.mu.Lock()
 	if len(r.buffer) == 0 {
@@ -268,7 +580,11 @@ func (r *EventRecorder) Flush(ctx context.Context) error {
 	r.buffer = make([]BidEvent, 0, r.bufferSize)
 	r.mu.Unlock()
 
-	return r.sendEvents(ctx, events)
+	err := r.sendEvents(ctx, events)
+	if err != nil {
+		r.spillEvents(events)
+	}
+	return err
 }
 
 // Close flushes remaining events and shuts down workers gracefully
@@ -285,6 +601,14 @@ func (r *EventRecorder) Close() error {
 	close(r.flushQueue)
 	r.wg.Wait()
 
+	// Batches the workers didn't get to are kept for the next start
+	if q := r.spill.Load(); q != nil {
+		for events := range r.flushQueue {
+			r.spillEvents(events)
+		}
+		q.close()
+	}
+
 	return err
 }
 
@@ -294,8 +618,16 @@ type EventRecorderStats struct {
 	FlushedEvents  int64 `json:"flushed_events"`  // Events successfully queued for flush
 	DroppedEvents  int64 `json:"dropped_events"`  // Events dropped due to full queue
 	DroppedBatches int64 `json:"dropped_batches"` // Batches dropped due to full queue
+	SkippedEvents  int64 `json:"skipped_events"`  // Events not recorded due to account policy or sampling
 	BufferedEvents int   `json:"buffered_events"` // Events currently in buffer
 	QueuedBatches  int   `json:"queued_batches"`  // Batches waiting in flush queue
+
+	// Disk spill, when enabled
+	SpilledEvents      int64 `json:"spilled_events"`       // Events written to the spill
+	ReplayedEvents     int64 `json:"replayed_events"`      // Spilled events delivered
+	SpillDroppedEvents int64 `json:"spill_dropped_events"` // Spilled events lost
+	PendingSpill       int64 `json:"pending_spill"`        // Events waiting in the spill
+	SpillBytes         int64 `json:"spill_bytes"`          // Size of the spill on disk
 }
 
 // Stats returns current metrics for the event recorder.
@@ -305,12 +637,24 @@ func (r *EventRecorder) Stats() EventRecorderStats {
 	buffered := len(r.buffer)
 	r.mu.Unlock()
 
+	var pending, spillBytes int64
+	if q := r.spill.Load(); q != nil {
+		pending, spillBytes = q.pending()
+	}
+
 	return EventRecorderStats{
 		TotalEvents:    r.totalEvents.Load(),
 		FlushedEvents:  r.flushedEvents.Load(),
 		DroppedEvents:  r.droppedEvents.Load(),
 		DroppedBatches: r.droppedBatches.Load(),
+		SkippedEvents:  r.skippedEvents.Load(),
 		BufferedEvents: buffered,
 		QueuedBatches:  len(r.flushQueue),
+
+		SpilledEvents:      r.spilledEvents.Load(),
+		ReplayedEvents:     r.replayedEvents.Load(),
+		SpillDroppedEvents: r.spillDroppedEvents.Load(),
+		PendingSpill:       pending,
+		SpillBytes:         spillBytes,
 	}
 }
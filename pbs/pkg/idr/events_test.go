@@ -0,0 +1,159 @@
+package idr
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/signing"
+)
+
+func TestEventRecorder_RecordFeedback(t *testing.T) {
+	var received []BidEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Events []BidEvent `json:"events"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("failed to decode events: %v", err)
+		}
+		received = append(received, body.Events...)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	r := NewEventRecorder(server.URL, 100)
+	defer r.Close()
+
+	cpm := 1.25
+	r.RecordFeedback("auction-1", "bid-1", "appnexus", FeedbackRenderFailed, "timeout", &cpm, "pub-1")
+	if err := r.Flush(context.Background()); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+
+	if len(received) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(received))
+	}
+	ev := received[0]
+	if ev.EventType != "feedback" || ev.Outcome != FeedbackRenderFailed || ev.BidID != "bid-1" ||
+		ev.Reason != "timeout" || ev.PublisherID != "pub-1" || ev.BidCPM == nil || *ev.BidCPM != 1.25 {
+		t.Errorf("unexpected feedback event %+v", ev)
+	}
+}
+
+func TestEventRecorder_RecordNotification(t *testing.T) {
+	var received []BidEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Events []BidEvent `json:"events"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("failed to decode events: %v", err)
+		}
+		received = append(received, body.Events...)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	r := NewEventRecorder(server.URL, 100)
+	defer r.Close()
+
+	cpm := 2.5
+	ts := time.UnixMilli(1700000000123)
+	r.RecordNotification(NotificationWin, "auction-1", "bid-1", "appnexus", &cpm, "pub-1", ts)
+	r.RecordNotification(NotificationImp, "", "bid-2", "rubicon", nil, "pub-1", ts)
+	if err := r.Flush(context.Background()); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+
+	if len(received) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(received))
+	}
+	win, imp := received[0], received[1]
+	if win.EventType != "win" || win.BidID != "bid-1" || win.WinCPM == nil || *win.WinCPM != 2.5 || win.Timestamp != 1700000000123 {
+		t.Errorf("unexpected win event %+v", win)
+	}
+	if imp.EventType != "imp" || imp.BidID != "bid-2" || imp.BidCPM != nil || imp.AuctionID != "" {
+		t.Errorf("unexpected imp event %+v", imp)
+	}
+}
+
+func TestEventRecorder_RecordShadowComparison(t *testing.T) {
+	var received []BidEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Events []BidEvent `json:"events"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("failed to decode events: %v", err)
+		}
+		received = append(received, body.Events...)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	r := NewEventRecorder(server.URL, 100)
+	defer r.Close()
+
+	r.RecordShadowComparison("auction-1", "pub-1", ShadowComparison{
+		BiddersCalled:   4,
+		BiddersSelected: 3,
+		RevenueKept:     2.5,
+		WouldExclude:    []string{"rubicon"},
+	})
+	if err := r.Flush(context.Background()); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+
+	if len(received) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(received))
+	}
+	ev := received[0]
+	if ev.EventType != "idr_shadow" || ev.BiddersCalled != 4 || ev.BiddersSelected != 3 ||
+		ev.RevenueKept == nil || *ev.RevenueKept != 2.5 || ev.RevenueLost == nil || *ev.RevenueLost != 0 ||
+		len(ev.WouldExclude) != 1 || ev.WouldExclude[0] != "rubicon" {
+		t.Errorf("unexpected shadow event %+v", ev)
+	}
+}
+
+func TestValidFeedbackOutcome(t *testing.T) {
+	for _, outcome := range []string{FeedbackWon, FeedbackLost, FeedbackRendered, FeedbackRenderFailed, FeedbackViewable} {
+		if !ValidFeedbackOutcome(outcome) {
+			t.Errorf("expected %q to be valid", outcome)
+		}
+	}
+	if ValidFeedbackOutcome("clicked") || ValidFeedbackOutcome("") {
+		t.Error("expected unknown outcomes to be invalid")
+	}
+}
+
+func TestEventRecorder_SignsBatches(t *testing.T) {
+	signer, err := signing.FromEnv("k1:secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var verifyErr error
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		verifyErr = signer.Verify(r, body, signing.DefaultMaxSkew)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	r := NewEventRecorder(server.URL, 100)
+	defer r.Close()
+	r.SetSigner(signer)
+
+	r.RecordWin("auction-1", "appnexus", 1.5, "US", "desktop", "banner", "300x250", "pub-1")
+	if err := r.Flush(context.Background()); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+	if verifyErr != nil {
+		t.Errorf("expected signed event batch, got %v", verifyErr)
+	}
+}
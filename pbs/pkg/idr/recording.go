@@ -0,0 +1,94 @@
+package idr
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+)
+
+// AccountRecording controls event recording for one account. Nil fields
+// inherit from the policy default.
+type AccountRecording struct {
+	Enabled    *bool    `json:"enabled,omitempty"`     // false opts the account out entirely
+	SampleRate *float64 `json:"sample_rate,omitempty"` // Fraction of auctions recorded (0.0-1.0)
+}
+
+// RecordingPolicy decides which auctions are recorded to the IDR service.
+// Sampling is keyed on the auction ID so every event of an auction is kept
+// or dropped together, and the same auction samples identically on any instance.
+type RecordingPolicy struct {
+	DefaultSampleRate float64
+	Accounts          map[string]AccountRecording
+}
+
+// NewRecordingPolicy builds a policy from a default sample rate and a JSON object of
+// per-account overrides, e.g. {"pub-1":{"enabled":false},"pub-2":{"sample_rate":0.1}}
+func NewRecordingPolicy(defaultSampleRate float64, accountsJSON string) (*RecordingPolicy, error) {
+	if err := validateSampleRate(defaultSampleRate); err != nil {
+		return nil, fmt.Errorf("default sample rate: %w", err)
+	}
+
+	policy := &RecordingPolicy{
+		DefaultSampleRate: defaultSampleRate,
+		Accounts:          make(map[string]AccountRecording),
+	}
+	if accountsJSON == "" {
+		return policy, nil
+	}
+
+	if err := json.Unmarshal([]byte(accountsJSON), &policy.Accounts); err != nil {
+		return nil, fmt.Errorf("invalid account recording config: %w", err)
+	}
+	for account, cfg := range policy.Accounts {
+		if cfg.SampleRate != nil {
+			if err := validateSampleRate(*cfg.SampleRate); err != nil {
+				return nil, fmt.Errorf("account %s: %w", account, err)
+			}
+		}
+	}
+	return policy, nil
+}
+
+func validateSampleRate(rate float64) error {
+	if math.IsNaN(rate) || rate < 0 || rate > 1 {
+		return fmt.Errorf("sample rate must be between 0 and 1, got %v", rate)
+	}
+	return nil
+}
+
+// SampleRate returns the effective sample rate for an account (0 when opted out)
+func (p *RecordingPolicy) SampleRate(publisherID string) float64 {
+	rate := p.DefaultSampleRate
+	if cfg, ok := p.Accounts[publisherID]; ok {
+		if cfg.Enabled != nil && !*cfg.Enabled {
+			return 0
+		}
+		if cfg.SampleRate != nil {
+			rate = *cfg.SampleRate
+		}
+	}
+	return rate
+}
+
+// ShouldRecord reports whether events for the auction should be recorded
+func (p *RecordingPolicy) ShouldRecord(auctionID, publisherID string) bool {
+	return Sampled(auctionID, p.SampleRate(publisherID))
+}
+
+// Sampled reports whether an auction falls within a sample rate. The same
+// auction ID samples identically everywhere, so rates applied at different
+// layers keep or drop an auction's events together.
+func Sampled(auctionID string, rate float64) bool {
+	switch {
+	case rate >= 1:
+		return true
+	case rate <= 0:
+		return false
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(auctionID))
+	// Map the hash onto [0,1) and keep the lowest rate fraction
+	return float64(h.Sum64()>>11)/(1<<53) < rate
+}
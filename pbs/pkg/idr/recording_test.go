@@ -0,0 +1,103 @@
+package idr
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestNewRecordingPolicy(t *testing.T) {
+	p, err := NewRecordingPolicy(0.5, `{"optout":{"enabled":false},"sampled":{"sample_rate":0.1},"full":{"sample_rate":1}}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		account string
+		want    float64
+	}{
+		{"optout", 0},
+		{"sampled", 0.1},
+		{"full", 1},
+		{"unknown", 0.5},
+	}
+	for _, tt := range tests {
+		if got := p.SampleRate(tt.account); got != tt.want {
+			t.Errorf("SampleRate(%s) = %v, want %v", tt.account, got, tt.want)
+		}
+	}
+}
+
+func TestNewRecordingPolicy_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		rate     float64
+		accounts string
+	}{
+		{"default above 1", 1.5, ""},
+		{"negative default", -0.1, ""},
+		{"bad json", 1, `{"pub":`},
+		{"account rate out of range", 1, `{"pub":{"sample_rate":2}}`},
+	}
+	for _, tt := range tests {
+		if _, err := NewRecordingPolicy(tt.rate, tt.accounts); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+}
+
+func TestRecordingPolicy_ShouldRecord(t *testing.T) {
+	p, err := NewRecordingPolicy(1, `{"pub-10":{"sample_rate":0.1}}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	recorded := 0
+	const auctions = 10000
+	for i := 0; i < auctions; i++ {
+		id := fmt.Sprintf("auction-%d", i)
+		if p.ShouldRecord(id, "pub-10") {
+			recorded++
+		}
+		// Decisions are deterministic per auction
+		if p.ShouldRecord(id, "pub-10") != p.ShouldRecord(id, "pub-10") {
+			t.Fatalf("non-deterministic decision for %s", id)
+		}
+		if !p.ShouldRecord(id, "other") {
+			t.Fatalf("expected default rate 1 to record %s", id)
+		}
+	}
+
+	if recorded < 800 || recorded > 1200 {
+		t.Errorf("expected ~10%% of auctions recorded, got %d/%d", recorded, auctions)
+	}
+}
+
+func TestEventRecorder_RecordingPolicy(t *testing.T) {
+	r := NewEventRecorder("http://localhost:0", 1000)
+	defer r.Close()
+
+	p, err := NewRecordingPolicy(1, `{"optout":{"enabled":false}}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r.SetRecordingPolicy(p)
+
+	r.RecordBidResponse("a1", "appnexus", 10, false, nil, nil, "USA", "", "banner", "300x250", "optout", false, false, "")
+	r.RecordWin("a1", "appnexus", 1.0, "USA", "", "banner", "300x250", "optout")
+	r.RecordBidResponse("a2", "appnexus", 10, false, nil, nil, "USA", "", "banner", "300x250", "pub-1", false, false, "")
+
+	stats := r.Stats()
+	if stats.TotalEvents != 1 || stats.BufferedEvents != 1 {
+		t.Errorf("expected 1 recorded event, got total=%d buffered=%d", stats.TotalEvents, stats.BufferedEvents)
+	}
+	if stats.SkippedEvents != 2 {
+		t.Errorf("expected 2 skipped events, got %d", stats.SkippedEvents)
+	}
+
+	// Removing the policy records everything again
+	r.SetRecordingPolicy(nil)
+	r.RecordWin("a3", "appnexus", 1.0, "USA", "", "banner", "300x250", "optout")
+	if got := r.Stats().TotalEvents; got != 2 {
+		t.Errorf("expected 2 recorded events without policy, got %d", got)
+	}
+}
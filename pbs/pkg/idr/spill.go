@@ -0,0 +1,281 @@
+package idr
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// spillSegmentsPerQueue splits the spill size limit into this many
+	// segment files, so the oldest can be dropped without losing much
+	spillSegmentsPerQueue = 16
+	// minSpillSegmentSize keeps segments from getting too small to hold a batch
+	minSpillSegmentSize = 64 << 10
+
+	spillSegmentPrefix = "events-"
+	spillSegmentSuffix = ".jsonl"
+)
+
+// spillQueue keeps the event batches that couldn't be delivered in segment
+// files under a directory, one JSON batch per line, so they survive IDR
+// outages and restarts. Segments are replayed oldest first. The total size is
+// bounded: once full, the oldest segments are dropped to make room.
+type spillQueue struct {
+	dir         string
+	maxBytes    int64
+	segmentSize int64
+
+	mu       sync.Mutex
+	segments []*spillSegment // Oldest first; the last may be open for writing
+	active   *os.File        // Segment being written, nil until the next write
+	size     int64           // Bytes across segments
+	events   int64           // Events across segments
+	nextSeq  uint64
+}
+
+// spillSegment is one segment file
+type spillSegment struct {
+	seq    uint64
+	size   int64
+	events int
+}
+
+// openSpillQueue opens the spill queue in dir, creating it if needed and
+// picking up the segments a previous process left behind
+func openSpillQueue(dir string, maxBytes int64) (*spillQueue, error) {
+	if maxBytes <= 0 {
+		return nil, fmt.Errorf("spill size limit must be positive")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create spill directory: %w", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spill directory: %w", err)
+	}
+
+	q := &spillQueue{
+		dir:         dir,
+		maxBytes:    maxBytes,
+		segmentSize: max(maxBytes/spillSegmentsPerQueue, minSpillSegmentSize),
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, spillSegmentPrefix) || !strings.HasSuffix(name, spillSegmentSuffix) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(name, spillSegmentPrefix), spillSegmentSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		batches, size, err := readSpillSegment(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		seg := &spillSegment{seq: seq, size: size, events: countEvents(batches)}
+		q.segments = append(q.segments, seg)
+		q.size += seg.size
+		q.events += int64(seg.events)
+		q.nextSeq = max(q.nextSeq, seq+1)
+	}
+	sort.Slice(q.segments, func(i, j int) bool { return q.segments[i].seq < q.segments[j].seq })
+	return q, nil
+}
+
+// write appends a batch to the queue, dropping the oldest segments when it
+// would go over its size limit. It returns the number of events dropped to
+// make room, and an error when the batch itself couldn't be kept.
+func (q *spillQueue) write(batch []BidEvent) (dropped int, err error) {
+	line, err := json.Marshal(batch)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal spilled events: %w", err)
+	}
+	line = append(line, '\n')
+	n := int64(len(line))
+	if n > q.segmentSize {
+		return 0, fmt.Errorf("event batch of %d bytes is larger than a spill segment", n)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for q.size+n > q.maxBytes {
+		events, ok := q.dropOldest()
+		if !ok {
+			return dropped, fmt.Errorf("spill queue is full")
+		}
+		dropped += events
+	}
+
+	if q.active == nil || q.segments[len(q.segments)-1].size+n > q.segmentSize {
+		if err := q.rotate(); err != nil {
+			return dropped, err
+		}
+	}
+	if _, err := q.active.Write(line); err != nil {
+		// Don't append to a segment that may end in a partial line
+		q.seal()
+		return dropped, fmt.Errorf("failed to write spilled events: %w", err)
+	}
+	seg := q.segments[len(q.segments)-1]
+	seg.size += n
+	seg.events += len(batch)
+	q.size += n
+	q.events += int64(len(batch))
+	return dropped, nil
+}
+
+// rotate closes the segment being written and starts a new one
+func (q *spillQueue) rotate() error {
+	q.seal()
+	seq := q.nextSeq
+	f, err := os.OpenFile(q.segmentPath(seq), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create spill segment: %w", err)
+	}
+	q.nextSeq++
+	q.active = f
+	q.segments = append(q.segments, &spillSegment{seq: seq})
+	return nil
+}
+
+// seal closes the segment being written, if any; the next write starts a new one
+func (q *spillQueue) seal() {
+	if q.active != nil {
+		q.active.Close()
+		q.active = nil
+	}
+}
+
+// dropOldest removes the oldest segment other than the one being written,
+// returning the events it held. It fails when there is no such segment.
+func (q *spillQueue) dropOldest() (int, bool) {
+	if len(q.segments) == 0 || (len(q.segments) == 1 && q.active != nil) {
+		return 0, false
+	}
+	seg := q.segments[0]
+	q.segments = q.segments[1:]
+	q.size -= seg.size
+	q.events -= int64(seg.events)
+	os.Remove(q.segmentPath(seg.seq))
+	return seg.events, true
+}
+
+// take removes the oldest segment from the queue for replay and returns its
+// batches. The file stays on disk until done, so a restart mid-replay
+// replays it again. It returns nil when the queue is empty, and the segment
+// along with the error when it can't be read; the segment is then gone.
+func (q *spillQueue) take() (*spillSegment, [][]BidEvent, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.segments) == 0 {
+		return nil, nil, nil
+	}
+	seg := q.segments[0]
+	if len(q.segments) == 1 {
+		q.seal()
+	}
+	q.segments = q.segments[1:]
+	q.size -= seg.size
+	q.events -= int64(seg.events)
+
+	batches, _, err := readSpillSegment(q.segmentPath(seg.seq))
+	if err != nil {
+		os.Remove(q.segmentPath(seg.seq))
+		return seg, nil, err
+	}
+	return seg, batches, nil
+}
+
+// done deletes a replayed segment
+func (q *spillQueue) done(seg *spillSegment) {
+	os.Remove(q.segmentPath(seg.seq))
+}
+
+// putBack returns the batches of a taken segment that weren't replayed to the
+// front of the queue
+func (q *spillQueue) putBack(seg *spillSegment, remaining [][]BidEvent) error {
+	var buf bytes.Buffer
+	for _, batch := range remaining {
+		line, err := json.Marshal(batch)
+		if err != nil {
+			continue
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	path := q.segmentPath(seg.seq)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		os.Remove(path)
+		return fmt.Errorf("failed to rewrite spill segment: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		os.Remove(path)
+		return fmt.Errorf("failed to rewrite spill segment: %w", err)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	seg.size = int64(buf.Len())
+	seg.events = countEvents(remaining)
+	q.segments = append([]*spillSegment{seg}, q.segments...)
+	q.size += seg.size
+	q.events += int64(seg.events)
+	return nil
+}
+
+// pending returns the events and bytes waiting on disk
+func (q *spillQueue) pending() (events, size int64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.events, q.size
+}
+
+// close closes the segment being written; its events stay on disk
+func (q *spillQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.seal()
+}
+
+func (q *spillQueue) segmentPath(seq uint64) string {
+	return filepath.Join(q.dir, fmt.Sprintf("%s%020d%s", spillSegmentPrefix, seq, spillSegmentSuffix))
+}
+
+// readSpillSegment reads a segment's batches and size. Lines that don't
+// decode, such as one cut short by a crash, are skipped.
+func readSpillSegment(path string) ([][]BidEvent, int64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read spill segment: %w", err)
+	}
+	var batches [][]BidEvent
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for scanner.Scan() {
+		var batch []BidEvent
+		if json.Unmarshal(scanner.Bytes(), &batch) == nil && len(batch) > 0 {
+			batches = append(batches, batch)
+		}
+	}
+	return batches, int64(len(data)), nil
+}
+
+func countEvents(batches [][]BidEvent) int {
+	n := 0
+	for _, batch := range batches {
+		n += len(batch)
+	}
+	return n
+}
@@ -0,0 +1,199 @@
+package idr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func spillBatch(auctionID string, n int) []BidEvent {
+	batch := make([]BidEvent, n)
+	for i := range batch {
+		batch[i] = BidEvent{AuctionID: auctionID, BidderCode: fmt.Sprintf("bidder%d", i), EventType: "bid_response"}
+	}
+	return batch
+}
+
+func TestSpillQueue_SurvivesReopen(t *testing.T) {
+	dir := t.TempDir()
+	q, err := openSpillQueue(dir, 1<<20)
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	for _, id := range []string{"a1", "a2"} {
+		if _, err := q.write(spillBatch(id, 2)); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+	q.close()
+
+	q, err = openSpillQueue(dir, 1<<20)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	if events, _ := q.pending(); events != 4 {
+		t.Fatalf("expected 4 pending events after reopen, got %d", events)
+	}
+	// Writes after a restart go to a new segment, replayed after the old one
+	if _, err := q.write(spillBatch("a3", 1)); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	var order []string
+	for {
+		seg, batches, err := q.take()
+		if err != nil {
+			t.Fatalf("take failed: %v", err)
+		}
+		if seg == nil {
+			break
+		}
+		for _, batch := range batches {
+			order = append(order, batch[0].AuctionID)
+		}
+		q.done(seg)
+	}
+	if fmt.Sprint(order) != "[a1 a2 a3]" {
+		t.Errorf("expected batches replayed oldest first, got %v", order)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("expected replayed segments removed, got %d files", len(entries))
+	}
+}
+
+func TestSpillQueue_DropsOldestWhenFull(t *testing.T) {
+	q, err := openSpillQueue(t.TempDir(), 4*minSpillSegmentSize)
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	line, _ := json.Marshal(spillBatch("first", 50))
+	perSegment := minSpillSegmentSize / (len(line) + 1)
+
+	dropped := 0
+	for i := 0; i < 6*perSegment; i++ {
+		n, err := q.write(spillBatch(fmt.Sprintf("a%d", i), 50))
+		if err != nil {
+			t.Fatalf("write %d failed: %v", i, err)
+		}
+		dropped += n
+	}
+
+	events, size := q.pending()
+	if size > 4*minSpillSegmentSize {
+		t.Errorf("expected the spill kept within its limit, got %d bytes", size)
+	}
+	if dropped == 0 || events+int64(dropped) != int64(6*perSegment*50) {
+		t.Errorf("expected every event either pending or dropped, got %d pending and %d dropped", events, dropped)
+	}
+	_, batches, _ := q.take()
+	if batches[0][0].AuctionID == "a0" {
+		t.Error("expected the oldest batches dropped")
+	}
+
+	if _, err := q.write(spillBatch("huge", 10000)); err == nil {
+		t.Error("expected a batch larger than a segment rejected")
+	}
+}
+
+// spillServer accepts events only while up is set
+type spillServer struct {
+	up       atomic.Bool
+	mu       sync.Mutex
+	received []string
+}
+
+func (s *spillServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.up.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	var body struct {
+		Events []BidEvent `json:"events"`
+	}
+	json.NewDecoder(r.Body).Decode(&body)
+	s.mu.Lock()
+	for _, ev := range body.Events {
+		s.received = append(s.received, ev.AuctionID)
+	}
+	s.mu.Unlock()
+}
+
+func (s *spillServer) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.received)
+}
+
+type spillMetrics struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (m *spillMetrics) RecordIDREventSpill(result string, events int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counts[result] += events
+}
+
+func TestEventRecorder_SpillsAndReplays(t *testing.T) {
+	srv := &spillServer{}
+	server := httptest.NewServer(srv)
+	defer server.Close()
+	dir := t.TempDir()
+
+	// IDR is down: events spill, and stay spilled across a restart
+	r := NewEventRecorder(server.URL, 100)
+	if err := r.EnableSpill(dir, 1<<20); err != nil {
+		t.Fatalf("enable spill failed: %v", err)
+	}
+	r.RecordWin("auction-1", "appnexus", 1.5, "US", "desktop", "banner", "300x250", "pub-1")
+	r.RecordWin("auction-2", "appnexus", 2.5, "US", "desktop", "banner", "300x250", "pub-1")
+	if err := r.Close(); err == nil {
+		t.Fatal("expected the final flush to fail while IDR is down")
+	}
+	if stats := r.Stats(); stats.SpilledEvents != 2 || stats.PendingSpill != 2 {
+		t.Fatalf("expected 2 events spilled, got %+v", stats)
+	}
+
+	// IDR is back: the new process replays them once a live send succeeds
+	srv.up.Store(true)
+	r = NewEventRecorder(server.URL, 1)
+	metrics := &spillMetrics{counts: make(map[string]int)}
+	r.SetMetrics(metrics)
+	if err := r.EnableSpill(dir, 1<<20); err != nil {
+		t.Fatalf("enable spill failed: %v", err)
+	}
+	defer r.Close()
+	r.RecordWin("auction-3", "appnexus", 1.0, "US", "desktop", "banner", "300x250", "pub-1")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for srv.count() < 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	srv.mu.Lock()
+	received := append([]string(nil), srv.received...)
+	srv.mu.Unlock()
+	if len(received) != 3 {
+		t.Fatalf("expected the live and both spilled events delivered, got %v", received)
+	}
+
+	stats := r.Stats()
+	if stats.ReplayedEvents != 2 || stats.PendingSpill != 0 || stats.SpillBytes != 0 {
+		t.Errorf("expected the spill replayed and empty, got %+v", stats)
+	}
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	if metrics.counts[SpillResultReplayed] != 2 {
+		t.Errorf("expected 2 replayed events reported, got %v", metrics.counts)
+	}
+	if err := r.Flush(context.Background()); err != nil {
+		t.Errorf("unexpected flush error: %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+// Package jsoncodec encodes and decodes JSON on the auction hot path: bid
+// requests read from clients and sent to bidders, and the responses parsed
+// from them. It uses encoding/json unless built with -tags jsoniter, which
+// swaps in jsoniter's encoding/json-compatible configuration.
+package jsoncodec
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// Name returns the JSON implementation compiled in: "encoding/json" or
+// "jsoniter"
+func Name() string {
+	return name
+}
+
+// Marshal returns the JSON encoding of v, as json.Marshal does
+func Marshal(v any) ([]byte, error) {
+	return marshal(v)
+}
+
+// Unmarshal parses data into v, as json.Unmarshal does
+func Unmarshal(data []byte, v any) error {
+	return unmarshal(data, v)
+}
+
+// Encode writes the JSON encoding of v to w followed by a newline, as
+// json.Encoder.Encode does
+func Encode(w io.Writer, v any) error {
+	return encode(w, v)
+}
+
+// maxPooledBuffer caps the buffers kept for reuse, so one oversized body
+// doesn't pin its memory
+const maxPooledBuffer = 1 << 20
+
+var buffers = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// ReadAll reads r to EOF through a pooled buffer and returns a copy sized to
+// the data. Bodies of similar size then cost one allocation each, rather
+// than the repeated growth of io.ReadAll.
+func ReadAll(r io.Reader) ([]byte, error) {
+	buf := buffers.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer func() {
+		if buf.Cap() <= maxPooledBuffer {
+			buffers.Put(buf)
+		}
+	}()
+
+	if _, err := buf.ReadFrom(r); err != nil {
+		return nil, err
+	}
+	return bytes.Clone(buf.Bytes()), nil
+}
@@ -0,0 +1,127 @@
+package jsoncodec
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type sample struct {
+	ID      string            `json:"id"`
+	Price   float64           `json:"price"`
+	Small   float64           `json:"small,omitempty"`
+	Secure  *int              `json:"secure,omitempty"`
+	Sizes   []int             `json:"sizes,omitempty"`
+	Targets map[string]string `json:"targets,omitempty"`
+	AdM     string            `json:"adm,omitempty"`
+	Ext     json.RawMessage   `json:"ext,omitempty"`
+	skipped int
+}
+
+func TestMarshal_MatchesEncodingJSON(t *testing.T) {
+	secure := 0
+	v := sample{
+		ID:      "bid-1",
+		Price:   1.25,
+		Small:   0.0000001,
+		Secure:  &secure,
+		Sizes:   []int{300, 250},
+		Targets: map[string]string{"hb_pb": "1.20", "hb_bidder": "appnexus"},
+		AdM:     `<script src="https://cdn.example/ad.js?a=1&b=2"></script>`,
+		Ext:     json.RawMessage(`{ "prebid": {"type": "banner"} }`),
+	}
+
+	want, _ := json.Marshal(v)
+	got, err := Marshal(v)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !sameJSON(got, want) {
+		t.Errorf("%s output differs from encoding/json:\n got %s\nwant %s", Name(), got, want)
+	}
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, v); err != nil || !strings.HasSuffix(buf.String(), "}\n") || !sameJSON(buf.Bytes(), want) {
+		t.Errorf("expected Encode to match with a trailing newline, got %q (%v)", buf.String(), err)
+	}
+
+	var decoded sample
+	if err := Unmarshal(got, &decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if decoded.Price != 1.25 || *decoded.Secure != 0 || decoded.Targets["hb_bidder"] != "appnexus" || !sameJSON(decoded.Ext, v.Ext) {
+		t.Errorf("round trip mismatch: %+v", decoded)
+	}
+}
+
+// sameJSON reports whether a and b encode the same value. Implementations may
+// differ in insignificant ways: jsoniter writes 1e-07 for 1e-7, and copies
+// json.RawMessage as is where encoding/json compacts it.
+func sameJSON(a, b []byte) bool {
+	var av, bv any
+	return json.Unmarshal(a, &av) == nil && json.Unmarshal(b, &bv) == nil && reflect.DeepEqual(av, bv)
+}
+
+func TestUnmarshal_Errors(t *testing.T) {
+	var v sample
+	if err := Unmarshal([]byte(`{"id":`), &v); err == nil {
+		t.Error("expected truncated JSON rejected")
+	}
+	if err := Unmarshal([]byte(`{"id":1}`), &v); err == nil {
+		t.Error("expected a type mismatch rejected")
+	}
+}
+
+func TestReadAll(t *testing.T) {
+	body := strings.Repeat("x", 3000)
+	for i := 0; i < 3; i++ {
+		got, err := ReadAll(strings.NewReader(body))
+		if err != nil || string(got) != body {
+			t.Fatalf("read %d: expected the body back, got %d bytes (%v)", i, len(got), err)
+		}
+	}
+
+	// The returned slice isn't reused by later reads
+	first, _ := ReadAll(strings.NewReader("first"))
+	ReadAll(strings.NewReader("second"))
+	if string(first) != "first" {
+		t.Errorf("expected the first result unchanged, got %q", first)
+	}
+
+	// Oversized buffers are read but not kept
+	large, err := ReadAll(strings.NewReader(strings.Repeat("y", maxPooledBuffer+1)))
+	if err != nil || len(large) != maxPooledBuffer+1 {
+		t.Errorf("expected a large body read, got %d bytes (%v)", len(large), err)
+	}
+
+	readErr := errors.New("connection reset")
+	if _, err := ReadAll(io.MultiReader(strings.NewReader("partial"), &failingReader{readErr})); !errors.Is(err, readErr) {
+		t.Errorf("expected the read error, got %v", err)
+	}
+}
+
+type failingReader struct{ err error }
+
+func (r *failingReader) Read([]byte) (int, error) { return 0, r.err }
+
+// Compare with: go test -bench . -benchmem ./pkg/jsoncodec/
+func BenchmarkReadAll(b *testing.B) {
+	body := []byte(strings.Repeat(`{"id":"bid","price":1.5},`, 400)) // ~10KB, a typical bidder response
+
+	b.Run("io.ReadAll", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			io.ReadAll(bytes.NewReader(body))
+		}
+	})
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			ReadAll(bytes.NewReader(body))
+		}
+	})
+}
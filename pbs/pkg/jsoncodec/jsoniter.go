@@ -0,0 +1,29 @@
+//go:build jsoniter
+
+package jsoncodec
+
+import (
+	"io"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+const name = "jsoniter"
+
+// compat matches encoding/json: sorted map keys, HTML escaping, and the same
+// handling of struct tags and json.Marshaler. Output may differ only in
+// insignificant ways, such as 1e-07 for 1e-7 and json.RawMessage written
+// without being compacted.
+var compat = jsoniter.ConfigCompatibleWithStandardLibrary
+
+func marshal(v any) ([]byte, error) {
+	return compat.Marshal(v)
+}
+
+func unmarshal(data []byte, v any) error {
+	return compat.Unmarshal(data, v)
+}
+
+func encode(w io.Writer, v any) error {
+	return compat.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,22 @@
+//go:build !jsoniter
+
+package jsoncodec
+
+import (
+	"encoding/json"
+	"io"
+)
+
+const name = "encoding/json"
+
+func marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+func encode(w io.Writer, v any) error {
+	return json.NewEncoder(w).Encode(v)
+}
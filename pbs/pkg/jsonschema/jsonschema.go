@@ -0,0 +1,394 @@
+// Package jsonschema validates JSON documents against the subset of JSON
+// Schema used to describe bidder params: type, properties, required,
+// additionalProperties, items, enum, string length and pattern, numeric
+// bounds, array length, anyOf and oneOf. Other keywords, such as title and
+// description, are accepted and ignored. It has no dependencies on the rest
+// of the server.
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Schema is a compiled schema. Safe for concurrent use.
+type Schema struct {
+	types                []string
+	properties           map[string]*Schema
+	required             []string
+	additionalProperties *Schema // nil allows anything
+	noAdditional         bool    // additionalProperties: false
+	items                *Schema
+	enum                 []any
+	minLength, maxLength *int
+	pattern              *regexp.Regexp
+	minimum, maximum     *float64
+	minItems, maxItems   *int
+	anyOf, oneOf         []*Schema
+}
+
+// ValidationError describes where a document doesn't match its schema
+type ValidationError struct {
+	Path    string // JSON path of the offending value, e.g. "unit" or "bcat[1]"; "" for the root
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// rawSchema is a schema as written
+type rawSchema struct {
+	Type                 json.RawMessage            `json:"type"`
+	Properties           map[string]json.RawMessage `json:"properties"`
+	Required             []string                   `json:"required"`
+	AdditionalProperties json.RawMessage            `json:"additionalProperties"`
+	Items                json.RawMessage            `json:"items"`
+	Enum                 []any                      `json:"enum"`
+	MinLength            *int                       `json:"minLength"`
+	MaxLength            *int                       `json:"maxLength"`
+	Pattern              string                     `json:"pattern"`
+	Minimum              *float64                   `json:"minimum"`
+	Maximum              *float64                   `json:"maximum"`
+	MinItems             *int                       `json:"minItems"`
+	MaxItems             *int                       `json:"maxItems"`
+	AnyOf                []json.RawMessage          `json:"anyOf"`
+	OneOf                []json.RawMessage          `json:"oneOf"`
+}
+
+var validTypes = []string{"object", "array", "string", "number", "integer", "boolean", "null"}
+
+// Compile parses a schema
+func Compile(data []byte) (*Schema, error) {
+	return compile(data, "")
+}
+
+func compile(data []byte, path string) (*Schema, error) {
+	var raw rawSchema
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("schema %s: %w", describePath(path), err)
+	}
+	s := &Schema{
+		required:  raw.Required,
+		minLength: raw.MinLength,
+		maxLength: raw.MaxLength,
+		minimum:   raw.Minimum,
+		maximum:   raw.Maximum,
+		minItems:  raw.MinItems,
+		maxItems:  raw.MaxItems,
+	}
+
+	if len(raw.Type) > 0 {
+		var single string
+		if json.Unmarshal(raw.Type, &single) == nil {
+			s.types = []string{single}
+		} else if err := json.Unmarshal(raw.Type, &s.types); err != nil {
+			return nil, fmt.Errorf("schema %s: type must be a string or an array of strings", describePath(path))
+		}
+		for _, t := range s.types {
+			if !slices.Contains(validTypes, t) {
+				return nil, fmt.Errorf("schema %s: unknown type %q", describePath(path), t)
+			}
+		}
+	}
+
+	for _, v := range raw.Enum {
+		s.enum = append(s.enum, normalize(v))
+	}
+
+	if raw.Pattern != "" {
+		re, err := regexp.Compile(raw.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("schema %s: pattern: %w", describePath(path), err)
+		}
+		s.pattern = re
+	}
+
+	if len(raw.Properties) > 0 {
+		s.properties = make(map[string]*Schema, len(raw.Properties))
+		for name, prop := range raw.Properties {
+			sub, err := compile(prop, joinPath(path, name))
+			if err != nil {
+				return nil, err
+			}
+			s.properties[name] = sub
+		}
+	}
+
+	if len(raw.AdditionalProperties) > 0 {
+		switch string(bytes.TrimSpace(raw.AdditionalProperties)) {
+		case "true":
+		case "false":
+			s.noAdditional = true
+		default:
+			sub, err := compile(raw.AdditionalProperties, path+".*")
+			if err != nil {
+				return nil, err
+			}
+			s.additionalProperties = sub
+		}
+	}
+
+	if len(raw.Items) > 0 {
+		sub, err := compile(raw.Items, path+"[]")
+		if err != nil {
+			return nil, err
+		}
+		s.items = sub
+	}
+
+	var err error
+	if s.anyOf, err = compileAll(raw.AnyOf, path); err != nil {
+		return nil, err
+	}
+	if s.oneOf, err = compileAll(raw.OneOf, path); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func compileAll(raws []json.RawMessage, path string) ([]*Schema, error) {
+	schemas := make([]*Schema, 0, len(raws))
+	for _, raw := range raws {
+		sub, err := compile(raw, path)
+		if err != nil {
+			return nil, err
+		}
+		schemas = append(schemas, sub)
+	}
+	return schemas, nil
+}
+
+// Validate checks a JSON document against the schema, returning a
+// *ValidationError for the first mismatch found
+func (s *Schema) Validate(doc []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return &ValidationError{Message: fmt.Sprintf("invalid JSON: %v", err)}
+	}
+	if dec.More() {
+		return &ValidationError{Message: "invalid JSON: trailing data"}
+	}
+	return s.validate(v, "")
+}
+
+func (s *Schema) validate(v any, path string) error {
+	fail := func(format string, args ...any) error {
+		return &ValidationError{Path: path, Message: fmt.Sprintf(format, args...)}
+	}
+
+	if len(s.types) > 0 && !slices.ContainsFunc(s.types, func(t string) bool { return hasType(v, t) }) {
+		return fail("expected %s, got %s", strings.Join(s.types, " or "), typeOf(v))
+	}
+	if len(s.enum) > 0 && !slices.ContainsFunc(s.enum, func(e any) bool { return reflect.DeepEqual(normalize(v), e) }) {
+		return fail("must be one of %s", formatEnum(s.enum))
+	}
+
+	switch v := v.(type) {
+	case string:
+		n := utf8.RuneCountInString(v)
+		if s.minLength != nil && n < *s.minLength {
+			if *s.minLength == 1 {
+				return fail("must not be empty")
+			}
+			return fail("must be at least %d characters", *s.minLength)
+		}
+		if s.maxLength != nil && n > *s.maxLength {
+			return fail("must be at most %d characters", *s.maxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			return fail("must match %s", s.pattern)
+		}
+	case json.Number:
+		f, _ := v.Float64()
+		if s.minimum != nil && f < *s.minimum {
+			return fail("must be at least %v", *s.minimum)
+		}
+		if s.maximum != nil && f > *s.maximum {
+			return fail("must be at most %v", *s.maximum)
+		}
+	case []any:
+		if s.minItems != nil && len(v) < *s.minItems {
+			return fail("must have at least %d items", *s.minItems)
+		}
+		if s.maxItems != nil && len(v) > *s.maxItems {
+			return fail("must have at most %d items", *s.maxItems)
+		}
+		if s.items != nil {
+			for i, item := range v {
+				if err := s.items.validate(item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case map[string]any:
+		for _, name := range s.required {
+			if _, ok := v[name]; !ok {
+				return &ValidationError{Path: joinPath(path, name), Message: "is required"}
+			}
+		}
+		// Sorted so the first error reported doesn't depend on map order
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			sub, ok := s.properties[name]
+			switch {
+			case ok:
+			case s.noAdditional:
+				return &ValidationError{Path: joinPath(path, name), Message: "is not allowed"}
+			case s.additionalProperties != nil:
+				sub = s.additionalProperties
+			default:
+				continue
+			}
+			if err := sub.validate(v[name], joinPath(path, name)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(s.anyOf) > 0 {
+		var first error
+		matched := false
+		for _, sub := range s.anyOf {
+			if err := sub.validate(v, path); err == nil {
+				matched = true
+				break
+			} else if first == nil {
+				first = err
+			}
+		}
+		if !matched {
+			return anyOfError(s.anyOf, first, path)
+		}
+	}
+	if len(s.oneOf) > 0 {
+		matches := 0
+		var first error
+		for _, sub := range s.oneOf {
+			if err := sub.validate(v, path); err == nil {
+				matches++
+			} else if first == nil {
+				first = err
+			}
+		}
+		switch {
+		case matches == 0:
+			return anyOfError(s.oneOf, first, path)
+		case matches > 1:
+			return fail("must match exactly one of %d alternatives, matched %d", len(s.oneOf), matches)
+		}
+	}
+	return nil
+}
+
+// anyOfError explains a value that matched none of its alternatives. When
+// every alternative only requires a field, as in "unit and one of delDomain
+// or platform", the fields are listed; otherwise the first alternative's
+// error is reported.
+func anyOfError(alternatives []*Schema, first error, path string) error {
+	var fields []string
+	for _, alt := range alternatives {
+		if len(alt.required) != 1 || alt.types != nil || alt.properties != nil || len(alt.anyOf)+len(alt.oneOf) > 0 {
+			return first
+		}
+		fields = append(fields, alt.required[0])
+	}
+	return &ValidationError{Path: path, Message: "one of " + strings.Join(fields, ", ") + " is required"}
+}
+
+// hasType reports whether v, decoded with UseNumber, is of JSON type t
+func hasType(v any, t string) bool {
+	switch v := v.(type) {
+	case map[string]any:
+		return t == "object"
+	case []any:
+		return t == "array"
+	case string:
+		return t == "string"
+	case bool:
+		return t == "boolean"
+	case nil:
+		return t == "null"
+	case json.Number:
+		if t == "number" {
+			return true
+		}
+		if t != "integer" {
+			return false
+		}
+		f, err := v.Float64()
+		return err == nil && f == math.Trunc(f) && !math.IsInf(f, 0)
+	}
+	return false
+}
+
+func typeOf(v any) string {
+	for _, t := range []string{"object", "array", "string", "boolean", "null", "number"} {
+		if hasType(v, t) {
+			return t
+		}
+	}
+	return "unknown"
+}
+
+// normalize converts decoded numbers to float64 so enum values compare equal
+// however they were written
+func normalize(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		f, _ := v.Float64()
+		return f
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = normalize(item)
+		}
+		return out
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			out[k] = normalize(item)
+		}
+		return out
+	}
+	return v
+}
+
+func formatEnum(values []any) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		b, _ := json.Marshal(v)
+		parts[i] = string(b)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func describePath(path string) string {
+	if path == "" {
+		return "root"
+	}
+	return path
+}
@@ -0,0 +1,113 @@
+package jsonschema
+
+import (
+	"errors"
+	"testing"
+)
+
+const paramsSchema = `{
+	"$schema": "http://json-schema.org/draft-04/schema#",
+	"title": "Example params",
+	"type": "object",
+	"properties": {
+		"unit": {"type": "string", "minLength": 1},
+		"delDomain": {"type": "string", "pattern": "\\.example\\.net$"},
+		"platform": {"type": "string"},
+		"floor": {"type": "number", "minimum": 0, "maximum": 100},
+		"zone": {"type": "integer"},
+		"size": {"type": "array", "items": {"type": "integer"}, "minItems": 2, "maxItems": 2},
+		"mode": {"enum": ["fast", "safe", 1]},
+		"id": {"type": ["string", "integer"]},
+		"custom": {"type": "object", "additionalProperties": {"type": "string"}}
+	},
+	"required": ["unit"],
+	"anyOf": [{"required": ["delDomain"]}, {"required": ["platform"]}]
+}`
+
+func TestSchema_Validate(t *testing.T) {
+	s, err := Compile([]byte(paramsSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		doc  string
+		want string // "" for valid
+	}{
+		{doc: `{"unit":"1","platform":"p"}`},
+		{doc: `{"unit":"1","delDomain":"a.example.net","floor":1.5,"zone":3,"size":[300,250],"mode":1,"id":7,"custom":{"k":"v"},"other":true}`},
+		{doc: `{"unit":"1","platform":"p","zone":3.0,"mode":"safe","id":"x"}`},
+		{doc: `{"platform":"p"}`, want: "unit: is required"},
+		{doc: `{"unit":"","platform":"p"}`, want: "unit: must not be empty"},
+		{doc: `{"unit":1,"platform":"p"}`, want: "unit: expected string, got number"},
+		{doc: `{"unit":"1"}`, want: "one of delDomain, platform is required"},
+		{doc: `{"unit":"1","delDomain":"a.example.com"}`, want: `delDomain: must match \.example\.net$`},
+		{doc: `{"unit":"1","platform":"p","floor":-1}`, want: "floor: must be at least 0"},
+		{doc: `{"unit":"1","platform":"p","floor":101}`, want: "floor: must be at most 100"},
+		{doc: `{"unit":"1","platform":"p","zone":1.5}`, want: "zone: expected integer, got number"},
+		{doc: `{"unit":"1","platform":"p","size":[300]}`, want: "size: must have at least 2 items"},
+		{doc: `{"unit":"1","platform":"p","size":[300,"250"]}`, want: "size[1]: expected integer, got string"},
+		{doc: `{"unit":"1","platform":"p","mode":"slow"}`, want: `mode: must be one of "fast", "safe", 1`},
+		{doc: `{"unit":"1","platform":"p","id":true}`, want: "id: expected string or integer, got boolean"},
+		{doc: `{"unit":"1","platform":"p","custom":{"k":1}}`, want: "custom.k: expected string, got number"},
+		{doc: `[1]`, want: "expected object, got array"},
+		{doc: `{"unit":`, want: "invalid JSON: unexpected EOF"},
+		{doc: `{} {}`, want: "invalid JSON: trailing data"},
+	}
+	for _, tt := range tests {
+		err := s.Validate([]byte(tt.doc))
+		if tt.want == "" {
+			if err != nil {
+				t.Errorf("%s: expected valid, got %v", tt.doc, err)
+			}
+			continue
+		}
+		var verr *ValidationError
+		if !errors.As(err, &verr) || err.Error() != tt.want {
+			t.Errorf("%s: expected %q, got %v", tt.doc, tt.want, err)
+		}
+	}
+}
+
+func TestSchema_AdditionalPropertiesFalse(t *testing.T) {
+	s, err := Compile([]byte(`{"type":"object","properties":{"a":{"type":"string"}},"additionalProperties":false}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Validate([]byte(`{"a":"x"}`)); err != nil {
+		t.Errorf("expected valid, got %v", err)
+	}
+	if err := s.Validate([]byte(`{"a":"x","b":1}`)); err == nil || err.Error() != "b: is not allowed" {
+		t.Errorf("expected b rejected, got %v", err)
+	}
+}
+
+func TestSchema_OneOf(t *testing.T) {
+	s, err := Compile([]byte(`{"oneOf":[{"type":"integer"},{"type":"number","minimum":10}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Validate([]byte(`1.5`)); err == nil {
+		t.Error("expected 1.5 to match neither alternative")
+	}
+	if err := s.Validate([]byte(`5`)); err != nil {
+		t.Errorf("expected 5 to match only the first alternative, got %v", err)
+	}
+	if err := s.Validate([]byte(`12`)); err == nil || err.Error() != "must match exactly one of 2 alternatives, matched 2" {
+		t.Errorf("expected 12 to match both alternatives, got %v", err)
+	}
+}
+
+func TestCompile_Invalid(t *testing.T) {
+	for _, schema := range []string{
+		`[]`,
+		`{"type":"str"}`,
+		`{"type":5}`,
+		`{"pattern":"("}`,
+		`{"properties":{"a":{"type":"bogus"}}}`,
+		`{"anyOf":[{"items":{"type":1}}]}`,
+	} {
+		if _, err := Compile([]byte(schema)); err == nil {
+			t.Errorf("%s: expected a compile error", schema)
+		}
+	}
+}
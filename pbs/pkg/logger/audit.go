@@ -0,0 +1,94 @@
+package logger
+
+import (
+	"math"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// auditLog writes auction audit lines; it ignores level sampling
+var auditLog zerolog.Logger
+
+// auctionSampleRate holds the float64 bits of Config.AuctionSampleRate
+var auctionSampleRate atomic.Uint64
+
+func init() {
+	auctionSampleRate.Store(math.Float64bits(1))
+}
+
+// rateSampler keeps a share of lines at random
+type rateSampler float64
+
+// Sample implements zerolog.Sampler
+func (r rateSampler) Sample(zerolog.Level) bool {
+	return sampled(float64(r))
+}
+
+func sampled(rate float64) bool {
+	return rate >= 1 || (rate > 0 && rand.Float64() < rate)
+}
+
+func clampRate(rate float64) float64 {
+	if math.IsNaN(rate) {
+		return 1
+	}
+	return min(max(rate, 0), 1)
+}
+
+// AuctionAudit summarises one auction for its audit line
+type AuctionAudit struct {
+	RequestID       string
+	Account         string
+	Status          int    // HTTP status returned
+	Error           string // Why the auction failed, if it did
+	SelectedBidders []string
+	Bids            int // Bids returned
+	WinningBidder   string
+	WinningBidID    string
+	WinningPrice    float64
+	Currency        string
+	Latency         time.Duration
+}
+
+// Failed reports whether the auction was rejected or errored
+func (a *AuctionAudit) Failed() bool {
+	return a.Status >= 400 || a.Error != ""
+}
+
+// LogAuction writes the audit line for an auction as a single structured
+// record. Failed auctions are always logged, at warn (4xx) or error (5xx);
+// successful ones at info for the configured LOG_SAMPLE_AUCTIONS share.
+func LogAuction(a *AuctionAudit) {
+	var event *zerolog.Event
+	switch {
+	case a.Status >= 500:
+		event = auditLog.Error()
+	case a.Failed():
+		event = auditLog.Warn()
+	case sampled(math.Float64frombits(auctionSampleRate.Load())):
+		event = auditLog.Info()
+	default:
+		return
+	}
+	event = event.
+		Str("request_id", a.RequestID).
+		Str("account", a.Account).
+		Int("status", a.Status).
+		Strs("selected_bidders", a.SelectedBidders).
+		Int("bids", a.Bids).
+		Int64("latency_ms", a.Latency.Milliseconds())
+	if a.WinningBidID != "" {
+		event = event.
+			Str("winning_bidder", a.WinningBidder).
+			Str("winning_bid_id", a.WinningBidID).
+			Float64("winning_price", a.WinningPrice).
+			Str("currency", a.Currency)
+	}
+	if a.Error != "" {
+		event = event.Str("error", a.Error)
+	}
+	event.Msg("auction")
+}
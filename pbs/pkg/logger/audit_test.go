@@ -0,0 +1,105 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// captureAudit points the audit logger at a buffer, keeping rate of successful auctions
+func captureAudit(t *testing.T, rate float64) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	prevLog, prevRate := auditLog, auctionSampleRate.Load()
+	auditLog = zerolog.New(&buf)
+	auctionSampleRate.Store(math.Float64bits(rate))
+	t.Cleanup(func() {
+		auditLog = prevLog
+		auctionSampleRate.Store(prevRate)
+	})
+	return &buf
+}
+
+func TestLogAuction_SingleLine(t *testing.T) {
+	buf := captureAudit(t, 1)
+	LogAuction(&AuctionAudit{
+		RequestID:       "req-1",
+		Status:          200,
+		SelectedBidders: []string{"appnexus", "rubicon"},
+		Bids:            2,
+		WinningBidder:   "rubicon",
+		WinningBidID:    "r1",
+		WinningPrice:    2.5,
+		Currency:        "USD",
+		Latency:         42 * time.Millisecond,
+	})
+
+	if lines := strings.Count(buf.String(), "\n"); lines != 1 {
+		t.Fatalf("expected one line, got %d: %s", lines, buf)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if got["level"] != "info" || got["request_id"] != "req-1" || got["winning_bidder"] != "rubicon" ||
+		got["winning_price"] != 2.5 || got["latency_ms"] != float64(42) {
+		t.Errorf("unexpected record %v", got)
+	}
+	if bidders, _ := got["selected_bidders"].([]any); len(bidders) != 2 {
+		t.Errorf("expected 2 selected bidders, got %v", got["selected_bidders"])
+	}
+}
+
+func TestLogAuction_Sampling(t *testing.T) {
+	buf := captureAudit(t, 0)
+
+	LogAuction(&AuctionAudit{RequestID: "ok", Status: 200})
+	if buf.Len() != 0 {
+		t.Fatalf("expected successful auction to be sampled out, got %s", buf)
+	}
+
+	LogAuction(&AuctionAudit{RequestID: "bad", Status: 400, Error: "invalid request"})
+	LogAuction(&AuctionAudit{RequestID: "err", Status: 500, Error: "boom"})
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected failures to always be logged, got %q", lines)
+	}
+	if !strings.Contains(lines[0], `"level":"warn"`) || !strings.Contains(lines[1], `"level":"error"`) {
+		t.Errorf("expected warn for 4xx and error for 5xx, got %q", lines)
+	}
+}
+
+func TestRateSampler(t *testing.T) {
+	if !rateSampler(1).Sample(zerolog.InfoLevel) || rateSampler(0).Sample(zerolog.InfoLevel) {
+		t.Error("expected rate 1 to keep and rate 0 to drop every line")
+	}
+	kept := 0
+	for range 10000 {
+		if rateSampler(0.1).Sample(zerolog.InfoLevel) {
+			kept++
+		}
+	}
+	if kept < 700 || kept > 1300 {
+		t.Errorf("expected about 10%% kept, got %d/10000", kept)
+	}
+}
+
+func TestGetEnvRate(t *testing.T) {
+	t.Setenv("LOG_SAMPLE_TEST", "0.01")
+	if got := getEnvRate("LOG_SAMPLE_TEST", 1); got != 0.01 {
+		t.Errorf("expected 0.01, got %v", got)
+	}
+	t.Setenv("LOG_SAMPLE_TEST", "5")
+	if got := getEnvRate("LOG_SAMPLE_TEST", 1); got != 1 {
+		t.Errorf("expected rate clamped to 1, got %v", got)
+	}
+	t.Setenv("LOG_SAMPLE_TEST", "lots")
+	if got := getEnvRate("LOG_SAMPLE_TEST", 0.5); got != 0.5 {
+		t.Errorf("expected default for invalid value, got %v", got)
+	}
+}
@@ -4,7 +4,9 @@ package logger
 import (
 	"context"
 	"io"
+	"math"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/rs/zerolog"
@@ -30,14 +32,24 @@ type Config struct {
 	Level      string // debug, info, warn, error
 	Format     string // json, console
 	TimeFormat string // time format for console output
+
+	// Share (0-1) of lines kept at each level; warnings and errors are never sampled
+	DebugSampleRate float64
+	InfoSampleRate  float64
+	// Share (0-1) of successful auctions given an audit line; failed ones always are
+	AuctionSampleRate float64
 }
 
-// DefaultConfig returns sensible defaults for production
+// DefaultConfig returns sensible defaults for production, overridden by
+// LOG_LEVEL, LOG_FORMAT, LOG_SAMPLE_DEBUG, LOG_SAMPLE_INFO and LOG_SAMPLE_AUCTIONS
 func DefaultConfig() Config {
 	return Config{
-		Level:      getEnv("LOG_LEVEL", "info"),
-		Format:     getEnv("LOG_FORMAT", "json"),
-		TimeFormat: time.RFC3339,
+		Level:             getEnv("LOG_LEVEL", "info"),
+		Format:            getEnv("LOG_FORMAT", "json"),
+		TimeFormat:        time.RFC3339,
+		DebugSampleRate:   getEnvRate("LOG_SAMPLE_DEBUG", 1),
+		InfoSampleRate:    getEnvRate("LOG_SAMPLE_INFO", 1),
+		AuctionSampleRate: getEnvRate("LOG_SAMPLE_AUCTIONS", 1),
 	}
 }
 
@@ -60,12 +72,25 @@ func Init(cfg Config) {
 	}
 
 	// Create logger with common fields
-	Log = zerolog.New(output).
+	base := zerolog.New(output).
 		Level(level).
 		With().
 		Timestamp().
 		Str("service", "pbs").
 		Logger()
+
+	// Audit lines are sampled by outcome rather than level
+	auditLog = base.With().Str("component", "auction_audit").Logger()
+	auctionSampleRate.Store(math.Float64bits(clampRate(cfg.AuctionSampleRate)))
+
+	Log = base
+	if cfg.DebugSampleRate < 1 || cfg.InfoSampleRate < 1 {
+		Log = base.Sample(zerolog.LevelSampler{
+			TraceSampler: rateSampler(clampRate(cfg.DebugSampleRate)),
+			DebugSampler: rateSampler(clampRate(cfg.DebugSampleRate)),
+			InfoSampler:  rateSampler(clampRate(cfg.InfoSampleRate)),
+		})
+	}
 }
 
 // WithRequestID adds a request ID to the logger context
@@ -113,6 +138,11 @@ func IDR() zerolog.Logger {
 	return Log.With().Str("component", "idr").Logger()
 }
 
+// PrivacyAudit returns a logger for the per-request privacy decisions trail
+func PrivacyAudit() zerolog.Logger {
+	return Log.With().Str("component", "privacy_audit").Logger()
+}
+
 // getEnv returns environment variable or default
 func getEnv(key, defaultVal string) string {
 	if val := os.Getenv(key); val != "" {
@@ -121,6 +151,14 @@ func getEnv(key, defaultVal string) string {
 	return defaultVal
 }
 
+// getEnvRate returns environment variable as a 0-1 rate or default
+func getEnvRate(key string, defaultVal float64) float64 {
+	if val, err := strconv.ParseFloat(os.Getenv(key), 64); err == nil {
+		return clampRate(val)
+	}
+	return defaultVal
+}
+
 // RequestLogger holds request-scoped logging state
 type RequestLogger struct {
 	logger    zerolog.Logger
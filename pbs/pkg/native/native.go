@@ -0,0 +1,231 @@
+// Package native parses OpenRTB Native 1.2 requests and responses and checks
+// that a native ad response fulfils the request it answers. Native 1.0 and 1.1
+// documents wrapped in a top-level "native" object are read too. It has no
+// dependencies outside the standard library.
+package native
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// Asset kinds, as named in validation errors
+const (
+	AssetTitle = "title"
+	AssetImage = "img"
+	AssetVideo = "video"
+	AssetData  = "data"
+)
+
+// Request is an imp.native.request: the assets the placement renders
+type Request struct {
+	Ver    string  `json:"ver,omitempty"`
+	Assets []Asset `json:"assets"`
+}
+
+// Asset is one requested asset; exactly one of its kinds is set
+type Asset struct {
+	ID       int             `json:"id"`
+	Required int             `json:"required,omitempty"`
+	Title    *TitleRequest   `json:"title,omitempty"`
+	Img      *ImageRequest   `json:"img,omitempty"`
+	Video    json.RawMessage `json:"video,omitempty"`
+	Data     *DataRequest    `json:"data,omitempty"`
+}
+
+// TitleRequest asks for a title of at most Len characters
+type TitleRequest struct {
+	Len int `json:"len"`
+}
+
+// ImageRequest asks for an image of a type (1 icon, 3 main)
+type ImageRequest struct {
+	Type int `json:"type,omitempty"`
+}
+
+// DataRequest asks for a data asset of a type (2 description, 12 CTA text, ...)
+type DataRequest struct {
+	Type int `json:"type"`
+}
+
+// Response is a native bid's adm
+type Response struct {
+	Ver           string          `json:"ver,omitempty"`
+	Assets        []ResponseAsset `json:"assets,omitempty"`
+	AssetsURL     string          `json:"assetsurl,omitempty"`
+	DCOURL        string          `json:"dcourl,omitempty"`
+	Link          *Link           `json:"link,omitempty"`
+	ImpTrackers   []string        `json:"imptrackers,omitempty"`
+	EventTrackers json.RawMessage `json:"eventtrackers,omitempty"`
+}
+
+// ResponseAsset is one returned asset, answering the request asset with its ID
+type ResponseAsset struct {
+	ID    int            `json:"id"`
+	Title *TitleResponse `json:"title,omitempty"`
+	Img   *ImageResponse `json:"img,omitempty"`
+	Video *VideoResponse `json:"video,omitempty"`
+	Data  *DataResponse  `json:"data,omitempty"`
+	Link  *Link          `json:"link,omitempty"`
+}
+
+// TitleResponse is a returned title
+type TitleResponse struct {
+	Text string `json:"text"`
+}
+
+// ImageResponse is a returned image
+type ImageResponse struct {
+	URL string `json:"url"`
+	W   int    `json:"w,omitempty"`
+	H   int    `json:"h,omitempty"`
+}
+
+// VideoResponse is a returned video's VAST
+type VideoResponse struct {
+	VASTTag string `json:"vasttag"`
+}
+
+// DataResponse is a returned data asset
+type DataResponse struct {
+	Value string `json:"value"`
+}
+
+// Link is where a click on the ad or an asset goes
+type Link struct {
+	URL           string   `json:"url"`
+	ClickTrackers []string `json:"clicktrackers,omitempty"`
+	Fallback      string   `json:"fallback,omitempty"`
+}
+
+// ParseRequest reads an imp.native.request
+func ParseRequest(s string) (*Request, error) {
+	var wrapped struct {
+		Native *Request `json:"native"`
+	}
+	if err := json.Unmarshal([]byte(s), &wrapped); err != nil {
+		return nil, fmt.Errorf("invalid native request: %w", err)
+	}
+	if wrapped.Native != nil {
+		return wrapped.Native, nil
+	}
+	var req Request
+	if err := json.Unmarshal([]byte(s), &req); err != nil {
+		return nil, fmt.Errorf("invalid native request: %w", err)
+	}
+	return &req, nil
+}
+
+// ParseResponse reads a native bid's adm
+func ParseResponse(adm string) (*Response, error) {
+	var wrapped struct {
+		Native *Response `json:"native"`
+	}
+	if err := json.Unmarshal([]byte(adm), &wrapped); err != nil {
+		return nil, fmt.Errorf("invalid native response: %w", err)
+	}
+	if wrapped.Native != nil {
+		return wrapped.Native, nil
+	}
+	var resp Response
+	if err := json.Unmarshal([]byte(adm), &resp); err != nil {
+		return nil, fmt.Errorf("invalid native response: %w", err)
+	}
+	return &resp, nil
+}
+
+// kind names the asset's kind, or "" when none is set
+func (a *Asset) kind() string {
+	switch {
+	case a.Title != nil:
+		return AssetTitle
+	case a.Img != nil:
+		return AssetImage
+	case len(a.Video) > 0:
+		return AssetVideo
+	case a.Data != nil:
+		return AssetData
+	}
+	return ""
+}
+
+// kind names the asset's kind, or "" when none is set
+func (a *ResponseAsset) kind() string {
+	switch {
+	case a.Title != nil:
+		return AssetTitle
+	case a.Img != nil:
+		return AssetImage
+	case a.Video != nil:
+		return AssetVideo
+	case a.Data != nil:
+		return AssetData
+	}
+	return ""
+}
+
+// empty reports whether the asset carries no content
+func (a *ResponseAsset) empty() bool {
+	switch {
+	case a.Title != nil:
+		return a.Title.Text == ""
+	case a.Img != nil:
+		return a.Img.URL == ""
+	case a.Video != nil:
+		return a.Video.VASTTag == ""
+	case a.Data != nil:
+		return a.Data.Value == ""
+	}
+	return true
+}
+
+// Validate checks that resp can render: it has a click-through link, each
+// asset answers a requested asset of the same kind (titles within the
+// requested len), and every required asset is returned with content.
+// Responses served from assetsurl or dcourl can't be checked and pass. req may
+// be nil, in which case only the link is checked.
+func Validate(req *Request, resp *Response) error {
+	if resp.AssetsURL != "" || resp.DCOURL != "" {
+		return nil
+	}
+	if resp.Link == nil || resp.Link.URL == "" {
+		return errors.New("native response has no link url")
+	}
+	if req == nil {
+		return nil
+	}
+
+	requested := make(map[int]*Asset, len(req.Assets))
+	for i := range req.Assets {
+		requested[req.Assets[i].ID] = &req.Assets[i]
+	}
+	returned := make(map[int]*ResponseAsset, len(resp.Assets))
+	for i := range resp.Assets {
+		asset := &resp.Assets[i]
+		want, ok := requested[asset.ID]
+		if !ok {
+			return fmt.Errorf("native asset %d was not requested", asset.ID)
+		}
+		switch got := asset.kind(); {
+		case got == "":
+			return fmt.Errorf("native asset %d has no title, img, video or data", asset.ID)
+		case got != want.kind():
+			return fmt.Errorf("native asset %d is a %s asset, requested %s", asset.ID, got, want.kind())
+		}
+		if want.Title != nil && want.Title.Len > 0 && utf8.RuneCountInString(asset.Title.Text) > want.Title.Len {
+			return fmt.Errorf("native title asset %d exceeds len %d", asset.ID, want.Title.Len)
+		}
+		returned[asset.ID] = asset
+	}
+	for _, want := range req.Assets {
+		if want.Required != 1 {
+			continue
+		}
+		if asset, ok := returned[want.ID]; !ok || asset.empty() {
+			return fmt.Errorf("required native %s asset %d missing", want.kind(), want.ID)
+		}
+	}
+	return nil
+}
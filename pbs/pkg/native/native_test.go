@@ -0,0 +1,77 @@
+package native
+
+import (
+	"strings"
+	"testing"
+)
+
+const testRequest = `{"ver":"1.2","assets":[
+	{"id":1,"required":1,"title":{"len":25}},
+	{"id":2,"required":1,"img":{"type":3,"w":1200,"h":627}},
+	{"id":3,"data":{"type":2}},
+	{"id":4,"video":{"mimes":["video/mp4"]}}
+]}`
+
+func TestParseRequest(t *testing.T) {
+	req, err := ParseRequest(testRequest)
+	if err != nil || req.Ver != "1.2" || len(req.Assets) != 4 {
+		t.Fatalf("expected the 1.2 request parsed, got %+v, %v", req, err)
+	}
+	if req.Assets[3].kind() != AssetVideo {
+		t.Errorf("expected asset 4 to be video, got %q", req.Assets[3].kind())
+	}
+
+	wrapped, err := ParseRequest(`{"native":{"ver":"1.1","assets":[{"id":1,"title":{"len":10}}]}}`)
+	if err != nil || wrapped.Ver != "1.1" || len(wrapped.Assets) != 1 {
+		t.Errorf("expected the wrapped 1.1 request parsed, got %+v, %v", wrapped, err)
+	}
+
+	if _, err := ParseRequest(`{"assets":`); err == nil {
+		t.Error("expected malformed request to fail")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	req, _ := ParseRequest(testRequest)
+	const link = `"link":{"url":"https://adv.example"}`
+
+	tests := []struct {
+		name    string
+		adm     string
+		wantErr string
+	}{
+		{"complete", `{` + link + `,"assets":[{"id":1,"title":{"text":"Hello"}},{"id":2,"img":{"url":"https://cdn.example/a.jpg"}},{"id":3,"data":{"value":"Desc"}}]}`, ""},
+		{"wrapped", `{"native":{` + link + `,"assets":[{"id":1,"title":{"text":"Hello"}},{"id":2,"img":{"url":"https://cdn.example/a.jpg"}}]}}`, ""},
+		{"assetsurl", `{"assetsurl":"https://adv.example/native.json"}`, ""},
+		{"no link", `{"assets":[{"id":1,"title":{"text":"Hello"}},{"id":2,"img":{"url":"https://cdn.example/a.jpg"}}]}`, "no link url"},
+		{"missing required", `{` + link + `,"assets":[{"id":1,"title":{"text":"Hello"}}]}`, "required native img asset 2 missing"},
+		{"empty required", `{` + link + `,"assets":[{"id":1,"title":{"text":""}},{"id":2,"img":{"url":"https://cdn.example/a.jpg"}}]}`, "required native title asset 1 missing"},
+		{"unrequested", `{` + link + `,"assets":[{"id":9,"title":{"text":"Hello"}}]}`, "asset 9 was not requested"},
+		{"wrong kind", `{` + link + `,"assets":[{"id":1,"data":{"value":"Hello"}}]}`, "asset 1 is a data asset, requested title"},
+		{"no kind", `{` + link + `,"assets":[{"id":3}]}`, "asset 3 has no title"},
+		{"title too long", `{` + link + `,"assets":[{"id":1,"title":{"text":"` + strings.Repeat("x", 26) + `"}}]}`, "exceeds len 25"},
+	}
+	for _, tt := range tests {
+		resp, err := ParseResponse(tt.adm)
+		if err != nil {
+			t.Fatalf("%s: unexpected parse error: %v", tt.name, err)
+		}
+		err = Validate(req, resp)
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("%s: expected valid, got %v", tt.name, err)
+		}
+		if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.wantErr, err)
+		}
+	}
+
+	// Without the request only the link is checked
+	resp, _ := ParseResponse(`{` + link + `,"assets":[{"id":9}]}`)
+	if err := Validate(nil, resp); err != nil {
+		t.Errorf("expected nil request to check only the link, got %v", err)
+	}
+
+	if _, err := ParseResponse(`<div>not native</div>`); err == nil {
+		t.Error("expected HTML adm to fail parsing")
+	}
+}
@@ -0,0 +1,102 @@
+// Package pricebucket turns CPMs into the price bucket strings used for ad
+// server targeting (hb_pb). It has no dependencies on the rest of the server
+// so other services can bucket prices exactly as the exchange does.
+package pricebucket
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// epsilon absorbs float error so a CPM sitting on a bucket edge (1.15 / 0.01)
+// isn't floored into the bucket below
+const epsilon = 1e-9
+
+// Range is one band of a granularity: CPMs up to Max round down to a multiple
+// of Increment above the previous band's Max
+type Range struct {
+	Max       float64 `json:"max"`
+	Increment float64 `json:"increment"`
+}
+
+// Granularity is an ordered set of bands. CPMs above the last band's Max are
+// capped at it.
+type Granularity struct {
+	Precision int     `json:"precision"` // Decimal places in the bucket string
+	Ranges    []Range `json:"ranges"`
+}
+
+// Standard granularities. Default is the exchange's hb_pb bucketing; the
+// others match the Prebid.js granularities of the same name.
+var (
+	Default = Granularity{Precision: 2, Ranges: []Range{{Max: 5, Increment: 0.01}, {Max: 10, Increment: 0.05}, {Max: 20, Increment: 0.5}}}
+	Low     = Granularity{Precision: 2, Ranges: []Range{{Max: 5, Increment: 0.5}}}
+	Medium  = Granularity{Precision: 2, Ranges: []Range{{Max: 20, Increment: 0.1}}}
+	High    = Granularity{Precision: 2, Ranges: []Range{{Max: 20, Increment: 0.01}}}
+	Auto    = Granularity{Precision: 2, Ranges: []Range{{Max: 5, Increment: 0.05}, {Max: 10, Increment: 0.1}, {Max: 20, Increment: 0.5}}}
+	Dense   = Granularity{Precision: 2, Ranges: []Range{{Max: 3, Increment: 0.01}, {Max: 8, Increment: 0.05}, {Max: 20, Increment: 0.5}}}
+)
+
+// ByName returns a standard granularity by name ("default", "low", "medium",
+// "high", "auto", "dense")
+func ByName(name string) (Granularity, bool) {
+	switch name {
+	case "default":
+		return Default, true
+	case "low":
+		return Low, true
+	case "medium":
+		return Medium, true
+	case "high":
+		return High, true
+	case "auto":
+		return Auto, true
+	case "dense":
+		return Dense, true
+	}
+	return Granularity{}, false
+}
+
+// Validate checks that bands are ascending with positive increments
+func (g Granularity) Validate() error {
+	if len(g.Ranges) == 0 {
+		return errors.New("granularity needs at least one range")
+	}
+	if g.Precision < 0 {
+		return fmt.Errorf("precision cannot be negative, got %d", g.Precision)
+	}
+	lower := 0.0
+	for i, r := range g.Ranges {
+		if r.Increment <= 0 {
+			return fmt.Errorf("range %d: increment must be positive, got %v", i, r.Increment)
+		}
+		if r.Max <= lower {
+			return fmt.Errorf("range %d: max %v must exceed the previous max %v", i, r.Max, lower)
+		}
+		lower = r.Max
+	}
+	return nil
+}
+
+// Bucket returns the bucket for cpm, formatted to the granularity's precision.
+// Zero, negative and non-finite CPMs bucket to zero.
+func (g Granularity) Bucket(cpm float64) string {
+	return strconv.FormatFloat(g.Value(cpm), 'f', g.Precision, 64)
+}
+
+// Value returns the bucket for cpm as a number
+func (g Granularity) Value(cpm float64) float64 {
+	if cpm <= 0 || math.IsNaN(cpm) || math.IsInf(cpm, 0) || len(g.Ranges) == 0 {
+		return 0
+	}
+	lower := 0.0
+	for _, r := range g.Ranges {
+		if cpm <= r.Max {
+			return lower + math.Floor((cpm-lower)/r.Increment+epsilon)*r.Increment
+		}
+		lower = r.Max
+	}
+	return lower
+}
@@ -0,0 +1,84 @@
+package pricebucket
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDefaultBuckets(t *testing.T) {
+	for cpm, want := range map[float64]string{
+		0:      "0.00",
+		-1:     "0.00",
+		0.015:  "0.01",
+		1.15:   "1.15",
+		4.999:  "4.99",
+		5:      "5.00",
+		7.37:   "7.35",
+		10:     "10.00",
+		12.74:  "12.50",
+		19.99:  "19.50",
+		20:     "20.00",
+		150.00: "20.00",
+	} {
+		if got := Default.Bucket(cpm); got != want {
+			t.Errorf("Default.Bucket(%v) = %s, want %s", cpm, got, want)
+		}
+	}
+}
+
+func TestStandardGranularities(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		cpm  float64
+		want string
+	}{
+		{"low", 3.87, "3.50"},
+		{"low", 7, "5.00"},
+		{"medium", 3.87, "3.80"},
+		{"high", 3.87, "3.87"},
+		{"auto", 3.87, "3.85"},
+		{"auto", 8.87, "8.80"},
+		{"dense", 2.87, "2.87"},
+		{"dense", 6.87, "6.85"},
+	} {
+		g, ok := ByName(tc.name)
+		if !ok {
+			t.Fatalf("unknown granularity %s", tc.name)
+		}
+		if got := g.Bucket(tc.cpm); got != tc.want {
+			t.Errorf("%s.Bucket(%v) = %s, want %s", tc.name, tc.cpm, got, tc.want)
+		}
+		if err := g.Validate(); err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+		}
+	}
+	if _, ok := ByName("extreme"); ok {
+		t.Error("expected unknown granularity to be rejected")
+	}
+}
+
+func TestCustomGranularity(t *testing.T) {
+	g := Granularity{Precision: 1, Ranges: []Range{{Max: 2, Increment: 0.25}, {Max: 10, Increment: 1}}}
+	if got := g.Bucket(1.8); got != "1.8" {
+		t.Errorf("expected 1.75 at precision 1, got %s", got)
+	}
+	if got := g.Bucket(4.5); got != "4.0" {
+		t.Errorf("expected whole-unit bucket above 2, got %s", got)
+	}
+	if g.Value(math.NaN()) != 0 || g.Value(math.Inf(1)) != 0 {
+		t.Error("expected non-finite CPMs to bucket to zero")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for name, g := range map[string]Granularity{
+		"empty":      {Precision: 2},
+		"zero inc":   {Precision: 2, Ranges: []Range{{Max: 5}}},
+		"descending": {Precision: 2, Ranges: []Range{{Max: 5, Increment: 0.1}, {Max: 3, Increment: 0.1}}},
+		"precision":  {Precision: -1, Ranges: []Range{{Max: 5, Increment: 0.1}}},
+	} {
+		if err := g.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -110,11 +110,102 @@ func (c *Client) HGetAll(ctx context.Context, key string) (map[string]string, er
 	return c.client.HGetAll(ctx, key).Result()
 }
 
+// HSet sets a single hash field
+func (c *Client) HSet(ctx context.Context, key, field, value string) error {
+	return c.client.HSet(ctx, key, field, value).Err()
+}
+
+// HDel deletes hash fields
+func (c *Client) HDel(ctx context.Context, key string, fields ...string) error {
+	return c.client.HDel(ctx, key, fields...).Err()
+}
+
+// IncrWithTTL increments a counter and (re)sets its expiry in one round trip
+func (c *Client) IncrWithTTL(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	pipe := c.client.Pipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+// GetWithTTL gets a string value and resets its expiry in one round trip,
+// returning "" for a missing key
+func (c *Client) GetWithTTL(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	pipe := c.client.Pipeline()
+	get := pipe.Get(ctx, key)
+	pipe.Expire(ctx, key, ttl)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return "", err
+	}
+	return get.Val(), nil
+}
+
+// SetWithTTL sets a string value that expires after ttl
+func (c *Client) SetWithTTL(ctx context.Context, key, value string, ttl time.Duration) error {
+	return c.client.Set(ctx, key, value, ttl).Err()
+}
+
+// Publish sends a message to a pub/sub channel
+func (c *Client) Publish(ctx context.Context, channel, message string) error {
+	return c.client.Publish(ctx, channel, message).Err()
+}
+
+// Subscribe listens on a pub/sub channel. The returned channel delivers message
+// payloads and is closed after the returned close func is called.
+func (c *Client) Subscribe(ctx context.Context, channel string) (<-chan string, func() error, error) {
+	ps := c.client.Subscribe(ctx, channel)
+	// Wait for the subscription confirmation so no message published after return is missed
+	if _, err := ps.Receive(ctx); err != nil {
+		ps.Close()
+		return nil, nil, err
+	}
+
+	out := make(chan string)
+	go func() {
+		defer close(out)
+		for msg := range ps.Channel() {
+			out <- msg.Payload
+		}
+	}()
+	return out, ps.Close, nil
+}
+
 // SMembers gets all members of a set
 func (c *Client) SMembers(ctx context.Context, key string) ([]string, error) {
 	return c.client.SMembers(ctx, key).Result()
 }
 
+// SAdd adds members to a set
+func (c *Client) SAdd(ctx context.Context, key string, members ...string) error {
+	return c.client.SAdd(ctx, key, toAny(members)...).Err()
+}
+
+// SRem removes members from a set
+func (c *Client) SRem(ctx context.Context, key string, members ...string) error {
+	return c.client.SRem(ctx, key, toAny(members)...).Err()
+}
+
+// ZAdd adds a member to a sorted set, or updates its score
+func (c *Client) ZAdd(ctx context.Context, key, member string, score float64) error {
+	return c.client.ZAdd(ctx, key, redis.Z{Score: score, Member: member}).Err()
+}
+
+// ZRem removes members from a sorted set
+func (c *Client) ZRem(ctx context.Context, key string, members ...string) error {
+	return c.client.ZRem(ctx, key, toAny(members)...).Err()
+}
+
+func toAny(values []string) []interface{} {
+	out := make([]interface{}, len(values))
+	for i, v := range values {
+		out[i] = v
+	}
+	return out
+}
+
 // Ping tests the connection
 func (c *Client) Ping(ctx context.Context) error {
 	return c.client.Ping(ctx).Err()
@@ -0,0 +1,155 @@
+// Package signing provides HMAC-SHA256 signing for outbound service requests
+package signing
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers set on signed requests
+const (
+	KeyIDHeader     = "X-Nexus-Key-Id"
+	TimestampHeader = "X-Nexus-Timestamp"
+	SignatureHeader = "X-Nexus-Signature"
+
+	// signatureVersion prefixes the signature so the scheme can change later
+	signatureVersion = "v1="
+)
+
+// DefaultMaxSkew is how far a request timestamp may drift from the verifier's clock
+const DefaultMaxSkew = 5 * time.Minute
+
+// Verification errors
+var (
+	ErrMissingSignature = errors.New("request is not signed")
+	ErrUnknownKey       = errors.New("unknown signing key")
+	ErrStaleTimestamp   = errors.New("signature timestamp outside allowed skew")
+	ErrBadSignature     = errors.New("signature mismatch")
+)
+
+// Key is a shared HMAC secret identified by ID so receivers can pick the
+// right secret while keys are rotated
+type Key struct {
+	ID     string
+	Secret []byte
+}
+
+// Signer signs requests with its primary key and verifies signatures made with
+// any of its keys. To rotate, add the new key to receivers, then make it the
+// primary on senders, then retire the old key.
+type Signer struct {
+	keys []Key
+	now  func() time.Time
+}
+
+// NewSigner creates a signer; the first key is the primary signing key
+func NewSigner(keys ...Key) (*Signer, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("signing requires at least one key")
+	}
+	seen := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		if k.ID == "" || len(k.Secret) == 0 {
+			return nil, fmt.Errorf("signing key %q must have an ID and a secret", k.ID)
+		}
+		if seen[k.ID] {
+			return nil, fmt.Errorf("duplicate signing key ID %q", k.ID)
+		}
+		seen[k.ID] = true
+	}
+	return &Signer{keys: keys, now: time.Now}, nil
+}
+
+// ParseKeys parses "id1:secret1,id2:secret2"; the first key is the primary.
+// An empty string returns no keys.
+func ParseKeys(raw string) ([]Key, error) {
+	var keys []Key
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, secret, ok := strings.Cut(entry, ":")
+		if !ok || strings.TrimSpace(id) == "" || secret == "" {
+			return nil, fmt.Errorf("signing key entries must be id:secret, got %q", id)
+		}
+		keys = append(keys, Key{ID: strings.TrimSpace(id), Secret: []byte(secret)})
+	}
+	return keys, nil
+}
+
+// FromEnv builds a signer from a ParseKeys value, or returns nil when raw is empty
+func FromEnv(raw string) (*Signer, error) {
+	keys, err := ParseKeys(raw)
+	if err != nil || len(keys) == 0 {
+		return nil, err
+	}
+	return NewSigner(keys...)
+}
+
+// Compute returns the hex HMAC-SHA256 of timestamp + "." + body
+func Compute(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// PrimaryKeyID returns the ID of the key used for signing
+func (s *Signer) PrimaryKeyID() string {
+	return s.keys[0].ID
+}
+
+// Sign sets the signature headers on req for body. Call it on every attempt
+// so retries carry a fresh timestamp. A nil signer leaves req unsigned.
+func (s *Signer) Sign(req *http.Request, body []byte) {
+	if s == nil {
+		return
+	}
+	key := s.keys[0]
+	timestamp := strconv.FormatInt(s.now().Unix(), 10)
+	req.Header.Set(KeyIDHeader, key.ID)
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, signatureVersion+Compute(key.Secret, timestamp, body))
+}
+
+// Verify checks the signature headers on req against body
+func (s *Signer) Verify(req *http.Request, body []byte, maxSkew time.Duration) error {
+	signature, ok := strings.CutPrefix(req.Header.Get(SignatureHeader), signatureVersion)
+	timestamp := req.Header.Get(TimestampHeader)
+	if !ok || signature == "" || timestamp == "" {
+		return ErrMissingSignature
+	}
+
+	var secret []byte
+	for _, k := range s.keys {
+		if k.ID == req.Header.Get(KeyIDHeader) {
+			secret = k.Secret
+			break
+		}
+	}
+	if secret == nil {
+		return ErrUnknownKey
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrStaleTimestamp
+	}
+	if skew := s.now().Sub(time.Unix(ts, 0)); skew > maxSkew || skew < -maxSkew {
+		return ErrStaleTimestamp
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(Compute(secret, timestamp, body))) {
+		return ErrBadSignature
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package signing
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCompute_KnownVector(t *testing.T) {
+	// Shared with tests/test_signing.py so both services agree on the scheme
+	got := Compute([]byte("k"), "1", []byte("{}"))
+	if want := "3dd49b2593d0f9a349e9e71c4bde3e2b862c2be4003fe9b4ba81332029310158"; got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	keys, err := ParseKeys(" k2:new-secret , k1:old:secret ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(keys) != 2 || keys[0].ID != "k2" || string(keys[1].Secret) != "old:secret" {
+		t.Errorf("unexpected keys %+v", keys)
+	}
+
+	for _, raw := range []string{"nosecret", ":secret", "k1:"} {
+		if _, err := ParseKeys(raw); err == nil {
+			t.Errorf("%q: expected error", raw)
+		}
+	}
+
+	if signer, err := FromEnv(""); signer != nil || err != nil {
+		t.Errorf("expected no signer for empty config, got %v, %v", signer, err)
+	}
+	if _, err := FromEnv("k1:a,k1:b"); err == nil {
+		t.Error("expected duplicate key IDs to be rejected")
+	}
+}
+
+func newTestSigner(t *testing.T, raw string, now time.Time) *Signer {
+	t.Helper()
+	s, err := FromEnv(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func TestSignAndVerify(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	body := []byte(`{"events":[]}`)
+
+	sender := newTestSigner(t, "k2:new-secret", now)
+	req := httptest.NewRequest(http.MethodPost, "/api/events", nil)
+	sender.Sign(req, body)
+
+	if req.Header.Get(KeyIDHeader) != "k2" || req.Header.Get(TimestampHeader) != "1700000000" {
+		t.Errorf("unexpected signature headers %v", req.Header)
+	}
+
+	// A receiver mid-rotation accepts both keys
+	receiver := newTestSigner(t, "k1:old-secret,k2:new-secret", now.Add(time.Minute))
+	if err := receiver.Verify(req, body, DefaultMaxSkew); err != nil {
+		t.Errorf("expected valid signature, got %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		receiver *Signer
+		body     []byte
+		want     error
+	}{
+		{"tampered body", receiver, []byte(`{"events":[{}]}`), ErrBadSignature},
+		{"unknown key", newTestSigner(t, "k1:old-secret", now), body, ErrUnknownKey},
+		{"wrong secret", newTestSigner(t, "k2:other", now), body, ErrBadSignature},
+		{"stale", newTestSigner(t, "k2:new-secret", now.Add(10*time.Minute)), body, ErrStaleTimestamp},
+		{"future", newTestSigner(t, "k2:new-secret", now.Add(-10*time.Minute)), body, ErrStaleTimestamp},
+	}
+	for _, tt := range tests {
+		if err := tt.receiver.Verify(req, tt.body, DefaultMaxSkew); !errors.Is(err, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, err)
+		}
+	}
+
+	unsigned := httptest.NewRequest(http.MethodPost, "/api/events", nil)
+	if err := receiver.Verify(unsigned, body, DefaultMaxSkew); !errors.Is(err, ErrMissingSignature) {
+		t.Errorf("expected missing signature, got %v", err)
+	}
+}
+
+func TestSign_NilSignerLeavesRequestUnsigned(t *testing.T) {
+	var s *Signer
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	s.Sign(req, nil)
+	if req.Header.Get(SignatureHeader) != "" {
+		t.Error("expected nil signer not to sign")
+	}
+}
@@ -0,0 +1,380 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/logger"
+)
+
+// DefaultServiceName is the service.name resource attribute unless
+// OTEL_SERVICE_NAME sets another
+const DefaultServiceName = "thenexusengine-pbs"
+
+// scopeName is the instrumentation scope spans are reported under
+const scopeName = "github.com/StreetsDigital/thenexusengine/pbs"
+
+// Config controls sampling and the OTLP/HTTP exporter
+type Config struct {
+	Endpoint           string            // OTLP/HTTP traces URL; empty disables tracing
+	Headers            map[string]string // Sent with every export, e.g. collector auth
+	ServiceName        string
+	ResourceAttributes map[string]string
+	SampleRatio        float64       // Share of new traces recorded, 0-1
+	BatchSize          int           // Spans per export request
+	FlushInterval      time.Duration // A partial batch is exported after this long
+	BufferSize         int           // Spans queued for export; more are dropped
+	Timeout            time.Duration // Per-export request timeout
+}
+
+// DefaultConfig returns configuration from the standard OpenTelemetry
+// environment variables:
+//   - OTEL_EXPORTER_OTLP_TRACES_ENDPOINT: traces URL, used as is; or
+//     OTEL_EXPORTER_OTLP_ENDPOINT: base URL, to which /v1/traces is added.
+//     Tracing is off when neither is set.
+//   - OTEL_SDK_DISABLED=true or OTEL_TRACES_EXPORTER=none: tracing is off
+//   - OTEL_EXPORTER_OTLP_HEADERS, OTEL_EXPORTER_OTLP_TRACES_HEADERS: key=value,... (default: none)
+//   - OTEL_EXPORTER_OTLP_TIMEOUT, OTEL_EXPORTER_OTLP_TRACES_TIMEOUT: milliseconds (default: 10000)
+//   - OTEL_SERVICE_NAME (default: thenexusengine-pbs); OTEL_RESOURCE_ATTRIBUTES: key=value,...
+//   - OTEL_TRACES_SAMPLER: always_on, always_off, traceidratio or their parentbased_ forms;
+//     OTEL_TRACES_SAMPLER_ARG: ratio for traceidratio (default: 1). Callers'
+//     sampling decisions are always followed.
+//   - OTEL_BSP_MAX_EXPORT_BATCH_SIZE (default: 512), OTEL_BSP_SCHEDULE_DELAY:
+//     milliseconds (default: 5000), OTEL_BSP_MAX_QUEUE_SIZE (default: 2048)
+func DefaultConfig() *Config {
+	c := &Config{
+		ServiceName:        getEnv("OTEL_SERVICE_NAME", DefaultServiceName),
+		Headers:            parseKeyValues(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS")),
+		ResourceAttributes: parseKeyValues(os.Getenv("OTEL_RESOURCE_ATTRIBUTES")),
+		SampleRatio:        1,
+		BatchSize:          getEnvInt("OTEL_BSP_MAX_EXPORT_BATCH_SIZE", 512),
+		FlushInterval:      time.Duration(getEnvInt("OTEL_BSP_SCHEDULE_DELAY", 5000)) * time.Millisecond,
+		BufferSize:         getEnvInt("OTEL_BSP_MAX_QUEUE_SIZE", 2048),
+		Timeout:            time.Duration(getEnvInt("OTEL_EXPORTER_OTLP_TIMEOUT", 10000)) * time.Millisecond,
+	}
+	for k, v := range parseKeyValues(os.Getenv("OTEL_EXPORTER_OTLP_TRACES_HEADERS")) {
+		c.Headers[k] = v
+	}
+	if ms := getEnvInt("OTEL_EXPORTER_OTLP_TRACES_TIMEOUT", 0); ms > 0 {
+		c.Timeout = time.Duration(ms) * time.Millisecond
+	}
+
+	switch os.Getenv("OTEL_TRACES_SAMPLER") {
+	case "always_off", "parentbased_always_off":
+		c.SampleRatio = 0
+	case "traceidratio", "parentbased_traceidratio":
+		if ratio, err := strconv.ParseFloat(os.Getenv("OTEL_TRACES_SAMPLER_ARG"), 64); err == nil {
+			c.SampleRatio = ratio
+		}
+	}
+
+	if os.Getenv("OTEL_SDK_DISABLED") == "true" || os.Getenv("OTEL_TRACES_EXPORTER") == "none" {
+		return c
+	}
+	if endpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"); endpoint != "" {
+		c.Endpoint = endpoint
+	} else if base := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); base != "" {
+		c.Endpoint = strings.TrimSuffix(base, "/") + "/v1/traces"
+	}
+	return c
+}
+
+// ExporterStats counts spans by fate
+type ExporterStats struct {
+	Exported int64 `json:"exported"`
+	Dropped  int64 `json:"dropped"` // Queue full
+	Failed   int64 `json:"failed"`  // In exports the collector didn't accept
+}
+
+// OTLPExporter queues spans and POSTs them in batches as OTLP/HTTP JSON from
+// a single background worker. Failed exports aren't retried, so the request
+// path never waits on the collector.
+type OTLPExporter struct {
+	config   *Config
+	client   *http.Client
+	resource otlpResource
+	queue    chan *SpanData
+	stop     chan struct{}
+	once     sync.Once
+	wg       sync.WaitGroup
+
+	exported atomic.Int64
+	dropped  atomic.Int64
+	failed   atomic.Int64
+}
+
+// NewOTLPExporter starts an exporter sending to config.Endpoint
+func NewOTLPExporter(config *Config) (*OTLPExporter, error) {
+	if config == nil || config.Endpoint == "" {
+		return nil, fmt.Errorf("OTLP endpoint is required")
+	}
+	if u, err := url.Parse(config.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid OTLP endpoint %q", config.Endpoint)
+	}
+	c := *config
+	if c.ServiceName == "" {
+		c.ServiceName = DefaultServiceName
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = 512
+	}
+	if c.FlushInterval <= 0 {
+		c.FlushInterval = 5 * time.Second
+	}
+	if c.BufferSize <= 0 {
+		c.BufferSize = 2048
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 10 * time.Second
+	}
+
+	resource := otlpResource{Attributes: []otlpAttribute{otlpAttr(String("service.name", c.ServiceName))}}
+	for _, k := range slices.Sorted(maps.Keys(c.ResourceAttributes)) {
+		if k != "service.name" {
+			resource.Attributes = append(resource.Attributes, otlpAttr(String(k, c.ResourceAttributes[k])))
+		}
+	}
+
+	e := &OTLPExporter{
+		config:   &c,
+		client:   &http.Client{Timeout: c.Timeout},
+		resource: resource,
+		queue:    make(chan *SpanData, c.BufferSize),
+		stop:     make(chan struct{}),
+	}
+	e.wg.Add(1)
+	go e.run()
+	return e, nil
+}
+
+// ExportSpan implements Exporter, dropping the span if the queue is full
+func (e *OTLPExporter) ExportSpan(span *SpanData) {
+	select {
+	case e.queue <- span:
+	default:
+		e.dropped.Add(1)
+	}
+}
+
+// Stats returns the span counts so far
+func (e *OTLPExporter) Stats() ExporterStats {
+	return ExporterStats{Exported: e.exported.Load(), Dropped: e.dropped.Load(), Failed: e.failed.Load()}
+}
+
+// run batches queued spans until Close, then exports what's left
+func (e *OTLPExporter) run() {
+	defer e.wg.Done()
+	ticker := time.NewTicker(e.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*SpanData, 0, e.config.BatchSize)
+	add := func(span *SpanData) {
+		batch = append(batch, span)
+		if len(batch) >= e.config.BatchSize {
+			e.send(batch)
+			batch = make([]*SpanData, 0, e.config.BatchSize)
+		}
+	}
+	for {
+		select {
+		case span := <-e.queue:
+			add(span)
+		case <-ticker.C:
+			if len(batch) > 0 {
+				e.send(batch)
+				batch = make([]*SpanData, 0, e.config.BatchSize)
+			}
+		case <-e.stop:
+			for {
+				select {
+				case span := <-e.queue:
+					add(span)
+				default:
+					if len(batch) > 0 {
+						e.send(batch)
+					}
+					return
+				}
+			}
+		}
+	}
+}
+
+// send POSTs one batch, counting its spans as exported or failed
+func (e *OTLPExporter) send(batch []*SpanData) {
+	body, err := json.Marshal(e.request(batch))
+	if err != nil {
+		e.failed.Add(int64(len(batch)))
+		logger.Log.Warn().Err(err).Msg("trace export: failed to encode spans")
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), e.config.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		e.failed.Add(int64(len(batch)))
+		logger.Log.Warn().Err(err).Msg("trace export: invalid request")
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.config.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		e.failed.Add(int64(len(batch)))
+		logger.Log.Warn().Err(err).Int("spans", len(batch)).Msg("trace export: spans not delivered")
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		e.failed.Add(int64(len(batch)))
+		logger.Log.Warn().Int("status", resp.StatusCode).Int("spans", len(batch)).Msg("trace export: spans rejected")
+		return
+	}
+	e.exported.Add(int64(len(batch)))
+}
+
+// Close exports the spans still queued and stops the worker. Spans ended
+// afterwards are dropped.
+func (e *OTLPExporter) Close() error {
+	e.once.Do(func() { close(e.stop) })
+	e.wg.Wait()
+	return nil
+}
+
+// OTLP JSON encoding of an ExportTraceServiceRequest. IDs are hex and 64-bit
+// integers are strings, per the OTLP JSON mapping.
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              SpanKind        `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            *otlpStatus     `json:"status,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"` // 2 = error
+	Message string `json:"message,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string         `json:"key"`
+	Value map[string]any `json:"value"`
+}
+
+func otlpAttr(a Attribute) otlpAttribute {
+	var value map[string]any
+	switch v := a.Value.(type) {
+	case string:
+		value = map[string]any{"stringValue": v}
+	case int64:
+		value = map[string]any{"intValue": strconv.FormatInt(v, 10)}
+	case float64:
+		value = map[string]any{"doubleValue": v}
+	case bool:
+		value = map[string]any{"boolValue": v}
+	default:
+		value = map[string]any{"stringValue": fmt.Sprint(v)}
+	}
+	return otlpAttribute{Key: a.Key, Value: value}
+}
+
+func (e *OTLPExporter) request(batch []*SpanData) *otlpRequest {
+	spans := make([]otlpSpan, len(batch))
+	for i, s := range batch {
+		span := otlpSpan{
+			TraceID:           s.Context.TraceID.String(),
+			SpanID:            s.Context.SpanID.String(),
+			Name:              s.Name,
+			Kind:              s.Kind,
+			StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.End.UnixNano(), 10),
+		}
+		if s.Parent != (SpanID{}) {
+			span.ParentSpanID = s.Parent.String()
+		}
+		for _, a := range s.Attributes {
+			span.Attributes = append(span.Attributes, otlpAttr(a))
+		}
+		if s.Failed {
+			span.Status = &otlpStatus{Code: 2, Message: s.Message}
+		}
+		spans[i] = span
+	}
+	return &otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   e.resource,
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: scopeName}, Spans: spans}},
+	}}}
+}
+
+// parseKeyValues parses the key=value,... lists OTEL_* variables use; values
+// may be URL-encoded
+func parseKeyValues(s string) map[string]string {
+	values := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			continue
+		}
+		if decoded, err := url.PathUnescape(strings.TrimSpace(value)); err == nil {
+			value = decoded
+		}
+		values[key] = strings.TrimSpace(value)
+	}
+	return values
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil && value > 0 {
+		return value
+	}
+	return defaultValue
+}
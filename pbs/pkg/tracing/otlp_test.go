@@ -0,0 +1,136 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// collector records the OTLP requests it receives
+type collector struct {
+	mu       sync.Mutex
+	requests []map[string]any
+	headers  []http.Header
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req map[string]any
+	if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" || json.NewDecoder(r.Body).Decode(&req) != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests = append(c.requests, req)
+	c.headers = append(c.headers, r.Header)
+}
+
+func TestOTLPExporter(t *testing.T) {
+	c := &collector{}
+	server := httptest.NewServer(c)
+	defer server.Close()
+
+	exporter, err := NewOTLPExporter(&Config{
+		Endpoint:           server.URL + "/v1/traces",
+		Headers:            map[string]string{"Authorization": "Bearer secret"},
+		ResourceAttributes: map[string]string{"deployment.environment": "test"},
+		FlushInterval:      time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tracer := NewTracer(exporter, 1)
+	ctx, root := tracer.Start(context.Background(), "auction", SpanKindServer)
+	_, child := tracer.Start(ctx, "bidder.request", SpanKindClient)
+	child.SetAttributes(String("bidder", "appnexus"), Int("http.status_code", 204), Float64("price", 1.5), Bool("timed_out", false))
+	child.SetError(errors.New("no bid"))
+	child.End()
+	root.End()
+	if err := exporter.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	if len(c.requests) != 1 || c.headers[0].Get("Authorization") != "Bearer secret" {
+		t.Fatalf("expected one authenticated export on close, got %d", len(c.requests))
+	}
+	rs := c.requests[0]["resourceSpans"].([]any)[0].(map[string]any)
+	resource, _ := json.Marshal(rs["resource"])
+	if want := `{"attributes":[{"key":"service.name","value":{"stringValue":"thenexusengine-pbs"}},{"key":"deployment.environment","value":{"stringValue":"test"}}]}`; string(resource) != want {
+		t.Errorf("unexpected resource %s", resource)
+	}
+	spans := rs["scopeSpans"].([]any)[0].(map[string]any)["spans"].([]any)
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	span := spans[0].(map[string]any)
+	if span["traceId"] != root.Context().TraceID.String() || span["parentSpanId"] != root.Context().SpanID.String() || span["kind"] != float64(SpanKindClient) {
+		t.Errorf("unexpected child span %v", span)
+	}
+	if _, isString := span["startTimeUnixNano"].(string); !isString {
+		t.Errorf("expected timestamps as strings, got %v", span["startTimeUnixNano"])
+	}
+	attrs, _ := json.Marshal(span["attributes"])
+	if want := `[{"key":"bidder","value":{"stringValue":"appnexus"}},{"key":"http.status_code","value":{"intValue":"204"}},{"key":"price","value":{"doubleValue":1.5}},{"key":"timed_out","value":{"boolValue":false}}]`; string(attrs) != want {
+		t.Errorf("unexpected attributes %s", attrs)
+	}
+	if status := span["status"].(map[string]any); status["code"] != float64(2) || status["message"] != "no bid" {
+		t.Errorf("expected an error status, got %v", status)
+	}
+	if _, hasParent := spans[1].(map[string]any)["parentSpanId"]; hasParent {
+		t.Error("expected the root span without a parent")
+	}
+	if stats := exporter.Stats(); stats.Exported != 2 || stats.Failed != 0 {
+		t.Errorf("expected 2 spans exported, got %+v", stats)
+	}
+}
+
+func TestOTLPExporter_QueueFull(t *testing.T) {
+	e := &OTLPExporter{queue: make(chan *SpanData, 1)}
+	e.ExportSpan(&SpanData{})
+	e.ExportSpan(&SpanData{})
+	if stats := e.Stats(); stats.Dropped != 1 {
+		t.Errorf("expected the span past the buffer dropped, got %+v", stats)
+	}
+}
+
+func TestNewOTLPExporter_InvalidEndpoint(t *testing.T) {
+	for _, endpoint := range []string{"", "collector:4318", "ftp://collector/v1/traces"} {
+		if _, err := NewOTLPExporter(&Config{Endpoint: endpoint}); err == nil {
+			t.Errorf("expected an error for %q", endpoint)
+		}
+	}
+}
+
+func TestDefaultConfig(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4318/")
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "api-key=abc%3D,x-team = ads")
+	t.Setenv("OTEL_SERVICE_NAME", "pbs-eu")
+	t.Setenv("OTEL_TRACES_SAMPLER", "parentbased_traceidratio")
+	t.Setenv("OTEL_TRACES_SAMPLER_ARG", "0.25")
+	t.Setenv("OTEL_BSP_SCHEDULE_DELAY", "200")
+
+	c := DefaultConfig()
+	if c.Endpoint != "http://collector:4318/v1/traces" {
+		t.Errorf("expected /v1/traces added to the base endpoint, got %q", c.Endpoint)
+	}
+	if c.Headers["api-key"] != "abc=" || c.Headers["x-team"] != "ads" {
+		t.Errorf("expected decoded headers, got %v", c.Headers)
+	}
+	if c.ServiceName != "pbs-eu" || c.SampleRatio != 0.25 || c.FlushInterval != 200*time.Millisecond || c.BatchSize != 512 {
+		t.Errorf("unexpected config %+v", c)
+	}
+
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "https://traces.example.com/ingest")
+	if c := DefaultConfig(); c.Endpoint != "https://traces.example.com/ingest" {
+		t.Errorf("expected the traces endpoint used as is, got %q", c.Endpoint)
+	}
+	t.Setenv("OTEL_SDK_DISABLED", "true")
+	if c := DefaultConfig(); c.Endpoint != "" {
+		t.Errorf("expected tracing off when the SDK is disabled, got %q", c.Endpoint)
+	}
+}
@@ -0,0 +1,88 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+)
+
+// TraceparentHeader carries trace context between services, per
+// https://www.w3.org/TR/trace-context/
+const TraceparentHeader = "traceparent"
+
+// Inject sets the traceparent header for the span in ctx, so the service
+// called continues the trace. It does nothing when ctx carries no span.
+func Inject(ctx context.Context, header http.Header) {
+	sc := SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+	header.Set(TraceparentHeader, formatTraceparent(sc))
+}
+
+// Extract returns ctx with the caller's span from a traceparent header as the
+// parent of spans started from it. Without a tracer installed, or a valid
+// header, ctx is returned unchanged so an incoming trace isn't passed on.
+func Extract(ctx context.Context, header http.Header) context.Context {
+	if !Enabled() {
+		return ctx
+	}
+	sc, ok := parseTraceparent(header.Get(TraceparentHeader))
+	if !ok {
+		return ctx
+	}
+	return ContextWithSpanContext(ctx, sc)
+}
+
+// formatTraceparent encodes version 00: version-traceid-spanid-flags
+func formatTraceparent(sc SpanContext) string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+// parseTraceparent decodes a traceparent header. Later versions may append
+// fields, which are ignored; version ff and all-zero IDs are invalid.
+func parseTraceparent(value string) (SpanContext, bool) {
+	if len(value) < 55 || (len(value) > 55 && value[55] != '-') {
+		return SpanContext{}, false
+	}
+	if value[2] != '-' || value[35] != '-' || value[52] != '-' {
+		return SpanContext{}, false
+	}
+	version, ok := decodeLowerHex(value[0:2])
+	if !ok || version[0] == 0xff || (version[0] == 0 && len(value) != 55) {
+		return SpanContext{}, false
+	}
+
+	var sc SpanContext
+	traceID, ok := decodeLowerHex(value[3:35])
+	if !ok {
+		return SpanContext{}, false
+	}
+	copy(sc.TraceID[:], traceID)
+	spanID, ok := decodeLowerHex(value[36:52])
+	if !ok {
+		return SpanContext{}, false
+	}
+	copy(sc.SpanID[:], spanID)
+	flags, ok := decodeLowerHex(value[53:55])
+	if !ok || !sc.IsValid() {
+		return SpanContext{}, false
+	}
+	sc.Sampled = flags[0]&0x01 == 1
+	return sc, true
+}
+
+// decodeLowerHex decodes hex, rejecting upper case as the spec requires
+func decodeLowerHex(s string) ([]byte, bool) {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return nil, false
+		}
+	}
+	b, err := hex.DecodeString(s)
+	return b, err == nil
+}
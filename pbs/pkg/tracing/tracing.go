@@ -0,0 +1,234 @@
+// Package tracing records OpenTelemetry-compatible spans for auctions and the
+// calls they make. Trace context is propagated to IDR and bidders in W3C
+// traceparent headers, and finished spans are exported over OTLP/HTTP.
+//
+// Tracing is off until SetTracer installs a tracer; until then Start returns
+// nil spans, whose methods do nothing.
+package tracing
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SpanKind is a span's role in its trace, numbered as in OTLP
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindServer   SpanKind = 2 // Handles an incoming request
+	SpanKindClient   SpanKind = 3 // Makes an outgoing request
+)
+
+// TraceID identifies a trace
+type TraceID [16]byte
+
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+
+// SpanID identifies a span within its trace
+type SpanID [8]byte
+
+func (id SpanID) String() string { return hex.EncodeToString(id[:]) }
+
+// SpanContext is the part of a span that is propagated to children, in this
+// process or downstream
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool // The trace is recorded; children inherit the decision
+}
+
+// IsValid reports whether both IDs are set
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != TraceID{} && sc.SpanID != SpanID{}
+}
+
+type spanContextKey struct{}
+
+// SpanContextFromContext returns the current span's context, which is zero
+// when ctx carries no span
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	sc, _ := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc
+}
+
+// ContextWithSpanContext makes sc the parent of spans started from the
+// returned context
+func ContextWithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// Attribute is a span attribute; values are strings, int64s, float64s or bools
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// String, Int, Int64, Float64 and Bool build attributes of each value type
+func String(key, value string) Attribute          { return Attribute{Key: key, Value: value} }
+func Int(key string, value int) Attribute         { return Attribute{Key: key, Value: int64(value)} }
+func Int64(key string, value int64) Attribute     { return Attribute{Key: key, Value: value} }
+func Float64(key string, value float64) Attribute { return Attribute{Key: key, Value: value} }
+func Bool(key string, value bool) Attribute       { return Attribute{Key: key, Value: value} }
+
+// SpanData is a finished span as handed to the exporter
+type SpanData struct {
+	Name       string
+	Kind       SpanKind
+	Context    SpanContext
+	Parent     SpanID // Zero for root spans
+	Start      time.Time
+	End        time.Time
+	Attributes []Attribute
+	Failed     bool   // Status is error
+	Message    string // Status description when failed
+}
+
+// Exporter receives sampled spans as they end. Calls are made on the request
+// path, so exporters must queue spans rather than send them inline.
+type Exporter interface {
+	ExportSpan(span *SpanData)
+}
+
+// Tracer starts spans and passes the sampled ones to its exporter
+type Tracer struct {
+	exporter    Exporter
+	sampleRatio float64
+}
+
+// NewTracer creates a tracer sampling sampleRatio (0-1) of new traces. Traces
+// continued from a caller's traceparent keep the caller's decision.
+func NewTracer(exporter Exporter, sampleRatio float64) *Tracer {
+	return &Tracer{exporter: exporter, sampleRatio: min(max(sampleRatio, 0), 1)}
+}
+
+// Start begins a span that's a child of the span in ctx, or the root of a
+// new trace. The returned context carries the new span.
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	parent := SpanContextFromContext(ctx)
+	sc := SpanContext{SpanID: newSpanID()}
+	if parent.IsValid() {
+		sc.TraceID = parent.TraceID
+		sc.Sampled = parent.Sampled
+	} else {
+		sc.TraceID = newTraceID()
+		sc.Sampled = t.sampleRatio >= 1 || rand.Float64() < t.sampleRatio
+	}
+	span := &Span{
+		tracer: t,
+		data: SpanData{
+			Name:    name,
+			Kind:    kind,
+			Context: sc,
+			Parent:  parent.SpanID,
+			Start:   time.Now(),
+		},
+	}
+	return ContextWithSpanContext(ctx, sc), span
+}
+
+var global atomic.Pointer[Tracer]
+
+// SetTracer installs the tracer Start uses; nil turns tracing off
+func SetTracer(t *Tracer) {
+	global.Store(t)
+}
+
+// Enabled reports whether a tracer is installed
+func Enabled() bool {
+	return global.Load() != nil
+}
+
+// Start begins a span with the installed tracer. Without one it returns ctx
+// unchanged and a nil span.
+func Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	t := global.Load()
+	if t == nil {
+		return ctx, nil
+	}
+	return t.Start(ctx, name, kind)
+}
+
+// Span is an operation in progress. Its methods are safe to call on a nil
+// span and after End, when they do nothing.
+type Span struct {
+	tracer *Tracer
+	mu     sync.Mutex
+	data   SpanData
+	ended  bool
+}
+
+// Context returns the span's propagated context
+func (s *Span) Context() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.data.Context
+}
+
+// SetAttributes adds attributes to the span
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil || !s.data.Context.Sampled {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ended {
+		s.data.Attributes = append(s.data.Attributes, attrs...)
+	}
+}
+
+// SetError marks the span failed with err's message; a nil err does nothing
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil || !s.data.Context.Sampled {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ended {
+		s.data.Failed = true
+		s.data.Message = err.Error()
+	}
+}
+
+// End finishes the span, exporting it if its trace is sampled
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	s.mu.Unlock()
+
+	if data.Context.Sampled && s.tracer.exporter != nil {
+		s.tracer.exporter.ExportSpan(&data)
+	}
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	for id == (TraceID{}) {
+		binary.BigEndian.PutUint64(id[:8], rand.Uint64())
+		binary.BigEndian.PutUint64(id[8:], rand.Uint64())
+	}
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for id == (SpanID{}) {
+		binary.BigEndian.PutUint64(id[:], rand.Uint64())
+	}
+	return id
+}
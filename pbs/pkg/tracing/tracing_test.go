@@ -0,0 +1,142 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+// recorder keeps the spans it's given
+type recorder struct {
+	mu    sync.Mutex
+	spans []*SpanData
+}
+
+func (r *recorder) ExportSpan(span *SpanData) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, span)
+}
+
+func TestTracer_ParentAndChild(t *testing.T) {
+	rec := &recorder{}
+	tracer := NewTracer(rec, 1)
+
+	ctx, root := tracer.Start(context.Background(), "auction", SpanKindServer)
+	_, child := tracer.Start(ctx, "bidder", SpanKindClient)
+	child.SetAttributes(String("bidder", "appnexus"), Int("status", 200))
+	child.SetError(errors.New("timeout"))
+	child.End()
+	root.End()
+	root.End() // Ending twice exports once
+
+	if len(rec.spans) != 2 {
+		t.Fatalf("expected 2 spans exported, got %d", len(rec.spans))
+	}
+	c, r := rec.spans[0], rec.spans[1]
+	if c.Context.TraceID != r.Context.TraceID || c.Parent != r.Context.SpanID {
+		t.Errorf("expected the child in the root's trace, parented to it: %+v %+v", c.Context, r.Context)
+	}
+	if r.Parent != (SpanID{}) || !r.Context.Sampled {
+		t.Errorf("expected a sampled root span, got %+v", r)
+	}
+	if len(c.Attributes) != 2 || c.Attributes[1].Value != int64(200) || !c.Failed || c.Message != "timeout" {
+		t.Errorf("expected attributes and error status on the child, got %+v", c)
+	}
+	if c.End.Before(c.Start) {
+		t.Error("expected the end time after the start")
+	}
+}
+
+func TestTracer_Sampling(t *testing.T) {
+	rec := &recorder{}
+	tracer := NewTracer(rec, 0)
+
+	ctx, span := tracer.Start(context.Background(), "auction", SpanKindServer)
+	if !span.Context().IsValid() || span.Context().Sampled {
+		t.Fatalf("expected an unsampled span that still propagates, got %+v", span.Context())
+	}
+	span.End()
+
+	// A sampled caller's decision wins over the ratio
+	parent := SpanContext{TraceID: TraceID{1}, SpanID: SpanID{2}, Sampled: true}
+	_, child := tracer.Start(ContextWithSpanContext(ctx, parent), "bidder", SpanKindClient)
+	child.End()
+
+	if len(rec.spans) != 1 || rec.spans[0].Context.TraceID != parent.TraceID {
+		t.Errorf("expected only the span under the sampled parent exported, got %d", len(rec.spans))
+	}
+}
+
+func TestStart_Disabled(t *testing.T) {
+	SetTracer(nil)
+	ctx := context.Background()
+	got, span := Start(ctx, "auction", SpanKindServer)
+	if span != nil || got != ctx {
+		t.Fatal("expected no span without a tracer")
+	}
+	// Nil spans are safe to use
+	span.SetAttributes(Bool("ok", true))
+	span.SetError(errors.New("ignored"))
+	span.End()
+
+	header := http.Header{}
+	header.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if sc := SpanContextFromContext(Extract(ctx, header)); sc.IsValid() {
+		t.Error("expected an incoming traceparent ignored without a tracer")
+	}
+}
+
+func TestInjectExtract(t *testing.T) {
+	rec := &recorder{}
+	SetTracer(NewTracer(rec, 1))
+	defer SetTracer(nil)
+
+	incoming := http.Header{}
+	incoming.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx, span := Start(Extract(context.Background(), incoming), "auction", SpanKindServer)
+	defer span.End()
+
+	outgoing := http.Header{}
+	Inject(ctx, outgoing)
+	sc, ok := parseTraceparent(outgoing.Get(TraceparentHeader))
+	if !ok || sc.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || sc.SpanID != span.Context().SpanID || !sc.Sampled {
+		t.Errorf("expected the caller's trace continued with this span as parent, got %q", outgoing.Get(TraceparentHeader))
+	}
+
+	empty := http.Header{}
+	Inject(context.Background(), empty)
+	if empty.Get(TraceparentHeader) != "" {
+		t.Error("expected no header without a span")
+	}
+}
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		value   string
+		valid   bool
+		sampled bool
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true, true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", true, false},
+		{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", true, true}, // Later versions may add fields
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false, false},
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false, false},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", false, false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false, false},
+		{"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", false, false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7", false, false},
+		{"", false, false},
+	}
+	for _, tt := range tests {
+		sc, ok := parseTraceparent(tt.value)
+		if ok != tt.valid || sc.Sampled != tt.sampled {
+			t.Errorf("parseTraceparent(%q) = %+v, %v; want valid %v, sampled %v", tt.value, sc, ok, tt.valid, tt.sampled)
+		}
+		if ok && formatTraceparent(sc)[3:] != "4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-"+tt.value[53:55] {
+			t.Errorf("expected %q to round trip, got %q", tt.value, formatTraceparent(sc))
+		}
+	}
+}
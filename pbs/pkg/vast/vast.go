@@ -0,0 +1,123 @@
+// Package vast inspects and edits VAST video ad markup: checking it parses,
+// reading the creative's duration, and adding impression trackers. It has no
+// dependencies outside the standard library so adapters and the exchange can
+// both use it.
+package vast
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrNotVAST is returned for well-formed XML whose root element isn't <VAST>
+var ErrNotVAST = errors.New("root element is not <VAST>")
+
+// Info is what an auction needs to know about a VAST document
+type Info struct {
+	Version string
+	// Duration is the first linear creative's duration in whole seconds,
+	// rounded up; 0 when no InLine ad declares one (wrappers don't)
+	Duration int
+}
+
+// Parse checks that markup is well-formed XML with a <VAST> root and reads
+// its version and duration
+func Parse(markup string) (Info, error) {
+	var info Info
+	dec := xml.NewDecoder(strings.NewReader(markup))
+	// Only the structure is checked, so non-UTF-8 documents are read as is
+	dec.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) { return input, nil }
+
+	var path []string
+	sawRoot := false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Info{}, fmt.Errorf("invalid VAST XML: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if len(path) == 0 {
+				if sawRoot {
+					return Info{}, errors.New("invalid VAST XML: more than one root element")
+				}
+				if t.Name.Local != "VAST" {
+					return Info{}, fmt.Errorf("%w: got <%s>", ErrNotVAST, t.Name.Local)
+				}
+				sawRoot = true
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "version" {
+						info.Version = attr.Value
+					}
+				}
+			}
+			path = append(path, t.Name.Local)
+			if t.Name.Local == "Duration" && info.Duration == 0 && inLinear(path) {
+				var text string
+				if err := dec.DecodeElement(&text, &t); err != nil {
+					return Info{}, fmt.Errorf("invalid VAST XML: %w", err)
+				}
+				path = path[:len(path)-1]
+				info.Duration = parseDuration(text)
+			}
+		case xml.EndElement:
+			path = path[:len(path)-1]
+		}
+	}
+	if !sawRoot {
+		return Info{}, errors.New("invalid VAST XML: no root element")
+	}
+	return info, nil
+}
+
+// inLinear reports whether path is inside an InLine ad's Linear creative
+func inLinear(path []string) bool {
+	inline := false
+	for _, name := range path {
+		switch name {
+		case "InLine":
+			inline = true
+		case "Linear":
+			return inline
+		}
+	}
+	return false
+}
+
+// parseDuration reads an HH:MM:SS or HH:MM:SS.mmm duration, rounded up to
+// whole seconds. Malformed durations read as 0.
+func parseDuration(s string) int {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) != 3 {
+		return 0
+	}
+	hours, err1 := strconv.Atoi(parts[0])
+	minutes, err2 := strconv.Atoi(parts[1])
+	seconds, err3 := strconv.ParseFloat(parts[2], 64)
+	if err1 != nil || err2 != nil || err3 != nil || hours < 0 || minutes < 0 || seconds < 0 {
+		return 0
+	}
+	return hours*3600 + minutes*60 + int(math.Ceil(seconds))
+}
+
+// InjectImpression adds an <Impression> for trackerURL to every InLine and
+// Wrapper ad, returning the markup unchanged and false when it has neither
+func InjectImpression(markup, trackerURL string) (string, bool) {
+	impression := "<Impression><![CDATA[" + trackerURL + "]]></Impression>"
+	injected := false
+	for _, closing := range []string{"</InLine>", "</Wrapper>"} {
+		if strings.Contains(markup, closing) {
+			markup = strings.ReplaceAll(markup, closing, impression+closing)
+			injected = true
+		}
+	}
+	return markup, injected
+}
@@ -0,0 +1,71 @@
+package vast
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const inline = `<?xml version="1.0" encoding="UTF-8"?>
+<VAST version="4.0"><Ad id="1"><InLine><AdSystem>x</AdSystem>
+<Creatives><Creative><Linear><Duration>00:00:14.500</Duration><MediaFiles/></Linear></Creative></Creatives>
+</InLine></Ad></VAST>`
+
+const wrapper = `<VAST version="3.0"><Ad><Wrapper><AdSystem>x</AdSystem><VASTAdTagURI><![CDATA[https://ads.example/vast]]></VASTAdTagURI>
+<Creatives><Creative><Linear><Duration>00:00:30</Duration></Linear></Creative></Creatives></Wrapper></Ad></VAST>`
+
+func TestParse(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		markup       string
+		wantVersion  string
+		wantDuration int
+		wantErr      bool
+	}{
+		{"inline", inline, "4.0", 15, false},
+		{"wrapper has no duration", wrapper, "3.0", 0, false},
+		{"minutes and hours", `<VAST version="2.0"><Ad><InLine><Creatives><Creative><Linear><Duration> 01:02:03 </Duration></Linear></Creative></Creatives></InLine></Ad></VAST>`, "2.0", 3723, false},
+		{"malformed duration", `<VAST><Ad><InLine><Creatives><Creative><Linear><Duration>30s</Duration></Linear></Creative></Creatives></InLine></Ad></VAST>`, "", 0, false},
+		{"no-fill", `<VAST version="3.0"/>`, "3.0", 0, false},
+		{"latin-1", `<?xml version="1.0" encoding="ISO-8859-1"?><VAST version="3.0"></VAST>`, "3.0", 0, false},
+		{"unclosed", `<VAST><Ad><InLine></Ad></VAST>`, "", 0, true},
+		{"not xml", `<div>banner</div`, "", 0, true},
+		{"html", `<div>banner</div>`, "", 0, true},
+		{"empty", ``, "", 0, true},
+		{"two roots", `<VAST></VAST><VAST></VAST>`, "", 0, true},
+	} {
+		info, err := Parse(tc.markup)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: expected error %v, got %v", tc.name, tc.wantErr, err)
+			continue
+		}
+		if info.Version != tc.wantVersion || info.Duration != tc.wantDuration {
+			t.Errorf("%s: expected version %q duration %d, got %+v", tc.name, tc.wantVersion, tc.wantDuration, info)
+		}
+	}
+
+	if _, err := Parse(`<div>banner</div>`); !errors.Is(err, ErrNotVAST) {
+		t.Errorf("expected ErrNotVAST for an HTML root, got %v", err)
+	}
+}
+
+func TestInjectImpression(t *testing.T) {
+	const tracker = "https://pbs.example/event?t=imp&b=bid1"
+
+	out, ok := InjectImpression(inline+wrapper, tracker)
+	if !ok {
+		t.Fatal("expected the tracker injected")
+	}
+	want := "<Impression><![CDATA[" + tracker + "]]></Impression>"
+	if strings.Count(out, want) != 2 || !strings.Contains(out, want+"</InLine>") || !strings.Contains(out, want+"</Wrapper>") {
+		t.Errorf("expected an impression in each ad, got %s", out)
+	}
+	injected, _ := InjectImpression(inline, tracker)
+	if _, err := Parse(injected); err != nil {
+		t.Errorf("expected injected VAST to still parse, got %v", err)
+	}
+
+	if out, ok := InjectImpression(`<VAST version="3.0"/>`, tracker); ok || out != `<VAST version="3.0"/>` {
+		t.Errorf("expected VAST without ads unchanged, got %q", out)
+	}
+}
@@ -0,0 +1,130 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters/ortb"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/warmup"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/idr"
+)
+
+func TestHealthAndStatus(t *testing.T) {
+	for _, path := range []string{"/health", "/status"} {
+		resp, body := doRequest(t, http.MethodGet, path, nil, nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("%s: expected 200, got %d: %s", path, resp.StatusCode, body)
+		}
+		if resp.Header.Get("X-Request-ID") == "" {
+			t.Errorf("%s: expected X-Request-ID header from logging middleware", path)
+		}
+	}
+}
+
+func TestReady_ReportsWarmupSteps(t *testing.T) {
+	resp, body := doRequest(t, http.MethodGet, "/ready", nil, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", resp.StatusCode, body)
+	}
+
+	var report warmup.Report
+	if err := json.Unmarshal(body, &report); err != nil {
+		t.Fatalf("invalid response JSON: %v", err)
+	}
+	if !report.Ready || len(report.Steps) == 0 {
+		t.Fatalf("expected completed warmup report, got %+v", report)
+	}
+	for _, step := range report.Steps {
+		if step.Error != "" {
+			t.Errorf("warmup step %s failed: %s", step.Name, step.Error)
+		}
+	}
+}
+
+func TestInfoBidders_IncludesDynamicBidders(t *testing.T) {
+	resp, body := doRequest(t, http.MethodGet, "/info/bidders", nil, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", resp.StatusCode, body)
+	}
+
+	var bidders []string
+	if err := json.Unmarshal(body, &bidders); err != nil {
+		t.Fatalf("invalid response JSON: %v", err)
+	}
+
+	found := make(map[string]bool, len(bidders))
+	for _, b := range bidders {
+		found[b] = true
+	}
+	for _, want := range []string{"appnexus", "e2ealpha", "e2ebeta"} {
+		if !found[want] {
+			t.Errorf("expected %s in /info/bidders, got %v", want, bidders)
+		}
+	}
+}
+
+func TestMetrics_ExposesRequestCounters(t *testing.T) {
+	// Make sure at least one request has been recorded
+	doRequest(t, http.MethodGet, "/status", nil, nil)
+
+	resp, body := doRequest(t, http.MethodGet, "/metrics", nil, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	if !strings.Contains(string(body), "pbs_http_requests_total") {
+		t.Error("expected pbs_http_requests_total in /metrics output")
+	}
+}
+
+func TestAdmin_RequiresAPIKey(t *testing.T) {
+	resp, _ := doRequest(t, http.MethodGet, "/admin/circuit-breaker", nil, nil)
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401 without API key, got %d", resp.StatusCode)
+	}
+
+	resp, _ = doRequest(t, http.MethodGet, "/admin/circuit-breaker", nil,
+		map[string]string{"X-API-Key": "wrong-key"})
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected 403 with invalid API key, got %d", resp.StatusCode)
+	}
+}
+
+func TestAdmin_CircuitBreakerStats(t *testing.T) {
+	resp, body := doRequest(t, http.MethodGet, "/admin/circuit-breaker", nil,
+		map[string]string{"X-API-Key": e2eAPIKey})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", resp.StatusCode, body)
+	}
+
+	var stats idr.CircuitBreakerStats
+	if err := json.Unmarshal(body, &stats); err != nil {
+		t.Fatalf("invalid response JSON: %v", err)
+	}
+	if stats.State != "closed" {
+		t.Errorf("expected closed circuit with healthy mock IDR, got %q", stats.State)
+	}
+}
+
+func TestAdmin_DynamicRegistryStats(t *testing.T) {
+	resp, body := doRequest(t, http.MethodGet, "/admin/dynamic-registry", nil,
+		map[string]string{"X-API-Key": e2eAPIKey})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", resp.StatusCode, body)
+	}
+
+	var stats ortb.RegistryStats
+	if err := json.Unmarshal(body, &stats); err != nil {
+		t.Fatalf("invalid response JSON: %v", err)
+	}
+	if stats.Stale {
+		t.Error("expected fresh registry with Redis available")
+	}
+	if stats.ConfigsLoaded < 3 || stats.LastSuccess == nil {
+		t.Errorf("expected seeded configs to be loaded, got %+v", stats)
+	}
+}
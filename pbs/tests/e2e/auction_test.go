@@ -0,0 +1,198 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+)
+
+// testBidRequest returns a minimal valid site banner request
+func testBidRequest(id string) openrtb.BidRequest {
+	return openrtb.BidRequest{
+		ID: id,
+		Imp: []openrtb.Imp{
+			{ID: "imp-1", Banner: &openrtb.Banner{W: 300, H: 250}},
+		},
+		Site: &openrtb.Site{
+			Domain:    "e2e.example.com",
+			Page:      "https://e2e.example.com/article",
+			Publisher: &openrtb.Publisher{ID: "e2e-pub"},
+		},
+		Device: &openrtb.Device{
+			UA: "Mozilla/5.0 (e2e)",
+			IP: "203.0.113.10",
+		},
+		TMax: 500,
+	}
+}
+
+// doRequest sends a request to the server under test and returns status and body
+func doRequest(t *testing.T, method, path string, body interface{}, headers map[string]string) (*http.Response, []byte) {
+	t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("failed to marshal body: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, env.baseURL+path, reader)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read response body: %v", err)
+	}
+	return resp, data
+}
+
+func TestAuction_SelectsDynamicBiddersViaIDR(t *testing.T) {
+	selectBefore := env.idr.selectCalls.Load()
+	alphaBefore := env.bidders["e2ealpha"].requests.Load()
+	betaBefore := env.bidders["e2ebeta"].requests.Load()
+
+	resp, body := doRequest(t, http.MethodPost, "/openrtb2/auction", testBidRequest("e2e-auction-1"), nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", resp.StatusCode, body)
+	}
+
+	var bidResp openrtb.BidResponse
+	if err := json.Unmarshal(body, &bidResp); err != nil {
+		t.Fatalf("invalid response JSON: %v", err)
+	}
+	if bidResp.ID != "e2e-auction-1" {
+		t.Errorf("expected response id e2e-auction-1, got %q", bidResp.ID)
+	}
+
+	if got := env.idr.selectCalls.Load(); got <= selectBefore {
+		t.Error("expected IDR /internal/select to be called")
+	}
+	if got := env.bidders["e2ealpha"].requests.Load(); got <= alphaBefore {
+		t.Error("expected e2ealpha to receive a bid request")
+	}
+	if got := env.bidders["e2ebeta"].requests.Load(); got <= betaBefore {
+		t.Error("expected e2ebeta to receive a bid request")
+	}
+
+	seats := make(map[string]openrtb.SeatBid)
+	for _, sb := range bidResp.SeatBid {
+		seats[sb.Seat] = sb
+	}
+
+	// Platform demand is obfuscated under the platform seat
+	platform, ok := seats[adapters.PlatformSeatName]
+	if !ok {
+		t.Fatalf("expected %s seat, got seats %v", adapters.PlatformSeatName, bidResp.SeatBid)
+	}
+	if len(platform.Bid) != 1 || platform.Bid[0].Price != 2.50 {
+		t.Errorf("expected single platform bid at 2.50, got %+v", platform.Bid)
+	}
+
+	// Publisher demand is shown transparently
+	beta, ok := seats["e2ebeta"]
+	if !ok {
+		t.Fatalf("expected e2ebeta seat, got seats %v", bidResp.SeatBid)
+	}
+	if len(beta.Bid) != 1 || beta.Bid[0].Price != 1.75 {
+		t.Errorf("expected single e2ebeta bid at 1.75, got %+v", beta.Bid)
+	}
+
+	var ext openrtb.BidExt
+	if err := json.Unmarshal(platform.Bid[0].Ext, &ext); err != nil || ext.Prebid == nil {
+		t.Fatalf("expected prebid bid ext, got %s", platform.Bid[0].Ext)
+	}
+	if ext.Prebid.Targeting["hb_bidder"] != adapters.PlatformSeatName {
+		t.Errorf("expected hb_bidder=%s, got %q", adapters.PlatformSeatName, ext.Prebid.Targeting["hb_bidder"])
+	}
+}
+
+func TestAuctionV2_StrictValidation(t *testing.T) {
+	resp, body := doRequest(t, http.MethodPost, "/openrtb2/auction/v2", testBidRequest("e2e-v2"), nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", resp.StatusCode, body)
+	}
+	if got := resp.Header.Get("X-API-Version"); got != "v2" {
+		t.Errorf("expected X-API-Version v2, got %q", got)
+	}
+
+	// Duplicate imp IDs are a 400 in v2 rather than an exchange error
+	req := testBidRequest("e2e-v2-dup")
+	req.Imp = append(req.Imp, req.Imp[0])
+	resp, body = doRequest(t, http.MethodPost, "/openrtb2/auction/v2", req, nil)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for duplicate imp ids, got %d: %s", resp.StatusCode, body)
+	}
+}
+
+func TestAuction_RejectsInvalidRequest(t *testing.T) {
+	req := testBidRequest("")
+	resp, body := doRequest(t, http.MethodPost, "/openrtb2/auction", req, nil)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for missing id, got %d: %s", resp.StatusCode, body)
+	}
+}
+
+func TestAuction_RejectsNonPost(t *testing.T) {
+	resp, _ := doRequest(t, http.MethodGet, "/openrtb2/auction", nil, nil)
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", resp.StatusCode)
+	}
+}
+
+func TestAuction_PrivacyBlocksCOPPA(t *testing.T) {
+	req := testBidRequest("e2e-coppa")
+	req.Regs = &openrtb.Regs{COPPA: 1}
+
+	resp, body := doRequest(t, http.MethodPost, "/openrtb2/auction", req, nil)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected privacy middleware to block COPPA request, got %d: %s", resp.StatusCode, body)
+	}
+}
+
+func TestAuction_DebugWithAPIKey(t *testing.T) {
+	resp, body := doRequest(t, http.MethodPost, "/openrtb2/auction?debug=1", testBidRequest("e2e-debug"),
+		map[string]string{"X-API-Key": e2eAPIKey})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", resp.StatusCode, body)
+	}
+
+	var bidResp openrtb.BidResponse
+	if err := json.Unmarshal(body, &bidResp); err != nil {
+		t.Fatalf("invalid response JSON: %v", err)
+	}
+
+	var ext openrtb.BidResponseExt
+	if err := json.Unmarshal(bidResp.Ext, &ext); err != nil {
+		t.Fatalf("expected debug ext, got %s", bidResp.Ext)
+	}
+	if _, ok := ext.ResponseTimeMillis["e2ealpha"]; !ok {
+		t.Errorf("expected responsetimemillis for e2ealpha, got %v", ext.ResponseTimeMillis)
+	}
+}
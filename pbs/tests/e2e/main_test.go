@@ -0,0 +1,237 @@
+//go:build e2e
+// +build e2e
+
+// Package e2e contains end-to-end tests that boot the PBS server binary
+// against a throwaway Redis, a mock IDR service and mock bidders.
+//
+// These tests catch wiring regressions in cmd/server (middleware order,
+// route registration, Redis-backed dynamic bidders, IDR selection) that
+// unit tests in individual packages cannot see.
+//
+// Run locally with:
+//
+//	docker compose -f tests/e2e/docker-compose.yml up -d
+//	go test -v -tags=e2e ./tests/e2e/...
+//
+// E2E_REDIS_URL overrides the Redis instance (default redis://localhost:6390/15).
+// The selected database is flushed before and after the run.
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+const (
+	// e2eAPIKey is the admin API key the server is booted with
+	e2eAPIKey = "e2e-admin-key"
+
+	// defaultRedisURL points at the redis service in tests/e2e/docker-compose.yml
+	defaultRedisURL = "redis://localhost:6390/15"
+
+	// serverStartTimeout bounds how long we wait for /ready after exec
+	serverStartTimeout = 15 * time.Second
+)
+
+// env holds the shared fixtures for every test in the suite
+var env *testEnv
+
+// testEnv wires the server process to its mock dependencies
+type testEnv struct {
+	baseURL string
+	redis   *goredis.Client
+	idr     *mockIDR
+	bidders map[string]*mockBidder
+	logPath string
+}
+
+func TestMain(m *testing.M) {
+	os.Exit(run(m))
+}
+
+// run sets up the environment, executes the suite and tears everything down.
+// Split out of TestMain so deferred cleanup runs before os.Exit.
+func run(m *testing.M) int {
+	redisURL := os.Getenv("E2E_REDIS_URL")
+	if redisURL == "" {
+		redisURL = defaultRedisURL
+	}
+
+	opts, err := goredis.ParseURL(redisURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "e2e: invalid E2E_REDIS_URL %q: %v\n", redisURL, err)
+		return 1
+	}
+	rdb := goredis.NewClient(opts)
+	defer rdb.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "e2e: redis unavailable at %s (start it with docker compose -f tests/e2e/docker-compose.yml up -d): %v\n", redisURL, err)
+		return 1
+	}
+	if err := rdb.FlushDB(ctx).Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "e2e: failed to flush redis: %v\n", err)
+		return 1
+	}
+	defer rdb.FlushDB(context.Background())
+
+	// Mock dependencies
+	idr := newMockIDR()
+	defer idr.Close()
+
+	bidders := map[string]*mockBidder{
+		"e2ealpha": newMockBidder("e2ealpha", 2.50),
+		"e2ebeta":  newMockBidder("e2ebeta", 1.75),
+		"e2enobid": newMockBidder("e2enobid", 0),
+	}
+	for _, b := range bidders {
+		defer b.Close()
+	}
+	idr.allow("e2ealpha", "e2ebeta", "e2enobid")
+
+	if err := seedBidders(ctx, rdb, bidders); err != nil {
+		fmt.Fprintf(os.Stderr, "e2e: failed to seed bidders: %v\n", err)
+		return 1
+	}
+
+	// Build and boot the server
+	workDir, err := os.MkdirTemp("", "pbs-e2e-*")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "e2e: failed to create temp dir: %v\n", err)
+		return 1
+	}
+	defer os.RemoveAll(workDir)
+
+	binPath := filepath.Join(workDir, "pbs-server")
+	build := exec.Command("go", "build", "-o", binPath, "../../cmd/server")
+	build.Stdout = os.Stdout
+	build.Stderr = os.Stderr
+	if err := build.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "e2e: failed to build server: %v\n", err)
+		return 1
+	}
+
+	port, err := freePort()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "e2e: failed to find free port: %v\n", err)
+		return 1
+	}
+
+	logPath := filepath.Join(workDir, "server.log")
+	logFile, err := os.Create(logPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "e2e: failed to create log file: %v\n", err)
+		return 1
+	}
+	defer logFile.Close()
+
+	baseURL := fmt.Sprintf("http://127.0.0.1:%d", port)
+	server := exec.Command(binPath)
+	server.Stdout = logFile
+	server.Stderr = logFile
+	server.Env = append(os.Environ(),
+		fmt.Sprintf("PBS_PORT=%d", port),
+		"PBS_HOST_URL="+baseURL,
+		"IDR_URL="+idr.URL,
+		"IDR_ENABLED=true",
+		// Every auction must reach the mock IDR so call counts are deterministic
+		"IDR_SELECTION_CACHE_TTL=0",
+		"REDIS_URL="+redisURL,
+		// The suite's mock bidders are dynamic bidders
+		"DYNAMIC_BIDDERS_ENABLED=true",
+		"AUTH_ENABLED=true",
+		"API_KEYS="+e2eAPIKey+":e2e-pub",
+		"AUTH_USE_REDIS=false",
+		"PUBLISHER_AUTH_ENABLED=false",
+		"RATE_LIMIT_ENABLED=false",
+		"LOG_LEVEL=debug",
+	)
+	if err := server.Start(); err != nil {
+		fmt.Fprintf(os.Stderr, "e2e: failed to start server: %v\n", err)
+		return 1
+	}
+	defer func() {
+		_ = server.Process.Signal(os.Interrupt)
+		done := make(chan struct{})
+		go func() {
+			_ = server.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(10 * time.Second):
+			_ = server.Process.Kill()
+		}
+	}()
+
+	if err := waitReady(baseURL, serverStartTimeout); err != nil {
+		fmt.Fprintf(os.Stderr, "e2e: server did not become ready: %v (log: %s)\n", err, logPath)
+		dumpLog(logPath)
+		return 1
+	}
+
+	env = &testEnv{
+		baseURL: baseURL,
+		redis:   rdb,
+		idr:     idr,
+		bidders: bidders,
+		logPath: logPath,
+	}
+
+	code := m.Run()
+	if code != 0 {
+		dumpLog(logPath)
+	}
+	return code
+}
+
+// freePort asks the kernel for an unused TCP port
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+// waitReady polls /ready until warmup finishes (200) or the timeout elapses
+func waitReady(baseURL string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	client := &http.Client{Timeout: time.Second}
+	var lastErr error
+	for time.Now().Before(deadline) {
+		resp, err := client.Get(baseURL + "/ready")
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+			lastErr = fmt.Errorf("status %d", resp.StatusCode)
+		} else {
+			lastErr = err
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return lastErr
+}
+
+// dumpLog writes the server log to stderr to help diagnose failures
+func dumpLog(path string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "---- server log ----\n%s\n---- end server log ----\n", data)
+}
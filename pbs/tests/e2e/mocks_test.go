@@ -0,0 +1,177 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/adapters/ortb"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/openrtb"
+	"github.com/StreetsDigital/thenexusengine/pbs/pkg/idr"
+)
+
+// redisBiddersHash must match the key the dynamic registry reads from
+const redisBiddersHash = "nexus:bidders"
+
+// mockIDR emulates the Python IDR service endpoints PBS depends on
+type mockIDR struct {
+	*httptest.Server
+
+	mu      sync.RWMutex
+	allowed map[string]bool
+
+	selectCalls atomic.Int64
+	eventCalls  atomic.Int64
+}
+
+func newMockIDR() *mockIDR {
+	m := &mockIDR{allowed: make(map[string]bool)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/internal/select", m.handleSelect)
+	mux.HandleFunc("/api/events", func(w http.ResponseWriter, r *http.Request) {
+		m.eventCalls.Add(1)
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	m.Server = httptest.NewServer(mux)
+	return m
+}
+
+// allow restricts partner selection to the given bidders, so the auction
+// never fans out to the real static adapter endpoints
+func (m *mockIDR) allow(bidders ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, b := range bidders {
+		m.allowed[b] = true
+	}
+}
+
+func (m *mockIDR) handleSelect(w http.ResponseWriter, r *http.Request) {
+	m.selectCalls.Add(1)
+
+	var req idr.SelectPartnersRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	m.mu.RLock()
+	resp := idr.SelectPartnersResponse{Mode: "normal"}
+	for _, code := range req.AvailableBidders {
+		if m.allowed[code] {
+			resp.SelectedBidders = append(resp.SelectedBidders, idr.SelectedBidder{
+				BidderCode: code,
+				Score:      0.9,
+				Reason:     "HIGH_SCORE",
+			})
+		} else {
+			resp.ExcludedBidders = append(resp.ExcludedBidders, idr.ExcludedBidder{
+				BidderCode: code,
+				Reason:     "E2E_EXCLUDED",
+			})
+		}
+	}
+	m.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// mockBidder is an OpenRTB endpoint that bids a fixed price on every imp.
+// A zero price makes it answer 204 No Content.
+type mockBidder struct {
+	*httptest.Server
+	code  string
+	price float64
+
+	requests atomic.Int64
+}
+
+func newMockBidder(code string, price float64) *mockBidder {
+	b := &mockBidder{code: code, price: price}
+	b.Server = httptest.NewServer(http.HandlerFunc(b.handle))
+	return b
+}
+
+func (b *mockBidder) handle(w http.ResponseWriter, r *http.Request) {
+	b.requests.Add(1)
+
+	var req openrtb.BidRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	if b.price == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	bids := make([]openrtb.Bid, 0, len(req.Imp))
+	for _, imp := range req.Imp {
+		bids = append(bids, openrtb.Bid{
+			ID:    b.code + "-" + imp.ID,
+			ImpID: imp.ID,
+			Price: b.price,
+			AdM:   "<div>" + b.code + "</div>",
+			CRID:  b.code + "-creative",
+			W:     300,
+			H:     250,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(openrtb.BidResponse{
+		ID:      req.ID,
+		Cur:     "USD",
+		SeatBid: []openrtb.SeatBid{{Seat: b.code, Bid: bids}},
+	})
+}
+
+// seedBidders writes a dynamic bidder config for every mock bidder.
+// e2ebeta is publisher demand so it surfaces under its own seat.
+func seedBidders(ctx context.Context, rdb *goredis.Client, bidders map[string]*mockBidder) error {
+	fields := make(map[string]interface{}, len(bidders))
+	for code, b := range bidders {
+		demandType := "platform"
+		if code == "e2ebeta" {
+			demandType = "publisher"
+		}
+		cfg := ortb.BidderConfig{
+			BidderCode: code,
+			Name:       code,
+			Status:     "active",
+			DemandType: demandType,
+			Endpoint: ortb.EndpointConfig{
+				URL:             b.URL,
+				Method:          http.MethodPost,
+				TimeoutMS:       200,
+				ProtocolVersion: "2.5",
+			},
+			Capabilities: ortb.CapabilitiesConfig{
+				MediaTypes:  []string{"banner", "video"},
+				SiteEnabled: true,
+				AppEnabled:  true,
+			},
+		}
+		data, err := json.Marshal(cfg)
+		if err != nil {
+			return err
+		}
+		fields[code] = string(data)
+	}
+	return rdb.HSet(ctx, redisBiddersHash, fields).Err()
+}
@@ -0,0 +1,107 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/endpoints"
+	"github.com/StreetsDigital/thenexusengine/pbs/internal/usersync"
+)
+
+func TestCookieSync_ReturnsSyncURLs(t *testing.T) {
+	resp, body := doRequest(t, http.MethodPost, "/cookie_sync", endpoints.CookieSyncRequest{
+		Bidders: []string{"appnexus", "rubicon"},
+	}, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", resp.StatusCode, body)
+	}
+
+	var syncResp endpoints.CookieSyncResponse
+	if err := json.Unmarshal(body, &syncResp); err != nil {
+		t.Fatalf("invalid response JSON: %v", err)
+	}
+	if syncResp.Status != "ok" {
+		t.Errorf("expected status ok, got %q", syncResp.Status)
+	}
+	if len(syncResp.BidderStatus) != 2 {
+		t.Fatalf("expected 2 bidder statuses, got %d", len(syncResp.BidderStatus))
+	}
+	for _, bs := range syncResp.BidderStatus {
+		if bs.UserSync == nil || bs.UserSync.URL == "" {
+			t.Errorf("expected sync URL for %s, got %+v", bs.Bidder, bs)
+			continue
+		}
+		// Redirect URL must point back at this server's /setuid
+		if !strings.Contains(bs.UserSync.URL, "setuid") {
+			t.Errorf("expected sync URL for %s to redirect to setuid, got %s", bs.Bidder, bs.UserSync.URL)
+		}
+	}
+}
+
+func TestSetUID_StoresUIDAndSkipsSyncedBidder(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, env.baseURL+"/setuid?bidder=appnexus&uid=e2e-user-123", nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("setuid failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "image/gif" {
+		t.Errorf("expected image/gif pixel, got %q", ct)
+	}
+
+	var uidsCookie *http.Cookie
+	for _, c := range resp.Cookies() {
+		if c.Name == usersync.CookieName {
+			uidsCookie = c
+		}
+	}
+	if uidsCookie == nil {
+		t.Fatal("expected uids cookie to be set")
+	}
+
+	// A follow-up cookie_sync carrying the cookie should not ask appnexus to sync again
+	syncReq, err := http.NewRequest(http.MethodPost, env.baseURL+"/cookie_sync",
+		strings.NewReader(`{"bidders":["appnexus","rubicon"]}`))
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	syncReq.Header.Set("Content-Type", "application/json")
+	syncReq.AddCookie(&http.Cookie{Name: uidsCookie.Name, Value: uidsCookie.Value})
+
+	syncResp, err := client.Do(syncReq)
+	if err != nil {
+		t.Fatalf("cookie_sync failed: %v", err)
+	}
+	defer syncResp.Body.Close()
+
+	var parsed endpoints.CookieSyncResponse
+	if err := json.NewDecoder(syncResp.Body).Decode(&parsed); err != nil {
+		t.Fatalf("invalid response JSON: %v", err)
+	}
+	for _, bs := range parsed.BidderStatus {
+		if bs.Bidder == "appnexus" {
+			t.Errorf("expected appnexus to be skipped after setuid, got %+v", bs)
+		}
+	}
+}
+
+func TestSetUID_MissingBidder(t *testing.T) {
+	resp, _ := doRequest(t, http.MethodGet, "/setuid?uid=abc", nil, nil)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", resp.StatusCode)
+	}
+}
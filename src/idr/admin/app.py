@@ -42,6 +42,8 @@ except ImportError:
     print("Flask not installed. Run: pip install flask")
     raise
 
+from src.idr.auth.signing import SignatureError, parse_signing_keys, verify_signature
+
 # Import IDR components
 try:
     from src.idr.classifier.request_classifier import RequestClassifier
@@ -65,6 +67,24 @@ except ImportError:
 _metrics_store = None
 _event_pipeline = None
 
+# Running totals of the IDR shadow-mode comparisons PBS reports per auction
+_shadow_summary = {
+    "auctions": 0,
+    "bidders_called": 0,
+    "bidders_selected": 0,
+    "revenue_kept": 0.0,
+    "revenue_lost": 0.0,
+}
+
+
+def _record_shadow_comparison(event: dict) -> None:
+    """Add one auction's IDR shadow comparison to the running totals."""
+    _shadow_summary["auctions"] += 1
+    _shadow_summary["bidders_called"] += int(event.get("bidders_called", 0))
+    _shadow_summary["bidders_selected"] += int(event.get("bidders_selected", 0))
+    _shadow_summary["revenue_kept"] += float(event.get("revenue_kept", 0.0))
+    _shadow_summary["revenue_lost"] += float(event.get("revenue_lost", 0.0))
+
 
 # =============================================================================
 # Authentication System
@@ -435,6 +455,11 @@ def create_app(config_path: Path | None = None) -> Flask:
     else:
         print("  [IDR] INTERNAL_API_KEY configured for service-to-service calls")
 
+    # Optional HMAC signatures on service-to-service calls (shared with PBS)
+    signing_keys = parse_signing_keys(os.environ.get("REQUEST_SIGNING_KEYS", ""))
+    if signing_keys:
+        print(f"  [IDR] Request signing enforced for internal calls ({len(signing_keys)} key(s))")
+
     if not auth_enabled:
         if allow_unprotected:
             print("\n" + "=" * 60)
@@ -484,7 +509,8 @@ def create_app(config_path: Path | None = None) -> Flask:
     def internal_api_required(f):
         """Decorator for internal service-to-service API calls.
 
-        Validates requests using the INTERNAL_API_KEY header.
+        Validates requests using the INTERNAL_API_KEY header, and the HMAC
+        signature headers when REQUEST_SIGNING_KEYS is set.
         Used for PBS -> IDR calls that don't use session auth.
         """
         @wraps(f)
@@ -505,6 +531,15 @@ def create_app(config_path: Path | None = None) -> Flask:
                     "message": "The provided API key is not valid"
                 }), 403
 
+            if signing_keys:
+                try:
+                    verify_signature(request.headers, request.get_data(), signing_keys)
+                except SignatureError as e:
+                    return jsonify({
+                        "error": "Invalid request signature",
+                        "message": str(e)
+                    }), 403
+
             g.user = "internal_service"
             return f(*args, **kwargs)
 
@@ -789,6 +824,24 @@ def create_app(config_path: Path | None = None) -> Flask:
         except Exception as e:
             return jsonify(_safe_error_response(e, "Failed to set shadow mode", 400))
 
+    @app.route("/api/mode/shadow/summary", methods=["GET"])
+    @login_required
+    def get_shadow_summary():
+        """
+        Summarize the IDR shadow-mode comparisons PBS has reported: how many
+        bidder calls enforcement would save and how much revenue it would lose.
+        """
+        summary = dict(_shadow_summary)
+        called = summary["bidders_called"]
+        revenue = summary["revenue_kept"] + summary["revenue_lost"]
+        summary["call_reduction_pct"] = (
+            (called - summary["bidders_selected"]) / called * 100 if called else 0.0
+        )
+        summary["revenue_lost_pct"] = (
+            summary["revenue_lost"] / revenue * 100 if revenue else 0.0
+        )
+        return jsonify(summary)
+
     @app.route("/api/reset", methods=["POST"])
     @login_required
     def reset_config():
@@ -1110,16 +1163,17 @@ def create_app(config_path: Path | None = None) -> Flask:
                 for s in result.selected
             ]
 
-            excluded = []
-            if result.shadow_log:
-                excluded = [
-                    {
-                        "bidder_code": e.bidder_code,
-                        "score": e.score,
-                        "reason": "EXCLUDED",
-                    }
-                    for e in result.shadow_log
-                ]
+            # In shadow mode every bidder is selected; these are the ones
+            # enforcement would have dropped
+            scores_by_code = {s.bidder_code: s.score for s in result.selected}
+            excluded = [
+                {
+                    "bidder_code": code,
+                    "score": scores_by_code.get(code, 0.0),
+                    "reason": "EXCLUDED",
+                }
+                for code in result.shadow_would_exclude
+            ]
 
             mode = "shadow" if sel_cfg.shadow_mode else "normal"
 
@@ -1226,16 +1280,17 @@ def create_app(config_path: Path | None = None) -> Flask:
                 for s in result.selected
             ]
 
-            excluded = []
-            if result.shadow_log:
-                excluded = [
-                    {
-                        "bidder_code": e.bidder_code,
-                        "score": e.score,
-                        "reason": "EXCLUDED",
-                    }
-                    for e in result.shadow_log
-                ]
+            # In shadow mode every bidder is selected; these are the ones
+            # enforcement would have dropped
+            scores_by_code = {s.bidder_code: s.score for s in result.selected}
+            excluded = [
+                {
+                    "bidder_code": code,
+                    "score": scores_by_code.get(code, 0.0),
+                    "reason": "EXCLUDED",
+                }
+                for code in result.shadow_would_exclude
+            ]
 
             mode = "shadow" if sel_cfg.shadow_mode else "normal"
 
@@ -1304,7 +1359,9 @@ def create_app(config_path: Path | None = None) -> Flask:
             for event_data in events:
                 event_type = event_data.get("event_type", "bid_response")
 
-                if event_type == "win":
+                if event_type == "idr_shadow":
+                    _record_shadow_comparison(event_data)
+                elif event_type == "win":
                     _event_pipeline.submit_win(
                         auction_id=event_data.get("auction_id", ""),
                         bidder_code=event_data.get("bidder_code", ""),
@@ -1,7 +1,8 @@
 """
 Authentication module for The Nexus Engine.
 
-Provides API key management for publisher authentication across IDR and PBS.
+Provides API key management for publisher authentication across IDR and PBS,
+and verification of signed requests from PBS.
 """
 
 from .api_keys import (
@@ -9,9 +10,13 @@ from .api_keys import (
     APIKeyManager,
     get_api_key_manager,
 )
+from .signing import SignatureError, parse_signing_keys, verify_signature
 
 __all__ = [
     "APIKeyInfo",
     "APIKeyManager",
     "get_api_key_manager",
+    "SignatureError",
+    "parse_signing_keys",
+    "verify_signature",
 ]
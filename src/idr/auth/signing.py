@@ -0,0 +1,81 @@
+"""
+Request signature verification for calls from PBS.
+
+PBS signs IDR calls and event batches with HMAC-SHA256 over
+``timestamp + "." + body`` when REQUEST_SIGNING_KEYS is set. The same
+variable configures verification here, so both services share one value:
+
+    REQUEST_SIGNING_KEYS=k2:new-secret,k1:old-secret
+
+PBS signs with the first key; any listed key verifies. To rotate, add the new
+key to IDR, then list it first on PBS, then drop the old one.
+"""
+
+import hashlib
+import hmac
+import time
+
+KEY_ID_HEADER = "X-Nexus-Key-Id"
+TIMESTAMP_HEADER = "X-Nexus-Timestamp"
+SIGNATURE_HEADER = "X-Nexus-Signature"
+
+SIGNATURE_VERSION = "v1="
+DEFAULT_MAX_SKEW_SECONDS = 300
+
+
+class SignatureError(Exception):
+    """Raised when a request signature is missing or invalid."""
+
+
+def parse_signing_keys(raw: str) -> dict[str, bytes]:
+    """Parse ``id1:secret1,id2:secret2`` into a key ID -> secret mapping."""
+    keys: dict[str, bytes] = {}
+    for entry in (raw or "").split(","):
+        entry = entry.strip()
+        if not entry:
+            continue
+        key_id, sep, secret = entry.partition(":")
+        if not sep or not key_id.strip() or not secret:
+            raise ValueError(f"signing key entries must be id:secret, got {key_id!r}")
+        keys[key_id.strip()] = secret.encode()
+    return keys
+
+
+def compute_signature(secret: bytes, timestamp: str, body: bytes) -> str:
+    """Return the hex HMAC-SHA256 of ``timestamp + "." + body``."""
+    return hmac.new(secret, timestamp.encode() + b"." + body, hashlib.sha256).hexdigest()
+
+
+def verify_signature(
+    headers,
+    body: bytes,
+    keys: dict[str, bytes],
+    max_skew: int = DEFAULT_MAX_SKEW_SECONDS,
+    now: float | None = None,
+) -> str:
+    """Verify a signed request and return the key ID that signed it.
+
+    Raises SignatureError if the signature is missing, made with an unknown
+    key, too old or too far in the future, or does not match the body.
+    """
+    signature = headers.get(SIGNATURE_HEADER, "")
+    timestamp = headers.get(TIMESTAMP_HEADER, "")
+    if not signature.startswith(SIGNATURE_VERSION) or not timestamp:
+        raise SignatureError("request is not signed")
+
+    key_id = headers.get(KEY_ID_HEADER, "")
+    secret = keys.get(key_id)
+    if secret is None:
+        raise SignatureError("unknown signing key")
+
+    try:
+        skew = (time.time() if now is None else now) - int(timestamp)
+    except ValueError:
+        raise SignatureError("signature timestamp outside allowed skew") from None
+    if abs(skew) > max_skew:
+        raise SignatureError("signature timestamp outside allowed skew")
+
+    expected = compute_signature(secret, timestamp, body)
+    if not hmac.compare_digest(signature[len(SIGNATURE_VERSION):], expected):
+        raise SignatureError("signature mismatch")
+    return key_id
@@ -474,6 +474,7 @@ class BidderManager:
                 protocol_version=source.endpoint.protocol_version,
                 auth_type=source.endpoint.auth_type,
                 custom_headers=source.endpoint.custom_headers.copy(),
+                max_imps_per_request=source.endpoint.max_imps_per_request,
             ),
             capabilities=BidderCapabilities(
                 media_types=source.capabilities.media_types.copy(),
@@ -55,6 +55,7 @@ class BidderEndpoint:
         method: HTTP method (POST for OpenRTB)
         timeout_ms: Request timeout in milliseconds
         protocol_version: OpenRTB version (2.5 or 2.6)
+        max_imps_per_request: Split larger requests into batches (0 = unlimited)
     """
 
     url: str
@@ -73,6 +74,9 @@ class BidderEndpoint:
     # Additional headers
     custom_headers: dict[str, str] = field(default_factory=dict)
 
+    # Partners that cap imps per request (e.g. CTV pods) get batched requests
+    max_imps_per_request: int = 0
+
     def to_dict(self) -> dict[str, Any]:
         """Convert to dictionary for serialization."""
         return {
@@ -87,6 +91,7 @@ class BidderEndpoint:
             "auth_header_name": self.auth_header_name,
             "auth_header_value": self.auth_header_value,
             "custom_headers": self.custom_headers,
+            "max_imps_per_request": self.max_imps_per_request,
         }
 
     @classmethod
@@ -104,6 +109,7 @@ class BidderEndpoint:
             auth_header_name=data.get("auth_header_name"),
             auth_header_value=data.get("auth_header_value"),
             custom_headers=data.get("custom_headers", {}),
+            max_imps_per_request=data.get("max_imps_per_request", 0),
         )
 
 
@@ -117,6 +123,12 @@ class BidderCapabilities:
         currencies: Supported currencies (ISO 4217 codes)
         protocols: Supported video protocols (VAST versions)
         apis: Supported APIs (VPAID, MRAID, etc.)
+        ignored_fields: Request fields the bidder doesn't read, stripped from
+            its requests when request shaping is on
+        unsupported_inventory: Inventory the bidder doesn't buy ("interstitial",
+            "rewarded"); such imps are left out of its requests
+        eid_sources: user.eids sources the bidder may receive; empty allows
+            every source the global EID filter allows
     """
 
     media_types: list[str] = field(default_factory=lambda: ["banner"])
@@ -159,6 +171,15 @@ class BidderCapabilities:
     supports_ad_pods: bool = False
     supports_dooh: bool = False
 
+    # Request shaping
+    ignored_fields: list[str] = field(default_factory=list)
+
+    # Inventory filtering
+    unsupported_inventory: list[str] = field(default_factory=list)
+
+    # EID filtering
+    eid_sources: list[str] = field(default_factory=list)
+
     def to_dict(self) -> dict[str, Any]:
         """Convert to dictionary for serialization."""
         return {
@@ -187,6 +208,9 @@ class BidderCapabilities:
             "supports_ctv": self.supports_ctv,
             "supports_ad_pods": self.supports_ad_pods,
             "supports_dooh": self.supports_dooh,
+            "ignored_fields": self.ignored_fields,
+            "unsupported_inventory": self.unsupported_inventory,
+            "eid_sources": self.eid_sources,
         }
 
     @classmethod
@@ -218,6 +242,9 @@ class BidderCapabilities:
             supports_ctv=data.get("supports_ctv", False),
             supports_ad_pods=data.get("supports_ad_pods", False),
             supports_dooh=data.get("supports_dooh", False),
+            ignored_fields=data.get("ignored_fields", []),
+            unsupported_inventory=data.get("unsupported_inventory", []),
+            eid_sources=data.get("eid_sources", []),
         )
 
 
@@ -479,6 +506,9 @@ class BidderConfig:
         status: Current operational status
         gvl_vendor_id: IAB Global Vendor List ID (for privacy)
         priority: Bidder priority (higher = preferred)
+        traffic_percent: Share of eligible auctions that call the bidder (0-100)
+        sandbox: Run the bidder's request/response handling under PBS sandbox limits
+        probe: Availability probe mode (head, options, test_bid or none; empty = head)
     """
 
     bidder_code: str
@@ -501,6 +531,9 @@ class BidderConfig:
     status: BidderStatus = BidderStatus.TESTING
     gvl_vendor_id: int | None = None  # IAB GVL ID
     priority: int = 50  # 0-100, higher = more preferred
+    traffic_percent: int | None = None  # 0-100 for gradual rollout; None = all traffic
+    sandbox: bool = False  # Isolate adapter work in PBS (for untrusted third-party configs)
+    probe: str = ""  # Availability probe: head (default), options, test_bid or none
 
     # Contact information
     maintainer_email: str = ""
@@ -640,6 +673,9 @@ class BidderConfig:
             "status": self.status.value,
             "gvl_vendor_id": self.gvl_vendor_id,
             "priority": self.priority,
+            "traffic_percent": self.traffic_percent,
+            "sandbox": self.sandbox,
+            "probe": self.probe,
             "maintainer_email": self.maintainer_email,
             "maintainer_name": self.maintainer_name,
             "allowed_publishers": self.allowed_publishers,
@@ -679,6 +715,9 @@ class BidderConfig:
             status=BidderStatus(data.get("status", "testing")),
             gvl_vendor_id=data.get("gvl_vendor_id"),
             priority=data.get("priority", 50),
+            traffic_percent=data.get("traffic_percent"),
+            sandbox=data.get("sandbox", False),
+            probe=data.get("probe", ""),
             maintainer_email=data.get("maintainer_email", ""),
             maintainer_name=data.get("maintainer_name", ""),
             allowed_publishers=data.get("allowed_publishers", []),
@@ -104,6 +104,30 @@ class TestBidderModels:
         assert "EUR" in caps.currencies
         assert caps.supports_ctv is True
 
+    def test_bidder_capabilities_ignored_fields(self):
+        """Test ignored fields round-trip for request shaping."""
+        caps = BidderCapabilities(ignored_fields=["site.content", "device.legacy_ids"])
+
+        restored = BidderCapabilities.from_dict(caps.to_dict())
+        assert restored.ignored_fields == ["site.content", "device.legacy_ids"]
+        assert BidderCapabilities.from_dict({}).ignored_fields == []
+
+    def test_bidder_capabilities_unsupported_inventory(self):
+        """Test unsupported inventory round-trip for imp filtering."""
+        caps = BidderCapabilities(unsupported_inventory=["rewarded"])
+
+        restored = BidderCapabilities.from_dict(caps.to_dict())
+        assert restored.unsupported_inventory == ["rewarded"]
+        assert BidderCapabilities.from_dict({}).unsupported_inventory == []
+
+    def test_bidder_capabilities_eid_sources(self):
+        """Test EID source allowlist round-trip for per-bidder EID filtering."""
+        caps = BidderCapabilities(eid_sources=["uidapi.com", "id5-sync.com"])
+
+        restored = BidderCapabilities.from_dict(caps.to_dict())
+        assert restored.eid_sources == ["uidapi.com", "id5-sync.com"]
+        assert BidderCapabilities.from_dict({}).eid_sources == []
+
     def test_bidder_config_creation(self):
         """Test creating a complete bidder configuration."""
         config = BidderConfig(
@@ -146,6 +170,9 @@ class TestBidderModels:
             ),
             status=BidderStatus.TESTING,
             priority=60,
+            traffic_percent=10,
+            sandbox=True,
+            probe="test_bid",
             gvl_vendor_id=123,
             allowed_countries=["US", "CA"],
         )
@@ -163,6 +190,9 @@ class TestBidderModels:
         assert restored.capabilities.media_types == original.capabilities.media_types
         assert restored.status == original.status
         assert restored.gvl_vendor_id == original.gvl_vendor_id
+        assert restored.traffic_percent == original.traffic_percent
+        assert restored.sandbox is True
+        assert restored.probe == "test_bid"
         assert restored.allowed_countries == original.allowed_countries
 
     def test_bidder_config_json(self):
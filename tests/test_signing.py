@@ -0,0 +1,66 @@
+"""Tests for request signature verification."""
+
+import pytest
+
+from src.idr.auth.signing import (
+    KEY_ID_HEADER,
+    SIGNATURE_HEADER,
+    TIMESTAMP_HEADER,
+    SignatureError,
+    compute_signature,
+    parse_signing_keys,
+    verify_signature,
+)
+
+NOW = 1_700_000_000
+BODY = b'{"events":[]}'
+
+
+def signed_headers(key_id: str, secret: bytes, timestamp: int = NOW, body: bytes = BODY) -> dict:
+    """Build the headers PBS sends on a signed request."""
+    return {
+        KEY_ID_HEADER: key_id,
+        TIMESTAMP_HEADER: str(timestamp),
+        SIGNATURE_HEADER: "v1=" + compute_signature(secret, str(timestamp), body),
+    }
+
+
+class TestSigning:
+    """Test suite for HMAC request signing."""
+
+    def test_known_vector_matches_pbs(self):
+        """The vector is shared with pbs/pkg/signing so both services agree."""
+        assert compute_signature(b"k", "1", b"{}") == (
+            "3dd49b2593d0f9a349e9e71c4bde3e2b862c2be4003fe9b4ba81332029310158"
+        )
+
+    def test_parse_signing_keys(self):
+        """Test parsing of id:secret lists."""
+        keys = parse_signing_keys(" k2:new-secret , k1:old:secret ")
+        assert keys == {"k2": b"new-secret", "k1": b"old:secret"}
+        assert parse_signing_keys("") == {}
+        for raw in ("nosecret", ":secret", "k1:"):
+            with pytest.raises(ValueError):
+                parse_signing_keys(raw)
+
+    def test_verify_accepts_any_configured_key(self):
+        """Receivers holding old and new keys accept either during rotation."""
+        keys = parse_signing_keys("k2:new-secret,k1:old-secret")
+        for key_id, secret in keys.items():
+            assert verify_signature(signed_headers(key_id, secret), BODY, keys, now=NOW + 60) == key_id
+
+    @pytest.mark.parametrize(
+        "headers,body,message",
+        [
+            ({}, BODY, "not signed"),
+            (signed_headers("k9", b"new-secret"), BODY, "unknown signing key"),
+            (signed_headers("k2", b"other"), BODY, "mismatch"),
+            (signed_headers("k2", b"new-secret"), b'{"events":[{}]}', "mismatch"),
+            (signed_headers("k2", b"new-secret", timestamp=NOW - 600), BODY, "skew"),
+            (signed_headers("k2", b"new-secret", timestamp=NOW + 600), BODY, "skew"),
+        ],
+    )
+    def test_verify_rejects(self, headers, body, message):
+        """Test rejection of unsigned, tampered and stale requests."""
+        with pytest.raises(SignatureError, match=message):
+            verify_signature(headers, body, {"k2": b"new-secret"}, now=NOW)